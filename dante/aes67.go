@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"danteCS/internal/aes67"
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// AES67 串流
+//==============================================================================
+
+// 許多控台同時橋接 Dante 與 AES67，AES67 串流不會出現在 Dante 的設備列表。
+// daemon 在每個 Dante 介面上收聽 SAP 公告 (aes67 功能)，API、Web UI 與
+// golane aes67 把串流與發送端的 Dante 設備 (依地址對應) 一起列出。
+
+// aes67AnnounceInterval AES67 設備的公告間隔 (一次性命令至少要等這麼久)
+const aes67AnnounceInterval = 30 * time.Second
+
+// apiStream AES67 串流 (附加發送端的 Dante 設備)
+type apiStream struct {
+	aes67.Stream
+	Domain string `json:"domain,omitempty"` // 發送端所在的網域
+	Device string `json:"device,omitempty"` // 發送端的 Dante 設備 (非 Dante 設備為空白)
+}
+
+// matchStreams 以地址對應串流與 Dante 設備 (lists 為網域 → 設備列表)
+func matchStreams(streams []aes67.Stream, lists map[string][]dante.Device) []apiStream {
+	byIP := make(map[string][2]string)
+	for domain, devices := range lists {
+		for _, dev := range devices {
+			for _, ip := range []string{dev.IPAddress, dev.SecondaryIP} {
+				if ip != "" {
+					byIP[ip] = [2]string{domain, dev.Name}
+				}
+			}
+		}
+	}
+	result := make([]apiStream, 0, len(streams))
+	for _, s := range streams {
+		item := apiStream{Stream: s}
+		if owner, ok := byIP[s.Source]; ok {
+			item.Domain, item.Device = owner[0], owner[1]
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// startAES67 在介面上收聽 SAP 公告，ctx 結束時停止
+// 單一介面失敗 (例如沒有 multicast) 只記錄，不影響監控
+func startAES67(ctx context.Context, ifaces []string) *aes67.Directory {
+	dir := aes67.NewDirectory()
+	for _, iface := range ifaces {
+		recovery.GoLoop(ctx, "aes67/"+iface, func() {
+			if err := dir.Listen(ctx, iface); err != nil {
+				logger.Warn("AES67 discovery unavailable", "iface", iface, "err", err)
+			}
+		})
+	}
+	return dir
+}
+
+// danteInterfaceNames 偵測到的 Dante 介面名稱
+func danteInterfaceNames(detector *NetworkDetector) []string {
+	names := make([]string, 0, len(detector.DanteInterfaces))
+	for _, iface := range detector.DanteInterfaces {
+		names = append(names, iface.Name)
+	}
+	return names
+}
+
+// printStreams 顯示 AES67 串流表格
+func printStreams(streams []apiStream) {
+	fmt.Print(i18n.T("\n=== AES67 Streams ===\n"))
+	fmt.Print(i18n.Sprintf("Total Streams: %d\n", len(streams)))
+	if len(streams) > 0 {
+		printHeader("\n%-24s %-16s %-16s %-19s %-13s %-7s %s\n", "Name", "Source", "Device", "Multicast", "Format", "Ptime", "PTP clock")
+		fmt.Println("───────────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+		for _, s := range streams {
+			device := s.Device
+			if device == "" {
+				device = "-"
+			}
+			format := fmt.Sprintf("%s/%d/%d", s.Encoding, s.SampleRate/1000, s.Channels)
+			fmt.Printf("%-24s %-16s %-16s %-19s %-13s %-7s %s\n",
+				s.Name, s.Source, device, fmt.Sprintf("%s:%d", s.Multicast, s.Port), format, s.PacketTime, s.PTPClock)
+			if s.Unsupported != "" {
+				fmt.Print(i18n.Sprintf("  ! not AES67 compatible: %s\n", s.Unsupported))
+			}
+		}
+	}
+	fmt.Println("=====================")
+	fmt.Println()
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// streamList 目前的 AES67 串流
+func (s *APIServer) streamList() []apiStream {
+	if s.aes67 == nil {
+		return nil
+	}
+	lists := make(map[string][]dante.Device)
+	for _, d := range s.snapshots() {
+		lists[d.Name] = d.Devices
+	}
+	return matchStreams(s.aes67.Streams(), lists)
+}
+
+// handleAES67 GET /api/aes67
+func (s *APIServer) handleAES67(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.streamList())
+}
+
+// AES67Streams daemon 收到公告的 AES67 串流
+func (c *RemoteClient) AES67Streams() ([]apiStream, error) {
+	var streams []apiStream
+	return streams, c.do(http.MethodGet, "/api/aes67", nil, &streams)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newAES67Command golane aes67
+func newAES67Command() *Command {
+	fs := newFlagSet("aes67")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", aes67AnnounceInterval+5*time.Second, "how long to listen for announcements (devices announce about every 30s)")
+	jsonOut := fs.Bool("json", false, "print streams as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "aes67",
+		Short: "List AES67 streams announced with SAP on the Dante interfaces",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+
+			var streams []apiStream
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if streams, err = client.AES67Streams(); err != nil {
+					return err
+				}
+			} else {
+				if *wait < aes67AnnounceInterval {
+					logger.Warn("Waiting less than the announce interval, streams may be missing", "wait", *wait, "interval", aes67AnnounceInterval)
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				names := danteInterfaceNames(detector)
+				if len(names) == 0 {
+					return fmt.Errorf("Dante interface not found (expected one of %v)", detector.DanteInterfaceNames)
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+				dir := startAES67(ctx, names)
+
+				// 收聽期間同時發現 Dante 設備，用來對應發送端
+				lists := make(map[string][]dante.Device)
+				if d, err := ifaces.openPrimaryDomain(ctx, detector); err != nil {
+					logger.Warn("Dante discovery unavailable, streams listed without devices", "err", err)
+					select {
+					case <-ctx.Done():
+					case <-time.After(*wait):
+					}
+				} else {
+					defer d.Cleanup()
+					if err := discover(ctx, d, *wait); err != nil {
+						return err
+					}
+					lists[d.Name] = d.GetDevices()
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				streams = matchStreams(dir.Streams(), lists)
+			}
+
+			if *jsonOut {
+				if streams == nil {
+					streams = []apiStream{}
+				}
+				return printJSON(streams)
+			}
+			printStreams(streams)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"danteCS/internal/aes67"
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+func TestAPIListsAES67StreamsWithDanteDevices(t *testing.T) {
+	s := supervisor.New(supervisor.DefaultConfig())
+	s.Add(supervisor.Spec{Name: "Dante1", Interface: "eth1", Run: func(ctx context.Context, report supervisor.Reporter) error {
+		report.Devices([]dante.Device{{ID: 1, Name: "FOH-Console", IPAddress: "10.0.1.20", SecondaryIP: "10.0.2.20"}})
+		<-ctx.Done()
+		return nil
+	}})
+	s.Start(context.Background())
+	t.Cleanup(s.Stop)
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if snap, _ := s.Snapshot("Dante1"); snap.State == supervisor.StateRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for Dante1")
+		}
+	}
+
+	dir := aes67.NewDirectory()
+	for _, source := range []string{"10.0.2.20", "10.0.1.99"} {
+		sdp := "v=0\r\no=- 7 1 IN IP4 " + source + "\r\ns=Bridge " + source + "\r\nc=IN IP4 239.69.1.1/32\r\n" +
+			"m=audio 5004 RTP/AVP 96\r\na=rtpmap:96 L24/48000/8\r\na=ptime:1\r\n" +
+			"a=ts-refclk:ptp=IEEE1588-2008:00-1D-C1-FF-FE-00-00-01:0\r\n"
+		packet := append([]byte{0x20, 0, 0, 1, 10, 0, 0, 1}, sdp...)
+		if err := dir.Handle("eth1", packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{Domains: s, AES67: dir}).mux)
+	defer server.Close()
+	var streams []apiStream
+	getJSON(t, server.URL+"/api/aes67", &streams)
+	if len(streams) != 2 {
+		t.Fatalf("got %d streams, want 2", len(streams))
+	}
+	// 次要地址的串流也對應到設備，非 Dante 的發送端保持空白
+	if streams[1].Device != "FOH-Console" || streams[1].Domain != "Dante1" || streams[0].Device != "" {
+		t.Fatalf("device matching: %+v", streams)
+	}
+	if streams[1].Channels != 8 || streams[1].Unsupported != "" {
+		t.Fatalf("stream: %+v", streams[1].Stream)
+	}
+}
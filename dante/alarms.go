@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 告警規則
+//==============================================================================
+
+// 內建的告警 (設備離線、名稱衝突) 只在事件發生時通知一次，值班人員看不出
+// 「現在有哪些問題」。AlarmEngine 依設定檔 alarms section 的規則持續評估
+// 各網域的設備列表：條件成立超過 For 時發出具名的告警，條件消失時清除；
+// 目前的告警與最近清除的告警可由 /api/alarms 查詢，發出與清除也交給
+// AlertManager，沿用通知、事件單與 /api/events。
+//
+// 設定檔範例：
+//
+//	"alarms": [
+//	  {"name": "too-few-devices", "condition": "device-count-below", "domain": "Dante1", "threshold": 12, "severity": "critical"},
+//	  {"name": "device-offline", "condition": "device-offline", "for": "30s", "severity": "critical"},
+//	  {"name": "slow-link", "condition": "link-speed-below", "threshold": 1000},
+//	  {"name": "redundancy-lost", "condition": "redundancy-lost", "for": "10s"}
+//	]
+
+// 告警條件
+const (
+	ConditionDeviceCountBelow = "device-count-below" // 網域的設備數少於 Threshold
+	ConditionDeviceOffline    = "device-offline"     // 曾經出現的設備不在列表上
+	ConditionLinkSpeedBelow   = "link-speed-below"   // 設備的連線速度低於 Threshold Mbps
+	ConditionRedundancyLost   = "redundancy-lost"    // 使用備援網路的設備只剩一條連線
+)
+
+// alarmHistoryLimit 保留的已清除告警數
+const alarmHistoryLimit = 100
+
+// alarmTick 評估持續時間的間隔
+const alarmTick = time.Second
+
+// AlarmRule 設定檔 alarms section 的一條規則
+type AlarmRule struct {
+	Name      string `json:"name"`
+	Condition string `json:"condition"`
+	Severity  string `json:"severity,omitempty"`  // 預設 warning
+	Domain    string `json:"domain,omitempty"`    // 空白表示所有網域
+	Threshold int    `json:"threshold,omitempty"` // 設備數或 Mbps
+	For       string `json:"for,omitempty"`       // 條件持續多久才發出 (Go duration，預設立即)
+
+	hold time.Duration
+}
+
+// DefaultAlarmRules 設定檔沒有 alarms section 時使用的規則
+func DefaultAlarmRules() []AlarmRule {
+	return []AlarmRule{
+		{Name: "slow-link", Condition: ConditionLinkSpeedBelow, Threshold: 1000, Severity: SeverityWarning},
+		{Name: "redundancy-lost", Condition: ConditionRedundancyLost, Severity: SeverityWarning, For: "10s"},
+	}
+}
+
+// ErrInvalidAlarmRule 規則設定錯誤
+var ErrInvalidAlarmRule = errors.New("invalid alarm rule")
+
+// compileAlarmRules 檢查規則並補上預設值
+func compileAlarmRules(rules []AlarmRule) ([]AlarmRule, error) {
+	names := make(map[string]bool, len(rules))
+	out := make([]AlarmRule, len(rules))
+	for i, r := range rules {
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("%w %q: %s", ErrInvalidAlarmRule, r.Name, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case r.Name == "":
+			return nil, fmt.Errorf("%w #%d: name is required", ErrInvalidAlarmRule, i+1)
+		case names[r.Name]:
+			return nil, fail("duplicate name")
+		}
+		names[r.Name] = true
+
+		switch r.Condition {
+		case ConditionDeviceCountBelow, ConditionLinkSpeedBelow:
+			if r.Threshold <= 0 {
+				return nil, fail("%s needs a positive threshold", r.Condition)
+			}
+		case ConditionDeviceOffline, ConditionRedundancyLost:
+		default:
+			return nil, fail("unknown condition %q", r.Condition)
+		}
+		switch r.Severity {
+		case "":
+			r.Severity = SeverityWarning
+		case SeverityCritical, SeverityWarning, SeverityInfo:
+		default:
+			return nil, fail("unknown severity %q", r.Severity)
+		}
+		if r.For != "" {
+			d, err := time.ParseDuration(r.For)
+			if err != nil || d < 0 {
+				return nil, fail("invalid duration %q", r.For)
+			}
+			r.hold = d
+		}
+		out[i] = r
+	}
+	return out, nil
+}
+
+// Alarm 發出中或已清除的告警
+type Alarm struct {
+	Name      string    `json:"name"`
+	Condition string    `json:"condition"`
+	Severity  string    `json:"severity"`
+	Domain    string    `json:"domain"`
+	Subject   string    `json:"subject,omitempty"` // 設備名稱 (網域層級的條件為空白)
+	Message   string    `json:"message"`
+	Since     time.Time `json:"since"` // 條件開始成立的時間
+	Raised    time.Time `json:"raised"`
+	Cleared   time.Time `json:"cleared,omitempty"`
+}
+
+// key 同一規則、網域與對象視為同一個告警
+func (a Alarm) key() string {
+	return a.Name + "|" + a.Domain + "|" + strings.ToLower(a.Subject)
+}
+
+// 告警變化 (TopicAlarm 事件的 State)
+const (
+	AlarmRaised  = "raised"
+	AlarmCleared = "cleared"
+)
+
+// AlarmEvent TopicAlarm 事件的內容
+type AlarmEvent struct {
+	State string `json:"state"` // AlarmRaised 或 AlarmCleared
+	Alarm
+}
+
+// AlarmStatus /api/alarms 的回應
+type AlarmStatus struct {
+	Rules   []AlarmRule `json:"rules"`
+	Active  []Alarm     `json:"active"`
+	Cleared []Alarm     `json:"cleared"` // 最近清除的告警，新的在前
+}
+
+// alarmDomain 網域最新的設備列表
+type alarmDomain struct {
+	devices []dante.Device
+	known   map[string]dante.Device // 出現過的設備 (小寫名稱)
+	missing map[string]time.Time    // 不在列表上的設備 → 開始消失的時間
+}
+
+// AlarmEngine 依規則評估設備列表，發出與清除告警
+type AlarmEngine struct {
+	mu      sync.Mutex
+	rules   []AlarmRule
+	alerts  *AlertManager
+	events  *golane.Bus
+	domains map[string]*alarmDomain
+	pending map[string]time.Time // 條件成立但還沒超過 For 的告警 → 開始時間
+	active  map[string]Alarm
+	cleared []Alarm
+}
+
+// NewAlarmEngine 建立引擎 (rules 須先經過 compileAlarmRules)，events 為 nil 時不發布 TopicAlarm
+func NewAlarmEngine(rules []AlarmRule, alerts *AlertManager, events *golane.Bus) *AlarmEngine {
+	return &AlarmEngine{
+		rules:   rules,
+		alerts:  alerts,
+		events:  events,
+		domains: make(map[string]*alarmDomain),
+		pending: make(map[string]time.Time),
+		active:  make(map[string]Alarm),
+	}
+}
+
+// Update 以網域最新的設備列表重新評估
+func (e *AlarmEngine) Update(domain string, devices []dante.Device, now time.Time) {
+	e.mu.Lock()
+	d := e.domains[domain]
+	if d == nil {
+		d = &alarmDomain{known: make(map[string]dante.Device), missing: make(map[string]time.Time)}
+		e.domains[domain] = d
+	}
+	d.devices = slices.Clone(devices)
+	listed := make(map[string]bool, len(devices))
+	for _, dev := range devices {
+		key := strings.ToLower(dev.Name)
+		listed[key] = true
+		d.known[key] = dev
+		delete(d.missing, key)
+	}
+	for key := range d.known {
+		if !listed[key] {
+			if _, ok := d.missing[key]; !ok {
+				d.missing[key] = now
+			}
+		}
+	}
+	raise, clear := e.evaluate(now)
+	e.mu.Unlock()
+	e.notify(raise, clear)
+}
+
+// Run 定期評估持續時間 (設備列表沒有變化時 For 仍會到期)，直到 ctx 結束
+func (e *AlarmEngine) Run(ctx context.Context) {
+	ticker := time.NewTicker(alarmTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			e.tick(now)
+		}
+	}
+}
+
+// tick 評估持續時間並通知
+func (e *AlarmEngine) tick(now time.Time) {
+	e.mu.Lock()
+	raise, clear := e.evaluate(now)
+	e.mu.Unlock()
+	e.notify(raise, clear)
+}
+
+// conditions 目前成立的條件 (key → 告警內容，Since 為條件開始的時間)
+func (e *AlarmEngine) conditions(now time.Time) map[string]Alarm {
+	found := make(map[string]Alarm)
+	add := func(r AlarmRule, domain, subject, message string, since time.Time) {
+		a := Alarm{Name: r.Name, Condition: r.Condition, Severity: r.Severity, Domain: domain, Subject: subject, Message: message, Since: since}
+		found[a.key()] = a
+	}
+	for _, r := range e.rules {
+		for name, d := range e.domains {
+			if r.Domain != "" && !strings.EqualFold(r.Domain, name) {
+				continue
+			}
+			switch r.Condition {
+			case ConditionDeviceCountBelow:
+				if len(d.devices) < r.Threshold {
+					add(r, name, "", fmt.Sprintf("%s has %d devices, expected at least %d", name, len(d.devices), r.Threshold), now)
+				}
+			case ConditionDeviceOffline:
+				for key, since := range d.missing {
+					dev := d.known[key]
+					add(r, name, dev.Name, fmt.Sprintf("device %s (%s) is offline", dev.Name, dev.IPAddress), since)
+				}
+			case ConditionLinkSpeedBelow:
+				for _, dev := range d.devices {
+					var slow []string
+					if dev.LinkSpeed > 0 && dev.LinkSpeed < r.Threshold {
+						slow = append(slow, fmt.Sprintf("primary %d Mbps", dev.LinkSpeed))
+					}
+					if dev.SecondaryIP != "" && dev.SecondarySpeed > 0 && dev.SecondarySpeed < r.Threshold {
+						slow = append(slow, fmt.Sprintf("secondary %d Mbps", dev.SecondarySpeed))
+					}
+					if len(slow) > 0 {
+						add(r, name, dev.Name, fmt.Sprintf("device %s links below %d Mbps: %s", dev.Name, r.Threshold, strings.Join(slow, ", ")), now)
+					}
+				}
+			case ConditionRedundancyLost:
+				for _, dev := range d.devices {
+					switch dev.Redundancy() {
+					case dante.RedundancySecondaryDown, dante.RedundancyPrimaryDown:
+						add(r, name, dev.Name, fmt.Sprintf("device %s lost redundancy (%s)", dev.Name, dev.Redundancy()), now)
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+// evaluate 比對成立的條件與目前的告警，回傳要發出與清除的告警 (呼叫者持有 e.mu)
+func (e *AlarmEngine) evaluate(now time.Time) (raise, clear []Alarm) {
+	found := e.conditions(now)
+	holds := make(map[string]time.Duration, len(e.rules))
+	for _, r := range e.rules {
+		holds[r.Name] = r.hold
+	}
+
+	for key, a := range found {
+		if since, ok := e.pending[key]; ok && since.Before(a.Since) {
+			a.Since = since
+		}
+		if existing, ok := e.active[key]; ok {
+			existing.Message = a.Message // 例如設備數持續變化
+			e.active[key] = existing
+			continue
+		}
+		e.pending[key] = a.Since
+		if now.Sub(a.Since) >= holds[a.Name] {
+			a.Raised = now
+			e.active[key] = a
+			delete(e.pending, key)
+			raise = append(raise, a)
+		}
+	}
+	for key := range e.pending {
+		if _, ok := found[key]; !ok {
+			delete(e.pending, key) // 在 For 之內就恢復
+		}
+	}
+	for key, a := range e.active {
+		if _, ok := found[key]; ok {
+			continue
+		}
+		a.Cleared = now
+		delete(e.active, key)
+		e.cleared = append(e.cleared, a)
+		if len(e.cleared) > alarmHistoryLimit {
+			e.cleared = e.cleared[len(e.cleared)-alarmHistoryLimit:]
+		}
+		clear = append(clear, a)
+	}
+	return raise, clear
+}
+
+// notify 把發出與清除的告警交給 AlertManager 並發布到事件匯流排
+// (AlertManager 只通知發出，清除只出現在 TopicAlarm)
+func (e *AlarmEngine) notify(raise, clear []Alarm) {
+	for _, a := range clear {
+		logger.Info("Alarm cleared", "alarm", a.Name, "domain", a.Domain, "subject", a.Subject)
+		if e.alerts != nil {
+			e.alerts.Resolve(a.Name, a.Domain, a.Subject)
+		}
+		e.publish(AlarmCleared, a, a.Cleared)
+	}
+	for _, a := range raise {
+		logger.Warn("Alarm raised", "alarm", a.Name, "domain", a.Domain, "subject", a.Subject, "severity", a.Severity, "message", a.Message)
+		if e.alerts != nil {
+			e.alerts.Raise(Alert{Kind: a.Name, Severity: a.Severity, Domain: a.Domain, Subject: a.Subject, Message: a.Message, Time: a.Raised})
+		}
+		e.publish(AlarmRaised, a, a.Raised)
+	}
+}
+
+// publish 發布 TopicAlarm 事件
+func (e *AlarmEngine) publish(state string, a Alarm, at time.Time) {
+	if e.events == nil {
+		return
+	}
+	e.events.Publish(golane.Event{Topic: golane.TopicAlarm, Domain: a.Domain, Subject: a.Name, Time: at, Data: AlarmEvent{State: state, Alarm: a}})
+}
+
+// Status 目前的告警 (依嚴重度、名稱與對象排序) 與最近清除的告警
+func (e *AlarmEngine) Status() AlarmStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := AlarmStatus{Rules: slices.Clone(e.rules), Active: []Alarm{}, Cleared: []Alarm{}}
+	for _, a := range e.active {
+		status.Active = append(status.Active, a)
+	}
+	rank := map[string]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+	slices.SortFunc(status.Active, func(a, b Alarm) int {
+		if c := rank[a.Severity] - rank[b.Severity]; c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Domain, b.Domain); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Subject, b.Subject)
+	})
+	for i := len(e.cleared) - 1; i >= 0; i-- {
+		status.Cleared = append(status.Cleared, e.cleared[i])
+	}
+	return status
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// handleAlarms GET /api/alarms
+func (s *APIServer) handleAlarms(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.alarms.Status())
+}
+
+// Alarms daemon 目前的告警
+func (c *RemoteClient) Alarms() (AlarmStatus, error) {
+	var status AlarmStatus
+	return status, c.do(http.MethodGet, "/api/alarms", nil, &status)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newAlarmsCommand golane alarms (告警由執行中的 monitor 評估，需要 -host)
+func newAlarmsCommand() *Command {
+	fs := newFlagSet("alarms")
+	lf := addLogFlags(fs)
+	cleared := fs.Bool("cleared", false, "also list recently cleared alarms")
+	jsonOut := fs.Bool("json", false, "print the alarms as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "alarms",
+		Short: "List the active alarms of a running monitor",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if !remote.enabled() {
+				return errors.New("alarms are evaluated by a running monitor, use -host")
+			}
+			client, err := remote.client()
+			if err != nil {
+				return err
+			}
+			status, err := client.Alarms()
+			if err != nil {
+				return err
+			}
+			if !*cleared {
+				status.Cleared = nil
+			}
+			if *jsonOut {
+				return printJSON(status)
+			}
+			printAlarms(status)
+			return nil
+		},
+	}
+}
+
+// printAlarms 印出告警
+func printAlarms(status AlarmStatus) {
+	fmt.Print(i18n.Sprintf("\n=== Active alarms (%d) ===\n", len(status.Active)))
+	printHeader("%-9s %-20s %-10s %-20s %-19s %s\n", "SEVERITY", "ALARM", "DOMAIN", "SUBJECT", "SINCE", "MESSAGE")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────────")
+	for _, a := range status.Active {
+		fmt.Printf("%-9s %-20s %-10s %-20s %-19s %s\n", a.Severity, a.Name, a.Domain, a.Subject,
+			a.Since.Local().Format(time.DateTime), a.Message)
+	}
+	if len(status.Cleared) > 0 {
+		fmt.Print(i18n.T("\n=== Recently cleared ===\n"))
+		for _, a := range status.Cleared {
+			fmt.Printf("%-19s %-20s %-10s %-20s %s\n", a.Cleared.Local().Format(time.DateTime), a.Name, a.Domain, a.Subject, a.Message)
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+func TestCompileAlarmRules(t *testing.T) {
+	rules, err := compileAlarmRules([]AlarmRule{
+		{Name: "offline", Condition: ConditionDeviceOffline, For: "30s"},
+		{Name: "few", Condition: ConditionDeviceCountBelow, Threshold: 4, Severity: SeverityCritical},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].hold != 30*time.Second || rules[0].Severity != SeverityWarning || rules[1].Severity != SeverityCritical {
+		t.Fatalf("rules = %+v", rules)
+	}
+	if _, err := compileAlarmRules(DefaultAlarmRules()); err != nil {
+		t.Fatalf("default rules: %v", err)
+	}
+
+	for _, bad := range [][]AlarmRule{
+		{{Condition: ConditionDeviceOffline}},
+		{{Name: "a", Condition: ConditionDeviceOffline}, {Name: "a", Condition: ConditionRedundancyLost}},
+		{{Name: "a", Condition: "cpu-high"}},
+		{{Name: "a", Condition: ConditionLinkSpeedBelow}},
+		{{Name: "a", Condition: ConditionDeviceOffline, Severity: "urgent"}},
+		{{Name: "a", Condition: ConditionDeviceOffline, For: "soon"}},
+	} {
+		if _, err := compileAlarmRules(bad); !errors.Is(err, ErrInvalidAlarmRule) {
+			t.Errorf("%+v: err = %v", bad, err)
+		}
+	}
+}
+
+func TestAlarmEngine(t *testing.T) {
+	rules, err := compileAlarmRules([]AlarmRule{
+		{Name: "few", Condition: ConditionDeviceCountBelow, Domain: "Dante1", Threshold: 3, Severity: SeverityCritical},
+		{Name: "offline", Condition: ConditionDeviceOffline, For: "30s"},
+		{Name: "slow", Condition: ConditionLinkSpeedBelow, Threshold: 1000},
+		{Name: "redundancy", Condition: ConditionRedundancyLost},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent []AlertNotification
+	alerts := NewAlertManager(NoiseFloor{}, func(n AlertNotification) { sent = append(sent, n) })
+	engine := NewAlarmEngine(rules, alerts, nil)
+
+	console := dante.Device{Name: "Console", LinkSpeed: 1000, SecondaryIP: "10.0.2.10", SecondarySpeed: 1000}
+	amp := dante.Device{Name: "Amp", LinkSpeed: 100}
+	stage := dante.Device{Name: "Stage", LinkSpeed: 1000}
+	now := time.Unix(1700000000, 0)
+
+	engine.Update("Dante1", []dante.Device{console, amp, stage}, now)
+	status := engine.Status()
+	if len(status.Active) != 1 || status.Active[0].Name != "slow" || status.Active[0].Subject != "Amp" {
+		t.Fatalf("active: %+v", status.Active)
+	}
+
+	// 設備離線：設備數立即告警，離線等待 For
+	console.SecondarySpeed = 0
+	engine.Update("Dante1", []dante.Device{console, amp}, now.Add(time.Second))
+	names := func() []string {
+		var list []string
+		for _, a := range engine.Status().Active {
+			list = append(list, a.Name+"/"+a.Subject)
+		}
+		return list
+	}
+	if got := names(); len(got) != 3 || got[0] != "few/" || got[1] != "redundancy/Console" || got[2] != "slow/Amp" {
+		t.Fatalf("active after Stage left: %v", got)
+	}
+	engine.tick(now.Add(31 * time.Second))
+	if got := names(); len(got) != 4 || got[1] != "offline/Stage" {
+		t.Fatalf("active after 30s: %v", got)
+	}
+
+	// 恢復後清除，並出現在已清除的列表
+	console.SecondarySpeed = 1000
+	amp.LinkSpeed = 1000
+	engine.Update("Dante1", []dante.Device{console, amp, stage}, now.Add(40*time.Second))
+	status = engine.Status()
+	if len(status.Active) != 0 || len(status.Cleared) != 4 {
+		t.Fatalf("status after recovery: %+v", status)
+	}
+
+	// For 之內恢復的離線不告警
+	engine.Update("Dante1", []dante.Device{console, amp, stage, {Name: "Extra", LinkSpeed: 1000}}, now.Add(50*time.Second))
+	engine.Update("Dante1", []dante.Device{console, amp, stage}, now.Add(51*time.Second))
+	engine.Update("Dante1", []dante.Device{console, amp, stage, {Name: "Extra", LinkSpeed: 1000}}, now.Add(60*time.Second))
+	engine.tick(now.Add(120 * time.Second))
+	if got := names(); len(got) != 0 {
+		t.Fatalf("short outage raised: %v", got)
+	}
+
+	// 其他網域不套用 Dante1 的設備數規則
+	engine.Update("Dante2", []dante.Device{{Name: "Mic", LinkSpeed: 1000}}, now.Add(130*time.Second))
+	if got := names(); len(got) != 0 {
+		t.Fatalf("domain filter: %v", got)
+	}
+	if len(sent) != 4 {
+		t.Fatalf("notifications: %+v", sent)
+	}
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 告警降噪
+//==============================================================================
+
+// 交換器重開機時整個網域的設備會同時離線，逐筆送出會變成幾十則通知。
+// AlertManager 依 (種類, 網域) 分組：第一筆告警後等待 GroupWait 收集同類告警，
+// 再一次送出；數量超過 BurstThreshold 時只送一則摘要。
+// 已送出的告警在 RepeatInterval 內不再重複，設備恢復 (Resolve) 後重新計算；
+// 在 GroupWait 期間就恢復的設備 (短暫閃斷) 不會送出任何通知。
+
+// 告警種類
+const (
+	AlertDeviceOffline = "device-offline"
+	AlertNameConflict  = "name-conflict"
+	AlertPanic         = "panic"
+	AlertSDKStall      = "sdk-stall"
+)
+
+// 告警嚴重度
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// summaryListLimit 摘要中最多列出的對象數
+const summaryListLimit = 5
+
+// Alert 單一告警
+type Alert struct {
+	Kind     string    `json:"kind"`             // 種類 (AlertDeviceOffline, ...)
+	Severity string    `json:"severity"`         // 嚴重度
+	Domain   string    `json:"domain,omitempty"` // 網域 (全域告警為空白)
+	Subject  string    `json:"subject"`          // 告警對象 (設備名稱、goroutine 位置)
+	Message  string    `json:"message"`          // 說明
+	Time     time.Time `json:"time"`             // 發生時間
+}
+
+// key 相同對象的相同告警視為重複
+func (a Alert) key() string {
+	return a.groupKey() + "|" + strings.ToLower(a.Subject)
+}
+
+// groupKey 同一網域的同類告警合併送出
+func (a Alert) groupKey() string {
+	return a.Kind + "|" + a.Domain
+}
+
+// NoiseFloor 告警降噪設定
+type NoiseFloor struct {
+	GroupWait      time.Duration // 群組第一筆告警後等待收集的時間 (0 表示立即送出)
+	RepeatInterval time.Duration // 相同告警在此期間內不重複送出 (0 表示不抑制)
+	BurstThreshold int           // 群組告警數超過此值時只送摘要 (0 表示不摘要)
+}
+
+// DefaultNoiseFloor 預設降噪設定
+func DefaultNoiseFloor() NoiseFloor {
+	return NoiseFloor{
+		GroupWait:      10 * time.Second,
+		RepeatInterval: 30 * time.Minute,
+		BurstThreshold: 3,
+	}
+}
+
+// AlertNotification 送出的通知 (單一告警或突發摘要)
+type AlertNotification struct {
+	Kind       string    `json:"kind"`
+	Severity   string    `json:"severity"`
+	Domain     string    `json:"domain,omitempty"`
+	Message    string    `json:"message"`
+	Alerts     []Alert   `json:"alerts"`     // 通知包含的告警
+	Summary    bool      `json:"summary"`    // 是否為突發摘要
+	Suppressed int       `json:"suppressed"` // 收集期間被抑制的重複告警數
+	Time       time.Time `json:"time"`
+}
+
+// AlertNotifier 通知目的地
+type AlertNotifier func(AlertNotification)
+
+// AlertStats 告警統計
+type AlertStats struct {
+	Raised     int64 `json:"raised"`     // 收到的告警
+	Notified   int64 `json:"notified"`   // 送出的通知
+	Suppressed int64 `json:"suppressed"` // 被抑制的重複告警
+	Resolved   int64 `json:"resolved"`   // 送出前就恢復的告警
+}
+
+// alertGroup 收集中的告警群組
+type alertGroup struct {
+	alerts     []Alert
+	suppressed int
+	timer      *time.Timer
+}
+
+// AlertManager 告警分組、抑制與摘要
+type AlertManager struct {
+	mu        sync.Mutex
+	cfg       NoiseFloor
+	notifiers []AlertNotifier
+	lastSent  map[string]time.Time   // 告警 key → 最後送出時間
+	pending   map[string]*alertGroup // group key → 收集中的群組
+	stats     AlertStats
+	resolved  []func(kind, domain, subject string)
+}
+
+// NewAlertManager 建立告警管理器，notifiers 為空時只寫入日誌
+func NewAlertManager(cfg NoiseFloor, notifiers ...AlertNotifier) *AlertManager {
+	if len(notifiers) == 0 {
+		notifiers = []AlertNotifier{logAlertNotifier}
+	}
+	return &AlertManager{
+		cfg:       cfg,
+		notifiers: notifiers,
+		lastSent:  make(map[string]time.Time),
+		pending:   make(map[string]*alertGroup),
+	}
+}
+
+// Raise 提交告警
+func (m *AlertManager) Raise(a Alert) {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+
+	m.mu.Lock()
+	m.stats.Raised++
+
+	gk := a.groupKey()
+	group := m.pending[gk]
+
+	// 最近已送出過相同告警
+	if last, ok := m.lastSent[a.key()]; ok && m.cfg.RepeatInterval > 0 && a.Time.Sub(last) < m.cfg.RepeatInterval {
+		m.stats.Suppressed++
+		if group != nil {
+			group.suppressed++
+		}
+		m.mu.Unlock()
+		return
+	}
+
+	if group == nil {
+		group = &alertGroup{}
+		m.pending[gk] = group
+		if m.cfg.GroupWait > 0 {
+			g := group
+			group.timer = time.AfterFunc(m.cfg.GroupWait, func() {
+				recovery.Run("alerts", func() { m.flush(gk, g) })
+			})
+		}
+	}
+
+	// 收集期間的重複告警
+	for _, existing := range group.alerts {
+		if existing.key() == a.key() {
+			m.stats.Suppressed++
+			group.suppressed++
+			m.mu.Unlock()
+			return
+		}
+	}
+	group.alerts = append(group.alerts, a)
+	m.mu.Unlock()
+
+	if m.cfg.GroupWait <= 0 {
+		m.flush(gk, group)
+	}
+}
+
+// OnResolve 註冊告警對象恢復的監聽者 (例如事件單)
+func (m *AlertManager) OnResolve(listener func(kind, domain, subject string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resolved = append(m.resolved, listener)
+}
+
+// Resolve 告警對象已恢復：清除重複抑制，尚未送出的告警直接取消
+func (m *AlertManager) Resolve(kind, domain, subject string) {
+	key := Alert{Kind: kind, Domain: domain, Subject: subject}.key()
+	gk := Alert{Kind: kind, Domain: domain}.groupKey()
+
+	m.mu.Lock()
+	delete(m.lastSent, key)
+
+	if group := m.pending[gk]; group != nil {
+		for i, a := range group.alerts {
+			if a.key() == key {
+				group.alerts = append(group.alerts[:i], group.alerts[i+1:]...)
+				m.stats.Resolved++
+				break
+			}
+		}
+		if len(group.alerts) == 0 {
+			if group.timer != nil {
+				group.timer.Stop()
+			}
+			delete(m.pending, gk)
+		}
+	}
+	listeners := m.resolved
+	m.mu.Unlock()
+
+	for _, listener := range listeners {
+		recovery.Run("alerts/resolve", func() { listener(kind, domain, subject) })
+	}
+}
+
+// flush 送出群組 (群組已被取消或取代時不動作)
+func (m *AlertManager) flush(gk string, group *alertGroup) {
+	m.mu.Lock()
+	if m.pending[gk] != group || len(group.alerts) == 0 {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.pending, gk)
+
+	now := time.Now()
+	for _, a := range group.alerts {
+		m.lastSent[a.key()] = now
+	}
+	notifications := m.buildNotifications(group, now)
+	m.stats.Notified += int64(len(notifications))
+	notifiers := m.notifiers
+	m.mu.Unlock()
+
+	for _, n := range notifications {
+		for _, notify := range notifiers {
+			recovery.Run("alerts/notify", func() { notify(n) })
+		}
+	}
+}
+
+// buildNotifications 依 BurstThreshold 決定逐筆送出或合併為摘要
+func (m *AlertManager) buildNotifications(group *alertGroup, now time.Time) []AlertNotification {
+	first := group.alerts[0]
+
+	if m.cfg.BurstThreshold <= 0 || len(group.alerts) <= m.cfg.BurstThreshold {
+		result := make([]AlertNotification, 0, len(group.alerts))
+		for i, a := range group.alerts {
+			n := AlertNotification{
+				Kind:     a.Kind,
+				Severity: a.Severity,
+				Domain:   a.Domain,
+				Message:  a.Message,
+				Alerts:   []Alert{a},
+				Time:     now,
+			}
+			// 抑制數只記在第一則，避免重複計算
+			if i == 0 {
+				n.Suppressed = group.suppressed
+			}
+			result = append(result, n)
+		}
+		return result
+	}
+
+	subjects := make([]string, 0, summaryListLimit)
+	for i, a := range group.alerts {
+		if i == summaryListLimit {
+			break
+		}
+		subjects = append(subjects, a.Subject)
+	}
+	list := strings.Join(subjects, ", ")
+	if extra := len(group.alerts) - len(subjects); extra > 0 {
+		list += fmt.Sprintf(" (+%d more)", extra)
+	}
+
+	scope := ""
+	if first.Domain != "" {
+		scope = " in " + first.Domain
+	}
+
+	return []AlertNotification{{
+		Kind:       first.Kind,
+		Severity:   first.Severity,
+		Domain:     first.Domain,
+		Message:    fmt.Sprintf("%d %s alerts%s: %s", len(group.alerts), first.Kind, scope, list),
+		Alerts:     append([]Alert{}, group.alerts...),
+		Summary:    true,
+		Suppressed: group.suppressed,
+		Time:       now,
+	}}
+}
+
+// Flush 立即送出所有收集中的群組 (結束前呼叫)
+func (m *AlertManager) Flush() {
+	m.mu.Lock()
+	groups := make(map[string]*alertGroup, len(m.pending))
+	for gk, group := range m.pending {
+		if group.timer != nil {
+			group.timer.Stop()
+		}
+		groups[gk] = group
+	}
+	m.mu.Unlock()
+
+	for gk, group := range groups {
+		m.flush(gk, group)
+	}
+}
+
+// Stats 取得告警統計
+func (m *AlertManager) Stats() AlertStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// HandleDeviceEvents 設備離線產生告警，重新上線時解除
+func (m *AlertManager) HandleDeviceEvents(events []DeviceEvent) {
+	for _, e := range events {
+		switch e.Kind {
+		case DeviceOffline:
+			m.Raise(Alert{
+				Kind:     AlertDeviceOffline,
+				Severity: SeverityCritical,
+				Domain:   e.Domain,
+				Subject:  e.Device.Name,
+				Message:  fmt.Sprintf("device %s (%s, %s) went offline", e.Device.Name, e.Device.Model, e.Device.IPAddress),
+				Time:     e.Time,
+			})
+		case DeviceOnline:
+			m.Resolve(AlertDeviceOffline, e.Domain, e.Device.Name)
+		}
+	}
+}
+
+// HandlePanic 把被回復的 panic 轉成告警 (搭配 recovery.OnPanic 使用)
+func (m *AlertManager) HandlePanic(e recovery.Event) {
+	m.Raise(Alert{
+		Kind:     AlertPanic,
+		Severity: SeverityCritical,
+		Subject:  e.Site,
+		Message:  fmt.Sprintf("recovered panic in %s: %s", e.Site, e.Value),
+		Time:     e.Time,
+	})
+}
+
+// busAlertNotifier 把通知發布到事件匯流排 (/api/events 與嵌入的程式)
+func busAlertNotifier(events *golane.Bus) AlertNotifier {
+	return func(n AlertNotification) {
+		events.Publish(golane.Event{Topic: golane.TopicAlert, Domain: n.Domain, Subject: n.Kind, Time: n.Time, Data: n})
+	}
+}
+
+// logAlertNotifier 預設通知目的地：寫入日誌
+func logAlertNotifier(n AlertNotification) {
+	level := slog.LevelInfo
+	switch n.Severity {
+	case SeverityCritical:
+		level = slog.LevelError
+	case SeverityWarning:
+		level = slog.LevelWarn
+	}
+
+	args := []any{"kind", n.Kind, "alerts", len(n.Alerts)}
+	if n.Domain != "" {
+		args = append(args, "domain", n.Domain)
+	}
+	if n.Suppressed > 0 {
+		args = append(args, "suppressed", n.Suppressed)
+	}
+	logger.Log(context.Background(), level, "ALERT: "+n.Message, args...)
+}
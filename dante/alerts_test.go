@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// alertRecorder 收集送出的通知
+type alertRecorder struct {
+	mu   sync.Mutex
+	sent []AlertNotification
+}
+
+func (r *alertRecorder) notify(n AlertNotification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+}
+
+func (r *alertRecorder) take() []AlertNotification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sent := r.sent
+	r.sent = nil
+	return sent
+}
+
+func offlineAlert(domain, device string) Alert {
+	return Alert{Kind: AlertDeviceOffline, Severity: SeverityCritical, Domain: domain, Subject: device, Message: device + " went offline"}
+}
+
+func TestAlertManagerRepeatInterval(t *testing.T) {
+	rec := &alertRecorder{}
+	m := NewAlertManager(NoiseFloor{RepeatInterval: time.Hour}, rec.notify)
+
+	m.Raise(offlineAlert("Dante1", "amp-1"))
+	if sent := rec.take(); len(sent) != 1 || sent[0].Alerts[0].Subject != "amp-1" {
+		t.Fatalf("first alert: %+v", sent)
+	}
+	// 大小寫不同仍是同一個對象
+	m.Raise(offlineAlert("Dante1", "AMP-1"))
+	if sent := rec.take(); len(sent) != 0 {
+		t.Fatalf("repeat within the interval sent %+v", sent)
+	}
+	// 其他網域的同名設備不受影響
+	m.Raise(offlineAlert("Dante2", "amp-1"))
+	if sent := rec.take(); len(sent) != 1 {
+		t.Fatalf("other domain: %+v", sent)
+	}
+	// 恢復後重新計算
+	m.Resolve(AlertDeviceOffline, "Dante1", "amp-1")
+	m.Raise(offlineAlert("Dante1", "amp-1"))
+	if sent := rec.take(); len(sent) != 1 {
+		t.Fatalf("after resolve: %+v", sent)
+	}
+	// 超過間隔後再送
+	m.Raise(Alert{Kind: AlertDeviceOffline, Domain: "Dante1", Subject: "amp-1", Time: time.Now().Add(2 * time.Hour)})
+	if sent := rec.take(); len(sent) != 1 {
+		t.Fatalf("after the interval: %+v", sent)
+	}
+	if stats := m.Stats(); stats.Raised != 5 || stats.Notified != 4 || stats.Suppressed != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestAlertManagerBurstSummary(t *testing.T) {
+	rec := &alertRecorder{}
+	// GroupWait 很長，只由 Flush 送出
+	m := NewAlertManager(NoiseFloor{GroupWait: time.Hour, BurstThreshold: 3}, rec.notify)
+
+	for i := 1; i <= 6; i++ {
+		m.Raise(offlineAlert("Dante1", fmt.Sprintf("amp-%d", i)))
+	}
+	m.Raise(offlineAlert("Dante1", "amp-2")) // 收集期間的重複
+	m.Raise(offlineAlert("Dante2", "mixer"))
+	m.Raise(offlineAlert("Dante2", "stagebox"))
+	if sent := rec.take(); len(sent) != 0 {
+		t.Fatalf("sent before GroupWait: %+v", sent)
+	}
+	m.Flush()
+
+	byDomain := map[string][]AlertNotification{}
+	for _, n := range rec.take() {
+		byDomain[n.Domain] = append(byDomain[n.Domain], n)
+	}
+	summary := byDomain["Dante1"]
+	if len(summary) != 1 || !summary[0].Summary || len(summary[0].Alerts) != 6 || summary[0].Suppressed != 1 {
+		t.Fatalf("Dante1 = %+v", summary)
+	}
+	if want := "6 device-offline alerts in Dante1: amp-1, amp-2, amp-3, amp-4, amp-5 (+1 more)"; summary[0].Message != want {
+		t.Errorf("summary message = %q, want %q", summary[0].Message, want)
+	}
+	// 未超過門檻時逐筆送出
+	if single := byDomain["Dante2"]; len(single) != 2 || single[0].Summary || single[1].Alerts[0].Subject != "stagebox" {
+		t.Errorf("Dante2 = %+v", single)
+	}
+}
+
+func TestAlertManagerFlapResolvedBeforeSend(t *testing.T) {
+	rec := &alertRecorder{}
+	var resolved []string
+	m := NewAlertManager(NoiseFloor{GroupWait: time.Hour, BurstThreshold: 3}, rec.notify)
+	m.OnResolve(func(kind, domain, subject string) { resolved = append(resolved, subject) })
+
+	m.Raise(offlineAlert("Dante1", "amp-1"))
+	m.Raise(offlineAlert("Dante1", "amp-2"))
+	m.Resolve(AlertDeviceOffline, "Dante1", "amp-1")
+	m.Flush()
+	if sent := rec.take(); len(sent) != 1 || sent[0].Alerts[0].Subject != "amp-2" {
+		t.Fatalf("sent = %+v", sent)
+	}
+
+	// 整個群組都恢復時不送出
+	m.Raise(offlineAlert("Dante2", "mixer"))
+	m.Resolve(AlertDeviceOffline, "Dante2", "mixer")
+	m.Flush()
+	if sent := rec.take(); len(sent) != 0 {
+		t.Fatalf("resolved group sent %+v", sent)
+	}
+	if stats := m.Stats(); stats.Resolved != 2 || stats.Notified != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if len(resolved) != 2 {
+		t.Errorf("resolve listeners = %v", resolved)
+	}
+}
+
+func TestAlertManagerGroupWait(t *testing.T) {
+	rec := &alertRecorder{}
+	m := NewAlertManager(NoiseFloor{GroupWait: 20 * time.Millisecond, BurstThreshold: 1}, rec.notify)
+	m.Raise(offlineAlert("Dante1", "amp-1"))
+	m.Raise(offlineAlert("Dante1", "amp-2"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	var sent []AlertNotification
+	for len(sent) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		sent = rec.take()
+	}
+	if len(sent) != 1 || !sent[0].Summary || len(sent[0].Alerts) != 2 {
+		t.Fatalf("sent = %+v", sent)
+	}
+}
@@ -0,0 +1,1029 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/aes67"
+	"danteCS/internal/dante"
+	"danteCS/internal/recovery"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 管理介面 REST API
+//==============================================================================
+
+// APIConfig API 伺服器設定與依賴的子系統 (nil 的子系統不註冊路由)
+type APIConfig struct {
+	Addr        string
+	TLS         *tls.Config // 以 HTTPS/WSS 提供服務 (nil 表示純 HTTP)
+	Token       string      // 存取權杖 (Tokens 為 nil 時使用，空白表示不驗證)
+	Tokens      *TokenStore // 具名的權杖 (見 tokens.go)
+	OpenReads   bool        // 讀取不需要權杖，只保護變更操作
+	Domains     *supervisor.Supervisor
+	ReadyAge    time.Duration // /readyz: 刷新多久沒有成功視為未就緒 (0 表示不檢查)
+	Detector    *NetworkDetector
+	Routes      map[string]RouteController  // 網域名稱 → 路由控制
+	Flows       map[string]FlowController   // 網域名稱 → 發送 flow 操作
+	Upgrades    map[string]FirmwareUpgrades // 網域名稱 → 韌體升級
+	Settings    map[string]SettingsReader   // 網域名稱 → 設備設定 (傳輸延遲報告)
+	Meters      map[string]MeterReader      // 網域名稱 → 通道電平
+	Icons       *IconStore
+	FloorPlan   *FloorPlanStore
+	Incidents   *IncidentStore
+	Quarantine  *QuarantineStore
+	Triggers    *TriggerEngine
+	Schedules   *RouteScheduler      // 路由排程 (nil 表示沒有設定)
+	Features    *FeatureFlags        // nil 表示全部使用預設值
+	Audit       *AuditLog            // 記錄隔離與功能開關的變更 (訂閱由 Routes 記錄)
+	Load        *LoadMonitor         // 主機過載時拒絕低優先的請求 (nil 表示不卸除)
+	Events      *golane.Bus          // /api/events 轉送的事件 (nil 時不註冊)
+	AES67       *aes67.Directory     // SAP 公告的 AES67 串流 (nil 表示未收聽)
+	IGMP        *IGMPWatch           // Dante 介面的 IGMP querier (nil 表示未收聽)
+	LLDP        *LLDPWatch           // Dante 介面的 LLDP 鄰居 (nil 表示未收聽)
+	SwitchPorts *SwitchPortMapper    // 設備的交換器埠 (nil 表示沒有設定交換器)
+	Reach       *ReachabilityTracker // 設備地址的可達性 (nil 時不註冊)
+	Clocks      *ClockTracker        // 時鐘同步歷史 (nil 時不註冊)
+	FlowStats   *FlowStatsTracker    // 接收 flow 的封包錯誤統計與 /metrics (nil 時不註冊)
+	Storms      *StormDetector       // Dante 介面的封包速率與風暴 (nil 時不註冊)
+	Alarms      *AlarmEngine         // 告警規則的評估結果 (nil 時不註冊)
+	Webhooks    *WebhookDispatcher   // webhook 送出統計 (nil 時不註冊)
+	Hooks       *HookRunner          // 事件 hook 執行統計 (nil 時不註冊)
+	Sinks       *SinkDispatcher      // 輸出 sink 寫入統計 (nil 時不註冊)
+}
+
+// RouteController 路由訂閱控制 (由 dante.Domain 實作)
+// 與嵌入用的 golane.Router 相同；ctx 帶有呼叫端的 span，SDK 操作記錄在同一條 trace 中
+type RouteController = golane.Router
+
+var _ RouteController = (*dante.Domain)(nil)
+
+// APIServer 管理網路 (eth0) 上的 HTTP API
+type APIServer struct {
+	addr        string
+	tls         *tls.Config
+	tokens      *TokenStore
+	openReads   bool
+	domains     *supervisor.Supervisor
+	readyAge    time.Duration
+	detector    *NetworkDetector
+	routes      map[string]RouteController
+	flows       map[string]FlowController
+	upgrades    map[string]FirmwareUpgrades
+	settings    map[string]SettingsReader
+	meters      map[string]MeterReader
+	icons       *IconStore
+	floorPlan   *FloorPlanStore
+	incidents   *IncidentStore
+	quarantine  *QuarantineStore
+	triggers    *TriggerEngine
+	schedules   *RouteScheduler
+	features    *FeatureFlags
+	audit       *AuditLog
+	load        *LoadMonitor
+	events      *golane.Bus
+	aes67       *aes67.Directory
+	igmp        *IGMPWatch
+	lldp        *LLDPWatch
+	switchPorts *SwitchPortMapper
+	reach       *ReachabilityTracker
+	clocks      *ClockTracker
+	flowStats   *FlowStatsTracker
+	storms      *StormDetector
+	alarms      *AlarmEngine
+	webhooks    *WebhookDispatcher
+	hooks       *HookRunner
+	sinks       *SinkDispatcher
+	endpoints   []apiEndpoint // 註冊的路由 (OpenAPI 文件)
+	mux         *http.ServeMux
+	server      *http.Server
+}
+
+// apiDomain 網域狀態
+type apiDomain struct {
+	Name        string `json:"name"`
+	Interface   string `json:"interface"`
+	IPAddress   string `json:"ip_address"`
+	Initialized bool   `json:"initialized"`
+	DeviceCount int    `json:"device_count"`
+	State       string `json:"state"`
+	Phase       string `json:"phase,omitempty"`
+	Restarts    int    `json:"restarts"`
+	LastError   string `json:"last_error,omitempty"`
+	Stale       bool   `json:"stale,omitempty"` // 設備列表來自上次執行或快取，發現完成前尚未確認
+
+	DevicesUpdated time.Time `json:"devices_updated,omitempty"` // 設備列表的時間
+}
+
+// apiDevice 設備資訊 (附加網域、備援狀態與圖示)
+type apiDevice struct {
+	Domain string `json:"domain"`
+	dante.Device
+	Redundancy string           `json:"redundancy"`
+	Icon       string           `json:"icon,omitempty"`
+	Quarantine *QuarantineEntry `json:"quarantine,omitempty"`
+	Stale      bool             `json:"stale,omitempty"`    // 網域的列表尚未重新確認
+	Conflict   string           `json:"conflict,omitempty"` // 名稱與其他設備 (任一網域) 重複
+	Domains    []string         `json:"domains,omitempty"`  // 備援設備在兩個網路都被發現時，發現它的網域
+}
+
+// newAPIDevice 建立 API 輸出的設備資訊
+func newAPIDevice(domain string, dev dante.Device) apiDevice {
+	return apiDevice{Domain: domain, Device: dev, Redundancy: dev.Redundancy()}
+}
+
+// NewAPIServer 建立 API 伺服器
+func NewAPIServer(cfg APIConfig) *APIServer {
+	s := &APIServer{
+		addr:        cfg.Addr,
+		tokens:      cfg.Tokens,
+		openReads:   cfg.OpenReads,
+		domains:     cfg.Domains,
+		readyAge:    cfg.ReadyAge,
+		detector:    cfg.Detector,
+		routes:      cfg.Routes,
+		flows:       cfg.Flows,
+		upgrades:    cfg.Upgrades,
+		settings:    cfg.Settings,
+		meters:      cfg.Meters,
+		icons:       cfg.Icons,
+		floorPlan:   cfg.FloorPlan,
+		incidents:   cfg.Incidents,
+		quarantine:  cfg.Quarantine,
+		triggers:    cfg.Triggers,
+		schedules:   cfg.Schedules,
+		features:    cfg.Features,
+		audit:       cfg.Audit,
+		load:        cfg.Load,
+		events:      cfg.Events,
+		aes67:       cfg.AES67,
+		igmp:        cfg.IGMP,
+		lldp:        cfg.LLDP,
+		switchPorts: cfg.SwitchPorts,
+		reach:       cfg.Reach,
+		clocks:      cfg.Clocks,
+		flowStats:   cfg.FlowStats,
+		storms:      cfg.Storms,
+		alarms:      cfg.Alarms,
+		webhooks:    cfg.Webhooks,
+		hooks:       cfg.Hooks,
+		sinks:       cfg.Sinks,
+		mux:         http.NewServeMux(),
+	}
+	if s.features == nil {
+		s.features = DefaultFeatureFlags()
+	}
+	if s.tokens == nil && cfg.Token != "" {
+		s.tokens, _ = NewTokenStore("", cfg.Token, nil)
+	}
+
+	s.handlePublic("GET /healthz", s.handleHealthz)
+	s.handlePublic("GET /readyz", s.handleReadyz)
+	s.handlePublic("GET /api/openapi.json", s.handleOpenAPI)
+	s.handlePublic("GET /api/i18n", s.handleI18n)
+	s.handle("GET /api/version", s.handleVersion)
+	s.handle("GET /api/domains", s.handleDomains)
+	s.handle("GET /api/devices", s.handleDevices)
+	s.handle("GET /api/topology", s.lowPriority(s.handleTopology))
+	s.handle("GET /api/bandwidth", s.lowPriority(s.handleBandwidth))
+	s.handle("GET /api/firmware", s.handleFirmware)
+	s.handle("GET /api/features", s.handleFeatures)
+	s.handleRole("PUT /api/features/{name}", RoleAdmin, s.handleSetFeature)
+	s.registerWebUI()
+	s.registerPprof()
+
+	if s.detector != nil {
+		s.handle("GET /api/interfaces", s.handleInterfaces)
+		s.handle("GET /api/qos", s.lowPriority(s.handleQoS))
+		s.handle("GET /api/latency", s.lowPriority(s.handleLatency))
+	}
+
+	if s.load != nil {
+		s.handle("GET /api/load", s.handleLoad)
+	}
+
+	if s.events != nil {
+		s.handle("GET /api/events", s.handleEvents)
+	}
+
+	if s.aes67 != nil {
+		s.handle("GET /api/aes67", s.handleAES67)
+	}
+
+	if s.igmp != nil {
+		s.handle("GET /api/igmp", s.handleIGMP)
+	}
+	if s.lldp != nil {
+		s.handle("GET /api/lldp", s.handleLLDP)
+	}
+	if s.switchPorts != nil {
+		s.handle("GET /api/switchports", s.handleSwitchPorts)
+	}
+
+	if s.storms != nil {
+		s.handle("GET /api/storms", s.handleStorms)
+	}
+
+	if s.alarms != nil {
+		s.handle("GET /api/alarms", s.handleAlarms)
+	}
+
+	if s.webhooks != nil {
+		s.handle("GET /api/webhooks", s.handleWebhooks)
+	}
+	if s.hooks != nil {
+		s.handle("GET /api/hooks", s.handleHooks)
+	}
+	if s.sinks != nil {
+		s.handle("GET /api/sinks", s.handleSinks)
+	}
+
+	if s.clocks != nil {
+		s.handle("GET /api/clock", s.handleClock)
+	}
+
+	if s.flowStats != nil {
+		s.handle("GET /api/flowstats", s.handleFlowStats)
+		s.handle("GET /metrics", s.handleMetrics)
+	}
+
+	if s.reach != nil {
+		s.handle("GET /api/reachability", s.requireFeature(FeatureReachability, http.HandlerFunc(s.handleReachability)))
+	}
+
+	if len(s.routes) > 0 {
+		s.handle("GET /api/routes/{device}", s.handleRoutes)
+		s.handle("PUT /api/routes/{device}/{channel}", s.requireFeature(FeatureRouting, http.HandlerFunc(s.handleSubscribe)))
+		s.handle("DELETE /api/routes/{device}/{channel}", s.requireFeature(FeatureRouting, http.HandlerFunc(s.handleUnsubscribe)))
+	}
+
+	if len(s.flows) > 0 {
+		s.handle("GET /api/devices/{device}/flows", s.handleFlows)
+		s.handle("POST /api/devices/{device}/flows", s.requireFeature(FeatureRouting, http.HandlerFunc(s.handleCreateFlow)))
+		s.handle("DELETE /api/devices/{device}/flows/{id}", s.requireFeature(FeatureRouting, http.HandlerFunc(s.handleDeleteFlow)))
+	}
+
+	if len(s.upgrades) > 0 {
+		s.handle("GET /api/devices/{device}/firmware", s.handleUpgradeStatus)
+		s.handleRole("POST /api/devices/{device}/firmware", RoleOperator, s.handleStartUpgrade)
+	}
+
+	if len(s.meters) > 0 {
+		s.handle("GET /api/devices/{device}/meters", s.handleMeters)
+	}
+
+	if s.quarantine != nil {
+		s.handle("GET /api/quarantine", s.handleQuarantineList)
+		s.handle("PUT /api/quarantine/{device}", s.handleQuarantine)
+		s.handle("DELETE /api/quarantine/{device}", s.handleRelease)
+	}
+
+	if s.triggers != nil {
+		s.handle("GET /api/presets", s.handlePresets)
+		s.handle("GET /api/presets/{name}/check", s.handleCheckPreset)
+		s.handle("POST /api/presets/{name}/recall", s.requireFeature(FeatureTriggers, http.HandlerFunc(s.handleRecallPreset)))
+		s.handle("GET /api/triggers", s.handleTriggers)
+		s.handle("POST /api/triggers/{input}", s.requireFeature(FeatureTriggers, http.HandlerFunc(s.handleFireTrigger)))
+	}
+	if s.schedules != nil {
+		s.handle("GET /api/schedules", s.handleSchedules)
+	}
+
+	if s.audit != nil {
+		s.handle("GET /api/audit", s.lowPriority(s.handleAudit))
+		s.handle("GET /api/audit/verify", s.lowPriority(s.handleVerifyAudit))
+	}
+
+	if s.icons != nil {
+		s.handle("GET /api/icons", s.handleIcons)
+		// 圖片由 <img> 直接載入，無法附加權杖
+		s.handlePublic("GET /api/icons/bundled/{name}", s.handleBundledIcon)
+		s.handlePublic("GET /api/icons/models/{model}", s.handleModelIcon)
+		s.handle("PUT /api/icons/models/{model}", s.handleUploadIcon)
+		s.handle("DELETE /api/icons/models/{model}", s.handleDeleteIcon)
+	}
+
+	if s.floorPlan != nil {
+		s.handle("GET /api/floorplan", s.handleFloorPlan)
+		s.handle("PUT /api/floorplan", s.handleReplaceFloorPlan)
+		s.handle("PUT /api/floorplan/rooms/{id}", s.handlePutRoom)
+		s.handle("DELETE /api/floorplan/rooms/{id}", s.handleDeleteRoom)
+		s.handle("PUT /api/floorplan/devices/{name}", s.handlePlaceDevice)
+		s.handle("DELETE /api/floorplan/devices/{name}", s.handleRemovePlacement)
+	}
+
+	if s.incidents != nil {
+		s.handle("GET /api/incidents", s.handleIncidents)
+		s.handle("GET /api/incidents/report", s.lowPriority(s.handleIncidentReport))
+		s.handle("GET /api/incidents/{id}", s.handleIncident)
+		s.handle("POST /api/incidents/{id}/ack", s.handleAcknowledgeIncident)
+		s.handle("POST /api/incidents/{id}/resolve", s.handleResolveIncident)
+		s.handle("POST /api/incidents/{id}/notes", s.handleIncidentNote)
+	}
+
+	return s
+}
+
+// readJSON 解析 JSON 請求內容
+func readJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// handle 註冊需要權杖的路由，所有 handler 都經過 panic 回復並建立 span
+// 驗證通過的請求在 context 中帶有操作人員 (稽核紀錄使用)
+func (s *APIServer) handle(pattern string, handler http.HandlerFunc) {
+	s.handleRole(pattern, "", handler)
+}
+
+// handleRole 註冊需要特定角色的路由 (role 空白時依方法：讀取為 viewer，其他為 operator)
+func (s *APIServer) handleRole(pattern, role string, handler http.HandlerFunc) {
+	s.endpoints = append(s.endpoints, apiEndpoint{pattern: pattern, role: role})
+	if role != "" {
+		handler = requireRole(role, handler)
+	}
+	s.mux.Handle(pattern, traceHandler(pattern, recoverHandler("api", s.authorize(func(w http.ResponseWriter, r *http.Request) {
+		ctx := withAuditVia(withAuditActor(r.Context(), requestActor(r)), requestVia(r))
+		handler(w, r.WithContext(ctx))
+	}))))
+}
+
+// handlePublic 註冊不需要權杖的路由 (圖示、Web UI 入口)
+func (s *APIServer) handlePublic(pattern string, handler http.HandlerFunc) {
+	s.endpoints = append(s.endpoints, apiEndpoint{pattern: pattern, public: true})
+	s.mux.Handle(pattern, traceHandler(pattern, recoverHandler("api", handler)))
+}
+
+// authorize 驗證權杖 (見 tokens.go)：沒有任何權杖時不驗證；讀取需要 viewer，
+// 其他方法需要 operator，更高的要求由 requireRole 檢查
+func (s *APIServer) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.tokens.Enabled() {
+			next(w, r.WithContext(withToken(r.Context(), "", RoleAdmin)))
+			return
+		}
+		need := RoleOperator
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			need = RoleViewer
+		}
+		name, role, ok := s.tokens.Authenticate(bearerToken(r))
+		switch {
+		case !ok && need == RoleViewer && s.openReads:
+			role = RoleViewer
+		case !ok:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="golane"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+			return
+		case !roleAllows(role, need):
+			writeError(w, http.StatusForbidden, fmt.Errorf("API token %q has the %s role and cannot make changes", name, role))
+			return
+		}
+		next(w, r.WithContext(withToken(r.Context(), name, role)))
+	}
+}
+
+// Start 開始監聽 (背景執行)
+func (s *APIServer) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if s.tls != nil {
+		listener = tls.NewListener(listener, s.tls)
+	}
+
+	s.server = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Info("API server listening", "addr", listener.Addr().String(), "tls", s.tls != nil)
+	recovery.Go("api", func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("API server stopped", "err", err)
+		}
+	})
+	return nil
+}
+
+// Shutdown 停止 API 伺服器
+func (s *APIServer) Shutdown(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
+
+// writeJSON 輸出 JSON 回應
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError 輸出 JSON 錯誤
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// snapshots 各網域最後回報的狀態 (不直接呼叫網域，失敗的網域不會拖住 API)
+func (s *APIServer) snapshots() []supervisor.Snapshot {
+	if s.domains == nil {
+		return nil
+	}
+	return s.domains.Snapshots()
+}
+
+// domainList 所有網域的狀態
+func (s *APIServer) domainList() []apiDomain {
+	snapshots := s.snapshots()
+	domains := make([]apiDomain, 0, len(snapshots))
+	for _, d := range snapshots {
+		domains = append(domains, apiDomain{
+			Name:        d.Name,
+			Interface:   d.Interface,
+			IPAddress:   d.IPAddress,
+			Initialized: d.State == supervisor.StateRunning,
+			DeviceCount: len(d.Devices),
+			State:       d.State,
+			Phase:       d.Phase,
+			Restarts:    d.Restarts,
+			LastError:   d.LastError,
+			Stale:       d.Stale,
+
+			DevicesUpdated: d.Updated,
+		})
+	}
+	return domains
+}
+
+// deviceList 所有網域的設備 (備援設備在兩個網域的記錄合併為一筆)
+func (s *APIServer) deviceList() []apiDevice {
+	snapshots := s.snapshots()
+	lists := make(map[string][]dante.Device, len(snapshots))
+	ordered := make([]domainDevices, 0, len(snapshots))
+	stale := make(map[string]bool, len(snapshots))
+	for _, d := range snapshots {
+		lists[d.Name] = d.Devices
+		ordered = append(ordered, domainDevices{Domain: d.Name, Devices: d.Devices})
+		stale[d.Name] = d.Stale
+	}
+	conflicts := FindNameConflicts(lists)
+
+	devices := []apiDevice{}
+	for _, logical := range mergeRedundantDevices(ordered) {
+		dev := logical.Device
+		item := newAPIDevice(logical.Domain, dev)
+		if s.icons != nil {
+			item.Icon = s.icons.IconURL(dev.Model)
+		}
+		if e, ok := s.quarantine.Get(dev.Name); ok {
+			item.Quarantine = &e
+		}
+		// 任一網域確認過就不是過時的資訊
+		item.Stale = true
+		for _, domain := range logical.Domains {
+			item.Stale = item.Stale && stale[domain]
+		}
+		if len(logical.Domains) > 1 {
+			item.Domains = logical.Domains
+		}
+		if c, ok := conflicts[strings.ToLower(dev.Name)]; ok {
+			item.Conflict = c.Describe(logical.Domain, dev.IPAddress)
+		}
+		devices = append(devices, item)
+	}
+	return devices
+}
+
+func (s *APIServer) handleDomains(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.domainList())
+}
+
+// handleDevices GET /api/devices[?name=&model=&ip=&mac=&version=&sort=&limit=&offset=]
+// 分頁時以 X-Total-Count 回傳符合條件的設備總數，還有下一頁時附上 Link rel="next"
+func (s *APIServer) handleDevices(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, err := parseDeviceFilter(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	order, err := dante.ParseDeviceOrder(q.Get("sort"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	page, err := parseDevicePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	devices := s.deviceList()
+	if !filter.Empty() {
+		matched := []apiDevice{}
+		for _, dev := range devices {
+			if filter.Match(dev.Device) {
+				matched = append(matched, dev)
+			}
+		}
+		devices = matched
+	}
+	slices.SortStableFunc(devices, func(a, b apiDevice) int { return order.Compare(a.Device, b.Device) })
+
+	if page.Limit > 0 {
+		total := len(devices)
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		if next := page.Offset + page.Limit; next < total {
+			q.Set("offset", strconv.Itoa(next))
+			w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, q.Encode()))
+		}
+		start, end := page.bounds(len(devices))
+		devices = devices[start:end]
+	}
+	writeJSON(w, http.StatusOK, devices)
+}
+
+// devicePage 設備列表分頁 (Limit 為 0 時不分頁)
+type devicePage struct {
+	Offset int
+	Limit  int
+}
+
+// parseDevicePage 解析 limit 與 offset
+func parseDevicePage(q url.Values) (devicePage, error) {
+	var page devicePage
+	for key, v := range map[string]*int{"limit": &page.Limit, "offset": &page.Offset} {
+		value := q.Get(key)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return devicePage{}, fmt.Errorf("invalid %s %q", key, value)
+		}
+		*v = n
+	}
+	if page.Offset > 0 && page.Limit == 0 {
+		return devicePage{}, errors.New("offset requires limit")
+	}
+	return page, nil
+}
+
+// bounds 這一頁在 n 台設備中的範圍
+func (p devicePage) bounds(n int) (start, end int) {
+	start = min(p.Offset, n)
+	return start, min(start+p.Limit, n)
+}
+
+// parseDeviceFilter 解析設備搜尋參數 (ip 可以是地址或 CIDR 網段)
+func parseDeviceFilter(q url.Values) (dante.DeviceFilter, error) {
+	filter := dante.DeviceFilter{
+		Name:         q.Get("name"),
+		Model:        q.Get("model"),
+		MAC:          q.Get("mac"),
+		DanteVersion: q.Get("version"),
+	}
+	if ip := q.Get("ip"); ip != "" {
+		network, err := dante.ParseNetwork(ip)
+		if err != nil {
+			return dante.DeviceFilter{}, err
+		}
+		filter.Network = network
+	}
+	return filter, nil
+}
+
+// deviceFilterQuery 把設備搜尋條件編碼成 parseDeviceFilter 的參數
+func deviceFilterQuery(filter dante.DeviceFilter) url.Values {
+	q := url.Values{}
+	for key, value := range map[string]string{
+		"name":    filter.Name,
+		"model":   filter.Model,
+		"mac":     filter.MAC,
+		"version": filter.DanteVersion,
+	} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	if filter.Network != nil {
+		q.Set("ip", filter.Network.String())
+	}
+	return q
+}
+
+func (s *APIServer) handleIcons(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bundled": BundledIconNames(),
+		"models":  s.icons.Mappings(),
+	})
+}
+
+func (s *APIServer) handleBundledIcon(w http.ResponseWriter, r *http.Request) {
+	data, err := bundledIcons.ReadFile("icons/" + r.PathValue("name"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "max-age=86400")
+	w.Write(data)
+}
+
+func (s *APIServer) handleModelIcon(w http.ResponseWriter, r *http.Request) {
+	data, contentType, err := s.icons.Open(r.PathValue("model"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// 上傳的內容不受信任：不讓瀏覽器猜測類型，直接開啟時也不執行任何內容
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox; default-src 'none'")
+	w.Write(data)
+}
+
+func (s *APIServer) handleUploadIcon(w http.ResponseWriter, r *http.Request) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("Content-Type header is required"))
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxIconSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	model := r.PathValue("model")
+	if err := s.icons.Upload(model, contentType, data); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	logger.Info("Device icon uploaded", "model", model, "type", contentType, "bytes", len(data))
+	writeJSON(w, http.StatusOK, map[string]string{"model": normalizeModel(model), "icon": s.icons.IconURL(model)})
+}
+
+func (s *APIServer) handleDeleteIcon(w http.ResponseWriter, r *http.Request) {
+	model := r.PathValue("model")
+	if err := s.icons.Remove(model); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"model": normalizeModel(model), "icon": s.icons.IconURL(model)})
+}
+
+func (s *APIServer) handleFloorPlan(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.floorPlan.View(s.snapshots()))
+}
+
+func (s *APIServer) handleReplaceFloorPlan(w http.ResponseWriter, r *http.Request) {
+	var plan FloorPlan
+	if err := readJSON(r, &plan); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.floorPlan.Replace(plan); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.floorPlan.View(s.snapshots()))
+}
+
+func (s *APIServer) handlePutRoom(w http.ResponseWriter, r *http.Request) {
+	var room Room
+	if err := readJSON(r, &room); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	room.ID = r.PathValue("id")
+	if err := s.floorPlan.PutRoom(room); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (s *APIServer) handleDeleteRoom(w http.ResponseWriter, r *http.Request) {
+	if err := s.floorPlan.DeleteRoom(r.PathValue("id")); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *APIServer) handlePlaceDevice(w http.ResponseWriter, r *http.Request) {
+	var placement DevicePlacement
+	if err := readJSON(r, &placement); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	placement.Device = r.PathValue("name")
+	if err := s.floorPlan.PlaceDevice(placement); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, placement)
+}
+
+func (s *APIServer) handleRemovePlacement(w http.ResponseWriter, r *http.Request) {
+	if err := s.floorPlan.RemoveDevice(r.PathValue("name")); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *APIServer) handleInterfaces(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.detector)
+}
+
+// routeController 依 ?domain= 選擇網域，只有一個網域時可省略
+func (s *APIServer) routeController(r *http.Request) (RouteController, error) {
+	return selectDomain(r, s.routes)
+}
+
+// selectDomain 依 ?domain= 從網域名稱對應的控制中選擇，只有一個網域時可省略
+func selectDomain[T any](r *http.Request, controllers map[string]T) (T, error) {
+	var zero T
+	if name := r.URL.Query().Get("domain"); name != "" {
+		c, ok := controllers[name]
+		if !ok {
+			return zero, fmt.Errorf("unknown domain %q", name)
+		}
+		return c, nil
+	}
+	if len(controllers) > 1 {
+		return zero, errors.New("several domains available, specify ?domain=")
+	}
+	for _, c := range controllers {
+		return c, nil
+	}
+	return zero, errors.New("no domain available")
+}
+
+// routeRequest 設定訂閱的內容
+type routeRequest struct {
+	TxChannel string `json:"tx_channel"`
+	TxDevice  string `json:"tx_device"`
+	Override  bool   `json:"override,omitempty"` // 允許接到隔離中的設備
+}
+
+// apiSubscription 接收通道訂閱 (附上狀態分類與原因，標記經過隔離設備的路由)
+type apiSubscription struct {
+	dante.Subscription
+	StatusText  string `json:"status_text"`
+	State       string `json:"state"`
+	Reason      string `json:"reason,omitempty"`
+	Quarantined bool   `json:"quarantined,omitempty"`
+}
+
+func (s *APIServer) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	rc, err := s.routeController(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	device := r.PathValue("device")
+	subs, err := rc.ListSubscriptions(r.Context(), device)
+	if err != nil {
+		writeError(w, subscribeStatus(err), err)
+		return
+	}
+
+	list := make([]apiSubscription, 0, len(subs))
+	for _, sub := range subs {
+		flagged := sub.Subscribed() && s.quarantine.CheckRoute(device, sub.TxDevice) != nil
+		list = append(list, apiSubscription{Subscription: sub, StatusText: sub.StatusText(),
+			State: sub.State(), Reason: sub.Reason(), Quarantined: flagged})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *APIServer) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	rc, err := s.routeController(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req routeRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.TxChannel == "" || req.TxDevice == "" {
+		writeError(w, http.StatusBadRequest, errors.New("tx_channel and tx_device are required"))
+		return
+	}
+	device := r.PathValue("device")
+	if err := s.quarantine.CheckRoute(device, req.TxDevice); err != nil {
+		if !req.Override {
+			writeError(w, http.StatusConflict, fmt.Errorf("%v, set \"override\": true to route anyway", err))
+			return
+		}
+		logger.Warn("Routing to quarantined device", "rx_device", device, "rx_channel", r.PathValue("channel"),
+			"tx", req.TxChannel+"@"+req.TxDevice, "reason", err)
+	}
+	if err := rc.Subscribe(r.Context(), device, r.PathValue("channel"), req.TxDevice, req.TxChannel); err != nil {
+		writeError(w, subscribeStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *APIServer) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	rc, err := s.routeController(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := rc.Subscribe(r.Context(), r.PathValue("device"), r.PathValue("channel"), "", ""); err != nil {
+		writeError(w, subscribeStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// subscribeStatus 設備操作失敗的 HTTP 狀態 (依 SDK 錯誤的類別；這個控制器無法
+// 設定的設備為 409，無法歸類的 SDK 錯誤為 502)
+func subscribeStatus(err error) int {
+	switch {
+	case errors.Is(err, dante.ErrReadOnly), errors.Is(err, dante.ErrAccessDenied), errors.Is(err, dante.ErrCapacity):
+		return http.StatusConflict
+	case errors.Is(err, dante.ErrDeviceNotFound), errors.Is(err, dante.ErrChannelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, dante.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, dante.ErrUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, dante.ErrNotInitialized), errors.Is(err, dante.ErrInterfaceDown), errors.Is(err, dante.ErrNotConnected):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, dante.ErrDiscoveryTimeout):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+// quarantineRequest 隔離設備的內容
+type quarantineRequest struct {
+	Reason string `json:"reason"`
+	Actor  string `json:"actor"`
+}
+
+func (s *APIServer) handleQuarantineList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.quarantine.List())
+}
+
+func (s *APIServer) handleQuarantine(w http.ResponseWriter, r *http.Request) {
+	var req quarantineRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	device := r.PathValue("device")
+	previous, wasQuarantined := s.quarantine.Get(device)
+	entry, err := s.quarantine.Quarantine(device, req.Reason, req.Actor)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	logger.Warn("Device quarantined", "device", entry.Device, "reason", entry.Reason, "actor", entry.By)
+	change := AuditEntry{Operation: AuditQuarantineAdd, Actor: req.Actor, Device: entry.Device,
+		After: quarantineAuditValue(entry), Note: entry.Reason}
+	if wasQuarantined {
+		change.Before = quarantineAuditValue(previous)
+	}
+	s.audit.Record(r.Context(), change)
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (s *APIServer) handleRelease(w http.ResponseWriter, r *http.Request) {
+	device := r.PathValue("device")
+	previous, _ := s.quarantine.Get(device)
+	if err := s.quarantine.Release(device); err != nil {
+		if errors.Is(err, errNotQuarantined) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	logger.Info("Device released from quarantine", "device", device)
+	s.audit.Record(r.Context(), AuditEntry{Operation: AuditQuarantineRelease, Device: previous.Device,
+		Before: quarantineAuditValue(previous)})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// incidentAction 事件單操作內容
+type incidentAction struct {
+	Actor string `json:"actor"`
+	Note  string `json:"note"`
+}
+
+// writeIncidentResult 輸出事件單操作結果
+func writeIncidentResult(w http.ResponseWriter, inc Incident, err error) {
+	switch {
+	case errors.Is(err, errIncidentNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeJSON(w, http.StatusOK, inc)
+	}
+}
+
+func (s *APIServer) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", IncidentOpen, IncidentAcknowledged, IncidentResolved:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown status %q", status))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.incidents.List(status))
+}
+
+func (s *APIServer) handleIncidentReport(w http.ResponseWriter, r *http.Request) {
+	period := 7 * 24 * time.Hour
+	if v := r.URL.Query().Get("since"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since %q, use a duration such as 24h", v))
+			return
+		}
+		period = d
+	}
+	writeJSON(w, http.StatusOK, s.incidents.Report(time.Now().Add(-period)))
+}
+
+func (s *APIServer) handleIncident(w http.ResponseWriter, r *http.Request) {
+	inc, ok := s.incidents.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errIncidentNotFound, r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, inc)
+}
+
+func (s *APIServer) handleAcknowledgeIncident(w http.ResponseWriter, r *http.Request) {
+	var action incidentAction
+	if err := readJSON(r, &action); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	inc, err := s.incidents.Acknowledge(r.PathValue("id"), action.Actor, action.Note)
+	writeIncidentResult(w, inc, err)
+}
+
+func (s *APIServer) handleResolveIncident(w http.ResponseWriter, r *http.Request) {
+	var action incidentAction
+	if err := readJSON(r, &action); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	inc, err := s.incidents.Resolve(r.PathValue("id"), action.Actor, action.Note)
+	writeIncidentResult(w, inc, err)
+}
+
+func (s *APIServer) handleIncidentNote(w http.ResponseWriter, r *http.Request) {
+	var action incidentAction
+	if err := readJSON(r, &action); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	inc, err := s.incidents.AddNote(r.PathValue("id"), action.Actor, action.Note)
+	if err != nil && !errors.Is(err, errIncidentNotFound) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeIncidentResult(w, inc, err)
+}
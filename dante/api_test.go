@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/internal/backoff"
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+func TestAPIServesHealthyDomainWhileOtherFails(t *testing.T) {
+	s := supervisor.New(supervisor.Config{Restart: backoff.Policy{Initial: time.Second, Max: time.Second}})
+	s.Add(supervisor.Spec{Name: "Dante1", Interface: "eth1", Run: func(ctx context.Context, report supervisor.Reporter) error {
+		return errors.New("simulated SDK crash")
+	}})
+	s.Add(supervisor.Spec{Name: "Dante2", Interface: "eth2", Run: func(ctx context.Context, report supervisor.Reporter) error {
+		report.Devices([]dante.Device{{ID: 1, Name: "mixer", IPAddress: "10.0.2.5", LinkSpeed: 1000}})
+		<-ctx.Done()
+		return nil
+	}})
+	s.Start(context.Background())
+	t.Cleanup(s.Stop)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		failed, _ := s.Snapshot("Dante1")
+		running, _ := s.Snapshot("Dante2")
+		if failed.State == supervisor.StateFailed && running.State == supervisor.StateRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for Dante1 failed / Dante2 running")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{Domains: s}).mux)
+	defer server.Close()
+
+	var domains []apiDomain
+	getJSON(t, server.URL+"/api/domains", &domains)
+	if len(domains) != 2 {
+		t.Fatalf("got %d domains, want 2", len(domains))
+	}
+	if domains[0].State != supervisor.StateFailed || domains[0].Initialized || domains[0].LastError == "" {
+		t.Fatalf("failed domain not reported: %+v", domains[0])
+	}
+	if domains[1].State != supervisor.StateRunning || domains[1].DeviceCount != 1 {
+		t.Fatalf("healthy domain not reported: %+v", domains[1])
+	}
+
+	var devices []apiDevice
+	getJSON(t, server.URL+"/api/devices", &devices)
+	if len(devices) != 1 || devices[0].Domain != "Dante2" || devices[0].Name != "mixer" {
+		t.Fatalf("unexpected devices: %+v", devices)
+	}
+
+	// 搜尋參數 (與 -host 的 devices list 使用相同的編碼)
+	network, _ := dante.ParseNetwork("10.0.2.0/24")
+	query := deviceFilterQuery(dante.DeviceFilter{Name: "MIX", Network: network})
+	getJSON(t, server.URL+"/api/devices?"+query.Encode(), &devices)
+	if len(devices) != 1 {
+		t.Fatalf("filter %s: got %d devices, want 1", query.Encode(), len(devices))
+	}
+	getJSON(t, server.URL+"/api/devices?model=DL32", &devices)
+	if len(devices) != 0 {
+		t.Fatalf("model filter: got %+v", devices)
+	}
+	resp, err := http.Get(server.URL + "/api/devices?ip=10.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid ip filter: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func getJSON(t *testing.T, url string, v any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuarantineBlocksRouting(t *testing.T) {
+	dir := t.TempDir()
+	state, err := OpenStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quarantine, err := NewQuarantineStore(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{
+		Routes:     map[string]RouteController{d.Name: d},
+		Quarantine: quarantine,
+	}).mux)
+	defer server.Close()
+
+	send := func(method, path, body string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// 先接好一條路由，隔離後應被標記
+	if status := send(http.MethodPut, "/api/routes/Amp-Left/01", `{"tx_channel": "01", "tx_device": "FOH-Console"}`); status != http.StatusNoContent {
+		t.Fatalf("PUT route: status %d", status)
+	}
+	if status := send(http.MethodPut, "/api/quarantine/foh-console", `{"reason": "hum on all outputs", "actor": "a1"}`); status != http.StatusOK {
+		t.Fatalf("PUT quarantine: status %d", status)
+	}
+
+	var subs []apiSubscription
+	getJSON(t, server.URL+"/api/routes/Amp-Left", &subs)
+	if len(subs) < 2 || !subs[0].Quarantined || subs[1].Quarantined {
+		t.Fatalf("quarantined route not flagged: %+v", subs)
+	}
+	if subs[0].State != dante.SubscriptionOK || subs[0].Reason != "" || subs[1].State != dante.SubscriptionNone {
+		t.Fatalf("subscription states: %+v", subs[:2])
+	}
+
+	route := `{"tx_channel": "02", "tx_device": "FOH-Console"}`
+	if status := send(http.MethodPut, "/api/routes/Amp-Left/02", route); status != http.StatusConflict {
+		t.Fatalf("route to quarantined device: status %d, want 409", status)
+	}
+	if status := send(http.MethodPut, "/api/routes/Amp-Left/02", `{"tx_channel": "02", "tx_device": "FOH-Console", "override": true}`); status != http.StatusNoContent {
+		t.Fatalf("route with override: status %d", status)
+	}
+	if status := send(http.MethodDelete, "/api/routes/Amp-Left/02", ""); status != http.StatusNoContent {
+		t.Fatalf("unsubscribe from quarantined device: status %d", status)
+	}
+
+	// 隔離列表跨重啟保存
+	reopened, err := OpenStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewQuarantineStore(reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := restored.Get("FOH-Console"); !ok || e.Reason != "hum on all outputs" || e.By != "a1" {
+		t.Fatalf("quarantine not persisted: %+v, %v", e, ok)
+	}
+
+	if status := send(http.MethodDelete, "/api/quarantine/FOH-Console", ""); status != http.StatusNoContent {
+		t.Fatalf("DELETE quarantine: status %d", status)
+	}
+	if status := send(http.MethodDelete, "/api/quarantine/FOH-Console", ""); status != http.StatusNotFound {
+		t.Fatalf("DELETE released device: status %d, want 404", status)
+	}
+	if status := send(http.MethodPut, "/api/routes/Amp-Left/02", route); status != http.StatusNoContent {
+		t.Fatalf("route after release: status %d", status)
+	}
+}
+
+func TestDevicesSortAndPaginate(t *testing.T) {
+	s := supervisor.New(supervisor.DefaultConfig())
+	s.Add(supervisor.Spec{Name: "Dante1", Run: func(ctx context.Context, report supervisor.Reporter) error {
+		report.Devices([]dante.Device{
+			{ID: 1, Name: "mixer", IPAddress: "10.0.0.10"},
+			{ID: 2, Name: "amp-2", IPAddress: "10.0.0.9"},
+			{ID: 3, Name: "amp-1", IPAddress: "10.0.0.30"},
+			{ID: 4, Name: "stagebox", IPAddress: "10.0.0.2"},
+		})
+		<-ctx.Done()
+		return nil
+	}})
+	s.Start(context.Background())
+	t.Cleanup(s.Stop)
+	deadline := time.Now().Add(2 * time.Second)
+	for snap, _ := s.Snapshot("Dante1"); len(snap.Devices) == 0; snap, _ = s.Snapshot("Dante1") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for devices")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{Domains: s}).mux)
+	defer server.Close()
+
+	names := func(devices []apiDevice) string {
+		var list []string
+		for _, dev := range devices {
+			list = append(list, dev.Name)
+		}
+		return strings.Join(list, ",")
+	}
+
+	var devices []apiDevice
+	getJSON(t, server.URL+"/api/devices?sort=ip", &devices)
+	if got := names(devices); got != "stagebox,amp-2,mixer,amp-1" {
+		t.Fatalf("sort=ip: %s", got)
+	}
+
+	resp, err := http.Get(server.URL + "/api/devices?sort=-name&limit=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(resp.Body).Decode(&devices)
+	resp.Body.Close()
+	if got := names(devices); got != "stagebox,mixer,amp-2" || resp.Header.Get("X-Total-Count") != "4" {
+		t.Fatalf("first page: %s (total %s)", got, resp.Header.Get("X-Total-Count"))
+	}
+	next, ok := strings.CutPrefix(resp.Header.Get("Link"), "<")
+	next, _, _ = strings.Cut(next, ">")
+	if !ok || !strings.Contains(next, "offset=3") {
+		t.Fatalf("missing next link: %q", resp.Header.Get("Link"))
+	}
+
+	resp, err = http.Get(server.URL + next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(resp.Body).Decode(&devices)
+	resp.Body.Close()
+	if got := names(devices); got != "amp-1" || resp.Header.Get("Link") != "" {
+		t.Fatalf("last page: %s (link %q)", got, resp.Header.Get("Link"))
+	}
+
+	for _, query := range []string{"sort=mac", "limit=-1", "offset=2"} {
+		resp, err := http.Get(server.URL + "/api/devices?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: status %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,733 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// 稽核紀錄 (audit log)
+//==============================================================================
+
+// 廣播客戶的合規要求需要證明「誰在什麼時候改了什麼」。每次變更 (訂閱、
+// 隔離、功能開關、multicast flow、設備改名、設定與韌體升級) 附加一筆到 <state-dir>/audit.jsonl，
+// 記錄變更前後的值與經由哪個介面 (API、命令列、觸發輸入)；
+// 每筆的 hash 涵蓋前一筆的 hash，竄改或刪除任何一筆都會讓之後的鏈斷掉。
+// 稽核紀錄只附加不清除 (不放在 state.json，避免每次寫入都重寫整個檔案)。
+
+// auditFileName 稽核紀錄檔名稱
+const auditFileName = "audit.jsonl"
+
+// auditActorHeader API 請求指定操作人員的標頭 (-host 模式送出 $USER)
+const auditActorHeader = "X-Golane-Actor"
+
+// auditViaHeader API 請求指定介面的標頭 (-host 模式送出 cli)
+const auditViaHeader = "X-Golane-Via"
+
+// 稽核操作種類
+const (
+	AuditRouteSubscribe    = "route.subscribe"
+	AuditRouteUnsubscribe  = "route.unsubscribe"
+	AuditQuarantineAdd     = "quarantine.add"
+	AuditQuarantineRelease = "quarantine.release"
+	AuditFeatureSet        = "feature.set"
+	AuditFlowCreate        = "flow.create"
+	AuditFlowDelete        = "flow.delete"
+	AuditDeviceRename      = "device.rename"
+	AuditDeviceSampleRate  = "device.sample_rate"
+	AuditDeviceLatency     = "device.latency"
+	AuditChannelRename     = "channel.rename"
+	AuditFirmwareUpgrade   = "device.firmware"
+)
+
+// 變更經由的介面
+const (
+	AuditViaAPI     = "api"     // 管理 API 與 Web UI
+	AuditViaCLI     = "cli"     // 命令列 (本機或 -host)
+	AuditViaTrigger = "trigger" // OSC、GPIO 等觸發輸入
+)
+
+// auditSystemActor 沒有操作人員時的執行者名稱
+const auditSystemActor = "system"
+
+// errAuditChainBroken 稽核紀錄的 hash 鏈不連續
+var errAuditChainBroken = errors.New("audit chain broken")
+
+// AuditEntry 一筆變更
+type AuditEntry struct {
+	Seq       int64     `json:"seq"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`
+	Via       string    `json:"via,omitempty"` // 變更經由的介面 (AuditViaAPI, ...)
+	Operation string    `json:"operation"`
+	Domain    string    `json:"domain,omitempty"`
+	Device    string    `json:"device,omitempty"` // 路由為接收設備
+	Target    string    `json:"target,omitempty"` // 接收通道、功能名稱
+	Before    string    `json:"before,omitempty"` // 變更前的值 (空白表示沒有)
+	After     string    `json:"after,omitempty"`  // 變更後的值
+	Note      string    `json:"note,omitempty"`   // 原因或觸發來源
+	Error     string    `json:"error,omitempty"`  // 變更失敗的原因
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// computeHash 計算紀錄的 hash (涵蓋 Hash 以外的所有欄位，包含 PrevHash)
+func (e AuditEntry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditLog 稽核紀錄檔
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+	seq  int64
+	head string // 最後一筆的 hash
+}
+
+// OpenAuditLog 開啟狀態目錄下的稽核紀錄，從最後一筆接續序號與 hash 鏈
+func OpenAuditLog(dir string) (*AuditLog, error) {
+	l := &AuditLog{path: filepath.Join(dir, auditFileName)}
+	entries, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if n := len(entries); n > 0 {
+		l.seq = entries[n-1].Seq
+		l.head = entries[n-1].Hash
+	}
+	return l, nil
+}
+
+// read 讀取所有紀錄 (檔案不存在時回傳空白)
+func (l *AuditLog) read() ([]AuditEntry, error) {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log %s line %d: %v", l.path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return entries, nil
+}
+
+// Record 附加一筆變更 (Actor、Via 空白時取 ctx 的操作人員與介面)
+// 寫入失敗只記錄警告，不中斷已經完成的變更
+func (l *AuditLog) Record(ctx context.Context, e AuditEntry) {
+	if l == nil {
+		return
+	}
+	if e.Actor == "" {
+		e.Actor = auditActor(ctx)
+	}
+	if e.Via == "" {
+		e.Via = auditVia(ctx)
+	}
+	if err := l.append(e); err != nil {
+		logger.Warn("Failed to write audit log", "operation", e.Operation, "err", err)
+	}
+}
+
+// append 接上 hash 鏈後寫入檔案
+func (l *AuditLog) append(e AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = l.seq + 1
+	e.Time = time.Now().UTC()
+	e.PrevHash = l.head
+	e.Hash = e.computeHash()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	l.seq, l.head = e.Seq, e.Hash
+	return nil
+}
+
+// Entries 符合條件的紀錄 (依序號)
+func (l *AuditLog) Entries(filter AuditFilter) ([]AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	result := []AuditEntry{}
+	for _, e := range entries {
+		if filter.Match(e) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// AuditVerification hash 鏈驗證結果
+type AuditVerification struct {
+	Entries int    `json:"entries"`
+	Head    string `json:"head,omitempty"` // 最後一筆的 hash (可另外保存，事後比對整個檔案沒有被截斷)
+	Valid   bool   `json:"valid"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Verify 驗證整個紀錄檔：序號從 1 連續，每筆的 hash 正確並接上前一筆
+func (l *AuditLog) Verify() (AuditVerification, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, err := l.read()
+	if err != nil {
+		return AuditVerification{}, err
+	}
+	if len(entries) > 0 && (entries[0].Seq != 1 || entries[0].PrevHash != "") {
+		return verification(entries, fmt.Errorf("%w: log does not start at entry 1", errAuditChainBroken)), nil
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Seq != entries[i-1].Seq+1 {
+			return verification(entries, fmt.Errorf("%w: entry %d follows %d", errAuditChainBroken, entries[i].Seq, entries[i-1].Seq)), nil
+		}
+	}
+	return verification(entries, VerifyAuditEntries(entries)), nil
+}
+
+// verification 建立驗證結果
+func verification(entries []AuditEntry, err error) AuditVerification {
+	v := AuditVerification{Entries: len(entries), Valid: err == nil}
+	if n := len(entries); n > 0 {
+		v.Head = entries[n-1].Hash
+	}
+	if err != nil {
+		v.Error = err.Error()
+	}
+	return v
+}
+
+// VerifyAuditEntries 驗證每筆紀錄的 hash，序號連續的紀錄必須接上前一筆
+// 篩選過的匯出也能驗證：每筆仍可獨立驗證，連續的部分驗證鏈結
+func VerifyAuditEntries(entries []AuditEntry) error {
+	for i, e := range entries {
+		if e.computeHash() != e.Hash {
+			return fmt.Errorf("%w: entry %d has been modified", errAuditChainBroken, e.Seq)
+		}
+		if i > 0 && e.Seq == entries[i-1].Seq+1 && e.PrevHash != entries[i-1].Hash {
+			return fmt.Errorf("%w: entry %d does not follow entry %d", errAuditChainBroken, e.Seq, entries[i-1].Seq)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+// 篩選與匯出
+//------------------------------------------------------------------------------
+
+// AuditFilter 匯出條件 (空白欄位不篩選)
+type AuditFilter struct {
+	Since     time.Time
+	Until     time.Time
+	Actor     string
+	Via       string
+	Device    string
+	Operation string // 完整名稱或種類 (route 包含 route.subscribe 與 route.unsubscribe)
+}
+
+// Match 紀錄是否符合條件 (名稱不分大小寫)
+func (f AuditFilter) Match(e AuditEntry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if f.Actor != "" && !strings.EqualFold(e.Actor, f.Actor) {
+		return false
+	}
+	if f.Via != "" && !strings.EqualFold(e.Via, f.Via) {
+		return false
+	}
+	if f.Device != "" && !strings.EqualFold(e.Device, f.Device) {
+		return false
+	}
+	if f.Operation != "" && e.Operation != f.Operation && !strings.HasPrefix(e.Operation, f.Operation+".") {
+		return false
+	}
+	return true
+}
+
+// parseAuditTime 解析 RFC 3339 時間或相對於現在的期間 (24h 表示 24 小時前)
+func parseAuditTime(v string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339 or a duration such as 24h", v)
+}
+
+// auditColumns CSV 欄位 (via 在最後，舊的匯出欄位位置不變)
+var auditColumns = []string{"seq", "time", "actor", "operation", "domain", "device", "target", "before", "after", "note", "error", "prev_hash", "hash", "via"}
+
+// WriteAuditCSV 以 CSV 匯出 (保留 hash，可對照 JSON 匯出驗證)
+func WriteAuditCSV(w io.Writer, entries []AuditEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write(auditColumns)
+	for _, e := range entries {
+		cw.Write([]string{
+			strconv.FormatInt(e.Seq, 10), e.Time.Format(time.RFC3339Nano), e.Actor, e.Operation,
+			e.Domain, e.Device, e.Target, e.Before, e.After, e.Note, e.Error, e.PrevHash, e.Hash, e.Via,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//------------------------------------------------------------------------------
+// 操作人員
+//------------------------------------------------------------------------------
+
+// auditActorKey context 中的操作人員
+type auditActorKey struct{}
+
+// withAuditActor 標記這次操作的人員或來源 (已有時不覆蓋)
+func withAuditActor(ctx context.Context, actor string) context.Context {
+	if actor == "" || ctx.Value(auditActorKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// auditActor ctx 的操作人員，沒有時為 system
+func auditActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(auditActorKey{}).(string); ok {
+		return actor
+	}
+	return auditSystemActor
+}
+
+// auditViaKey context 中的介面
+type auditViaKey struct{}
+
+// withAuditVia 標記這次操作經由的介面 (已有時不覆蓋)
+func withAuditVia(ctx context.Context, via string) context.Context {
+	if via == "" || ctx.Value(auditViaKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, auditViaKey{}, via)
+}
+
+// auditVia ctx 的介面，沒有時為空白 (monitor 自己的動作)
+func auditVia(ctx context.Context) string {
+	via, _ := ctx.Value(auditViaKey{}).(string)
+	return via
+}
+
+// auditNoteKey context 中的變更原因
+type auditNoteKey struct{}
+
+// withAuditNote 標記這次操作的原因 (例如套用的 preset)
+func withAuditNote(ctx context.Context, note string) context.Context {
+	return context.WithValue(ctx, auditNoteKey{}, note)
+}
+
+// auditNote ctx 的變更原因
+func auditNote(ctx context.Context) string {
+	note, _ := ctx.Value(auditNoteKey{}).(string)
+	return note
+}
+
+// requestActor API 請求的操作人員：X-Golane-Actor 標頭，沒有時為權杖名稱或來源地址
+func requestActor(r *http.Request) string {
+	if actor := strings.TrimSpace(r.Header.Get(auditActorHeader)); actor != "" {
+		return actor
+	}
+	if name := tokenName(r); name != "" {
+		return "token:" + name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "api@" + host
+}
+
+// requestVia API 請求經由的介面：X-Golane-Via 標頭 (只接受 cli)，沒有時為 api
+func requestVia(r *http.Request) string {
+	if strings.EqualFold(strings.TrimSpace(r.Header.Get(auditViaHeader)), AuditViaCLI) {
+		return AuditViaCLI
+	}
+	return AuditViaAPI
+}
+
+// cliAuditContext 本機命令列的操作人員 ($USER) 與介面
+func cliAuditContext(ctx context.Context) context.Context {
+	actor := os.Getenv("USER")
+	if actor == "" {
+		actor = AuditViaCLI
+	}
+	return withAuditVia(withAuditActor(ctx, actor), AuditViaCLI)
+}
+
+//------------------------------------------------------------------------------
+// 路由變更
+//------------------------------------------------------------------------------
+
+// auditedRoutes 記錄每次訂閱變更的 RouteController (API 與 preset 共用)
+// 變更前先讀取接收設備目前的訂閱作為 Before，讀取失敗不影響變更
+type auditedRoutes struct {
+	RouteController
+	domain string
+	log    *AuditLog
+}
+
+// auditRoutes 以稽核紀錄包裝路由控制 (log 為 nil 時直接回傳 rc)
+func auditRoutes(log *AuditLog, domain string, rc RouteController) RouteController {
+	if log == nil {
+		return rc
+	}
+	return auditedRoutes{RouteController: rc, domain: domain, log: log}
+}
+
+// Unwrap 原本的路由控制 (preset 驗證需要設備列表)
+func (a auditedRoutes) Unwrap() RouteController {
+	return a.RouteController
+}
+
+func (a auditedRoutes) Subscribe(ctx context.Context, rxDevice, rxChannel, txDevice, txChannel string) error {
+	entry := AuditEntry{Operation: AuditRouteSubscribe, Domain: a.domain, Device: rxDevice, Target: rxChannel}
+	if txDevice == "" {
+		entry.Operation = AuditRouteUnsubscribe
+	} else {
+		entry.After = txChannel + "@" + txDevice
+	}
+	if subs, err := a.ListSubscriptions(ctx, rxDevice); err == nil {
+		for _, sub := range subs {
+			if sub.Channel == rxChannel && sub.Subscribed() {
+				entry.Before = sub.TxChannel + "@" + sub.TxDevice
+			}
+		}
+	}
+	entry.Note = auditNote(ctx)
+	if routeOverride(ctx) {
+		entry.Note = strings.TrimPrefix(entry.Note+", quarantine override", ", ")
+	}
+
+	err := a.RouteController.Subscribe(ctx, rxDevice, rxChannel, txDevice, txChannel)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.log.Record(ctx, entry)
+	return err
+}
+
+var _ RouteController = auditedRoutes{}
+
+// auditedFlows 記錄建立與刪除 multicast flow 的 FlowController
+type auditedFlows struct {
+	FlowController
+	domain string
+	log    *AuditLog
+}
+
+// auditFlows 以稽核紀錄包裝 flow 操作 (log 為 nil 時不包裝)
+func auditFlows(log *AuditLog, domain string, fc FlowController) FlowController {
+	if log == nil {
+		return fc
+	}
+	return auditedFlows{FlowController: fc, domain: domain, log: log}
+}
+
+func (a auditedFlows) CreateMulticastFlow(ctx context.Context, device string, config dante.FlowConfig) (int, error) {
+	entry := AuditEntry{Operation: AuditFlowCreate, Domain: a.domain, Device: device,
+		After: flowAuditValue(config), Note: auditNote(ctx)}
+	id, err := a.FlowController.CreateMulticastFlow(ctx, device, config)
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Target = strconv.Itoa(id)
+	}
+	a.log.Record(ctx, entry)
+	return id, err
+}
+
+func (a auditedFlows) DeleteTxFlow(ctx context.Context, device string, flowID int) error {
+	entry := AuditEntry{Operation: AuditFlowDelete, Domain: a.domain, Device: device,
+		Target: strconv.Itoa(flowID), Note: auditNote(ctx)}
+	if flows, err := a.TxFlows(ctx, device); err == nil {
+		for _, f := range flows {
+			if f.ID == flowID {
+				entry.Before = flowAuditValue(dante.FlowConfig{Name: f.Name, Channels: f.Channels, Address: f.Address, Port: f.Port})
+			}
+		}
+	}
+	err := a.FlowController.DeleteTxFlow(ctx, device, flowID)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.log.Record(ctx, entry)
+	return err
+}
+
+// flowAuditValue flow 在稽核紀錄的內容 (名稱、通道與地址)
+func flowAuditValue(c dante.FlowConfig) string {
+	channels := make([]string, len(c.Channels))
+	for i, ch := range c.Channels {
+		channels[i] = strconv.Itoa(ch)
+	}
+	value := "channels " + strings.Join(channels, ",")
+	if c.Name != "" {
+		value = c.Name + ": " + value
+	}
+	if c.Address != "" {
+		value += fmt.Sprintf(" -> %s:%d", c.Address, c.Port)
+	}
+	return value
+}
+
+var _ FlowController = auditedFlows{}
+
+// auditedSettings 記錄設備改名、通道標籤、取樣率與延遲變更的 SettingsController
+// (訂閱經由 auditedRoutes 記錄)
+type auditedSettings struct {
+	SettingsController
+	routes RouteController
+	domain string
+	log    *AuditLog
+}
+
+// auditSettings 以稽核紀錄包裝設備設定 (log 為 nil 時不包裝)
+func auditSettings(log *AuditLog, domain string, sc SettingsController) SettingsController {
+	if log == nil {
+		return sc
+	}
+	return auditedSettings{SettingsController: sc, routes: auditRoutes(log, domain, sc), domain: domain, log: log}
+}
+
+// record 執行變更並記錄結果
+func (a auditedSettings) record(ctx context.Context, entry AuditEntry, change func() error) error {
+	entry.Domain = a.domain
+	entry.Note = auditNote(ctx)
+	err := change()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.log.Record(ctx, entry)
+	return err
+}
+
+func (a auditedSettings) Subscribe(ctx context.Context, rxDevice, rxChannel, txDevice, txChannel string) error {
+	return a.routes.Subscribe(ctx, rxDevice, rxChannel, txDevice, txChannel)
+}
+
+func (a auditedSettings) RenameDevice(ctx context.Context, device, newName string) error {
+	return a.record(ctx, AuditEntry{Operation: AuditDeviceRename, Device: device, Before: device, After: newName}, func() error {
+		return a.SettingsController.RenameDevice(ctx, device, newName)
+	})
+}
+
+func (a auditedSettings) SetTxChannelName(ctx context.Context, device string, channelID int, name string) error {
+	entry := AuditEntry{Operation: AuditChannelRename, Device: device, Target: "tx " + strconv.Itoa(channelID), After: name}
+	if channels, err := a.TxChannels(ctx, device); err == nil {
+		for _, ch := range channels {
+			if ch.ID == channelID {
+				entry.Before = ch.Name
+			}
+		}
+	}
+	return a.record(ctx, entry, func() error {
+		return a.SettingsController.SetTxChannelName(ctx, device, channelID, name)
+	})
+}
+
+func (a auditedSettings) SetRxChannelName(ctx context.Context, device string, channelID int, name string) error {
+	entry := AuditEntry{Operation: AuditChannelRename, Device: device, Target: "rx " + strconv.Itoa(channelID), After: name}
+	if subs, err := a.ListSubscriptions(ctx, device); err == nil {
+		for _, sub := range subs {
+			if sub.ChannelID == channelID {
+				entry.Before = sub.Channel
+			}
+		}
+	}
+	return a.record(ctx, entry, func() error {
+		return a.SettingsController.SetRxChannelName(ctx, device, channelID, name)
+	})
+}
+
+func (a auditedSettings) SetLatency(ctx context.Context, device string, latencyUs int) error {
+	entry := AuditEntry{Operation: AuditDeviceLatency, Device: device, After: fmt.Sprintf("%d µs", latencyUs)}
+	if settings, err := a.DeviceSettings(ctx, device); err == nil && settings.LatencyUs > 0 {
+		entry.Before = fmt.Sprintf("%d µs", settings.LatencyUs)
+	}
+	return a.record(ctx, entry, func() error {
+		return a.SettingsController.SetLatency(ctx, device, latencyUs)
+	})
+}
+
+func (a auditedSettings) SetSampleRate(ctx context.Context, device string, sampleRate int) error {
+	entry := AuditEntry{Operation: AuditDeviceSampleRate, Device: device, After: fmt.Sprintf("%d Hz", sampleRate)}
+	if settings, err := a.DeviceSettings(ctx, device); err == nil && settings.SampleRate > 0 {
+		entry.Before = fmt.Sprintf("%d Hz", settings.SampleRate)
+	}
+	return a.record(ctx, entry, func() error {
+		return a.SettingsController.SetSampleRate(ctx, device, sampleRate)
+	})
+}
+
+var _ SettingsController = auditedSettings{}
+
+// auditedUpgrades 記錄韌體升級請求的 FirmwareUpgrades (結果由 TopicFirmware 事件通知)
+type auditedUpgrades struct {
+	FirmwareUpgrades
+	domain string
+	log    *AuditLog
+}
+
+// auditUpgrades 以稽核紀錄包裝韌體升級 (log 為 nil 時不包裝)
+func auditUpgrades(log *AuditLog, domain string, fu FirmwareUpgrades) FirmwareUpgrades {
+	if log == nil {
+		return fu
+	}
+	return auditedUpgrades{FirmwareUpgrades: fu, domain: domain, log: log}
+}
+
+func (a auditedUpgrades) StartUpgrade(ctx context.Context, device string, src dante.FirmwareSource) (FirmwareUpgrade, error) {
+	entry := AuditEntry{Operation: AuditFirmwareUpgrade, Domain: a.domain, Device: device,
+		After: src.String(), Note: auditNote(ctx)}
+	up, err := a.FirmwareUpgrades.StartUpgrade(ctx, device, src)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.log.Record(ctx, entry)
+	return up, err
+}
+
+var _ FirmwareUpgrades = auditedUpgrades{}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// parseAuditFilter 解析匯出條件 (since/until 可以是 RFC 3339 或期間)
+func parseAuditFilter(q url.Values) (AuditFilter, error) {
+	filter := AuditFilter{
+		Actor:     q.Get("actor"),
+		Via:       q.Get("via"),
+		Device:    q.Get("device"),
+		Operation: q.Get("operation"),
+	}
+	now := time.Now()
+	for key, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(key); v != "" {
+			parsed, err := parseAuditTime(v, now)
+			if err != nil {
+				return AuditFilter{}, fmt.Errorf("%s: %v", key, err)
+			}
+			*t = parsed
+		}
+	}
+	return filter, nil
+}
+
+// auditFilterQuery 把匯出條件編碼成 parseAuditFilter 的參數
+func auditFilterQuery(filter AuditFilter) url.Values {
+	q := url.Values{}
+	for key, value := range map[string]string{
+		"actor":     filter.Actor,
+		"via":       filter.Via,
+		"device":    filter.Device,
+		"operation": filter.Operation,
+	} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	for key, t := range map[string]time.Time{"since": filter.Since, "until": filter.Until} {
+		if !t.IsZero() {
+			q.Set(key, t.UTC().Format(time.RFC3339))
+		}
+	}
+	return q
+}
+
+// handleAudit GET /api/audit[?since=&until=&actor=&via=&device=&operation=&format=csv]
+func (s *APIServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q, use json or csv", format))
+		return
+	}
+	entries, err := s.audit.Entries(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+		WriteAuditCSV(w, entries)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *APIServer) handleVerifyAudit(w http.ResponseWriter, r *http.Request) {
+	result, err := s.audit.Verify()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
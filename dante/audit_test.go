@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+func TestAuditRoutesRecordsPresetChanges(t *testing.T) {
+	dir := t.TempDir()
+	audit, err := OpenAuditLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, d := newTriggerEngine(t, false)
+	e.routes = map[string]RouteController{d.Name: auditRoutes(audit, d.Name, d)}
+
+	if _, err := e.Fire(context.Background(), "cam1", "osc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Recall(context.Background(), "Cam2", "gpio", false); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := audit.Entries(AuditFilter{Device: "amp-left", Operation: "route"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("got %d route entries, want 4: %+v", len(entries), entries)
+	}
+	first, last := entries[0], entries[3]
+	if first.Actor != "osc" || first.Via != AuditViaTrigger || first.Note != "preset Cam1" || first.After != "01@FOH-Console" {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+	if last.Operation != AuditRouteUnsubscribe || last.Actor != "gpio" || last.Before != "02@FOH-Console" || last.After != "" {
+		t.Fatalf("unexpected unsubscribe entry: %+v", last)
+	}
+
+	// 重新開啟後接續 hash 鏈
+	audit, err = OpenAuditLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.Record(withAuditActor(context.Background(), "a1"), AuditEntry{Operation: AuditFeatureSet, Target: FeatureTriggers, Before: "true", After: "false"})
+	result, err := audit.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Entries != 5 {
+		t.Fatalf("verify = %+v", result)
+	}
+
+	// 篩選過的匯出仍可驗證
+	features, _ := audit.Entries(AuditFilter{Actor: "A1", Since: time.Now().Add(-time.Minute)})
+	if len(features) != 1 || VerifyAuditEntries(features) != nil {
+		t.Fatalf("filtered export = %+v", features)
+	}
+	var buf bytes.Buffer
+	if err := WriteAuditCSV(&buf, features); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 2 || rows[1][3] != AuditFeatureSet || rows[1][12] != features[0].Hash {
+		t.Fatalf("csv export = %v, %v", rows, err)
+	}
+}
+
+func TestAuditVerifyDetectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	audit, err := OpenAuditLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, device := range []string{"amp-1", "amp-2", "amp-3"} {
+		audit.Record(context.Background(), AuditEntry{Operation: AuditQuarantineAdd, Device: device, After: "quarantined"})
+	}
+
+	path := filepath.Join(dir, auditFileName)
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(original), "\n")
+
+	for name, tampered := range map[string]string{
+		"modified":  strings.Replace(string(original), "amp-2", "amp-9", 1),
+		"removed":   lines[0] + lines[2],
+		"truncated": lines[1] + lines[2],
+	} {
+		if err := os.WriteFile(path, []byte(tampered), 0644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := audit.Verify()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid || !strings.Contains(result.Error, errAuditChainBroken.Error()) {
+			t.Fatalf("%s log verified: %+v", name, result)
+		}
+	}
+
+	// 直接驗證匯出的紀錄 (audit verify -file)
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := audit.Entries(AuditFilter{})
+	entries[1].Actor = "someone-else"
+	if err := VerifyAuditEntries(entries); !errors.Is(err, errAuditChainBroken) {
+		t.Fatalf("modified export verified: %v", err)
+	}
+}
+
+func TestAuditSettingsRecordsChanges(t *testing.T) {
+	ctx := withAuditVia(withAuditActor(context.Background(), "ops"), AuditViaCLI)
+	audit, err := OpenAuditLog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newSnapshotDomain(t, dante.DefaultSimulationConfig())
+	sc := auditSettings(audit, d.Name, d)
+	for _, err := range []error{
+		sc.SetTxChannelName(ctx, "Stage-Box-A", 1, "Vocal"),
+		sc.SetLatency(ctx, "Amp-Left", 2000),
+		sc.RenameDevice(ctx, "Amp-Left", "Amp-L"),
+		sc.Subscribe(ctx, "Amp-L", "01", "Stage-Box-A", "Vocal"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sc.SetSampleRate(ctx, "Missing", 48000)
+
+	entries, err := audit.Entries(AuditFilter{Via: "CLI"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries: %+v", len(entries), entries)
+	}
+	label, latency, rename := entries[0], entries[1], entries[2]
+	if label.Operation != AuditChannelRename || label.Target != "tx 1" || label.Before == "" || label.After != "Vocal" {
+		t.Errorf("label: %+v", label)
+	}
+	if latency.Operation != AuditDeviceLatency || latency.Before == "" || latency.After != "2000 µs" {
+		t.Errorf("latency: %+v", latency)
+	}
+	if rename.Operation != AuditDeviceRename || rename.Before != "Amp-Left" || rename.After != "Amp-L" || rename.Actor != "ops" {
+		t.Errorf("rename: %+v", rename)
+	}
+	if entries[3].Operation != AuditRouteSubscribe || entries[4].Error == "" {
+		t.Errorf("route / failed change: %+v %+v", entries[3], entries[4])
+	}
+}
+
+func TestParseAuditFilter(t *testing.T) {
+	now := time.Now()
+	filter := AuditFilter{Actor: "a1", Via: AuditViaCLI, Device: "Amp-Left", Operation: "route", Since: now.Add(-time.Hour).Truncate(time.Second)}
+	parsed, err := parseAuditFilter(auditFilterQuery(filter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Actor != "a1" || parsed.Via != AuditViaCLI || parsed.Device != "Amp-Left" || parsed.Operation != "route" || !parsed.Since.Equal(filter.Since) || !parsed.Until.IsZero() {
+		t.Fatalf("round trip = %+v", parsed)
+	}
+	if _, err := parseAuditTime("yesterday", now); err == nil {
+		t.Fatal("invalid time accepted")
+	}
+	entry := AuditEntry{Operation: AuditRouteSubscribe, Time: now}
+	if !(AuditFilter{Operation: "route"}).Match(entry) || (AuditFilter{Operation: "rout"}).Match(entry) {
+		t.Fatal("operation kind filter")
+	}
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 頻寬估算
+//==============================================================================
+
+// 100 Mbps 的設備 (常見於小型 Ultimo 與接錯埠的設備) 大約只能承載每個方向
+// 四、五十個 48 kHz 通道，訂閱再多就會掉音。以拓撲的訂閱與發送設備的
+// multicast flow 估算每台設備收發的 flow 與頻寬，並與 link_speed 比較：
+//
+//   - unicast：每一對發送/接收設備依 UnicastFlowChannels 分成多個 flow，
+//     發送端與接收端都計入
+//   - multicast：發送端的每個 flow 只計一次，接收端依訂閱的通道計入
+//   - 每個 flow 每個封包加上 Ethernet/IP/UDP/音訊標頭 (flowOverheadBytes)
+//
+// 備援網路承載相同的流量，secondary link 以同樣的頻寬比較。
+// 估算不包含 PTP、控制訊息與沒有 IGMP snooping 時氾濫的 multicast。
+
+// flowOverheadBytes 每個封包的固定開銷：preamble 與 IFG 20、Ethernet 18、IP 20、UDP 8、音訊標頭 12
+const flowOverheadBytes = 78
+
+// 預設的 flow 通道數 (Dante unicast flow 為 4，multicast flow 通常最多 8)
+const (
+	defaultUnicastFlowChannels   = 4
+	defaultMulticastFlowChannels = 8
+)
+
+// BandwidthOptions 估算參數
+type BandwidthOptions struct {
+	SampleRate            int     `json:"sample_rate"`             // 沒有 flow 資訊時的取樣率
+	BitDepth              int     `json:"bit_depth"`               // 每個取樣的位元數
+	PacketTimeUs          int     `json:"packet_time_us"`          // 沒有 flow 資訊時的 packet time
+	UnicastFlowChannels   int     `json:"unicast_flow_channels"`   // 每個 unicast flow 的通道數
+	MulticastFlowChannels int     `json:"multicast_flow_channels"` // 沒有 flow 資訊時每個 multicast flow 的通道數
+	Threshold             float64 `json:"threshold"`               // link 利用率超過此值時警告
+}
+
+// DefaultBandwidthOptions 48 kHz / 24 bit / 1 ms，利用率 70% 警告
+func DefaultBandwidthOptions() BandwidthOptions {
+	return BandwidthOptions{
+		SampleRate:            48000,
+		BitDepth:              24,
+		PacketTimeUs:          1000,
+		UnicastFlowChannels:   defaultUnicastFlowChannels,
+		MulticastFlowChannels: defaultMulticastFlowChannels,
+		Threshold:             0.7,
+	}
+}
+
+// Validate 檢查參數
+func (o BandwidthOptions) Validate() error {
+	switch {
+	case o.SampleRate <= 0:
+		return fmt.Errorf("invalid sample rate %d", o.SampleRate)
+	case o.BitDepth <= 0:
+		return fmt.Errorf("invalid bit depth %d", o.BitDepth)
+	case o.PacketTimeUs <= 0:
+		return fmt.Errorf("invalid packet time %d µs", o.PacketTimeUs)
+	case o.UnicastFlowChannels <= 0 || o.MulticastFlowChannels <= 0:
+		return errors.New("flow channel counts must be positive")
+	case o.Threshold <= 0 || o.Threshold > 1:
+		return fmt.Errorf("threshold %g must be between 0 and 1", o.Threshold)
+	}
+	return nil
+}
+
+// BandwidthReport 估算結果
+type BandwidthReport struct {
+	Generated time.Time         `json:"generated"`
+	Options   BandwidthOptions  `json:"options"`
+	Devices   []DeviceBandwidth `json:"devices"`
+	Warnings  []string          `json:"warnings,omitempty"` // 可能飽和的 link
+	Errors    []string          `json:"errors,omitempty"`   // 無法讀取訂閱或 flow 的設備 (估算偏低)
+}
+
+// DeviceBandwidth 單一設備收發的估算
+type DeviceBandwidth struct {
+	Domain     string          `json:"domain"`
+	Device     string          `json:"device"`
+	TxChannels int             `json:"tx_channels"` // 送出的音訊通道 (multicast 通道只計一次)
+	RxChannels int             `json:"rx_channels"`
+	TxFlows    int             `json:"tx_flows"`
+	RxFlows    int             `json:"rx_flows"`
+	TxMbps     float64         `json:"tx_mbps"`
+	RxMbps     float64         `json:"rx_mbps"`
+	Links      []LinkBandwidth `json:"links,omitempty"` // 沒有 link_speed 時為空
+}
+
+// LinkBandwidth 設備的一條網路連線 (全雙工，取較忙的方向)
+type LinkBandwidth struct {
+	Network     string  `json:"network"` // primary 或 secondary
+	SpeedMbps   int     `json:"speed_mbps"`
+	Utilization float64 `json:"utilization"`
+	Saturated   bool    `json:"saturated,omitempty"` // 超過 Threshold
+}
+
+// flowRate flow 的格式 (取樣率與每個封包的 frame 數)
+type flowRate struct {
+	sampleRate int
+	fpp        int
+}
+
+// mbps 一個 flow 的頻寬
+func (r flowRate) mbps(channels, bitDepth int) float64 {
+	packets := float64(r.sampleRate) / float64(r.fpp)
+	bytesPerPacket := float64(r.fpp*channels*bitDepth)/8 + flowOverheadBytes
+	return packets * bytesPerPacket * 8 / 1e6
+}
+
+// bandwidthFlow 估算用的 flow
+type bandwidthFlow struct {
+	rate     flowRate
+	channels int
+}
+
+// deviceTraffic 累計中的設備流量
+type deviceTraffic struct {
+	tx, rx []bandwidthFlow
+}
+
+// EstimateBandwidth 依拓撲估算各設備的頻寬 (flows 讀取有 multicast 訂閱的發送設備，nil 時以預設值估算)
+func EstimateBandwidth(ctx context.Context, topo Topology, flows map[string]FlowController, opts BandwidthOptions) BandwidthReport {
+	report := BandwidthReport{Generated: time.Now().UTC(), Options: opts, Devices: []DeviceBandwidth{}}
+	defaultRate := flowRate{sampleRate: opts.SampleRate, fpp: max(1, opts.PacketTimeUs*opts.SampleRate/1_000_000)}
+
+	for _, domain := range topo.Domains {
+		report.Errors = append(report.Errors, prefixed(domain.Name, domain.Errors)...)
+		traffic := make(map[string]*deviceTraffic, len(domain.Devices))
+		for _, dev := range domain.Devices {
+			traffic[dev.Name] = &deviceTraffic{}
+		}
+
+		// 依發送/接收設備分組 (依出現順序，讓結果固定)
+		type pair struct{ tx, rx string }
+		var order []pair
+		unicast := make(map[pair]int)
+		multicast := make(map[pair]int)
+		multicastTx := make(map[string]map[string]bool) // 發送設備 → 以 multicast 送出的通道
+		for _, route := range domain.Routes {
+			p := pair{route.TxDevice, route.RxDevice}
+			if traffic[p.tx] == nil || traffic[p.rx] == nil {
+				continue // 發送設備不在這個網域 (訂閱無法解析)
+			}
+			if unicast[p] == 0 && multicast[p] == 0 {
+				order = append(order, p)
+			}
+			if route.Multicast {
+				multicast[p]++
+				if multicastTx[p.tx] == nil {
+					multicastTx[p.tx] = make(map[string]bool)
+				}
+				multicastTx[p.tx][route.TxChannel] = true
+			} else {
+				unicast[p]++
+			}
+		}
+
+		// 發送設備的 multicast flow：有 flow 資訊時使用實際的通道數與格式
+		rates := make(map[string]flowRate)
+		for _, dev := range domain.Devices {
+			if len(multicastTx[dev.Name]) == 0 {
+				continue
+			}
+			list, err := readMulticastFlows(ctx, flows[domain.Name], dev.Name)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %s: %v", domain.Name, dev.Name, err))
+			}
+			if len(list) == 0 {
+				// 沒有 flow 資訊：依 multicast 送出的通道數估算
+				n := len(multicastTx[dev.Name])
+				traffic[dev.Name].tx = append(traffic[dev.Name].tx, splitFlows(defaultRate, n, opts.MulticastFlowChannels)...)
+				continue
+			}
+			for _, f := range list {
+				rate := defaultRate
+				if f.SampleRate > 0 && f.FramesPerPacket > 0 {
+					rate = flowRate{sampleRate: f.SampleRate, fpp: f.FramesPerPacket}
+				}
+				rates[dev.Name] = rate
+				channels := 0
+				for _, ch := range f.Channels {
+					if ch > 0 {
+						channels++
+					}
+				}
+				traffic[dev.Name].tx = append(traffic[dev.Name].tx, bandwidthFlow{rate: rate, channels: channels})
+			}
+		}
+
+		for _, p := range order {
+			if n := unicast[p]; n > 0 {
+				split := splitFlows(defaultRate, n, opts.UnicastFlowChannels)
+				traffic[p.tx].tx = append(traffic[p.tx].tx, split...)
+				traffic[p.rx].rx = append(traffic[p.rx].rx, split...)
+			}
+			if n := multicast[p]; n > 0 {
+				rate, ok := rates[p.tx]
+				if !ok {
+					rate = defaultRate
+				}
+				traffic[p.rx].rx = append(traffic[p.rx].rx, splitFlows(rate, n, opts.MulticastFlowChannels)...)
+			}
+		}
+
+		for _, dev := range domain.Devices {
+			t := traffic[dev.Name]
+			if len(t.tx) == 0 && len(t.rx) == 0 {
+				continue
+			}
+			db := DeviceBandwidth{Domain: domain.Name, Device: dev.Name, TxFlows: len(t.tx), RxFlows: len(t.rx)}
+			for _, f := range t.tx {
+				db.TxChannels += f.channels
+				db.TxMbps += f.rate.mbps(f.channels, opts.BitDepth)
+			}
+			for _, f := range t.rx {
+				db.RxChannels += f.channels
+				db.RxMbps += f.rate.mbps(f.channels, opts.BitDepth)
+			}
+			db.TxMbps, db.RxMbps = roundMbps(db.TxMbps), roundMbps(db.RxMbps)
+
+			busiest := max(db.TxMbps, db.RxMbps)
+			speeds := map[string]int{"primary": dev.LinkSpeed}
+			if dev.SecondaryIP != "" {
+				speeds["secondary"] = dev.SecondarySpeed
+			}
+			for _, network := range []string{"primary", "secondary"} {
+				speed := speeds[network]
+				if speed <= 0 {
+					continue
+				}
+				link := LinkBandwidth{Network: network, SpeedMbps: speed,
+					Utilization: math.Round(busiest/float64(speed)*1000) / 1000}
+				if link.Utilization > opts.Threshold {
+					link.Saturated = true
+					report.Warnings = append(report.Warnings, fmt.Sprintf(
+						"%s: %s %s link (%d Mbps) carries an estimated %.1f Mbps (%.0f%%), audio may drop out",
+						domain.Name, dev.Name, network, speed, busiest, link.Utilization*100))
+				}
+				db.Links = append(db.Links, link)
+			}
+			report.Devices = append(report.Devices, db)
+		}
+	}
+	return report
+}
+
+// readMulticastFlows 設備的 multicast flow (fc 為 nil 時沒有 flow 資訊)
+func readMulticastFlows(ctx context.Context, fc FlowController, device string) ([]dante.Flow, error) {
+	if fc == nil {
+		return nil, nil
+	}
+	list, err := fc.TxFlows(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(list, func(f dante.Flow) bool { return !f.Multicast }), nil
+}
+
+// splitFlows 把 channels 個通道分成每個最多 perFlow 個通道的 flow
+func splitFlows(rate flowRate, channels, perFlow int) []bandwidthFlow {
+	var out []bandwidthFlow
+	for channels > 0 {
+		n := min(channels, perFlow)
+		out = append(out, bandwidthFlow{rate: rate, channels: n})
+		channels -= n
+	}
+	return out
+}
+
+// roundMbps 四捨五入到 0.01 Mbps
+func roundMbps(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// prefixed 在每個訊息前加上網域名稱
+func prefixed(domain string, messages []string) []string {
+	out := make([]string, len(messages))
+	for i, m := range messages {
+		out[i] = domain + ": " + m
+	}
+	return out
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// bandwidthQuery 以查詢參數覆寫預設的估算參數 (sample_rate、threshold)
+func bandwidthQuery(q url.Values) (BandwidthOptions, error) {
+	opts := DefaultBandwidthOptions()
+	if v := q.Get("sample_rate"); v != "" {
+		rate, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid sample_rate %q", v)
+		}
+		opts.SampleRate = rate
+	}
+	if v := q.Get("threshold"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid threshold %q", v)
+		}
+		opts.Threshold = threshold
+	}
+	return opts, opts.Validate()
+}
+
+// handleBandwidth GET /api/bandwidth[?sample_rate=&threshold=]
+func (s *APIServer) handleBandwidth(w http.ResponseWriter, r *http.Request) {
+	opts, err := bandwidthQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, EstimateBandwidth(r.Context(), s.topology(r.Context()), s.flows, opts))
+}
+
+// Bandwidth daemon 估算的頻寬
+func (c *RemoteClient) Bandwidth(opts BandwidthOptions) (BandwidthReport, error) {
+	q := url.Values{}
+	q.Set("sample_rate", strconv.Itoa(opts.SampleRate))
+	q.Set("threshold", strconv.FormatFloat(opts.Threshold, 'g', -1, 64))
+	var report BandwidthReport
+	return report, c.do(http.MethodGet, "/api/bandwidth?"+q.Encode(), nil, &report)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newBandwidthCommand golane bandwidth
+func newBandwidthCommand() *Command {
+	fs := newFlagSet("bandwidth")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	opts := DefaultBandwidthOptions()
+	fs.IntVar(&opts.SampleRate, "sample-rate", opts.SampleRate, "sample rate assumed for flows without format information")
+	fs.Float64Var(&opts.Threshold, "threshold", opts.Threshold, "warn when a link is busier than this fraction of its speed")
+	jsonOut := fs.Bool("json", false, "print the estimate as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "bandwidth",
+		Short: "Estimate per-device and per-link audio bandwidth and warn about saturated links",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			var report BandwidthReport
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if report, err = client.Bandwidth(opts); err != nil {
+					return err
+				}
+			} else {
+				if err := ifaces.checkTiming(*wait); err != nil {
+					return err
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+				domain, err := ifaces.openPrimaryDomain(ctx, detector)
+				if err != nil {
+					return err
+				}
+				defer domain.Cleanup()
+				if err := discover(ctx, domain, *wait); err != nil {
+					return err
+				}
+				topo := BuildTopology(ctx, nil, []topologySource{{
+					Name:      domain.Name,
+					Interface: domain.NetworkConfig.InterfaceName,
+					IPAddress: domain.NetworkConfig.IPAddress,
+					Devices:   domain.GetDevices(),
+					Routes:    domain,
+				}})
+				report = EstimateBandwidth(ctx, topo, map[string]FlowController{domain.Name: domain}, opts)
+			}
+
+			for _, e := range report.Errors {
+				logger.Warn("Bandwidth estimate incomplete", "err", e)
+			}
+			if *jsonOut {
+				return printJSON(report)
+			}
+			printBandwidth(report)
+			return nil
+		},
+	}
+}
+
+// printBandwidth 印出估算結果
+func printBandwidth(report BandwidthReport) {
+	fmt.Print(i18n.Sprintf("\n=== Estimated bandwidth (%d Hz, %d bit, %.0f%% warning) ===\n",
+		report.Options.SampleRate, report.Options.BitDepth, report.Options.Threshold*100))
+	printHeader("%-10s %-20s %-11s %-11s %-10s %-10s %s\n", "DOMAIN", "DEVICE", "TX CH/FLOW", "RX CH/FLOW", "TX MBPS", "RX MBPS", "LINKS")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────────")
+	for _, d := range report.Devices {
+		links := "-"
+		for i, l := range d.Links {
+			text := fmt.Sprintf("%s %d Mbps %.0f%%", l.Network, l.SpeedMbps, l.Utilization*100)
+			if l.Saturated {
+				text += " !"
+			}
+			if i == 0 {
+				links = text
+			} else {
+				links += ", " + text
+			}
+		}
+		fmt.Printf("%-10s %-20s %-11s %-11s %-10.1f %-10.1f %s\n", d.Domain, d.Device,
+			fmt.Sprintf("%d/%d", d.TxChannels, d.TxFlows), fmt.Sprintf("%d/%d", d.RxChannels, d.RxFlows),
+			d.TxMbps, d.RxMbps, links)
+	}
+	fmt.Println()
+	for _, w := range report.Warnings {
+		fmt.Fprint(os.Stderr, i18n.Sprintf("WARNING: %s\n", w))
+	}
+}
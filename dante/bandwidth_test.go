@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestEstimateBandwidth(t *testing.T) {
+	topo := Topology{Domains: []TopologyDomain{{
+		Name: "Dante1",
+		Devices: []TopologyDevice{
+			{Device: dante.Device{Name: "console", LinkSpeed: 1000, SecondaryIP: "192.168.200.10", SecondarySpeed: 1000}},
+			{Device: dante.Device{Name: "stagebox", LinkSpeed: 100}},
+			{Device: dante.Device{Name: "amp", LinkSpeed: 100}},
+		},
+	}}}
+	route := func(tx, txChannel, rx string, rxChannel int, multicast bool) TopologyRoute {
+		return TopologyRoute{TxDevice: tx, TxChannel: txChannel, RxDevice: rx, RxChannel: fmt.Sprint(rxChannel), Multicast: multicast}
+	}
+	// console → stagebox 64 通道 unicast：16 個 flow，100 Mbps 接近飽和
+	for ch := 1; ch <= 64; ch++ {
+		topo.Domains[0].Routes = append(topo.Domains[0].Routes, route("console", fmt.Sprint(ch), "stagebox", ch, false))
+	}
+	// console → amp 2 通道 multicast，另有一個不在網域的發送設備
+	topo.Domains[0].Routes = append(topo.Domains[0].Routes,
+		route("console", "1", "amp", 1, true), route("console", "2", "amp", 2, true), route("elsewhere", "1", "amp", 3, false))
+
+	report := EstimateBandwidth(context.Background(), topo, nil, DefaultBandwidthOptions())
+	if len(report.Devices) != 3 {
+		t.Fatalf("devices = %+v", report.Devices)
+	}
+	console, stagebox, amp := report.Devices[0], report.Devices[1], report.Devices[2]
+
+	// 4 通道 flow：48 frames × 4 × 3 bytes + 78 bytes 開銷，每秒 1000 個封包 = 5.232 Mbps
+	if stagebox.RxFlows != 16 || stagebox.RxChannels != 64 || stagebox.RxMbps != 83.71 {
+		t.Fatalf("stagebox = %+v", stagebox)
+	}
+	if len(stagebox.Links) != 1 || !stagebox.Links[0].Saturated || stagebox.Links[0].Utilization != 0.837 {
+		t.Fatalf("stagebox links = %+v", stagebox.Links)
+	}
+	// multicast 在發送端只計一個 flow
+	if console.TxFlows != 17 || console.TxChannels != 66 || len(console.Links) != 2 || console.Links[1].Saturated {
+		t.Fatalf("console = %+v", console)
+	}
+	if amp.RxFlows != 1 || amp.RxChannels != 2 || amp.Links[0].Saturated {
+		t.Fatalf("amp = %+v", amp)
+	}
+	if len(report.Warnings) != 1 {
+		t.Fatalf("warnings = %q", report.Warnings)
+	}
+
+	// 門檻提高後不再警告
+	opts := DefaultBandwidthOptions()
+	opts.Threshold = 0.9
+	if report := EstimateBandwidth(context.Background(), topo, nil, opts); len(report.Warnings) != 0 {
+		t.Fatalf("warnings at 90%% = %q", report.Warnings)
+	}
+}
+
+func TestEstimateBandwidthUsesMulticastFlows(t *testing.T) {
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(ctx)
+
+	// 8 通道的 multicast flow，兩台功放各訂閱其中兩個通道
+	if _, err := d.CreateMulticastFlow(ctx, "FOH-Console", dante.FlowConfig{Channels: []int{1, 2, 3, 4, 5, 6, 7, 8}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, amp := range []string{"Amp-Left", "Amp-Right"} {
+		for _, ch := range []string{"01", "02"} {
+			if err := d.Subscribe(ctx, amp, ch, "FOH-Console", ch); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	topo := BuildTopology(ctx, nil, []topologySource{{Name: d.Name, Devices: d.GetDevices(), Routes: d}})
+	report := EstimateBandwidth(ctx, topo, map[string]FlowController{d.Name: d}, DefaultBandwidthOptions())
+
+	for _, dev := range report.Devices {
+		switch dev.Device {
+		case "FOH-Console":
+			if dev.TxFlows != 1 || dev.TxChannels != 8 {
+				t.Fatalf("console = %+v", dev)
+			}
+		case "Amp-Left", "Amp-Right":
+			if dev.RxFlows != 1 || dev.RxChannels != 2 {
+				t.Fatalf("%s = %+v", dev.Device, dev)
+			}
+		}
+	}
+	if len(report.Errors) != 0 {
+		t.Fatalf("errors = %q", report.Errors)
+	}
+}
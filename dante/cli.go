@@ -0,0 +1,1959 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"danteCS/internal/backoff"
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/trace"
+)
+
+//==============================================================================
+// 命令列子命令
+//==============================================================================
+
+// golane scan | devices list | interfaces | topology | aes67 | monitor | route | preset | snapshot | capture | quarantine | plan | incidents | audit | instance
+// 不帶子命令 (或第一個參數是旗標) 時執行 monitor，相容舊的啟動方式。
+// scan、devices、interfaces、topology、aes67、route、preset、capture、quarantine、incidents、audit 加上 -host 時改為操作遠端的 monitor。
+
+// programName 用法說明中的程式名稱
+var programName = filepath.Base(os.Args[0])
+
+// errUsage 參數錯誤，由 Execute 顯示命令用法
+var errUsage = errors.New("invalid arguments")
+
+// Command 子命令
+type Command struct {
+	Name  string                    // 命令名稱
+	Short string                    // 一行說明
+	Args  string                    // 位置參數說明
+	Flags *flag.FlagSet             // 命令專屬參數 (群組命令為 nil)
+	Run   func(args []string) error // 執行 (群組命令為 nil)
+	Sub   []*Command                // 子命令
+
+	log *logFlags // 解析後用來設定日誌
+}
+
+// find 依參數尋找子命令，回傳命令、完整路徑與剩餘參數
+func (c *Command) find(args []string) (*Command, []string, []string) {
+	path := []string{c.Name}
+	for len(args) > 0 {
+		var next *Command
+		for _, sub := range c.Sub {
+			if sub.Name == args[0] {
+				next = sub
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		c, path, args = next, append(path, next.Name), args[1:]
+	}
+	return c, path, args
+}
+
+// printUsage 顯示命令用法
+func (c *Command) printUsage(path []string) {
+	out := os.Stderr
+	name := strings.Join(append([]string{programName}, path[1:]...), " ")
+
+	if len(c.Sub) > 0 {
+		fmt.Fprintf(out, "Usage: %s <command> [flags]\n\n", name)
+		if c.Short != "" {
+			fmt.Fprintf(out, "%s\n\n", c.Short)
+		}
+		fmt.Fprintln(out, "Commands:")
+		for _, sub := range c.Sub {
+			fmt.Fprintf(out, "  %-12s %s\n", sub.Name, sub.Short)
+		}
+		fmt.Fprintf(out, "\nRun '%s <command> -h' for command flags.\n", name)
+		return
+	}
+
+	fmt.Fprintf(out, "Usage: %s [flags] %s\n\n%s\n", name, c.Args, c.Short)
+	if c.Flags != nil {
+		fmt.Fprintln(out, "\nFlags:")
+		c.Flags.SetOutput(out)
+		c.Flags.PrintDefaults()
+	}
+}
+
+// Execute 執行子命令，回傳行程結束碼
+func Execute(root *Command, args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			root.printUsage([]string{root.Name})
+			return 0
+		}
+	}
+
+	// 相容舊的啟動方式: golane [flags]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		args = append([]string{"monitor"}, args...)
+	}
+
+	cmd, path, rest := root.find(args)
+	if cmd.Run == nil {
+		if len(rest) > 0 {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", rest[0])
+		}
+		cmd.printUsage(path)
+		return 2
+	}
+
+	if cmd.Flags != nil {
+		cmd.Flags.Usage = func() { cmd.printUsage(path) }
+		if err := cmd.Flags.Parse(rest); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			return 2
+		}
+		rest = cmd.Flags.Args()
+	}
+
+	if cmd.log != nil {
+		if err := SetupLogging(cmd.log.options()); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 2
+		}
+		defer CloseLogging()
+	}
+
+	if err := cmd.Run(rest); err != nil {
+		if errors.Is(err, errUsage) {
+			cmd.printUsage(path)
+			return 2
+		}
+		logger.Error("Command failed", "command", strings.Join(path[1:], " "), "err", err)
+		return 1
+	}
+	return 0
+}
+
+// newFlagSet 建立子命令參數
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+//------------------------------------------------------------------------------
+// 共用參數
+//------------------------------------------------------------------------------
+
+// logFlags 日誌參數
+type logFlags struct {
+	level       string
+	format      string
+	file        string
+	maxSize     int
+	rotateEvery time.Duration
+	maxBackups  int
+	maxAge      time.Duration
+	compress    bool
+	sink        string
+	lang        string
+}
+
+// addLogFlags 註冊日誌等級與格式
+func addLogFlags(fs *flag.FlagSet) *logFlags {
+	lf := &logFlags{}
+	fs.StringVar(&lf.level, "log-level", "info", "log level: debug, info, warn, error")
+	fs.StringVar(&lf.format, "log-format", "auto", "log format: pretty, text, json, auto")
+	fs.StringVar(&lf.lang, "lang", "", "output language: en, zh-TW (default: $GOLANE_LANG or $LANG)")
+	return lf
+}
+
+// addOutputFlags 註冊長時間執行的命令才需要的日誌檔、輪替與轉送參數
+func (lf *logFlags) addOutputFlags(fs *flag.FlagSet) {
+	fs.StringVar(&lf.file, "log-file", "", "write logs to this file instead of stderr")
+	fs.IntVar(&lf.maxSize, "log-max-size", 10, "rotate the log file when it exceeds this size in MB (0 = unlimited)")
+	fs.DurationVar(&lf.rotateEvery, "log-rotate-every", 0, "also rotate the log file at this interval, e.g. 24h (0 = disabled)")
+	fs.IntVar(&lf.maxBackups, "log-max-backups", 5, "number of rotated log files to keep (0 = unlimited)")
+	fs.DurationVar(&lf.maxAge, "log-max-age", 7*24*time.Hour, "delete rotated log files older than this (0 = keep)")
+	fs.BoolVar(&lf.compress, "log-compress", true, "gzip rotated log files")
+	fs.StringVar(&lf.sink, "log-sink", "", "also forward logs to journald, syslog, or syslog://host:port")
+}
+
+func (lf *logFlags) options() LogOptions {
+	return LogOptions{
+		Level:  lf.level,
+		Format: lf.format,
+		File:   lf.file,
+		Sink:   lf.sink,
+		Lang:   lf.lang,
+		Rotate: RotateOptions{
+			MaxSize:     int64(lf.maxSize) << 20,
+			RotateEvery: lf.rotateEvery,
+			MaxBackups:  lf.maxBackups,
+			MaxAge:      lf.maxAge,
+			Compress:    lf.compress,
+		},
+	}
+}
+
+// interfaceFlags 介面選擇參數
+type interfaceFlags struct {
+	danteIfaces   string
+	vlan          string
+	simulate      bool
+	simulateFile  string
+	eventInterval time.Duration
+	deviceTTL     time.Duration  // 設備資訊快取的有效時間
+	callRetry     backoff.Policy // SDK 呼叫暫時性失敗的重試
+	roles         InterfaceRoles // 設定檔的介面角色 (nil 表示沒有)
+	fallbackList  string         // -dante-fallback
+	fallback      DanteFallback  // 指定的 Dante 介面都不存在時的備用規則
+	nicNames      NICNames       // 設定檔的 udev 穩定名稱 (nil 表示沒有)
+}
+
+func addInterfaceFlags(fs *flag.FlagSet) *interfaceFlags {
+	f := &interfaceFlags{}
+	fs.StringVar(&f.danteIfaces, "dante-ifaces", "", "comma-separated Dante interface names (e.g. eth1.10), overrides the built-in list")
+	fs.StringVar(&f.fallbackList, "dante-fallback", "", "comma-separated interfaces to try in order when none of the Dante interfaces is found (e.g. after replacing a USB NIC)")
+	fs.StringVar(&f.fallback.Subnet, "dante-fallback-subnet", "", "otherwise use any interface with an address in this subnet (e.g. 10.1.0.0/16)")
+	fs.StringVar(&f.vlan, "vlan", "", "802.1Q sub-interfaces to create if missing, e.g. eth1.10,eth1.20")
+	fs.BoolVar(&f.simulate, "simulate", false, "use synthetic Dante devices instead of the SDK (demos, off-site testing)")
+	fs.StringVar(&f.simulateFile, "simulate-config", "", "JSON file with the simulated devices (implies -simulate, default: built-in demo devices)")
+	fs.DurationVar(&f.eventInterval, "event-interval", dante.DefaultEventInterval, "how often to process Dante SDK events during a device scan")
+	fs.DurationVar(&f.deviceTTL, "device-cache-ttl", dante.DefaultDeviceTTL, "serve the device list from cache for this long between SDK change notifications (0 = always read from the SDK)")
+	f.callRetry = dante.DefaultCallRetry()
+	fs.IntVar(&f.callRetry.MaxAttempts, "sdk-retry-attempts", f.callRetry.MaxAttempts, "attempts for scan, refresh and device info SDK calls that fail transiently (1 = no retry)")
+	fs.DurationVar(&f.callRetry.Initial, "sdk-retry-delay", f.callRetry.Initial, "wait this long before retrying a transient SDK failure (doubles on each failure)")
+	fs.DurationVar(&f.callRetry.Max, "sdk-retry-max-delay", f.callRetry.Max, "upper bound of the SDK call retry delay")
+	fs.Float64Var(&f.callRetry.Jitter, "sdk-retry-jitter", f.callRetry.Jitter, "randomize SDK call retry delays by up to this fraction (0.2 = ±20%)")
+	return f
+}
+
+// deviceFilterFlags 設備搜尋參數 (devices list)
+type deviceFilterFlags struct {
+	name, model, ip, mac, version string
+}
+
+func addDeviceFilterFlags(fs *flag.FlagSet) *deviceFilterFlags {
+	f := &deviceFilterFlags{}
+	fs.StringVar(&f.name, "name", "", "only devices whose name contains this text")
+	fs.StringVar(&f.model, "model", "", "only devices whose model contains this text")
+	fs.StringVar(&f.ip, "ip", "", "only devices with a primary or secondary address in this subnet or equal to this address (e.g. 10.0.1.0/24)")
+	fs.StringVar(&f.mac, "mac", "", "only devices whose MAC address contains this (separators optional, e.g. 001dc1)")
+	fs.StringVar(&f.version, "dante-version", "", "only devices running this Dante version (4.2 matches 4.2.x)")
+	return f
+}
+
+// filter 轉成搜尋條件
+func (f *deviceFilterFlags) filter() (dante.DeviceFilter, error) {
+	filter := dante.DeviceFilter{Name: f.name, Model: f.model, MAC: f.mac, DanteVersion: f.version}
+	if f.ip != "" {
+		network, err := dante.ParseNetwork(f.ip)
+		if err != nil {
+			return dante.DeviceFilter{}, fmt.Errorf("-ip: %v", err)
+		}
+		filter.Network = network
+	}
+	return filter, nil
+}
+
+// simulation 模擬設定 (未使用 -simulate 時為 nil)
+func (f *interfaceFlags) simulation() (*dante.SimulationConfig, error) {
+	if !f.simulate && f.simulateFile == "" {
+		return nil, nil
+	}
+	return dante.LoadSimulationConfig(f.simulateFile)
+}
+
+// applyConfig 套用設定檔的介面角色與備用規則 (命令列參數優先)
+func (f *interfaceFlags) applyConfig(cfg *MonitorConfig) {
+	f.roles = cfg.Interfaces
+	f.nicNames = cfg.NICNames
+	if cfg.DanteFallback != nil && f.fallbackList == "" && f.fallback.Subnet == "" {
+		f.fallback = *cfg.DanteFallback
+	}
+}
+
+// detect 建立 VLAN 子介面並偵測網路介面
+func (f *interfaceFlags) detect() (*NetworkDetector, error) {
+	detector := NewNetworkDetector()
+	if f.danteIfaces != "" {
+		detector.DanteInterfaceNames = strings.Split(f.danteIfaces, ",")
+	}
+	if len(f.roles) > 0 {
+		if err := f.roles.Validate(); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		if f.danteIfaces != "" && len(f.roles.danteNames()) > 0 {
+			return nil, errors.New("-dante-ifaces cannot be combined with the Dante interface roles of the config file")
+		}
+		detector.Roles = f.roles
+	}
+	if f.fallbackList != "" {
+		f.fallback.Interfaces = strings.Split(f.fallbackList, ",")
+	}
+	if err := f.fallback.Validate(); err != nil {
+		return nil, err
+	}
+	detector.DanteFallback = f.fallback
+	if len(f.nicNames) > 0 {
+		names, err := compileNICNames(f.nicNames)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		detector.NICNames = names
+	}
+
+	if f.vlan != "" {
+		if err := detector.ConfigureVLANs(f.vlan); err != nil {
+			return nil, fmt.Errorf("VLAN configuration failed: %v", err)
+		}
+	}
+
+	if err := detector.AutoConfigureFromSystem(); err != nil {
+		return nil, fmt.Errorf("network detection failed: %v", err)
+	}
+	if err := detector.checkRoles(); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if len(detector.NICNames) > 0 {
+		checkUdevRules(udevRulesPath, detector.NICNames)
+	}
+	return detector, nil
+}
+
+// commandContext 收到 SIGINT/SIGTERM 時取消，讓一次性命令結束掃描並清理 SDK
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// openPrimaryDomain 以第一個 Dante 介面 (或 -simulate 的模擬設備) 初始化 Dante1 網域
+func (f *interfaceFlags) openPrimaryDomain(ctx context.Context, detector *NetworkDetector) (*dante.Domain, error) {
+	domain, err := f.newPrimaryDomain(detector)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+// newPrimaryDomain 建立尚未初始化的 Dante1 網域
+func (f *interfaceFlags) newPrimaryDomain(detector *NetworkDetector) (*dante.Domain, error) {
+	if err := checkEventInterval(f.eventInterval); err != nil {
+		return nil, err
+	}
+	if err := checkCallRetry(f.callRetry); err != nil {
+		return nil, err
+	}
+	sim, err := f.simulation()
+	if err != nil {
+		return nil, err
+	}
+	if sim != nil {
+		domain := dante.NewSimulatedDomain("Dante1", sim.NetworkConfig(), dante.NewSimulatedSDK(sim))
+		domain.EventInterval = f.eventInterval
+		domain.CallRetry = f.callRetry
+		return domain, nil
+	}
+
+	if len(detector.DanteInterfaces) == 0 {
+		return nil, fmt.Errorf("Dante interface not found (expected one of %v)", detector.DanteInterfaceNames)
+	}
+
+	config, err := detector.GetDanteConfig(0)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := dante.NewDomain("Dante1", *config)
+	domain.EventInterval = f.eventInterval
+	domain.CallRetry = f.callRetry
+	return domain, nil
+}
+
+// discover 掃描並等待設備發現 (ctx 取消時回傳錯誤)
+func discover(ctx context.Context, d *dante.Domain, wait time.Duration) error {
+	if err := d.StartDeviceScan(ctx); err != nil {
+		d.Logger().Warn("Device scan failed", "err", err)
+	}
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("discovery interrupted: %w", ctx.Err())
+	case <-time.After(wait):
+	}
+	d.RefreshDevices(ctx)
+	return nil
+}
+
+// showDevices 顯示網域的設備列表
+func showDevices(d *dante.Domain) {
+	printDeviceTable(d.Name, d.NetworkConfig.InterfaceName, d.NetworkConfig.IPAddress, d.GetDevices())
+}
+
+// printDeviceTable 顯示網域的設備表格 (本機或遠端 daemon 的設備)
+func printDeviceTable(domain, iface, ip string, devices []dante.Device) {
+	fmt.Print(i18n.Sprintf("\n=== %s Device List ===\n", domain))
+	fmt.Print(i18n.Sprintf("Interface: %s (%s)\n", iface, ip))
+	fmt.Print(i18n.Sprintf("Total Devices: %d\n", len(devices)))
+
+	if len(devices) > 0 {
+		printHeader("\n%-3s %-20s %-16s %-16s %-17s %-7s %s\n", "ID", "Name", "Model", "IP Address", "MAC Address", "TX/RX", "Dante Ver")
+		fmt.Println("─────────────────────────────────────────────────────────────────────────────────────────────────")
+
+		for _, dev := range devices {
+			ip := dev.IPAddress
+			if dev.IsLinkLocal() {
+				ip += "*"
+			}
+
+			fmt.Printf("%-3d %-20s %-16s %-16s %-17s %-7s %s\n",
+				dev.ID, dev.Name, dev.Model, ip, dev.MacAddress, dev.ChannelCounts(), dev.DanteVersion)
+			if dev.ReadOnly != "" {
+				fmt.Print(i18n.Sprintf("    ! read-only: %s\n", readOnlyText(dev)))
+			}
+		}
+	}
+
+	fmt.Println("==========================")
+	fmt.Println()
+}
+
+// readOnlyText 無法設定的原因 (附加已知的 DDM 網域)
+func readOnlyText(dev dante.Device) string {
+	if dev.DDMDomain != "" {
+		return fmt.Sprintf("%s (DDM domain %s)", dev.ReadOnly, dev.DDMDomain)
+	}
+	return dev.ReadOnly
+}
+
+// printJSON 以縮排 JSON 輸出到 stdout
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+//------------------------------------------------------------------------------
+// 命令樹
+//------------------------------------------------------------------------------
+
+// newRootCommand 建立完整命令樹
+func newRootCommand() *Command {
+	return &Command{
+		Name:  programName,
+		Short: "Dante network monitoring and control",
+		Sub: []*Command{
+			newScanCommand(),
+			{
+				Name:  "devices",
+				Short: "Dante device inventory",
+				Sub:   []*Command{newDevicesListCommand(), newDevicesRenameCommand(), newDevicesUpgradeCommand()},
+			},
+			newInterfacesCommand(),
+			newUdevCommand(),
+			newTopologyCommand(),
+			newBandwidthCommand(),
+			newFirmwareCommand(),
+			newAES67Command(),
+			newIGMPCommand(),
+			newLLDPCommand(),
+			newSwitchPortsCommand(),
+			newDiagCommand(),
+			newMonitorCommand(),
+			newRouteCommand(),
+			newFlowCommand(),
+			newPresetCommand(),
+			newSnapshotCommand(),
+			newCaptureCommand(),
+			newQuarantineCommand(),
+			newPlanCommand(),
+			newIncidentsCommand(),
+			newAlarmsCommand(),
+			newSchedulesCommand(),
+			newAuditCommand(),
+			newTokenCommand(),
+			newInstanceCommand(),
+			newVersionCommand(),
+		},
+	}
+}
+
+// newScanCommand golane scan
+func newScanCommand() *Command {
+	fs := newFlagSet("scan")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	linkLocalAlias := fs.Bool("linklocal-alias", false, "add a 169.254/16 alias to the Dante interface when Auto-IP devices are found")
+	addressPlanFile := fs.String("address-plan", "", "accepted address plan used to validate discovered devices")
+	probe := fs.Bool("probe", false, "ping or ARP each discovered device to catch devices that are listed but offline")
+	probeOpts := DefaultReachOptions()
+	fs.StringVar(&probeOpts.Method, "probe-method", probeOpts.Method, "how -probe checks device addresses: icmp (measures latency) or arp (same subnet, works when ICMP is blocked)")
+	fs.DurationVar(&probeOpts.Timeout, "probe-timeout", probeOpts.Timeout, "how long -probe waits for replies")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "scan",
+		Short: "Run a one-shot device discovery and report the results",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if err := probeOpts.Validate(); err != nil {
+				return err
+			}
+
+			var addressPlan *AddressPlan
+			if *addressPlanFile != "" {
+				plan, err := LoadAddressPlan(*addressPlanFile)
+				if err != nil {
+					return err
+				}
+				addressPlan = plan
+			}
+
+			// 遠端 daemon 持續掃描，直接顯示它目前看到的設備
+			if remote.enabled() {
+				if *linkLocalAlias {
+					return fmt.Errorf("-linklocal-alias: %w", errRemoteUnsupported)
+				}
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				domains, err := client.Domains()
+				if err != nil {
+					return err
+				}
+				devices, err := client.Devices(dante.DeviceFilter{}, dante.DeviceOrder{}, devicePage{})
+				if err != nil {
+					return err
+				}
+				printRemoteDevices(domains, devices)
+
+				if addressPlan != nil {
+					for _, d := range domains {
+						var list []dante.Device
+						for _, dev := range devices {
+							if dev.Domain == d.Name {
+								list = append(list, dev.Device)
+							}
+						}
+						for _, problem := range planProblems(addressPlan, d.Name, d.Interface, d.IPAddress, list) {
+							logger.Warn("Address plan violation", "domain", d.Name, "problem", problem)
+						}
+					}
+				}
+				// daemon 依 -reach-method 定期檢查，顯示最後一次的結果
+				if *probe {
+					list, err := client.Reachability()
+					if err != nil {
+						return err
+					}
+					printReachability(list)
+				}
+				return nil
+			}
+
+			if err := ifaces.checkTiming(*wait); err != nil {
+				return err
+			}
+			detector, err := ifaces.detect()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			domain, err := ifaces.openPrimaryDomain(ctx, detector)
+			if err != nil {
+				return err
+			}
+			defer domain.Cleanup()
+
+			if err := discover(ctx, domain, *wait); err != nil {
+				return err
+			}
+			showDevices(domain)
+			reportLinkLocalDevices(domain, detector, *linkLocalAlias)
+
+			if addressPlan != nil {
+				for _, problem := range validateAgainstPlan(domain, addressPlan) {
+					domain.Logger().Warn("Address plan violation", "problem", problem)
+				}
+			}
+			if *probe {
+				printReachability(CheckReachability(ctx, detector, domain.Name, domain.NetworkConfig.InterfaceName, domain.GetDevices(), probeOpts))
+			}
+			return nil
+		},
+	}
+}
+
+// newDevicesListCommand golane devices list
+func newDevicesListCommand() *Command {
+	fs := newFlagSet("devices list")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	jsonOut := fs.Bool("json", false, "print devices as JSON")
+	search := addDeviceFilterFlags(fs)
+	sortKey := fs.String("sort", "", "sort devices by name, ip or model (prefix - for descending, default: discovery order)")
+	var page devicePage
+	fs.IntVar(&page.Limit, "limit", 0, "show at most this many devices (0 = all)")
+	fs.IntVar(&page.Offset, "offset", 0, "skip this many devices (with -limit)")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "list",
+		Short: "List discovered Dante devices",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			filter, err := search.filter()
+			if err != nil {
+				return err
+			}
+			order, err := dante.ParseDeviceOrder(*sortKey)
+			if err != nil {
+				return fmt.Errorf("-sort: %v", err)
+			}
+			if page.Limit < 0 || page.Offset < 0 || (page.Offset > 0 && page.Limit == 0) {
+				return errors.New("-offset requires a positive -limit")
+			}
+
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				devices, err := client.Devices(filter, order, page)
+				if err != nil {
+					return err
+				}
+				if *jsonOut {
+					return printJSON(devices)
+				}
+				domains, err := client.Domains()
+				if err != nil {
+					return err
+				}
+				printRemoteDevices(domains, devices)
+				return nil
+			}
+
+			if err := ifaces.checkTiming(*wait); err != nil {
+				return err
+			}
+			detector, err := ifaces.detect()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			domain, err := ifaces.openPrimaryDomain(ctx, detector)
+			if err != nil {
+				return err
+			}
+			defer domain.Cleanup()
+
+			if err := discover(ctx, domain, *wait); err != nil {
+				return err
+			}
+
+			matched := dante.FilterDevices(domain.GetDevices(), filter)
+			dante.SortDevices(matched, order)
+			if page.Limit > 0 {
+				start, end := page.bounds(len(matched))
+				matched = matched[start:end]
+			}
+			if *jsonOut {
+				devices := []apiDevice{}
+				for _, dev := range matched {
+					devices = append(devices, newAPIDevice(domain.Name, dev))
+				}
+				return printJSON(devices)
+			}
+			printDeviceTable(domain.Name, domain.NetworkConfig.InterfaceName, domain.NetworkConfig.IPAddress, matched)
+			return nil
+		},
+	}
+}
+
+// newDevicesRenameCommand golane devices rename
+func newDevicesRenameCommand() *Command {
+	fs := newFlagSet("devices rename")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	dry := fs.Bool("dry-run", false, "check the new name and print the change without touching the network")
+	stateDir := fs.String("state-dir", ".", "state directory whose audit log records the change")
+
+	return &Command{
+		Name:  "rename",
+		Short: "Rename a Dante device (subscriptions to the old name become unresolved)",
+		Args:  "<device> <new-name>",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) != 2 {
+				return errUsage
+			}
+			if *dry {
+				return printPlannedRename(os.Stdout, args[0], args[1])
+			}
+			if err := dante.CheckDeviceName(args[1]); err != nil {
+				return err
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+
+			audit, err := OpenAuditLog(*stateDir)
+			if err != nil {
+				return err
+			}
+			detector, err := ifaces.detect()
+			if err != nil {
+				return err
+			}
+			d, err := ifaces.openPrimaryDomain(ctx, detector)
+			if err != nil {
+				return err
+			}
+			defer d.Cleanup()
+			return auditSettings(audit, d.Name, d).RenameDevice(cliAuditContext(ctx), args[0], args[1])
+		},
+	}
+}
+
+// newInterfacesCommand golane interfaces
+func newInterfacesCommand() *Command {
+	fs := newFlagSet("interfaces")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	suggest := fs.Bool("suggest", true, "print the suggested interface assignment")
+	dry := fs.Bool("dry-run", false, "print the commands -vlan would run without creating the sub-interfaces")
+	configFile := fs.String("config", "", "monitor config file whose \"interfaces\" section assigns the interface roles")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "interfaces",
+		Short: "Show network interfaces, Dante interface selection and isolation checks",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+
+			var detector *NetworkDetector
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if detector, err = client.Interfaces(); err != nil {
+					return err
+				}
+			} else {
+				if *dry && ifaces.vlan != "" {
+					if err := printPlannedVLANs(os.Stdout, ifaces.vlan); err != nil {
+						return err
+					}
+					ifaces.vlan = "" // 只列出現有的介面
+				}
+				if *configFile != "" {
+					cfg, err := LoadMonitorConfig(*configFile)
+					if err != nil {
+						return err
+					}
+					ifaces.applyConfig(cfg)
+				}
+				var err error
+				if detector, err = ifaces.detect(); err != nil {
+					return err
+				}
+			}
+
+			detector.ListAvailableInterfaces()
+			if *suggest {
+				detector.SuggestNetworkConfiguration()
+			}
+			detector.CheckNetworkIsolation()
+			return nil
+		},
+	}
+}
+
+// newTopologyCommand golane topology
+func newTopologyCommand() *Command {
+	fs := newFlagSet("topology")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	format := fs.String("format", "json", "output format: json, dot (render with dot -Tsvg)")
+	out := fs.String("out", "", "write the topology to this file instead of stdout")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "topology",
+		Short: "Export interfaces, domains, devices and subscriptions as JSON or Graphviz",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if *format != "json" && *format != "dot" {
+				return fmt.Errorf("unknown -format %q, use json or dot", *format)
+			}
+
+			var topo Topology
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if topo, err = client.Topology(); err != nil {
+					return err
+				}
+			} else {
+				if err := ifaces.checkTiming(*wait); err != nil {
+					return err
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+				domain, err := ifaces.openPrimaryDomain(ctx, detector)
+				if err != nil {
+					return err
+				}
+				defer domain.Cleanup()
+				if err := discover(ctx, domain, *wait); err != nil {
+					return err
+				}
+				topo = BuildTopology(ctx, detector, []topologySource{{
+					Name:      domain.Name,
+					Interface: domain.NetworkConfig.InterfaceName,
+					IPAddress: domain.NetworkConfig.IPAddress,
+					Devices:   domain.GetDevices(),
+					Routes:    domain,
+				}})
+			}
+
+			w := io.Writer(os.Stdout)
+			if *out != "" {
+				file, err := os.Create(*out)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				w = file
+			}
+			if *format == "dot" {
+				return WriteTopologyDot(w, topo)
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(topo)
+		},
+	}
+}
+
+// newMonitorCommand golane monitor (預設命令)
+func newMonitorCommand() *Command {
+	fs := newFlagSet("monitor")
+	lf := addLogFlags(fs)
+	lf.addOutputFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	opts := &MonitorOptions{}
+	fs.DurationVar(&opts.Wait, "wait", 3*time.Second, "how long to wait for the initial device discovery")
+	opts.Refresh = DefaultRefreshPolicy()
+	fs.DurationVar(&opts.Refresh.MaxInterval, "interval", opts.Refresh.MaxInterval, "refresh the device list at least this often even without change notifications (0 = only on changes)")
+	fs.DurationVar(&opts.Refresh.Debounce, "refresh-debounce", opts.Refresh.Debounce, "after a device change notification, wait this long for further changes before refreshing")
+	fs.DurationVar(&opts.Refresh.MinInterval, "refresh-min-interval", opts.Refresh.MinInterval, "minimum time between two device list refreshes")
+	fs.BoolVar(&opts.LinkLocalAlias, "linklocal-alias", false, "add a 169.254/16 alias to the Dante interface when Auto-IP devices are found")
+	fs.StringVar(&opts.AddressPlanFile, "address-plan", "", "accepted address plan used to validate interfaces and devices")
+	fs.StringVar(&opts.DnsmasqFile, "plan-dnsmasq", "", "write a dnsmasq DHCP config seeded from the address plan and discovered devices")
+	fs.StringVar(&opts.StateDir, "state-dir", ".", "directory for persistent state (floor plan, uploaded icons, ...)")
+	fs.StringVar(&opts.APIToken, "api-token", os.Getenv(apiTokenEnv), "token required by the management API (default $"+apiTokenEnv+"), more can be set in the config file or issued with golane token issue")
+	fs.StringVar(&opts.TLS.Cert, "tls-cert", "", "serve the management API over HTTPS with this PEM certificate (may include intermediates)")
+	fs.StringVar(&opts.TLS.Key, "tls-key", "", "PEM private key for -tls-cert")
+	fs.BoolVar(&opts.TLS.SelfSigned, "tls-self-signed", false, "serve over HTTPS with a self-signed certificate generated in -state-dir on first start")
+	fs.BoolVar(&opts.APIOpenReads, "api-public-reads", false, "allow read-only API requests without a token and require one only for changes")
+	fs.DurationVar(&opts.ReadyAge, "ready-age", 0, "/readyz reports not ready when a domain has not refreshed its device list for this long (0 = three times -interval)")
+	fs.StringVar(&opts.APIAddr, "api-addr", "", "listen address for the management REST API and web UI (e.g. 10.0.0.5:8080), empty to disable")
+	fs.BoolVar(&opts.TUI, "tui", false, "show an interactive dashboard instead of printing the device table on every refresh")
+	configFile := fs.String("config", "", "JSON config file with \"features\", \"timing\", \"presets\", \"triggers\" and \"schedules\" sections (e.g. {\"features\": {\"webui\": false}})")
+	featureSpec := fs.String("features", "", "comma-separated features to enable (name) or disable (-name), applied after -config; see GET /api/features")
+	opts.InitRetry = dante.DefaultInitBackoff()
+	fs.DurationVar(&opts.InitRetry.Initial, "init-retry-delay", opts.InitRetry.Initial, "wait this long before retrying a failed SDK initialization (doubles on each failure)")
+	fs.DurationVar(&opts.InitRetry.Max, "init-retry-max-delay", opts.InitRetry.Max, "upper bound of the initialization retry delay")
+	fs.IntVar(&opts.InitRetry.MaxAttempts, "init-retry-attempts", opts.InitRetry.MaxAttempts, "give up initializing a domain after this many attempts (0 = retry forever, 1 = fail at startup)")
+	opts.Watchdog = dante.DefaultWatchdog()
+	fs.DurationVar(&opts.Watchdog.Hang, "watchdog-hang", opts.Watchdog.Hang, "reinitialize a domain when an SDK event or refresh call has not returned for this long, exit if it still does not return (0 = off)")
+	fs.IntVar(&opts.Watchdog.Failures, "watchdog-failures", opts.Watchdog.Failures, "reinitialize a domain after this many consecutive failed SDK event or refresh calls (0 = off)")
+	opts.Tracing = trace.DefaultConfig(programName)
+	fs.StringVar(&opts.Tracing.Endpoint, "otlp-endpoint", opts.Tracing.Endpoint, "send trace spans to this OTLP/HTTP collector (e.g. http://collector:4318), empty to disable (default $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	fs.Float64Var(&opts.Tracing.SampleRatio, "trace-sample", opts.Tracing.SampleRatio, "fraction of new traces to record (requests carrying a traceparent follow the caller)")
+	opts.NoiseFloor = DefaultNoiseFloor()
+	fs.DurationVar(&opts.NoiseFloor.GroupWait, "alert-group-wait", opts.NoiseFloor.GroupWait, "collect alerts of the same kind for this long before notifying (0 = notify immediately)")
+	fs.DurationVar(&opts.NoiseFloor.RepeatInterval, "alert-repeat-interval", opts.NoiseFloor.RepeatInterval, "suppress repeats of an alert for this long (0 = never suppress)")
+	fs.IntVar(&opts.NoiseFloor.BurstThreshold, "alert-burst-threshold", opts.NoiseFloor.BurstThreshold, "send one summary when a group has more alerts than this (0 = never summarize)")
+	opts.LoadShed = DefaultLoadShedPolicy()
+	fs.Float64Var(&opts.LoadShed.CPU, "shed-cpu", opts.LoadShed.CPU, "reject report and export API requests with 503 while host CPU usage is above this fraction (0 = ignore CPU)")
+	fs.DurationVar(&opts.LoadShed.Latency, "shed-latency", opts.LoadShed.Latency, "also reject them while the scheduling latency of the monitor exceeds this (0 = ignore latency)")
+	fs.DurationVar(&opts.LoadShed.RetryAfter, "shed-retry-after", opts.LoadShed.RetryAfter, "Retry-After sent with rejected requests")
+	opts.Reach = DefaultReachOptions()
+	fs.StringVar(&opts.Reach.Method, "reach-method", opts.Reach.Method, "how the reachability feature checks device addresses: icmp (measures latency) or arp (same subnet, works when ICMP is blocked)")
+	fs.DurationVar(&opts.Reach.Interval, "reach-interval", opts.Reach.Interval, "check device reachability at most this often")
+	fs.DurationVar(&opts.Reach.Timeout, "reach-timeout", opts.Reach.Timeout, "how long to wait for replies in each reachability check")
+	opts.Clock = DefaultClockOptions()
+	opts.Alarms = DefaultAlarmRules()
+	fs.DurationVar(&opts.Clock.Interval, "clock-interval", opts.Clock.Interval, "how often to query the clock status of each device")
+	fs.DurationVar(&opts.Clock.Window, "clock-loss-window", opts.Clock.Window, "count clock sync losses within this period")
+	fs.IntVar(&opts.Clock.LossCount, "clock-loss-count", opts.Clock.LossCount, "alert when a device loses clock sync this many times within -clock-loss-window")
+	opts.FlowStats = DefaultFlowStatsOptions()
+	fs.DurationVar(&opts.FlowStats.Interval, "flowstats-interval", opts.FlowStats.Interval, "how often to read the RX flow packet error counters of each device")
+	opts.Storm = DefaultStormOptions()
+	fs.Float64Var(&opts.Storm.Packets, "storm-pps", opts.Storm.Packets, "treat more received packets per second than this on a Dante interface as a broadcast/multicast storm (0 = ignore)")
+	fs.Float64Var(&opts.Storm.Multicast, "storm-multicast-pps", opts.Storm.Multicast, "also treat more multicast packets per second than this as a storm (0 = ignore)")
+	fs.IntVar(&opts.Storm.Sustain, "storm-sustain", opts.Storm.Sustain, "raise the storm alert after this many consecutive samples over the threshold")
+	fs.BoolVar(&opts.Storm.PauseDiscovery, "storm-pause-discovery", false, "pause the device refresh of the affected domain during a storm to reduce CPU load")
+	opts.SNMP = DefaultSNMPOptions()
+	fs.StringVar(&opts.SNMP.Addr, "snmp", "", "listen address for the read-only SNMP v1/v2c agent (e.g. 10.0.0.5:161), empty to disable")
+	fs.StringVar(&opts.SNMP.Community, "snmp-community", opts.SNMP.Community, "SNMP read community")
+	fs.StringVar(&opts.SNMP.Traps, "snmp-trap", "", "comma-separated host[:port] receivers for SNMPv2c traps on device loss and domain failure")
+	fs.StringVar(&opts.SNMP.TrapCommunity, "snmp-trap-community", "", "community sent with traps (default: -snmp-community)")
+	fs.StringVar(&opts.SNMP.Base, "snmp-oid-base", opts.SNMP.Base, "OID under which the GOlane tables are served (default is the NET-SNMP playpen, use your own enterprise number)")
+
+	return &Command{
+		Name:  "monitor",
+		Short: "Continuously monitor the Dante network (default command)",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			var cfg *MonitorConfig
+			if *configFile != "" {
+				var err error
+				if cfg, err = LoadMonitorConfig(*configFile); err != nil {
+					return err
+				}
+				opts.Presets, opts.Triggers, opts.Schedules = cfg.Presets, cfg.Triggers, cfg.Schedules
+				if cfg.Alarms != nil {
+					opts.Alarms = cfg.Alarms
+				}
+				opts.Webhooks, opts.Hooks, opts.Sinks, opts.Notify = cfg.Webhooks, cfg.Hooks, cfg.Sinks, cfg.Notify
+				opts.Switches, opts.StatusLED, opts.FrontPanel = cfg.Switches, cfg.StatusLED, cfg.FrontPanel
+				opts.SerialControl, opts.TCPControl = cfg.SerialControl, cfg.TCPControl
+				ifaces.applyConfig(cfg)
+				opts.APITokens = cfg.APITokens
+				if cfg.Timing != nil {
+					if err := cfg.Timing.apply(fs); err != nil {
+						return err
+					}
+				}
+			}
+			features, err := resolveFeatures(cfg, *featureSpec)
+			if err != nil {
+				return err
+			}
+			if err := ifaces.checkTiming(opts.Wait); err != nil {
+				return err
+			}
+			if err := opts.Refresh.Validate(); err != nil {
+				return err
+			}
+			if err := opts.LoadShed.Validate(); err != nil {
+				return err
+			}
+			if err := opts.Reach.Validate(); err != nil {
+				return err
+			}
+			if err := opts.Clock.Validate(); err != nil {
+				return err
+			}
+			if err := opts.FlowStats.Validate(); err != nil {
+				return err
+			}
+			if err := opts.Storm.Validate(); err != nil {
+				return err
+			}
+			if err := opts.SNMP.Validate(); err != nil {
+				return err
+			}
+			if err := opts.TLS.Validate(); err != nil {
+				return err
+			}
+			if opts.ReadyAge == 0 {
+				opts.ReadyAge = 3 * opts.Refresh.MaxInterval
+			}
+			if opts.Alarms, err = compileAlarmRules(opts.Alarms); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+			if opts.Webhooks, err = compileWebhooks(opts.Webhooks); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+			if opts.Hooks, err = compileHooks(opts.Hooks); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+			if opts.Sinks, err = compileSinks(opts.Sinks); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+			if opts.Switches, err = compileSwitches(opts.Switches); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+			if opts.Notify != nil {
+				if err := opts.Notify.Validate(); err != nil {
+					return fmt.Errorf("config: %w", err)
+				}
+			}
+			if opts.StatusLED != nil {
+				if err := opts.StatusLED.Validate(); err != nil {
+					return fmt.Errorf("config: %w", err)
+				}
+			}
+			if opts.FrontPanel != nil {
+				if err := opts.FrontPanel.Validate(); err != nil {
+					return fmt.Errorf("config: %w", err)
+				}
+			}
+			if opts.SerialControl != nil {
+				if err := opts.SerialControl.Validate(); err != nil {
+					return fmt.Errorf("config: %w", err)
+				}
+			}
+			if opts.TCPControl != nil {
+				if err := opts.TCPControl.Validate(); err != nil {
+					return fmt.Errorf("config: %w", err)
+				}
+			}
+			if _, err := compileTokens(opts.APITokens); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+			simulation, err := ifaces.simulation()
+			if err != nil {
+				return err
+			}
+			opts.Interfaces = ifaces
+			opts.Features = features
+			opts.Simulation = simulation
+			return runMonitor(opts)
+		},
+	}
+}
+
+// newRouteCommand golane route list|add|remove|import
+func newRouteCommand() *Command {
+	override := new(bool)
+	add := newRouteActionCommand("add", "<rx-device> <rx-channel> <tx-channel>@<tx-device>",
+		"Subscribe an RX channel to a TX channel",
+		func(ctx context.Context, rc RouteController, args []string, _ bool) error {
+			if len(args) != 3 {
+				return errUsage
+			}
+			txChannel, txDevice, ok := strings.Cut(args[2], "@")
+			if !ok || txChannel == "" || txDevice == "" {
+				return fmt.Errorf("TX channel must be written as channel@device, got %q", args[2])
+			}
+			if *override {
+				ctx = withRouteOverride(ctx)
+			}
+			return rc.Subscribe(ctx, args[0], args[1], txDevice, txChannel)
+		})
+	add.Flags.BoolVar(override, "override", false, "route even if one of the devices is quarantined (with -host)")
+
+	format := new(string)
+	importRoutes := newRouteActionCommand("import", "<file.csv|file.xlsx|file.json>",
+		"Apply subscriptions from a CSV, XLSX or JSON file and report each row",
+		func(ctx context.Context, rc RouteController, args []string, jsonOut bool) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			f := *format
+			if f == "" {
+				var err error
+				if f, err = RouteImportFormat(args[0]); err != nil {
+					return err
+				}
+			}
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			rows, err := ParseRouteImport(f, file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+			report := ApplyRouteImport(ctx, rc, rows)
+			if jsonOut {
+				if err := printJSON(report); err != nil {
+					return err
+				}
+			} else {
+				PrintRouteImportReport(os.Stdout, report)
+			}
+			if report.Failed > 0 || report.Invalid > 0 {
+				return fmt.Errorf("%d of %d rows not applied", report.Failed+report.Invalid, len(report.Rows))
+			}
+			return nil
+		})
+	importRoutes.Flags.StringVar(format, "format", "", "file format: csv, xlsx or json (default from the file extension)")
+
+	return &Command{
+		Name:  "route",
+		Short: "Inspect and change Dante subscriptions",
+		Sub: []*Command{
+			newRouteActionCommand("list", "<rx-device>",
+				"List the RX channels of a device and their subscriptions",
+				func(ctx context.Context, rc RouteController, args []string, jsonOut bool) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					subs, err := rc.ListSubscriptions(ctx, args[0])
+					if err != nil {
+						return err
+					}
+					if jsonOut {
+						return printJSON(subs)
+					}
+					printSubscriptions(args[0], subs)
+					return nil
+				}),
+			add,
+			newRouteActionCommand("remove", "<rx-device> <rx-channel>",
+				"Remove the subscription of an RX channel",
+				func(ctx context.Context, rc RouteController, args []string, _ bool) error {
+					if len(args) != 2 {
+						return errUsage
+					}
+					return rc.Subscribe(ctx, args[0], args[1], "", "")
+				}),
+			importRoutes,
+		},
+	}
+}
+
+// newRouteActionCommand 建立 route 子命令 (只初始化 SDK，不需要設備掃描)
+func newRouteActionCommand(name, usage, short string, action func(ctx context.Context, rc RouteController, args []string, jsonOut bool) error) *Command {
+	fs := newFlagSet("route " + name)
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	jsonOut := fs.Bool("json", false, "print results as JSON")
+	remote := addRemoteFlags(fs)
+	domain := fs.String("domain", "", "domain on the remote monitor (required with -host when it runs several domains)")
+	stateDir := fs.String("state-dir", ".", "state directory whose audit log records local changes (the monitor records changes made with -host)")
+	var dry bool
+	if name != "list" {
+		fs.BoolVar(&dry, "dry-run", false, "check the arguments and print the planned changes without touching the network")
+	}
+
+	return &Command{
+		Name:  name,
+		Short: short,
+		Args:  usage,
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			ctx, cancel := commandContext()
+			defer cancel()
+			if dry {
+				return action(withDryRun(ctx), plannedRoutes{os.Stdout}, args, *jsonOut)
+			}
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				return action(ctx, client.Routes(*domain), args, *jsonOut)
+			}
+
+			audit, err := OpenAuditLog(*stateDir)
+			if err != nil {
+				return err
+			}
+			detector, err := ifaces.detect()
+			if err != nil {
+				return err
+			}
+			d, err := ifaces.openPrimaryDomain(ctx, detector)
+			if err != nil {
+				return err
+			}
+			defer d.Cleanup()
+
+			return action(cliAuditContext(ctx), auditRoutes(audit, d.Name, d), args, *jsonOut)
+		},
+	}
+}
+
+// newPresetCommand golane preset save|load
+func newPresetCommand() *Command {
+	return &Command{
+		Name:  "preset",
+		Short: "Save the subscription matrix of a domain to a file and restore it",
+		Sub: []*Command{
+			newPresetActionCommand("save", "<name>", "Capture every RX channel subscription into a preset file",
+				func(ctx context.Context, rc RouteController, devices []dante.Device, _ string, f *presetFlags, args []string) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					p, err := CapturePreset(ctx, args[0], devices, rc)
+					if err != nil {
+						return err
+					}
+					path := f.out
+					if path == "" {
+						path = args[0] + ".json"
+					}
+					if err := SavePresetFile(path, p); err != nil {
+						return err
+					}
+					fmt.Printf("Saved %d RX channels of %d devices to %s\n", len(p.Routes), len(devices), path)
+					return nil
+				}),
+			newPresetActionCommand("load", "<file>", "Validate a preset file and apply its subscriptions",
+				func(ctx context.Context, rc RouteController, devices []dante.Device, domain string, f *presetFlags, args []string) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					p, err := LoadPresetFile(args[0])
+					if err != nil {
+						return err
+					}
+					engine, err := newPresetEngine(p, domain, &inventoryRoutes{rc, devices})
+					if err != nil {
+						return err
+					}
+
+					if f.check {
+						check, err := engine.Check(ctx, p.Name)
+						if err != nil {
+							return err
+						}
+						if f.json {
+							return printJSON(check)
+						}
+						printPresetProblems(check.Problems)
+						if !check.Ready {
+							return fmt.Errorf("%w: %s has %d problem(s)", errPresetNotReady, p.Name, len(check.Problems))
+						}
+						fmt.Printf("Preset %s is ready (%d RX channels)\n", p.Name, len(p.Routes))
+						return nil
+					}
+
+					ev, err := engine.Recall(ctx, p.Name, "cli", f.partial)
+					if f.json {
+						if jsonErr := printJSON(ev); jsonErr != nil {
+							return jsonErr
+						}
+					} else {
+						printPresetProblems(ev.Problems)
+						for _, e := range ev.Errors {
+							fmt.Printf("FAILED   %s\n", e)
+						}
+					}
+					if err != nil {
+						if errors.Is(err, errPresetNotReady) {
+							return fmt.Errorf("%w (nothing applied, use -partial to apply the other channels)", err)
+						}
+						return err
+					}
+					if !f.json {
+						fmt.Printf("Applied %d of %d RX channels from %s\n", ev.Applied, len(p.Routes), p.Name)
+					}
+					if len(ev.Errors) > 0 {
+						return fmt.Errorf("%d subscription(s) failed", len(ev.Errors))
+					}
+					return nil
+				}),
+		},
+	}
+}
+
+// presetFlags preset 子命令參數
+type presetFlags struct {
+	out     string
+	check   bool
+	partial bool
+	json    bool
+}
+
+// newPresetActionCommand 建立 preset 子命令 (本機掃描設備，或使用遠端 monitor 的設備列表)
+func newPresetActionCommand(name, usage, short string, action func(ctx context.Context, rc RouteController, devices []dante.Device, domain string, f *presetFlags, args []string) error) *Command {
+	fs := newFlagSet("preset " + name)
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	remote := addRemoteFlags(fs)
+	domain := fs.String("domain", "", "domain on the remote monitor (required with -host when it runs several domains)")
+	f := &presetFlags{}
+	switch name {
+	case "save":
+		fs.StringVar(&f.out, "out", "", "preset file to write (default <name>.json)")
+	case "load":
+		fs.BoolVar(&f.check, "check", false, "only validate the preset against the network, do not apply it")
+		fs.BoolVar(&f.partial, "partial", false, "apply the valid channels even if some devices or channels are missing")
+		fs.BoolVar(&f.json, "json", false, "print the result as JSON")
+	}
+
+	return &Command{
+		Name:  name,
+		Short: short,
+		Args:  usage,
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			ctx, cancel := commandContext()
+			defer cancel()
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				devices, err := client.DomainDevices(*domain)
+				if err != nil {
+					return err
+				}
+				return action(ctx, client.Routes(*domain), devices, *domain, f, args)
+			}
+
+			if err := ifaces.checkTiming(*wait); err != nil {
+				return err
+			}
+			detector, err := ifaces.detect()
+			if err != nil {
+				return err
+			}
+			d, err := ifaces.openPrimaryDomain(ctx, detector)
+			if err != nil {
+				return err
+			}
+			defer d.Cleanup()
+			if err := discover(ctx, d, *wait); err != nil {
+				return err
+			}
+			return action(ctx, d, d.GetDevices(), d.Name, f, args)
+		},
+	}
+}
+
+// printPresetProblems 顯示 preset 驗證問題
+func printPresetProblems(problems []PresetProblem) {
+	for _, p := range problems {
+		fmt.Print(i18n.Sprintf("SKIPPED  %s/%s: %s\n", p.RxDevice, p.RxChannel, p.Problem))
+	}
+}
+
+// printSubscriptions 顯示接收通道訂閱表
+func printSubscriptions(device string, subs []dante.Subscription) {
+	fmt.Print(i18n.Sprintf("\n=== %s RX Channels ===\n", device))
+	printHeader("%-4s %-20s %-32s %-16s %s\n", "ID", "RX CHANNEL", "SUBSCRIPTION", "STATE", "STATUS")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────")
+	var reasons []string
+	for _, s := range subs {
+		subscription := "-"
+		if s.Subscribed() {
+			subscription = s.TxChannel + "@" + s.TxDevice
+		}
+		fmt.Printf("%-4d %-20s %-32s %-16s %s\n", s.ChannelID, s.Channel, subscription, s.State(), s.StatusText())
+		if reason := s.Reason(); reason != "" {
+			reasons = append(reasons, fmt.Sprintf("  %s: %s", s.Channel, reason))
+		}
+	}
+	if len(reasons) > 0 {
+		fmt.Print(i18n.Sprintf("\n%d subscriptions without audio:\n", len(reasons)))
+		fmt.Println(strings.Join(reasons, "\n"))
+	}
+	fmt.Println()
+}
+
+// newPlanCommand golane plan
+func newPlanCommand() *Command {
+	fs := newFlagSet("plan")
+	lf := addLogFlags(fs)
+	base := fs.String("base", DefaultPlanOptions().BaseNetwork, "base network for the address plan")
+	out := fs.String("out", "", "export the generated address plan to this JSON file")
+	dnsmasq := fs.String("dnsmasq", "", "write a dnsmasq DHCP config seeded from the address plan")
+
+	return &Command{
+		Name:  "plan",
+		Short: "Generate an IP address plan for Dante domains (no SDK required)",
+		Args:  "Dante1=40,Dante2=24",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			return runAddressPlanner(args[0], *base, *out, *dnsmasq)
+		},
+	}
+}
+
+// newIncidentsCommand golane incidents list|show|report (讀取狀態目錄或遠端 daemon，不需要 SDK)
+func newIncidentsCommand() *Command {
+	return &Command{
+		Name:  "incidents",
+		Short: "List incidents and print incident reports from the state directory",
+		Sub: []*Command{
+			newIncidentActionCommand("list", "", "List incidents, newest first",
+				func(source incidentSource, fs *incidentFlags, args []string) error {
+					if len(args) > 0 {
+						return errUsage
+					}
+					incidents, err := source.ListIncidents(fs.status)
+					if err != nil {
+						return err
+					}
+					if fs.json {
+						return printJSON(incidents)
+					}
+					fmt.Printf("%-9s %-13s %-9s %-19s %s\n", "ID", "STATUS", "SEVERITY", "OPENED", "TITLE")
+					fmt.Println("────────────────────────────────────────────────────────────────────────────")
+					for _, inc := range incidents {
+						fmt.Printf("%-9s %-13s %-9s %-19s %s\n",
+							inc.ID, inc.Status, inc.Severity, inc.OpenedAt.Format(time.DateTime), inc.Title())
+					}
+					return nil
+				}),
+			newIncidentActionCommand("show", "<id>", "Show an incident with its subjects and timeline",
+				func(source incidentSource, fs *incidentFlags, args []string) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					inc, err := source.GetIncident(args[0])
+					if err != nil {
+						return err
+					}
+					if fs.json {
+						return printJSON(inc)
+					}
+					printIncident(inc)
+					return nil
+				}),
+			newIncidentActionCommand("report", "", "Summarize incidents opened in a period",
+				func(source incidentSource, fs *incidentFlags, args []string) error {
+					if len(args) > 0 {
+						return errUsage
+					}
+					report, err := source.IncidentReport(fs.since)
+					if err != nil {
+						return err
+					}
+					if fs.json {
+						return printJSON(report)
+					}
+					PrintIncidentReport(os.Stdout, report)
+					return nil
+				}),
+		},
+	}
+}
+
+// incidentFlags incidents 子命令參數
+type incidentFlags struct {
+	stateDir string
+	status   string
+	since    time.Duration
+	json     bool
+}
+
+// incidentSource 事件單來源: 本機狀態目錄或遠端 daemon
+type incidentSource interface {
+	ListIncidents(status string) ([]Incident, error)
+	GetIncident(id string) (Incident, error)
+	IncidentReport(period time.Duration) (IncidentReport, error)
+}
+
+// localIncidents 直接讀取狀態目錄
+type localIncidents struct {
+	store *IncidentStore
+}
+
+func (l localIncidents) ListIncidents(status string) ([]Incident, error) {
+	return l.store.List(status), nil
+}
+
+func (l localIncidents) GetIncident(id string) (Incident, error) {
+	inc, ok := l.store.Get(id)
+	if !ok {
+		return Incident{}, fmt.Errorf("%w: %s", errIncidentNotFound, id)
+	}
+	return inc, nil
+}
+
+func (l localIncidents) IncidentReport(period time.Duration) (IncidentReport, error) {
+	return l.store.Report(time.Now().Add(-period)), nil
+}
+
+func newIncidentActionCommand(name, usage, short string, action func(source incidentSource, f *incidentFlags, args []string) error) *Command {
+	fs := newFlagSet("incidents " + name)
+	lf := addLogFlags(fs)
+	remote := addRemoteFlags(fs)
+	f := &incidentFlags{}
+	fs.StringVar(&f.stateDir, "state-dir", ".", "state directory of the monitor")
+	fs.BoolVar(&f.json, "json", false, "print as JSON")
+	switch name {
+	case "list":
+		fs.StringVar(&f.status, "status", "", "only show incidents with this status: open, acknowledged, resolved")
+	case "report":
+		fs.DurationVar(&f.since, "since", 7*24*time.Hour, "report period")
+	}
+
+	return &Command{
+		Name:  name,
+		Short: short,
+		Args:  usage,
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				return action(client, f, args)
+			}
+
+			state, err := OpenStateStore(f.stateDir)
+			if err != nil {
+				return err
+			}
+			store, err := NewIncidentStore(state)
+			if err != nil {
+				return err
+			}
+			return action(localIncidents{store}, f, args)
+		},
+	}
+}
+
+// printIncident 顯示單一事件單
+func printIncident(inc Incident) {
+	fmt.Printf("\n=== %s %s ===\n", inc.ID, inc.Title())
+	rows := [][]string{
+		{i18n.T("Status:"), inc.Status},
+		{i18n.T("Severity:"), inc.Severity},
+		{i18n.T("Opened:"), inc.OpenedAt.Format(time.DateTime)},
+	}
+	if inc.AcknowledgedAt != nil {
+		rows = append(rows, []string{i18n.T("Acked:"), i18n.Sprintf("%s by %s", inc.AcknowledgedAt.Format(time.DateTime), inc.AcknowledgedBy)})
+	}
+	if inc.ResolvedAt != nil {
+		rows = append(rows, []string{i18n.T("Resolved:"), i18n.Sprintf("%s by %s", inc.ResolvedAt.Format(time.DateTime), inc.ResolvedBy)})
+	}
+	printTable(os.Stdout, rows)
+
+	fmt.Print(i18n.Sprintf("\nSubjects (%d):\n", len(inc.Subjects)))
+	for _, sub := range inc.Subjects {
+		mark := "❌"
+		if sub.Recovered {
+			mark = "✅"
+		}
+		fmt.Printf("  %s %-24s %s\n", mark, sub.Name, sub.Message)
+	}
+
+	fmt.Println("\n" + i18n.T("Timeline:"))
+	for _, ev := range inc.Timeline {
+		actor := ""
+		if ev.Actor != "" {
+			actor = " (" + ev.Actor + ")"
+		}
+		fmt.Printf("  %s %-12s%s %s\n", ev.Time.Format(time.DateTime), ev.Type, actor, ev.Message)
+	}
+	fmt.Println()
+}
+
+// newQuarantineCommand golane quarantine list|add|remove
+// 隔離列表由執行中的 monitor 保存，修改必須透過 -host；list 也可直接讀取狀態目錄
+func newQuarantineCommand() *Command {
+	return &Command{
+		Name:  "quarantine",
+		Short: "Mark devices as suspected faulty so routing to them needs an override",
+		Sub: []*Command{
+			newQuarantineActionCommand("list", "", "List quarantined devices",
+				func(source quarantineSource, f *quarantineFlags, args []string) error {
+					if len(args) > 0 {
+						return errUsage
+					}
+					entries, err := source.ListQuarantine()
+					if err != nil {
+						return err
+					}
+					if f.json {
+						return printJSON(entries)
+					}
+					fmt.Printf("%-24s %-19s %-12s %s\n", "DEVICE", "SINCE", "BY", "REASON")
+					fmt.Println("────────────────────────────────────────────────────────────────────────────")
+					for _, e := range entries {
+						fmt.Printf("%-24s %-19s %-12s %s\n", e.Device, e.Since.Local().Format(time.DateTime), e.By, e.Reason)
+					}
+					return nil
+				}),
+			newQuarantineActionCommand("add", "<device>", "Quarantine a device",
+				func(source quarantineSource, f *quarantineFlags, args []string) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					entry, err := source.Quarantine(args[0], f.reason, f.actor)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("%s quarantined\n", entry.Device)
+					return nil
+				}),
+			newQuarantineActionCommand("remove", "<device>", "Release a device from quarantine",
+				func(source quarantineSource, f *quarantineFlags, args []string) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					if err := source.Release(args[0]); err != nil {
+						return err
+					}
+					fmt.Printf("%s released\n", args[0])
+					return nil
+				}),
+		},
+	}
+}
+
+// quarantineFlags quarantine 子命令參數
+type quarantineFlags struct {
+	stateDir string
+	reason   string
+	actor    string
+	json     bool
+}
+
+// quarantineSource 隔離列表來源: 遠端 daemon 或 (只讀的) 本機狀態目錄
+type quarantineSource interface {
+	ListQuarantine() ([]QuarantineEntry, error)
+	Quarantine(device, reason, actor string) (QuarantineEntry, error)
+	Release(device string) error
+}
+
+// errQuarantineNeedsHost 修改隔離列表必須透過執行中的 monitor
+var errQuarantineNeedsHost = errors.New("changing the quarantine list requires -host (the running monitor owns the state file)")
+
+// localQuarantine 直接讀取狀態目錄
+type localQuarantine struct {
+	store *QuarantineStore
+}
+
+func (l localQuarantine) ListQuarantine() ([]QuarantineEntry, error) {
+	return l.store.List(), nil
+}
+
+func (l localQuarantine) Quarantine(device, reason, actor string) (QuarantineEntry, error) {
+	return QuarantineEntry{}, errQuarantineNeedsHost
+}
+
+func (l localQuarantine) Release(device string) error {
+	return errQuarantineNeedsHost
+}
+
+func newQuarantineActionCommand(name, usage, short string, action func(source quarantineSource, f *quarantineFlags, args []string) error) *Command {
+	fs := newFlagSet("quarantine " + name)
+	lf := addLogFlags(fs)
+	remote := addRemoteFlags(fs)
+	f := &quarantineFlags{}
+	fs.StringVar(&f.stateDir, "state-dir", ".", "state directory of the monitor (read-only, use -host to make changes)")
+	switch name {
+	case "list":
+		fs.BoolVar(&f.json, "json", false, "print as JSON")
+	case "add":
+		fs.StringVar(&f.reason, "reason", "", "why the device is quarantined")
+		fs.StringVar(&f.actor, "actor", os.Getenv("USER"), "who quarantines the device")
+	}
+
+	return &Command{
+		Name:  name,
+		Short: short,
+		Args:  usage,
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				return action(client, f, args)
+			}
+
+			state, err := OpenStateStore(f.stateDir)
+			if err != nil {
+				return err
+			}
+			store, err := NewQuarantineStore(state)
+			if err != nil {
+				return err
+			}
+			return action(localQuarantine{store}, f, args)
+		},
+	}
+}
+
+// newAuditCommand golane audit export|verify (讀取狀態目錄或遠端 daemon，不需要 SDK)
+func newAuditCommand() *Command {
+	return &Command{
+		Name:  "audit",
+		Short: "Export and verify the tamper-evident change history",
+		Sub: []*Command{
+			newAuditActionCommand("export", "", "Export audit entries as CSV or JSON",
+				func(source auditSource, f *auditFlags, args []string) error {
+					if len(args) > 0 {
+						return errUsage
+					}
+					filter, err := f.filter()
+					if err != nil {
+						return err
+					}
+					entries, err := source.AuditEntries(filter)
+					if err != nil {
+						return err
+					}
+
+					out := io.Writer(os.Stdout)
+					if f.out != "" {
+						file, err := os.Create(f.out)
+						if err != nil {
+							return err
+						}
+						defer file.Close()
+						out = file
+					}
+					if f.format == "csv" {
+						return WriteAuditCSV(out, entries)
+					}
+					enc := json.NewEncoder(out)
+					enc.SetIndent("", "  ")
+					return enc.Encode(entries)
+				}),
+			newAuditActionCommand("verify", "", "Verify the hash chain of the audit log or of a JSON export (-file)",
+				func(source auditSource, f *auditFlags, args []string) error {
+					if len(args) > 0 {
+						return errUsage
+					}
+					var result AuditVerification
+					if f.file != "" {
+						data, err := os.ReadFile(f.file)
+						if err != nil {
+							return err
+						}
+						var entries []AuditEntry
+						if err := json.Unmarshal(data, &entries); err != nil {
+							return fmt.Errorf("failed to parse %s: %v", f.file, err)
+						}
+						result = verification(entries, VerifyAuditEntries(entries))
+					} else {
+						var err error
+						if result, err = source.VerifyAudit(); err != nil {
+							return err
+						}
+					}
+					if f.json {
+						return printJSON(result)
+					}
+					if !result.Valid {
+						return errors.New(result.Error)
+					}
+					fmt.Printf("✅ %d entries verified, head %s\n", result.Entries, result.Head)
+					return nil
+				}),
+		},
+	}
+}
+
+// auditFlags audit 子命令參數
+type auditFlags struct {
+	stateDir  string
+	since     string
+	until     string
+	actor     string
+	via       string
+	device    string
+	operation string
+	format    string
+	out       string
+	file      string
+	json      bool
+}
+
+// filter 轉成匯出條件
+func (f *auditFlags) filter() (AuditFilter, error) {
+	filter := AuditFilter{Actor: f.actor, Via: f.via, Device: f.device, Operation: f.operation}
+	now := time.Now()
+	var err error
+	if f.since != "" {
+		if filter.Since, err = parseAuditTime(f.since, now); err != nil {
+			return AuditFilter{}, fmt.Errorf("-since: %v", err)
+		}
+	}
+	if f.until != "" {
+		if filter.Until, err = parseAuditTime(f.until, now); err != nil {
+			return AuditFilter{}, fmt.Errorf("-until: %v", err)
+		}
+	}
+	return filter, nil
+}
+
+// auditSource 稽核紀錄來源: 本機狀態目錄或遠端 daemon
+type auditSource interface {
+	AuditEntries(filter AuditFilter) ([]AuditEntry, error)
+	VerifyAudit() (AuditVerification, error)
+}
+
+// localAudit 直接讀取狀態目錄 (只讀，monitor 仍可繼續附加)
+type localAudit struct {
+	log *AuditLog
+}
+
+func (l localAudit) AuditEntries(filter AuditFilter) ([]AuditEntry, error) {
+	return l.log.Entries(filter)
+}
+
+func (l localAudit) VerifyAudit() (AuditVerification, error) {
+	return l.log.Verify()
+}
+
+func newAuditActionCommand(name, usage, short string, action func(source auditSource, f *auditFlags, args []string) error) *Command {
+	fs := newFlagSet("audit " + name)
+	lf := addLogFlags(fs)
+	remote := addRemoteFlags(fs)
+	f := &auditFlags{}
+	fs.StringVar(&f.stateDir, "state-dir", ".", "state directory of the monitor")
+	switch name {
+	case "export":
+		fs.StringVar(&f.since, "since", "", "only entries at or after this time (RFC 3339, or a duration such as 720h)")
+		fs.StringVar(&f.until, "until", "", "only entries before this time (RFC 3339, or a duration such as 24h)")
+		fs.StringVar(&f.actor, "actor", "", "only entries by this user or source (osc, gpio, api@<address>)")
+		fs.StringVar(&f.via, "via", "", "only entries made through this interface: api, cli, trigger")
+		fs.StringVar(&f.device, "device", "", "only entries for this device (the RX device for routes)")
+		fs.StringVar(&f.operation, "operation", "", "only this operation (route.subscribe) or kind (route, quarantine, feature, flow, device, channel)")
+		fs.StringVar(&f.format, "format", "csv", "output format: csv, json (a JSON export can be checked with audit verify -file)")
+		fs.StringVar(&f.out, "out", "", "write the export to this file instead of stdout")
+	case "verify":
+		fs.StringVar(&f.file, "file", "", "verify this JSON export instead of the audit log")
+		fs.BoolVar(&f.json, "json", false, "print as JSON")
+	}
+
+	return &Command{
+		Name:  name,
+		Short: short,
+		Args:  usage,
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if f.format != "" && f.format != "csv" && f.format != "json" {
+				return fmt.Errorf("unknown -format %q, use csv or json", f.format)
+			}
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				return action(client, f, args)
+			}
+
+			log, err := OpenAuditLog(f.stateDir)
+			if err != nil {
+				return err
+			}
+			return action(localAudit{log}, f, args)
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+// token
+//------------------------------------------------------------------------------
+
+// newTokenCommand 發行與撤銷管理 API 權杖 (直接寫入狀態目錄，monitor 不需重啟)
+func newTokenCommand() *Command {
+	return &Command{
+		Name:  "token",
+		Short: "Issue, list and revoke management API tokens",
+		Sub: []*Command{
+			newTokenActionCommand("issue", "<name>", "Issue a new token and print it once",
+				func(stateDir, role string, args []string) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					token, err := IssueToken(stateDir, args[0], role)
+					if err != nil {
+						return err
+					}
+					fmt.Println(token)
+					fmt.Fprintln(os.Stderr, "Store this token now, it cannot be shown again")
+					return nil
+				}),
+			newTokenActionCommand("list", "", "List issued tokens (tokens from the config file are not shown)",
+				func(stateDir, role string, args []string) error {
+					if len(args) > 0 {
+						return errUsage
+					}
+					tokens, err := ListIssuedTokens(stateDir)
+					if err != nil {
+						return err
+					}
+					printIssuedTokens(os.Stdout, tokens)
+					return nil
+				}),
+			newTokenActionCommand("revoke", "<name>", "Revoke an issued token",
+				func(stateDir, role string, args []string) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					if err := RevokeToken(stateDir, args[0]); err != nil {
+						return err
+					}
+					fmt.Printf("Revoked token %q\n", args[0])
+					return nil
+				}),
+		},
+	}
+}
+
+func newTokenActionCommand(name, usage, short string, action func(stateDir, role string, args []string) error) *Command {
+	fs := newFlagSet("token " + name)
+	lf := addLogFlags(fs)
+	stateDir := fs.String("state-dir", ".", "state directory of the monitor")
+	var role string
+	if name == "issue" {
+		fs.StringVar(&role, "role", RoleOperator, "token role: viewer (read only), operator (routing and other control), admin (also feature flags)")
+	}
+
+	return &Command{
+		Name:  name,
+		Short: short,
+		Args:  usage,
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			return action(*stateDir, role, args)
+		},
+	}
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 時鐘同步追蹤
+//==============================================================================
+
+// 現場聽到的斷音最常來自時鐘：設備短暫失去 PTP 同步，或 grandmaster 因為
+// 設備重開機、偏好設定不一致而換手，整個網域重新同步。單次的狀態只顯示在
+// 儀表板上，ClockTracker 記錄每台設備的同步狀態歷史：
+//
+//   - Window 內失去同步 LossCount 次以上時告警 (偶發一次不告警)，Window 內
+//     沒有再失去同步且目前已同步時解除
+//   - 網域的 grandmaster 換成另一台設備時告警，說明原本的 grandmaster 是否
+//     仍在網路上 (仍在時通常是偏好設定或網路問題)
+
+// 時鐘告警種類
+const (
+	AlertClockSyncLoss     = "clock-sync-loss"
+	AlertGrandmasterChange = "grandmaster-change"
+)
+
+// ClockOptions 時鐘追蹤參數
+type ClockOptions struct {
+	Interval  time.Duration `json:"interval"`   // 查詢時鐘狀態的間隔
+	Window    time.Duration `json:"window"`     // 計算失去同步次數的期間
+	LossCount int           `json:"loss_count"` // Window 內失去同步達此次數時告警
+}
+
+// DefaultClockOptions 每 10 秒查詢，10 分鐘內失去同步 3 次告警
+func DefaultClockOptions() ClockOptions {
+	return ClockOptions{Interval: 10 * time.Second, Window: 10 * time.Minute, LossCount: 3}
+}
+
+// Validate 檢查參數
+func (o ClockOptions) Validate() error {
+	if o.Interval <= 0 || o.Window <= 0 || o.LossCount <= 0 {
+		return fmt.Errorf("invalid clock tracking interval %s, window %s or loss count %d", o.Interval, o.Window, o.LossCount)
+	}
+	return nil
+}
+
+// clockSynced 時鐘狀態是否表示已同步 (ConMon 的狀態字串依韌體版本大小寫不同)
+// grandmaster 自己沒有 servo 可鎖定，視為已同步
+func clockSynced(info dante.ClockInfo) bool {
+	if info.IsGrandmaster {
+		return true
+	}
+	if strings.EqualFold(info.ClockState, "faulty") {
+		return false
+	}
+	servo := strings.ToLower(info.ServoState)
+	if servo == "" {
+		servo = strings.ToLower(info.ClockState)
+	}
+	switch {
+	case strings.Contains(servo, "unlock"), strings.Contains(servo, "unsync"):
+		return false
+	case strings.Contains(servo, "lock"), strings.Contains(servo, "sync"):
+		return true
+	}
+	return false
+}
+
+// DeviceClock 單一設備的時鐘狀態與歷史
+type DeviceClock struct {
+	Domain string `json:"domain"`
+	Device string `json:"device"`
+	dante.ClockInfo
+	Synced   bool      `json:"synced"`
+	Since    time.Time `json:"since"`               // 目前同步狀態開始的時間
+	Losses   int       `json:"losses"`              // Window 內失去同步的次數
+	LastLoss time.Time `json:"last_loss,omitempty"` // 最後一次失去同步
+}
+
+// ClockDomain 網域的 grandmaster
+type ClockDomain struct {
+	Domain      string    `json:"domain"`
+	Grandmaster string    `json:"grandmaster"`        // 空白表示還沒有設備回報為 grandmaster
+	Previous    string    `json:"previous,omitempty"` // 上一個 grandmaster
+	Changes     int       `json:"changes"`            // 觀察到的換手次數
+	Changed     time.Time `json:"changed,omitempty"`  // 最後一次換手
+}
+
+// ClockStatus /api/clock 的回應
+type ClockStatus struct {
+	Options ClockOptions  `json:"options"`
+	Domains []ClockDomain `json:"domains"`
+	Devices []DeviceClock `json:"devices"`
+}
+
+// clockHistory 追蹤中的設備
+type clockHistory struct {
+	DeviceClock
+	losses  []time.Time
+	alerted bool
+}
+
+// ClockTracker 各網域設備的時鐘同步歷史，所有網域的 domainWorker 共用同一個
+type ClockTracker struct {
+	mu      sync.Mutex
+	alerts  *AlertManager // nil 表示只記錄 (diag clock)
+	opts    ClockOptions
+	devices map[string]*clockHistory // 網域|小寫設備名稱
+	domains map[string]*ClockDomain
+}
+
+// NewClockTracker 建立追蹤器
+func NewClockTracker(alerts *AlertManager, opts ClockOptions) *ClockTracker {
+	return &ClockTracker{
+		alerts:  alerts,
+		opts:    opts,
+		devices: make(map[string]*clockHistory),
+		domains: make(map[string]*ClockDomain),
+	}
+}
+
+// Update 以網域的設備列表與收到的時鐘狀態 (依設備名稱) 更新歷史
+// 沒有狀態的設備保留上一次的狀態，離開列表的設備不再追蹤 (由 device-offline 處理)
+func (t *ClockTracker) Update(domain string, devices []dante.Device, infos map[string]dante.ClockInfo, now time.Time) {
+	t.mu.Lock()
+	var raise []Alert
+	var resolve []string
+
+	listed := make(map[string]bool, len(devices))
+	var grandmasters []string
+	for _, dev := range devices {
+		key := domain + "|" + strings.ToLower(dev.Name)
+		listed[key] = true
+		info, ok := infos[dev.Name]
+		if !ok {
+			continue
+		}
+		if info.IsGrandmaster {
+			grandmasters = append(grandmasters, dev.Name)
+		}
+
+		synced := clockSynced(info)
+		h := t.devices[key]
+		switch {
+		case h == nil:
+			h = &clockHistory{DeviceClock: DeviceClock{Domain: domain, Device: dev.Name, Synced: synced, Since: now}}
+			t.devices[key] = h
+		case h.Synced && !synced:
+			h.losses = append(h.losses, now)
+			h.LastLoss, h.Since = now, now
+			logger.Warn("Device lost clock sync", "domain", domain, "device", dev.Name,
+				"clock_state", info.ClockState, "servo_state", info.ServoState)
+		case !h.Synced && synced:
+			h.Since = now
+		}
+		h.Device, h.ClockInfo, h.Synced = dev.Name, info, synced
+
+		h.losses = slices.DeleteFunc(h.losses, func(at time.Time) bool { return now.Sub(at) > t.opts.Window })
+		h.Losses = len(h.losses)
+		switch {
+		case !h.alerted && h.Losses >= t.opts.LossCount:
+			h.alerted = true
+			raise = append(raise, Alert{
+				Kind:     AlertClockSyncLoss,
+				Severity: SeverityWarning,
+				Domain:   domain,
+				Subject:  dev.Name,
+				Message: fmt.Sprintf("device %s lost clock sync %d times in %s (now %s), expect audible dropouts",
+					dev.Name, h.Losses, t.opts.Window, clockText(info)),
+				Time: now,
+			})
+		case h.alerted && h.Losses == 0 && synced:
+			h.alerted = false
+			resolve = append(resolve, AlertClockSyncLoss+"|"+dev.Name)
+		}
+	}
+	for key, h := range t.devices {
+		if strings.HasPrefix(key, domain+"|") && !listed[key] {
+			if h.alerted {
+				resolve = append(resolve, AlertClockSyncLoss+"|"+h.Device)
+			}
+			delete(t.devices, key)
+		}
+	}
+
+	// grandmaster 換手 (第一次看到的 grandmaster 只記錄)
+	cd := t.domains[domain]
+	if cd == nil {
+		cd = &ClockDomain{Domain: domain}
+		t.domains[domain] = cd
+	}
+	if len(grandmasters) > 0 {
+		slices.Sort(grandmasters)
+		current := grandmasters[0]
+		if slices.ContainsFunc(grandmasters, func(name string) bool { return strings.EqualFold(name, cd.Grandmaster) }) {
+			current = cd.Grandmaster // 多台同時回報時維持原本的 grandmaster
+		}
+		if cd.Grandmaster != "" && !strings.EqualFold(current, cd.Grandmaster) {
+			cause := "after it left the network"
+			if listed[domain+"|"+strings.ToLower(cd.Grandmaster)] {
+				cause = fmt.Sprintf("while %s is still online, check the preferred leader settings and the PTP path between them", cd.Grandmaster)
+			}
+			cd.Previous, cd.Changed = cd.Grandmaster, now
+			cd.Changes++
+			if cd.Previous != "" {
+				resolve = append(resolve, AlertGrandmasterChange+"|"+cd.Previous)
+			}
+			raise = append(raise, Alert{
+				Kind:     AlertGrandmasterChange,
+				Severity: SeverityWarning,
+				Domain:   domain,
+				Subject:  current,
+				Message:  fmt.Sprintf("PTP grandmaster changed from %s to %s %s, devices resynchronize and may drop audio", cd.Previous, current, cause),
+				Time:     now,
+			})
+			logger.Warn("PTP grandmaster changed", "domain", domain, "from", cd.Previous, "to", current)
+		}
+		cd.Grandmaster = current
+	}
+	t.mu.Unlock()
+
+	if t.alerts == nil {
+		return
+	}
+	// 先解除上一個 grandmaster 的告警，再提交新的
+	for _, r := range resolve {
+		kind, subject, _ := strings.Cut(r, "|")
+		t.alerts.Resolve(kind, domain, subject)
+	}
+	for _, a := range raise {
+		t.alerts.Raise(a)
+	}
+}
+
+// Status 目前的狀態 (依網域與設備排序)
+func (t *ClockTracker) Status() ClockStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := ClockStatus{Options: t.opts, Domains: []ClockDomain{}, Devices: []DeviceClock{}}
+	for _, cd := range t.domains {
+		status.Domains = append(status.Domains, *cd)
+	}
+	for _, h := range t.devices {
+		status.Devices = append(status.Devices, h.DeviceClock)
+	}
+	slices.SortFunc(status.Domains, func(a, b ClockDomain) int { return strings.Compare(a.Domain, b.Domain) })
+	slices.SortFunc(status.Devices, func(a, b DeviceClock) int {
+		if c := strings.Compare(a.Domain, b.Domain); c != 0 {
+			return c
+		}
+		return strings.Compare(strings.ToLower(a.Device), strings.ToLower(b.Device))
+	})
+	return status
+}
+
+// clockText 時鐘狀態的簡短說明
+func clockText(info dante.ClockInfo) string {
+	text := info.ClockState
+	if info.ServoState != "" {
+		text += "/" + info.ServoState
+	}
+	if text == "" {
+		return "unknown"
+	}
+	return text
+}
+
+//------------------------------------------------------------------------------
+// 監控
+//------------------------------------------------------------------------------
+
+// pollClocks 讀取設備最新的時鐘狀態交給 ClockTracker，並重新查詢 (回應在下一次讀取)
+func (w *domainWorker) pollClocks() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d := w.domain
+	if w.report == nil || w.clocks == nil {
+		return
+	}
+	devices := d.GetDevices()
+	infos := readClocks(d, devices)
+	w.clocks.Update(d.Name, devices, infos, time.Now())
+}
+
+// readClocks 讀取已收到的時鐘狀態並重新查詢每台設備
+func readClocks(d *dante.Domain, devices []dante.Device) map[string]dante.ClockInfo {
+	infos := make(map[string]dante.ClockInfo, len(devices))
+	for _, dev := range devices {
+		if info, ok := d.ClockInfo(dev.Name); ok {
+			infos[dev.Name] = info
+		}
+		if err := d.WatchClock(dev.Name); err != nil {
+			d.Logger().Debug("Clock query failed", "device", dev.Name, "err", err)
+		}
+	}
+	return infos
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// handleClock GET /api/clock
+func (s *APIServer) handleClock(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.clocks.Status())
+}
+
+// Clock daemon 追蹤的時鐘狀態
+func (c *RemoteClient) Clock() (ClockStatus, error) {
+	var status ClockStatus
+	return status, c.do(http.MethodGet, "/api/clock", nil, &status)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newClockCommand golane diag clock
+func newClockCommand() *Command {
+	fs := newFlagSet("clock")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	duration := fs.Duration("duration", 30*time.Second, "how long to watch the clocks (local mode)")
+	opts := DefaultClockOptions()
+	opts.Interval = time.Second
+	fs.DurationVar(&opts.Interval, "interval", opts.Interval, "how often to query the clocks (local mode)")
+	jsonOut := fs.Bool("json", false, "print the clock status as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "clock",
+		Short: "Show PTP clock sync state, sync losses and grandmaster changes",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if *duration <= 0 {
+				return fmt.Errorf("invalid duration %s", *duration)
+			}
+			opts.Window = *duration
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			var status ClockStatus
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if status, err = client.Clock(); err != nil {
+					return err
+				}
+			} else {
+				if err := ifaces.checkTiming(*wait); err != nil {
+					return err
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+				domain, err := ifaces.openPrimaryDomain(ctx, detector)
+				if err != nil {
+					return err
+				}
+				defer domain.Cleanup()
+				if err := domain.StartMonitoring(); err != nil {
+					return err
+				}
+				if err := discover(ctx, domain, *wait); err != nil {
+					return err
+				}
+
+				tracker := NewClockTracker(nil, opts)
+				end := time.Now().Add(*duration)
+				ticker := time.NewTicker(opts.Interval)
+				defer ticker.Stop()
+				logger.Info("Watching clocks", "duration", *duration, "interval", opts.Interval)
+				for time.Now().Before(end) {
+					devices := domain.GetDevices()
+					tracker.Update(domain.Name, devices, readClocks(domain, devices), time.Now())
+					select {
+					case <-ctx.Done():
+						end = time.Now()
+					case <-ticker.C:
+					}
+				}
+				status = tracker.Status()
+			}
+
+			if *jsonOut {
+				return printJSON(status)
+			}
+			printClockStatus(status)
+			return nil
+		},
+	}
+}
+
+// printClockStatus 印出時鐘狀態
+func printClockStatus(status ClockStatus) {
+	fmt.Print(i18n.Sprintf("\n=== Clock sync (losses within %s) ===\n", status.Options.Window))
+	for _, cd := range status.Domains {
+		gm := cd.Grandmaster
+		if gm == "" {
+			gm = i18n.T("(none reported)")
+		}
+		fmt.Print(i18n.Sprintf("%s grandmaster: %s", cd.Domain, gm))
+		if cd.Changes > 0 {
+			fmt.Print(i18n.Sprintf("  (changed %d times, last from %s at %s)", cd.Changes, cd.Previous, cd.Changed.Local().Format(time.TimeOnly)))
+		}
+		fmt.Println()
+	}
+	printHeader("\n%-10s %-20s %-14s %-14s %-10s %-7s %-10s %s\n", "DOMAIN", "DEVICE", "CLOCK", "SERVO", "SOURCE", "SYNCED", "SINCE", "LOSSES")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────────")
+	for _, d := range status.Devices {
+		synced := "no"
+		if d.Synced {
+			synced = "yes"
+		}
+		device := d.Device
+		if d.IsGrandmaster {
+			device += " (GM)"
+		}
+		fmt.Printf("%-10s %-20s %-14s %-14s %-10s %-7s %-10s %d\n", d.Domain, device, d.ClockState, d.ServoState,
+			d.ClockSource, synced, d.Since.Local().Format(time.TimeOnly), d.Losses)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+func TestClockSynced(t *testing.T) {
+	for _, tc := range []struct {
+		info dante.ClockInfo
+		want bool
+	}{
+		{dante.ClockInfo{ClockState: "SLAVE", ServoState: "LOCKED"}, true},
+		{dante.ClockInfo{ClockState: "slave", ServoState: "sync"}, true},
+		{dante.ClockInfo{ClockState: "SLAVE", ServoState: "UNLOCKED"}, false},
+		{dante.ClockInfo{ClockState: "LISTENING", ServoState: "HOLDOVER"}, false},
+		{dante.ClockInfo{ClockState: "FAULTY", ServoState: "LOCKED"}, false},
+		{dante.ClockInfo{ClockState: "locked"}, true},
+		{dante.ClockInfo{ClockState: "MASTER", ServoState: "NONE", IsGrandmaster: true}, true},
+	} {
+		if got := clockSynced(tc.info); got != tc.want {
+			t.Errorf("clockSynced(%+v) = %v", tc.info, got)
+		}
+	}
+}
+
+func TestClockTrackerSyncLoss(t *testing.T) {
+	var sent []AlertNotification
+	alerts := NewAlertManager(NoiseFloor{}, func(n AlertNotification) { sent = append(sent, n) })
+	tracker := NewClockTracker(alerts, ClockOptions{Interval: time.Second, Window: 10 * time.Minute, LossCount: 2})
+
+	devices := []dante.Device{{Name: "Amp-Left"}, {Name: "Console"}}
+	locked := dante.ClockInfo{ClockState: "SLAVE", ServoState: "LOCKED"}
+	unlocked := dante.ClockInfo{ClockState: "SLAVE", ServoState: "UNLOCKED"}
+	gm := dante.ClockInfo{ClockState: "MASTER", IsGrandmaster: true}
+	now := time.Unix(1700000000, 0)
+	update := func(amp dante.ClockInfo) {
+		tracker.Update("Dante1", devices, map[string]dante.ClockInfo{"Amp-Left": amp, "Console": gm}, now)
+		now = now.Add(time.Minute)
+	}
+
+	// 一次失去同步不告警，Window 內第二次告警
+	update(locked)
+	update(unlocked)
+	update(locked)
+	if len(sent) != 0 {
+		t.Fatalf("alert after a single loss: %+v", sent)
+	}
+	update(unlocked)
+	if len(sent) != 1 || sent[0].Kind != AlertClockSyncLoss || sent[0].Alerts[0].Subject != "Amp-Left" {
+		t.Fatalf("notifications: %+v", sent)
+	}
+	status := tracker.Status()
+	if len(status.Devices) != 2 || status.Devices[0].Losses != 2 || status.Devices[0].Synced {
+		t.Fatalf("status: %+v", status.Devices)
+	}
+	if len(status.Domains) != 1 || status.Domains[0].Grandmaster != "Console" || status.Domains[0].Changes != 0 {
+		t.Fatalf("domains: %+v", status.Domains)
+	}
+
+	// Window 過後且已同步時解除，再次失去同步重新計算
+	update(locked)
+	now = now.Add(time.Hour)
+	update(locked)
+	if status := tracker.Status(); status.Devices[0].Losses != 0 || !status.Devices[0].Synced {
+		t.Fatalf("status after window: %+v", status.Devices[0])
+	}
+	update(unlocked)
+	if len(sent) != 1 {
+		t.Fatalf("alert after resolve and a single loss: %+v", sent)
+	}
+}
+
+func TestClockTrackerGrandmasterChange(t *testing.T) {
+	var sent []AlertNotification
+	alerts := NewAlertManager(NoiseFloor{}, func(n AlertNotification) { sent = append(sent, n) })
+	tracker := NewClockTracker(alerts, DefaultClockOptions())
+
+	gm := dante.ClockInfo{ClockState: "MASTER", IsGrandmaster: true}
+	follower := dante.ClockInfo{ClockState: "SLAVE", ServoState: "LOCKED"}
+	devices := []dante.Device{{Name: "Console"}, {Name: "Stage-Box"}}
+	now := time.Unix(1700000000, 0)
+
+	tracker.Update("Dante1", devices, map[string]dante.ClockInfo{"Console": gm, "Stage-Box": follower}, now)
+	// 狀態還沒收到的輪次不影響 grandmaster
+	tracker.Update("Dante1", devices, map[string]dante.ClockInfo{}, now.Add(time.Second))
+	if len(sent) != 0 {
+		t.Fatalf("alert without a change: %+v", sent)
+	}
+
+	// 原本的 grandmaster 仍在線上時換手
+	tracker.Update("Dante1", devices, map[string]dante.ClockInfo{"Console": follower, "Stage-Box": gm}, now.Add(2*time.Second))
+	if len(sent) != 1 || sent[0].Kind != AlertGrandmasterChange || !strings.Contains(sent[0].Message, "Console is still online") {
+		t.Fatalf("notifications: %+v", sent)
+	}
+
+	// 原本的 grandmaster 離開網路
+	tracker.Update("Dante1", devices[:1], map[string]dante.ClockInfo{"Console": gm}, now.Add(3*time.Second))
+	status := tracker.Status()
+	if len(sent) != 2 || !strings.Contains(sent[1].Message, "after it left the network") {
+		t.Fatalf("notifications: %+v", sent)
+	}
+	if d := status.Domains[0]; d.Grandmaster != "Console" || d.Previous != "Stage-Box" || d.Changes != 2 || len(status.Devices) != 1 {
+		t.Fatalf("status: %+v", status)
+	}
+}
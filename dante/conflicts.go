@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// 設備名稱衝突
+//==============================================================================
+
+// Dante 以設備名稱 (不分大小寫) 解析訂閱，兩台設備同名時接收端會訂閱到
+// 其中任意一台，或解析失敗。兩個網域各自的列表看不出問題 (例如更換的設備
+// 以舊名稱接到另一個網域)，所以由 NameConflictTracker 合併所有網域的列表檢查。
+// 備援設備在 primary 與 secondary 網路的兩筆記錄是同一台，不算衝突 (見 redundant.go)。
+
+// NameConflictDevice 使用衝突名稱的設備
+type NameConflictDevice struct {
+	Domain     string `json:"domain"`
+	IPAddress  string `json:"ip_address"`
+	MacAddress string `json:"mac_address,omitempty"`
+}
+
+// NameConflict 被多台設備使用的名稱
+type NameConflict struct {
+	Name    string               `json:"name"`
+	Devices []NameConflictDevice `json:"devices"`
+}
+
+// Describe 說明其他使用相同名稱的設備 (domain、ip 為目前這台)
+func (c NameConflict) Describe(domain, ip string) string {
+	var others []string
+	for _, dev := range c.Devices {
+		if dev.Domain == domain && dev.IPAddress == ip {
+			continue
+		}
+		others = append(others, fmt.Sprintf("%s (%s)", dev.Domain, dev.IPAddress))
+	}
+	return "name also used in " + strings.Join(others, ", ")
+}
+
+// String 告警訊息
+func (c NameConflict) String() string {
+	devices := make([]string, len(c.Devices))
+	for i, dev := range c.Devices {
+		devices[i] = fmt.Sprintf("%s %s", dev.Domain, dev.IPAddress)
+	}
+	return fmt.Sprintf("device name %s is used by %d devices (%s), subscriptions to it are ambiguous",
+		c.Name, len(c.Devices), strings.Join(devices, ", "))
+}
+
+// FindNameConflicts 找出各網域設備列表中重複的名稱 (key 為小寫名稱)
+func FindNameConflicts(lists map[string][]dante.Device) map[string]NameConflict {
+	// 依網域名稱處理，讓衝突中的設備順序固定
+	domains := make([]string, 0, len(lists))
+	for domain := range lists {
+		domains = append(domains, domain)
+	}
+	slices.Sort(domains)
+
+	byName := make(map[string]*NameConflict)
+	seen := make(map[string][]dante.Device) // 小寫名稱 → 已記錄的設備
+	for _, domain := range domains {
+		for _, dev := range lists[domain] {
+			key := strings.ToLower(dev.Name)
+			if slices.ContainsFunc(seen[key], func(other dante.Device) bool { return samePhysicalDevice(other, dev) }) {
+				continue
+			}
+			seen[key] = append(seen[key], dev)
+			c := byName[key]
+			if c == nil {
+				c = &NameConflict{Name: dev.Name}
+				byName[key] = c
+			}
+			c.Devices = append(c.Devices, NameConflictDevice{Domain: domain, IPAddress: dev.IPAddress, MacAddress: dev.MacAddress})
+		}
+	}
+
+	conflicts := make(map[string]NameConflict)
+	for key, c := range byName {
+		if len(c.Devices) > 1 {
+			conflicts[key] = *c
+		}
+	}
+	return conflicts
+}
+
+// NameConflictTracker 合併各網域最新的設備列表，名稱衝突出現時告警、消失時解除
+// 所有網域的 domainWorker 共用同一個
+type NameConflictTracker struct {
+	mu     sync.Mutex
+	alerts *AlertManager
+	lists  map[string][]dante.Device
+	active map[string]bool // 小寫名稱 → 已告警
+}
+
+// NewNameConflictTracker 建立追蹤器
+func NewNameConflictTracker(alerts *AlertManager) *NameConflictTracker {
+	return &NameConflictTracker{
+		alerts: alerts,
+		lists:  make(map[string][]dante.Device),
+		active: make(map[string]bool),
+	}
+}
+
+// Update 以網域最新的設備列表重新檢查，回傳目前的衝突
+func (t *NameConflictTracker) Update(domain string, devices []dante.Device) map[string]NameConflict {
+	t.mu.Lock()
+	t.lists[domain] = devices
+	conflicts := FindNameConflicts(t.lists)
+
+	var raise []NameConflict
+	var resolve []string
+	for key, c := range conflicts {
+		if !t.active[key] {
+			t.active[key] = true
+			raise = append(raise, c)
+		}
+	}
+	for key := range t.active {
+		if _, ok := conflicts[key]; !ok {
+			delete(t.active, key)
+			resolve = append(resolve, key)
+		}
+	}
+	t.mu.Unlock()
+
+	// 衝突可能跨網域，告警不屬於單一網域
+	for _, c := range raise {
+		t.alerts.Raise(Alert{
+			Kind:     AlertNameConflict,
+			Severity: SeverityWarning,
+			Subject:  c.Name,
+			Message:  c.String(),
+		})
+	}
+	for _, name := range resolve {
+		t.alerts.Resolve(AlertNameConflict, "", name)
+	}
+	return conflicts
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestNameConflictTracker(t *testing.T) {
+	var sent []AlertNotification
+	alerts := NewAlertManager(NoiseFloor{}, func(n AlertNotification) { sent = append(sent, n) })
+	tracker := NewNameConflictTracker(alerts)
+
+	dante1 := []dante.Device{
+		{Name: "Stage-Box-A", IPAddress: "10.0.1.10"},
+		{Name: "Amp-Left", IPAddress: "10.0.1.20"},
+	}
+	if conflicts := tracker.Update("Dante1", dante1); len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+
+	// 更換的設備以舊名稱 (大小寫不同) 接到另一個網域
+	conflicts := tracker.Update("Dante2", []dante.Device{{Name: "stage-box-a", IPAddress: "10.0.2.10"}})
+	c, ok := conflicts["stage-box-a"]
+	if !ok || len(c.Devices) != 2 || c.Devices[0].Domain != "Dante1" || c.Devices[1].Domain != "Dante2" {
+		t.Fatalf("cross-domain conflict: %+v", conflicts)
+	}
+	if got := c.Describe("Dante1", "10.0.1.10"); got != "name also used in Dante2 (10.0.2.10)" {
+		t.Fatalf("Describe() = %q", got)
+	}
+	if len(sent) != 1 || sent[0].Kind != AlertNameConflict || !strings.Contains(sent[0].Message, "Dante2 10.0.2.10") {
+		t.Fatalf("notifications: %+v", sent)
+	}
+
+	// 衝突持續時不重複告警，同網域內的重複也檢查
+	dante1 = append(dante1, dante.Device{Name: "Amp-Left", IPAddress: "10.0.1.21"})
+	conflicts = tracker.Update("Dante1", dante1)
+	if len(conflicts) != 2 || len(sent) != 2 || sent[1].Alerts[0].Subject != "Amp-Left" {
+		t.Fatalf("conflicts %v, notifications %+v", conflicts, sent)
+	}
+
+	// 改名後解除，再次出現時重新告警
+	tracker.Update("Dante2", []dante.Device{{Name: "Stage-Box-B", IPAddress: "10.0.2.10"}})
+	conflicts = tracker.Update("Dante1", dante1[:2])
+	if len(conflicts) != 0 {
+		t.Fatalf("conflicts after rename: %v", conflicts)
+	}
+	tracker.Update("Dante2", []dante.Device{{Name: "Stage-Box-A", IPAddress: "10.0.2.10"}})
+	if len(sent) != 3 {
+		t.Fatalf("conflict after resolve not raised again: %+v", sent)
+	}
+}
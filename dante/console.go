@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// ASCII 控制台
+//==============================================================================
+
+// 舊型中控系統只能送出一行文字的指令，ControlConsole 提供與管理 API 相同的
+// 查詢與路由操作，以一行一個指令的 ASCII 協定存取 (序列埠見 serialcontrol.go；
+// Crestron/AMX 的 TCP 協定見 tcpcontrol.go，指令較簡短但共用路由操作)。
+// 指令不分大小寫，參數以空白分隔，含空白的通道名稱以雙引號包住：
+//
+//	STATUS                              網域狀態
+//	DEVICES                             目前選擇網域的設備
+//	ROUTES <rx-device>                  接收設備的訂閱
+//	ROUTE <rx-ch>@<rx-dev> <tx-ch>@<tx-dev>
+//	UNROUTE <rx-ch>@<rx-dev>
+//	RECALL <preset>                     套用 preset
+//	TRIGGER <input>                     觸發輸入 (triggers.map)
+//	ALARMS                              作用中的告警
+//	DOMAIN [name]                       選擇多網域時操作的網域
+//	ECHO ON|OFF                         回顯輸入 (以終端機操作時使用)
+//	HELP
+//
+// 每個指令先回傳零或多行結果，最後一行為 OK 或 ERR <原因>；行尾為 CRLF。
+// 路由變更與管理 API 一樣經過稽核與 routing 功能開關，且不能接到隔離中的設備。
+
+// consoleCommandTimeout 單一指令的 SDK 操作時間上限
+const consoleCommandTimeout = 30 * time.Second
+
+// consoleMaxLine 一行指令的長度上限
+const consoleMaxLine = 1024
+
+// consoleHelp HELP 的內容
+var consoleHelp = []string{
+	"STATUS",
+	"DEVICES",
+	"ROUTES <rx-device>",
+	"ROUTE <rx-channel>@<rx-device> <tx-channel>@<tx-device>",
+	"UNROUTE <rx-channel>@<rx-device>",
+	"RECALL <preset>",
+	"TRIGGER <input>",
+	"ALARMS",
+	"DOMAIN [name]",
+	"ECHO ON|OFF",
+}
+
+// ControlConsole ASCII 指令的直譯器 (各連線共用)
+type ControlConsole struct {
+	domains    *supervisor.Supervisor
+	routes     map[string]RouteController
+	triggers   *TriggerEngine   // nil 表示沒有 preset
+	quarantine *QuarantineStore // nil 表示不檢查
+	alarms     *AlarmEngine     // nil 表示沒有告警
+	features   *FeatureFlags
+}
+
+// consoleSession 一條連線的狀態
+type consoleSession struct {
+	source string // 稽核與日誌顯示的來源 (例如 serial:/dev/ttyUSB0)
+	domain string // DOMAIN 選擇的網域
+	echo   bool
+}
+
+// NewControlConsole 建立控制台
+func NewControlConsole(domains *supervisor.Supervisor, routes map[string]RouteController, triggers *TriggerEngine,
+	quarantine *QuarantineStore, alarms *AlarmEngine, features *FeatureFlags) *ControlConsole {
+	return &ControlConsole{domains: domains, routes: routes, triggers: triggers, quarantine: quarantine, alarms: alarms, features: features}
+}
+
+// Serve 逐行讀取指令並回應，直到讀取結束或 ctx 結束
+func (c *ControlConsole) Serve(ctx context.Context, rw io.ReadWriter, s *consoleSession) error {
+	scanner := bufio.NewScanner(rw)
+	scanner.Buffer(make([]byte, 0, 256), consoleMaxLine)
+	scanner.Split(scanConsoleLines)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var out bytes.Buffer
+		if s.echo {
+			out.WriteString(line + "\r\n")
+		}
+		cmdCtx, cancel := context.WithTimeout(ctx, consoleCommandTimeout)
+		lines, err := c.Execute(cmdCtx, s, line)
+		cancel()
+		for _, l := range lines {
+			out.WriteString(l + "\r\n")
+		}
+		if err != nil {
+			fmt.Fprintf(&out, "ERR %s\r\n", strings.ReplaceAll(err.Error(), "\n", " "))
+		} else {
+			out.WriteString("OK\r\n")
+		}
+		if _, err := rw.Write(out.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// scanConsoleLines 以 CR、LF 或 CRLF 分行 (中控系統常只送 CR)
+func scanConsoleLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// Execute 執行一行指令，回傳結果的各行
+func (c *ControlConsole) Execute(ctx context.Context, s *consoleSession, line string) ([]string, error) {
+	args, err := splitConsoleArgs(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+	cmd, args := strings.ToUpper(args[0]), args[1:]
+	want := func(n int, usage string) error {
+		if len(args) != n {
+			return fmt.Errorf("usage: %s", usage)
+		}
+		return nil
+	}
+
+	switch cmd {
+	case "HELP", "?":
+		return consoleHelp, nil
+	case "STATUS":
+		return c.status(), nil
+	case "DEVICES":
+		snap, err := c.snapshot(s)
+		if err != nil {
+			return nil, err
+		}
+		lines := make([]string, 0, len(snap.Devices))
+		for _, d := range snap.Devices {
+			lines = append(lines, fmt.Sprintf("DEVICE %s %s %s", d.Name, consoleValue(d.IPAddress), consoleValue(d.Model)))
+		}
+		return lines, nil
+	case "ROUTES":
+		if err := want(1, "ROUTES <rx-device>"); err != nil {
+			return nil, err
+		}
+		return c.listRoutes(ctx, s, args[0])
+	case "ROUTE":
+		if err := want(2, "ROUTE <rx-channel>@<rx-device> <tx-channel>@<tx-device>"); err != nil {
+			return nil, err
+		}
+		return nil, c.route(ctx, s, args[0], args[1])
+	case "UNROUTE":
+		if err := want(1, "UNROUTE <rx-channel>@<rx-device>"); err != nil {
+			return nil, err
+		}
+		return nil, c.route(ctx, s, args[0], "")
+	case "RECALL", "TRIGGER":
+		if err := want(1, cmd+" <name>"); err != nil {
+			return nil, err
+		}
+		return c.recall(ctx, s, cmd, args[0])
+	case "ALARMS":
+		if c.alarms == nil {
+			return nil, nil
+		}
+		var lines []string
+		for _, a := range c.alarms.Status().Active {
+			lines = append(lines, fmt.Sprintf("ALARM %s %s %s", a.Severity, consoleValue(a.Domain), a.Message))
+		}
+		return lines, nil
+	case "DOMAIN":
+		if len(args) == 0 {
+			return []string{"DOMAIN " + consoleValue(s.domain)}, nil
+		}
+		if err := want(1, "DOMAIN [name]"); err != nil {
+			return nil, err
+		}
+		if _, ok := c.routes[args[0]]; !ok {
+			return nil, fmt.Errorf("unknown domain %q", args[0])
+		}
+		s.domain = args[0]
+		return nil, nil
+	case "ECHO":
+		if err := want(1, "ECHO ON|OFF"); err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(args[0]) {
+		case "ON":
+			s.echo = true
+		case "OFF":
+			s.echo = false
+		default:
+			return nil, errors.New("usage: ECHO ON|OFF")
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown command %s, try HELP", cmd)
+}
+
+// status 每個網域一行：名稱、狀態、設備數、IP
+func (c *ControlConsole) status() []string {
+	var lines []string
+	for _, snap := range c.domains.Snapshots() {
+		lines = append(lines, fmt.Sprintf("DOMAIN %s %s %d %s", snap.Name, snap.State, len(snap.Devices), consoleValue(snap.IPAddress)))
+	}
+	return lines
+}
+
+// domainName 操作的網域：DOMAIN 選擇的，或唯一的網域
+func (c *ControlConsole) domainName(s *consoleSession) (string, error) {
+	if s.domain != "" {
+		return s.domain, nil
+	}
+	if len(c.routes) > 1 {
+		return "", errors.New("several domains available, select one with DOMAIN <name>")
+	}
+	for name := range c.routes {
+		return name, nil
+	}
+	return "", errors.New("no domain available")
+}
+
+// snapshot 操作網域的快照
+func (c *ControlConsole) snapshot(s *consoleSession) (supervisor.Snapshot, error) {
+	name, err := c.domainName(s)
+	if err != nil {
+		return supervisor.Snapshot{}, err
+	}
+	for _, snap := range c.domains.Snapshots() {
+		if snap.Name == name {
+			return snap, nil
+		}
+	}
+	return supervisor.Snapshot{}, fmt.Errorf("domain %s is not running", name)
+}
+
+// listRoutes ROUTE <rx>@<device> <tx>@<device>|- <state> 每個接收通道一行
+func (c *ControlConsole) listRoutes(ctx context.Context, s *consoleSession, device string) ([]string, error) {
+	name, err := c.domainName(s)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := c.routes[name].ListSubscriptions(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		tx := "-"
+		if sub.Subscribed() {
+			tx = consoleQuote(sub.TxChannel) + "@" + sub.TxDevice
+		}
+		lines = append(lines, fmt.Sprintf("ROUTE %s@%s %s %s", consoleQuote(sub.Channel), device, tx, sub.State()))
+	}
+	return lines, nil
+}
+
+// route 設定或取消 (tx 空白) 訂閱
+func (c *ControlConsole) route(ctx context.Context, s *consoleSession, rx, tx string) error {
+	if !c.features.Enabled(FeatureRouting) {
+		return fmt.Errorf("feature %s is disabled", FeatureRouting)
+	}
+	rxChannel, rxDevice, err := splitChannelAddress(rx)
+	if err != nil {
+		return err
+	}
+	var txChannel, txDevice string
+	if tx != "" {
+		if txChannel, txDevice, err = splitChannelAddress(tx); err != nil {
+			return err
+		}
+		if err := c.quarantine.CheckRoute(rxDevice, txDevice); err != nil {
+			return fmt.Errorf("%v, use the management API to override", err)
+		}
+	}
+	name, err := c.domainName(s)
+	if err != nil {
+		return err
+	}
+	logger.Info("Console route change", "source", s.source, "rx", rx, "tx", tx)
+	return c.routes[name].Subscribe(ctx, rxDevice, rxChannel, txDevice, txChannel)
+}
+
+// recall RECALL 套用 preset、TRIGGER 觸發輸入
+func (c *ControlConsole) recall(ctx context.Context, s *consoleSession, cmd, name string) ([]string, error) {
+	if c.triggers == nil {
+		return nil, errors.New("no presets configured")
+	}
+	var ev TriggerEvent
+	var err error
+	if cmd == "TRIGGER" {
+		ev, err = c.triggers.Fire(ctx, name, s.source)
+	} else {
+		ev, err = c.triggers.Recall(ctx, name, s.source, false)
+	}
+	lines := []string{fmt.Sprintf("APPLIED %d", ev.Applied)}
+	for _, p := range ev.Problems {
+		lines = append(lines, fmt.Sprintf("PROBLEM %s@%s %s", consoleQuote(p.RxChannel), p.RxDevice, p.Problem))
+	}
+	for _, e := range ev.Errors {
+		lines = append(lines, "FAILED "+e)
+	}
+	return lines, err
+}
+
+// splitChannelAddress 拆開 channel@device
+func splitChannelAddress(s string) (channel, device string, err error) {
+	i := strings.LastIndex(s, "@")
+	if i <= 0 || i == len(s)-1 {
+		return "", "", fmt.Errorf("%q is not channel@device", s)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// splitConsoleArgs 以空白分隔參數，雙引號內的空白不分隔
+func splitConsoleArgs(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	quoted, started := false, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted, started = !quoted, true
+		case (r == ' ' || r == '\t') && !quoted:
+			if started {
+				args = append(args, cur.String())
+				cur.Reset()
+				started = false
+			}
+		default:
+			cur.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if started {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// consoleQuote 含空白的名稱加上雙引號
+func consoleQuote(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// consoleValue 空白的值顯示為 -
+func consoleValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return consoleQuote(s)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/backoff"
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+// consoleIO 以字串作為輸入、收集輸出
+type consoleIO struct {
+	*strings.Reader
+	out bytes.Buffer
+}
+
+func (c *consoleIO) Write(p []byte) (int, error) { return c.out.Write(p) }
+
+// newTestConsole 以模擬網域建立控制台 (Stage-Box-A 隔離中)，events 不為 nil 時發布路由變更
+func newTestConsole(t *testing.T, events *golane.Bus) (*ControlConsole, *dante.Domain) {
+	t.Helper()
+	ctx := context.Background()
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(ctx)
+
+	s := supervisor.New(supervisor.Config{Restart: backoff.Policy{Initial: time.Second}})
+	s.Add(supervisor.Spec{Name: "Dante1", IPAddress: "192.168.100.2", Run: func(ctx context.Context, report supervisor.Reporter) error {
+		report.Devices(d.GetDevices())
+		<-ctx.Done()
+		return nil
+	}})
+	s.Start(ctx)
+	t.Cleanup(s.Stop)
+	deadline := time.Now().Add(2 * time.Second)
+	for snap, _ := s.Snapshot("Dante1"); len(snap.Devices) == 0; snap, _ = s.Snapshot("Dante1") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for devices")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	state, err := OpenStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	quarantine, err := NewQuarantineStore(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := quarantine.Quarantine("Stage-Box-A", "intermittent clock", "a1"); err != nil {
+		t.Fatal(err)
+	}
+	var rc RouteController = d
+	if events != nil {
+		rc = golane.PublishRoutes(events, "Dante1", d)
+	}
+	return NewControlConsole(s, map[string]RouteController{"Dante1": rc}, nil, quarantine, nil, nil), d
+}
+
+func TestControlConsole(t *testing.T) {
+	ctx := context.Background()
+	console, d := newTestConsole(t, nil)
+
+	// 中控系統只送 CR
+	rw := &consoleIO{Reader: strings.NewReader("status\rroute 01@Amp-Left 02@FOH-Console\r" +
+		"ROUTE 02@Amp-Left 01@Stage-Box-A\rroutes Amp-Left\r\nbogus\r\"open\n")}
+	if err := console.Serve(ctx, rw, &consoleSession{source: "serial"}); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(rw.out.String(), "\r\n"), "\r\n")
+	want := []string{
+		"DOMAIN Dante1 running 4 192.168.100.2", "OK",
+		"OK",
+		"ERR device is quarantined: Stage-Box-A (intermittent clock), use the management API to override",
+		"ROUTE 01@Amp-Left 02@FOH-Console",
+	}
+	for i, w := range want {
+		if i >= len(got) || !strings.HasPrefix(got[i], w) {
+			t.Fatalf("line %d = %q, want %q\n%s", i, got[min(i, len(got)-1)], w, rw.out.String())
+		}
+	}
+	if tail := got[len(got)-2:]; tail[0] != "ERR unknown command BOGUS, try HELP" || tail[1] != "ERR unterminated quote" {
+		t.Errorf("errors = %q", tail)
+	}
+
+	subs, err := d.ListSubscriptions(ctx, "Amp-Left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs[0].TxDevice != "FOH-Console" || subs[0].TxChannel != "02" || subs[1].Subscribed() {
+		t.Errorf("Amp-Left = %+v", subs[:2])
+	}
+}
+
+func TestSplitConsoleArgs(t *testing.T) {
+	args, err := splitConsoleArgs(`ROUTE "Left Main"@Amp-Left  01@FOH-Console`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 3 || args[1] != "Left Main@Amp-Left" || args[2] != "01@FOH-Console" {
+		t.Errorf("args = %q", args)
+	}
+	if _, _, err := splitChannelAddress("Amp-Left"); err == nil {
+		t.Error("channel without device accepted")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 設備列表快取
+//==============================================================================
+
+// 重新啟動後要等 SDK 初始化加上首次發現 (-wait) 才有設備列表，這段時間
+// API 與 Web UI 一片空白。每次回報的列表保存在狀態檔，啟動時先交給
+// supervisor 作為 Stale 的列表，首次發現完成後由即時列表取代。
+// 曾評估過預先初始化備用的 SDK session，但 libdapi 與 wrapper 的狀態是
+// 行程全域的 (一個行程只能有一個 DAPI 實例)，無法在行程內準備第二個。
+
+// deviceCacheSection 設備列表快取在狀態檔中的 section 名稱
+const deviceCacheSection = "device_cache"
+
+// maxDeviceCacheAge 超過這個時間的快取不再使用 (設備可能早已換過)
+const maxDeviceCacheAge = 24 * time.Hour
+
+// deviceCacheFlushInterval 列表沒有變化時更新快取時間的間隔
+const deviceCacheFlushInterval = 10 * time.Minute
+
+// cachedDevices 單一網域最後的設備列表
+type cachedDevices struct {
+	Devices []dante.Device `json:"devices"`
+	Updated time.Time      `json:"updated"`
+}
+
+// DeviceCache 各網域最後的設備列表 (保存在狀態檔)
+type DeviceCache struct {
+	mu      sync.Mutex
+	state   *StateStore
+	entries map[string]cachedDevices
+	saved   map[string]time.Time // 各網域最後寫入狀態檔的時間
+}
+
+// NewDeviceCache 從狀態檔載入設備列表快取
+func NewDeviceCache(state *StateStore) (*DeviceCache, error) {
+	dc := &DeviceCache{state: state, entries: make(map[string]cachedDevices), saved: make(map[string]time.Time)}
+	if _, err := state.Load(deviceCacheSection, &dc.entries); err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+// Get 網域快取的設備列表，沒有或過期時回傳 false
+func (dc *DeviceCache) Get(domain string) ([]dante.Device, time.Time, bool) {
+	if dc == nil {
+		return nil, time.Time{}, false
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	entry, ok := dc.entries[domain]
+	if !ok || len(entry.Devices) == 0 || time.Since(entry.Updated) > maxDeviceCacheAge {
+		return nil, time.Time{}, false
+	}
+	return append([]dante.Device{}, entry.Devices...), entry.Updated, true
+}
+
+// Update 保存網域最新的設備列表
+// 列表沒有變化時每 deviceCacheFlushInterval 才寫檔，避免每次刷新都寫入 flash
+func (dc *DeviceCache) Update(domain string, devices []dante.Device) error {
+	if dc == nil {
+		return nil
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	now := time.Now().UTC()
+	changed := !slices.Equal(dc.entries[domain].Devices, devices)
+	dc.entries[domain] = cachedDevices{Devices: append([]dante.Device{}, devices...), Updated: now}
+	if !changed && now.Sub(dc.saved[domain]) < deviceCacheFlushInterval {
+		return nil
+	}
+	if err := dc.state.Save(deviceCacheSection, dc.entries); err != nil {
+		return err
+	}
+	dc.saved[domain] = now
+	return nil
+}
+
+// Seed 把快取的列表交給 supervisor (在 Start 之前)
+func (dc *DeviceCache) Seed(domains *supervisor.Supervisor, name string) {
+	devices, updated, ok := dc.Get(name)
+	if !ok {
+		return
+	}
+	if domains.Seed(name, devices, updated) {
+		logger.Info("Serving cached device list until discovery completes", "domain", name,
+			"devices", len(devices), "age", time.Since(updated).Round(time.Second))
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+func TestDeviceCacheSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	state, err := OpenStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := NewDeviceCache(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices := []dante.Device{{ID: 1, Name: "amp-1", IPAddress: "10.0.0.21"}, {ID: 2, Name: "mixer"}}
+	if err := cache.Update("Dante1", devices); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新的行程從狀態檔載入，Start 之前交給 supervisor
+	state, err = OpenStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err = NewDeviceCache(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, updated, ok := cache.Get("Dante1")
+	if !ok || len(got) != 2 || got[1].Name != "mixer" || time.Since(updated) > time.Minute {
+		t.Fatalf("Get = %+v, %v, %v", got, updated, ok)
+	}
+	if _, _, ok := cache.Get("Dante2"); ok {
+		t.Fatal("unknown domain has a cache")
+	}
+
+	domains := supervisor.New(supervisor.DefaultConfig())
+	domains.Add(supervisor.Spec{Name: "Dante1"})
+	cache.Seed(domains, "Dante1")
+	snap, _ := domains.Snapshot("Dante1")
+	if len(snap.Devices) != 2 || !snap.Stale {
+		t.Fatalf("seeded snapshot = %+v", snap)
+	}
+
+	// 過期的快取不再使用
+	cache.entries["Dante1"] = cachedDevices{Devices: devices, Updated: time.Now().Add(-2 * maxDeviceCacheAge)}
+	if _, _, ok := cache.Get("Dante1"); ok {
+		t.Fatal("expired cache returned")
+	}
+}
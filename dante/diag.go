@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"danteCS/internal/pcap"
+)
+
+//==============================================================================
+// 網路診斷 (golane diag)
+//==============================================================================
+
+// newDiagCommand golane diag
+func newDiagCommand() *Command {
+	return &Command{
+		Name:  "diag",
+		Short: "Network diagnostics on the Dante interfaces",
+		Sub:   []*Command{newQoSCommand(), newLatencyCommand(), newClockCommand(), newFlowStatsCommand(), newDiagCaptureCommand()},
+	}
+}
+
+//------------------------------------------------------------------------------
+// 封包擷取
+//------------------------------------------------------------------------------
+
+// 向 Audinate 支援回報問題時需要現場的封包。golane diag capture 只擷取
+// Dante 相關的流量 (mDNS、PTP、控制、音訊、IGMP)，以 ring buffer 限制檔案
+// 大小與數量，可以放著等偶發的問題而不會塞滿設備的儲存空間。
+
+// newDiagCaptureCommand golane diag capture
+func newDiagCaptureCommand() *Command {
+	fs := newFlagSet("diag capture")
+	lf := addLogFlags(fs)
+	iface := fs.String("iface", "", "interface to capture on (default: the first Dante interface)")
+	duration := fs.Duration("duration", 30*time.Second, "how long to capture (0 = until interrupted)")
+	out := fs.String("out", "", "pcap file name, numbered per ring file (default: golane-<iface>-<time>.pcap)")
+	maxSize := fs.Int("max-size", 100, "start a new file when the current one exceeds this size in MB (0 = one file)")
+	maxFiles := fs.Int("max-files", 5, "keep only the newest files of the ring buffer (0 = keep all)")
+	snaplen := fs.Int("snaplen", pcap.DefaultSnaplen, "bytes of each frame to keep")
+	all := fs.Bool("all", false, "capture all traffic on the interface, not only Dante traffic")
+
+	return &Command{
+		Name:  "capture",
+		Short: "Capture Dante traffic (mDNS, PTP, control, audio) to pcap files for Audinate support",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if *duration < 0 || *maxSize < 0 || *maxFiles < 0 || *snaplen <= 0 {
+				return errors.New("-duration, -max-size and -max-files must not be negative, -snaplen must be positive")
+			}
+
+			name := *iface
+			if name == "" {
+				detector := NewNetworkDetector()
+				if err := detector.AutoConfigureFromSystem(); err != nil {
+					return fmt.Errorf("network detection failed: %v", err)
+				}
+				names := danteInterfaceNames(detector)
+				if len(names) == 0 {
+					return fmt.Errorf("Dante interface not found (expected one of %v), use -iface", detector.DanteInterfaceNames)
+				}
+				name = names[0]
+			}
+			path := *out
+			if path == "" {
+				path = fmt.Sprintf("golane-%s-%s.pcap", name, time.Now().Format("20060102-150405"))
+			}
+
+			ring, err := pcap.NewRing(path, int64(*maxSize)<<20, *maxFiles, *snaplen)
+			if err != nil {
+				return err
+			}
+			filter := pcap.IsDanteTraffic
+			if *all {
+				filter = nil
+			}
+
+			ctx, cancel := commandContext()
+			defer cancel()
+			if *duration > 0 {
+				ctx, cancel = context.WithTimeout(ctx, *duration)
+				defer cancel()
+			}
+			logger.Info("Capturing", "iface", name, "duration", *duration, "out", path, "dante_only", !*all)
+			stats, err := pcap.Capture(ctx, name, ring, filter)
+			if cerr := ring.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("\nCaptured %d of %d frames (%.1f MB) on %s in %s\n",
+				stats.Packets, stats.Seen, float64(stats.Bytes)/(1<<20), name, stats.Duration.Round(time.Second))
+			for _, f := range ring.Files() {
+				fmt.Printf("  %s\n", f)
+			}
+			fmt.Println()
+			return nil
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 預演 (-dry-run)
+//==============================================================================
+
+// 彩排前先確認指令與檔案是否正確：route add|remove|import、devices rename 與
+// interfaces -vlan 加上 -dry-run 時只檢查參數並列出會做的變更，不初始化 SDK、
+// 不連線遠端 monitor，也不修改網路設定。snapshot restore 的 -dry-run 需要比對
+// 設備目前的設定，仍會掃描網路。
+
+// errDryRunRead dry-run 的 RouteController 不讀取設備
+var errDryRunRead = errors.New("dry run: subscriptions are not read from the network")
+
+// dryRunKey context 中的 dry-run 標記
+type dryRunKey struct{}
+
+// withDryRun 標記這次操作只列出變更 (-dry-run)
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// dryRun ctx 是否為 dry-run
+func dryRun(ctx context.Context) bool {
+	v, _ := ctx.Value(dryRunKey{}).(bool)
+	return v
+}
+
+// plannedRoutes dry-run 的 RouteController：檢查參數並印出會做的訂閱變更
+type plannedRoutes struct {
+	w io.Writer
+}
+
+var _ RouteController = plannedRoutes{}
+
+func (p plannedRoutes) ListSubscriptions(context.Context, string) ([]dante.Subscription, error) {
+	return nil, errDryRunRead
+}
+
+func (p plannedRoutes) Subscribe(_ context.Context, rxDevice, rxChannel, txDevice, txChannel string) error {
+	if rxDevice == "" || rxChannel == "" {
+		return errors.New("RX device and channel are required")
+	}
+	if txDevice == "" {
+		fmt.Fprint(p.w, i18n.Sprintf("Would remove the subscription of %s@%s\n", rxChannel, rxDevice))
+		return nil
+	}
+	if txChannel == "" {
+		return errors.New("TX channel is required")
+	}
+	fmt.Fprint(p.w, i18n.Sprintf("Would subscribe %s@%s to %s@%s\n", rxChannel, rxDevice, txChannel, txDevice))
+	return nil
+}
+
+// printPlannedRename 檢查新名稱並印出 devices rename 會做的變更
+func printPlannedRename(w io.Writer, device, newName string) error {
+	if err := dante.CheckDeviceName(newName); err != nil {
+		return err
+	}
+	fmt.Fprint(w, i18n.Sprintf("Would rename %s to %s\n", device, newName))
+	return nil
+}
+
+// printPlannedVLANs 印出 -vlan 會執行的指令
+func printPlannedVLANs(w io.Writer, spec string) error {
+	commands, err := PlanVLANs(spec)
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
+		fmt.Fprintln(w, i18n.T("All VLAN interfaces already exist"))
+	}
+	for _, cmd := range commands {
+		fmt.Fprint(w, i18n.Sprintf("Would run: %s\n", cmd))
+	}
+	return nil
+}
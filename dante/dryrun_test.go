@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPlannedRoutes(t *testing.T) {
+	var out bytes.Buffer
+	rc := plannedRoutes{&out}
+	ctx := withDryRun(context.Background())
+	if err := rc.Subscribe(ctx, "amp-1", "In 2", "mixer", "Out 7"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Subscribe(ctx, "amp-1", "In 3", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Subscribe(ctx, "", "In 3", "mixer", "Out 1"); err == nil {
+		t.Error("missing RX device accepted")
+	}
+	want := "Would subscribe In 2@amp-1 to Out 7@mixer\nWould remove the subscription of In 3@amp-1\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if _, err := rc.ListSubscriptions(ctx, "amp-1"); err == nil {
+		t.Error("dry run read subscriptions")
+	}
+}
+
+func TestRouteImportDryRun(t *testing.T) {
+	rows, err := ParseRouteImport(ImportFormatCSV, strings.NewReader("rx_device,rx_channel,tx_device,tx_channel\n"+
+		"Amp-Left,01,FOH-Console,01\n"+
+		"Amp-Left,01,FOH-Console,02\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nil controller：dry-run 不得呼叫 Subscribe
+	report := ApplyRouteImport(withDryRun(context.Background()), nil, rows)
+	if !report.DryRun || report.Planned != 1 || report.Invalid != 1 || report.Applied != 0 {
+		t.Fatalf("report = %+v", report)
+	}
+	if report.Rows[0].Status != ImportPlanned {
+		t.Errorf("status = %s", report.Rows[0].Status)
+	}
+	var out bytes.Buffer
+	PrintRouteImportReport(&out, report)
+	if !strings.Contains(out.String(), "1 planned, 1 invalid (dry run, nothing applied)") {
+		t.Errorf("report:\n%s", out.String())
+	}
+}
+
+func TestPlannedRenameChecksName(t *testing.T) {
+	var out bytes.Buffer
+	if err := printPlannedRename(&out, "amp-1", "amp 2"); err == nil {
+		t.Error("invalid name accepted")
+	}
+	if err := printPlannedRename(&out, "amp-1", "stage-amp-1"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Would rename amp-1 to stage-amp-1\n" {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestPlanVLANs(t *testing.T) {
+	if _, err := PlanVLANs("eth1.5000"); err == nil {
+		t.Error("invalid VLAN ID accepted")
+	}
+	if _, err := PlanVLANs("golane-missing0.10"); err == nil {
+		t.Error("missing parent accepted")
+	}
+	commands, err := PlanVLANs("lo.10")
+	if err != nil {
+		t.Skipf("no loopback interface: %v", err)
+	}
+	want := []string{"ip link add link lo name lo.10 type vlan id 10", "ip link set lo.10 up"}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands = %q", commands)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// Dante 介面的備用選擇
+//==============================================================================
+
+// 指定的 Dante 網卡 (角色、-dante-ifaces 或內建清單) 一張都找不到時，
+// 例如 USB 網卡換了一張 MAC 不同的，依序嘗試：
+//  1. Interfaces: 依序第一張存在、啟用且有 IP 的網卡
+//  2. Subnet: 第一張 IPv4 地址落在這個網段內的網卡
+//
+// 管理介面不會被選為備用。選用的備用記錄在 NetworkDetector.Fallback
+// (/api/interfaces 與啟動時的配置表格)，讓維護人員知道該更新設定。
+// 沒有設定或沒有符合的網卡時維持原本的行為 (網域持續重試直到網卡出現)。
+
+// DanteFallback 備用 Dante 介面的規則 (設定檔的 dante_fallback section)
+type DanteFallback struct {
+	Interfaces []string `json:"interfaces"` // 依序嘗試的介面名稱
+	Subnet     string   `json:"subnet"`     // 地址在這個網段內的任何介面 (CIDR)
+}
+
+// FallbackChoice 實際選用的備用介面
+type FallbackChoice struct {
+	Interface string    `json:"interface"`
+	Rule      string    `json:"rule"`    // 符合的規則 ("list" 或 "subnet 10.1.0.0/16")
+	Missing   []string  `json:"missing"` // 找不到的指定介面
+	Time      time.Time `json:"time"`
+}
+
+// Enabled 是否有任何規則
+func (f DanteFallback) Enabled() bool {
+	return len(f.Interfaces) > 0 || f.Subnet != ""
+}
+
+// Validate 檢查規則
+func (f DanteFallback) Validate() error {
+	for _, name := range f.Interfaces {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("dante fallback: empty interface name")
+		}
+	}
+	if f.Subnet != "" {
+		if _, err := netip.ParsePrefix(f.Subnet); err != nil {
+			return fmt.Errorf("dante fallback: invalid subnet %q (use CIDR, e.g. 10.1.0.0/16)", f.Subnet)
+		}
+	}
+	return nil
+}
+
+// applyFallback 指定的 Dante 介面都不存在時選用備用介面 (沒有符合的網卡時回傳 false)
+func (nd *NetworkDetector) applyFallback(missing []string) bool {
+	if len(nd.DanteInterfaces) > 0 || !nd.DanteFallback.Enabled() {
+		return false
+	}
+	info, rule := nd.findFallback()
+	if info == nil {
+		logger.Warn("No fallback Dante interface matches", "missing", missing)
+		return false
+	}
+	nd.DanteInterfaces = append(nd.DanteInterfaces, *info)
+	nd.Fallback = &FallbackChoice{Interface: info.Name, Rule: rule, Missing: missing, Time: time.Now()}
+	logger.Warn("Configured Dante interface not found, using fallback",
+		"iface", info.Name, "ip", info.IPAddress, "rule", rule, "missing", missing)
+	return true
+}
+
+// findFallback 依序套用備用規則
+func (nd *NetworkDetector) findFallback() (*NetworkInterfaceInfo, string) {
+	usable := func(info *NetworkInterfaceInfo) bool {
+		return info != nil && info.IsUp && info.HasIP &&
+			(nd.ManagementInterface == nil || nd.ManagementInterface.Name != info.Name)
+	}
+	for _, name := range nd.DanteFallback.Interfaces {
+		if info := nd.GetInterfaceByName(name); usable(info) {
+			return info, "list"
+		}
+	}
+	if nd.DanteFallback.Subnet == "" {
+		return nil, ""
+	}
+	subnet, err := netip.ParsePrefix(nd.DanteFallback.Subnet)
+	if err != nil {
+		return nil, ""
+	}
+	for i := range nd.AllInterfaces {
+		info := &nd.AllInterfaces[i]
+		if !usable(info) {
+			continue
+		}
+		for _, addr := range info.Addresses {
+			if ip, err := netip.ParseAddr(addr.IP); err == nil && !addr.IsIPv6 && subnet.Contains(ip) {
+				return info, "subnet " + subnet.Masked().String()
+			}
+		}
+	}
+	return nil, ""
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestDanteFallback(t *testing.T) {
+	detector := func(fallback DanteFallback) *NetworkDetector {
+		nd := NewNetworkDetector()
+		nd.DanteFallback = fallback
+		nd.AllInterfaces = []NetworkInterfaceInfo{
+			{Name: "eth0", IsUp: true, HasIP: true, IPAddress: "10.1.0.5", Addresses: []InterfaceAddress{{IP: "10.1.0.5", PrefixLen: 16}}},
+			{Name: "eth3", IsUp: false, HasIP: true, IPAddress: "10.1.5.1", Addresses: []InterfaceAddress{{IP: "10.1.5.1", PrefixLen: 24}}},
+			{Name: "enx001122334455", IsUp: true, HasIP: true, IPAddress: "10.1.6.1", Addresses: []InterfaceAddress{{IP: "10.1.6.1", PrefixLen: 24}}},
+		}
+		nd.DanteInterfaceNames = []string{"enxf8e43bd6309e"}
+		nd.IdentifyDanteInterfaces(nd.danteCandidates())
+		nd.identifyManagementInterface("eth0")
+		return nd
+	}
+
+	// 清單中停用的網卡略過，再以網段找到新的 USB 網卡 (管理介面雖在網段內也不選)
+	nd := detector(DanteFallback{Interfaces: []string{"eth3", "eth4"}, Subnet: "10.1.0.0/16"})
+	if !nd.applyFallback(nd.danteCandidates()) {
+		t.Fatal("no fallback chosen")
+	}
+	if fb := nd.Fallback; fb.Interface != "enx001122334455" || fb.Rule != "subnet 10.1.0.0/16" || fb.Missing[0] != "enxf8e43bd6309e" {
+		t.Errorf("fallback = %+v", fb)
+	}
+	if config, err := selectDanteConfig(nd); err != nil || config.InterfaceName != "enx001122334455" {
+		t.Errorf("config = %+v, err = %v", config, err)
+	}
+
+	nd = detector(DanteFallback{Interfaces: []string{"eth4", "enx001122334455"}})
+	if !nd.applyFallback(nil) || nd.Fallback.Rule != "list" {
+		t.Errorf("fallback = %+v", nd.Fallback)
+	}
+
+	nd = detector(DanteFallback{Subnet: "192.168.0.0/24"})
+	if nd.applyFallback(nil) || nd.Fallback != nil || len(nd.DanteInterfaces) != 0 {
+		t.Error("fallback chosen outside the subnet")
+	}
+
+	if err := (DanteFallback{Subnet: "10.1.0.0"}).Validate(); err == nil {
+		t.Error("subnet without prefix length accepted")
+	}
+}
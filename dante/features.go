@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//==============================================================================
+// 功能開關
+//==============================================================================
+
+// 嵌入式部署希望只開啟需要的子系統 (減少攻擊面與資源使用)。
+// 每個可停用的子系統在 knownFeatures 登記一次，啟動時由設定檔的
+// features section 與 -features 參數決定是否啟用；Runtime 為 true 的
+// 功能可在執行中透過 API 切換 (停用時路由回傳 404，不需要重新註冊)。
+
+// 功能名稱
+const (
+	FeatureAPI          = "api"          // 管理 REST API
+	FeatureWebUI        = "webui"        // 內建 Web UI 與 WebSocket
+	FeatureRouting      = "routing"      // 透過 API 修改訂閱
+	FeatureIcons        = "icons"        // 設備圖示
+	FeatureFloorPlan    = "floorplan"    // 平面圖
+	FeatureIncidents    = "incidents"    // 告警合併為事件單
+	FeatureClock        = "clock"        // ConMon 時鐘狀態、失去同步告警與設備識別
+	FeatureFlowStats    = "flowstats"    // 接收 flow 的封包錯誤統計與 Prometheus /metrics
+	FeatureTriggers     = "triggers"     // 觸發輸入套用 preset (audio-follow-video)
+	FeatureSchedules    = "schedules"    // 依排程套用 preset
+	FeatureAES67        = "aes67"        // 在 Dante 介面收聽 AES67 的 SAP 公告
+	FeatureDDM          = "ddm"          // 檢查設備的 DDM 註冊狀態並標示無法設定的設備
+	FeatureIGMP         = "igmp"         // 在 Dante 介面收聽 IGMP 查詢並檢查 querier
+	FeatureLLDP         = "lldp"         // 在 Dante 介面收聽 LLDP，列出連接的交換器埠
+	FeatureStorm        = "storm"        // 偵測 Dante 介面的廣播/多播風暴
+	FeatureReachability = "reachability" // 發現後以 ICMP/ARP 確認設備地址可達
+	FeaturePprof        = "pprof"        // 管理 API 上的 /debug/pprof/ 效能分析
+)
+
+// Feature 可個別停用的子系統
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+	Runtime     bool   `json:"runtime"` // 可在執行中切換
+}
+
+// knownFeatures 所有功能開關 (新增子系統時在這裡登記)
+var knownFeatures = []Feature{
+	{Name: FeatureAPI, Description: "management REST API", Default: true},
+	{Name: FeatureWebUI, Description: "embedded web UI and live WebSocket updates", Default: true, Runtime: true},
+	{Name: FeatureRouting, Description: "change subscriptions through the API", Default: true, Runtime: true},
+	{Name: FeatureIcons, Description: "device icons and icon uploads", Default: true},
+	{Name: FeatureFloorPlan, Description: "floor plan editor and view", Default: true},
+	{Name: FeatureIncidents, Description: "group alerts into incidents", Default: true},
+	{Name: FeatureClock, Description: "ConMon clock status, sync-loss and grandmaster-change alerts, and identify in the dashboard", Default: true},
+	{Name: FeatureFlowStats, Description: "read dropped, late and out-of-order packet counters of RX flows and export them on /metrics", Default: true},
+	{Name: FeatureTriggers, Description: "trigger inputs (HTTP, OSC, GPIO) that recall presets", Default: true, Runtime: true},
+	{Name: FeatureSchedules, Description: "recall presets at the times configured in schedules", Default: true, Runtime: true},
+	{Name: FeatureAES67, Description: "list AES67 streams announced with SAP on the Dante interfaces", Default: true},
+	{Name: FeatureDDM, Description: "check Dante Domain Manager enrollment and mark devices this controller cannot configure", Default: true},
+	{Name: FeatureIGMP, Description: "listen for IGMP queries on the Dante interfaces and flag a missing querier", Default: true},
+	{Name: FeatureLLDP, Description: "listen for LLDP on the Dante interfaces and report the switch port of each NIC", Default: true},
+	{Name: FeatureStorm, Description: "sample the packet rates of the Dante interfaces and alert on broadcast/multicast storms", Default: true},
+	{Name: FeatureReachability, Description: "ping or ARP discovered devices to catch listed devices that are actually offline", Default: false, Runtime: true},
+	{Name: FeaturePprof, Description: "CPU, memory and goroutine profiles on /debug/pprof/ for admin tokens", Default: false, Runtime: true},
+}
+
+// lookupFeature 依名稱取得功能
+func lookupFeature(name string) (Feature, bool) {
+	for _, f := range knownFeatures {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// errUnknownFeature 未登記的功能名稱
+var errUnknownFeature = errors.New("unknown feature")
+
+// unknownFeature 列出可用的功能名稱
+func unknownFeature(name string) error {
+	names := make([]string, 0, len(knownFeatures))
+	for _, f := range knownFeatures {
+		names = append(names, f.Name)
+	}
+	return fmt.Errorf("%w %q (known: %s)", errUnknownFeature, name, strings.Join(names, ", "))
+}
+
+// FeatureFlags 目前的功能開關 (nil 表示全部使用預設值)
+type FeatureFlags struct {
+	mu      sync.RWMutex
+	enabled map[string]bool
+}
+
+// DefaultFeatureFlags 所有功能使用預設值
+func DefaultFeatureFlags() *FeatureFlags {
+	ff := &FeatureFlags{enabled: make(map[string]bool)}
+	for _, f := range knownFeatures {
+		ff.enabled[f.Name] = f.Default
+	}
+	return ff
+}
+
+// Enabled 功能是否啟用
+func (ff *FeatureFlags) Enabled(name string) bool {
+	if ff == nil {
+		f, ok := lookupFeature(name)
+		return ok && f.Default
+	}
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+	return ff.enabled[name]
+}
+
+// Set 啟動時設定功能
+func (ff *FeatureFlags) Set(name string, on bool) error {
+	if _, ok := lookupFeature(name); !ok {
+		return unknownFeature(name)
+	}
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	ff.enabled[name] = on
+	return nil
+}
+
+// SetRuntime 執行中切換功能 (只允許 Runtime 功能)
+func (ff *FeatureFlags) SetRuntime(name string, on bool) error {
+	f, ok := lookupFeature(name)
+	if !ok {
+		return unknownFeature(name)
+	}
+	if !f.Runtime {
+		return fmt.Errorf("feature %s can only be changed at startup", name)
+	}
+
+	ff.mu.Lock()
+	changed := ff.enabled[name] != on
+	ff.enabled[name] = on
+	ff.mu.Unlock()
+
+	if changed {
+		logger.Info("Feature changed", "feature", name, "enabled", on)
+	}
+	return nil
+}
+
+// Apply 套用 -features 參數: 以逗號分隔，"name" 啟用、"-name" 停用
+func (ff *FeatureFlags) Apply(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, off := strings.CutPrefix(item, "-")
+		name = strings.TrimPrefix(name, "+")
+		if err := ff.Set(name, !off); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FeatureState 功能與目前狀態 (API 輸出)
+type FeatureState struct {
+	Feature
+	Enabled bool `json:"enabled"`
+}
+
+// List 所有功能與目前狀態 (依名稱排序)
+func (ff *FeatureFlags) List() []FeatureState {
+	states := make([]FeatureState, 0, len(knownFeatures))
+	for _, f := range knownFeatures {
+		states = append(states, FeatureState{Feature: f, Enabled: ff.Enabled(f.Name)})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// Disabled 停用的功能名稱 (啟動時記錄)
+func (ff *FeatureFlags) Disabled() []string {
+	var names []string
+	for _, s := range ff.List() {
+		if !s.Enabled {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+//----------------------------------------------------------------------
+// 設定檔
+//----------------------------------------------------------------------
+
+// MonitorConfig monitor 設定檔 (-config)
+type MonitorConfig struct {
+	Features   map[string]bool `json:"features"`   // 功能名稱 → 是否啟用，未列出的使用預設值
+	Interfaces InterfaceRoles  `json:"interfaces"` // 介面角色 → 介面名稱 (management、dante-primary、dante-secondary)
+	Timing     *TimingConfig   `json:"timing"`     // 事件處理、發現與刷新的時間 (命令列參數優先)
+	Presets    []Preset        `json:"presets"`    // 可由觸發輸入或 API 套用的訂閱組合
+	Triggers   *TriggerConfig  `json:"triggers"`   // 觸發輸入 (未設定時只能透過 API 套用 preset)
+	Schedules  []ScheduleEntry `json:"schedules"`  // 在指定時間套用 preset
+	Alarms     []AlarmRule     `json:"alarms"`     // 告警規則 (未設定時使用 DefaultAlarmRules)
+	Webhooks   []WebhookTarget `json:"webhooks"`   // 接收事件的 HTTP 目標
+	Hooks      []EventHook     `json:"hooks"`      // 事件發生時執行的本機指令
+	Sinks      []SinkConfig    `json:"sinks"`      // golane.RegisterSink 登記種類的輸出
+	Switches   []SwitchConfig  `json:"switches"`   // 以 SNMP 讀取 FDB 對應設備埠的交換器
+	Notify     *NotifyConfig   `json:"notify"`     // 告警通知寄信或送到 Slack
+
+	APITokens     []ConfiguredToken    `json:"api_tokens"`     // 管理 API 的具名權杖
+	DanteFallback *DanteFallback       `json:"dante_fallback"` // 指定的 Dante 介面都不存在時的備用介面
+	NICNames      NICNames             `json:"nic_names"`      // USB 網卡的穩定名稱 → MAC (golane udev)
+	StatusLED     *StatusLEDConfig     `json:"status_led"`     // 以 GPIO 或 sysfs LED 顯示整體狀態
+	FrontPanel    *FrontPanelConfig    `json:"front_panel"`    // 機櫃前面板的 I2C 顯示器
+	SerialControl *SerialControlConfig `json:"serial_control"` // RS-232 上的 ASCII 控制台
+	TCPControl    *TCPControlConfig    `json:"tcp_control"`    // Crestron/AMX 的 TCP 控制協定
+}
+
+// LoadMonitorConfig 載入設定檔
+func LoadMonitorConfig(path string) (*MonitorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	var cfg MonitorConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// resolveFeatures 依設定檔 (nil 表示沒有) 與 -features 參數決定功能開關
+func resolveFeatures(cfg *MonitorConfig, spec string) (*FeatureFlags, error) {
+	ff := DefaultFeatureFlags()
+	if cfg != nil {
+		for name, on := range cfg.Features {
+			if err := ff.Set(name, on); err != nil {
+				return nil, fmt.Errorf("config: %v", err)
+			}
+		}
+	}
+	if err := ff.Apply(spec); err != nil {
+		return nil, fmt.Errorf("-features: %v", err)
+	}
+	return ff, nil
+}
+
+//----------------------------------------------------------------------
+// API
+//----------------------------------------------------------------------
+
+// featureRequest PUT /api/features/{name}
+type featureRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// requireFeature 功能停用時回傳 404 (讓 Runtime 功能不需要重新註冊路由)
+func (s *APIServer) requireFeature(feature string, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.features.Enabled(feature) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("feature %s is disabled", feature))
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+func (s *APIServer) handleFeatures(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.features.List())
+}
+
+func (s *APIServer) handleSetFeature(w http.ResponseWriter, r *http.Request) {
+	var req featureRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	name := r.PathValue("name")
+	before := s.features.Enabled(name)
+	if err := s.features.SetRuntime(name, req.Enabled); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, errUnknownFeature) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	s.audit.Record(r.Context(), AuditEntry{Operation: AuditFeatureSet, Target: name,
+		Before: strconv.FormatBool(before), After: strconv.FormatBool(req.Enabled)})
+	f, _ := lookupFeature(name)
+	writeJSON(w, http.StatusOK, FeatureState{Feature: f, Enabled: req.Enabled})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveFeaturesConfigAndFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golane.json")
+	if err := os.WriteFile(path, []byte(`{"features": {"webui": false, "incidents": false}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadMonitorConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff, err := resolveFeatures(cfg, "incidents, -routing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ff.Enabled(FeatureWebUI) || ff.Enabled(FeatureRouting) {
+		t.Fatal("disabled features are enabled")
+	}
+	if !ff.Enabled(FeatureIncidents) || !ff.Enabled(FeatureAPI) {
+		t.Fatal("-features did not override the config, or defaults were lost")
+	}
+	if got := strings.Join(ff.Disabled(), ","); got != "pprof,reachability,routing,webui" {
+		t.Fatalf("Disabled() = %s", got)
+	}
+
+	if _, err := resolveFeatures(nil, "-mqtt"); err == nil || !strings.Contains(err.Error(), "unknown feature") {
+		t.Fatalf("unknown feature accepted: %v", err)
+	}
+}
+
+func TestFeaturesToggleAtRuntime(t *testing.T) {
+	server := httptest.NewServer(NewAPIServer(APIConfig{}).mux)
+	defer server.Close()
+
+	put := func(name, body string) int {
+		req, _ := http.NewRequest(http.MethodPut, server.URL+"/api/features/"+name, bytes.NewBufferString(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	get := func(path string) int {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := get("/ui/"); status != http.StatusOK {
+		t.Fatalf("web UI status %d, want 200", status)
+	}
+	if status := put(FeatureWebUI, `{"enabled": false}`); status != http.StatusOK {
+		t.Fatalf("disable webui: status %d", status)
+	}
+	if status := get("/ui/"); status != http.StatusNotFound {
+		t.Fatalf("disabled web UI status %d, want 404", status)
+	}
+	put(FeatureWebUI, `{"enabled": true}`)
+	if status := get("/ui/"); status != http.StatusOK {
+		t.Fatalf("re-enabled web UI status %d, want 200", status)
+	}
+
+	if status := put(FeatureIncidents, `{"enabled": false}`); status != http.StatusConflict {
+		t.Fatalf("startup-only feature: status %d, want 409", status)
+	}
+	if status := put("mqtt", `{"enabled": true}`); status != http.StatusNotFound {
+		t.Fatalf("unknown feature: status %d, want 404", status)
+	}
+
+	var states []FeatureState
+	getJSON(t, server.URL+"/api/features", &states)
+	if len(states) != len(knownFeatures) {
+		t.Fatalf("got %d features, want %d", len(states), len(knownFeatures))
+	}
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 韌體版本清單
+//==============================================================================
+
+// 排定維護前先確認各場館有哪些型號、各跑哪個版本：依型號與 Dante 版本
+// (同時列出產品版本) 分組，低於最低版本的群組標記為需要更新。最低版本
+// 可以整體設定，也可以依型號設定 ("4.2.0,PA-4D=4.1.2")。無法解析的版本
+// (Unknown) 不與最低版本比較。多個場館時以 -instances 讀取每個實例的 API。
+
+// FirmwarePolicy 最低版本
+type FirmwarePolicy struct {
+	Default string            `json:"default,omitempty"` // 所有型號
+	Models  map[string]string `json:"models,omitempty"`  // 依型號 (取代 Default)
+}
+
+// ParseFirmwarePolicy 解析 "4.2.0,PA-4D=4.1.2" (空白表示不檢查)
+func ParseFirmwarePolicy(s string) (FirmwarePolicy, error) {
+	var p FirmwarePolicy
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		model, version, perModel := strings.Cut(part, "=")
+		if !perModel {
+			model, version = "", part
+		}
+		model, version = strings.TrimSpace(model), strings.TrimSpace(version)
+		if _, ok := parseVersion(version); !ok {
+			return FirmwarePolicy{}, fmt.Errorf("invalid minimum version %q", part)
+		}
+		switch {
+		case !perModel && p.Default != "":
+			return FirmwarePolicy{}, fmt.Errorf("more than one default minimum version in %q", s)
+		case !perModel:
+			p.Default = version
+		case model == "":
+			return FirmwarePolicy{}, fmt.Errorf("missing model in %q", part)
+		default:
+			if p.Models == nil {
+				p.Models = make(map[string]string)
+			}
+			p.Models[model] = version
+		}
+	}
+	return p, nil
+}
+
+// String 與 ParseFirmwarePolicy 相同的格式 (型號依名稱排序)
+func (p FirmwarePolicy) String() string {
+	var parts []string
+	if p.Default != "" {
+		parts = append(parts, p.Default)
+	}
+	models := make([]string, 0, len(p.Models))
+	for model := range p.Models {
+		models = append(models, model)
+	}
+	slices.Sort(models)
+	for _, model := range models {
+		parts = append(parts, model+"="+p.Models[model])
+	}
+	return strings.Join(parts, ",")
+}
+
+// Minimum 型號的最低版本 (空白表示不檢查)
+func (p FirmwarePolicy) Minimum(model string) string {
+	if v, ok := p.Models[model]; ok {
+		return v
+	}
+	return p.Default
+}
+
+// parseVersion 解析 "4.2.0" (可加上 v 前綴)，各段都必須是數字
+func parseVersion(s string) ([]int, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return nil, false
+	}
+	parts := strings.Split(s, ".")
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+// compareVersions 比較兩個版本 (缺少的段視為 0)，任一個無法解析時 ok 為 false
+func compareVersions(a, b string) (result int, ok bool) {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := range max(len(va), len(vb)) {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c, true
+		}
+	}
+	return 0, true
+}
+
+// FirmwareDevice 清單中的一台設備
+type FirmwareDevice struct {
+	Venue          string `json:"venue,omitempty"` // 實例名稱 (-instances，其他情況為空白)
+	Domain         string `json:"domain"`
+	Name           string `json:"name"`
+	SerialNumber   string `json:"serial_number,omitempty"`
+	Model          string `json:"model"`
+	ProductVersion string `json:"product_version"`
+	DanteVersion   string `json:"dante_version"`
+}
+
+// FirmwareGroup 相同型號與版本的設備
+type FirmwareGroup struct {
+	Model          string           `json:"model"`
+	DanteVersion   string           `json:"dante_version"`
+	ProductVersion string           `json:"product_version"`
+	MinVersion     string           `json:"min_version,omitempty"` // 這個型號的最低版本
+	Outdated       bool             `json:"outdated,omitempty"`    // Dante 版本低於 MinVersion
+	Devices        []FirmwareDevice `json:"devices"`
+}
+
+// FirmwareReport 韌體版本清單
+type FirmwareReport struct {
+	Generated time.Time       `json:"generated"`
+	Policy    FirmwarePolicy  `json:"policy"`
+	Devices   int             `json:"devices"`
+	Outdated  int             `json:"outdated"` // 低於最低版本的設備數
+	Groups    []FirmwareGroup `json:"groups"`   // 依型號、版本排序
+	Errors    []string        `json:"errors,omitempty"`
+}
+
+// firmwareDevices 把網域的設備轉成清單的設備
+func firmwareDevices(venue string, devices []apiDevice) []FirmwareDevice {
+	out := make([]FirmwareDevice, 0, len(devices))
+	for _, dev := range devices {
+		out = append(out, FirmwareDevice{
+			Venue:          venue,
+			Domain:         dev.Domain,
+			Name:           dev.Name,
+			SerialNumber:   dev.SerialNumber,
+			Model:          dev.Model,
+			ProductVersion: dev.ProductVersion,
+			DanteVersion:   dev.DanteVersion,
+		})
+	}
+	return out
+}
+
+// BuildFirmwareReport 依型號與版本分組並標記低於最低版本的群組
+func BuildFirmwareReport(devices []FirmwareDevice, policy FirmwarePolicy) FirmwareReport {
+	report := FirmwareReport{Generated: time.Now(), Policy: policy, Devices: len(devices), Groups: []FirmwareGroup{}}
+
+	type key struct{ model, dante, product string }
+	index := make(map[key]int)
+	for _, dev := range devices {
+		k := key{dev.Model, dev.DanteVersion, dev.ProductVersion}
+		i, ok := index[k]
+		if !ok {
+			i = len(report.Groups)
+			index[k] = i
+			group := FirmwareGroup{Model: dev.Model, DanteVersion: dev.DanteVersion, ProductVersion: dev.ProductVersion,
+				MinVersion: policy.Minimum(dev.Model)}
+			if group.MinVersion != "" {
+				c, ok := compareVersions(group.DanteVersion, group.MinVersion)
+				group.Outdated = ok && c < 0
+			}
+			report.Groups = append(report.Groups, group)
+		}
+		report.Groups[i].Devices = append(report.Groups[i].Devices, dev)
+	}
+
+	slices.SortFunc(report.Groups, func(a, b FirmwareGroup) int {
+		if c := cmp.Compare(a.Model, b.Model); c != 0 {
+			return c
+		}
+		if c, ok := compareVersions(a.DanteVersion, b.DanteVersion); ok && c != 0 {
+			return c
+		}
+		return cmp.Or(cmp.Compare(a.DanteVersion, b.DanteVersion), cmp.Compare(a.ProductVersion, b.ProductVersion))
+	})
+	for i := range report.Groups {
+		g := &report.Groups[i]
+		slices.SortFunc(g.Devices, func(a, b FirmwareDevice) int {
+			return cmp.Or(cmp.Compare(a.Venue, b.Venue), cmp.Compare(a.Domain, b.Domain), cmp.Compare(a.Name, b.Name))
+		})
+		if g.Outdated {
+			report.Outdated += len(g.Devices)
+		}
+	}
+	return report
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// handleFirmware GET /api/firmware[?min_version=]
+func (s *APIServer) handleFirmware(w http.ResponseWriter, r *http.Request) {
+	policy, err := ParseFirmwarePolicy(r.URL.Query().Get("min_version"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, BuildFirmwareReport(firmwareDevices("", s.deviceList()), policy))
+}
+
+// Firmware daemon 的韌體版本清單
+func (c *RemoteClient) Firmware(policy FirmwarePolicy) (FirmwareReport, error) {
+	path := "/api/firmware"
+	if s := policy.String(); s != "" {
+		path += "?" + url.Values{"min_version": {s}}.Encode()
+	}
+	var report FirmwareReport
+	return report, c.do(http.MethodGet, path, nil, &report)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newFirmwareCommand golane firmware
+func newFirmwareCommand() *Command {
+	fs := newFlagSet("firmware")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	minVersion := fs.String("min-version", "", "minimum Dante version, overall and/or per model (4.2.0,PA-4D=4.1.2)")
+	instancesFile := fs.String("instances", "", "instances file: combine the inventory of every instance with an API address (one per venue)")
+	outdatedOnly := fs.Bool("outdated", false, "only list devices below the minimum version")
+	jsonOut := fs.Bool("json", false, "print the report as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "firmware",
+		Short: "Group devices by model and firmware version and flag devices below a minimum version",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			policy, err := ParseFirmwarePolicy(*minVersion)
+			if err != nil {
+				return err
+			}
+
+			var report FirmwareReport
+			switch {
+			case *instancesFile != "":
+				report, err = instancesFirmware(*instancesFile, remote, policy)
+			case remote.enabled():
+				var client *RemoteClient
+				if client, err = remote.client(); err == nil {
+					report, err = client.Firmware(policy)
+				}
+			default:
+				report, err = localFirmware(ifaces, *wait, policy)
+			}
+			if err != nil {
+				return err
+			}
+
+			for _, e := range report.Errors {
+				logger.Warn("Firmware inventory incomplete", "err", e)
+			}
+			if *outdatedOnly {
+				report.Groups = slices.DeleteFunc(report.Groups, func(g FirmwareGroup) bool { return !g.Outdated })
+			}
+			if *jsonOut {
+				return printJSON(report)
+			}
+			printFirmware(report)
+			return nil
+		},
+	}
+}
+
+// localFirmware 掃描本機主要網域的清單
+func localFirmware(ifaces *interfaceFlags, wait time.Duration, policy FirmwarePolicy) (FirmwareReport, error) {
+	if err := ifaces.checkTiming(wait); err != nil {
+		return FirmwareReport{}, err
+	}
+	detector, err := ifaces.detect()
+	if err != nil {
+		return FirmwareReport{}, err
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	domain, err := ifaces.openPrimaryDomain(ctx, detector)
+	if err != nil {
+		return FirmwareReport{}, err
+	}
+	defer domain.Cleanup()
+	if err := discover(ctx, domain, wait); err != nil {
+		return FirmwareReport{}, err
+	}
+	var devices []apiDevice
+	for _, dev := range domain.GetDevices() {
+		devices = append(devices, newAPIDevice(domain.Name, dev))
+	}
+	return BuildFirmwareReport(firmwareDevices("", devices), policy), nil
+}
+
+// instancesFirmware 合併每個實例 (場館) 的清單；無法連線的實例記錄在 Errors
+// 所有實例使用 -token 與 -tls-ca
+func instancesFirmware(path string, remote *remoteFlags, policy FirmwarePolicy) (FirmwareReport, error) {
+	set, err := LoadInstanceSet(path)
+	if err != nil {
+		return FirmwareReport{}, err
+	}
+	var devices []FirmwareDevice
+	var errs []string
+	queried := 0
+	for _, inst := range set.Instances {
+		if inst.Disabled || inst.APIAddr == "" {
+			continue
+		}
+		queried++
+		venue := *remote
+		venue.host = inst.APIAddr
+		client, err := venue.client()
+		if err == nil {
+			var list []apiDevice
+			if list, err = client.Devices(dante.DeviceFilter{}, dante.DeviceOrder{}, devicePage{}); err == nil {
+				devices = append(devices, firmwareDevices(inst.Name, list)...)
+				continue
+			}
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", inst.Name, err))
+	}
+	if queried == 0 {
+		return FirmwareReport{}, fmt.Errorf("no enabled instance in %s has an API address", path)
+	}
+	report := BuildFirmwareReport(devices, policy)
+	report.Errors = errs
+	return report, nil
+}
+
+// printFirmware 印出清單
+func printFirmware(report FirmwareReport) {
+	fmt.Print(i18n.Sprintf("\n=== Firmware inventory (%d devices) ===\n", report.Devices))
+	if s := report.Policy.String(); s != "" {
+		fmt.Print(i18n.Sprintf("Minimum version: %s\n", s))
+	}
+	printHeader("%-16s %-10s %-10s %-8s %s\n", "MODEL", "DANTE", "PRODUCT", "COUNT", "DEVICES")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────────")
+	for _, g := range report.Groups {
+		names := make([]string, 0, len(g.Devices))
+		for _, dev := range g.Devices {
+			name := dev.Name
+			if dev.Venue != "" {
+				name = dev.Venue + "/" + name
+			}
+			names = append(names, name)
+		}
+		product := cmp.Or(g.ProductVersion, "-")
+		fmt.Printf("%-16s %-10s %-10s %-8d %s\n", g.Model, g.DanteVersion, product, len(g.Devices), strings.Join(names, ", "))
+		if g.Outdated {
+			fmt.Print(i18n.Sprintf("    ! below minimum version %s\n", g.MinVersion))
+		}
+	}
+	fmt.Println()
+	if report.Outdated > 0 {
+		fmt.Fprint(os.Stderr, i18n.Sprintf("WARNING: %d devices below the minimum version\n", report.Outdated))
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseFirmwarePolicy(t *testing.T) {
+	p, err := ParseFirmwarePolicy(" 4.2.0, PA-4D=4.1.2 ,Ultimo X4=v4.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Minimum("DL32") != "4.2.0" || p.Minimum("PA-4D") != "4.1.2" || p.Minimum("Ultimo X4") != "v4.1" {
+		t.Fatalf("unexpected policy: %+v", p)
+	}
+	if got := p.String(); got != "4.2.0,PA-4D=4.1.2,Ultimo X4=v4.1" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if p, err := ParseFirmwarePolicy(""); err != nil || p.Minimum("DL32") != "" {
+		t.Errorf("empty policy = %+v, %v", p, err)
+	}
+	for _, bad := range []string{"latest", "4.2,4.3", "=4.1", "PA-4D=4.x"} {
+		if _, err := ParseFirmwarePolicy(bad); err == nil {
+			t.Errorf("ParseFirmwarePolicy(%q) accepted", bad)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"4.2.0", "4.2.0", 0},
+		{"4.2", "4.2.0", 0},
+		{"4.1.9", "4.2.0", -1},
+		{"4.10.0", "4.9.1", 1},
+		{"v4.2.1", "4.2", 1},
+	} {
+		if got, ok := compareVersions(tc.a, tc.b); !ok || got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, %v, want %d", tc.a, tc.b, got, ok, tc.want)
+		}
+	}
+	if _, ok := compareVersions("Unknown", "4.2.0"); ok {
+		t.Error("Unknown compared as a version")
+	}
+}
+
+func TestBuildFirmwareReport(t *testing.T) {
+	devices := []FirmwareDevice{
+		{Venue: "hall-b", Domain: "Dante1", Name: "amp-2", Model: "PA-4D", DanteVersion: "4.1.0", ProductVersion: "1.2"},
+		{Venue: "hall-a", Domain: "Dante1", Name: "amp-1", Model: "PA-4D", DanteVersion: "4.1.0", ProductVersion: "1.2"},
+		{Venue: "hall-a", Domain: "Dante1", Name: "amp-3", Model: "PA-4D", DanteVersion: "4.2.1", ProductVersion: "1.3"},
+		{Venue: "hall-a", Domain: "Dante1", Name: "console", Model: "DL32", DanteVersion: "4.0.0", ProductVersion: "N/A"},
+		{Venue: "hall-b", Domain: "Dante2", Name: "stagebox", Model: "Ultimo X4", DanteVersion: "Unknown", ProductVersion: "N/A"},
+	}
+	policy, err := ParseFirmwarePolicy("4.2,DL32=4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := BuildFirmwareReport(devices, policy)
+
+	if report.Devices != 5 || len(report.Groups) != 4 {
+		t.Fatalf("got %d devices in %d groups: %+v", report.Devices, len(report.Groups), report.Groups)
+	}
+	// 依型號、版本排序，群組內依場館、網域、名稱排序
+	dl32, old, current, unknown := report.Groups[0], report.Groups[1], report.Groups[2], report.Groups[3]
+	if dl32.Model != "DL32" || dl32.Outdated || dl32.MinVersion != "4.0" {
+		t.Errorf("DL32 uses its own minimum: %+v", dl32)
+	}
+	if old.DanteVersion != "4.1.0" || !old.Outdated || len(old.Devices) != 2 ||
+		old.Devices[0].Name != "amp-1" || old.Devices[1].Name != "amp-2" {
+		t.Errorf("outdated PA-4D group: %+v", old)
+	}
+	if current.DanteVersion != "4.2.1" || current.Outdated {
+		t.Errorf("current PA-4D group: %+v", current)
+	}
+	if unknown.Model != "Ultimo X4" || unknown.Outdated {
+		t.Errorf("unknown versions are not compared: %+v", unknown)
+	}
+	if report.Outdated != 2 {
+		t.Errorf("outdated = %d, want 2", report.Outdated)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// 從現場網路產生模擬設定 (capture fixture)
+//==============================================================================
+
+// 現場回報的問題常與特定拓撲有關 (設備數量、Auto-IP、次要網路中斷、
+// unresolved 訂閱)。capture fixture 把目前發現的設備、接收通道與訂閱轉成
+// -simulate-config 的檔案，在實驗室以相同拓撲重現。客戶的設備名稱、通道
+// 標籤、IP 與 MAC 預設會去識別化；型號、版本、連線速度、通道數與訂閱
+// 關係保持不變。SDK 無法列出發送通道，只記錄有被訂閱的發送通道。
+
+// FixtureOptions 產生模擬設定的選項
+type FixtureOptions struct {
+	KeepNames bool // 保留設備與通道名稱 (仍替換 IP 與 MAC)
+}
+
+// CaptureFixture 讀取設備與訂閱，產生去識別化的模擬設定
+// 任何一台設備的接收通道讀取失敗都回傳錯誤 (不產生不完整的拓撲)
+func CaptureFixture(ctx context.Context, devices []dante.Device, rc RouteController, opts FixtureOptions) (*dante.SimulationConfig, error) {
+	devices = append([]dante.Device{}, devices...)
+	dante.SortDevices(devices, dante.DeviceOrder{Key: dante.SortByName})
+
+	san := newFixtureSanitizer(opts)
+	cfg := &dante.SimulationConfig{Devices: []dante.SimulatedDevice{}}
+	txChannels := make(map[string][]string) // 原始發送設備名稱 → 被訂閱的通道 (原始名稱)
+	for _, dev := range devices {
+		san.device(dev.Name)
+	}
+
+	for i, dev := range devices {
+		subs, err := rc.ListSubscriptions(ctx, dev.Name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read RX channels of %s: %w", dev.Name, err)
+		}
+		sim := dante.SimulatedDevice{
+			Name:           san.device(dev.Name),
+			Model:          dev.Model,
+			ProductVersion: dev.ProductVersion,
+			DanteVersion:   dev.DanteVersion,
+			IPAddress:      san.ip(dev.IPAddress),
+			MacAddress:     san.mac(dev.MacAddress, i+1),
+			LinkSpeed:      dev.LinkSpeed,
+			SecondaryIP:    san.ip(dev.SecondaryIP),
+			SecondarySpeed: dev.SecondarySpeed,
+			RxChannels:     len(subs),
+		}
+		for n, sub := range subs {
+			id := sub.ChannelID
+			if id == 0 {
+				id = n + 1
+			}
+			sim.RxChannelNames = append(sim.RxChannelNames, san.channel("Rx", sub.Channel, id))
+			if !sub.Subscribed() {
+				continue
+			}
+			if !slices.Contains(txChannels[sub.TxDevice], sub.TxChannel) {
+				txChannels[sub.TxDevice] = append(txChannels[sub.TxDevice], sub.TxChannel)
+			}
+			cfg.Routes = append(cfg.Routes, dante.SimulatedRoute{
+				RxDevice:  sim.Name,
+				RxChannel: sim.RxChannelNames[n],
+				TxDevice:  san.device(sub.TxDevice),
+				TxChannel: san.txChannel(sub.TxDevice, sub.TxChannel),
+			})
+		}
+		cfg.Devices = append(cfg.Devices, sim)
+	}
+
+	for i, dev := range devices {
+		for _, ch := range txChannels[dev.Name] {
+			cfg.Devices[i].TxChannelNames = append(cfg.Devices[i].TxChannelNames, san.txChannel(dev.Name, ch))
+		}
+		cfg.Devices[i].TxChannels = len(cfg.Devices[i].TxChannelNames)
+	}
+	return cfg, nil
+}
+
+// fixtureSanitizer 一致地替換識別資訊 (同一個原始值在整份設定中替換成同一個值)
+type fixtureSanitizer struct {
+	opts     FixtureOptions
+	devices  map[string]string            // 原始設備名稱 → 替換名稱
+	channels map[string]map[string]string // 發送設備 → 原始通道名稱 → 替換名稱
+	subnets  map[string]int               // 原始 /24 → 替換網段編號
+}
+
+func newFixtureSanitizer(opts FixtureOptions) *fixtureSanitizer {
+	return &fixtureSanitizer{
+		opts:     opts,
+		devices:  make(map[string]string),
+		channels: make(map[string]map[string]string),
+		subnets:  make(map[string]int),
+	}
+}
+
+// device 設備名稱依出現順序替換為 Device-01、Device-02、...
+// (不在設備列表中的發送設備排在最後)
+func (s *fixtureSanitizer) device(name string) string {
+	if s.opts.KeepNames {
+		return name
+	}
+	if alias, ok := s.devices[name]; ok {
+		return alias
+	}
+	alias := fmt.Sprintf("Device-%02d", len(s.devices)+1)
+	s.devices[name] = alias
+	return alias
+}
+
+// channel 通道名稱：Dante 預設的數字名稱保留，自訂標籤以通道編號替換
+func (s *fixtureSanitizer) channel(prefix, name string, id int) string {
+	if s.opts.KeepNames || isDefaultChannelName(name) {
+		return name
+	}
+	return fmt.Sprintf("%s%02d", prefix, id)
+}
+
+// txChannel 發送通道名稱 (編號未知，自訂標籤依出現順序編號)
+func (s *fixtureSanitizer) txChannel(device, name string) string {
+	if s.opts.KeepNames || isDefaultChannelName(name) {
+		return name
+	}
+	names, ok := s.channels[device]
+	if !ok {
+		names = make(map[string]string)
+		s.channels[device] = names
+	}
+	if alias, ok := names[name]; ok {
+		return alias
+	}
+	alias := s.channel("Tx", name, len(names)+1)
+	names[name] = alias
+	return alias
+}
+
+// isDefaultChannelName 是否為 Dante 預設的通道名稱 (01、02、...)
+func isDefaultChannelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ip 位址的 /24 網段依出現順序替換為 10.0.1.0/24、10.0.2.0/24、...，主機位址不變
+// link-local 位址保留 (Auto-IP 是要重現的狀況，不含現場資訊)
+func (s *fixtureSanitizer) ip(address string) string {
+	ip := net.ParseIP(address).To4()
+	if ip == nil || dante.IsLinkLocalIPv4(address) {
+		return address
+	}
+	prefix := ip.Mask(net.CIDRMask(24, 32)).String()
+	n, ok := s.subnets[prefix]
+	if !ok {
+		n = len(s.subnets) + 1
+		s.subnets[prefix] = n
+	}
+	return fmt.Sprintf("10.%d.%d.%d", n>>8, n&0xff, ip[3])
+}
+
+// mac 保留廠商前綴 (OUI)，後半以設備編號替換
+func (s *fixtureSanitizer) mac(address string, n int) string {
+	hw, err := net.ParseMAC(address)
+	if err != nil || len(hw) != 6 {
+		return ""
+	}
+	return fmt.Sprintf("%02x:%02x:%02x:00:%02x:%02x", hw[0], hw[1], hw[2], n>>8, n&0xff)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newCaptureCommand golane capture fixture
+func newCaptureCommand() *Command {
+	fs := newFlagSet("capture fixture")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	out := fs.String("out", "", "write the fixture to this file instead of stdout (load it with -simulate-config)")
+	keepNames := fs.Bool("keep-names", false, "keep device and channel names (addresses are still replaced)")
+	remote := addRemoteFlags(fs)
+	domain := fs.String("domain", "", "domain on the remote monitor (required with -host when it runs several domains)")
+
+	fixture := &Command{
+		Name:  "fixture",
+		Short: "Write the discovered devices and subscriptions as a sanitized simulator config",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			opts := FixtureOptions{KeepNames: *keepNames}
+
+			var cfg *dante.SimulationConfig
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				devices, err := client.DomainDevices(*domain)
+				if err != nil {
+					return err
+				}
+				if cfg, err = CaptureFixture(ctx, devices, client.Routes(*domain), opts); err != nil {
+					return err
+				}
+			} else {
+				if err := ifaces.checkTiming(*wait); err != nil {
+					return err
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				d, err := ifaces.openPrimaryDomain(ctx, detector)
+				if err != nil {
+					return err
+				}
+				defer d.Cleanup()
+				if err := discover(ctx, d, *wait); err != nil {
+					return err
+				}
+				if cfg, err = CaptureFixture(ctx, d.GetDevices(), d, opts); err != nil {
+					return err
+				}
+			}
+
+			data, err := json.MarshalIndent(cfg, "", "  ")
+			if err != nil {
+				return err
+			}
+			data = append(data, '\n')
+			if *out == "" {
+				_, err = os.Stdout.Write(data)
+				return err
+			}
+			if err := os.WriteFile(*out, data, 0644); err != nil {
+				return err
+			}
+			fmt.Printf("Captured %d devices and %d subscriptions to %s\n", len(cfg.Devices), len(cfg.Routes), *out)
+			if !*keepNames {
+				fmt.Println("Device names, channel labels, addresses and MACs were replaced (use -keep-names to keep names)")
+			}
+			return nil
+		},
+	}
+
+	return &Command{
+		Name:  "capture",
+		Short: "Capture the live network for reproducing problems in the lab",
+		Sub:   []*Command{fixture, newCaptureTapeCommand()},
+	}
+}
+
+// newCaptureTapeCommand golane capture sdk-tape
+// 在實驗室以新版 libdapi 錄製後放入 internal/dante/testdata/sdktapes，
+// go test 會以它重播 Go 層 (tape 含設備名稱與位址，不要在現場錄製)
+func newCaptureTapeCommand() *Command {
+	fs := newFlagSet("capture sdk-tape")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	out := fs.String("out", "", "write the tape to this file instead of stdout")
+	notes := fs.String("notes", "", "describe the lab setup (devices, firmware) in the tape")
+
+	return &Command{
+		Name:  "sdk-tape",
+		Short: "Record the SDK answers to a read-only script for the compatibility tests",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			if err := ifaces.checkTiming(*wait); err != nil {
+				return err
+			}
+			detector, err := ifaces.detect()
+			if err != nil {
+				return err
+			}
+			d, err := ifaces.newPrimaryDomain(detector)
+			if err != nil {
+				return err
+			}
+			rec := d.RecordSDK()
+			if err := d.Initialize(ctx); err != nil {
+				return err
+			}
+			result := dante.RunTapeScript(ctx, d, *wait)
+			d.Cleanup()
+
+			tape := rec.Tape(d.NetworkConfig.InterfaceName, *notes)
+			tape.Expect = &result
+			data, err := json.MarshalIndent(tape, "", "  ")
+			if err != nil {
+				return err
+			}
+			data = append(data, '\n')
+			if *out == "" {
+				_, err = os.Stdout.Write(data)
+			} else if err = os.WriteFile(*out, data, 0644); err == nil {
+				fmt.Printf("Recorded %d SDK answers from libdapi %s to %s\n", len(tape.Answers), tape.SDKVersion, *out)
+			}
+			for _, problem := range result.Problems() {
+				fmt.Fprintln(os.Stderr, "warning:", problem)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestCaptureFixture(t *testing.T) {
+	ctx := context.Background()
+	live := &dante.SimulationConfig{
+		Devices: []dante.SimulatedDevice{
+			{Name: "Chapel-Desk", Model: "DL32", IPAddress: "172.20.5.10", SecondaryIP: "172.21.5.10", SecondarySpeed: 1000,
+				MacAddress: "00:1d:c1:8a:22:01", TxChannelNames: []string{"Pastor", "Choir"}, RxChannels: 2},
+			{Name: "Balcony-Amp", Model: "PA-4D", IPAddress: "169.254.40.7", MacAddress: "00:1d:c1:8a:22:02",
+				RxChannelNames: []string{"Left", "Right", "03"}},
+		},
+		Routes: []dante.SimulatedRoute{
+			{RxDevice: "Balcony-Amp", RxChannel: "Left", TxDevice: "Chapel-Desk", TxChannel: "Pastor"},
+			{RxDevice: "Balcony-Amp", RxChannel: "Right", TxDevice: "Chapel-Desk", TxChannel: "Choir"},
+			{RxDevice: "Balcony-Amp", RxChannel: "03", TxDevice: "Vestry-Mic", TxChannel: "01"},
+		},
+	}
+	d := dante.NewSimulatedDomain("Dante1", live.NetworkConfig(), dante.NewSimulatedSDK(live))
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(ctx)
+
+	cfg, err := CaptureFixture(ctx, d.GetDevices(), d, FixtureOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"Chapel", "Balcony", "Vestry", "Pastor", "Choir", "Left", "172.2", "8a:22"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("fixture contains %q: %s", secret, data)
+		}
+	}
+
+	// 去識別化後的設定可以載入，且重現相同的訂閱狀態
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := dante.LoadSimulationConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lab := dante.NewSimulatedDomain("Lab", loaded.NetworkConfig(), dante.NewSimulatedSDK(loaded))
+	if err := lab.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(lab.Cleanup)
+	if err := lab.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	lab.RefreshDevices(ctx)
+
+	devices := lab.GetDevices()
+	if len(devices) != 2 || devices[0].Name != "Device-01" || devices[0].IPAddress != "169.254.40.7" ||
+		devices[1].IPAddress != "10.0.1.10" || devices[1].SecondaryIP != "10.0.2.10" || devices[1].MacAddress != "00:1d:c1:00:00:02" {
+		t.Fatalf("lab devices = %+v", devices)
+	}
+	subs, err := lab.ListSubscriptions(ctx, "Device-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ channel, tx, status string }{
+		{"Rx01", "Tx01@Device-02", "connected"},
+		{"Rx02", "Tx02@Device-02", "connected"},
+		{"03", "01@Device-03", "unresolved"},
+	}
+	if len(subs) != len(want) {
+		t.Fatalf("lab subscriptions = %+v", subs)
+	}
+	for i, w := range want {
+		if subs[i].Channel != w.channel || subs[i].TxChannel+"@"+subs[i].TxDevice != w.tx || !strings.Contains(subs[i].StatusText(), w.status) {
+			t.Errorf("subscription %d = %+v (%s), want %+v", i, subs[i], subs[i].StatusText(), w)
+		}
+	}
+}
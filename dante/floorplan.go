@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 平面圖資料模型
+//==============================================================================
+
+// floorPlanSection 平面圖在狀態檔中的 section 名稱
+const floorPlanSection = "floorplan"
+
+// 設備在平面圖上的狀態顏色
+const (
+	StatusColorOnline  = "green"  // 已發現且正常
+	StatusColorWarning = "yellow" // 已發現但有問題 (例如停在 link-local)
+	StatusColorOffline = "red"    // 已放置但目前未發現
+)
+
+// Room 房間/區域
+type Room struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Floor  string  `json:"floor,omitempty"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// DevicePlacement 設備在平面圖上的位置
+type DevicePlacement struct {
+	Device string  `json:"device"` // 設備名稱
+	RoomID string  `json:"room_id,omitempty"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+}
+
+// FloorPlan 平面圖
+type FloorPlan struct {
+	Units      string            `json:"units,omitempty"` // 座標單位 (m、px)
+	Width      float64           `json:"width,omitempty"`
+	Height     float64           `json:"height,omitempty"`
+	Rooms      []Room            `json:"rooms"`
+	Placements []DevicePlacement `json:"placements"`
+}
+
+// PlacedDeviceStatus 平面圖上的設備與即時狀態
+type PlacedDeviceStatus struct {
+	DevicePlacement
+	Domain string `json:"domain,omitempty"`
+	Model  string `json:"model,omitempty"`
+	IP     string `json:"ip_address,omitempty"`
+	Online bool   `json:"online"`
+	Color  string `json:"color"`
+}
+
+// FloorPlanView 平面圖的即時檢視
+type FloorPlanView struct {
+	Units    string               `json:"units,omitempty"`
+	Width    float64              `json:"width,omitempty"`
+	Height   float64              `json:"height,omitempty"`
+	Rooms    []Room               `json:"rooms"`
+	Devices  []PlacedDeviceStatus `json:"devices"`
+	Unplaced []string             `json:"unplaced"` // 已發現但尚未放置的設備
+}
+
+// FloorPlanStore 平面圖存取
+type FloorPlanStore struct {
+	mu    sync.RWMutex
+	state *StateStore
+	plan  FloorPlan
+}
+
+// NewFloorPlanStore 從狀態檔載入平面圖
+func NewFloorPlanStore(state *StateStore) (*FloorPlanStore, error) {
+	fs := &FloorPlanStore{state: state}
+	if _, err := state.Load(floorPlanSection, &fs.plan); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Plan 取得平面圖副本
+func (fs *FloorPlanStore) Plan() FloorPlan {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.plan.clone()
+}
+
+func (p FloorPlan) clone() FloorPlan {
+	p.Rooms = append([]Room{}, p.Rooms...)
+	p.Placements = append([]DevicePlacement{}, p.Placements...)
+	return p
+}
+
+// Validate 檢查房間 ID 唯一且設備放置引用存在的房間
+func (p FloorPlan) Validate() error {
+	rooms := make(map[string]bool)
+	for _, r := range p.Rooms {
+		if r.ID == "" {
+			return fmt.Errorf("room without id")
+		}
+		if rooms[r.ID] {
+			return fmt.Errorf("duplicate room id %s", r.ID)
+		}
+		if r.Width < 0 || r.Height < 0 {
+			return fmt.Errorf("room %s has negative size", r.ID)
+		}
+		rooms[r.ID] = true
+	}
+
+	devices := make(map[string]bool)
+	for _, pl := range p.Placements {
+		if pl.Device == "" {
+			return fmt.Errorf("placement without device name")
+		}
+		if devices[pl.Device] {
+			return fmt.Errorf("device %s placed more than once", pl.Device)
+		}
+		devices[pl.Device] = true
+		if pl.RoomID != "" && !rooms[pl.RoomID] {
+			return fmt.Errorf("device %s references unknown room %s", pl.Device, pl.RoomID)
+		}
+	}
+	return nil
+}
+
+// update 驗證並保存修改後的平面圖
+func (fs *FloorPlanStore) update(change func(p *FloorPlan) error) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	next := fs.plan.clone()
+	if err := change(&next); err != nil {
+		return err
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	if err := fs.state.Save(floorPlanSection, next); err != nil {
+		return err
+	}
+	fs.plan = next
+	return nil
+}
+
+// Replace 取代整個平面圖
+func (fs *FloorPlanStore) Replace(plan FloorPlan) error {
+	return fs.update(func(p *FloorPlan) error {
+		*p = plan.clone()
+		return nil
+	})
+}
+
+// PutRoom 新增或修改房間
+func (fs *FloorPlanStore) PutRoom(room Room) error {
+	return fs.update(func(p *FloorPlan) error {
+		for i := range p.Rooms {
+			if p.Rooms[i].ID == room.ID {
+				p.Rooms[i] = room
+				return nil
+			}
+		}
+		p.Rooms = append(p.Rooms, room)
+		return nil
+	})
+}
+
+// DeleteRoom 刪除房間，房間內的設備保留座標但不再屬於任何房間
+func (fs *FloorPlanStore) DeleteRoom(id string) error {
+	return fs.update(func(p *FloorPlan) error {
+		for i := range p.Rooms {
+			if p.Rooms[i].ID == id {
+				p.Rooms = append(p.Rooms[:i], p.Rooms[i+1:]...)
+				for j := range p.Placements {
+					if p.Placements[j].RoomID == id {
+						p.Placements[j].RoomID = ""
+					}
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("room %s not found", id)
+	})
+}
+
+// PlaceDevice 設定設備位置
+func (fs *FloorPlanStore) PlaceDevice(placement DevicePlacement) error {
+	return fs.update(func(p *FloorPlan) error {
+		for i := range p.Placements {
+			if strings.EqualFold(p.Placements[i].Device, placement.Device) {
+				p.Placements[i] = placement
+				return nil
+			}
+		}
+		p.Placements = append(p.Placements, placement)
+		return nil
+	})
+}
+
+// RemoveDevice 從平面圖移除設備
+func (fs *FloorPlanStore) RemoveDevice(name string) error {
+	return fs.update(func(p *FloorPlan) error {
+		for i := range p.Placements {
+			if strings.EqualFold(p.Placements[i].Device, name) {
+				p.Placements = append(p.Placements[:i], p.Placements[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("device %s is not placed", name)
+	})
+}
+
+// View 結合目前發現的設備產生即時檢視
+func (fs *FloorPlanStore) View(domains []supervisor.Snapshot) FloorPlanView {
+	plan := fs.Plan()
+
+	type seenDevice struct {
+		domain string
+		device dante.Device
+	}
+	seen := make(map[string]seenDevice)
+	for _, d := range domains {
+		for _, dev := range d.Devices {
+			seen[strings.ToLower(dev.Name)] = seenDevice{d.Name, dev}
+		}
+	}
+
+	view := FloorPlanView{
+		Units:    plan.Units,
+		Width:    plan.Width,
+		Height:   plan.Height,
+		Rooms:    plan.Rooms,
+		Devices:  []PlacedDeviceStatus{},
+		Unplaced: []string{},
+	}
+
+	placed := make(map[string]bool)
+	for _, pl := range plan.Placements {
+		key := strings.ToLower(pl.Device)
+		placed[key] = true
+
+		status := PlacedDeviceStatus{DevicePlacement: pl, Color: StatusColorOffline}
+		if s, ok := seen[key]; ok {
+			status.Domain = s.domain
+			status.Model = s.device.Model
+			status.IP = s.device.IPAddress
+			status.Online = true
+			status.Color = StatusColorOnline
+			if s.device.IsLinkLocal() {
+				status.Color = StatusColorWarning
+			}
+		}
+		view.Devices = append(view.Devices, status)
+	}
+
+	for key, s := range seen {
+		if !placed[key] {
+			view.Unplaced = append(view.Unplaced, s.device.Name)
+		}
+	}
+	sort.Strings(view.Unplaced)
+
+	return view
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// Multicast 發送 flow
+//==============================================================================
+
+// 一個來源送到大量接收端時，unicast 訂閱會用完發送設備的 flow 數量。
+// 先在發送設備建立 multicast flow，接收端訂閱其中的通道時就由這個 flow 提供。
+
+// FlowController 發送 flow 操作 (由 dante.Domain 實作)
+type FlowController interface {
+	TxFlows(ctx context.Context, device string) ([]dante.Flow, error)
+	CreateMulticastFlow(ctx context.Context, device string, config dante.FlowConfig) (int, error)
+	DeleteTxFlow(ctx context.Context, device string, flowID int) error
+}
+
+var _ FlowController = (*dante.Domain)(nil)
+
+// flowCreated 建立 flow 的回應
+type flowCreated struct {
+	ID int `json:"id"`
+}
+
+// flowStatus flow 操作失敗的 HTTP 狀態
+func flowStatus(err error) int {
+	if errors.Is(err, dante.ErrInvalidFlow) {
+		return http.StatusBadRequest
+	}
+	return subscribeStatus(err)
+}
+
+func (s *APIServer) handleFlows(w http.ResponseWriter, r *http.Request) {
+	fc, err := selectDomain(r, s.flows)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	flows, err := fc.TxFlows(r.Context(), r.PathValue("device"))
+	if err != nil {
+		writeError(w, flowStatus(err), err)
+		return
+	}
+	if flows == nil {
+		flows = []dante.Flow{}
+	}
+	writeJSON(w, http.StatusOK, flows)
+}
+
+func (s *APIServer) handleCreateFlow(w http.ResponseWriter, r *http.Request) {
+	fc, err := selectDomain(r, s.flows)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req dante.FlowConfig
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	id, err := fc.CreateMulticastFlow(r.Context(), r.PathValue("device"), req)
+	if err != nil {
+		writeError(w, flowStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, flowCreated{ID: id})
+}
+
+func (s *APIServer) handleDeleteFlow(w http.ResponseWriter, r *http.Request) {
+	fc, err := selectDomain(r, s.flows)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid flow id %q", r.PathValue("id")))
+		return
+	}
+	if err := fc.DeleteTxFlow(r.Context(), r.PathValue("device"), id); err != nil {
+		writeError(w, flowStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//------------------------------------------------------------------------------
+// 遠端
+//------------------------------------------------------------------------------
+
+// Flows 指定網域的 flow 操作 (domain 空白時由 daemon 選擇唯一的網域)
+func (c *RemoteClient) Flows(domain string) FlowController {
+	return remoteFlows{client: c, domain: domain}
+}
+
+// remoteFlows 透過 API 實作 FlowController
+type remoteFlows struct {
+	client *RemoteClient
+	domain string
+}
+
+// path /api/devices/<device>/flows[/<id>]
+func (f remoteFlows) path(device string, id ...int) string {
+	path := "/api/devices/" + url.PathEscape(device) + "/flows"
+	for _, i := range id {
+		path += "/" + strconv.Itoa(i)
+	}
+	if f.domain != "" {
+		path += "?domain=" + url.QueryEscape(f.domain)
+	}
+	return path
+}
+
+func (f remoteFlows) TxFlows(ctx context.Context, device string) ([]dante.Flow, error) {
+	var flows []dante.Flow
+	return flows, f.client.doContext(ctx, http.MethodGet, f.path(device), nil, &flows)
+}
+
+func (f remoteFlows) CreateMulticastFlow(ctx context.Context, device string, config dante.FlowConfig) (int, error) {
+	var created flowCreated
+	err := f.client.doContext(ctx, http.MethodPost, f.path(device), config, &created)
+	return created.ID, err
+}
+
+func (f remoteFlows) DeleteTxFlow(ctx context.Context, device string, flowID int) error {
+	return f.client.doContext(ctx, http.MethodDelete, f.path(device, flowID), nil, nil)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newFlowCommand golane flow list|create|delete
+func newFlowCommand() *Command {
+	var name, address string
+	var packetTime time.Duration
+	create := newFlowActionCommand("create", "<tx-device> <channels>",
+		"Create a multicast TX flow carrying the given TX channel IDs (e.g. 1-8 or 1,3,5)",
+		func(ctx context.Context, fc FlowController, args []string, jsonOut bool) error {
+			if len(args) != 2 {
+				return errUsage
+			}
+			config := dante.FlowConfig{Name: name, PacketTimeUs: int(packetTime / time.Microsecond)}
+			var err error
+			if config.Channels, err = parseChannelIDs(args[1]); err != nil {
+				return err
+			}
+			if address != "" {
+				ap, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return fmt.Errorf("multicast address must be written as address:port, got %q", address)
+				}
+				config.Address, config.Port = ap.Addr().String(), int(ap.Port())
+			}
+			id, err := fc.CreateMulticastFlow(ctx, args[0], config)
+			if err != nil {
+				return err
+			}
+			if jsonOut {
+				return printJSON(flowCreated{ID: id})
+			}
+			fmt.Printf("Created multicast flow %d on %s\n", id, args[0])
+			return nil
+		})
+	create.Flags.StringVar(&name, "name", "", "flow name (default chosen by the device)")
+	create.Flags.StringVar(&address, "address", "", "multicast address:port (default chosen by the device)")
+	create.Flags.DurationVar(&packetTime, "packet-time", 0, "packet time, e.g. 1ms (default chosen by the device)")
+
+	return &Command{
+		Name:  "flow",
+		Short: "Manage multicast TX flows for large fan-out routes",
+		Sub: []*Command{
+			newFlowActionCommand("list", "<tx-device>",
+				"List the TX flows of a device",
+				func(ctx context.Context, fc FlowController, args []string, jsonOut bool) error {
+					if len(args) != 1 {
+						return errUsage
+					}
+					flows, err := fc.TxFlows(ctx, args[0])
+					if err != nil {
+						return err
+					}
+					if jsonOut {
+						if flows == nil {
+							flows = []dante.Flow{}
+						}
+						return printJSON(flows)
+					}
+					printFlows(args[0], flows)
+					return nil
+				}),
+			create,
+			newFlowActionCommand("delete", "<tx-device> <flow-id>",
+				"Delete a multicast TX flow (receivers fall back to unicast)",
+				func(ctx context.Context, fc FlowController, args []string, _ bool) error {
+					if len(args) != 2 {
+						return errUsage
+					}
+					id, err := strconv.Atoi(args[1])
+					if err != nil || id < 1 {
+						return fmt.Errorf("invalid flow id %q", args[1])
+					}
+					return fc.DeleteTxFlow(ctx, args[0], id)
+				}),
+		},
+	}
+}
+
+// newFlowActionCommand 建立 flow 子命令 (只初始化 SDK，不需要設備掃描)
+func newFlowActionCommand(name, usage, short string, action func(ctx context.Context, fc FlowController, args []string, jsonOut bool) error) *Command {
+	fs := newFlagSet("flow " + name)
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	jsonOut := fs.Bool("json", false, "print results as JSON")
+	remote := addRemoteFlags(fs)
+	domain := fs.String("domain", "", "domain on the remote monitor (required with -host when it runs several domains)")
+	stateDir := fs.String("state-dir", ".", "state directory whose audit log records local changes (the monitor records changes made with -host)")
+
+	return &Command{
+		Name:  name,
+		Short: short,
+		Args:  usage,
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			ctx, cancel := commandContext()
+			defer cancel()
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				return action(ctx, client.Flows(*domain), args, *jsonOut)
+			}
+
+			audit, err := OpenAuditLog(*stateDir)
+			if err != nil {
+				return err
+			}
+			detector, err := ifaces.detect()
+			if err != nil {
+				return err
+			}
+			d, err := ifaces.openPrimaryDomain(ctx, detector)
+			if err != nil {
+				return err
+			}
+			defer d.Cleanup()
+
+			return action(cliAuditContext(ctx), auditFlows(audit, d.Name, d), args, *jsonOut)
+		},
+	}
+}
+
+// parseChannelIDs 解析通道編號列表 ("1-8", "1,3,5" 或兩者混用)
+func parseChannelIDs(s string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel list %q", s)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil || last < first {
+				return nil, fmt.Errorf("invalid channel range %q", part)
+			}
+		}
+		for id := first; id <= last; id++ {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// printFlows 印出設備的發送 flow
+func printFlows(device string, flows []dante.Flow) {
+	fmt.Print(i18n.Sprintf("\n=== %s TX Flows ===\n", device))
+	printHeader("%-4s %-20s %-10s %-22s %-6s %s\n", "ID", "NAME", "TYPE", "DESTINATION", "FPP", "CHANNELS")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────")
+	for _, f := range flows {
+		kind := "unicast"
+		if f.Multicast {
+			kind = "multicast"
+		}
+		destination := "-"
+		if f.Address != "" {
+			destination = fmt.Sprintf("%s:%d", f.Address, f.Port)
+		}
+		channels := make([]string, len(f.Channels))
+		for i, ch := range f.Channels {
+			channels[i] = "-"
+			if ch > 0 {
+				channels[i] = strconv.Itoa(ch)
+			}
+		}
+		fmt.Printf("%-4d %-20s %-10s %-22s %-6d %s\n", f.ID, f.Name, kind, destination, f.FramesPerPacket, strings.Join(channels, ","))
+	}
+	fmt.Println()
+}
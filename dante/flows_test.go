@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestFlowAPI(t *testing.T) {
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	audit, err := OpenAuditLog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{
+		Flows: map[string]FlowController{d.Name: auditFlows(audit, d.Name, d)},
+		Audit: audit,
+	}).mux)
+	defer server.Close()
+
+	send := func(method, path, body string) int {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := send(http.MethodPost, "/api/devices/FOH-Console/flows", `{"channels": []}`); status != http.StatusBadRequest {
+		t.Fatalf("empty flow: status %d, want 400", status)
+	}
+	if status := send(http.MethodPost, "/api/devices/FOH-Console/flows", `{"channels": [1, 2], "address": "239.69.0.10", "port": 5004}`); status != http.StatusCreated {
+		t.Fatalf("POST flow: status %d", status)
+	}
+	var flows []dante.Flow
+	getJSON(t, server.URL+"/api/devices/FOH-Console/flows", &flows)
+	if len(flows) != 1 || flows[0].Address != "239.69.0.10" || !flows[0].Multicast {
+		t.Fatalf("flows = %+v", flows)
+	}
+	if status := send(http.MethodDelete, "/api/devices/FOH-Console/flows/x", ""); status != http.StatusBadRequest {
+		t.Fatalf("DELETE invalid id: status %d, want 400", status)
+	}
+	if status := send(http.MethodDelete, "/api/devices/FOH-Console/flows/1", ""); status != http.StatusNoContent {
+		t.Fatalf("DELETE flow: status %d", status)
+	}
+
+	entries, err := audit.Entries(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[1].Operation != AuditFlowCreate || entries[2].Operation != AuditFlowDelete ||
+		entries[2].Before != "flow-1: channels 1,2 -> 239.69.0.10:5004" {
+		t.Fatalf("audit entries = %+v", entries)
+	}
+}
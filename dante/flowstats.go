@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/trace"
+)
+
+//==============================================================================
+// 接收 flow 的封包錯誤統計
+//==============================================================================
+
+// 設備回報的計數從開機開始累計，只看總數分不出是現在正在掉封包還是昨天
+// 換線時掉的。FlowStatsTracker 定期讀取每台接收設備的 flow 統計，記住上一次
+// 的計數算出這段期間新增的錯誤 (Recent)，設備的健康狀態依此判斷；累計的
+// 計數另外以 Prometheus 文字格式在 /metrics 輸出，由 Prometheus 自己算速率。
+
+// 設備的 flow 健康狀態
+const (
+	FlowHealthOK      = "ok"      // 上次讀取後沒有新的錯誤封包
+	FlowHealthErrors  = "errors"  // 上次讀取後有新的錯誤封包
+	FlowHealthUnknown = "unknown" // 第一次讀取，還沒有可比較的計數
+)
+
+// FlowStatsOptions flow 統計參數
+type FlowStatsOptions struct {
+	Interval time.Duration `json:"interval"` // 讀取統計的間隔
+}
+
+// DefaultFlowStatsOptions 每分鐘讀取一次
+func DefaultFlowStatsOptions() FlowStatsOptions {
+	return FlowStatsOptions{Interval: time.Minute}
+}
+
+// Validate 檢查參數
+func (o FlowStatsOptions) Validate() error {
+	if o.Interval <= 0 {
+		return fmt.Errorf("invalid flow statistics interval %s", o.Interval)
+	}
+	return nil
+}
+
+// DeviceFlowStats 單一接收設備的 flow 統計
+type DeviceFlowStats struct {
+	Domain  string              `json:"domain"`
+	Device  string              `json:"device"`
+	Health  string              `json:"health"`
+	Total   dante.FlowCounters  `json:"total"`  // 所有 flow 與介面的累計計數
+	Recent  dante.FlowCounters  `json:"recent"` // 上次讀取後新增的計數
+	Flows   []dante.RxFlowStats `json:"flows"`
+	Updated time.Time           `json:"updated"`
+}
+
+// FlowStatsStatus /api/flowstats 的回應
+type FlowStatsStatus struct {
+	Options FlowStatsOptions  `json:"options"`
+	Devices []DeviceFlowStats `json:"devices"`
+}
+
+// FlowStatsTracker 各網域接收設備的 flow 統計，所有網域的 domainWorker 共用同一個
+type FlowStatsTracker struct {
+	mu      sync.Mutex
+	opts    FlowStatsOptions
+	devices map[string]*DeviceFlowStats // 網域|小寫設備名稱
+}
+
+// NewFlowStatsTracker 建立追蹤器
+func NewFlowStatsTracker(opts FlowStatsOptions) *FlowStatsTracker {
+	return &FlowStatsTracker{opts: opts, devices: make(map[string]*DeviceFlowStats)}
+}
+
+// flowKey 比較前後兩次計數用的 flow 與介面
+func flowKey(flow dante.RxFlowStats, iface int) string {
+	return strconv.Itoa(flow.ID) + "/" + flow.TxDevice + "/" + flow.TxFlow + "/" + strconv.Itoa(iface)
+}
+
+// counterDelta 兩次讀取之間新增的計數，計數變小 (設備重開機或被清除) 時取目前的值
+func counterDelta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// Update 以設備最新的 flow 統計更新，回傳更新後的狀態
+func (t *FlowStatsTracker) Update(domain, device string, flows []dante.RxFlowStats, now time.Time) DeviceFlowStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := domain + "|" + strings.ToLower(device)
+	prev := t.devices[key]
+	last := make(map[string]dante.FlowCounters)
+	if prev != nil {
+		for _, flow := range prev.Flows {
+			for i, c := range flow.Interfaces {
+				last[flowKey(flow, i)] = c
+			}
+		}
+	}
+
+	stats := &DeviceFlowStats{Domain: domain, Device: device, Health: FlowHealthUnknown, Flows: flows, Updated: now}
+	for _, flow := range flows {
+		for i, c := range flow.Interfaces {
+			stats.Total.Add(c)
+			p := last[flowKey(flow, i)] // 新的 flow 從 0 開始
+			stats.Recent.Add(dante.FlowCounters{
+				Early:        counterDelta(p.Early, c.Early),
+				Late:         counterDelta(p.Late, c.Late),
+				OutOfOrder:   counterDelta(p.OutOfOrder, c.OutOfOrder),
+				Dropped:      counterDelta(p.Dropped, c.Dropped),
+				MaxLatencyUs: c.MaxLatencyUs,
+			})
+		}
+	}
+	if prev != nil {
+		stats.Health = FlowHealthOK
+		if stats.Recent.Errors() > 0 {
+			stats.Health = FlowHealthErrors
+			logger.Warn("Audio packet errors on RX flows", "domain", domain, "device", device,
+				"late", stats.Recent.Late, "dropped", stats.Recent.Dropped,
+				"early", stats.Recent.Early, "out_of_order", stats.Recent.OutOfOrder)
+		}
+	} else {
+		stats.Recent = dante.FlowCounters{MaxLatencyUs: stats.Total.MaxLatencyUs}
+	}
+	t.devices[key] = stats
+	return *stats
+}
+
+// Prune 移除網域中不在列表上的設備
+func (t *FlowStatsTracker) Prune(domain string, devices []dante.Device) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	listed := make(map[string]bool, len(devices))
+	for _, dev := range devices {
+		listed[domain+"|"+strings.ToLower(dev.Name)] = true
+	}
+	for key := range t.devices {
+		if strings.HasPrefix(key, domain+"|") && !listed[key] {
+			delete(t.devices, key)
+		}
+	}
+}
+
+// Status 目前的統計 (依網域與設備排序)
+func (t *FlowStatsTracker) Status() FlowStatsStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := FlowStatsStatus{Options: t.opts, Devices: []DeviceFlowStats{}}
+	for _, s := range t.devices {
+		status.Devices = append(status.Devices, *s)
+	}
+	slices.SortFunc(status.Devices, func(a, b DeviceFlowStats) int {
+		if c := strings.Compare(a.Domain, b.Domain); c != 0 {
+			return c
+		}
+		return strings.Compare(strings.ToLower(a.Device), strings.ToLower(b.Device))
+	})
+	return status
+}
+
+//------------------------------------------------------------------------------
+// Prometheus
+//------------------------------------------------------------------------------
+
+// promLabel 跳脫 Prometheus 標籤值
+var promLabel = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// flowInterfaces 介面的標籤值 (依 RxFlowStats.Interfaces 的順序)
+var flowInterfaces = []string{"primary", "secondary"}
+
+// writeMetrics 以 Prometheus 文字格式輸出統計
+// 只輸出設備提供的統計種類，沒有提供的計數不能當成 0
+func (t *FlowStatsTracker) writeMetrics(w io.Writer) {
+	status := t.Status()
+
+	fmt.Fprintln(w, "# HELP golane_rxflow_packets_total Packets received with errors on a Dante RX flow, counted by the device since it started.")
+	fmt.Fprintln(w, "# TYPE golane_rxflow_packets_total counter")
+	for _, dev := range status.Devices {
+		for _, flow := range dev.Flows {
+			for i, c := range flow.Interfaces {
+				values := map[string]uint64{
+					dante.FlowStatEarly:      c.Early,
+					dante.FlowStatLate:       c.Late,
+					dante.FlowStatOutOfOrder: c.OutOfOrder,
+					dante.FlowStatDropped:    c.Dropped,
+				}
+				for _, kind := range flow.Reported {
+					if v, ok := values[kind]; ok {
+						fmt.Fprintf(w, "golane_rxflow_packets_total{%s,type=%q} %d\n", flowLabels(dev, flow, i), kind, v)
+					}
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP golane_rxflow_max_latency_seconds Highest latency of packets received on a Dante RX flow.")
+	fmt.Fprintln(w, "# TYPE golane_rxflow_max_latency_seconds gauge")
+	for _, dev := range status.Devices {
+		for _, flow := range dev.Flows {
+			if !slices.Contains(flow.Reported, dante.FlowStatMaxLatency) {
+				continue
+			}
+			for i, c := range flow.Interfaces {
+				fmt.Fprintf(w, "golane_rxflow_max_latency_seconds{%s} %g\n", flowLabels(dev, flow, i), float64(c.MaxLatencyUs)/1e6)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP golane_device_rxflow_healthy Whether the device received no new packet errors since the previous read (1 ok, 0 errors, -1 unknown).")
+	fmt.Fprintln(w, "# TYPE golane_device_rxflow_healthy gauge")
+	for _, dev := range status.Devices {
+		healthy := -1
+		switch dev.Health {
+		case FlowHealthOK:
+			healthy = 1
+		case FlowHealthErrors:
+			healthy = 0
+		}
+		fmt.Fprintf(w, "golane_device_rxflow_healthy{%s} %d\n", deviceLabels(dev), healthy)
+	}
+
+	fmt.Fprintln(w, "# HELP golane_device_rxflow_recent_errors Packet errors on all RX flows of the device since the previous read.")
+	fmt.Fprintln(w, "# TYPE golane_device_rxflow_recent_errors gauge")
+	for _, dev := range status.Devices {
+		fmt.Fprintf(w, "golane_device_rxflow_recent_errors{%s} %d\n", deviceLabels(dev), dev.Recent.Errors())
+	}
+}
+
+// deviceLabels 設備的標籤
+func deviceLabels(dev DeviceFlowStats) string {
+	return fmt.Sprintf(`domain="%s",device="%s"`, promLabel.Replace(dev.Domain), promLabel.Replace(dev.Device))
+}
+
+// flowLabels flow 在一個介面上的標籤
+func flowLabels(dev DeviceFlowStats, flow dante.RxFlowStats, iface int) string {
+	name := flow.Name
+	if name == "" {
+		name = strconv.Itoa(flow.ID)
+	}
+	ifname := strconv.Itoa(iface)
+	if iface < len(flowInterfaces) {
+		ifname = flowInterfaces[iface]
+	}
+	return fmt.Sprintf(`%s,flow="%s",tx_device="%s",interface="%s"`, deviceLabels(dev),
+		promLabel.Replace(name), promLabel.Replace(flow.TxDevice), ifname)
+}
+
+//------------------------------------------------------------------------------
+// 監控
+//------------------------------------------------------------------------------
+
+// pollFlowStats 讀取每台接收設備的 flow 統計交給 FlowStatsTracker
+func (w *domainWorker) pollFlowStats() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d := w.domain
+	if w.report == nil || w.flowStats == nil {
+		return
+	}
+	ctx, span := trace.Start(context.Background(), "domain.flow_stats", slog.String("dante.domain", d.Name))
+	defer span.End()
+	readFlowStats(ctx, d, d.GetDevices(), w.flowStats)
+}
+
+// readFlowStats 讀取有接收通道的設備的 flow 統計 (不支援或讀取失敗的設備略過)
+func readFlowStats(ctx context.Context, d *dante.Domain, devices []dante.Device, tracker *FlowStatsTracker) {
+	tracker.Prune(d.Name, devices)
+	for _, dev := range devices {
+		if dev.RxChannels == 0 {
+			continue
+		}
+		flows, err := d.RxFlowStats(ctx, dev.Name)
+		if err != nil {
+			d.Logger().Debug("Flow statistics query failed", "device", dev.Name, "err", err)
+			continue
+		}
+		tracker.Update(d.Name, dev.Name, flows, time.Now())
+	}
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// handleFlowStats GET /api/flowstats
+func (s *APIServer) handleFlowStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.flowStats.Status())
+}
+
+// handleMetrics GET /metrics (Prometheus 文字格式)
+func (s *APIServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.flowStats.writeMetrics(w)
+}
+
+// FlowStats daemon 讀取的 flow 統計
+func (c *RemoteClient) FlowStats() (FlowStatsStatus, error) {
+	var status FlowStatsStatus
+	return status, c.do(http.MethodGet, "/api/flowstats", nil, &status)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newFlowStatsCommand golane diag flowstats
+func newFlowStatsCommand() *Command {
+	fs := newFlagSet("flowstats")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	duration := fs.Duration("duration", 10*time.Second, "read the statistics twice this far apart to show recent errors (local mode)")
+	jsonOut := fs.Bool("json", false, "print the statistics as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "flowstats",
+		Short: "Show dropped, late and out-of-order packets on the RX flows of every device",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			opts := FlowStatsOptions{Interval: *duration}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			var status FlowStatsStatus
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if status, err = client.FlowStats(); err != nil {
+					return err
+				}
+			} else {
+				if err := ifaces.checkTiming(*wait); err != nil {
+					return err
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+				domain, err := ifaces.openPrimaryDomain(ctx, detector)
+				if err != nil {
+					return err
+				}
+				defer domain.Cleanup()
+				if err := discover(ctx, domain, *wait); err != nil {
+					return err
+				}
+
+				tracker := NewFlowStatsTracker(opts)
+				readFlowStats(ctx, domain, domain.GetDevices(), tracker)
+				logger.Info("Reading flow statistics", "duration", *duration)
+				select {
+				case <-ctx.Done():
+				case <-time.After(*duration):
+					readFlowStats(ctx, domain, domain.GetDevices(), tracker)
+				}
+				status = tracker.Status()
+			}
+
+			if *jsonOut {
+				return printJSON(status)
+			}
+			printFlowStats(status)
+			return nil
+		},
+	}
+}
+
+// printFlowStats 印出 flow 統計
+func printFlowStats(status FlowStatsStatus) {
+	fmt.Print(i18n.Sprintf("\n=== RX flow packet errors (recent within %s) ===\n", status.Options.Interval))
+	printHeader("\n%-10s %-20s %-8s %-20s %-20s %-10s %-10s %-10s %-10s %s\n",
+		"DOMAIN", "DEVICE", "HEALTH", "FLOW", "SOURCE", "DROPPED", "LATE", "EARLY", "ORDER", "LATENCY")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+	for _, dev := range status.Devices {
+		if len(dev.Flows) == 0 {
+			fmt.Printf("%-10s %-20s %-8s %s\n", dev.Domain, dev.Device, dev.Health, i18n.T("(no flows)"))
+			continue
+		}
+		for _, flow := range dev.Flows {
+			name := flow.Name
+			if name == "" {
+				name = strconv.Itoa(flow.ID)
+			}
+			source := flow.TxDevice
+			if flow.TxFlow != "" {
+				source += "/" + flow.TxFlow
+			}
+			c := flow.Total()
+			latency := "-"
+			if slices.Contains(flow.Reported, dante.FlowStatMaxLatency) {
+				latency = (time.Duration(c.MaxLatencyUs) * time.Microsecond).String()
+			}
+			fmt.Printf("%-10s %-20s %-8s %-20s %-20s %-10s %-10s %-10s %-10s %s\n", dev.Domain, dev.Device, dev.Health,
+				name, source, flowCount(flow, dante.FlowStatDropped, c.Dropped), flowCount(flow, dante.FlowStatLate, c.Late),
+				flowCount(flow, dante.FlowStatEarly, c.Early), flowCount(flow, dante.FlowStatOutOfOrder, c.OutOfOrder), latency)
+		}
+	}
+	fmt.Println()
+}
+
+// flowCount 計數的文字，設備沒有提供的種類顯示 -
+func flowCount(flow dante.RxFlowStats, kind string, v uint64) string {
+	if !slices.Contains(flow.Reported, kind) {
+		return "-"
+	}
+	return strconv.FormatUint(v, 10)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+func TestFlowStatsTrackerRecent(t *testing.T) {
+	tracker := NewFlowStatsTracker(DefaultFlowStatsOptions())
+	now := time.Unix(1700000000, 0)
+	update := func(late, dropped uint64) DeviceFlowStats {
+		now = now.Add(time.Minute)
+		return tracker.Update("Dante1", "Amp-Left", []dante.RxFlowStats{{
+			ID: 1, TxDevice: "Console", Reported: []string{dante.FlowStatLate, dante.FlowStatDropped},
+			Interfaces: []dante.FlowCounters{{Late: late, Dropped: dropped}, {}},
+		}}, now)
+	}
+
+	// 第一次讀取只有累計值，無法判斷
+	if s := update(10, 4); s.Health != FlowHealthUnknown || s.Recent.Errors() != 0 || s.Total.Errors() != 14 {
+		t.Fatalf("first read: %+v", s)
+	}
+	if s := update(10, 4); s.Health != FlowHealthOK || s.Recent.Errors() != 0 {
+		t.Fatalf("unchanged: %+v", s)
+	}
+	if s := update(12, 5); s.Health != FlowHealthErrors || s.Recent.Late != 2 || s.Recent.Dropped != 1 {
+		t.Fatalf("new errors: %+v", s)
+	}
+	// 設備重開機後計數從 0 開始
+	if s := update(1, 0); s.Health != FlowHealthErrors || s.Recent.Late != 1 || s.Recent.Dropped != 0 {
+		t.Fatalf("after reset: %+v", s)
+	}
+
+	tracker.Prune("Dante1", []dante.Device{{Name: "Console"}})
+	if status := tracker.Status(); len(status.Devices) != 0 {
+		t.Errorf("pruned device still listed: %+v", status.Devices)
+	}
+}
+
+func TestFlowStatsMetrics(t *testing.T) {
+	cfg := dante.DefaultSimulationConfig()
+	cfg.Routes = []dante.SimulatedRoute{
+		{RxDevice: "Amp-Left", RxChannel: "01", TxDevice: "Stage-Box-A", TxChannel: "01"},
+	}
+	sdk := dante.NewSimulatedSDK(cfg)
+	d := dante.NewSimulatedDomain("Dante1", cfg.NetworkConfig(), sdk)
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+
+	// 沒有接收通道的設備不讀取
+	devices := []dante.Device{{Name: "Amp-Left", RxChannels: 2}, {Name: "Stage-Box-A"}}
+	tracker := NewFlowStatsTracker(DefaultFlowStatsOptions())
+	readFlowStats(ctx, d, devices, tracker)
+	sdk.FlowErrors["Amp-Left"] = map[string]dante.FlowCounters{"Stage-Box-A": {Dropped: 7, MaxLatencyUs: 1500}}
+	readFlowStats(ctx, d, devices, tracker)
+	if status := tracker.Status(); len(status.Devices) != 1 || len(status.Devices[0].Flows) != 1 {
+		t.Fatalf("status: %+v", status.Devices)
+	}
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{FlowStats: tracker}).mux)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	labels := `domain="Dante1",device="Amp-Left"`
+	for _, want := range []string{
+		"# TYPE golane_rxflow_packets_total counter",
+		`golane_rxflow_packets_total{` + labels + `,flow=`,
+		`interface="primary",type="dropped"} 7`,
+		`golane_rxflow_max_latency_seconds{` + labels,
+		`golane_device_rxflow_healthy{` + labels + `} 0`,
+		`golane_device_rxflow_recent_errors{` + labels + `} 7`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/panel"
+	"danteCS/internal/recovery"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 前面板顯示器
+//==============================================================================
+
+// 無頭部署的機櫃前面板裝一塊 I2C 小螢幕，顯示每個網域的名稱、設備數與 IP：
+//
+//	"front_panel": {"driver": "ssd1306", "bus": 1}
+//	"front_panel": {"driver": "hd44780", "bus": 1, "columns": 20, "rows": 4}
+//
+// 每次網域刷新 (TopicDevices) 或網域失敗時重繪，另外定期重繪以反映啟動中的網域。
+// 行數不足時先顯示網域，最後一行有空間時顯示管理介面的 IP。
+
+// frontPanelRedraw 沒有事件時的重繪間隔
+const frontPanelRedraw = 10 * time.Second
+
+// 顯示器驅動
+const (
+	PanelSSD1306 = "ssd1306" // 128x64 或 128x32 OLED
+	PanelHD44780 = "hd44780" // PCF8574 轉接板的字元 LCD
+)
+
+// FrontPanelConfig 設定檔的 front_panel section
+type FrontPanelConfig struct {
+	Driver  string `json:"driver"`            // ssd1306 或 hd44780
+	Bus     int    `json:"bus"`               // /dev/i2c-N
+	Address int    `json:"address,omitempty"` // I2C 地址 (預設 ssd1306 0x3c、hd44780 0x27)
+	Height  int    `json:"height,omitempty"`  // ssd1306 高度 32 或 64 (預設 64)
+	Columns int    `json:"columns,omitempty"` // hd44780 欄數 (預設 16)
+	Rows    int    `json:"rows,omitempty"`    // hd44780 列數 (預設 2)
+}
+
+// Validate 檢查驅動與尺寸
+func (c FrontPanelConfig) Validate() error {
+	switch c.Driver {
+	case PanelSSD1306:
+		if c.Height != 0 && c.Height != 32 && c.Height != 64 {
+			return fmt.Errorf("front_panel: height must be 32 or 64, not %d", c.Height)
+		}
+	case PanelHD44780:
+		if c.Columns < 0 || c.Columns > 40 || c.Rows < 0 || c.Rows > 4 {
+			return fmt.Errorf("front_panel: unsupported size %dx%d", c.Columns, c.Rows)
+		}
+	default:
+		return fmt.Errorf("front_panel: unknown driver %q (use %s or %s)", c.Driver, PanelSSD1306, PanelHD44780)
+	}
+	if c.Bus < 0 || c.Address < 0 || c.Address > 0x7f {
+		return fmt.Errorf("front_panel: invalid bus %d or address %#x", c.Bus, c.Address)
+	}
+	return nil
+}
+
+// open 開啟 I2C 匯流排並初始化顯示器
+func (c FrontPanelConfig) open() (panel.Display, error) {
+	addr := cmp.Or(c.Address, 0x3c)
+	if c.Driver == PanelHD44780 {
+		addr = cmp.Or(c.Address, 0x27)
+	}
+	bus, err := panel.OpenI2C(c.Bus, uint16(addr))
+	if err != nil {
+		return nil, err
+	}
+	var d panel.Display
+	if c.Driver == PanelHD44780 {
+		d, err = panel.NewHD44780(bus, cmp.Or(c.Columns, 16), cmp.Or(c.Rows, 2))
+	} else {
+		d, err = panel.NewSSD1306(bus, cmp.Or(c.Height, 64))
+	}
+	if err != nil {
+		bus.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// FrontPanel 在顯示器上顯示網域狀態
+type FrontPanel struct {
+	cfg      FrontPanelConfig
+	domains  *supervisor.Supervisor
+	detector *NetworkDetector
+}
+
+// NewFrontPanel 建立前面板 (尚未開啟顯示器)
+func NewFrontPanel(cfg FrontPanelConfig, domains *supervisor.Supervisor, detector *NetworkDetector) *FrontPanel {
+	return &FrontPanel{cfg: cfg, domains: domains, detector: detector}
+}
+
+// Start 開啟顯示器並在背景更新直到 ctx 結束 (結束時清空畫面)
+func (p *FrontPanel) Start(ctx context.Context, events *golane.Bus) {
+	display, err := p.cfg.open()
+	if err != nil {
+		logger.Warn("Front panel display unavailable", "driver", p.cfg.Driver, "bus", p.cfg.Bus, "err", err)
+		return
+	}
+	cols, rows := display.Size()
+	logger.Info("Front panel display enabled", "driver", p.cfg.Driver, "bus", p.cfg.Bus, "size", fmt.Sprintf("%dx%d", cols, rows))
+
+	mgmt := ""
+	if p.detector != nil && p.detector.ManagementInterface != nil {
+		mgmt = p.detector.ManagementInterface.IPAddress
+	}
+	draw := func() {
+		if err := display.Show(frontPanelLines(p.domains.Snapshots(), mgmt, cols, rows)); err != nil {
+			logger.Debug("Front panel update failed", "err", err)
+		}
+	}
+
+	sub := events.Subscribe(1, golane.TopicDevices, golane.TopicDomainFailed)
+	recovery.Go("frontpanel", func() {
+		defer sub.Close()
+		defer display.Close()
+		recovery.Loop(ctx, "frontpanel", func() {
+			ticker := time.NewTicker(frontPanelRedraw)
+			defer ticker.Stop()
+			draw()
+			for {
+				select {
+				case <-ctx.Done():
+					display.Show(nil)
+					return
+				case <-sub.C:
+				case <-ticker.C:
+				}
+				draw()
+			}
+		})
+	})
+}
+
+// frontPanelLines 顯示的文字：每個網域一行名稱與狀態、一行 IP，最後是管理介面
+func frontPanelLines(snaps []supervisor.Snapshot, mgmt string, cols, rows int) []string {
+	var lines []string
+	if len(snaps) == 0 {
+		lines = append(lines, "Starting...")
+	}
+	for _, snap := range snaps {
+		status := fmt.Sprintf("%d dev", len(snap.Devices))
+		switch snap.State {
+		case supervisor.StateFailed:
+			status = "FAILED"
+		case supervisor.StateStarting:
+			status = "starting"
+		case supervisor.StateStopped:
+			status = "stopped"
+		}
+		lines = append(lines, panelRow(snap.Name, status, cols))
+		ip := snap.IPAddress
+		if ip == "" {
+			ip = "no IP"
+		}
+		lines = append(lines, " "+ip)
+	}
+	if mgmt != "" && len(lines) < rows {
+		lines = append(lines, panelRow("Mgmt", mgmt, cols))
+	}
+	if len(lines) > rows {
+		lines = lines[:rows]
+	}
+	return lines
+}
+
+// panelRow 名稱靠左、狀態靠右 (空間不足時名稱截斷)
+func panelRow(name, status string, cols int) string {
+	room := cols - len(status) - 1
+	if room < 1 {
+		return name + " " + status
+	}
+	if len(name) > room {
+		name = name[:room]
+	}
+	return fmt.Sprintf("%-*s %s", room, name, status)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"danteCS/golane"
+	"danteCS/internal/supervisor"
+)
+
+func TestFrontPanelLines(t *testing.T) {
+	snaps := []supervisor.Snapshot{
+		{Name: "Dante1", IPAddress: "169.254.1.5", State: supervisor.StateRunning, Devices: make([]golane.Device, 12)},
+		{Name: "Dante-Secondary", State: supervisor.StateFailed},
+	}
+	got := frontPanelLines(snaps, "10.0.0.5", 16, 5)
+	want := []string{
+		"Dante1    12 dev",
+		" 169.254.1.5",
+		"Dante-Sec FAILED",
+		" no IP",
+		"Mgmt    10.0.0.5",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("lines = %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// 16x2 只放得下第一個網域
+	if got := frontPanelLines(snaps, "10.0.0.5", 16, 2); len(got) != 2 || got[1] != " 169.254.1.5" {
+		t.Errorf("2 rows = %q", got)
+	}
+	if got := frontPanelLines(nil, "", 16, 2); len(got) != 1 || got[0] != "Starting..." {
+		t.Errorf("no domains = %q", got)
+	}
+}
+
+func TestFrontPanelConfigValidate(t *testing.T) {
+	for _, c := range []FrontPanelConfig{
+		{Driver: "st7735"},
+		{Driver: PanelSSD1306, Height: 48},
+		{Driver: PanelHD44780, Rows: 5},
+		{Driver: PanelHD44780, Address: 0x80},
+	} {
+		if c.Validate() == nil {
+			t.Errorf("%+v accepted", c)
+		}
+	}
+	if err := (FrontPanelConfig{Driver: PanelHD44780, Bus: 1, Columns: 20, Rows: 4}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
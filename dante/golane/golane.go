@@ -0,0 +1,383 @@
+// Package golane 以函式庫方式嵌入 GOlane (不需要 monitor daemon)
+//
+// Node 在同一個行程內監督網域、刷新設備列表並把事件發布到 Bus，提供與
+// daemon 管理 API 相同的模型：Registry 對應 /api/domains 與 /api/devices，
+// Router 對應 /api/routes，Bus 的事件與 /api/events 推送的內容相同。
+package golane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"danteCS/internal/backoff"
+	"danteCS/internal/bus"
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 型別
+//==============================================================================
+
+// 與 daemon 共用的型別 (外部模組無法直接匯入 internal 套件)
+type (
+	Device             = dante.Device
+	DeviceCapabilities = dante.DeviceCapabilities
+	Subscription       = dante.Subscription
+	NetworkConfig      = dante.NetworkConfig
+	SimulationConfig   = dante.SimulationConfig
+	DomainStatus       = supervisor.Snapshot
+	SDKError           = dante.SDKError
+	RemoteDevice       = dante.RemoteDevice
+
+	Bus             = bus.Bus
+	Event           = bus.Event
+	BusSubscription = bus.Subscription
+)
+
+// 事件主題
+const (
+	TopicDevices       = bus.TopicDevices
+	TopicDeviceOnline  = bus.TopicDeviceOnline
+	TopicDeviceOffline = bus.TopicDeviceOffline
+	TopicRoute         = bus.TopicRoute
+	TopicAlert         = bus.TopicAlert
+	TopicAlarm         = bus.TopicAlarm
+	TopicDomainFailed  = bus.TopicDomainFailed
+	TopicFirmware      = bus.TopicFirmware
+)
+
+// 網域狀態
+const (
+	StateStarting = supervisor.StateStarting
+	StateRunning  = supervisor.StateRunning
+	StateFailed   = supervisor.StateFailed
+	StateStopped  = supervisor.StateStopped
+)
+
+// changeDelay 變更通知後等待的時間 (合併同一次變更的多個通知)
+const changeDelay = time.Second
+
+// ErrUnknownDomain 名稱不是 Node 或 daemon 管理的網域
+var ErrUnknownDomain = errors.New("unknown domain")
+
+// ErrReadOnly 設備已註冊到 DDM 網域或鎖定，這個控制器無法設定 (Device.ReadOnly 說明原因)
+var ErrReadOnly = dante.ErrReadOnly
+
+// SDK 錯誤的類別，以 errors.Is 判斷 (*SDKError 保留 SDK 的原始訊息與代碼)
+var (
+	ErrNotInitialized   = dante.ErrNotInitialized
+	ErrInterfaceDown    = dante.ErrInterfaceDown
+	ErrSDKLicense       = dante.ErrSDKLicense
+	ErrDiscoveryTimeout = dante.ErrDiscoveryTimeout
+	ErrDeviceNotFound   = dante.ErrDeviceNotFound
+	ErrChannelNotFound  = dante.ErrChannelNotFound
+	ErrNotConnected     = dante.ErrNotConnected
+	ErrAccessDenied     = dante.ErrAccessDenied
+	ErrInvalidArgument  = dante.ErrInvalidArgument
+	ErrCapacity         = dante.ErrCapacity
+	ErrUnsupported      = dante.ErrUnsupported
+	ErrSDKInUse         = dante.ErrSDKInUse
+)
+
+// IsTransient 錯誤是否為重試可能成功的暫時性失敗 (逾時、連線中斷、網卡暫時沒有地址)
+func IsTransient(err error) bool {
+	return dante.IsTransient(err)
+}
+
+// NewBus 建立事件匯流排
+func NewBus() *Bus {
+	return bus.New()
+}
+
+// DefaultSimulationConfig 內建的模擬設備 (與 -simulate 相同)
+func DefaultSimulationConfig() *SimulationConfig {
+	return dante.DefaultSimulationConfig()
+}
+
+// LoadSimulationConfig 讀取模擬設定檔 (與 -simulate-config 相同)
+func LoadSimulationConfig(path string) (*SimulationConfig, error) {
+	return dante.LoadSimulationConfig(path)
+}
+
+//==============================================================================
+// 介面
+//==============================================================================
+
+// Registry 網域與設備的登錄
+type Registry interface {
+	Domains() []DomainStatus                 // 所有網域的狀態 (依加入順序)
+	Devices(domain string) ([]Device, error) // 網域最新的設備列表
+	Routes(domain string) (Router, error)    // 網域的路由操作
+}
+
+// Router 路由操作 (daemon 的 RouteController)
+type Router interface {
+	ListSubscriptions(ctx context.Context, rxDevice string) ([]Subscription, error)
+	Subscribe(ctx context.Context, rxDevice, rxChannel, txDevice, txChannel string) error
+}
+
+// RouteChange TopicRoute 事件的內容 (TxChannel 空白為取消訂閱)
+type RouteChange struct {
+	RxDevice  string `json:"rx_device"`
+	RxChannel string `json:"rx_channel"`
+	TxDevice  string `json:"tx_device,omitempty"`
+	TxChannel string `json:"tx_channel,omitempty"`
+}
+
+// PublishRoutes 成功的訂閱變更發布為 TopicRoute 事件
+func PublishRoutes(b *Bus, domain string, r Router) Router {
+	return publishedRoutes{Router: r, bus: b, domain: domain}
+}
+
+// publishedRoutes 發布訂閱變更的 Router
+type publishedRoutes struct {
+	Router
+	bus    *Bus
+	domain string
+}
+
+func (r publishedRoutes) Subscribe(ctx context.Context, rxDevice, rxChannel, txDevice, txChannel string) error {
+	if err := r.Router.Subscribe(ctx, rxDevice, rxChannel, txDevice, txChannel); err != nil {
+		return err
+	}
+	r.bus.Publish(Event{
+		Topic:   TopicRoute,
+		Domain:  r.domain,
+		Subject: rxDevice,
+		Data:    RouteChange{RxDevice: rxDevice, RxChannel: rxChannel, TxDevice: txDevice, TxChannel: txChannel},
+	})
+	return nil
+}
+
+// DomainFailure TopicDomainFailed 事件的內容
+type DomainFailure struct {
+	DomainStatus
+	Permanent bool `json:"permanent"` // 不再重啟
+}
+
+// PublishDomainFailures 把網域失敗發布為 TopicDomainFailed 事件 (作為 supervisor 的 OnFailure)
+func PublishDomainFailures(b *Bus) func(status DomainStatus, permanent bool) {
+	return func(status DomainStatus, permanent bool) {
+		b.Publish(Event{
+			Topic:   TopicDomainFailed,
+			Domain:  status.Name,
+			Subject: status.Name,
+			Data:    DomainFailure{DomainStatus: status, Permanent: permanent},
+		})
+	}
+}
+
+// PublishDevices 發布網域的設備列表，以及與上一次列表比較的上下線事件
+// (prev 為 nil 時只發布列表)
+func PublishDevices(b *Bus, domain string, prev, devices []Device) {
+	now := time.Now()
+	if prev != nil {
+		before := make(map[string]bool, len(prev))
+		for _, dev := range prev {
+			before[strings.ToLower(dev.Name)] = true
+		}
+		after := make(map[string]bool, len(devices))
+		for _, dev := range devices {
+			key := strings.ToLower(dev.Name)
+			after[key] = true
+			if !before[key] {
+				b.Publish(Event{Topic: TopicDeviceOnline, Domain: domain, Subject: dev.Name, Time: now, Data: dev})
+			}
+		}
+		for _, dev := range prev {
+			if !after[strings.ToLower(dev.Name)] {
+				b.Publish(Event{Topic: TopicDeviceOffline, Domain: domain, Subject: dev.Name, Time: now, Data: dev})
+			}
+		}
+	}
+	b.Publish(Event{Topic: TopicDevices, Domain: domain, Time: now, Data: append([]Device{}, devices...)})
+}
+
+//==============================================================================
+// Node
+//==============================================================================
+
+// DomainConfig Node 管理的網域
+type DomainConfig struct {
+	Name       string
+	Network    NetworkConfig     // 介面與地址 (Simulation 不為 nil 時可省略)
+	Simulation *SimulationConfig // 使用模擬設備 (測試與展示)
+}
+
+// Options Node 設定
+type Options struct {
+	Domains   []DomainConfig
+	Bus       *Bus           // 共用的事件匯流排 (nil 時建立新的)
+	Wait      time.Duration  // 掃描後等待設備發現的時間 (預設 3 秒)
+	Refresh   time.Duration  // 沒有變更通知時的刷新間隔 (預設 30 秒)
+	InitRetry backoff.Policy // SDK 初始化失敗的重試 (零值為 daemon 的預設)
+	CallRetry backoff.Policy // 掃描、刷新與讀取設備資訊的暫時性失敗重試 (零值為 daemon 的預設)
+}
+
+// Node 行程內的 GOlane (實作 Registry)
+// libdapi 在同一個行程只能初始化一次，實體網路只能有一個網域，
+// 需要兩個實體網域時請用 daemon (instance supervise 讓每個網域獨立一個行程)
+type Node struct {
+	opts       Options
+	bus        *Bus
+	supervisor *supervisor.Supervisor
+	domains    map[string]*dante.Domain // 建立後不再變更
+}
+
+// NewNode 建立 Node (Start 之後才開始掃描)
+func NewNode(opts Options) (*Node, error) {
+	if len(opts.Domains) == 0 {
+		return nil, errors.New("no domains configured")
+	}
+	if opts.Wait <= 0 {
+		opts.Wait = 3 * time.Second
+	}
+	if opts.Refresh <= 0 {
+		opts.Refresh = 30 * time.Second
+	}
+	if opts.InitRetry == (backoff.Policy{}) {
+		opts.InitRetry = dante.DefaultInitBackoff()
+	}
+	if opts.CallRetry == (backoff.Policy{}) {
+		opts.CallRetry = dante.DefaultCallRetry()
+	}
+	if opts.Bus == nil {
+		opts.Bus = bus.New()
+	}
+
+	supervisorCfg := supervisor.DefaultConfig()
+	supervisorCfg.OnFailure = PublishDomainFailures(opts.Bus)
+	n := &Node{
+		opts:       opts,
+		bus:        opts.Bus,
+		supervisor: supervisor.New(supervisorCfg),
+		domains:    make(map[string]*dante.Domain),
+	}
+	physical := 0
+	for _, cfg := range opts.Domains {
+		if cfg.Name == "" {
+			return nil, errors.New("domain without name")
+		}
+		if _, dup := n.domains[cfg.Name]; dup {
+			return nil, fmt.Errorf("duplicate domain %s", cfg.Name)
+		}
+		var d *dante.Domain
+		if cfg.Simulation != nil {
+			network := cfg.Network
+			if network.InterfaceName == "" {
+				network = cfg.Simulation.NetworkConfig()
+			}
+			d = dante.NewSimulatedDomain(cfg.Name, network, dante.NewSimulatedSDK(cfg.Simulation))
+		} else {
+			if physical++; physical > 1 {
+				return nil, fmt.Errorf("domain %s: only one SDK domain per process", cfg.Name)
+			}
+			d = dante.NewDomain(cfg.Name, cfg.Network)
+		}
+		d.CallRetry = opts.CallRetry
+		n.domains[cfg.Name] = d
+		n.supervisor.Add(supervisor.Spec{
+			Name:      d.Name,
+			Interface: d.NetworkConfig.InterfaceName,
+			IPAddress: d.NetworkConfig.IPAddress,
+			Run:       n.runner(d),
+		})
+	}
+	return n, nil
+}
+
+// Start 啟動所有網域 (ctx 結束時與 Stop 相同)
+func (n *Node) Start(ctx context.Context) {
+	n.supervisor.Start(ctx)
+}
+
+// Stop 結束所有網域並清理 SDK
+func (n *Node) Stop() {
+	n.supervisor.Stop()
+}
+
+// Bus 事件匯流排
+func (n *Node) Bus() *Bus {
+	return n.bus
+}
+
+// Domains 實作 Registry
+func (n *Node) Domains() []DomainStatus {
+	return n.supervisor.Snapshots()
+}
+
+// Devices 實作 Registry
+func (n *Node) Devices(domain string) ([]Device, error) {
+	snap, ok := n.supervisor.Snapshot(domain)
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownDomain, domain)
+	}
+	return snap.Devices, nil
+}
+
+// Routes 實作 Registry：成功的訂閱變更發布為 TopicRoute 事件
+func (n *Node) Routes(domain string) (Router, error) {
+	d, ok := n.domains[domain]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownDomain, domain)
+	}
+	return PublishRoutes(n.bus, domain, d), nil
+}
+
+// OpenDevice 保持網域中一台設備的連線，連續查詢或設定時不必每次重新連線 (用完要 Close)
+// 經由 RemoteDevice 的訂閱變更不會發布 TopicRoute 事件，需要事件時請用 Routes
+func (n *Node) OpenDevice(ctx context.Context, domain, device string) (*RemoteDevice, error) {
+	d, ok := n.domains[domain]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownDomain, domain)
+	}
+	return d.OpenDevice(ctx, device)
+}
+
+// runner 網域工作：初始化、掃描，之後在變更通知或 Refresh 到期時刷新
+func (n *Node) runner(d *dante.Domain) supervisor.Runner {
+	var prev []Device // 跨重啟保留，重啟後只發布真正的上下線
+	return func(ctx context.Context, report supervisor.Reporter) error {
+		if err := d.InitializeWithRetry(ctx, n.opts.InitRetry, report); err != nil {
+			if errors.Is(err, backoff.ErrStopped) {
+				return nil
+			}
+			return fmt.Errorf("%w: initialization %v", supervisor.ErrPermanent, err)
+		}
+		defer d.Cleanup()
+
+		if err := d.StartDeviceScan(ctx); err != nil {
+			return err
+		}
+		next := time.Now().Add(n.opts.Wait)
+		timer := time.NewTimer(n.opts.Wait)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-d.Changes():
+				// 變更通知提前刷新 (已排定更早的刷新時不動作)
+				if at := time.Now().Add(changeDelay); at.Before(next) {
+					next = at
+					timer.Reset(changeDelay)
+				}
+				continue
+			case <-timer.C:
+			}
+
+			d.RefreshDevices(ctx)
+			d.CheckEnrollments(ctx, d.GetDevices())
+			devices := d.GetDevices()
+			report.Devices(devices)
+			PublishDevices(n.bus, d.Name, prev, devices)
+			prev = append([]Device{}, devices...)
+			next = time.Now().Add(n.opts.Refresh)
+			timer.Reset(n.opts.Refresh)
+		}
+	}
+}
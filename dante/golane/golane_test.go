@@ -0,0 +1,117 @@
+package golane
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// next 等待主題的下一則事件
+func next(t *testing.T, sub *BusSubscription, topic string) Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-sub.C:
+			if e.Topic == topic {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s event", topic)
+		}
+	}
+}
+
+func TestNodeRegistryRoutesAndEvents(t *testing.T) {
+	node, err := NewNode(Options{
+		Domains: []DomainConfig{{Name: "Dante1", Simulation: DefaultSimulationConfig()}},
+		Wait:    10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := node.Bus().Subscribe(0)
+	defer events.Close()
+	node.Start(context.Background())
+	defer node.Stop()
+
+	e := next(t, events, TopicDevices)
+	devices, _ := e.Data.([]Device)
+	if e.Domain != "Dante1" || len(devices) != 4 {
+		t.Fatalf("devices event: %+v", e)
+	}
+
+	// 登錄與事件內容一致
+	var registry Registry = node
+	if status := registry.Domains(); len(status) != 1 || status[0].State != StateRunning {
+		t.Fatalf("Domains() = %+v", status)
+	}
+	if list, err := registry.Devices("Dante1"); err != nil || len(list) != len(devices) {
+		t.Fatalf("Devices() = %d devices, %v", len(list), err)
+	}
+	if _, err := registry.Devices("Dante2"); !errors.Is(err, ErrUnknownDomain) {
+		t.Fatalf("unknown domain: err = %v", err)
+	}
+
+	routes, err := registry.Routes("Dante1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := routes.Subscribe(ctx, "Amp-Left", "01", "Stage-Box-A", "01"); err != nil {
+		t.Fatal(err)
+	}
+	e = next(t, events, TopicRoute)
+	if change, ok := e.Data.(RouteChange); !ok || change.RxDevice != "Amp-Left" || change.TxDevice != "Stage-Box-A" {
+		t.Fatalf("route event: %+v", e)
+	}
+	subs, err := routes.ListSubscriptions(ctx, "Amp-Left")
+	if err != nil || subs[0].TxDevice != "Stage-Box-A" {
+		t.Fatalf("ListSubscriptions() = %+v, %v", subs, err)
+	}
+
+	// 失敗的訂閱不發布事件
+	if err := routes.Subscribe(ctx, "No-Such-Device", "01", "Stage-Box-A", "01"); err == nil {
+		t.Fatal("subscribe on a missing device succeeded")
+	}
+	select {
+	case e := <-events.C:
+		if e.Topic == TopicRoute {
+			t.Fatalf("failed subscribe published %+v", e)
+		}
+	default:
+	}
+}
+
+func TestPublishDevicesPresence(t *testing.T) {
+	b := NewBus()
+	sub := b.Subscribe(0, TopicDeviceOnline, TopicDeviceOffline)
+	defer sub.Close()
+
+	PublishDevices(b, "Dante1", nil, []Device{{Name: "Amp-Left"}})
+	PublishDevices(b, "Dante1", []Device{{Name: "Amp-Left"}}, []Device{{Name: "amp-left"}, {Name: "FOH"}})
+	PublishDevices(b, "Dante1", []Device{{Name: "amp-left"}, {Name: "FOH"}}, []Device{{Name: "FOH"}})
+
+	if e := <-sub.C; e.Topic != TopicDeviceOnline || e.Subject != "FOH" {
+		t.Fatalf("first event %+v, want FOH online", e)
+	}
+	if e := <-sub.C; e.Topic != TopicDeviceOffline || e.Subject != "amp-left" {
+		t.Fatalf("second event %+v, want amp-left offline", e)
+	}
+	select {
+	case e := <-sub.C:
+		t.Fatalf("unexpected event %+v", e)
+	default:
+	}
+}
+
+func TestNewNodeRejectsSecondSDKDomain(t *testing.T) {
+	_, err := NewNode(Options{Domains: []DomainConfig{
+		{Name: "Dante1", Network: NetworkConfig{InterfaceName: "eth1"}},
+		{Name: "Dante2", Network: NetworkConfig{InterfaceName: "eth2"}},
+	}})
+	if err == nil {
+		t.Fatal("two SDK domains in one process accepted")
+	}
+}
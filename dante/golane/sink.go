@@ -0,0 +1,123 @@
+package golane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+//==============================================================================
+// 輸出 sink
+//==============================================================================
+
+// 事件除了 webhook 與 hook 之外，常要送到現場自己的系統 (專有的控制協定、
+// 中控主機)。實作 Sink 並在 init 以 RegisterSink 登記種類後，daemon 設定檔
+// sinks section 就能以 type 使用，不需要修改發現與網域的程式：
+//
+//	func init() {
+//		golane.RegisterSink("crestron", func(config json.RawMessage) (golane.Sink, error) {
+//			var c crestronConfig
+//			if err := json.Unmarshal(config, &c); err != nil {
+//				return nil, err
+//			}
+//			return dialCrestron(c)
+//		})
+//	}
+//
+// 自訂的 sink 放在 daemon 的 main 套件旁 (或以空白匯入) 一起編譯。
+
+// Sink 事件的輸出目的地
+// Write 由同一個 goroutine 依序呼叫 (不需要自行加鎖)，ctx 結束表示逾時或關閉；
+// 回傳錯誤只會計入統計，事件不重送
+type Sink interface {
+	Write(ctx context.Context, e Event) error
+	Close() error
+}
+
+// SinkFactory 以設定檔的 config (type 自訂的 JSON，未設定時為 nil) 建立 Sink
+type SinkFactory func(config json.RawMessage) (Sink, error)
+
+// ErrUnknownSink 種類沒有登記
+var ErrUnknownSink = errors.New("unknown sink type")
+
+var (
+	sinksMu   sync.RWMutex
+	factories = map[string]SinkFactory{"file": openFileSink}
+)
+
+// RegisterSink 登記 sink 種類 (名稱重複或 factory 為 nil 時 panic，與 database/sql 相同)
+func RegisterSink(kind string, factory SinkFactory) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	if kind == "" || factory == nil {
+		panic("golane: RegisterSink with empty type or nil factory")
+	}
+	if _, dup := factories[kind]; dup {
+		panic("golane: RegisterSink called twice for " + kind)
+	}
+	factories[kind] = factory
+}
+
+// OpenSink 建立登記過的 sink
+func OpenSink(kind string, config json.RawMessage) (Sink, error) {
+	sinksMu.RLock()
+	factory, ok := factories[kind]
+	sinksMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (%v)", ErrUnknownSink, kind, SinkTypes())
+	}
+	return factory(config)
+}
+
+// SinkTypes 已登記的種類 (排序)
+func SinkTypes() []string {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	kinds := make([]string, 0, len(factories))
+	for kind := range factories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+//------------------------------------------------------------------------------
+// file: 每則事件一行 JSON 附加到檔案
+//------------------------------------------------------------------------------
+
+// fileSink 內建的 file sink，config 為 {"path": "/var/log/golane-events.jsonl"}
+type fileSink struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+func openFileSink(config json.RawMessage) (Sink, error) {
+	var c struct {
+		Path string `json:"path"`
+	}
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &c); err != nil {
+			return nil, fmt.Errorf("invalid file sink config: %v", err)
+		}
+	}
+	if c.Path == "" {
+		return nil, errors.New("file sink requires path")
+	}
+	f, err := os.OpenFile(c.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (s *fileSink) Write(_ context.Context, e Event) error {
+	return s.enc.Encode(e)
+}
+
+func (s *fileSink) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 健康檢查 (/healthz、/readyz)
+//==============================================================================
+
+// 給 systemd watchdog 腳本、負載平衡器與 Kubernetes probe 使用，不需要權杖：
+//
+//	/healthz  liveness：行程與 API 仍在回應 (只要能回應就是 200)
+//	/readyz   readiness：每個網域的 SDK 已初始化、工作在執行中，而且最後一次
+//	          成功刷新設備列表在 ReadyAge 之內；任一網域不符合時回應 503
+//
+// 兩者都回傳 JSON 說明，/readyz 逐一列出網域與原因，方便從 watchdog 的日誌
+// 判斷要重啟行程還是檢查網路。
+
+// 健康狀態
+const (
+	HealthOK       = "ok"
+	HealthNotReady = "not-ready"
+)
+
+// processStarted 行程啟動時間 (uptime)
+var processStarted = time.Now()
+
+// DomainHealth 單一網域的 readiness
+type DomainHealth struct {
+	Name        string    `json:"name"`
+	Ready       bool      `json:"ready"`
+	Reason      string    `json:"reason,omitempty"` // 未就緒的原因
+	State       string    `json:"state"`
+	Initialized bool      `json:"initialized"` // SDK 已初始化並回報過設備
+	Phase       string    `json:"phase,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	Restarts    int       `json:"restarts"`
+	Devices     int       `json:"devices"`
+	LastRefresh time.Time `json:"last_refresh,omitempty"` // 最後一次成功刷新
+	Age         string    `json:"age,omitempty"`          // 距離最後一次刷新
+}
+
+// HealthReport /healthz 與 /readyz 的回應
+type HealthReport struct {
+	Status  string         `json:"status"`
+	Uptime  string         `json:"uptime"`
+	Time    time.Time      `json:"time"`
+	MaxAge  string         `json:"max_age,omitempty"` // 刷新多久沒有成功視為未就緒
+	Domains []DomainHealth `json:"domains,omitempty"`
+}
+
+// domainHealth 依快照判斷網域是否就緒 (maxAge 0 表示不檢查刷新時間)
+func domainHealth(snap supervisor.Snapshot, maxAge time.Duration, now time.Time) DomainHealth {
+	h := DomainHealth{
+		Name:        snap.Name,
+		State:       snap.State,
+		Initialized: snap.State == supervisor.StateRunning,
+		Phase:       snap.Phase,
+		LastError:   snap.LastError,
+		Restarts:    snap.Restarts,
+		Devices:     len(snap.Devices),
+		LastRefresh: snap.Updated,
+	}
+	if !snap.Updated.IsZero() {
+		h.Age = now.Sub(snap.Updated).Round(time.Second).String()
+	}
+	switch {
+	case snap.State != supervisor.StateRunning:
+		h.Reason = "domain is " + snap.State
+		if snap.Phase != "" {
+			h.Reason += ": " + snap.Phase
+		}
+	case snap.Stale:
+		h.Reason = "device list not yet confirmed after restart"
+	case maxAge > 0 && now.Sub(snap.Updated) > maxAge:
+		h.Reason = fmt.Sprintf("no successful refresh for %s", h.Age)
+	default:
+		h.Ready = true
+	}
+	return h
+}
+
+// readiness 所有網域的 readiness
+func (s *APIServer) readiness(now time.Time) HealthReport {
+	report := HealthReport{Status: HealthOK, Uptime: now.Sub(processStarted).Round(time.Second).String(), Time: now}
+	if s.readyAge > 0 {
+		report.MaxAge = s.readyAge.String()
+	}
+	for _, snap := range s.snapshots() {
+		h := domainHealth(snap, s.readyAge, now)
+		if !h.Ready {
+			report.Status = HealthNotReady
+		}
+		report.Domains = append(report.Domains, h)
+	}
+	return report
+}
+
+// handleHealthz GET /healthz
+func (s *APIServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, HealthReport{Status: HealthOK, Uptime: now.Sub(processStarted).Round(time.Second).String(), Time: now})
+}
+
+// handleReadyz GET /readyz
+func (s *APIServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := s.readiness(time.Now())
+	status := http.StatusOK
+	if report.Status != HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, report)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+func TestDomainHealth(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name  string
+		snap  supervisor.Snapshot
+		ready bool
+	}{
+		{"fresh", supervisor.Snapshot{State: supervisor.StateRunning, Updated: now.Add(-time.Second)}, true},
+		{"starting", supervisor.Snapshot{State: supervisor.StateStarting, Phase: "waiting for interface"}, false},
+		{"failed", supervisor.Snapshot{State: supervisor.StateFailed, LastError: "SDK crash"}, false},
+		{"stale", supervisor.Snapshot{State: supervisor.StateRunning, Stale: true, Updated: now}, false},
+		{"old refresh", supervisor.Snapshot{State: supervisor.StateRunning, Updated: now.Add(-time.Hour)}, false},
+	}
+	for _, c := range cases {
+		h := domainHealth(c.snap, time.Minute, now)
+		if h.Ready != c.ready || (!h.Ready && h.Reason == "") {
+			t.Errorf("%s: ready %v reason %q", c.name, h.Ready, h.Reason)
+		}
+	}
+	// maxAge 0 不檢查刷新時間
+	if h := domainHealth(cases[4].snap, 0, now); !h.Ready {
+		t.Errorf("max age 0: %+v", h)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	domains := supervisor.New(supervisor.DefaultConfig())
+	domains.Add(supervisor.Spec{Name: "Dante1", Interface: "eth1"})
+	domains.Seed("Dante1", []dante.Device{{Name: "Amp"}}, time.Now())
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{Domains: domains, Token: "secret", ReadyAge: time.Minute}).mux)
+	defer server.Close()
+
+	var report HealthReport
+	getJSON(t, server.URL+"/healthz", &report) // 不需要權杖
+	if report.Status != HealthOK {
+		t.Errorf("healthz: %+v", report)
+	}
+	resp, err := http.Get(server.URL + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("readyz with a domain not started: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 事件 hook (執行本機指令)
+//==============================================================================
+
+// 現場常有各自的整合需求 (設備離線時切換備用線路、告警時點亮機櫃燈號)，
+// 寫成 webhook 需要另外架 HTTP 服務。設定檔 hooks section 的每個 hook 在
+// 事件發生時執行一個本機指令：事件種類與 webhook 相同，JSON 內容
+// (WebhookPayload) 從 stdin 傳入，常用欄位也放在環境變數：
+//
+//	GOLANE_EVENT     device.added、alarm.raised…
+//	GOLANE_EVENT_ID  事件 ID
+//	GOLANE_DOMAIN    網域名稱
+//	GOLANE_SUBJECT   設備、告警或網域名稱
+//	GOLANE_TIME      事件時間 (RFC 3339)
+//
+// command 直接執行 (不經過 shell)，shell 以 /bin/sh -c 執行，兩者擇一。
+// 每個 hook 依序執行自己的事件 (同一個 hook 不會同時執行兩次)，逾時時終止，
+// 失敗不重試 (指令不一定能重複執行)。
+//
+//	"hooks": [
+//	  {"name": "rack-lamp", "command": ["/usr/local/bin/lamp", "red"], "events": ["alarm.raised"]},
+//	  {"name": "log", "shell": "jq -c . >> /var/log/golane-events.jsonl"}
+//	]
+
+const (
+	hookQueueSize      = 64               // 每個 hook 等待執行的事件數 (滿時丟棄)
+	hookDefaultTimeout = 30 * time.Second // 每次執行的逾時
+	hookWaitDelay      = 5 * time.Second  // 終止後等待子程序關閉輸出的時間
+	hookMaxOutput      = 4 << 10          // 保留的輸出長度 (失敗時記錄)
+)
+
+// ErrInvalidHook hook 設定錯誤
+var ErrInvalidHook = errors.New("invalid hook")
+
+// EventHook 設定檔 hooks section 的一個 hook
+type EventHook struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command,omitempty"` // 程式與參數 (不經過 shell)
+	Shell   string   `json:"shell,omitempty"`   // 以 /bin/sh -c 執行的指令
+	Events  []string `json:"events,omitempty"`  // 只在這些事件執行 (空白表示全部)
+	Dir     string   `json:"dir,omitempty"`     // 工作目錄 (預設為 monitor 的工作目錄)
+	Timeout string   `json:"timeout,omitempty"` // 每次執行的逾時 (Go duration，預設 30s)
+
+	timeout time.Duration
+}
+
+// compileHooks 檢查 hook 並補上預設值
+func compileHooks(hooks []EventHook) ([]EventHook, error) {
+	names := make(map[string]bool, len(hooks))
+	out := make([]EventHook, len(hooks))
+	for i, h := range hooks {
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("%w %q: %s", ErrInvalidHook, h.Name, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case h.Name == "":
+			return nil, fmt.Errorf("%w #%d: name is required", ErrInvalidHook, i+1)
+		case names[h.Name]:
+			return nil, fail("duplicate name")
+		case (len(h.Command) == 0) == (h.Shell == ""):
+			return nil, fail("exactly one of command and shell is required")
+		case len(h.Command) > 0 && h.Command[0] == "":
+			return nil, fail("command without program")
+		}
+		names[h.Name] = true
+
+		for _, e := range h.Events {
+			if !slices.Contains(webhookEvents, e) {
+				return nil, fail("unknown event %q (%v)", e, webhookEvents)
+			}
+		}
+		h.timeout = hookDefaultTimeout
+		if h.Timeout != "" {
+			var err error
+			if h.timeout, err = time.ParseDuration(h.Timeout); err != nil || h.timeout <= 0 {
+				return nil, fail("invalid timeout %q", h.Timeout)
+			}
+		}
+		out[i] = h
+	}
+	return out, nil
+}
+
+// wants hook 是否在這個事件執行
+func (h EventHook) wants(event string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, event)
+}
+
+// argv 要執行的程式與參數
+func (h EventHook) argv() []string {
+	if h.Shell != "" {
+		return []string{"/bin/sh", "-c", h.Shell}
+	}
+	return h.Command
+}
+
+// HookStats hook 的執行統計 (/api/hooks)
+type HookStats struct {
+	Name      string    `json:"name"`
+	Command   string    `json:"command"`
+	Events    []string  `json:"events,omitempty"`
+	Runs      int64     `json:"runs"`
+	Failed    int64     `json:"failed"`  // 非 0 結束、逾時或無法執行
+	Dropped   int64     `json:"dropped"` // 佇列已滿而丟棄的事件
+	Queued    int       `json:"queued"`
+	LastExit  int       `json:"last_exit"` // 最後一次的結束碼 (無法執行或逾時為 -1)
+	LastError string    `json:"last_error,omitempty"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+}
+
+// hookWorker 單一 hook 的佇列
+type hookWorker struct {
+	hook  EventHook
+	queue chan WebhookPayload
+
+	mu    sync.Mutex
+	stats HookStats
+}
+
+// HookRunner 訂閱事件匯流排並執行各 hook
+type HookRunner struct {
+	workers []*hookWorker
+}
+
+// NewHookRunner 建立 runner (hooks 須先經過 compileHooks)
+func NewHookRunner(hooks []EventHook) *HookRunner {
+	r := &HookRunner{}
+	for _, h := range hooks {
+		command := h.Shell
+		if command == "" {
+			command = strings.Join(h.Command, " ")
+		}
+		r.workers = append(r.workers, &hookWorker{
+			hook:  h,
+			queue: make(chan WebhookPayload, hookQueueSize),
+			stats: HookStats{Name: h.Name, Command: command, Events: h.Events},
+		})
+	}
+	return r
+}
+
+// Start 訂閱事件並啟動各 hook 的執行，直到 ctx 結束 (執行中的指令會被終止)
+func (r *HookRunner) Start(ctx context.Context, events *golane.Bus) {
+	sub := events.Subscribe(hookQueueSize, golane.TopicDeviceOnline, golane.TopicDeviceOffline, golane.TopicAlarm, golane.TopicDomainFailed)
+	recovery.Go("hooks", func() {
+		defer sub.Close()
+		recovery.Loop(ctx, "hooks", func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e := <-sub.C:
+					if p, ok := webhookPayload(e); ok {
+						r.enqueue(p)
+					}
+				}
+			}
+		})
+	})
+	for _, w := range r.workers {
+		recovery.GoLoop(ctx, "hooks/"+w.hook.Name, func() { r.run(ctx, w) })
+	}
+}
+
+// enqueue 交給在這個事件執行的 hook (佇列已滿時丟棄)
+func (r *HookRunner) enqueue(p WebhookPayload) {
+	for _, w := range r.workers {
+		if !w.hook.wants(p.Event) {
+			continue
+		}
+		select {
+		case w.queue <- p:
+		default:
+			w.mu.Lock()
+			w.stats.Dropped++
+			w.mu.Unlock()
+			logger.Warn("Hook queue full, event dropped", "hook", w.hook.Name, "event", p.Event)
+		}
+	}
+}
+
+// run 依序執行 hook 的事件
+func (r *HookRunner) run(ctx context.Context, w *hookWorker) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-w.queue:
+			exit, err := execHook(ctx, w.hook, p)
+			w.record(exit, err)
+			if err != nil {
+				logger.Warn("Hook failed", "hook", w.hook.Name, "event", p.Event, "id", p.ID, "exit", exit, "err", err)
+			} else {
+				logger.Debug("Hook finished", "hook", w.hook.Name, "event", p.Event, "id", p.ID)
+			}
+		}
+	}
+}
+
+// execHook 執行一次 hook，回傳結束碼 (無法執行或逾時為 -1)
+// 失敗時錯誤訊息附上輸出的結尾
+func execHook(ctx context.Context, h EventHook, p WebhookPayload) (int, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return -1, fmt.Errorf("failed to encode payload: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	argv := h.argv()
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = h.Dir
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"GOLANE_EVENT="+p.Event,
+		"GOLANE_EVENT_ID="+p.ID,
+		"GOLANE_DOMAIN="+p.Domain,
+		"GOLANE_SUBJECT="+p.Subject,
+		"GOLANE_TIME="+p.Time.UTC().Format(time.RFC3339),
+	)
+	var output tailBuffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	// 逾時時終止整個 process group (shell 啟動的子程序也一起)，
+	// 之後仍有程序持有輸出時最多再等 hookWaitDelay
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = hookWaitDelay
+
+	err = cmd.Run()
+	switch {
+	case err == nil:
+		return 0, nil
+	case ctx.Err() == context.DeadlineExceeded:
+		return -1, fmt.Errorf("timed out after %v%s", h.timeout, output.suffix())
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return exitErr.ExitCode(), fmt.Errorf("exit status %d%s", exitErr.ExitCode(), output.suffix())
+	}
+	return -1, fmt.Errorf("%v%s", err, output.suffix())
+}
+
+// tailBuffer 只保留最後 hookMaxOutput 位元組的輸出
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - hookMaxOutput; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+// suffix 附加在錯誤訊息後的輸出 (沒有輸出時為空白)
+func (b *tailBuffer) suffix() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := strings.TrimSpace(string(b.buf))
+	if out == "" {
+		return ""
+	}
+	return ": " + out
+}
+
+// record 更新統計
+func (w *hookWorker) record(exit int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.Runs++
+	w.stats.LastRun = time.Now()
+	w.stats.LastExit = exit
+	if err != nil {
+		w.stats.Failed++
+		w.stats.LastError = err.Error()
+	} else {
+		w.stats.LastError = ""
+	}
+}
+
+// Stats 各 hook 的執行統計 (依設定順序)
+func (r *HookRunner) Stats() []HookStats {
+	stats := make([]HookStats, 0, len(r.workers))
+	for _, w := range r.workers {
+		w.mu.Lock()
+		s := w.stats
+		s.Queued = len(w.queue)
+		w.mu.Unlock()
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// handleHooks GET /api/hooks
+func (s *APIServer) handleHooks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.hooks.Stats())
+}
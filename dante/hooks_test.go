@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/dante"
+)
+
+func TestCompileHooks(t *testing.T) {
+	hooks, err := compileHooks([]EventHook{
+		{Name: "lamp", Command: []string{"/usr/local/bin/lamp", "red"}, Events: []string{WebhookAlarmRaised}},
+		{Name: "log", Shell: "cat >> events.jsonl", Timeout: "2s"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooks[0].timeout != hookDefaultTimeout || hooks[0].wants(WebhookDeviceAdded) || hooks[1].timeout != 2*time.Second {
+		t.Errorf("hooks: %+v", hooks)
+	}
+
+	for _, bad := range [][]EventHook{
+		{{Shell: "true"}},
+		{{Name: "a", Shell: "true"}, {Name: "a", Shell: "false"}},
+		{{Name: "a"}},
+		{{Name: "a", Shell: "true", Command: []string{"true"}}},
+		{{Name: "a", Shell: "true", Events: []string{"device.renamed"}}},
+		{{Name: "a", Shell: "true", Timeout: "-1s"}},
+	} {
+		if _, err := compileHooks(bad); !errors.Is(err, ErrInvalidHook) {
+			t.Errorf("%+v: err = %v", bad, err)
+		}
+	}
+}
+
+func TestHookRunner(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+	dir := t.TempDir()
+	hooks, err := compileHooks([]EventHook{
+		// 事件內容從 stdin 傳入，欄位也在環境變數
+		{Name: "record", Shell: `cat > "$GOLANE_EVENT_ID.json"; echo "$GOLANE_EVENT $GOLANE_DOMAIN $GOLANE_SUBJECT" >> events.txt`,
+			Dir: dir, Events: []string{WebhookDeviceRemoved, WebhookAlarmRaised}},
+		{Name: "broken", Shell: "echo no lamp attached >&2; exit 3"},
+		{Name: "slow", Shell: "sleep 10", Timeout: "50ms", Events: []string{WebhookDeviceRemoved}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewHookRunner(hooks)
+	events := golane.NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Start(ctx, events)
+
+	events.Publish(golane.Event{Topic: golane.TopicDeviceOnline, Domain: "Dante1", Subject: "Amp"}) // record 沒有訂閱
+	events.Publish(golane.Event{Topic: golane.TopicDeviceOffline, Domain: "Dante1", Subject: "Stage-Box-A", Data: dante.Device{Name: "Stage-Box-A"}})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := r.Stats()
+		if stats[0].Runs == 1 && stats[1].Runs == 2 && stats[2].Runs == 1 {
+			if stats[0].Failed != 0 || stats[0].LastExit != 0 {
+				t.Errorf("record: %+v", stats[0])
+			}
+			if stats[1].Failed != 2 || stats[1].LastExit != 3 || !strings.Contains(stats[1].LastError, "no lamp attached") {
+				t.Errorf("broken: %+v", stats[1])
+			}
+			if stats[2].LastExit != -1 || !strings.Contains(stats[2].LastError, "timed out") {
+				t.Errorf("slow: %+v", stats[2])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stats: %+v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	lines, err := os.ReadFile(filepath.Join(dir, "events.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(lines)); got != "device.removed Dante1 Stage-Box-A" {
+		t.Errorf("environment: %q", got)
+	}
+	payloads, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(payloads) != 1 {
+		t.Fatalf("payload files: %v", payloads)
+	}
+	data, _ := os.ReadFile(payloads[0])
+	var p WebhookPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Event != WebhookDeviceRemoved || p.Subject != "Stage-Box-A" || filepath.Base(payloads[0]) != p.ID+".json" {
+		t.Errorf("payload: %+v", p)
+	}
+}
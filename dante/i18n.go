@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 輸出語言 (-lang)
+//==============================================================================
+
+// 每個命令都有 -lang (預設由 GOLANE_LANG 或 LANG 決定)，套用到：
+//
+//	日誌        pretty 與 text 格式的訊息 (json 與轉送目的地維持英文，方便集中搜尋)
+//	CLI 表格    表頭與標題，以顯示寬度對齊
+//	網頁介面    /api/i18n 提供翻譯表，預設跟隨 daemon 的 -lang，網址加 ?lang= 可覆寫
+//
+// 翻譯表在 internal/i18n，新增訊息時在 zhTW 加上對應的翻譯即可。
+
+// setLanguage 套用 -lang (空白時由環境變數決定)
+func setLanguage(lang string) error {
+	l := i18n.FromEnv()
+	if lang != "" {
+		var err error
+		if l, err = i18n.Parse(lang); err != nil {
+			return err
+		}
+	}
+	i18n.Set(l)
+	return nil
+}
+
+// translateHandler 把日誌訊息翻譯成目前的語言 (欄位名稱與值不翻譯)
+type translateHandler struct {
+	slog.Handler
+}
+
+func (h translateHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.Message = i18n.T(r.Message)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h translateHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return translateHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h translateHandler) WithGroup(name string) slog.Handler {
+	return translateHandler{h.Handler.WithGroup(name)}
+}
+
+// headerVerb 表頭格式中的 %s、%-10s
+var headerVerb = regexp.MustCompile(`%(-?)(\d*)s`)
+
+// tableHeader 以表格列相同的格式排出翻譯後的表頭：
+// tableHeader("%-10s %-20s %s\n", "DOMAIN", "DEVICE", "MESSAGE")
+// 欄寬以顯示寬度計算，中文表頭與英文資料列仍然對齊
+func tableHeader(format string, names ...string) string {
+	var b strings.Builder
+	i := 0
+	last := 0
+	for _, m := range headerVerb.FindAllStringSubmatchIndex(format, -1) {
+		b.WriteString(format[last:m[0]])
+		last = m[1]
+		if i >= len(names) {
+			b.WriteString("%!s(MISSING)")
+			continue
+		}
+		name := i18n.T(names[i])
+		i++
+		width, _ := strconv.Atoi(format[m[4]:m[5]])
+		if m[3] > m[2] { // 靠左
+			name = i18n.Pad(name, width)
+		} else if pad := width - i18n.Width(name); pad > 0 {
+			name = strings.Repeat(" ", pad) + name
+		}
+		b.WriteString(name)
+	}
+	b.WriteString(format[last:])
+	return b.String()
+}
+
+// printHeader 印出 tableHeader
+func printHeader(format string, names ...string) {
+	fmt.Print(tableHeader(format, names...))
+}
+
+// apiI18n GET /api/i18n 的回應
+type apiI18n struct {
+	Lang      string            `json:"lang"`
+	Languages []string          `json:"languages"`
+	Messages  map[string]string `json:"messages"` // 英文原文 → 翻譯
+}
+
+// handleI18n GET /api/i18n?lang=
+func (s *APIServer) handleI18n(w http.ResponseWriter, r *http.Request) {
+	lang := i18n.Current()
+	if q := r.URL.Query().Get("lang"); q != "" {
+		var err error
+		if lang, err = i18n.Parse(q); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	resp := apiI18n{Lang: string(lang), Messages: i18n.Messages(lang)}
+	for _, l := range i18n.Supported {
+		resp.Languages = append(resp.Languages, string(l))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// printTable 以顯示寬度對齊欄位，欄位間隔兩格 (tabwriter 以字元數計算寬度，
+// 翻譯成中文後會歪掉)
+func printTable(w io.Writer, rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], i18n.Width(cell))
+		}
+	}
+	for _, row := range rows {
+		var b strings.Builder
+		for i, cell := range row {
+			if i == len(row)-1 {
+				b.WriteString(cell)
+				break
+			}
+			b.WriteString(i18n.Pad(cell, widths[i]+2))
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"danteCS/internal/i18n"
+)
+
+func TestTableHeader(t *testing.T) {
+	defer i18n.Set(i18n.Current())
+
+	i18n.Set(i18n.EN)
+	if got := tableHeader("%-10s %-6s %s\n", "DOMAIN", "DEVICE", "MESSAGE"); got != "DOMAIN     DEVICE MESSAGE\n" {
+		t.Errorf("en: %q", got)
+	}
+	// 中文佔兩格，補空白後與英文資料列對齊
+	i18n.Set(i18n.ZhTW)
+	if got := tableHeader("%-10s %-6s %s\n", "DOMAIN", "DEVICE", "MESSAGE"); got != "網域       設備   訊息\n" {
+		t.Errorf("zh-TW: %q", got)
+	}
+}
+
+func TestTranslateHandler(t *testing.T) {
+	defer i18n.Set(i18n.Current())
+	i18n.Set(i18n.ZhTW)
+
+	var out bytes.Buffer
+	log := slog.New(translateHandler{slog.NewTextHandler(&out, nil)}).With("domain", "Dante1")
+	log.Info("Device list refreshed", "devices", 3)
+	if got := out.String(); !strings.Contains(got, `msg=設備列表已更新`) || !strings.Contains(got, "devices=3") {
+		t.Errorf("log line: %s", got)
+	}
+}
+
+func TestI18nEndpoint(t *testing.T) {
+	defer i18n.Set(i18n.Current())
+	i18n.Set(i18n.EN)
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{}).mux)
+	defer server.Close()
+
+	var en apiI18n
+	getJSON(t, server.URL+"/api/i18n", &en)
+	if en.Lang != "en" || len(en.Messages) != 0 {
+		t.Errorf("default: %+v", en)
+	}
+	var zh apiI18n
+	getJSON(t, server.URL+"/api/i18n?lang=zh_TW", &zh)
+	if zh.Lang != "zh-TW" || zh.Messages["No devices discovered"] != "未發現設備" {
+		t.Errorf("zh-TW: lang %q, %d messages", zh.Lang, len(zh.Messages))
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//==============================================================================
+// 設備型號圖示
+//==============================================================================
+
+// 內建一組通用圖示，使用者可以針對個別型號上傳照片/圖示覆蓋。
+// 上傳的檔案存放在 <state-dir>/icons/，對照表為 icons/index.json。
+
+//go:embed icons/*.svg
+var bundledIcons embed.FS
+
+// maxIconSize 上傳圖示大小上限
+const maxIconSize = 1 << 20
+
+// iconContentTypes 允許上傳的圖片格式
+// 只接受點陣圖：SVG 可以帶 script，與 Web UI 同源提供時會讀到 localStorage 中的 API token
+var iconContentTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+// bundledIconRules 依型號關鍵字對應內建圖示 (依序比對，不分大小寫)
+var bundledIconRules = []struct {
+	Keywords []string
+	Icon     string
+}{
+	{[]string{"AVIO", "ADAPTER", "ADP-"}, "adapter"},
+	{[]string{"DVS", "VIRTUAL SOUNDCARD", "VIA"}, "computer"},
+	{[]string{"AMP", "POWER"}, "amplifier"},
+	{[]string{"SPK", "SPEAKER", "LOUD"}, "speaker"},
+	{[]string{"MIC", "WIRELESS", "RECEIVER"}, "microphone"},
+	{[]string{"CONSOLE", "DESK", "MIXER", "STAGEBOX"}, "console"},
+}
+
+// IconStore 型號與圖示的對照
+type IconStore struct {
+	mu      sync.RWMutex
+	dir     string            // 上傳圖示目錄
+	uploads map[string]string // 型號 → 檔名
+}
+
+// NewIconStore 建立圖示庫並載入已上傳的對照表
+func NewIconStore(stateDir string) (*IconStore, error) {
+	store := &IconStore{
+		dir:     filepath.Join(stateDir, "icons"),
+		uploads: make(map[string]string),
+	}
+
+	data, err := os.ReadFile(store.indexPath())
+	if err == nil {
+		if err := json.Unmarshal(data, &store.uploads); err != nil {
+			return nil, fmt.Errorf("failed to parse icon index: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read icon index: %v", err)
+	}
+
+	return store, nil
+}
+
+func (s *IconStore) indexPath() string {
+	return filepath.Join(s.dir, "index.json")
+}
+
+// normalizeModel 型號比對不分大小寫
+func normalizeModel(model string) string {
+	return strings.ToUpper(strings.TrimSpace(model))
+}
+
+// bundledIconFor 依型號關鍵字挑選內建圖示
+func bundledIconFor(model string) string {
+	upper := normalizeModel(model)
+	for _, rule := range bundledIconRules {
+		for _, keyword := range rule.Keywords {
+			if strings.Contains(upper, keyword) {
+				return rule.Icon
+			}
+		}
+	}
+	return "generic"
+}
+
+// IconURL 取得型號對應的圖示路徑 (上傳優先，否則使用內建圖示)
+func (s *IconStore) IconURL(model string) string {
+	s.mu.RLock()
+	_, uploaded := s.uploads[normalizeModel(model)]
+	s.mu.RUnlock()
+
+	if uploaded {
+		return "/api/icons/models/" + url.PathEscape(normalizeModel(model))
+	}
+	return "/api/icons/bundled/" + bundledIconFor(model) + ".svg"
+}
+
+// Mappings 取得所有上傳的型號對照
+func (s *IconStore) Mappings() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]string, len(s.uploads))
+	for model, file := range s.uploads {
+		result[model] = file
+	}
+	return result
+}
+
+// BundledIconNames 取得內建圖示名稱
+func BundledIconNames() []string {
+	entries, _ := bundledIcons.ReadDir("icons")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".svg"))
+	}
+	return names
+}
+
+// Upload 儲存型號的自訂圖示
+func (s *IconStore) Upload(model, contentType string, data []byte) error {
+	key := normalizeModel(model)
+	if key == "" {
+		return fmt.Errorf("model is required")
+	}
+	if len(data) > maxIconSize {
+		return fmt.Errorf("icon larger than %d bytes", maxIconSize)
+	}
+	ext, ok := iconContentTypes[contentType]
+	if !ok {
+		return fmt.Errorf("unsupported icon type %q", contentType)
+	}
+	if detected := http.DetectContentType(data); detected != contentType {
+		return fmt.Errorf("icon content is %s, not %s", detected, contentType)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create icon dir: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file := iconFileName(key) + ext
+	if old, ok := s.uploads[key]; ok && old != file {
+		s.removeFileLocked(key, old)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, file), data, 0644); err != nil {
+		return fmt.Errorf("failed to write icon: %v", err)
+	}
+
+	s.uploads[key] = file
+	return s.saveIndexLocked()
+}
+
+// Remove 刪除型號的自訂圖示，回到內建圖示
+func (s *IconStore) Remove(model string) error {
+	key := normalizeModel(model)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, ok := s.uploads[key]
+	if !ok {
+		return fmt.Errorf("no uploaded icon for model %s", model)
+	}
+	s.removeFileLocked(key, file)
+	delete(s.uploads, key)
+	return s.saveIndexLocked()
+}
+
+// iconFileName 以型號的 SHA-256 命名，不同型號不會對應到同一個檔案
+func iconFileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// removeFileLocked 刪除型號的圖示檔；舊版對照表可能讓多個型號共用同一個檔名，仍被使用時保留
+func (s *IconStore) removeFileLocked(key, file string) {
+	for other, f := range s.uploads {
+		if other != key && f == file {
+			return
+		}
+	}
+	os.Remove(filepath.Join(s.dir, file))
+}
+
+// Open 讀取上傳的圖示內容
+func (s *IconStore) Open(model string) ([]byte, string, error) {
+	s.mu.RLock()
+	file, ok := s.uploads[normalizeModel(model)]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, "", os.ErrNotExist
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, file))
+	if err != nil {
+		return nil, "", err
+	}
+	for contentType, ext := range iconContentTypes {
+		if strings.HasSuffix(file, ext) {
+			return data, contentType, nil
+		}
+	}
+	return data, "application/octet-stream", nil
+}
+
+func (s *IconStore) saveIndexLocked() error {
+	data, err := json.MarshalIndent(s.uploads, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.indexPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write icon index: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pngIcon 內容可被辨識為 PNG 的圖示
+func pngIcon(tag string) []byte {
+	return []byte("\x89PNG\r\n\x1a\n" + tag)
+}
+
+func TestIconUploadTypes(t *testing.T) {
+	store, err := NewIconStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		ok          bool
+	}{
+		{"png", "image/png", pngIcon("amp"), true},
+		{"jpeg", "image/jpeg", []byte("\xff\xd8\xff\xe0jpeg"), true},
+		{"webp", "image/webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), true},
+		{"svg", "image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), false},
+		{"html labelled as png", "image/png", []byte("<html><script>alert(1)</script></html>"), false},
+		{"png labelled as jpeg", "image/jpeg", pngIcon("amp"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.Upload("AMP-"+tt.name, tt.contentType, tt.data)
+			if (err == nil) != tt.ok {
+				t.Errorf("Upload(%s) = %v, want ok %v", tt.contentType, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestIconModelsDoNotShareFiles(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewIconStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 以前的檔名把這三個型號都換成 A_B
+	models := []string{"A B", "A/B", "A_B"}
+	for _, model := range models {
+		if err := store.Upload(model, "image/png", pngIcon(model)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, model := range models {
+		data, _, err := store.Open(model)
+		if err != nil || string(data) != string(pngIcon(model)) {
+			t.Errorf("Open(%q) = %q, %v", model, data, err)
+		}
+	}
+
+	if err := store.Remove("A/B"); err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []string{"A B", "A_B"} {
+		if data, _, err := store.Open(model); err != nil || string(data) != string(pngIcon(model)) {
+			t.Errorf("after removing A/B, Open(%q) = %q, %v", model, data, err)
+		}
+	}
+
+	// 重新載入後對照表仍指向各自的檔案
+	reloaded, err := NewIconStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := reloaded.Open("a b"); err != nil || string(data) != string(pngIcon("A B")) {
+		t.Errorf("reloaded Open(a b) = %q, %v", data, err)
+	}
+}
+
+func TestModelIconHeaders(t *testing.T) {
+	store, err := NewIconStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Upload("AMP-1", "image/png", pngIcon("amp")); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewAPIServer(APIConfig{Icons: store}).mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/icons/models/AMP-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/png" {
+		t.Fatalf("status %d, type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get("X-Content-Type-Options") != "nosniff" || !strings.Contains(resp.Header.Get("Content-Security-Policy"), "sandbox") {
+		t.Errorf("missing protective headers: %v", resp.Header)
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/api/icons/models/AMP-2", strings.NewReader(`<svg/>`))
+	req.Header.Set("Content-Type", "image/svg+xml")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("SVG upload status = %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"danteCS/internal/i18n"
+	"danteCS/internal/igmp"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// IGMP 診斷
+//==============================================================================
+
+// Dante 的 multicast (PTP 時鐘、multicast flow、AES67) 需要交換器的 IGMP snooping
+// 與網路上的 querier。最常見的故障是開了 snooping 卻沒有 querier：成員資格
+// 逾時後 multicast 氾濫到所有埠，100 Mbps 的設備與無線橋接被音訊淹沒。
+// daemon 在 Dante 介面上收聽 IGMP 查詢 (igmp 功能)，API 與 golane igmp 列出
+// 介面加入的群組、聽到的 querier 與發現的問題。
+
+// procIGMPPath 介面群組的來源 (測試可替換)
+var procIGMPPath = igmp.ProcPath
+
+// IGMPWatch 收聽中的 Dante 介面
+type IGMPWatch struct {
+	monitor *igmp.Monitor
+	ifaces  []string
+}
+
+// startIGMP 在介面上收聽 IGMP 查詢，ctx 結束時停止
+// 無法開啟 raw socket (沒有 CAP_NET_RAW) 時只記錄，報告仍列出群組
+func startIGMP(ctx context.Context, ifaces []string) *IGMPWatch {
+	w := &IGMPWatch{monitor: igmp.NewMonitor(), ifaces: ifaces}
+	recovery.GoLoop(ctx, "igmp", func() {
+		if err := w.monitor.Listen(ctx, ifaces); err != nil {
+			logger.Warn("IGMP querier detection unavailable", "err", err)
+		}
+	})
+	// 一個查詢間隔後檢查一次，沒有 querier 時在日誌中提醒
+	recovery.Go("igmp/check", func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(igmp.DefaultQueryInterval + 5*time.Second):
+		}
+		reports, err := w.Reports()
+		if err != nil {
+			logger.Warn("IGMP group memberships unavailable", "err", err)
+			return
+		}
+		for _, r := range reports {
+			for _, problem := range r.Problems {
+				logger.Warn("Multicast problem", "iface", r.Interface, "problem", problem)
+			}
+		}
+	})
+	return w
+}
+
+// Reports 各介面的診斷 (讀取目前的群組成員)
+func (w *IGMPWatch) Reports() ([]igmp.Report, error) {
+	memberships, err := readIGMPMemberships()
+	if err != nil {
+		return nil, err
+	}
+	queriers, listened := w.monitor.Queriers(), w.monitor.Listening()
+	reports := make([]igmp.Report, 0, len(w.ifaces))
+	for _, iface := range w.ifaces {
+		reports = append(reports, igmp.Diagnose(iface, memberships, queriers, listened))
+	}
+	return reports, nil
+}
+
+// readIGMPMemberships 讀取 procIGMPPath
+func readIGMPMemberships() ([]igmp.Membership, error) {
+	f, err := os.Open(procIGMPPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return igmp.ParseProcIGMP(f)
+}
+
+// printIGMPReports 顯示各介面的群組、querier 與問題
+func printIGMPReports(reports []igmp.Report) {
+	for _, r := range reports {
+		fmt.Printf("\n=== %s (IGMP %s) ===\n", r.Interface, r.Version)
+		fmt.Println(i18n.T("Groups:"))
+		for _, g := range r.Groups {
+			purpose := g.Purpose
+			if purpose == "" {
+				purpose = "-"
+			}
+			fmt.Printf("  %-17s %s\n", g.Address, purpose)
+		}
+		fmt.Print(i18n.Sprintf("Queriers (listened %s):\n", r.Listened.Round(time.Second)))
+		if len(r.Queriers) == 0 {
+			fmt.Println(i18n.T("  none"))
+		}
+		for _, q := range r.Queriers {
+			fmt.Print(i18n.Sprintf("  %-17s v%d  %d queries, last %s\n", q.Address, q.Version, q.Queries, q.LastSeen.Format(time.TimeOnly)))
+		}
+		for _, p := range r.Problems {
+			fmt.Printf("  ! %s\n", p)
+		}
+		for _, n := range r.Notes {
+			fmt.Printf("  - %s\n", n)
+		}
+	}
+	fmt.Println()
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// handleIGMP GET /api/igmp
+func (s *APIServer) handleIGMP(w http.ResponseWriter, r *http.Request) {
+	reports, err := s.igmp.Reports()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// IGMPReports daemon 的 IGMP 診斷
+func (c *RemoteClient) IGMPReports() ([]igmp.Report, error) {
+	var reports []igmp.Report
+	return reports, c.do(http.MethodGet, "/api/igmp", nil, &reports)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newIGMPCommand golane igmp
+func newIGMPCommand() *Command {
+	fs := newFlagSet("igmp")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", igmp.DefaultQueryInterval+5*time.Second, "how long to listen for IGMP queries (queriers send about every 125s)")
+	jsonOut := fs.Bool("json", false, "print the diagnostics as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "igmp",
+		Short: "Show multicast groups joined on the Dante interfaces and check for an IGMP querier",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+
+			var reports []igmp.Report
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if reports, err = client.IGMPReports(); err != nil {
+					return err
+				}
+			} else {
+				if *wait < igmp.DefaultQueryInterval {
+					logger.Warn("Waiting less than the query interval, a querier may be missed", "wait", *wait, "interval", igmp.DefaultQueryInterval)
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				names := danteInterfaceNames(detector)
+				if len(names) == 0 {
+					return fmt.Errorf("Dante interface not found (expected one of %v)", detector.DanteInterfaceNames)
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+				watch := &IGMPWatch{monitor: igmp.NewMonitor(), ifaces: names}
+				listenCtx, stop := context.WithTimeout(ctx, *wait)
+				defer stop()
+				if err := watch.monitor.Listen(listenCtx, names); err != nil {
+					logger.Warn("IGMP querier detection unavailable, listing groups only", "err", err)
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if reports, err = watch.Reports(); err != nil {
+					return err
+				}
+			}
+
+			if *jsonOut {
+				return printJSON(reports)
+			}
+			printIGMPReports(reports)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// 事件單 (Incident)
+//==============================================================================
+
+// 告警通知會併入同種類、同網域且尚未解決的事件單，
+// 生命週期為 open → acknowledged → resolved，並保留時間軸與備註。
+// 所有受影響對象都恢復時事件單自動解決。
+
+// incidentSection 事件單在狀態檔中的 section 名稱
+const incidentSection = "incidents"
+
+// maxResolvedIncidents 保留的已解決事件單數量
+const maxResolvedIncidents = 500
+
+// 事件單狀態
+const (
+	IncidentOpen         = "open"
+	IncidentAcknowledged = "acknowledged"
+	IncidentResolved     = "resolved"
+)
+
+// 時間軸項目種類
+const (
+	TimelineOpened       = "opened"
+	TimelineAlert        = "alert"
+	TimelineRecovered    = "recovered"
+	TimelineAcknowledged = "acknowledged"
+	TimelineNote         = "note"
+	TimelineResolved     = "resolved"
+)
+
+// incidentSystemActor 自動操作的執行者名稱
+const incidentSystemActor = "system"
+
+// errIncidentNotFound 事件單不存在
+var errIncidentNotFound = errors.New("incident not found")
+
+// IncidentSubject 事件單影響的對象
+type IncidentSubject struct {
+	Name        string     `json:"name"`
+	Message     string     `json:"message"`
+	FirstSeen   time.Time  `json:"first_seen"`
+	Recovered   bool       `json:"recovered"`
+	RecoveredAt *time.Time `json:"recovered_at,omitempty"`
+}
+
+// IncidentEvent 時間軸項目
+type IncidentEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Actor   string    `json:"actor,omitempty"`
+	Message string    `json:"message"`
+}
+
+// Incident 事件單
+type Incident struct {
+	ID             string            `json:"id"`
+	Kind           string            `json:"kind"`
+	Domain         string            `json:"domain,omitempty"`
+	Severity       string            `json:"severity"`
+	Status         string            `json:"status"`
+	Subjects       []IncidentSubject `json:"subjects"`
+	Timeline       []IncidentEvent   `json:"timeline"`
+	AlertCount     int               `json:"alert_count"` // 併入的告警數 (含重複)
+	OpenedAt       time.Time         `json:"opened_at"`
+	AcknowledgedAt *time.Time        `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string            `json:"acknowledged_by,omitempty"`
+	ResolvedAt     *time.Time        `json:"resolved_at,omitempty"`
+	ResolvedBy     string            `json:"resolved_by,omitempty"`
+}
+
+// Title 事件單標題
+func (inc *Incident) Title() string {
+	scope := ""
+	if inc.Domain != "" {
+		scope = " in " + inc.Domain
+	}
+	if len(inc.Subjects) == 1 {
+		return fmt.Sprintf("%s%s: %s", inc.Kind, scope, inc.Subjects[0].Name)
+	}
+	return fmt.Sprintf("%s%s: %d subjects", inc.Kind, scope, len(inc.Subjects))
+}
+
+// Active 尚未解決
+func (inc *Incident) Active() bool {
+	return inc.Status != IncidentResolved
+}
+
+func (inc *Incident) addEvent(eventType, actor, message string, t time.Time) {
+	inc.Timeline = append(inc.Timeline, IncidentEvent{Time: t, Type: eventType, Actor: actor, Message: message})
+}
+
+func (inc *Incident) subject(name string) *IncidentSubject {
+	for i := range inc.Subjects {
+		if strings.EqualFold(inc.Subjects[i].Name, name) {
+			return &inc.Subjects[i]
+		}
+	}
+	return nil
+}
+
+func (inc *Incident) resolve(actor, message string, t time.Time) {
+	inc.Status = IncidentResolved
+	inc.ResolvedAt = &t
+	inc.ResolvedBy = actor
+	inc.addEvent(TimelineResolved, actor, message, t)
+}
+
+func (inc Incident) clone() Incident {
+	inc.Subjects = append([]IncidentSubject{}, inc.Subjects...)
+	inc.Timeline = append([]IncidentEvent{}, inc.Timeline...)
+	return inc
+}
+
+// incidentState 事件單持久化內容
+type incidentState struct {
+	NextID    int        `json:"next_id"`
+	Incidents []Incident `json:"incidents"`
+}
+
+// IncidentStore 事件單存取
+type IncidentStore struct {
+	mu    sync.Mutex
+	state *StateStore
+	data  incidentState
+}
+
+// NewIncidentStore 從狀態檔載入事件單
+func NewIncidentStore(state *StateStore) (*IncidentStore, error) {
+	s := &IncidentStore{state: state, data: incidentState{NextID: 1}}
+	if _, err := state.Load(incidentSection, &s.data); err != nil {
+		return nil, err
+	}
+	if s.data.NextID < 1 {
+		s.data.NextID = 1
+	}
+	return s, nil
+}
+
+// saveLocked 保存並清除過多的已解決事件單
+func (s *IncidentStore) saveLocked() error {
+	resolved := 0
+	for i := len(s.data.Incidents) - 1; i >= 0; i-- {
+		if s.data.Incidents[i].Active() {
+			continue
+		}
+		resolved++
+		if resolved > maxResolvedIncidents {
+			s.data.Incidents = append(s.data.Incidents[:i], s.data.Incidents[i+1:]...)
+		}
+	}
+	return s.state.Save(incidentSection, s.data)
+}
+
+// findLocked 依 ID 取得事件單
+func (s *IncidentStore) findLocked(id string) *Incident {
+	for i := range s.data.Incidents {
+		if strings.EqualFold(s.data.Incidents[i].ID, id) {
+			return &s.data.Incidents[i]
+		}
+	}
+	return nil
+}
+
+// activeLocked 取得同種類、同網域且尚未解決的事件單
+func (s *IncidentStore) activeLocked(kind, domain string) *Incident {
+	for i := len(s.data.Incidents) - 1; i >= 0; i-- {
+		inc := &s.data.Incidents[i]
+		if inc.Active() && inc.Kind == kind && inc.Domain == domain {
+			return inc
+		}
+	}
+	return nil
+}
+
+// HandleNotification 把告警通知併入事件單 (作為 AlertNotifier 使用)
+func (s *IncidentStore) HandleNotification(n AlertNotification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc := s.activeLocked(n.Kind, n.Domain)
+	if inc == nil {
+		s.data.Incidents = append(s.data.Incidents, Incident{
+			ID:       fmt.Sprintf("INC-%04d", s.data.NextID),
+			Kind:     n.Kind,
+			Domain:   n.Domain,
+			Severity: n.Severity,
+			Status:   IncidentOpen,
+			OpenedAt: n.Time,
+		})
+		s.data.NextID++
+		inc = &s.data.Incidents[len(s.data.Incidents)-1]
+		inc.addEvent(TimelineOpened, incidentSystemActor, n.Message, n.Time)
+		logger.Info("Incident opened", "incident", inc.ID, "kind", inc.Kind, "domain", inc.Domain)
+	} else {
+		inc.addEvent(TimelineAlert, incidentSystemActor, n.Message, n.Time)
+	}
+
+	inc.AlertCount += len(n.Alerts) + n.Suppressed
+	for _, a := range n.Alerts {
+		if sub := inc.subject(a.Subject); sub != nil {
+			// 已恢復的對象再次出現
+			sub.Recovered = false
+			sub.RecoveredAt = nil
+			sub.Message = a.Message
+			continue
+		}
+		inc.Subjects = append(inc.Subjects, IncidentSubject{Name: a.Subject, Message: a.Message, FirstSeen: a.Time})
+	}
+
+	if err := s.saveLocked(); err != nil {
+		logger.Warn("Failed to save incidents", "err", err)
+	}
+}
+
+// HandleRecovery 告警對象恢復，全部恢復時自動解決事件單 (搭配 AlertManager.OnResolve)
+func (s *IncidentStore) HandleRecovery(kind, domain, subject string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc := s.activeLocked(kind, domain)
+	if inc == nil {
+		return
+	}
+	sub := inc.subject(subject)
+	if sub == nil || sub.Recovered {
+		return
+	}
+
+	now := time.Now()
+	sub.Recovered = true
+	sub.RecoveredAt = &now
+	inc.addEvent(TimelineRecovered, incidentSystemActor, subject+" recovered", now)
+
+	for _, other := range inc.Subjects {
+		if !other.Recovered {
+			if err := s.saveLocked(); err != nil {
+				logger.Warn("Failed to save incidents", "err", err)
+			}
+			return
+		}
+	}
+
+	inc.resolve(incidentSystemActor, "all subjects recovered", now)
+	logger.Info("Incident resolved", "incident", inc.ID, "by", incidentSystemActor)
+	if err := s.saveLocked(); err != nil {
+		logger.Warn("Failed to save incidents", "err", err)
+	}
+}
+
+// update 修改單一事件單並保存
+func (s *IncidentStore) update(id string, change func(inc *Incident, now time.Time) error) (Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc := s.findLocked(id)
+	if inc == nil {
+		return Incident{}, fmt.Errorf("%w: %s", errIncidentNotFound, id)
+	}
+
+	backup := inc.clone()
+	if err := change(inc, time.Now()); err != nil {
+		return Incident{}, err
+	}
+	// 保存時可能清除舊的事件單，先取得結果
+	result := inc.clone()
+	if err := s.saveLocked(); err != nil {
+		if inc := s.findLocked(id); inc != nil {
+			*inc = backup
+		}
+		return Incident{}, err
+	}
+	return result, nil
+}
+
+// Acknowledge 確認事件單 (open → acknowledged)
+func (s *IncidentStore) Acknowledge(id, actor, note string) (Incident, error) {
+	return s.update(id, func(inc *Incident, now time.Time) error {
+		if inc.Status != IncidentOpen {
+			return fmt.Errorf("incident %s is %s", inc.ID, inc.Status)
+		}
+		inc.Status = IncidentAcknowledged
+		inc.AcknowledgedAt = &now
+		inc.AcknowledgedBy = actor
+		inc.addEvent(TimelineAcknowledged, actor, note, now)
+		return nil
+	})
+}
+
+// Resolve 手動解決事件單
+func (s *IncidentStore) Resolve(id, actor, note string) (Incident, error) {
+	return s.update(id, func(inc *Incident, now time.Time) error {
+		if !inc.Active() {
+			return fmt.Errorf("incident %s is already resolved", inc.ID)
+		}
+		inc.resolve(actor, note, now)
+		return nil
+	})
+}
+
+// AddNote 新增備註
+func (s *IncidentStore) AddNote(id, actor, text string) (Incident, error) {
+	if strings.TrimSpace(text) == "" {
+		return Incident{}, fmt.Errorf("note text is empty")
+	}
+	return s.update(id, func(inc *Incident, now time.Time) error {
+		inc.addEvent(TimelineNote, actor, text, now)
+		return nil
+	})
+}
+
+// Get 依 ID 取得事件單
+func (s *IncidentStore) Get(id string) (Incident, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc := s.findLocked(id)
+	if inc == nil {
+		return Incident{}, false
+	}
+	return inc.clone(), true
+}
+
+// List 列出事件單 (新的在前)，status 空白表示全部
+func (s *IncidentStore) List(status string) []Incident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []Incident{}
+	for i := len(s.data.Incidents) - 1; i >= 0; i-- {
+		inc := &s.data.Incidents[i]
+		if status == "" || inc.Status == status {
+			result = append(result, inc.clone())
+		}
+	}
+	return result
+}
+
+//==============================================================================
+// 事件單報表
+//==============================================================================
+
+// IncidentReport 期間內的事件單統計
+type IncidentReport struct {
+	Since        time.Time      `json:"since"`
+	Until        time.Time      `json:"until"`
+	Total        int            `json:"total"`
+	Open         int            `json:"open"`
+	Acknowledged int            `json:"acknowledged"`
+	Resolved     int            `json:"resolved"`
+	ByKind       map[string]int `json:"by_kind"`
+	MTTASeconds  float64        `json:"mtta_seconds"` // 平均確認時間
+	MTTRSeconds  float64        `json:"mttr_seconds"` // 平均解決時間
+	Incidents    []Incident     `json:"incidents"`
+}
+
+// Report 產生 since 之後開立的事件單報表
+func (s *IncidentStore) Report(since time.Time) IncidentReport {
+	report := IncidentReport{
+		Since:     since,
+		Until:     time.Now(),
+		ByKind:    make(map[string]int),
+		Incidents: []Incident{},
+	}
+
+	var ackTotal, resolveTotal time.Duration
+	var ackCount, resolveCount int
+	for _, inc := range s.List("") {
+		if inc.OpenedAt.Before(since) {
+			continue
+		}
+		report.Incidents = append(report.Incidents, inc)
+		report.Total++
+		report.ByKind[inc.Kind]++
+
+		switch inc.Status {
+		case IncidentOpen:
+			report.Open++
+		case IncidentAcknowledged:
+			report.Acknowledged++
+		case IncidentResolved:
+			report.Resolved++
+		}
+		if inc.AcknowledgedAt != nil {
+			ackTotal += inc.AcknowledgedAt.Sub(inc.OpenedAt)
+			ackCount++
+		}
+		if inc.ResolvedAt != nil {
+			resolveTotal += inc.ResolvedAt.Sub(inc.OpenedAt)
+			resolveCount++
+		}
+	}
+
+	if ackCount > 0 {
+		report.MTTASeconds = (ackTotal / time.Duration(ackCount)).Seconds()
+	}
+	if resolveCount > 0 {
+		report.MTTRSeconds = (resolveTotal / time.Duration(resolveCount)).Seconds()
+	}
+	return report
+}
+
+// PrintIncidentReport 以文字格式輸出報表
+func PrintIncidentReport(w io.Writer, report IncidentReport) {
+	fmt.Fprintf(w, "\n📋 Incident Report %s → %s\n", report.Since.Format(time.DateTime), report.Until.Format(time.DateTime))
+	fmt.Fprintln(w, "════════════════════════════════════════════════════════════════")
+	fmt.Fprintf(w, "Total: %d   Open: %d   Acknowledged: %d   Resolved: %d\n",
+		report.Total, report.Open, report.Acknowledged, report.Resolved)
+	if report.MTTASeconds > 0 || report.MTTRSeconds > 0 {
+		fmt.Fprintf(w, "MTTA: %v   MTTR: %v\n",
+			(time.Duration(report.MTTASeconds) * time.Second).Round(time.Second),
+			(time.Duration(report.MTTRSeconds) * time.Second).Round(time.Second))
+	}
+
+	kinds := make([]string, 0, len(report.ByKind))
+	for kind := range report.ByKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "  • %-20s %d\n", kind, report.ByKind[kind])
+	}
+
+	fmt.Fprintln(w, "────────────────────────────────────────────────────────────────")
+	for _, inc := range report.Incidents {
+		fmt.Fprintf(w, "%-9s %-13s %s  %s\n", inc.ID, inc.Status, inc.OpenedAt.Format(time.DateTime), inc.Title())
+		for _, ev := range inc.Timeline {
+			if ev.Type != TimelineNote {
+				continue
+			}
+			fmt.Fprintf(w, "          📝 %s %s: %s\n", ev.Time.Format(time.DateTime), ev.Actor, ev.Message)
+		}
+	}
+	fmt.Fprintln(w, "════════════════════════════════════════════════════════════════")
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestIncidentStore(t *testing.T, dir string) *IncidentStore {
+	t.Helper()
+	state, err := OpenStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewIncidentStore(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func offlineNotification(domain string, subjects ...string) AlertNotification {
+	n := AlertNotification{Kind: AlertDeviceOffline, Severity: SeverityCritical, Domain: domain, Time: time.Now()}
+	for _, s := range subjects {
+		n.Alerts = append(n.Alerts, Alert{Kind: AlertDeviceOffline, Domain: domain, Subject: s, Message: s + " went offline", Time: n.Time})
+	}
+	n.Message = strings.Join(subjects, ", ")
+	return n
+}
+
+func TestIncidentAutoResolve(t *testing.T) {
+	store := newTestIncidentStore(t, t.TempDir())
+
+	store.HandleNotification(offlineNotification("Dante1", "amp-1"))
+	store.HandleNotification(offlineNotification("Dante1", "amp-2", "AMP-1"))
+	store.HandleNotification(offlineNotification("Dante2", "mixer"))
+
+	active := store.List(IncidentOpen)
+	if len(active) != 2 {
+		t.Fatalf("open incidents = %d, want one per domain", len(active))
+	}
+	inc, ok := store.Get("inc-0001")
+	if !ok {
+		t.Fatal("INC-0001 not found")
+	}
+	if len(inc.Subjects) != 2 || inc.AlertCount != 3 || inc.Title() != "device-offline in Dante1: 2 subjects" {
+		t.Fatalf("merged incident = %+v (%s)", inc, inc.Title())
+	}
+
+	// 未知對象或其他網域的恢復不影響事件單
+	store.HandleRecovery(AlertDeviceOffline, "Dante1", "stagebox")
+	store.HandleRecovery(AlertDeviceOffline, "Dante1", "amp-1")
+	if inc, _ := store.Get("INC-0001"); inc.Status != IncidentOpen {
+		t.Fatalf("resolved with a subject still offline: %s", inc.Status)
+	}
+	store.HandleRecovery(AlertDeviceOffline, "Dante1", "amp-2")
+	inc, _ = store.Get("INC-0001")
+	if inc.Status != IncidentResolved || inc.ResolvedBy != incidentSystemActor || inc.ResolvedAt == nil {
+		t.Fatalf("not auto-resolved: %+v", inc)
+	}
+	last := inc.Timeline[len(inc.Timeline)-1]
+	if last.Type != TimelineResolved {
+		t.Errorf("last timeline event = %+v", last)
+	}
+
+	// 解決後的新告警開立新事件單
+	store.HandleNotification(offlineNotification("Dante1", "amp-1"))
+	if open := store.List(IncidentOpen); len(open) != 2 || open[0].ID != "INC-0003" {
+		t.Errorf("open after resolve = %+v", open)
+	}
+}
+
+func TestIncidentLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestIncidentStore(t, dir)
+	store.HandleNotification(offlineNotification("Dante1", "amp-1"))
+
+	if _, err := store.Acknowledge("INC-0001", "alice", "looking"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Acknowledge("INC-0001", "bob", ""); err == nil {
+		t.Error("acknowledged twice")
+	}
+	if _, err := store.AddNote("INC-0001", "alice", "  "); err == nil {
+		t.Error("empty note accepted")
+	}
+	if _, err := store.AddNote("INC-0001", "alice", "PSU replaced"); err != nil {
+		t.Fatal(err)
+	}
+	inc, err := store.Resolve("INC-0001", "alice", "fixed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Resolve("INC-0001", "alice", ""); err == nil {
+		t.Error("resolved twice")
+	}
+	if _, err := store.Acknowledge("INC-9999", "alice", ""); !errors.Is(err, errIncidentNotFound) {
+		t.Errorf("unknown incident: %v", err)
+	}
+
+	var types []string
+	for _, ev := range inc.Timeline {
+		types = append(types, ev.Type)
+	}
+	if got := strings.Join(types, ","); got != "opened,acknowledged,note,resolved" {
+		t.Errorf("timeline = %s", got)
+	}
+
+	// 重新載入後保留狀態與編號
+	reopened := newTestIncidentStore(t, dir)
+	got, ok := reopened.Get("INC-0001")
+	if !ok || got.Status != IncidentResolved || got.AcknowledgedBy != "alice" || len(got.Timeline) != 4 {
+		t.Fatalf("reloaded = %+v", got)
+	}
+	reopened.HandleNotification(offlineNotification("Dante1", "amp-1"))
+	if open := reopened.List(IncidentOpen); len(open) != 1 || open[0].ID != "INC-0002" {
+		t.Errorf("next incident after reload = %+v", open)
+	}
+}
+
+func TestIncidentReport(t *testing.T) {
+	store := newTestIncidentStore(t, t.TempDir())
+	store.HandleNotification(offlineNotification("Dante1", "amp-1"))
+	store.HandleNotification(offlineNotification("Dante2", "mixer"))
+	store.HandleNotification(AlertNotification{Kind: AlertNameConflict, Domain: "Dante1", Time: time.Now(),
+		Alerts: []Alert{{Kind: AlertNameConflict, Subject: "amp-3"}}})
+	store.Acknowledge("INC-0002", "alice", "")
+	store.Resolve("INC-0003", "alice", "")
+
+	report := store.Report(time.Now().Add(-time.Hour))
+	if report.Total != 3 || report.Open != 1 || report.Acknowledged != 1 || report.Resolved != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	if report.ByKind[AlertDeviceOffline] != 2 || report.ByKind[AlertNameConflict] != 1 {
+		t.Errorf("by kind = %v", report.ByKind)
+	}
+	if future := store.Report(time.Now().Add(time.Hour)); future.Total != 0 {
+		t.Errorf("report since the future = %+v", future)
+	}
+}
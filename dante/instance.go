@@ -0,0 +1,454 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 多實例 (Profile) 管理
+//==============================================================================
+
+// Dante SDK wrapper 使用全域狀態，每個實例必須是獨立的行程。
+// 實驗室主機可用一份 instances.json 模擬多個場館。
+
+// defaultInstancesFile 預設實例設定檔
+const defaultInstancesFile = "instances.json"
+
+// instanceEnvName 子行程用來識別自己的環境變數
+const instanceEnvName = "GOLANE_INSTANCE"
+
+// InstanceProfile 單一實例設定
+type InstanceProfile struct {
+	Name        string            `json:"name"`
+	DanteIfaces []string          `json:"dante_ifaces"`       // Dante 介面
+	StateDir    string            `json:"state_dir"`          // 狀態目錄 (工作目錄、PID、日誌)
+	APIAddr     string            `json:"api_addr,omitempty"` // 管理 API 監聽地址
+	Args        []string          `json:"args,omitempty"`     // 額外命令列參數
+	Env         map[string]string `json:"env,omitempty"`      // 額外環境變數
+	Disabled    bool              `json:"disabled,omitempty"` // 不由 supervise/start 啟動
+}
+
+// InstanceSet 實例設定檔
+type InstanceSet struct {
+	Instances []InstanceProfile `json:"instances"`
+}
+
+// LoadInstanceSet 載入實例設定並驗證彼此隔離
+func LoadInstanceSet(path string) (*InstanceSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instances file: %v", err)
+	}
+
+	var set InstanceSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse instances file: %v", err)
+	}
+
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// Validate 確認實例名稱、狀態目錄與介面不互相衝突
+func (s *InstanceSet) Validate() error {
+	names := make(map[string]bool)
+	dirs := make(map[string]string)
+	ifaces := make(map[string]string)
+	ports := make(map[string]string) // tcp/8080 → 實例名稱
+
+	for _, inst := range s.Instances {
+		if inst.Name == "" {
+			return fmt.Errorf("instance without name")
+		}
+		if names[inst.Name] {
+			return fmt.Errorf("duplicate instance name %s", inst.Name)
+		}
+		names[inst.Name] = true
+
+		if inst.StateDir == "" {
+			return fmt.Errorf("instance %s has no state_dir", inst.Name)
+		}
+		dir := filepath.Clean(inst.StateDir)
+		if other, ok := dirs[dir]; ok {
+			return fmt.Errorf("instances %s and %s share state dir %s", other, inst.Name, dir)
+		}
+		dirs[dir] = inst.Name
+
+		if len(inst.DanteIfaces) == 0 {
+			return fmt.Errorf("instance %s has no dante_ifaces", inst.Name)
+		}
+		for _, iface := range inst.DanteIfaces {
+			if other, ok := ifaces[iface]; ok {
+				return fmt.Errorf("instances %s and %s both use interface %s", other, inst.Name, iface)
+			}
+			ifaces[iface] = inst.Name
+		}
+
+		listeners, err := inst.listeners()
+		if err != nil {
+			return err
+		}
+		for _, port := range listeners {
+			if other, ok := ports[port]; ok {
+				return fmt.Errorf("instances %s and %s both listen on port %s", other, inst.Name, port)
+			}
+			ports[port] = inst.Name
+		}
+	}
+	return nil
+}
+
+// listenFlags 實例 Args 中會監聽埠號的參數與協定
+var listenFlags = map[string]string{"api-addr": "tcp", "snmp": "udp"}
+
+// listeners 實例監聽的埠號 (協定/埠號)：管理 API (也提供 /metrics) 與 Args 中的監聽參數
+// 不同 IP 的相同埠號也視為衝突，避免其中一個實例監聽 0.0.0.0 時在啟動時才失敗
+func (p *InstanceProfile) listeners() ([]string, error) {
+	addrs := map[string][]string{}
+	if p.APIAddr != "" {
+		addrs["tcp"] = append(addrs["tcp"], p.APIAddr)
+	}
+	for i, arg := range p.Args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		proto, ok := listenFlags[name]
+		if !ok || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(p.Args) {
+				continue
+			}
+			value = p.Args[i+1]
+		}
+		if value != "" {
+			addrs[proto] = append(addrs[proto], value)
+		}
+	}
+
+	var ports []string
+	for _, proto := range []string{"tcp", "udp"} {
+		for _, addr := range addrs[proto] {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, fmt.Errorf("instance %s: invalid listen address %q: %v", p.Name, addr, err)
+			}
+			if port != "" && port != "0" && !slices.Contains(ports, proto+"/"+port) {
+				ports = append(ports, proto+"/"+port)
+			}
+		}
+	}
+	return ports, nil
+}
+
+// Find 依名稱取得實例
+func (s *InstanceSet) Find(name string) *InstanceProfile {
+	for i := range s.Instances {
+		if s.Instances[i].Name == name {
+			return &s.Instances[i]
+		}
+	}
+	return nil
+}
+
+// selectInstances 依名稱選擇實例，未指定時回傳全部啟用的實例
+func (s *InstanceSet) selectInstances(names []string) ([]*InstanceProfile, error) {
+	var result []*InstanceProfile
+	if len(names) == 0 {
+		for i := range s.Instances {
+			if !s.Instances[i].Disabled {
+				result = append(result, &s.Instances[i])
+			}
+		}
+		return result, nil
+	}
+
+	for _, name := range names {
+		inst := s.Find(name)
+		if inst == nil {
+			return nil, fmt.Errorf("unknown instance %s", name)
+		}
+		result = append(result, inst)
+	}
+	return result, nil
+}
+
+func (p *InstanceProfile) pidFile() string {
+	return filepath.Join(p.StateDir, "golane.pid")
+}
+
+func (p *InstanceProfile) logFile() string {
+	return filepath.Join(p.StateDir, "golane.log")
+}
+
+// command 建立實例子行程
+func (p *InstanceProfile) command() (*exec.Cmd, *os.File, error) {
+	if err := os.MkdirAll(p.StateDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create state dir for %s: %v", p.Name, err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to locate executable: %v", err)
+	}
+
+	logOut, err := os.OpenFile(p.logFile(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open log for %s: %v", p.Name, err)
+	}
+
+	args := []string{"monitor", "-dante-ifaces", strings.Join(p.DanteIfaces, ",")}
+	if p.APIAddr != "" {
+		args = append(args, "-api-addr", p.APIAddr)
+	}
+	args = append(args, p.Args...)
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = p.StateDir
+	cmd.Stdout = logOut
+	cmd.Stderr = logOut
+	cmd.Env = append(os.Environ(), instanceEnvName+"="+p.Name)
+	for k, v := range p.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	return cmd, logOut, nil
+}
+
+// RunningPID 讀取 PID 檔並確認行程仍存在
+func (p *InstanceProfile) RunningPID() (int, bool) {
+	data, err := os.ReadFile(p.pidFile())
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	if err := syscall.Kill(pid, 0); err != nil {
+		return pid, false
+	}
+	return pid, true
+}
+
+// Start 以背景方式啟動實例
+func (p *InstanceProfile) Start() (int, error) {
+	if pid, running := p.RunningPID(); running {
+		return pid, fmt.Errorf("instance %s already running (pid %d)", p.Name, pid)
+	}
+
+	cmd, logOut, err := p.command()
+	if err != nil {
+		return 0, err
+	}
+	defer logOut.Close()
+
+	// 脫離目前的 session，讓實例在 CLI 結束後繼續運行
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start instance %s: %v", p.Name, err)
+	}
+
+	pid := cmd.Process.Pid
+	if err := os.WriteFile(p.pidFile(), []byte(strconv.Itoa(pid)+"\n"), 0644); err != nil {
+		return pid, fmt.Errorf("instance %s started but pid file failed: %v", p.Name, err)
+	}
+	cmd.Process.Release()
+	return pid, nil
+}
+
+// Stop 送出 SIGTERM 並等待實例結束
+func (p *InstanceProfile) Stop(timeout time.Duration) error {
+	pid, running := p.RunningPID()
+	if !running {
+		os.Remove(p.pidFile())
+		return fmt.Errorf("instance %s is not running", p.Name)
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to stop instance %s: %v", p.Name, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if syscall.Kill(pid, 0) != nil {
+			os.Remove(p.pidFile())
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	syscall.Kill(pid, syscall.SIGKILL)
+	os.Remove(p.pidFile())
+	return fmt.Errorf("instance %s did not exit in %v, killed", p.Name, timeout)
+}
+
+// superviseInstance 前景監督單一實例，異常結束時自動重啟
+func superviseInstance(p *InstanceProfile, stop <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	log := logger.With("instance", p.Name)
+	backoff := time.Second
+	for {
+		cmd, logOut, err := p.command()
+		if err != nil {
+			log.Error("Failed to prepare instance", "err", err)
+			return
+		}
+
+		if err := cmd.Start(); err != nil {
+			logOut.Close()
+			log.Error("Failed to start instance", "err", err)
+			return
+		}
+		os.WriteFile(p.pidFile(), []byte(strconv.Itoa(cmd.Process.Pid)+"\n"), 0644)
+		log.Info("Instance started", "pid", cmd.Process.Pid)
+
+		done := make(chan error, 1)
+		started := time.Now()
+		go func() { done <- cmd.Wait() }()
+
+		select {
+		case <-stop:
+			cmd.Process.Signal(syscall.SIGTERM)
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				cmd.Process.Kill()
+				<-done
+			}
+			logOut.Close()
+			os.Remove(p.pidFile())
+			log.Info("Instance stopped")
+			return
+		case err := <-done:
+			logOut.Close()
+			os.Remove(p.pidFile())
+			// 穩定運行一段時間後重置退避時間
+			if time.Since(started) > time.Minute {
+				backoff = time.Second
+			}
+			log.Warn("Instance exited, restarting", "err", err, "backoff", backoff)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+// newInstanceCommand golane instance list|start|stop|supervise
+func newInstanceCommand() *Command {
+	return &Command{
+		Name:  "instance",
+		Short: "Manage multiple isolated instances from an instances file",
+		Sub: []*Command{
+			newInstanceActionCommand("list", "Show the status of all instances", listInstances),
+			newInstanceActionCommand("start", "Start instances in the background", startInstances),
+			newInstanceActionCommand("stop", "Stop background instances", stopInstances),
+			newInstanceActionCommand("supervise", "Run instances in the foreground and restart them on exit", superviseInstances),
+		},
+	}
+}
+
+// newInstanceActionCommand 建立 instance 子命令，位置參數為實例名稱 (未指定時為全部啟用的實例)
+func newInstanceActionCommand(name, short string, action func(set *InstanceSet, selected []*InstanceProfile) error) *Command {
+	fs := newFlagSet("instance " + name)
+	lf := addLogFlags(fs)
+	instancesFile := fs.String("instances", defaultInstancesFile, "instance profiles file")
+
+	return &Command{
+		Name:  name,
+		Short: short,
+		Args:  "[name...]",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			set, err := LoadInstanceSet(*instancesFile)
+			if err != nil {
+				return err
+			}
+			selected, err := set.selectInstances(args)
+			if err != nil {
+				return err
+			}
+			return action(set, selected)
+		},
+	}
+}
+
+func listInstances(set *InstanceSet, _ []*InstanceProfile) error {
+	fmt.Printf("%-16s %-8s %-8s %-24s %s\n", "NAME", "STATUS", "PID", "INTERFACES", "STATE DIR")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────")
+	for i := range set.Instances {
+		inst := &set.Instances[i]
+		status, pidText := "stopped", "-"
+		if pid, running := inst.RunningPID(); running {
+			status, pidText = "running", strconv.Itoa(pid)
+		} else if inst.Disabled {
+			status = "disabled"
+		}
+		fmt.Printf("%-16s %-8s %-8s %-24s %s\n",
+			inst.Name, status, pidText, strings.Join(inst.DanteIfaces, ","), inst.StateDir)
+	}
+	return nil
+}
+
+func startInstances(_ *InstanceSet, selected []*InstanceProfile) error {
+	for _, inst := range selected {
+		pid, err := inst.Start()
+		if err != nil {
+			logger.Warn("Instance not started", "instance", inst.Name, "err", err)
+			continue
+		}
+		logger.Info("Instance started", "instance", inst.Name, "pid", pid, "log", inst.logFile())
+	}
+	return nil
+}
+
+func stopInstances(_ *InstanceSet, selected []*InstanceProfile) error {
+	for _, inst := range selected {
+		if err := inst.Stop(10 * time.Second); err != nil {
+			logger.Warn("Instance not stopped cleanly", "instance", inst.Name, "err", err)
+			continue
+		}
+		logger.Info("Instance stopped", "instance", inst.Name)
+	}
+	return nil
+}
+
+func superviseInstances(_ *InstanceSet, selected []*InstanceProfile) error {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, inst := range selected {
+		wg.Add(1)
+		inst := inst
+		recovery.Go("instance/"+inst.Name, func() { superviseInstance(inst, stop, &wg) })
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+	logger.Info("Stopping all instances")
+	close(stop)
+	wg.Wait()
+	return nil
+}
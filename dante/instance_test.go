@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInstanceSetValidatePorts(t *testing.T) {
+	inst := func(name, iface, api string, args ...string) InstanceProfile {
+		return InstanceProfile{Name: name, DanteIfaces: []string{iface}, StateDir: "/var/lib/golane/" + name, APIAddr: api, Args: args}
+	}
+	tests := []struct {
+		name      string
+		instances []InstanceProfile
+		err       string // 空白表示通過
+	}{
+		{"distinct ports", []InstanceProfile{inst("a", "eth1", ":8080"), inst("b", "eth2", ":8081")}, ""},
+		{"no API", []InstanceProfile{inst("a", "eth1", ""), inst("b", "eth2", "")}, ""},
+		{"ephemeral ports", []InstanceProfile{inst("a", "eth1", "127.0.0.1:0"), inst("b", "eth2", "127.0.0.1:0")}, ""},
+		{"same addr", []InstanceProfile{inst("a", "eth1", ":8080"), inst("b", "eth2", ":8080")}, "instances a and b both listen on port tcp/8080"},
+		{"same port, other IP", []InstanceProfile{inst("a", "eth1", "0.0.0.0:8080"), inst("b", "eth2", "10.0.0.5:8080")}, "instances a and b both listen on port tcp/8080"},
+		{"API port in args", []InstanceProfile{inst("a", "eth1", ":8080"), inst("b", "eth2", "", "-api-addr", ":8080")}, "instances a and b both listen on port tcp/8080"},
+		{"SNMP port", []InstanceProfile{inst("a", "eth1", "", "-snmp=:161"), inst("b", "eth2", "", "--snmp", "10.0.0.5:161")}, "instances a and b both listen on port udp/161"},
+		{"TCP and UDP", []InstanceProfile{inst("a", "eth1", ":161"), inst("b", "eth2", "", "-snmp", ":161")}, ""},
+		{"bad addr", []InstanceProfile{inst("a", "eth1", "8080")}, "instance a: invalid listen address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&InstanceSet{Instances: tt.instances}).Validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.err != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.err)):
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package aes67
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// 串流目錄
+//==============================================================================
+
+// DefaultTimeout 超過此時間沒有收到公告的串流視為消失
+// (AES67 設備約每 30 秒公告一次，容許遺失數次)
+const DefaultTimeout = 5 * time.Minute
+
+// maxPacket SAP 訊息的大小上限
+const maxPacket = 4096
+
+// Directory 以收到的 SAP 公告維護目前的串流
+type Directory struct {
+	Timeout time.Duration // 0 表示 DefaultTimeout
+
+	mu      sync.Mutex
+	streams map[string]Stream // Stream.ID → 串流
+	now     func() time.Time
+}
+
+// NewDirectory 建立目錄
+func NewDirectory() *Directory {
+	return &Directory{streams: make(map[string]Stream), now: time.Now}
+}
+
+// Handle 處理一個 SAP 訊息 (iface 為收到的介面)
+func (d *Directory) Handle(iface string, packet []byte) error {
+	a, err := ParseSAP(packet)
+	if err != nil {
+		return err
+	}
+	s, err := ParseSDP(a.SDP)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if a.Delete {
+		delete(d.streams, s.ID)
+		return nil
+	}
+	now := d.now()
+	s.Interface = iface
+	s.Announcer = a.Origin
+	s.FirstSeen, s.LastSeen = now, now
+	if old, ok := d.streams[s.ID]; ok {
+		s.FirstSeen = old.FirstSeen
+	}
+	d.streams[s.ID] = s
+	return nil
+}
+
+// Streams 目前的串流 (依名稱排序，移除逾時的串流)
+func (d *Directory) Streams() []Stream {
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	result := make([]Stream, 0, len(d.streams))
+	for id, s := range d.streams {
+		if now.Sub(s.LastSeen) > timeout {
+			delete(d.streams, id)
+			continue
+		}
+		result = append(result, s)
+	}
+	slices.SortFunc(result, func(a, b Stream) int {
+		if c := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return result
+}
+
+// Listen 在介面上加入 SAP 群組並處理公告，直到 ctx 結束
+// 每個介面各自開啟 socket；同一台主機的多個 socket 都綁定 SAP 埠，
+// 兩個介面都收到同一個公告時以最後收到的介面為準
+func (d *Directory) Listen(ctx context.Context, iface string) error {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenMulticastUDP("udp4", ifi, &net.UDPAddr{IP: net.ParseIP(SAPGroup), Port: SAPPort})
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	log := slog.With("iface", iface)
+	log.Info("Listening for AES67 announcements", "group", SAPGroup, "port", SAPPort)
+	buf := make([]byte, maxPacket)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := d.Handle(iface, buf[:n]); err != nil {
+			log.Debug("Ignored SAP packet", "from", from, "err", err)
+		}
+	}
+}
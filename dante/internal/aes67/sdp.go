@@ -0,0 +1,227 @@
+// Package aes67 以 SAP/SDP 發現網路上的 AES67 串流
+package aes67
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// SAP (RFC 2974) 與 SDP (RFC 4566)
+//==============================================================================
+
+// AES67 設備 (包括開啟 AES67 模式的 Dante 設備) 定期以 SAP 在
+// 239.255.255.255:9875 發布每個串流的 SDP；結束串流時送出刪除訊息。
+// 這裡只解析列出串流需要的欄位，不處理加密或壓縮的公告。
+
+// SAP 公告的 multicast 位址 (AES67 使用 administratively scoped 的全域位址)
+const (
+	SAPGroup = "239.255.255.255"
+	SAPPort  = 9875
+)
+
+// sdpPayloadType SAP 的預設內容類型
+const sdpPayloadType = "application/sdp"
+
+var (
+	ErrNotSAP      = errors.New("not a SAP packet")
+	ErrUnsupported = errors.New("encrypted or compressed SAP announcement")
+	ErrNotSDP      = errors.New("SAP payload is not SDP")
+)
+
+// Stream 一個 AES67 串流
+type Stream struct {
+	ID          string        `json:"id"`                    // SDP origin (使用者、session id、來源地址)
+	Name        string        `json:"name"`                  // s=
+	Info        string        `json:"info,omitempty"`        // i=
+	Source      string        `json:"source"`                // 發送端地址 (o= 的地址)
+	Multicast   string        `json:"multicast"`             // 串流目的地址 (c=)
+	Port        int           `json:"port"`                  // RTP 埠
+	TTL         int           `json:"ttl,omitempty"`         // multicast TTL
+	Encoding    string        `json:"encoding"`              // L16、L24、AM824
+	SampleRate  int           `json:"sample_rate"`           // Hz
+	Channels    int           `json:"channels"`              // 聲道數
+	PacketTime  time.Duration `json:"packet_time"`           // a=ptime
+	PTPClock    string        `json:"ptp_clock,omitempty"`   // a=ts-refclk 的 grandmaster (AES67 必須為 PTP)
+	PTPDomain   int           `json:"ptp_domain"`            // PTP domain
+	MediaClock  string        `json:"media_clock,omitempty"` // a=mediaclk
+	Interface   string        `json:"interface,omitempty"`   // 收到公告的介面
+	FirstSeen   time.Time     `json:"first_seen"`
+	LastSeen    time.Time     `json:"last_seen"`
+	Announcer   string        `json:"announcer,omitempty"`   // SAP 的發送地址 (通常與 Source 相同)
+	Unsupported string        `json:"unsupported,omitempty"` // 不符合 AES67 的原因 (仍列出)
+}
+
+// Announcement 一個 SAP 訊息
+type Announcement struct {
+	Delete bool   // 刪除訊息 (串流結束)
+	Hash   uint16 // 訊息 id
+	Origin string // SAP 發送地址
+	SDP    string
+}
+
+// ParseSAP 解析 SAP 訊息
+func ParseSAP(packet []byte) (Announcement, error) {
+	if len(packet) < 8 || packet[0]>>5 != 1 {
+		return Announcement{}, ErrNotSAP
+	}
+	flags := packet[0]
+	ipv6 := flags&0x10 != 0
+	if flags&0x02 != 0 || flags&0x01 != 0 {
+		return Announcement{}, ErrUnsupported
+	}
+
+	a := Announcement{Delete: flags&0x04 != 0, Hash: binary.BigEndian.Uint16(packet[2:4])}
+	pos := 4
+	if ipv6 {
+		if len(packet) < pos+16 {
+			return Announcement{}, ErrNotSAP
+		}
+		a.Origin = net.IP(packet[pos : pos+16]).String()
+		pos += 16
+	} else {
+		a.Origin = net.IP(packet[pos : pos+4]).String()
+		pos += 4
+	}
+	pos += int(packet[1]) * 4 // 驗證資料
+	if pos > len(packet) {
+		return Announcement{}, ErrNotSAP
+	}
+
+	payload := packet[pos:]
+	// 內容類型可省略 (內容直接以 v=0 開始)
+	if !strings.HasPrefix(string(payload), "v=0") {
+		end := strings.IndexByte(string(payload), 0)
+		if end < 0 {
+			return Announcement{}, ErrNotSDP
+		}
+		if typ := string(payload[:end]); typ != sdpPayloadType {
+			return Announcement{}, fmt.Errorf("%w (%s)", ErrNotSDP, typ)
+		}
+		payload = payload[end+1:]
+	}
+	a.SDP = string(payload)
+	return a, nil
+}
+
+// ParseSDP 解析串流的 SDP (只使用第一個 audio media)
+// 刪除訊息可能只有 o= 行，此時只有 ID 與 Source
+func ParseSDP(sdp string) (Stream, error) {
+	var s Stream
+	var payloadType string
+	inAudio, seenMedia := false, false
+	for _, line := range strings.Split(sdp, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if len(line) < 2 || line[1] != '=' {
+			continue
+		}
+		key, value := line[0], line[2:]
+
+		// 第一個 audio media 之後的其他 media 不處理
+		if key == 'm' {
+			if seenMedia {
+				inAudio = false
+				continue
+			}
+			seenMedia = true
+			fields := strings.Fields(value)
+			if len(fields) < 4 || fields[0] != "audio" {
+				continue
+			}
+			inAudio = true
+			s.Port, _ = strconv.Atoi(fields[1])
+			payloadType = fields[3]
+			continue
+		}
+		if seenMedia && !inAudio {
+			continue
+		}
+
+		switch key {
+		case 'o':
+			fields := strings.Fields(value)
+			if len(fields) != 6 {
+				return Stream{}, fmt.Errorf("invalid SDP origin %q", value)
+			}
+			s.ID = fields[0] + " " + fields[1] + " " + fields[5]
+			s.Source = fields[5]
+		case 's':
+			s.Name = strings.TrimSpace(value)
+		case 'i':
+			s.Info = strings.TrimSpace(value)
+		case 'c':
+			// media 的 c= 優先於 session 的 c=
+			fields := strings.Fields(value)
+			if len(fields) == 3 {
+				addr, ttl, _ := strings.Cut(fields[2], "/")
+				s.Multicast = addr
+				s.TTL, _ = strconv.Atoi(strings.SplitN(ttl, "/", 2)[0])
+			}
+		case 'a':
+			s.attribute(value, payloadType)
+		}
+	}
+	if s.ID == "" {
+		return Stream{}, errors.New("SDP without origin")
+	}
+	s.Unsupported = s.check()
+	return s, nil
+}
+
+// attribute 處理 a= 行
+func (s *Stream) attribute(value, payloadType string) {
+	name, arg, _ := strings.Cut(value, ":")
+	switch name {
+	case "rtpmap":
+		pt, format, ok := strings.Cut(arg, " ")
+		if !ok || pt != payloadType {
+			return
+		}
+		parts := strings.Split(format, "/")
+		s.Encoding = parts[0]
+		if len(parts) > 1 {
+			s.SampleRate, _ = strconv.Atoi(parts[1])
+		}
+		s.Channels = 1
+		if len(parts) > 2 {
+			s.Channels, _ = strconv.Atoi(parts[2])
+		}
+	case "ptime":
+		if ms, err := strconv.ParseFloat(arg, 64); err == nil {
+			s.PacketTime = time.Duration(ms * float64(time.Millisecond))
+		}
+	case "ts-refclk":
+		// ptp=IEEE1588-2008:00-1D-C1-FF-FE-12-34-56:0
+		if clock, ok := strings.CutPrefix(arg, "ptp="); ok {
+			parts := strings.Split(clock, ":")
+			if len(parts) > 1 {
+				s.PTPClock = parts[1]
+			}
+			if len(parts) > 2 {
+				s.PTPDomain, _ = strconv.Atoi(parts[2])
+			}
+		}
+	case "mediaclk":
+		s.MediaClock = arg
+	}
+}
+
+// check AES67 的基本要求 (不符合時仍列出，讓操作人員看到原因)
+func (s *Stream) check() string {
+	switch {
+	case s.Multicast == "" || s.Port == 0:
+		return "no audio media"
+	case s.Encoding != "L16" && s.Encoding != "L24" && s.Encoding != "AM824":
+		return fmt.Sprintf("encoding %q", s.Encoding)
+	case s.SampleRate != 48000 && s.SampleRate != 96000 && s.SampleRate != 44100:
+		return fmt.Sprintf("sample rate %d", s.SampleRate)
+	case s.PTPClock == "":
+		return "no PTP reference clock"
+	}
+	return ""
+}
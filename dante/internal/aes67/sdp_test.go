@@ -0,0 +1,102 @@
+package aes67
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// 開啟 AES67 模式的 Dante 設備送出的 SDP
+const danteSDP = "v=0\r\n" +
+	"o=- 1423986 1423994 IN IP4 192.168.1.41\r\n" +
+	"s=AVIO-AI2 : 32\r\n" +
+	"c=IN IP4 239.69.161.58/32\r\n" +
+	"t=0 0\r\n" +
+	"a=keywds:Dante\r\n" +
+	"m=audio 5004 RTP/AVP 97\r\n" +
+	"i=2 channels: In 1, In 2\r\n" +
+	"a=recvonly\r\n" +
+	"a=rtpmap:97 L24/48000/2\r\n" +
+	"a=ptime:1\r\n" +
+	"a=ts-refclk:ptp=IEEE1588-2008:00-1D-C1-FF-FE-0A-1B-2C:0\r\n" +
+	"a=mediaclk:direct=0\r\n"
+
+// sapPacket 組成 SAP 訊息
+func sapPacket(flags byte, withType bool, sdp string) []byte {
+	packet := []byte{0x20 | flags, 0, 0x12, 0x34, 192, 168, 1, 41}
+	if withType {
+		packet = append(packet, "application/sdp\x00"...)
+	}
+	return append(packet, sdp...)
+}
+
+func TestParseDanteAnnouncement(t *testing.T) {
+	a, err := ParseSAP(sapPacket(0, true, danteSDP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Delete || a.Hash != 0x1234 || a.Origin != "192.168.1.41" {
+		t.Fatalf("SAP header: %+v", a)
+	}
+	s, err := ParseSDP(a.SDP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Stream{
+		ID: "- 1423986 192.168.1.41", Name: "AVIO-AI2 : 32", Info: "2 channels: In 1, In 2",
+		Source: "192.168.1.41", Multicast: "239.69.161.58", Port: 5004, TTL: 32,
+		Encoding: "L24", SampleRate: 48000, Channels: 2, PacketTime: time.Millisecond,
+		PTPClock: "00-1D-C1-FF-FE-0A-1B-2C", MediaClock: "direct=0",
+	}
+	if s != want {
+		t.Fatalf("stream:\n got %+v\nwant %+v", s, want)
+	}
+}
+
+func TestParseSAPErrors(t *testing.T) {
+	if _, err := ParseSAP([]byte("v=0")); !errors.Is(err, ErrNotSAP) {
+		t.Fatalf("short packet: %v", err)
+	}
+	if _, err := ParseSAP(sapPacket(0x02, true, danteSDP)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("encrypted: %v", err)
+	}
+	packet := append([]byte{0x20, 0, 0, 1, 10, 0, 0, 1}, "text/plain\x00hello"...)
+	if _, err := ParseSAP(packet); !errors.Is(err, ErrNotSDP) {
+		t.Fatalf("text payload: %v", err)
+	}
+
+	// 不符合 AES67 的串流仍列出並說明原因
+	s, err := ParseSDP("v=0\no=- 1 1 IN IP4 10.0.0.9\ns=Video\nc=IN IP4 239.1.1.1/15\nm=video 5004 RTP/AVP 96\n")
+	if err != nil || s.Unsupported != "no audio media" {
+		t.Fatalf("video stream: %+v, %v", s, err)
+	}
+}
+
+func TestDirectoryAnnounceDeleteExpire(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := NewDirectory()
+	d.now = func() time.Time { return now }
+
+	// 內容類型省略時也接受
+	if err := d.Handle("eth1", sapPacket(0, false, danteSDP)); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Second)
+	d.Handle("eth1", sapPacket(0, true, danteSDP))
+	streams := d.Streams()
+	if len(streams) != 1 || streams[0].Interface != "eth1" || !streams[0].LastSeen.After(streams[0].FirstSeen) {
+		t.Fatalf("streams: %+v", streams)
+	}
+
+	// 刪除訊息只有 o= 行
+	d.Handle("eth1", sapPacket(0x04, true, "v=0\r\no=- 1423986 1423994 IN IP4 192.168.1.41\r\n"))
+	if streams := d.Streams(); len(streams) != 0 {
+		t.Fatalf("stream not deleted: %+v", streams)
+	}
+
+	d.Handle("eth1", sapPacket(0, true, danteSDP))
+	now = now.Add(DefaultTimeout + time.Second)
+	if streams := d.Streams(); len(streams) != 0 {
+		t.Fatalf("stream not expired: %+v", streams)
+	}
+}
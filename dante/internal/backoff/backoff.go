@@ -0,0 +1,96 @@
+// Package backoff 指數退避與重試
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+//==============================================================================
+// 指數退避
+//==============================================================================
+
+// ErrStopped 重試期間收到結束通知
+var ErrStopped = errors.New("stopped")
+
+// Policy 指數退避設定
+type Policy struct {
+	Initial     time.Duration // 第一次失敗後的等待時間
+	Max         time.Duration // 等待時間上限 (每次失敗加倍，0 表示不設上限)
+	MaxAttempts int           // 最多嘗試次數 (0 表示無限重試)
+	Jitter      float64       // 等待時間隨機增減的比例 (0.2 為 ±20%，避免多個網域同時重試)
+}
+
+// Delay 第 attempt 次失敗後的等待時間 (attempt 從 1 開始)
+func (p Policy) Delay(attempt int) time.Duration {
+	delay := p.Initial
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.Max > 0 && delay >= p.Max {
+			delay = p.Max
+			break
+		}
+	}
+	if p.Max > 0 && delay > p.Max {
+		delay = p.Max
+	}
+	return p.jitter(delay)
+}
+
+// jitter 依 Jitter 隨機調整等待時間
+func (p Policy) jitter(delay time.Duration) time.Duration {
+	if p.Jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	return delay + time.Duration((rand.Float64()*2-1)*p.Jitter*float64(delay))
+}
+
+// Exhausted 是否已用完嘗試次數
+func (p Policy) Exhausted(attempt int) bool {
+	return p.MaxAttempts > 0 && attempt >= p.MaxAttempts
+}
+
+// permanentError 不應重試的錯誤
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 標記重試也不會成功的錯誤 (設定錯誤、未授權)，Retry 立即回傳原本的錯誤
+func Permanent(err error) error {
+	return &permanentError{err}
+}
+
+// Retry 執行 fn 直到成功、用完嘗試次數或 ctx 結束 (回傳包含 ErrStopped 與 ctx.Err() 的錯誤)
+// onRetry 在每次失敗、等待前呼叫；fn 回傳 Permanent 的錯誤時不再重試
+func Retry(ctx context.Context, p Policy, onRetry func(attempt int, err error, delay time.Duration), fn func(attempt int) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if p.Exhausted(attempt) {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		delay := p.Delay(attempt)
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrStopped, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package bus 行程內的事件發布/訂閱 (daemon 與嵌入的函式庫共用)
+package bus
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//==============================================================================
+// 事件匯流排
+//==============================================================================
+
+// 網域工作把設備列表、上下線、訂閱變更、告警與網域失敗發布到 Bus。daemon 以
+// /api/events (WebSocket) 轉送給遠端，嵌入 golane 套件的程式直接 Subscribe，
+// 兩者收到相同的 Event。
+// 發布端是網域工作，不能被慢的訂閱者拖住：訂閱者的緩衝滿時丟棄事件並
+// 計入 Dropped，需要完整狀態的訂閱者應在丟棄後重新讀取登錄 (設備列表)。
+
+// 事件主題
+const (
+	TopicDevices       = "devices"        // 網域的完整設備列表 (每次刷新)
+	TopicDeviceOnline  = "device-online"  // 設備上線
+	TopicDeviceOffline = "device-offline" // 設備離線
+	TopicRoute         = "route"          // 訂閱變更 (成功送出的 Subscribe)
+	TopicAlert         = "alert"          // 告警通知
+	TopicAlarm         = "alarm"          // 具名告警發出或清除 (告警規則)
+	TopicDomainFailed  = "domain-failed"  // 網域工作失敗 (初始化失敗、SDK 錯誤)
+	TopicFirmware      = "firmware"       // 韌體升級的狀態或進度改變
+)
+
+// DefaultBuffer 訂閱者預設的緩衝事件數
+const DefaultBuffer = 64
+
+// Event 一則事件
+type Event struct {
+	Topic   string    `json:"topic"`
+	Domain  string    `json:"domain,omitempty"`  // 網域 (全域事件為空白)
+	Subject string    `json:"subject,omitempty"` // 對象 (設備名稱、告警種類)
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data,omitempty"` // 主題的內容 (可序列化為 JSON)
+}
+
+// Bus 事件匯流排 (零值不可用，請用 New)
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// New 建立事件匯流排
+func New() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription 一個訂閱者
+type Subscription struct {
+	C <-chan Event // 事件 (Close 後關閉)
+
+	bus     *Bus
+	ch      chan Event
+	topics  []string
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Subscribe 訂閱主題 (topics 為空時訂閱全部)，buffer <= 0 時使用 DefaultBuffer
+func (b *Bus) Subscribe(buffer int, topics ...string) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	ch := make(chan Event, buffer)
+	s := &Subscription{C: ch, bus: b, ch: ch, topics: slices.Clone(topics)}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+// Publish 送出事件給所有訂閱該主題的訂閱者 (不等待)
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if len(s.topics) > 0 && !slices.Contains(s.topics, e.Topic) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribers 目前的訂閱者數
+func (b *Bus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Dropped 緩衝滿而丟棄的事件數
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close 取消訂閱並關閉 C (可重複呼叫)
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		close(s.ch)
+	})
+}
@@ -0,0 +1,32 @@
+package bus
+
+import "testing"
+
+func TestBusTopicsAndSlowSubscriber(t *testing.T) {
+	b := New()
+	all := b.Subscribe(0)
+	routes := b.Subscribe(1, TopicRoute)
+	defer all.Close()
+
+	b.Publish(Event{Topic: TopicDevices, Domain: "Dante1"})
+	b.Publish(Event{Topic: TopicRoute, Domain: "Dante1", Subject: "Amp-Left"})
+	b.Publish(Event{Topic: TopicRoute, Domain: "Dante1", Subject: "Amp-Right"})
+
+	if e := <-all.C; e.Topic != TopicDevices || e.Time.IsZero() {
+		t.Fatalf("first event = %+v", e)
+	}
+	// 緩衝只有 1：第二則訂閱變更被丟棄，發布端沒有被擋住
+	if e := <-routes.C; e.Subject != "Amp-Left" || routes.Dropped() != 1 {
+		t.Fatalf("route event %+v, dropped %d", e, routes.Dropped())
+	}
+
+	routes.Close()
+	routes.Close()
+	if _, ok := <-routes.C; ok {
+		t.Fatal("channel open after Close")
+	}
+	if b.Subscribers() != 1 {
+		t.Fatalf("Subscribers() = %d, want 1", b.Subscribers())
+	}
+	b.Publish(Event{Topic: TopicRoute})
+}
@@ -0,0 +1,188 @@
+// Package cron 解析 cron 表示式並計算下一次執行時間
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// cron 表示式
+//==============================================================================
+
+// 標準的五個欄位：分 時 日 月 週 (0-7，0 與 7 都是週日)，每個欄位可用
+// *、數字、範圍 (1-5)、間隔 (*/15、8-18/2) 與逗號分隔的列表；月與週也可以用
+// 英文縮寫 (jan、mon)。日與週都有限制 (不以 * 開頭) 時符合其中一個即可
+// (與 Vixie cron 相同)。
+// 另外支援 @yearly、@monthly、@weekly、@daily 與 @hourly。
+
+// maxSearchYears Next 最多往後找的年數 (例如 2 月 30 日永遠不會發生)
+const maxSearchYears = 5
+
+// macros 常用排程的簡寫
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field 一個欄位的範圍
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = [5]field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// Schedule 解析後的 cron 表示式
+type Schedule struct {
+	expr string
+	bits [5]uint64 // 各欄位允許的值
+	star [5]bool   // 欄位以 * 開頭 (*、*/n)
+}
+
+// Parse 解析 cron 表示式
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if m, ok := macros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron %q: want 5 fields (minute hour day month weekday), got %d", expr, len(parts))
+	}
+	s := &Schedule{expr: expr}
+	for i, part := range parts {
+		bits, err := fields[i].parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %s: %v", expr, fields[i].name, err)
+		}
+		s.bits[i], s.star[i] = bits, strings.HasPrefix(part, "*")
+	}
+	// 7 也是週日
+	if s.bits[4]&(1<<7) != 0 {
+		s.bits[4] = s.bits[4]&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parse 解析一個欄位
+func (f field) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepSpec)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangeSpec == "*":
+		case strings.Contains(rangeSpec, "-"):
+			from, to, _ := strings.Cut(rangeSpec, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(to); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("range %q goes backwards", rangeSpec)
+			}
+		default:
+			v, err := f.value(rangeSpec)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v // 5/10 表示從 5 開始每 10
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value 解析一個數字或名稱
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String 原始的表示式
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next after 之後 (不含) 第一個符合的時間，以 after 的時區計算；
+// maxSearchYears 內都不會發生時回傳零值
+func (s *Schedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Year() + maxSearchYears
+	for t.Year() <= limit {
+		switch {
+		case !s.match(3, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !s.match(1, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !s.match(0, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// match 欄位 i 是否允許 v
+func (s *Schedule) match(i, v int) bool {
+	return s.bits[i]&(1<<v) != 0
+}
+
+// dayMatches 日與週：其中一個為 * 時兩者都要符合，都有限制時符合其一即可
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := s.match(2, t.Day()), s.match(4, int(t.Weekday()))
+	if s.star[2] || s.star[4] {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,45 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// 2026-03-06 是週五
+	from := time.Date(2026, 3, 6, 22, 15, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"30 22 * * *", time.Date(2026, 3, 6, 22, 30, 0, 0, time.UTC)},
+		{"15 22 * * *", time.Date(2026, 3, 7, 22, 15, 0, 0, time.UTC)}, // 不含 from 本身
+		{"*/20 * * * *", time.Date(2026, 3, 6, 22, 20, 0, 0, time.UTC)},
+		{"0 8-18/2 * * mon-fri", time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 6, 23, 0, 0, 0, time.UTC)},
+		// 日與週都有限制時符合其一：10 日或週一
+		{"0 12 10 * 1", time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) accepted", expr)
+		}
+	}
+}
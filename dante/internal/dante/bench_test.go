@@ -0,0 +1,93 @@
+package dante
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+)
+
+// 效能基準：大型網路 (數百台設備) 上每次刷新與每個事件 tick 的成本。
+// 以模擬 SDK 量測 Go 這一側 (重試、鎖、列表複製)，cgo 的解碼見
+// dante_cgo_bench_test.go。比較前後版本：
+//
+//	go test -tags nodante -run '^$' -bench . -benchmem -count 10 ./internal/dante/ > new.txt
+//	benchstat old.txt new.txt
+
+// benchDeviceCounts 基準測試的設備數
+var benchDeviceCounts = []int{10, 100, 500}
+
+// benchDomain 已開始掃描、有 n 台模擬設備的網域
+func benchDomain(b *testing.B, n int) *Domain {
+	b.Helper()
+	cfg := &SimulationConfig{Interface: "sim0"}
+	for i := 0; i < n; i++ {
+		cfg.Devices = append(cfg.Devices, SimulatedDevice{
+			Name:       "Stage-Box-" + strconv.Itoa(i+1),
+			Model:      "Ultimo X4",
+			IPAddress:  fmt.Sprintf("10.1.%d.%d", i/250, i%250+1),
+			TxChannels: 4,
+			RxChannels: 4,
+		})
+	}
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), NewSimulatedSDK(cfg))
+	d.log = slog.New(slog.NewTextHandler(io.Discard, nil)) // 每次刷新的 Info 日誌不計入
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(d.Cleanup)
+	if err := d.StartDeviceScan(ctx); err != nil {
+		b.Fatal(err)
+	}
+	d.RefreshDevices(ctx)
+	if got := len(d.GetDevices()); got != n {
+		b.Fatalf("discovered %d devices, want %d", got, n)
+	}
+	return d
+}
+
+// BenchmarkRefreshCycle 一次定期刷新：重新掃描並讀取整個設備列表
+func BenchmarkRefreshCycle(b *testing.B) {
+	for _, n := range benchDeviceCounts {
+		b.Run(fmt.Sprintf("devices=%d", n), func(b *testing.B) {
+			d := benchDomain(b, n)
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				d.RefreshDevices(ctx)
+				if len(d.GetDevices()) != n {
+					b.Fatal("device list changed")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEventLoopTick 事件循環的一個 tick (處理 SDK 事件並檢查變更計數)
+func BenchmarkEventLoopTick(b *testing.B) {
+	for _, n := range benchDeviceCounts {
+		b.Run(fmt.Sprintf("devices=%d", n), func(b *testing.B) {
+			d := benchDomain(b, n)
+			ctx := context.Background()
+			seen := d.processEvents(ctx, 0)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				seen = d.processEvents(ctx, seen)
+			}
+		})
+	}
+}
+
+// BenchmarkSDKThreadCall 每個原生 SDK 呼叫切換到鎖定 OS thread 的成本
+func BenchmarkSDKThreadCall(b *testing.B) {
+	var t sdkThread
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		t.call(func() int { return 0 })
+	}
+}
@@ -0,0 +1,731 @@
+//go:build !nodante
+
+package dante
+
+/*
+#cgo CFLAGS: -I${SRCDIR}/../../include/audinate -I${SRCDIR}/../../include
+#cgo LDFLAGS: -L${SRCDIR}/../../lib -ldapi -L${SRCDIR}/../../redist -ldns_sd -lcurl -ljansson -lssl -lcrypto -lz -ldl -lpthread -lstdc++ -lm
+
+#include <stdlib.h>
+
+// Dante API 基礎函數聲明
+int dante_init(void);
+int dante_init_with_interface(const char* interface_name);
+void dante_cleanup(void);
+const char* dante_get_last_error(void);
+const char* dante_get_sdk_version(void);
+
+// 靜態連結的函式庫回報版本的函數 (標頭檔不在 include 中，直接宣告)
+char* curl_version(void);
+const char* OpenSSL_version(int type);
+const char* zlibVersion(void);
+int dante_connect_local_device(void);
+int dante_is_device_connected(void);
+int dante_get_device_name(char* buffer, int buffer_size);
+int dante_get_tx_channel_count(void);
+int dante_get_rx_channel_count(void);
+int dante_get_tx_channel_name(int channel_index, char* buffer, int buffer_size);
+int dante_run_basic_test(void);
+
+// 設備掃描函數
+int dante_start_device_scan(void);
+int dante_stop_device_scan(void);
+int dante_get_discovered_device_count(void);
+int dante_refresh_device_scan(void);
+int dante_process_events_briefly(void);
+int dante_get_current_device_list(void);
+int dante_get_change_count(void);
+
+// 設備資訊結構
+struct dante_device_info_t {
+    int id;
+    char name[64];
+    char model[64];
+    char product_version[32];
+    char dante_version[32];
+    char ip_address[16];
+    int link_speed;
+    char secondary_ip[16];
+    int secondary_speed;
+    char mac_address[18];
+    int tx_channels;
+    int rx_channels;
+    char manufacturer[64];
+    char serial[20];
+    int capabilities;
+    int is_valid;
+};
+
+#define DANTE_DEVICE_CAP_REDUNDANCY 0x1
+#define DANTE_DEVICE_CAP_AES67      0x2
+#define DANTE_DEVICE_CAP_LOCKABLE   0x4
+#define DANTE_DEVICE_CAP_METERING   0x8
+
+int dante_get_device_info(int index, struct dante_device_info_t* info);
+int dante_get_device_list(struct dante_device_info_t* list, int max_count);
+
+// 接收通道訂閱資訊
+struct dante_subscription_info_t {
+    int id;
+    char name[64];
+    char tx_channel[64];
+    char tx_device[64];
+    int status;
+};
+
+// 路由訂閱函數
+int dante_route_list(const char* rx_device, struct dante_subscription_info_t* list, int max_count);
+int dante_route_subscribe(const char* rx_device, const char* rx_channel, const char* tx_device, const char* tx_channel);
+
+// 設備時鐘狀態
+struct dante_clock_info_t {
+    char device[64];
+    char clock_state[32];
+    char servo_state[32];
+    char clock_source[32];
+    int is_grandmaster;
+    long long updated;
+};
+
+// ConMon 監控函數
+int dante_monitor_start(void);
+int dante_monitor_watch_device(const char* device);
+int dante_get_clock_info(const char* device, struct dante_clock_info_t* info);
+int dante_identify_device(const char* device);
+
+// 韌體升級狀態
+struct dante_upgrade_info_t {
+    char device[64];
+    int state;
+    int last_error;
+    char error[64];
+    long long progress_curr;
+    long long progress_total;
+    long long updated;
+};
+
+int dante_firmware_upgrade(const char* device, int protocol, const char* server, int port, const char* path);
+int dante_get_upgrade_status(const char* device, struct dante_upgrade_info_t* info);
+
+// 接收 flow 錯誤統計
+#define DANTE_MAX_FLOW_INTERFACES 2
+struct dante_rxflow_stats_t {
+    int id;
+    char name[32];
+    char tx_device[64];
+    char tx_flow[32];
+    int multicast;
+    int fields;
+    int num_interfaces;
+    unsigned int early[DANTE_MAX_FLOW_INTERFACES];
+    unsigned int late[DANTE_MAX_FLOW_INTERFACES];
+    unsigned int out_of_order[DANTE_MAX_FLOW_INTERFACES];
+    unsigned int dropped[DANTE_MAX_FLOW_INTERFACES];
+    unsigned int max_latency_us[DANTE_MAX_FLOW_INTERFACES];
+};
+
+int dante_rx_flow_stats(const char* device, struct dante_rxflow_stats_t* list, int max_count);
+
+// 通道電平
+#define DANTE_MAX_METER_CHANNELS 512
+struct dante_meter_info_t {
+    char device[64];
+    int num_tx;
+    int num_rx;
+    unsigned char tx_peak[DANTE_MAX_METER_CHANNELS];
+    unsigned char rx_peak[DANTE_MAX_METER_CHANNELS];
+    long long updated;
+};
+
+int dante_monitor_watch_meters(const char* device);
+int dante_get_meters(const char* device, struct dante_meter_info_t* info);
+
+// 通道名稱 (發送或接收)
+struct dante_channel_info_t {
+    int id;
+    char name[64];
+};
+
+// 設備設定
+struct dante_device_settings_t {
+    int sample_rate;
+    int rx_latency_us;
+};
+
+// 設備設定函數
+int dante_tx_channel_list(const char* device, struct dante_channel_info_t* list, int max_count);
+int dante_get_device_settings(const char* device, struct dante_device_settings_t* settings);
+int dante_rename_device(const char* device, const char* new_name);
+int dante_set_channel_name(const char* device, int is_tx, int channel_id, const char* name);
+int dante_set_rx_latency(const char* device, int latency_us);
+int dante_set_sample_rate(const char* device, int sample_rate);
+
+// DDM 註冊狀態
+struct dante_enrollment_info_t {
+    int managed;
+    char domain[128];
+    char read_only[128];
+};
+
+int dante_get_device_enrollment(const char* device, struct dante_enrollment_info_t* info);
+
+// 發送 flow
+struct dante_flow_info_t {
+    int id;
+    char name[32];
+    int multicast;
+    int manual;
+    char address[16];
+    int port;
+    int fpp;
+    int latency_us;
+    int sample_rate;
+    int num_slots;
+    int channels[64];
+};
+
+struct dante_flow_config_t {
+    char name[32];
+    char address[16];
+    int port;
+    int packet_time_us;
+    int num_channels;
+    int channels[64];
+};
+
+int dante_tx_flow_list(const char* device, struct dante_flow_info_t* list, int max_count);
+int dante_create_multicast_flow(const char* device, const struct dante_flow_config_t* config);
+int dante_delete_tx_flow(const char* device, int flow_id);
+
+// 保持開啟的遠端設備連線
+int dante_device_open(const char* device);
+int dante_device_close(const char* device);
+*/
+import "C"
+
+import (
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+//==============================================================================
+// Dante SDK 綁定 (cgo)
+//==============================================================================
+
+// 每個 dante_* C 函數對應一個 Go 函數，回傳值維持 C 的慣例 (0 成功，負數失敗)。
+// 以 -tags nodante 建置時改用 dante_stub.go 的純 Go 實作，
+// 不需要 libdapi 與 Audinate 標頭檔就能編譯與測試。
+
+// cStringBufferSize 讀取字串輸出參數的緩衝區大小
+const cStringBufferSize = 256
+
+func danteInit() int {
+	return int(C.dante_init())
+}
+
+func danteInitWithInterface(interfaceName string) int {
+	cName := C.CString(interfaceName)
+	defer C.free(unsafe.Pointer(cName))
+	return int(C.dante_init_with_interface(cName))
+}
+
+func danteCleanup() {
+	C.dante_cleanup()
+}
+
+func danteGetLastError() string {
+	return C.GoString(C.dante_get_last_error())
+}
+
+func danteGetSDKVersion() string {
+	return C.GoString(C.dante_get_sdk_version())
+}
+
+func danteLibraryVersions() map[string]string {
+	return map[string]string{
+		"libcurl": C.GoString(C.curl_version()),
+		"openssl": C.GoString(C.OpenSSL_version(0)), // OPENSSL_VERSION
+		"zlib":    C.GoString(C.zlibVersion()),
+	}
+}
+
+func danteConnectLocalDevice() int {
+	return int(C.dante_connect_local_device())
+}
+
+func danteIsDeviceConnected() int {
+	return int(C.dante_is_device_connected())
+}
+
+func danteGetDeviceName() (string, int) {
+	var buf [cStringBufferSize]C.char
+	result := C.dante_get_device_name(&buf[0], C.int(len(buf)))
+	return C.GoString(&buf[0]), int(result)
+}
+
+func danteGetTxChannelCount() int {
+	return int(C.dante_get_tx_channel_count())
+}
+
+func danteGetRxChannelCount() int {
+	return int(C.dante_get_rx_channel_count())
+}
+
+func danteGetTxChannelName(channelIndex int) (string, int) {
+	var buf [cStringBufferSize]C.char
+	result := C.dante_get_tx_channel_name(C.int(channelIndex), &buf[0], C.int(len(buf)))
+	return C.GoString(&buf[0]), int(result)
+}
+
+func danteRunBasicTest() int {
+	return int(C.dante_run_basic_test())
+}
+
+func danteStartDeviceScan() int {
+	return int(C.dante_start_device_scan())
+}
+
+func danteStopDeviceScan() int {
+	return int(C.dante_stop_device_scan())
+}
+
+func danteGetDiscoveredDeviceCount() int {
+	return int(C.dante_get_discovered_device_count())
+}
+
+func danteRefreshDeviceScan() int {
+	return int(C.dante_refresh_device_scan())
+}
+
+func danteProcessEventsBriefly() int {
+	return int(C.dante_process_events_briefly())
+}
+
+func danteGetCurrentDeviceList() int {
+	return int(C.dante_get_current_device_list())
+}
+
+func danteGetChangeCount() int {
+	return int(C.dante_get_change_count())
+}
+
+func danteGetDeviceInfo(index int) (Device, int) {
+	var cInfo C.struct_dante_device_info_t
+	if result := C.dante_get_device_info(C.int(index), &cInfo); result != 0 {
+		return Device{}, int(result)
+	}
+	return decodeDeviceInfo(&cInfo), 0
+}
+
+// danteGetDeviceList 一次讀取最多 maxCount 台設備，回傳的 int 為設備數，負數表示失敗
+func danteGetDeviceList(maxCount int) ([]Device, int) {
+	if maxCount <= 0 {
+		return nil, 0
+	}
+
+	list := make([]C.struct_dante_device_info_t, maxCount)
+	count := int(C.dante_get_device_list(&list[0], C.int(len(list))))
+	if count < 0 {
+		return nil, count
+	}
+
+	return decodeDeviceList(list[:count]), count
+}
+
+// decodeDeviceList 把 dante_get_device_list 填入的設備資訊轉成 Device
+func decodeDeviceList(list []C.struct_dante_device_info_t) []Device {
+	devices := make([]Device, 0, len(list))
+	for i := range list {
+		devices = append(devices, decodeDeviceInfo(&list[i]))
+	}
+	return devices
+}
+
+// sampleDeviceInfos 產生 n 台設備的 C 設備資訊，字串欄位填滿常見長度
+// (基準測試不需要 SDK 與網路就能量測 decodeDeviceList)
+func sampleDeviceInfos(n int) []C.struct_dante_device_info_t {
+	list := make([]C.struct_dante_device_info_t, n)
+	for i := range list {
+		info := &list[i]
+		num := strconv.Itoa(i + 1)
+		info.id = C.int(i + 1)
+		putCString(info.name[:], "Stage-Box-"+num)
+		putCString(info.model[:], "Ultimo X4")
+		putCString(info.product_version[:], "4.2.1.3")
+		putCString(info.dante_version[:], "4.2.1.3")
+		putCString(info.ip_address[:], "169.254."+strconv.Itoa(i/250)+"."+strconv.Itoa(i%250+1))
+		putCString(info.mac_address[:], "00:1d:c1:00:00:00")
+		putCString(info.manufacturer[:], "Audinate")
+		putCString(info.serial[:], "001dc1fffe0000"+num)
+		info.link_speed = 1000
+		info.secondary_speed = -1
+		info.tx_channels, info.rx_channels = 4, 4
+		info.capabilities = C.DANTE_DEVICE_CAP_AES67 | C.DANTE_DEVICE_CAP_METERING
+		info.is_valid = 1
+	}
+	return list
+}
+
+// putCString 把 s 複製到固定長度的 C 字元陣列 (超過時截斷，保留結尾的 NUL)
+func putCString(dst []C.char, s string) {
+	n := copy(unsafe.Slice((*byte)(unsafe.Pointer(&dst[0])), len(dst)-1), s)
+	dst[n] = 0
+}
+
+// decodeDeviceInfo 把 C 的設備資訊轉成 Device
+func decodeDeviceInfo(cInfo *C.struct_dante_device_info_t) Device {
+	return Device{
+		ID:             int(cInfo.id),
+		Name:           C.GoString(&cInfo.name[0]),
+		Model:          C.GoString(&cInfo.model[0]),
+		ProductVersion: C.GoString(&cInfo.product_version[0]),
+		DanteVersion:   C.GoString(&cInfo.dante_version[0]),
+		IPAddress:      C.GoString(&cInfo.ip_address[0]),
+		LinkSpeed:      int(cInfo.link_speed),
+		SecondaryIP:    C.GoString(&cInfo.secondary_ip[0]),
+		SecondarySpeed: int(cInfo.secondary_speed),
+		MacAddress:     C.GoString(&cInfo.mac_address[0]),
+		TxChannels:     int(cInfo.tx_channels),
+		RxChannels:     int(cInfo.rx_channels),
+		Manufacturer:   C.GoString(&cInfo.manufacturer[0]),
+		SerialNumber:   C.GoString(&cInfo.serial[0]),
+		Capabilities: DeviceCapabilities{
+			Redundancy: cInfo.capabilities&C.DANTE_DEVICE_CAP_REDUNDANCY != 0,
+			AES67:      cInfo.capabilities&C.DANTE_DEVICE_CAP_AES67 != 0,
+			Lockable:   cInfo.capabilities&C.DANTE_DEVICE_CAP_LOCKABLE != 0,
+			Metering:   cInfo.capabilities&C.DANTE_DEVICE_CAP_METERING != 0,
+		},
+	}
+}
+
+// danteRouteList 回傳的 int 為通道數，負數表示失敗
+func danteRouteList(rxDevice string, maxCount int) ([]Subscription, int) {
+	cDevice := C.CString(rxDevice)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	list := make([]C.struct_dante_subscription_info_t, maxCount)
+	count := int(C.dante_route_list(cDevice, &list[0], C.int(len(list))))
+	if count < 0 {
+		return nil, count
+	}
+
+	subs := make([]Subscription, 0, count)
+	for _, info := range list[:count] {
+		subs = append(subs, Subscription{
+			ChannelID: int(info.id),
+			Channel:   C.GoString(&info.name[0]),
+			TxChannel: C.GoString(&info.tx_channel[0]),
+			TxDevice:  C.GoString(&info.tx_device[0]),
+			Status:    int(info.status),
+		})
+	}
+	return subs, count
+}
+
+func danteRouteSubscribe(rxDevice, rxChannel, txDevice, txChannel string) int {
+	cRxDevice := C.CString(rxDevice)
+	defer C.free(unsafe.Pointer(cRxDevice))
+	cRxChannel := C.CString(rxChannel)
+	defer C.free(unsafe.Pointer(cRxChannel))
+	cTxDevice := C.CString(txDevice)
+	defer C.free(unsafe.Pointer(cTxDevice))
+	cTxChannel := C.CString(txChannel)
+	defer C.free(unsafe.Pointer(cTxChannel))
+
+	return int(C.dante_route_subscribe(cRxDevice, cRxChannel, cTxDevice, cTxChannel))
+}
+
+func danteMonitorStart() int {
+	return int(C.dante_monitor_start())
+}
+
+func danteMonitorWatchDevice(device string) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	return int(C.dante_monitor_watch_device(cDevice))
+}
+
+func danteGetClockInfo(device string) (ClockInfo, int) {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	var cInfo C.struct_dante_clock_info_t
+	if result := C.dante_get_clock_info(cDevice, &cInfo); result != 0 {
+		return ClockInfo{}, int(result)
+	}
+	return ClockInfo{
+		ClockState:    C.GoString(&cInfo.clock_state[0]),
+		ServoState:    C.GoString(&cInfo.servo_state[0]),
+		ClockSource:   C.GoString(&cInfo.clock_source[0]),
+		IsGrandmaster: cInfo.is_grandmaster != 0,
+		Updated:       time.Unix(int64(cInfo.updated), 0),
+	}, 0
+}
+
+func danteIdentifyDevice(device string) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	return int(C.dante_identify_device(cDevice))
+}
+
+func danteFirmwareUpgrade(device string, src FirmwareSource) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	cServer := C.CString(src.Server)
+	defer C.free(unsafe.Pointer(cServer))
+	cPath := C.CString(src.Path)
+	defer C.free(unsafe.Pointer(cPath))
+	return int(C.dante_firmware_upgrade(cDevice, C.int(upgradeProtocols[src.Protocol]), cServer, C.int(src.Port), cPath))
+}
+
+func danteGetUpgradeStatus(device string) (UpgradeStatus, int) {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	var cInfo C.struct_dante_upgrade_info_t
+	if result := C.dante_get_upgrade_status(cDevice, &cInfo); result != 0 {
+		return UpgradeStatus{}, int(result)
+	}
+	return UpgradeStatus{
+		State:   upgradeState(int(cInfo.state)),
+		Error:   C.GoString(&cInfo.error[0]),
+		Current: int64(cInfo.progress_curr),
+		Total:   int64(cInfo.progress_total),
+		Updated: time.Unix(int64(cInfo.updated), 0),
+	}, 0
+}
+
+func danteMonitorWatchMeters(device string) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	return int(C.dante_monitor_watch_meters(cDevice))
+}
+
+func danteGetMeters(device string) (Meters, int) {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	var cInfo C.struct_dante_meter_info_t
+	if result := C.dante_get_meters(cDevice, &cInfo); result != 0 {
+		return Meters{}, int(result)
+	}
+	tx := C.GoBytes(unsafe.Pointer(&cInfo.tx_peak[0]), cInfo.num_tx)
+	rx := C.GoBytes(unsafe.Pointer(&cInfo.rx_peak[0]), cInfo.num_rx)
+	return Meters{
+		TX:      channelLevels(tx),
+		RX:      channelLevels(rx),
+		Updated: time.Unix(int64(cInfo.updated), 0),
+	}, 0
+}
+
+// danteTxChannelList 回傳的 int 為通道數，負數表示失敗
+func danteTxChannelList(device string, maxCount int) ([]Channel, int) {
+	if maxCount <= 0 {
+		return nil, 0
+	}
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	list := make([]C.struct_dante_channel_info_t, maxCount)
+	count := int(C.dante_tx_channel_list(cDevice, &list[0], C.int(len(list))))
+	if count < 0 {
+		return nil, count
+	}
+
+	channels := make([]Channel, 0, count)
+	for _, info := range list[:count] {
+		channels = append(channels, Channel{ID: int(info.id), Name: C.GoString(&info.name[0])})
+	}
+	return channels, count
+}
+
+func danteGetDeviceSettings(device string) (DeviceSettings, int) {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	var cSettings C.struct_dante_device_settings_t
+	if result := C.dante_get_device_settings(cDevice, &cSettings); result != 0 {
+		return DeviceSettings{}, int(result)
+	}
+	return DeviceSettings{
+		SampleRate: int(cSettings.sample_rate),
+		LatencyUs:  int(cSettings.rx_latency_us),
+	}, 0
+}
+
+func danteRenameDevice(device, newName string) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	cName := C.CString(newName)
+	defer C.free(unsafe.Pointer(cName))
+	return int(C.dante_rename_device(cDevice, cName))
+}
+
+func danteSetChannelName(device string, tx bool, channelID int, name string) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	isTx := 0
+	if tx {
+		isTx = 1
+	}
+	return int(C.dante_set_channel_name(cDevice, C.int(isTx), C.int(channelID), cName))
+}
+
+func danteSetRxLatency(device string, latencyUs int) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	return int(C.dante_set_rx_latency(cDevice, C.int(latencyUs)))
+}
+
+func danteSetSampleRate(device string, sampleRate int) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	return int(C.dante_set_sample_rate(cDevice, C.int(sampleRate)))
+}
+
+func danteGetDeviceEnrollment(device string) (Enrollment, int) {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	var cInfo C.struct_dante_enrollment_info_t
+	if result := C.dante_get_device_enrollment(cDevice, &cInfo); result != 0 {
+		return Enrollment{}, int(result)
+	}
+	return Enrollment{
+		Managed:  cInfo.managed != 0,
+		Domain:   C.GoString(&cInfo.domain[0]),
+		ReadOnly: C.GoString(&cInfo.read_only[0]),
+	}, 0
+}
+
+// danteTxFlowList 回傳的 int 為 flow 數，負數表示失敗
+func danteTxFlowList(device string, maxCount int) ([]Flow, int) {
+	if maxCount <= 0 {
+		return nil, 0
+	}
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	list := make([]C.struct_dante_flow_info_t, maxCount)
+	count := int(C.dante_tx_flow_list(cDevice, &list[0], C.int(len(list))))
+	if count < 0 {
+		return nil, count
+	}
+
+	flows := make([]Flow, 0, count)
+	for i := range list[:count] {
+		info := &list[i]
+		f := Flow{
+			ID:              int(info.id),
+			Name:            C.GoString(&info.name[0]),
+			Multicast:       info.multicast != 0,
+			Manual:          info.manual != 0,
+			Address:         C.GoString(&info.address[0]),
+			Port:            int(info.port),
+			FramesPerPacket: int(info.fpp),
+			LatencyUs:       int(info.latency_us),
+			SampleRate:      int(info.sample_rate),
+			Channels:        make([]int, int(info.num_slots)),
+		}
+		for slot := range f.Channels {
+			f.Channels[slot] = int(info.channels[slot])
+		}
+		flows = append(flows, f)
+	}
+	return flows, count
+}
+
+// danteRxFlowStats 回傳的 int 為 flow 數，負數表示失敗
+func danteRxFlowStats(device string, maxCount int) ([]RxFlowStats, int) {
+	if maxCount <= 0 {
+		return nil, 0
+	}
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	list := make([]C.struct_dante_rxflow_stats_t, maxCount)
+	count := int(C.dante_rx_flow_stats(cDevice, &list[0], C.int(len(list))))
+	if count < 0 {
+		return nil, count
+	}
+
+	stats := make([]RxFlowStats, 0, count)
+	for i := range list[:count] {
+		info := &list[i]
+		s := RxFlowStats{
+			ID:         int(info.id),
+			Name:       C.GoString(&info.name[0]),
+			TxDevice:   C.GoString(&info.tx_device[0]),
+			TxFlow:     C.GoString(&info.tx_flow[0]),
+			Multicast:  info.multicast != 0,
+			Reported:   flowStatNames(int(info.fields)),
+			Interfaces: make([]FlowCounters, int(info.num_interfaces)),
+		}
+		for intf := range s.Interfaces {
+			s.Interfaces[intf] = FlowCounters{
+				Early:        uint64(info.early[intf]),
+				Late:         uint64(info.late[intf]),
+				OutOfOrder:   uint64(info.out_of_order[intf]),
+				Dropped:      uint64(info.dropped[intf]),
+				MaxLatencyUs: int(info.max_latency_us[intf]),
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats, count
+}
+
+// danteCreateMulticastFlow 回傳新 flow 的編號，第二個 int 為結果 (負數表示失敗)
+func danteCreateMulticastFlow(device string, config FlowConfig) (int, int) {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+
+	var cConfig C.struct_dante_flow_config_t
+	copyCString(cConfig.name[:], config.Name)
+	copyCString(cConfig.address[:], config.Address)
+	cConfig.port = C.int(config.Port)
+	cConfig.packet_time_us = C.int(config.PacketTimeUs)
+	cConfig.num_channels = C.int(len(config.Channels))
+	for i, ch := range config.Channels {
+		if i >= len(cConfig.channels) {
+			break
+		}
+		cConfig.channels[i] = C.int(ch)
+	}
+
+	id := int(C.dante_create_multicast_flow(cDevice, &cConfig))
+	if id < 0 {
+		return 0, id
+	}
+	return id, 0
+}
+
+func danteDeleteTxFlow(device string, flowID int) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	return int(C.dante_delete_tx_flow(cDevice, C.int(flowID)))
+}
+
+func danteDeviceOpen(device string) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	return int(C.dante_device_open(cDevice))
+}
+
+func danteDeviceClose(device string) int {
+	cDevice := C.CString(device)
+	defer C.free(unsafe.Pointer(cDevice))
+	return int(C.dante_device_close(cDevice))
+}
+
+// copyCString 複製字串到固定大小的 C 字元陣列 (截斷並保留結尾的 0)
+func copyCString(dst []C.char, s string) {
+	n := min(len(s), len(dst)-1)
+	for i := 0; i < n; i++ {
+		dst[i] = C.char(s[i])
+	}
+	dst[n] = 0
+}
@@ -0,0 +1,26 @@
+//go:build !nodante
+
+package dante
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkDecodeDeviceInfo 把 dante_get_device_list 的 C 結構轉成 Device
+// (每次刷新每台設備一次；需要以 cgo 建置，連結 libdapi)
+func BenchmarkDecodeDeviceInfo(b *testing.B) {
+	for _, n := range benchDeviceCounts {
+		b.Run(fmt.Sprintf("devices=%d", n), func(b *testing.B) {
+			list := sampleDeviceInfos(n)
+			if devices := decodeDeviceList(list); len(devices) != n || devices[n-1].Name != fmt.Sprintf("Stage-Box-%d", n) {
+				b.Fatalf("decoded %+v", devices[n-1])
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				decodeDeviceList(list)
+			}
+		})
+	}
+}
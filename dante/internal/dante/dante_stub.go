@@ -0,0 +1,207 @@
+//go:build nodante
+
+package dante
+
+//==============================================================================
+// Dante SDK 模擬 (nodante)
+//==============================================================================
+
+// 以 -tags nodante 建置時取代 dante_cgo.go：不連結 libdapi、不需要 Audinate 標頭檔，
+// CI 與開發機也能編譯並測試 Go 的邏輯。dante_* 函數都轉給記憶體中的
+// SimulatedSDK，測試透過 stubSDK 設定設備、訂閱、時鐘與要模擬的失敗。
+
+// stubSDK nodante 建置使用的模擬 SDK
+var stubSDK = newSimulatedSDK()
+
+// resetStubSDK 還原模擬 SDK (測試之間呼叫)
+func resetStubSDK() {
+	stubSDK = newSimulatedSDK()
+}
+
+// stubNotConnected 模擬沒有本機 Dante 設備
+func stubNotConnected() int {
+	s := stubSDK
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fail("Device not connected")
+}
+
+func danteInit() int {
+	return danteInitWithInterface("")
+}
+
+func danteInitWithInterface(interfaceName string) int {
+	return stubSDK.InitWithInterface(interfaceName)
+}
+
+func danteCleanup() {
+	stubSDK.Cleanup()
+}
+
+func danteGetLastError() string {
+	return stubSDK.GetLastError()
+}
+
+func danteGetSDKVersion() string {
+	return "nodante"
+}
+
+func danteLibraryVersions() map[string]string {
+	return nil
+}
+
+func danteConnectLocalDevice() int {
+	return stubNotConnected()
+}
+
+func danteIsDeviceConnected() int {
+	return 0
+}
+
+func danteGetDeviceName() (string, int) {
+	return "", stubNotConnected()
+}
+
+func danteGetTxChannelCount() int {
+	return stubNotConnected()
+}
+
+func danteGetRxChannelCount() int {
+	return stubNotConnected()
+}
+
+func danteGetTxChannelName(channelIndex int) (string, int) {
+	return "", stubNotConnected()
+}
+
+func danteRunBasicTest() int {
+	return 0
+}
+
+func danteStartDeviceScan() int {
+	return stubSDK.StartDeviceScan()
+}
+
+func danteStopDeviceScan() int {
+	return stubSDK.StopDeviceScan()
+}
+
+func danteGetDiscoveredDeviceCount() int {
+	return stubSDK.GetDiscoveredDeviceCount()
+}
+
+func danteRefreshDeviceScan() int {
+	return stubSDK.RefreshDeviceScan()
+}
+
+func danteProcessEventsBriefly() int {
+	return stubSDK.ProcessEventsBriefly()
+}
+
+func danteGetCurrentDeviceList() int {
+	return stubSDK.GetDiscoveredDeviceCount()
+}
+
+func danteGetChangeCount() int {
+	return stubSDK.ChangeCount()
+}
+
+func danteGetDeviceInfo(index int) (Device, int) {
+	return stubSDK.GetDeviceInfo(index)
+}
+
+func danteGetDeviceList(maxCount int) ([]Device, int) {
+	return stubSDK.GetDeviceList(maxCount)
+}
+
+func danteRouteList(rxDevice string, maxCount int) ([]Subscription, int) {
+	return stubSDK.RouteList(rxDevice, maxCount)
+}
+
+func danteRouteSubscribe(rxDevice, rxChannel, txDevice, txChannel string) int {
+	return stubSDK.RouteSubscribe(rxDevice, rxChannel, txDevice, txChannel)
+}
+
+func danteMonitorStart() int {
+	return stubSDK.MonitorStart()
+}
+
+func danteMonitorWatchDevice(device string) int {
+	return stubSDK.MonitorWatchDevice(device)
+}
+
+func danteGetClockInfo(device string) (ClockInfo, int) {
+	return stubSDK.GetClockInfo(device)
+}
+
+func danteIdentifyDevice(device string) int {
+	return stubSDK.IdentifyDevice(device)
+}
+
+func danteFirmwareUpgrade(device string, src FirmwareSource) int {
+	return stubSDK.StartFirmwareUpgrade(device, src)
+}
+
+func danteGetUpgradeStatus(device string) (UpgradeStatus, int) {
+	return stubSDK.GetUpgradeStatus(device)
+}
+
+func danteMonitorWatchMeters(device string) int {
+	return stubSDK.MonitorWatchMeters(device)
+}
+
+func danteGetMeters(device string) (Meters, int) {
+	return stubSDK.GetMeters(device)
+}
+
+func danteTxChannelList(device string, maxCount int) ([]Channel, int) {
+	return stubSDK.TxChannelList(device, maxCount)
+}
+
+func danteGetDeviceSettings(device string) (DeviceSettings, int) {
+	return stubSDK.GetDeviceSettings(device)
+}
+
+func danteRenameDevice(device, newName string) int {
+	return stubSDK.RenameDevice(device, newName)
+}
+
+func danteSetChannelName(device string, tx bool, channelID int, name string) int {
+	return stubSDK.SetChannelName(device, tx, channelID, name)
+}
+
+func danteSetRxLatency(device string, latencyUs int) int {
+	return stubSDK.SetRxLatency(device, latencyUs)
+}
+
+func danteSetSampleRate(device string, sampleRate int) int {
+	return stubSDK.SetSampleRate(device, sampleRate)
+}
+
+func danteGetDeviceEnrollment(device string) (Enrollment, int) {
+	return stubSDK.GetDeviceEnrollment(device)
+}
+
+func danteRxFlowStats(device string, maxCount int) ([]RxFlowStats, int) {
+	return stubSDK.RxFlowStats(device, maxCount)
+}
+
+func danteTxFlowList(device string, maxCount int) ([]Flow, int) {
+	return stubSDK.TxFlowList(device, maxCount)
+}
+
+func danteCreateMulticastFlow(device string, config FlowConfig) (int, int) {
+	return stubSDK.CreateMulticastFlow(device, config)
+}
+
+func danteDeleteTxFlow(device string, flowID int) int {
+	return stubSDK.DeleteTxFlow(device, flowID)
+}
+
+func danteDeviceOpen(device string) int {
+	return stubSDK.OpenDevice(device)
+}
+
+func danteDeviceClose(device string) int {
+	return stubSDK.CloseDevice(device)
+}
@@ -0,0 +1,291 @@
+//go:build nodante
+
+package dante
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newStubDomain 以模擬 SDK 初始化網域
+func newStubDomain(t *testing.T) *Domain {
+	t.Helper()
+	resetStubSDK()
+	d := NewDomain("Dante1", NetworkConfig{InterfaceName: "eth1", IPAddress: "10.0.0.5"})
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	return d
+}
+
+func TestStubInitializeFailure(t *testing.T) {
+	resetStubSDK()
+	stubSDK.InitError = "Failed to create DAPI: 12"
+
+	d := NewDomain("Dante1", NetworkConfig{InterfaceName: "eth1"})
+	err := d.Initialize(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Failed to create DAPI: 12") {
+		t.Fatalf("Initialize() = %v, want SDK error", err)
+	}
+	if d.Initialized() {
+		t.Fatal("domain marked initialized after failure")
+	}
+}
+
+func TestStubDeviceScan(t *testing.T) {
+	d := newStubDomain(t)
+	stubSDK.Devices = []Device{
+		{Name: "amp-1", IPAddress: "10.0.0.21", LinkSpeed: 1000},
+		{Name: "mixer", IPAddress: "169.254.3.4", LinkSpeed: 100},
+	}
+
+	// 掃描前刷新不應發現設備
+	d.RefreshDevices(context.Background())
+	if len(d.GetDevices()) != 0 {
+		t.Fatal("devices discovered before the scan started")
+	}
+
+	if err := d.StartDeviceScan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(context.Background())
+	devices := d.GetDevices()
+	if len(devices) != 2 || devices[0].ID != 1 || devices[1].Name != "mixer" {
+		t.Fatalf("unexpected devices: %+v", devices)
+	}
+	if !devices[1].IsLinkLocal() {
+		t.Fatal("mixer should be link-local")
+	}
+
+	d.Cleanup()
+	if d.Initialized() || danteGetDiscoveredDeviceCount() != 0 {
+		t.Fatal("Cleanup did not reset the SDK")
+	}
+}
+
+func TestStubRouting(t *testing.T) {
+	d := newStubDomain(t)
+	stubSDK.TxChannels["mixer"] = []string{"Out 7"}
+	stubSDK.Subscriptions["amp-1"] = []Subscription{
+		{ChannelID: 1, Channel: "In 1"},
+		{ChannelID: 2, Channel: "In 2"},
+	}
+
+	if err := d.Subscribe(context.Background(), "amp-1", "In 2", "mixer", "Out 7"); err != nil {
+		t.Fatal(err)
+	}
+	subs, err := d.ListSubscriptions(context.Background(), "amp-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs[0].Subscribed() || !subs[1].Subscribed() || subs[1].TxDevice != "mixer" || subs[1].TxChannel != "Out 7" {
+		t.Fatalf("unexpected subscriptions: %+v", subs)
+	}
+	if subs[1].StatusText() != "connected (unicast)" {
+		t.Fatalf("status = %q", subs[1].StatusText())
+	}
+
+	if err := d.Subscribe(context.Background(), "amp-1", "In 2", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	subs, _ = d.ListSubscriptions(context.Background(), "amp-1")
+	if subs[1].Subscribed() {
+		t.Fatal("subscription not removed")
+	}
+
+	if err := d.Subscribe(context.Background(), "amp-1", "In 9", "mixer", "Out 1"); err == nil || !strings.Contains(err.Error(), "In 9") {
+		t.Fatalf("Subscribe to missing channel = %v", err)
+	}
+	if _, err := d.ListSubscriptions(context.Background(), "nope"); err == nil {
+		t.Fatal("ListSubscriptions of unknown device succeeded")
+	}
+}
+
+func TestStubClockAndIdentify(t *testing.T) {
+	d := newStubDomain(t)
+	stubSDK.Clocks["amp-1"] = ClockInfo{ClockState: "locked", IsGrandmaster: true, Updated: time.Unix(1700000000, 0)}
+
+	if err := d.Identify("amp-1"); err == nil {
+		t.Fatal("Identify succeeded before StartMonitoring")
+	}
+	if err := d.StartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.ClockInfo("amp-1"); ok {
+		t.Fatal("clock info available before WatchClock")
+	}
+	if err := d.WatchClock("amp-1"); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := d.ClockInfo("amp-1")
+	if !ok || info.ClockState != "locked" || !info.IsGrandmaster {
+		t.Fatalf("ClockInfo = %+v, %v", info, ok)
+	}
+	if err := d.Identify("amp-1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(stubSDK.identified) != 1 || stubSDK.identified[0] != "amp-1" {
+		t.Fatalf("identified = %v", stubSDK.identified)
+	}
+}
+
+func TestStubContextCancellation(t *testing.T) {
+	resetStubSDK()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	d := NewDomain("Dante1", NetworkConfig{InterfaceName: "eth1"})
+	if err := d.Initialize(canceled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Initialize(canceled) = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 取消後網域不再接受操作，Cleanup 等待事件處理結束並釋放 SDK
+	cancel()
+	if d.Initialized() {
+		t.Fatal("domain still initialized after cancel")
+	}
+	if _, err := d.ListSubscriptions(context.Background(), "amp-1"); err == nil {
+		t.Fatal("ListSubscriptions succeeded after cancel")
+	}
+	d.Cleanup()
+	if stubSDK.initialized || stubSDK.scanning {
+		t.Fatal("SDK not released by Cleanup")
+	}
+}
+
+func TestStubConcurrentCalls(t *testing.T) {
+	d := newStubDomain(t)
+	stubSDK.Devices = []Device{{Name: "amp-1", IPAddress: "10.0.0.21"}}
+	stubSDK.Subscriptions["amp-1"] = []Subscription{{ChannelID: 1, Channel: "In 1"}}
+	if err := d.StartDeviceScan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 事件處理、刷新與路由同時呼叫 SDK；每個失敗都要拿到自己的錯誤訊息
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		channel := fmt.Sprintf("Missing %d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				d.RefreshDevices(context.Background())
+				err := d.Subscribe(context.Background(), "amp-1", channel, "mixer", "Out 1")
+				if err == nil || !strings.Contains(err.Error(), channel) {
+					t.Errorf("Subscribe(%s) = %v", channel, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if len(d.GetDevices()) != 1 {
+		t.Fatalf("unexpected devices: %+v", d.GetDevices())
+	}
+}
+
+func TestStubOneNativeDomainPerProcess(t *testing.T) {
+	first := newStubDomain(t)
+
+	// 第二個原生網域的 Cleanup 會清掉第一個網域的 C 狀態，所以不能初始化
+	second := NewDomain("Dante2", NetworkConfig{InterfaceName: "eth2"})
+	err := second.Initialize(context.Background())
+	if !errors.Is(err, ErrSDKInUse) || !strings.Contains(err.Error(), "Dante1") {
+		t.Fatalf("second Initialize() = %v, want ErrSDKInUse naming Dante1", err)
+	}
+	if !first.Initialized() || !stubSDK.initialized {
+		t.Fatal("rejected domain reset the SDK")
+	}
+
+	// 模擬網域有自己的 SDK，不受限制
+	sim := NewSimulatedDomain("Sim", NetworkConfig{InterfaceName: "sim0"}, NewSimulatedSDK(DefaultSimulationConfig()))
+	if err := sim.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sim.Cleanup()
+	if !stubSDK.initialized {
+		t.Fatal("simulated domain cleanup released the native SDK")
+	}
+
+	// 第一個網域釋放後可以初始化
+	first.Cleanup()
+	if err := second.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize() after the owner cleaned up = %v", err)
+	}
+	second.Cleanup()
+}
+
+func TestSDKThreadTryDo(t *testing.T) {
+	var thread sdkThread
+	busy, release := make(chan struct{}), make(chan struct{})
+	go thread.do(func() {
+		close(busy)
+		<-release
+	})
+	<-busy
+	if thread.tryDo(func() {}) {
+		t.Fatal("tryDo ran while the worker was busy")
+	}
+	close(release)
+
+	ran := false
+	deadline := time.Now().Add(2 * time.Second)
+	for !ran && time.Now().Before(deadline) {
+		thread.tryDo(func() { ran = true })
+	}
+	if !ran {
+		t.Fatal("tryDo never ran on the idle worker")
+	}
+}
+
+func TestSDKThreadPanic(t *testing.T) {
+	var thread sdkThread
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("recover() = %v, want boom", p)
+			}
+		}()
+		thread.do(func() { panic("boom") })
+	}()
+
+	// worker 在 panic 後仍繼續處理呼叫
+	if result := thread.call(func() int { return 7 }); result != 7 {
+		t.Fatalf("call after panic = %d", result)
+	}
+}
+
+func TestStubDeviceListBatch(t *testing.T) {
+	d := newStubDomain(t)
+	for i := 0; i < 150; i++ {
+		stubSDK.Devices = append(stubSDK.Devices, Device{Name: fmt.Sprintf("amp-%d", i), IPAddress: "10.0.0.21"})
+	}
+	if err := d.StartDeviceScan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(context.Background())
+
+	devices := d.GetDevices()
+	if len(devices) != 150 || devices[149].ID != 150 || devices[149].Name != "amp-149" {
+		t.Fatalf("got %d devices, last %+v", len(devices), devices[len(devices)-1])
+	}
+
+	// 緩衝區小於設備數時只回傳前 maxCount 台
+	list, count := danteGetDeviceList(10)
+	if count != 10 || len(list) != 10 || list[9].Name != "amp-9" {
+		t.Fatalf("danteGetDeviceList(10) = %d devices, count %d", len(list), count)
+	}
+}
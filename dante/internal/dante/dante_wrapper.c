@@ -0,0 +1,2758 @@
+//go:build !nodante
+
+/*
+ * dante_wrapper.c
+ * 基礎 Dante API C Wrapper for Go integration
+ * 
+ * 支援背景設備掃描和自動列表更新
+ */
+
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <unistd.h> 
+// Dante API headers
+#include "audinate/dante_api.h"
+#include <sys/socket.h>
+#include <netdb.h>
+#include <arpa/inet.h>
+#include <time.h>
+
+// 函數宣告
+int dante_init(void);
+int dante_init_with_interface(const char* interface_name);
+void dante_cleanup(void);
+const char* dante_get_last_error(void);
+const char* dante_get_sdk_version(void);
+int dante_connect_local_device(void);
+int dante_is_device_connected(void);
+int dante_get_device_name(char* buffer, int buffer_size);
+int dante_get_tx_channel_count(void);
+int dante_get_rx_channel_count(void);
+int dante_get_tx_channel_name(int channel_index, char* buffer, int buffer_size);
+int dante_run_basic_test(void);
+
+// 設備資訊結構定義
+typedef struct {
+    int id;
+    char name[64];
+    char model[64]; 
+    char product_version[32];
+    char dante_version[32];
+    char ip_address[16];
+    int link_speed;
+    char secondary_ip[16];
+    int secondary_speed;
+    char mac_address[18];   // 需與 dante_cgo.go 的宣告一致 (批次讀取依賴相同的陣列間距)
+    int tx_channels;        // -1 表示未知 (設備沒有回應能力查詢)
+    int rx_channels;
+    char manufacturer[64];  // 製造商名稱 (ConMon 製造商版本，尚未收到時為空白)
+    char serial[20];        // 序號 (十六進位，同上)
+    int capabilities;       // DANTE_DEVICE_CAP_* 位元
+    int is_valid;
+} dante_device_info_t;
+
+// 設備能力 (dante_device_info_t.capabilities)
+#define DANTE_DEVICE_CAP_REDUNDANCY 0x1  // 有次要網路介面 (能力查詢)
+#define DANTE_DEVICE_CAP_AES67      0x2  // 支援 AES67 模式 (ConMon)
+#define DANTE_DEVICE_CAP_LOCKABLE   0x4  // 可以鎖定 (ConMon)
+#define DANTE_DEVICE_CAP_METERING   0x8  // 提供通道電平 (ConMon)
+
+// 新增的背景掃描功能
+int dante_start_device_scan(void);
+int dante_stop_device_scan(void);
+int dante_get_discovered_device_count(void);
+int dante_get_device_info(int index, dante_device_info_t* info);
+int dante_get_device_list(dante_device_info_t* list, int max_count);
+int dante_refresh_device_scan(void);
+int dante_process_events_briefly(void);
+int dante_get_current_device_list(void);
+int dante_get_change_count(void);
+
+// 接收通道訂閱資訊
+typedef struct {
+    int id;                 // 接收通道編號 (1-based)
+    char name[64];          // 接收通道名稱
+    char tx_channel[64];    // 訂閱的發送通道 (空白表示未訂閱)
+    char tx_device[64];     // 訂閱的發送設備
+    int status;             // dante_rxstatus_t
+} dante_subscription_info_t;
+
+// 路由訂閱
+int dante_route_list(const char* rx_device, dante_subscription_info_t* list, int max_count);
+int dante_route_subscribe(const char* rx_device, const char* rx_channel,
+                          const char* tx_device, const char* tx_channel);
+
+// 設備時鐘狀態
+typedef struct {
+    char device[64];        // 設備名稱
+    char clock_state[32];   // 時鐘狀態 (conmon_audinate_clock_state_string)
+    char servo_state[32];   // 鎖定狀態 (conmon_audinate_servo_state_string)
+    char clock_source[32];  // 時鐘來源
+    int is_grandmaster;     // 是否為 PTP grandmaster
+    long long updated;      // 最後更新時間 (unix 秒)
+} dante_clock_info_t;
+
+// ConMon 監控 (時鐘狀態、識別)
+int dante_monitor_start(void);
+int dante_monitor_watch_device(const char* device);
+int dante_get_clock_info(const char* device, dante_clock_info_t* info);
+int dante_identify_device(const char* device);
+
+// 韌體升級狀態 (state: -1 已送出請求, 其他為 CONMON_AUDINATE_UPGRADE_STATE_*)
+typedef struct {
+    char device[64];        // 設備名稱
+    int state;              // 升級狀態
+    int last_error;         // 最後錯誤碼 (0 表示無錯誤)
+    char error[64];         // 錯誤說明 (conmon_audinate_upgrade_error_to_string)
+    long long progress_curr; // 目前進度 (通常為位元組)
+    long long progress_total; // 總進度 (0 表示未知)
+    long long updated;      // 最後更新時間 (unix 秒)
+} dante_upgrade_info_t;
+
+// 韌體升級 (protocol 為 CONMON_AUDINATE_UPGRADE_PROTOCOL_*)
+int dante_firmware_upgrade(const char* device, int protocol, const char* server, int port, const char* path);
+int dante_get_upgrade_status(const char* device, dante_upgrade_info_t* info);
+
+// 通道電平 (peak 為 CONMON_METERING_PEAK_* 值：0 削峰、1 為 0dB、每級 -0.5dB、0xFE 靜音)
+#define DANTE_MAX_METER_CHANNELS 512
+typedef struct {
+    char device[64];        // 設備名稱
+    int num_tx;             // 發送通道數
+    int num_rx;             // 接收通道數
+    unsigned char tx_peak[DANTE_MAX_METER_CHANNELS];
+    unsigned char rx_peak[DANTE_MAX_METER_CHANNELS];
+    long long updated;      // 最後更新時間 (unix 秒)
+} dante_meter_info_t;
+
+// Metering channel (設備不支援時不會收到電平)
+int dante_monitor_watch_meters(const char* device);
+int dante_get_meters(const char* device, dante_meter_info_t* info);
+
+// 通道名稱 (發送或接收)
+typedef struct {
+    int id;                 // 通道編號 (1-based)
+    char name[64];          // 通道名稱 (自訂標籤或預設名稱)
+} dante_channel_info_t;
+
+// 設備設定
+typedef struct {
+    int sample_rate;        // 取樣率 (Hz，0 表示未知)
+    int rx_latency_us;      // 接收延遲 (微秒)
+} dante_device_settings_t;
+
+// 設備設定 (名稱、通道標籤、取樣率、延遲)
+int dante_tx_channel_list(const char* device, dante_channel_info_t* list, int max_count);
+int dante_get_device_settings(const char* device, dante_device_settings_t* settings);
+int dante_rename_device(const char* device, const char* new_name);
+int dante_set_channel_name(const char* device, int is_tx, int channel_id, const char* name);
+int dante_set_rx_latency(const char* device, int latency_us);
+int dante_set_sample_rate(const char* device, int sample_rate);
+
+// DDM 註冊狀態
+typedef struct {
+    int managed;            // 已註冊到 Dante Domain Manager 的網域
+    char domain[128];       // DDM 網域名稱 (DANTE_DOMAIN_NAME_LENGTH，無法得知時為空白)
+    char read_only[128];    // 非受管控制器無法設定的原因 (可以設定時為空白)
+} dante_enrollment_info_t;
+
+int dante_get_device_enrollment(const char* device, dante_enrollment_info_t* info);
+
+// 發送 flow
+#define MAX_FLOW_SLOTS 64
+typedef struct {
+    int id;
+    char name[32];              // DANTE_NAME_LENGTH
+    int multicast;              // 目的地址為 multicast
+    int manual;                 // 使用者建立 (可刪除)
+    char address[16];           // 主要介面的目的地址
+    int port;
+    int fpp;                    // frames per packet
+    int latency_us;
+    int sample_rate;
+    int num_slots;
+    int channels[MAX_FLOW_SLOTS]; // 每個 slot 的發送通道編號 (0 表示空白)
+} dante_flow_info_t;
+
+// 建立 multicast flow 的設定
+typedef struct {
+    char name[32];              // 空白使用預設名稱
+    char address[16];           // multicast 地址 (空白由設備選擇)
+    int port;                   // 指定地址時的埠
+    int packet_time_us;         // 0 為設備預設
+    int num_channels;
+    int channels[MAX_FLOW_SLOTS];
+} dante_flow_config_t;
+
+int dante_tx_flow_list(const char* device, dante_flow_info_t* list, int max_count);
+int dante_create_multicast_flow(const char* device, const dante_flow_config_t* config);
+int dante_delete_tx_flow(const char* device, int flow_id);
+
+// 接收 flow 的錯誤統計 (設備啟動或清除後的累計，每個介面一組)
+#define DANTE_MAX_FLOW_INTERFACES 2
+typedef struct {
+    int id;
+    char name[32];              // DANTE_NAME_LENGTH
+    char tx_device[64];         // 發送設備
+    char tx_flow[32];           // 發送設備上的 flow 名稱
+    int multicast;
+    int fields;                 // 設備提供的統計 (DANTE_RXFLOW_ERROR_FLAG_*)
+    int num_interfaces;
+    unsigned int early[DANTE_MAX_FLOW_INTERFACES];
+    unsigned int late[DANTE_MAX_FLOW_INTERFACES];
+    unsigned int out_of_order[DANTE_MAX_FLOW_INTERFACES];
+    unsigned int dropped[DANTE_MAX_FLOW_INTERFACES];
+    unsigned int max_latency_us[DANTE_MAX_FLOW_INTERFACES];
+} dante_rxflow_stats_t;
+
+int dante_rx_flow_stats(const char* device, dante_rxflow_stats_t* list, int max_count);
+
+// 保持開啟的遠端設備連線 (連續查詢、設定同一台設備時不必每次重新解析)
+int dante_device_open(const char* device);
+int dante_device_close(const char* device);
+
+// 全域變數
+static dapi_t* g_dapi = NULL;
+static dante_runtime_t* g_runtime = NULL;
+static dr_devices_t* g_devices = NULL;
+static dr_device_t* g_device = NULL;
+static aud_env_t* g_env = NULL;
+
+// 設備瀏覽相關
+static db_browse_t* g_browse = NULL;
+static db_browse_config_t g_browse_config;
+static int g_device_scan_active = 0;
+static int g_background_scanning = 0;
+
+// 回調函數和狀態追蹤
+static int g_device_ready = 0;
+
+// 錯誤處理
+static char g_error_buffer[256];
+
+// 設備列表管理
+#define MAX_DEVICES 256
+static dante_device_info_t g_discovered_devices[MAX_DEVICES];
+static int g_device_count = 0;
+static unsigned int g_change_count = 0;  // SDK 通知網路變更的次數 (手動刷新不計)
+
+// ConMon client 與已訂閱 status channel 的設備時鐘狀態
+static conmon_client_t* g_conmon = NULL;
+static int g_conmon_registered = 0;
+static dante_clock_info_t g_clock_info[MAX_DEVICES];
+static int g_clock_count = 0;
+
+// ConMon 製造商版本 (監控中的設備，依名稱附加到設備列表)
+typedef struct {
+    char device[64];
+    char manufacturer[64];
+    char serial[20];
+    int capabilities;       // DANTE_DEVICE_CAP_AES67、DANTE_DEVICE_CAP_LOCKABLE
+} dante_identity_t;
+static dante_identity_t g_identity[MAX_DEVICES];
+static int g_identity_count = 0;
+
+// 韌體升級狀態 (送出升級請求的設備)
+static dante_upgrade_info_t g_upgrade[MAX_DEVICES];
+static int g_upgrade_count = 0;
+
+// 通道電平 (訂閱 metering channel 的設備，訊息量大所以限制數量)
+#define MAX_METERED_DEVICES 64
+static dante_meter_info_t g_meters[MAX_METERED_DEVICES];
+static int g_meter_count = 0;
+static int g_metering_registered = 0;
+
+// 已查詢到的通道數 (依名稱，型號或版本改變時重新查詢)
+// 能力查詢每台最多等 ROUTE_TIMEOUT_MS，每次瀏覽回調與刷新都查詢會卡住事件循環
+typedef struct {
+    char name[64];
+    char model[64];
+    char dante_version[32];
+    int tx_channels;
+    int rx_channels;
+    int redundant;
+} routing_cache_t;
+static routing_cache_t g_routing_cache[MAX_DEVICES];
+static int g_routing_cache_count = 0;
+
+// 保持開啟的遠端設備連線 (見 dante_device_open)
+static void close_open_devices(void);
+
+// 發現設備時讀取通道數與介面數 (見 query_routing_info)
+static void query_routing_info(dr_device_t* device, dante_device_info_t* info);
+static void routing_cache_prune(void);
+
+// 附加 ConMon 收到的製造商資訊 (見 apply_identity)
+static void apply_identity(dante_device_info_t* info);
+
+//==============================================================================
+// 回調函數 - 自動更新設備列表
+//==============================================================================
+/**
+ * 網路變更回調函數 - 當有設備加入/離開時自動更新列表
+ * 修復 Auto-IP 環境下的 IP 和 MAC 地址獲取問題
+ */
+static void browse_network_changed_callback(const db_browse_t* browse) {
+    printf("Network changed - auto-updating device list\n");
+    
+    // 清空現有列表
+    memset(g_discovered_devices, 0, sizeof(g_discovered_devices));
+    g_device_count = 0;
+    
+    const db_browse_network_t* network = db_browse_get_network(browse);
+    if (!network) {
+        return;
+    }
+    
+    uint16_t device_count = db_browse_network_get_num_devices(network);
+    
+    for (uint16_t i = 0; i < device_count && i < MAX_DEVICES; i++) {
+        const db_browse_device_t* device = db_browse_network_device_at_index(network, i);
+        if (!device) continue;
+        
+        dante_device_info_t* info = &g_discovered_devices[g_device_count];
+        
+        // 填充設備資訊
+        info->id = g_device_count + 1;
+        info->tx_channels = -1;
+        info->rx_channels = -1;
+        info->is_valid = 1;
+        
+        // 設備名稱
+        const char* name = db_browse_device_get_name(device);
+        if (name) {
+            snprintf(info->name, sizeof(info->name), "%s", name);
+        } else {
+            snprintf(info->name, sizeof(info->name), "Unknown Device %d", info->id);
+        }
+        
+        // 預設名稱（通常是型號）
+        // 嘗試獲取更好的型號資訊
+        const char* router_info = db_browse_device_get_router_info(device);
+        const dante_id64_t* mf_id = db_browse_device_get_manufacturer_id(device);
+        const dante_id64_t* model_id = db_browse_device_get_model_id(device);
+        const char* default_name = db_browse_device_get_default_name(device);
+
+        if (router_info && strlen(router_info) > 0) {
+            // 優先使用 router_info (如 "ULTIMOX4")
+            snprintf(info->model, sizeof(info->model), "%s", router_info);
+        } else if (mf_id && model_id) {
+            // 次選：組合製造商和型號 ID
+            char mf_buf[DANTE_ID64_DNSSD_BUF_LENGTH];
+            char model_buf[DANTE_ID64_DNSSD_BUF_LENGTH];
+            dante_id64_to_dnssd_text(mf_id, mf_buf);
+            dante_id64_to_dnssd_text(model_id, model_buf);
+            snprintf(info->model, sizeof(info->model), "%s-%s", mf_buf, model_buf);
+        } else if (default_name) {
+            // 最後選擇：使用 default_name
+            snprintf(info->model, sizeof(info->model), "%s", default_name);
+        } else {
+            snprintf(info->model, sizeof(info->model), "Unknown Model");
+        }
+
+        // 版本資訊
+        const dante_version_t* router_version = db_browse_device_get_router_version(device);
+        if (router_version) {
+            snprintf(info->dante_version, sizeof(info->dante_version), 
+                    "%u.%u.%u", router_version->major, router_version->minor, router_version->bugfix);
+        } else {
+            snprintf(info->dante_version, sizeof(info->dante_version), "Unknown");
+        }
+        
+        // 修正其他無法獲得的欄位
+        snprintf(info->product_version, sizeof(info->product_version), "N/A");
+
+        //IP
+      
+      printf("[DEBUG] Getting IP for device '%s' using routing API...\n", info->name);
+        
+        dr_device_t* routing_device = NULL;
+        aud_error_t result = dr_device_open_remote(g_devices, info->name, &routing_device);
+        
+        if (result == AUD_SUCCESS && routing_device) {
+            printf("[DEBUG] Successfully opened routing connection to '%s'\n", info->name);
+            
+            // 等待設備解析完成（最多等 3 秒）
+            int max_wait_attempts = 30; // 3 秒，每次 100ms
+            dr_device_state_t state;
+            
+            for (int attempt = 0; attempt < max_wait_attempts; attempt++) {
+                state = dr_device_get_state(routing_device);
+                
+                if (state == DR_DEVICE_STATE_RESOLVED || state == DR_DEVICE_STATE_ACTIVE) {
+                    printf("[DEBUG] Device '%s' resolved after %d attempts (state: %d)\n", 
+                           info->name, attempt, state);
+                    break;
+                }
+                
+                if (state == DR_DEVICE_STATE_ERROR) {
+                    printf("[ERROR] Device '%s' entered error state\n", info->name);
+                    break;
+                }
+                
+                // 處理一些 runtime 事件，讓解析繼續
+                if (g_runtime) {
+                    dante_runtime_process(g_runtime);
+                }
+                
+                usleep(100000); // 等待 100ms
+            }
+            
+            // 現在嘗試取得 IP 位址
+            if (state == DR_DEVICE_STATE_RESOLVED || state == DR_DEVICE_STATE_ACTIVE) {
+                dante_ipv4_address_t device_address;
+                aud_error_t addr_result = dr_device_get_address(routing_device, &device_address);
+                
+                if (addr_result == AUD_SUCCESS) {
+                    uint32_t ip_addr = ntohl(device_address.host);
+                    snprintf(info->ip_address, sizeof(info->ip_address), 
+                            "%u.%u.%u.%u", 
+                            (ip_addr >> 24) & 0xFF,
+                            (ip_addr >> 16) & 0xFF,
+                            (ip_addr >> 8) & 0xFF,
+                            ip_addr & 0xFF);
+                    
+                    printf("[INFO] Device '%s' IP: %s\n", info->name, info->ip_address);
+                } else {
+                    printf("[ERROR] Failed to get address for device '%s': %d\n", info->name, addr_result);
+                    snprintf(info->ip_address, sizeof(info->ip_address), "0.0.0.0");
+                }
+
+                query_routing_info(routing_device, info);
+            } else {
+                printf("[WARN] Device '%s' did not resolve in time (final state: %d)\n", info->name, state);
+                snprintf(info->ip_address, sizeof(info->ip_address), "0.0.0.0");
+            }
+            
+            // 關閉 routing device 連接
+            dr_device_close(routing_device);
+            
+        } else {
+            printf("[ERROR] Failed to open routing connection to device '%s': %d\n", info->name, result);
+            snprintf(info->ip_address, sizeof(info->ip_address), "0.0.0.0");
+        }
+
+
+
+
+                info->link_speed = -1;  // 用 -1 表示無效
+                g_device_count++;
+            }
+            
+            routing_cache_prune();
+            printf("Device list updated - now has %d devices\n", g_device_count);
+        }
+
+/**
+ * SDK 的網路變更通知：計數後更新列表
+ * Go 端比較計數決定是否刷新 (dante_refresh_device_scan 直接更新列表，不計入)
+ */
+static void browse_network_changed_notify(const db_browse_t* browse) {
+    g_change_count++;
+    browse_network_changed_callback(browse);
+}
+
+
+//==============================================================================
+// 基礎初始化和清理
+//==============================================================================
+
+/**
+ * 初始化 Dante API 環境
+ * @return 0 成功, -1 失敗
+ */
+
+int dante_init(void) {
+    // 使用 NULL 或空字串表示使用預設介面
+    return dante_init_with_interface(NULL);
+}
+
+int dante_init_with_interface(const char* interface_name) {
+    aud_error_t result;
+    
+    printf("Initializing Dante API...\n");
+    
+    // 建立 DAPI 環境
+    result = dapi_new(&g_dapi);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Failed to create DAPI: %d", result);
+        return -1;
+    }
+   
+    // 取得 runtime 和 env
+    g_env = dapi_get_env(g_dapi);
+    g_runtime = dapi_get_runtime(g_dapi);
+    if (!g_runtime || !g_env) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to get runtime/env");
+        dapi_delete(g_dapi);
+        g_dapi = NULL;
+        return -1;
+    }
+    
+    // 建立設備管理器
+    result = dr_devices_new_dapi(g_dapi, &g_devices);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Failed to create device manager: %d", result);
+        dapi_delete(g_dapi);
+        g_dapi = NULL;
+        return -1;
+    }
+    
+    // 初始化瀏覽配置
+    db_browse_config_init_defaults(&g_browse_config);
+
+     // 配置要使用的網卡
+    if (interface_name && interface_name[0] != '\0') {
+        printf("[INFO] Configuring browse to use interface: %s\n", interface_name);
+        
+        // 將網卡名稱轉換為 interface index
+        aud_interface_identifier_t iface;
+        memset(&iface, 0, sizeof(iface));
+        iface.flags = AUD_INTERFACE_IDENTIFIER_FLAG_NAME;
+        aud_strlcpy(iface.name, interface_name, AUD_INTERFACE_NAME_LENGTH);
+        
+        // 轉換名稱為 index
+        result = aud_interface_get_identifiers(g_env, &iface, 1);
+        if (result != AUD_SUCCESS) {
+            printf("[WARN] Failed to resolve interface '%s': %d\n", interface_name, result);
+            printf("[WARN] Will use default network settings\n");
+        } else {
+            // 設置到 browse config
+            g_browse_config.interface_indexes[0] = iface.index;
+            g_browse_config.num_interface_indexes = 1;
+            printf("[INFO] Interface '%s' resolved to index %u\n", interface_name, iface.index);
+        }
+    } else {
+        printf("[INFO] Using default network interface (auto-select)\n");
+    }
+    
+    printf("Dante API initialized successfully\n");
+    return 0;
+}
+
+/**
+ * 清理 Dante API 資源
+ */
+void dante_cleanup(void) {
+    printf("Cleaning up Dante API...\n");
+    
+    // 停止設備掃描
+    if (g_browse) {
+        dante_stop_device_scan();
+    }
+    
+    if (g_conmon) {
+        conmon_client_delete(g_conmon);
+        g_conmon = NULL;
+    }
+    g_conmon_registered = 0;
+    g_clock_count = 0;
+    g_identity_count = 0;
+    g_upgrade_count = 0;
+    g_meter_count = 0;
+    g_metering_registered = 0;
+    
+    close_open_devices();
+    
+    if (g_device) {
+        dr_device_close(g_device);
+        g_device = NULL;
+    }
+    
+    if (g_devices) {
+        dr_devices_delete(g_devices);
+        g_devices = NULL;
+    }
+    
+    if (g_dapi) {
+        dapi_delete(g_dapi);
+        g_dapi = NULL;
+    }
+    
+    g_runtime = NULL;
+    g_env = NULL;
+    g_device_ready = 0;
+    g_device_scan_active = 0;
+    g_background_scanning = 0;
+    g_device_count = 0;
+    memset(g_discovered_devices, 0, sizeof(g_discovered_devices));
+    g_routing_cache_count = 0;
+    
+    printf("Dante API cleanup completed\n");
+}
+
+/**
+ * 取得最後錯誤訊息
+ */
+const char* dante_get_last_error(void) {
+    return g_error_buffer;
+}
+
+#define DANTE_STRINGIFY_(x) #x
+#define DANTE_STRINGIFY(x) DANTE_STRINGIFY_(x)
+
+/**
+ * 取得編譯時的 Dante API 版本 (標頭檔與 libdapi 一起更新)
+ */
+const char* dante_get_sdk_version(void) {
+    return DANTE_STRINGIFY(DANTE_API_VERSION_MAJOR) "."
+           DANTE_STRINGIFY(DANTE_API_VERSION_MINOR) "."
+           DANTE_STRINGIFY(DANTE_API_VERSION_BUGFIX);
+}
+
+//==============================================================================
+// 設備連接和管理
+//==============================================================================
+
+/**
+ * 連接到本地 Dante 設備
+ * @return 0 成功, -1 失敗
+ */
+int dante_connect_local_device(void) {
+    aud_error_t result;
+    
+    if (!g_devices) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Dante not initialized");
+        return -1;
+    }
+    
+    printf("Connecting to local Dante device...\n");
+    
+    // 開啟本地設備連接
+    result = dr_device_open_local(g_devices, &g_device);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Failed to connect to local device: %d", result);
+        return -1;
+    }
+    
+    // 等待設備就緒
+    int timeout = 50; // 5秒超時
+    while (timeout-- > 0) {
+        dr_device_state_t state = dr_device_get_state(g_device);
+        if (state == DR_DEVICE_STATE_ACTIVE) {
+            g_device_ready = 1;
+            printf("Local device connected successfully\n");
+            return 0;
+        }
+        sleep(1); // 1秒
+    }
+    
+    snprintf(g_error_buffer, sizeof(g_error_buffer), "Device connection timeout");
+    return -1;
+}
+
+/**
+ * 檢查設備是否已連接
+ * @return 1 已連接, 0 未連接
+ */
+int dante_is_device_connected(void) {
+    if (!g_device) return 0;
+    
+    dr_device_state_t state = dr_device_get_state(g_device);
+    return (state == DR_DEVICE_STATE_ACTIVE) ? 1 : 0;
+}
+
+/**
+ * 取得設備名稱
+ * @param buffer 輸出緩衝區
+ * @param buffer_size 緩衝區大小
+ * @return 0 成功, -1 失敗
+ */
+int dante_get_device_name(char* buffer, int buffer_size) {
+    if (!g_device || !dante_is_device_connected()) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Device not connected");
+        return -1;
+    }
+    
+    const char* name = dr_device_get_name(g_device);
+    if (!name) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to get device name");
+        return -1;
+    }
+    
+    snprintf(buffer, buffer_size, "%s", name);
+    return 0;
+}
+
+//==============================================================================
+// 基礎路由資訊
+//==============================================================================
+
+/**
+ * 取得 TX 通道數量
+ * @return 通道數量, -1 表示錯誤
+ */
+int dante_get_tx_channel_count(void) {
+    if (!g_device || !dante_is_device_connected()) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Device not connected");
+        return -1;
+    }
+    
+    return dr_device_num_txchannels(g_device);
+}
+
+/**
+ * 取得 RX 通道數量
+ * @return 通道數量, -1 表示錯誤
+ */
+int dante_get_rx_channel_count(void) {
+    if (!g_device || !dante_is_device_connected()) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Device not connected");
+        return -1;
+    }
+    
+    return dr_device_num_rxchannels(g_device);
+}
+
+/**
+ * 取得 TX 通道名稱
+ * @param channel_index 通道索引 (0-based)
+ * @param buffer 輸出緩衝區
+ * @param buffer_size 緩衝區大小
+ * @return 0 成功, -1 失敗
+ */
+int dante_get_tx_channel_name(int channel_index, char* buffer, int buffer_size) {
+    if (!g_device || !dante_is_device_connected()) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Device not connected");
+        return -1;
+    }
+    
+    dr_txchannel_t* tx_channel = dr_device_txchannel_at_index(g_device, channel_index);
+    if (!tx_channel) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid TX channel index: %d", channel_index);
+        return -1;
+    }
+    
+    const char* name = dr_txchannel_get_canonical_name(tx_channel);
+    if (!name) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to get TX channel name");
+        return -1;
+    }
+    
+    snprintf(buffer, buffer_size, "%s", name);
+    return 0;
+}
+
+//==============================================================================
+// 背景設備掃描和發現功能
+//==============================================================================
+
+/**
+ * 啟動背景設備掃描（非阻塞）
+ * @return 0 成功, -1 失敗
+ */
+int dante_start_device_scan(void) {
+    aud_error_t result;
+    
+    if (!g_env) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Dante API not initialized");
+        return -1;
+    }
+    
+    if (g_browse) {
+        printf("Device scan already active\n");
+        return 0;
+    }
+    
+    printf("Starting background device scan...\n");
+    
+    // 建立瀏覽物件，掃描媒體設備和控制設備
+    db_browse_types_t browse_types = DB_BROWSE_TYPE_MEDIA_DEVICE | DB_BROWSE_TYPE_CONMON_DEVICE;
+    
+    result = db_browse_new(g_env, browse_types, &g_browse);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Failed to create browse object: %d", result);
+        return -1;
+    }
+    
+    // 設置最大 socket 數量
+    result = db_browse_set_max_sockets(g_browse, 32);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Failed to set max sockets: %d", result);
+        db_browse_delete(g_browse);
+        g_browse = NULL;
+        return -1;
+    }
+    
+    // 設置回調函數 - 關鍵！自動更新列表
+    db_browse_set_network_changed_callback(g_browse, browse_network_changed_notify);
+    
+    // 使用配置啟動瀏覽
+    result = db_browse_start_config(g_browse, &g_browse_config);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Failed to start browse: %d", result);
+        db_browse_delete(g_browse);
+        g_browse = NULL;
+        return -1;
+    }
+    
+    g_device_scan_active = 1;
+    g_background_scanning = 1;
+    printf("Background device scan started successfully\n");
+    
+    return 0;
+}
+
+/**
+ * 停止設備掃描
+ * @return 0 成功, -1 失敗
+ */
+int dante_stop_device_scan(void) {
+    if (!g_browse) {
+        return 0; // 已經停止
+    }
+    
+    printf("Stopping device scan...\n");
+    
+    db_browse_stop(g_browse);
+    db_browse_delete(g_browse);
+    g_browse = NULL;
+    g_device_scan_active = 0;
+    g_background_scanning = 0;
+    
+    printf("Device scan stopped\n");
+    return 0;
+}
+
+/**
+ * GO 調用這個來處理事件（短時間，非阻塞）
+ * @return 0 成功, -1 失敗
+ */
+int dante_process_events_briefly(void) {
+    if (!g_runtime || !g_background_scanning) {
+        return 0;
+    }
+    
+    // 只處理短時間的事件，然後立即返回
+    for (int i = 0; i < 5; i++) {  // 0.5秒
+        aud_error_t result = dante_runtime_process(g_runtime);
+        if (result != AUD_SUCCESS && result != AUD_ERR_DONE) {
+            // 忽略非嚴重錯誤
+        }
+        usleep(100000); // 100ms
+    }
+    
+    return 0;
+}
+
+/**
+ * GO 調用這個來取得當前設備列表（立即返回）
+ * @return 設備數量
+ */
+int dante_get_current_device_list(void) {
+    // 不做掃描，只返回當前已知的設備數量
+    return g_device_count;
+}
+
+/**
+ * 手動觸發設備列表更新
+ * @return 0 成功, -1 失敗
+ */
+int dante_refresh_device_scan(void) {
+    // 這個函數現在只是手動觸發一次列表更新
+    if (g_browse) {
+        browse_network_changed_callback(g_browse);
+    }
+    return 0;
+}
+
+/**
+ * 取得發現的設備數量
+ * @return 設備數量
+ */
+int dante_get_discovered_device_count(void) {
+    return g_device_count;
+}
+
+/**
+ * 取得 SDK 通知網路變更的次數
+ * @return 變更次數 (遞增，溢位時從 0 開始)
+ */
+int dante_get_change_count(void) {
+    return (int)(g_change_count & 0x7fffffff);
+}
+
+/**
+ * 取得指定設備的詳細資訊
+ * @param index 設備索引 (0-based)
+ * @param info 輸出的設備資訊結構
+ * @return 0 成功, -1 失敗
+ */
+int dante_get_device_info(int index, dante_device_info_t* info) {
+    if (!info) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid info pointer");
+        return -1;
+    }
+    
+    if (index < 0 || index >= g_device_count) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Invalid device index: %d (available: 0-%d)", index, g_device_count - 1);
+        return -1;
+    }
+    
+    if (!g_discovered_devices[index].is_valid) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Device at index %d is not valid", index);
+        return -1;
+    }
+    
+    // 複製設備資訊
+    *info = g_discovered_devices[index];
+    apply_identity(info);
+    return 0;
+}
+
+/**
+ * 一次取得所有已發現設備的資訊 (大型網路時避免每台設備一次 cgo 呼叫)
+ * @param list 輸出的設備資訊陣列
+ * @param max_count 陣列容量
+ * @return 寫入的設備數量, -1 失敗
+ */
+int dante_get_device_list(dante_device_info_t* list, int max_count) {
+    if (!list || max_count < 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid device list buffer");
+        return -1;
+    }
+
+    int count = 0;
+    for (int i = 0; i < g_device_count && count < max_count; i++) {
+        if (!g_discovered_devices[i].is_valid) {
+            continue;
+        }
+        list[count] = g_discovered_devices[i];
+        apply_identity(&list[count]);
+        count++;
+    }
+    return count;
+}
+
+//==============================================================================
+// 路由訂閱
+//==============================================================================
+
+#define ROUTE_TIMEOUT_MS 5000
+
+// 路由請求狀態 (同一時間只有一個請求)
+static int g_route_pending = 0;
+static aud_error_t g_route_result = AUD_SUCCESS;
+
+/**
+ * 路由請求回應回調
+ */
+static void route_response_callback(dr_device_t* device, dante_request_id_t request_id, aud_error_t result) {
+    (void) device;
+    (void) request_id;
+    g_route_result = result;
+    g_route_pending = 0;
+}
+
+/**
+ * 處理 runtime 事件直到請求完成
+ * @param what 請求說明 (錯誤訊息用)
+ * @return 0 成功, -1 失敗或逾時
+ */
+static int route_wait_response(const char* what) {
+    for (int waited = 0; g_route_pending && waited < ROUTE_TIMEOUT_MS; waited += 10) {
+        dante_runtime_process(g_runtime);
+        usleep(10000); // 10ms
+    }
+    
+    if (g_route_pending) {
+        g_route_pending = 0;
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "%s timed out", what);
+        return -1;
+    }
+    if (g_route_result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "%s failed: %d", what, g_route_result);
+        return -1;
+    }
+    return 0;
+}
+
+/**
+ * 發送路由請求並等待回應
+ * @return 0 成功, -1 失敗
+ */
+static int route_request(aud_error_t sent, const char* what) {
+    if (sent != AUD_SUCCESS) {
+        g_route_pending = 0;
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "%s failed: %d", what, sent);
+        return -1;
+    }
+    return route_wait_response(what);
+}
+
+//==============================================================================
+// 發現設備的通道數與介面數
+//==============================================================================
+
+// 能力查詢狀態 (與路由請求分開：瀏覽回調可能在等待路由回應時觸發)
+static int g_caps_pending = 0;
+static aud_error_t g_caps_result = AUD_SUCCESS;
+
+/**
+ * 找到設備的快取 (名稱、型號與版本都相同)
+ * @return 快取，沒有時 NULL
+ */
+static routing_cache_t* routing_cache_find(const dante_device_info_t* info) {
+    for (int i = 0; i < g_routing_cache_count; i++) {
+        routing_cache_t* c = &g_routing_cache[i];
+        if (strcmp(c->name, info->name) == 0) {
+            if (strcmp(c->model, info->model) == 0 && strcmp(c->dante_version, info->dante_version) == 0) {
+                return c;
+            }
+            return NULL;
+        }
+    }
+    return NULL;
+}
+
+/**
+ * 記錄查詢到的通道數 (同名的舊項目被取代)
+ */
+static void routing_cache_store(const dante_device_info_t* info) {
+    routing_cache_t* c = NULL;
+    for (int i = 0; i < g_routing_cache_count; i++) {
+        if (strcmp(g_routing_cache[i].name, info->name) == 0) {
+            c = &g_routing_cache[i];
+            break;
+        }
+    }
+    if (!c) {
+        if (g_routing_cache_count >= MAX_DEVICES) {
+            return;
+        }
+        c = &g_routing_cache[g_routing_cache_count++];
+    }
+    snprintf(c->name, sizeof(c->name), "%s", info->name);
+    snprintf(c->model, sizeof(c->model), "%s", info->model);
+    snprintf(c->dante_version, sizeof(c->dante_version), "%s", info->dante_version);
+    c->tx_channels = info->tx_channels;
+    c->rx_channels = info->rx_channels;
+    c->redundant = (info->capabilities & DANTE_DEVICE_CAP_REDUNDANCY) != 0;
+}
+
+/**
+ * 移除不在目前設備列表中的快取 (離開後再加入的設備重新查詢)
+ */
+static void routing_cache_prune(void) {
+    int kept = 0;
+    for (int i = 0; i < g_routing_cache_count; i++) {
+        for (int j = 0; j < g_device_count; j++) {
+            if (strcmp(g_routing_cache[i].name, g_discovered_devices[j].name) == 0) {
+                g_routing_cache[kept++] = g_routing_cache[i];
+                break;
+            }
+        }
+    }
+    g_routing_cache_count = kept;
+}
+
+/**
+ * 能力查詢回應回調
+ */
+static void caps_response_callback(dr_device_t* device, dante_request_id_t request_id, aud_error_t result) {
+    (void) device;
+    (void) request_id;
+    g_caps_result = result;
+    g_caps_pending = 0;
+}
+
+/**
+ * 讀取已解析設備的 TX/RX 通道數與是否有次要介面 (需要先查詢能力)
+ * 已查詢過的設備使用快取；查詢失敗或逾時時通道數保留 -1，下次再試
+ */
+static void query_routing_info(dr_device_t* device, dante_device_info_t* info) {
+    const routing_cache_t* cached = routing_cache_find(info);
+    if (cached) {
+        info->tx_channels = cached->tx_channels;
+        info->rx_channels = cached->rx_channels;
+        if (cached->redundant) {
+            info->capabilities |= DANTE_DEVICE_CAP_REDUNDANCY;
+        }
+        return;
+    }
+    if (dr_device_get_state(device) == DR_DEVICE_STATE_RESOLVED) {
+        dante_request_id_t request_id;
+        g_caps_pending = 1;
+        aud_error_t sent = dr_device_query_capabilities(device, caps_response_callback, &request_id);
+        if (sent != AUD_SUCCESS) {
+            g_caps_pending = 0;
+            printf("[WARN] Failed to query capabilities of '%s': %d\n", info->name, sent);
+            return;
+        }
+        for (int waited = 0; g_caps_pending && waited < ROUTE_TIMEOUT_MS; waited += 10) {
+            dante_runtime_process(g_runtime);
+            usleep(10000); // 10ms
+        }
+        if (g_caps_pending || g_caps_result != AUD_SUCCESS) {
+            g_caps_pending = 0;
+            printf("[WARN] Capabilities of '%s' not available\n", info->name);
+            return;
+        }
+    }
+    if (dr_device_get_state(device) != DR_DEVICE_STATE_ACTIVE) {
+        return;
+    }
+    info->tx_channels = dr_device_num_txchannels(device);
+    info->rx_channels = dr_device_num_rxchannels(device);
+    if (dr_device_num_interfaces(device) >= 2) {
+        info->capabilities |= DANTE_DEVICE_CAP_REDUNDANCY;
+    }
+    routing_cache_store(info);
+}
+
+//==============================================================================
+// 保持開啟的遠端設備連線
+//==============================================================================
+
+#define MAX_OPEN_DEVICES 32
+
+// dante_device_open 開啟的連線 (依名稱，refs 為 open 的次數)
+static struct {
+    char name[64];
+    dr_device_t* device;
+    int refs;
+} g_open_devices[MAX_OPEN_DEVICES];
+
+/**
+ * 尋找保持開啟的連線
+ * @return 表格索引, -1 表示沒有
+ */
+static int open_device_index(const char* name) {
+    for (int i = 0; i < MAX_OPEN_DEVICES; i++) {
+        if (g_open_devices[i].device && strcmp(g_open_devices[i].name, name) == 0) {
+            return i;
+        }
+    }
+    return -1;
+}
+
+/**
+ * 結束一次操作：保持開啟的連線留給之後的操作，其他的關閉
+ */
+static void device_release(dr_device_t* device) {
+    for (int i = 0; i < MAX_OPEN_DEVICES; i++) {
+        if (g_open_devices[i].device == device) {
+            return;
+        }
+    }
+    dr_device_close(device);
+}
+
+/**
+ * 關閉所有保持開啟的連線 (dante_cleanup)
+ */
+static void close_open_devices(void) {
+    for (int i = 0; i < MAX_OPEN_DEVICES; i++) {
+        if (g_open_devices[i].device) {
+            dr_device_close(g_open_devices[i].device);
+        }
+    }
+    memset(g_open_devices, 0, sizeof(g_open_devices));
+}
+
+/**
+ * 開啟遠端設備並讀取接收通道
+ * 設備有 dante_device_open 保持的連線時直接使用 (只重新讀取接收通道)
+ * @param name 設備名稱
+ * @return 設備物件, NULL 表示失敗 (呼叫者負責 device_release)
+ */
+static dr_device_t* route_open_device(const char* name) {
+    dr_device_t* device = NULL;
+    dante_request_id_t request_id;
+    
+    if (!g_devices || !g_runtime) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Dante not initialized");
+        return NULL;
+    }
+    
+    int held = open_device_index(name);
+    if (held >= 0 && dr_device_get_state(g_open_devices[held].device) == DR_DEVICE_STATE_ACTIVE) {
+        device = g_open_devices[held].device;
+        g_route_pending = 1;
+        if (route_request(dr_device_update_component(device, route_response_callback, &request_id,
+                                                     DR_DEVICE_COMPONENT_RXCHANNELS),
+                          "Update RX channels") != 0) {
+            return NULL;
+        }
+        return device;
+    }
+    
+    aud_error_t result = dr_device_open_remote(g_devices, name, &device);
+    if (result != AUD_SUCCESS || !device) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Failed to open device '%s': %d", name, result);
+        return NULL;
+    }
+    
+    // 等待設備名稱解析
+    dr_device_state_t state = dr_device_get_state(device);
+    for (int waited = 0; state == DR_DEVICE_STATE_RESOLVING && waited < ROUTE_TIMEOUT_MS; waited += 10) {
+        dante_runtime_process(g_runtime);
+        usleep(10000); // 10ms
+        state = dr_device_get_state(device);
+    }
+    
+    if (state != DR_DEVICE_STATE_RESOLVED && state != DR_DEVICE_STATE_ACTIVE) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "Device '%s' did not resolve (state: %d)", name, state);
+        dr_device_close(device);
+        return NULL;
+    }
+    
+    // 查詢能力後設備進入 ACTIVE
+    if (state == DR_DEVICE_STATE_RESOLVED) {
+        g_route_pending = 1;
+        if (route_request(dr_device_query_capabilities(device, route_response_callback, &request_id),
+                          "Query capabilities") != 0) {
+            dr_device_close(device);
+            return NULL;
+        }
+    }
+    
+    // 讀取接收通道 (含訂閱)
+    g_route_pending = 1;
+    if (route_request(dr_device_update_component(device, route_response_callback, &request_id,
+                                                 DR_DEVICE_COMPONENT_RXCHANNELS),
+                      "Update RX channels") != 0) {
+        dr_device_close(device);
+        return NULL;
+    }
+    
+    return device;
+}
+
+/**
+ * 開啟並保持遠端設備的連線，之後以名稱操作這台設備時重複使用
+ * 同一台設備可以開啟多次，關閉相同次數後才真正關閉
+ * @param device 設備名稱
+ * @return 0 成功, -1 失敗
+ */
+int dante_device_open(const char* device) {
+    if (!device || !device[0] || strlen(device) >= sizeof(g_open_devices[0].name)) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+    
+    int held = open_device_index(device);
+    if (held >= 0) {
+        if (dr_device_get_state(g_open_devices[held].device) == DR_DEVICE_STATE_ACTIVE) {
+            g_open_devices[held].refs++;
+            return 0;
+        }
+        // 連線已中斷，重新開啟 (保留開啟次數)
+        dr_device_close(g_open_devices[held].device);
+        g_open_devices[held].device = NULL;
+    }
+    
+    int slot = -1;
+    for (int i = 0; i < MAX_OPEN_DEVICES; i++) {
+        if (!g_open_devices[i].device) {
+            slot = i;
+            break;
+        }
+    }
+    if (slot < 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer),
+                "Too many open devices (at most %d)", MAX_OPEN_DEVICES);
+        return -1;
+    }
+    
+    dr_device_t* dev = route_open_device(device);
+    if (!dev) {
+        return -1;
+    }
+    snprintf(g_open_devices[slot].name, sizeof(g_open_devices[slot].name), "%s", device);
+    g_open_devices[slot].device = dev;
+    g_open_devices[slot].refs = held >= 0 ? g_open_devices[held].refs + 1 : 1;
+    if (held >= 0 && held != slot) {
+        memset(&g_open_devices[held], 0, sizeof(g_open_devices[held]));
+    }
+    return 0;
+}
+
+/**
+ * 關閉 dante_device_open 開啟的連線
+ * @param device 設備名稱
+ * @return 0 成功, -1 設備沒有開啟
+ */
+int dante_device_close(const char* device) {
+    int held = device ? open_device_index(device) : -1;
+    if (held < 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Device '%s' is not open", device ? device : "");
+        return -1;
+    }
+    if (--g_open_devices[held].refs > 0) {
+        return 0;
+    }
+    dr_device_close(g_open_devices[held].device);
+    memset(&g_open_devices[held], 0, sizeof(g_open_devices[held]));
+    return 0;
+}
+
+/**
+ * 依名稱或編號尋找接收通道
+ */
+static dr_rxchannel_t* route_find_rxchannel(dr_device_t* device, const char* channel) {
+    char* end = NULL;
+    long id = strtol(channel, &end, 10);
+    if (end && end != channel && *end == '\0') {
+        return dr_device_rxchannel_with_id(device, (dante_id_t) id);
+    }
+    
+    uint16_t count = dr_device_num_rxchannels(device);
+    for (uint16_t i = 0; i < count; i++) {
+        dr_rxchannel_t* rx = dr_device_rxchannel_at_index(device, i);
+        const char* name = rx ? dr_rxchannel_get_name(rx) : NULL;
+        if (name && strcmp(name, channel) == 0) {
+            return rx;
+        }
+    }
+    return NULL;
+}
+
+/**
+ * 列出設備所有接收通道的訂閱
+ * @param rx_device 接收設備名稱
+ * @param list 輸出陣列
+ * @param max_count 陣列大小
+ * @return 通道數量, -1 表示失敗
+ */
+int dante_route_list(const char* rx_device, dante_subscription_info_t* list, int max_count) {
+    if (!rx_device || !list) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+    
+    dr_device_t* device = route_open_device(rx_device);
+    if (!device) {
+        return -1;
+    }
+    
+    int count = 0;
+    uint16_t num_channels = dr_device_num_rxchannels(device);
+    for (uint16_t i = 0; i < num_channels && count < max_count; i++) {
+        dr_rxchannel_t* rx = dr_device_rxchannel_at_index(device, i);
+        if (!rx) continue;
+        
+        dante_subscription_info_t* info = &list[count++];
+        memset(info, 0, sizeof(*info));
+        info->id = dr_rxchannel_get_id(rx);
+        
+        const char* name = dr_rxchannel_get_name(rx);
+        const char* tx_channel = dr_rxchannel_get_subscription_channel(rx);
+        const char* tx_device = dr_rxchannel_get_subscription_device(rx);
+        snprintf(info->name, sizeof(info->name), "%s", name ? name : "");
+        snprintf(info->tx_channel, sizeof(info->tx_channel), "%s", tx_channel ? tx_channel : "");
+        snprintf(info->tx_device, sizeof(info->tx_device), "%s", tx_device ? tx_device : "");
+        info->status = dr_rxchannel_get_status(rx);
+    }
+    
+    device_release(device);
+    return count;
+}
+
+/**
+ * 設定接收通道的訂閱
+ * @param rx_device 接收設備名稱
+ * @param rx_channel 接收通道名稱或編號
+ * @param tx_device 發送設備名稱 (NULL 或空字串表示取消訂閱)
+ * @param tx_channel 發送通道名稱
+ * @return 0 成功, -1 失敗
+ */
+int dante_route_subscribe(const char* rx_device, const char* rx_channel,
+                          const char* tx_device, const char* tx_channel) {
+    dante_request_id_t request_id;
+    
+    if (!rx_device || !rx_channel) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+    
+    dr_device_t* device = route_open_device(rx_device);
+    if (!device) {
+        return -1;
+    }
+    
+    dr_rxchannel_t* rx = route_find_rxchannel(device, rx_channel);
+    if (!rx) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), 
+                "RX channel '%s' not found on '%s'", rx_channel, rx_device);
+        device_release(device);
+        return -1;
+    }
+    
+    // NULL 表示取消訂閱
+    if (tx_device && tx_device[0] == '\0') {
+        tx_device = NULL;
+        tx_channel = NULL;
+    }
+    
+    g_route_pending = 1;
+    int result = route_request(dr_rxchannel_subscribe(rx, route_response_callback, &request_id,
+                                                      tx_device, tx_channel),
+                               "Subscribe");
+    
+    device_release(device);
+    return result;
+}
+
+//==============================================================================
+// ConMon 監控 (時鐘狀態、識別)
+//==============================================================================
+
+#define CONMON_TIMEOUT_MS 3000
+
+// ConMon 請求狀態 (同一時間只有一個同步請求)
+static int g_conmon_pending = 0;
+static aud_error_t g_conmon_result = AUD_SUCCESS;
+
+/**
+ * ConMon 同步請求回應回調
+ */
+static void conmon_response_callback(conmon_client_t* client, conmon_client_request_id_t request_id, aud_error_t result) {
+    (void) client;
+    (void) request_id;
+    g_conmon_result = result;
+    g_conmon_pending = 0;
+}
+
+/**
+ * ConMon 非同步請求回應 (只記錄失敗)
+ */
+static void conmon_async_callback(conmon_client_t* client, conmon_client_request_id_t request_id, aud_error_t result) {
+    (void) client;
+    (void) request_id;
+    if (result != AUD_SUCCESS) {
+        printf("[WARN] ConMon request failed: %d\n", result);
+    }
+}
+
+/**
+ * 尋找 (或新增) 設備的時鐘狀態
+ */
+static dante_clock_info_t* clock_info_for(const char* device, int create) {
+    for (int i = 0; i < g_clock_count; i++) {
+        if (strcmp(g_clock_info[i].device, device) == 0) {
+            return &g_clock_info[i];
+        }
+    }
+    if (!create || g_clock_count >= MAX_DEVICES) {
+        return NULL;
+    }
+    dante_clock_info_t* info = &g_clock_info[g_clock_count++];
+    memset(info, 0, sizeof(*info));
+    snprintf(info->device, sizeof(info->device), "%s", device);
+    return info;
+}
+
+/**
+ * 尋找 (或新增) 設備的製造商資訊
+ */
+static dante_identity_t* identity_for(const char* device, int create) {
+    for (int i = 0; i < g_identity_count; i++) {
+        if (strcmp(g_identity[i].device, device) == 0) {
+            return &g_identity[i];
+        }
+    }
+    if (!create || g_identity_count >= MAX_DEVICES) {
+        return NULL;
+    }
+    dante_identity_t* identity = &g_identity[g_identity_count++];
+    memset(identity, 0, sizeof(*identity));
+    snprintf(identity->device, sizeof(identity->device), "%s", device);
+    return identity;
+}
+
+/**
+ * 記錄製造商版本訊息中的製造商、序號與能力
+ */
+static void update_identity(const char* device, const conmon_message_body_t* body) {
+    dante_identity_t* identity = identity_for(device, 1);
+    if (!identity) {
+        return;
+    }
+    
+    const char* manufacturer = conmon_audinate_manf_versions_status_get_manufacturer_name(body);
+    snprintf(identity->manufacturer, sizeof(identity->manufacturer), "%s", manufacturer ? manufacturer : "");
+    
+    const dante_device_id_t* serial = conmon_audinate_manf_versions_status_get_serial_id(body);
+    if (serial && dante_device_id_is_non_zero(serial)) {
+        dante_device_id_str_t buf;
+        snprintf(identity->serial, sizeof(identity->serial), "%s", dante_device_id_to_string(serial, buf));
+    }
+    
+    uint32_t caps = conmon_audinate_manf_versions_status_get_capabilities(body);
+    identity->capabilities = 0;
+    if (caps & CONMON_AUDINATE_CAPABILITY_SUPPORTS_AES67) {
+        identity->capabilities |= DANTE_DEVICE_CAP_AES67;
+    }
+    if (caps & CONMON_AUDINATE_CAPABILITY_CAN_LOCK) {
+        identity->capabilities |= DANTE_DEVICE_CAP_LOCKABLE;
+    }
+    if (caps & CONMON_AUDINATE_CAPABILITY_HAS_METERING) {
+        identity->capabilities |= DANTE_DEVICE_CAP_METERING;
+    }
+}
+
+/**
+ * 附加 ConMon 收到的製造商資訊 (沒有收到時不變)
+ */
+static void apply_identity(dante_device_info_t* info) {
+    dante_identity_t* identity = identity_for(info->name, 0);
+    if (!identity) {
+        return;
+    }
+    snprintf(info->manufacturer, sizeof(info->manufacturer), "%s", identity->manufacturer);
+    snprintf(info->serial, sizeof(info->serial), "%s", identity->serial);
+    info->capabilities |= identity->capabilities;
+}
+
+/**
+ * 尋找 (或新增) 設備的韌體升級狀態
+ */
+static dante_upgrade_info_t* upgrade_info_for(const char* device, int create) {
+    for (int i = 0; i < g_upgrade_count; i++) {
+        if (strcmp(g_upgrade[i].device, device) == 0) {
+            return &g_upgrade[i];
+        }
+    }
+    if (!create || g_upgrade_count >= MAX_DEVICES) {
+        return NULL;
+    }
+    dante_upgrade_info_t* info = &g_upgrade[g_upgrade_count++];
+    memset(info, 0, sizeof(*info));
+    snprintf(info->device, sizeof(info->device), "%s", device);
+    return info;
+}
+
+/**
+ * 記錄升級狀態訊息 (只記錄已送出升級請求的設備)
+ */
+static void update_upgrade(const char* device, const conmon_message_head_t* head, const conmon_message_body_t* body) {
+    dante_upgrade_info_t* info = upgrade_info_for(device, 0);
+    if (!info) {
+        return;
+    }
+    
+    conmon_audinate_upgrade_status_t status;
+    if (conmon_audinate_upgrade_status_get_upgrade_status(body, conmon_message_head_get_body_size(head), &status) != AUD_SUCCESS) {
+        return;
+    }
+    info->state = status.status;
+    info->last_error = status.last_error;
+    const char* error = status.last_error ? conmon_audinate_upgrade_error_to_string(status.last_error) : "";
+    snprintf(info->error, sizeof(info->error), "%s", error ? error : "");
+    info->progress_curr = status.progress.curr;
+    info->progress_total = status.progress.total;
+    info->updated = (long long) time(NULL);
+}
+
+/**
+ * Status channel 訊息回調 - 更新時鐘狀態、製造商資訊與升級狀態
+ */
+static void conmon_status_callback(conmon_client_t* client, conmon_channel_type_t channel_type,
+                                   conmon_channel_direction_t channel_direction,
+                                   const conmon_message_head_t* head, const conmon_message_body_t* body) {
+    (void) channel_type;
+    (void) channel_direction;
+    
+    conmon_audinate_message_type_t type = conmon_audinate_message_get_type(body);
+    if (type != CONMON_AUDINATE_MESSAGE_TYPE_CLOCKING_STATUS &&
+        type != CONMON_AUDINATE_MESSAGE_TYPE_MANF_VERSIONS_STATUS &&
+        type != CONMON_AUDINATE_MESSAGE_TYPE_UPGRADE_STATUS) {
+        return;
+    }
+    
+    conmon_instance_id_t instance_id;
+    conmon_message_head_get_instance_id(head, &instance_id);
+    const char* device = conmon_client_device_name_for_instance_id(client, &instance_id);
+    if (!device) {
+        return;
+    }
+    
+    if (type == CONMON_AUDINATE_MESSAGE_TYPE_MANF_VERSIONS_STATUS) {
+        update_identity(device, body);
+        return;
+    }
+    if (type == CONMON_AUDINATE_MESSAGE_TYPE_UPGRADE_STATUS) {
+        update_upgrade(device, head, body);
+        return;
+    }
+    
+    dante_clock_info_t* info = clock_info_for(device, 0);
+    if (!info) {
+        return;
+    }
+    
+    snprintf(info->clock_state, sizeof(info->clock_state), "%s",
+             conmon_audinate_clock_state_string(conmon_audinate_clocking_status_get_clock_state(body)));
+    snprintf(info->servo_state, sizeof(info->servo_state), "%s",
+             conmon_audinate_servo_state_string(conmon_audinate_clocking_status_get_servo_state(body)));
+    snprintf(info->clock_source, sizeof(info->clock_source), "%s",
+             conmon_audinate_clock_source_string(conmon_audinate_clocking_status_get_clock_source(body)));
+    
+    const conmon_audinate_clock_uuid_t* uuid = conmon_audinate_clocking_status_get_uuid(body);
+    const conmon_audinate_clock_uuid_t* gm = conmon_audinate_clocking_status_get_grandmaster_uuid(body);
+    info->is_grandmaster = (uuid && gm && memcmp(uuid->data, gm->data, CONMON_AUDINATE_CLOCK_UUID_LENGTH) == 0);
+    info->updated = (long long) time(NULL);
+}
+
+/**
+ * 尋找 (或新增) 設備的通道電平
+ */
+static dante_meter_info_t* meter_info_for(const char* device, int create) {
+    for (int i = 0; i < g_meter_count; i++) {
+        if (strcmp(g_meters[i].device, device) == 0) {
+            return &g_meters[i];
+        }
+    }
+    if (!create || g_meter_count >= MAX_METERED_DEVICES) {
+        return NULL;
+    }
+    dante_meter_info_t* info = &g_meters[g_meter_count++];
+    memset(info, 0, sizeof(*info));
+    snprintf(info->device, sizeof(info->device), "%s", device);
+    return info;
+}
+
+/**
+ * Metering channel 訊息回調 - 記錄已訂閱設備的 TX/RX peak
+ */
+static void conmon_metering_callback(conmon_client_t* client, conmon_channel_type_t channel_type,
+                                     conmon_channel_direction_t channel_direction,
+                                     const conmon_message_head_t* head, const conmon_message_body_t* body) {
+    (void) channel_type;
+    (void) channel_direction;
+    
+    conmon_instance_id_t instance_id;
+    conmon_message_head_get_instance_id(head, &instance_id);
+    const char* device = conmon_client_device_name_for_instance_id(client, &instance_id);
+    dante_meter_info_t* info = device ? meter_info_for(device, 0) : NULL;
+    if (!info) {
+        return;
+    }
+    
+    conmon_metering_message_version_t version;
+    uint16_t num_tx = 0, num_rx = 0;
+    if (conmon_metering_message_parse(body, &version, &num_tx, &num_rx) != AUD_SUCCESS) {
+        return;
+    }
+    info->num_tx = num_tx < DANTE_MAX_METER_CHANNELS ? num_tx : DANTE_MAX_METER_CHANNELS;
+    info->num_rx = num_rx < DANTE_MAX_METER_CHANNELS ? num_rx : DANTE_MAX_METER_CHANNELS;
+    
+    const conmon_metering_message_peak_t* tx = conmon_metering_message_get_peaks_const(body, CONMON_CHANNEL_DIRECTION_TX);
+    const conmon_metering_message_peak_t* rx = conmon_metering_message_get_peaks_const(body, CONMON_CHANNEL_DIRECTION_RX);
+    if (tx) {
+        memcpy(info->tx_peak, tx, (size_t) info->num_tx);
+    }
+    if (rx) {
+        memcpy(info->rx_peak, rx, (size_t) info->num_rx);
+    }
+    info->updated = (long long) time(NULL);
+}
+
+/**
+ * 連線狀態改變 - 連上後註冊 status channel 與 metering channel
+ */
+static void conmon_connection_callback(conmon_client_t* client) {
+    if (conmon_client_state(client) != CONMON_CLIENT_CONNECTED) {
+        g_conmon_registered = 0;
+        g_metering_registered = 0;
+        return;
+    }
+    
+    conmon_client_request_id_t request_id;
+    aud_error_t result;
+    if (!g_conmon_registered) {
+        result = conmon_client_register_monitoring_messages(client, conmon_async_callback, &request_id,
+                                                            CONMON_CHANNEL_TYPE_STATUS, CONMON_CHANNEL_DIRECTION_RX,
+                                                            conmon_status_callback);
+        if (result == AUD_SUCCESS) {
+            g_conmon_registered = 1;
+            printf("[INFO] ConMon connected - status channel registered\n");
+        } else {
+            printf("[WARN] Failed to register ConMon status channel: %d\n", result);
+        }
+    }
+    
+    // 其他程式佔用 metering 埠時沒有電平，其餘監控照常
+    if (!g_metering_registered && conmon_client_is_metering_channel_active(client)) {
+        result = conmon_client_register_monitoring_messages(client, conmon_async_callback, &request_id,
+                                                            CONMON_CHANNEL_TYPE_METERING, CONMON_CHANNEL_DIRECTION_RX,
+                                                            conmon_metering_callback);
+        if (result == AUD_SUCCESS) {
+            g_metering_registered = 1;
+        } else {
+            printf("[WARN] Failed to register ConMon metering channel: %d\n", result);
+        }
+    }
+}
+
+/**
+ * 發送 ConMon 同步請求並等待回應
+ * @return 0 成功, -1 失敗
+ */
+static int conmon_wait_response(aud_error_t sent, const char* what) {
+    if (sent != AUD_SUCCESS) {
+        g_conmon_pending = 0;
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "%s failed: %d", what, sent);
+        return -1;
+    }
+    
+    for (int waited = 0; g_conmon_pending && waited < CONMON_TIMEOUT_MS; waited += 10) {
+        dante_runtime_process(g_runtime);
+        usleep(10000); // 10ms
+    }
+    
+    if (g_conmon_pending) {
+        g_conmon_pending = 0;
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "%s timed out", what);
+        return -1;
+    }
+    if (g_conmon_result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "%s failed: %d", what, g_conmon_result);
+        return -1;
+    }
+    return 0;
+}
+
+/**
+ * 建立 ConMon client 並開始自動連線到本機 ConMon server
+ * @return 0 成功, -1 失敗
+ */
+int dante_monitor_start(void) {
+    if (g_conmon) {
+        return 0;
+    }
+    if (!g_dapi) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Dante API not initialized");
+        return -1;
+    }
+    
+    conmon_client_config_t* config = conmon_client_config_new("golane");
+    if (!config) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to create ConMon config");
+        return -1;
+    }
+    conmon_client_config_set_metering_channel_enabled(config, AUD_TRUE);
+    conmon_client_config_allow_metering_channel_init_failure(config, AUD_TRUE);
+    
+    aud_error_t result = conmon_client_new_dapi(g_dapi, config, &g_conmon);
+    conmon_client_config_delete(config);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to create ConMon client: %d", result);
+        g_conmon = NULL;
+        return -1;
+    }
+    
+    conmon_client_set_connection_state_changed_callback(g_conmon, conmon_connection_callback);
+    
+    result = conmon_client_auto_connect(g_conmon);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "ConMon auto-connect failed: %d", result);
+        conmon_client_delete(g_conmon);
+        g_conmon = NULL;
+        return -1;
+    }
+    
+    printf("[INFO] ConMon client started\n");
+    return 0;
+}
+
+/**
+ * 訂閱設備的 status channel 並查詢時鐘狀態與製造商版本 (重複呼叫只會重新查詢)
+ * @param device 設備名稱
+ * @return 0 成功, -1 失敗
+ */
+int dante_monitor_watch_device(const char* device) {
+    conmon_client_request_id_t request_id;
+    
+    if (!g_conmon || conmon_client_state(g_conmon) != CONMON_CLIENT_CONNECTED) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "ConMon not connected");
+        return -1;
+    }
+    
+    if (!clock_info_for(device, 0)) {
+        if (!clock_info_for(device, 1)) {
+            snprintf(g_error_buffer, sizeof(g_error_buffer), "Too many monitored devices");
+            return -1;
+        }
+        aud_error_t result = conmon_client_subscribe(g_conmon, conmon_async_callback, &request_id,
+                                                     CONMON_CHANNEL_TYPE_STATUS, device);
+        if (result != AUD_SUCCESS) {
+            snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to subscribe to '%s': %d", device, result);
+            return -1;
+        }
+    }
+    
+    // 空的 clocking control 訊息即為狀態查詢，回應由 status channel 送回
+    conmon_message_body_t body;
+    conmon_audinate_init_clocking_control(&body, 0);
+    aud_error_t result = conmon_client_send_control_message(g_conmon, conmon_async_callback, &request_id,
+                                                            device, CONMON_MESSAGE_CLASS_VENDOR_SPECIFIC,
+                                                            CONMON_VENDOR_ID_AUDINATE, &body,
+                                                            conmon_audinate_clocking_control_get_size(&body), NULL);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to query clock of '%s': %d", device, result);
+        return -1;
+    }
+    
+    // 製造商版本 (序號、製造商、能力) 同樣由 status channel 送回
+    conmon_audinate_init_query_message(&body, CONMON_AUDINATE_MESSAGE_TYPE_MANF_VERSIONS_QUERY, 0);
+    result = conmon_client_send_control_message(g_conmon, conmon_async_callback, &request_id,
+                                                device, CONMON_MESSAGE_CLASS_VENDOR_SPECIFIC,
+                                                CONMON_VENDOR_ID_AUDINATE, &body,
+                                                conmon_audinate_query_message_get_size(&body), NULL);
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to query versions of '%s': %d", device, result);
+        return -1;
+    }
+    return 0;
+}
+
+/**
+ * 取得設備最新的時鐘狀態
+ * @return 0 成功, -1 尚未收到狀態
+ */
+int dante_get_clock_info(const char* device, dante_clock_info_t* info) {
+    dante_clock_info_t* found = device ? clock_info_for(device, 0) : NULL;
+    if (!info || !found || found->updated == 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "No clock status for '%s'", device ? device : "");
+        return -1;
+    }
+    *info = *found;
+    return 0;
+}
+
+/**
+ * 訂閱設備的 metering channel (重複呼叫不會重複訂閱)
+ * @param device 設備名稱
+ * @return 0 成功, -1 失敗
+ */
+int dante_monitor_watch_meters(const char* device) {
+    conmon_client_request_id_t request_id;
+    
+    if (!g_conmon || conmon_client_state(g_conmon) != CONMON_CLIENT_CONNECTED) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "ConMon not connected");
+        return -1;
+    }
+    if (!g_metering_registered) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "ConMon metering channel not available");
+        return -1;
+    }
+    if (meter_info_for(device, 0)) {
+        return 0;
+    }
+    if (!meter_info_for(device, 1)) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Too many metered devices");
+        return -1;
+    }
+    
+    aud_error_t result = conmon_client_subscribe(g_conmon, conmon_async_callback, &request_id,
+                                                 CONMON_CHANNEL_TYPE_METERING, device);
+    if (result != AUD_SUCCESS) {
+        g_meter_count--;
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to subscribe to meters of '%s': %d", device, result);
+        return -1;
+    }
+    return 0;
+}
+
+/**
+ * 取得設備最新的通道電平
+ * @return 0 成功, -1 尚未收到電平 (設備不支援 metering 時不會收到)
+ */
+int dante_get_meters(const char* device, dante_meter_info_t* info) {
+    dante_meter_info_t* found = device ? meter_info_for(device, 0) : NULL;
+    if (!info || !found || found->updated == 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "No metering data for '%s'", device ? device : "");
+        return -1;
+    }
+    *info = *found;
+    return 0;
+}
+
+/**
+ * 讓設備以自身方式 (閃燈等) 識別自己
+ * @return 0 成功, -1 失敗
+ */
+int dante_identify_device(const char* device) {
+    conmon_client_request_id_t request_id;
+    
+    if (!g_conmon || conmon_client_state(g_conmon) != CONMON_CLIENT_CONNECTED) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "ConMon not connected");
+        return -1;
+    }
+    
+    conmon_message_body_t body;
+    conmon_audinate_init_query_message(&body, CONMON_AUDINATE_MESSAGE_TYPE_IDENTIFY_QUERY, 0);
+    
+    g_conmon_pending = 1;
+    return conmon_wait_response(
+        conmon_client_send_control_message(g_conmon, conmon_response_callback, &request_id,
+                                           device, CONMON_MESSAGE_CLASS_VENDOR_SPECIFIC,
+                                           CONMON_VENDOR_ID_AUDINATE, &body,
+                                           conmon_audinate_query_message_get_size(&body), NULL),
+        "Identify");
+}
+
+/**
+ * 要求設備從檔案伺服器下載並寫入韌體 (進度由 status channel 送回，
+ * 呼叫前須先以 dante_monitor_watch_device 訂閱)
+ * @param protocol CONMON_AUDINATE_UPGRADE_PROTOCOL_TFTP_GET/HTTP/HTTPS
+ * @param server 檔案伺服器 IPv4 位址
+ * @param port 伺服器埠 (0 表示協定預設值)
+ * @param path 伺服器上的 .dnt 檔案路徑
+ * @return 0 成功, -1 失敗
+ */
+int dante_firmware_upgrade(const char* device, int protocol, const char* server, int port, const char* path) {
+    conmon_client_request_id_t request_id;
+    
+    if (!g_conmon || conmon_client_state(g_conmon) != CONMON_CLIENT_CONNECTED) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "ConMon not connected");
+        return -1;
+    }
+    
+    conmon_audinate_upgrade_source_file_t file;
+    memset(&file, 0, sizeof(file));
+    file.protocol = (uint16_t) protocol;
+    file.port = (uint16_t) port;
+    file.filename = path;
+    if (inet_pton(AF_INET, server, &file.addr_inet) != 1) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid upgrade server: %s", server);
+        return -1;
+    }
+    
+    dante_upgrade_info_t* info = upgrade_info_for(device, 1);
+    if (!info) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Too many upgrading devices");
+        return -1;
+    }
+    
+    conmon_message_body_t body;
+    conmon_message_size_info_t size = {0};
+    aud_error_t result = conmon_audinate_init_upgrade_control_v3(&body, &size, 0);
+    if (result == AUD_SUCCESS) {
+        result = conmon_audinate_upgrade_control_set_source_file(&body, &size, &file);
+    }
+    if (result != AUD_SUCCESS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Failed to build upgrade request: %d", result);
+        return -1;
+    }
+    
+    g_conmon_pending = 1;
+    if (conmon_wait_response(
+            conmon_client_send_control_message(g_conmon, conmon_response_callback, &request_id,
+                                               device, CONMON_MESSAGE_CLASS_VENDOR_SPECIFIC,
+                                               CONMON_VENDOR_ID_AUDINATE, &body,
+                                               (uint16_t) size.curr, NULL),
+            "Firmware upgrade") != 0) {
+        return -1;
+    }
+    
+    memset(info->error, 0, sizeof(info->error));
+    info->state = -1;
+    info->last_error = 0;
+    info->progress_curr = 0;
+    info->progress_total = 0;
+    info->updated = (long long) time(NULL);
+    return 0;
+}
+
+/**
+ * 取得設備最新的韌體升級狀態
+ * @return 0 成功, -1 沒有升級紀錄
+ */
+int dante_get_upgrade_status(const char* device, dante_upgrade_info_t* info) {
+    dante_upgrade_info_t* found = device ? upgrade_info_for(device, 0) : NULL;
+    if (!info || !found || found->updated == 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "No upgrade status for '%s'", device ? device : "");
+        return -1;
+    }
+    *info = *found;
+    return 0;
+}
+
+//==============================================================================
+// 設備設定 (名稱、通道標籤、取樣率、延遲)
+//==============================================================================
+
+/**
+ * 開啟遠端設備並另外讀取指定元件 (發送通道、設備屬性)
+ * @return 設備物件, NULL 表示失敗 (呼叫者負責 device_release)
+ */
+static dr_device_t* settings_open_device(const char* name, dr_device_component_t component, const char* what) {
+    dante_request_id_t request_id;
+
+    dr_device_t* device = route_open_device(name);
+    if (!device) {
+        return NULL;
+    }
+
+    g_route_pending = 1;
+    if (route_request(dr_device_update_component(device, route_response_callback, &request_id, component),
+                      what) != 0) {
+        device_release(device);
+        return NULL;
+    }
+    return device;
+}
+
+/**
+ * 列出設備的發送通道
+ * @param device 設備名稱
+ * @param list 輸出陣列
+ * @param max_count 陣列大小
+ * @return 通道數量, -1 表示失敗
+ */
+int dante_tx_channel_list(const char* device, dante_channel_info_t* list, int max_count) {
+    if (!device || !list) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+
+    dr_device_t* dev = settings_open_device(device, DR_DEVICE_COMPONENT_TXCHANNELS, "Update TX channels");
+    if (!dev) {
+        return -1;
+    }
+
+    int count = 0;
+    uint16_t num_channels = dr_device_num_txchannels(dev);
+    for (uint16_t i = 0; i < num_channels && count < max_count; i++) {
+        dr_txchannel_t* tx = dr_device_txchannel_at_index(dev, i);
+        if (!tx) continue;
+
+        dante_channel_info_t* info = &list[count++];
+        memset(info, 0, sizeof(*info));
+        info->id = dr_txchannel_get_id(tx);
+        const char* name = dr_txchannel_get_name(tx);
+        snprintf(info->name, sizeof(info->name), "%s", name ? name : "");
+    }
+
+    device_release(dev);
+    return count;
+}
+
+/**
+ * 讀取設備的取樣率與接收延遲
+ * 取樣率取自第一個發送 (或接收) 通道，沒有通道的設備回傳 0
+ * @return 0 成功, -1 失敗
+ */
+int dante_get_device_settings(const char* device, dante_device_settings_t* settings) {
+    dante_request_id_t request_id;
+
+    if (!device || !settings) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+    memset(settings, 0, sizeof(*settings));
+
+    dr_device_t* dev = settings_open_device(device, DR_DEVICE_COMPONENT_PROPERTIES, "Update properties");
+    if (!dev) {
+        return -1;
+    }
+    g_route_pending = 1;
+    if (route_request(dr_device_update_component(dev, route_response_callback, &request_id,
+                                                 DR_DEVICE_COMPONENT_TXCHANNELS),
+                      "Update TX channels") != 0) {
+        device_release(dev);
+        return -1;
+    }
+
+    settings->rx_latency_us = (int) dr_device_get_rx_latency_us(dev);
+    if (dr_device_num_txchannels(dev) > 0) {
+        dr_txchannel_t* tx = dr_device_txchannel_at_index(dev, 0);
+        settings->sample_rate = tx ? (int) dr_txchannel_get_sample_rate(tx) : 0;
+    } else if (dr_device_num_rxchannels(dev) > 0) {
+        dr_rxchannel_t* rx = dr_device_rxchannel_at_index(dev, 0);
+        settings->sample_rate = rx ? (int) dr_rxchannel_get_sample_rate(rx) : 0;
+    }
+
+    device_release(dev);
+    return 0;
+}
+
+/**
+ * 重新命名設備 (之後要以新名稱開啟設備)
+ * @return 0 成功, -1 失敗
+ */
+int dante_rename_device(const char* device, const char* new_name) {
+    dante_request_id_t request_id;
+
+    if (!device || !new_name || !new_name[0]) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+
+    dr_device_t* dev = route_open_device(device);
+    if (!dev) {
+        return -1;
+    }
+
+    g_route_pending = 1;
+    int result = route_request(dr_device_rename(dev, route_response_callback, &request_id, new_name),
+                               "Rename device");
+    int held = open_device_index(device);
+    if (result == 0 && held >= 0) {
+        // 保持開啟的連線改用新名稱
+        snprintf(g_open_devices[held].name, sizeof(g_open_devices[held].name), "%s", new_name);
+    }
+    device_release(dev);
+    return result;
+}
+
+/**
+ * 設定通道名稱
+ * @param is_tx 1 為發送通道, 0 為接收通道
+ * @param channel_id 通道編號 (1-based)
+ * @param name 新名稱 (NULL 或空字串表示恢復預設名稱)
+ * @return 0 成功, -1 失敗
+ */
+int dante_set_channel_name(const char* device, int is_tx, int channel_id, const char* name) {
+    dante_request_id_t request_id;
+    aud_error_t sent;
+
+    if (!device) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+    if (name && name[0] == '\0') {
+        name = NULL;
+    }
+
+    dr_device_t* dev = is_tx
+        ? settings_open_device(device, DR_DEVICE_COMPONENT_TXCHANNELS, "Update TX channels")
+        : route_open_device(device);
+    if (!dev) {
+        return -1;
+    }
+
+    if (is_tx) {
+        dr_txchannel_t* tx = dr_device_txchannel_with_id(dev, (dante_id_t) channel_id);
+        if (!tx) {
+            snprintf(g_error_buffer, sizeof(g_error_buffer),
+                    "TX channel %d not found on '%s'", channel_id, device);
+            device_release(dev);
+            return -1;
+        }
+        sent = dr_txchannel_set_name(tx, route_response_callback, &request_id, name);
+    } else {
+        dr_rxchannel_t* rx = dr_device_rxchannel_with_id(dev, (dante_id_t) channel_id);
+        if (!rx) {
+            snprintf(g_error_buffer, sizeof(g_error_buffer),
+                    "RX channel %d not found on '%s'", channel_id, device);
+            device_release(dev);
+            return -1;
+        }
+        sent = dr_rxchannel_set_name(rx, route_response_callback, &request_id, name);
+    }
+
+    g_route_pending = 1;
+    int result = route_request(sent, "Set channel name");
+    device_release(dev);
+    return result;
+}
+
+/**
+ * 設定設備的接收延遲 (保留目前的 frames per packet)
+ * @return 0 成功, -1 失敗
+ */
+int dante_set_rx_latency(const char* device, int latency_us) {
+    dante_request_id_t request_id;
+
+    if (!device || latency_us <= 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+
+    dr_device_t* dev = settings_open_device(device, DR_DEVICE_COMPONENT_PROPERTIES, "Update properties");
+    if (!dev) {
+        return -1;
+    }
+
+    g_route_pending = 1;
+    int result = route_request(dr_device_set_rx_performance_us(dev, (dante_latency_us_t) latency_us,
+                                                               dr_device_get_rx_fpp(dev),
+                                                               route_response_callback, &request_id),
+                               "Set latency");
+    device_release(dev);
+    return result;
+}
+
+/**
+ * 以 ConMon 控制訊息設定設備的取樣率 (部分設備要重新開機才生效)
+ * @return 0 成功, -1 失敗
+ */
+int dante_set_sample_rate(const char* device, int sample_rate) {
+    conmon_client_request_id_t request_id;
+
+    if (!device || sample_rate <= 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+    if (!g_conmon || conmon_client_state(g_conmon) != CONMON_CLIENT_CONNECTED) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "ConMon not connected");
+        return -1;
+    }
+
+    conmon_message_body_t body;
+    conmon_audinate_init_srate_control(&body, 0);
+    conmon_audinate_srate_control_set_rate(&body, (uint32_t) sample_rate);
+
+    g_conmon_pending = 1;
+    return conmon_wait_response(
+        conmon_client_send_control_message(g_conmon, conmon_response_callback, &request_id,
+                                           device, CONMON_MESSAGE_CLASS_VENDOR_SPECIFIC,
+                                           CONMON_VENDOR_ID_AUDINATE, &body,
+                                           conmon_audinate_srate_control_get_size(&body), NULL),
+        "Set sample rate");
+}
+
+//==============================================================================
+// DDM 註冊狀態
+//==============================================================================
+
+/**
+ * 檢查設備是否已註冊到 DDM 網域、這個非受管 (ad-hoc) 控制器能否設定
+ * 已註冊的設備拒絕 ad-hoc 的能力查詢 (AUD_ERR_ACCES)；
+ * ad-hoc 瀏覽看不到設備所屬網域的名稱，domain 維持空白
+ * @return 0 成功, -1 失敗 (設備無法解析或查詢逾時)
+ */
+int dante_get_device_enrollment(const char* device, dante_enrollment_info_t* info) {
+    dr_device_t* dev = NULL;
+    dante_request_id_t request_id;
+
+    if (!device || !info) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+    memset(info, 0, sizeof(*info));
+    if (!g_devices || !g_runtime) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Dante not initialized");
+        return -1;
+    }
+
+    aud_error_t result = dr_device_open_remote(g_devices, device, &dev);
+    if (result != AUD_SUCCESS || !dev) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer),
+                "Failed to open device '%s': %d", device, result);
+        return -1;
+    }
+
+    dr_device_state_t state = dr_device_get_state(dev);
+    for (int waited = 0; state == DR_DEVICE_STATE_RESOLVING && waited < ROUTE_TIMEOUT_MS; waited += 10) {
+        dante_runtime_process(g_runtime);
+        usleep(10000); // 10ms
+        state = dr_device_get_state(dev);
+    }
+
+    if (state == DR_DEVICE_STATE_RESOLVED) {
+        g_route_pending = 1;
+        aud_error_t sent = dr_device_query_capabilities(dev, route_response_callback, &request_id);
+        if (sent == AUD_SUCCESS) {
+            route_wait_response("Query capabilities");
+            result = g_route_result;
+        } else {
+            g_route_pending = 0;
+            result = sent;
+        }
+        state = dr_device_get_state(dev);
+        if (state == DR_DEVICE_STATE_ERROR) {
+            result = dr_device_get_error_state_error(dev);
+        }
+        if (result == AUD_ERR_ACCES) {
+            info->managed = 1;
+            snprintf(info->read_only, sizeof(info->read_only),
+                    "enrolled in a Dante domain, configure it from Dante Domain Manager");
+            dr_device_close(dev);
+            return 0;
+        }
+    }
+
+    if (state != DR_DEVICE_STATE_ACTIVE) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer),
+                "Device '%s' did not become active (state: %d, error: %d)", device, state, result);
+        dr_device_close(dev);
+        return -1;
+    }
+
+    dr_device_status_flags_t flags = 0;
+    if (dr_device_get_status_flags(dev, &flags) == AUD_SUCCESS) {
+        if (flags & DR_DEVICE_STATUS_FLAG_LOCKDOWN) {
+            snprintf(info->read_only, sizeof(info->read_only), "device is in lockdown mode");
+        } else if (flags & DR_DEVICE_STATUS_FLAG_UNLICENSED) {
+            snprintf(info->read_only, sizeof(info->read_only), "device is unlicensed");
+        }
+    }
+
+    dr_device_close(dev);
+    return 0;
+}
+
+//==============================================================================
+// 發送 flow (multicast)
+//==============================================================================
+
+/**
+ * 列出設備的發送 flow (自動建立的 unicast flow 與手動建立的 multicast flow)
+ * @return flow 數量, -1 表示失敗
+ */
+int dante_tx_flow_list(const char* device, dante_flow_info_t* list, int max_count) {
+    if (!device || !list) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+
+    dr_device_t* dev = settings_open_device(device, DR_DEVICE_COMPONENT_TXFLOWS, "Update TX flows");
+    if (!dev) {
+        return -1;
+    }
+
+    int count = 0;
+    uint16_t num_flows = dr_device_num_txflows(dev);
+    for (uint16_t i = 0; i < num_flows && count < max_count; i++) {
+        dr_txflow_t* flow = NULL;
+        if (dr_device_txflow_at_index(dev, i, &flow) != AUD_SUCCESS || !flow) continue;
+
+        dante_flow_info_t* info = &list[count++];
+        memset(info, 0, sizeof(*info));
+
+        dante_id_t id = 0;
+        dr_txflow_get_id(flow, &id);
+        info->id = id;
+        const char* name = NULL;
+        if (dr_txflow_get_name(flow, &name) == AUD_SUCCESS && name) {
+            snprintf(info->name, sizeof(info->name), "%s", name);
+        }
+        aud_bool_t manual = AUD_FALSE;
+        dr_txflow_is_manual(flow, &manual);
+        info->manual = manual ? 1 : 0;
+
+        dante_samplerate_t rate = 0;
+        dante_encoding_t encoding = 0;
+        if (dr_txflow_get_format(flow, &rate, &encoding) == AUD_SUCCESS) {
+            info->sample_rate = (int) rate;
+        }
+        dante_fpp_t fpp = 0;
+        if (dr_txflow_get_fpp(flow, &fpp) == AUD_SUCCESS) {
+            info->fpp = fpp;
+        }
+        dante_latency_us_t latency = 0;
+        if (dr_txflow_get_latency_us(flow, &latency) == AUD_SUCCESS) {
+            info->latency_us = (int) latency;
+        }
+
+        dante_ipv4_address_t addr;
+        if (dr_txflow_address_at_index(flow, 0, &addr) == AUD_SUCCESS && addr.host != 0) {
+            uint32_t ip = ntohl(addr.host);
+            snprintf(info->address, sizeof(info->address), "%u.%u.%u.%u",
+                    (ip >> 24) & 0xFF, (ip >> 16) & 0xFF, (ip >> 8) & 0xFF, ip & 0xFF);
+            info->port = addr.port;
+            info->multicast = IN_MULTICAST(ip) ? 1 : 0;
+        }
+
+        uint16_t num_slots = 0;
+        dr_txflow_num_slots(flow, &num_slots);
+        info->num_slots = num_slots < MAX_FLOW_SLOTS ? num_slots : MAX_FLOW_SLOTS;
+        for (int slot = 0; slot < info->num_slots; slot++) {
+            dr_txchannel_t* tx = NULL;
+            if (dr_txflow_channel_at_slot(flow, (uint16_t) slot, &tx) == AUD_SUCCESS && tx) {
+                info->channels[slot] = dr_txchannel_get_id(tx);
+            }
+        }
+        dr_txflow_release(&flow);
+    }
+
+    device_release(dev);
+    return count;
+}
+
+/**
+ * 建立 multicast 發送 flow
+ * flow 編號使用第一個未使用的編號；packet time 依第一個通道的取樣率換算為 frames per packet
+ * @return 新 flow 的編號, -1 表示失敗
+ */
+int dante_create_multicast_flow(const char* device, const dante_flow_config_t* config) {
+    dante_request_id_t request_id;
+
+    if (!device || !config || config->num_channels <= 0 || config->num_channels > MAX_FLOW_SLOTS) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+
+    dr_device_t* dev = settings_open_device(device, DR_DEVICE_COMPONENT_TXFLOWS, "Update TX flows");
+    if (!dev) {
+        return -1;
+    }
+    g_route_pending = 1;
+    if (route_request(dr_device_update_component(dev, route_response_callback, &request_id,
+                                                 DR_DEVICE_COMPONENT_TXCHANNELS),
+                      "Update TX channels") != 0) {
+        device_release(dev);
+        return -1;
+    }
+
+    uint16_t max_slots = dr_device_max_txflow_slots(dev);
+    if (max_slots > 0 && config->num_channels > max_slots) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer),
+                "Device '%s' allows at most %u channels per flow", device, max_slots);
+        device_release(dev);
+        return -1;
+    }
+
+    // 第一個未使用的 flow 編號
+    uint16_t max_flows = 0;
+    dr_device_max_txflows(dev, &max_flows);
+    dante_id_t flow_id = 0;
+    for (dante_id_t id = 1; id <= max_flows && !flow_id; id++) {
+        dr_txflow_t* existing = NULL;
+        if (dr_device_txflow_with_id(dev, id, &existing) == AUD_SUCCESS && existing) {
+            dr_txflow_release(&existing);
+            continue;
+        }
+        flow_id = id;
+    }
+    if (!flow_id) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer),
+                "Device '%s' has no free TX flows (max %u)", device, max_flows);
+        device_release(dev);
+        return -1;
+    }
+
+    dr_txflow_config_t* flow = NULL;
+    aud_error_t result = dr_txflow_config_new(dev, flow_id, (uint16_t) config->num_channels, &flow);
+    if (result != AUD_SUCCESS || !flow) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Create TX flow failed: %d", result);
+        device_release(dev);
+        return -1;
+    }
+
+    dante_samplerate_t rate = 0;
+    for (int slot = 0; slot < config->num_channels; slot++) {
+        dr_txchannel_t* tx = dr_device_txchannel_with_id(dev, (dante_id_t) config->channels[slot]);
+        if (!tx) {
+            snprintf(g_error_buffer, sizeof(g_error_buffer),
+                    "TX channel %d not found on '%s'", config->channels[slot], device);
+            dr_txflow_config_discard(flow);
+            device_release(dev);
+            return -1;
+        }
+        if (!rate) {
+            rate = dr_txchannel_get_sample_rate(tx);
+        }
+        result = dr_txflow_config_add_channel(flow, tx, (uint16_t) slot);
+        if (result != AUD_SUCCESS) {
+            snprintf(g_error_buffer, sizeof(g_error_buffer),
+                    "Add TX channel %d to flow failed: %d", config->channels[slot], result);
+            dr_txflow_config_discard(flow);
+            device_release(dev);
+            return -1;
+        }
+    }
+
+    if (config->name[0]) {
+        dr_txflow_config_set_name(flow, config->name);
+    }
+    if (config->packet_time_us > 0 && rate > 0) {
+        dr_txflow_config_set_fpp(flow, (dante_fpp_t) ((uint64_t) config->packet_time_us * rate / 1000000));
+    }
+    if (config->address[0]) {
+        struct in_addr in;
+        if (inet_pton(AF_INET, config->address, &in) != 1 || !IN_MULTICAST(ntohl(in.s_addr))) {
+            snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid multicast address '%s'", config->address);
+            dr_txflow_config_discard(flow);
+            device_release(dev);
+            return -1;
+        }
+        dante_ipv4_address_t addr = { .host = in.s_addr, .port = (uint16_t) config->port };
+        // 手動地址套用到所有介面 (備援網路使用相同的群組)
+        for (uint16_t intf = 0; intf < dr_device_num_interfaces(dev); intf++) {
+            dr_txflow_config_set_address(flow, intf, &addr);
+        }
+        dr_txflow_config_set_advertised(flow, AUD_TRUE);
+    }
+
+    g_route_pending = 1;
+    if (route_request(dr_txflow_config_commit(flow, route_response_callback, &request_id),
+                      "Commit TX flow") != 0) {
+        device_release(dev);
+        return -1;
+    }
+
+    device_release(dev);
+    return flow_id;
+}
+
+/**
+ * 刪除手動建立的發送 flow (訂閱這個 flow 的接收端改回 unicast 或中斷)
+ * @return 0 成功, -1 失敗
+ */
+int dante_delete_tx_flow(const char* device, int flow_id) {
+    dante_request_id_t request_id;
+
+    if (!device || flow_id <= 0) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+
+    dr_device_t* dev = settings_open_device(device, DR_DEVICE_COMPONENT_TXFLOWS, "Update TX flows");
+    if (!dev) {
+        return -1;
+    }
+
+    dr_txflow_t* flow = NULL;
+    if (dr_device_txflow_with_id(dev, (dante_id_t) flow_id, &flow) != AUD_SUCCESS || !flow) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "TX flow %d not found on '%s'", flow_id, device);
+        device_release(dev);
+        return -1;
+    }
+    aud_bool_t manual = AUD_FALSE;
+    if (dr_txflow_is_manual(flow, &manual) != AUD_SUCCESS || !manual) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer),
+                "TX flow %d on '%s' is automatic and cannot be deleted", flow_id, device);
+        dr_txflow_release(&flow);
+        device_release(dev);
+        return -1;
+    }
+
+    g_route_pending = 1;
+    int rc = route_request(dr_txflow_delete(&flow, route_response_callback, &request_id), "Delete TX flow");
+    if (flow) {
+        dr_txflow_release(&flow);
+    }
+    device_release(dev);
+    return rc;
+}
+
+//==============================================================================
+// 接收 flow 錯誤統計
+//==============================================================================
+
+// 讀取的統計種類 (依 dante_rxflow_stats_t 的欄位順序)
+static const dante_rxflow_error_type_t g_rxflow_stat_types[] = {
+    DANTE_RXFLOW_ERROR_TYPE_EARLY_PACKETS,
+    DANTE_RXFLOW_ERROR_TYPE_LATE_PACKETS,
+    DANTE_RXFLOW_ERROR_TYPE_OUT_OF_ORDER_PACKETS,
+    DANTE_RXFLOW_ERROR_TYPE_DROPPED_PACKETS,
+    DANTE_RXFLOW_ERROR_TYPE_MAX_LATENCY,
+};
+
+/**
+ * 統計種類在 dante_rxflow_stats_t 的欄位
+ */
+static unsigned int* rxflow_stat_field(dante_rxflow_stats_t* info, dante_rxflow_error_type_t type) {
+    switch (type) {
+    case DANTE_RXFLOW_ERROR_TYPE_EARLY_PACKETS:        return info->early;
+    case DANTE_RXFLOW_ERROR_TYPE_LATE_PACKETS:         return info->late;
+    case DANTE_RXFLOW_ERROR_TYPE_OUT_OF_ORDER_PACKETS: return info->out_of_order;
+    case DANTE_RXFLOW_ERROR_TYPE_DROPPED_PACKETS:      return info->dropped;
+    case DANTE_RXFLOW_ERROR_TYPE_MAX_LATENCY:          return info->max_latency_us;
+    }
+    return NULL;
+}
+
+/**
+ * 讀取設備每個接收 flow 的錯誤統計 (不清除計數)
+ * 設備不提供的統計保持 0，fields 標示提供的種類
+ * @return flow 數量, -1 表示失敗
+ */
+int dante_rx_flow_stats(const char* device, dante_rxflow_stats_t* list, int max_count) {
+    dante_request_id_t request_id;
+
+    if (!device || !list) {
+        snprintf(g_error_buffer, sizeof(g_error_buffer), "Invalid arguments");
+        return -1;
+    }
+
+    dr_device_t* dev = settings_open_device(device, DR_DEVICE_COMPONENT_RXFLOWS, "Update RX flows");
+    if (!dev) {
+        return -1;
+    }
+
+    dr_rxflow_error_field_flags_t fields = dr_device_available_rxflow_error_fields(dev);
+    for (size_t i = 0; i < sizeof(g_rxflow_stat_types) / sizeof(g_rxflow_stat_types[0]); i++) {
+        dante_rxflow_error_type_t type = g_rxflow_stat_types[i];
+        if (!(fields & (1 << type))) {
+            continue;
+        }
+        g_route_pending = 1;
+        if (route_request(dr_device_update_rxflow_error_fields(dev, route_response_callback, &request_id, type, AUD_FALSE),
+                          "Update RX flow statistics") != 0) {
+            device_release(dev);
+            return -1;
+        }
+    }
+
+    int count = 0;
+    uint16_t num_flows = dr_device_num_rxflows(dev);
+    for (uint16_t i = 0; i < num_flows && count < max_count; i++) {
+        dr_rxflow_t* flow = NULL;
+        if (dr_device_rxflow_at_index(dev, i, &flow) != AUD_SUCCESS || !flow) continue;
+
+        dante_rxflow_stats_t* info = &list[count++];
+        memset(info, 0, sizeof(*info));
+
+        dante_id_t id = 0;
+        dr_rxflow_get_id(flow, &id);
+        info->id = id;
+        const char* name = NULL;
+        if (dr_rxflow_get_name(flow, &name) == AUD_SUCCESS && name) {
+            snprintf(info->name, sizeof(info->name), "%s", name);
+        }
+        if (dr_rxflow_get_tx_device_name(flow, &name) == AUD_SUCCESS && name) {
+            snprintf(info->tx_device, sizeof(info->tx_device), "%s", name);
+        }
+        if (dr_rxflow_get_tx_flow_name(flow, &name) == AUD_SUCCESS && name) {
+            snprintf(info->tx_flow, sizeof(info->tx_flow), "%s", name);
+        }
+        aud_bool_t multicast = AUD_FALSE;
+        dr_rxflow_is_multicast(flow, &multicast);
+        info->multicast = multicast ? 1 : 0;
+        info->fields = fields;
+
+        uint16_t num_interfaces = 0;
+        dr_rxflow_num_interfaces(flow, &num_interfaces);
+        info->num_interfaces = num_interfaces < DANTE_MAX_FLOW_INTERFACES ? num_interfaces : DANTE_MAX_FLOW_INTERFACES;
+        for (int intf = 0; intf < info->num_interfaces; intf++) {
+            for (size_t t = 0; t < sizeof(g_rxflow_stat_types) / sizeof(g_rxflow_stat_types[0]); t++) {
+                dante_rxflow_error_type_t type = g_rxflow_stat_types[t];
+                uint32_t value = 0;
+                if ((fields & (1 << type)) &&
+                    dr_rxflow_get_error_field_uint32(flow, (unsigned int) intf, type, &value, NULL) == AUD_SUCCESS) {
+                    rxflow_stat_field(info, type)[intf] = value;
+                }
+            }
+        }
+        dr_rxflow_release(&flow);
+    }
+
+    device_release(dev);
+    return count;
+}
+
+//==============================================================================
+// 測試/除錯函數
+//==============================================================================
+
+/**
+ * 執行基礎系統測試
+ * @return 0 所有測試通過, -1 有測試失敗
+ */
+int dante_run_basic_test(void) {
+    printf("\n=== Dante Basic Test ===\n");
+    
+    // 測試 1: 初始化
+    printf("Test 1: Initialization... ");
+    if (dante_init() != 0) {
+        printf("FAILED: %s\n", dante_get_last_error());
+        return -1;
+    }
+    printf("PASSED\n");
+    
+    // 測試 2: 設備連接
+    printf("Test 2: Device connection... ");
+    if (dante_connect_local_device() != 0) {
+        printf("FAILED: %s\n", dante_get_last_error());
+        dante_cleanup();
+        return -1;
+    }
+    printf("PASSED\n");
+    
+    // 測試 3: 設備資訊
+    printf("Test 3: Device info... ");
+    char device_name[64];
+    if (dante_get_device_name(device_name, sizeof(device_name)) == 0) {
+        printf("PASSED (Device: %s)\n", device_name);
+    } else {
+        printf("FAILED: %s\n", dante_get_last_error());
+        dante_cleanup();
+        return -1;
+    }
+    
+    // 測試 4: 通道數量
+    printf("Test 4: Channel counts... ");
+    int tx_count = dante_get_tx_channel_count();
+    int rx_count = dante_get_rx_channel_count();
+    if (tx_count >= 0 && rx_count >= 0) {
+        printf("PASSED (TX: %d, RX: %d)\n", tx_count, rx_count);
+    } else {
+        printf("FAILED: %s\n", dante_get_last_error());
+        dante_cleanup();
+        return -1;
+    }
+    
+    // 測試 5: 第一個 TX 通道名稱
+    if (tx_count > 0) {
+        printf("Test 5: First TX channel name... ");
+        char channel_name[64];
+        if (dante_get_tx_channel_name(0, channel_name, sizeof(channel_name)) == 0) {
+            printf("PASSED (Channel 0: %s)\n", channel_name);
+        } else {
+            printf("FAILED: %s\n", dante_get_last_error());
+        }
+    }
+    
+    // 測試 6: 設備掃描
+    printf("Test 6: Device scan... ");
+    if (dante_start_device_scan() == 0) {
+        printf("PASSED\n");
+        
+        // 等待設備被發現
+        printf("Waiting for devices to be discovered (5 seconds)...\n");
+        sleep(5);
+        
+        // 刷新掃描結果
+        printf("Test 7: Refresh scan results... ");
+        if (dante_refresh_device_scan() == 0) {
+            int device_count = dante_get_discovered_device_count();
+            printf("PASSED (Found %d devices)\n", device_count);
+            
+            // 顯示每個設備的詳細資訊
+            for (int i = 0; i < device_count; i++) {
+                dante_device_info_t info;
+                if (dante_get_device_info(i, &info) == 0) {
+                    printf("  Device %d: %s (%s) - Dante %s\n", 
+                           i, info.name, info.model, info.dante_version);
+                }
+            }
+        } else {
+            printf("FAILED: %s\n", dante_get_last_error());
+        }
+        
+        dante_stop_device_scan();
+    } else {
+        printf("FAILED: %s\n", dante_get_last_error());
+    }
+    
+    printf("\n=== All Tests Completed ===\n");
+    
+    // 保持連接，不清理，讓後續 API 可以使用
+    return 0;
+}
+
+//==============================================================================
+// 主要測試入口點 (可選)
+//==============================================================================
+
+#ifdef DANTE_WRAPPER_STANDALONE
+int main(int argc, char* argv[]) {
+    printf("Dante Wrapper Basic Test\n");
+    
+    int result = dante_run_basic_test();
+    
+    if (result == 0) {
+        printf("\nAll tests passed! Press Enter to exit...\n");
+        getchar();
+    }
+    
+    dante_cleanup();
+    return result;
+}
+#endif
\ No newline at end of file
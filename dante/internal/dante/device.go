@@ -0,0 +1,311 @@
+// Package dante Dante 網域的領域邏輯：設備模型、SDK 綁定 (cgo 或模擬)、
+// 網域生命週期、路由訂閱與 ConMon 監控。不得依賴任何呈現或傳輸層。
+package dante
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+//==============================================================================
+// 核心網路配置
+//==============================================================================
+
+// NetworkConfig 網路介面配置
+type NetworkConfig struct {
+	InterfaceName string // 網路介面名稱 (eth1)
+	MacAddress    string // MAC 地址
+	IPAddress     string // IP 地址
+	NetworkType   string // "dante1"
+	Enabled       bool   // 是否啟用
+}
+
+//==============================================================================
+// Dante 設備資訊
+//==============================================================================
+
+// Device 已發現的 Dante 設備
+type Device struct {
+	ID             int    `json:"id"`              // 設備編號 (1-based)
+	Name           string `json:"name"`            // 設備名稱
+	Model          string `json:"model"`           // 型號
+	ProductVersion string `json:"product_version"` // 產品版本
+	DanteVersion   string `json:"dante_version"`   // Dante 版本
+	IPAddress      string `json:"ip_address"`      // 主要 IP 地址
+	LinkSpeed      int    `json:"link_speed"`      // 主要連線速度 (-1 表示未知)
+	SecondaryIP    string `json:"secondary_ip"`    // 次要 IP 地址
+	SecondarySpeed int    `json:"secondary_speed"` // 次要連線速度
+	MacAddress     string `json:"mac_address"`     // MAC 地址
+	TxChannels     int    `json:"tx_channels"`     // 發送通道數 (-1 表示未知)
+	RxChannels     int    `json:"rx_channels"`     // 接收通道數 (-1 表示未知)
+
+	// 製造商與序號來自 ConMon 的製造商版本，需要 Domain.StartMonitoring 後才有
+	Manufacturer string             `json:"manufacturer,omitempty"`  // 製造商名稱
+	SerialNumber string             `json:"serial_number,omitempty"` // 序號 (十六進位)
+	Capabilities DeviceCapabilities `json:"capabilities"`            // 支援的功能
+
+	// DDM 註冊狀態 (Domain.CheckEnrollments 檢查後附加，SDK 的設備列表不含)
+	Managed   bool   `json:"managed,omitempty"`    // 已註冊到 Dante Domain Manager 的網域
+	DDMDomain string `json:"ddm_domain,omitempty"` // 註冊的 DDM 網域 (無法得知時為空白)
+	ReadOnly  string `json:"read_only,omitempty"`  // 這個控制器無法設定的原因 (可以設定時為空白)
+}
+
+// DeviceCapabilities 設備支援的功能
+// Redundancy 在發現時由能力查詢得知，其餘同製造商名稱需要 ConMon 監控
+type DeviceCapabilities struct {
+	AES67      bool `json:"aes67"`      // 支援 AES67 模式
+	Redundancy bool `json:"redundancy"` // 有次要網路介面 (支援備援)
+	Lockable   bool `json:"lockable"`   // 可以鎖定 (Dante Device Lock)
+	Metering   bool `json:"metering"`   // 提供通道電平 (見 Domain.Meters)
+}
+
+// List 支援的功能名稱 (aes67、redundancy、lockable、metering)
+func (c DeviceCapabilities) List() []string {
+	var names []string
+	if c.AES67 {
+		names = append(names, "aes67")
+	}
+	if c.Redundancy {
+		names = append(names, "redundancy")
+	}
+	if c.Lockable {
+		names = append(names, "lockable")
+	}
+	if c.Metering {
+		names = append(names, "metering")
+	}
+	return names
+}
+
+// 備援 (primary/secondary) 狀態
+const (
+	RedundancyRedundant     = "redundant"      // 主要與次要連線都正常
+	RedundancyPrimaryOnly   = "primary-only"   // 設備未使用次要網路
+	RedundancySecondaryDown = "secondary-down" // 有次要地址但連線中斷
+	RedundancyPrimaryDown   = "primary-down"   // 只剩次要連線
+)
+
+// Redundancy 依主要/次要連線判斷備援狀態
+func (dev Device) Redundancy() string {
+	switch {
+	case dev.SecondaryIP == "":
+		return RedundancyPrimaryOnly
+	case dev.SecondarySpeed <= 0:
+		return RedundancySecondaryDown
+	case dev.LinkSpeed <= 0:
+		return RedundancyPrimaryDown
+	}
+	return RedundancyRedundant
+}
+
+// linkLocalNet IPv4 link-local 網段 (RFC 3927)
+var linkLocalNet = &net.IPNet{
+	IP:   net.IPv4(169, 254, 0, 0),
+	Mask: net.CIDRMask(16, 32),
+}
+
+// IsLinkLocalIPv4 判斷地址是否為 IPv4 link-local
+func IsLinkLocalIPv4(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil || ip.To4() == nil {
+		return false
+	}
+	return linkLocalNet.Contains(ip)
+}
+
+// IsLinkLocal 設備主要 IP 是否為 link-local
+func (dev Device) IsLinkLocal() bool {
+	return IsLinkLocalIPv4(dev.IPAddress)
+}
+
+// ChannelCounts 以 "TX/RX" 顯示通道數 (未知時為 "-")
+func (dev Device) ChannelCounts() string {
+	if dev.TxChannels < 0 || dev.RxChannels < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", dev.TxChannels, dev.RxChannels)
+}
+
+// MaxDeviceNameLength 設備名稱的長度上限
+const MaxDeviceNameLength = 31
+
+// CheckDeviceName 檢查設備名稱是否符合 Dante 的規則：1 到 31 個英文字母、
+// 數字或連字號，不能以連字號開頭或結尾 (名稱同時是 mDNS 主機名稱)
+func CheckDeviceName(name string) error {
+	if name == "" || len(name) > MaxDeviceNameLength {
+		return classified(ErrInvalidArgument, "device name %q must be 1 to %d characters", name, MaxDeviceNameLength)
+	}
+	if strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
+		return classified(ErrInvalidArgument, "device name %q must not start or end with a hyphen", name)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return classified(ErrInvalidArgument, "device name %q may only contain letters, digits and hyphens", name)
+		}
+	}
+	return nil
+}
+
+//==============================================================================
+// 設備搜尋
+//==============================================================================
+
+// DeviceFilter 設備搜尋條件，空白欄位不限制，字串比對不分大小寫
+type DeviceFilter struct {
+	Name         string     // 名稱包含
+	Model        string     // 型號包含
+	Network      *net.IPNet // 主要或次要 IP 在此網段 (單一地址為 /32)
+	MAC          string     // MAC 地址包含 (忽略 : - . 分隔，可只輸入 OUI)
+	DanteVersion string     // Dante 版本 (4.2 符合 4.2.x)
+}
+
+// ParseNetwork 解析 IP 地址或 CIDR 網段
+func ParseNetwork(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnet %q", s)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", s)
+	}
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Empty 沒有任何條件
+func (f DeviceFilter) Empty() bool {
+	return f == DeviceFilter{}
+}
+
+// Match 設備是否符合所有條件
+func (f DeviceFilter) Match(dev Device) bool {
+	if f.Name != "" && !containsFold(dev.Name, f.Name) {
+		return false
+	}
+	if f.Model != "" && !containsFold(dev.Model, f.Model) {
+		return false
+	}
+	if f.Network != nil && !inNetwork(f.Network, dev.IPAddress) && !inNetwork(f.Network, dev.SecondaryIP) {
+		return false
+	}
+	if f.MAC != "" && !strings.Contains(normalizeMAC(dev.MacAddress), normalizeMAC(f.MAC)) {
+		return false
+	}
+	if f.DanteVersion != "" && dev.DanteVersion != f.DanteVersion && !strings.HasPrefix(dev.DanteVersion, f.DanteVersion+".") {
+		return false
+	}
+	return true
+}
+
+// FilterDevices 符合條件的設備
+func FilterDevices(devices []Device, f DeviceFilter) []Device {
+	matched := []Device{}
+	for _, dev := range devices {
+		if f.Match(dev) {
+			matched = append(matched, dev)
+		}
+	}
+	return matched
+}
+
+//==============================================================================
+// 設備排序
+//==============================================================================
+
+// 排序欄位
+const (
+	SortByName  = "name"
+	SortByIP    = "ip"
+	SortByModel = "model"
+)
+
+// DeviceOrder 設備排序，Key 空白表示維持發現順序
+// 相同時依名稱排序，IP 依數值比較 (10.0.0.9 在 10.0.0.10 之前)
+type DeviceOrder struct {
+	Key  string
+	Desc bool
+}
+
+// ParseDeviceOrder 解析排序欄位，前面加 - 表示反向 (例如 -ip)
+func ParseDeviceOrder(s string) (DeviceOrder, error) {
+	key, desc := strings.CutPrefix(strings.ToLower(s), "-")
+	switch key {
+	case "", SortByName, SortByIP, SortByModel:
+		return DeviceOrder{Key: key, Desc: desc && key != ""}, nil
+	}
+	return DeviceOrder{}, fmt.Errorf("unknown sort %q, use name, ip or model (prefix - for descending)", s)
+}
+
+// String 以 ParseDeviceOrder 的格式顯示
+func (o DeviceOrder) String() string {
+	if o.Desc {
+		return "-" + o.Key
+	}
+	return o.Key
+}
+
+// Compare 比較兩台設備 (搭配 slices.SortStableFunc)
+func (o DeviceOrder) Compare(a, b Device) int {
+	var c int
+	switch o.Key {
+	case "":
+		return 0
+	case SortByIP:
+		c = compareIP(a.IPAddress, b.IPAddress)
+	case SortByModel:
+		c = cmp.Compare(strings.ToLower(a.Model), strings.ToLower(b.Model))
+	}
+	if c == 0 {
+		c = cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+	if o.Desc {
+		return -c
+	}
+	return c
+}
+
+// SortDevices 依排序欄位排序 (相同時維持原本順序)
+func SortDevices(devices []Device, o DeviceOrder) {
+	slices.SortStableFunc(devices, o.Compare)
+}
+
+// compareIP 依數值比較地址，無法解析的地址排在最後
+func compareIP(a, b string) int {
+	ipA, ipB := net.ParseIP(a).To16(), net.ParseIP(b).To16()
+	switch {
+	case ipA == nil && ipB == nil:
+		return cmp.Compare(a, b)
+	case ipA == nil:
+		return 1
+	case ipB == nil:
+		return -1
+	}
+	return bytes.Compare(ipA, ipB)
+}
+
+// containsFold 不分大小寫的子字串比對
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// inNetwork 地址是否在網段內
+func inNetwork(network *net.IPNet, address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && network.Contains(ip)
+}
+
+// normalizeMAC 去掉分隔符號並轉成小寫
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+}
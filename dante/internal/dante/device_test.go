@@ -0,0 +1,116 @@
+package dante
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeviceFilter(t *testing.T) {
+	devices := []Device{
+		{Name: "FOH-Console", Model: "DL32", IPAddress: "192.168.100.10", SecondaryIP: "192.168.200.10", MacAddress: "00:1d:c1:00:00:01", DanteVersion: "4.2.0"},
+		{Name: "Amp-Left", Model: "PA-4D", IPAddress: "192.168.100.31", MacAddress: "00:1D:C1:00:00:03", DanteVersion: "4.20.1"},
+		{Name: "Amp-Right", Model: "PA-4D", IPAddress: "169.254.12.7", MacAddress: "a4:00:e2:00:00:04", DanteVersion: "3.10.2"},
+	}
+	secondary, err := ParseNetwork("192.168.200.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	single, err := ParseNetwork("169.254.12.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter DeviceFilter
+		want   []string
+	}{
+		{"no filter", DeviceFilter{}, []string{"FOH-Console", "Amp-Left", "Amp-Right"}},
+		{"name substring", DeviceFilter{Name: "amp"}, []string{"Amp-Left", "Amp-Right"}},
+		{"model and name", DeviceFilter{Name: "left", Model: "pa-4d"}, []string{"Amp-Left"}},
+		{"secondary subnet", DeviceFilter{Network: secondary}, []string{"FOH-Console"}},
+		{"single address", DeviceFilter{Network: single}, []string{"Amp-Right"}},
+		{"MAC OUI without separators", DeviceFilter{MAC: "001dc1"}, []string{"FOH-Console", "Amp-Left"}},
+		{"dante version prefix", DeviceFilter{DanteVersion: "4.2"}, []string{"FOH-Console"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, dev := range FilterDevices(devices, tt.filter) {
+			got = append(got, dev.Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if _, err := ParseNetwork("10.0.0.0/33"); err == nil {
+		t.Fatal("invalid subnet accepted")
+	}
+}
+
+func TestDeviceOrder(t *testing.T) {
+	devices := []Device{
+		{Name: "mixer", Model: "DL32", IPAddress: "10.0.0.10"},
+		{Name: "Amp-2", Model: "PA-4D", IPAddress: "10.0.0.9"},
+		{Name: "amp-1", Model: "pa-4d", IPAddress: ""},
+		{Name: "Stagebox", Model: "Rio1608", IPAddress: "10.0.1.2"},
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"", []string{"mixer", "Amp-2", "amp-1", "Stagebox"}},
+		{"name", []string{"amp-1", "Amp-2", "mixer", "Stagebox"}},
+		{"-NAME", []string{"Stagebox", "mixer", "Amp-2", "amp-1"}},
+		{"ip", []string{"Amp-2", "mixer", "Stagebox", "amp-1"}},
+		{"model", []string{"mixer", "amp-1", "Amp-2", "Stagebox"}},
+	}
+	for _, tt := range tests {
+		order, err := ParseDeviceOrder(tt.sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sorted := append([]Device{}, devices...)
+		SortDevices(sorted, order)
+		for i, dev := range sorted {
+			if dev.Name != tt.want[i] {
+				t.Errorf("sort %q: got %v at %d, want %v", tt.sort, dev.Name, i, tt.want)
+				break
+			}
+		}
+	}
+
+	if _, err := ParseDeviceOrder("mac"); err == nil {
+		t.Fatal("unknown sort key accepted")
+	}
+}
+
+func TestCheckDeviceName(t *testing.T) {
+	for _, name := range []string{"amp-1", "FOH-Console", "A", "stagebox-0123456789-0123456789a"} {
+		if err := CheckDeviceName(name); err != nil {
+			t.Errorf("CheckDeviceName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "-amp", "amp-", "amp 1", "amp_1", "舞台", "stagebox-0123456789-0123456789ab"} {
+		if err := CheckDeviceName(name); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("CheckDeviceName(%q) = %v, want ErrInvalidArgument", name, err)
+		}
+	}
+}
+
+func TestDeviceChannelCounts(t *testing.T) {
+	if got := (Device{TxChannels: 32, RxChannels: 16}).ChannelCounts(); got != "32/16" {
+		t.Errorf("ChannelCounts = %q, want 32/16", got)
+	}
+	if got := (Device{TxChannels: -1, RxChannels: -1}).ChannelCounts(); got != "-" {
+		t.Errorf("unknown ChannelCounts = %q, want -", got)
+	}
+}
@@ -0,0 +1,83 @@
+package dante
+
+import (
+	"sort"
+	"time"
+)
+
+//==============================================================================
+// 設備資訊快取
+//==============================================================================
+
+// API、表格、告警與拓撲每次讀取設備列表都要跨一次 cgo，而且要跟事件處理與
+// 刷新搶同一個 SDK 鎖；SDK 卡在網路 I/O 時所有讀取都跟著卡住。
+// 解碼後的設備資訊依設備編號快取：
+//
+//   - 世代 (generation)：SDK 的變更計數改變、刷新掃描或成功變更設定時加一，
+//     世代不同的快取不再使用
+//   - TTL：世代沒變時快取最多使用 DeviceTTL，之後重新讀取
+//   - SDK 忙碌中 (另一個呼叫持有鎖) 時回傳現有的快取而不等待，
+//     I/O 卡住時表格仍立即顯示最後的結果
+//
+// DeviceTTL 為 0 時不快取，每次都向 SDK 讀取。
+
+// DefaultDeviceTTL 設備資訊快取的預設有效時間
+const DefaultDeviceTTL = 5 * time.Second
+
+// deviceCache 最後一次讀取的設備資訊
+type deviceCache struct {
+	generation uint64         // 讀取時的世代
+	read       time.Time      // 讀取時間
+	byID       map[int]Device // 設備編號 → 設備資訊
+}
+
+// devices 依設備編號排序的副本 (呼叫者可以修改)
+func (c *deviceCache) devices() []Device {
+	devices := make([]Device, 0, len(c.byID))
+	for _, dev := range c.byID {
+		devices = append(devices, dev)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	return devices
+}
+
+// invalidate 設備資訊可能改變，讓快取失效
+func (d *Domain) invalidate() {
+	d.cacheMu.Lock()
+	d.generation++
+	d.cacheMu.Unlock()
+}
+
+// cachedDevices 回傳仍可使用的快取；stale 為 true 時忽略世代與 TTL (SDK 忙碌中)
+func (d *Domain) cachedDevices(now time.Time, stale bool) ([]Device, bool) {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+	c := d.cache
+	if c == nil {
+		return nil, false
+	}
+	if !stale && (c.generation != d.generation || now.Sub(c.read) >= d.DeviceTTL) {
+		return nil, false
+	}
+	return c.devices(), true
+}
+
+// storeDevices 記錄讀取結果；讀取期間世代已改變時不保存 (內容可能是變更前的)
+func (d *Domain) storeDevices(devices []Device, generation uint64, now time.Time) {
+	byID := make(map[int]Device, len(devices))
+	for _, dev := range devices {
+		byID[dev.ID] = dev
+	}
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+	if generation != d.generation {
+		return
+	}
+	d.cache = &deviceCache{generation: generation, read: now, byID: byID}
+}
+
+// trySDKOp 同 sdkOp，但 SDK 忙碌中時不等待，ok 為 false
+func (d *Domain) trySDKOp(op func(SDK) int) (result int, errorMsg string, ok bool) {
+	ok = nativeThread.tryDo(func() { result, errorMsg = d.runOp(op) })
+	return result, errorMsg, ok
+}
@@ -0,0 +1,101 @@
+package dante
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSDK 記錄讀取設備列表的次數
+type countingSDK struct {
+	*SimulatedSDK
+	reads atomic.Int32
+}
+
+func (c *countingSDK) GetDeviceList(maxCount int) ([]Device, int) {
+	c.reads.Add(1)
+	return c.SimulatedSDK.GetDeviceList(maxCount)
+}
+
+func TestDeviceCache(t *testing.T) {
+	cfg := DefaultSimulationConfig()
+	sdk := &countingSDK{SimulatedSDK: NewSimulatedSDK(cfg)}
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), sdk.SimulatedSDK)
+	d.sdk = sdk
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	// 背景事件處理停止，變更計數不會在測試中途讓快取失效
+	scan, stop := context.WithCancel(ctx)
+	if err := d.StartDeviceScan(scan); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	d.RefreshDevices(ctx)
+
+	expectReads := func(what string, want int32) {
+		t.Helper()
+		if got := sdk.reads.Load(); got != want {
+			t.Fatalf("%s: %d SDK reads, want %d", what, got, want)
+		}
+	}
+	devices := d.GetDevices()
+	if len(devices) != len(cfg.Devices) {
+		t.Fatalf("got %d devices, want %d", len(devices), len(cfg.Devices))
+	}
+	devices[0].Name = "changed by caller"
+	if again := d.GetDevices(); again[0].Name == "changed by caller" {
+		t.Fatal("cached list shared with the caller")
+	}
+	expectReads("cache hit", 1)
+
+	// 變更設定或刷新後重新讀取
+	name := devices[1].Name
+	if err := d.RenameDevice(ctx, name, name+"-2"); err != nil {
+		t.Fatal(err)
+	}
+	d.GetDevices()
+	expectReads("after rename", 2)
+	d.RefreshDevices(ctx)
+	if got := d.GetDevices(); got[1].Name != name+"-2" {
+		t.Fatalf("after refresh: %q", got[1].Name)
+	}
+	expectReads("after refresh", 3)
+
+	// 超過 TTL
+	d.cacheMu.Lock()
+	d.cache.read = d.cache.read.Add(-d.DeviceTTL)
+	d.cacheMu.Unlock()
+	d.GetDevices()
+	expectReads("after TTL", 4)
+
+	// SDK 忙碌中回傳最後的結果而不等待
+	d.invalidate()
+	busy, release := make(chan struct{}), make(chan struct{})
+	go nativeThread.do(func() {
+		close(busy)
+		<-release
+	})
+	<-busy
+	done := make(chan []Device)
+	go func() { done <- d.GetDevices() }()
+	select {
+	case stale := <-done:
+		if len(stale) != len(cfg.Devices) {
+			t.Errorf("stale list has %d devices", len(stale))
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("GetDevices waited for the busy SDK")
+	}
+	close(release)
+	expectReads("while busy", 4)
+
+	// 不快取
+	d.DeviceTTL = 0
+	d.GetDevices()
+	d.GetDevices()
+	expectReads("without cache", 6)
+}
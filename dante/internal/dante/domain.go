@@ -0,0 +1,886 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/backoff"
+	"danteCS/internal/recovery"
+	"danteCS/internal/trace"
+)
+
+// DefaultInitBackoff SDK 初始化的預設退避 (開機時網卡可能較晚就緒)
+func DefaultInitBackoff() backoff.Policy {
+	return backoff.Policy{Initial: 2 * time.Second, Max: time.Minute}
+}
+
+// DefaultEventInterval 背景事件處理的預設間隔
+const DefaultEventInterval = 500 * time.Millisecond
+
+// InitReporter 初始化期間的狀態回報 (由 supervisor 實作)
+type InitReporter interface {
+	Progress(phase string, err error) // 啟動階段，err 為上次失敗原因
+	Network(iface, ip string)         // 實際使用的介面與地址
+}
+
+//==============================================================================
+// Dante 網域管理器
+//==============================================================================
+
+// Domain 代表一個 Dante 網域
+type Domain struct {
+	Name          string
+	NetworkConfig NetworkConfig
+	EventInterval time.Duration  // 背景事件處理間隔 (StartDeviceScan 前設定)
+	CallRetry     backoff.Policy // 掃描、刷新與讀取設備資訊的暫時性失敗重試 (見 retry.go)
+	DeviceTTL     time.Duration  // 設備資訊快取的有效時間，0 表示不快取 (見 devicecache.go)
+
+	sdk SDK          // 原生 SDK 或模擬 (只在 nativeThread 上呼叫，見 sdkOp)
+	log *slog.Logger // 附加 domain 欄位的日誌
+
+	// 生命週期: Initialize 建立 ctx，Cleanup 或呼叫者取消時結束
+	mu          sync.Mutex
+	initialized bool
+	deviceCount int // 最後一次刷新時 SDK 回報的設備數量
+	ctx         context.Context
+	cancel      context.CancelFunc
+	events      sync.WaitGroup // 背景事件處理循環
+
+	changes chan struct{} // SDK 通知設備列表變更 (最多保留一個未讀通知)
+
+	healthMu sync.Mutex
+	health   Health // 事件處理與刷新的狀態 (見 watchdog.go)
+
+	enrollMu    sync.Mutex
+	enrollments map[string]Enrollment // 設備名稱 → 已檢查的 DDM 註冊狀態
+
+	cacheMu    sync.Mutex
+	generation uint64       // 設備資訊的世代，可能改變時加一
+	cache      *deviceCache // 最後一次讀取的設備列表 (尚未讀取時為 nil)
+}
+
+// NewDomain 創建新的 Dante 網域
+func NewDomain(name string, config NetworkConfig) *Domain {
+	return &Domain{
+		Name:          name,
+		NetworkConfig: config,
+		EventInterval: DefaultEventInterval,
+		CallRetry:     DefaultCallRetry(),
+		DeviceTTL:     DefaultDeviceTTL,
+		sdk:           nativeSDK{},
+		log:           slog.Default().With("domain", name),
+		changes:       make(chan struct{}, 1),
+		enrollments:   make(map[string]Enrollment),
+	}
+}
+
+// NewSimulatedDomain 創建使用模擬設備的網域 (-simulate)
+func NewSimulatedDomain(name string, config NetworkConfig, sim *SimulatedSDK) *Domain {
+	d := NewDomain(name, config)
+	d.sdk = sim
+	return d
+}
+
+// Simulated 是否為模擬網域 (不檢查實體網路介面)
+func (d *Domain) Simulated() bool {
+	sdk := d.sdk
+	if rec, ok := sdk.(*TapeRecorder); ok {
+		sdk = rec.inner
+	}
+	_, ok := sdk.(*SimulatedSDK)
+	return ok
+}
+
+// Initialize 初始化 Dante 網域
+// ctx 決定網域的生命週期：取消後背景事件處理結束，之後仍須呼叫 Cleanup 釋放 SDK
+func (d *Domain) Initialize(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.log.Info("Initializing Dante domain",
+		"iface", d.NetworkConfig.InterfaceName, "ip", d.NetworkConfig.IPAddress)
+
+	// span 只涵蓋 SDK 呼叫，網域的 context 不以它為上層
+	_, span := trace.Start(ctx, "dante.init",
+		slog.String("dante.domain", d.Name), slog.String("network.interface", d.NetworkConfig.InterfaceName))
+	defer span.End()
+
+	if err := d.claimNative(); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	// 傳遞網卡名稱給 Dante SDK
+	result, errorMsg := d.sdkOp(func(s SDK) int { return s.InitWithInterface(d.NetworkConfig.InterfaceName) })
+	if result != 0 {
+		d.releaseNative()
+		err := newSDKError("dante_init_with_interface", errorMsg)
+		span.RecordError(err)
+		return err
+	}
+
+	d.log.Info("Dante API initialized", "iface", d.NetworkConfig.InterfaceName)
+
+	d.mu.Lock()
+	d.ctx, d.cancel = context.WithCancel(ctx)
+	d.initialized = true
+	d.mu.Unlock()
+	d.healthMu.Lock()
+	d.health = Health{}
+	d.healthMu.Unlock()
+	d.log.Info("Dante domain ready for network scanning")
+	return nil
+}
+
+// InitializeWithRetry 初始化失敗時依退避重試 (開機時網卡可能較晚啟動或取得 IP)
+// 每次嘗試前重新讀取介面狀態，ctx 結束時回傳 backoff.ErrStopped；SDK 未授權時不再重試
+func (d *Domain) InitializeWithRetry(ctx context.Context, policy backoff.Policy, report InitReporter) error {
+	onRetry := func(attempt int, err error, delay time.Duration) {
+		d.log.Warn("Initialization failed, retrying", "attempt", attempt, "err", err, "retry_in", delay)
+		report.Progress(fmt.Sprintf("initialization attempt %d failed, retrying in %s", attempt, delay), err)
+	}
+	return backoff.Retry(ctx, policy, onRetry, func(attempt int) error {
+		if !d.Simulated() {
+			report.Progress("waiting for interface "+d.NetworkConfig.InterfaceName, nil)
+			if err := d.refreshNetworkConfig(); err != nil {
+				return err
+			}
+		}
+		report.Network(d.NetworkConfig.InterfaceName, d.NetworkConfig.IPAddress)
+
+		report.Progress(fmt.Sprintf("initializing SDK (attempt %d)", attempt), nil)
+		err := d.Initialize(ctx)
+		if errors.Is(err, ErrSDKLicense) || errors.Is(err, ErrSDKInUse) {
+			return backoff.Permanent(err) // 未授權或 SDK 已被使用時等待網卡也沒有用
+		}
+		return err
+	})
+}
+
+// refreshNetworkConfig 重新讀取介面的狀態、MAC 與第一個 IPv4 地址
+func (d *Domain) refreshNetworkConfig() error {
+	name := d.NetworkConfig.InterfaceName
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return classified(ErrInterfaceDown, "interface %s not found", name)
+	}
+	if iface.Flags&net.FlagUp == 0 {
+		return classified(ErrInterfaceDown, "interface %s is down", name)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return classified(ErrInterfaceDown, "interface %s: %v", name, err)
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			if ip := ipnet.IP.String(); ip != d.NetworkConfig.IPAddress {
+				d.log.Info("Interface address changed", "iface", name, "ip", ip)
+				d.NetworkConfig.IPAddress = ip
+			}
+			d.NetworkConfig.MacAddress = iface.HardwareAddr.String()
+			d.NetworkConfig.Enabled = true
+			return nil
+		}
+	}
+	return classified(ErrInterfaceDown, "interface %s has no IP address", name)
+}
+
+// Initialized 網域是否已初始化 (且尚未取消)
+func (d *Domain) Initialized() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.initialized && d.ctx.Err() == nil
+}
+
+// Logger 附加 domain 欄位的日誌 (供網域外的報告使用)
+func (d *Domain) Logger() *slog.Logger {
+	return d.log
+}
+
+// sdkOp 在 nativeThread 上執行一次 SDK 操作，失敗 (負數) 時在同一個工作中讀取錯誤訊息。
+// C 的錯誤訊息是整個行程共用的，每個網域各自的鎖擋不住其他網域的呼叫，
+// 所以配對由共用的 worker 保證
+func (d *Domain) sdkOp(op func(SDK) int) (result int, errorMsg string) {
+	nativeThread.do(func() { result, errorMsg = d.runOp(op) })
+	return result, errorMsg
+}
+
+// runOp 執行操作並讀取失敗的錯誤訊息 (只在 nativeThread 上呼叫)
+func (d *Domain) runOp(op func(SDK) int) (int, string) {
+	result := op(d.sdk)
+	if result < 0 {
+		return result, d.sdk.GetLastError()
+	}
+	return result, ""
+}
+
+// StartDeviceScan 開始設備掃描
+// 背景事件處理持續到 ctx 或網域的 context 結束
+func (d *Domain) StartDeviceScan(ctx context.Context) error {
+	if !d.Initialized() {
+		return d.errNotInitialized()
+	}
+
+	d.log.Info("Starting device scan", "iface", d.NetworkConfig.InterfaceName)
+
+	_, span := trace.Start(ctx, "dante.start_device_scan", slog.String("dante.domain", d.Name))
+	defer span.End()
+
+	// 調用 Dante SDK 開始設備掃描
+	result, errorMsg := d.retryOp(ctx, "dante_start_device_scan", SDK.StartDeviceScan)
+	if result != 0 {
+		err := newSDKError("dante_start_device_scan", errorMsg)
+		span.RecordError(err)
+		return err
+	}
+
+	d.log.Info("Device scan started")
+
+	// 啟動背景事件處理
+	d.mu.Lock()
+	domainCtx := d.ctx
+	d.events.Add(1)
+	d.mu.Unlock()
+	// 事件處理中的 panic (例如異常的 cgo 回呼) 不能讓網域從此不再處理事件
+	recovery.Go(d.Name+"/events", func() {
+		defer d.events.Done()
+		recovery.Loop(domainCtx, d.Name+"/events", func() { d.processEventsLoop(ctx, domainCtx) })
+	})
+
+	return nil
+}
+
+// processEventsLoop 背景事件處理循環，scan 或 domain 結束時返回
+// 事件處理後 SDK 的變更計數增加時發出 Changes 通知
+func (d *Domain) processEventsLoop(scan, domain context.Context) {
+	interval := d.EventInterval
+	if interval <= 0 {
+		interval = DefaultEventInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen, _ := d.sdkOp(SDK.ChangeCount)
+	for {
+		select {
+		case <-scan.Done():
+			return
+		case <-domain.Done():
+			return
+		case <-ticker.C:
+			seen = d.processEvents(scan, seen)
+		}
+	}
+}
+
+// processEvents 處理一次 SDK 事件，回傳最新的變更計數
+// panic 時也結束呼叫的記錄，watchdog 不會把回復後的循環當成卡住
+func (d *Domain) processEvents(ctx context.Context, seen int) int {
+	_, span := trace.Start(ctx, "dante.process_events", slog.String("dante.domain", d.Name))
+	defer span.End()
+
+	d.beginCall(CallProcessEvents)
+	result, errorMsg := -1, "panic while processing events"
+	func() {
+		defer func() { d.endCall(CallProcessEvents, result < 0, errorMsg) }()
+		result, errorMsg = d.sdkOp(SDK.ProcessEventsBriefly)
+	}()
+	count, _ := d.sdkOp(SDK.ChangeCount)
+	if count != seen {
+		span.AddEvent("dante.devices_changed")
+		d.invalidate()
+		d.notifyChange()
+	}
+	return count
+}
+
+// notifyChange 發出變更通知；前一個通知還沒被讀取時合併
+func (d *Domain) notifyChange() {
+	select {
+	case d.changes <- struct{}{}:
+	default:
+	}
+}
+
+// Changes SDK 通知設備加入、離開或變更時收到訊號 (連續的通知會合併)
+func (d *Domain) Changes() <-chan struct{} {
+	return d.changes
+}
+
+// RefreshDevices 刷新設備列表
+// 無法取得設備數量時保留上次的數量 (-1 會讓 GetDevices 回傳空列表)
+func (d *Domain) RefreshDevices(ctx context.Context) error {
+	if !d.Initialized() {
+		return d.errNotInitialized()
+	}
+
+	d.log.Debug("Refreshing device list")
+	_, span := trace.Start(ctx, "dante.refresh_device_scan", slog.String("dante.domain", d.Name))
+	defer span.End()
+
+	// 刷新掃描結果
+	d.beginCall(CallRefresh)
+	result, errorMsg := d.retryOp(ctx, "dante_refresh_device_scan", SDK.RefreshDeviceScan)
+
+	// 獲取設備數量
+	count, countMsg := d.sdkOp(SDK.GetDiscoveredDeviceCount)
+	d.invalidate()
+	if count < 0 {
+		d.endCall(CallRefresh, true, countMsg)
+		d.log.Warn("Device count failed", "err", countMsg)
+		err := newSDKError("dante_get_discovered_device_count", countMsg)
+		span.RecordError(err)
+		return err
+	}
+	var err error
+	if result < 0 {
+		d.endCall(CallRefresh, true, errorMsg)
+		d.log.Warn("Device scan refresh failed", "err", errorMsg)
+		err = newSDKError("dante_refresh_device_scan", errorMsg)
+		span.RecordError(err)
+	} else {
+		d.endCall(CallRefresh, false, "")
+	}
+	d.mu.Lock()
+	previous := d.deviceCount
+	d.deviceCount = count
+	d.mu.Unlock()
+	span.SetAttributes(slog.Int("dante.devices", count))
+
+	// 定期刷新的結果通常相同，只有數量改變時以 Info 記錄
+	level := slog.LevelDebug
+	if count != previous {
+		level = slog.LevelInfo
+	}
+	d.log.Log(ctx, level, "Device list refreshed", "devices", count)
+	return err
+}
+
+// GetDevices 取得目前已發現的設備資訊
+// 以一次 SDK 呼叫讀取整個列表，避免大型網路每台設備各跨一次 cgo；
+// 快取有效時不呼叫 SDK，SDK 忙碌中時回傳最後一次的結果 (見 devicecache.go)
+func (d *Domain) GetDevices() []Device {
+	now := time.Now()
+	cached := d.DeviceTTL > 0
+	if cached {
+		if devices, ok := d.cachedDevices(now, false); ok {
+			d.applyEnrollments(devices)
+			return devices
+		}
+	}
+
+	d.mu.Lock()
+	count := d.deviceCount
+	d.mu.Unlock()
+	d.cacheMu.Lock()
+	generation := d.generation
+	d.cacheMu.Unlock()
+
+	var devices []Device
+	read := func(s SDK) (result int) {
+		devices, result = s.GetDeviceList(count)
+		return result
+	}
+	var result int
+	if cached {
+		var ok bool
+		if result, _, ok = d.trySDKOp(read); !ok {
+			if stale, ok := d.cachedDevices(now, true); ok {
+				d.applyEnrollments(stale)
+				return stale
+			}
+			result, _ = d.sdkOp(read)
+		}
+	} else {
+		result, _ = d.sdkOp(read)
+	}
+	if result < 0 || devices == nil {
+		return []Device{}
+	}
+	if cached {
+		d.storeDevices(devices, generation, now)
+	}
+	d.applyEnrollments(devices)
+	return devices
+}
+
+// Cleanup 清理資源
+// 先取消網域的 context 並等待事件處理結束，確保 SDK 釋放後不再被呼叫
+func (d *Domain) Cleanup() {
+	d.mu.Lock()
+	if !d.initialized {
+		d.mu.Unlock()
+		return
+	}
+	d.initialized = false
+	d.cancel()
+	d.mu.Unlock()
+	d.cacheMu.Lock()
+	d.generation++
+	d.cache = nil
+	d.cacheMu.Unlock()
+
+	d.log.Info("Cleaning up Dante domain")
+	d.events.Wait()
+	d.sdkOp(SDK.StopDeviceScan)
+	d.sdkOp(func(s SDK) int {
+		s.Cleanup()
+		return 0
+	})
+	d.releaseNative()
+}
+
+// native 是否使用原生 SDK (C 狀態是整個行程共用的)
+func (d *Domain) native() bool {
+	sdk := d.sdk
+	if rec, ok := sdk.(*TapeRecorder); ok {
+		sdk = rec.inner
+	}
+	_, ok := sdk.(nativeSDK)
+	return ok
+}
+
+// claimNative 原生網域取得行程內唯一的 SDK 狀態，已由其他網域使用時回傳 ErrSDKInUse
+// (另一個網域的重啟會以 dante_cleanup 清掉這個網域的 DAPI 實例與設備瀏覽)
+func (d *Domain) claimNative() error {
+	if !d.native() {
+		return nil
+	}
+	var owner string
+	nativeThread.do(func() {
+		if nativeOwner == nil {
+			nativeOwner = d
+		}
+		if nativeOwner != d {
+			owner = nativeOwner.Name
+		}
+	})
+	if owner != "" {
+		return classified(ErrSDKInUse, "domain %s: Dante SDK already initialized by domain %s in this process, run each domain as its own instance", d.Name, owner)
+	}
+	return nil
+}
+
+// releaseNative 釋放 claimNative 取得的 SDK 狀態
+func (d *Domain) releaseNative() {
+	nativeThread.do(func() {
+		if nativeOwner == d {
+			nativeOwner = nil
+		}
+	})
+}
+
+//==============================================================================
+// Dante 路由訂閱
+//==============================================================================
+
+// maxRxChannels 單一設備最多讀取的接收通道數
+const maxRxChannels = 512
+
+// Subscription 接收通道的訂閱狀態
+type Subscription struct {
+	ChannelID int    `json:"channel_id"` // 接收通道編號 (1-based)
+	Channel   string `json:"channel"`    // 接收通道名稱
+	TxChannel string `json:"tx_channel"` // 訂閱的發送通道 (空白表示未訂閱)
+	TxDevice  string `json:"tx_device"`  // 訂閱的發送設備
+	Status    int    `json:"status"`     // dante_rxstatus_t
+}
+
+// Subscribed 是否有訂閱
+func (s Subscription) Subscribed() bool {
+	return s.TxChannel != ""
+}
+
+// Multicast 訂閱由發送設備的 multicast flow 提供
+func (s Subscription) Multicast() bool {
+	return s.Status == rxStatusMulticast
+}
+
+// StatusText 訂閱狀態說明
+func (s Subscription) StatusText() string {
+	if text, ok := rxStatusText[s.Status]; ok {
+		return text
+	}
+	return fmt.Sprintf("status 0x%x", s.Status)
+}
+
+// 訂閱狀態分類 (Subscription.State)
+const (
+	SubscriptionNone           = "none"            // 未訂閱
+	SubscriptionOK             = "ok"              // 已連線，有聲音
+	SubscriptionPending        = "pending"         // 正在建立連線
+	SubscriptionUnresolved     = "unresolved"      // 網路上找不到發送設備或通道
+	SubscriptionFormatMismatch = "format-mismatch" // 取樣率或編碼與發送通道不同
+	SubscriptionNoAudio        = "no-audio"        // 找到發送設備但無法建立音訊 flow
+)
+
+// State 訂閱狀態的分類，細節見 Reason
+func (s Subscription) State() string {
+	if !s.Subscribed() {
+		return SubscriptionNone
+	}
+	switch s.Status {
+	case 0x04, 0x09, 0x0A, 0x0E:
+		return SubscriptionOK
+	case 0x02, 0x07, 0x08, 0x23, 0x24, 0x61:
+		return SubscriptionPending
+	case 0x00, 0x01, 0x03, 0x05, 0x20, 0x42:
+		return SubscriptionUnresolved
+	case 0x10, 0x11, 0x41:
+		return SubscriptionFormatMismatch
+	}
+	return SubscriptionNoAudio
+}
+
+// Reason 訂閱沒有聲音的原因 (已連線或未訂閱時為空白)
+func (s Subscription) Reason() string {
+	switch s.State() {
+	case SubscriptionNone, SubscriptionOK:
+		return ""
+	}
+	if reason, ok := rxStatusReason[s.Status]; ok {
+		return strings.ReplaceAll(reason, "{tx}", s.TxChannel+"@"+s.TxDevice)
+	}
+	return "the receiver reports " + s.StatusText()
+}
+
+// rxStatusMulticast DANTE_RXSTATUS_MULTICAST
+const rxStatusMulticast = 0x0A
+
+// rxStatusText 常見的 DANTE_RXSTATUS_* 說明
+var rxStatusText = map[int]string{
+	0x00: "none",
+	0x01: "unresolved",
+	0x02: "resolved",
+	0x03: "resolve failed",
+	0x04: "subscribed to self",
+	0x07: "idle",
+	0x08: "in progress",
+	0x09: "connected (unicast)",
+	0x0A: "connected (multicast)",
+	0x0E: "manual",
+	0x0F: "no connection",
+	0x10: "channel format mismatch",
+	0x11: "bundle format mismatch",
+	0x12: "no RX flows",
+	0x13: "RX failure",
+	0x14: "no TX flows",
+	0x15: "TX failure",
+	0x16: "RX QoS failure",
+	0x17: "TX QoS failure",
+	0x18: "TX rejected address",
+	0x1A: "latency mismatch",
+	0x1B: "clock domain mismatch",
+	0x1D: "RX link down",
+	0x1E: "TX link down",
+	0x20: "invalid TX channel",
+	0x23: "TX not ready",
+	0x24: "RX not ready",
+	0x25: "TX fan-out limit reached",
+	0x60: "TX access denied",
+	0x61: "TX access pending",
+	0xFF: "system failure",
+}
+
+// rxStatusReason 訂閱沒有聲音的原因 ({tx} 代入發送通道@發送設備)
+var rxStatusReason = map[int]string{
+	0x00: "{tx} has not been looked up yet",
+	0x01: "{tx} was not found on the network, check that the transmitter is online and the names are spelled correctly",
+	0x02: "{tx} was found, the receiver has not set up the flow yet",
+	0x03: "an error occurred while looking up {tx}",
+	0x05: "the transmitter of {tx} is online but has no such channel",
+	0x07: "the flow from {tx} is configured but lacks the information to carry audio",
+	0x08: "the flow from {tx} is being set up",
+	0x0F: "the receiver could not communicate with the transmitter of {tx}",
+	0x10: "the sample rate or encoding of {tx} does not match the receiver",
+	0x11: "the flow format offered by {tx} does not match the receiver",
+	0x12: "the receiver has no free RX flows, combine channels from the same transmitter or use multicast",
+	0x13: "the receiver failed to set up the flow from {tx}",
+	0x14: "the transmitter has no free TX flows for {tx}, use a multicast flow",
+	0x15: "the transmitter failed to set up the flow for {tx}",
+	0x16: "the receiver could not reserve bandwidth for the flow from {tx}",
+	0x17: "the transmitter could not reserve bandwidth for {tx}",
+	0x18: "the transmitter of {tx} rejected the receiver address, check the IP addresses and subnets",
+	0x19: "the transmitter of {tx} sent an invalid response",
+	0x1A: "the latency of {tx} exceeds the receive latency, raise the latency of the receiver",
+	0x1B: "{tx} is in a different clock domain",
+	0x1C: "the receiver does not support the flow from {tx}",
+	0x1D: "the network link of the receiver is down",
+	0x1E: "the network link of the transmitter of {tx} is down",
+	0x1F: "the flow protocol of {tx} is not supported by the receiver",
+	0x20: "{tx} is not a valid channel of the transmitter",
+	0x21: "the transmitter of {tx} could not schedule the flow",
+	0x22: "the receiver does not allow subscriptions to its own channels",
+	0x23: "the transmitter of {tx} is not ready yet (starting up)",
+	0x24: "the receiver is not ready yet (starting up)",
+	0x25: "the transmitter of {tx} reached its unicast flow limit, use a multicast flow",
+	0x26: "{tx} is encrypted and the receiver cannot decrypt it",
+	0x41: "the format of {tx} does not match the device template",
+	0x42: "{tx} is missing from the device template",
+	0x60: "the transmitter of {tx} denied access to the receiver",
+	0x61: "the transmitter of {tx} has not granted access to the receiver yet",
+	0xFF: "the receiver reported a system failure",
+}
+
+// ListSubscriptions 讀取接收設備所有通道的訂閱
+func (d *Domain) ListSubscriptions(ctx context.Context, rxDevice string) ([]Subscription, error) {
+	if !d.Initialized() {
+		return nil, d.errNotInitialized()
+	}
+
+	_, span := trace.Start(ctx, "dante.route_list",
+		slog.String("dante.domain", d.Name), slog.String("dante.rx.device", rxDevice))
+	defer span.End()
+
+	var subs []Subscription
+	count, errorMsg := d.retryOp(ctx, "dante_route_list", func(s SDK) (count int) {
+		subs, count = s.RouteList(rxDevice, maxRxChannels)
+		return count
+	})
+	if count < 0 {
+		err := newSDKError("dante_route_list", errorMsg)
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(slog.Int("dante.rx.channels", count))
+	return subs, nil
+}
+
+// Subscribe 讓接收通道訂閱 txChannel@txDevice，txDevice 空白表示取消訂閱
+func (d *Domain) Subscribe(ctx context.Context, rxDevice, rxChannel, txDevice, txChannel string) error {
+	if !d.Initialized() {
+		return d.errNotInitialized()
+	}
+	if err := d.checkConfigurable(rxDevice); err != nil {
+		return err
+	}
+
+	_, span := trace.Start(ctx, "dante.route_subscribe",
+		slog.String("dante.domain", d.Name),
+		slog.String("dante.rx.device", rxDevice), slog.String("dante.rx.channel", rxChannel),
+		slog.String("dante.tx.device", txDevice), slog.String("dante.tx.channel", txChannel))
+	defer span.End()
+
+	result, errorMsg := d.sdkOp(func(s SDK) int { return s.RouteSubscribe(rxDevice, rxChannel, txDevice, txChannel) })
+	if result != 0 {
+		err := newSDKError("dante_route_subscribe", errorMsg)
+		span.RecordError(err)
+		return err
+	}
+
+	if txDevice == "" {
+		d.log.Info("Subscription removed", "rx_device", rxDevice, "rx_channel", rxChannel)
+	} else {
+		d.log.Info("Subscription set", "rx_device", rxDevice, "rx_channel", rxChannel,
+			"tx", txChannel+"@"+txDevice)
+	}
+	return nil
+}
+
+//==============================================================================
+// Dante ConMon 監控 (時鐘狀態、識別)
+//==============================================================================
+
+// ClockInfo 設備的時鐘狀態 (來自 ConMon status channel)
+type ClockInfo struct {
+	ClockState    string    `json:"clock_state"`    // 時鐘狀態
+	ServoState    string    `json:"servo_state"`    // 鎖定狀態
+	ClockSource   string    `json:"clock_source"`   // 時鐘來源
+	IsGrandmaster bool      `json:"is_grandmaster"` // 是否為 PTP grandmaster
+	Updated       time.Time `json:"updated"`        // 最後更新時間
+}
+
+// StartMonitoring 建立 ConMon client，之後才能查詢時鐘狀態與識別設備
+func (d *Domain) StartMonitoring() error {
+	if !d.Initialized() {
+		return d.errNotInitialized()
+	}
+	if result, errorMsg := d.sdkOp(SDK.MonitorStart); result != 0 {
+		return newSDKError("dante_monitor_start", errorMsg)
+	}
+	d.log.Info("ConMon monitoring started")
+	return nil
+}
+
+// WatchClock 訂閱設備狀態並查詢時鐘 (重複呼叫會重新查詢)
+// 同時查詢製造商版本，之後的設備列表附加製造商、序號與能力
+func (d *Domain) WatchClock(device string) error {
+	if !d.Initialized() {
+		return d.errNotInitialized()
+	}
+
+	if result, errorMsg := d.sdkOp(func(s SDK) int { return s.MonitorWatchDevice(device) }); result != 0 {
+		return newSDKError("dante_monitor_watch_device", errorMsg)
+	}
+	return nil
+}
+
+// ClockInfo 取得設備最新的時鐘狀態，尚未收到狀態時回傳 false
+func (d *Domain) ClockInfo(device string) (ClockInfo, bool) {
+	if !d.Initialized() {
+		return ClockInfo{}, false
+	}
+
+	var info ClockInfo
+	result, _ := d.sdkOp(func(s SDK) (result int) {
+		info, result = s.GetClockInfo(device)
+		return result
+	})
+	if result != 0 {
+		return ClockInfo{}, false
+	}
+	return info, true
+}
+
+// Identify 讓設備閃燈識別自己
+func (d *Domain) Identify(device string) error {
+	if !d.Initialized() {
+		return d.errNotInitialized()
+	}
+
+	if result, errorMsg := d.sdkOp(func(s SDK) int { return s.IdentifyDevice(device) }); result != 0 {
+		return newSDKError("dante_identify_device", errorMsg)
+	}
+	d.log.Info("Identify sent", "device", device)
+	return nil
+}
+
+//==============================================================================
+// Dante 設備設定 (名稱、通道標籤、取樣率、延遲)
+//==============================================================================
+
+// maxTxChannels 單一設備最多讀取的發送通道數
+const maxTxChannels = 512
+
+// Channel 通道編號與名稱
+type Channel struct {
+	ID   int    `json:"id"`   // 通道編號 (1-based)
+	Name string `json:"name"` // 自訂標籤或預設名稱
+}
+
+// DeviceSettings 設備的音訊設定
+type DeviceSettings struct {
+	SampleRate int `json:"sample_rate"` // Hz (0 表示未知)
+	LatencyUs  int `json:"latency_us"`  // 接收延遲 (微秒)
+}
+
+// TxChannels 讀取設備的發送通道
+func (d *Domain) TxChannels(ctx context.Context, device string) ([]Channel, error) {
+	if !d.Initialized() {
+		return nil, d.errNotInitialized()
+	}
+
+	_, span := trace.Start(ctx, "dante.tx_channel_list",
+		slog.String("dante.domain", d.Name), slog.String("dante.device", device))
+	defer span.End()
+
+	var channels []Channel
+	count, errorMsg := d.retryOp(ctx, "dante_tx_channel_list", func(s SDK) (count int) {
+		channels, count = s.TxChannelList(device, maxTxChannels)
+		return count
+	})
+	if count < 0 {
+		err := newSDKError("dante_tx_channel_list", errorMsg)
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(slog.Int("dante.tx.channels", count))
+	return channels, nil
+}
+
+// DeviceSettings 讀取設備的取樣率與接收延遲
+func (d *Domain) DeviceSettings(ctx context.Context, device string) (DeviceSettings, error) {
+	if !d.Initialized() {
+		return DeviceSettings{}, d.errNotInitialized()
+	}
+
+	_, span := trace.Start(ctx, "dante.get_device_settings",
+		slog.String("dante.domain", d.Name), slog.String("dante.device", device))
+	defer span.End()
+
+	var settings DeviceSettings
+	result, errorMsg := d.retryOp(ctx, "dante_get_device_settings", func(s SDK) (result int) {
+		settings, result = s.GetDeviceSettings(device)
+		return result
+	})
+	if result != 0 {
+		err := newSDKError("dante_get_device_settings", errorMsg)
+		span.RecordError(err)
+		return DeviceSettings{}, err
+	}
+	return settings, nil
+}
+
+// RenameDevice 重新命名設備 (以舊名稱訂閱這台設備的通道會變成 unresolved)
+func (d *Domain) RenameDevice(ctx context.Context, device, newName string) error {
+	if err := CheckDeviceName(newName); err != nil {
+		return err
+	}
+	err := d.settingsOp(ctx, "dante.rename_device", "dante_rename_device", device,
+		func(s SDK) int { return s.RenameDevice(device, newName) },
+		"Device renamed", "name", newName)
+	if err == nil {
+		d.renameEnrollment(device, newName)
+	}
+	return err
+}
+
+// SetTxChannelName 設定發送通道的標籤，name 空白表示恢復預設名稱
+func (d *Domain) SetTxChannelName(ctx context.Context, device string, channelID int, name string) error {
+	return d.settingsOp(ctx, "dante.set_channel_name", "dante_set_channel_name", device,
+		func(s SDK) int { return s.SetChannelName(device, true, channelID, name) },
+		"TX channel renamed", "channel", channelID, "name", name)
+}
+
+// SetRxChannelName 設定接收通道的標籤，name 空白表示恢復預設名稱
+func (d *Domain) SetRxChannelName(ctx context.Context, device string, channelID int, name string) error {
+	return d.settingsOp(ctx, "dante.set_channel_name", "dante_set_channel_name", device,
+		func(s SDK) int { return s.SetChannelName(device, false, channelID, name) },
+		"RX channel renamed", "channel", channelID, "name", name)
+}
+
+// SetLatency 設定設備的接收延遲 (微秒)
+func (d *Domain) SetLatency(ctx context.Context, device string, latencyUs int) error {
+	return d.settingsOp(ctx, "dante.set_rx_latency", "dante_set_rx_latency", device,
+		func(s SDK) int { return s.SetRxLatency(device, latencyUs) },
+		"Latency set", "latency_us", latencyUs)
+}
+
+// SetSampleRate 設定設備的取樣率 (經由 ConMon，需要先 StartMonitoring；
+// 部分設備要重新開機才生效)
+func (d *Domain) SetSampleRate(ctx context.Context, device string, sampleRate int) error {
+	return d.settingsOp(ctx, "dante.set_sample_rate", "dante_set_sample_rate", device,
+		func(s SDK) int { return s.SetSampleRate(device, sampleRate) },
+		"Sample rate set", "sample_rate", sampleRate)
+}
+
+// settingsOp 執行變更設備設定的 SDK 操作，成功時記錄 msg 與 attrs
+func (d *Domain) settingsOp(ctx context.Context, spanName, function, device string, op func(SDK) int, msg string, attrs ...any) error {
+	if !d.Initialized() {
+		return d.errNotInitialized()
+	}
+	if err := d.checkConfigurable(device); err != nil {
+		return err
+	}
+
+	_, span := trace.Start(ctx, spanName,
+		slog.String("dante.domain", d.Name), slog.String("dante.device", device))
+	defer span.End()
+
+	if result, errorMsg := d.sdkOp(op); result != 0 {
+		err := newSDKError(function, errorMsg)
+		span.RecordError(err)
+		return err
+	}
+	d.invalidate()
+	d.log.Info(msg, append([]any{"device", device}, attrs...)...)
+	return nil
+}
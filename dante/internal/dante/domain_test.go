@@ -0,0 +1,57 @@
+package dante
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sharedErrorSDK 與 C wrapper 一樣，所有網域共用最後的錯誤訊息
+type sharedErrorSDK struct {
+	*SimulatedSDK
+	lastError *string
+	failed    chan struct{} // 非 nil 時失敗後通知，並停留一段時間讓其他網域有機會插入
+}
+
+func (s *sharedErrorSDK) IdentifyDevice(device string) int {
+	*s.lastError = "identify " + device + " failed"
+	if s.failed != nil {
+		close(s.failed)
+		time.Sleep(50 * time.Millisecond)
+	}
+	return -1
+}
+
+func (s *sharedErrorSDK) GetLastError() string {
+	return *s.lastError
+}
+
+func TestSDKOpPairsLastErrorAcrossDomains(t *testing.T) {
+	var lastError string
+	slow := &sharedErrorSDK{SimulatedSDK: NewSimulatedSDK(DefaultSimulationConfig()), lastError: &lastError, failed: make(chan struct{})}
+	fast := &sharedErrorSDK{SimulatedSDK: NewSimulatedSDK(DefaultSimulationConfig()), lastError: &lastError}
+
+	domains := make([]*Domain, 2)
+	for i, sdk := range []*sharedErrorSDK{slow, fast} {
+		d := NewSimulatedDomain(fmt.Sprintf("Dante%d", i+1), NetworkConfig{InterfaceName: "sim0"}, sdk.SimulatedSDK)
+		d.sdk = sdk
+		if err := d.Initialize(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(d.Cleanup)
+		domains[i] = d
+	}
+
+	// 第二個網域在第一個網域失敗後、讀取錯誤訊息前呼叫
+	slowErr := make(chan error)
+	go func() { slowErr <- domains[0].Identify("amp-1") }()
+	<-slow.failed
+	if err := domains[1].Identify("mixer"); err == nil || !strings.Contains(err.Error(), "identify mixer failed") {
+		t.Errorf("Dante2 Identify = %v", err)
+	}
+	if err := <-slowErr; err == nil || !strings.Contains(err.Error(), "identify amp-1 failed") {
+		t.Errorf("Dante1 Identify = %v, want its own error message", err)
+	}
+}
@@ -0,0 +1,128 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"danteCS/internal/trace"
+)
+
+//==============================================================================
+// Dante Domain Manager (DDM) 註冊狀態
+//==============================================================================
+
+// golane 是非受管 (ad-hoc) 控制器：註冊到 DDM 網域的設備只接受該網域的
+// 控制器設定，從這裡改名、改設定或訂閱都會被設備拒絕。刷新後檢查新設備的
+// 註冊狀態，設備列表標示無法設定的設備，設定操作事先回傳 ErrReadOnly，
+// 而不是送出後才得到 SDK 的錯誤碼。
+
+// Enrollment 設備的 DDM 註冊狀態
+type Enrollment struct {
+	Managed  bool   `json:"managed"`             // 已註冊到 DDM 網域
+	Domain   string `json:"domain,omitempty"`    // DDM 網域名稱 (ad-hoc 瀏覽無法得知時為空白)
+	ReadOnly string `json:"read_only,omitempty"` // 這個控制器無法設定的原因 (鎖定、未授權也在此)
+}
+
+// ErrReadOnly 設備無法由這個控制器設定 (已註冊到 DDM 網域或鎖定)
+var ErrReadOnly = errors.New("device cannot be configured by this controller")
+
+// CheckEnrollment 查詢設備的註冊狀態並記住結果 (之後的 GetDevices 會附加)
+func (d *Domain) CheckEnrollment(ctx context.Context, device string) (Enrollment, error) {
+	if !d.Initialized() {
+		return Enrollment{}, d.errNotInitialized()
+	}
+
+	_, span := trace.Start(ctx, "dante.get_device_enrollment",
+		slog.String("dante.domain", d.Name), slog.String("dante.device", device))
+	defer span.End()
+
+	var e Enrollment
+	result, errorMsg := d.retryOp(ctx, "dante_get_device_enrollment", func(s SDK) (result int) {
+		e, result = s.GetDeviceEnrollment(device)
+		return result
+	})
+	if result != 0 {
+		err := newSDKError("dante_get_device_enrollment", errorMsg)
+		span.RecordError(err)
+		return Enrollment{}, err
+	}
+
+	d.enrollMu.Lock()
+	old, seen := d.enrollments[device]
+	d.enrollments[device] = e
+	d.enrollMu.Unlock()
+	if e.ReadOnly != "" && (!seen || old != e) {
+		d.log.Warn("Device cannot be configured by this controller", "device", device,
+			"managed", e.Managed, "ddm_domain", e.Domain, "reason", e.ReadOnly)
+	}
+	return e, nil
+}
+
+// CheckEnrollments 檢查還沒有註冊狀態的設備，並忘記已離開的設備
+// (設備重新出現時再檢查一次，註冊或退出 DDM 網域的設備會重新公告)
+// 單台設備查詢失敗只記錄，下次刷新再試
+func (d *Domain) CheckEnrollments(ctx context.Context, devices []Device) {
+	present := make(map[string]bool, len(devices))
+	var pending []string
+	d.enrollMu.Lock()
+	for _, dev := range devices {
+		present[dev.Name] = true
+		if _, ok := d.enrollments[dev.Name]; !ok {
+			pending = append(pending, dev.Name)
+		}
+	}
+	for name := range d.enrollments {
+		if !present[name] {
+			delete(d.enrollments, name)
+		}
+	}
+	d.enrollMu.Unlock()
+
+	for _, name := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := d.CheckEnrollment(ctx, name); err != nil {
+			d.log.Debug("Enrollment check failed", "device", name, "err", err)
+		}
+	}
+}
+
+// Enrollment 已檢查的註冊狀態，尚未檢查時回傳 false
+func (d *Domain) Enrollment(device string) (Enrollment, bool) {
+	d.enrollMu.Lock()
+	defer d.enrollMu.Unlock()
+	e, ok := d.enrollments[device]
+	return e, ok
+}
+
+// applyEnrollments 把已檢查的註冊狀態附加到設備列表
+func (d *Domain) applyEnrollments(devices []Device) {
+	d.enrollMu.Lock()
+	defer d.enrollMu.Unlock()
+	for i := range devices {
+		e := d.enrollments[devices[i].Name]
+		devices[i].Managed, devices[i].DDMDomain, devices[i].ReadOnly = e.Managed, e.Domain, e.ReadOnly
+	}
+}
+
+// renameEnrollment 改名後以新名稱保留註冊狀態
+func (d *Domain) renameEnrollment(from, to string) {
+	d.enrollMu.Lock()
+	defer d.enrollMu.Unlock()
+	if e, ok := d.enrollments[from]; ok {
+		delete(d.enrollments, from)
+		d.enrollments[to] = e
+	}
+}
+
+// checkConfigurable 已知無法設定的設備回傳 ErrReadOnly (尚未檢查的設備照常送出)
+func (d *Domain) checkConfigurable(device string) error {
+	e, ok := d.Enrollment(device)
+	if !ok || e.ReadOnly == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s: %s", ErrReadOnly, device, e.ReadOnly)
+}
@@ -0,0 +1,60 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEnrolledDeviceMarkedAndRejected(t *testing.T) {
+	cfg := &SimulationConfig{Interface: "sim0", Devices: []SimulatedDevice{
+		{Name: "console", Model: "DL32", IPAddress: "10.1.0.10", TxChannels: 2, RxChannels: 2},
+		{Name: "amp", Model: "PA-4D", IPAddress: "10.1.0.11", RxChannels: 2, DDMDomain: "Venue-A"},
+	}}
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), NewSimulatedSDK(cfg))
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(ctx)
+
+	// 檢查前照常送出，由設備拒絕
+	if err := d.Subscribe(ctx, "amp", "01", "console", "01"); err == nil || errors.Is(err, ErrReadOnly) {
+		t.Fatalf("unchecked device: err = %v, want SDK error", err)
+	}
+
+	d.CheckEnrollments(ctx, d.GetDevices())
+	devices := d.GetDevices()
+	if devices[0].Managed || devices[0].ReadOnly != "" {
+		t.Fatalf("console marked read-only: %+v", devices[0])
+	}
+	if !devices[1].Managed || devices[1].DDMDomain != "Venue-A" || devices[1].ReadOnly == "" {
+		t.Fatalf("amp not marked enrolled: %+v", devices[1])
+	}
+
+	if err := d.Subscribe(ctx, "amp", "01", "console", "01"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("subscribe on enrolled device: err = %v", err)
+	}
+	if err := d.SetLatency(ctx, "amp", 2000); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("latency on enrolled device: err = %v", err)
+	}
+	if err := d.Subscribe(ctx, "console", "01", "amp", "01"); err != nil {
+		t.Fatalf("enrolled device as transmitter: %v", err)
+	}
+
+	// 改名後以新名稱保留，離開的設備下次重新檢查
+	if err := d.RenameDevice(ctx, "console", "foh"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.Enrollment("foh"); !ok {
+		t.Fatal("enrollment not moved to the new name")
+	}
+	d.CheckEnrollments(ctx, []Device{{Name: "foh"}})
+	if _, ok := d.Enrollment("amp"); ok {
+		t.Fatal("enrollment of a departed device kept")
+	}
+}
@@ -0,0 +1,178 @@
+package dante
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//==============================================================================
+// SDK 錯誤分類
+//==============================================================================
+
+// SDK 失敗時只有 dante_get_last_error 的字串 (部分附帶 aud_error_t 代碼)。
+// 呼叫端要依失敗的類別決定處理方式 (重試、回報設定錯誤、改用其他網卡)，
+// 所以每個 SDK 錯誤都包成 *SDKError，並依代碼或訊息歸類到下面的哨兵錯誤，
+// 以 errors.Is(err, dante.ErrDiscoveryTimeout) 判斷，不必比對字串。
+// 錯誤訊息維持原本的 "<函式> failed: <SDK 訊息>"。
+
+// 錯誤類別
+var (
+	ErrNotInitialized   = errors.New("not initialized")             // 網域或 SDK 尚未初始化
+	ErrInterfaceDown    = errors.New("network interface down")      // 網卡不存在、沒有連線或沒有地址
+	ErrSDKLicense       = errors.New("Dante SDK license problem")   // SDK 或設備未授權
+	ErrDiscoveryTimeout = errors.New("discovery timed out")         // 設備沒有在時限內回應或解析
+	ErrDeviceNotFound   = errors.New("device not found")            // 設備不在網路上
+	ErrChannelNotFound  = errors.New("channel or flow not found")   // 設備上沒有這個通道或 flow
+	ErrNotConnected     = errors.New("not connected")               // 與設備或 ConMon 的連線中斷
+	ErrAccessDenied     = errors.New("access denied")               // 設備拒絕變更 (鎖定、權限)
+	ErrInvalidArgument  = errors.New("invalid argument")            // 參數或地址不正確
+	ErrCapacity         = errors.New("device capacity exceeded")    // 超過設備的 flow、通道或頻寬上限
+	ErrUnsupported      = errors.New("not supported by the device") // 設備或韌體不支援
+	ErrSDKInUse         = errors.New("Dante SDK in use")            // 行程內的原生 SDK 已由其他網域初始化
+)
+
+// SDKError SDK 呼叫失敗
+type SDKError struct {
+	Func    string // SDK 函式 (dante_route_subscribe)
+	Message string // dante_get_last_error 的內容
+	Code    int    // 訊息中的 aud_error_t，沒有時為 0
+	Kind    error  // 錯誤類別 (ErrDiscoveryTimeout 等)，無法歸類時為 nil
+}
+
+func (e *SDKError) Error() string {
+	return e.Func + " failed: " + e.Message
+}
+
+// Unwrap 讓 errors.Is 比對錯誤類別
+func (e *SDKError) Unwrap() error {
+	return e.Kind
+}
+
+// newSDKError 由 SDK 函式名稱與錯誤訊息建立 *SDKError 並歸類
+func newSDKError(function, message string) *SDKError {
+	code := parseErrorCode(message)
+	kind := codeKinds[code]
+	if kind == nil {
+		kind = messageKind(message)
+	}
+	return &SDKError{Func: function, Message: message, Code: code, Kind: kind}
+}
+
+// aud_error_t 代碼 (platform_error.h 的列舉順序，只列出有歸類的)
+const (
+	audErrInvalidParameter  = 3
+	audErrNotSupported      = 9
+	audErrTimedOut          = 10
+	audErrNotFound          = 11
+	audErrRange             = 13
+	audErrPolicy            = 14
+	audErrVersion           = 15
+	audErrAccess            = 18
+	audErrAddrNotAvail      = 20
+	audErrConnRefused       = 25
+	audErrConnReset         = 26
+	audErrHostUnreach       = 29
+	audErrNetDown           = 34
+	audErrNetUnreach        = 36
+	audErrNoBufs            = 37
+	audErrNoDev             = 39
+	audErrNotConn           = 42
+	audErrNotInitialised    = 43
+	audErrBandwidthExceeded = 51
+)
+
+// codeKinds aud_error_t 代碼的類別
+var codeKinds = map[int]error{
+	audErrInvalidParameter:  ErrInvalidArgument,
+	audErrRange:             ErrInvalidArgument,
+	audErrNotInitialised:    ErrNotInitialized,
+	audErrNotSupported:      ErrUnsupported,
+	audErrVersion:           ErrUnsupported,
+	audErrTimedOut:          ErrDiscoveryTimeout,
+	audErrNotFound:          ErrDeviceNotFound,
+	audErrHostUnreach:       ErrDeviceNotFound,
+	audErrPolicy:            ErrAccessDenied,
+	audErrAccess:            ErrAccessDenied,
+	audErrAddrNotAvail:      ErrInterfaceDown,
+	audErrNetDown:           ErrInterfaceDown,
+	audErrNetUnreach:        ErrInterfaceDown,
+	audErrNoDev:             ErrInterfaceDown,
+	audErrConnRefused:       ErrNotConnected,
+	audErrConnReset:         ErrNotConnected,
+	audErrNotConn:           ErrNotConnected,
+	audErrNoBufs:            ErrCapacity,
+	audErrBandwidthExceeded: ErrCapacity,
+}
+
+// errorCode dante_wrapper.c 附帶代碼的訊息："Failed to open device 'x': 11"、
+// "dante_route_subscribe failed: 10"、"... (state: 3, error: 10)"
+var errorCode = regexp.MustCompile(`(?:^Failed to .*: |failed: |error: )(\d+)\)?$`)
+
+// parseErrorCode 訊息中的 aud_error_t (沒有時回傳 0)
+func parseErrorCode(message string) int {
+	m := errorCode.FindStringSubmatch(message)
+	if m == nil {
+		return 0
+	}
+	code, _ := strconv.Atoi(m[1])
+	return code
+}
+
+// messageKinds 沒有代碼時依訊息歸類 (依序比對，先符合者優先)
+var messageKinds = []struct {
+	substr string
+	kind   error
+}{
+	{"licen", ErrSDKLicense},
+	{"not initialized", ErrNotInitialized},
+	{"not started", ErrNotInitialized},
+	{"interface", ErrInterfaceDown},
+	{"timed out", ErrDiscoveryTimeout},
+	{"timeout", ErrDiscoveryTimeout},
+	{"did not resolve", ErrDiscoveryTimeout},
+	{"did not become active", ErrDiscoveryTimeout},
+	{"allows at most", ErrCapacity},
+	{"no free", ErrCapacity},
+	{"too many", ErrCapacity},
+	{"cannot be deleted", ErrUnsupported},
+	{"channel", ErrChannelNotFound},
+	{"flow", ErrChannelNotFound},
+	{"not connected", ErrNotConnected},
+	{"denied", ErrAccessDenied},
+	{"not found", ErrDeviceNotFound},
+	{"device index", ErrDeviceNotFound},
+	{"is not valid", ErrDeviceNotFound},
+	{"invalid", ErrInvalidArgument},
+}
+
+func messageKind(message string) error {
+	lower := strings.ToLower(message)
+	for _, k := range messageKinds {
+		if strings.Contains(lower, k.substr) {
+			return k.kind
+		}
+	}
+	return nil
+}
+
+// kindError 帶有錯誤類別的一般錯誤 (訊息不變)
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// classified 建立歸類到 kind 的錯誤
+func classified(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// errNotInitialized 網域尚未初始化
+func (d *Domain) errNotInitialized() error {
+	return classified(ErrNotInitialized, "domain %s not initialized", d.Name)
+}
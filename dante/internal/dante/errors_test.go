@@ -0,0 +1,62 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSDKErrorKinds(t *testing.T) {
+	for msg, want := range map[string]error{
+		"Failed to open device 'amp-1': 10":                         ErrDiscoveryTimeout,
+		"dante_route_subscribe failed: 18":                          ErrAccessDenied,
+		"Failed to create DAPI: 34":                                 ErrInterfaceDown,
+		"Device 'amp-1' did not become active (state: 3, error: 0)": ErrDiscoveryTimeout,
+		"Device 'amp-1' did not resolve (state: 1)":                 ErrDiscoveryTimeout,
+		"Dante not initialized":                                     ErrNotInitialized,
+		"ConMon not connected":                                      ErrNotConnected,
+		"RX channel 'In 9' not found on 'amp-1'":                    ErrChannelNotFound,
+		"Device 'amp-1' has no free TX flows (max 2)":               ErrCapacity,
+		"TX flow 3 on 'amp-1' is automatic and cannot be deleted":   ErrUnsupported,
+		"Device mixer not found":                                    ErrDeviceNotFound,
+		"Invalid multicast address '10.0.0.1'":                      ErrInvalidArgument,
+		"device is unlicensed":                                      ErrSDKLicense,
+	} {
+		err := newSDKError("dante_test", msg)
+		if !errors.Is(err, want) {
+			t.Errorf("%q: kind %v, want %v", msg, err.Kind, want)
+		}
+		if err.Error() != "dante_test failed: "+msg {
+			t.Errorf("message changed: %q", err.Error())
+		}
+	}
+
+	err := newSDKError("dante_init_with_interface", "Failed to get runtime/env")
+	if err.Kind != nil || err.Code != 0 {
+		t.Errorf("unclassified error: %+v", err)
+	}
+	if err := newSDKError("dante_test", "Failed to start browse: 10"); err.Code != audErrTimedOut {
+		t.Errorf("code %d, want %d", err.Code, audErrTimedOut)
+	}
+	// 通道編號不是錯誤代碼
+	if err := newSDKError("dante_test", "Invalid TX channel index: 10"); err.Code != 0 || !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("index parsed as code: %+v", err)
+	}
+}
+
+func TestDomainErrorsAreClassified(t *testing.T) {
+	d := NewSimulatedDomain("Dante1", NetworkConfig{}, NewSimulatedSDK(DefaultSimulationConfig()))
+	if _, err := d.ListSubscriptions(context.Background(), "amp-1"); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("before Initialize: %v", err)
+	}
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	_, err := d.ListSubscriptions(context.Background(), "no-such-device")
+	var sdkErr *SDKError
+	if !errors.As(err, &sdkErr) || sdkErr.Func != "dante_route_list" || !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("unknown device: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"slices"
+
+	"danteCS/internal/trace"
+)
+
+//==============================================================================
+// 發送 flow (multicast)
+//==============================================================================
+
+// 訂閱預設使用 unicast flow，每個接收端佔用發送設備的一個 flow，
+// 大型分配 (一個來源送到數十台設備) 很快就用完設備的 flow 數量。
+// 在發送設備上建立 multicast flow 後，接收端的訂閱改由同一個 flow 提供。
+
+// maxTxFlows 單一設備最多讀取的發送 flow 數
+const maxTxFlows = 64
+
+// maxFlowChannels 單一 flow 最多的通道數 (C wrapper 的上限，設備通常更少)
+const maxFlowChannels = 64
+
+// flowPacketTimes 可用的 packet time (微秒)
+var flowPacketTimes = []int{125, 250, 500, 1000, 2000, 4000, 5000}
+
+// Flow 設備的發送 flow
+type Flow struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	Multicast       bool   `json:"multicast"`
+	Manual          bool   `json:"manual"`            // 手動建立 (可以刪除)，否則是訂閱自動建立的 unicast flow
+	Address         string `json:"address,omitempty"` // 目的地址 (主要網路)
+	Port            int    `json:"port,omitempty"`
+	FramesPerPacket int    `json:"fpp,omitempty"`
+	LatencyUs       int    `json:"latency_us,omitempty"`
+	SampleRate      int    `json:"sample_rate,omitempty"`
+	Channels        []int  `json:"channels"` // 每個 slot 的發送通道編號 (0 為空白 slot)
+}
+
+// FlowConfig 建立 multicast flow 的設定
+type FlowConfig struct {
+	Name         string `json:"name,omitempty"`           // 空白由設備命名
+	Channels     []int  `json:"channels"`                 // 發送通道編號，依 slot 順序
+	Address      string `json:"address,omitempty"`        // multicast 地址，空白由設備選擇
+	Port         int    `json:"port,omitempty"`           // 指定地址時必填
+	PacketTimeUs int    `json:"packet_time_us,omitempty"` // 0 為設備預設
+}
+
+// ErrInvalidFlow flow 設定不正確
+var ErrInvalidFlow = errors.New("invalid flow config")
+
+// Validate 檢查設定 (設備的通道數與 flow 上限由 SDK 檢查)
+func (c FlowConfig) Validate() error {
+	if len(c.Channels) == 0 {
+		return fmt.Errorf("%w: no channels", ErrInvalidFlow)
+	}
+	if len(c.Channels) > maxFlowChannels {
+		return fmt.Errorf("%w: %d channels (max %d)", ErrInvalidFlow, len(c.Channels), maxFlowChannels)
+	}
+	seen := make(map[int]bool, len(c.Channels))
+	for _, ch := range c.Channels {
+		if ch < 1 {
+			return fmt.Errorf("%w: channel %d", ErrInvalidFlow, ch)
+		}
+		if seen[ch] {
+			return fmt.Errorf("%w: channel %d listed twice", ErrInvalidFlow, ch)
+		}
+		seen[ch] = true
+	}
+	if len(c.Name) > 31 {
+		return fmt.Errorf("%w: name longer than 31 characters", ErrInvalidFlow)
+	}
+	if c.Address != "" {
+		addr, err := netip.ParseAddr(c.Address)
+		if err != nil || !addr.Is4() || !addr.IsMulticast() {
+			return fmt.Errorf("%w: %q is not an IPv4 multicast address", ErrInvalidFlow, c.Address)
+		}
+		if c.Port < 1 || c.Port > 65535 {
+			return fmt.Errorf("%w: port %d", ErrInvalidFlow, c.Port)
+		}
+	} else if c.Port != 0 {
+		return fmt.Errorf("%w: port without address", ErrInvalidFlow)
+	}
+	if c.PacketTimeUs != 0 && !slices.Contains(flowPacketTimes, c.PacketTimeUs) {
+		return fmt.Errorf("%w: packet time %d µs (supported: %v)", ErrInvalidFlow, c.PacketTimeUs, flowPacketTimes)
+	}
+	return nil
+}
+
+// TxFlows 讀取設備的發送 flow
+func (d *Domain) TxFlows(ctx context.Context, device string) ([]Flow, error) {
+	if !d.Initialized() {
+		return nil, d.errNotInitialized()
+	}
+
+	_, span := trace.Start(ctx, "dante.tx_flow_list",
+		slog.String("dante.domain", d.Name), slog.String("dante.device", device))
+	defer span.End()
+
+	var flows []Flow
+	count, errorMsg := d.retryOp(ctx, "dante_tx_flow_list", func(s SDK) (count int) {
+		flows, count = s.TxFlowList(device, maxTxFlows)
+		return count
+	})
+	if count < 0 {
+		err := newSDKError("dante_tx_flow_list", errorMsg)
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(slog.Int("dante.tx.flows", count))
+	return flows, nil
+}
+
+// CreateMulticastFlow 在設備上建立 multicast 發送 flow，回傳新 flow 的編號
+func (d *Domain) CreateMulticastFlow(ctx context.Context, device string, config FlowConfig) (int, error) {
+	if err := config.Validate(); err != nil {
+		return 0, err
+	}
+	var id int
+	err := d.settingsOp(ctx, "dante.create_multicast_flow", "dante_create_multicast_flow", device,
+		func(s SDK) (result int) {
+			id, result = s.CreateMulticastFlow(device, config)
+			return result
+		},
+		"Multicast flow created", "channels", config.Channels, "address", config.Address)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// DeleteTxFlow 刪除手動建立的發送 flow (自動建立的 unicast flow 無法刪除)
+func (d *Domain) DeleteTxFlow(ctx context.Context, device string, flowID int) error {
+	return d.settingsOp(ctx, "dante.delete_tx_flow", "dante_delete_tx_flow", device,
+		func(s SDK) int { return s.DeleteTxFlow(device, flowID) },
+		"TX flow deleted", "flow", flowID)
+}
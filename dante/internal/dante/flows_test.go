@@ -0,0 +1,80 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMulticastFlowLifecycle(t *testing.T) {
+	cfg := &SimulationConfig{Interface: "sim0", Devices: []SimulatedDevice{
+		{Name: "console", Model: "DL32", IPAddress: "10.1.0.10", TxChannels: 16, RxChannels: 2},
+		{Name: "amp", Model: "PA-4D", IPAddress: "10.1.0.11", TxChannels: 2, RxChannels: 2, DDMDomain: "Venue-A"},
+	}}
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), NewSimulatedSDK(cfg))
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	// 設定錯誤在送出前拒絕
+	for _, bad := range []FlowConfig{
+		{},
+		{Channels: []int{1, 1}},
+		{Channels: []int{1}, Address: "10.0.0.1", Port: 5004},
+		{Channels: []int{1}, Address: "239.69.1.1"},
+		{Channels: []int{1}, PacketTimeUs: 333},
+	} {
+		if _, err := d.CreateMulticastFlow(ctx, "console", bad); !errors.Is(err, ErrInvalidFlow) {
+			t.Errorf("%+v: err = %v, want ErrInvalidFlow", bad, err)
+		}
+	}
+	// 通道數與通道是否存在由設備檢查
+	if _, err := d.CreateMulticastFlow(ctx, "console", FlowConfig{Channels: []int{1, 2, 3, 4, 5, 6, 7, 8, 9}}); err == nil {
+		t.Error("flow larger than the device allows accepted")
+	}
+	if _, err := d.CreateMulticastFlow(ctx, "console", FlowConfig{Channels: []int{17}}); err == nil {
+		t.Error("flow with missing TX channel accepted")
+	}
+
+	first, err := d.CreateMulticastFlow(ctx, "console", FlowConfig{Name: "band", Channels: []int{1, 2, 3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := d.CreateMulticastFlow(ctx, "console", FlowConfig{Channels: []int{5, 6},
+		Address: "239.69.1.1", Port: 5004, PacketTimeUs: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flows, err := d.TxFlows(ctx, "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flows) != 2 || flows[0].ID != first || flows[1].ID != second || first == second {
+		t.Fatalf("flows = %+v", flows)
+	}
+	if f := flows[0]; f.Name != "band" || !f.Multicast || !f.Manual || f.Address == "" || len(f.Channels) != 4 || f.FramesPerPacket != 48 {
+		t.Fatalf("device-chosen flow = %+v", f)
+	}
+	if f := flows[1]; f.Address != "239.69.1.1" || f.Port != 5004 || f.FramesPerPacket != 12 {
+		t.Fatalf("addressed flow = %+v", f)
+	}
+
+	// 刪除後編號可以重新使用
+	if err := d.DeleteTxFlow(ctx, "console", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.DeleteTxFlow(ctx, "console", first); err == nil {
+		t.Fatal("deleting a missing flow succeeded")
+	}
+	if id, err := d.CreateMulticastFlow(ctx, "console", FlowConfig{Channels: []int{7}}); err != nil || id != first {
+		t.Fatalf("reused id = %d, %v, want %d", id, err, first)
+	}
+
+	// DDM 設備只能由 DDM 設定
+	d.CheckEnrollments(ctx, []Device{{Name: "console"}, {Name: "amp"}})
+	if _, err := d.CreateMulticastFlow(ctx, "amp", FlowConfig{Channels: []int{1}}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("flow on enrolled device: err = %v", err)
+	}
+}
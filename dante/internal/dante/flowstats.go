@@ -0,0 +1,108 @@
+package dante
+
+import (
+	"context"
+	"log/slog"
+
+	"danteCS/internal/trace"
+)
+
+//==============================================================================
+// 接收 flow 錯誤統計 (routing error reporting)
+//==============================================================================
+
+// 接收設備對每個 flow、每個介面計算太早、太晚、亂序與遺失的封包數，
+// 以及收到封包的最大延遲。計數從設備啟動 (或其他控制器清除) 開始累計，
+// 這裡只讀取不清除；不同設備提供的種類不同 (見 RxFlowStats.Reported)。
+
+// maxRxFlows 單一設備最多讀取的接收 flow 數
+const maxRxFlows = 64
+
+// 統計種類 (依 DANTE_RXFLOW_ERROR_TYPE_* 的位元順序)
+const (
+	FlowStatEarly      = "early"
+	FlowStatLate       = "late"
+	FlowStatOutOfOrder = "out_of_order"
+	FlowStatDropped    = "dropped"
+	FlowStatMaxLatency = "max_latency"
+)
+
+// flowStatTypes 位元對應的統計種類
+var flowStatTypes = []string{FlowStatEarly, FlowStatLate, FlowStatOutOfOrder, FlowStatDropped, FlowStatMaxLatency}
+
+// flowStatNames 設備提供的統計種類 (DANTE_RXFLOW_ERROR_FLAG_* 位元)
+func flowStatNames(fields int) []string {
+	names := []string{}
+	for bit, name := range flowStatTypes {
+		if fields&(1<<bit) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// FlowCounters 接收 flow 在一個介面上的錯誤計數
+type FlowCounters struct {
+	Early        uint64 `json:"early"`          // 太早到達 (無法使用)
+	Late         uint64 `json:"late"`           // 太晚到達 (超過延遲設定)
+	OutOfOrder   uint64 `json:"out_of_order"`   // 順序錯亂
+	Dropped      uint64 `json:"dropped"`        // 遺失
+	MaxLatencyUs int    `json:"max_latency_us"` // 收到封包的最大延遲 (微秒)
+}
+
+// Errors 所有錯誤封包數
+func (c FlowCounters) Errors() uint64 {
+	return c.Early + c.Late + c.OutOfOrder + c.Dropped
+}
+
+// Add 累加另一組計數 (最大延遲取較大者)
+func (c *FlowCounters) Add(o FlowCounters) {
+	c.Early += o.Early
+	c.Late += o.Late
+	c.OutOfOrder += o.OutOfOrder
+	c.Dropped += o.Dropped
+	c.MaxLatencyUs = max(c.MaxLatencyUs, o.MaxLatencyUs)
+}
+
+// RxFlowStats 接收 flow 的錯誤統計
+type RxFlowStats struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name,omitempty"`
+	TxDevice   string         `json:"tx_device"`         // 發送設備
+	TxFlow     string         `json:"tx_flow,omitempty"` // 發送設備上的 flow (multicast)
+	Multicast  bool           `json:"multicast"`
+	Reported   []string       `json:"reported"`   // 設備提供的統計種類 (其餘計數為 0)
+	Interfaces []FlowCounters `json:"interfaces"` // 依介面：主要、次要
+}
+
+// Total 所有介面的計數
+func (s RxFlowStats) Total() FlowCounters {
+	var total FlowCounters
+	for _, c := range s.Interfaces {
+		total.Add(c)
+	}
+	return total
+}
+
+// RxFlowStats 讀取設備每個接收 flow 的錯誤統計
+func (d *Domain) RxFlowStats(ctx context.Context, device string) ([]RxFlowStats, error) {
+	if !d.Initialized() {
+		return nil, d.errNotInitialized()
+	}
+
+	_, span := trace.Start(ctx, "dante.rx_flow_stats",
+		slog.String("dante.domain", d.Name), slog.String("dante.device", device))
+	defer span.End()
+
+	var stats []RxFlowStats
+	count, errorMsg := d.retryOp(ctx, "dante_rx_flow_stats", func(s SDK) (count int) {
+		stats, count = s.RxFlowStats(device, maxRxFlows)
+		return count
+	})
+	if count < 0 {
+		err := newSDKError("dante_rx_flow_stats", errorMsg)
+		span.RecordError(err)
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,50 @@
+package dante
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRxFlowStats(t *testing.T) {
+	cfg := &SimulationConfig{
+		Interface: "sim0",
+		Devices: []SimulatedDevice{
+			{Name: "console", Model: "DL32", IPAddress: "10.1.0.11", TxChannels: 4},
+			{Name: "stagebox", Model: "Ultimo X4", IPAddress: "10.1.0.12", TxChannels: 2},
+			{Name: "amp", Model: "PA-4D", IPAddress: "10.1.0.13", SecondaryIP: "10.2.0.13", RxChannels: 4},
+		},
+		Routes: []SimulatedRoute{
+			{RxDevice: "amp", RxChannel: "01", TxDevice: "console", TxChannel: "01"},
+			{RxDevice: "amp", RxChannel: "02", TxDevice: "console", TxChannel: "02"},
+			{RxDevice: "amp", RxChannel: "03", TxDevice: "stagebox", TxChannel: "01"},
+			{RxDevice: "amp", RxChannel: "04", TxDevice: "offline", TxChannel: "01"},
+		},
+	}
+	sdk := NewSimulatedSDK(cfg)
+	sdk.FlowErrors["amp"] = map[string]FlowCounters{"console": {Late: 3, Dropped: 2, MaxLatencyUs: 1200}}
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), sdk)
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	stats, err := d.RxFlowStats(ctx, "amp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一台發送設備的通道共用一個 unicast flow，未連線的訂閱沒有 flow
+	if len(stats) != 2 || stats[0].TxDevice != "console" || stats[1].TxDevice != "stagebox" {
+		t.Fatalf("flows = %+v", stats)
+	}
+	total := stats[0].Total()
+	if len(stats[0].Interfaces) != 2 || total.Errors() != 5 || total.MaxLatencyUs != 1200 || stats[1].Total().Errors() != 0 {
+		t.Errorf("console flow = %+v", stats[0])
+	}
+	if got := flowStatNames(0b01010); len(got) != 2 || got[0] != FlowStatLate || got[1] != FlowStatDropped {
+		t.Errorf("flowStatNames = %v", got)
+	}
+	if _, err := d.RxFlowStats(ctx, "missing"); err == nil {
+		t.Error("stats of a missing device")
+	}
+}
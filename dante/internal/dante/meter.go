@@ -0,0 +1,102 @@
+package dante
+
+import (
+	"math"
+	"time"
+)
+
+//==============================================================================
+// 通道電平 (ConMon metering channel)
+//==============================================================================
+
+// 支援 metering 的設備持續送出每個 TX/RX 通道的 peak，訂閱後才會收到。
+// 不支援的設備 (Capabilities.Metering 為 false) 訂閱成功但永遠沒有電平。
+
+// MinPeakDB metering 能表示的最低電平，更低即為靜音
+const MinPeakDB = -126.0
+
+// SignalThresholdDB 視為有訊號的最低電平
+const SignalThresholdDB = -60.0
+
+// metering peak 值 (CONMON_METERING_PEAK_*)
+const (
+	meterPeakClip  = 0x00 // 削峰
+	meterPeak0dB   = 0x01 // 0 dBFS，之後每級 -0.5 dB
+	meterPeakFloor = 0xFD // -126 dBFS
+	meterPeakMute  = 0xFE // 靜音
+)
+
+// ChannelLevel 單一通道的電平
+type ChannelLevel struct {
+	ID     int     `json:"id"`              // 通道編號 (1-based)
+	PeakDB float64 `json:"peak_db"`         // peak 電平 (dBFS，靜音時為 MinPeakDB)
+	Signal bool    `json:"signal"`          // 電平高於 SignalThresholdDB
+	Clip   bool    `json:"clip,omitempty"`  // 削峰
+	Muted  bool    `json:"muted,omitempty"` // 靜音 (沒有音訊)
+}
+
+// Meters 設備所有通道的最新電平
+type Meters struct {
+	TX      []ChannelLevel `json:"tx"`
+	RX      []ChannelLevel `json:"rx"`
+	Updated time.Time      `json:"updated"` // 最後收到電平的時間
+}
+
+// channelLevels 將 metering peak 值轉成通道電平 (依通道順序)
+func channelLevels(peaks []byte) []ChannelLevel {
+	levels := make([]ChannelLevel, len(peaks))
+	for i, peak := range peaks {
+		level := ChannelLevel{ID: i + 1, PeakDB: MinPeakDB}
+		switch {
+		case peak == meterPeakClip:
+			level.PeakDB, level.Clip = 0, true
+		case peak >= meterPeak0dB && peak <= meterPeakFloor:
+			level.PeakDB = -float64(peak-meterPeak0dB) / 2
+		default:
+			level.Muted = true
+		}
+		level.Signal = level.PeakDB >= SignalThresholdDB
+		levels[i] = level
+	}
+	return levels
+}
+
+// meterPeak 將電平轉成 metering peak 值 (模擬使用，與 SDK 的 peak_from_float 相同)
+func meterPeak(db float64) byte {
+	switch {
+	case db > 0:
+		return meterPeakClip
+	case db < MinPeakDB || math.IsNaN(db):
+		return meterPeakMute
+	}
+	return byte(-db*2) + meterPeak0dB
+}
+
+// WatchMeters 訂閱設備的通道電平 (需要 StartMonitoring，重複呼叫不會重複訂閱)
+func (d *Domain) WatchMeters(device string) error {
+	if !d.Initialized() {
+		return d.errNotInitialized()
+	}
+
+	if result, errorMsg := d.sdkOp(func(s SDK) int { return s.MonitorWatchMeters(device) }); result != 0 {
+		return newSDKError("dante_monitor_watch_meters", errorMsg)
+	}
+	return nil
+}
+
+// Meters 取得設備最新的通道電平，設備不支援或尚未收到電平時回傳 false
+func (d *Domain) Meters(device string) (Meters, bool) {
+	if !d.Initialized() {
+		return Meters{}, false
+	}
+
+	var meters Meters
+	result, _ := d.sdkOp(func(s SDK) (result int) {
+		meters, result = s.GetMeters(device)
+		return result
+	})
+	if result != 0 {
+		return Meters{}, false
+	}
+	return meters, true
+}
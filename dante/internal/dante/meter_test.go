@@ -0,0 +1,89 @@
+package dante
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestChannelLevels(t *testing.T) {
+	levels := channelLevels([]byte{meterPeakClip, meterPeak0dB, 0x29, 0x7A, meterPeakFloor, meterPeakMute, 0xFF})
+	want := []ChannelLevel{
+		{ID: 1, PeakDB: 0, Signal: true, Clip: true},
+		{ID: 2, PeakDB: 0, Signal: true},
+		{ID: 3, PeakDB: -20, Signal: true},
+		{ID: 4, PeakDB: -60.5},
+		{ID: 5, PeakDB: MinPeakDB},
+		{ID: 6, PeakDB: MinPeakDB, Muted: true},
+		{ID: 7, PeakDB: MinPeakDB, Muted: true},
+	}
+	if len(levels) != len(want) {
+		t.Fatalf("levels = %+v", levels)
+	}
+	for i := range want {
+		if levels[i] != want[i] {
+			t.Errorf("level %d = %+v, want %+v", i+1, levels[i], want[i])
+		}
+	}
+
+	for _, db := range []float64{0, -0.5, -20, -125.5, MinPeakDB} {
+		if got := channelLevels([]byte{meterPeak(db)})[0].PeakDB; got != db {
+			t.Errorf("meterPeak(%v) round trip = %v", db, got)
+		}
+	}
+	if meterPeak(3) != meterPeakClip || meterPeak(math.Inf(-1)) != meterPeakMute {
+		t.Error("out of range levels not clipped or muted")
+	}
+}
+
+func TestSimulatedMeters(t *testing.T) {
+	cfg := &SimulationConfig{
+		Interface: "sim0",
+		Devices: []SimulatedDevice{
+			{Name: "stagebox", Model: "Ultimo X4", IPAddress: "10.1.0.11", TxChannels: 4, RxChannels: 0, SilentChannels: []string{"02"}},
+			{Name: "amp", Model: "PA-4D", IPAddress: "10.1.0.12", RxChannels: 4, Metering: true},
+			{Name: "legacy", Model: "DAO", IPAddress: "10.1.0.13", RxChannels: 2},
+		},
+		Routes: []SimulatedRoute{
+			{RxDevice: "amp", RxChannel: "01", TxDevice: "stagebox", TxChannel: "01"},
+			{RxDevice: "amp", RxChannel: "02", TxDevice: "stagebox", TxChannel: "02"},
+			{RxDevice: "amp", RxChannel: "03", TxDevice: "offline", TxChannel: "01"},
+		},
+	}
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), NewSimulatedSDK(cfg))
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	if err := d.WatchMeters("amp"); err == nil {
+		t.Fatal("meters watched without ConMon")
+	}
+	if err := d.StartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.Meters("amp"); ok {
+		t.Fatal("meters before subscribing")
+	}
+	for _, name := range []string{"amp", "legacy"} {
+		if err := d.WatchMeters(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := d.Meters("legacy"); ok {
+		t.Error("meters from a device without metering")
+	}
+
+	meters, ok := d.Meters("amp")
+	if !ok {
+		t.Fatal("no meters")
+	}
+	if len(meters.TX) != 0 || len(meters.RX) != 4 || meters.Updated.IsZero() {
+		t.Fatalf("meters = %+v", meters)
+	}
+	// 只有連到有訊號的發送通道才有電平
+	if rx := meters.RX; !rx[0].Signal || rx[0].PeakDB != -12 || rx[1].Signal || !rx[1].Muted ||
+		rx[2].Signal || rx[3].Signal {
+		t.Errorf("rx levels = %+v", rx)
+	}
+}
@@ -0,0 +1,177 @@
+package dante
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"danteCS/internal/trace"
+)
+
+//==============================================================================
+// 遠端設備連線
+//==============================================================================
+
+// Domain 以設備名稱操作設備時，C wrapper 每次都重新開啟連線、等待名稱解析
+// 並查詢能力，連續讀取或設定同一台設備 (snapshot 還原、逐通道改標籤) 時
+// 大部分時間花在這裡。RemoteDevice.Open 讓 SDK 保持這台設備的連線，之後
+// 不論經由 RemoteDevice 或 Domain 以名稱操作這台設備都重複使用；Close 後
+// 回到每次開啟。同一台設備可以被多個 RemoteDevice 開啟，全部關閉後才
+// 真正斷線。網域 Cleanup 時 SDK 會關閉所有連線。
+
+// RemoteDevice 網路上的一台設備 (Domain.RemoteDevice 或 Domain.OpenDevice 取得)
+type RemoteDevice struct {
+	d *Domain
+
+	mu   sync.Mutex
+	name string
+	open bool
+}
+
+// RemoteDevice 取得設備的操作介面 (尚未保持連線)
+func (d *Domain) RemoteDevice(name string) *RemoteDevice {
+	return &RemoteDevice{d: d, name: name}
+}
+
+// OpenDevice 取得設備的操作介面並保持連線 (用完要 Close)
+func (d *Domain) OpenDevice(ctx context.Context, name string) (*RemoteDevice, error) {
+	r := d.RemoteDevice(name)
+	if err := r.Open(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Name 設備名稱 (Rename 成功後為新名稱)
+func (r *RemoteDevice) Name() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.name
+}
+
+// IsOpen 是否保持連線中
+func (r *RemoteDevice) IsOpen() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.open && r.d.Initialized()
+}
+
+// Open 開啟並保持設備的連線 (已開啟時不做任何事)
+// 設備沒有回應時依 Domain.CallRetry 重試
+func (r *RemoteDevice) Open(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.open && r.d.Initialized() {
+		return nil
+	}
+	if !r.d.Initialized() {
+		return r.d.errNotInitialized()
+	}
+
+	_, span := trace.Start(ctx, "dante.device_open",
+		slog.String("dante.domain", r.d.Name), slog.String("dante.device", r.name))
+	defer span.End()
+
+	name := r.name
+	if result, errorMsg := r.d.retryOp(ctx, "dante_device_open", func(s SDK) int { return s.OpenDevice(name) }); result != 0 {
+		err := newSDKError("dante_device_open", errorMsg)
+		span.RecordError(err)
+		return err
+	}
+	r.open = true
+	r.d.log.Debug("Device connection opened", "device", name)
+	return nil
+}
+
+// Close 關閉 Open 保持的連線 (沒有開啟或網域已清理時不做任何事)
+func (r *RemoteDevice) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.open {
+		return nil
+	}
+	r.open = false
+	if !r.d.Initialized() {
+		return nil // Cleanup 時已經關閉
+	}
+	name := r.name
+	if result, errorMsg := r.d.sdkOp(func(s SDK) int { return s.CloseDevice(name) }); result != 0 {
+		return newSDKError("dante_device_close", errorMsg)
+	}
+	r.d.log.Debug("Device connection closed", "device", name)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+// 查詢
+//------------------------------------------------------------------------------
+
+// Subscriptions 所有接收通道的訂閱
+func (r *RemoteDevice) Subscriptions(ctx context.Context) ([]Subscription, error) {
+	return r.d.ListSubscriptions(ctx, r.Name())
+}
+
+// TxChannels 發送通道
+func (r *RemoteDevice) TxChannels(ctx context.Context) ([]Channel, error) {
+	return r.d.TxChannels(ctx, r.Name())
+}
+
+// TxFlows 發送 flow
+func (r *RemoteDevice) TxFlows(ctx context.Context) ([]Flow, error) {
+	return r.d.TxFlows(ctx, r.Name())
+}
+
+// Settings 取樣率與接收延遲
+func (r *RemoteDevice) Settings(ctx context.Context) (DeviceSettings, error) {
+	return r.d.DeviceSettings(ctx, r.Name())
+}
+
+// Enrollment DDM 註冊狀態 (同時更新網域記住的狀態)
+func (r *RemoteDevice) Enrollment(ctx context.Context) (Enrollment, error) {
+	return r.d.CheckEnrollment(ctx, r.Name())
+}
+
+//------------------------------------------------------------------------------
+// 設定
+//------------------------------------------------------------------------------
+
+// Subscribe 設定接收通道的訂閱 (txDevice 空白為取消訂閱)
+func (r *RemoteDevice) Subscribe(ctx context.Context, rxChannel, txDevice, txChannel string) error {
+	return r.d.Subscribe(ctx, r.Name(), rxChannel, txDevice, txChannel)
+}
+
+// Rename 重新命名設備，保持中的連線與之後的操作改用新名稱
+func (r *RemoteDevice) Rename(ctx context.Context, newName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.d.RenameDevice(ctx, r.name, newName); err != nil {
+		return err
+	}
+	r.name = newName
+	return nil
+}
+
+// SetTxChannelName 設定發送通道的標籤 (name 空白恢復預設名稱)
+func (r *RemoteDevice) SetTxChannelName(ctx context.Context, channelID int, name string) error {
+	return r.d.SetTxChannelName(ctx, r.Name(), channelID, name)
+}
+
+// SetRxChannelName 設定接收通道的標籤 (name 空白恢復預設名稱)
+func (r *RemoteDevice) SetRxChannelName(ctx context.Context, channelID int, name string) error {
+	return r.d.SetRxChannelName(ctx, r.Name(), channelID, name)
+}
+
+// SetLatency 設定接收延遲 (微秒)
+func (r *RemoteDevice) SetLatency(ctx context.Context, latencyUs int) error {
+	return r.d.SetLatency(ctx, r.Name(), latencyUs)
+}
+
+// SetSampleRate 設定取樣率 (需要先 Domain.StartMonitoring)
+func (r *RemoteDevice) SetSampleRate(ctx context.Context, sampleRate int) error {
+	return r.d.SetSampleRate(ctx, r.Name(), sampleRate)
+}
+
+// Identify 讓設備閃燈
+func (r *RemoteDevice) Identify() error {
+	return r.d.Identify(r.Name())
+}
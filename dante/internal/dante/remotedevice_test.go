@@ -0,0 +1,92 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRemoteDeviceOpenClose(t *testing.T) {
+	ctx := context.Background()
+	sim := NewSimulatedSDK(DefaultSimulationConfig())
+	d := NewSimulatedDomain("Dante1", NetworkConfig{InterfaceName: "sim0"}, sim)
+	if _, err := d.OpenDevice(ctx, "Amp-Left"); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("open before Initialize: %v", err)
+	}
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	amp, err := d.OpenDevice(ctx, "Amp-Left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := d.OpenDevice(ctx, "Amp-Left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.open["Amp-Left"] != 2 || !amp.IsOpen() {
+		t.Fatalf("open connections = %v", sim.open)
+	}
+	if err := other.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sim.open["Amp-Left"] != 1 {
+		t.Fatalf("after closing one of two: %v", sim.open)
+	}
+
+	if err := amp.Subscribe(ctx, "01", "Stage-Box-A", "01"); err != nil {
+		t.Fatal(err)
+	}
+	subs, err := amp.Subscriptions(ctx)
+	if err != nil || subs[0].TxDevice != "Stage-Box-A" {
+		t.Fatalf("subscriptions = %+v, %v", subs, err)
+	}
+
+	// 改名後保持中的連線跟著改名
+	if err := amp.Rename(ctx, "Amp-L"); err != nil {
+		t.Fatal(err)
+	}
+	if amp.Name() != "Amp-L" || sim.open["Amp-L"] != 1 {
+		t.Fatalf("after rename: name %s, open %v", amp.Name(), sim.open)
+	}
+	if _, err := amp.Settings(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := amp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := amp.Close(); err != nil {
+		t.Fatalf("second close: %v", err)
+	}
+	if len(sim.open) != 0 || amp.IsOpen() {
+		t.Fatalf("connections left open: %v", sim.open)
+	}
+}
+
+func TestRemoteDeviceOpenUnknown(t *testing.T) {
+	ctx := context.Background()
+	d := NewSimulatedDomain("Dante1", NetworkConfig{InterfaceName: "sim0"}, NewSimulatedSDK(DefaultSimulationConfig()))
+	d.CallRetry.MaxAttempts = 1
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_, err := d.OpenDevice(ctx, "nope")
+	if !errors.Is(err, ErrDiscoveryTimeout) {
+		t.Fatalf("open unknown device: %v", err)
+	}
+
+	amp, err := d.OpenDevice(ctx, "Amp-Right")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Cleanup()
+	if amp.IsOpen() {
+		t.Error("connection open after Cleanup")
+	}
+	if err := amp.Close(); err != nil {
+		t.Errorf("close after Cleanup: %v", err)
+	}
+}
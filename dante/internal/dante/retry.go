@@ -0,0 +1,62 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"danteCS/internal/backoff"
+)
+
+//==============================================================================
+// 暫時性 SDK 失敗的重試
+//==============================================================================
+
+// 掃描開始、刷新與讀取設備資訊 (設定、通道、flow、訂閱、註冊狀態) 在設備
+// 剛上線或網路短暫中斷時常常失敗一兩次，依 Domain.CallRetry 重試；參數錯誤、
+// 未授權等重試也不會成功的錯誤立即回傳。變更設定的操作 (訂閱、改名、建立
+// flow) 不自動重試，避免逾時但實際已生效的變更被重複執行。
+
+// DefaultCallRetry SDK 呼叫的預設重試 (最多 3 次，250ms 起每次加倍，±20%)
+func DefaultCallRetry() backoff.Policy {
+	return backoff.Policy{Initial: 250 * time.Millisecond, Max: 2 * time.Second, MaxAttempts: 3, Jitter: 0.2}
+}
+
+// IsTransient 重試是否可能成功：逾時、連線中斷、網卡暫時沒有地址，以及無法
+// 歸類的 SDK 錯誤；參數錯誤、未授權、唯讀、不支援、超過上限與找不到設備
+// (名稱打錯；剛上線還在解析的設備會回報逾時) 不會因為重試而成功
+func IsTransient(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrDiscoveryTimeout), errors.Is(err, ErrNotConnected), errors.Is(err, ErrInterfaceDown):
+		return true
+	case errors.Is(err, ErrSDKLicense), errors.Is(err, ErrInvalidArgument), errors.Is(err, ErrUnsupported),
+		errors.Is(err, ErrAccessDenied), errors.Is(err, ErrCapacity), errors.Is(err, ErrDeviceNotFound),
+		errors.Is(err, ErrChannelNotFound), errors.Is(err, ErrNotInitialized), errors.Is(err, ErrReadOnly):
+		return false
+	}
+	var sdkErr *SDKError
+	return errors.As(err, &sdkErr)
+}
+
+// retryOp 與 sdkOp 相同，但暫時性的失敗依 d.CallRetry 重試 (ctx 結束時停止)
+// function 是錯誤歸類與日誌使用的 SDK 函式名稱；回傳最後一次的結果與錯誤訊息
+func (d *Domain) retryOp(ctx context.Context, function string, op func(SDK) int) (int, string) {
+	var result int
+	var msg string
+	onRetry := func(attempt int, err error, delay time.Duration) {
+		d.log.Warn("SDK call failed, retrying", "call", function, "attempt", attempt, "err", err, "retry_in", delay)
+	}
+	backoff.Retry(ctx, d.CallRetry, onRetry, func(int) error {
+		if result, msg = d.sdkOp(op); result >= 0 {
+			return nil
+		}
+		err := newSDKError(function, msg)
+		if !IsTransient(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	})
+	return result, msg
+}
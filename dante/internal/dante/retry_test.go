@@ -0,0 +1,138 @@
+package dante
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"danteCS/internal/backoff"
+)
+
+// flakySDK 前 failures 次刷新回傳 message
+type flakySDK struct {
+	*SimulatedSDK
+	failures int
+	message  string
+	calls    int
+	failed   bool
+}
+
+func (s *flakySDK) RefreshDeviceScan() int {
+	s.calls++
+	if s.failed = s.calls <= s.failures; s.failed {
+		return -1
+	}
+	return s.SimulatedSDK.RefreshDeviceScan()
+}
+
+func (s *flakySDK) GetLastError() string {
+	if s.failed {
+		return s.message
+	}
+	return s.SimulatedSDK.GetLastError()
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{newSDKError("dante_refresh_device_scan", "Operation timed out"), true},
+		{newSDKError("dante_refresh_device_scan", "socket closed"), true},
+		{newSDKError("dante_get_device_settings", "Failed to open device 'amp-1': 42"), true},
+		{classified(ErrInterfaceDown, "interface eth1 is down"), true},
+		{newSDKError("dante_init_with_interface", "Dante API license invalid"), false},
+		{newSDKError("dante_get_device_settings", "Device nope not found"), false},
+		{newSDKError("dante_set_latency", "Failed to set latency: 3"), false},
+		{context.Canceled, false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshRetriesTransientFailure(t *testing.T) {
+	sdk := &flakySDK{SimulatedSDK: newSimulatedSDK(), failures: 2, message: "socket closed"}
+	d := NewSimulatedDomain("Dante1", NetworkConfig{InterfaceName: "sim0"}, sdk.SimulatedSDK)
+	d.sdk = sdk
+	d.CallRetry = backoff.Policy{Initial: time.Millisecond, MaxAttempts: 3}
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	d.RefreshDevices(context.Background())
+	if sdk.calls != 3 {
+		t.Errorf("calls = %d, want 3", sdk.calls)
+	}
+	if h := d.Health().Refresh; h.Failures != 0 || h.LastSuccess.IsZero() {
+		t.Errorf("health after retried refresh: %+v", h)
+	}
+}
+
+func TestRetryStopsOnFatalError(t *testing.T) {
+	sdk := &flakySDK{SimulatedSDK: newSimulatedSDK(), failures: 5, message: "Dante API license invalid"}
+	d := NewSimulatedDomain("Dante1", NetworkConfig{InterfaceName: "sim0"}, sdk.SimulatedSDK)
+	d.sdk = sdk
+	d.CallRetry = backoff.Policy{Initial: time.Millisecond, MaxAttempts: 3}
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	d.RefreshDevices(context.Background())
+	if sdk.calls != 1 {
+		t.Errorf("calls = %d, want 1 (license errors are not retried)", sdk.calls)
+	}
+	if h := d.Health().Refresh; h.Failures != 1 || h.LastError != "Dante API license invalid" {
+		t.Errorf("health after fatal refresh: %+v", h)
+	}
+}
+
+// countFailSDK 設定 fail 後設備數量查詢失敗
+type countFailSDK struct {
+	*SimulatedSDK
+	fail bool
+}
+
+func (s *countFailSDK) GetDiscoveredDeviceCount() int {
+	if s.fail {
+		return -1
+	}
+	return s.SimulatedSDK.GetDiscoveredDeviceCount()
+}
+
+func TestRefreshKeepsCountWhenCountFails(t *testing.T) {
+	sdk := &countFailSDK{SimulatedSDK: NewSimulatedSDK(DefaultSimulationConfig())}
+	d := NewSimulatedDomain("Dante1", NetworkConfig{InterfaceName: "sim0"}, sdk.SimulatedSDK)
+	d.sdk = sdk
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RefreshDevices(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := len(d.GetDevices())
+	if want == 0 {
+		t.Fatal("no simulated devices")
+	}
+
+	sdk.fail = true
+	if err := d.RefreshDevices(ctx); err == nil {
+		t.Error("failed device count not reported")
+	}
+	if got := len(d.GetDevices()); got != want {
+		t.Errorf("devices after failed count = %d, want %d", got, want)
+	}
+	if h := d.Health().Refresh; h.Failures != 1 {
+		t.Errorf("health after failed count: %+v", h)
+	}
+}
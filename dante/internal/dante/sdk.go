@@ -0,0 +1,254 @@
+package dante
+
+import (
+	"runtime"
+	"sync"
+)
+
+//==============================================================================
+// Dante SDK 介面
+//==============================================================================
+
+// Domain 透過 SDK 操作 SDK：正式執行時是 dante_* 綁定 (cgo，
+// 或 nodante 建置的模擬)，-simulate 時是 SimulatedSDK。
+// 方法對應 dante_* C 函數，回傳值維持 C 的慣例 (0 成功，負數失敗)。
+
+// SDK Domain 使用的 SDK 操作
+type SDK interface {
+	InitWithInterface(interfaceName string) int
+	Cleanup()
+	GetLastError() string
+	StartDeviceScan() int
+	StopDeviceScan() int
+	ProcessEventsBriefly() int
+	RefreshDeviceScan() int
+	GetDiscoveredDeviceCount() int
+	ChangeCount() int // SDK 通知設備列表變更的次數 (事件驅動刷新)
+	GetDeviceInfo(index int) (Device, int)
+	GetDeviceList(maxCount int) ([]Device, int) // 一次讀取 (大型網路減少 cgo 呼叫)
+	RouteList(rxDevice string, maxCount int) ([]Subscription, int)
+	RouteSubscribe(rxDevice, rxChannel, txDevice, txChannel string) int
+	MonitorStart() int
+	MonitorWatchDevice(device string) int
+	GetClockInfo(device string) (ClockInfo, int)
+	IdentifyDevice(device string) int
+	StartFirmwareUpgrade(device string, src FirmwareSource) int // 進度由 GetUpgradeStatus 查詢
+	GetUpgradeStatus(device string) (UpgradeStatus, int)
+	MonitorWatchMeters(device string) int // 訂閱 metering channel
+	GetMeters(device string) (Meters, int)
+	TxChannelList(device string, maxCount int) ([]Channel, int)
+	GetDeviceSettings(device string) (DeviceSettings, int)
+	RenameDevice(device, newName string) int
+	SetChannelName(device string, tx bool, channelID int, name string) int // name 空白表示恢復預設名稱
+	SetRxLatency(device string, latencyUs int) int
+	SetSampleRate(device string, sampleRate int) int
+	GetDeviceEnrollment(device string) (Enrollment, int)
+	TxFlowList(device string, maxCount int) ([]Flow, int)
+	RxFlowStats(device string, maxCount int) ([]RxFlowStats, int)    // 接收 flow 的錯誤計數
+	CreateMulticastFlow(device string, config FlowConfig) (int, int) // 回傳新 flow 的編號
+	DeleteTxFlow(device string, flowID int) int
+	OpenDevice(device string) int  // 保持設備的連線，之後以名稱的操作重複使用 (可重複開啟)
+	CloseDevice(device string) int // 關閉 OpenDevice 的連線 (關閉相同次數後才真正關閉)
+}
+
+//==============================================================================
+// C API 序列化
+//==============================================================================
+
+// dante_* 綁定背後是 libdapi 與 wrapper 的全域狀態 (DAPI 實例、設備瀏覽、
+// 最後的錯誤訊息)，不能同時從主程式、刷新 ticker 與 processEventsLoop 呼叫。
+// 所有 SDK 操作都交給同一個鎖定 OS thread 的 goroutine 依序執行；
+// 錯誤訊息是整個行程共用的，Domain.sdkOp 把操作與之後的 GetLastError
+// 放在同一個工作中，其他網域的呼叫無法在中間覆寫。
+
+// sdkThread 在專用 OS thread 上依序執行 dante_* 呼叫
+type sdkThread struct {
+	once  sync.Once
+	calls chan sdkCall
+}
+
+// sdkCall 交給 worker 的呼叫，done 回傳 fn 的 panic (沒有則為 nil)
+type sdkCall struct {
+	fn   func()
+	done chan any
+}
+
+// nativeThread 所有網域共用的 worker (C 狀態是全域的，所以只有一個)
+var nativeThread sdkThread
+
+// nativeOwner 目前初始化原生 SDK 的網域 (只在 nativeThread 上存取)。
+// dante_cleanup 會釋放整份 C 狀態，同一個行程只能有一個原生網域；
+// 多個網域由 instance supervise 放在各自的行程
+var nativeOwner *Domain
+
+// do 在 worker 上執行 fn 並等待完成；fn 的 panic 會在呼叫端重新拋出
+func (t *sdkThread) do(fn func()) {
+	t.start()
+	call := sdkCall{fn: fn, done: make(chan any, 1)}
+	t.calls <- call
+	if p := <-call.done; p != nil {
+		panic(p)
+	}
+}
+
+// tryDo 同 do，但 worker 忙碌中時不等待，回傳 false
+func (t *sdkThread) tryDo(fn func()) bool {
+	t.start()
+	call := sdkCall{fn: fn, done: make(chan any, 1)}
+	select {
+	case t.calls <- call:
+	default:
+		return false
+	}
+	if p := <-call.done; p != nil {
+		panic(p)
+	}
+	return true
+}
+
+func (t *sdkThread) start() {
+	t.once.Do(func() {
+		t.calls = make(chan sdkCall)
+		go t.run()
+	})
+}
+
+// run worker 迴圈：鎖定 OS thread，讓 SDK 的 thread-local 狀態保持一致
+func (t *sdkThread) run() {
+	runtime.LockOSThread()
+	for call := range t.calls {
+		call.done <- t.exec(call.fn)
+	}
+}
+
+// exec 執行單一呼叫並回收 panic，避免 worker 結束導致之後的呼叫永遠等待
+func (t *sdkThread) exec(fn func()) (p any) {
+	defer func() { p = recover() }()
+	fn()
+	return nil
+}
+
+// call 在 worker 上執行回傳 C 結果碼的呼叫
+func (t *sdkThread) call(fn func() int) int {
+	var result int
+	t.do(func() { result = fn() })
+	return result
+}
+
+// nativeSDK 直接呼叫 dante_* 綁定，只能在 nativeThread 上執行 (見 Domain.sdkOp)
+type nativeSDK struct{}
+
+func (nativeSDK) InitWithInterface(interfaceName string) int {
+	return danteInitWithInterface(interfaceName)
+}
+
+func (nativeSDK) Cleanup() { danteCleanup() }
+
+func (nativeSDK) GetLastError() string { return danteGetLastError() }
+
+func (nativeSDK) StartDeviceScan() int      { return danteStartDeviceScan() }
+func (nativeSDK) StopDeviceScan() int       { return danteStopDeviceScan() }
+func (nativeSDK) ProcessEventsBriefly() int { return danteProcessEventsBriefly() }
+func (nativeSDK) RefreshDeviceScan() int    { return danteRefreshDeviceScan() }
+
+func (nativeSDK) GetDiscoveredDeviceCount() int { return danteGetDiscoveredDeviceCount() }
+
+func (nativeSDK) ChangeCount() int { return danteGetChangeCount() }
+
+func (nativeSDK) GetDeviceInfo(index int) (Device, int) {
+	return danteGetDeviceInfo(index)
+}
+
+func (nativeSDK) GetDeviceList(maxCount int) ([]Device, int) {
+	return danteGetDeviceList(maxCount)
+}
+
+func (nativeSDK) RouteList(rxDevice string, maxCount int) ([]Subscription, int) {
+	return danteRouteList(rxDevice, maxCount)
+}
+
+func (nativeSDK) RouteSubscribe(rxDevice, rxChannel, txDevice, txChannel string) int {
+	return danteRouteSubscribe(rxDevice, rxChannel, txDevice, txChannel)
+}
+
+func (nativeSDK) MonitorStart() int { return danteMonitorStart() }
+
+func (nativeSDK) MonitorWatchDevice(device string) int {
+	return danteMonitorWatchDevice(device)
+}
+
+func (nativeSDK) GetClockInfo(device string) (ClockInfo, int) {
+	return danteGetClockInfo(device)
+}
+
+func (nativeSDK) IdentifyDevice(device string) int {
+	return danteIdentifyDevice(device)
+}
+
+func (nativeSDK) StartFirmwareUpgrade(device string, src FirmwareSource) int {
+	return danteFirmwareUpgrade(device, src)
+}
+
+func (nativeSDK) GetUpgradeStatus(device string) (UpgradeStatus, int) {
+	return danteGetUpgradeStatus(device)
+}
+
+func (nativeSDK) MonitorWatchMeters(device string) int {
+	return danteMonitorWatchMeters(device)
+}
+
+func (nativeSDK) GetMeters(device string) (Meters, int) {
+	return danteGetMeters(device)
+}
+
+func (nativeSDK) TxChannelList(device string, maxCount int) ([]Channel, int) {
+	return danteTxChannelList(device, maxCount)
+}
+
+func (nativeSDK) GetDeviceSettings(device string) (DeviceSettings, int) {
+	return danteGetDeviceSettings(device)
+}
+
+func (nativeSDK) RenameDevice(device, newName string) int {
+	return danteRenameDevice(device, newName)
+}
+
+func (nativeSDK) SetChannelName(device string, tx bool, channelID int, name string) int {
+	return danteSetChannelName(device, tx, channelID, name)
+}
+
+func (nativeSDK) SetRxLatency(device string, latencyUs int) int {
+	return danteSetRxLatency(device, latencyUs)
+}
+
+func (nativeSDK) SetSampleRate(device string, sampleRate int) int {
+	return danteSetSampleRate(device, sampleRate)
+}
+
+func (nativeSDK) GetDeviceEnrollment(device string) (Enrollment, int) {
+	return danteGetDeviceEnrollment(device)
+}
+
+func (nativeSDK) RxFlowStats(device string, maxCount int) ([]RxFlowStats, int) {
+	return danteRxFlowStats(device, maxCount)
+}
+
+func (nativeSDK) TxFlowList(device string, maxCount int) ([]Flow, int) {
+	return danteTxFlowList(device, maxCount)
+}
+
+func (nativeSDK) CreateMulticastFlow(device string, config FlowConfig) (int, int) {
+	return danteCreateMulticastFlow(device, config)
+}
+
+func (nativeSDK) DeleteTxFlow(device string, flowID int) int {
+	return danteDeleteTxFlow(device, flowID)
+}
+
+func (nativeSDK) OpenDevice(device string) int {
+	return danteDeviceOpen(device)
+}
+
+func (nativeSDK) CloseDevice(device string) int {
+	return danteDeviceClose(device)
+}
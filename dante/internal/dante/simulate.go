@@ -0,0 +1,1051 @@
+package dante
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// 模擬模式
+//==============================================================================
+
+// -simulate 以 SimulatedSDK 取代 Dante SDK，模擬設備經過與真實發現相同的
+// Domain 流程 (掃描、刷新、路由、時鐘、告警、API、Web UI)，
+// 不需要 Dante 網路就能展示或做整合測試。nodante 建置也以它模擬 dante_* 函數。
+
+// 模擬訂閱的狀態 (與 DANTE_RXSTATUS_* 相同)
+const (
+	simRxStatusNone       = 0x00
+	simRxStatusUnresolved = 0x01 // 發送設備或通道不存在
+	simRxStatusConnected  = 0x09 // connected (unicast)
+	simRxStatusMulticast  = 0x0A // connected (multicast)
+	simRxStatusFormat     = 0x10 // 取樣率不同 (channel format mismatch)
+)
+
+// 模擬設備的預設設定
+const (
+	simDefaultSampleRate = 48000
+	simDefaultLatencyUs  = 1000
+)
+
+// 模擬設備的發送 flow 限制與 multicast 預設值
+const (
+	simMaxTxFlows       = 32
+	simMaxFlowSlots     = 8
+	simMulticastPort    = 4321 // Dante 音訊 multicast 的埠
+	simDefaultFlowFrame = 1000 // 預設 packet time (微秒)
+	simMaxOpenDevices   = 32   // 與 C wrapper 的 MAX_OPEN_DEVICES 相同
+)
+
+// simFirmwareSize 模擬韌體檔的大小 (每次查詢升級狀態下載一半)
+const simFirmwareSize = 4 << 20
+
+// simEnrolledReason 已註冊設備無法設定的原因 (與 C wrapper 相同)
+const simEnrolledReason = "enrolled in a Dante domain, configure it from Dante Domain Manager"
+
+// SimulatedDevice 模擬設備設定
+type SimulatedDevice struct {
+	Name           string `json:"name"`
+	Model          string `json:"model"`
+	ProductVersion string `json:"product_version,omitempty"`
+	DanteVersion   string `json:"dante_version,omitempty"`
+	IPAddress      string `json:"ip_address"`
+	MacAddress     string `json:"mac_address,omitempty"`
+	LinkSpeed      int    `json:"link_speed,omitempty"`      // 預設 1000
+	SecondaryIP    string `json:"secondary_ip,omitempty"`    // 空白表示沒有次要網路
+	SecondarySpeed int    `json:"secondary_speed,omitempty"` // 次要連線速度 (0 表示中斷)
+	TxChannels     int    `json:"tx_channels"`               // 發送通道數 (名稱 01、02、...)
+	RxChannels     int    `json:"rx_channels"`               // 接收通道數 (名稱 01、02、...)
+	ClockState     string `json:"clock_state,omitempty"`     // 預設 locked
+	Grandmaster    bool   `json:"grandmaster,omitempty"`
+	SampleRate     int    `json:"sample_rate,omitempty"`   // 預設 48000
+	LatencyUs      int    `json:"latency_us,omitempty"`    // 接收延遲，預設 1000
+	DDMDomain      string `json:"ddm_domain,omitempty"`    // 已註冊的 DDM 網域 (設備拒絕這個控制器的設定)
+	Manufacturer   string `json:"manufacturer,omitempty"`  // 預設 Audinate
+	SerialNumber   string `json:"serial_number,omitempty"` // 預設依編號產生
+	AES67          bool   `json:"aes67,omitempty"`         // 支援 AES67 模式
+	Lockable       bool   `json:"lockable,omitempty"`      // 可以鎖定
+	Metering       bool   `json:"metering,omitempty"`      // 提供通道電平
+
+	// 沒有音訊的發送通道 (其餘通道有 -12 ~ -33 dBFS 的訊號)
+	SilentChannels []string `json:"silent_channels,omitempty"`
+
+	// 自訂通道名稱 (設定時取代通道數與預設名稱，capture fixture 產生)
+	TxChannelNames []string `json:"tx_channel_names,omitempty"`
+	RxChannelNames []string `json:"rx_channel_names,omitempty"`
+}
+
+// SimulatedRoute 模擬網路啟動時已存在的訂閱
+type SimulatedRoute struct {
+	RxDevice  string `json:"rx_device"`
+	RxChannel string `json:"rx_channel"`
+	TxDevice  string `json:"tx_device"`
+	TxChannel string `json:"tx_channel"`
+}
+
+// SimulationConfig 模擬設定檔 (-simulate-config)
+type SimulationConfig struct {
+	Interface string            `json:"interface,omitempty"`  // 顯示用的介面名稱
+	IPAddress string            `json:"ip_address,omitempty"` // 顯示用的介面地址
+	Devices   []SimulatedDevice `json:"devices"`
+	Routes    []SimulatedRoute  `json:"routes,omitempty"`
+}
+
+// DefaultSimulationConfig 展示用的預設設備 (包含 Auto-IP 與次要網路中斷的設備)
+func DefaultSimulationConfig() *SimulationConfig {
+	return &SimulationConfig{
+		Interface: "sim0",
+		IPAddress: "192.168.100.1",
+		Devices: []SimulatedDevice{
+			{Name: "FOH-Console", Model: "DL32", IPAddress: "192.168.100.10", SecondaryIP: "192.168.200.10", SecondarySpeed: 1000, TxChannels: 32, RxChannels: 32, Grandmaster: true, Metering: true},
+			{Name: "Stage-Box-A", Model: "Ultimo X4", IPAddress: "192.168.100.20", TxChannels: 4, RxChannels: 4, Metering: true, SilentChannels: []string{"04"}},
+			{Name: "Amp-Left", Model: "PA-4D", IPAddress: "192.168.100.31", SecondaryIP: "192.168.200.31", TxChannels: 0, RxChannels: 4, Metering: true},
+			{Name: "Amp-Right", Model: "PA-4D", IPAddress: "169.254.12.7", LinkSpeed: 100, TxChannels: 0, RxChannels: 4},
+		},
+	}
+}
+
+// LoadSimulationConfig 載入模擬設定檔 (path 空白時使用預設設備)
+func LoadSimulationConfig(path string) (*SimulationConfig, error) {
+	if path == "" {
+		return DefaultSimulationConfig(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read simulation config: %v", err)
+	}
+	var cfg SimulationConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse simulation config %s: %v", path, err)
+	}
+
+	defaults := DefaultSimulationConfig()
+	if cfg.Interface == "" {
+		cfg.Interface = defaults.Interface
+	}
+	if cfg.IPAddress == "" {
+		cfg.IPAddress = defaults.IPAddress
+	}
+	rxChannels := make(map[string][]string)
+	for _, dev := range cfg.Devices {
+		if dev.Name == "" {
+			return nil, fmt.Errorf("simulation config %s: device without name", path)
+		}
+		if _, dup := rxChannels[dev.Name]; dup {
+			return nil, fmt.Errorf("simulation config %s: duplicate device %s", path, dev.Name)
+		}
+		rxChannels[dev.Name] = dev.rxChannelNames()
+	}
+	// 發送端可以不在設備列表中 (離線設備的訂閱是 unresolved)，接收通道必須存在
+	for i, r := range cfg.Routes {
+		if !slices.Contains(rxChannels[r.RxDevice], r.RxChannel) {
+			return nil, fmt.Errorf("simulation config %s: route %d: RX channel %s not found on %s", path, i+1, r.RxChannel, r.RxDevice)
+		}
+		if r.TxDevice == "" || r.TxChannel == "" {
+			return nil, fmt.Errorf("simulation config %s: route %d: tx_device and tx_channel are required", path, i+1)
+		}
+	}
+	return &cfg, nil
+}
+
+// NetworkConfig 模擬網域的網路配置
+func (c *SimulationConfig) NetworkConfig() NetworkConfig {
+	return NetworkConfig{InterfaceName: c.Interface, IPAddress: c.IPAddress, NetworkType: "dante1", Enabled: true}
+}
+
+// txChannelNames 發送通道名稱
+func (d SimulatedDevice) txChannelNames() []string {
+	if len(d.TxChannelNames) > 0 {
+		return d.TxChannelNames
+	}
+	return simChannelNames(d.TxChannels)
+}
+
+// rxChannelNames 接收通道名稱
+func (d SimulatedDevice) rxChannelNames() []string {
+	if len(d.RxChannelNames) > 0 {
+		return d.RxChannelNames
+	}
+	return simChannelNames(d.RxChannels)
+}
+
+// simChannelNames Dante 預設的通道名稱 01、02、...
+func simChannelNames(count int) []string {
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("%02d", i+1)
+	}
+	return names
+}
+
+//----------------------------------------------------------------------
+// 模擬 SDK
+//----------------------------------------------------------------------
+
+// SimulatedSDK 在記憶體中模擬 SDK 狀態 (實作 SDK)
+type SimulatedSDK struct {
+	mu sync.Mutex
+
+	// 模擬內容 (測試可直接設定)
+	Devices       []Device                           // 掃描後「發現」的設備
+	TxChannels    map[string][]string                // 依發送設備名稱的通道
+	Subscriptions map[string][]Subscription          // 依接收設備名稱的通道
+	Clocks        map[string]ClockInfo               // 依設備名稱的時鐘狀態
+	TxLevels      map[string][]float64               // 依發送設備名稱的通道電平 (dBFS，依通道順序，低於 MinPeakDB 為靜音)
+	Settings      map[string]DeviceSettings          // 依設備名稱的取樣率與延遲
+	Enrollments   map[string]Enrollment              // 依設備名稱的 DDM 註冊狀態 (沒有表示未註冊)
+	Flows         map[string][]Flow                  // 依設備名稱手動建立的發送 flow
+	FlowErrors    map[string]map[string]FlowCounters // 接收設備 → 發送設備 → 接收 flow 的錯誤計數 (主要介面)
+	InitError     string                             // 非空白時初始化失敗
+	RefreshError  string                             // 非空白時刷新掃描失敗 (watchdog 測試)
+
+	// SDK 內部狀態
+	initialized bool
+	iface       string
+	scanning    bool
+	monitoring  bool
+	discovered  []Device
+	notified    []Device // 最後一次變更通知時的 Devices
+	changes     int      // 變更通知的次數
+	watched     map[string]bool
+	open        map[string]int // OpenDevice 保持的連線 (設備名稱 → 開啟次數)
+	identified  []string
+	upgrades    map[string]*simUpgrade // 設備名稱 → 韌體升級
+	metered     map[string]bool        // 訂閱 metering channel 的設備
+	lastError   string
+}
+
+// newSimulatedSDK 建立空白的模擬 SDK
+func newSimulatedSDK() *SimulatedSDK {
+	return &SimulatedSDK{
+		TxChannels:    map[string][]string{},
+		Subscriptions: map[string][]Subscription{},
+		Clocks:        map[string]ClockInfo{},
+		TxLevels:      map[string][]float64{},
+		Settings:      map[string]DeviceSettings{},
+		Enrollments:   map[string]Enrollment{},
+		Flows:         map[string][]Flow{},
+		FlowErrors:    map[string]map[string]FlowCounters{},
+		watched:       map[string]bool{},
+		open:          map[string]int{},
+		upgrades:      map[string]*simUpgrade{},
+		metered:       map[string]bool{},
+	}
+}
+
+// NewSimulatedSDK 依模擬設定建立 SDK
+func NewSimulatedSDK(cfg *SimulationConfig) *SimulatedSDK {
+	s := newSimulatedSDK()
+	for i, d := range cfg.Devices {
+		dev := Device{
+			ID:             i + 1,
+			Name:           d.Name,
+			Model:          d.Model,
+			ProductVersion: d.ProductVersion,
+			DanteVersion:   d.DanteVersion,
+			IPAddress:      d.IPAddress,
+			LinkSpeed:      d.LinkSpeed,
+			SecondaryIP:    d.SecondaryIP,
+			SecondarySpeed: d.SecondarySpeed,
+			MacAddress:     d.MacAddress,
+			TxChannels:     len(d.txChannelNames()),
+			RxChannels:     len(d.rxChannelNames()),
+			Manufacturer:   d.Manufacturer,
+			SerialNumber:   d.SerialNumber,
+			Capabilities:   DeviceCapabilities{AES67: d.AES67, Redundancy: d.SecondaryIP != "", Lockable: d.Lockable, Metering: d.Metering},
+		}
+		if dev.LinkSpeed == 0 {
+			dev.LinkSpeed = 1000
+		}
+		if dev.DanteVersion == "" {
+			dev.DanteVersion = "4.2.0"
+		}
+		if dev.MacAddress == "" {
+			dev.MacAddress = fmt.Sprintf("00:1d:c1:00:%02x:%02x", (i+1)>>8, (i+1)&0xff)
+		}
+		if dev.Manufacturer == "" {
+			dev.Manufacturer = "Audinate"
+		}
+		if dev.SerialNumber == "" {
+			dev.SerialNumber = fmt.Sprintf("001dc1fffe%06x", i+1)
+		}
+		s.Devices = append(s.Devices, dev)
+
+		s.TxChannels[d.Name] = slices.Clone(d.txChannelNames())
+		levels := make([]float64, len(d.txChannelNames()))
+		for n, name := range d.txChannelNames() {
+			levels[n] = -12 - 3*float64(n%8)
+			if slices.Contains(d.SilentChannels, name) {
+				levels[n] = math.Inf(-1)
+			}
+		}
+		s.TxLevels[d.Name] = levels
+		var rx []Subscription
+		for n, name := range d.rxChannelNames() {
+			rx = append(rx, Subscription{ChannelID: n + 1, Channel: name})
+		}
+		s.Subscriptions[d.Name] = rx
+
+		clock := ClockInfo{ClockState: d.ClockState, ServoState: "locked", ClockSource: "PTP", IsGrandmaster: d.Grandmaster}
+		if clock.ClockState == "" {
+			clock.ClockState = "locked"
+		}
+		s.Clocks[d.Name] = clock
+
+		settings := DeviceSettings{SampleRate: d.SampleRate, LatencyUs: d.LatencyUs}
+		if settings.SampleRate == 0 {
+			settings.SampleRate = simDefaultSampleRate
+		}
+		if settings.LatencyUs == 0 {
+			settings.LatencyUs = simDefaultLatencyUs
+		}
+		s.Settings[d.Name] = settings
+
+		if d.DDMDomain != "" {
+			s.Enrollments[d.Name] = Enrollment{Managed: true, Domain: d.DDMDomain, ReadOnly: simEnrolledReason}
+		}
+	}
+
+	// 訂閱在所有設備建立後才套用，狀態依發送通道是否存在
+	for _, r := range cfg.Routes {
+		s.route(r.RxDevice, r.RxChannel, r.TxDevice, r.TxChannel)
+	}
+	return s
+}
+
+// fail 記錄錯誤訊息並回傳 -1 (與 C wrapper 相同)
+func (s *SimulatedSDK) fail(format string, args ...any) int {
+	s.lastError = fmt.Sprintf(format, args...)
+	return -1
+}
+
+func (s *SimulatedSDK) InitWithInterface(interfaceName string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.InitError != "" {
+		return s.fail("%s", s.InitError)
+	}
+	s.initialized = true
+	s.iface = interfaceName
+	return 0
+}
+
+func (s *SimulatedSDK) Cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.initialized = false
+	s.scanning = false
+	s.monitoring = false
+	s.discovered = nil
+	s.watched = map[string]bool{}
+	s.metered = map[string]bool{}
+	s.open = map[string]int{}
+}
+
+func (s *SimulatedSDK) GetLastError() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastError
+}
+
+func (s *SimulatedSDK) StartDeviceScan() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return s.fail("Dante API not initialized")
+	}
+	s.scanning = true
+	return 0
+}
+
+func (s *SimulatedSDK) StopDeviceScan() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scanning = false
+	return 0
+}
+
+// ProcessEventsBriefly 掃描中 Devices 被修改時 (設備加入或離開) 產生變更通知，
+// 列表本身和原生 SDK 一樣等到 RefreshDeviceScan 才讀取
+func (s *SimulatedSDK) ProcessEventsBriefly() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scanning && !slices.Equal(s.Devices, s.notified) {
+		s.notified = append([]Device{}, s.Devices...)
+		s.changes++
+	}
+	return 0
+}
+
+// ChangeCount 變更通知的次數
+func (s *SimulatedSDK) ChangeCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changes
+}
+
+// RefreshDeviceScan 掃描中時把設定的 Devices 視為已發現
+func (s *SimulatedSDK) RefreshDeviceScan() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.RefreshError != "" {
+		return s.fail("%s", s.RefreshError)
+	}
+	if s.scanning {
+		s.discovered = append([]Device{}, s.Devices...)
+	}
+	return 0
+}
+
+func (s *SimulatedSDK) GetDiscoveredDeviceCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.discovered)
+}
+
+func (s *SimulatedSDK) GetDeviceInfo(index int) (Device, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index < 0 || index >= len(s.discovered) {
+		return Device{}, s.fail("Invalid device index: %d (available: 0-%d)", index, len(s.discovered)-1)
+	}
+	dev := s.discovered[index]
+	if dev.ID == 0 {
+		dev.ID = index + 1
+	}
+	return dev, 0
+}
+
+func (s *SimulatedSDK) GetDeviceList(maxCount int) ([]Device, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maxCount < 0 {
+		return nil, s.fail("Invalid device list buffer")
+	}
+	count := min(maxCount, len(s.discovered))
+	devices := make([]Device, 0, count)
+	for i, dev := range s.discovered[:count] {
+		if dev.ID == 0 {
+			dev.ID = i + 1
+		}
+		devices = append(devices, dev)
+	}
+	return devices, count
+}
+
+func (s *SimulatedSDK) RouteList(rxDevice string, maxCount int) ([]Subscription, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return nil, s.fail("Dante not initialized")
+	}
+	subs, ok := s.Subscriptions[rxDevice]
+	if !ok {
+		return nil, s.fail("Device %s not found", rxDevice)
+	}
+	if len(subs) > maxCount {
+		subs = subs[:maxCount]
+	}
+	return append([]Subscription{}, subs...), len(subs)
+}
+
+// RouteSubscribe 更新接收通道；發送設備或通道不存在時與真實網路一樣是 unresolved
+func (s *SimulatedSDK) RouteSubscribe(rxDevice, rxChannel, txDevice, txChannel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return s.fail("Dante not initialized")
+	}
+	if s.denied(rxDevice) {
+		return s.fail("Device '%s' denied access", rxDevice)
+	}
+	return s.route(rxDevice, rxChannel, txDevice, txChannel)
+}
+
+// route 更新接收通道 (呼叫者持有 mu)
+func (s *SimulatedSDK) route(rxDevice, rxChannel, txDevice, txChannel string) int {
+	subs, ok := s.Subscriptions[rxDevice]
+	if !ok {
+		return s.fail("Device %s not found", rxDevice)
+	}
+	for i := range subs {
+		if subs[i].Channel != rxChannel {
+			continue
+		}
+		subs[i].TxDevice = txDevice
+		subs[i].TxChannel = txChannel
+		subs[i].Status = s.routeStatus(rxDevice, txDevice, txChannel)
+		return 0
+	}
+	return s.fail("RX channel %s not found on %s", rxChannel, rxDevice)
+}
+
+// routeStatus 訂閱的狀態：發送設備或通道不存在時是 unresolved，
+// 兩台設備的取樣率不同時是 channel format mismatch，
+// 發送通道在 multicast flow 中時由該 flow 提供
+func (s *SimulatedSDK) routeStatus(rxDevice, txDevice, txChannel string) int {
+	switch {
+	case txDevice == "":
+		return simRxStatusNone
+	case !s.hasTxChannel(txDevice, txChannel):
+		return simRxStatusUnresolved
+	case s.Settings[rxDevice].SampleRate != s.Settings[txDevice].SampleRate:
+		return simRxStatusFormat
+	case s.inMulticastFlow(txDevice, txChannel):
+		return simRxStatusMulticast
+	default:
+		return simRxStatusConnected
+	}
+}
+
+// inMulticastFlow 發送通道是否在設備的 multicast flow 中
+func (s *SimulatedSDK) inMulticastFlow(device, channel string) bool {
+	id := slices.Index(s.TxChannels[device], channel) + 1
+	if id == 0 {
+		return false
+	}
+	return slices.ContainsFunc(s.Flows[device], func(f Flow) bool { return slices.Contains(f.Channels, id) })
+}
+
+// hasTxChannel 發送設備是否有該通道
+func (s *SimulatedSDK) hasTxChannel(device, channel string) bool {
+	for _, name := range s.TxChannels[device] {
+		if name == channel {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SimulatedSDK) MonitorStart() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return s.fail("Dante not initialized")
+	}
+	s.monitoring = true
+	return 0
+}
+
+func (s *SimulatedSDK) MonitorWatchDevice(device string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.monitoring {
+		return s.fail("ConMon monitoring not started")
+	}
+	s.watched[device] = true
+	return 0
+}
+
+// GetClockInfo 只回傳已訂閱 (MonitorWatchDevice) 設備的時鐘狀態
+func (s *SimulatedSDK) GetClockInfo(device string) (ClockInfo, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.Clocks[device]
+	if !ok || !s.watched[device] {
+		return ClockInfo{}, s.fail("No clock status for %s", device)
+	}
+	if info.Updated.IsZero() {
+		info.Updated = time.Now()
+	}
+	return info, 0
+}
+
+func (s *SimulatedSDK) IdentifyDevice(device string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.monitoring {
+		return s.fail("ConMon monitoring not started")
+	}
+	s.identified = append(s.identified, device)
+	return 0
+}
+
+// simUpgrade 模擬設備的韌體升級
+type simUpgrade struct {
+	status UpgradeStatus
+	path   string
+}
+
+// StartFirmwareUpgrade 與 C wrapper 一樣需要 ConMon，已註冊的設備拒絕升級
+func (s *SimulatedSDK) StartFirmwareUpgrade(device string, src FirmwareSource) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.monitoring {
+		return s.fail("ConMon not connected")
+	}
+	if !slices.ContainsFunc(s.Devices, func(d Device) bool { return d.Name == device }) {
+		return s.fail("Firmware upgrade failed: device '%s' not found", device)
+	}
+	if s.denied(device) {
+		return s.fail("Device '%s' denied access", device)
+	}
+	if u := s.upgrades[device]; u != nil && !u.status.Finished() {
+		return s.fail("Firmware upgrade denied: upgrade in progress on '%s'", device)
+	}
+	s.upgrades[device] = &simUpgrade{status: UpgradeStatus{State: UpgradeRequested, Updated: time.Now()}, path: src.Path}
+	return 0
+}
+
+// GetUpgradeStatus 每次查詢推進一步：下載 (兩次)、寫入、完成；
+// 不是 .dnt 的檔案在下載後失敗
+func (s *SimulatedSDK) GetUpgradeStatus(device string) (UpgradeStatus, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.upgrades[device]
+	if !ok {
+		return UpgradeStatus{}, s.fail("No upgrade status for '%s'", device)
+	}
+	st := &u.status
+	switch st.State {
+	case UpgradeRequested:
+		st.State, st.Total = UpgradeDownloading, simFirmwareSize
+	case UpgradeDownloading:
+		if st.Current += simFirmwareSize / 2; st.Current < st.Total {
+			break
+		}
+		if !strings.HasSuffix(u.path, ".dnt") {
+			st.State, st.Error = UpgradeFailed, "dnt file malformed"
+			break
+		}
+		st.State, st.Current = UpgradeWriting, 0
+	case UpgradeWriting:
+		st.State, st.Current = UpgradeDone, st.Total
+	}
+	st.Updated = time.Now()
+	return *st, 0
+}
+
+func (s *SimulatedSDK) MonitorWatchMeters(device string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.monitoring {
+		return s.fail("ConMon not connected")
+	}
+	s.metered[device] = true
+	return 0
+}
+
+// GetMeters 只有支援 metering 且已訂閱的設備有電平；
+// 接收通道的電平來自已連線的發送通道，沒有訂閱或未連線時靜音
+func (s *SimulatedSDK) GetMeters(device string) (Meters, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := slices.IndexFunc(s.Devices, func(d Device) bool { return d.Name == device })
+	if index < 0 || !s.Devices[index].Capabilities.Metering || !s.metered[device] {
+		return Meters{}, s.fail("No metering data for '%s'", device)
+	}
+
+	tx := make([]byte, len(s.TxLevels[device]))
+	for i, db := range s.TxLevels[device] {
+		tx[i] = meterPeak(db)
+	}
+	rx := make([]byte, len(s.Subscriptions[device]))
+	for i, sub := range s.Subscriptions[device] {
+		rx[i] = meterPeakMute
+		if sub.Status != simRxStatusConnected && sub.Status != simRxStatusMulticast {
+			continue
+		}
+		if n := slices.Index(s.TxChannels[sub.TxDevice], sub.TxChannel); n >= 0 && n < len(s.TxLevels[sub.TxDevice]) {
+			rx[i] = meterPeak(s.TxLevels[sub.TxDevice][n])
+		}
+	}
+	return Meters{TX: channelLevels(tx), RX: channelLevels(rx), Updated: time.Now()}, 0
+}
+
+func (s *SimulatedSDK) TxChannelList(device string, maxCount int) ([]Channel, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return nil, s.fail("Dante not initialized")
+	}
+	names, ok := s.TxChannels[device]
+	if !ok {
+		return nil, s.fail("Device %s not found", device)
+	}
+	count := min(maxCount, len(names))
+	channels := make([]Channel, 0, count)
+	for i, name := range names[:count] {
+		channels = append(channels, Channel{ID: i + 1, Name: name})
+	}
+	return channels, count
+}
+
+func (s *SimulatedSDK) GetDeviceSettings(device string) (DeviceSettings, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return DeviceSettings{}, s.fail("Dante not initialized")
+	}
+	settings, ok := s.Settings[device]
+	if !ok {
+		return DeviceSettings{}, s.fail("Device %s not found", device)
+	}
+	return settings, 0
+}
+
+// RenameDevice 與真實網路一樣，以舊名稱訂閱這台設備的通道會變成 unresolved
+func (s *SimulatedSDK) RenameDevice(device, newName string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return s.fail("Dante not initialized")
+	}
+	if newName == "" {
+		return s.fail("Invalid arguments")
+	}
+	if s.denied(device) {
+		return s.fail("Device '%s' denied access", device)
+	}
+	index := slices.IndexFunc(s.Devices, func(d Device) bool { return d.Name == device })
+	if index < 0 {
+		return s.fail("Device %s not found", device)
+	}
+	if newName != device && slices.ContainsFunc(s.Devices, func(d Device) bool { return d.Name == newName }) {
+		return s.fail("Device name %s already in use", newName)
+	}
+
+	s.Devices[index].Name = newName
+	renameKey(s.TxChannels, device, newName)
+	renameKey(s.Subscriptions, device, newName)
+	renameKey(s.Clocks, device, newName)
+	renameKey(s.Settings, device, newName)
+	renameKey(s.Enrollments, device, newName)
+	renameKey(s.Flows, device, newName)
+	renameKey(s.FlowErrors, device, newName)
+	renameKey(s.open, device, newName)
+	renameKey(s.upgrades, device, newName)
+	renameKey(s.TxLevels, device, newName)
+	renameKey(s.metered, device, newName)
+	s.resolveRoutes()
+	return 0
+}
+
+// SetChannelName 名稱已被同一設備的其他通道使用時，該通道恢復預設名稱 (與 SDK 相同)
+func (s *SimulatedSDK) SetChannelName(device string, tx bool, channelID int, name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return s.fail("Dante not initialized")
+	}
+	if s.denied(device) {
+		return s.fail("Device '%s' denied access", device)
+	}
+	defaultName := func(id int) string { return fmt.Sprintf("%02d", id) }
+	if name == "" {
+		name = defaultName(channelID)
+	}
+
+	if tx {
+		names, ok := s.TxChannels[device]
+		if !ok {
+			return s.fail("Device %s not found", device)
+		}
+		if channelID < 1 || channelID > len(names) {
+			return s.fail("TX channel %d not found on '%s'", channelID, device)
+		}
+		for i := range names {
+			if names[i] == name && i+1 != channelID {
+				names[i] = defaultName(i + 1)
+			}
+		}
+		names[channelID-1] = name
+		s.resolveRoutes()
+		return 0
+	}
+
+	subs, ok := s.Subscriptions[device]
+	if !ok {
+		return s.fail("Device %s not found", device)
+	}
+	index := slices.IndexFunc(subs, func(sub Subscription) bool { return sub.ChannelID == channelID })
+	if index < 0 {
+		return s.fail("RX channel %d not found on '%s'", channelID, device)
+	}
+	for i := range subs {
+		if subs[i].Channel == name && i != index {
+			subs[i].Channel = defaultName(subs[i].ChannelID)
+		}
+	}
+	subs[index].Channel = name
+	return 0
+}
+
+func (s *SimulatedSDK) SetRxLatency(device string, latencyUs int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return s.fail("Dante not initialized")
+	}
+	settings, ok := s.Settings[device]
+	if !ok {
+		return s.fail("Device %s not found", device)
+	}
+	if latencyUs <= 0 {
+		return s.fail("Invalid arguments")
+	}
+	if s.denied(device) {
+		return s.fail("Device '%s' denied access", device)
+	}
+	settings.LatencyUs = latencyUs
+	s.Settings[device] = settings
+	return 0
+}
+
+// SetSampleRate 與 C wrapper 一樣經由 ConMon，需要先 MonitorStart
+func (s *SimulatedSDK) SetSampleRate(device string, sampleRate int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.monitoring {
+		return s.fail("ConMon not connected")
+	}
+	settings, ok := s.Settings[device]
+	if !ok {
+		return s.fail("Device %s not found", device)
+	}
+	if sampleRate <= 0 {
+		return s.fail("Invalid arguments")
+	}
+	if s.denied(device) {
+		return s.fail("Device '%s' denied access", device)
+	}
+	settings.SampleRate = sampleRate
+	s.Settings[device] = settings
+	s.resolveRoutes()
+	return 0
+}
+
+// GetDeviceEnrollment 與 C wrapper 一樣需要設備存在 (可以解析)
+func (s *SimulatedSDK) GetDeviceEnrollment(device string) (Enrollment, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return Enrollment{}, s.fail("Dante not initialized")
+	}
+	if _, ok := s.Settings[device]; !ok {
+		return Enrollment{}, s.fail("Device '%s' did not resolve", device)
+	}
+	return s.Enrollments[device], 0
+}
+
+// TxFlowList 只有手動建立的 flow (模擬設備沒有自動建立的 unicast flow)
+func (s *SimulatedSDK) TxFlowList(device string, maxCount int) ([]Flow, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return nil, s.fail("Dante not initialized")
+	}
+	if _, ok := s.TxChannels[device]; !ok {
+		return nil, s.fail("Device '%s' did not resolve", device)
+	}
+	flows := s.Flows[device]
+	if len(flows) > maxCount {
+		flows = flows[:maxCount]
+	}
+	out := make([]Flow, len(flows))
+	for i, f := range flows {
+		f.Channels = slices.Clone(f.Channels)
+		out[i] = f
+	}
+	return out, len(out)
+}
+
+// RxFlowStats 每個發送設備 (multicast 為每個發送 flow) 一個接收 flow，
+// 只有已連線的訂閱；所有統計種類都提供，計數來自 FlowErrors
+func (s *SimulatedSDK) RxFlowStats(device string, maxCount int) ([]RxFlowStats, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return nil, s.fail("Dante not initialized")
+	}
+	index := slices.IndexFunc(s.Devices, func(d Device) bool { return d.Name == device })
+	if index < 0 {
+		return nil, s.fail("Device '%s' did not resolve", device)
+	}
+	interfaces := 1
+	if s.Devices[index].SecondaryIP != "" {
+		interfaces = 2
+	}
+
+	var stats []RxFlowStats
+	for _, sub := range s.Subscriptions[device] {
+		if sub.Status != simRxStatusConnected && sub.Status != simRxStatusMulticast {
+			continue
+		}
+		var txFlow string
+		if sub.Status == simRxStatusMulticast {
+			id := slices.Index(s.TxChannels[sub.TxDevice], sub.TxChannel) + 1
+			if i := slices.IndexFunc(s.Flows[sub.TxDevice], func(f Flow) bool { return slices.Contains(f.Channels, id) }); i >= 0 {
+				txFlow = s.Flows[sub.TxDevice][i].Name
+			}
+		}
+		if slices.ContainsFunc(stats, func(f RxFlowStats) bool { return f.TxDevice == sub.TxDevice && f.TxFlow == txFlow }) {
+			continue
+		}
+		if len(stats) == maxCount {
+			break
+		}
+		flow := RxFlowStats{
+			ID:         len(stats) + 1,
+			TxDevice:   sub.TxDevice,
+			TxFlow:     txFlow,
+			Multicast:  txFlow != "",
+			Reported:   slices.Clone(flowStatTypes),
+			Interfaces: make([]FlowCounters, interfaces),
+		}
+		flow.Interfaces[0] = s.FlowErrors[device][sub.TxDevice]
+		stats = append(stats, flow)
+	}
+	return stats, len(stats)
+}
+
+// CreateMulticastFlow 與 C wrapper 一樣使用第一個未使用的編號，
+// 沒有指定地址時由設備選擇 239.255.x.y
+func (s *SimulatedSDK) CreateMulticastFlow(device string, config FlowConfig) (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return 0, s.fail("Dante not initialized")
+	}
+	if len(config.Channels) == 0 {
+		return 0, s.fail("Invalid arguments")
+	}
+	names, ok := s.TxChannels[device]
+	if !ok {
+		return 0, s.fail("Device '%s' did not resolve", device)
+	}
+	if s.denied(device) {
+		return 0, s.fail("Device '%s' denied access", device)
+	}
+	if len(config.Channels) > simMaxFlowSlots {
+		return 0, s.fail("Device '%s' allows at most %d channels per flow", device, simMaxFlowSlots)
+	}
+	for _, ch := range config.Channels {
+		if ch < 1 || ch > len(names) {
+			return 0, s.fail("TX channel %d not found on '%s'", ch, device)
+		}
+	}
+
+	flows := s.Flows[device]
+	id := 0
+	for candidate := 1; candidate <= simMaxTxFlows && id == 0; candidate++ {
+		if !slices.ContainsFunc(flows, func(f Flow) bool { return f.ID == candidate }) {
+			id = candidate
+		}
+	}
+	if id == 0 {
+		return 0, s.fail("Device '%s' has no free TX flows (max %d)", device, simMaxTxFlows)
+	}
+
+	settings := s.Settings[device]
+	flow := Flow{
+		ID:         id,
+		Name:       config.Name,
+		Multicast:  true,
+		Manual:     true,
+		Address:    config.Address,
+		Port:       config.Port,
+		LatencyUs:  settings.LatencyUs,
+		SampleRate: settings.SampleRate,
+		Channels:   slices.Clone(config.Channels),
+	}
+	if flow.Name == "" {
+		flow.Name = fmt.Sprintf("flow-%d", id)
+	}
+	if flow.Address == "" {
+		index := slices.IndexFunc(s.Devices, func(d Device) bool { return d.Name == device })
+		flow.Address = fmt.Sprintf("239.255.%d.%d", s.Devices[index].ID&0xff, id)
+		flow.Port = simMulticastPort
+	}
+	packetTime := config.PacketTimeUs
+	if packetTime <= 0 {
+		packetTime = simDefaultFlowFrame
+	}
+	flow.FramesPerPacket = packetTime * settings.SampleRate / 1_000_000
+	s.Flows[device] = append(flows, flow)
+	s.resolveRoutes()
+	return id, 0
+}
+
+// DeleteTxFlow 與 C wrapper 一樣只能刪除手動建立的 flow
+func (s *SimulatedSDK) DeleteTxFlow(device string, flowID int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return s.fail("Dante not initialized")
+	}
+	if _, ok := s.TxChannels[device]; !ok {
+		return s.fail("Device '%s' did not resolve", device)
+	}
+	index := slices.IndexFunc(s.Flows[device], func(f Flow) bool { return f.ID == flowID })
+	if index < 0 {
+		return s.fail("TX flow %d not found on '%s'", flowID, device)
+	}
+	if s.denied(device) {
+		return s.fail("Device '%s' denied access", device)
+	}
+	s.Flows[device] = slices.Delete(s.Flows[device], index, index+1)
+	s.resolveRoutes()
+	return 0
+}
+
+// OpenDevice 與 C wrapper 一樣最多保持 simMaxOpenDevices 台設備的連線
+func (s *SimulatedSDK) OpenDevice(device string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return s.fail("Dante not initialized")
+	}
+	if !slices.ContainsFunc(s.Devices, func(d Device) bool { return d.Name == device }) {
+		return s.fail("Device '%s' did not resolve", device)
+	}
+	if s.open[device] == 0 && len(s.open) >= simMaxOpenDevices {
+		return s.fail("Too many open devices (at most %d)", simMaxOpenDevices)
+	}
+	s.open[device]++
+	return 0
+}
+
+func (s *SimulatedSDK) CloseDevice(device string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.open[device] == 0 {
+		return s.fail("Device '%s' is not open", device)
+	}
+	if s.open[device]--; s.open[device] == 0 {
+		delete(s.open, device)
+	}
+	return 0
+}
+
+// denied 已註冊或鎖定的設備拒絕設定 (呼叫者持有 mu)
+func (s *SimulatedSDK) denied(device string) bool {
+	return s.Enrollments[device].ReadOnly != ""
+}
+
+// resolveRoutes 設備或發送通道改名後重新判斷所有訂閱的狀態 (呼叫者持有 mu)
+func (s *SimulatedSDK) resolveRoutes() {
+	for rx, subs := range s.Subscriptions {
+		for i := range subs {
+			subs[i].Status = s.routeStatus(rx, subs[i].TxDevice, subs[i].TxChannel)
+		}
+	}
+}
+
+// renameKey 把 map 的項目移到新的 key
+func renameKey[V any](m map[string]V, from, to string) {
+	if v, ok := m[from]; ok {
+		delete(m, from)
+		m[to] = v
+	}
+}
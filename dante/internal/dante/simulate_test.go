@@ -0,0 +1,242 @@
+package dante
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimulatedDomainDiscovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sim.json")
+	config := `{"devices": [
+		{"name": "console", "model": "DL32", "ip_address": "10.1.0.10", "tx_channels": 8, "rx_channels": 8},
+		{"name": "amp", "model": "PA-4D", "ip_address": "169.254.1.2", "secondary_ip": "10.2.0.11", "rx_channels": 2,
+		 "manufacturer": "Acme", "serial_number": "00ff00ff00ff00ff", "aes67": true, "lockable": true}
+	]}`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadSimulationConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Interface != "sim0" {
+		t.Fatalf("interface = %q, want default sim0", cfg.Interface)
+	}
+
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), NewSimulatedSDK(cfg))
+	if !d.Simulated() {
+		t.Fatal("domain not marked simulated")
+	}
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+	if err := d.StartDeviceScan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(context.Background())
+
+	devices := d.GetDevices()
+	if len(devices) != 2 || devices[0].Name != "console" || devices[0].LinkSpeed != 1000 {
+		t.Fatalf("unexpected devices: %+v", devices)
+	}
+	if !devices[1].IsLinkLocal() || devices[1].Redundancy() != RedundancySecondaryDown {
+		t.Fatalf("amp should be link-local with secondary down: %+v", devices[1])
+	}
+	if devices[0].ChannelCounts() != "8/8" || devices[1].ChannelCounts() != "0/2" {
+		t.Fatalf("channel counts = %s, %s, want 8/8, 0/2", devices[0].ChannelCounts(), devices[1].ChannelCounts())
+	}
+	if devices[0].Manufacturer != "Audinate" || devices[0].SerialNumber == "" || len(devices[0].Capabilities.List()) != 0 {
+		t.Fatalf("console identity should use defaults: %+v", devices[0])
+	}
+	if got := strings.Join(devices[1].Capabilities.List(), ","); devices[1].SerialNumber != "00ff00ff00ff00ff" || got != "aes67,redundancy,lockable" {
+		t.Fatalf("amp identity = %s %s, capabilities %s", devices[1].Manufacturer, devices[1].SerialNumber, got)
+	}
+
+	// 存在的發送通道 connected，不存在的 unresolved
+	if err := d.Subscribe(context.Background(), "amp", "01", "console", "08"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Subscribe(context.Background(), "amp", "02", "console", "09"); err != nil {
+		t.Fatal(err)
+	}
+	subs, err := d.ListSubscriptions(context.Background(), "amp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs[0].Status != simRxStatusConnected || subs[1].Status != simRxStatusUnresolved {
+		t.Fatalf("unexpected subscription status: %+v", subs)
+	}
+}
+
+func TestSimulationConfigRejectsDuplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sim.json")
+	os.WriteFile(path, []byte(`{"devices": [{"name": "a"}, {"name": "a"}]}`), 0644)
+	if _, err := LoadSimulationConfig(path); err == nil {
+		t.Fatal("duplicate device names accepted")
+	}
+	os.WriteFile(path, []byte(`{"devices": [{"name": "a", "rx_channel_names": ["L"]}],
+		"routes": [{"rx_device": "a", "rx_channel": "01", "tx_device": "b", "tx_channel": "01"}]}`), 0644)
+	if _, err := LoadSimulationConfig(path); err == nil {
+		t.Fatal("route to a missing RX channel accepted")
+	}
+}
+
+func TestSimulatedChangeNotification(t *testing.T) {
+	sim := NewSimulatedSDK(DefaultSimulationConfig())
+	d := NewSimulatedDomain("Dante1", DefaultSimulationConfig().NetworkConfig(), sim)
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+	if err := d.StartDeviceScan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	waitChange := func(what string) {
+		t.Helper()
+		select {
+		case <-d.Changes():
+		case <-time.After(3 * time.Second):
+			t.Fatalf("no change notification after %s", what)
+		}
+	}
+	waitChange("initial discovery")
+
+	// 設備離開時再次通知，刷新後列表反映變化
+	sim.mu.Lock()
+	sim.Devices = sim.Devices[:2]
+	sim.mu.Unlock()
+	waitChange("device removal")
+	d.RefreshDevices(context.Background())
+	if devices := d.GetDevices(); len(devices) != 2 {
+		t.Fatalf("got %d devices after removal, want 2", len(devices))
+	}
+}
+
+func TestSimulatedDeviceSettings(t *testing.T) {
+	ctx := context.Background()
+	d := NewSimulatedDomain("Dante1", NetworkConfig{InterfaceName: "sim0"}, NewSimulatedSDK(DefaultSimulationConfig()))
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+	if err := d.Subscribe(ctx, "Amp-Left", "01", "Stage-Box-A", "01"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 發送通道改名後，以舊名稱的訂閱變成 unresolved
+	if err := d.SetTxChannelName(ctx, "Stage-Box-A", 1, "Vocal"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := d.TxChannels(ctx, "Stage-Box-A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx) != 4 || tx[0] != (Channel{ID: 1, Name: "Vocal"}) {
+		t.Fatalf("unexpected TX channels: %+v", tx)
+	}
+	subs, _ := d.ListSubscriptions(ctx, "Amp-Left")
+	if subs[0].Status != simRxStatusUnresolved {
+		t.Fatalf("subscription to renamed channel: status %#x, want unresolved", subs[0].Status)
+	}
+
+	// 名稱已被其他通道使用時，該通道恢復預設名稱
+	if err := d.SetRxChannelName(ctx, "Amp-Left", 2, "L"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetRxChannelName(ctx, "Amp-Left", 3, "L"); err != nil {
+		t.Fatal(err)
+	}
+	subs, _ = d.ListSubscriptions(ctx, "Amp-Left")
+	if subs[1].Channel != "02" || subs[2].Channel != "L" {
+		t.Fatalf("RX labels = %q, %q, want 02, L", subs[1].Channel, subs[2].Channel)
+	}
+
+	// 設備改名後設定跟著新名稱
+	if err := d.RenameDevice(ctx, "Amp-Left", "Amp-Left"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RenameDevice(ctx, "Amp-Left", "Amp-Right"); err == nil {
+		t.Fatal("rename to a name in use succeeded")
+	}
+	if err := d.RenameDevice(ctx, "Amp-Left", "Amp-L"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetLatency(ctx, "Amp-L", 2000); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetSampleRate(ctx, "Amp-L", 96000); err == nil {
+		t.Fatal("sample rate set without ConMon")
+	}
+	if err := d.StartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetSampleRate(ctx, "Amp-L", 96000); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := d.DeviceSettings(ctx, "Amp-L")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings != (DeviceSettings{SampleRate: 96000, LatencyUs: 2000}) {
+		t.Fatalf("settings = %+v", settings)
+	}
+	if _, err := d.DeviceSettings(ctx, "Amp-Left"); err == nil {
+		t.Fatal("old device name still has settings")
+	}
+}
+
+func TestSubscriptionState(t *testing.T) {
+	cfg := &SimulationConfig{Interface: "sim0", Devices: []SimulatedDevice{
+		{Name: "console", Model: "DL32", IPAddress: "10.1.0.11", TxChannels: 4},
+		{Name: "amp", Model: "PA-4D", IPAddress: "10.1.0.13", RxChannels: 4},
+	}, Routes: []SimulatedRoute{
+		{RxDevice: "amp", RxChannel: "01", TxDevice: "console", TxChannel: "01"},
+		{RxDevice: "amp", RxChannel: "02", TxDevice: "offline", TxChannel: "01"},
+	}}
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), NewSimulatedSDK(cfg))
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	subs, err := d.ListSubscriptions(ctx, "amp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs[0].State() != SubscriptionOK || subs[0].Reason() != "" {
+		t.Errorf("connected: %s %q", subs[0].State(), subs[0].Reason())
+	}
+	if subs[1].State() != SubscriptionUnresolved || !strings.Contains(subs[1].Reason(), "01@offline was not found") {
+		t.Errorf("unresolved: %s %q", subs[1].State(), subs[1].Reason())
+	}
+	if subs[2].State() != SubscriptionNone || subs[2].Reason() != "" {
+		t.Errorf("unsubscribed: %s %q", subs[2].State(), subs[2].Reason())
+	}
+
+	// 取樣率不同的設備之間沒有聲音
+	if err := d.StartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetSampleRate(ctx, "console", 96000); err != nil {
+		t.Fatal(err)
+	}
+	subs, _ = d.ListSubscriptions(ctx, "amp")
+	if subs[0].State() != SubscriptionFormatMismatch || !strings.Contains(subs[0].Reason(), "sample rate") {
+		t.Errorf("format mismatch: %s %q", subs[0].State(), subs[0].Reason())
+	}
+
+	for status, want := range map[int]string{0x08: SubscriptionPending, 0x14: SubscriptionNoAudio, 0x1A: SubscriptionNoAudio, 0x99: SubscriptionNoAudio} {
+		sub := Subscription{TxDevice: "console", TxChannel: "01", Status: status}
+		if sub.State() != want || sub.Reason() == "" {
+			t.Errorf("status 0x%x: %s %q, want %s", status, sub.State(), sub.Reason(), want)
+		}
+	}
+}
@@ -0,0 +1,738 @@
+package dante
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// SDK 回應錄製與重播 (answer tape)
+//==============================================================================
+
+// Audinate 更新 libdapi 時，設備列表、訂閱狀態碼、錯誤訊息等回應的格式可能
+// 改變，而 Go 層只有在實機上才會遇到。TapeRecorder 包住 SDK，把 tapeScript
+// 執行期間每個呼叫的參數與回應錄成 SDKTape (golane capture sdk-tape)；
+// TapeSDK 依 tape 回答相同的呼叫，測試以 testdata/sdktapes 中各版本的 tape
+// 重播同一個腳本，檢查 Go 層的結果沒有改變，且通過 TapeResult.Problems。
+// 腳本只讀取狀態，對不存在的設備送出的訂閱用來錄製錯誤訊息，不會改變網路。
+
+// tapeProbeDevice 錄製錯誤回應用的設備名稱 (網路上不存在)
+const tapeProbeDevice = "golane-tape-probe"
+
+// SDKTape 錄製的 SDK 回應
+type SDKTape struct {
+	SDKVersion string       `json:"sdk_version"`     // libdapi 版本 (模擬為 simulated)
+	Interface  string       `json:"interface"`       // 錄製時的網路介面 (重播時 InitWithInterface 的參數)
+	Recorded   time.Time    `json:"recorded"`        // 錄製時間
+	Notes      string       `json:"notes,omitempty"` // 錄製環境說明
+	Answers    []TapeAnswer `json:"answers"`
+	Expect     *TapeResult  `json:"expect,omitempty"` // 錄製時 Go 層的結果
+}
+
+// TapeAnswer 一次 SDK 呼叫的回應
+// 同一個呼叫 (Op 與 Args 相同) 依錄製順序回答，用完後重複最後一個回應
+// (背景事件處理的呼叫次數與時間有關)
+type TapeAnswer struct {
+	Op            string          `json:"op"`
+	Args          []string        `json:"args,omitempty"`
+	Result        int             `json:"result"`
+	Error         string          `json:"error,omitempty"` // 失敗時 GetLastError 的內容
+	Devices       []Device        `json:"devices,omitempty"`
+	Subscriptions []Subscription  `json:"subscriptions,omitempty"`
+	Channels      []Channel       `json:"channels,omitempty"`
+	Clock         *ClockInfo      `json:"clock,omitempty"`
+	Upgrade       *UpgradeStatus  `json:"upgrade,omitempty"`
+	Meters        *Meters         `json:"meters,omitempty"`
+	Settings      *DeviceSettings `json:"settings,omitempty"`
+	Enrollment    *Enrollment     `json:"enrollment,omitempty"`
+	Flows         []Flow          `json:"flows,omitempty"`
+	FlowStats     []RxFlowStats   `json:"flow_stats,omitempty"`
+	FlowID        int             `json:"flow_id,omitempty"` // CreateMulticastFlow 建立的 flow
+}
+
+// key 比對呼叫用的 key
+func (a TapeAnswer) key() string {
+	return a.Op + "(" + strings.Join(a.Args, ",") + ")"
+}
+
+// tapeArgs 把呼叫參數轉成 tape 的字串
+func tapeArgs(args ...any) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = fmt.Sprint(arg)
+	}
+	return out
+}
+
+// LoadSDKTape 讀取 tape 檔
+func LoadSDKTape(path string) (*SDKTape, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tape SDKTape
+	if err := json.Unmarshal(data, &tape); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if tape.SDKVersion == "" {
+		return nil, fmt.Errorf("%s: tape without sdk_version", path)
+	}
+	return &tape, nil
+}
+
+// SDKVersion 連結的 libdapi 版本 (nodante 建置為 nodante)
+func SDKVersion() string {
+	return danteGetSDKVersion()
+}
+
+// LibraryVersions 與 libdapi 一起連結的函式庫版本 (名稱 → 版本，nodante 建置為 nil)
+func LibraryVersions() map[string]string {
+	return danteLibraryVersions()
+}
+
+//----------------------------------------------------------------------
+// 錄製
+//----------------------------------------------------------------------
+
+// TapeRecorder 轉呼叫給內部的 SDK 並錄下回應 (實作 SDK)
+type TapeRecorder struct {
+	inner SDK
+
+	mu      sync.Mutex
+	answers []TapeAnswer
+	last    int // 最後一個失敗呼叫的索引 (GetLastError 的內容記在它上面)
+}
+
+// RecordSDK 之後的 SDK 呼叫都經過回傳的 recorder (在 Initialize 之前呼叫)
+func (d *Domain) RecordSDK() *TapeRecorder {
+	rec := &TapeRecorder{inner: d.sdk, last: -1}
+	d.sdk = rec
+	return rec
+}
+
+// Tape 目前為止錄下的回應
+func (r *TapeRecorder) Tape(iface, notes string) *SDKTape {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	version := SDKVersion()
+	if _, ok := r.inner.(*SimulatedSDK); ok {
+		version = "simulated"
+	}
+	return &SDKTape{
+		SDKVersion: version,
+		Interface:  iface,
+		Recorded:   time.Now().UTC().Truncate(time.Second),
+		Notes:      notes,
+		Answers:    slices.Clone(r.answers),
+	}
+}
+
+// record 記錄一個回應；相同呼叫與回應連續重複時 (背景事件處理) 只記一次
+func (r *TapeRecorder) record(a TapeAnswer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n := len(r.answers); n > 0 && a.Result >= 0 {
+		prev, _ := json.Marshal(r.answers[n-1])
+		next, _ := json.Marshal(a)
+		if string(prev) == string(next) {
+			return
+		}
+	}
+	r.answers = append(r.answers, a)
+	if a.Result < 0 {
+		r.last = len(r.answers) - 1
+	}
+}
+
+func (r *TapeRecorder) GetLastError() string {
+	msg := r.inner.GetLastError()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last >= 0 {
+		r.answers[r.last].Error = msg
+		r.last = -1
+	}
+	return msg
+}
+
+func (r *TapeRecorder) InitWithInterface(interfaceName string) int {
+	// 介面名稱與現場有關，重播時以 SDKTape.Interface 代入
+	result := r.inner.InitWithInterface(interfaceName)
+	r.record(TapeAnswer{Op: "InitWithInterface", Result: result})
+	return result
+}
+
+func (r *TapeRecorder) Cleanup() {
+	r.inner.Cleanup()
+	r.record(TapeAnswer{Op: "Cleanup"})
+}
+
+func (r *TapeRecorder) simple(op string, fn func() int) int {
+	result := fn()
+	r.record(TapeAnswer{Op: op, Result: result})
+	return result
+}
+
+func (r *TapeRecorder) StartDeviceScan() int {
+	return r.simple("StartDeviceScan", r.inner.StartDeviceScan)
+}
+
+func (r *TapeRecorder) StopDeviceScan() int {
+	return r.simple("StopDeviceScan", r.inner.StopDeviceScan)
+}
+
+func (r *TapeRecorder) ProcessEventsBriefly() int {
+	return r.simple("ProcessEventsBriefly", r.inner.ProcessEventsBriefly)
+}
+
+func (r *TapeRecorder) RefreshDeviceScan() int {
+	return r.simple("RefreshDeviceScan", r.inner.RefreshDeviceScan)
+}
+
+func (r *TapeRecorder) GetDiscoveredDeviceCount() int {
+	return r.simple("GetDiscoveredDeviceCount", r.inner.GetDiscoveredDeviceCount)
+}
+
+func (r *TapeRecorder) ChangeCount() int {
+	return r.simple("ChangeCount", r.inner.ChangeCount)
+}
+
+func (r *TapeRecorder) GetDeviceInfo(index int) (Device, int) {
+	dev, result := r.inner.GetDeviceInfo(index)
+	r.record(TapeAnswer{Op: "GetDeviceInfo", Args: tapeArgs(index), Result: result, Devices: []Device{dev}})
+	return dev, result
+}
+
+func (r *TapeRecorder) GetDeviceList(maxCount int) ([]Device, int) {
+	devices, count := r.inner.GetDeviceList(maxCount)
+	r.record(TapeAnswer{Op: "GetDeviceList", Args: tapeArgs(maxCount), Result: count, Devices: devices})
+	return devices, count
+}
+
+func (r *TapeRecorder) RouteList(rxDevice string, maxCount int) ([]Subscription, int) {
+	subs, count := r.inner.RouteList(rxDevice, maxCount)
+	r.record(TapeAnswer{Op: "RouteList", Args: tapeArgs(rxDevice, maxCount), Result: count, Subscriptions: subs})
+	return subs, count
+}
+
+func (r *TapeRecorder) RouteSubscribe(rxDevice, rxChannel, txDevice, txChannel string) int {
+	result := r.inner.RouteSubscribe(rxDevice, rxChannel, txDevice, txChannel)
+	r.record(TapeAnswer{Op: "RouteSubscribe", Args: tapeArgs(rxDevice, rxChannel, txDevice, txChannel), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) MonitorStart() int {
+	return r.simple("MonitorStart", r.inner.MonitorStart)
+}
+
+func (r *TapeRecorder) MonitorWatchDevice(device string) int {
+	result := r.inner.MonitorWatchDevice(device)
+	r.record(TapeAnswer{Op: "MonitorWatchDevice", Args: tapeArgs(device), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) GetClockInfo(device string) (ClockInfo, int) {
+	info, result := r.inner.GetClockInfo(device)
+	a := TapeAnswer{Op: "GetClockInfo", Args: tapeArgs(device), Result: result}
+	if result == 0 {
+		a.Clock = &info
+	}
+	r.record(a)
+	return info, result
+}
+
+func (r *TapeRecorder) IdentifyDevice(device string) int {
+	result := r.inner.IdentifyDevice(device)
+	r.record(TapeAnswer{Op: "IdentifyDevice", Args: tapeArgs(device), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) StartFirmwareUpgrade(device string, src FirmwareSource) int {
+	result := r.inner.StartFirmwareUpgrade(device, src)
+	r.record(TapeAnswer{Op: "StartFirmwareUpgrade", Args: tapeArgs(device, src.String()), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) GetUpgradeStatus(device string) (UpgradeStatus, int) {
+	status, result := r.inner.GetUpgradeStatus(device)
+	a := TapeAnswer{Op: "GetUpgradeStatus", Args: tapeArgs(device), Result: result}
+	if result == 0 {
+		a.Upgrade = &status
+	}
+	r.record(a)
+	return status, result
+}
+
+func (r *TapeRecorder) MonitorWatchMeters(device string) int {
+	result := r.inner.MonitorWatchMeters(device)
+	r.record(TapeAnswer{Op: "MonitorWatchMeters", Args: tapeArgs(device), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) GetMeters(device string) (Meters, int) {
+	meters, result := r.inner.GetMeters(device)
+	a := TapeAnswer{Op: "GetMeters", Args: tapeArgs(device), Result: result}
+	if result == 0 {
+		a.Meters = &meters
+	}
+	r.record(a)
+	return meters, result
+}
+
+func (r *TapeRecorder) TxChannelList(device string, maxCount int) ([]Channel, int) {
+	channels, count := r.inner.TxChannelList(device, maxCount)
+	r.record(TapeAnswer{Op: "TxChannelList", Args: tapeArgs(device, maxCount), Result: count, Channels: channels})
+	return channels, count
+}
+
+func (r *TapeRecorder) GetDeviceSettings(device string) (DeviceSettings, int) {
+	settings, result := r.inner.GetDeviceSettings(device)
+	a := TapeAnswer{Op: "GetDeviceSettings", Args: tapeArgs(device), Result: result}
+	if result == 0 {
+		a.Settings = &settings
+	}
+	r.record(a)
+	return settings, result
+}
+
+func (r *TapeRecorder) RenameDevice(device, newName string) int {
+	result := r.inner.RenameDevice(device, newName)
+	r.record(TapeAnswer{Op: "RenameDevice", Args: tapeArgs(device, newName), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) SetChannelName(device string, tx bool, channelID int, name string) int {
+	result := r.inner.SetChannelName(device, tx, channelID, name)
+	r.record(TapeAnswer{Op: "SetChannelName", Args: tapeArgs(device, tx, channelID, name), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) SetRxLatency(device string, latencyUs int) int {
+	result := r.inner.SetRxLatency(device, latencyUs)
+	r.record(TapeAnswer{Op: "SetRxLatency", Args: tapeArgs(device, latencyUs), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) SetSampleRate(device string, sampleRate int) int {
+	result := r.inner.SetSampleRate(device, sampleRate)
+	r.record(TapeAnswer{Op: "SetSampleRate", Args: tapeArgs(device, sampleRate), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) GetDeviceEnrollment(device string) (Enrollment, int) {
+	e, result := r.inner.GetDeviceEnrollment(device)
+	a := TapeAnswer{Op: "GetDeviceEnrollment", Args: tapeArgs(device), Result: result}
+	if result == 0 {
+		a.Enrollment = &e
+	}
+	r.record(a)
+	return e, result
+}
+
+func (r *TapeRecorder) RxFlowStats(device string, maxCount int) ([]RxFlowStats, int) {
+	stats, count := r.inner.RxFlowStats(device, maxCount)
+	r.record(TapeAnswer{Op: "RxFlowStats", Args: tapeArgs(device, maxCount), Result: count, FlowStats: stats})
+	return stats, count
+}
+
+func (r *TapeRecorder) TxFlowList(device string, maxCount int) ([]Flow, int) {
+	flows, count := r.inner.TxFlowList(device, maxCount)
+	r.record(TapeAnswer{Op: "TxFlowList", Args: tapeArgs(device, maxCount), Result: count, Flows: flows})
+	return flows, count
+}
+
+func (r *TapeRecorder) CreateMulticastFlow(device string, config FlowConfig) (int, int) {
+	id, result := r.inner.CreateMulticastFlow(device, config)
+	r.record(TapeAnswer{Op: "CreateMulticastFlow", Args: tapeArgs(device, config), Result: result, FlowID: id})
+	return id, result
+}
+
+func (r *TapeRecorder) DeleteTxFlow(device string, flowID int) int {
+	result := r.inner.DeleteTxFlow(device, flowID)
+	r.record(TapeAnswer{Op: "DeleteTxFlow", Args: tapeArgs(device, flowID), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) OpenDevice(device string) int {
+	result := r.inner.OpenDevice(device)
+	r.record(TapeAnswer{Op: "OpenDevice", Args: tapeArgs(device), Result: result})
+	return result
+}
+
+func (r *TapeRecorder) CloseDevice(device string) int {
+	result := r.inner.CloseDevice(device)
+	r.record(TapeAnswer{Op: "CloseDevice", Args: tapeArgs(device), Result: result})
+	return result
+}
+
+//----------------------------------------------------------------------
+// 重播
+//----------------------------------------------------------------------
+
+// TapeSDK 依 tape 回答 SDK 呼叫 (實作 SDK)
+// tape 中沒有的呼叫回傳 -1，並記錄在 Unanswered (Go 層送出了錄製時沒有的呼叫)
+type TapeSDK struct {
+	mu         sync.Mutex
+	answers    map[string][]TapeAnswer
+	used       map[string]int
+	lastError  string
+	unanswered []string
+}
+
+// NewTapeSDK 建立重播 tape 的 SDK
+func NewTapeSDK(tape *SDKTape) *TapeSDK {
+	s := &TapeSDK{answers: make(map[string][]TapeAnswer), used: make(map[string]int)}
+	for _, a := range tape.Answers {
+		s.answers[a.key()] = append(s.answers[a.key()], a)
+	}
+	return s
+}
+
+// NewTapeDomain 建立以 tape 回答 SDK 呼叫的網域 (介面名稱取自 tape)
+func NewTapeDomain(name string, tape *SDKTape, sdk *TapeSDK) *Domain {
+	d := NewDomain(name, NetworkConfig{InterfaceName: tape.Interface, NetworkType: "dante1", Enabled: true})
+	d.sdk = sdk
+	return d
+}
+
+// Unanswered tape 中沒有回應的呼叫
+func (s *TapeSDK) Unanswered() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.unanswered)
+}
+
+// answer 取出呼叫的下一個回應
+func (s *TapeSDK) answer(op string, args ...any) TapeAnswer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	call := TapeAnswer{Op: op, Args: tapeArgs(args...)}
+	key := call.key()
+	answers := s.answers[key]
+	if len(answers) == 0 {
+		if !slices.Contains(s.unanswered, key) {
+			s.unanswered = append(s.unanswered, key)
+		}
+		s.lastError = "call not on tape: " + key
+		call.Result = -1
+		return call
+	}
+	i := min(s.used[key], len(answers)-1)
+	s.used[key]++
+	if answers[i].Result < 0 {
+		s.lastError = answers[i].Error
+	}
+	return answers[i]
+}
+
+func (s *TapeSDK) GetLastError() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastError
+}
+
+func (s *TapeSDK) InitWithInterface(string) int { return s.answer("InitWithInterface").Result }
+func (s *TapeSDK) Cleanup()                     { s.answer("Cleanup") }
+func (s *TapeSDK) StartDeviceScan() int         { return s.answer("StartDeviceScan").Result }
+func (s *TapeSDK) StopDeviceScan() int          { return s.answer("StopDeviceScan").Result }
+func (s *TapeSDK) ProcessEventsBriefly() int    { return s.answer("ProcessEventsBriefly").Result }
+func (s *TapeSDK) RefreshDeviceScan() int       { return s.answer("RefreshDeviceScan").Result }
+func (s *TapeSDK) ChangeCount() int             { return s.answer("ChangeCount").Result }
+
+func (s *TapeSDK) GetDiscoveredDeviceCount() int {
+	return s.answer("GetDiscoveredDeviceCount").Result
+}
+
+func (s *TapeSDK) GetDeviceInfo(index int) (Device, int) {
+	a := s.answer("GetDeviceInfo", index)
+	if len(a.Devices) == 0 {
+		return Device{}, a.Result
+	}
+	return a.Devices[0], a.Result
+}
+
+func (s *TapeSDK) GetDeviceList(maxCount int) ([]Device, int) {
+	a := s.answer("GetDeviceList", maxCount)
+	return a.Devices, a.Result
+}
+
+func (s *TapeSDK) RouteList(rxDevice string, maxCount int) ([]Subscription, int) {
+	a := s.answer("RouteList", rxDevice, maxCount)
+	return a.Subscriptions, a.Result
+}
+
+func (s *TapeSDK) RouteSubscribe(rxDevice, rxChannel, txDevice, txChannel string) int {
+	return s.answer("RouteSubscribe", rxDevice, rxChannel, txDevice, txChannel).Result
+}
+
+func (s *TapeSDK) MonitorStart() int { return s.answer("MonitorStart").Result }
+
+func (s *TapeSDK) MonitorWatchDevice(device string) int {
+	return s.answer("MonitorWatchDevice", device).Result
+}
+
+func (s *TapeSDK) GetClockInfo(device string) (ClockInfo, int) {
+	a := s.answer("GetClockInfo", device)
+	if a.Clock == nil {
+		return ClockInfo{}, a.Result
+	}
+	return *a.Clock, a.Result
+}
+
+func (s *TapeSDK) IdentifyDevice(device string) int {
+	return s.answer("IdentifyDevice", device).Result
+}
+
+func (s *TapeSDK) StartFirmwareUpgrade(device string, src FirmwareSource) int {
+	return s.answer("StartFirmwareUpgrade", device, src.String()).Result
+}
+
+func (s *TapeSDK) GetUpgradeStatus(device string) (UpgradeStatus, int) {
+	a := s.answer("GetUpgradeStatus", device)
+	if a.Upgrade == nil {
+		return UpgradeStatus{}, a.Result
+	}
+	return *a.Upgrade, a.Result
+}
+
+func (s *TapeSDK) MonitorWatchMeters(device string) int {
+	return s.answer("MonitorWatchMeters", device).Result
+}
+
+func (s *TapeSDK) GetMeters(device string) (Meters, int) {
+	a := s.answer("GetMeters", device)
+	if a.Meters == nil {
+		return Meters{}, a.Result
+	}
+	return *a.Meters, a.Result
+}
+
+func (s *TapeSDK) TxChannelList(device string, maxCount int) ([]Channel, int) {
+	a := s.answer("TxChannelList", device, maxCount)
+	return a.Channels, a.Result
+}
+
+func (s *TapeSDK) GetDeviceSettings(device string) (DeviceSettings, int) {
+	a := s.answer("GetDeviceSettings", device)
+	if a.Settings == nil {
+		return DeviceSettings{}, a.Result
+	}
+	return *a.Settings, a.Result
+}
+
+func (s *TapeSDK) RenameDevice(device, newName string) int {
+	return s.answer("RenameDevice", device, newName).Result
+}
+
+func (s *TapeSDK) SetChannelName(device string, tx bool, channelID int, name string) int {
+	return s.answer("SetChannelName", device, tx, channelID, name).Result
+}
+
+func (s *TapeSDK) SetRxLatency(device string, latencyUs int) int {
+	return s.answer("SetRxLatency", device, latencyUs).Result
+}
+
+func (s *TapeSDK) SetSampleRate(device string, sampleRate int) int {
+	return s.answer("SetSampleRate", device, sampleRate).Result
+}
+
+func (s *TapeSDK) GetDeviceEnrollment(device string) (Enrollment, int) {
+	a := s.answer("GetDeviceEnrollment", device)
+	if a.Enrollment == nil {
+		return Enrollment{}, a.Result
+	}
+	return *a.Enrollment, a.Result
+}
+
+func (s *TapeSDK) RxFlowStats(device string, maxCount int) ([]RxFlowStats, int) {
+	a := s.answer("RxFlowStats", device, maxCount)
+	return a.FlowStats, a.Result
+}
+
+func (s *TapeSDK) TxFlowList(device string, maxCount int) ([]Flow, int) {
+	a := s.answer("TxFlowList", device, maxCount)
+	return a.Flows, a.Result
+}
+
+func (s *TapeSDK) CreateMulticastFlow(device string, config FlowConfig) (int, int) {
+	a := s.answer("CreateMulticastFlow", device, config)
+	return a.FlowID, a.Result
+}
+
+func (s *TapeSDK) DeleteTxFlow(device string, flowID int) int {
+	return s.answer("DeleteTxFlow", device, flowID).Result
+}
+
+func (s *TapeSDK) OpenDevice(device string) int {
+	return s.answer("OpenDevice", device).Result
+}
+
+func (s *TapeSDK) CloseDevice(device string) int {
+	return s.answer("CloseDevice", device).Result
+}
+
+//----------------------------------------------------------------------
+// 腳本與結果
+//----------------------------------------------------------------------
+
+// TapeResult 腳本在 Go 層得到的結果 (錄製時存入 SDKTape.Expect，重播時比對)
+type TapeResult struct {
+	Devices       []Device                  `json:"devices"`
+	Subscriptions map[string][]Subscription `json:"subscriptions"`
+	TxChannels    map[string][]Channel      `json:"tx_channels"`
+	Settings      map[string]DeviceSettings `json:"settings"`
+	Clocks        map[string]ClockInfo      `json:"clocks"`
+	Errors        map[string]string         `json:"errors"` // 步驟 → Go 層的錯誤訊息
+}
+
+// RunTapeScript 以已初始化的網域執行錄製與重播共用的腳本
+// wait 是掃描後等待設備發現的時間 (重播時為 0)
+func RunTapeScript(ctx context.Context, d *Domain, wait time.Duration) TapeResult {
+	r := TapeResult{
+		Subscriptions: make(map[string][]Subscription),
+		TxChannels:    make(map[string][]Channel),
+		Settings:      make(map[string]DeviceSettings),
+		Clocks:        make(map[string]ClockInfo),
+		Errors:        make(map[string]string),
+	}
+	fail := func(step string, err error) bool {
+		if err != nil {
+			r.Errors[step] = err.Error()
+		}
+		return err != nil
+	}
+
+	fail("monitor", d.StartMonitoring())
+	if fail("scan", d.StartDeviceScan(ctx)) {
+		return r
+	}
+	if wait > 0 {
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+	}
+	d.RefreshDevices(ctx)
+	r.Devices = d.GetDevices()
+	SortDevices(r.Devices, DeviceOrder{Key: SortByName})
+
+	for _, dev := range r.Devices {
+		if subs, err := d.ListSubscriptions(ctx, dev.Name); !fail("route_list "+dev.Name, err) {
+			r.Subscriptions[dev.Name] = subs
+		}
+		if tx, err := d.TxChannels(ctx, dev.Name); !fail("tx_channels "+dev.Name, err) {
+			r.TxChannels[dev.Name] = tx
+		}
+		if settings, err := d.DeviceSettings(ctx, dev.Name); !fail("settings "+dev.Name, err) {
+			r.Settings[dev.Name] = settings
+		}
+		if !fail("watch_clock "+dev.Name, d.WatchClock(dev.Name)) {
+			if info, ok := d.ClockInfo(dev.Name); ok {
+				r.Clocks[dev.Name] = info
+			}
+		}
+	}
+
+	// 錯誤回應：不存在的設備
+	_, err := d.ListSubscriptions(ctx, tapeProbeDevice)
+	r.Errors["probe route_list"] = fmt.Sprint(err)
+	r.Errors["probe subscribe"] = fmt.Sprint(d.Subscribe(ctx, tapeProbeDevice, "01", tapeProbeDevice, "01"))
+	return r
+}
+
+// 已知的取樣率 (0 表示未知)
+var tapeSampleRates = []int{0, 44100, 48000, 88200, 96000, 176400, 192000}
+
+// Problems Go 層無法正確使用的回應 (新版 SDK 改變格式時出現)
+func (r TapeResult) Problems() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(r.Devices) == 0 {
+		add("no devices discovered")
+	}
+	names := make(map[string]bool)
+	for _, dev := range r.Devices {
+		switch {
+		case dev.Name == "":
+			add("device %d has no name", dev.ID)
+		case names[dev.Name]:
+			add("duplicate device %s", dev.Name)
+		}
+		names[dev.Name] = true
+		if ip := net.ParseIP(dev.IPAddress); ip == nil || ip.To4() == nil {
+			add("%s: invalid IP address %q", dev.Name, dev.IPAddress)
+		}
+		if dev.SecondaryIP != "" && net.ParseIP(dev.SecondaryIP) == nil {
+			add("%s: invalid secondary IP address %q", dev.Name, dev.SecondaryIP)
+		}
+		if dev.MacAddress != "" {
+			if _, err := net.ParseMAC(dev.MacAddress); err != nil {
+				add("%s: invalid MAC address %q", dev.Name, dev.MacAddress)
+			}
+		}
+		if dev.LinkSpeed < -1 || dev.SecondarySpeed < -1 {
+			add("%s: invalid link speed %d/%d", dev.Name, dev.LinkSpeed, dev.SecondarySpeed)
+		}
+	}
+
+	for device, subs := range r.Subscriptions {
+		ids := make(map[int]bool)
+		for _, sub := range subs {
+			if sub.ChannelID <= 0 || ids[sub.ChannelID] {
+				add("%s: invalid or duplicate RX channel id %d", device, sub.ChannelID)
+			}
+			ids[sub.ChannelID] = true
+			if sub.Channel == "" {
+				add("%s: RX channel %d has no name", device, sub.ChannelID)
+			}
+			if _, known := rxStatusText[sub.Status]; !known {
+				add("%s: RX channel %s has unknown status 0x%x", device, sub.Channel, sub.Status)
+			}
+			if sub.Subscribed() && sub.TxDevice == "" {
+				add("%s: RX channel %s subscribed to %s without device", device, sub.Channel, sub.TxChannel)
+			}
+		}
+	}
+	for device, channels := range r.TxChannels {
+		ids := make(map[int]bool)
+		for _, ch := range channels {
+			if ch.ID <= 0 || ids[ch.ID] {
+				add("%s: invalid or duplicate TX channel id %d", device, ch.ID)
+			}
+			ids[ch.ID] = true
+			if ch.Name == "" {
+				add("%s: TX channel %d has no name", device, ch.ID)
+			}
+		}
+	}
+	for device, settings := range r.Settings {
+		if !slices.Contains(tapeSampleRates, settings.SampleRate) {
+			add("%s: unknown sample rate %d", device, settings.SampleRate)
+		}
+		if settings.LatencyUs < 0 || settings.LatencyUs > int(time.Second/time.Microsecond) {
+			add("%s: latency %d µs out of range", device, settings.LatencyUs)
+		}
+	}
+	for device, clock := range r.Clocks {
+		if clock.ClockState == "" {
+			add("%s: empty clock state", device)
+		}
+	}
+	for _, step := range []string{"probe route_list", "probe subscribe"} {
+		if msg := r.Errors[step]; msg == "<nil>" || strings.HasSuffix(msg, "failed: ") {
+			add("%s: no error message (%q)", step, msg)
+		}
+	}
+	slices.Sort(problems)
+	return problems
+}
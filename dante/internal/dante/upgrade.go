@@ -0,0 +1,166 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// 韌體升級 (ConMon upgrade control)
+//==============================================================================
+
+// 設備自己從檔案伺服器 (TFTP、HTTP、HTTPS) 下載 .dnt 檔並寫入 flash，
+// 進度由 ConMon status channel 送回。升級請求送出後立即回傳，呼叫端輪詢
+// UpgradeStatus 直到 Finished；寫入完成後設備會重新開機 (暫時離線)。
+
+// 升級狀態
+const (
+	UpgradeRequested   = "requested"   // 已送出請求，設備尚未回報狀態
+	UpgradeIdle        = "none"        // 設備沒有進行中的升級
+	UpgradeDownloading = "downloading" // 下載檔案中
+	UpgradeWriting     = "writing"     // 寫入 flash 中
+	UpgradeDone        = "done"        // 完成 (設備將重新開機)
+	UpgradeFailed      = "failed"      // 失敗 (原因見 Error)
+)
+
+// upgradeStates C wrapper 的狀態 (-1 與 CONMON_AUDINATE_UPGRADE_STATE_*)
+var upgradeStates = map[int]string{
+	-1: UpgradeRequested,
+	0:  UpgradeIdle,
+	1:  UpgradeDownloading,
+	2:  UpgradeDone,
+	3:  UpgradeFailed,
+	4:  UpgradeWriting,
+}
+
+// upgradeState C wrapper 狀態的名稱
+func upgradeState(state int) string {
+	if s, ok := upgradeStates[state]; ok {
+		return s
+	}
+	return "unknown (" + strconv.Itoa(state) + ")"
+}
+
+// upgradeProtocols 支援的下載協定 (CONMON_AUDINATE_UPGRADE_PROTOCOL_*)
+var upgradeProtocols = map[string]int{
+	"tftp":  2,
+	"http":  4,
+	"https": 5,
+}
+
+// UpgradeStatus 設備的韌體升級狀態
+type UpgradeStatus struct {
+	State   string    `json:"state"`
+	Error   string    `json:"error,omitempty"` // 設備回報的最後錯誤
+	Current int64     `json:"current"`         // 目前進度 (通常為位元組)
+	Total   int64     `json:"total,omitempty"` // 總進度 (0 表示未知)
+	Updated time.Time `json:"updated"`         // 最後更新時間
+}
+
+// Finished 升級已結束 (完成或失敗)
+func (u UpgradeStatus) Finished() bool {
+	return u.State == UpgradeDone || u.State == UpgradeFailed
+}
+
+// Percent 進度百分比，總進度未知時回傳 -1
+func (u UpgradeStatus) Percent() int {
+	if u.Total <= 0 {
+		return -1
+	}
+	return int(min(u.Current, u.Total) * 100 / u.Total)
+}
+
+// FirmwareSource 設備下載韌體的位置
+type FirmwareSource struct {
+	Protocol string // tftp、http 或 https
+	Server   string // 檔案伺服器 IPv4 位址 (設備不解析名稱)
+	Port     int    // 0 為協定預設值
+	Path     string // 伺服器上的 .dnt 檔案路徑
+}
+
+// ErrInvalidFirmwareSource 韌體位置不正確
+var ErrInvalidFirmwareSource = errors.New("invalid firmware source")
+
+// ParseFirmwareSource 解析 tftp://10.0.0.5/fw/pa4d.dnt 形式的韌體位置
+func ParseFirmwareSource(raw string) (FirmwareSource, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return FirmwareSource{}, fmt.Errorf("%w: %v", ErrInvalidFirmwareSource, err)
+	}
+	src := FirmwareSource{Protocol: strings.ToLower(u.Scheme), Server: u.Hostname(), Path: u.Path}
+	if _, ok := upgradeProtocols[src.Protocol]; !ok {
+		return FirmwareSource{}, fmt.Errorf("%w: %q (use tftp, http or https)", ErrInvalidFirmwareSource, u.Scheme)
+	}
+	if port := u.Port(); port != "" {
+		if src.Port, err = strconv.Atoi(port); err != nil {
+			return FirmwareSource{}, fmt.Errorf("%w: port %q", ErrInvalidFirmwareSource, port)
+		}
+	}
+	if err := src.Validate(); err != nil {
+		return FirmwareSource{}, err
+	}
+	return src, nil
+}
+
+// Validate 檢查位置 (檔案內容由設備檢查)
+func (s FirmwareSource) Validate() error {
+	if _, ok := upgradeProtocols[s.Protocol]; !ok {
+		return fmt.Errorf("%w: protocol %q", ErrInvalidFirmwareSource, s.Protocol)
+	}
+	if addr, err := netip.ParseAddr(s.Server); err != nil || !addr.Is4() {
+		return fmt.Errorf("%w: server %q is not an IPv4 address", ErrInvalidFirmwareSource, s.Server)
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("%w: port %d", ErrInvalidFirmwareSource, s.Port)
+	}
+	if s.Path == "" || s.Path == "/" {
+		return fmt.Errorf("%w: missing file path", ErrInvalidFirmwareSource)
+	}
+	return nil
+}
+
+// String 以 URL 表示
+func (s FirmwareSource) String() string {
+	host := s.Server
+	if s.Port != 0 {
+		host += ":" + strconv.Itoa(s.Port)
+	}
+	return (&url.URL{Scheme: s.Protocol, Host: host, Path: s.Path}).String()
+}
+
+// StartFirmwareUpgrade 要求設備下載並寫入韌體 (需要 StartMonitoring)
+// 先訂閱設備的 status channel 才收得到進度；請求不重試 (設備可能已經開始下載)
+func (d *Domain) StartFirmwareUpgrade(ctx context.Context, device string, src FirmwareSource) error {
+	if err := src.Validate(); err != nil {
+		return err
+	}
+	if err := d.WatchClock(device); err != nil {
+		return err
+	}
+	return d.settingsOp(ctx, "dante.firmware_upgrade", "dante_firmware_upgrade", device,
+		func(s SDK) int { return s.StartFirmwareUpgrade(device, src) },
+		"Firmware upgrade requested", "source", src.String())
+}
+
+// UpgradeStatus 取得設備最新的升級狀態，沒有升級紀錄時回傳 false
+func (d *Domain) UpgradeStatus(device string) (UpgradeStatus, bool) {
+	if !d.Initialized() {
+		return UpgradeStatus{}, false
+	}
+
+	var status UpgradeStatus
+	result, _ := d.sdkOp(func(s SDK) (result int) {
+		status, result = s.GetUpgradeStatus(device)
+		return result
+	})
+	if result != 0 {
+		return UpgradeStatus{}, false
+	}
+	return status, true
+}
@@ -0,0 +1,111 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseFirmwareSource(t *testing.T) {
+	src, err := ParseFirmwareSource("TFTP://10.0.0.5:6969/fw/pa4d.dnt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src != (FirmwareSource{Protocol: "tftp", Server: "10.0.0.5", Port: 6969, Path: "/fw/pa4d.dnt"}) {
+		t.Fatalf("source = %+v", src)
+	}
+	if got := src.String(); got != "tftp://10.0.0.5:6969/fw/pa4d.dnt" {
+		t.Errorf("String() = %q", got)
+	}
+
+	for _, bad := range []string{
+		"ftp://10.0.0.5/fw.dnt",
+		"http://fw.example.com/fw.dnt",
+		"http://[fe80::1]/fw.dnt",
+		"https://10.0.0.5",
+		"tftp://10.0.0.5:99999/fw.dnt",
+		"10.0.0.5/fw.dnt",
+	} {
+		if _, err := ParseFirmwareSource(bad); !errors.Is(err, ErrInvalidFirmwareSource) {
+			t.Errorf("ParseFirmwareSource(%q) err = %v, want ErrInvalidFirmwareSource", bad, err)
+		}
+	}
+}
+
+func TestFirmwareUpgradeLifecycle(t *testing.T) {
+	cfg := &SimulationConfig{Interface: "sim0", Devices: []SimulatedDevice{
+		{Name: "amp", Model: "PA-4D", IPAddress: "10.1.0.11", TxChannels: 2, RxChannels: 2},
+		{Name: "stagebox", Model: "Ultimo X4", IPAddress: "10.1.0.12", TxChannels: 4, RxChannels: 4},
+		{Name: "managed", Model: "DL32", IPAddress: "10.1.0.13", TxChannels: 2, RxChannels: 2, DDMDomain: "Venue-A"},
+	}}
+	d := NewSimulatedDomain("Dante1", cfg.NetworkConfig(), NewSimulatedSDK(cfg))
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+
+	src := FirmwareSource{Protocol: "tftp", Server: "10.1.0.1", Path: "/pa4d.dnt"}
+	if err := d.StartFirmwareUpgrade(ctx, "amp", src); err == nil {
+		t.Fatal("upgrade started without ConMon")
+	}
+	if err := d.StartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.UpgradeStatus("amp"); ok {
+		t.Fatal("status before any upgrade")
+	}
+	if err := d.StartFirmwareUpgrade(ctx, "amp", FirmwareSource{Protocol: "tftp", Server: "fw-server", Path: "/pa4d.dnt"}); !errors.Is(err, ErrInvalidFirmwareSource) {
+		t.Errorf("host name accepted: %v", err)
+	}
+	if err := d.StartFirmwareUpgrade(ctx, "missing", src); err == nil {
+		t.Error("upgrade of a missing device accepted")
+	}
+
+	if err := d.StartFirmwareUpgrade(ctx, "amp", src); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.StartFirmwareUpgrade(ctx, "amp", src); err == nil {
+		t.Error("second upgrade accepted while the first is in progress")
+	}
+	var states []string
+	for i := 0; i < 10; i++ {
+		status, ok := d.UpgradeStatus("amp")
+		if !ok {
+			t.Fatal("no upgrade status")
+		}
+		if len(states) == 0 || states[len(states)-1] != status.State {
+			states = append(states, status.State)
+		}
+		if status.Finished() {
+			if status.State != UpgradeDone || status.Percent() != 100 {
+				t.Errorf("finished with %+v", status)
+			}
+			break
+		}
+	}
+	if want := []string{UpgradeDownloading, UpgradeWriting, UpgradeDone}; len(states) != len(want) ||
+		states[0] != want[0] || states[1] != want[1] || states[2] != want[2] {
+		t.Errorf("states = %v, want %v", states, want)
+	}
+
+	// 設備檢查檔案內容
+	if err := d.StartFirmwareUpgrade(ctx, "stagebox", FirmwareSource{Protocol: "http", Server: "10.1.0.1", Path: "/x4.bin"}); err != nil {
+		t.Fatal(err)
+	}
+	var status UpgradeStatus
+	for i := 0; i < 10 && !status.Finished(); i++ {
+		status, _ = d.UpgradeStatus("stagebox")
+	}
+	if status.State != UpgradeFailed || status.Error == "" {
+		t.Errorf("malformed file: %+v", status)
+	}
+
+	// 已註冊 DDM 網域的設備事先拒絕
+	if _, err := d.CheckEnrollment(ctx, "managed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.StartFirmwareUpgrade(ctx, "managed", src); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("enrolled device: err = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,131 @@
+package dante
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//==============================================================================
+// SDK 呼叫健康狀態 (watchdog)
+//==============================================================================
+
+// 背景事件處理 (dante_process_events_briefly) 與設備刷新是網域的心跳：
+// 每次呼叫記錄開始時間、成功時間與連續失敗次數。SDK 卡住時呼叫不會返回
+// (其他呼叫也會在 nativeThread 排隊)，連續回傳錯誤時網域看起來仍在運行但什麼都
+// 掃描不到；Health.Check 把兩種情況轉成 StallError，交給呼叫端重新初始化。
+
+// SDK 呼叫種類
+const (
+	CallProcessEvents = "process_events"
+	CallRefresh       = "refresh"
+)
+
+// ErrStalled SDK 呼叫卡住或持續失敗 (errors.Is 判斷 StallError)
+var ErrStalled = errors.New("SDK stalled")
+
+// CallHealth 單一種類呼叫的狀態
+type CallHealth struct {
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	Failures    int       `json:"failures"`             // 連續失敗次數
+	LastError   string    `json:"last_error,omitempty"` // 最後一次失敗的 SDK 錯誤
+	Pending     time.Time `json:"pending,omitempty"`    // 進行中的呼叫開始時間 (零值表示沒有)
+}
+
+// Health 網域 SDK 呼叫的健康狀態
+type Health struct {
+	Events  CallHealth `json:"events"`
+	Refresh CallHealth `json:"refresh"`
+}
+
+// WatchdogConfig 判定卡住的條件
+type WatchdogConfig struct {
+	Hang     time.Duration // 呼叫超過此時間未返回視為卡住 (0 表示不檢查)
+	Failures int           // 連續失敗達此次數視為卡住 (0 表示不檢查)
+}
+
+// DefaultWatchdog 預設條件
+func DefaultWatchdog() WatchdogConfig {
+	return WatchdogConfig{Hang: 30 * time.Second, Failures: 10}
+}
+
+// Enabled 是否有任何檢查
+func (c WatchdogConfig) Enabled() bool {
+	return c.Hang > 0 || c.Failures > 0
+}
+
+// StallError Check 偵測到的問題
+type StallError struct {
+	Call      string        // 呼叫種類 (CallProcessEvents, CallRefresh)
+	Hung      bool          // 呼叫未返回 (否則為連續失敗)
+	Duration  time.Duration // 卡住的時間
+	Failures  int           // 連續失敗次數
+	LastError string
+}
+
+func (e *StallError) Error() string {
+	if e.Hung {
+		return fmt.Sprintf("%s: %s call has not returned for %s", ErrStalled, e.Call, e.Duration.Round(time.Second))
+	}
+	msg := fmt.Sprintf("%s: %s failed %d times in a row", ErrStalled, e.Call, e.Failures)
+	if e.LastError != "" {
+		msg += ": " + e.LastError
+	}
+	return msg
+}
+
+func (e *StallError) Unwrap() error { return ErrStalled }
+
+// Check 依條件檢查，正常時回傳 nil
+func (h Health) Check(cfg WatchdogConfig, now time.Time) error {
+	for _, c := range []struct {
+		name string
+		call CallHealth
+	}{{CallProcessEvents, h.Events}, {CallRefresh, h.Refresh}} {
+		if cfg.Hang > 0 && !c.call.Pending.IsZero() && now.Sub(c.call.Pending) > cfg.Hang {
+			return &StallError{Call: c.name, Hung: true, Duration: now.Sub(c.call.Pending)}
+		}
+		if cfg.Failures > 0 && c.call.Failures >= cfg.Failures {
+			return &StallError{Call: c.name, Failures: c.call.Failures, LastError: c.call.LastError}
+		}
+	}
+	return nil
+}
+
+// Health 目前的健康狀態
+func (d *Domain) Health() Health {
+	d.healthMu.Lock()
+	defer d.healthMu.Unlock()
+	return d.health
+}
+
+// callHealth 呼叫種類對應的狀態 (必須持有 healthMu)
+func (d *Domain) callHealth(call string) *CallHealth {
+	if call == CallRefresh {
+		return &d.health.Refresh
+	}
+	return &d.health.Events
+}
+
+// beginCall 記錄呼叫開始
+func (d *Domain) beginCall(call string) {
+	d.healthMu.Lock()
+	d.callHealth(call).Pending = time.Now()
+	d.healthMu.Unlock()
+}
+
+// endCall 記錄呼叫結果 (failed 表示 SDK 回傳錯誤)
+func (d *Domain) endCall(call string, failed bool, errMsg string) {
+	d.healthMu.Lock()
+	defer d.healthMu.Unlock()
+	c := d.callHealth(call)
+	c.Pending = time.Time{}
+	if failed {
+		c.Failures++
+		c.LastError = errMsg
+		return
+	}
+	c.LastSuccess = time.Now()
+	c.Failures = 0
+	c.LastError = ""
+}
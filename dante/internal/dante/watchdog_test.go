@@ -0,0 +1,54 @@
+package dante
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"danteCS/internal/backoff"
+)
+
+func TestHealthCheck(t *testing.T) {
+	now := time.Now()
+	cfg := WatchdogConfig{Hang: 30 * time.Second, Failures: 3}
+
+	if err := (Health{Events: CallHealth{Pending: now.Add(-time.Second)}}).Check(cfg, now); err != nil {
+		t.Errorf("call in progress reported: %v", err)
+	}
+	err := (Health{Events: CallHealth{Pending: now.Add(-time.Minute)}}).Check(cfg, now)
+	var stalled *StallError
+	if !errors.As(err, &stalled) || !stalled.Hung || stalled.Call != CallProcessEvents || !errors.Is(err, ErrStalled) {
+		t.Errorf("hung call: %v", err)
+	}
+	err = (Health{Refresh: CallHealth{Failures: 3, LastError: "socket closed"}}).Check(cfg, now)
+	if !errors.As(err, &stalled) || stalled.Hung || stalled.Call != CallRefresh {
+		t.Errorf("failing refresh: %v", err)
+	}
+	if err := (Health{Refresh: CallHealth{Failures: 3}}).Check(WatchdogConfig{}, now); err != nil {
+		t.Errorf("disabled watchdog: %v", err)
+	}
+}
+
+func TestRefreshFailuresTracked(t *testing.T) {
+	sim := newSimulatedSDK()
+	d := NewSimulatedDomain("Dante1", NetworkConfig{InterfaceName: "sim0"}, sim)
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Cleanup()
+	d.CallRetry = backoff.Policy{MaxAttempts: 1} // 每次刷新只計一次失敗
+
+	sim.RefreshError = "socket closed"
+	d.RefreshDevices(context.Background())
+	d.RefreshDevices(context.Background())
+	if h := d.Health().Refresh; h.Failures != 2 || h.LastError != "socket closed" || !h.Pending.IsZero() {
+		t.Fatalf("after failures: %+v", h)
+	}
+
+	sim.RefreshError = ""
+	d.RefreshDevices(context.Background())
+	if h := d.Health().Refresh; h.Failures != 0 || h.LastSuccess.IsZero() {
+		t.Errorf("after recovery: %+v", h)
+	}
+}
@@ -0,0 +1,150 @@
+// Package i18n 輸出語言 (英文與繁體中文)
+package i18n
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+//==============================================================================
+// 語言
+//==============================================================================
+
+// 訊息以英文原文作為鍵 (與 gettext 相同)：程式碼中照常寫英文，翻譯表沒有
+// 收錄的訊息直接輸出英文，新增訊息不會因為漏翻而壞掉。只翻譯給人看的文字：
+// 日誌訊息、表頭與標題；設備名稱、狀態值、錯誤內容與 JSON 欄位維持原樣，
+// 腳本與日誌收集不受語言設定影響。
+
+// Lang 輸出語言 (BCP 47 標籤)
+type Lang string
+
+// 支援的語言
+const (
+	EN   Lang = "en"
+	ZhTW Lang = "zh-TW"
+)
+
+// Supported 支援的語言
+var Supported = []Lang{EN, ZhTW}
+
+// catalogs 各語言的翻譯表 (英文不需要)
+var catalogs = map[Lang]map[string]string{
+	ZhTW: zhTW,
+}
+
+// Parse 解析語言標籤或 locale (zh-TW、zh_TW.UTF-8、zh-Hant、en_US 等)
+func Parse(s string) (Lang, error) {
+	tag := strings.ToLower(strings.TrimSpace(s))
+	tag, _, _ = strings.Cut(tag, ".") // 編碼 (.UTF-8)
+	tag, _, _ = strings.Cut(tag, "@") // 修飾 (@euro)
+	tag = strings.ReplaceAll(tag, "_", "-")
+	switch {
+	case tag == "", tag == "c", tag == "posix", tag == "en", strings.HasPrefix(tag, "en-"):
+		return EN, nil
+	case tag == "zh", tag == "zh-tw", tag == "zh-hk", tag == "zh-mo", strings.HasPrefix(tag, "zh-hant"):
+		return ZhTW, nil
+	}
+	return EN, fmt.Errorf("unsupported language %q (en, zh-TW)", s)
+}
+
+// FromEnv 由環境變數決定預設語言：GOLANE_LANG 優先，其次是 LC_ALL、
+// LC_MESSAGES、LANG；都沒有設定或不支援時使用英文
+func FromEnv() Lang {
+	for _, key := range []string{"GOLANE_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			lang, err := Parse(v)
+			if err != nil {
+				return EN
+			}
+			return lang
+		}
+	}
+	return EN
+}
+
+var current atomic.Value // Lang
+
+// Set 設定目前的輸出語言
+func Set(lang Lang) {
+	current.Store(lang)
+}
+
+// Current 目前的輸出語言
+func Current() Lang {
+	if lang, ok := current.Load().(Lang); ok {
+		return lang
+	}
+	return EN
+}
+
+//==============================================================================
+// 翻譯
+//==============================================================================
+
+// T 以目前的語言翻譯訊息
+func T(msg string) string {
+	return In(Current(), msg)
+}
+
+// In 以指定的語言翻譯訊息 (沒有翻譯時回傳原文)
+func In(lang Lang, msg string) string {
+	if text, ok := catalogs[lang][msg]; ok {
+		return text
+	}
+	return msg
+}
+
+// Sprintf 翻譯格式字串後格式化 (翻譯必須保留相同的 % 動詞與順序)
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Messages 指定語言的完整翻譯表 (給網頁介面使用，英文回傳空白的表)
+func Messages(lang Lang) map[string]string {
+	if catalog, ok := catalogs[lang]; ok {
+		return maps.Clone(catalog)
+	}
+	return map[string]string{}
+}
+
+//==============================================================================
+// 顯示寬度
+//==============================================================================
+
+// 表格以空白對齊，fmt 的 %-10s 以字元數補空白，但中文字在終端機佔兩格，
+// 翻譯後的表頭要以顯示寬度補齊才不會歪掉。
+
+// Width 字串在終端機的顯示寬度 (東亞全形字元佔兩格)
+func Width(s string) int {
+	n := 0
+	for _, r := range s {
+		if wide(r) {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// Pad 在右邊補空白到指定的顯示寬度
+func Pad(s string, width int) string {
+	if w := Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// wide 是否為全形字元 (CJK 文字、注音、全形標點與韓文)
+func wide(r rune) bool {
+	return r >= 0x1100 && r <= 0x115F ||
+		r >= 0x2E80 && r <= 0xA4CF && r != 0x303F ||
+		r >= 0xAC00 && r <= 0xD7A3 ||
+		r >= 0xF900 && r <= 0xFAFF ||
+		r >= 0xFE30 && r <= 0xFE4F ||
+		r >= 0xFF00 && r <= 0xFF60 ||
+		r >= 0xFFE0 && r <= 0xFFE6
+}
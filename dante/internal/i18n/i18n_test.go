@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]Lang{
+		"":            EN,
+		"en":          EN,
+		"en_US.UTF-8": EN,
+		"C":           EN,
+		"zh-TW":       ZhTW,
+		"zh_TW.UTF-8": ZhTW,
+		"zh-Hant-TW":  ZhTW,
+	} {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := Parse("fr"); err == nil {
+		t.Error("Parse(fr) should fail")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("GOLANE_LANG", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "zh_TW.UTF-8")
+	if got := FromEnv(); got != ZhTW {
+		t.Errorf("LANG=zh_TW: %q", got)
+	}
+	t.Setenv("GOLANE_LANG", "en")
+	if got := FromEnv(); got != EN {
+		t.Errorf("GOLANE_LANG=en overrides LANG: %q", got)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer Set(Current())
+
+	Set(EN)
+	if got := T("Shutting down"); got != "Shutting down" {
+		t.Errorf("en: %q", got)
+	}
+	Set(ZhTW)
+	if got := T("Shutting down"); got != "正在關閉" {
+		t.Errorf("zh-TW: %q", got)
+	}
+	if got := T("not in the catalog"); got != "not in the catalog" {
+		t.Errorf("missing translations should fall back to English: %q", got)
+	}
+	if got := Sprintf("%d devices", 3); got != "3 台設備" {
+		t.Errorf("Sprintf: %q", got)
+	}
+}
+
+// 翻譯必須保留原文的格式動詞，否則 Sprintf 的輸出會錯亂
+func TestCatalogVerbs(t *testing.T) {
+	verb := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for lang, catalog := range catalogs {
+		for msg, text := range catalog {
+			if want, got := verb.FindAllString(msg, -1), verb.FindAllString(text, -1); !slices.Equal(want, got) {
+				t.Errorf("%s %q: verbs %v, want %v", lang, text, got, want)
+			}
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	if w := Width("設備 ID"); w != 7 {
+		t.Errorf("Width = %d, want 7", w)
+	}
+	if got := Pad("網域", 6); got != "網域  " {
+		t.Errorf("Pad = %q", got)
+	}
+	if got := Pad("DOMAIN", 4); got != "DOMAIN" {
+		t.Errorf("Pad should not truncate: %q", got)
+	}
+}
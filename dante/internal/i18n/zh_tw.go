@@ -0,0 +1,426 @@
+package i18n
+
+// zhTW 繁體中文翻譯表 (英文原文 → 翻譯，格式字串要保留相同的 % 動詞)
+var zhTW = map[string]string{
+	//--------------------------------------------------------------------------
+	// 啟動與網路介面
+	//--------------------------------------------------------------------------
+	"Step 1: Network interface detection":                                    "步驟 1：偵測網路介面",
+	"Step 2: Configure Dante interface":                                      "步驟 2：設定 Dante 介面",
+	"Step 3: Initializing Dante API":                                         "步驟 3：初始化 Dante API",
+	"Step 4: Starting device scan":                                           "步驟 4：開始掃描設備",
+	"System ready. Press Ctrl+C to exit":                                     "系統就緒，按 Ctrl+C 結束",
+	"Shutting down":                                                          "正在關閉",
+	"Simulation mode, using synthetic devices":                               "模擬模式，使用模擬設備",
+	"Detecting network interfaces":                                           "偵測網路介面",
+	"Found interface":                                                        "找到介面",
+	"Identifying Dante interfaces":                                           "辨識 Dante 介面",
+	"Dante interface found":                                                  "找到 Dante 介面",
+	"No Dante interfaces found":                                              "找不到 Dante 介面",
+	"Using Dante interface":                                                  "使用 Dante 介面",
+	"Dante interface not ready, will keep retrying":                          "Dante 介面尚未就緒，會持續重試",
+	"Interface address changed":                                              "介面地址已變更",
+	"Created VLAN interface":                                                 "已建立 VLAN 介面",
+	"Checking network isolation":                                             "檢查網路隔離",
+	"Dante networks are properly isolated":                                   "Dante 網路已正確隔離",
+	"Dante interface shares a network segment with the management interface": "Dante 介面與管理介面位於同一網段",
+	"Audio multicast will leak onto the management network and office traffic onto the Dante network; keep the management network on its own subnet": "音訊多播會流入管理網路，辦公網路流量也會進入 Dante 網路；請將管理網路放在獨立的子網路",
+	"Management interface found":               "找到管理介面",
+	"Dante interfaces share a network segment": "Dante 介面位於同一網段",
+	"Shared segments may cause broadcast storms and interference; use different networks (e.g. 10.1.0.x and 10.2.0.x)": "共用網段可能造成廣播風暴與干擾，請使用不同的網路 (例如 10.1.0.x 與 10.2.0.x)",
+	"Dante interface is not on 169.254/16; add an alias (or start with -linklocal-alias) to reach these devices":       "Dante 介面不在 169.254/16，請加上別名 (或以 -linklocal-alias 啟動) 才能連到這些設備",
+	"Enable DHCP on this network or assign static addresses in Dante Controller so devices leave the Auto-IP range":    "請在這個網路啟用 DHCP，或在 Dante Controller 指定固定地址，讓設備離開 Auto-IP 範圍",
+	"Device on link-local address":   "設備使用 link-local 地址",
+	"Link-local alias added":         "已加上 link-local 別名",
+	"Failed to add link-local alias": "無法加上 link-local 別名",
+
+	"Available Network Interfaces:":    "可用的網路介面：",
+	"Suggested Network Configuration:": "建議的網路配置：",
+	"⚠️  Warning: Only %d interfaces are UP with IP. RTD1619B requires 3 interfaces.\n": "⚠️  警告：只有 %d 個介面啟用且有 IP，RTD1619B 需要 3 個介面。\n",
+	"Recommended setup:":                                                     "建議配置：",
+	"Management (Telnet) - External network":                                 "管理 (Telnet) - 外部網路",
+	"Dante Domain %d - Audio network %d":                                     "Dante 網域 %d - 音訊網路 %d",
+	"Single NIC on a trunked switch port:":                                   "單一網卡接在 trunk 交換器埠：",
+	"Management (VLAN 20)":                                                   "管理 (VLAN 20)",
+	"Dante Domain 1 (VLAN 10)":                                               "Dante 網域 1 (VLAN 10)",
+	"Sufficient interfaces available":                                        "可用的介面足夠",
+	"Suggested assignment:":                                                  "建議分配：",
+	"Management (Telnet)":                                                    "管理 (Telnet)",
+	"Fallback for missing %s (%s)":                                           "%s 不存在，使用備用介面 (%s)",
+	"Configured Dante interface not found, using fallback":                   "找不到指定的 Dante 介面，使用備用介面",
+	"No fallback Dante interface matches":                                    "沒有符合的備用 Dante 介面",
+	"udev rules for the NIC names are not installed, run golane udev -write": "尚未安裝網卡名稱的 udev 規則，請執行 golane udev -write",
+	"Failed to read the udev rules":                                          "無法讀取 udev 規則",
+	"udev rules differ from nic_names in the config, run golane udev -write": "udev 規則與設定檔的 nic_names 不同，請執行 golane udev -write",
+	"NIC not renamed yet, re-plug it or reboot to apply the udev rules":      "網卡尚未改名，請重新插拔或重新開機以套用 udev 規則",
+	"Status LED unavailable":                                                 "無法使用狀態 LED",
+	"Status LED enabled":                                                     "已啟用狀態 LED",
+	"Status LED changed":                                                     "狀態 LED 已變更",
+	"Front panel display unavailable":                                        "無法使用前面板顯示器",
+	"Front panel display enabled":                                            "已啟用前面板顯示器",
+	"Failed to open the serial control port, retrying":                       "無法開啟序列埠控制，稍後重試",
+	"Serial control listening":                                               "序列埠控制已啟動",
+	"Serial control port closed, reopening":                                  "序列埠控制已中斷，重新開啟",
+	"Profiling routes unavailable until an admin API token is configured":    "設定 admin API 權杖前無法使用效能分析路由",
+	"TCP control listening":                                                  "TCP 控制已開始監聽",
+	"TCP control stopped accepting connections":                              "TCP 控制停止接受連線",
+	"TCP control connection refused":                                         "拒絕 TCP 控制連線",
+	"TCP control client connected":                                           "TCP 控制用戶端已連線",
+	"TCP control client disconnected":                                        "TCP 控制用戶端已中斷",
+	"TCP control client too slow, feedback dropped":                          "TCP 控制用戶端太慢，已丟棄回饋",
+	"Console route change":                                                   "控制台變更路由",
+	"Front panel update failed":                                              "前面板顯示器更新失敗",
+	"Dante primary network":                                                  "Dante 主要網路",
+	"Dante secondary network":                                                "Dante 備援網路",
+	"Pin this assignment in the config file:":                                "在設定檔中固定這個分配：",
+	"Interface of the configured role not found":                             "找不到設定角色的介面",
+	"Interface of the configured role is down or has no IP address":          "設定角色的介面未啟用或沒有 IP 地址",
+	"Selected Dante Configuration:":                                          "選定的 Dante 配置：",
+	"Interface:":                                                             "介面：",
+	"Enabled:":                                                               "啟用：",
+	"   Version: %s\n":                                                       "   版本：%s\n",
+	"   Instance: %s\n":                                                      "   實例：%s\n",
+	"   Mode:    SIMULATION":                                                 "   模式：模擬",
+
+	//--------------------------------------------------------------------------
+	// Dante 網域與設備
+	//--------------------------------------------------------------------------
+	"Initializing Dante domain":                    "初始化 Dante 網域",
+	"Dante API initialized":                        "Dante API 已初始化",
+	"Dante domain ready for network scanning":      "Dante 網域已可掃描網路",
+	"Cleaning up Dante domain":                     "清理 Dante 網域",
+	"Initialization failed, retrying":              "初始化失敗，重試中",
+	"SDK call failed, retrying":                    "SDK 呼叫失敗，重試中",
+	"Device connection opened":                     "已開啟設備連線",
+	"Device connection closed":                     "已關閉設備連線",
+	"Domain failed, restarting":                    "網域失敗，重新啟動",
+	"Domain failed permanently, not restarting":    "網域持續失敗，不再重新啟動",
+	"Watchdog: SDK stalled, reinitializing domain": "看門狗：SDK 停止回應，重新初始化網域",
+	"Watchdog: SDK call did not return, exiting for the service manager to restart": "看門狗：SDK 呼叫沒有返回，結束程式讓服務管理員重新啟動",
+	"ConMon monitoring started":                            "ConMon 監控已啟動",
+	"Starting device scan":                                 "開始掃描設備",
+	"Device scan started":                                  "設備掃描已啟動",
+	"Device scan failed":                                   "設備掃描失敗",
+	"Device scan refresh failed":                           "設備掃描更新失敗",
+	"Device count failed":                                  "無法取得設備數量",
+	"Refreshing device list":                               "更新設備列表",
+	"Device list refreshed":                                "設備列表已更新",
+	"SDK output":                                           "SDK 輸出",
+	"Failed to restore standard output":                    "無法還原標準輸出",
+	"Device change reported, refresh scheduled":            "設備回報變更，已排程更新",
+	"Device cannot be configured by this controller":       "這個控制器無法設定此設備",
+	"Device reservation failed":                            "設備保留失敗",
+	"Serving cached device list until discovery completes": "發現完成前先提供快取的設備列表",
+	"Failed to save device cache":                          "無法儲存設備快取",
+	"Identify sent":                                        "已送出識別",
+	"Firmware upgrade requested":                           "已送出韌體升級請求",
+	"Firmware upgrade state changed":                       "韌體升級狀態改變",
+	"Firmware upgrade failed":                              "韌體升級失敗",
+	"Firmware upgrade tracking timed out":                  "韌體升級追蹤逾時",
+	"Subscription set":                                     "已設定訂閱",
+	"Subscription removed":                                 "已移除訂閱",
+	"Sample rates cannot be restored":                      "無法還原取樣率",
+	"Routing to quarantined device":                        "路由到隔離中的設備",
+	"Device quarantined":                                   "設備已隔離",
+	"Device released from quarantine":                      "設備已解除隔離",
+	"Device icon uploaded":                                 "設備圖示已上傳",
+	"Address plan violation":                               "違反地址規劃",
+	"Address plan exported":                                "地址規劃已匯出",
+	"DHCP config written":                                  "DHCP 設定已寫入",
+	"DHCP config seeded from address plan":                 "已由地址規劃產生 DHCP 設定",
+	"Failed to write DHCP config":                          "無法寫入 DHCP 設定",
+	"Enrollment check failed":                              "註冊狀態檢查失敗",
+
+	//--------------------------------------------------------------------------
+	// 時鐘、串流與網路診斷
+	//--------------------------------------------------------------------------
+	"Watching clocks":                                                 "監看時鐘",
+	"Clock query failed":                                              "時鐘查詢失敗",
+	"Audio packet errors on RX flows":                                 "接收 flow 出現音訊封包錯誤",
+	"Flow statistics query failed":                                    "flow 統計查詢失敗",
+	"Reading flow statistics":                                         "讀取 flow 統計",
+	"\n=== RX flow packet errors (recent within %s) ===\n":            "\n=== 接收 flow 封包錯誤 (最近 %s 內) ===\n",
+	"(no flows)":                                                      "(沒有 flow)",
+	"Clock status and identify unavailable":                           "無法使用時鐘狀態與識別",
+	"Device lost clock sync":                                          "設備失去時鐘同步",
+	"PTP grandmaster changed":                                         "PTP grandmaster 已變更",
+	"Listening for AES67 announcements":                               "監聽 AES67 公告",
+	"AES67 discovery unavailable":                                     "無法使用 AES67 發現",
+	"Dante discovery unavailable, streams listed without devices":     "無法使用 Dante 發現，串流列表不含設備",
+	"Waiting less than the announce interval, streams may be missing": "等待時間短於公告間隔，可能缺少部分串流",
+	"Ignored SAP packet":                                              "忽略 SAP 封包",
+	"Listening for IGMP queries":                                      "監聽 IGMP 查詢",
+	"IGMP querier heard":                                              "收到 IGMP querier",
+	"IGMP group memberships unavailable":                              "無法取得 IGMP 群組成員",
+	"IGMP querier detection unavailable":                              "無法偵測 IGMP querier",
+	"IGMP querier detection unavailable, listing groups only":         "無法偵測 IGMP querier，只列出群組",
+	"Waiting less than the query interval, a querier may be missed":   "等待時間短於查詢間隔，可能漏掉 querier",
+	"Listening for LLDP":                                              "監聽 LLDP",
+	"LLDP neighbor heard":                                             "收到 LLDP 鄰居",
+	"LLDP neighbor discovery unavailable":                             "無法收聽 LLDP 鄰居",
+	"Polling switches for the port map":                               "輪詢交換器以對應設備埠",
+	"Switch poll failed":                                              "交換器輪詢失敗",
+	"Switch polled":                                                   "交換器輪詢完成",
+	" (+%d MACs)":                                                     " (另有 %d 個 MAC)",
+	"  - %s (%s): not polled yet\n":                                   "  - %s (%s)：尚未輪詢\n",
+	"  - %s (%s): %d MACs, polled %s\n":                               "  - %s (%s)：%d 個 MAC，輪詢於 %s\n",
+	"Ignored LLDP frame":                                              "忽略 LLDP frame",
+	"Switch port: %s":                                                 "交換器埠：%s",
+	"Ignored IGMP packet":                                             "忽略 IGMP 封包",
+	"Multicast problem":                                               "多播問題",
+	"QoS sampling unavailable":                                        "無法取樣 QoS",
+	"Capturing":                                                       "擷取中",
+	"Bandwidth estimate incomplete":                                   "頻寬估算不完整",
+	"Firmware inventory incomplete":                                   "韌體清單不完整",
+	"Latency measurement incomplete":                                  "延遲量測不完整",
+	"Device discovery unavailable, listing addresses only":            "無法使用設備發現，只列出地址",
+
+	//--------------------------------------------------------------------------
+	// 管理 API、告警與外部整合
+	//--------------------------------------------------------------------------
+	"API server listening":                    "API 伺服器已開始監聽",
+	"API server stopped":                      "API 伺服器已停止",
+	"Management API disabled by feature flag": "管理 API 已由功能開關停用",
+	"Management API has no token, anyone on the management network can control routing": "管理 API 沒有設定權杖，管理網路上的任何人都能控制路由",
+	"Failed to reload issued API tokens":                                                "無法重新載入發行的 API 權杖",
+	"Ignoring malformed issued API token":                                               "忽略格式錯誤的 API 權杖",
+	"Generated self-signed TLS certificate":                                             "已產生自簽 TLS 憑證",
+	"TLS enabled":                                                                       "TLS 已啟用",
+	"TLS certificate has expired":                                                       "TLS 憑證已過期",
+	"Host overloaded, shedding low-priority API requests":                               "主機負載過高，暫停低優先權的 API 請求",
+	"Broadcast/multicast storm detected":                                                "偵測到廣播/多播風暴",
+	"Broadcast/multicast storm ended":                                                   "廣播/多播風暴已結束",
+	"Refresh paused during network storm":                                               "網路風暴期間暫停刷新",
+	"Host load recovered, serving all API requests":                                     "主機負載已恢復，處理所有 API 請求",
+	"CPU usage unavailable, shedding on scheduling latency only":                        "無法取得 CPU 使用率，只依排程延遲調節",
+	"Features disabled":                                                                 "已停用的功能",
+	"Feature changed":                                                                   "功能已變更",
+	"Alarm raised":                                                                      "告警發生",
+	"Alarm cleared":                                                                     "告警解除",
+	"Alert notification failed":                                                         "告警通知失敗",
+	"Alert notification failed, retrying":                                               "告警通知失敗，重試中",
+	"Alert notification queue full, dropped":                                            "告警通知佇列已滿，已丟棄",
+	"Incident opened":                                                                   "事件單已開立",
+	"Incident resolved":                                                                 "事件單已解決",
+	"Failed to save incidents":                                                          "無法儲存事件單",
+	"Failed to write audit log":                                                         "無法寫入稽核日誌",
+	"Webhooks enabled":                                                                  "Webhook 已啟用",
+	"Webhook delivery failed":                                                           "Webhook 傳送失敗",
+	"Webhook delivery failed, retrying":                                                 "Webhook 傳送失敗，重試中",
+	"Webhook queue full, event dropped":                                                 "Webhook 佇列已滿，已丟棄事件",
+	"Event hooks enabled":                                                               "事件 hook 已啟用",
+	"Hook failed":                                                                       "Hook 執行失敗",
+	"Hook finished":                                                                     "Hook 執行完成",
+	"Hook queue full, event dropped":                                                    "Hook 佇列已滿，已丟棄事件",
+	"Output sinks enabled":                                                              "輸出 sink 已啟用",
+	"Sink write failed":                                                                 "Sink 寫入失敗",
+	"SNMP agent listening":                                                              "SNMP agent 已開始監聽",
+	"SNMP read failed":                                                                  "SNMP 讀取失敗",
+	"SNMP response failed":                                                              "SNMP 回應失敗",
+	"SNMP trap failed":                                                                  "SNMP trap 傳送失敗",
+	"GPIO trigger input watching":                                                       "監看 GPIO 觸發輸入",
+	"GPIO trigger input unavailable (export the pin first)":                             "無法使用 GPIO 觸發輸入 (請先 export 腳位)",
+	"GPIO trigger failed":                                                               "GPIO 觸發失敗",
+	"OSC trigger input listening":                                                       "OSC 觸發輸入已開始監聽",
+	"OSC trigger input stopped":                                                         "OSC 觸發輸入已停止",
+	"OSC message ignored":                                                               "忽略 OSC 訊息",
+	"Ignoring invalid OSC packet":                                                       "忽略無效的 OSC 封包",
+	"Preset recalled":                                                                   "預設已載入",
+	"Preset partially recalled":                                                         "預設部分載入",
+	"Preset not recalled, endpoints missing":                                            "預設未載入，缺少端點",
+	"Routing schedules loaded":                                                          "已載入路由排程",
+	"Scheduled runs missed":                                                             "錯過排程執行",
+	"Scheduled preset skipped, schedules disabled":                                      "排程已停用，略過排程的預設",
+	"Scheduled preset failed":                                                           "排程的預設載入失敗",
+	"Failed to save schedule history":                                                   "無法保存排程歷史",
+	"Tracing enabled":                                                                   "追蹤已啟用",
+	"Trace export failed":                                                               "追蹤匯出失敗",
+	"Trace queue full, spans dropped":                                                   "追蹤佇列已滿，已丟棄 span",
+	"Recovered from panic":                                                              "已從 panic 復原",
+	"Panic listener failed":                                                             "panic 監聽器失敗",
+	"Restarting after panic":                                                            "panic 後重新執行",
+	"State migrated":                                                                    "狀態已遷移",
+	"State upgraded":                                                                    "狀態已升級",
+	"Command failed":                                                                    "命令失敗",
+
+	//--------------------------------------------------------------------------
+	// 多實例
+	//--------------------------------------------------------------------------
+	"Instance started":             "實例已啟動",
+	"Instance stopped":             "實例已停止",
+	"Instance exited, restarting":  "實例已結束，重新啟動",
+	"Instance not started":         "實例未啟動",
+	"Instance not stopped cleanly": "實例未正常停止",
+	"Failed to prepare instance":   "無法準備實例",
+	"Failed to start instance":     "無法啟動實例",
+	"Stopping all instances":       "停止所有實例",
+
+	//--------------------------------------------------------------------------
+	// CLI 表格
+	//--------------------------------------------------------------------------
+	"\n=== %s Device List ===\n":                                      "\n=== %s 設備列表 ===\n",
+	"Interface: %s (%s)\n":                                            "介面：%s (%s)\n",
+	"Total Devices: %d\n":                                             "設備總數：%d\n",
+	"    ! read-only: %s\n":                                           "    ! 唯讀：%s\n",
+	"\n=== %s RX Channels ===\n":                                      "\n=== %s 接收通道 ===\n",
+	"\n%d subscriptions without audio:\n":                             "\n%d 個訂閱沒有聲音：\n",
+	"\n=== %s TX Flows ===\n":                                         "\n=== %s 發送 Flow ===\n",
+	"\n=== AES67 Streams ===\n":                                       "\n=== AES67 串流 ===\n",
+	"Total Streams: %d\n":                                             "串流總數：%d\n",
+	"  ! not AES67 compatible: %s\n":                                  "  ! 不相容 AES67：%s\n",
+	"\n=== Active alarms (%d) ===\n":                                  "\n=== 進行中的告警 (%d) ===\n",
+	"\n=== Recently cleared ===\n":                                    "\n=== 最近解除 ===\n",
+	"\n=== Schedules (%d) ===\n":                                      "\n=== 排程 (%d) ===\n",
+	"\n=== Recent runs (%d) ===\n":                                    "\n=== 最近的執行 (%d) ===\n",
+	"Schedules are disabled, scheduled presets are skipped":           "排程已停用，排程的預設會被略過",
+	"\n=== Estimated bandwidth (%d Hz, %d bit, %.0f%% warning) ===\n": "\n=== 估算頻寬 (%d Hz，%d bit，%.0f%% 警告) ===\n",
+	"\n=== Firmware inventory (%d devices) ===\n":                     "\n=== 韌體清單 (%d 台設備) ===\n",
+	"Minimum version: %s\n":                                           "最低版本：%s\n",
+	"    ! below minimum version %s\n":                                "    ! 低於最低版本 %s\n",
+	"WARNING: %d devices below the minimum version\n":                 "警告：%d 台設備低於最低版本\n",
+	"Firmware upgrade of %s requested from %s\n":                      "已要求 %s 從 %s 升級韌體\n",
+	"Firmware of %s written, the device restarts\n":                   "%s 的韌體已寫入，設備將重新開機\n",
+	"\n=== Transit latency (%d samples, %.0f%% warning) ===\n":        "\n=== 傳輸延遲 (%d 次取樣，%.0f%% 警告) ===\n",
+	"\n=== Clock sync (losses within %s) ===\n":                       "\n=== 時鐘同步 (%s 內的失去同步次數) ===\n",
+	"%s grandmaster: %s":                                              "%s grandmaster：%s",
+	"  (changed %d times, last from %s at %s)":                        "  (變更 %d 次，最後一次從 %s，於 %s)",
+	"(none reported)":                                                 "(未回報)",
+	"\n=== %s DSCP (sampled %s) ===\n":                                "\n=== %s DSCP (取樣 %s) ===\n",
+	"\n=== Reachability ===\n":                                        "\n=== 可達性 ===\n",
+	"Groups:":                                                         "群組：",
+	"Queriers (listened %s):\n":                                       "Querier (監聽 %s)：\n",
+	"  none":                                                          "  無",
+	"  %-17s v%d  %d queries, last %s\n":                              "  %-17s v%d  %d 次查詢，最後一次 %s\n",
+	"\n⚠️  %s is %s":                                                  "\n⚠️  %s 狀態為 %s",
+	"WARNING: %s\n":                                                   "警告：%s\n",
+	"SKIPPED  %s/%s: %s\n":                                            "略過  %s/%s：%s\n",
+	"Would subscribe %s@%s to %s@%s\n":                                "將訂閱 %s@%s 到 %s@%s\n",
+	"Would remove the subscription of %s@%s\n":                        "將移除 %s@%s 的訂閱\n",
+	"Would rename %s to %s\n":                                         "將把 %s 重新命名為 %s\n",
+	"Would run: %s\n":                                                 "將執行：%s\n",
+	"All VLAN interfaces already exist":                               "所有 VLAN 子介面都已存在",
+	"Status:":                                                         "狀態：",
+	"Severity:":                                                       "嚴重性：",
+	"Opened:":                                                         "開立：",
+	"Acked:":                                                          "確認：",
+	"Resolved:":                                                       "解決：",
+	"%s by %s":                                                        "%s，由 %s",
+	"\nSubjects (%d):\n":                                              "\n對象 (%d)：\n",
+	"Timeline:":                                                       "時間軸：",
+	"No issued tokens":                                                "沒有發行的權杖",
+	"Version:":                                                        "版本：",
+	"Commit:":                                                         "Commit：",
+	"Built:":                                                          "建置時間：",
+	"Running since:":                                                  "執行自：",
+	"Features:":                                                       "功能：",
+	"Disabled:":                                                       "已停用：",
+	"unknown":                                                         "未知",
+	" (modified)":                                                     " (有未提交的變更)",
+
+	// 表頭
+	"ID":              "ID",
+	"Name":            "名稱",
+	"NAME":            "名稱",
+	"Model":           "型號",
+	"IP Address":      "IP 地址",
+	"MAC Address":     "MAC 地址",
+	"TX/RX":           "發送/接收",
+	"Dante Ver":       "Dante 版本",
+	"Source":          "來源",
+	"SOURCE":          "來源",
+	"Device":          "設備",
+	"DEVICE":          "設備",
+	"MAC ADDRESS":     "MAC 地址",
+	"SWITCH":          "交換器",
+	"PORT":            "埠",
+	"VLAN":            "VLAN",
+	"Multicast":       "多播",
+	"Format":          "格式",
+	"Ptime":           "封包時間",
+	"PTP clock":       "PTP 時鐘",
+	"TYPE":            "類型",
+	"DESTINATION":     "目的地",
+	"CHANNELS":        "通道",
+	"RX CHANNEL":      "接收通道",
+	"SUBSCRIPTION":    "訂閱",
+	"STATUS":          "狀態",
+	"SEVERITY":        "嚴重性",
+	"ALARM":           "告警",
+	"DOMAIN":          "網域",
+	"SUBJECT":         "對象",
+	"SINCE":           "開始時間",
+	"MESSAGE":         "訊息",
+	"TX CH/FLOW":      "發送通道/Flow",
+	"RX CH/FLOW":      "接收通道/Flow",
+	"LINKS":           "連線",
+	"MODEL":           "型號",
+	"DANTE":           "Dante",
+	"PRODUCT":         "產品",
+	"COUNT":           "數量",
+	"DEVICES":         "設備",
+	"CLOCK":           "時鐘",
+	"SERVO":           "伺服",
+	"SYNCED":          "同步",
+	"LOSSES":          "失去同步",
+	"ADDRESS":         "地址",
+	"RTT MIN/AVG/MAX": "RTT 最小/平均/最大",
+	"JITTER":          "抖動",
+	"LOSS":            "遺失",
+	"TRANSIT":         "傳輸",
+	"LATENCY":         "延遲",
+	"RISK":            "風險",
+	"CLASS":           "類別",
+	"EXPECTED":        "預期",
+	"PACKETS":         "封包",
+	"RECEIVED":        "收到",
+	"VERDICT":         "判定",
+	"PRIMARY":         "主要",
+	"SECONDARY":       "次要",
+	"ROLE":            "角色",
+	"CREATED":         "建立時間",
+	"HEALTH":          "健康",
+	"STATE":           "狀態分類",
+	"FLOW":            "Flow",
+	"DROPPED":         "遺失",
+	"LATE":            "太晚",
+	"EARLY":           "太早",
+	"ORDER":           "亂序",
+	"PRESET":          "預設",
+	"WHEN":            "時間",
+	"NEXT":            "下一次",
+	"TIME":            "時間",
+	"APPLIED":         "已套用",
+	"RESULT":          "結果",
+
+	//--------------------------------------------------------------------------
+	// 網頁介面
+	//--------------------------------------------------------------------------
+	"connecting…":                  "連線中…",
+	"disconnected":                 "已中斷",
+	"updated %s":                   "更新於 %s",
+	"redundant":                    "備援",
+	"primary only":                 "只有主要",
+	"secondary down":               "次要中斷",
+	"primary down":                 "主要中斷",
+	"down":                         "中斷",
+	"Quarantined":                  "隔離中",
+	"by %s":                        "由 %s",
+	"quarantined":                  "隔離中",
+	"duplicate name":               "名稱重複",
+	"read-only":                    "唯讀",
+	"last error: %s (%d restarts)": "最後錯誤：%s (重啟 %d 次)",
+	"Last known list from %s, waiting for discovery": "%s 的最後列表，等待發現",
+	"stale":                               "未確認",
+	"%d devices":                          "%d 台設備",
+	"No devices discovered":               "未發現設備",
+	"Primary IP":                          "主要 IP",
+	"Primary link":                        "主要連線",
+	"Secondary":                           "次要",
+	"Redundancy":                          "備援",
+	"AES67 streams":                       "AES67 串流",
+	"%d announced":                        "%d 個公告",
+	"not AES67":                           "非 AES67",
+	"Dante device":                        "Dante 設備",
+	"Packet time":                         "封包時間",
+	"This monitor requires an API token:": "這個監控需要 API 權杖：",
+}
@@ -0,0 +1,172 @@
+// Package igmp 檢查 Dante 介面的 multicast 群組與 IGMP querier
+package igmp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// 群組成員 (/proc/net/igmp)
+//==============================================================================
+
+// ProcPath Linux 列出各介面 IPv4 multicast 群組的檔案
+const ProcPath = "/proc/net/igmp"
+
+// Membership 介面加入的群組
+type Membership struct {
+	Interface string   `json:"interface"`
+	Version   string   `json:"version"` // 核心使用的 IGMP 版本 (聽到舊版 querier 時降級，例如 V2)
+	Groups    []string `json:"groups"`
+}
+
+// ParseProcIGMP 解析 /proc/net/igmp
+//
+//	Idx	Device    : Count Querier	Group    Users Timer	Reporter
+//	2	eth1      :     3      V3
+//					FB0000E0     1 0:00000000		0
+//
+// 群組是以主機位元組順序 (little-endian) 印出的網路位元組順序地址
+func ParseProcIGMP(r io.Reader) ([]Membership, error) {
+	var list []Membership
+	scanner := bufio.NewScanner(r)
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		if first || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			// 介面: Idx Device : Count Querier
+			name, rest, ok := strings.Cut(line, ":")
+			fields := strings.Fields(name)
+			if !ok || len(fields) != 2 {
+				return nil, fmt.Errorf("invalid interface line %q", line)
+			}
+			m := Membership{Interface: fields[1], Groups: []string{}}
+			if f := strings.Fields(rest); len(f) >= 2 {
+				m.Version = f[1]
+			}
+			list = append(list, m)
+			continue
+		}
+
+		if len(list) == 0 {
+			return nil, fmt.Errorf("group before interface: %q", line)
+		}
+		fields := strings.Fields(line)
+		v, err := strconv.ParseUint(fields[0], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group %q", fields[0])
+		}
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(v))
+		m := &list[len(list)-1]
+		m.Groups = append(m.Groups, netip.AddrFrom4(b).String())
+	}
+	return list, scanner.Err()
+}
+
+// GroupPurpose Dante 網路常見群組的用途 (不認得的群組為空白)
+func GroupPurpose(group string) string {
+	addr, err := netip.ParseAddr(group)
+	if err != nil || !addr.Is4() {
+		return ""
+	}
+	b := addr.As4()
+	switch {
+	case group == "224.0.0.1":
+		return "all hosts"
+	case group == "224.0.0.251":
+		return "mDNS (Dante discovery)"
+	case b[0] == 224 && b[1] == 0 && b[2] == 1 && b[3] >= 129 && b[3] <= 132:
+		return "PTP (Dante clock)"
+	case group == "239.255.255.255":
+		return "SAP (AES67 announcements)"
+	case b[0] == 239 && b[1] == 255:
+		return "Dante multicast audio"
+	case b[0] == 239 && b[1] == 69:
+		return "AES67 multicast audio"
+	}
+	return ""
+}
+
+//==============================================================================
+// Membership query
+//==============================================================================
+
+// igmpTypeQuery IGMP membership query
+const igmpTypeQuery = 0x11
+
+// DefaultQueryInterval querier 預設每 125 秒送出一般查詢 (RFC 2236/3376)
+const DefaultQueryInterval = 125 * time.Second
+
+// Query 收到的 membership query
+type Query struct {
+	Version     int           // 1、2 或 3
+	Group       string        // 一般查詢為 0.0.0.0
+	MaxResponse time.Duration // 成員回報的期限
+	Interval    time.Duration // querier 的查詢間隔 (只有 v3 帶有，其他為 0)
+}
+
+// ErrNotQuery 不是 membership query (回報、離開訊息)
+var ErrNotQuery = errors.New("not an IGMP membership query")
+
+// ParseQuery 解析 IGMP 訊息 (不含 IP 標頭)
+func ParseQuery(b []byte) (Query, error) {
+	if len(b) < 8 {
+		return Query{}, fmt.Errorf("IGMP message too short (%d bytes)", len(b))
+	}
+	if b[0] != igmpTypeQuery {
+		return Query{}, ErrNotQuery
+	}
+	if checksum(b) != 0 {
+		return Query{}, errors.New("invalid IGMP checksum")
+	}
+	q := Query{Group: netip.AddrFrom4([4]byte(b[4:8])).String()}
+	switch {
+	case len(b) >= 12:
+		q.Version = 3
+		q.MaxResponse = time.Duration(decodeCode(b[1])) * 100 * time.Millisecond
+		if qqic := decodeCode(b[9]); qqic > 0 {
+			q.Interval = time.Duration(qqic) * time.Second
+		}
+	case b[1] == 0:
+		q.Version = 1
+		q.MaxResponse = 10 * time.Second
+	default:
+		q.Version = 2
+		q.MaxResponse = time.Duration(b[1]) * 100 * time.Millisecond
+	}
+	return q, nil
+}
+
+// decodeCode IGMPv3 的 Max Resp Code 與 QQIC (128 以上為浮點格式)
+func decodeCode(c byte) int {
+	if c < 128 {
+		return int(c)
+	}
+	mant, exp := int(c&0x0f), int(c>>4&0x07)
+	return (mant | 0x10) << (exp + 3)
+}
+
+// checksum 網際網路 checksum (訊息正確時為 0)
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,103 @@
+package igmp
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+const procIGMP = `Idx	Device    : Count Querier	Group    Users Timer	Reporter
+1	lo        :     1      V3
+				010000E0     1 0:00000000		0
+3	eth1      :     4      V2
+				FFFFFFEF     1 0:00000000		0
+				0A01FFEF     1 0:00000000		0
+				FB0000E0     1 0:00000000		0
+				010000E0     1 0:00000000		0
+`
+
+func TestParseProcIGMP(t *testing.T) {
+	list, err := ParseProcIGMP(strings.NewReader(procIGMP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[1].Interface != "eth1" || list[1].Version != "V2" {
+		t.Fatalf("memberships = %+v", list)
+	}
+	want := []string{"239.255.255.255", "239.255.1.10", "224.0.0.251", "224.0.0.1"}
+	if strings.Join(list[1].Groups, " ") != strings.Join(want, " ") {
+		t.Fatalf("groups = %v, want %v", list[1].Groups, want)
+	}
+	if _, err := ParseProcIGMP(strings.NewReader("header\n\t\t\t\t010000E0 1 0:0 0\n")); err == nil {
+		t.Fatal("group without interface accepted")
+	}
+}
+
+// query 建立 membership query 並填入 checksum
+func query(b ...byte) []byte {
+	sum := checksum(b)
+	b[2], b[3] = byte(sum>>8), byte(sum)
+	return b
+}
+
+func TestParseQuery(t *testing.T) {
+	v2 := query(0x11, 100, 0, 0, 0, 0, 0, 0)
+	q, err := ParseQuery(v2)
+	if err != nil || q.Version != 2 || q.Group != "0.0.0.0" || q.MaxResponse != 10*time.Second {
+		t.Fatalf("v2 = %+v, %v", q, err)
+	}
+	// QQIC 125 秒、Max Resp Code 浮點格式 (0x80 = 128 × 100 ms)
+	v3 := query(0x11, 0x80, 0, 0, 239, 255, 1, 10, 0x02, 125, 0, 0)
+	q, err = ParseQuery(v3)
+	if err != nil || q.Version != 3 || q.Group != "239.255.1.10" || q.Interval != 125*time.Second || q.MaxResponse != 12800*time.Millisecond {
+		t.Fatalf("v3 = %+v, %v", q, err)
+	}
+	if _, err := ParseQuery(query(0x16, 0, 0, 0, 239, 255, 1, 10)); err != ErrNotQuery {
+		t.Fatalf("report: err = %v", err)
+	}
+	v2[7] ^= 1
+	if _, err := ParseQuery(v2); err == nil {
+		t.Fatal("bad checksum accepted")
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	memberships, _ := ParseProcIGMP(strings.NewReader(procIGMP))
+
+	// 收聽超過一個查詢間隔仍沒有 querier
+	r := Diagnose("eth1", memberships, nil, 130*time.Second)
+	if len(r.Groups) != 4 || r.Groups[1].Purpose != "Dante multicast audio" {
+		t.Fatalf("groups = %+v", r.Groups)
+	}
+	if len(r.Problems) != 1 || !strings.Contains(r.Problems[0], "no IGMP querier") {
+		t.Fatalf("problems = %q", r.Problems)
+	}
+	if r := Diagnose("eth1", memberships, nil, 30*time.Second); len(r.Problems) != 0 || len(r.Notes) == 0 {
+		t.Fatalf("short listen: %+v", r)
+	}
+
+	m := NewMonitor()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	m.AddInterface("eth1", netip.MustParsePrefix("192.168.100.0/24"))
+	if err := m.Handle(netip.MustParseAddr("192.168.100.1"), query(0x11, 100, 0, 0, 0, 0, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if r := Diagnose("eth1", memberships, m.Queriers(), 130*time.Second); len(r.Problems) != 0 || len(r.Queriers) != 1 {
+		t.Fatalf("with querier: %+v", r)
+	}
+
+	// 兩台 querier 同時查詢
+	now = now.Add(time.Minute)
+	if err := m.Handle(netip.MustParseAddr("192.168.100.2"), query(0x11, 0, 0, 0, 0, 0, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	r = Diagnose("eth1", memberships, m.Queriers(), 130*time.Second)
+	if len(r.Problems) != 2 || !strings.Contains(r.Problems[0], "IGMPv1") || !strings.Contains(r.Problems[1], "2 active queriers") {
+		t.Fatalf("problems = %q", r.Problems)
+	}
+	if r := Diagnose("eth2", memberships, m.Queriers(), 130*time.Second); len(r.Queriers) != 0 {
+		t.Fatalf("querier attributed to other interface: %+v", r.Queriers)
+	}
+}
@@ -0,0 +1,270 @@
+package igmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// Querier 偵測
+//==============================================================================
+
+// 啟用 IGMP snooping 的交換器只把 multicast 送到回報過成員的埠，成員資格
+// 要靠網路上的 querier 定期查詢才會更新。沒有 querier 時成員資格逾時，
+// 交換器依設定把 multicast 氾濫到所有埠 (100 Mbps 設備被音訊淹沒) 或直接
+// 丟棄 (multicast 訂閱約在幾分鐘後中斷)。Monitor 以 raw IGMP socket 收聽
+// 查詢，記錄每個介面上的 querier。
+
+// maxPacket IGMP 訊息的大小上限
+const maxPacket = 1500
+
+// Querier 聽到的 querier
+type Querier struct {
+	Interface   string        `json:"interface,omitempty"` // 依來源地址所在的子網路判斷
+	Address     string        `json:"address"`
+	Version     int           `json:"version"`
+	Interval    time.Duration `json:"interval,omitempty"` // v3 查詢帶有的間隔
+	MaxResponse time.Duration `json:"max_response"`
+	Queries     int           `json:"queries"`
+	FirstSeen   time.Time     `json:"first_seen"`
+	LastSeen    time.Time     `json:"last_seen"`
+}
+
+// Monitor 記錄收到的 membership query
+type Monitor struct {
+	mu       sync.Mutex
+	started  time.Time
+	queriers map[string]*Querier // 來源地址 → querier
+	networks map[string][]netip.Prefix
+	now      func() time.Time
+}
+
+// NewMonitor 建立 Monitor
+func NewMonitor() *Monitor {
+	return &Monitor{
+		queriers: make(map[string]*Querier),
+		networks: make(map[string][]netip.Prefix),
+		now:      time.Now,
+	}
+}
+
+// AddInterface 登記介面的子網路 (查詢來源依子網路對應到介面)
+func (m *Monitor) AddInterface(name string, networks ...netip.Prefix) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.networks[name] = append(m.networks[name], networks...)
+}
+
+// Handle 處理一個 IGMP 訊息 (不含 IP 標頭)，不是查詢時回傳 ErrNotQuery
+func (m *Monitor) Handle(from netip.Addr, packet []byte) error {
+	q, err := ParseQuery(packet)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	key := from.String()
+	qr, ok := m.queriers[key]
+	if !ok {
+		qr = &Querier{Interface: m.interfaceOf(from), Address: key, FirstSeen: now}
+		m.queriers[key] = qr
+		slog.Info("IGMP querier heard", "iface", qr.Interface, "querier", key, "version", q.Version)
+	}
+	qr.Version, qr.MaxResponse, qr.LastSeen = q.Version, q.MaxResponse, now
+	if q.Interval > 0 {
+		qr.Interval = q.Interval
+	}
+	qr.Queries++
+	return nil
+}
+
+// interfaceOf 來源地址所在的介面 (呼叫者持有 mu)
+func (m *Monitor) interfaceOf(addr netip.Addr) string {
+	for name, prefixes := range m.networks {
+		for _, p := range prefixes {
+			if p.Contains(addr) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// Queriers 聽到的 querier (依介面與地址排序)
+func (m *Monitor) Queriers() []Querier {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Querier, 0, len(m.queriers))
+	for _, q := range m.queriers {
+		list = append(list, *q)
+	}
+	slices.SortFunc(list, func(a, b Querier) int {
+		if c := strings.Compare(a.Interface, b.Interface); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Address, b.Address)
+	})
+	return list
+}
+
+// Listening 開始收聽後經過的時間 (尚未收聽為 0)
+func (m *Monitor) Listening() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started.IsZero() {
+		return 0
+	}
+	return m.now().Sub(m.started)
+}
+
+// Listen 開啟 raw IGMP socket 收聽查詢，直到 ctx 結束 (需要 CAP_NET_RAW)
+// 查詢送到 224.0.0.1，所有介面都會收到，不需要加入群組
+func (m *Monitor) Listen(ctx context.Context, ifaces []string) error {
+	for _, name := range ifaces {
+		ifi, err := net.InterfaceByName(name)
+		if err != nil {
+			return err
+		}
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			return err
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok {
+				if p, err := netip.ParsePrefix(ipnet.String()); err == nil && p.Addr().Is4() {
+					m.AddInterface(name, p.Masked())
+				}
+			}
+		}
+	}
+
+	conn, err := net.ListenIP("ip4:igmp", &net.IPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return fmt.Errorf("open IGMP socket: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	m.mu.Lock()
+	m.started = m.now()
+	m.mu.Unlock()
+	slog.Info("Listening for IGMP queries", "ifaces", ifaces)
+
+	buf := make([]byte, maxPacket)
+	for {
+		n, from, err := conn.ReadFromIP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		addr, ok := netip.AddrFromSlice(from.IP)
+		if !ok {
+			continue
+		}
+		if err := m.Handle(addr.Unmap(), buf[:n]); err != nil && !errors.Is(err, ErrNotQuery) {
+			slog.Debug("Ignored IGMP packet", "from", from, "err", err)
+		}
+	}
+}
+
+//==============================================================================
+// 診斷
+//==============================================================================
+
+// Group 介面加入的群組
+type Group struct {
+	Address string `json:"address"`
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// Report 單一介面的診斷
+type Report struct {
+	Interface string        `json:"interface"`
+	Version   string        `json:"version,omitempty"` // 核心使用的 IGMP 版本
+	Groups    []Group       `json:"groups"`
+	Queriers  []Querier     `json:"queriers"`
+	Listened  time.Duration `json:"listened"` // 收聽查詢的時間
+	Problems  []string      `json:"problems,omitempty"`
+	Notes     []string      `json:"notes,omitempty"`
+}
+
+// Diagnose 依介面的群組與聽到的 querier 檢查常見問題
+// listened 不到一個查詢間隔時，沒有 querier 只列為 note
+func Diagnose(iface string, memberships []Membership, queriers []Querier, listened time.Duration) Report {
+	r := Report{Interface: iface, Groups: []Group{}, Queriers: []Querier{}, Listened: listened}
+	joined := false
+	for _, m := range memberships {
+		if m.Interface != iface {
+			continue
+		}
+		r.Version = m.Version
+		for _, g := range m.Groups {
+			purpose := GroupPurpose(g)
+			r.Groups = append(r.Groups, Group{Address: g, Purpose: purpose})
+			joined = joined || (purpose != "" && g != "224.0.0.1")
+		}
+	}
+	for _, q := range queriers {
+		if q.Interface == iface {
+			r.Queriers = append(r.Queriers, q)
+		}
+	}
+
+	switch {
+	case len(r.Queriers) == 0 && listened >= DefaultQueryInterval:
+		r.Problems = append(r.Problems, fmt.Sprintf(
+			"no IGMP querier heard in %s: switches with IGMP snooping flood Dante multicast to every port "+
+				"(or drop it once memberships time out); enable the querier on one switch per Dante VLAN",
+			listened.Round(time.Second)))
+	case len(r.Queriers) == 0 && listened > 0:
+		r.Notes = append(r.Notes, fmt.Sprintf("no IGMP querier heard yet (listened %s, queriers send every %s by default)",
+			listened.Round(time.Second), DefaultQueryInterval))
+	case len(r.Queriers) == 0:
+		r.Notes = append(r.Notes, "IGMP queries not monitored")
+	}
+
+	// 選舉後只剩地址最小的 querier；一個查詢間隔內聽到多個表示選舉不一致
+	// (IGMP 版本不同或 VLAN 設定錯誤)
+	var active []string
+	latest := time.Time{}
+	for _, q := range r.Queriers {
+		if q.LastSeen.After(latest) {
+			latest = q.LastSeen
+		}
+	}
+	for _, q := range r.Queriers {
+		if latest.Sub(q.LastSeen) < DefaultQueryInterval {
+			active = append(active, fmt.Sprintf("%s v%d", q.Address, q.Version))
+		}
+		if q.Version == 1 {
+			r.Problems = append(r.Problems, fmt.Sprintf(
+				"IGMPv1 querier %s: receivers cannot leave groups, multicast keeps flowing after unsubscribing", q.Address))
+		}
+	}
+	if len(active) > 1 {
+		r.Problems = append(r.Problems, fmt.Sprintf(
+			"%d active queriers (%s): election is not settling, check for mixed IGMP versions or bridged VLANs",
+			len(active), strings.Join(active, ", ")))
+	}
+
+	if r.Version == "V1" || r.Version == "V2" {
+		r.Notes = append(r.Notes, fmt.Sprintf("kernel fell back to IGMP %s after hearing an older querier", r.Version))
+	}
+	if !joined {
+		r.Notes = append(r.Notes, "interface has not joined any Dante or AES67 multicast group")
+	}
+	return r
+}
@@ -0,0 +1,294 @@
+// Package lldp 解析 LLDP (含 LLDP-MED) 並記錄各介面聽到的鄰居
+package lldp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// LLDPDU
+//==============================================================================
+
+// 交換器每 30 秒在每個埠送出 LLDPDU (ethertype 0x88cc，目的地 01:80:c2:00:00:0e)，
+// 內容是交換器名稱、埠名稱、管理地址與 VLAN。標準交換器不轉送 LLDP，所以網卡上
+// 聽到的是直接連接的交換器；接在非管理型交換器 (會轉送) 或直接連接的 Dante 設備
+// 也會出現，其中支援 LLDP-MED 的設備另外帶有型號、韌體與 QoS 政策。
+
+// EtherType LLDP 的 ethertype
+const EtherType = 0x88cc
+
+// ErrNotLLDP frame 不是 LLDPDU
+var ErrNotLLDP = errors.New("not an LLDP frame")
+
+// TLV 種類
+const (
+	tlvEnd          = 0
+	tlvChassisID    = 1
+	tlvPortID       = 2
+	tlvTTL          = 3
+	tlvPortDesc     = 4
+	tlvSystemName   = 5
+	tlvSystemDesc   = 6
+	tlvCapabilities = 7
+	tlvMgmtAddress  = 8
+	tlvOrgSpecific  = 127
+)
+
+// 組織自訂 TLV 的 OUI
+var (
+	oui8021 = [3]byte{0x00, 0x80, 0xc2} // IEEE 802.1
+	ouiMED  = [3]byte{0x00, 0x12, 0xbb} // TIA LLDP-MED
+)
+
+// capabilityNames 系統能力的位元 (bit 0 起)
+var capabilityNames = []string{"other", "repeater", "bridge", "wlan-ap", "router", "telephone", "docsis", "station", "c-vlan", "s-vlan", "tpmr"}
+
+// medClasses LLDP-MED 設備類別
+var medClasses = map[byte]string{1: "endpoint-1", 2: "endpoint-2", 3: "endpoint-3", 4: "network-connectivity"}
+
+// medApplications LLDP-MED network policy 的應用類型
+var medApplications = map[byte]string{
+	1: "voice", 2: "voice-signaling", 3: "guest-voice", 4: "guest-voice-signaling",
+	5: "softphone-voice", 6: "video-conferencing", 7: "streaming-video", 8: "video-signaling",
+}
+
+// Neighbor 一個 LLDP 鄰居 (一個 chassis 的一個埠)
+type Neighbor struct {
+	Interface         string        `json:"interface"`  // 收到的本機介面
+	SourceMAC         string        `json:"source_mac"` // frame 的來源地址 (送出的埠)
+	ChassisID         string        `json:"chassis_id"`
+	PortID            string        `json:"port_id"`
+	PortDescription   string        `json:"port_description,omitempty"`
+	SystemName        string        `json:"system_name,omitempty"`
+	SystemDescription string        `json:"system_description,omitempty"`
+	Capabilities      []string      `json:"capabilities,omitempty"` // 啟用的能力 (bridge、router、station…)
+	ManagementAddress []string      `json:"management_address,omitempty"`
+	PortVLAN          int           `json:"port_vlan,omitempty"` // 埠的 untagged VLAN (802.1)
+	TTL               time.Duration `json:"ttl"`
+	MED               *MED          `json:"med,omitempty"`
+	FirstSeen         time.Time     `json:"first_seen"`
+	LastSeen          time.Time     `json:"last_seen"`
+}
+
+// MED LLDP-MED 的內容 (支援的端點與交換器送出)
+type MED struct {
+	Class        string          `json:"class,omitempty"` // endpoint-1..3、network-connectivity
+	Policies     []NetworkPolicy `json:"policies,omitempty"`
+	Hardware     string          `json:"hardware,omitempty"`
+	Firmware     string          `json:"firmware,omitempty"`
+	Software     string          `json:"software,omitempty"`
+	Serial       string          `json:"serial,omitempty"`
+	Manufacturer string          `json:"manufacturer,omitempty"`
+	Model        string          `json:"model,omitempty"`
+	AssetID      string          `json:"asset_id,omitempty"`
+}
+
+// NetworkPolicy LLDP-MED 的 VLAN 與 QoS 政策
+type NetworkPolicy struct {
+	Application string `json:"application"`
+	Unknown     bool   `json:"unknown,omitempty"` // 政策尚未設定
+	Tagged      bool   `json:"tagged"`
+	VLAN        int    `json:"vlan"`
+	Priority    int    `json:"priority"` // 802.1p
+	DSCP        int    `json:"dscp"`
+}
+
+// IsSwitch 鄰居是否為交換器 (bridge 能力或 LLDP-MED network connectivity)
+func (n Neighbor) IsSwitch() bool {
+	if n.MED != nil && n.MED.Class == "network-connectivity" {
+		return true
+	}
+	for _, c := range n.Capabilities {
+		if c == "bridge" {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired TTL 到期 (沒有再收到 LLDPDU)
+func (n Neighbor) Expired(now time.Time) bool {
+	return now.Sub(n.LastSeen) > n.TTL
+}
+
+// Parse 解析一個 Ethernet frame (可含 802.1Q 標籤)，不是 LLDP 時回傳 ErrNotLLDP
+// TTL 為 0 的 shutdown LLDPDU 也會回傳 (表示鄰居停用 LLDP 或即將關閉)
+func Parse(frame []byte) (Neighbor, error) {
+	var n Neighbor
+	if len(frame) < 14 {
+		return n, ErrNotLLDP
+	}
+	etherType, offset := binary.BigEndian.Uint16(frame[12:]), 14
+	if etherType == 0x8100 && len(frame) >= 18 {
+		etherType, offset = binary.BigEndian.Uint16(frame[16:]), 18
+	}
+	if etherType != EtherType {
+		return n, ErrNotLLDP
+	}
+	n.SourceMAC = net.HardwareAddr(frame[6:12]).String()
+
+	data := frame[offset:]
+	var required uint8 // 收到的必要 TLV (chassis ID、port ID、TTL)
+	for len(data) >= 2 {
+		header := binary.BigEndian.Uint16(data)
+		typ, length := int(header>>9), int(header&0x1ff)
+		if len(data) < 2+length {
+			return n, fmt.Errorf("truncated TLV %d", typ)
+		}
+		value := data[2 : 2+length]
+		data = data[2+length:]
+
+		switch typ {
+		case tlvEnd:
+			data = nil
+		case tlvChassisID:
+			if length < 2 {
+				return n, errors.New("invalid chassis ID")
+			}
+			n.ChassisID = formatID(value[0], value[1:], 4)
+		case tlvPortID:
+			if length < 2 {
+				return n, errors.New("invalid port ID")
+			}
+			n.PortID = formatID(value[0], value[1:], 3)
+		case tlvTTL:
+			if length != 2 {
+				return n, errors.New("invalid TTL")
+			}
+			n.TTL = time.Duration(binary.BigEndian.Uint16(value)) * time.Second
+		case tlvPortDesc:
+			n.PortDescription = text(value)
+		case tlvSystemName:
+			n.SystemName = text(value)
+		case tlvSystemDesc:
+			n.SystemDescription = text(value)
+		case tlvCapabilities:
+			if length == 4 {
+				n.Capabilities = capabilities(binary.BigEndian.Uint16(value[2:]))
+			}
+		case tlvMgmtAddress:
+			if addr, ok := managementAddress(value); ok {
+				n.ManagementAddress = append(n.ManagementAddress, addr)
+			}
+		case tlvOrgSpecific:
+			n.parseOrgSpecific(value)
+		}
+		if typ >= tlvChassisID && typ <= tlvTTL {
+			required |= 1 << typ
+		}
+	}
+	if required != 1<<tlvChassisID|1<<tlvPortID|1<<tlvTTL {
+		return n, errors.New("missing chassis ID, port ID or TTL")
+	}
+	return n, nil
+}
+
+// parseOrgSpecific 802.1 的 port VLAN 與 LLDP-MED
+func (n *Neighbor) parseOrgSpecific(value []byte) {
+	if len(value) < 4 {
+		return
+	}
+	oui, subtype, info := [3]byte(value[:3]), value[3], value[4:]
+	switch oui {
+	case oui8021:
+		if subtype == 1 && len(info) == 2 {
+			n.PortVLAN = int(binary.BigEndian.Uint16(info))
+		}
+	case ouiMED:
+		if n.MED == nil {
+			n.MED = &MED{}
+		}
+		switch subtype {
+		case 1: // capabilities 與設備類別
+			if len(info) == 3 {
+				n.MED.Class = medClasses[info[2]]
+			}
+		case 2: // network policy
+			if len(info) == 4 {
+				v := binary.BigEndian.Uint32(info)
+				app := medApplications[info[0]]
+				if app == "" {
+					app = fmt.Sprintf("type-%d", info[0])
+				}
+				n.MED.Policies = append(n.MED.Policies, NetworkPolicy{
+					Application: app,
+					Unknown:     v&(1<<23) != 0,
+					Tagged:      v&(1<<22) != 0,
+					VLAN:        int(v>>9) & 0xfff,
+					Priority:    int(v>>6) & 0x7,
+					DSCP:        int(v) & 0x3f,
+				})
+			}
+		case 5:
+			n.MED.Hardware = text(info)
+		case 6:
+			n.MED.Firmware = text(info)
+		case 7:
+			n.MED.Software = text(info)
+		case 8:
+			n.MED.Serial = text(info)
+		case 9:
+			n.MED.Manufacturer = text(info)
+		case 10:
+			n.MED.Model = text(info)
+		case 11:
+			n.MED.AssetID = text(info)
+		}
+	}
+}
+
+// formatID chassis/port ID 依 subtype 顯示 (macSubtype 的值為 MAC，network address 為 IP)
+func formatID(subtype byte, value []byte, macSubtype byte) string {
+	switch {
+	case subtype == macSubtype && len(value) == 6:
+		return net.HardwareAddr(value).String()
+	case subtype == macSubtype+1: // network address: IANA 地址類別 + 地址
+		if addr, ok := ianaAddress(value); ok {
+			return addr
+		}
+	}
+	return text(value)
+}
+
+// managementAddress 管理地址 TLV: 長度 (含類別)、IANA 地址類別、地址、介面編號…
+func managementAddress(value []byte) (string, bool) {
+	if len(value) < 2 || int(value[0]) < 1 || len(value) < 1+int(value[0]) {
+		return "", false
+	}
+	return ianaAddress(value[1 : 1+int(value[0])])
+}
+
+// ianaAddress IANA 地址類別 (1 IPv4、2 IPv6) 與地址
+func ianaAddress(value []byte) (string, bool) {
+	if len(value) < 1 {
+		return "", false
+	}
+	switch family, addr := value[0], value[1:]; {
+	case family == 1 && len(addr) == 4, family == 2 && len(addr) == 16:
+		a, _ := netip.AddrFromSlice(addr)
+		return a.String(), true
+	}
+	return "", false
+}
+
+// capabilities 啟用的能力名稱
+func capabilities(bits uint16) []string {
+	var names []string
+	for i, name := range capabilityNames {
+		if bits&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// text 字串 TLV (去掉結尾的 NUL 與空白)
+func text(b []byte) string {
+	return strings.TrimRight(string(b), "\x00 \r\n")
+}
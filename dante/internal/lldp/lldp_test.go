@@ -0,0 +1,119 @@
+package lldp
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// tlv 編碼一個 TLV
+func tlv(typ int, value ...byte) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(typ<<9|len(value)))
+	return append(b, value...)
+}
+
+// frame 建立來源為 src 的 LLDPDU
+func frame(src []byte, tlvs ...[]byte) []byte {
+	b := []byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+	b = append(b, src...)
+	b = append(b, 0x88, 0xcc)
+	for _, t := range tlvs {
+		b = append(b, t...)
+	}
+	return append(b, tlv(tlvEnd)...)
+}
+
+// switchFrame 交換器 core-sw1 的 Gi1/0/12 (untagged VLAN 10)
+func switchFrame(ttl byte) []byte {
+	return frame([]byte{0x00, 0x1b, 0x54, 0xaa, 0xbb, 0x0c},
+		tlv(tlvChassisID, 4, 0x00, 0x1b, 0x54, 0xaa, 0xbb, 0x00),
+		tlv(tlvPortID, 5, 'G', 'i', '1', '/', '0', '/', '1', '2'),
+		tlv(tlvTTL, 0, ttl),
+		tlv(tlvPortDesc, []byte("Stage rack")...),
+		tlv(tlvSystemName, []byte("core-sw1")...),
+		tlv(tlvCapabilities, 0x00, 0x14, 0x00, 0x14),
+		tlv(tlvMgmtAddress, 5, 1, 10, 0, 0, 2, 2, 0, 0, 0, 1, 0),
+		tlv(tlvOrgSpecific, 0x00, 0x80, 0xc2, 1, 0x00, 10),
+	)
+}
+
+func TestParse(t *testing.T) {
+	n, err := Parse(switchFrame(120))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.ChassisID != "00:1b:54:aa:bb:00" || n.PortID != "Gi1/0/12" || n.SystemName != "core-sw1" || n.PortDescription != "Stage rack" {
+		t.Errorf("neighbor = %+v", n)
+	}
+	if n.SourceMAC != "00:1b:54:aa:bb:0c" || n.TTL != 2*time.Minute || n.PortVLAN != 10 || !n.IsSwitch() {
+		t.Errorf("neighbor = %+v", n)
+	}
+	if len(n.ManagementAddress) != 1 || n.ManagementAddress[0] != "10.0.0.2" {
+		t.Errorf("management address = %v", n.ManagementAddress)
+	}
+
+	// LLDP-MED 端點: Dante 設備的型號、韌體與 voice 政策 (tagged VLAN 20, priority 6, DSCP 46)
+	policy := uint32(1)<<22 | 20<<9 | 6<<6 | 46
+	med, err := Parse(frame([]byte{0x00, 0x1d, 0xc1, 0x00, 0x00, 0x01},
+		tlv(tlvChassisID, 5, 1, 10, 0, 0, 50),
+		tlv(tlvPortID, 3, 0x00, 0x1d, 0xc1, 0x00, 0x00, 0x01),
+		tlv(tlvTTL, 0, 120),
+		tlv(tlvOrgSpecific, 0x00, 0x12, 0xbb, 1, 0x00, 0x33, 3),
+		tlv(tlvOrgSpecific, 0x00, 0x12, 0xbb, 2, 1, byte(policy>>16), byte(policy>>8), byte(policy)),
+		tlv(tlvOrgSpecific, append([]byte{0x00, 0x12, 0xbb, 6}, "4.2.1"...)...),
+		tlv(tlvOrgSpecific, append([]byte{0x00, 0x12, 0xbb, 10}, "Stagebox-16"...)...),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if med.ChassisID != "10.0.0.50" || med.PortID != "00:1d:c1:00:00:01" || med.IsSwitch() || med.MED == nil {
+		t.Fatalf("MED neighbor = %+v", med)
+	}
+	want := NetworkPolicy{Application: "voice", Tagged: true, VLAN: 20, Priority: 6, DSCP: 46}
+	if med.MED.Class != "endpoint-3" || med.MED.Firmware != "4.2.1" || med.MED.Model != "Stagebox-16" ||
+		len(med.MED.Policies) != 1 || med.MED.Policies[0] != want {
+		t.Errorf("MED = %+v", med.MED)
+	}
+
+	if _, err := Parse([]byte{0x01, 0x00, 0x5e, 0, 0, 1, 0, 0, 0, 0, 0, 1, 0x08, 0x00, 0x45}); err != ErrNotLLDP {
+		t.Errorf("IPv4 frame: err = %v", err)
+	}
+	if _, err := Parse(frame([]byte{0, 0, 0, 0, 0, 1}, tlv(tlvChassisID, 7, 'x'), tlv(tlvTTL, 0, 120))); err == nil {
+		t.Error("LLDPDU without port ID accepted")
+	}
+	if _, err := Parse(switchFrame(120)[:30]); err == nil {
+		t.Error("truncated LLDPDU accepted")
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	m := NewMonitor()
+	now := time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	if err := m.Handle("eth1", switchFrame(120)); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Second)
+	m.Handle("eth1", switchFrame(120))
+	list := m.Neighbors("eth1")
+	if len(list) != 1 || list[0].Interface != "eth1" || list[0].LastSeen.Sub(list[0].FirstSeen) != 30*time.Second {
+		t.Fatalf("neighbors = %+v", list)
+	}
+	if len(m.Neighbors("eth2")) != 0 {
+		t.Error("neighbor listed on another interface")
+	}
+
+	// TTL 到期後不再列出
+	now = now.Add(121 * time.Second)
+	if list := m.Neighbors(""); len(list) != 0 {
+		t.Errorf("expired neighbors = %+v", list)
+	}
+
+	// shutdown LLDPDU 立即移除
+	m.Handle("eth1", switchFrame(120))
+	m.Handle("eth1", switchFrame(0))
+	if list := m.Neighbors(""); len(list) != 0 {
+		t.Errorf("neighbors after shutdown = %+v", list)
+	}
+}
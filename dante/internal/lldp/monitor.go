@@ -0,0 +1,176 @@
+package lldp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/packet"
+)
+
+//==============================================================================
+// 鄰居表
+//==============================================================================
+
+// maxFrame LLDPDU 的大小上限 (一個 Ethernet frame)
+const maxFrame = 1522
+
+// Monitor 記錄各介面聽到的 LLDP 鄰居 (TTL 到期後不再列出)
+type Monitor struct {
+	mu        sync.Mutex
+	neighbors map[string]*Neighbor // 介面 + chassis ID + port ID → 鄰居
+	listening map[string]time.Time // 介面 → 開始收聽的時間
+	now       func() time.Time
+}
+
+// NewMonitor 建立 Monitor
+func NewMonitor() *Monitor {
+	return &Monitor{
+		neighbors: make(map[string]*Neighbor),
+		listening: make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// Handle 處理介面上收到的一個 frame，不是 LLDP 時回傳 ErrNotLLDP
+func (m *Monitor) Handle(iface string, frame []byte) error {
+	n, err := Parse(frame)
+	if err != nil {
+		return err
+	}
+	n.Interface = iface
+	key := iface + "\x00" + n.ChassisID + "\x00" + n.PortID
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	prev, ok := m.neighbors[key]
+	if n.TTL == 0 {
+		// shutdown LLDPDU: 鄰居停用 LLDP 或埠即將關閉
+		delete(m.neighbors, key)
+		return nil
+	}
+	n.FirstSeen, n.LastSeen = now, now
+	if ok && !prev.Expired(now) {
+		n.FirstSeen = prev.FirstSeen
+	} else {
+		slog.Info("LLDP neighbor heard", "iface", iface, "system", n.SystemName, "chassis", n.ChassisID, "port", n.PortID)
+	}
+	m.neighbors[key] = &n
+	return nil
+}
+
+// Neighbors 目前的鄰居 (依介面、系統名稱與埠排序)，iface 空白時列出所有介面
+func (m *Monitor) Neighbors(iface string) []Neighbor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	list := []Neighbor{}
+	for key, n := range m.neighbors {
+		if n.Expired(now) {
+			delete(m.neighbors, key)
+			continue
+		}
+		if iface == "" || n.Interface == iface {
+			list = append(list, *n)
+		}
+	}
+	slices.SortFunc(list, func(a, b Neighbor) int {
+		if c := strings.Compare(a.Interface, b.Interface); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.SystemName, b.SystemName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.PortID, b.PortID)
+	})
+	return list
+}
+
+// Listening 介面開始收聽後經過的時間 (尚未收聽為 0)
+func (m *Monitor) Listening(iface string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	started, ok := m.listening[iface]
+	if !ok {
+		return 0
+	}
+	return m.now().Sub(started)
+}
+
+// Listen 在每個介面開啟只收 LLDP 的 packet socket，直到 ctx 結束 (需要 CAP_NET_RAW)
+// 任何一個介面無法開啟時關閉已開啟的並回傳錯誤
+func (m *Monitor) Listen(ctx context.Context, ifaces []string) error {
+	if len(ifaces) == 0 {
+		return errors.New("no interfaces to listen on")
+	}
+	conns := make(map[string]*os.File, len(ifaces))
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+	for _, name := range ifaces {
+		if _, dup := conns[name]; dup {
+			continue
+		}
+		ifi, err := net.InterfaceByName(name)
+		if err != nil {
+			return err
+		}
+		conn, err := packet.OpenEtherType(ifi, EtherType)
+		if err != nil {
+			return fmt.Errorf("open LLDP socket on %s: %w", name, err)
+		}
+		conns[name] = conn
+	}
+	stop := context.AfterFunc(ctx, func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	})
+	defer stop()
+
+	m.mu.Lock()
+	for _, name := range ifaces {
+		m.listening[name] = m.now()
+	}
+	m.mu.Unlock()
+	slog.Info("Listening for LLDP", "ifaces", ifaces)
+
+	errs := make(chan error, len(conns))
+	for name, conn := range conns {
+		go func() {
+			buf := make([]byte, maxFrame)
+			for {
+				n, err := conn.Read(buf)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if err := m.Handle(name, buf[:n]); err != nil && !errors.Is(err, ErrNotLLDP) {
+					slog.Debug("Ignored LLDP frame", "iface", name, "err", err)
+				}
+			}
+		}()
+	}
+	// 一個介面失敗時結束全部 (Close 中斷其他的 Read)，ctx 結束不是錯誤
+	err := <-errs
+	for _, c := range conns {
+		c.Close()
+	}
+	for range len(conns) - 1 {
+		<-errs
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
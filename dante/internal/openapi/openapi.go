@@ -0,0 +1,301 @@
+// Package openapi 由 Go 型別產生 OpenAPI 3 文件
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//==============================================================================
+// 文件
+//==============================================================================
+
+// 只涵蓋 GOlane 管理 API 需要的部分：路徑、查詢參數、JSON 請求與回應，以及
+// Bearer 權杖。schema 由 encoding/json 會輸出的欄位產生，具名的 struct 放在
+// components/schemas 中互相參照，產生 client SDK 時會成為對應的型別。
+
+// Version 產生的 OpenAPI 版本
+const Version = "3.0.3"
+
+// Document OpenAPI 文件
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+// Info 文件說明
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components 共用的 schema 與驗證方式
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 驗證方式
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem 同一路徑的操作 (依 HTTP 方法)
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+// Operation 單一操作
+type Operation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []Parameter            `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]*Response   `json:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty"` // 指向空白陣列表示不需要驗證
+}
+
+// Parameter 路徑或查詢參數
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path 或 query
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 請求內容
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response 回應
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType 內容的 schema
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// JSON application/json 內容
+func JSON(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// Method 依 HTTP 方法取得操作的欄位 (不支援的方法回傳 nil)
+func (p *PathItem) Method(method string) **Operation {
+	switch method {
+	case "GET":
+		return &p.Get
+	case "PUT":
+		return &p.Put
+	case "POST":
+		return &p.Post
+	case "DELETE":
+		return &p.Delete
+	}
+	return nil
+}
+
+//==============================================================================
+// Schema
+//==============================================================================
+
+// Schema JSON schema (OpenAPI 3.0 的子集)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+// String 字串 schema
+func String() *Schema { return &Schema{Type: "string"} }
+
+// Integer 整數 schema
+func Integer() *Schema { return &Schema{Type: "integer"} }
+
+// Boolean 布林 schema
+func Boolean() *Schema { return &Schema{Type: "boolean"} }
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// Generator 產生 schema，具名的 struct 收集到 Schemas
+type Generator struct {
+	Schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// NewGenerator 建立 Generator
+func NewGenerator() *Generator {
+	return &Generator{Schemas: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// SchemaFor v 的型別的 schema (v 為 nil 時回傳 nil)
+func (g *Generator) SchemaFor(v any) *Schema {
+	if v == nil {
+		return nil
+	}
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *Generator) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t, nullable = t.Elem(), true
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		s = &Schema{}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		s = &Schema{} // 自訂的 JSON 格式，無法由型別得知
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		s = String()
+	default:
+		s = g.kind(t)
+	}
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+func (g *Generator) kind(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s := Integer()
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			s.Format = "int64"
+		}
+		return s
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+	return &Schema{} // interface 等任意值
+}
+
+// ref 具名 struct 放在 components/schemas (遞迴的型別也只產生一次)
+func (g *Generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.uniqueName(t)
+		g.names[t] = name
+		g.Schemas[name] = &Schema{} // 先佔位，遞迴參照時不會重複產生
+		*g.Schemas[name] = *g.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// uniqueName schema 名稱：主程式 (main 或模組根目錄) 的型別使用型別名稱，
+// 其他套件加上套件名稱 (qos.Report → QosReport)，名稱相同時加上編號
+func (g *Generator) uniqueName(t reflect.Type) string {
+	name := exported(strings.NewReplacer("[", "Of", "]", "", ".", "", "*", "", ",", "And", "/", "").Replace(t.Name()))
+	if pkg := t.PkgPath(); strings.Contains(pkg, "/") {
+		name = exported(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	for base, n := name, 2; ; n++ {
+		if _, taken := g.Schemas[name]; !taken {
+			return name
+		}
+		name = base + strconv.Itoa(n)
+	}
+}
+
+// object struct 的欄位 (依 encoding/json 的規則：json 標籤、omitempty、內嵌)
+func (g *Generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range g.object(ft).Properties {
+					if _, ok := s.Properties[k]; !ok {
+						s.Properties[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.schema(f.Type)
+		if strings.Contains(tag, ",string") && fs.Ref == "" {
+			fs = String()
+		}
+		s.Properties[name] = fs
+	}
+	return s
+}
+
+// exported 第一個字母改成大寫
+func exported(s string) string {
+	r := []rune(s)
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+	return string(r)
+}
@@ -0,0 +1,58 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+type base struct {
+	ID int64 `json:"id"`
+}
+
+type node struct {
+	base
+	Name     string            `json:"name"`
+	Addr     netip.Addr        `json:"addr"`
+	Seen     time.Time         `json:"seen"`
+	Children []node            `json:"children,omitempty"`
+	Parent   *node             `json:"parent,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Count    int               `json:"count,string"`
+	Raw      json.RawMessage   `json:"raw"`
+	Hidden   string            `json:"-"`
+	internal int
+}
+
+func TestGenerator(t *testing.T) {
+	g := NewGenerator()
+	s := g.SchemaFor([]node{}) // 不是主程式的型別加上套件名稱
+	if s.Type != "array" || s.Items.Ref != "#/components/schemas/OpenapiNode" {
+		t.Fatalf("schema %+v", s)
+	}
+	n := g.Schemas["OpenapiNode"]
+	if n == nil || n.Type != "object" {
+		t.Fatalf("OpenapiNode: %+v", n)
+	}
+	want := map[string]string{"id": "integer", "name": "string", "addr": "string", "seen": "string", "children": "array", "labels": "object", "count": "string", "raw": ""}
+	for name, typ := range want {
+		p, ok := n.Properties[name]
+		if !ok || p.Type != typ {
+			t.Errorf("%s: %+v, want type %q", name, p, typ)
+		}
+	}
+	if len(n.Properties) != len(want)+1 {
+		t.Errorf("properties %v", n.Properties)
+	}
+	// 遞迴的型別參照同一個 schema
+	if n.Properties["parent"].Ref != "#/components/schemas/OpenapiNode" || n.Properties["children"].Items.Ref != n.Properties["parent"].Ref {
+		t.Errorf("recursive refs: %+v %+v", n.Properties["parent"], n.Properties["children"].Items)
+	}
+	if n.Properties["seen"].Format != "date-time" || n.Properties["labels"].AdditionalProperties.Type != "string" {
+		t.Errorf("seen %+v labels %+v", n.Properties["seen"], n.Properties["labels"])
+	}
+	if g.SchemaFor(nil) != nil {
+		t.Error("nil value has a schema")
+	}
+}
@@ -0,0 +1,30 @@
+// Package packet 開啟 AF_PACKET socket 讀取介面上的封包 (QoS 取樣與封包擷取共用)
+package packet
+
+import (
+	"net"
+	"os"
+)
+
+// ethertype (核心以 ETH_P_ALL 表示所有協定)
+const (
+	etherTypeAll  = 0x0003
+	etherTypeIPv4 = 0x0800
+)
+
+// OpenIPv4 開啟介面上只接收 IPv4 的 socket，讀到的封包不含 Ethernet 標頭 (需要 CAP_NET_RAW)
+func OpenIPv4(ifi *net.Interface) (*os.File, error) {
+	return open(ifi, false, etherTypeIPv4)
+}
+
+// OpenFrames 開啟介面上接收所有協定的 socket，讀到完整的 Ethernet frame
+// (包含本機送出的 frame；需要 CAP_NET_RAW)
+func OpenFrames(ifi *net.Interface) (*os.File, error) {
+	return open(ifi, true, etherTypeAll)
+}
+
+// OpenEtherType 開啟介面上只接收一種 ethertype 的 socket，讀到完整的 Ethernet frame
+// (LLDP 等控制協定，不必讀取介面上的音訊；需要 CAP_NET_RAW)
+func OpenEtherType(ifi *net.Interface, etherType uint16) (*os.File, error) {
+	return open(ifi, true, etherType)
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package packet
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"syscall"
+)
+
+// open 開啟綁定在介面上的 AF_PACKET socket，並接收所有 multicast，
+// 讓沒有加入的群組在交換器氾濫時也讀得到
+func open(ifi *net.Interface, frames bool, etherType uint16) (*os.File, error) {
+	sockType, proto := syscall.SOCK_DGRAM, htons(etherType)
+	if frames {
+		sockType = syscall.SOCK_RAW
+	}
+	fd, err := syscall.Socket(syscall.AF_PACKET, sockType|syscall.SOCK_CLOEXEC, int(proto))
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrLinklayer{Protocol: proto, Ifindex: ifi.Index}); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	// syscall 沒有 packet_mreq 的 setsockopt，以相同記憶體配置的位元組傳入
+	// struct packet_mreq { int mr_ifindex; unsigned short mr_type, mr_alen; unsigned char mr_address[8]; }
+	mreq := make([]byte, 16)
+	binary.NativeEndian.PutUint32(mreq[0:], uint32(ifi.Index))
+	binary.NativeEndian.PutUint16(mreq[4:], syscall.PACKET_MR_ALLMULTI)
+	if err := syscall.SetsockoptString(fd, syscall.SOL_PACKET, syscall.PACKET_ADD_MEMBERSHIP, string(mreq)); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	// 非阻塞的 fd 交給 runtime poller，Close 會中斷進行中的 Read
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), "packet:"+ifi.Name), nil
+}
+
+// htons 轉成網路位元組順序
+func htons(v uint16) uint16 {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], v)
+	return binary.NativeEndian.Uint16(b[:])
+}
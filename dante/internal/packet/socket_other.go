@@ -0,0 +1,14 @@
+//go:build !linux
+
+package packet
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// open 讀取介面上的封包只支援 Linux (AF_PACKET)
+func open(ifi *net.Interface, frames bool, etherType uint16) (*os.File, error) {
+	return nil, errors.New("packet sockets require Linux")
+}
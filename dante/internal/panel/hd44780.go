@@ -0,0 +1,117 @@
+package panel
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// HD44780 以 PCF8574 I2C 轉接板連接的字元 LCD (4-bit 模式)
+//
+// PCF8574 腳位：P0=RS、P1=RW、P2=E、P3=背光、P4-P7=D4-D7 (常見轉接板的接法)。
+type HD44780 struct {
+	bus   io.WriteCloser
+	cols  int
+	rows  int
+	shown []string // 上次顯示的文字 (相同的列不重送)
+}
+
+// PCF8574 輸出位元
+const (
+	lcdRS        = 0x01
+	lcdEnable    = 0x04
+	lcdBacklight = 0x08
+)
+
+// lcdRowOffsets 各列的 DDRAM 起始地址
+var lcdRowOffsets = [4]byte{0x00, 0x40, 0x14, 0x54}
+
+// lcdSleep 等待 LCD 執行命令 (測試時替換)
+var lcdSleep = time.Sleep
+
+// NewHD44780 初始化 LCD (cols 16 或 20，rows 1-4)
+func NewHD44780(bus io.WriteCloser, cols, rows int) (*HD44780, error) {
+	if cols < 8 || cols > 40 || rows < 1 || rows > len(lcdRowOffsets) {
+		return nil, fmt.Errorf("hd44780: unsupported size %dx%d", cols, rows)
+	}
+	d := &HD44780{bus: bus, cols: cols, rows: rows}
+	// 上電後不確定在 8-bit 或 4-bit 模式，依資料手冊的程序切換為 4-bit
+	lcdSleep(50 * time.Millisecond)
+	for _, wait := range []time.Duration{5 * time.Millisecond, time.Millisecond, time.Millisecond} {
+		if err := d.write(d.nibble(nil, 0x03, 0)); err != nil {
+			return nil, fmt.Errorf("hd44780: %w", err)
+		}
+		lcdSleep(wait)
+	}
+	if err := d.write(d.nibble(nil, 0x02, 0)); err != nil {
+		return nil, fmt.Errorf("hd44780: %w", err)
+	}
+	err := d.write(d.command(nil,
+		0x28, // 4-bit、2 列、5x8 字型
+		0x0c, // 顯示開、游標關
+		0x06, // 寫入後游標右移
+		0x01, // 清除
+	))
+	if err != nil {
+		return nil, fmt.Errorf("hd44780: %w", err)
+	}
+	lcdSleep(2 * time.Millisecond)
+	return d, nil
+}
+
+// Size 可顯示的欄數與列數
+func (d *HD44780) Size() (cols, rows int) {
+	return d.cols, d.rows
+}
+
+// Show 顯示各列文字 (只重寫變更的列)
+func (d *HD44780) Show(lines []string) error {
+	lines = fit(lines, d.cols, d.rows)
+	for row, line := range lines {
+		if d.shown != nil && d.shown[row] == line {
+			continue
+		}
+		buf := d.command(nil, 0x80|lcdRowOffsets[row])
+		for i := 0; i < len(line); i++ {
+			buf = d.send(buf, line[i], lcdRS)
+		}
+		if err := d.write(buf); err != nil {
+			d.shown = nil
+			return fmt.Errorf("hd44780: %w", err)
+		}
+	}
+	d.shown = lines
+	return nil
+}
+
+// command 附加命令位元組
+func (d *HD44780) command(buf []byte, cmds ...byte) []byte {
+	for _, c := range cmds {
+		buf = d.send(buf, c, 0)
+	}
+	return buf
+}
+
+// send 附加一個位元組 (高 4 位元在前)
+func (d *HD44780) send(buf []byte, b, rs byte) []byte {
+	buf = d.nibble(buf, b>>4, rs)
+	return d.nibble(buf, b&0x0f, rs)
+}
+
+// nibble 附加 4 位元資料與 E 的脈衝
+func (d *HD44780) nibble(buf []byte, n, rs byte) []byte {
+	b := n<<4 | rs | lcdBacklight
+	return append(buf, b|lcdEnable, b)
+}
+
+// write 送出 PCF8574 的輸出序列
+func (d *HD44780) write(buf []byte) error {
+	_, err := d.bus.Write(buf)
+	return err
+}
+
+// Close 關閉背光與匯流排
+func (d *HD44780) Close() error {
+	d.bus.Write([]byte{0})
+	return d.bus.Close()
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package panel
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// i2cSlave ioctl I2C_SLAVE: 設定之後讀寫的裝置地址
+const i2cSlave = 0x0703
+
+// I2C 開啟的 I2C 裝置 (/dev/i2c-N 上的一個地址)
+type I2C struct {
+	f *os.File
+}
+
+// OpenI2C 開啟 /dev/i2c-bus 上地址為 addr 的裝置
+func OpenI2C(bus int, addr uint16) (*I2C, error) {
+	f, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), i2cSlave, uintptr(addr)); errno != 0 {
+		f.Close()
+		return nil, fmt.Errorf("i2c-%d address %#x: %v", bus, addr, errno)
+	}
+	return &I2C{f: f}, nil
+}
+
+// Write 以一次 I2C 寫入送出 b
+func (d *I2C) Write(b []byte) (int, error) {
+	return d.f.Write(b)
+}
+
+// Close 關閉裝置
+func (d *I2C) Close() error {
+	return d.f.Close()
+}
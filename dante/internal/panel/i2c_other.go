@@ -0,0 +1,23 @@
+//go:build !linux
+
+package panel
+
+import "errors"
+
+// I2C 開啟的 I2C 裝置 (只支援 Linux)
+type I2C struct{}
+
+// OpenI2C I2C 只支援 Linux (/dev/i2c-N)
+func OpenI2C(bus int, addr uint16) (*I2C, error) {
+	return nil, errors.New("I2C displays require Linux")
+}
+
+// Write 不支援
+func (d *I2C) Write(b []byte) (int, error) {
+	return 0, errors.New("I2C displays require Linux")
+}
+
+// Close 不支援
+func (d *I2C) Close() error {
+	return nil
+}
@@ -0,0 +1,41 @@
+// Package panel 驅動機櫃前面板的小型文字顯示器
+//
+// 支援 I2C 介面的 SSD1306 OLED (128x64 或 128x32，以 5x7 字型顯示 21 欄文字)
+// 與接 PCF8574 轉接板的 HD44780 字元 LCD (16x2、20x4)。SPI 介面的面板需要額外的
+// D/C 接腳，目前不支援。只能顯示 ASCII，其他字元以 '?' 取代。
+package panel
+
+import "strings"
+
+// Display 文字顯示器
+type Display interface {
+	// Size 可顯示的欄數與列數
+	Size() (cols, rows int)
+	// Show 顯示各列文字 (超出的欄與列截斷，不足的列清空)
+	Show(lines []string) error
+	// Close 關閉顯示器與匯流排
+	Close() error
+}
+
+// fit 把文字調整為 rows 列、每列剛好 cols 個 ASCII 字元
+func fit(lines []string, cols, rows int) []string {
+	out := make([]string, rows)
+	for i := range out {
+		var line string
+		if i < len(lines) {
+			line = lines[i]
+		}
+		b := make([]byte, 0, cols)
+		for _, r := range line {
+			if len(b) == cols {
+				break
+			}
+			if r < 0x20 || r > 0x7e {
+				r = '?'
+			}
+			b = append(b, byte(r))
+		}
+		out[i] = string(b) + strings.Repeat(" ", cols-len(b))
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package panel
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// fakeBus 記錄每次 I2C 寫入
+type fakeBus struct {
+	writes [][]byte
+}
+
+func (b *fakeBus) Write(p []byte) (int, error) {
+	b.writes = append(b.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (b *fakeBus) Close() error { return nil }
+
+func TestFit(t *testing.T) {
+	got := fit([]string{"Dante1 12 devices", "音訊", "x", "dropped"}, 8, 3)
+	want := []string{"Dante1 1", "??      ", "x       "}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSSD1306(t *testing.T) {
+	bus := &fakeBus{}
+	d, err := NewSSD1306(bus, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cols, rows := d.Size(); cols != 21 || rows != 4 {
+		t.Fatalf("size = %dx%d", cols, rows)
+	}
+	bus.writes = nil
+	if err := d.Show([]string{"A", "", "", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	// 一次定址命令 + 128*4/16 次資料
+	if len(bus.writes) != 1+32 {
+		t.Fatalf("writes = %d", len(bus.writes))
+	}
+	var frame []byte
+	for _, w := range bus.writes[1:] {
+		if w[0] != ssd1306Data {
+			t.Fatalf("data write starts with %#x", w[0])
+		}
+		frame = append(frame, w[1:]...)
+	}
+	if !bytes.Equal(frame[:5], font5x7['A'-0x20][:]) || !bytes.Equal(frame[3*128:3*128+5], font5x7['1'-0x20][:]) {
+		t.Error("glyphs not at the start of their page")
+	}
+
+	// 畫面沒變時不重送
+	bus.writes = nil
+	d.Show([]string{"A", "", "", "1"})
+	if len(bus.writes) != 0 {
+		t.Errorf("unchanged frame sent %d writes", len(bus.writes))
+	}
+	if _, err := NewSSD1306(bus, 48); err == nil {
+		t.Error("height 48 accepted")
+	}
+}
+
+func TestHD44780(t *testing.T) {
+	saved := lcdSleep
+	lcdSleep = func(time.Duration) {}
+	t.Cleanup(func() { lcdSleep = saved })
+
+	bus := &fakeBus{}
+	d, err := NewHD44780(bus, 16, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.writes = nil
+	d.Show([]string{"Hi", "IP"})
+	if len(bus.writes) != 2 {
+		t.Fatalf("writes = %d, want one per row", len(bus.writes))
+	}
+	// 第二列：設定地址 0xC0，接著 16 個字元，每個位元組 4 個 PCF8574 輸出
+	row := bus.writes[1]
+	if len(row) != 4*17 {
+		t.Fatalf("row bytes = %d", len(row))
+	}
+	wantAddr := []byte{0xc0 | lcdEnable | lcdBacklight, 0xc0 | lcdBacklight, lcdEnable | lcdBacklight, lcdBacklight}
+	if !bytes.Equal(row[:4], wantAddr) {
+		t.Errorf("address = %#v, want %#v", row[:4], wantAddr)
+	}
+	// 'I' = 0x49 以 RS=1 送出
+	wantI := []byte{0x40 | lcdRS | lcdEnable | lcdBacklight, 0x40 | lcdRS | lcdBacklight, 0x90 | lcdRS | lcdEnable | lcdBacklight, 0x90 | lcdRS | lcdBacklight}
+	if !bytes.Equal(row[4:8], wantI) {
+		t.Errorf("char = %#v, want %#v", row[4:8], wantI)
+	}
+
+	// 只重寫變更的列
+	bus.writes = nil
+	d.Show([]string{"Hi", "IP2"})
+	if len(bus.writes) != 1 {
+		t.Errorf("writes = %d, want only the changed row", len(bus.writes))
+	}
+}
@@ -0,0 +1,112 @@
+package panel
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// SSD1306 以 I2C 連接的 128 點寬 OLED，每 8 點高一列文字 (每字 6 點寬，21 欄)
+type SSD1306 struct {
+	bus    io.WriteCloser
+	height int    // 32 或 64
+	frame  []byte // 上次送出的畫面 (相同時不重送)
+}
+
+// ssd1306Width 面板寬度 (點)
+const ssd1306Width = 128
+
+// ssd1306Chunk 每次 I2C 寫入的最大資料量 (部分 I2C 控制器限制單次傳輸長度)
+const ssd1306Chunk = 16
+
+// SSD1306 控制位元組
+const (
+	ssd1306Command = 0x00
+	ssd1306Data    = 0x40
+)
+
+// NewSSD1306 初始化面板 (height 為 32 或 64)
+func NewSSD1306(bus io.WriteCloser, height int) (*SSD1306, error) {
+	if height != 32 && height != 64 {
+		return nil, fmt.Errorf("ssd1306: height must be 32 or 64, not %d", height)
+	}
+	comPins := byte(0x12)
+	if height == 32 {
+		comPins = 0x02
+	}
+	d := &SSD1306{bus: bus, height: height}
+	err := d.command(
+		0xae,       // 關閉顯示
+		0xd5, 0x80, // 時脈
+		0xa8, byte(height-1), // multiplex
+		0xd3, 0x00, // 顯示偏移
+		0x40,       // 起始列
+		0x8d, 0x14, // 內建升壓
+		0x20, 0x00, // 水平定址
+		0xa1,          // 左右翻轉
+		0xc8,          // 上下翻轉
+		0xda, comPins, // COM 腳位
+		0x81, 0xcf, // 對比
+		0xd9, 0xf1, // 預充電
+		0xdb, 0x40, // VCOMH
+		0xa4, // 顯示 RAM 內容
+		0xa6, // 正常 (非反白)
+		0xaf, // 開啟顯示
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ssd1306: %w", err)
+	}
+	return d, nil
+}
+
+// Size 可顯示的欄數與列數
+func (d *SSD1306) Size() (cols, rows int) {
+	return ssd1306Width / 6, d.height / 8
+}
+
+// Show 顯示各列文字
+func (d *SSD1306) Show(lines []string) error {
+	frame := d.render(lines)
+	if bytes.Equal(frame, d.frame) {
+		return nil
+	}
+	if err := d.command(0x21, 0, ssd1306Width-1, 0x22, 0, byte(d.height/8-1)); err != nil {
+		return fmt.Errorf("ssd1306: %w", err)
+	}
+	buf := make([]byte, 0, ssd1306Chunk+1)
+	for i := 0; i < len(frame); i += ssd1306Chunk {
+		buf = append(buf[:0], ssd1306Data)
+		buf = append(buf, frame[i:min(i+ssd1306Chunk, len(frame))]...)
+		if _, err := d.bus.Write(buf); err != nil {
+			d.frame = nil
+			return fmt.Errorf("ssd1306: %w", err)
+		}
+	}
+	d.frame = frame
+	return nil
+}
+
+// render 繪製畫面：每列文字佔一個 page (8 點高)，位元組為一行 8 點
+func (d *SSD1306) render(lines []string) []byte {
+	cols, rows := d.Size()
+	frame := make([]byte, ssd1306Width*rows)
+	for row, line := range fit(lines, cols, rows) {
+		for col := 0; col < cols; col++ {
+			g := glyph(line[col])
+			copy(frame[row*ssd1306Width+col*6:], g[:])
+		}
+	}
+	return frame
+}
+
+// command 送出控制命令
+func (d *SSD1306) command(cmds ...byte) error {
+	_, err := d.bus.Write(append([]byte{ssd1306Command}, cmds...))
+	return err
+}
+
+// Close 關閉顯示並釋放匯流排
+func (d *SSD1306) Close() error {
+	d.command(0xae)
+	return d.bus.Close()
+}
@@ -0,0 +1,147 @@
+package pcap
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"danteCS/internal/packet"
+)
+
+//==============================================================================
+// Dante 流量
+//==============================================================================
+
+// Audinate 支援需要的流量：發現 (mDNS)、時鐘 (PTP)、控制與監控、音訊與 IGMP。
+// 其他流量 (SSH、管理網頁) 不寫入，檔案較小也不會帶出無關的資料。
+
+// 控制與監控埠
+const (
+	portMDNS           = 5353
+	portPTPEvent       = 319
+	portPTPGeneral     = 320
+	portDanteMulticast = 4321 // multicast 音訊
+	portARC            = 4440 // 路由控制
+	portCMC            = 4444 // 設備管理
+	portConMon         = 4455 // 狀態監控
+	portControlLow     = 8700 // 控制與監控 8700–8708
+	portControlHigh    = 8708
+	portDanteVia       = 8800
+	portAudioLow       = 14336 // unicast 音訊 14336–14591
+	portAudioHigh      = 14591
+)
+
+// danteUDPPort 是否為 Dante 使用的 UDP 埠
+func danteUDPPort(port uint16) bool {
+	switch {
+	case port == portMDNS, port == portPTPEvent, port == portPTPGeneral, port == portDanteMulticast,
+		port == portARC, port == portCMC, port == portConMon, port == portDanteVia:
+		return true
+	case port >= portControlLow && port <= portControlHigh,
+		port >= portAudioLow && port <= portAudioHigh:
+		return true
+	}
+	return false
+}
+
+// ethertype
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeVLAN = 0x8100
+	etherTypePTP  = 0x88f7 // PTP over Ethernet (AES67 layer 2 設定)
+)
+
+// IsDanteTraffic frame 是否為 Dante 相關的流量 (mDNS、PTP、控制、音訊、IGMP)
+func IsDanteTraffic(frame []byte) bool {
+	if len(frame) < 14 {
+		return false
+	}
+	etherType, offset := binary.BigEndian.Uint16(frame[12:]), 14
+	if etherType == etherTypeVLAN && len(frame) >= 18 {
+		etherType, offset = binary.BigEndian.Uint16(frame[16:]), 18
+	}
+	switch etherType {
+	case etherTypePTP:
+		return true
+	case etherTypeIPv4:
+	default:
+		return false
+	}
+
+	ip := frame[offset:]
+	if len(ip) < 20 || ip[0]>>4 != 4 {
+		return false
+	}
+	ihl := int(ip[0]&0x0f) * 4
+	switch ip[9] {
+	case 2: // IGMP
+		return true
+	case 17: // UDP
+	default:
+		return false
+	}
+	if binary.BigEndian.Uint16(ip[6:])&0x1fff != 0 {
+		return true // UDP 分段的後續片段 (只有大型控制訊息會分段)
+	}
+	if len(ip) < ihl+4 {
+		return false
+	}
+	return danteUDPPort(binary.BigEndian.Uint16(ip[ihl:])) || danteUDPPort(binary.BigEndian.Uint16(ip[ihl+2:]))
+}
+
+//==============================================================================
+// 擷取
+//==============================================================================
+
+// Stats 擷取的統計
+type Stats struct {
+	Interface string        `json:"interface"`
+	Duration  time.Duration `json:"duration"`
+	Seen      int           `json:"seen"`    // 介面上讀到的 frame
+	Packets   int           `json:"packets"` // 寫入的 frame
+	Bytes     int64         `json:"bytes"`   // 寫入的 frame 原始大小
+	Files     []string      `json:"files"`
+}
+
+// Capture 在介面上擷取 filter 接受的 frame 寫入 ring，直到 ctx 結束 (需要 CAP_NET_RAW)
+// filter 為 nil 時寫入所有 frame
+func Capture(ctx context.Context, iface string, ring *Ring, filter func([]byte) bool) (Stats, error) {
+	stats := Stats{Interface: iface}
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return stats, err
+	}
+	conn, err := packet.OpenFrames(ifi)
+	if err != nil {
+		return stats, fmt.Errorf("open packet socket on %s: %w", iface, err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	start := time.Now()
+	buf := make([]byte, DefaultSnaplen) // 網卡的 GRO/TSO 會交出大於 MTU 的 frame
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			stats.Duration, stats.Files = time.Since(start), ring.Files()
+			if ctx.Err() != nil {
+				return stats, nil
+			}
+			return stats, err
+		}
+		stats.Seen++
+		frame := buf[:n]
+		if filter != nil && !filter(frame) {
+			continue
+		}
+		if err := ring.WritePacket(time.Now(), frame); err != nil {
+			stats.Duration, stats.Files = time.Since(start), ring.Files()
+			return stats, err
+		}
+		stats.Packets++
+		stats.Bytes += int64(n)
+	}
+}
@@ -0,0 +1,164 @@
+// Package pcap 擷取 Dante 相關的封包並寫成 pcap 檔 (交給 Audinate 支援分析)
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// pcap 格式
+//==============================================================================
+
+// 經典 pcap 格式 (微秒時間戳記、Ethernet link type)，Wireshark 與 tcpdump 都能讀
+const (
+	magicMicroseconds = 0xa1b2c3d4
+	linkTypeEthernet  = 1
+	headerSize        = 24
+	recordHeaderSize  = 16
+)
+
+// DefaultSnaplen 每個 frame 保留的長度 (完整的 Ethernet frame)
+const DefaultSnaplen = 65535
+
+// Writer 寫入 pcap 格式
+type Writer struct {
+	w       io.Writer
+	snaplen int
+}
+
+// NewWriter 寫入檔頭並建立 Writer
+func NewWriter(w io.Writer, snaplen int) (*Writer, error) {
+	if snaplen <= 0 {
+		snaplen = DefaultSnaplen
+	}
+	var h [headerSize]byte
+	binary.LittleEndian.PutUint32(h[0:], magicMicroseconds)
+	binary.LittleEndian.PutUint16(h[4:], 2) // version 2.4
+	binary.LittleEndian.PutUint16(h[6:], 4)
+	binary.LittleEndian.PutUint32(h[16:], uint32(snaplen))
+	binary.LittleEndian.PutUint32(h[20:], linkTypeEthernet)
+	if _, err := w.Write(h[:]); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, snaplen: snaplen}, nil
+}
+
+// WritePacket 寫入一個 frame (超過 snaplen 的部分截斷)，回傳寫入的位元組數
+func (w *Writer) WritePacket(ts time.Time, frame []byte) (int, error) {
+	data := frame
+	if len(data) > w.snaplen {
+		data = data[:w.snaplen]
+	}
+	var h [recordHeaderSize]byte
+	binary.LittleEndian.PutUint32(h[0:], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(h[4:], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(h[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(h[12:], uint32(len(frame)))
+	if _, err := w.w.Write(h[:]); err != nil {
+		return 0, err
+	}
+	if _, err := w.w.Write(data); err != nil {
+		return 0, err
+	}
+	return recordHeaderSize + len(data), nil
+}
+
+//==============================================================================
+// Ring buffer
+//==============================================================================
+
+// Ring 依大小輪替的 pcap 檔 (name-001.pcap、name-002.pcap…)，只保留最新的 MaxFiles 個，
+// 長時間擷取偶發問題時不會塞滿磁碟
+type Ring struct {
+	stem     string // 去掉 .pcap 的路徑
+	maxSize  int64  // 單一檔案的大小上限 (0 表示不輪替)
+	maxFiles int    // 保留的檔案數 (0 表示全部保留)
+	snaplen  int
+
+	seq   int
+	files []string // 保留中的檔案 (舊到新)
+	file  *os.File
+	buf   *bufio.Writer
+	w     *Writer
+	size  int64
+}
+
+// NewRing 建立第一個檔案
+func NewRing(path string, maxSize int64, maxFiles, snaplen int) (*Ring, error) {
+	if maxSize < 0 || maxFiles < 0 {
+		return nil, fmt.Errorf("invalid ring buffer limits (size %d, files %d)", maxSize, maxFiles)
+	}
+	r := &Ring{stem: strings.TrimSuffix(path, filepath.Ext(path)), maxSize: maxSize, maxFiles: maxFiles, snaplen: snaplen}
+	if err := r.rotate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// WritePacket 寫入一個 frame，目前的檔案超過 maxSize 時先輪替
+func (r *Ring) WritePacket(ts time.Time, frame []byte) error {
+	if r.maxSize > 0 && r.size+int64(recordHeaderSize+min(len(frame), r.w.snaplen)) > r.maxSize && r.size > headerSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.w.WritePacket(ts, frame)
+	r.size += int64(n)
+	return err
+}
+
+// rotate 關閉目前的檔案、開啟下一個，並刪除超過 maxFiles 的舊檔
+func (r *Ring) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	r.seq++
+	name := fmt.Sprintf("%s-%03d.pcap", r.stem, r.seq)
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	r.file, r.buf = f, bufio.NewWriter(f)
+	if r.w, err = NewWriter(r.buf, r.snaplen); err != nil {
+		return err
+	}
+	r.size = headerSize
+	r.files = append(r.files, name)
+	for r.maxFiles > 0 && len(r.files) > r.maxFiles {
+		if err := os.Remove(r.files[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		r.files = r.files[1:]
+	}
+	return nil
+}
+
+// closeFile 寫出緩衝並關閉目前的檔案
+func (r *Ring) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.buf.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	return err
+}
+
+// Files 保留中的檔案 (舊到新)
+func (r *Ring) Files() []string {
+	return append([]string(nil), r.files...)
+}
+
+// Close 寫出並關閉目前的檔案
+func (r *Ring) Close() error {
+	return r.closeFile()
+}
@@ -0,0 +1,99 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Unix(1700000000, 123456789)
+	if n, err := w.WritePacket(ts, []byte{1, 2, 3, 4, 5, 6}); err != nil || n != recordHeaderSize+4 {
+		t.Fatalf("WritePacket = %d, %v", n, err)
+	}
+	b := buf.Bytes()
+	if len(b) != headerSize+recordHeaderSize+4 || binary.LittleEndian.Uint32(b) != magicMicroseconds ||
+		binary.LittleEndian.Uint32(b[20:]) != linkTypeEthernet {
+		t.Fatalf("header = %x", b[:headerSize])
+	}
+	rec := b[headerSize:]
+	if binary.LittleEndian.Uint32(rec[0:]) != 1700000000 || binary.LittleEndian.Uint32(rec[4:]) != 123456 ||
+		binary.LittleEndian.Uint32(rec[8:]) != 4 || binary.LittleEndian.Uint32(rec[12:]) != 6 {
+		t.Fatalf("record header = %x", rec[:recordHeaderSize])
+	}
+}
+
+func TestRing(t *testing.T) {
+	dir := t.TempDir()
+	// 每個檔案放得下檔頭與一個 100 位元組的 frame
+	ring, err := NewRing(filepath.Join(dir, "cap.pcap"), headerSize+recordHeaderSize+100, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := make([]byte, 100)
+	for range 4 {
+		if err := ring.WritePacket(time.Now(), frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ring.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files := ring.Files()
+	want := []string{filepath.Join(dir, "cap-003.pcap"), filepath.Join(dir, "cap-004.pcap")}
+	if len(files) != 2 || files[0] != want[0] || files[1] != want[1] {
+		t.Fatalf("files = %v, want %v", files, want)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("%d files left in the directory", len(entries))
+	}
+	info, err := os.Stat(files[1])
+	if err != nil || info.Size() != headerSize+recordHeaderSize+100 {
+		t.Fatalf("stat = %v, %v", info, err)
+	}
+}
+
+// frame 建立 Ethernet/IPv4 frame (vlan 時加上 802.1Q tag)
+func frame(proto byte, dstPort uint16, vlan bool) []byte {
+	f := make([]byte, 12)
+	if vlan {
+		f = append(f, 0x81, 0x00, 0x00, 0x0a)
+	}
+	f = append(f, 0x08, 0x00)
+	ip := make([]byte, 28)
+	ip[0], ip[9] = 0x45, proto
+	binary.BigEndian.PutUint16(ip[20:], 50000)
+	binary.BigEndian.PutUint16(ip[22:], dstPort)
+	return append(f, ip...)
+}
+
+func TestIsDanteTraffic(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		frame []byte
+		want  bool
+	}{
+		{"mdns", frame(17, 5353, false), true},
+		{"ptp over vlan", frame(17, 319, true), true},
+		{"unicast audio", frame(17, 14400, false), true},
+		{"igmp", frame(2, 0, false), true},
+		{"dns", frame(17, 53, false), false},
+		{"tcp", frame(6, 8700, false), false},
+		{"ptp over ethernet", append(make([]byte, 12), 0x88, 0xf7, 0, 0), true},
+		{"arp", append(make([]byte, 12), 0x08, 0x06, 0, 0), false},
+		{"short", []byte{1, 2, 3}, false},
+	} {
+		if got := IsDanteTraffic(tc.frame); got != tc.want {
+			t.Errorf("%s: IsDanteTraffic = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
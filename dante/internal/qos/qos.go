@@ -0,0 +1,271 @@
+// Package qos 取樣 Dante 介面上的封包，檢查 PTP 與音訊的 DSCP 標記
+package qos
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// DSCP 與流量分類
+//==============================================================================
+
+// Dante 設備以 DSCP 標記時間關鍵的流量，交換器依標記排入優先佇列：
+//
+//	PTP event (UDP 319)        CS7 (56)
+//	PTP general (UDP 320)      EF  (46)
+//	Dante 音訊                 EF  (46)
+//
+// 交換器啟用 QoS 卻沒有信任 DSCP 時會把標記改寫為 0 (best effort)，
+// 網路一忙 PTP 與音訊就和檔案傳輸搶同一個佇列，造成時鐘跳動與掉音。
+// AES67 串流的標記依廠商而異 (媒體常用 AF41)，不列入檢查。
+
+// DSCP 值
+const (
+	DSCPBestEffort = 0
+	DSCPEF         = 46
+	DSCPCS7        = 56
+)
+
+// Class 需要檢查標記的流量類別
+type Class string
+
+const (
+	ClassPTPEvent   Class = "ptp-event"   // Sync、Delay_Req (時間戳記的封包)
+	ClassPTPGeneral Class = "ptp-general" // Follow_Up、Announce 等
+	ClassAudio      Class = "audio"       // Dante unicast/multicast 音訊
+)
+
+// Classes 依優先順序排列的類別
+var Classes = []Class{ClassPTPEvent, ClassPTPGeneral, ClassAudio}
+
+// Expected 類別應有的 DSCP (Audinate 建議值)
+func (c Class) Expected() uint8 {
+	if c == ClassPTPEvent {
+		return DSCPCS7
+	}
+	return DSCPEF
+}
+
+// UDP 埠
+const (
+	portPTPEvent       = 319
+	portPTPGeneral     = 320
+	portDanteMulticast = 4321  // Dante multicast 音訊
+	portDanteAudioLow  = 14336 // Dante unicast 音訊 14336–14591
+	portDanteAudioHigh = 14591
+)
+
+// DSCPName DSCP 的常用名稱 (沒有名稱時為數值)
+func DSCPName(v uint8) string {
+	switch {
+	case v == DSCPBestEffort:
+		return "BE"
+	case v == DSCPEF:
+		return "EF"
+	case v%8 == 0:
+		return fmt.Sprintf("CS%d", v/8)
+	case v >= 10 && v <= 38 && v%2 == 0:
+		return fmt.Sprintf("AF%d%d", v/8, v%8/2)
+	}
+	return fmt.Sprintf("DSCP %d", v)
+}
+
+//==============================================================================
+// 封包
+//==============================================================================
+
+// Packet 取樣需要的 IPv4/UDP 欄位
+type Packet struct {
+	Source  netip.Addr
+	Dest    netip.Addr
+	DSCP    uint8
+	SrcPort uint16
+	DstPort uint16
+}
+
+// ErrNotUDP 不是 UDP 封包 (或是分段的後續片段，沒有 UDP 標頭)
+var ErrNotUDP = errors.New("not a UDP packet")
+
+// ParseIPv4 解析 IPv4 封包 (含 IP 標頭，不含 Ethernet 標頭)
+func ParseIPv4(b []byte) (Packet, error) {
+	if len(b) < 20 {
+		return Packet{}, fmt.Errorf("IPv4 packet too short (%d bytes)", len(b))
+	}
+	if b[0]>>4 != 4 {
+		return Packet{}, fmt.Errorf("not IPv4 (version %d)", b[0]>>4)
+	}
+	ihl := int(b[0]&0x0f) * 4
+	if ihl < 20 || len(b) < ihl {
+		return Packet{}, fmt.Errorf("invalid IPv4 header length %d", ihl)
+	}
+	p := Packet{
+		DSCP:   b[1] >> 2,
+		Source: netip.AddrFrom4([4]byte(b[12:16])),
+		Dest:   netip.AddrFrom4([4]byte(b[16:20])),
+	}
+	fragOffset := (uint16(b[6])<<8 | uint16(b[7])) & 0x1fff
+	if b[9] != 17 || fragOffset != 0 {
+		return p, ErrNotUDP
+	}
+	if len(b) < ihl+8 {
+		return p, fmt.Errorf("UDP header truncated (%d bytes)", len(b)-ihl)
+	}
+	p.SrcPort = uint16(b[ihl])<<8 | uint16(b[ihl+1])
+	p.DstPort = uint16(b[ihl+2])<<8 | uint16(b[ihl+3])
+	return p, nil
+}
+
+// Classify 封包的流量類別 (不需要檢查的封包回傳 false)
+func Classify(p Packet) (Class, bool) {
+	switch {
+	case p.DstPort == portPTPEvent:
+		return ClassPTPEvent, true
+	case p.DstPort == portPTPGeneral:
+		return ClassPTPGeneral, true
+	case p.DstPort == portDanteMulticast && p.Dest.IsMulticast(),
+		p.DstPort >= portDanteAudioLow && p.DstPort <= portDanteAudioHigh:
+		return ClassAudio, true
+	}
+	return "", false
+}
+
+//==============================================================================
+// 檢查結果
+//==============================================================================
+
+// 判定
+const (
+	VerdictOK       = "ok"       // 標記正確
+	VerdictStripped = "stripped" // 標記被改寫為 best effort
+	VerdictRemarked = "remarked" // 標記被改成其他值
+	VerdictMixed    = "mixed"    // 部分封包標記正確
+)
+
+// Flow 單一來源、單一類別的取樣結果
+type Flow struct {
+	Interface string         `json:"interface"`
+	Source    string         `json:"source"`
+	Device    string         `json:"device,omitempty"` // 來源地址對應的 Dante 設備
+	Class     Class          `json:"class"`
+	Expected  string         `json:"expected"`
+	Packets   int            `json:"packets"`
+	Marked    int            `json:"marked"` // 標記正確的封包數
+	DSCP      map[string]int `json:"dscp"`   // 收到的標記 → 封包數
+	Verdict   string         `json:"verdict"`
+}
+
+// verdict 依收到的標記判定
+func (f *Flow) verdict() string {
+	switch {
+	case f.Marked == f.Packets:
+		return VerdictOK
+	case f.Marked > 0:
+		return VerdictMixed
+	case f.DSCP[DSCPName(DSCPBestEffort)] == f.Packets:
+		return VerdictStripped
+	}
+	return VerdictRemarked
+}
+
+// received 收到的標記 (依封包數由多到少)
+func (f *Flow) received() string {
+	names := make([]string, 0, len(f.DSCP))
+	for name := range f.DSCP {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if c := f.DSCP[b] - f.DSCP[a]; c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	return strings.Join(names, "/")
+}
+
+// label 來源的顯示名稱
+func (f *Flow) label() string {
+	if f.Device != "" {
+		return fmt.Sprintf("%s (%s)", f.Device, f.Source)
+	}
+	return f.Source
+}
+
+// Report 單一介面的檢查結果
+type Report struct {
+	Interface string        `json:"interface"`
+	Sampled   time.Duration `json:"sampled"` // 取樣的時間
+	Flows     []Flow        `json:"flows"`
+	Problems  []string      `json:"problems,omitempty"`
+	Notes     []string      `json:"notes,omitempty"`
+}
+
+// Verify 檢查介面收到的標記 (devices 為地址 → Dante 設備名稱，可為 nil)
+// 同一類別每個來源都被清除時，問題在本機連接的交換器埠或共用的上行；
+// 只有部分來源被清除時，問題在那些設備與本機之間的交換器
+func Verify(iface string, flows []Flow, sampled time.Duration, devices map[string]string) Report {
+	r := Report{Interface: iface, Sampled: sampled, Flows: []Flow{}}
+	for _, f := range flows {
+		if f.Interface == iface {
+			f.Device = devices[f.Source]
+			r.Flows = append(r.Flows, f)
+		}
+	}
+	if sampled == 0 {
+		r.Notes = append(r.Notes, "traffic not sampled")
+		return r
+	}
+
+	for _, class := range Classes {
+		var sources, stripped []*Flow
+		for i := range r.Flows {
+			if f := &r.Flows[i]; f.Class == class {
+				sources = append(sources, f)
+				if f.Verdict == VerdictStripped {
+					stripped = append(stripped, f)
+				}
+			}
+		}
+		if len(sources) == 0 {
+			r.Notes = append(r.Notes, missingNote(class))
+			continue
+		}
+		expected := DSCPName(class.Expected())
+		if len(sources) > 1 && len(stripped) == len(sources) {
+			r.Problems = append(r.Problems, fmt.Sprintf(
+				"%s from all %d sources arrives as BE instead of %s: the switch port this host is connected to "+
+					"(or an uplink shared by every path) does not trust DSCP", class, len(sources), expected))
+			continue
+		}
+		for _, f := range sources {
+			switch f.Verdict {
+			case VerdictStripped:
+				r.Problems = append(r.Problems, fmt.Sprintf(
+					"%s from %s arrives as BE instead of %s: a switch between it and this host strips DSCP "+
+						"(enable DSCP trust on its ports)", class, f.label(), expected))
+			case VerdictRemarked:
+				r.Problems = append(r.Problems, fmt.Sprintf(
+					"%s from %s arrives as %s instead of %s: a switch between it and this host re-marks the traffic",
+					class, f.label(), f.received(), expected))
+			case VerdictMixed:
+				r.Problems = append(r.Problems, fmt.Sprintf(
+					"%s from %s: only %d of %d packets marked %s (also %s), the paths through the network treat it differently",
+					class, f.label(), f.Marked, f.Packets, expected, f.received()))
+			}
+		}
+	}
+	return r
+}
+
+// missingNote 沒有取樣到類別時的說明
+func missingNote(class Class) string {
+	if class == ClassAudio {
+		return "no Dante audio sampled: this host only sees unicast flows sent to it and multicast flows " +
+			"that are joined (-group) or flooded to its port"
+	}
+	return fmt.Sprintf("no %s packets sampled (is a Dante device the clock leader on this network?)", class)
+}
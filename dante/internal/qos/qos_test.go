@@ -0,0 +1,124 @@
+package qos
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+// udp 建立 IPv4/UDP 封包
+func udp(src, dst string, dscp uint8, dstPort uint16) []byte {
+	b := make([]byte, 28)
+	b[0] = 0x45
+	b[1] = dscp << 2
+	b[9] = 17
+	s, d := netip.MustParseAddr(src).As4(), netip.MustParseAddr(dst).As4()
+	copy(b[12:16], s[:])
+	copy(b[16:20], d[:])
+	b[20], b[21] = 0x01, 0x3f
+	b[22], b[23] = byte(dstPort>>8), byte(dstPort)
+	return b
+}
+
+func TestParseIPv4(t *testing.T) {
+	p, err := ParseIPv4(udp("10.0.1.5", "224.0.1.129", DSCPCS7, 319))
+	if err != nil || p.DSCP != DSCPCS7 || p.Source.String() != "10.0.1.5" || p.SrcPort != 319 || p.DstPort != 319 {
+		t.Fatalf("packet = %+v, %v", p, err)
+	}
+	if c, ok := Classify(p); !ok || c != ClassPTPEvent {
+		t.Fatalf("class = %q, %v", c, ok)
+	}
+
+	tcp := udp("10.0.1.5", "10.0.1.1", 0, 80)
+	tcp[9] = 6
+	if _, err := ParseIPv4(tcp); err != ErrNotUDP {
+		t.Fatalf("tcp: err = %v", err)
+	}
+	if _, err := ParseIPv4(udp("10.0.1.5", "10.0.1.1", 0, 80)[:24]); err == nil {
+		t.Fatal("truncated UDP header accepted")
+	}
+
+	// 4321 只有 multicast 是 Dante 音訊
+	for _, tc := range []struct {
+		dst  string
+		port uint16
+		ok   bool
+	}{
+		{"239.255.1.10", 4321, true},
+		{"10.0.1.1", 4321, false},
+		{"10.0.1.1", 14400, true},
+		{"224.0.0.251", 5353, false},
+	} {
+		p, _ := ParseIPv4(udp("10.0.1.5", tc.dst, 0, tc.port))
+		if c, ok := Classify(p); ok != tc.ok || (ok && c != ClassAudio) {
+			t.Errorf("%s:%d: class = %q, %v", tc.dst, tc.port, c, ok)
+		}
+	}
+}
+
+func TestDSCPName(t *testing.T) {
+	for v, want := range map[uint8]string{0: "BE", 46: "EF", 56: "CS7", 8: "CS1", 34: "AF41", 5: "DSCP 5"} {
+		if got := DSCPName(v); got != want {
+			t.Errorf("DSCPName(%d) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+// sample 以封包建立 Sampler 並檢查
+func sample(t *testing.T, packets ...[]byte) Report {
+	t.Helper()
+	s := NewSampler()
+	s.AddLocal(netip.MustParseAddr("10.0.1.1"))
+	s.started = time.Now().Add(-10 * time.Second)
+	for _, p := range packets {
+		if _, err := s.Handle("eth1", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Verify("eth1", s.Flows(), s.Sampled(), map[string]string{"10.0.1.5": "Stagebox"})
+}
+
+func TestVerify(t *testing.T) {
+	// 正確標記，本機送出的封包不列入
+	r := sample(t,
+		udp("10.0.1.5", "224.0.1.129", DSCPCS7, 319),
+		udp("10.0.1.5", "224.0.1.129", DSCPEF, 320),
+		udp("10.0.1.5", "239.255.1.10", DSCPEF, 4321),
+		udp("10.0.1.1", "10.0.1.5", 0, 14400))
+	if len(r.Problems) != 0 || len(r.Flows) != 3 || r.Flows[0].Device != "Stagebox" || r.Flows[0].Verdict != VerdictOK {
+		t.Fatalf("report = %+v", r)
+	}
+
+	// 只有一台設備被清除：問題在它的路徑上
+	r = sample(t,
+		udp("10.0.1.5", "224.0.1.129", 0, 319),
+		udp("10.0.1.6", "224.0.1.129", DSCPCS7, 319))
+	if len(r.Problems) != 1 || !strings.Contains(r.Problems[0], "Stagebox (10.0.1.5) arrives as BE instead of CS7") {
+		t.Fatalf("problems = %v", r.Problems)
+	}
+	if !strings.Contains(strings.Join(r.Notes, "\n"), "no Dante audio sampled") {
+		t.Fatalf("notes = %v", r.Notes)
+	}
+
+	// 所有來源都被清除：問題在本機的交換器埠
+	r = sample(t,
+		udp("10.0.1.5", "239.255.1.10", 0, 4321),
+		udp("10.0.1.6", "10.0.1.1", 0, 14400))
+	if len(r.Problems) != 1 || !strings.Contains(r.Problems[0], "all 2 sources") {
+		t.Fatalf("problems = %v", r.Problems)
+	}
+
+	// 改寫與部分標記
+	r = sample(t,
+		udp("10.0.1.5", "239.255.1.10", 34, 4321),
+		udp("10.0.1.6", "239.255.1.11", DSCPEF, 4321),
+		udp("10.0.1.6", "239.255.1.11", 0, 4321))
+	if r.Flows[0].Verdict != VerdictRemarked || r.Flows[1].Verdict != VerdictMixed || len(r.Problems) != 2 {
+		t.Fatalf("report = %+v", r)
+	}
+
+	if r := Verify("eth1", nil, 0, nil); len(r.Notes) != 1 || r.Flows == nil {
+		t.Fatalf("not sampled = %+v", r)
+	}
+}
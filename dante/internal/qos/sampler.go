@@ -0,0 +1,183 @@
+package qos
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/packet"
+)
+
+//==============================================================================
+// 取樣
+//==============================================================================
+
+// maxPacket 取樣讀取的長度 (只需要 IP 與 UDP 標頭)
+const maxPacket = 128
+
+// PTPGroups Dante 的 PTP 群組 (取樣時加入，讓 IGMP snooping 的交換器把 PTP 送過來)
+var PTPGroups = []netip.Addr{
+	netip.AddrFrom4([4]byte{224, 0, 1, 129}),
+	netip.AddrFrom4([4]byte{224, 0, 1, 130}),
+	netip.AddrFrom4([4]byte{224, 0, 1, 131}),
+	netip.AddrFrom4([4]byte{224, 0, 1, 132}),
+}
+
+// flowKey 累計的單位
+type flowKey struct {
+	iface  string
+	source netip.Addr
+	class  Class
+}
+
+// Sampler 依介面、來源與類別累計封包的 DSCP
+type Sampler struct {
+	mu      sync.Mutex
+	flows   map[flowKey]*Flow
+	local   map[netip.Addr]bool // 本機地址 (自己送出的封包不列入)
+	started time.Time
+	now     func() time.Time
+}
+
+// NewSampler 建立 Sampler
+func NewSampler() *Sampler {
+	return &Sampler{flows: make(map[flowKey]*Flow), local: make(map[netip.Addr]bool), now: time.Now}
+}
+
+// AddLocal 登記本機地址
+func (s *Sampler) AddLocal(addrs ...netip.Addr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range addrs {
+		s.local[a] = true
+	}
+}
+
+// Handle 處理在 iface 收到的 IPv4 封包，回傳是否列入統計
+func (s *Sampler) Handle(iface string, data []byte) (bool, error) {
+	p, err := ParseIPv4(data)
+	if err != nil {
+		if err == ErrNotUDP {
+			return false, nil
+		}
+		return false, err
+	}
+	class, ok := Classify(p)
+	if !ok {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.local[p.Source] {
+		return false, nil
+	}
+	key := flowKey{iface, p.Source, class}
+	f, ok := s.flows[key]
+	if !ok {
+		f = &Flow{Interface: iface, Source: p.Source.String(), Class: class,
+			Expected: DSCPName(class.Expected()), DSCP: make(map[string]int)}
+		s.flows[key] = f
+	}
+	f.Packets++
+	f.DSCP[DSCPName(p.DSCP)]++
+	if p.DSCP == class.Expected() {
+		f.Marked++
+	}
+	return true, nil
+}
+
+// Flows 目前的統計 (依介面、類別與來源排序)
+func (s *Sampler) Flows() []Flow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Flow, 0, len(s.flows))
+	for _, f := range s.flows {
+		flow := *f
+		flow.DSCP = make(map[string]int, len(f.DSCP))
+		for k, v := range f.DSCP {
+			flow.DSCP[k] = v
+		}
+		flow.Verdict = flow.verdict()
+		list = append(list, flow)
+	}
+	slices.SortFunc(list, func(a, b Flow) int {
+		if c := strings.Compare(a.Interface, b.Interface); c != 0 {
+			return c
+		}
+		if c := slices.Index(Classes, a.Class) - slices.Index(Classes, b.Class); c != 0 {
+			return c
+		}
+		return netip.MustParseAddr(a.Source).Compare(netip.MustParseAddr(b.Source))
+	})
+	return list
+}
+
+// Sampled 開始取樣後經過的時間 (尚未開始為 0)
+func (s *Sampler) Sampled() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started.IsZero() {
+		return 0
+	}
+	return s.now().Sub(s.started)
+}
+
+// Sample 在介面上取樣 IPv4 封包直到 ctx 結束 (需要 CAP_NET_RAW)
+// 取樣期間加入 PTP 群組與 groups (例如要檢查的 multicast flow)
+func (s *Sampler) Sample(ctx context.Context, iface string, groups []netip.Addr) error {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return err
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return err
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok {
+			if addr, ok := netip.AddrFromSlice(ipnet.IP); ok {
+				s.AddLocal(addr.Unmap())
+			}
+		}
+	}
+
+	conn, err := packet.OpenIPv4(ifi)
+	if err != nil {
+		return fmt.Errorf("open packet socket on %s: %w", iface, err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	for _, g := range append(slices.Clone(PTPGroups), groups...) {
+		mc, err := net.ListenMulticastUDP("udp4", ifi, &net.UDPAddr{IP: g.AsSlice()})
+		if err != nil {
+			return fmt.Errorf("join %s on %s: %w", g, iface, err)
+		}
+		defer mc.Close()
+	}
+
+	s.mu.Lock()
+	if s.started.IsZero() {
+		s.started = s.now()
+	}
+	s.mu.Unlock()
+
+	buf := make([]byte, maxPacket)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		s.Handle(iface, buf[:n])
+	}
+}
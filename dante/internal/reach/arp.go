@@ -0,0 +1,89 @@
+package reach
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"danteCS/internal/packet"
+)
+
+//==============================================================================
+// ARP
+//==============================================================================
+
+// 設備關閉 ICMP 或被防火牆擋掉時仍會回應 ARP (否則無法收到任何 IP 封包)，
+// 只適用於與介面同網段的設備
+
+// ARP 欄位
+const (
+	etherTypeARP = 0x0806
+	arpRequest   = 1
+	arpReply     = 2
+	arpFrameSize = 42
+)
+
+// arpRequestFrame 建立詢問 target 的廣播 ARP 請求
+func arpRequestFrame(mac net.HardwareAddr, source, target netip.Addr) []byte {
+	b := make([]byte, arpFrameSize)
+	copy(b[0:6], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	copy(b[6:12], mac)
+	binary.BigEndian.PutUint16(b[12:], etherTypeARP)
+	binary.BigEndian.PutUint16(b[14:], 1)      // Ethernet
+	binary.BigEndian.PutUint16(b[16:], 0x0800) // IPv4
+	b[18], b[19] = 6, 4
+	binary.BigEndian.PutUint16(b[20:], arpRequest)
+	copy(b[22:28], mac)
+	s, t := source.As4(), target.As4()
+	copy(b[28:32], s[:])
+	copy(b[38:42], t[:])
+	return b
+}
+
+// parseARPSender frame 為其他主機送出的 ARP 時回傳發送者的地址
+// (回應或設備自己的公告都表示地址在線上)
+func parseARPSender(frame []byte, own net.HardwareAddr) (netip.Addr, bool) {
+	if len(frame) < arpFrameSize || binary.BigEndian.Uint16(frame[12:]) != etherTypeARP {
+		return netip.Addr{}, false
+	}
+	op := binary.BigEndian.Uint16(frame[20:])
+	if (op != arpRequest && op != arpReply) || bytes.Equal(frame[22:28], own) {
+		return netip.Addr{}, false
+	}
+	addr := netip.AddrFrom4([4]byte(frame[28:32]))
+	if addr.IsUnspecified() {
+		return netip.Addr{}, false // 位址衝突偵測的 probe
+	}
+	return addr, true
+}
+
+// arpAll 從介面對每個地址送出 ARP 請求
+func arpAll(ctx context.Context, ifi *net.Interface, local []netip.Prefix, targets []netip.Addr) (map[netip.Addr]time.Duration, error) {
+	if len(ifi.HardwareAddr) != 6 {
+		return nil, fmt.Errorf("%s is not an Ethernet interface", ifi.Name)
+	}
+	conn, err := packet.OpenFrames(ifi)
+	if err != nil {
+		return nil, fmt.Errorf("open packet socket: %w", err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 1600)
+	return collect(ctx, conn, targets,
+		func(target netip.Addr) error {
+			_, err := conn.Write(arpRequestFrame(ifi.HardwareAddr, sourceFor(local, target), target))
+			return err
+		},
+		func() (netip.Addr, time.Time, error) {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return netip.Addr{}, time.Time{}, err
+			}
+			addr, _ := parseARPSender(buf[:n], ifi.HardwareAddr)
+			return addr, time.Time{}, nil
+		})
+}
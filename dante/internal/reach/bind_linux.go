@@ -0,0 +1,18 @@
+//go:build linux
+
+package reach
+
+import "syscall"
+
+// bindToDevice 讓 socket 只使用介面 (多個 Dante 介面都有 link-local 地址時依路由會選錯介面)
+func bindToDevice(name string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var serr error
+		if err := c.Control(func(fd uintptr) {
+			serr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, name)
+		}); err != nil {
+			return err
+		}
+		return serr
+	}
+}
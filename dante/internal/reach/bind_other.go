@@ -0,0 +1,10 @@
+//go:build !linux
+
+package reach
+
+import "syscall"
+
+// bindToDevice 其他平台不綁定介面 (依路由選擇)
+func bindToDevice(name string) func(network, address string, c syscall.RawConn) error {
+	return nil
+}
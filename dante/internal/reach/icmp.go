@@ -0,0 +1,144 @@
+package reach
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"time"
+)
+
+//==============================================================================
+// ICMP echo
+//==============================================================================
+
+// ICMP 類型
+const (
+	icmpEchoReply   = 0
+	icmpEchoRequest = 8
+)
+
+// errNotReply 不是這次檢查的 echo reply
+var errNotReply = errors.New("not a matching echo reply")
+
+// echoRequest 建立 echo request，payload 為送出的時間 (回應原樣帶回，用來計算往返時間)
+func echoRequest(id, seq uint16, sent time.Time) []byte {
+	b := make([]byte, 16)
+	b[0] = icmpEchoRequest
+	binary.BigEndian.PutUint16(b[4:], id)
+	binary.BigEndian.PutUint16(b[6:], seq)
+	binary.BigEndian.PutUint64(b[8:], uint64(sent.UnixNano()))
+	binary.BigEndian.PutUint16(b[2:], checksum(b))
+	return b
+}
+
+// parseEchoReply 解析 echo reply (不含 IP 標頭)，回傳 request 送出的時間
+func parseEchoReply(b []byte, id uint16) (time.Time, error) {
+	if len(b) < 16 || b[0] != icmpEchoReply || binary.BigEndian.Uint16(b[4:]) != id {
+		return time.Time{}, errNotReply
+	}
+	if checksum(b) != 0 {
+		return time.Time{}, errors.New("invalid ICMP checksum")
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b[8:]))), nil
+}
+
+// checksum 網際網路 checksum (訊息正確時為 0)
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// pingAll 從介面對每個地址送出 echo request
+func pingAll(ctx context.Context, ifi *net.Interface, targets []netip.Addr) (map[netip.Addr]time.Duration, error) {
+	lc := net.ListenConfig{Control: bindToDevice(ifi.Name)}
+	pc, err := lc.ListenPacket(ctx, "ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return nil, fmt.Errorf("open ICMP socket: %w", err)
+	}
+	defer pc.Close()
+
+	id := uint16(rand.Uint32())
+	var seq uint16
+	buf := make([]byte, 1500)
+	return collect(ctx, pc, targets,
+		func(target netip.Addr) error {
+			seq++
+			_, err := pc.WriteTo(echoRequest(id, seq, time.Now()), &net.IPAddr{IP: target.AsSlice()})
+			return err
+		},
+		func() (netip.Addr, time.Time, error) {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				return netip.Addr{}, time.Time{}, err
+			}
+			addr, _ := netip.AddrFromSlice(from.(*net.IPAddr).IP)
+			sent, err := parseEchoReply(buf[:n], id)
+			if err != nil {
+				return netip.Addr{}, time.Time{}, nil
+			}
+			return addr.Unmap(), sent, nil
+		})
+}
+
+// collect 重送請求直到每個地址都回應或 ctx 結束 (read 以零值地址表示不相關的封包，
+// sent 為零時以最後一次送出的時間計算往返時間)
+func collect(ctx context.Context, conn interface{ SetReadDeadline(time.Time) error }, targets []netip.Addr,
+	send func(netip.Addr) error, read func() (netip.Addr, time.Time, error)) (map[netip.Addr]time.Duration, error) {
+	deadline, _ := ctx.Deadline()
+	interval := resendInterval(ctx)
+	replies := make(map[netip.Addr]time.Duration, len(targets))
+	lastSent := make(map[netip.Addr]time.Time, len(targets))
+	var next time.Time
+	for len(replies) < len(targets) && ctx.Err() == nil {
+		if now := time.Now(); !now.Before(next) {
+			for _, t := range targets {
+				if _, ok := replies[t]; ok {
+					continue
+				}
+				if err := send(t); err != nil {
+					return replies, fmt.Errorf("send to %s: %w", t, err)
+				}
+				lastSent[t] = time.Now()
+			}
+			next = time.Now().Add(interval)
+		}
+		readDeadline := next
+		if deadline.Before(next) {
+			readDeadline = deadline
+		}
+		conn.SetReadDeadline(readDeadline)
+		from, sent, err := read()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			return replies, err
+		}
+		at, ok := lastSent[from]
+		if _, done := replies[from]; !ok || done {
+			continue
+		}
+		if !sent.IsZero() {
+			at = sent
+		}
+		replies[from] = time.Since(at)
+	}
+	return replies, nil
+}
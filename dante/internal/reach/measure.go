@@ -0,0 +1,106 @@
+package reach
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+//==============================================================================
+// 往返時間量測
+//==============================================================================
+
+// 單次 echo 只能確認設備在線上；估算傳輸延遲需要多輪樣本，取最大值與
+// 抖動，才看得出偶發的排隊延遲 (交換器 buffer、未設定 QoS 的上行埠)。
+
+// Series 單一地址多輪 echo 的往返時間統計
+type Series struct {
+	Interface string        `json:"interface"`
+	Address   string        `json:"address"`
+	Sent      int           `json:"sent"`     // 輪數
+	Received  int           `json:"received"` // 有回應的輪數
+	Min       time.Duration `json:"min,omitempty"`
+	Avg       time.Duration `json:"avg,omitempty"`
+	Max       time.Duration `json:"max,omitempty"`
+	Jitter    time.Duration `json:"jitter,omitempty"` // 相鄰樣本差的平均
+	Error     string        `json:"error,omitempty"`
+}
+
+// Loss 沒有回應的輪數比例
+func (s Series) Loss() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Sent-s.Received) / float64(s.Sent)
+}
+
+// Measure 從 iface 對 targets 送出 count 輪 ICMP echo (每輪等待 timeout，輪與輪間隔 interval)，
+// 回傳與 targets 相同順序的統計
+func Measure(ctx context.Context, iface string, targets []netip.Addr, count int, interval, timeout time.Duration) []Series {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	samples := make([][]time.Duration, len(targets))
+	series := make([]Series, len(targets))
+	for i, t := range targets {
+		series[i] = Series{Interface: iface, Address: t.String()}
+	}
+	if len(targets) == 0 {
+		return series
+	}
+
+	for round := 0; round < count && ctx.Err() == nil; round++ {
+		if round > 0 && interval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		replies, err := probe(ctx, MethodICMP, iface, targets, timeout)
+		if err != nil {
+			for i := range series {
+				series[i].Error = err.Error()
+			}
+			break
+		}
+		for i, t := range targets {
+			series[i].Sent++
+			if rtt, ok := replies[t]; ok {
+				samples[i] = append(samples[i], rtt)
+			}
+		}
+	}
+
+	for i := range series {
+		summarize(&series[i], samples[i])
+		if series[i].Error == "" && series[i].Sent > 0 && series[i].Received == 0 {
+			series[i].Error = "no echo reply within " + timeout.String()
+		}
+	}
+	return series
+}
+
+// summarize 計算樣本的最小、平均、最大與抖動
+func summarize(s *Series, rtts []time.Duration) {
+	s.Received = len(rtts)
+	if len(rtts) == 0 {
+		return
+	}
+	var sum, diff time.Duration
+	for i, rtt := range rtts {
+		sum += rtt
+		if i > 0 {
+			d := rtt - rtts[i-1]
+			diff += max(d, -d)
+		}
+	}
+	s.Min, s.Max = slices.Min(rtts), slices.Max(rtts)
+	s.Avg = sum / time.Duration(len(rtts))
+	if len(rtts) > 1 {
+		s.Jitter = diff / time.Duration(len(rtts)-1)
+	}
+}
@@ -0,0 +1,139 @@
+// Package reach 以 ICMP echo 或 ARP 確認設備地址實際可達並量測往返時間
+package reach
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+//==============================================================================
+// 可達性檢查
+//==============================================================================
+
+// mDNS 快取讓已關機或拔線的設備在列表上多停留一段時間，而 IP 衝突、錯誤的
+// VLAN 或只剩次要網路的設備在列表上看起來都正常。發現後從對應的介面對每個
+// 地址送出 ICMP echo (會量到往返時間) 或 ARP 請求 (設備關閉 ICMP 時仍可確認
+// 同網段的設備存在)，兩者都需要 CAP_NET_RAW。
+
+// 檢查方式
+const (
+	MethodICMP = "icmp"
+	MethodARP  = "arp"
+)
+
+// DefaultTimeout 等待回應的時間 (期間重送 DefaultAttempts 次)
+const (
+	DefaultTimeout  = 2 * time.Second
+	DefaultAttempts = 3
+)
+
+// Result 單一地址的檢查結果
+type Result struct {
+	Interface string        `json:"interface"`
+	Address   string        `json:"address"`
+	Method    string        `json:"method"`
+	Reachable bool          `json:"reachable"`
+	RTT       time.Duration `json:"rtt,omitempty"` // 第一個回應的往返時間
+	Error     string        `json:"error,omitempty"`
+}
+
+// ErrUnknownMethod 不支援的檢查方式
+var ErrUnknownMethod = errors.New("unknown reachability method")
+
+// ParseMethod 驗證檢查方式
+func ParseMethod(s string) (string, error) {
+	switch s {
+	case MethodICMP, MethodARP:
+		return s, nil
+	}
+	return "", fmt.Errorf("%w %q (use %s or %s)", ErrUnknownMethod, s, MethodICMP, MethodARP)
+}
+
+// Probe 從 iface 檢查 targets，回傳與 targets 相同順序的結果
+// 介面無法使用 (沒有 CAP_NET_RAW、沒有 IPv4 地址) 時每個結果帶有錯誤
+func Probe(ctx context.Context, method, iface string, targets []netip.Addr, timeout time.Duration) []Result {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	results := make([]Result, len(targets))
+	for i, t := range targets {
+		results[i] = Result{Interface: iface, Address: t.String(), Method: method}
+	}
+	if len(targets) == 0 {
+		return results
+	}
+
+	replies, err := probe(ctx, method, iface, targets, timeout)
+	for i, t := range targets {
+		switch rtt, ok := replies[t]; {
+		case ok:
+			results[i].Reachable, results[i].RTT = true, rtt
+		case err != nil:
+			results[i].Error = err.Error()
+		default:
+			results[i].Error = fmt.Sprintf("no %s reply within %s", method, timeout)
+		}
+	}
+	return results
+}
+
+// probe 送出請求並收集回應 (地址 → 往返時間)
+func probe(ctx context.Context, method, iface string, targets []netip.Addr, timeout time.Duration) (map[netip.Addr]time.Duration, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+	local, err := localAddrs(ifi)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch method {
+	case MethodICMP:
+		return pingAll(ctx, ifi, targets)
+	case MethodARP:
+		return arpAll(ctx, ifi, local, targets)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownMethod, method)
+}
+
+// localAddrs 介面的 IPv4 網段
+func localAddrs(ifi *net.Interface) ([]netip.Prefix, error) {
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []netip.Prefix
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok {
+			if p, err := netip.ParsePrefix(ipnet.String()); err == nil && p.Addr().Is4() {
+				prefixes = append(prefixes, p)
+			}
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("%s has no IPv4 address", ifi.Name)
+	}
+	return prefixes, nil
+}
+
+// sourceFor 送往 target 時使用的本機地址 (同網段的地址優先，例如 link-local 設備用 169.254 別名)
+func sourceFor(local []netip.Prefix, target netip.Addr) netip.Addr {
+	if i := slices.IndexFunc(local, func(p netip.Prefix) bool { return p.Contains(target) }); i >= 0 {
+		return local[i].Addr()
+	}
+	return local[0].Addr()
+}
+
+// resendInterval 重送的間隔
+func resendInterval(ctx context.Context) time.Duration {
+	deadline, _ := ctx.Deadline()
+	return max(time.Until(deadline)/DefaultAttempts, 50*time.Millisecond)
+}
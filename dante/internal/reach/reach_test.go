@@ -0,0 +1,102 @@
+package reach
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestEchoRoundTrip(t *testing.T) {
+	sent := time.Unix(1700000000, 123456789)
+	req := echoRequest(0x1234, 7, sent)
+	if checksum(req) != 0 {
+		t.Fatalf("request checksum does not verify: %x", checksum(req))
+	}
+
+	// 設備把 type 改成 reply 並重算 checksum，其餘原樣帶回
+	reply := append([]byte(nil), req...)
+	reply[0], reply[2], reply[3] = icmpEchoReply, 0, 0
+	c := checksum(reply)
+	reply[2], reply[3] = byte(c>>8), byte(c)
+
+	got, err := parseEchoReply(reply, 0x1234)
+	if err != nil || !got.Equal(sent) {
+		t.Fatalf("parseEchoReply = %v, %v", got, err)
+	}
+	if _, err := parseEchoReply(reply, 0x4321); !errors.Is(err, errNotReply) {
+		t.Errorf("other id: err = %v", err)
+	}
+	if _, err := parseEchoReply(req, 0x1234); !errors.Is(err, errNotReply) {
+		t.Errorf("echo request accepted as reply: err = %v", err)
+	}
+	reply[10] ^= 0xff
+	if _, err := parseEchoReply(reply, 0x1234); err == nil {
+		t.Error("corrupted reply accepted")
+	}
+}
+
+func TestARPFrames(t *testing.T) {
+	own := net.HardwareAddr{0x02, 0, 0, 0, 0, 1}
+	peer := net.HardwareAddr{0x02, 0, 0, 0, 0, 2}
+	src, dst := netip.MustParseAddr("10.0.1.1"), netip.MustParseAddr("10.0.1.20")
+
+	// 自己送出的請求不算回應
+	req := arpRequestFrame(own, src, dst)
+	if _, ok := parseARPSender(req, own); ok {
+		t.Error("own request counted as a reply")
+	}
+
+	// 回應: 發送者為設備
+	reply := arpRequestFrame(peer, dst, src)
+	reply[21] = arpReply
+	if addr, ok := parseARPSender(reply, own); !ok || addr != dst {
+		t.Errorf("reply sender = %s, %v", addr, ok)
+	}
+
+	// 位址衝突偵測的 probe 沒有發送地址
+	probe := arpRequestFrame(peer, netip.IPv4Unspecified(), dst)
+	if _, ok := parseARPSender(probe, own); ok {
+		t.Error("ARP probe counted as a reply")
+	}
+	if _, ok := parseARPSender(reply[:30], own); ok {
+		t.Error("truncated frame accepted")
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	for _, m := range []string{MethodICMP, MethodARP} {
+		if got, err := ParseMethod(m); err != nil || got != m {
+			t.Errorf("ParseMethod(%q) = %q, %v", m, got, err)
+		}
+	}
+	if _, err := ParseMethod("tcp"); !errors.Is(err, ErrUnknownMethod) {
+		t.Errorf("tcp: err = %v", err)
+	}
+}
+
+func TestSourceFor(t *testing.T) {
+	local := []netip.Prefix{netip.MustParsePrefix("10.0.1.1/24"), netip.MustParsePrefix("169.254.10.1/16")}
+	if got := sourceFor(local, netip.MustParseAddr("169.254.3.4")); got.String() != "169.254.10.1" {
+		t.Errorf("link-local target: source = %s", got)
+	}
+	if got := sourceFor(local, netip.MustParseAddr("10.9.0.1")); got.String() != "10.0.1.1" {
+		t.Errorf("other subnet: source = %s", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var s Series
+	s.Sent = 4
+	summarize(&s, []time.Duration{300 * time.Microsecond, 100 * time.Microsecond, 200 * time.Microsecond})
+	if s.Received != 3 || s.Min != 100*time.Microsecond || s.Max != 300*time.Microsecond || s.Avg != 200*time.Microsecond {
+		t.Fatalf("series = %+v", s)
+	}
+	if s.Jitter != 150*time.Microsecond {
+		t.Errorf("jitter = %s", s.Jitter)
+	}
+	if s.Loss() != 0.25 {
+		t.Errorf("loss = %g", s.Loss())
+	}
+}
@@ -0,0 +1,139 @@
+// Package recovery 讓 goroutine 的 panic 變成事件與計數，而不是結束整個行程
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// Panic 回復機制
+//==============================================================================
+
+// 所有 goroutine 入口 (事件循環、刷新、API handler、hook) 都必須經過這裡，
+// panic 只會變成一筆事件與計數，不會讓整個行程結束。
+
+// Event 一次被回復的 panic
+type Event struct {
+	Site  string    // 發生位置 (例如 "Dante1/events")
+	Value string    // panic 內容
+	Stack string    // 呼叫堆疊
+	Time  time.Time // 發生時間
+}
+
+var (
+	mu        sync.Mutex
+	counts    = make(map[string]int64)
+	listeners []func(Event)
+)
+
+// OnPanic 註冊 panic 事件監聽者 (例如告警系統)
+func OnPanic(listener func(Event)) {
+	mu.Lock()
+	defer mu.Unlock()
+	listeners = append(listeners, listener)
+}
+
+// Counts 取得各位置的 panic 次數
+func Counts() map[string]int64 {
+	mu.Lock()
+	defer mu.Unlock()
+
+	result := make(map[string]int64, len(counts))
+	for site, n := range counts {
+		result[site] = n
+	}
+	return result
+}
+
+// Record 記錄 panic、累加計數並通知監聽者 (供自行 recover 的中介層使用)
+func Record(site string, value any) {
+	event := Event{
+		Site:  site,
+		Value: fmt.Sprint(value),
+		Stack: string(debug.Stack()),
+		Time:  time.Now(),
+	}
+
+	mu.Lock()
+	counts[site]++
+	notify := append([]func(Event){}, listeners...)
+	mu.Unlock()
+
+	slog.Error("Recovered from panic", "site", site, "panic", event.Value, "stack", event.Stack)
+
+	for _, listener := range notify {
+		// 監聽者本身的 panic 不能再往外傳
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("Panic listener failed", "site", site, "panic", fmt.Sprint(r))
+				}
+			}()
+			listener(event)
+		}()
+	}
+}
+
+// Run 執行 fn，panic 時回復並回傳 true
+func Run(site string, fn func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			Record(site, r)
+			panicked = true
+		}
+	}()
+	fn()
+	return false
+}
+
+// Go 以受保護的方式啟動 goroutine
+func Go(site string, fn func()) {
+	go Run(site, fn)
+}
+
+//==============================================================================
+// 長時間執行的循環
+//==============================================================================
+
+// 事件處理、webhook 與 trap 這類循環只用 Go 保護時，panic 後 goroutine 結束，
+// 對應的功能就默默停了 (例如一次異常的 cgo 呼叫讓網域不再處理 SDK 事件)。
+// Loop 回復 panic 後重新執行循環：連續 panic 時等待時間加倍，上限 maxRestartDelay；
+// 執行超過 maxRestartDelay 才 panic 的視為偶發，重新從 restartDelay 開始等待。
+
+// 重新執行前的等待時間 (測試時縮短)
+var (
+	restartDelay    = 100 * time.Millisecond
+	maxRestartDelay = 30 * time.Second
+)
+
+// Loop 執行 fn 直到正常返回或 ctx 結束，fn panic 時記錄後重新執行
+// fn 必須自己在 ctx 結束時返回；重新執行時 fn 的區域變數重新建立，閉包外的狀態保留
+func Loop(ctx context.Context, site string, fn func()) {
+	delay := restartDelay
+	for restarts := 1; ; restarts++ {
+		started := time.Now()
+		if !Run(site, fn) {
+			return
+		}
+		if time.Since(started) > maxRestartDelay {
+			delay = restartDelay
+		}
+		slog.Warn("Restarting after panic", "site", site, "restarts", restarts, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRestartDelay)
+	}
+}
+
+// GoLoop 以 Loop 啟動 goroutine
+func GoLoop(ctx context.Context, site string, fn func()) {
+	go Loop(ctx, site, fn)
+}
@@ -0,0 +1,101 @@
+package recovery
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunRecoversPanic(t *testing.T) {
+	before := Counts()["test/run"]
+
+	if !Run("test/run", func() { panic("boom") }) {
+		t.Fatal("Run did not report the panic")
+	}
+	if Run("test/run", func() {}) {
+		t.Fatal("Run reported a panic for a clean run")
+	}
+
+	if got := Counts()["test/run"]; got != before+1 {
+		t.Fatalf("panic count = %d, want %d", got, before+1)
+	}
+}
+
+func TestGoNotifiesListeners(t *testing.T) {
+	var mu sync.Mutex
+	var events []Event
+	done := make(chan struct{}, 1)
+
+	OnPanic(func(e Event) {
+		if e.Site != "test/go" {
+			return
+		}
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+	})
+
+	Go("test/go", func() { panic("injected") })
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("panic listener was not called")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || events[0].Value != "injected" || events[0].Stack == "" {
+		t.Fatalf("unexpected panic events: %+v", events)
+	}
+}
+
+func TestPanickingListenerIsContained(t *testing.T) {
+	OnPanic(func(e Event) {
+		if e.Site == "test/listener" {
+			panic("listener failure")
+		}
+	})
+
+	if !Run("test/listener", func() { panic("first") }) {
+		t.Fatal("Run did not report the panic")
+	}
+}
+
+func TestLoopRestartsAfterPanic(t *testing.T) {
+	restartDelay, maxRestartDelay = time.Millisecond, 4*time.Millisecond
+	defer func() { restartDelay, maxRestartDelay = 100*time.Millisecond, 30*time.Second }()
+	before := Counts()["test/loop"]
+
+	// 前兩次 panic，第三次正常返回
+	runs := 0
+	Loop(context.Background(), "test/loop", func() {
+		runs++
+		if runs < 3 {
+			panic("cgo callback failed")
+		}
+	})
+	if runs != 3 {
+		t.Fatalf("runs = %d, want 3", runs)
+	}
+	if got := Counts()["test/loop"]; got != before+2 {
+		t.Fatalf("panic count = %d, want %d", got, before+2)
+	}
+
+	// ctx 結束後不再重新執行
+	ctx, cancel := context.WithCancel(context.Background())
+	runs = 0
+	Loop(ctx, "test/loop", func() {
+		runs++
+		cancel()
+		panic("after shutdown")
+	})
+	if runs != 1 {
+		t.Fatalf("runs after cancel = %d, want 1", runs)
+	}
+}
@@ -0,0 +1,28 @@
+// Package serial 開啟 RS-232/USB 序列埠 (8N1、無流量控制、raw 模式)
+package serial
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Bauds 支援的鮑率 (由小到大)
+func Bauds() []int {
+	list := make([]int, 0, len(bauds))
+	for b := range bauds {
+		list = append(list, b)
+	}
+	sort.Ints(list)
+	return list
+}
+
+// ValidBaud 是否為支援的鮑率
+func ValidBaud(baud int) bool {
+	_, ok := bauds[baud]
+	return ok
+}
+
+// errBaud 不支援的鮑率
+func errBaud(baud int) error {
+	return fmt.Errorf("unsupported baud rate %d (use one of %v)", baud, Bauds())
+}
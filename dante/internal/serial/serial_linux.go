@@ -0,0 +1,63 @@
+//go:build linux
+
+package serial
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// bauds 鮑率 → termios 速度
+var bauds = map[int]uint32{
+	1200:   syscall.B1200,
+	2400:   syscall.B2400,
+	4800:   syscall.B4800,
+	9600:   syscall.B9600,
+	19200:  syscall.B19200,
+	38400:  syscall.B38400,
+	57600:  syscall.B57600,
+	115200: syscall.B115200,
+}
+
+// Open 以 baud 8N1 開啟序列埠 (不成為控制終端機，關閉時中斷讀取)
+func Open(device string, baud int) (*os.File, error) {
+	speed, ok := bauds[baud]
+	if !ok {
+		return nil, errBaud(baud)
+	}
+	f, err := os.OpenFile(device, os.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := syscall.Termios{
+		Cflag:  speed | syscall.CS8 | syscall.CREAD | syscall.CLOCAL,
+		Ispeed: speed,
+		Ospeed: speed,
+	}
+	t.Cc[syscall.VMIN] = 1
+	if err := ioctl(f, syscall.TCSETS, &t); err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "tcsets", Path: device, Err: err}
+	}
+	return f, nil
+}
+
+// ioctl 設定終端機屬性
+func ioctl(f *os.File, req uintptr, t *syscall.Termios) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(t)))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package serial
+
+import (
+	"errors"
+	"os"
+)
+
+// bauds 常用的鮑率 (其他平台只用於檢查設定)
+var bauds = map[int]uint32{1200: 0, 2400: 0, 4800: 0, 9600: 0, 19200: 0, 38400: 0, 57600: 0, 115200: 0}
+
+// Open 序列埠只支援 Linux
+func Open(device string, baud int) (*os.File, error) {
+	if !ValidBaud(baud) {
+		return nil, errBaud(baud)
+	}
+	return nil, errors.New("serial ports require Linux")
+}
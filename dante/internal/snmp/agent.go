@@ -0,0 +1,349 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// Agent
+//==============================================================================
+
+// 只支援讀取 (Get、GetNext、GetBulk) 與 community 驗證，Set 一律回應
+// notWritable/readOnly。每個請求呼叫 Source 取得完整的變數列表快照，
+// 表格不大 (網域與設備數百筆以內)，不需要逐欄查詢。
+
+// SNMP 版本 (訊息中的 version 欄位)
+const (
+	Version1  = 0
+	Version2c = 1
+)
+
+// PDU 種類
+const (
+	pduGet      = 0xa0
+	pduGetNext  = 0xa1
+	pduResponse = 0xa2
+	pduSet      = 0xa3
+	pduGetBulk  = 0xa5
+	pduTrapV2   = 0xa7
+)
+
+// 錯誤狀態
+const (
+	errNoError     = 0
+	errTooBig      = 1
+	errNoSuchName  = 2  // v1
+	errReadOnly    = 4  // v1
+	errNotWritable = 17 // v2c
+)
+
+const (
+	maxMessageSize  = 1472 // 回應大小上限 (避免 IP 分段)
+	maxRepetitions  = 64   // GetBulk max-repetitions 上限
+	readBufferSize  = 65535
+	defaultTrapPort = "162"
+)
+
+// 標準物件
+var (
+	SysUpTime   = MustParseOID("1.3.6.1.2.1.1.3.0")
+	snmpTrapOID = MustParseOID("1.3.6.1.6.3.1.1.4.1.0")
+)
+
+// Source 回傳目前所有的變數 (不需排序)
+type Source func() []VarBind
+
+// Config agent 設定
+type Config struct {
+	Addr          string   // 監聽地址 (例如 :161)
+	Community     string   // 讀取 community
+	Traps         []string // trap 目的地 host[:port] (預設埠 162)
+	TrapCommunity string   // trap 的 community (空白時與 Community 相同)
+}
+
+// Stats 請求統計
+type Stats struct {
+	Requests     int64 `json:"requests"`
+	BadCommunity int64 `json:"bad_community"`
+	Malformed    int64 `json:"malformed"`
+	Traps        int64 `json:"traps"`
+	TrapErrors   int64 `json:"trap_errors"`
+}
+
+// Agent SNMP agent
+type Agent struct {
+	cfg     Config
+	source  Source
+	started time.Time
+	conn    net.PacketConn
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewAgent 建立 agent (Listen 之後才開始回應)
+func NewAgent(cfg Config, source Source) (*Agent, error) {
+	if cfg.Community == "" {
+		return nil, errors.New("snmp community must not be empty")
+	}
+	if cfg.TrapCommunity == "" {
+		cfg.TrapCommunity = cfg.Community
+	}
+	for i, t := range cfg.Traps {
+		if _, _, err := net.SplitHostPort(t); err != nil {
+			t = net.JoinHostPort(t, defaultTrapPort)
+		}
+		if _, err := net.ResolveUDPAddr("udp", t); err != nil {
+			return nil, fmt.Errorf("invalid trap destination %q: %v", cfg.Traps[i], err)
+		}
+		cfg.Traps[i] = t
+	}
+	return &Agent{cfg: cfg, source: source, started: time.Now()}, nil
+}
+
+// Uptime 啟動後經過的時間 (百分之一秒，sysUpTime)
+func (a *Agent) Uptime() uint32 {
+	return uint32(time.Since(a.started) / (10 * time.Millisecond))
+}
+
+// Listen 開始監聽並在背景回應請求，直到 ctx 結束
+func (a *Agent) Listen(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", a.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for SNMP on %s: %v", a.cfg.Addr, err)
+	}
+	a.conn = conn
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	recovery.Go("snmp", func() { a.serve(conn) })
+	return nil
+}
+
+// LocalAddr 實際監聽的地址
+func (a *Agent) LocalAddr() net.Addr {
+	return a.conn.LocalAddr()
+}
+
+// serve 讀取並回應請求直到連線關閉
+func (a *Agent) serve(conn net.PacketConn) {
+	buf := make([]byte, readBufferSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Warn("SNMP read failed", "err", err)
+			}
+			return
+		}
+		if resp := a.Handle(buf[:n]); resp != nil {
+			if _, err := conn.WriteTo(resp, addr); err != nil {
+				slog.Debug("SNMP response failed", "addr", addr, "err", err)
+			}
+		}
+	}
+}
+
+// Handle 處理一個請求封包，回傳回應 (不回應時為 nil)
+func (a *Agent) Handle(packet []byte) []byte {
+	m, err := decodeMessage(packet)
+	if err != nil || (m.version != Version1 && m.version != Version2c) {
+		a.count(func(s *Stats) { s.Malformed++ })
+		return nil
+	}
+	if m.community != a.cfg.Community {
+		a.count(func(s *Stats) { s.BadCommunity++ })
+		return nil
+	}
+	a.count(func(s *Stats) { s.Requests++ })
+
+	vars := a.source()
+	slices.SortFunc(vars, func(x, y VarBind) int { return x.OID.Compare(y.OID) })
+
+	status, index := errNoError, 0
+	var binds []VarBind
+	switch m.pduType {
+	case pduGet:
+		binds, status, index = a.get(m, vars)
+	case pduGetNext:
+		binds, status, index = a.getNext(m, vars)
+	case pduGetBulk:
+		if m.version == Version1 {
+			return nil
+		}
+		binds = a.getBulk(m, vars)
+	case pduSet:
+		binds, status, index = m.binds, errNotWritable, 1
+		if m.version == Version1 {
+			status = errReadOnly
+		}
+	default:
+		a.count(func(s *Stats) { s.Malformed++ })
+		return nil
+	}
+
+	resp := encodeMessage(m.version, m.community, pduResponse, m.requestID, status, index, binds)
+	if len(resp) > maxMessageSize {
+		if m.pduType == pduGetBulk {
+			// GetBulk 可以只回傳放得下的部分
+			for len(binds) > 1 && len(resp) > maxMessageSize {
+				binds = binds[:len(binds)*3/4]
+				resp = encodeMessage(m.version, m.community, pduResponse, m.requestID, errNoError, 0, binds)
+			}
+		} else {
+			resp = encodeMessage(m.version, m.community, pduResponse, m.requestID, errTooBig, 0, nil)
+		}
+	}
+	return resp
+}
+
+// get 完全相符的變數
+func (a *Agent) get(m *message, vars []VarBind) ([]VarBind, int, int) {
+	binds := make([]VarBind, len(m.binds))
+	for i, req := range m.binds {
+		j, found := slices.BinarySearchFunc(vars, req.OID, func(vb VarBind, oid OID) int { return vb.OID.Compare(oid) })
+		switch {
+		case found:
+			binds[i] = vars[j]
+		case m.version == Version1:
+			return m.binds, errNoSuchName, i + 1
+		default:
+			// Source 只有變數沒有 MIB 定義，無法分辨 noSuchInstance
+			binds[i] = VarBind{OID: req.OID, Value: noSuchObject}
+		}
+	}
+	return binds, errNoError, 0
+}
+
+// getNext 每個請求 OID 之後的第一個變數
+func (a *Agent) getNext(m *message, vars []VarBind) ([]VarBind, int, int) {
+	binds := make([]VarBind, len(m.binds))
+	for i, req := range m.binds {
+		vb, ok := next(vars, req.OID)
+		switch {
+		case ok:
+			binds[i] = vb
+		case m.version == Version1:
+			return m.binds, errNoSuchName, i + 1
+		default:
+			binds[i] = VarBind{OID: req.OID, Value: endOfMibView}
+		}
+	}
+	return binds, errNoError, 0
+}
+
+// getBulk 前 non-repeaters 個變數做一次 GetNext，其餘重複 max-repetitions 次
+func (a *Agent) getBulk(m *message, vars []VarBind) []VarBind {
+	nonRepeaters := min(max(m.errStatus, 0), len(m.binds))
+	repetitions := min(max(m.errIndex, 0), maxRepetitions)
+
+	var binds []VarBind
+	for _, req := range m.binds[:nonRepeaters] {
+		vb, ok := next(vars, req.OID)
+		if !ok {
+			vb = VarBind{OID: req.OID, Value: endOfMibView}
+		}
+		binds = append(binds, vb)
+	}
+	cursors := make([]OID, 0, len(m.binds)-nonRepeaters)
+	for _, req := range m.binds[nonRepeaters:] {
+		cursors = append(cursors, req.OID)
+	}
+	for r := 0; r < repetitions && len(cursors) > 0; r++ {
+		done := true
+		for i, oid := range cursors {
+			vb, ok := next(vars, oid)
+			if !ok {
+				vb = VarBind{OID: oid, Value: endOfMibView}
+			} else {
+				done = false
+			}
+			binds = append(binds, vb)
+			cursors[i] = vb.OID
+		}
+		if done {
+			break
+		}
+	}
+	return binds
+}
+
+// next 排序後的列表中大於 oid 的第一個變數
+func next(vars []VarBind, oid OID) (VarBind, bool) {
+	j, found := slices.BinarySearchFunc(vars, oid, func(vb VarBind, oid OID) int { return vb.OID.Compare(oid) })
+	if found {
+		j++
+	}
+	if j >= len(vars) {
+		return VarBind{}, false
+	}
+	return vars[j], true
+}
+
+// hasPrefix oid 是否以 prefix 開頭
+func hasPrefix(oid, prefix OID) bool {
+	return len(oid) >= len(prefix) && slices.Equal(oid[:len(prefix)], prefix)
+}
+
+// count 更新統計
+func (a *Agent) count(update func(*Stats)) {
+	a.mu.Lock()
+	update(&a.stats)
+	a.mu.Unlock()
+}
+
+// Stats 請求與 trap 統計
+func (a *Agent) Stats() Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stats
+}
+
+//------------------------------------------------------------------------------
+// Trap
+//------------------------------------------------------------------------------
+
+// Trap 送出 SNMPv2-Trap 給所有目的地 (前面自動加上 sysUpTime.0 與 snmpTrapOID.0)
+func (a *Agent) Trap(trap OID, binds ...VarBind) error {
+	if len(a.cfg.Traps) == 0 {
+		return nil
+	}
+	all := append([]VarBind{
+		{OID: SysUpTime, Value: TimeTicks(a.Uptime())},
+		{OID: snmpTrapOID, Value: ObjectID(trap)},
+	}, binds...)
+	packet := encodeMessage(Version2c, a.cfg.TrapCommunity, pduTrapV2, int32(time.Now().UnixNano()&0x7fffffff), 0, 0, all)
+
+	var errs []error
+	for _, dest := range a.cfg.Traps {
+		if err := sendUDP(dest, packet); err != nil {
+			errs = append(errs, fmt.Errorf("trap to %s: %v", dest, err))
+		}
+	}
+	a.count(func(s *Stats) {
+		s.Traps++
+		s.TrapErrors += int64(len(errs))
+	})
+	return errors.Join(errs...)
+}
+
+// sendUDP 送出一個 UDP 封包
+func sendUDP(dest string, packet []byte) error {
+	conn, err := net.Dial("udp", dest)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(packet)
+	return err
+}
@@ -0,0 +1,428 @@
+// Package snmp 唯讀的 SNMP v1/v2c agent、v2c trap 與查詢交換器的 client
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//==============================================================================
+// BER 編碼
+//==============================================================================
+
+// 只實作 agent 與 client 需要的部分：請求的 OID 與回應的值 (INTEGER、OCTET STRING、
+// OBJECT IDENTIFIER、IpAddress、Counter32、Gauge32、TimeTicks 與 v2c 的例外)。
+
+// ASN.1 / SNMP 的 tag
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+	tagIPAddress   = 0x40
+	tagCounter32   = 0x41
+	tagGauge32     = 0x42
+	tagTimeTicks   = 0x43
+
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+)
+
+// errMalformed 無法解析的封包
+var errMalformed = errors.New("malformed BER")
+
+// OID 物件識別碼
+type OID []uint32
+
+// ParseOID 解析點分表示法 (可有開頭的點)
+func ParseOID(s string) (OID, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", s)
+	}
+	oid := make(OID, len(parts))
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OID %q", s)
+		}
+		oid[i] = uint32(n)
+	}
+	return oid, nil
+}
+
+// MustParseOID 解析常數 OID (錯誤時 panic)
+func MustParseOID(s string) OID {
+	oid, err := ParseOID(s)
+	if err != nil {
+		panic(err)
+	}
+	return oid
+}
+
+// String 點分表示法
+func (o OID) String() string {
+	parts := make([]string, len(o))
+	for i, n := range o {
+		parts[i] = strconv.FormatUint(uint64(n), 10)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Append 回傳加上子識別碼的新 OID
+func (o OID) Append(sub ...uint32) OID {
+	return append(slices.Clip(o), sub...)
+}
+
+// Compare 依 SNMP 的字典順序比較
+func (o OID) Compare(other OID) int {
+	return slices.Compare(o, other)
+}
+
+// Value 變數的值 (tag 與編碼後的內容)
+type Value struct {
+	tag  byte
+	data []byte
+}
+
+// Integer INTEGER
+func Integer(n int) Value {
+	return Value{tag: tagInteger, data: encodeInt(int64(n))}
+}
+
+// String OCTET STRING
+func String(s string) Value {
+	return Value{tag: tagOctetString, data: []byte(s)}
+}
+
+// ObjectID OBJECT IDENTIFIER
+func ObjectID(oid OID) Value {
+	return Value{tag: tagOID, data: encodeOID(oid)}
+}
+
+// IPAddress IpAddress (非 IPv4 地址編碼為 0.0.0.0)
+func IPAddress(addr netip.Addr) Value {
+	ip := [4]byte{}
+	if addr.Is4() {
+		ip = addr.As4()
+	}
+	return Value{tag: tagIPAddress, data: ip[:]}
+}
+
+// Counter32 Counter32
+func Counter32(n uint32) Value {
+	return Value{tag: tagCounter32, data: encodeUint(uint64(n))}
+}
+
+// Gauge32 Gauge32
+func Gauge32(n uint32) Value {
+	return Value{tag: tagGauge32, data: encodeUint(uint64(n))}
+}
+
+// TimeTicks TimeTicks (百分之一秒)
+func TimeTicks(n uint32) Value {
+	return Value{tag: tagTimeTicks, data: encodeUint(uint64(n))}
+}
+
+var (
+	null         = Value{tag: tagNull}
+	noSuchObject = Value{tag: tagNoSuchObject}
+	endOfMibView = Value{tag: tagEndOfMibView}
+)
+
+// Int 數值型別的值 (INTEGER、Counter32、Gauge32、TimeTicks)
+func (v Value) Int() (int64, bool) {
+	switch v.tag {
+	case tagInteger:
+		return decodeInt(v.data), true
+	case tagCounter32, tagGauge32, tagTimeTicks:
+		var n int64
+		for _, b := range v.data {
+			n = n<<8 | int64(b)
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// Text OCTET STRING 的內容
+func (v Value) Text() (string, bool) {
+	return string(v.data), v.tag == tagOctetString
+}
+
+// Exception 是否為 v2c 的例外 (noSuchObject、noSuchInstance、endOfMibView)
+func (v Value) Exception() bool {
+	return v.tag == tagNoSuchObject || v.tag == tagNoSuchInstance || v.tag == tagEndOfMibView
+}
+
+// VarBind 變數與值
+type VarBind struct {
+	OID   OID
+	Value Value
+}
+
+//------------------------------------------------------------------------------
+// 編碼
+//------------------------------------------------------------------------------
+
+// appendTLV 加上 tag、長度與內容
+func appendTLV(b []byte, tag byte, content []byte) []byte {
+	b = append(b, tag)
+	n := len(content)
+	switch {
+	case n < 0x80:
+		b = append(b, byte(n))
+	case n <= 0xff:
+		b = append(b, 0x81, byte(n))
+	case n <= 0xffff:
+		b = append(b, 0x82, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(b, content...)
+}
+
+// encodeInt 二補數的最短表示
+func encodeInt(n int64) []byte {
+	b := []byte{byte(n)}
+	for n >>= 8; !(n == 0 && b[0]&0x80 == 0) && !(n == -1 && b[0]&0x80 != 0); n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return b
+}
+
+// encodeUint 無號數 (最高位元為 1 時補 0)
+func encodeUint(n uint64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+		if n == 0 {
+			break
+		}
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+// encodeOID 前兩個識別碼合併為 40*x+y，其餘以 base-128 編碼
+func encodeOID(oid OID) []byte {
+	if len(oid) < 2 {
+		return []byte{0}
+	}
+	var b []byte
+	b = appendBase128(b, oid[0]*40+oid[1])
+	for _, n := range oid[2:] {
+		b = appendBase128(b, n)
+	}
+	return b
+}
+
+func appendBase128(b []byte, n uint32) []byte {
+	var tmp [5]byte
+	i := len(tmp) - 1
+	tmp[i] = byte(n & 0x7f)
+	for n >>= 7; n > 0; n >>= 7 {
+		i--
+		tmp[i] = byte(n&0x7f) | 0x80
+	}
+	return append(b, tmp[i:]...)
+}
+
+// encodeVarBinds VarBindList
+func encodeVarBinds(binds []VarBind) []byte {
+	var list []byte
+	for _, vb := range binds {
+		var item []byte
+		item = appendTLV(item, tagOID, encodeOID(vb.OID))
+		item = appendTLV(item, vb.Value.tag, vb.Value.data)
+		list = appendTLV(list, tagSequence, item)
+	}
+	return list
+}
+
+// encodeMessage 完整的 SNMP 訊息
+func encodeMessage(version int, community string, pduType byte, requestID int32, errStatus, errIndex int, binds []VarBind) []byte {
+	var pdu []byte
+	pdu = appendTLV(pdu, tagInteger, encodeInt(int64(requestID)))
+	pdu = appendTLV(pdu, tagInteger, encodeInt(int64(errStatus)))
+	pdu = appendTLV(pdu, tagInteger, encodeInt(int64(errIndex)))
+	pdu = appendTLV(pdu, tagSequence, encodeVarBinds(binds))
+
+	var msg []byte
+	msg = appendTLV(msg, tagInteger, encodeInt(int64(version)))
+	msg = appendTLV(msg, tagOctetString, []byte(community))
+	msg = appendTLV(msg, pduType, pdu)
+	return appendTLV(nil, tagSequence, msg)
+}
+
+//------------------------------------------------------------------------------
+// 解碼
+//------------------------------------------------------------------------------
+
+// readTLV 讀取一個 TLV，回傳 tag、內容與剩餘的資料
+func readTLV(b []byte) (tag byte, content, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errMalformed
+	}
+	tag = b[0]
+	n, b := int(b[1]), b[2:]
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		if size == 0 || size > 3 || len(b) < size {
+			return 0, nil, nil, errMalformed
+		}
+		n = 0
+		for _, c := range b[:size] {
+			n = n<<8 | int(c)
+		}
+		b = b[size:]
+	}
+	if len(b) < n {
+		return 0, nil, nil, errMalformed
+	}
+	return tag, b[:n], b[n:], nil
+}
+
+// readExpect 讀取指定 tag 的 TLV
+func readExpect(b []byte, want byte) (content, rest []byte, err error) {
+	tag, content, rest, err := readTLV(b)
+	if err == nil && tag != want {
+		err = fmt.Errorf("%w: tag 0x%02x, want 0x%02x", errMalformed, tag, want)
+	}
+	return content, rest, err
+}
+
+// readInt 讀取 INTEGER
+func readInt(b []byte) (int64, []byte, error) {
+	content, rest, err := readExpect(b, tagInteger)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(content) == 0 || len(content) > 8 {
+		return 0, nil, errMalformed
+	}
+	return decodeInt(content), rest, nil
+}
+
+func decodeInt(b []byte) int64 {
+	var n int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n = -1
+	}
+	for _, c := range b {
+		n = n<<8 | int64(c)
+	}
+	return n
+}
+
+// decodeOID 解析 OBJECT IDENTIFIER 的內容
+func decodeOID(b []byte) (OID, error) {
+	if len(b) == 0 {
+		return nil, errMalformed
+	}
+	var oid OID
+	var n uint32
+	for i, c := range b {
+		if n > 0x1ffffff {
+			return nil, errMalformed
+		}
+		n = n<<7 | uint32(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(b)-1 {
+				return nil, errMalformed
+			}
+			continue
+		}
+		if oid == nil {
+			first := min(n/40, 2)
+			oid = OID{first, n - first*40}
+		} else {
+			oid = append(oid, n)
+		}
+		n = 0
+	}
+	return oid, nil
+}
+
+// message 解析後的 SNMP 訊息
+type message struct {
+	version   int
+	community string
+	pduType   byte
+	requestID int32
+	errStatus int // GetBulk 為 non-repeaters
+	errIndex  int // GetBulk 為 max-repetitions
+	binds     []VarBind
+}
+
+// decodeMessage 解析 SNMP 訊息
+func decodeMessage(b []byte) (*message, error) {
+	body, _, err := readExpect(b, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	var m message
+	version, body, err := readInt(body)
+	if err != nil {
+		return nil, err
+	}
+	m.version = int(version)
+	community, body, err := readExpect(body, tagOctetString)
+	if err != nil {
+		return nil, err
+	}
+	m.community = string(community)
+
+	pduType, pdu, _, err := readTLV(body)
+	if err != nil {
+		return nil, err
+	}
+	m.pduType = pduType
+	var n int64
+	if n, pdu, err = readInt(pdu); err != nil {
+		return nil, err
+	}
+	m.requestID = int32(n)
+	if n, pdu, err = readInt(pdu); err != nil {
+		return nil, err
+	}
+	m.errStatus = int(n)
+	if n, pdu, err = readInt(pdu); err != nil {
+		return nil, err
+	}
+	m.errIndex = int(n)
+
+	list, _, err := readExpect(pdu, tagSequence)
+	if err != nil {
+		return nil, err
+	}
+	for len(list) > 0 {
+		var item []byte
+		if item, list, err = readExpect(list, tagSequence); err != nil {
+			return nil, err
+		}
+		raw, item, err := readExpect(item, tagOID)
+		if err != nil {
+			return nil, err
+		}
+		oid, err := decodeOID(raw)
+		if err != nil {
+			return nil, err
+		}
+		tag, data, _, err := readTLV(item)
+		if err != nil {
+			return nil, err
+		}
+		m.binds = append(m.binds, VarBind{OID: oid, Value: Value{tag: tag, data: data}})
+	}
+	return &m, nil
+}
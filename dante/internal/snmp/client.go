@@ -0,0 +1,139 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+//==============================================================================
+// Client
+//==============================================================================
+
+// 讀取交換器的表格 (FDB、ARP、介面名稱)：v2c 以 GetBulk、v1 以 GetNext 走訪
+// 子樹。每個請求逾時後重送，回應的 request ID 不符時丟棄 (重送前的遲到回應)。
+
+const (
+	defaultClientTimeout = 2 * time.Second
+	bulkRepetitions      = 32     // GetBulk 每次要求的列數
+	maxWalk              = 100000 // 一次走訪的變數上限 (避免不遞增的 agent 造成無窮迴圈)
+)
+
+// ErrTimeout agent 沒有回應
+var ErrTimeout = errors.New("snmp request timed out")
+
+// Client 對單一 agent 的唯讀查詢
+type Client struct {
+	Addr      string        // host[:port] (預設埠 161)
+	Community string        // 讀取 community
+	V1        bool          // 使用 SNMPv1 (GetNext)，預設為 v2c (GetBulk)
+	Timeout   time.Duration // 每個請求的逾時 (預設 2 秒)
+	Retries   int           // 逾時後重送的次數
+}
+
+// Walk 走訪 root 之下的所有變數 (依 OID 排序)
+func (c *Client) Walk(ctx context.Context, root OID) ([]VarBind, error) {
+	addr := c.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "161")
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	var out []VarBind
+	cursor := root
+	for {
+		binds, err := c.next(ctx, conn, cursor)
+		if err != nil {
+			return out, err
+		}
+		if len(binds) == 0 {
+			return out, nil
+		}
+		for _, vb := range binds {
+			if vb.Value.Exception() || !hasPrefix(vb.OID, root) {
+				return out, nil
+			}
+			if vb.OID.Compare(cursor) <= 0 {
+				return out, fmt.Errorf("agent returned %s after %s (OIDs not increasing)", vb.OID, cursor)
+			}
+			if len(out) >= maxWalk {
+				return out, fmt.Errorf("more than %d variables under %s", maxWalk, root)
+			}
+			out = append(out, vb)
+			cursor = vb.OID
+		}
+	}
+}
+
+// next 從 cursor 之後讀取下一批變數 (v1 的 noSuchName 表示走訪結束)
+func (c *Client) next(ctx context.Context, conn net.Conn, cursor OID) ([]VarBind, error) {
+	pdu, a1, a2 := byte(pduGetBulk), 0, bulkRepetitions
+	if c.V1 {
+		pdu, a2 = pduGetNext, 0
+	}
+	m, err := c.exchange(ctx, conn, pdu, a1, a2, cursor)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case m.errStatus == errNoSuchName && c.V1:
+		return nil, nil
+	case m.errStatus != errNoError:
+		return nil, fmt.Errorf("snmp error status %d at index %d", m.errStatus, m.errIndex)
+	}
+	return m.binds, nil
+}
+
+// exchange 送出請求並等待相同 request ID 的回應 (逾時重送 Retries 次)
+func (c *Client) exchange(ctx context.Context, conn net.Conn, pdu byte, a1, a2 int, oid OID) (*message, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultClientTimeout
+	}
+	requestID := rand.Int32()
+	request := encodeMessage(c.version(), c.Community, pdu, requestID, a1, a2, []VarBind{{OID: oid, Value: null}})
+	buf := make([]byte, readBufferSize)
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if _, err := conn.Write(request); err != nil {
+			return nil, err
+		}
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+			m, err := decodeMessage(buf[:n])
+			if err != nil || m.pduType != pduResponse || m.requestID != requestID {
+				continue
+			}
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTimeout, conn.RemoteAddr())
+}
+
+// version 訊息的版本欄位
+func (c *Client) version() int {
+	if c.V1 {
+		return Version1
+	}
+	return Version2c
+}
@@ -0,0 +1,214 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIntegerEncoding(t *testing.T) {
+	for _, n := range []int64{0, 1, 127, 128, 255, 256, -1, -128, -129, 1 << 31, -(1 << 31)} {
+		if got := decodeInt(encodeInt(n)); got != n {
+			t.Errorf("round trip %d = %d (% x)", n, got, encodeInt(n))
+		}
+	}
+	if b := encodeInt(128); len(b) != 2 || b[0] != 0 {
+		t.Errorf("128 encoded as % x", b)
+	}
+	if v, _ := Gauge32(0xffffffff).Int(); v != 0xffffffff {
+		t.Errorf("gauge = %d", v)
+	}
+}
+
+func TestOIDEncoding(t *testing.T) {
+	oid := MustParseOID(".1.3.6.1.4.1.8072.9999.9999.1.2.1.3.1.300")
+	got, err := decodeOID(encodeOID(oid))
+	if err != nil || got.Compare(oid) != 0 {
+		t.Fatalf("round trip = %s, %v", got, err)
+	}
+	if _, err := decodeOID([]byte{0x2b, 0x86}); err == nil {
+		t.Error("truncated OID accepted")
+	}
+	if _, err := ParseOID("1.3.x"); err == nil {
+		t.Error("invalid OID accepted")
+	}
+}
+
+// testAgent 三個變數的 agent
+func testAgent(t *testing.T, traps ...string) *Agent {
+	t.Helper()
+	a, err := NewAgent(Config{Community: "public", Traps: traps}, func() []VarBind {
+		return []VarBind{
+			{OID: MustParseOID("1.3.6.1.4.1.1.2.1.2.1"), Value: String("Amp")},
+			{OID: MustParseOID("1.3.6.1.4.1.1.1.0"), Value: Integer(2)},
+			{OID: MustParseOID("1.3.6.1.4.1.1.2.1.2.2"), Value: String("Stage")},
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// request 送出請求並解析回應
+func request(t *testing.T, a *Agent, version int, community string, pdu byte, a1, a2 int, oids ...string) *message {
+	t.Helper()
+	binds := make([]VarBind, len(oids))
+	for i, s := range oids {
+		binds[i] = VarBind{OID: MustParseOID(s), Value: null}
+	}
+	resp := a.Handle(encodeMessage(version, community, pdu, 42, a1, a2, binds))
+	if resp == nil {
+		return nil
+	}
+	m, err := decodeMessage(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.pduType != pduResponse || m.requestID != 42 {
+		t.Fatalf("response %+v", m)
+	}
+	return m
+}
+
+func oids(binds []VarBind) []string {
+	var list []string
+	for _, vb := range binds {
+		list = append(list, vb.OID.String())
+	}
+	return list
+}
+
+func TestAgentRequests(t *testing.T) {
+	a := testAgent(t)
+
+	m := request(t, a, Version2c, "public", pduGet, 0, 0, "1.3.6.1.4.1.1.1.0", "1.3.6.1.4.1.1.2.1.2.9", "1.3.6.1.9")
+	if n, _ := m.binds[0].Value.Int(); n != 2 {
+		t.Errorf("get: %+v", m.binds[0])
+	}
+	if m.binds[1].Value.tag != tagNoSuchObject || m.binds[2].Value.tag != tagNoSuchObject {
+		t.Errorf("missing variables: %+v", m.binds[1:])
+	}
+
+	// walk
+	var walked []string
+	cursor := "1.3.6.1.4.1.1"
+	for {
+		m := request(t, a, Version2c, "public", pduGetNext, 0, 0, cursor)
+		if m.binds[0].Value.Exception() {
+			break
+		}
+		cursor = m.binds[0].OID.String()
+		walked = append(walked, cursor)
+	}
+	want := []string{"1.3.6.1.4.1.1.1.0", "1.3.6.1.4.1.1.2.1.2.1", "1.3.6.1.4.1.1.2.1.2.2"}
+	if !slices.Equal(walked, want) {
+		t.Errorf("walk = %v", walked)
+	}
+
+	// GetBulk: 一個 non-repeater，其餘重複到 endOfMibView
+	m = request(t, a, Version2c, "public", pduGetBulk, 1, 10, "1.3.6.1.4.1.1", "1.3.6.1.4.1.1.2")
+	got := oids(m.binds)
+	if !slices.Equal(got, []string{"1.3.6.1.4.1.1.1.0", "1.3.6.1.4.1.1.2.1.2.1", "1.3.6.1.4.1.1.2.1.2.2", "1.3.6.1.4.1.1.2.1.2.2"}) ||
+		!m.binds[3].Value.Exception() {
+		t.Errorf("bulk = %v", got)
+	}
+
+	// v1: 沒有例外，回應 noSuchName
+	m = request(t, a, Version1, "public", pduGet, 0, 0, "1.3.6.1.4.1.1.1.0", "1.3.6.1.9")
+	if m.errStatus != errNoSuchName || m.errIndex != 2 {
+		t.Errorf("v1 get: status %d index %d", m.errStatus, m.errIndex)
+	}
+	if m := request(t, a, Version2c, "public", pduSet, 0, 0, "1.3.6.1.4.1.1.1.0"); m.errStatus != errNotWritable {
+		t.Errorf("set: status %d", m.errStatus)
+	}
+
+	if m := request(t, a, Version2c, "private", pduGet, 0, 0, "1.3.6.1.4.1.1.1.0"); m != nil {
+		t.Error("answered a wrong community")
+	}
+	if a.Handle([]byte{0x30, 0x03, 0x02}) != nil {
+		t.Error("answered a malformed packet")
+	}
+	if s := a.Stats(); s.BadCommunity != 1 || s.Malformed != 1 {
+		t.Errorf("stats: %+v", s)
+	}
+}
+
+func TestTrap(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	a := testAgent(t, conn.LocalAddr().String())
+
+	trap := MustParseOID("1.3.6.1.4.1.1.4.0.1")
+	if err := a.Trap(trap, VarBind{OID: MustParseOID("1.3.6.1.4.1.1.2.1.5.1"), Value: IPAddress(netip.MustParseAddr("10.0.1.20"))}); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1500)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := decodeMessage(buf[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.pduType != pduTrapV2 || m.version != Version2c || len(m.binds) != 3 {
+		t.Fatalf("trap %+v", m)
+	}
+	if m.binds[0].OID.Compare(SysUpTime) != 0 || m.binds[1].OID.Compare(snmpTrapOID) != 0 {
+		t.Errorf("trap header: %v", oids(m.binds))
+	}
+	if oid, _ := decodeOID(m.binds[1].Value.data); oid.Compare(trap) != 0 {
+		t.Errorf("trap OID = %s", oid)
+	}
+	if ip := m.binds[2].Value.data; len(ip) != 4 || ip[3] != 20 {
+		t.Errorf("ip = % x", ip)
+	}
+}
+
+func TestClientWalk(t *testing.T) {
+	a, err := NewAgent(Config{Addr: "127.0.0.1:0", Community: "public"}, func() []VarBind {
+		vars := []VarBind{{OID: MustParseOID("1.3.6.1.2.1.1.5.0"), Value: String("core-sw1")}}
+		for i := 1; i <= 50; i++ {
+			vars = append(vars, VarBind{OID: MustParseOID("1.3.6.1.2.1.17.4.3.1.2").Append(0, 0x1d, 0xc1, 0, 0, uint32(i)), Value: Integer(i)})
+		}
+		return append(vars, VarBind{OID: MustParseOID("1.3.6.1.2.1.31.1.1.1.1.1"), Value: String("Gi1/0/1")})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := a.Listen(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	fdb := MustParseOID("1.3.6.1.2.1.17.4.3.1.2")
+	for _, v1 := range []bool{false, true} {
+		c := &Client{Addr: a.LocalAddr().String(), Community: "public", V1: v1}
+		vars, err := c.Walk(ctx, fdb)
+		if err != nil {
+			t.Fatalf("v1=%v: %v", v1, err)
+		}
+		if len(vars) != 50 || vars[49].OID[len(vars[49].OID)-1] != 50 {
+			t.Fatalf("v1=%v: walked %v", v1, oids(vars))
+		}
+		if port, _ := vars[9].Value.Int(); port != 10 {
+			t.Errorf("v1=%v: port = %d", v1, port)
+		}
+	}
+
+	// community 錯誤時 agent 不回應
+	c := &Client{Addr: a.LocalAddr().String(), Community: "private", Timeout: 50 * time.Millisecond, Retries: 1}
+	if _, err := c.Walk(ctx, fdb); !errors.Is(err, ErrTimeout) {
+		t.Errorf("wrong community: err = %v", err)
+	}
+}
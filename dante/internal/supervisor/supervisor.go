@@ -0,0 +1,320 @@
+// Package supervisor 獨立執行各網域的工作並在失敗時依退避重啟
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"danteCS/internal/backoff"
+	"danteCS/internal/dante"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 網域失敗隔離
+//==============================================================================
+
+// 每個網域的工作 (初始化、掃描、刷新) 在獨立的 goroutine 執行，
+// 回傳錯誤或 panic 只會讓該網域進入 failed 並依退避時間重啟，
+// 不影響其他網域的工作與 API。API 只讀取 supervisor 保存的快照，
+// 不直接呼叫網域 (SDK 卡住時也不會拖住 HTTP 請求)。
+// 這裡的隔離只到 Go 這一層：原生 SDK 的 C 狀態是整個行程共用的，
+// 重啟時的 dante_cleanup 會清掉全部，所以一個行程只有一個原生網域
+// (第二個會得到 dante.ErrSDKInUse)，其他網域是各自有 SDK 的模擬網域。
+// C 層的崩潰 (SIGSEGV) 也無法在行程內回復；多個實體網域或需要完全隔離時
+// 請用 instance supervise 把每個網域放在獨立的行程。
+//
+// 重啟期間 (失敗等待與重新初始化、首次發現) 保留上次的設備列表並標記
+// Stale，行程啟動時也可以用 Seed 放入快取的列表，讓操作人員不會看到
+// 空白的設備列表；這次執行回報設備後才清除 Stale。
+
+// 網域狀態
+const (
+	StateStarting = "starting" // 工作啟動中，尚未回報設備
+	StateRunning  = "running"  // 已回報設備列表
+	StateFailed   = "failed"   // 工作失敗，等待重啟
+	StateStopped  = "stopped"  // supervisor 已停止
+)
+
+// Runner 網域工作：持續執行直到 ctx 結束，透過 report 回報狀態
+// 回傳 (包含 nil) 或 panic 都視為網域失敗；錯誤包含 ErrPermanent 時不再重啟
+type Runner func(ctx context.Context, report Reporter) error
+
+// Reporter 網域工作回報狀態
+type Reporter interface {
+	dante.InitReporter              // 啟動階段 (等待介面、初始化重試...) 與實際使用的介面
+	Devices(devices []dante.Device) // 發布最新設備列表 (進入 running)
+}
+
+// ErrPermanent 無法以重啟恢復的失敗 (例如用完初始化重試次數)
+var ErrPermanent = errors.New("permanent failure")
+
+// Spec 受監督的網域
+type Spec struct {
+	Name      string
+	Interface string
+	IPAddress string
+	Run       Runner
+}
+
+// Snapshot 網域最後一次回報的狀態
+type Snapshot struct {
+	Name      string         `json:"name"`
+	Interface string         `json:"interface"`
+	IPAddress string         `json:"ip_address"`
+	State     string         `json:"state"`
+	Phase     string         `json:"phase,omitempty"`      // 啟動階段說明 (starting 時)
+	Restarts  int            `json:"restarts"`             // 失敗後重啟的次數
+	LastError string         `json:"last_error,omitempty"` // 最近一次失敗原因
+	Since     time.Time      `json:"since"`                // 進入目前狀態的時間
+	Updated   time.Time      `json:"updated"`              // 最後一次回報設備的時間
+	Stale     bool           `json:"stale,omitempty"`      // Devices 來自上次執行或快取，尚未重新確認
+	Devices   []dante.Device `json:"-"`
+}
+
+// Config 重啟退避設定
+type Config struct {
+	Restart     backoff.Policy // 重啟退避 (MaxAttempts 不使用)
+	StableAfter time.Duration  // 持續運行超過此時間後，下次失敗從頭計算退避
+
+	// OnFailure 網域失敗後、等待重啟前呼叫 (permanent 表示不再重啟)，可為 nil
+	OnFailure func(snap Snapshot, permanent bool)
+}
+
+// DefaultConfig 預設退避設定
+func DefaultConfig() Config {
+	return Config{
+		Restart:     backoff.Policy{Initial: time.Second, Max: time.Minute},
+		StableAfter: 5 * time.Minute,
+	}
+}
+
+// supervisedDomain 單一網域的狀態 (各自加鎖，互不影響)
+type supervisedDomain struct {
+	spec Spec
+
+	mu       sync.Mutex
+	snapshot Snapshot
+}
+
+// Supervisor 獨立執行並重啟各網域的工作
+type Supervisor struct {
+	cfg     Config
+	domains []*supervisedDomain
+
+	ctx    context.Context // Start 之後有效，Stop 時取消
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 建立 supervisor
+func New(cfg Config) *Supervisor {
+	return &Supervisor{cfg: cfg}
+}
+
+// Add 加入網域 (必須在 Start 之前呼叫)
+func (s *Supervisor) Add(spec Spec) {
+	s.domains = append(s.domains, &supervisedDomain{
+		spec: spec,
+		snapshot: Snapshot{
+			Name:      spec.Name,
+			Interface: spec.Interface,
+			IPAddress: spec.IPAddress,
+			State:     StateStopped,
+			Since:     time.Now(),
+		},
+	})
+}
+
+// Start 啟動所有網域的工作，ctx 結束時與 Stop 相同
+func (s *Supervisor) Start(ctx context.Context) {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, d := range s.domains {
+		s.wg.Add(1)
+		go s.supervise(d)
+	}
+}
+
+// Stop 通知所有網域結束並等待
+func (s *Supervisor) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// Seed 在 Start 之前放入快取的設備列表 (標記 Stale，asOf 為列表的時間)
+func (s *Supervisor) Seed(name string, devices []dante.Device, asOf time.Time) bool {
+	for _, d := range s.domains {
+		if d.spec.Name == name {
+			d.mu.Lock()
+			d.snapshot.Devices = append([]dante.Device{}, devices...)
+			d.snapshot.Updated = asOf
+			d.snapshot.Stale = len(devices) > 0
+			d.mu.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshots 所有網域的狀態 (依加入順序)
+func (s *Supervisor) Snapshots() []Snapshot {
+	result := make([]Snapshot, 0, len(s.domains))
+	for _, d := range s.domains {
+		result = append(result, d.get())
+	}
+	return result
+}
+
+// Snapshot 取得單一網域的狀態
+func (s *Supervisor) Snapshot(name string) (Snapshot, bool) {
+	for _, d := range s.domains {
+		if d.spec.Name == name {
+			return d.get(), true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// supervise 執行網域工作，失敗後依退避時間重啟直到 Stop
+func (s *Supervisor) supervise(d *supervisedDomain) {
+	defer s.wg.Done()
+	log := slog.With("domain", d.spec.Name)
+	failures := 0
+
+	for {
+		d.setState(StateStarting, "")
+		started := time.Now()
+
+		var err error
+		if recovery.Run(d.spec.Name+"/worker", func() { err = d.spec.Run(s.ctx, d) }) {
+			err = errors.New("worker panicked")
+		}
+
+		select {
+		case <-s.ctx.Done():
+			d.setState(StateStopped, "")
+			return
+		default:
+		}
+
+		if err == nil {
+			err = errors.New("worker exited unexpectedly")
+		}
+		d.fail(err)
+		permanent := errors.Is(err, ErrPermanent)
+		if permanent {
+			d.dropDevices()
+		}
+		if s.cfg.OnFailure != nil {
+			snap := d.get()
+			recovery.Run(d.spec.Name+"/on-failure", func() { s.cfg.OnFailure(snap, permanent) })
+		}
+		if permanent {
+			log.Error("Domain failed permanently, not restarting", "err", err)
+			return
+		}
+
+		if s.cfg.StableAfter > 0 && time.Since(started) >= s.cfg.StableAfter {
+			failures = 0
+		}
+		failures++
+		delay := s.cfg.Restart.Delay(failures)
+		log.Error("Domain failed, restarting", "err", err, "retry_in", delay)
+
+		select {
+		case <-s.ctx.Done():
+			d.setState(StateStopped, "")
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// get 複製目前的狀態
+func (d *supervisedDomain) get() Snapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	snap := d.snapshot
+	snap.Devices = append([]dante.Device{}, d.snapshot.Devices...)
+	return snap
+}
+
+// Devices 實作 Reporter
+func (d *supervisedDomain) Devices(devices []dante.Device) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if d.snapshot.State != StateRunning {
+		d.snapshot.State = StateRunning
+		d.snapshot.Since = now
+	}
+	d.snapshot.Phase = ""
+	d.snapshot.Devices = append([]dante.Device{}, devices...)
+	d.snapshot.Updated = now
+	d.snapshot.Stale = false
+}
+
+// Progress 實作 Reporter
+func (d *supervisedDomain) Progress(phase string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.snapshot.Phase = phase
+	if err != nil {
+		d.snapshot.LastError = err.Error()
+	}
+}
+
+// Network 實作 Reporter
+func (d *supervisedDomain) Network(iface, ip string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.snapshot.Interface = iface
+	d.snapshot.IPAddress = ip
+}
+
+// setState 更新狀態，lastError 空白時保留上次的失敗原因
+func (d *supervisedDomain) setState(state, lastError string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.snapshot.State != state {
+		d.snapshot.State = state
+		d.snapshot.Since = time.Now()
+	}
+	if lastError != "" {
+		d.snapshot.LastError = lastError
+	}
+	switch state {
+	case StateRunning:
+	case StateStopped:
+		d.snapshot.Devices = nil
+		d.snapshot.Stale = false
+	default:
+		// 重啟期間保留上次的列表，標記為未確認
+		d.snapshot.Stale = len(d.snapshot.Devices) > 0
+	}
+	if state != StateStarting {
+		d.snapshot.Phase = ""
+	}
+}
+
+// fail 記錄失敗 (設備列表保留並標記 Stale，等待重啟後確認)
+func (d *supervisedDomain) fail(err error) {
+	d.setState(StateFailed, fmt.Sprint(err))
+	d.mu.Lock()
+	d.snapshot.Restarts++
+	d.mu.Unlock()
+}
+
+// dropDevices 不再重啟的網域清除設備列表 (不會再有人確認)
+func (d *supervisedDomain) dropDevices() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.snapshot.Devices = nil
+	d.snapshot.Stale = false
+}
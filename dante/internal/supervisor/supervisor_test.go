@@ -0,0 +1,314 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"danteCS/internal/backoff"
+	"danteCS/internal/dante"
+)
+
+// testSupervisorConfig 測試用的退避時間
+func testSupervisorConfig(delay time.Duration) Config {
+	return Config{Restart: backoff.Policy{Initial: delay, Max: delay}}
+}
+
+// fakeDomain 可從測試中終止的網域工作
+type fakeDomain struct {
+	devices []dante.Device
+	kill    chan any // 送入 error 讓工作回傳錯誤，其他值讓工作 panic
+	starts  atomic.Int32
+	reports atomic.Int32
+}
+
+func newFakeDomain(names ...string) *fakeDomain {
+	f := &fakeDomain{kill: make(chan any, 1)}
+	for i, name := range names {
+		f.devices = append(f.devices, dante.Device{ID: i + 1, Name: name, LinkSpeed: 1000})
+	}
+	return f
+}
+
+func (f *fakeDomain) Run(ctx context.Context, report Reporter) error {
+	f.starts.Add(1)
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		report.Devices(f.devices)
+		f.reports.Add(1)
+		select {
+		case <-ctx.Done():
+			return nil
+		case v := <-f.kill:
+			if err, ok := v.(error); ok {
+				return err
+			}
+			panic(v)
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitFor 等待條件成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func startTwoDomains(t *testing.T, restartDelay time.Duration) (*Supervisor, *fakeDomain, *fakeDomain) {
+	t.Helper()
+	a := newFakeDomain("amp-1", "amp-2")
+	b := newFakeDomain("mixer")
+
+	s := New(testSupervisorConfig(restartDelay))
+	s.Add(Spec{Name: "Dante1", Interface: "eth1", Run: a.Run})
+	s.Add(Spec{Name: "Dante2", Interface: "eth2", Run: b.Run})
+	s.Start(context.Background())
+	t.Cleanup(s.Stop)
+
+	waitFor(t, "both domains running", func() bool {
+		for _, snap := range s.Snapshots() {
+			if snap.State != StateRunning {
+				return false
+			}
+		}
+		return true
+	})
+	return s, a, b
+}
+
+func TestDomainPanicDoesNotAffectOtherDomain(t *testing.T) {
+	// 退避時間讓 Dante1 停在失敗狀態夠久，確認 Dante2 持續回報
+	s, a, b := startTwoDomains(t, 500*time.Millisecond)
+
+	a.kill <- "simulated SDK crash"
+
+	waitFor(t, "Dante1 failed", func() bool {
+		snap, _ := s.Snapshot("Dante1")
+		return snap.State == StateFailed
+	})
+
+	// 失敗期間保留上次的設備，但標記為未確認
+	snap, _ := s.Snapshot("Dante1")
+	if len(snap.Devices) != 2 || !snap.Stale {
+		t.Fatalf("failed domain: %d devices, stale %v; want 2 stale devices", len(snap.Devices), snap.Stale)
+	}
+	if snap.Restarts != 1 || snap.LastError == "" {
+		t.Fatalf("unexpected failure record: restarts=%d last_error=%q", snap.Restarts, snap.LastError)
+	}
+
+	before := b.reports.Load()
+	waitFor(t, "Dante2 keeps reporting", func() bool { return b.reports.Load() > before+3 })
+
+	other, _ := s.Snapshot("Dante2")
+	if other.State != StateRunning || other.Restarts != 0 || len(other.Devices) != 1 {
+		t.Fatalf("healthy domain was affected: %+v", other)
+	}
+	if b.starts.Load() != 1 {
+		t.Fatalf("healthy domain was restarted %d times", b.starts.Load()-1)
+	}
+}
+
+func TestFailedDomainRestartsIndependently(t *testing.T) {
+	s, a, b := startTwoDomains(t, 10*time.Millisecond)
+
+	a.kill <- errors.New("interface eth1 is down")
+	waitFor(t, "Dante1 restarted", func() bool {
+		snap, _ := s.Snapshot("Dante1")
+		return a.starts.Load() == 2 && snap.State == StateRunning
+	})
+
+	snap, _ := s.Snapshot("Dante1")
+	if snap.Restarts != 1 || snap.LastError != "interface eth1 is down" {
+		t.Fatalf("unexpected failure record: restarts=%d last_error=%q", snap.Restarts, snap.LastError)
+	}
+	if len(snap.Devices) != 2 || snap.Stale {
+		t.Fatalf("restarted domain reports %d devices (stale %v), want 2 live devices", len(snap.Devices), snap.Stale)
+	}
+	if b.starts.Load() != 1 {
+		t.Fatalf("healthy domain was restarted %d times", b.starts.Load()-1)
+	}
+}
+
+// sdkDomain 以 dante.Domain 與它自己的模擬 SDK 執行的網域工作
+type sdkDomain struct {
+	domain *dante.Domain
+	kill   chan error
+	starts atomic.Int32
+}
+
+func newSDKDomain(name string) *sdkDomain {
+	sim := dante.NewSimulatedSDK(dante.DefaultSimulationConfig())
+	d := dante.NewSimulatedDomain(name, dante.NetworkConfig{InterfaceName: "sim0"}, sim)
+	d.DeviceTTL = 0 // 每次都向 SDK 讀取，確認 SDK 狀態沒有被清掉
+	return &sdkDomain{domain: d, kill: make(chan error, 1)}
+}
+
+func (f *sdkDomain) Run(ctx context.Context, report Reporter) error {
+	f.starts.Add(1)
+	d := f.domain
+	if err := d.Initialize(ctx); err != nil {
+		return err
+	}
+	defer d.Cleanup()
+	if err := d.StartDeviceScan(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		d.RefreshDevices(ctx)
+		report.Devices(d.GetDevices())
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-f.kill:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+func TestDomainRestartKeepsOtherDomainSDK(t *testing.T) {
+	a, b := newSDKDomain("Dante1"), newSDKDomain("Dante2")
+	want := len(dante.DefaultSimulationConfig().Devices)
+
+	s := New(testSupervisorConfig(10 * time.Millisecond))
+	s.Add(Spec{Name: "Dante1", Interface: "sim0", Run: a.Run})
+	s.Add(Spec{Name: "Dante2", Interface: "sim0", Run: b.Run})
+	s.Start(context.Background())
+	t.Cleanup(s.Stop)
+	waitFor(t, "both domains discovered their devices", func() bool {
+		for _, snap := range s.Snapshots() {
+			if snap.State != StateRunning || len(snap.Devices) != want {
+				return false
+			}
+		}
+		return true
+	})
+
+	// Dante1 的重啟 (Cleanup 後重新 Initialize) 不清除 Dante2 的 SDK 狀態
+	a.kill <- errors.New("interface sim0 is down")
+	waitFor(t, "Dante1 restarted", func() bool {
+		snap, _ := s.Snapshot("Dante1")
+		return a.starts.Load() == 2 && snap.State == StateRunning && len(snap.Devices) == want
+	})
+
+	if !b.domain.Initialized() || b.starts.Load() != 1 {
+		t.Fatalf("Dante2 was reset: initialized=%v starts=%d", b.domain.Initialized(), b.starts.Load())
+	}
+	if got := len(b.domain.GetDevices()); got != want {
+		t.Fatalf("Dante2 SDK lists %d devices after Dante1 restarted, want %d", got, want)
+	}
+	other, _ := s.Snapshot("Dante2")
+	if other.State != StateRunning || other.Restarts != 0 || other.Stale {
+		t.Fatalf("healthy domain was affected: %+v", other)
+	}
+}
+
+func TestSupervisorStopEndsAllDomains(t *testing.T) {
+	s, _, _ := startTwoDomains(t, 10*time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	for _, snap := range s.Snapshots() {
+		if snap.State != StateStopped || len(snap.Devices) != 0 {
+			t.Fatalf("domain not stopped: %+v", snap)
+		}
+	}
+}
+
+func TestPermanentFailureIsNotRestarted(t *testing.T) {
+	var starts, failures atomic.Int32
+	cfg := testSupervisorConfig(5 * time.Millisecond)
+	cfg.OnFailure = func(snap Snapshot, permanent bool) {
+		if snap.Name == "Dante1" && permanent && snap.LastError != "" {
+			failures.Add(1)
+		}
+	}
+	s := New(cfg)
+	s.Add(Spec{Name: "Dante1", Run: func(ctx context.Context, report Reporter) error {
+		starts.Add(1)
+		report.Progress("waiting for interface eth1", nil)
+		err := backoff.Retry(ctx, backoff.Policy{Initial: time.Millisecond, MaxAttempts: 3}, nil, func(int) error {
+			return errors.New("interface eth1 has no IP address")
+		})
+		return fmt.Errorf("%w: initialization %v", ErrPermanent, err)
+	}})
+	s.Add(Spec{Name: "Dante2", Run: newFakeDomain("mixer").Run})
+	s.Start(context.Background())
+	t.Cleanup(s.Stop)
+
+	waitFor(t, "Dante2 running", func() bool {
+		snap, _ := s.Snapshot("Dante2")
+		return snap.State == StateRunning
+	})
+	waitFor(t, "Dante1 failed", func() bool {
+		snap, _ := s.Snapshot("Dante1")
+		return snap.State == StateFailed
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	snap, _ := s.Snapshot("Dante1")
+	if starts.Load() != 1 || snap.State != StateFailed || snap.Phase != "" {
+		t.Fatalf("domain restarted after giving up: starts=%d %+v", starts.Load(), snap)
+	}
+	if failures.Load() != 1 {
+		t.Errorf("OnFailure called %d times for the permanent failure", failures.Load())
+	}
+}
+
+func TestSeededDevicesAreStaleUntilReported(t *testing.T) {
+	release := make(chan struct{})
+	live := newFakeDomain("amp-1", "amp-2", "amp-3")
+	s := New(testSupervisorConfig(10 * time.Millisecond))
+	s.Add(Spec{Name: "Dante1", Run: func(ctx context.Context, report Reporter) error {
+		// 模擬初始化與首次發現還沒完成
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil
+		}
+		return live.Run(ctx, report)
+	}})
+	asOf := time.Now().Add(-time.Hour)
+	if !s.Seed("Dante1", newFakeDomain("amp-1").devices, asOf) || s.Seed("Dante9", nil, asOf) {
+		t.Fatal("Seed matched the wrong domain")
+	}
+	s.Start(context.Background())
+	t.Cleanup(s.Stop)
+
+	waitFor(t, "Dante1 starting", func() bool {
+		snap, _ := s.Snapshot("Dante1")
+		return snap.State == StateStarting
+	})
+	snap, _ := s.Snapshot("Dante1")
+	if len(snap.Devices) != 1 || !snap.Stale || !snap.Updated.Equal(asOf) {
+		t.Fatalf("cached devices not served while starting: %+v", snap)
+	}
+
+	close(release)
+	waitFor(t, "live devices", func() bool {
+		snap, _ := s.Snapshot("Dante1")
+		return snap.State == StateRunning && len(snap.Devices) == 3 && !snap.Stale
+	})
+}
@@ -0,0 +1,621 @@
+// Package trace 分散式追蹤 (OpenTelemetry 相容的 span 與 OTLP/HTTP 匯出)
+package trace
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 分散式追蹤 (OpenTelemetry)
+//==============================================================================
+
+// API 請求、SDK 操作 (CGo 呼叫) 與事件處理各自建立 span，透過 context 串成
+// 一條 trace，例如 PUT /api/routes → dante.route_subscribe。
+// span 以 OTLP/HTTP (JSON 編碼) 批次送到 collector，可直接接到現有的追蹤後端；
+// 呼叫端送來的 W3C traceparent 標頭由傳輸層以 WithRemote 放入 context。
+// 未呼叫 Enable 時全域 tracer 為 nil，Start 不做任何事。
+
+// global 全域 tracer (nil 表示停用追蹤)
+var global atomic.Pointer[Tracer]
+
+// Span 種類 (OTLP SpanKind)
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// otlpTracesPath OTLP/HTTP 的 traces 路徑
+const otlpTracesPath = "/v1/traces"
+
+// Config 追蹤設定
+type Config struct {
+	Endpoint    string            // OTLP/HTTP endpoint (例如 http://collector:4318)
+	ServiceName string            // service.name 資源屬性
+	Headers     map[string]string // 額外的請求標頭 (例如後端的驗證)
+	SampleRatio float64           // 新 trace 的取樣比例 (0-1)，延續的 trace 依上層決定
+	BatchSize   int               // 累積多少 span 送出一次
+	Interval    time.Duration     // 最長多久送出一次
+}
+
+// DefaultConfig 預設值，並套用標準的 OTEL_* 環境變數
+func DefaultConfig(serviceName string) Config {
+	cfg := Config{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		ServiceName: serviceName,
+		Headers:     parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		SampleRatio: 1,
+		BatchSize:   512,
+		Interval:    5 * time.Second,
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		cfg.ServiceName = name
+	}
+	return cfg
+}
+
+// parseOTLPHeaders 解析 OTEL_EXPORTER_OTLP_HEADERS (key=value,key2=value2)
+func parseOTLPHeaders(spec string) map[string]string {
+	headers := map[string]string{}
+	for _, item := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		if v, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = v
+		}
+		headers[strings.TrimSpace(key)] = value
+	}
+	return headers
+}
+
+// tracesURL endpoint 沒有路徑時補上 /v1/traces
+func tracesURL(endpoint string) (string, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid OTLP endpoint %q", endpoint)
+	}
+	if !strings.HasSuffix(u.Path, otlpTracesPath) {
+		u.Path = strings.TrimSuffix(u.Path, "/") + otlpTracesPath
+	}
+	return u.String(), nil
+}
+
+//----------------------------------------------------------------------
+// Span
+//----------------------------------------------------------------------
+
+// TraceID 與 SpanID (W3C Trace Context)
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+// SpanContext 跨行程傳遞的 span 識別
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// Valid 是否為有效的識別 (全 0 無效)
+func (sc SpanContext) Valid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Span 一段操作 (nil 時所有方法都不做任何事)
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent SpanID
+	name   string
+	kind   int
+	start  time.Time
+
+	mu     sync.Mutex
+	attrs  []slog.Attr
+	events []spanEvent
+	err    string
+	ended  bool
+}
+
+// spanEvent span 期間發生的事件
+type spanEvent struct {
+	name  string
+	time  time.Time
+	attrs []slog.Attr
+}
+
+// spanContextKey context 中目前 span 的 key
+type spanContextKey struct{}
+
+// remoteSpanKey context 中呼叫端傳來的 span 識別
+type remoteSpanKey struct{}
+
+// Start 建立 span 並放入 ctx (追蹤停用或未取樣時回傳 nil span)
+func Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal, attrs...)
+}
+
+// StartKind 指定種類建立 span
+func StartKind(ctx context.Context, name string, kind int, attrs ...slog.Attr) (context.Context, *Span) {
+	t := global.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := FromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.Valid() {
+		sc.TraceID, sc.Sampled = parent.TraceID, parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample(sc.TraceID)
+	}
+	if !sc.Sampled {
+		// 不記錄，但仍傳遞識別讓下游維持相同的取樣決定
+		return context.WithValue(ctx, remoteSpanKey{}, sc), nil
+	}
+
+	span := &Span{
+		tracer: t,
+		sc:     sc,
+		parent: parent.SpanID,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// WithRemote 把呼叫端傳來的 span 識別放入 ctx，之後的 span 以它為上層
+func WithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanKey{}, sc)
+}
+
+// FromContext ctx 中目前的 span 識別 (本行程的 span 或呼叫端傳來的)
+func FromContext(ctx context.Context) SpanContext {
+	if span, ok := ctx.Value(spanContextKey{}).(*Span); ok {
+		return span.sc
+	}
+	if sc, ok := ctx.Value(remoteSpanKey{}).(SpanContext); ok {
+		return sc
+	}
+	return SpanContext{}
+}
+
+// SetAttributes 加入屬性
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// AddEvent 記錄 span 期間的事件
+func (s *Span) AddEvent(name string, attrs ...slog.Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, spanEvent{name: name, time: time.Now(), attrs: attrs})
+}
+
+// RecordError 標記 span 失敗 (err 為 nil 時不做任何事)
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+	s.events = append(s.events, spanEvent{name: "exception", time: time.Now(),
+		attrs: []slog.Attr{slog.String("exception.message", err.Error())}})
+}
+
+// End 結束 span 並交給 exporter (重複呼叫只算第一次)
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := s.encode(end)
+	s.mu.Unlock()
+	s.tracer.enqueue(data)
+}
+
+//----------------------------------------------------------------------
+// W3C traceparent
+//----------------------------------------------------------------------
+
+// TraceparentHeader W3C Trace Context 標頭
+const TraceparentHeader = "traceparent"
+
+// ParseTraceparent 解析 00-<trace-id>-<span-id>-<flags>
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || !sc.Valid() {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, true
+}
+
+// FormatTraceparent 產生 traceparent 標頭值
+func FormatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+//----------------------------------------------------------------------
+// Tracer 與 OTLP exporter
+//----------------------------------------------------------------------
+
+// Tracer 取樣並批次匯出 span
+type Tracer struct {
+	cfg    Config
+	url    string
+	client *http.Client
+
+	queue   chan otlpSpan
+	flushCh chan chan struct{}
+	done    chan struct{}
+	dropped atomic.Int64 // 佇列已滿而丟棄的 span
+}
+
+// NewTracer 建立 tracer 並開始背景匯出
+func NewTracer(cfg Config) (*Tracer, error) {
+	u, err := tracesURL(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+
+	t := &Tracer{
+		cfg:     cfg,
+		url:     u,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan otlpSpan, cfg.BatchSize*4),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	recovery.Go("tracing", t.exportLoop)
+	return t, nil
+}
+
+// Enabled 是否已啟用全域 tracer
+func Enabled() bool {
+	return global.Load() != nil
+}
+
+// SetGlobal 替換全域 tracer (nil 停用追蹤)，回傳原本的 tracer
+func SetGlobal(t *Tracer) *Tracer {
+	return global.Swap(t)
+}
+
+// Enable 設定全域 tracer，回傳的函數在結束時送出剩餘的 span
+func Enable(cfg Config) (func(), error) {
+	t, err := NewTracer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	global.Store(t)
+	slog.Info("Tracing enabled", "endpoint", t.url, "service", cfg.ServiceName, "sample", cfg.SampleRatio)
+	return func() {
+		global.Store(nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		t.Shutdown(ctx)
+	}, nil
+}
+
+// sample 依 trace ID 決定是否取樣 (同一個 trace 在任何行程得到相同結果)
+func (t *Tracer) sample(id TraceID) bool {
+	switch {
+	case t.cfg.SampleRatio >= 1:
+		return true
+	case t.cfg.SampleRatio <= 0:
+		return false
+	}
+	return float64(binary.BigEndian.Uint64(id[8:])>>1) < t.cfg.SampleRatio*float64(uint64(1)<<63)
+}
+
+// enqueue 交給背景匯出 (佇列已滿時丟棄，不阻塞 SDK 操作)
+func (t *Tracer) enqueue(span otlpSpan) {
+	select {
+	case t.queue <- span:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+// Flush 立即送出佇列中的 span
+func (t *Tracer) Flush(ctx context.Context) {
+	ack := make(chan struct{})
+	select {
+	case t.flushCh <- ack:
+	case <-t.done:
+		return
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-ack:
+	case <-ctx.Done():
+	}
+}
+
+// Shutdown 送出剩餘的 span 並停止背景匯出
+func (t *Tracer) Shutdown(ctx context.Context) {
+	t.Flush(ctx)
+	select {
+	case <-t.done:
+	default:
+		close(t.done)
+	}
+}
+
+// exportLoop 累積到 BatchSize 或每 Interval 送出一次
+func (t *Tracer) exportLoop() {
+	ticker := time.NewTicker(t.cfg.Interval)
+	defer ticker.Stop()
+
+	var batch []otlpSpan
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.export(batch); err != nil {
+			slog.Warn("Trace export failed", "spans", len(batch), "err", err)
+		}
+		if dropped := t.dropped.Swap(0); dropped > 0 {
+			slog.Warn("Trace queue full, spans dropped", "spans", dropped)
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= t.cfg.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-t.flushCh:
+			for drained := false; !drained; {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(ack)
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// export 以 OTLP/HTTP JSON 送出一批 span
+func (t *Tracer) export(spans []otlpSpan) error {
+	req := otlpTraceRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: otlpAttributes([]slog.Attr{
+			slog.String("service.name", t.cfg.ServiceName),
+		})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: t.cfg.ServiceName},
+			Spans: spans,
+		}},
+	}}}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range t.cfg.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+//----------------------------------------------------------------------
+// OTLP JSON 編碼
+//----------------------------------------------------------------------
+
+// OTLP/HTTP JSON: trace/span ID 為 hex 字串，64 位元整數為十進位字串
+type (
+	otlpTraceRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Events            []otlpEvent    `json:"events,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpEvent struct {
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Name         string         `json:"name"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 0 unset, 1 ok, 2 error
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// encode 轉成 OTLP span (呼叫端持有 s.mu)
+func (s *Span) encode(end time.Time) otlpSpan {
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: unixNano(s.start),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes:        otlpAttributes(s.attrs),
+	}
+	if s.parent != (SpanID{}) {
+		span.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	for _, e := range s.events {
+		span.Events = append(span.Events, otlpEvent{
+			TimeUnixNano: unixNano(e.time),
+			Name:         e.name,
+			Attributes:   otlpAttributes(e.attrs),
+		})
+	}
+	if s.err != "" {
+		span.Status = otlpStatus{Code: 2, Message: s.err}
+	}
+	return span
+}
+
+// otlpAttributes 把 slog 屬性轉成 OTLP AnyValue
+func otlpAttributes(attrs []slog.Attr) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		var value map[string]any
+		switch v.Kind() {
+		case slog.KindBool:
+			value = map[string]any{"boolValue": v.Bool()}
+		case slog.KindInt64:
+			value = map[string]any{"intValue": strconv.FormatInt(v.Int64(), 10)}
+		case slog.KindUint64:
+			value = map[string]any{"intValue": strconv.FormatUint(v.Uint64(), 10)}
+		case slog.KindFloat64:
+			value = map[string]any{"doubleValue": v.Float64()}
+		case slog.KindDuration:
+			value = map[string]any{"intValue": strconv.FormatInt(int64(v.Duration()), 10)}
+		default:
+			value = map[string]any{"stringValue": v.String()}
+		}
+		kvs = append(kvs, otlpKeyValue{Key: a.Key, Value: value})
+	}
+	return kvs
+}
+
+// unixNano OTLP 的時間 (十進位字串)
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// newTraceID 隨機 trace ID
+func newTraceID() (id TraceID) {
+	for id == (TraceID{}) {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+// newSpanID 隨機 span ID
+func newSpanID() (id SpanID) {
+	for id == (SpanID{}) {
+		rand.Read(id[:])
+	}
+	return id
+}
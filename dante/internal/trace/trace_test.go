@@ -0,0 +1,145 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCollector 收集 OTLP/HTTP JSON 送來的 span
+type fakeCollector struct {
+	mu    sync.Mutex
+	spans []otlpSpan
+}
+
+func (c *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != otlpTracesPath || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	var req otlpTraceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+// byName 依名稱找 span
+func (c *fakeCollector) byName(name string) (otlpSpan, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.spans {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return otlpSpan{}, false
+}
+
+// startTestTracing 啟用送到 fakeCollector 的 tracer
+func startTestTracing(t *testing.T, ratio float64) (*fakeCollector, *Tracer) {
+	t.Helper()
+	collector := &fakeCollector{}
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+
+	tr, err := NewTracer(Config{Endpoint: server.URL, ServiceName: "test", SampleRatio: ratio, Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetGlobal(tr)
+	t.Cleanup(func() {
+		SetGlobal(nil)
+		tr.Shutdown(context.Background())
+	})
+	return collector, tr
+}
+
+func TestTraceparent(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(header)
+	if !ok || !sc.Sampled {
+		t.Fatalf("ParseTraceparent(%q) = %+v, %v", header, sc, ok)
+	}
+	if got := FormatTraceparent(sc); got != header {
+		t.Fatalf("FormatTraceparent = %q", got)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	} {
+		if _, ok := ParseTraceparent(bad); ok {
+			t.Errorf("ParseTraceparent(%q) accepted", bad)
+		}
+	}
+}
+
+func TestTracingDisabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+	if span != nil || ctx != context.Background() {
+		t.Fatal("span created without a tracer")
+	}
+	// nil span 的方法不應 panic
+	span.RecordError(context.Canceled)
+	span.End()
+}
+
+func TestSampling(t *testing.T) {
+	_, tr := startTestTracing(t, 0)
+
+	// 新 trace 依比例不取樣，但呼叫端已取樣的 trace 要延續
+	if _, span := Start(context.Background(), "root"); span != nil {
+		t.Fatal("span recorded with sample ratio 0")
+	}
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := context.WithValue(context.Background(), remoteSpanKey{}, sc)
+	_, span := Start(ctx, "child")
+	if span == nil || span.sc.TraceID != sc.TraceID || span.parent != sc.SpanID {
+		t.Fatalf("sampled parent not followed: %+v", span)
+	}
+
+	if tr.sample(newTraceID()) {
+		t.Fatal("ratio 0 sampled a trace")
+	}
+}
+
+func TestExport(t *testing.T) {
+	collector, tr := startTestTracing(t, 1)
+
+	ctx, parent := Start(context.Background(), "parent", slog.String("dante.domain", "Dante1"))
+	_, child := Start(ctx, "child")
+	child.RecordError(errors.New("dante_route_subscribe failed"))
+	child.End()
+	child.End()
+	parent.End()
+
+	tr.Flush(context.Background())
+	p, ok := collector.byName("parent")
+	if !ok || len(p.Attributes) != 1 || p.Attributes[0].Value["stringValue"] != "Dante1" {
+		t.Fatalf("parent span = %+v", p)
+	}
+	c, ok := collector.byName("child")
+	if !ok || c.ParentSpanID != p.SpanID || c.TraceID != p.TraceID || c.Status.Code != 2 {
+		t.Fatalf("child span = %+v", c)
+	}
+	if len(collector.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(collector.spans))
+	}
+}
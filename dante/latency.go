@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/reach"
+)
+
+//==============================================================================
+// 傳輸延遲
+//==============================================================================
+
+// 接收設備的延遲設定 (預設 1 ms) 必須涵蓋音訊封包從發送設備經過交換器
+// 到達的時間，超過時封包太晚到而被丟棄，聽起來是偶發的爆音。從 Dante
+// 介面對每台設備送出多輪 ICMP echo，以往返時間估算單向的傳輸時間，並與
+// 設備的接收延遲比較：
+//
+//   - 單向傳輸時間以 (平均往返 + 2 × 抖動) / 2 估算；最大值常受設備 CPU
+//     處理 ICMP 的延遲影響，只作參考
+//   - 傳輸時間超過延遲設定的 Threshold 比例時標示為 at-risk，超過延遲設定
+//     時標示為 late；有遺失的 echo 也標示為 at-risk
+//
+// 估算以本機到設備的路徑代表設備之間的路徑，本機與設備不在同一台交換器時
+// 偏差較大。
+
+// 延遲風險
+const (
+	LatencyOK      = "ok"
+	LatencyAtRisk  = "at-risk"
+	LatencyLate    = "late"
+	LatencyUnknown = "unknown" // 沒有回應或讀不到延遲設定
+)
+
+// maxLatencySamples API 一次量測的輪數上限
+const maxLatencySamples = 100
+
+// SettingsReader 讀取設備的取樣率與延遲設定 (由 dante.Domain 實作)
+type SettingsReader interface {
+	DeviceSettings(ctx context.Context, device string) (dante.DeviceSettings, error)
+}
+
+var _ SettingsReader = (*dante.Domain)(nil)
+
+// LatencyOptions 量測參數
+type LatencyOptions struct {
+	Samples   int           `json:"samples"`   // echo 輪數
+	Interval  time.Duration `json:"interval"`  // 輪與輪的間隔
+	Timeout   time.Duration `json:"timeout"`   // 每輪等待回應的時間
+	Threshold float64       `json:"threshold"` // 傳輸時間超過延遲設定的此比例時警告
+}
+
+// DefaultLatencyOptions 10 輪、間隔 100 ms、傳輸時間超過延遲設定一半時警告
+func DefaultLatencyOptions() LatencyOptions {
+	return LatencyOptions{Samples: 10, Interval: 100 * time.Millisecond, Timeout: time.Second, Threshold: 0.5}
+}
+
+// Validate 檢查參數
+func (o LatencyOptions) Validate() error {
+	switch {
+	case o.Samples <= 0:
+		return fmt.Errorf("invalid sample count %d", o.Samples)
+	case o.Interval < 0 || o.Timeout <= 0:
+		return fmt.Errorf("invalid interval %s or timeout %s", o.Interval, o.Timeout)
+	case o.Threshold <= 0 || o.Threshold > 1:
+		return fmt.Errorf("threshold %g must be between 0 and 1", o.Threshold)
+	}
+	return nil
+}
+
+// LatencyReport 量測結果
+type LatencyReport struct {
+	Generated time.Time       `json:"generated"`
+	Options   LatencyOptions  `json:"options"`
+	Devices   []DeviceLatency `json:"devices"`
+	Warnings  []string        `json:"warnings,omitempty"` // 可能有封包太晚到的設備
+	Errors    []string        `json:"errors,omitempty"`   // 無法量測或讀取設定的設備
+}
+
+// DeviceLatency 單一設備的量測
+type DeviceLatency struct {
+	Domain    string       `json:"domain"`
+	Device    string       `json:"device"`
+	LatencyUs int          `json:"latency_us,omitempty"` // 設備的接收延遲設定 (0 表示讀不到)
+	RTT       reach.Series `json:"rtt"`
+	TransitUs int          `json:"transit_us,omitempty"` // 估算的單向傳輸時間
+	Budget    float64      `json:"budget,omitempty"`     // 傳輸時間佔延遲設定的比例
+	Risk      string       `json:"risk"`
+}
+
+// latencySource 量測的網域
+type latencySource struct {
+	Name      string
+	Interface string
+	Devices   []dante.Device
+	Settings  SettingsReader // nil 表示不讀取延遲設定
+}
+
+// MeasureLatency 量測各網域設備的傳輸延遲並與延遲設定比較
+func MeasureLatency(ctx context.Context, detector *NetworkDetector, sources []latencySource, opts LatencyOptions) LatencyReport {
+	report := LatencyReport{Generated: time.Now().UTC(), Options: opts, Devices: []DeviceLatency{}}
+
+	// 依介面分組，各介面同時量測
+	type slot struct{ source, device int }
+	targets := make(map[string][]netip.Addr)
+	slots := make(map[string][]slot)
+	results := make([][]DeviceLatency, len(sources))
+	for i, src := range sources {
+		results[i] = make([]DeviceLatency, len(src.Devices))
+		for j, dev := range src.Devices {
+			results[i][j] = DeviceLatency{Domain: src.Name, Device: dev.Name, Risk: LatencyUnknown}
+			addr, err := netip.ParseAddr(dev.IPAddress)
+			if err != nil || !addr.Is4() {
+				results[i][j].RTT = reach.Series{Address: dev.IPAddress, Error: "no IPv4 address"}
+				continue
+			}
+			iface := src.Interface
+			if detector != nil {
+				iface = reachInterface(detector, dev.IPAddress, src.Interface)
+			}
+			targets[iface] = append(targets[iface], addr)
+			slots[iface] = append(slots[iface], slot{i, j})
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for iface, addrs := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			series := reach.Measure(ctx, iface, addrs, opts.Samples, opts.Interval, opts.Timeout)
+			mu.Lock()
+			defer mu.Unlock()
+			for k, s := range slots[iface] {
+				results[s.source][s.device].RTT = series[k]
+			}
+		}()
+	}
+
+	// 量測的同時讀取延遲設定
+	for i, src := range sources {
+		if src.Settings == nil {
+			continue
+		}
+		for j, dev := range src.Devices {
+			settings, err := src.Settings.DeviceSettings(ctx, dev.Name)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %s: %v", src.Name, dev.Name, err))
+				continue
+			}
+			mu.Lock()
+			results[i][j].LatencyUs = settings.LatencyUs
+			mu.Unlock()
+		}
+	}
+	wg.Wait()
+
+	for _, list := range results {
+		for _, d := range list {
+			assessLatency(&d, opts.Threshold)
+			switch {
+			case d.RTT.Error != "":
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %s: %s", d.Domain, d.Device, d.RTT.Error))
+			case d.Risk == LatencyLate:
+				report.Warnings = append(report.Warnings, fmt.Sprintf(
+					"%s: %s transit of about %d µs exceeds its %d µs latency, audio packets will arrive late",
+					d.Domain, d.Device, d.TransitUs, d.LatencyUs))
+			case d.Risk == LatencyAtRisk && d.RTT.Received < d.RTT.Sent:
+				report.Warnings = append(report.Warnings, fmt.Sprintf(
+					"%s: %s lost %.0f%% of echo requests, audio packets are likely lost or late too",
+					d.Domain, d.Device, d.RTT.Loss()*100))
+			case d.Risk == LatencyAtRisk:
+				report.Warnings = append(report.Warnings, fmt.Sprintf(
+					"%s: %s transit of about %d µs uses %.0f%% of its %d µs latency, raise the latency or check the switches",
+					d.Domain, d.Device, d.TransitUs, d.Budget*100, d.LatencyUs))
+			}
+			report.Devices = append(report.Devices, d)
+		}
+	}
+	return report
+}
+
+// assessLatency 估算傳輸時間並判斷風險
+func assessLatency(d *DeviceLatency, threshold float64) {
+	if d.RTT.Received == 0 {
+		d.Risk = LatencyUnknown
+		return
+	}
+	d.TransitUs = int(((d.RTT.Avg + 2*d.RTT.Jitter) / 2).Microseconds())
+	if d.LatencyUs <= 0 {
+		d.Risk = LatencyUnknown
+		return
+	}
+	d.Budget = math.Round(float64(d.TransitUs)/float64(d.LatencyUs)*1000) / 1000
+	switch {
+	case d.TransitUs >= d.LatencyUs:
+		d.Risk = LatencyLate
+	case d.Budget > threshold || d.RTT.Received < d.RTT.Sent:
+		d.Risk = LatencyAtRisk
+	default:
+		d.Risk = LatencyOK
+	}
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// latencyQuery 以查詢參數覆寫預設的量測參數 (samples、threshold)
+func latencyQuery(q url.Values) (LatencyOptions, error) {
+	opts := DefaultLatencyOptions()
+	if v := q.Get("samples"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n > maxLatencySamples {
+			return opts, fmt.Errorf("invalid samples %q, use up to %d", v, maxLatencySamples)
+		}
+		opts.Samples = n
+	}
+	if v := q.Get("threshold"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid threshold %q", v)
+		}
+		opts.Threshold = threshold
+	}
+	return opts, opts.Validate()
+}
+
+// handleLatency GET /api/latency[?samples=&threshold=]
+func (s *APIServer) handleLatency(w http.ResponseWriter, r *http.Request) {
+	opts, err := latencyQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var sources []latencySource
+	for _, d := range s.snapshots() {
+		sources = append(sources, latencySource{Name: d.Name, Interface: d.Interface, Devices: d.Devices, Settings: s.settings[d.Name]})
+	}
+	writeJSON(w, http.StatusOK, MeasureLatency(r.Context(), s.detector, sources, opts))
+}
+
+// Latency daemon 量測的傳輸延遲
+func (c *RemoteClient) Latency(opts LatencyOptions) (LatencyReport, error) {
+	q := url.Values{}
+	q.Set("samples", strconv.Itoa(opts.Samples))
+	q.Set("threshold", strconv.FormatFloat(opts.Threshold, 'g', -1, 64))
+	var report LatencyReport
+	return report, c.do(http.MethodGet, "/api/latency?"+q.Encode(), nil, &report)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newLatencyCommand golane diag latency
+func newLatencyCommand() *Command {
+	fs := newFlagSet("latency")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	opts := DefaultLatencyOptions()
+	fs.IntVar(&opts.Samples, "samples", opts.Samples, "echo requests sent to each device")
+	fs.DurationVar(&opts.Interval, "interval", opts.Interval, "time between echo requests")
+	fs.Float64Var(&opts.Threshold, "threshold", opts.Threshold, "warn when the estimated transit uses more than this fraction of a device's latency")
+	jsonOut := fs.Bool("json", false, "print the report as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "latency",
+		Short: "Estimate network transit latency to each device and compare it with the device latency setting",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			var report LatencyReport
+			if remote.enabled() {
+				if opts.Samples > maxLatencySamples {
+					return fmt.Errorf("-samples %d exceeds %d in remote mode", opts.Samples, maxLatencySamples)
+				}
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if report, err = client.Latency(opts); err != nil {
+					return err
+				}
+			} else {
+				if err := ifaces.checkTiming(*wait); err != nil {
+					return err
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+				domain, err := ifaces.openPrimaryDomain(ctx, detector)
+				if err != nil {
+					return err
+				}
+				defer domain.Cleanup()
+				if err := discover(ctx, domain, *wait); err != nil {
+					return err
+				}
+				report = MeasureLatency(ctx, detector, []latencySource{{
+					Name:      domain.Name,
+					Interface: domain.NetworkConfig.InterfaceName,
+					Devices:   domain.GetDevices(),
+					Settings:  domain,
+				}}, opts)
+			}
+
+			for _, e := range report.Errors {
+				logger.Warn("Latency measurement incomplete", "err", e)
+			}
+			if *jsonOut {
+				return printJSON(report)
+			}
+			printLatency(report)
+			return nil
+		},
+	}
+}
+
+// printLatency 印出量測結果
+func printLatency(report LatencyReport) {
+	fmt.Print(i18n.Sprintf("\n=== Transit latency (%d samples, %.0f%% warning) ===\n", report.Options.Samples, report.Options.Threshold*100))
+	printHeader("%-10s %-20s %-16s %-24s %-8s %-6s %-9s %-9s %s\n",
+		"DOMAIN", "DEVICE", "ADDRESS", "RTT MIN/AVG/MAX", "JITTER", "LOSS", "TRANSIT", "LATENCY", "RISK")
+	fmt.Println("──────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+	us := func(d time.Duration) string { return strconv.FormatInt(d.Microseconds(), 10) }
+	for _, d := range report.Devices {
+		rtt, jitter, transit, latency := "-", "-", "-", "-"
+		if d.RTT.Received > 0 {
+			rtt = us(d.RTT.Min) + "/" + us(d.RTT.Avg) + "/" + us(d.RTT.Max) + " µs"
+			jitter = us(d.RTT.Jitter) + " µs"
+			transit = strconv.Itoa(d.TransitUs) + " µs"
+		}
+		if d.LatencyUs > 0 {
+			latency = strconv.Itoa(d.LatencyUs) + " µs"
+		}
+		fmt.Printf("%-10s %-20s %-16s %-24s %-8s %-6s %-9s %-9s %s\n", d.Domain, d.Device, d.RTT.Address,
+			rtt, jitter, fmt.Sprintf("%.0f%%", d.RTT.Loss()*100), transit, latency, d.Risk)
+	}
+	fmt.Println()
+	for _, w := range report.Warnings {
+		fmt.Fprint(os.Stderr, i18n.Sprintf("WARNING: %s\n", w))
+	}
+}
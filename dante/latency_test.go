@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"danteCS/internal/reach"
+)
+
+func TestAssessLatency(t *testing.T) {
+	series := func(sent, received int, avg, jitter time.Duration) reach.Series {
+		return reach.Series{Sent: sent, Received: received, Avg: avg, Jitter: jitter}
+	}
+	for _, tc := range []struct {
+		name      string
+		latencyUs int
+		rtt       reach.Series
+		transit   int
+		risk      string
+	}{
+		{"fast", 1000, series(10, 10, 200*time.Microsecond, 10*time.Microsecond), 110, LatencyOK},
+		{"half budget", 1000, series(10, 10, time.Millisecond, 100*time.Microsecond), 600, LatencyAtRisk},
+		{"late", 250, series(10, 10, 500*time.Microsecond, 50*time.Microsecond), 300, LatencyLate},
+		{"loss", 1000, series(10, 9, 200*time.Microsecond, 0), 100, LatencyAtRisk},
+		{"no setting", 0, series(10, 10, 200*time.Microsecond, 0), 100, LatencyUnknown},
+		{"no reply", 1000, series(10, 0, 0, 0), 0, LatencyUnknown},
+	} {
+		d := DeviceLatency{LatencyUs: tc.latencyUs, RTT: tc.rtt}
+		assessLatency(&d, 0.5)
+		if d.TransitUs != tc.transit || d.Risk != tc.risk {
+			t.Errorf("%s: transit %d µs, risk %s; want %d µs, %s", tc.name, d.TransitUs, d.Risk, tc.transit, tc.risk)
+		}
+	}
+}
+
+func TestLatencyQuery(t *testing.T) {
+	opts, err := latencyQuery(url.Values{"samples": {"20"}, "threshold": {"0.8"}})
+	if err != nil || opts.Samples != 20 || opts.Threshold != 0.8 {
+		t.Fatalf("opts = %+v, %v", opts, err)
+	}
+	for _, q := range []url.Values{
+		{"samples": {"1000"}},
+		{"samples": {"0"}},
+		{"threshold": {"1.5"}},
+	} {
+		if _, err := latencyQuery(q); err == nil {
+			t.Errorf("%v accepted", q)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// internalImports 各 internal 套件允許匯入的其他 internal 套件
+// 新增套件時必須在此登記，確保網域邏輯不依賴呈現層或傳輸層
+var internalImports = map[string][]string{
+	"recovery":   nil,
+	"aes67":      nil,
+	"igmp":       nil,
+	"lldp":       {"packet"},
+	"packet":     nil,
+	"qos":        {"packet"},
+	"reach":      {"packet"},
+	"snmp":       {"recovery"},
+	"pcap":       {"packet"},
+	"backoff":    nil,
+	"cron":       nil,
+	"openapi":    nil,
+	"i18n":       nil,
+	"bus":        nil,
+	"panel":      nil,
+	"serial":     nil,
+	"trace":      {"recovery"},
+	"dante":      {"backoff", "recovery", "trace"},
+	"supervisor": {"backoff", "dante", "recovery"},
+}
+
+// transportImports 網域套件不可匯入的標準庫 (HTTP、CLI、訊號、UI 資源)
+// trace 需要 net/http 匯出 OTLP，所以不在檢查範圍
+var transportImports = []string{"net/http", "flag", "os/signal", "embed", "html/template", "text/template"}
+
+// domainPackages 必須維持與傳輸層無關的套件
+var domainPackages = []string{"aes67", "backoff", "bus", "cron", "dante", "igmp", "lldp", "packet", "panel", "pcap", "qos", "reach", "recovery", "serial", "supervisor"}
+
+func TestInternalPackageLayering(t *testing.T) {
+	const module = "danteCS/"
+	entries, err := os.ReadDir("internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		allowed, ok := internalImports[name]
+		if !ok {
+			t.Errorf("internal/%s is not registered in internalImports", name)
+			continue
+		}
+		domain := slices.Contains(domainPackages, name)
+
+		for path, file := range packageImports(t, filepath.Join("internal", name)) {
+			for _, imp := range file {
+				if rel, ok := strings.CutPrefix(imp, module); ok {
+					dep, ok := strings.CutPrefix(rel, "internal/")
+					if !ok || !slices.Contains(allowed, dep) {
+						t.Errorf("%s imports %s (allowed: %v)", path, imp, allowed)
+					}
+					continue
+				}
+				if domain && slices.Contains(transportImports, imp) {
+					t.Errorf("%s imports transport package %s", path, imp)
+				}
+			}
+		}
+	}
+}
+
+// packageImports 讀取目錄中每個 Go 檔案 (含所有 build tag 與測試) 的 import
+func packageImports(t *testing.T, dir string) map[string][]string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string][]string)
+	fset := token.NewFileSet()
+	for _, path := range files {
+		f, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, spec := range f.Imports {
+			imp, _ := strconv.Unquote(spec.Path.Value)
+			result[path] = append(result[path], imp)
+		}
+	}
+	return result
+}
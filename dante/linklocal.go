@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// IPv4 Link-Local (169.254/16) 設備處理
+//==============================================================================
+
+// 出廠設備在沒有 DHCP 的網路上會停在 Auto-IP (169.254.x.x)，
+// 控制端必須在同一個 link-local 網段才能與它們溝通。
+
+// LinkLocalAliasAddress 根據介面 MAC 產生穩定的 link-local 別名地址
+// RFC 3927 保留 169.254.0.x 與 169.254.255.x，所以第三段限制在 1-254
+func LinkLocalAliasAddress(macAddress string) (string, error) {
+	mac, err := net.ParseMAC(macAddress)
+	if err != nil {
+		return "", fmt.Errorf("invalid MAC address %q: %v", macAddress, err)
+	}
+	if len(mac) < 2 {
+		return "", fmt.Errorf("MAC address %q too short", macAddress)
+	}
+
+	third := 1 + int(mac[len(mac)-2])%254
+	fourth := 1 + int(mac[len(mac)-1])%254
+	return fmt.Sprintf("169.254.%d.%d", third, fourth), nil
+}
+
+// linkLocalAddress 介面上已有的 link-local IPv4 地址 (主要地址或別名)，沒有時為空白
+func linkLocalAddress(info *NetworkInterfaceInfo) string {
+	if dante.IsLinkLocalIPv4(info.IPAddress) {
+		return info.IPAddress
+	}
+	for _, addr := range info.Addresses {
+		if !addr.IsIPv6 && dante.IsLinkLocalIPv4(addr.IP) {
+			return addr.IP
+		}
+	}
+	return ""
+}
+
+// EnsureLinkLocalAlias 在 Dante 介面上加入 link-local 別名，讓控制端能連到 Auto-IP 設備
+// 介面已有 link-local 地址時不做任何事，added 為 false
+func EnsureLinkLocalAlias(info *NetworkInterfaceInfo) (alias string, added bool, err error) {
+	if existing := linkLocalAddress(info); existing != "" {
+		return existing, false, nil
+	}
+
+	alias, err = LinkLocalAliasAddress(info.MacAddress)
+	if err != nil {
+		return "", false, err
+	}
+
+	cmd := exec.Command("ip", "addr", "add", alias+"/16", "dev", info.Name)
+	output, err := cmd.CombinedOutput()
+	switch {
+	case err == nil:
+		added = true
+	case strings.Contains(string(output), "File exists"):
+		// 介面資訊掃描之後才加上的別名
+	default:
+		return "", false, fmt.Errorf("failed to add link-local alias %s to %s: %v (%s)",
+			alias, info.Name, err, strings.TrimSpace(string(output)))
+	}
+
+	// 記在介面資訊上，之後的刷新不再重複加入
+	info.Addresses = append(info.Addresses, InterfaceAddress{IP: alias, PrefixLen: 16})
+	return alias, added, nil
+}
+
+// linkLocalDevices 回傳網域中停在 link-local 地址的設備
+func linkLocalDevices(d *dante.Domain) []dante.Device {
+	var result []dante.Device
+	for _, dev := range d.GetDevices() {
+		if dev.IsLinkLocal() {
+			result = append(result, dev)
+		}
+	}
+	return result
+}
+
+// reportLinkLocalDevices 報告 link-local 設備並提供設定指引
+// autoAlias 為 true 時會自動在 Dante 介面加上 link-local 別名
+func reportLinkLocalDevices(d *dante.Domain, nd *NetworkDetector, autoAlias bool) {
+	devices := linkLocalDevices(d)
+	if len(devices) == 0 {
+		return
+	}
+
+	for _, dev := range devices {
+		d.Logger().Warn("Device on link-local address", "device", dev.Name, "model", dev.Model, "ip", dev.IPAddress)
+	}
+
+	iface := nd.GetInterfaceByName(d.NetworkConfig.InterfaceName)
+	if iface == nil {
+		return
+	}
+
+	if linkLocalAddress(iface) == "" {
+		if autoAlias {
+			alias, added, err := EnsureLinkLocalAlias(iface)
+			if err != nil {
+				d.Logger().Warn("Failed to add link-local alias", "err", err)
+			} else if added {
+				d.Logger().Info("Link-local alias added", "iface", iface.Name, "alias", alias)
+			}
+		} else {
+			alias, err := LinkLocalAliasAddress(iface.MacAddress)
+			if err == nil {
+				d.Logger().Warn("Dante interface is not on 169.254/16; add an alias (or start with -linklocal-alias) to reach these devices",
+					"iface", iface.Name, "ip", iface.IPAddress,
+					"command", fmt.Sprintf("ip addr add %s/16 dev %s", alias, iface.Name))
+			}
+		}
+	}
+
+	d.Logger().Info("Enable DHCP on this network or assign static addresses in Dante Controller so devices leave the Auto-IP range",
+		"devices", len(devices))
+}
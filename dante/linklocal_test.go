@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinkLocalAliasAddress(t *testing.T) {
+	tests := []struct {
+		mac  string
+		want string
+		err  string
+	}{
+		{"00:1d:c1:12:34:56", "169.254.53.87", ""},
+		{"00:1d:c1:00:00:00", "169.254.1.1", ""},   // 不使用 169.254.0.x
+		{"00:1d:c1:00:fe:ff", "169.254.1.2", ""},   // 254 繞回 1
+		{"ff:ff:ff:ff:ff:ff", "169.254.2.2", ""},   // 不使用 169.254.255.x 與 .255
+		{"00-1D-C1-0A-0B-0C", "169.254.12.13", ""}, // 其他 MAC 格式
+		{"", "", "invalid MAC address"},
+		{"00:1d:c1:12:34", "", "invalid MAC address"},
+	}
+	for _, tt := range tests {
+		got, err := LinkLocalAliasAddress(tt.mac)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("LinkLocalAliasAddress(%q) = %q, %v; want error %q", tt.mac, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("LinkLocalAliasAddress(%q) = %q, %v; want %q", tt.mac, got, err, tt.want)
+		}
+	}
+}
+
+func TestEnsureLinkLocalAliasExisting(t *testing.T) {
+	tests := []struct {
+		name string
+		info NetworkInterfaceInfo
+		want string
+	}{
+		{"primary address", NetworkInterfaceInfo{Name: "eth1", IPAddress: "169.254.7.8"}, "169.254.7.8"},
+		{"alias added earlier", NetworkInterfaceInfo{Name: "eth1", IPAddress: "10.10.0.1",
+			Addresses: []InterfaceAddress{{IP: "10.10.0.1", PrefixLen: 24}, {IP: "169.254.53.87", PrefixLen: 16}}}, "169.254.53.87"},
+	}
+	for _, tt := range tests {
+		// 沒有 MAC：若沒有提前返回會在產生別名時失敗
+		alias, added, err := EnsureLinkLocalAlias(&tt.info)
+		if err != nil || added || alias != tt.want {
+			t.Errorf("%s: EnsureLinkLocalAlias = %q, %v, %v; want %q without change", tt.name, alias, added, err, tt.want)
+		}
+	}
+
+	// IPv6 link-local 與一般 IPv4 地址不算，需要加入別名
+	info := NetworkInterfaceInfo{Name: "eth1", IPAddress: "10.10.0.1",
+		Addresses: []InterfaceAddress{{IP: "10.10.0.1", PrefixLen: 24}, {IP: "fe80::1", PrefixLen: 64, IsIPv6: true}}}
+	if _, _, err := EnsureLinkLocalAlias(&info); err == nil || !strings.Contains(err.Error(), "invalid MAC address") {
+		t.Errorf("interface without link-local address: err = %v, want an attempt to add the alias", err)
+	}
+}
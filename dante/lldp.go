@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/lldp"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// LLDP 鄰居
+//==============================================================================
+
+// 支援電話的第一個問題是「接在交換器的哪個埠」。daemon 在 Dante 介面收聽
+// LLDP (lldp 功能)，列出每張網卡接的交換器與埠、埠的 VLAN，以及直接聽到
+// 的 Dante 設備 (直接連接或經過會轉送 LLDP 的非管理型交換器)：設備依 MAC
+// 或管理地址對應到發現的設備，支援 LLDP-MED 的設備另外有型號與 QoS 政策。
+
+// lldpInterval 交換器預設的 LLDP 送出間隔
+const lldpInterval = 30 * time.Second
+
+// LLDPWatch 收聽中的 Dante 介面
+type LLDPWatch struct {
+	monitor *lldp.Monitor
+	ifaces  []string
+}
+
+// LLDPDevice 聽到 LLDP 的 Dante 設備
+type LLDPDevice struct {
+	Domain   string        `json:"domain"`
+	Device   string        `json:"device"`
+	Neighbor lldp.Neighbor `json:"neighbor"`
+}
+
+// LLDPReport 單一介面的鄰居
+type LLDPReport struct {
+	Interface string          `json:"interface"`
+	Switch    *lldp.Neighbor  `json:"switch,omitempty"` // 網卡直接連接的交換器埠
+	Devices   []LLDPDevice    `json:"devices"`          // 對應到發現設備的鄰居
+	Neighbors []lldp.Neighbor `json:"neighbors"`        // 其他鄰居
+	Listened  time.Duration   `json:"listened"`
+	Notes     []string        `json:"notes,omitempty"`
+}
+
+// startLLDP 在介面上收聽 LLDP，ctx 結束時停止
+// 無法開啟 packet socket (沒有 CAP_NET_RAW) 時只記錄，報告沒有鄰居
+func startLLDP(ctx context.Context, ifaces []string) *LLDPWatch {
+	w := &LLDPWatch{monitor: lldp.NewMonitor(), ifaces: ifaces}
+	recovery.GoLoop(ctx, "lldp", func() {
+		if err := w.monitor.Listen(ctx, ifaces); err != nil {
+			logger.Warn("LLDP neighbor discovery unavailable", "err", err)
+		}
+	})
+	return w
+}
+
+// Reports 各介面的鄰居，並對應到 domains 中發現的設備
+func (w *LLDPWatch) Reports(domains []domainDevices) []LLDPReport {
+	reports := make([]LLDPReport, 0, len(w.ifaces))
+	for _, iface := range w.ifaces {
+		reports = append(reports, lldpReport(iface, w.monitor.Neighbors(iface), w.monitor.Listening(iface), domains))
+	}
+	return reports
+}
+
+// lldpReport 整理一個介面的鄰居
+// 第一個交換器鄰居視為網卡連接的埠，對應到設備的鄰居列在 Devices
+func lldpReport(iface string, neighbors []lldp.Neighbor, listened time.Duration, domains []domainDevices) LLDPReport {
+	r := LLDPReport{Interface: iface, Devices: []LLDPDevice{}, Neighbors: []lldp.Neighbor{}, Listened: listened}
+	switches := 0
+	for _, n := range neighbors {
+		if domain, dev, ok := lldpDevice(n, domains); ok {
+			r.Devices = append(r.Devices, LLDPDevice{Domain: domain, Device: dev.Name, Neighbor: n})
+			continue
+		}
+		if n.IsSwitch() {
+			if switches++; r.Switch == nil {
+				r.Switch = &n
+				continue
+			}
+		}
+		r.Neighbors = append(r.Neighbors, n)
+	}
+	slices.SortFunc(r.Devices, func(a, b LLDPDevice) int { return strings.Compare(a.Device, b.Device) })
+
+	switch {
+	case len(neighbors) == 0 && listened >= lldpInterval:
+		r.Notes = append(r.Notes, fmt.Sprintf("no LLDP heard in %s: LLDP is disabled on the switch port, or the NIC is on an unmanaged switch",
+			listened.Round(time.Second)))
+	case len(neighbors) == 0 && listened > 0:
+		r.Notes = append(r.Notes, fmt.Sprintf("no LLDP heard yet (listened %s, switches send every %s by default)",
+			listened.Round(time.Second), lldpInterval))
+	case len(neighbors) == 0:
+		r.Notes = append(r.Notes, "LLDP not monitored")
+	}
+	if switches > 1 {
+		r.Notes = append(r.Notes, fmt.Sprintf("%d switches heard: the NIC is behind an unmanaged switch or hub that forwards LLDP, the switch port is not known", switches))
+	}
+	return r
+}
+
+// lldpDevice 鄰居對應的設備：chassis ID 或來源地址為設備的 MAC，或管理地址為設備的 IP
+func lldpDevice(n lldp.Neighbor, domains []domainDevices) (string, dante.Device, bool) {
+	macs := []string{normalizeMAC(n.SourceMAC), normalizeMAC(n.ChassisID)}
+	for _, d := range domains {
+		for _, dev := range d.Devices {
+			if mac := normalizeMAC(dev.MacAddress); mac != "" && slices.Contains(macs, mac) {
+				return d.Domain, dev, true
+			}
+			for _, addr := range n.ManagementAddress {
+				if addr == dev.IPAddress || (dev.SecondaryIP != "" && addr == dev.SecondaryIP) {
+					return d.Domain, dev, true
+				}
+			}
+		}
+	}
+	return "", dante.Device{}, false
+}
+
+// normalizeMAC 統一 MAC 的格式 (不是 MAC 時為空白)
+func normalizeMAC(s string) string {
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		return ""
+	}
+	return mac.String()
+}
+
+// lldpPort 顯示交換器與埠 ("core-sw1 Gi1/0/12 (Stage rack)")
+func lldpPort(n lldp.Neighbor) string {
+	name := n.SystemName
+	if name == "" {
+		name = n.ChassisID
+	}
+	port := n.PortID
+	if n.PortDescription != "" && n.PortDescription != n.PortID {
+		port += " (" + n.PortDescription + ")"
+	}
+	return name + " " + port
+}
+
+// printLLDPReports 顯示各介面的交換器埠、設備與其他鄰居
+func printLLDPReports(reports []LLDPReport) {
+	for _, r := range reports {
+		fmt.Printf("\n=== %s ===\n", r.Interface)
+		if r.Switch != nil {
+			fmt.Print(i18n.Sprintf("Switch port: %s", lldpPort(*r.Switch)))
+			if r.Switch.PortVLAN > 0 {
+				fmt.Printf(", VLAN %d", r.Switch.PortVLAN)
+			}
+			if len(r.Switch.ManagementAddress) > 0 {
+				fmt.Printf(", %s", strings.Join(r.Switch.ManagementAddress, " "))
+			}
+			fmt.Println()
+		}
+		for _, d := range r.Devices {
+			line := fmt.Sprintf("  %-20s %-10s %s", d.Device, d.Domain, lldpPort(d.Neighbor))
+			if med := d.Neighbor.MED; med != nil {
+				for _, p := range med.Policies {
+					line += fmt.Sprintf(", %s VLAN %d DSCP %d", p.Application, p.VLAN, p.DSCP)
+				}
+			}
+			fmt.Println(line)
+		}
+		for _, n := range r.Neighbors {
+			fmt.Printf("  %-20s %-10s %s\n", "-", strings.Join(n.Capabilities, ","), lldpPort(n))
+		}
+		for _, n := range r.Notes {
+			fmt.Printf("  - %s\n", n)
+		}
+	}
+	fmt.Println()
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// handleLLDP GET /api/lldp
+func (s *APIServer) handleLLDP(w http.ResponseWriter, r *http.Request) {
+	snapshots := s.snapshots()
+	domains := make([]domainDevices, 0, len(snapshots))
+	for _, d := range snapshots {
+		domains = append(domains, domainDevices{Domain: d.Name, Devices: d.Devices})
+	}
+	writeJSON(w, http.StatusOK, s.lldp.Reports(domains))
+}
+
+// LLDPReports daemon 的 LLDP 鄰居
+func (c *RemoteClient) LLDPReports() ([]LLDPReport, error) {
+	var reports []LLDPReport
+	return reports, c.do(http.MethodGet, "/api/lldp", nil, &reports)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newLLDPCommand golane lldp
+func newLLDPCommand() *Command {
+	fs := newFlagSet("lldp")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", lldpInterval+5*time.Second, "how long to listen for LLDP (switches send about every 30s)")
+	jsonOut := fs.Bool("json", false, "print the neighbors as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "lldp",
+		Short: "Show the switch port of each Dante interface and devices heard with LLDP",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+
+			var reports []LLDPReport
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if reports, err = client.LLDPReports(); err != nil {
+					return err
+				}
+			} else {
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				names := danteInterfaceNames(detector)
+				if len(names) == 0 {
+					return fmt.Errorf("Dante interface not found (expected one of %v)", detector.DanteInterfaceNames)
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+				watch := &LLDPWatch{monitor: lldp.NewMonitor(), ifaces: names}
+				listenCtx, stop := context.WithTimeout(ctx, *wait)
+				defer stop()
+				if err := watch.monitor.Listen(listenCtx, names); err != nil {
+					return err
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				// 沒有連線 daemon 時不知道發現的設備，鄰居都列為其他
+				reports = watch.Reports(nil)
+			}
+
+			if *jsonOut {
+				return printJSON(reports)
+			}
+			printLLDPReports(reports)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/lldp"
+)
+
+func TestLLDPReport(t *testing.T) {
+	sw := lldp.Neighbor{Interface: "eth1", SourceMAC: "00:1b:54:aa:bb:0c", ChassisID: "00:1b:54:aa:bb:00", PortID: "Gi1/0/12",
+		SystemName: "core-sw1", Capabilities: []string{"bridge", "router"}, TTL: 2 * time.Minute}
+	// 直接連接的設備 (MAC 大小寫與格式不同) 與以管理地址對應的次要網路
+	stagebox := lldp.Neighbor{Interface: "eth1", SourceMAC: "00:1d:c1:00:00:01", ChassisID: "00-1D-C1-00-00-01", PortID: "1",
+		Capabilities: []string{"station"}, MED: &lldp.MED{Class: "endpoint-3"}}
+	amp := lldp.Neighbor{Interface: "eth1", SourceMAC: "00:1d:c1:00:00:99", ChassisID: "amp", PortID: "2", ManagementAddress: []string{"172.31.0.12"}}
+	phone := lldp.Neighbor{Interface: "eth1", SourceMAC: "00:04:f2:00:00:01", ChassisID: "00:04:f2:00:00:01", PortID: "1",
+		Capabilities: []string{"telephone"}}
+
+	domains := []domainDevices{{Domain: "Dante1", Devices: []dante.Device{
+		{Name: "Stage-Box-A", MacAddress: "00:1d:c1:00:00:01", IPAddress: "169.254.1.10"},
+		{Name: "Amp", MacAddress: "00:1d:c1:00:00:02", IPAddress: "169.254.1.12", SecondaryIP: "172.31.0.12"},
+	}}}
+	r := lldpReport("eth1", []lldp.Neighbor{sw, stagebox, amp, phone}, time.Minute, domains)
+	if r.Switch == nil || r.Switch.SystemName != "core-sw1" {
+		t.Errorf("switch = %+v", r.Switch)
+	}
+	if len(r.Devices) != 2 || r.Devices[0].Device != "Amp" || r.Devices[1].Device != "Stage-Box-A" || r.Devices[1].Domain != "Dante1" {
+		t.Errorf("devices = %+v", r.Devices)
+	}
+	if len(r.Neighbors) != 1 || r.Neighbors[0].ChassisID != phone.ChassisID || len(r.Notes) != 0 {
+		t.Errorf("neighbors = %+v, notes = %v", r.Neighbors, r.Notes)
+	}
+
+	// 兩台交換器: 網卡接在會轉送 LLDP 的非管理型交換器
+	sw2 := sw
+	sw2.ChassisID, sw2.SystemName = "00:1b:54:cc:dd:00", "edge-sw2"
+	r = lldpReport("eth1", []lldp.Neighbor{sw, sw2}, time.Minute, nil)
+	if len(r.Notes) != 1 || !strings.Contains(r.Notes[0], "2 switches heard") {
+		t.Errorf("notes = %v", r.Notes)
+	}
+
+	if r := lldpReport("eth1", nil, time.Minute, nil); len(r.Notes) != 1 || !strings.Contains(r.Notes[0], "no LLDP heard in 1m0s") {
+		t.Errorf("notes without neighbors = %v", r.Notes)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// API 負載卸除 (load shedding)
+//==============================================================================
+
+// SoC 的 CPU 被報表或大量查詢佔滿時，SDK 事件處理與路由操作也會跟著變慢，
+// 最壞的情況是音訊訂閱來不及恢復。LoadMonitor 定期取樣主機 CPU 使用率與
+// Go 排程延遲 (ticker 實際觸發與預期的差距)，超過門檻時低優先的 API
+// (報表、稽核匯出) 直接回傳 503 + Retry-After；路由、設備列表等操作不受影響。
+// 恢復門檻比觸發門檻低 10%，避免在臨界值附近反覆切換。
+
+// procStatPath CPU 統計 (測試時替換)
+var procStatPath = "/proc/stat"
+
+// loadRecoverRatio 低於門檻的這個比例才解除卸除
+const loadRecoverRatio = 0.9
+
+// loadSmoothing CPU 使用率的指數平滑係數 (單次尖峰不觸發)
+const loadSmoothing = 0.5
+
+// LoadShedPolicy 負載卸除的門檻
+type LoadShedPolicy struct {
+	CPU        float64       // CPU 使用率門檻 (0-1)，0 表示不檢查
+	Latency    time.Duration // 排程延遲門檻，0 表示不檢查
+	Interval   time.Duration // 取樣間隔
+	RetryAfter time.Duration // 503 回應的 Retry-After
+}
+
+// DefaultLoadShedPolicy 預設的負載卸除設定
+func DefaultLoadShedPolicy() LoadShedPolicy {
+	return LoadShedPolicy{
+		CPU:        0.9,
+		Latency:    250 * time.Millisecond,
+		Interval:   time.Second,
+		RetryAfter: 10 * time.Second,
+	}
+}
+
+// Validate 檢查設定是否合理
+func (p LoadShedPolicy) Validate() error {
+	if p.CPU < 0 || p.CPU > 1 {
+		return fmt.Errorf("the CPU shedding threshold must be between 0 and 1, got %v", p.CPU)
+	}
+	if p.Latency < 0 || p.RetryAfter < 0 {
+		return errors.New("load shedding durations must not be negative")
+	}
+	if p.Interval <= 0 {
+		return errors.New("the load sampling interval must be positive")
+	}
+	return nil
+}
+
+// Enabled 是否有任何門檻
+func (p LoadShedPolicy) Enabled() bool {
+	return p.CPU > 0 || p.Latency > 0
+}
+
+// LoadStatus 目前的負載
+type LoadStatus struct {
+	CPU        float64   `json:"cpu"`        // 平滑後的 CPU 使用率 (0-1)
+	LatencyMS  float64   `json:"latency_ms"` // 最近一次取樣的排程延遲
+	Overloaded bool      `json:"overloaded"`
+	Since      time.Time `json:"since,omitempty"` // 開始卸除的時間
+	Shed       int64     `json:"shed"`            // 累計拒絕的請求數
+}
+
+// cpuTimes /proc/stat 的 CPU 時間 (jiffies)
+type cpuTimes struct {
+	busy, total uint64
+}
+
+// LoadMonitor 取樣主機負載並決定是否卸除低優先的工作
+type LoadMonitor struct {
+	policy LoadShedPolicy
+	sample func() (cpuTimes, error) // 讀取 CPU 時間 (測試時替換)
+
+	mu     sync.Mutex
+	status LoadStatus
+	last   cpuTimes
+}
+
+// NewLoadMonitor 建立負載監控 (尚未開始取樣)
+func NewLoadMonitor(policy LoadShedPolicy) *LoadMonitor {
+	return &LoadMonitor{policy: policy, sample: readCPUTimes}
+}
+
+// Start 在背景定期取樣直到 ctx 結束
+// 無法讀取 /proc/stat 時只檢查排程延遲
+func (m *LoadMonitor) Start(ctx context.Context) {
+	if m.policy.CPU > 0 {
+		if times, err := m.sample(); err != nil {
+			logger.Warn("CPU usage unavailable, shedding on scheduling latency only", "err", err)
+			m.sample = nil
+		} else {
+			m.last = times
+		}
+	}
+
+	recovery.GoLoop(ctx, "loadshed", func() {
+		ticker := time.NewTicker(m.policy.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case tick := <-ticker.C:
+				// tick 是 ticker 送出的時間，收到得越晚表示 goroutine 越排不到 CPU
+				m.update(time.Now(), time.Since(tick))
+			}
+		}
+	})
+}
+
+// update 加入一次取樣並更新卸除狀態
+func (m *LoadMonitor) update(now time.Time, lag time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sample != nil && m.policy.CPU > 0 {
+		if times, err := m.sample(); err == nil {
+			if times.total > m.last.total {
+				usage := float64(times.busy-m.last.busy) / float64(times.total-m.last.total)
+				m.status.CPU = loadSmoothing*usage + (1-loadSmoothing)*m.status.CPU
+			}
+			m.last = times
+		}
+	}
+	m.status.LatencyMS = float64(lag) / float64(time.Millisecond)
+
+	cpuHigh := m.policy.CPU > 0 && m.status.CPU > m.policy.CPU
+	lagHigh := m.policy.Latency > 0 && lag > m.policy.Latency
+	if !m.status.Overloaded && (cpuHigh || lagHigh) {
+		m.status.Overloaded = true
+		m.status.Since = now
+		logger.Warn("Host overloaded, shedding low-priority API requests",
+			"cpu", math.Round(m.status.CPU*100), "latency", lag.Round(time.Millisecond))
+		return
+	}
+
+	cpuOK := m.policy.CPU == 0 || m.status.CPU < m.policy.CPU*loadRecoverRatio
+	lagOK := m.policy.Latency == 0 || float64(lag) < float64(m.policy.Latency)*loadRecoverRatio
+	if m.status.Overloaded && cpuOK && lagOK {
+		logger.Info("Host load recovered, serving all API requests",
+			"shed", m.status.Shed, "duration", now.Sub(m.status.Since).Round(time.Second))
+		m.status.Overloaded = false
+		m.status.Since = time.Time{}
+	}
+}
+
+// Status 目前的負載
+func (m *LoadMonitor) Status() LoadStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// admit 是否接受低優先的請求 (拒絕時計數)
+func (m *LoadMonitor) admit() bool {
+	if m == nil {
+		return true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.status.Overloaded {
+		return true
+	}
+	m.status.Shed++
+	return false
+}
+
+// readCPUTimes 讀取 /proc/stat 的總 CPU 時間 (busy 不含 idle 與 iowait)
+func readCPUTimes() (cpuTimes, error) {
+	file, err := os.Open(procStatPath)
+	if err != nil {
+		return cpuTimes{}, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return cpuTimes{}, fmt.Errorf("%s is empty", procStatPath)
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return cpuTimes{}, fmt.Errorf("unexpected %s format", procStatPath)
+	}
+
+	var times cpuTimes
+	for i, field := range fields[1:] {
+		// guest 與 guest_nice 已包含在 user 與 nice 中
+		if i >= 8 {
+			break
+		}
+		v, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return cpuTimes{}, fmt.Errorf("unexpected %s value %q", procStatPath, field)
+		}
+		times.total += v
+		if i != 3 && i != 4 { // idle、iowait
+			times.busy += v
+		}
+	}
+	return times, nil
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// lowPriority 主機過載時以 503 拒絕的路由 (報表、匯出等可以稍後重試的工作)
+func (s *APIServer) lowPriority(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.load.admit() {
+			retry := max(s.load.policy.RetryAfter, time.Second)
+			w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())))
+			writeError(w, http.StatusServiceUnavailable, errors.New("host overloaded, try again later"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *APIServer) handleLoad(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.load.Status())
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadCPUTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stat")
+	stat := "cpu  100 20 30 800 50 0 0 0 7 0\ncpu0 50 10 15 400 25 0 0 0 0 0\n"
+	if err := os.WriteFile(path, []byte(stat), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := procStatPath
+	procStatPath = path
+	t.Cleanup(func() { procStatPath = saved })
+
+	times, err := readCPUTimes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if times.busy != 150 || times.total != 1000 {
+		t.Fatalf("got %+v, want busy 150 of 1000", times)
+	}
+}
+
+func TestLoadMonitorShedsLowPriorityRequests(t *testing.T) {
+	policy := DefaultLoadShedPolicy()
+	m := NewLoadMonitor(policy)
+	var times cpuTimes
+	m.sample = func() (cpuTimes, error) { return times, nil }
+	// 每次取樣經過 100 jiffies，busy 為 usage%
+	step := func(usage uint64, lag time.Duration) {
+		times.busy += usage
+		times.total += 100
+		m.update(time.Now(), lag)
+	}
+
+	api := NewAPIServer(APIConfig{Load: m})
+	api.handle("GET /report", api.lowPriority(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server := httptest.NewServer(api.mux)
+	defer server.Close()
+	get := func() *http.Response {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/report")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	step(50, 0)
+	step(95, 0) // 單次尖峰被平滑
+	if m.Status().Overloaded {
+		t.Fatalf("overloaded after a single spike: %+v", m.Status())
+	}
+	step(100, 0)
+	step(100, 0)
+	step(100, 0)
+	if !m.Status().Overloaded {
+		t.Fatalf("not overloaded at sustained full CPU: %+v", m.Status())
+	}
+	if resp := get(); resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "10" {
+		t.Fatalf("status %d, Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	var status LoadStatus
+	getJSON(t, server.URL+"/api/load", &status)
+	if !status.Overloaded || status.Shed != 1 {
+		t.Fatalf("load status = %+v", status)
+	}
+
+	// 恢復門檻比觸發門檻低
+	step(85, 0)
+	step(85, 0)
+	if !m.Status().Overloaded {
+		t.Fatalf("recovered just below the threshold: %+v", m.Status())
+	}
+	step(20, 0)
+	step(20, 0)
+	if m.Status().Overloaded {
+		t.Fatalf("still overloaded at low CPU: %+v", m.Status())
+	}
+	if resp := get(); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status %d after recovery", resp.StatusCode)
+	}
+
+	// 排程延遲也會觸發
+	step(10, time.Second)
+	if !m.Status().Overloaded {
+		t.Fatalf("not overloaded with %v scheduling latency", time.Second)
+	}
+}
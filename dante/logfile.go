@@ -0,0 +1,219 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// 日誌檔案輸出與輪替
+//==============================================================================
+
+// 嵌入式設備上 journald 空間有限，日誌寫入檔案並依大小/時間輪替，
+// 舊檔以 <file>.20060102-150405[.gz] 保存，超過數量或天數即刪除。
+
+// backupTimeFormat 輪替檔案的時間戳記格式
+const backupTimeFormat = "20060102-150405"
+
+// RotateOptions 輪替設定
+type RotateOptions struct {
+	MaxSize     int64         // 單檔大小上限 (bytes)，0 表示不限
+	RotateEvery time.Duration // 定時輪替間隔，0 表示停用
+	MaxBackups  int           // 保留的舊檔數量，0 表示不限
+	MaxAge      time.Duration // 舊檔保留時間，0 表示不限
+	Compress    bool          // 以 gzip 壓縮舊檔
+}
+
+// RotatingFile 可輪替的日誌檔
+type RotatingFile struct {
+	mu     sync.Mutex
+	path   string
+	opts   RotateOptions
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenRotatingFile 開啟 (或延續) 日誌檔
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log dir: %v", err)
+	}
+
+	rf := &RotatingFile{path: path, opts: opts}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %v", err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	rf.opened = time.Now()
+	return nil
+}
+
+// Write 寫入日誌，必要時先輪替
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.shouldRotate(int64(len(p))) {
+		if err := rf.rotate(); err != nil {
+			// 輪替失敗時繼續寫入原檔，避免遺失日誌
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) shouldRotate(incoming int64) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if rf.opts.MaxSize > 0 && rf.size+incoming > rf.opts.MaxSize {
+		return true
+	}
+	return rf.opts.RotateEvery > 0 && time.Since(rf.opened) >= rf.opts.RotateEvery
+}
+
+// Rotate 立即輪替 (例如收到 SIGHUP)
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.rotate()
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+
+	backup := rf.backupName(time.Now())
+	if err := os.Rename(rf.path, backup); err != nil {
+		// 重新開啟原檔，讓後續寫入不中斷
+		rf.open()
+		return err
+	}
+
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	// 壓縮與清理不阻塞日誌寫入
+	go func() {
+		if rf.opts.Compress {
+			if err := compressFile(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "log compression failed: %v\n", err)
+			}
+		}
+		rf.prune()
+	}()
+	return nil
+}
+
+// backupName 舊檔名稱，同一秒內多次輪替時往後順延，避免覆寫前一個舊檔
+func (rf *RotatingFile) backupName(t time.Time) string {
+	for {
+		name := rf.path + "." + t.Format(backupTimeFormat)
+		_, errPlain := os.Lstat(name)
+		_, errGz := os.Lstat(name + ".gz")
+		if os.IsNotExist(errPlain) && os.IsNotExist(errGz) {
+			return name
+		}
+		t = t.Add(time.Second)
+	}
+}
+
+// prune 依數量與時間刪除舊檔
+func (rf *RotatingFile) prune() {
+	matches, err := filepath.Glob(rf.path + ".*")
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		path string
+		time time.Time
+	}
+	var backups []backup
+	prefix := filepath.Base(rf.path) + "."
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), prefix), ".gz")
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{m, t})
+	}
+
+	// 新的在前
+	sort.Slice(backups, func(i, j int) bool { return backups[i].time.After(backups[j].time) })
+
+	for i, b := range backups {
+		tooMany := rf.opts.MaxBackups > 0 && i >= rf.opts.MaxBackups
+		tooOld := rf.opts.MaxAge > 0 && time.Since(b.time) > rf.opts.MaxAge
+		if tooMany || tooOld {
+			os.Remove(b.path)
+		}
+	}
+}
+
+// Close 關閉日誌檔
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.file.Close()
+}
+
+// compressFile 以 gzip 壓縮並刪除原檔
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		gz.Close()
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logBackups 列出輪替產生的舊檔
+func logBackups(t *testing.T, path string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotatingFileMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dante.log")
+	rf, err := OpenRotatingFile(path, RotateOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	// 空檔即使單筆超過上限也不輪替
+	rf.Write([]byte("first line\n"))
+	if backups := logBackups(t, path); len(backups) != 0 {
+		t.Fatalf("rotated an empty file: %v", backups)
+	}
+	// 同一秒內連續輪替不可覆寫前一個舊檔
+	rf.Write([]byte("second\n"))
+	rf.Write([]byte("third\n"))
+
+	backups := logBackups(t, path)
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v", backups)
+	}
+	if got := readLog(t, backups[0]) + readLog(t, backups[1]); got != "first line\nsecond\n" {
+		t.Errorf("backup contents = %q", got)
+	}
+	if got := readLog(t, path); got != "third\n" {
+		t.Errorf("current file = %q", got)
+	}
+}
+
+func TestRotatingFileRotateEvery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dante.log")
+	rf, err := OpenRotatingFile(path, RotateOptions{RotateEvery: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	rf.Write([]byte("old\n"))
+	rf.Write([]byte("still current\n"))
+	if backups := logBackups(t, path); len(backups) != 0 {
+		t.Fatalf("rotated before the interval: %v", backups)
+	}
+
+	rf.mu.Lock()
+	rf.opened = time.Now().Add(-2 * time.Hour)
+	rf.mu.Unlock()
+	rf.Write([]byte("new\n"))
+	if backups := logBackups(t, path); len(backups) != 1 || readLog(t, backups[0]) != "old\nstill current\n" {
+		t.Fatalf("backups = %v", backups)
+	}
+	if got := readLog(t, path); got != "new\n" {
+		t.Errorf("current file = %q", got)
+	}
+}
+
+func TestRotatingFilePrune(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RotateOptions
+		keep []time.Duration // 保留的既有舊檔 (距今時間)
+	}{
+		{"max age", RotateOptions{MaxAge: 24 * time.Hour}, []time.Duration{time.Hour, 20 * time.Hour}},
+		{"max backups", RotateOptions{MaxBackups: 2}, []time.Duration{time.Hour}},
+		{"both", RotateOptions{MaxAge: 24 * time.Hour, MaxBackups: 3}, []time.Duration{time.Hour, 20 * time.Hour}},
+		{"unlimited", RotateOptions{}, []time.Duration{time.Hour, 20 * time.Hour, 48 * time.Hour, 72 * time.Hour}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "dante.log")
+			now := time.Now()
+			existing := map[time.Duration]string{}
+			for _, age := range []time.Duration{time.Hour, 20 * time.Hour, 48 * time.Hour, 72 * time.Hour} {
+				name := path + "." + now.Add(-age).Format(backupTimeFormat)
+				if age == 72*time.Hour {
+					name += ".gz"
+				}
+				os.WriteFile(name, []byte("x"), 0644)
+				existing[age] = name
+			}
+			// 不符合命名格式的檔案不處理
+			unrelated := path + ".bak"
+			os.WriteFile(unrelated, []byte("x"), 0644)
+
+			rf, err := OpenRotatingFile(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rf.Close()
+			rf.Write([]byte("line\n"))
+			if err := rf.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]bool{unrelated: true}
+			for _, age := range tt.keep {
+				want[existing[age]] = true
+			}
+			waitFor(t, func() bool {
+				backups := logBackups(t, path)
+				kept := 0
+				for _, b := range backups {
+					if want[b] {
+						kept++
+					}
+				}
+				// 舊檔 + 剛輪替的檔案 + 無關檔案
+				return kept == len(want) && len(backups) == len(want)+1
+			})
+		})
+	}
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dante.log")
+	rf, err := OpenRotatingFile(path, RotateOptions{Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	rf.Write([]byte("compressed\n"))
+	if err := rf.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		backups := logBackups(t, path)
+		return len(backups) == 1 && strings.HasSuffix(backups[0], ".gz")
+	})
+
+	f, err := os.Open(logBackups(t, path)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil || string(data) != "compressed\n" {
+		t.Errorf("decompressed = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// 結構化日誌
+//==============================================================================
+
+// logger 全域結構化日誌，網域相關訊息以 logger.With("domain", name) 附加欄位
+var logger = slog.New(newPrettyHandler(os.Stderr, slog.LevelInfo))
+
+// ParseLogLevel 解析 debug/info/warn/error
+func ParseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q (debug, info, warn, error)", level)
+}
+
+// LogOptions 日誌設定
+type LogOptions struct {
+	Level  string        // debug, info, warn, error
+	Format string        // pretty (互動用)、text (logfmt)、json、auto
+	File   string        // 日誌檔路徑，空白表示輸出到 stderr
+	Rotate RotateOptions // 日誌檔輪替設定
+	Sink   string        // 額外轉送目的地: journald、syslog、syslog://host:port
+	Lang   string        // 輸出語言 en、zh-TW，空白時由環境變數決定
+}
+
+// logFile 目前使用中的日誌檔 (未使用檔案時為 nil)
+var logFile *RotatingFile
+
+// logSink 目前使用中的轉送目的地 (未使用時為 nil)
+var logSink entryWriter
+
+// logLevel 目前的日誌等級
+var logLevel = slog.LevelInfo
+
+// SetupLogging 設定日誌等級、格式與輸出位置
+// format auto: 輸出到終端機時用 pretty，否則用 text
+func SetupLogging(opts LogOptions) error {
+	lvl, err := ParseLogLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	if err := setLanguage(opts.Lang); err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stderr
+	interactive := isTerminal(os.Stderr)
+	if opts.File != "" {
+		rf, err := OpenRotatingFile(opts.File, opts.Rotate)
+		if err != nil {
+			return err
+		}
+		logFile = rf
+		out = rf
+		interactive = false
+	}
+
+	format := opts.Format
+	if format == "" || format == "auto" {
+		format = "text"
+		if interactive {
+			format = "pretty"
+		}
+	}
+
+	var handler slog.Handler
+	switch format {
+	case "pretty":
+		handler = newPrettyHandler(out, lvl)
+	case "text":
+		handler = slog.NewTextHandler(out, &slog.HandlerOptions{Level: lvl})
+	case "json":
+		handler = slog.NewJSONHandler(out, &slog.HandlerOptions{Level: lvl})
+	default:
+		return fmt.Errorf("unknown log format %q (pretty, text, json, auto)", format)
+	}
+	if format != "json" {
+		handler = translateHandler{handler}
+	}
+
+	if opts.Sink != "" {
+		sink, err := OpenLogSink(opts.Sink)
+		if err != nil {
+			return err
+		}
+		logSink = sink
+		handler = fanoutHandler{handler, newSinkHandler(sink, lvl)}
+	}
+
+	logLevel = lvl
+	logger = slog.New(handler)
+	// 讓標準 log 套件的輸出也走同一個 handler
+	slog.SetDefault(logger)
+	return nil
+}
+
+// CaptureLogs 把互動輸出改寫到 w (TUI 模式)
+// 日誌檔與轉送目的地照常寫入，只有原本輸出到 stderr 的部分被取代
+func CaptureLogs(w io.Writer) {
+	var handler slog.Handler = translateHandler{newPrettyHandler(w, logLevel)}
+	switch {
+	case logFile != nil:
+		handler = fanoutHandler{logger.Handler(), handler}
+	case logSink != nil:
+		handler = fanoutHandler{handler, newSinkHandler(logSink, logLevel)}
+	}
+	logger = slog.New(handler)
+	slog.SetDefault(logger)
+}
+
+// CloseLogging 關閉日誌檔與轉送目的地
+func CloseLogging() {
+	if logFile != nil {
+		logFile.Close()
+	}
+	if logSink != nil {
+		logSink.Close()
+	}
+}
+
+// fatal 記錄錯誤並結束程式
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// isTerminal 判斷輸出是否為終端機
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+//==============================================================================
+// Pretty handler (互動模式)
+//==============================================================================
+
+// prettyHandler 以人類易讀的單行格式輸出：
+// 15:04:05 ⚠️  [Dante1] Device scan failed err=...
+type prettyHandler struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  slog.Leveler
+	prefix string      // 來自 domain/instance 欄位
+	attrs  []slog.Attr // 透過 With 附加的欄位
+	group  string
+}
+
+func newPrettyHandler(out io.Writer, level slog.Leveler) *prettyHandler {
+	return &prettyHandler{mu: &sync.Mutex{}, out: out, level: level}
+}
+
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+
+	b.WriteString(r.Time.Format(time.TimeOnly))
+	b.WriteByte(' ')
+	b.WriteString(levelIcon(r.Level))
+	b.WriteByte(' ')
+
+	prefix := h.prefix
+	var fields []string
+	for _, a := range h.attrs {
+		fields = append(fields, formatAttr(h.group, a))
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if isPrefixAttr(a) && prefix == "" {
+			prefix = a.Value.String()
+			return true
+		}
+		fields = append(fields, formatAttr(h.group, a))
+		return true
+	})
+
+	if prefix != "" {
+		b.WriteString("[" + prefix + "] ")
+	}
+	b.WriteString(r.Message)
+	for _, f := range fields {
+		b.WriteString("  ")
+		b.WriteString(f)
+	}
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, b.String())
+	return err
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		if isPrefixAttr(a) && clone.prefix == "" {
+			clone.prefix = a.Value.String()
+			continue
+		}
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	if clone.group != "" {
+		name = clone.group + "." + name
+	}
+	clone.group = name
+	return &clone
+}
+
+// isPrefixAttr domain/instance 欄位以 [name] 前綴顯示
+func isPrefixAttr(a slog.Attr) bool {
+	return a.Key == "domain" || a.Key == "instance"
+}
+
+func formatAttr(group string, a slog.Attr) string {
+	key := a.Key
+	if group != "" {
+		key = group + "." + key
+	}
+	value := a.Value.Resolve().String()
+	if strings.ContainsAny(value, " \t") {
+		value = fmt.Sprintf("%q", value)
+	}
+	return key + "=" + value
+}
+
+func levelIcon(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "❌"
+	case level >= slog.LevelWarn:
+		return "⚠️ "
+	case level >= slog.LevelInfo:
+		return "✅"
+	default:
+		return "🔍"
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"log/syslog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//==============================================================================
+// Syslog / journald 日誌轉送
+//==============================================================================
+
+// 讓全站的日誌集中系統收到 Dante 發現失敗等事件，
+// 欄位保留結構 (journald 為獨立欄位，syslog 為 key=value)。
+
+// journaldSocket systemd-journald 原生協定 socket
+const journaldSocket = "/run/systemd/journal/socket"
+
+// syslogTag syslog/journald 的識別名稱
+var syslogTag = filepath.Base(os.Args[0])
+
+// entryWriter 日誌轉送目的地
+type entryWriter interface {
+	WriteEntry(level slog.Level, msg string, attrs []slog.Attr) error
+	Close() error
+}
+
+// OpenLogSink 依規格開啟轉送目的地
+//
+//	journald              本機 journald
+//	syslog                本機 syslog
+//	syslog://host:514     遠端 syslog (UDP)
+//	syslog+tcp://host:514 遠端 syslog (TCP)
+func OpenLogSink(spec string) (entryWriter, error) {
+	switch {
+	case spec == "journald":
+		return newJournaldWriter()
+	case spec == "syslog":
+		return newSyslogWriter("", "")
+	case strings.HasPrefix(spec, "syslog://"), strings.HasPrefix(spec, "syslog+tcp://"):
+		u, err := url.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog address %q: %v", spec, err)
+		}
+		network := "udp"
+		if u.Scheme == "syslog+tcp" {
+			network = "tcp"
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "514")
+		}
+		return newSyslogWriter(network, host)
+	}
+	return nil, fmt.Errorf("unknown log sink %q (journald, syslog, syslog://host:port)", spec)
+}
+
+//------------------------------------------------------------------------------
+// syslog
+//------------------------------------------------------------------------------
+
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+func newSyslogWriter(network, addr string) (*syslogWriter, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, syslogTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %v", err)
+	}
+	return &syslogWriter{w: w}, nil
+}
+
+func (s *syslogWriter) WriteEntry(level slog.Level, msg string, attrs []slog.Attr) error {
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, a := range attrs {
+		b.WriteByte(' ')
+		b.WriteString(formatAttr("", a))
+	}
+	line := b.String()
+
+	switch {
+	case level >= slog.LevelError:
+		return s.w.Err(line)
+	case level >= slog.LevelWarn:
+		return s.w.Warning(line)
+	case level >= slog.LevelInfo:
+		return s.w.Info(line)
+	default:
+		return s.w.Debug(line)
+	}
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
+
+//------------------------------------------------------------------------------
+// journald (原生協定)
+//------------------------------------------------------------------------------
+
+type journaldWriter struct {
+	conn *net.UnixConn
+}
+
+func newJournaldWriter() (*journaldWriter, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %v", err)
+	}
+	return &journaldWriter{conn: conn}, nil
+}
+
+// journalPriority slog 等級對應 syslog priority
+func journalPriority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3 // err
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// journalFieldName journald 欄位名稱只允許大寫英數與底線
+func journalFieldName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := strings.TrimLeft(b.String(), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "F_" + name
+	}
+	return name
+}
+
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name + "=" + value + "\n")
+		return
+	}
+	// 多行內容使用長度前綴格式
+	buf.WriteString(name + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
+
+func (j *journaldWriter) WriteEntry(level slog.Level, msg string, attrs []slog.Attr) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", msg)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(journalPriority(level)))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", syslogTag)
+	for _, a := range attrs {
+		writeJournalField(&buf, journalFieldName(a.Key), a.Value.Resolve().String())
+	}
+	_, err := j.conn.Write(buf.Bytes())
+	return err
+}
+
+func (j *journaldWriter) Close() error {
+	return j.conn.Close()
+}
+
+//------------------------------------------------------------------------------
+// slog handler
+//------------------------------------------------------------------------------
+
+// sinkHandler 把 slog record 轉送到 entryWriter
+type sinkHandler struct {
+	mu     *sync.Mutex
+	writer entryWriter
+	level  slog.Leveler
+	attrs  []slog.Attr
+	group  string
+}
+
+func newSinkHandler(writer entryWriter, level slog.Leveler) *sinkHandler {
+	return &sinkHandler{mu: &sync.Mutex{}, writer: writer, level: level}
+}
+
+func (h *sinkHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *sinkHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]slog.Attr{}, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		if h.group != "" {
+			a.Key = h.group + "." + a.Key
+		}
+		attrs = append(attrs, a)
+		return true
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.writer.WriteEntry(r.Level, r.Message, attrs)
+}
+
+func (h *sinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		if h.group != "" {
+			a.Key = h.group + "." + a.Key
+		}
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+func (h *sinkHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	if clone.group != "" {
+		name = clone.group + "." + name
+	}
+	clone.group = name
+	return &clone
+}
+
+// fanoutHandler 同時輸出到多個 handler
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range f {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := make(fanoutHandler, len(f))
+	for i, h := range f {
+		result[i] = h.WithAttrs(attrs)
+	}
+	return result
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	result := make(fanoutHandler, len(f))
+	for i, h := range f {
+		result[i] = h.WithGroup(name)
+	}
+	return result
+}
@@ -1,59 +1,27 @@
 ﻿package main
 
-/*
-#cgo CFLAGS: -I./include/audinate -I./include
-#cgo LDFLAGS: -L./lib -ldapi -L./redist -ldns_sd -lcurl -ljansson -lssl -lcrypto -lz -ldl -lpthread -lstdc++ -lm
-
-#include <stdlib.h>
-
-// Dante API 基礎函數聲明
-int dante_init(void);
-int dante_init_with_interface(const char* interface_name);
-void dante_cleanup(void);
-const char* dante_get_last_error(void);
-int dante_connect_local_device(void);
-int dante_is_device_connected(void);
-int dante_get_device_name(char* buffer, int buffer_size);
-int dante_get_tx_channel_count(void);
-int dante_get_rx_channel_count(void);
-
-// 設備掃描函數
-int dante_start_device_scan(void);
-int dante_stop_device_scan(void);
-int dante_get_discovered_device_count(void);
-int dante_refresh_device_scan(void);
-int dante_process_events_briefly(void);
-int dante_get_current_device_list(void);
-
-// 設備資訊結構
-struct dante_device_info_t {
-    int id;
-    char name[64];
-    char model[64]; 
-    char product_version[32];
-    char dante_version[32];
-    char ip_address[16];
-    int link_speed;
-    char secondary_ip[16];
-    int secondary_speed;
-    char mac_address[18];
-    int is_valid;
-};
-
-int dante_get_device_info(int index, struct dante_device_info_t* info);
-*/
-import "C"
-
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
-	"unsafe"
+
+	"danteCS/golane"
+	"danteCS/internal/aes67"
+	"danteCS/internal/backoff"
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/recovery"
+	"danteCS/internal/supervisor"
+	"danteCS/internal/trace"
 )
 
 //==============================================================================
@@ -62,19 +30,85 @@ import (
 
 // NetworkInterfaceInfo 網路介面資訊
 type NetworkInterfaceInfo struct {
-	Name       string   // 介面名稱 (eth0, eth1, eth2)
-	MacAddress string   // MAC 地址
-	IPAddress  string   // IP 地址
-	NetMask    string   // 子網路遮罩
-	IsUp       bool     // 是否啟用
-	HasIP      bool     // 是否有 IP
+	Name       string   `json:"name"`               // 介面名稱 (eth0, eth1, eth2)
+	MacAddress string   `json:"mac_address"`        // MAC 地址
+	IPAddress  string   `json:"ip_address"`         // IP 地址
+	NetMask    string   `json:"netmask"`            // 子網路遮罩
+	IsUp       bool     `json:"up"`                 // 是否啟用
+	HasIP      bool     `json:"has_ip"`             // 是否有 IP
+	VLANID     int      `json:"vlan_id,omitempty"`  // 802.1Q VLAN ID (0 表示非 VLAN 介面)
+	Parent     string   `json:"parent,omitempty"`   // VLAN 子介面的實體介面 (eth1)
+	Addresses  []InterfaceAddress `json:"addresses"` // 所有地址 (IPv4 與 IPv6)
+}
+
+// InterfaceAddress 介面上的單一地址
+type InterfaceAddress struct {
+	IP        string `json:"ip"`         // 地址
+	PrefixLen int    `json:"prefix_len"` // 前綴長度
+	IsIPv6    bool   `json:"ipv6"`       // 是否為 IPv6
+}
+
+// Prefix 取得地址所屬的網段
+func (a InterfaceAddress) Prefix() *net.IPNet {
+	ip := net.ParseIP(a.IP)
+	if ip == nil {
+		return nil
+	}
+	bits := 32
+	if a.IsIPv6 {
+		bits = 128
+	} else {
+		ip = ip.To4()
+	}
+	mask := net.CIDRMask(a.PrefixLen, bits)
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// IsLinkLocal 是否為 link-local 地址 (169.254/16 或 fe80::/10)
+func (a InterfaceAddress) IsLinkLocal() bool {
+	ip := net.ParseIP(a.IP)
+	return ip != nil && ip.IsLinkLocalUnicast()
+}
+
+// ignoredForOverlap 網段重疊檢查忽略的地址：IPv6 fe80::/64 與 -linklocal-alias 自己加上的
+// 169.254 別名。主要地址是 Auto-IP 的兩張網卡確實在同一個網段，仍要回報
+func ignoredForOverlap(info NetworkInterfaceInfo, addr InterfaceAddress) bool {
+	if !addr.IsLinkLocal() {
+		return false
+	}
+	if addr.IsIPv6 {
+		return true
+	}
+	alias, err := LinkLocalAliasAddress(info.MacAddress)
+	return err == nil && addr.IP == alias && addr.IP != info.IPAddress
+}
+
+// String 以 CIDR 格式顯示
+func (a InterfaceAddress) String() string {
+	return fmt.Sprintf("%s/%d", a.IP, a.PrefixLen)
+}
+
+// IPv6Addresses 取得介面的 IPv6 地址
+func (info NetworkInterfaceInfo) IPv6Addresses() []InterfaceAddress {
+	var result []InterfaceAddress
+	for _, addr := range info.Addresses {
+		if addr.IsIPv6 {
+			result = append(result, addr)
+		}
+	}
+	return result
 }
 
 // NetworkDetector 網路檢測器
 type NetworkDetector struct {
-	AllInterfaces      []NetworkInterfaceInfo
-	DanteInterfaces    []NetworkInterfaceInfo
-	ManagementInterface *NetworkInterfaceInfo
+	AllInterfaces      []NetworkInterfaceInfo `json:"all_interfaces"`
+	DanteInterfaces    []NetworkInterfaceInfo `json:"dante_interfaces"`
+	ManagementInterface *NetworkInterfaceInfo `json:"management_interface"`
+	DanteInterfaceNames []string `json:"dante_interface_names"` // 指定的 Dante 介面名稱 (空白時使用預設清單)
+	Roles InterfaceRoles `json:"roles,omitempty"` // 設定檔指定的介面角色 (優先於介面名稱)
+	DanteFallback DanteFallback `json:"-"` // 指定的 Dante 介面都不存在時的備用規則
+	Fallback *FallbackChoice `json:"fallback,omitempty"` // 選用的備用介面 (nil 表示使用指定的介面)
+	NICNames NICNames `json:"-"` // udev 規則的穩定名稱 → MAC (規則尚未生效時以 MAC 尋找網卡)
 }
 
 // NewNetworkDetector 創建網路檢測器
@@ -92,7 +126,9 @@ func (nd *NetworkDetector) DetectAllInterfaces() error {
 		return fmt.Errorf("failed to get network interfaces: %v", err)
 	}
 
-	log.Println("🔍 Detecting network interfaces...")
+	logger.Info("Detecting network interfaces")
+	
+	vlans := readVLANConfig()
 	
 	for _, iface := range interfaces {
 		// 跳過 loopback
@@ -111,43 +147,76 @@ func (nd *NetworkDetector) DetectAllInterfaces() error {
 		addrs, err := iface.Addrs()
 		if err == nil && len(addrs) > 0 {
 			for _, addr := range addrs {
-				// 只取 IPv4 地址
-				if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+				ipnet, ok := addr.(*net.IPNet)
+				if !ok {
+					continue
+				}
+				
+				ones, _ := ipnet.Mask.Size()
+				info.Addresses = append(info.Addresses, InterfaceAddress{
+					IP:        ipnet.IP.String(),
+					PrefixLen: ones,
+					IsIPv6:    ipnet.IP.To4() == nil,
+				})
+				
+				// 主要地址取第一個 IPv4
+				if ipnet.IP.To4() != nil && !info.HasIP {
 					info.IPAddress = ipnet.IP.String()
 					info.NetMask = net.IP(ipnet.Mask).String()
 					info.HasIP = true
-					break
 				}
 			}
 		}
 
+		// VLAN 子介面
+		if vlan, ok := vlans[iface.Name]; ok {
+			info.VLANID = vlan.ID
+			info.Parent = vlan.Parent
+		}
+
 		nd.AllInterfaces = append(nd.AllInterfaces, info)
 		
-		log.Printf("  ✓ Found: %s (MAC: %s, IP: %s, Up: %v)", 
-			info.Name, info.MacAddress, info.IPAddress, info.IsUp)
+		logger.Info("Found interface",
+			"iface", info.Name, "mac", info.MacAddress, "ip", info.IPAddress, "up", info.IsUp)
 	}
 
 	return nil
 }
 
-// IdentifyDanteInterfaces 識別 Dante 網路介面
+// IdentifyDanteInterfaces 識別 Dante 網路介面 (依名稱清單的順序，第一個為 primary)
 func (nd *NetworkDetector) IdentifyDanteInterfaces(danteInterfaceNames []string) {
-	log.Println("🔍 Identifying Dante interfaces...")
+	logger.Info("Identifying Dante interfaces")
 	
-	for _, info := range nd.AllInterfaces {
-		for _, danteName := range danteInterfaceNames {
+	// 穩定名稱尚未生效時，dante1 與網卡目前的核心名稱會指向同一張網卡，只加入一次
+	seen := make(map[string]bool, len(danteInterfaceNames))
+	for _, danteName := range danteInterfaceNames {
+		danteName = nd.currentName(danteName)
+		if seen[danteName] {
+			continue
+		}
+		seen[danteName] = true
+		for _, info := range nd.AllInterfaces {
 			if info.Name == danteName {
 				nd.DanteInterfaces = append(nd.DanteInterfaces, info)
-				log.Printf("  ✓ Dante interface found: %s (%s)", info.Name, info.IPAddress)
+				logger.Info("Dante interface found", "iface", info.Name, "ip", info.IPAddress)
 			}
 		}
 	}
 	
 	if len(nd.DanteInterfaces) == 0 {
-		log.Println("  ⚠️  No Dante interfaces found!")
+		logger.Warn("No Dante interfaces found")
 	}
 }
 
+// defaultDanteInterfaceNames 預設 Dante 介面名稱
+var defaultDanteInterfaceNames = []string{
+	"dante1", // golane udev 產生的穩定名稱
+	"dante2",
+	"enxf8e43bd6309e",  // Dante1 網卡
+	"enxf8e43bd55df6",  // JC add Dante 網卡
+	// 未來 Dante2 網卡可以在這裡添加
+}
+
 // AutoConfigureFromSystem 自動從系統配置網路
 func (nd *NetworkDetector) AutoConfigureFromSystem() error {
 	// 1. 檢測所有網路介面
@@ -156,19 +225,73 @@ func (nd *NetworkDetector) AutoConfigureFromSystem() error {
 	}
 	
 	// 2. 指定 Dante 介面名稱
-	danteInterfaceNames := []string{
-		"enxf8e43bd6309e",  // Dante1 網卡
-		"enxf8e43bd55df6",  // JC add Dante 網卡
-		// 未來 Dante2 網卡可以在這裡添加
+	nd.IdentifyDanteInterfaces(nd.danteCandidates())
+	
+	// 3. 管理網路: 設定檔的角色，或預設路由所在的非 Dante 介面
+	management := nd.Roles[InterfaceRoleManagement]
+	if management == "" {
+		management = defaultRouteInterface()
 	}
+	nd.identifyManagementInterface(management)
 	
-	nd.IdentifyDanteInterfaces(danteInterfaceNames)
+	// 4. 指定的 Dante 介面都不存在時選用備用介面
+	nd.applyFallback(nd.danteCandidates())
 	
 	return nil
 }
 
+// danteCandidates Dante 介面的候選名稱 (設定檔的角色、-dante-ifaces 或內建清單)
+func (nd *NetworkDetector) danteCandidates() []string {
+	if names := nd.Roles.danteNames(); len(names) > 0 {
+		return names
+	}
+	if len(nd.DanteInterfaceNames) > 0 {
+		return nd.DanteInterfaceNames
+	}
+	return defaultDanteInterfaceNames
+}
+
+// identifyManagementInterface 以 name 作為管理介面 (Dante 介面或不存在時不設定)
+func (nd *NetworkDetector) identifyManagementInterface(name string) {
+	if name == "" {
+		return
+	}
+	name = nd.currentName(name)
+	for _, info := range nd.DanteInterfaces {
+		if info.Name == name {
+			return
+		}
+	}
+	for i, info := range nd.AllInterfaces {
+		if info.Name == name {
+			nd.ManagementInterface = &nd.AllInterfaces[i]
+			logger.Info("Management interface found", "iface", info.Name, "ip", info.IPAddress)
+			return
+		}
+	}
+}
+
+// routeProcPath IPv4 路由表 (測試時替換)
+var routeProcPath = "/proc/net/route"
+
+// defaultRouteInterface IPv4 預設路由所在的介面 (沒有預設路由時為空白)
+func defaultRouteInterface() string {
+	data, err := os.ReadFile(routeProcPath)
+	if err != nil {
+		return ""
+	}
+	// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+	for _, line := range strings.Split(string(data), "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) >= 8 && fields[1] == "00000000" && fields[7] == "00000000" {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
 // GetDanteConfig 根據檢測結果生成 Dante 配置
-func (nd *NetworkDetector) GetDanteConfig(index int) (*NetworkConfig, error) {
+func (nd *NetworkDetector) GetDanteConfig(index int) (*dante.NetworkConfig, error) {
 	if index >= len(nd.DanteInterfaces) {
 		return nil, fmt.Errorf("Dante interface index %d out of range", index)
 	}
@@ -179,7 +302,7 @@ func (nd *NetworkDetector) GetDanteConfig(index int) (*NetworkConfig, error) {
 		return nil, fmt.Errorf("interface %s has no IP address", info.Name)
 	}
 	
-	config := &NetworkConfig{
+	config := &dante.NetworkConfig{
 		InterfaceName: info.Name,
 		MacAddress:    info.MacAddress,
 		IPAddress:     info.IPAddress,
@@ -190,6 +313,26 @@ func (nd *NetworkDetector) GetDanteConfig(index int) (*NetworkConfig, error) {
 	return config, nil
 }
 
+// selectDanteConfig 選擇第一個 Dante 介面
+// 介面不存在或尚未取得 IP 時回傳錯誤，同時回傳以候選介面名稱建立的配置，
+// 讓網域初始化可以等待介面就緒
+func selectDanteConfig(nd *NetworkDetector) (*dante.NetworkConfig, error) {
+	if len(nd.DanteInterfaces) == 0 {
+		names := nd.danteCandidates()
+		placeholder := &dante.NetworkConfig{InterfaceName: names[0], NetworkType: "dante1"}
+		return placeholder, fmt.Errorf("Dante interface not found, please check network connection (expected one of %v)", names)
+	}
+	
+	logger.Info("Using Dante interface", "iface", nd.DanteInterfaces[0].Name)
+	config, err := nd.GetDanteConfig(0)
+	if err != nil {
+		info := nd.DanteInterfaces[0]
+		placeholder := &dante.NetworkConfig{InterfaceName: info.Name, MacAddress: info.MacAddress, NetworkType: "dante1"}
+		return placeholder, fmt.Errorf("failed to get Dante config: %v", err)
+	}
+	return config, nil
+}
+
 // GetInterfaceByName 根據名稱獲取介面資訊
 func (nd *NetworkDetector) GetInterfaceByName(name string) *NetworkInterfaceInfo {
 	for i, info := range nd.AllInterfaces {
@@ -221,9 +364,9 @@ func (nd *NetworkDetector) ValidateInterfaceForDante(interfaceName string) error
 
 // ListAvailableInterfaces 列出所有可用介面
 func (nd *NetworkDetector) ListAvailableInterfaces() {
-	fmt.Println("\n📋 Available Network Interfaces:")
+	fmt.Println("\n📋 " + i18n.T("Available Network Interfaces:"))
 	fmt.Println("────────────────────────────────────────────────────────────────")
-	fmt.Printf("%-10s %-18s %-15s %-10s\n", "NAME", "MAC", "IP", "STATUS")
+	printHeader("%-10s %-18s %-15s %-10s %s\n", "NAME", "MAC", "IP", "STATUS", "VLAN")
 	fmt.Println("────────────────────────────────────────────────────────────────")
 	
 	for _, info := range nd.AllInterfaces {
@@ -237,15 +380,25 @@ func (nd *NetworkDetector) ListAvailableInterfaces() {
 			ip = "N/A"
 		}
 		
-		fmt.Printf("%-10s %-18s %-15s %-10s\n", 
-			info.Name, info.MacAddress, ip, status)
+		vlan := "-"
+		if info.IsVLAN() {
+			vlan = fmt.Sprintf("%d@%s", info.VLANID, info.Parent)
+		}
+		
+		fmt.Printf("%-10s %-18s %-15s %-10s %s\n", 
+			info.Name, info.MacAddress, ip, status, vlan)
+		
+		for _, addr := range info.IPv6Addresses() {
+			fmt.Printf("%-10s ↳ %s\n", "", addr)
+		}
 	}
-	fmt.Println("────────────────────────────────────────────────────────────────\n")
+	fmt.Println("────────────────────────────────────────────────────────────────")
+	fmt.Println()
 }
 
 // SuggestNetworkConfiguration 建議網路配置
 func (nd *NetworkDetector) SuggestNetworkConfiguration() {
-	fmt.Println("💡 Suggested Network Configuration:")
+	fmt.Println("💡 " + i18n.T("Suggested Network Configuration:"))
 	fmt.Println("════════════════════════════════════════════════════════════════")
 	
 	// 檢查是否有足夠的介面
@@ -257,217 +410,117 @@ func (nd *NetworkDetector) SuggestNetworkConfiguration() {
 	}
 	
 	if upInterfaces < 3 {
-		fmt.Printf("⚠️  Warning: Only %d interfaces are UP with IP. RTD1619B requires 3 interfaces.\n", upInterfaces)
-		fmt.Println("\nRecommended setup:")
-		fmt.Println("  • eth0: Management (Telnet) - External network")
-		fmt.Println("  • eth1: Dante Domain 1 - Audio network 1")
-		fmt.Println("  • eth2: Dante Domain 2 - Audio network 2")
+		fmt.Print(i18n.Sprintf("⚠️  Warning: Only %d interfaces are UP with IP. RTD1619B requires 3 interfaces.\n", upInterfaces))
+		fmt.Println("\n" + i18n.T("Recommended setup:"))
+		fmt.Println("  • eth0: " + i18n.T("Management (Telnet) - External network"))
+		fmt.Println("  • eth1: " + i18n.Sprintf("Dante Domain %d - Audio network %d", 1, 1))
+		fmt.Println("  • eth2: " + i18n.Sprintf("Dante Domain %d - Audio network %d", 2, 2))
+		fmt.Println("\n" + i18n.T("Single NIC on a trunked switch port:"))
+		fmt.Println("  • eth1.20: " + i18n.T("Management (VLAN 20)"))
+		fmt.Println("  • eth1.10: " + i18n.T("Dante Domain 1 (VLAN 10)") + "   -vlan eth1.10,eth1.20")
 	} else {
-		fmt.Println("✓ Sufficient interfaces available")
+		fmt.Println("✓ " + i18n.T("Sufficient interfaces available"))
 		
-		// 建議配置
-		fmt.Println("\nSuggested assignment:")
-		count := 0
+		// 建議配置: 設定檔的角色，或偵測到的 Dante 介面與預設路由所在的管理介面
+		fmt.Println("\n" + i18n.T("Suggested assignment:"))
 		for _, info := range nd.AllInterfaces {
-			if !info.IsUp || !info.HasIP {
-				continue
-			}
-			
-			role := ""
-			switch count {
-			case 0:
-				role = "Management (Telnet)"
-			case 1:
-				role = "Dante Domain 1"
-			case 2:
-				role = "Dante Domain 2"
-			default:
-				role = "Unused"
+			if role := nd.interfaceRole(info.Name); role != "" && info.IsUp && info.HasIP {
+				fmt.Printf("  • %s (%s) → %s\n", info.Name, info.IPAddress, roleText(role))
 			}
-			
-			if role != "Unused" {
-				fmt.Printf("  • %s (%s) → %s\n", info.Name, info.IPAddress, role)
-			}
-			count++
+		}
+		if roles := nd.detectedRoles(); len(nd.Roles) == 0 && len(roles) > 0 {
+			data, _ := json.Marshal(roles)
+			fmt.Println("\n" + i18n.T("Pin this assignment in the config file:"))
+			fmt.Printf("  \"interfaces\": %s\n", data)
 		}
 	}
 	
-	fmt.Println("════════════════════════════════════════════════════════════════\n")
+	fmt.Println("════════════════════════════════════════════════════════════════")
+	fmt.Println()
 }
 
-// CheckNetworkIsolation 檢查 Dante 網路是否隔離
+// CheckNetworkIsolation 檢查 Dante 網路彼此以及與管理網路是否隔離
 func (nd *NetworkDetector) CheckNetworkIsolation() {
-	if len(nd.DanteInterfaces) < 2 {
+	if len(nd.DanteInterfaces) < 2 && nd.ManagementInterface == nil {
 		return
 	}
 	
-	fmt.Println("🔒 Checking network isolation...")
-	
-	dante1IP := nd.DanteInterfaces[0].IPAddress
-	dante2IP := nd.DanteInterfaces[1].IPAddress
-	
-	dante1Net := strings.Join(strings.Split(dante1IP, ".")[0:3], ".")
-	dante2Net := strings.Join(strings.Split(dante2IP, ".")[0:3], ".")
+	logger.Info("Checking network isolation")
 	
-	if dante1Net == dante2Net {
-		fmt.Println("  ⚠️  WARNING: Dante1 and Dante2 are on the same network segment!")
-		fmt.Println("  This may cause broadcast storms and interference.")
-		fmt.Println("  Recommended: Use different network segments (e.g., 10.1.0.x and 10.2.0.x)")
-	} else {
-		fmt.Println("  ✓ Dante networks are properly isolated")
+	shared, management := nd.networkOverlaps()
+	for _, o := range shared {
+		logger.Warn("Dante interfaces share a network segment",
+			"first", o.First, "second", o.Second, "segment", o.Segment)
 	}
-	fmt.Println()
-}
-
-//==============================================================================
-// 核心網路配置
-//==============================================================================
-
-// NetworkConfig 網路介面配置
-type NetworkConfig struct {
-	InterfaceName string // 網路介面名稱 (eth1)
-	MacAddress    string // MAC 地址
-	IPAddress     string // IP 地址
-	NetworkType   string // "dante1"
-	Enabled       bool   // 是否啟用
-}
-
-//==============================================================================
-// Dante 網域管理器
-//==============================================================================
-
-// DanteDomain 代表一個 Dante 網域
-type DanteDomain struct {
-	Name          string
-	NetworkConfig NetworkConfig
-	Initialized   bool
-	DeviceCount   int
-}
-
-// NewDanteDomain 創建新的 Dante 網域
-func NewDanteDomain(name string, config NetworkConfig) *DanteDomain {
-	return &DanteDomain{
-		Name:          name,
-		NetworkConfig: config,
-		Initialized:   false,
-		DeviceCount:   0,
+	for _, o := range management {
+		logger.Warn("Dante interface shares a network segment with the management interface",
+			"dante", o.First, "management", o.Second, "segment", o.Segment)
 	}
-}
-
-// Initialize 初始化 Dante 網域
-func (d *DanteDomain) Initialize() error {
-	log.Printf("🔧 Initializing Dante Domain: %s on %s (%s)", 
-		d.Name, d.NetworkConfig.InterfaceName, d.NetworkConfig.IPAddress)
 	
-	// 傳遞網卡名稱給 Dante SDK
-	interfaceName := C.CString(d.NetworkConfig.InterfaceName)
-	defer C.free(unsafe.Pointer(interfaceName))
-	
-	result := C.dante_init_with_interface(interfaceName)
-	if result != 0 {
-		errorMsg := C.GoString(C.dante_get_last_error())
-		return fmt.Errorf("dante_init_with_interface failed: %s", errorMsg)
+	if len(shared) > 0 {
+		logger.Warn("Shared segments may cause broadcast storms and interference; use different networks (e.g. 10.1.0.x and 10.2.0.x)")
 	}
-	
-	log.Printf("✅ Dante API initialized on %s", d.NetworkConfig.InterfaceName)
-	
-	d.Initialized = true
-	log.Printf("✅ Dante Domain %s ready for network scanning", d.Name)
-	return nil
-}
-
-// StartDeviceScan 開始設備掃描
-func (d *DanteDomain) StartDeviceScan() error {
-	if !d.Initialized {
-		return fmt.Errorf("domain %s not initialized", d.Name)
+	if len(management) > 0 {
+		logger.Warn("Audio multicast will leak onto the management network and office traffic onto the Dante network; keep the management network on its own subnet")
 	}
-	
-	log.Printf("🔍 [%s] Starting device scan on %s", d.Name, d.NetworkConfig.InterfaceName)
-	
-	// 調用 Dante SDK 開始設備掃描
-	result := C.dante_start_device_scan()
-	if result != 0 {
-		errorMsg := C.GoString(C.dante_get_last_error())
-		return fmt.Errorf("dante_start_device_scan failed: %s", errorMsg)
+	if len(shared) == 0 && len(management) == 0 {
+		logger.Info("Dante networks are properly isolated")
 	}
-	
-	log.Printf("✅ Device scan started")
-	
-	// 啟動背景事件處理
-	go d.processEventsLoop()
-	
-	return nil
 }
 
-// processEventsLoop 背景事件處理循環
-func (d *DanteDomain) processEventsLoop() {
-	ticker := time.NewTicker(500 * time.Millisecond)
-	defer ticker.Stop()
-	
-	for d.Initialized {
-		select {
-		case <-ticker.C:
-			C.dante_process_events_briefly()
-		}
-	}
+// segmentOverlap 兩個介面共用的網段
+type segmentOverlap struct {
+	First, Second, Segment string
 }
 
-// RefreshDevices 刷新設備列表
-func (d *DanteDomain) RefreshDevices() {
-	if !d.Initialized {
-		return
+// networkOverlaps 找出 Dante 介面之間 (shared)，以及 Dante 介面與管理介面之間
+// (management) 重疊的網段
+func (nd *NetworkDetector) networkOverlaps() (shared, management []segmentOverlap) {
+	for i := 0; i < len(nd.DanteInterfaces); i++ {
+		a := nd.DanteInterfaces[i]
+		for j := i + 1; j < len(nd.DanteInterfaces); j++ {
+			b := nd.DanteInterfaces[j]
+			for _, segment := range overlappingPrefixes(a, b) {
+				shared = append(shared, segmentOverlap{First: a.Name, Second: b.Name, Segment: segment})
+			}
+		}
+		if m := nd.ManagementInterface; m != nil {
+			for _, segment := range overlappingPrefixes(a, *m) {
+				management = append(management, segmentOverlap{First: a.Name, Second: m.Name, Segment: segment})
+			}
+		}
 	}
-	
-	log.Printf("🔄 [%s] Refreshing device list...", d.Name)
-	
-	// 刷新掃描結果
-	C.dante_refresh_device_scan()
-	
-	// 獲取設備數量
-	d.DeviceCount = int(C.dante_get_discovered_device_count())
-	
-	log.Printf("📊 [%s] Found %d devices", d.Name, d.DeviceCount)
+	return shared, management
 }
 
-// ShowDevices 顯示設備列表
-func (d *DanteDomain) ShowDevices() {
-	fmt.Printf("\n=== %s Device List ===\n", d.Name)
-	fmt.Printf("Interface: %s (%s)\n", d.NetworkConfig.InterfaceName, d.NetworkConfig.IPAddress)
-	fmt.Printf("Total Devices: %d\n", d.DeviceCount)
-	
-	if d.DeviceCount > 0 {
-		fmt.Println("\nID  Name                 Model            IP Address       MAC Address       Dante Ver")
-		fmt.Println("─────────────────────────────────────────────────────────────────────────────────────────")
-		
-		for i := 0; i < d.DeviceCount; i++ {
-			var cInfo C.struct_dante_device_info_t
-			
-			result := C.dante_get_device_info(C.int(i), &cInfo)
-			if result != 0 {
+// overlappingPrefixes 找出兩個介面之間重疊的網段
+// 忽略每張網卡都有的 link-local 地址 (見 ignoredForOverlap)
+func overlappingPrefixes(a, b NetworkInterfaceInfo) []string {
+	var overlaps []string
+	for _, addrA := range a.Addresses {
+		if ignoredForOverlap(a, addrA) {
+			continue
+		}
+		prefixA := addrA.Prefix()
+		for _, addrB := range b.Addresses {
+			if addrB.IsIPv6 != addrA.IsIPv6 || ignoredForOverlap(b, addrB) {
 				continue
 			}
-			
-			fmt.Printf("%-3d %-20s %-16s %-16s %-17s %s\n",
-				int(cInfo.id),
-				C.GoString(&cInfo.name[0]),
-				C.GoString(&cInfo.model[0]),
-				C.GoString(&cInfo.ip_address[0]),
-				C.GoString(&cInfo.mac_address[0]),
-				C.GoString(&cInfo.dante_version[0]))
+			prefixB := addrB.Prefix()
+			if prefixA == nil || prefixB == nil {
+				continue
+			}
+			if prefixA.Contains(prefixB.IP) || prefixB.Contains(prefixA.IP) {
+				// 以較大的網段表示重疊範圍
+				if addrA.PrefixLen <= addrB.PrefixLen {
+					overlaps = append(overlaps, prefixA.String())
+				} else {
+					overlaps = append(overlaps, prefixB.String())
+				}
+			}
 		}
 	}
-	
-	fmt.Println("==========================\n")
-}
-
-// Cleanup 清理資源
-func (d *DanteDomain) Cleanup() {
-	if d.Initialized {
-		log.Printf("🧹 Cleaning up Dante Domain: %s", d.Name)
-		C.dante_stop_device_scan()
-		C.dante_cleanup()
-		d.Initialized = false
-	}
+	return overlaps
 }
 
 //==============================================================================
@@ -475,21 +528,93 @@ func (d *DanteDomain) Cleanup() {
 //==============================================================================
 
 func main() {
+	os.Exit(Execute(newRootCommand(), os.Args[1:]))
+}
+
+// MonitorOptions monitor 命令設定
+type MonitorOptions struct {
+	Interfaces      *interfaceFlags         // Dante 介面與 VLAN
+	Wait            time.Duration           // 首次設備發現等待時間
+	Refresh         RefreshPolicy           // 設備列表事件驅動刷新
+	LinkLocalAlias  bool                    // 發現 Auto-IP 設備時自動加上 169.254/16 別名
+	AddressPlanFile string                  // 用來驗證的位址規劃
+	DnsmasqFile     string                  // 依位址規劃與已發現設備產生的 DHCP 設定
+	StateDir        string                  // 持久化狀態目錄
+	APIAddr         string                  // 管理 API 監聽地址
+	TLS             TLSOptions              // 管理 API 的 HTTPS/WSS (未設定憑證時為純 HTTP)
+	APIToken        string                  // 管理 API 存取權杖 (空白表示不驗證)
+	APITokens       []ConfiguredToken       // 設定檔的具名權杖 (已經過 compileTokens 檢查)
+	APIOpenReads    bool                    // 讀取不需要權杖，只保護變更操作
+	ReadyAge        time.Duration           // /readyz: 刷新多久沒有成功視為未就緒
+	NoiseFloor      NoiseFloor              // 告警降噪設定
+	InitRetry       backoff.Policy          // SDK 初始化失敗時的重試退避
+	Watchdog        dante.WatchdogConfig    // SDK 呼叫卡住或持續失敗時重新初始化網域
+	Tracing         trace.Config            // OTLP 追蹤 (Endpoint 空白表示停用)
+	TUI             bool                    // 以互動式儀表板取代定期輸出的設備表格
+	Features        *FeatureFlags           // 功能開關 (設定檔與 -features)
+	Simulation      *dante.SimulationConfig // -simulate 的模擬設備 (nil 表示使用 Dante SDK)
+	Presets         []Preset                // 設定檔的 preset
+	Triggers        *TriggerConfig          // 設定檔的觸發輸入 (nil 表示沒有)
+	Schedules       []ScheduleEntry         // 設定檔的路由排程
+	LoadShed        LoadShedPolicy          // 主機過載時卸除低優先的 API 請求
+	Reach           ReachOptions            // 發現後的可達性檢查 (reachability 功能)
+	Clock           ClockOptions            // 時鐘同步追蹤 (clock 功能)
+	FlowStats       FlowStatsOptions        // 接收 flow 的封包錯誤統計 (flowstats 功能)
+	Storm           StormOptions            // 廣播/多播風暴偵測 (storm 功能)
+	Alarms          []AlarmRule             // 告警規則 (已經過 compileAlarmRules)
+	Webhooks        []WebhookTarget         // 接收事件的 HTTP 目標 (已經過 compileWebhooks)
+	Hooks           []EventHook             // 事件發生時執行的本機指令 (已經過 compileHooks)
+	Sinks           []SinkConfig            // 登記種類的輸出 sink (已經過 compileSinks)
+	Switches        []SwitchConfig          // 以 SNMP 輪詢 FDB 的交換器 (已經過 compileSwitches)
+	StatusLED       *StatusLEDConfig        // 面板狀態 LED (nil 表示沒有)
+	FrontPanel      *FrontPanelConfig       // 前面板顯示器 (nil 表示沒有)
+	SerialControl   *SerialControlConfig    // 序列埠上的 ASCII 控制台 (nil 表示沒有)
+	TCPControl      *TCPControlConfig       // Crestron/AMX 的 TCP 控制協定 (nil 表示沒有)
+	Notify          *NotifyConfig           // 告警通知寄信或送到 Slack (nil 表示沒有)
+	SNMP            SNMPOptions             // SNMP agent 與 trap (Addr 空白表示停用)
+}
+
+// runMonitor 持續監控 Dante 網路直到收到結束信號
+func runMonitor(opts *MonitorOptions) error {
+	var addressPlan *AddressPlan
+	if opts.AddressPlanFile != "" {
+		plan, err := LoadAddressPlan(opts.AddressPlanFile)
+		if err != nil {
+			return fmt.Errorf("failed to load address plan: %v", err)
+		}
+		addressPlan = plan
+	}
+	
+	// 儀表板模式: 日誌改寫到緩衝區，由儀表板顯示最近的日誌
+	var logs *LogBuffer
+	if opts.TUI {
+		if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			return fmt.Errorf("-tui requires an interactive terminal")
+		}
+		logs = NewLogBuffer(dashboardLogLines)
+		CaptureLogs(logs)
+	}
+	
 	// 打印啟動橫幅
 	fmt.Println("=========================================")
 	fmt.Println("   RTD1619B Dante Single Network Test")
-	fmt.Println("   Version: 1.0.0")
+	fmt.Print(i18n.Sprintf("   Version: %s\n", version))
+	if name := os.Getenv(instanceEnvName); name != "" {
+		fmt.Print(i18n.Sprintf("   Instance: %s\n", name))
+	}
+	if opts.Simulation != nil {
+		fmt.Println(i18n.T("   Mode:    SIMULATION"))
+	}
 	fmt.Println("=========================================")
 	fmt.Println()
 	
 	// ============================================
 	// 步驟 1: 網路介面自動檢測
 	// ============================================
-	log.Println("Step 1: Network Interface Detection")
-	detector := NewNetworkDetector()
-	
-	if err := detector.AutoConfigureFromSystem(); err != nil {
-		log.Fatalf("❌ Network detection failed: %v", err)
+	logger.Info("Step 1: Network interface detection")
+	detector, err := opts.Interfaces.detect()
+	if err != nil {
+		return err
 	}
 	
 	// 列出所有可用介面
@@ -501,88 +626,770 @@ func main() {
 	// ============================================
 	// 步驟 2: 選擇 Dante 介面
 	// ============================================
-	log.Println("Step 2: Configure Dante Interface")
-	
-	var config *NetworkConfig
-	var err error
+	logger.Info("Step 2: Configure Dante interface")
 	
-	// 使用檢測到的 Dante 介面
-	if len(detector.DanteInterfaces) > 0 {
-		log.Printf("✓ Using Dante interface: %s", detector.DanteInterfaces[0].Name)
-		config, err = detector.GetDanteConfig(0)
+	var config *dante.NetworkConfig
+	if opts.Simulation != nil {
+		simConfig := opts.Simulation.NetworkConfig()
+		config = &simConfig
+		logger.Info("Simulation mode, using synthetic devices", "devices", len(opts.Simulation.Devices))
+	} else {
+		config, err = selectDanteConfig(detector)
 		if err != nil {
-			log.Fatalf("❌ Failed to get Dante config: %v", err)
+			// 只嘗試一次時維持原本的行為；否則由網域持續重試直到介面就緒
+			if opts.InitRetry.MaxAttempts == 1 {
+				return err
+			}
+			logger.Warn("Dante interface not ready, will keep retrying", "err", err, "iface", config.InterfaceName)
 		}
-	} else {
-		log.Fatal("❌ Dante interface 'enxf8e43bd6309e' not found. Please check network connection.")
 	}
 	
 	// 顯示選定的配置
-	fmt.Println("\n✓ Selected Dante Configuration:")
-	fmt.Printf("  Interface: %s\n", config.InterfaceName)
-	fmt.Printf("  IP:        %s\n", config.IPAddress)
-	fmt.Printf("  MAC:       %s\n", config.MacAddress)
-	fmt.Printf("  Enabled:   %v\n", config.Enabled)
+	fmt.Println("\n✓ " + i18n.T("Selected Dante Configuration:"))
+	printTable(os.Stdout, [][]string{
+		{"  " + i18n.T("Interface:"), config.InterfaceName},
+		{"  IP:", config.IPAddress},
+		{"  MAC:", config.MacAddress},
+		{"  " + i18n.T("Enabled:"), fmt.Sprint(config.Enabled)},
+	})
+	if fb := detector.Fallback; fb != nil && opts.Simulation == nil {
+		fmt.Println("  " + i18n.Sprintf("Fallback for missing %s (%s)", strings.Join(fb.Missing, ", "), fb.Rule))
+	}
 	fmt.Println()
 	
 	// 設置信號處理
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	
-	// ============================================
-	// 步驟 3: 初始化 Dante
-	// ============================================
-	log.Println("Step 3: Initializing Dante API...")
-	dante1 := NewDanteDomain("Dante1", *config)
+	// 分散式追蹤
+	if opts.Tracing.Endpoint != "" {
+		stopTracing, err := trace.Enable(opts.Tracing)
+		if err != nil {
+			return err
+		}
+		defer stopTracing()
+	}
 	
-	if err := dante1.Initialize(); err != nil {
-		log.Fatalf("❌ Initialization failed: %v", err)
+	// 持久化狀態與事件單
+	state, err := OpenStateStore(opts.StateDir)
+	if err != nil {
+		return fmt.Errorf("failed to open state: %v", err)
+	}
+	if disabled := opts.Features.Disabled(); len(disabled) > 0 {
+		logger.Info("Features disabled", "features", disabled)
 	}
 	
-	// ============================================
-	// 步驟 4: 開始設備掃描
-	// ============================================
-	log.Println("Step 4: Starting device scan...")
-	if err := dante1.StartDeviceScan(); err != nil {
-		log.Printf("⚠️  Device scan warning: %v", err)
+	// 事件匯流排: /api/events 與嵌入的 golane 套件收到相同的事件
+	events := golane.NewBus()
+	
+	// 告警: 設備離線與 panic，通知併入事件單，也可以寄信或送到 Slack
+	notifiers := []AlertNotifier{logAlertNotifier, busAlertNotifier(events)}
+	var incidents *IncidentStore
+	if opts.Features.Enabled(FeatureIncidents) {
+		incidents, err = NewIncidentStore(state)
+		if err != nil {
+			return fmt.Errorf("failed to load incidents: %v", err)
+		}
+		notifiers = append(notifiers, incidents.HandleNotification)
+	}
+	if opts.Notify != nil {
+		notifyCtx, stopNotify := context.WithCancel(context.Background())
+		defer stopNotify()
+		notifiers = append(notifiers, opts.Notify.Notifiers(notifyCtx)...)
+	}
+	alerts := NewAlertManager(opts.NoiseFloor, notifiers...)
+	defer alerts.Flush()
+	if incidents != nil {
+		alerts.OnResolve(incidents.HandleRecovery)
+	}
+	recovery.OnPanic(alerts.HandlePanic)
+	
+	// 設備列表快取: 重啟後在發現完成前先提供上次的列表
+	deviceCache, err := NewDeviceCache(state)
+	if err != nil {
+		return fmt.Errorf("failed to load device cache: %v", err)
+	}
+	
+	// 可達性: 發現後確認設備地址實際有回應
+	reachTracker := NewReachabilityTracker(alerts)
+	
+	// 告警規則: 持續評估設備列表，API 可查詢目前的告警
+	alarms := NewAlarmEngine(opts.Alarms, alerts, events)
+	alarmCtx, stopAlarms := context.WithCancel(context.Background())
+	defer stopAlarms()
+	recovery.GoLoop(alarmCtx, "alarms", func() { alarms.Run(alarmCtx) })
+	
+	// Webhook: 設備加入/移除、告警規則與網域失敗送到外部系統
+	var webhooks *WebhookDispatcher
+	if len(opts.Webhooks) > 0 {
+		webhooks = NewWebhookDispatcher(opts.Webhooks)
+		webhookCtx, stopWebhooks := context.WithCancel(context.Background())
+		defer stopWebhooks()
+		webhooks.Start(webhookCtx, events)
+		logger.Info("Webhooks enabled", "targets", len(opts.Webhooks))
+	}
+	
+	// Hook: 同樣的事件執行本機指令 (現場的整合腳本)
+	var hooks *HookRunner
+	if len(opts.Hooks) > 0 {
+		hooks = NewHookRunner(opts.Hooks)
+		hookCtx, stopHooks := context.WithCancel(context.Background())
+		defer stopHooks()
+		hooks.Start(hookCtx, events)
+		logger.Info("Event hooks enabled", "hooks", len(opts.Hooks))
+	}
+	
+	// Sink: golane.RegisterSink 登記的輸出 (專有協定、中控系統)
+	var sinks *SinkDispatcher
+	if len(opts.Sinks) > 0 {
+		if sinks, err = NewSinkDispatcher(opts.Sinks); err != nil {
+			return err
+		}
+		sinkCtx, stopSinks := context.WithCancel(context.Background())
+		defer stopSinks()
+		sinks.Start(sinkCtx, events)
+		logger.Info("Output sinks enabled", "sinks", len(opts.Sinks))
+	}
+	
+	// 時鐘: 失去同步與 grandmaster 換手
+	var clocks *ClockTracker
+	if opts.Features.Enabled(FeatureClock) {
+		clocks = NewClockTracker(alerts, opts.Clock)
+	}
+	
+	// 接收 flow 的封包錯誤統計
+	var flowStats *FlowStatsTracker
+	if opts.Features.Enabled(FeatureFlowStats) {
+		flowStats = NewFlowStatsTracker(opts.FlowStats)
+	}
+	
+	// 廣播/多播風暴: Dante 介面的每秒封包數
+	var storms *StormDetector
+	if opts.Features.Enabled(FeatureStorm) && opts.Simulation == nil {
+		storms = NewStormDetector(alerts, opts.Storm, danteInterfaceNames(detector))
+		stormCtx, stopStorms := context.WithCancel(context.Background())
+		defer stopStorms()
+		storms.Start(stormCtx)
+	}
+	
+	// 隔離列表 (API 與觸發輸入共用)
+	quarantine, err := NewQuarantineStore(state)
+	if err != nil {
+		return fmt.Errorf("failed to load quarantine list: %v", err)
+	}
+	
+	// 稽核紀錄: 訂閱、隔離與功能開關的變更
+	audit, err := OpenAuditLog(opts.StateDir)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
 	}
 	
 	// ============================================
-	// 步驟 5: 等待設備發現
+	// 步驟 3: 初始化 Dante (由 supervisor 執行，失敗時重啟)
+	// SDK 的 C 狀態整個行程共用，這裡只有 Dante1；其他網域用 instance supervise 各自執行
 	// ============================================
-	log.Println("Step 5: Waiting for device discovery...")
-	time.Sleep(3 * time.Second)
+	logger.Info("Step 3: Initializing Dante API")
+	dante1 := dante.NewDomain("Dante1", *config)
+	if opts.Simulation != nil {
+		dante1 = dante.NewSimulatedDomain("Dante1", *config, dante.NewSimulatedSDK(opts.Simulation))
+	}
+	dante1.EventInterval = opts.Interfaces.eventInterval
+	dante1.CallRetry = opts.Interfaces.callRetry
+	dante1.DeviceTTL = opts.Interfaces.deviceTTL
+	var dhcp *DHCPSeeder
+	if addressPlan != nil && opts.DnsmasqFile != "" {
+		dhcp = NewDHCPSeeder(addressPlan, opts.DnsmasqFile, map[string]string{dante1.Name: config.InterfaceName})
+	}
+	worker1 := &domainWorker{
+		domain:      dante1,
+		opts:        opts,
+		detector:    detector,
+		addressPlan: addressPlan,
+		dhcp:        dhcp,
+		alerts:      alerts,
+		presence:    NewPresenceTracker(),
+		conflicts:   NewNameConflictTracker(alerts),
+		reach:       reachTracker,
+		clocks:      clocks,
+		flowStats:   flowStats,
+		storms:      storms,
+		alarms:      alarms,
+		cache:       deviceCache,
+		events:      events,
+	}
+	
+	supervisorCfg := supervisor.DefaultConfig()
+	supervisorCfg.OnFailure = golane.PublishDomainFailures(events)
+	domains := supervisor.New(supervisorCfg)
+	domains.Add(supervisor.Spec{
+		Name:      dante1.Name,
+		Interface: config.InterfaceName,
+		IPAddress: config.IPAddress,
+		Run:       worker1.Run,
+	})
+	deviceCache.Seed(domains, dante1.Name)
+	
+	routes := map[string]RouteController{
+		dante1.Name: golane.PublishRoutes(events, dante1.Name, auditRoutes(audit, dante1.Name, dante1)),
+	}
+	flows := map[string]FlowController{
+		dante1.Name: auditFlows(audit, dante1.Name, dante1),
+	}
+	// 韌體升級在背景追蹤，進度以 TopicFirmware 事件發布
+	upgradeCtx, stopUpgrades := context.WithCancel(context.Background())
+	defer stopUpgrades()
+	upgrades := map[string]FirmwareUpgrades{
+		dante1.Name: auditUpgrades(audit, dante1.Name, NewUpgradeTracker(upgradeCtx, events, dante1.Name, dante1)),
+	}
+	settings := map[string]SettingsReader{dante1.Name: dante1}
+	meters := map[string]MeterReader{dante1.Name: dante1}
+	
+	// 觸發輸入: 視訊切換台等外部訊號套用 preset
+	var triggers *TriggerEngine
+	if len(opts.Presets) > 0 || opts.Triggers != nil {
+		var triggerCfg TriggerConfig
+		if opts.Triggers != nil {
+			triggerCfg = *opts.Triggers
+		}
+		triggers, err = NewTriggerEngine(triggerCfg, opts.Presets, routes, quarantine, opts.Features)
+		if err != nil {
+			return fmt.Errorf("invalid trigger config: %v", err)
+		}
+		triggerCtx, stopTriggers := context.WithCancel(context.Background())
+		defer stopTriggers()
+		if err := triggers.Start(triggerCtx, triggerCfg); err != nil {
+			return err
+		}
+	}
+	
+	// 路由排程: 在指定時間套用 preset
+	var schedules *RouteScheduler
+	if len(opts.Schedules) > 0 {
+		schedules, err = NewRouteScheduler(opts.Schedules, triggers, state, opts.Features)
+		if err != nil {
+			return fmt.Errorf("invalid schedule config: %v", err)
+		}
+		scheduleCtx, stopSchedules := context.WithCancel(context.Background())
+		defer stopSchedules()
+		schedules.Start(scheduleCtx)
+	}
+	
+	// 中控系統的文字控制: RS-232 序列埠與 Crestron/AMX 的 TCP 協定
+	if opts.SerialControl != nil || opts.TCPControl != nil {
+		controlCtx, stopControl := context.WithCancel(context.Background())
+		defer stopControl()
+		console := NewControlConsole(domains, routes, triggers, quarantine, alarms, opts.Features)
+		if opts.SerialControl != nil {
+			StartSerialControl(controlCtx, *opts.SerialControl, console)
+		}
+		if opts.TCPControl != nil {
+			if _, err := NewTCPControl(*opts.TCPControl, console).Start(controlCtx, opts.TCPControl.Addr, events); err != nil {
+				return fmt.Errorf("failed to start TCP control: %v", err)
+			}
+		}
+	}
+	
+	// AES67 串流: 在 Dante 介面收聽 SAP 公告
+	var streams *aes67.Directory
+	if opts.Features.Enabled(FeatureAES67) && opts.Simulation == nil {
+		aes67Ctx, stopAES67 := context.WithCancel(context.Background())
+		defer stopAES67()
+		streams = startAES67(aes67Ctx, danteInterfaceNames(detector))
+	}
+	
+	// IGMP: 在 Dante 介面收聽查詢，檢查網路上是否有 querier
+	var igmpWatch *IGMPWatch
+	if opts.Features.Enabled(FeatureIGMP) && opts.Simulation == nil {
+		igmpCtx, stopIGMP := context.WithCancel(context.Background())
+		defer stopIGMP()
+		igmpWatch = startIGMP(igmpCtx, danteInterfaceNames(detector))
+	}
+	
+	// LLDP: 每張網卡連接的交換器埠，以及直接聽到的 Dante 設備
+	var lldpWatch *LLDPWatch
+	if opts.Features.Enabled(FeatureLLDP) && opts.Simulation == nil {
+		lldpCtx, stopLLDP := context.WithCancel(context.Background())
+		defer stopLLDP()
+		lldpWatch = startLLDP(lldpCtx, danteInterfaceNames(detector))
+	}
+	
+	// 交換器埠: 以 SNMP 讀取交換器的 FDB，對應出設備接的埠
+	var switchPorts *SwitchPortMapper
+	if len(opts.Switches) > 0 {
+		switchPorts = NewSwitchPortMapper(opts.Switches)
+		switchCtx, stopSwitches := context.WithCancel(context.Background())
+		defer stopSwitches()
+		switchPorts.Start(switchCtx)
+		logger.Info("Polling switches for the port map", "switches", len(opts.Switches))
+	}
+	
+	// 狀態 LED: 掃描中慢閃、正常恆亮、告警快閃
+	if opts.StatusLED != nil {
+		ledCtx, stopLED := context.WithCancel(context.Background())
+		defer stopLED()
+		NewStatusLED(*opts.StatusLED, domains, alarms, storms).Start(ledCtx)
+	}
+	
+	// 前面板顯示器: 網域名稱、設備數與 IP，每次刷新時更新
+	if opts.FrontPanel != nil {
+		panelCtx, stopPanel := context.WithCancel(context.Background())
+		defer stopPanel()
+		NewFrontPanel(*opts.FrontPanel, domains, detector).Start(panelCtx, events)
+	}
+	
+	// 管理 API (只讀取 supervisor 的快照)
+	if opts.APIAddr != "" && !opts.Features.Enabled(FeatureAPI) {
+		logger.Info("Management API disabled by feature flag", "addr", opts.APIAddr)
+	} else if opts.APIAddr != "" {
+		var load *LoadMonitor
+		if opts.LoadShed.Enabled() {
+			load = NewLoadMonitor(opts.LoadShed)
+			loadCtx, stopLoad := context.WithCancel(context.Background())
+			defer stopLoad()
+			load.Start(loadCtx)
+		}
+		apiServer, err := startAPIServer(opts, state, APIConfig{
+			Domains:    domains,
+			Detector:   detector,
+			Routes:     routes,
+			Flows:      flows,
+			Upgrades:   upgrades,
+			Settings:   settings,
+			Meters:     meters,
+			Incidents:  incidents,
+			Quarantine: quarantine,
+			Triggers:   triggers,
+			Schedules:  schedules,
+			Audit:      audit,
+			Load:       load,
+			Events:     events,
+			AES67:      streams,
+			IGMP:       igmpWatch,
+			LLDP:       lldpWatch,
+			SwitchPorts: switchPorts,
+			Reach:      reachTracker,
+			Clocks:     clocks,
+			FlowStats:  flowStats,
+			Storms:     storms,
+			Alarms:     alarms,
+			Webhooks:   webhooks,
+			Hooks:      hooks,
+			Sinks:      sinks,
+		})
+		if err != nil {
+			return err
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			apiServer.Shutdown(ctx)
+		}()
+	}
+	
+	// SNMP agent: 設施 NMS 輪詢網域、設備與介面表格，設備離線時送出 trap
+	if opts.SNMP.Addr != "" {
+		snmpCtx, stopSNMP := context.WithCancel(context.Background())
+		defer stopSNMP()
+		if err := startSNMP(snmpCtx, opts.SNMP, domains, detector, events); err != nil {
+			return err
+		}
+	}
+	
+	domains.Start(context.Background())
+	// 停止網域工作並清理 Dante 資源
+	defer domains.Stop()
+	
+	// 持續運行
+	logger.Info("System ready. Press Ctrl+C to exit")
+	
+	if opts.TUI {
+		dashboard := NewDashboard(DashboardConfig{
+			Domains:  []*dante.Domain{dante1},
+			Detector: detector,
+			Logs:     logs,
+			Refresh:  func() { recovery.Run(dante1.Name+"/refresh", worker1.Refresh) },
+		})
+		if err := dashboard.Run(sigChan); err != nil {
+			return err
+		}
+	} else {
+		// 等待退出信號
+		<-sigChan
+	}
+	logger.Info("Shutting down")
+	return nil
+}
+
+// domainWorker 單一網域的監控工作：初始化、掃描、定期刷新
+// 由 supervisor.Supervisor 執行，失敗時連同 SDK 初始化整個重新開始
+type domainWorker struct {
+	domain      *dante.Domain
+	opts        *MonitorOptions
+	detector    *NetworkDetector
+	addressPlan *AddressPlan
+	dhcp        *DHCPSeeder // 所有網域共用 (nil 表示不產生 DHCP 設定)
+	alerts      *AlertManager
+	presence    *PresenceTracker     // 跨重啟保留，重啟後只回報真正的變化
+	conflicts   *NameConflictTracker // 所有網域共用
+	reach       *ReachabilityTracker // 所有網域共用
+	clocks      *ClockTracker        // 所有網域共用 (nil 表示不追蹤)
+	flowStats   *FlowStatsTracker    // 所有網域共用 (nil 表示不讀取)
+	storms      *StormDetector       // 所有網域共用 (nil 表示不偵測)
+	alarms      *AlarmEngine         // 所有網域共用
+	cache       *DeviceCache         // 最後的設備列表 (重啟後先顯示)
+	events      *golane.Bus
+	published   []dante.Device // 上次發布的列表 (跨重啟保留，nil 表示尚未發布)
+	
+	mu     sync.Mutex     // 定期刷新、儀表板刷新與清理互斥
+	report supervisor.Reporter // 目前這次執行的回報對象 (未執行時為 nil)
+	
+	reachRunning bool      // 背景的可達性檢查進行中
+	reachChecked time.Time // 上次開始可達性檢查的時間
+}
+
+// Run 實作 DomainRunner
+func (w *domainWorker) Run(ctx context.Context, report supervisor.Reporter) error {
+	d := w.domain
+	
+	if err := d.InitializeWithRetry(ctx, w.opts.InitRetry, report); err != nil {
+		if errors.Is(err, backoff.ErrStopped) {
+			return nil
+		}
+		// 用完重試次數: 網域保持 failed，其他網域與 API 繼續運行
+		return fmt.Errorf("%w: initialization %v", supervisor.ErrPermanent, err)
+	}
+	
+	// watchdog 偵測到 SDK 卡住時取消 runCtx，Run 以 StallError 返回並清理 SDK，
+	// 交給 supervisor 重新初始化 (returned 在 Cleanup 之後關閉)
+	runCtx, stall := context.WithCancelCause(ctx)
+	defer stall(nil)
+	returned := make(chan struct{})
+	defer close(returned)
+	if w.opts.Watchdog.Enabled() {
+		recovery.Go(d.Name+"/watchdog", func() { w.watch(runCtx, stall, returned) })
+	}
+	ctx = runCtx
+	
+	defer func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.report = nil
+		d.Cleanup()
+	}()
+	
+	// 時鐘追蹤、儀表板的時鐘狀態與設備識別需要 ConMon
+	if w.opts.Features.Enabled(FeatureClock) {
+		if err := d.StartMonitoring(); err != nil {
+			d.Logger().Warn("Clock status and identify unavailable", "err", err)
+		}
+	}
 	
 	// ============================================
-	// 步驟 6: 刷新設備列表
+	// 步驟 4-6: 設備掃描、等待發現、刷新設備列表
 	// ============================================
-	log.Println("Step 6: Refreshing device list...")
-	dante1.RefreshDevices()
+	d.Logger().Info("Step 4: Starting device scan", "wait", w.opts.Wait)
+	if err := d.StartDeviceScan(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-time.After(w.opts.Wait):
+	}
+	d.RefreshDevices(ctx)
+	w.checkEnrollments(ctx)
 	
 	// ============================================
 	// 步驟 7: 顯示設備
 	// ============================================
-	dante1.ShowDevices()
+	devices := d.GetDevices()
+	if !w.opts.TUI {
+		showDevices(d)
+	}
+	reportLinkLocalDevices(d, w.detector, w.opts.LinkLocalAlias)
+	w.applyAddressPlan(devices)
 	
-	// 持續運行
-	log.Println("✅ System ready. Press Ctrl+C to exit")
+	w.mu.Lock()
+	w.report = report
+	w.alerts.HandleDeviceEvents(w.presence.Update(d.Name, devices))
+	w.conflicts.Update(d.Name, devices)
+	w.alarms.Update(d.Name, devices, time.Now())
+	w.publish(devices)
+	w.checkReachability(devices)
+	w.mu.Unlock()
+	report.Devices(devices)
+	w.saveDevices(devices)
+	if d.Health().Refresh.Failures == 0 {
+		w.alerts.Resolve(AlertSDKStall, d.Name, dante.CallProcessEvents)
+		w.alerts.Resolve(AlertSDKStall, d.Name, dante.CallRefresh)
+	}
 	
-	// 定期刷新設備列表
-	ticker := time.NewTicker(10 * time.Second)
-	go func() {
-		for range ticker.C {
-			dante1.RefreshDevices()
-			dante1.ShowDevices()
+	// SDK 通知變更時刷新設備列表 (沒有通知時依 MaxInterval 定期刷新)
+	policy := w.opts.Refresh
+	last := time.Now()
+	var changed time.Time
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var clockTick <-chan time.Time
+	if w.clocks != nil {
+		ticker := time.NewTicker(w.opts.Clock.Interval)
+		defer ticker.Stop()
+		clockTick = ticker.C
+	}
+	var flowTick <-chan time.Time
+	if w.flowStats != nil {
+		ticker := time.NewTicker(w.opts.FlowStats.Interval)
+		defer ticker.Stop()
+		flowTick = ticker.C
+	}
+	for {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
 		}
-	}()
+		var due <-chan time.Time
+		if at := policy.next(last, changed); !at.IsZero() {
+			timer.Reset(time.Until(at))
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-d.Changes():
+			if changed.IsZero() {
+				d.Logger().Debug("Device change reported, refresh scheduled")
+			}
+			changed = time.Now()
+			continue
+		case <-clockTick:
+			recovery.Run(d.Name+"/clock", w.pollClocks)
+			continue
+		case <-flowTick:
+			recovery.Run(d.Name+"/flowstats", w.pollFlowStats)
+			continue
+		case <-due:
+		}
+		last, changed = time.Now(), time.Time{}
+		
+		// 風暴期間暫停刷新以降低 CPU 負載，每個取樣間隔檢查一次，結束後立即刷新
+		if w.storms.Paused(d.NetworkConfig.InterfaceName) {
+			d.Logger().Debug("Refresh paused during network storm")
+			changed = last.Add(w.opts.Storm.Interval)
+			continue
+		}
+		
+		// 介面消失或斷線時交給 supervisor 重新初始化
+		if up, _ := interfaceStatus(d.NetworkConfig.InterfaceName); !up && !d.Simulated() {
+			return fmt.Errorf("interface %s is down", d.NetworkConfig.InterfaceName)
+		}
+		
+		// 單次刷新失敗不能中斷後續刷新
+		recovery.Run(d.Name+"/refresh", w.Refresh)
+	}
+}
+
+// watch 定期檢查 SDK 呼叫 (watchdog)：卡住或持續失敗時發出告警並以 StallError
+// 取消這次執行，由 supervisor 清理後重新初始化。呼叫卡在 SDK 內時 Run 無法返回，
+// 再等 Hang 仍未返回就結束行程交給 systemd 或 instance supervise 重啟
+// (原生 SDK 只有一個 worker thread，其他網域此時也已經停擺)
+func (w *domainWorker) watch(ctx context.Context, stall context.CancelCauseFunc, returned <-chan struct{}) {
+	d := w.domain
+	cfg := w.opts.Watchdog
+	interval := time.Second
+	if cfg.Hang > 0 && cfg.Hang/4 < interval {
+		interval = cfg.Hang / 4
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := d.Health().Check(cfg, time.Now())
+		var stalled *dante.StallError
+		if !errors.As(err, &stalled) {
+			continue
+		}
+		d.Logger().Error("Watchdog: SDK stalled, reinitializing domain", "err", err)
+		w.alerts.Raise(Alert{
+			Kind:     AlertSDKStall,
+			Severity: SeverityCritical,
+			Domain:   d.Name,
+			Subject:  stalled.Call,
+			Message:  fmt.Sprintf("domain %s: %v, reinitializing", d.Name, err),
+		})
+		stall(err)
+		if !stalled.Hung {
+			return
+		}
+		select {
+		case <-returned:
+		case <-time.After(cfg.Hang):
+			w.alerts.Flush()
+			fatal("Watchdog: SDK call did not return, exiting for the service manager to restart", "domain", d.Name, "err", err)
+		}
+		return
+	}
+}
+
+// Refresh 刷新設備列表並回報 (定期或由儀表板觸發)
+func (w *domainWorker) Refresh() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	
+	d := w.domain
+	if w.report == nil {
+		return
+	}
+	
+	ctx, span := trace.Start(context.Background(), "domain.refresh", slog.String("dante.domain", d.Name))
+	defer span.End()
+	
+	d.RefreshDevices(ctx)
+	w.checkEnrollments(ctx)
+	devices := d.GetDevices()
+	w.alerts.HandleDeviceEvents(w.presence.Update(d.Name, devices))
+	w.conflicts.Update(d.Name, devices)
+	w.alarms.Update(d.Name, devices, time.Now())
+	w.publish(devices)
+	w.checkReachability(devices)
+	w.report.Devices(devices)
+	w.saveDevices(devices)
+	
+	if w.opts.TUI {
+		if w.opts.Features.Enabled(FeatureClock) {
+			for _, dev := range devices {
+				if err := d.WatchClock(dev.Name); err != nil {
+					d.Logger().Debug("Clock query failed", "device", dev.Name, "err", err)
+				}
+			}
+		}
+	} else {
+		showDevices(d)
+	}
+	reportLinkLocalDevices(d, w.detector, w.opts.LinkLocalAlias)
+}
+
+// checkEnrollments 檢查新設備的 DDM 註冊狀態 (ddm 功能)，
+// 之後的設備列表標示這個控制器無法設定的設備
+func (w *domainWorker) checkEnrollments(ctx context.Context) {
+	if w.opts.Features.Enabled(FeatureDDM) {
+		w.domain.CheckEnrollments(ctx, w.domain.GetDevices())
+	}
+}
+
+// publish 發布設備列表與上下線事件 (與嵌入的 golane.Node 相同)
+func (w *domainWorker) publish(devices []dante.Device) {
+	golane.PublishDevices(w.events, w.domain.Name, w.published, devices)
+	w.published = append([]dante.Device{}, devices...)
+}
+
+// saveDevices 更新設備列表快取 (寫入失敗不影響監控)
+func (w *domainWorker) saveDevices(devices []dante.Device) {
+	if err := w.cache.Update(w.domain.Name, devices); err != nil {
+		w.domain.Logger().Warn("Failed to save device cache", "err", err)
+	}
+}
+
+// applyAddressPlan 以位址規劃驗證設備，並依規劃產生 DHCP 設定
+func (w *domainWorker) applyAddressPlan(devices []dante.Device) {
+	d := w.domain
+	plan := w.addressPlan
+	if plan == nil {
+		return
+	}
 	
-	// 等待退出信號
-	<-sigChan
-	fmt.Println("\n\n🛑 Shutting down...")
-	ticker.Stop()
+	for _, problem := range validateAgainstPlan(d, plan) {
+		d.Logger().Warn("Address plan violation", "problem", problem)
+	}
 	
-	// 清理 Dante 資源
-	dante1.Cleanup()
+	if w.dhcp != nil {
+		written, err := w.dhcp.Update(d.Name, d.NetworkConfig.InterfaceName, devices)
+		if err != nil {
+			d.Logger().Warn("Failed to seed DHCP config", "err", err)
+		}
+		if written {
+			d.Logger().Info("DHCP config seeded from address plan", "path", w.opts.DnsmasqFile)
+		}
+	}
+}
+
+// startAPIServer 載入圖示、平面圖與隔離列表並啟動管理 API
+func startAPIServer(opts *MonitorOptions, state *StateStore, cfg APIConfig) (*APIServer, error) {
+	if opts.Features.Enabled(FeatureIcons) {
+		icons, err := NewIconStore(opts.StateDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load device icons: %v", err)
+		}
+		cfg.Icons = icons
+	}
+	if opts.Features.Enabled(FeatureFloorPlan) {
+		floorPlan, err := NewFloorPlanStore(state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load floor plan: %v", err)
+		}
+		cfg.FloorPlan = floorPlan
+	}
+	tokens, err := NewTokenStore(opts.StateDir, opts.APIToken, opts.APITokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API tokens: %v", err)
+	}
+	if !tokens.Enabled() {
+		logger.Warn("Management API has no token, anyone on the management network can control routing", "addr", opts.APIAddr)
+	}
+	
+	if opts.TLS.Enabled() {
+		if cfg.TLS, err = opts.TLS.Config(opts.StateDir); err != nil {
+			return nil, err
+		}
+	}
+	
+	cfg.Addr = opts.APIAddr
+	cfg.Tokens = tokens
+	cfg.OpenReads = opts.APIOpenReads
+	cfg.ReadyAge = opts.ReadyAge
+	cfg.Features = opts.Features
+	apiServer := NewAPIServer(cfg)
+	if err := apiServer.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start API server on %s: %v", opts.APIAddr, err)
+	}
+	return apiServer, nil
+}
+
+// runAddressPlanner 產生並匯出位址規劃
+func runAddressPlanner(spec, base, out, dnsmasq string) error {
+	reqs, err := ParseDomainRequirements(spec)
+	if err != nil {
+		return fmt.Errorf("invalid plan specification: %v", err)
+	}
 	
-	log.Println("✅ Shutdown completed")
+	opts := DefaultPlanOptions()
+	opts.BaseNetwork = base
+	
+	plan, err := GenerateAddressPlan(reqs, opts)
+	if err != nil {
+		return fmt.Errorf("address planning failed: %v", err)
+	}
+	
+	PrintAddressPlan(plan)
+	
+	if out != "" {
+		if err := SaveAddressPlan(plan, out); err != nil {
+			return fmt.Errorf("failed to export address plan: %v", err)
+		}
+		logger.Info("Address plan exported", "path", out)
+	}
+	
+	if dnsmasq != "" {
+		if err := WriteDnsmasqConfig(plan, nil, dnsmasq); err != nil {
+			return fmt.Errorf("failed to write DHCP config: %v", err)
+		}
+		logger.Info("DHCP config written", "path", dnsmasq)
+	}
+	return nil
 }
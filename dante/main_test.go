@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultRouteInterface(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "route")
+	route := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"eth1\t0000FEA9\t00000000\t0001\t0\t0\t0\t0000FFFF\t0\t0\t0\n" +
+		"eth0\t00000000\t0100000A\t0003\t0\t0\t100\t00000000\t0\t0\t0\n"
+	if err := os.WriteFile(path, []byte(route), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := routeProcPath
+	routeProcPath = path
+	t.Cleanup(func() { routeProcPath = saved })
+
+	if name := defaultRouteInterface(); name != "eth0" {
+		t.Errorf("default route interface = %q, want eth0", name)
+	}
+}
+
+// overlapIface 以第一個地址為主要地址的介面
+func overlapIface(name, mac string, addrs ...InterfaceAddress) NetworkInterfaceInfo {
+	return NetworkInterfaceInfo{Name: name, MacAddress: mac, IPAddress: addrs[0].IP, Addresses: addrs}
+}
+
+func TestNetworkOverlaps(t *testing.T) {
+	nd := NewNetworkDetector()
+	nd.AllInterfaces = []NetworkInterfaceInfo{
+		// 管理網路 10.0.0.0/16 涵蓋 Dante2 的 10.0.2.0/24 (不同遮罩的包含關係)
+		overlapIface("eth0", "00:1d:c1:00:00:01", InterfaceAddress{IP: "10.0.0.5", PrefixLen: 16}),
+		// 兩張 Dante 網卡都有 -linklocal-alias 依 MAC 產生的 169.254 別名，不視為重疊
+		overlapIface("eth1", "00:1d:c1:00:0a:00", InterfaceAddress{IP: "172.16.1.10", PrefixLen: 24}, InterfaceAddress{IP: "fe80::1", PrefixLen: 64, IsIPv6: true},
+			InterfaceAddress{IP: "169.254.11.1", PrefixLen: 16}),
+		overlapIface("eth2", "00:1d:c1:00:14:00", InterfaceAddress{IP: "10.0.2.10", PrefixLen: 24}, InterfaceAddress{IP: "fe80::2", PrefixLen: 64, IsIPv6: true},
+			InterfaceAddress{IP: "169.254.21.1", PrefixLen: 16}),
+		// 與 eth1 同一個 /23 但不同 /24 的字串前綴
+		overlapIface("eth3", "00:1d:c1:00:00:03", InterfaceAddress{IP: "172.16.0.20", PrefixLen: 23}),
+	}
+	nd.IdentifyDanteInterfaces([]string{"eth1", "eth2", "eth3"})
+	nd.identifyManagementInterface("eth1")
+	if nd.ManagementInterface != nil {
+		t.Fatal("Dante interface taken as the management interface")
+	}
+	nd.identifyManagementInterface("eth0")
+	if nd.ManagementInterface == nil || nd.ManagementInterface.Name != "eth0" {
+		t.Fatalf("management interface = %+v", nd.ManagementInterface)
+	}
+
+	shared, management := nd.networkOverlaps()
+	if len(shared) != 1 || shared[0] != (segmentOverlap{First: "eth1", Second: "eth3", Segment: "172.16.0.0/23"}) {
+		t.Errorf("shared = %+v", shared)
+	}
+	if len(management) != 1 || management[0] != (segmentOverlap{First: "eth2", Second: "eth0", Segment: "10.0.0.0/16"}) {
+		t.Errorf("management = %+v", management)
+	}
+}
+
+func TestNetworkOverlapsAutoIP(t *testing.T) {
+	// 兩張 Dante 網卡都沒有 DHCP，主要地址停在 Auto-IP：同一個 169.254/16 網段
+	nd := NewNetworkDetector()
+	nd.AllInterfaces = []NetworkInterfaceInfo{
+		overlapIface("eth1", "00:1d:c1:00:0a:00", InterfaceAddress{IP: "169.254.40.2", PrefixLen: 16}),
+		overlapIface("eth2", "00:1d:c1:00:14:00", InterfaceAddress{IP: "169.254.90.7", PrefixLen: 16},
+			InterfaceAddress{IP: "169.254.21.1", PrefixLen: 16}), // 自己加上的別名仍忽略
+	}
+	nd.IdentifyDanteInterfaces([]string{"eth1", "eth2"})
+
+	shared, _ := nd.networkOverlaps()
+	if len(shared) != 1 || shared[0] != (segmentOverlap{First: "eth1", Second: "eth2", Segment: "169.254.0.0/16"}) {
+		t.Errorf("shared = %+v", shared)
+	}
+}
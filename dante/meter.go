@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// 通道電平 (確認路由真的有音訊)
+//==============================================================================
+
+// MeterReader 讀取設備的通道電平 (由 dante.Domain 實作)
+type MeterReader interface {
+	WatchMeters(device string) error
+	Meters(device string) (dante.Meters, bool)
+}
+
+var _ MeterReader = (*dante.Domain)(nil)
+
+// ErrNoMeters 設備沒有送出電平 (不支援 metering 或尚未收到)
+var ErrNoMeters = errors.New("no metering data")
+
+// meterWait 剛訂閱時等待第一個電平的時間 (設備每秒送出數次)
+const meterWait = time.Second
+
+// meterPoll 等待第一個電平時的檢查間隔
+const meterPoll = 50 * time.Millisecond
+
+// readMeters 訂閱設備的電平並讀取最新值；剛訂閱時最多等待 wait
+func readMeters(ctx context.Context, mr MeterReader, device string, wait time.Duration) (dante.Meters, error) {
+	if err := mr.WatchMeters(device); err != nil {
+		return dante.Meters{}, err
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		if meters, ok := mr.Meters(device); ok {
+			return meters, nil
+		}
+		if time.Now().After(deadline) {
+			return dante.Meters{}, fmt.Errorf("%w for %s (the device may not support metering)", ErrNoMeters, device)
+		}
+		select {
+		case <-ctx.Done():
+			return dante.Meters{}, ctx.Err()
+		case <-time.After(meterPoll):
+		}
+	}
+}
+
+// apiMeters 設備的通道電平 (RX 通道編號與 /api/routes 的 channel_id 相同)
+type apiMeters struct {
+	Device string `json:"device"`
+	dante.Meters
+}
+
+func (s *APIServer) handleMeters(w http.ResponseWriter, r *http.Request) {
+	mr, err := selectDomain(r, s.meters)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	device := r.PathValue("device")
+	meters, err := readMeters(r.Context(), mr, device, meterWait)
+	if err != nil {
+		status := subscribeStatus(err)
+		if errors.Is(err, ErrNoMeters) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, apiMeters{Device: device, Meters: meters})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestMetersAPI(t *testing.T) {
+	cfg := dante.DefaultSimulationConfig()
+	cfg.Routes = []dante.SimulatedRoute{
+		{RxDevice: "Amp-Left", RxChannel: "01", TxDevice: "Stage-Box-A", TxChannel: "01"},
+		{RxDevice: "Amp-Left", RxChannel: "02", TxDevice: "Stage-Box-A", TxChannel: "04"},
+	}
+	d := dante.NewSimulatedDomain("Dante1", cfg.NetworkConfig(), dante.NewSimulatedSDK(cfg))
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewAPIServer(APIConfig{Meters: map[string]MeterReader{d.Name: d}}).mux)
+	defer server.Close()
+
+	get := func(device string) (int, apiMeters) {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/api/devices/" + device + "/meters")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var m apiMeters
+		json.NewDecoder(resp.Body).Decode(&m)
+		return resp.StatusCode, m
+	}
+
+	status, m := get("Amp-Left")
+	if status != http.StatusOK || m.Device != "Amp-Left" || len(m.RX) != 4 {
+		t.Fatalf("meters: %d, %+v", status, m)
+	}
+	// 第二個路由接到沒有音訊的通道
+	if !m.RX[0].Signal || m.RX[1].Signal || m.RX[2].Signal {
+		t.Errorf("rx levels = %+v", m.RX)
+	}
+	// 不支援 metering 的設備等待後回傳 404
+	if status, _ := get("Amp-Right"); status != http.StatusNotFound {
+		t.Errorf("device without metering: status %d, want 404", status)
+	}
+}
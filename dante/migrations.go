@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+//==============================================================================
+// 狀態檔格式升級
+//==============================================================================
+
+// 修改任何 section 的持久化格式時：
+//  1. stateVersion 加一
+//  2. 在 stateMigrations 最後加入 Version 等於新版本的步驟
+//
+// 開啟較舊的狀態檔時會先把原始檔案備份為 state.json.v<舊版本>-<時間>.bak，
+// 再依序套用每個步驟並寫回，韌體升級後舊的狀態檔不會無法讀取。
+// 較新的狀態檔 (降級韌體) 維持拒絕開啟，避免覆蓋看不懂的資料。
+
+// StateMigration 狀態檔從 Version-1 升級到 Version 的步驟
+type StateMigration struct {
+	Version     int                                             // 升級後的版本
+	Description string                                          // 記錄在日誌中的說明
+	Migrate     func(sections map[string]json.RawMessage) error // 直接修改 sections
+}
+
+// stateMigrations 依版本排序的升級步驟 (版本 1 是第一個格式，沒有步驟)
+var stateMigrations = []StateMigration{}
+
+// checkMigrations 確認升級步驟從 2 開始連續排列到 target
+func checkMigrations(migrations []StateMigration, target int) error {
+	for i, m := range migrations {
+		if m.Version != i+2 {
+			return fmt.Errorf("state migration %q has version %d, expected %d", m.Description, m.Version, i+2)
+		}
+		if m.Migrate == nil {
+			return fmt.Errorf("state migration to version %d has no Migrate function", m.Version)
+		}
+	}
+	if latest := len(migrations) + 1; latest != target {
+		return fmt.Errorf("state migrations end at version %d, but the state version is %d", latest, target)
+	}
+	return nil
+}
+
+// migrateState 把 doc 從目前版本依序升級到 target，回傳套用的步驟
+// 任一步驟失敗時 doc 保持原狀
+func migrateState(doc *stateDocument, migrations []StateMigration, target int) ([]StateMigration, error) {
+	if err := checkMigrations(migrations, target); err != nil {
+		return nil, err
+	}
+
+	from := doc.Version
+	if from < 1 {
+		// 缺少 version 欄位的狀態檔視為第一個格式
+		from = 1
+	}
+	if from >= target {
+		doc.Version = target
+		return nil, nil
+	}
+
+	// 在副本上升級，失敗時不留下一半的結果
+	sections := make(map[string]json.RawMessage, len(doc.Sections))
+	for name, raw := range doc.Sections {
+		sections[name] = raw
+	}
+
+	var applied []StateMigration
+	for _, m := range migrations[from-1 : target-1] {
+		if err := m.Migrate(sections); err != nil {
+			return nil, fmt.Errorf("state migration to version %d (%s) failed: %v", m.Version, m.Description, err)
+		}
+		applied = append(applied, m)
+	}
+
+	doc.Version = target
+	doc.Sections = sections
+	return applied, nil
+}
+
+// backupStateFile 升級前保存原始狀態檔，回傳備份路徑
+func backupStateFile(path string, version int, data []byte) (string, error) {
+	backup := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up state before migration: %v", err)
+	}
+	return backup, nil
+}
+
+// migrateSection 以 JSON 物件的形式修改單一 section (section 不存在時略過)
+// 供升級步驟使用，例如改名欄位或補上預設值
+func migrateSection(sections map[string]json.RawMessage, name string, fn func(obj map[string]any) error) error {
+	raw, ok := sections[name]
+	if !ok {
+		return nil
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return fmt.Errorf("section %s: %v", name, err)
+	}
+	if err := fn(obj); err != nil {
+		return fmt.Errorf("section %s: %v", name, err)
+	}
+
+	updated, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("section %s: %v", name, err)
+	}
+	sections[name] = updated
+	return nil
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"danteCS/internal/backoff"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// Email / Slack 通知
+//==============================================================================
+
+// 告警通知 (設備離線、告警規則、panic...) 除了日誌與事件單，也可以寄信或
+// 送到 Slack，讓夜間沒有人看著 dashboard 時值班人員仍會收到。通知經過
+// AlertManager 的分組與摘要，交換器重開機只會收到一封摘要。送出在背景
+// 佇列進行並依退避重試，SMTP 或 Slack 變慢不會拖住告警。
+//
+// 設定檔範例：
+//
+//	"notify": {
+//	  "email": {
+//	    "server": "smtp.example.com:587", "username": "golane", "password": "...",
+//	    "from": "golane@example.com", "to": ["av-oncall@example.com"], "min_severity": "critical"
+//	  },
+//	  "slack": {"webhook_url": "https://hooks.slack.com/services/T000/B000/XXXX"}
+//	}
+
+const (
+	notifyQueueSize = 64               // 每個目的地等待送出的通知數 (滿時丟棄)
+	notifyTimeout   = 30 * time.Second // 每次送出的逾時
+	notifySubject   = "[GOlane]"       // 郵件主旨與 Slack 訊息的前綴
+)
+
+// notifyRetry 送出失敗時的重試
+var notifyRetry = backoff.Policy{Initial: 5 * time.Second, Max: time.Minute, MaxAttempts: 4}
+
+// NotifyConfig 設定檔 notify section
+type NotifyConfig struct {
+	Email *EmailConfig `json:"email,omitempty"`
+	Slack *SlackConfig `json:"slack,omitempty"`
+}
+
+// EmailConfig SMTP 通知
+type EmailConfig struct {
+	Server      string   `json:"server"`                 // host:port (587 以 STARTTLS，465 以 TLS 連線)
+	Username    string   `json:"username,omitempty"`     // 空白表示不驗證
+	Password    string   `json:"password,omitempty"`     // 也可以用 GOLANE_SMTP_PASSWORD 環境變數
+	From        string   `json:"from"`                   // 寄件者
+	To          []string `json:"to"`                     // 收件者
+	MinSeverity string   `json:"min_severity,omitempty"` // 只送這個嚴重度以上的通知 (預設 warning)
+}
+
+// SlackConfig Slack incoming webhook 通知
+type SlackConfig struct {
+	WebhookURL  string `json:"webhook_url"`
+	Channel     string `json:"channel,omitempty"`      // 覆寫 webhook 預設的頻道 (舊式 webhook 才支援)
+	MinSeverity string `json:"min_severity,omitempty"` // 只送這個嚴重度以上的通知 (預設 warning)
+}
+
+// ErrInvalidNotify notify 設定錯誤
+var ErrInvalidNotify = errors.New("invalid notify config")
+
+// Validate 檢查設定並補上預設值
+func (c *NotifyConfig) Validate() error {
+	if e := c.Email; e != nil {
+		if _, _, err := net.SplitHostPort(e.Server); err != nil {
+			return fmt.Errorf("%w: email server must be host:port, got %q", ErrInvalidNotify, e.Server)
+		}
+		if e.From == "" || len(e.To) == 0 {
+			return fmt.Errorf("%w: email needs from and to", ErrInvalidNotify)
+		}
+		if e.Password == "" {
+			e.Password = os.Getenv("GOLANE_SMTP_PASSWORD")
+		}
+		if err := validateMinSeverity(&e.MinSeverity); err != nil {
+			return fmt.Errorf("%w: email %v", ErrInvalidNotify, err)
+		}
+	}
+	if s := c.Slack; s != nil {
+		u, err := url.Parse(s.WebhookURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("%w: slack webhook_url must be an https URL", ErrInvalidNotify)
+		}
+		if err := validateMinSeverity(&s.MinSeverity); err != nil {
+			return fmt.Errorf("%w: slack %v", ErrInvalidNotify, err)
+		}
+	}
+	return nil
+}
+
+// validateMinSeverity 空白時使用 warning
+func validateMinSeverity(s *string) error {
+	switch *s {
+	case "":
+		*s = SeverityWarning
+	case SeverityCritical, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("unknown min_severity %q", *s)
+	}
+	return nil
+}
+
+// severityAtLeast 嚴重度是否達到 min
+func severityAtLeast(severity, min string) bool {
+	rank := map[string]int{SeverityInfo: 0, SeverityWarning: 1, SeverityCritical: 2}
+	return rank[severity] >= rank[min]
+}
+
+// Notifiers 依設定建立通知目的地，ctx 結束時停止背景送出
+func (c *NotifyConfig) Notifiers(ctx context.Context) []AlertNotifier {
+	var notifiers []AlertNotifier
+	if c.Email != nil {
+		e := *c.Email
+		notifiers = append(notifiers, queuedNotifier(ctx, "email", e.MinSeverity, func(ctx context.Context, n AlertNotification) error {
+			return sendEmail(ctx, e, n)
+		}))
+	}
+	if c.Slack != nil {
+		s := *c.Slack
+		client := &http.Client{}
+		notifiers = append(notifiers, queuedNotifier(ctx, "slack", s.MinSeverity, func(ctx context.Context, n AlertNotification) error {
+			return postSlack(ctx, client, s, n)
+		}))
+	}
+	return notifiers
+}
+
+// queuedNotifier 在背景依序送出 (佇列已滿時丟棄)，失敗時依 notifyRetry 重試
+func queuedNotifier(ctx context.Context, name, minSeverity string, send func(context.Context, AlertNotification) error) AlertNotifier {
+	queue := make(chan AlertNotification, notifyQueueSize)
+	recovery.Go("notify/"+name, func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-queue:
+				onRetry := func(attempt int, err error, delay time.Duration) {
+					logger.Warn("Alert notification failed, retrying", "notifier", name, "kind", n.Kind, "attempt", attempt, "err", err, "retry_in", delay)
+				}
+				err := backoff.Retry(ctx, notifyRetry, onRetry, func(int) error {
+					sendCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+					defer cancel()
+					return send(sendCtx, n)
+				})
+				if err != nil {
+					logger.Error("Alert notification failed", "notifier", name, "kind", n.Kind, "err", err)
+				}
+			}
+		}
+	})
+	return func(n AlertNotification) {
+		if !severityAtLeast(n.Severity, minSeverity) {
+			return
+		}
+		select {
+		case queue <- n:
+		default:
+			logger.Warn("Alert notification queue full, dropped", "notifier", name, "kind", n.Kind)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+// 內容
+//------------------------------------------------------------------------------
+
+// notificationTitle 一行標題 (郵件主旨、Slack 第一行)
+func notificationTitle(n AlertNotification) string {
+	return fmt.Sprintf("%s %s: %s", notifySubject, strings.ToUpper(n.Severity), n.Message)
+}
+
+// notificationBody 通知包含的告警，每行一筆
+func notificationBody(n AlertNotification) string {
+	var b strings.Builder
+	host, _ := os.Hostname()
+	fmt.Fprintf(&b, "Host:     %s\n", host)
+	if n.Domain != "" {
+		fmt.Fprintf(&b, "Domain:   %s\n", n.Domain)
+	}
+	fmt.Fprintf(&b, "Kind:     %s\n", n.Kind)
+	fmt.Fprintf(&b, "Time:     %s\n\n", n.Time.Local().Format(time.DateTime))
+	for _, a := range n.Alerts {
+		fmt.Fprintf(&b, "%s  %-20s %s\n", a.Time.Local().Format(time.TimeOnly), a.Subject, a.Message)
+	}
+	if n.Suppressed > 0 {
+		fmt.Fprintf(&b, "\n%d repeated alerts suppressed\n", n.Suppressed)
+	}
+	return b.String()
+}
+
+//------------------------------------------------------------------------------
+// SMTP
+//------------------------------------------------------------------------------
+
+// emailMessage RFC 5322 郵件內容
+func emailMessage(cfg EmailConfig, n AlertNotification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(notificationTitle(n)))
+	fmt.Fprintf(&b, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(notificationBody(n), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// sendEmail 寄出通知 (465 埠直接以 TLS 連線，其他埠在伺服器支援時使用 STARTTLS)
+func sendEmail(ctx context.Context, cfg EmailConfig, n AlertNotification) error {
+	host, port, _ := net.SplitHostPort(cfg.Server)
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", cfg.Server)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", cfg.Server)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if _, isTLS := conn.(*tls.Conn); !isTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return err
+			}
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, to := range cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(emailMessage(cfg, n)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+//------------------------------------------------------------------------------
+// Slack
+//------------------------------------------------------------------------------
+
+// slackMessage incoming webhook 的內容
+type slackMessage struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+// postSlack 送出通知到 Slack incoming webhook
+func postSlack(ctx context.Context, client *http.Client, cfg SlackConfig, n AlertNotification) error {
+	icon := ":warning:"
+	switch n.Severity {
+	case SeverityCritical:
+		icon = ":rotating_light:"
+	case SeverityInfo:
+		icon = ":information_source:"
+	}
+	body, err := json.Marshal(slackMessage{
+		Channel: cfg.Channel,
+		Text:    fmt.Sprintf("%s *%s*\n```\n%s```", icon, notificationTitle(n), notificationBody(n)),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
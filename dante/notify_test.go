@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifyConfigValidate(t *testing.T) {
+	cfg := NotifyConfig{
+		Email: &EmailConfig{Server: "smtp.example.com:587", From: "golane@example.com", To: []string{"oncall@example.com"}},
+		Slack: &SlackConfig{WebhookURL: "https://hooks.slack.com/services/T0/B0/X", MinSeverity: SeverityCritical},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Email.MinSeverity != SeverityWarning || cfg.Slack.MinSeverity != SeverityCritical {
+		t.Errorf("min severity: email %q, slack %q", cfg.Email.MinSeverity, cfg.Slack.MinSeverity)
+	}
+
+	for _, bad := range []NotifyConfig{
+		{Email: &EmailConfig{Server: "smtp.example.com", From: "a@b", To: []string{"c@d"}}},
+		{Email: &EmailConfig{Server: "smtp.example.com:25", From: "a@b"}},
+		{Slack: &SlackConfig{WebhookURL: "http://hooks.slack.com/x"}},
+		{Slack: &SlackConfig{WebhookURL: "https://hooks.slack.com/x", MinSeverity: "page"}},
+	} {
+		if err := bad.Validate(); !errors.Is(err, ErrInvalidNotify) {
+			t.Errorf("%+v: err = %v", bad, err)
+		}
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	if !severityAtLeast(SeverityCritical, SeverityWarning) || severityAtLeast(SeverityInfo, SeverityWarning) || !severityAtLeast(SeverityWarning, SeverityWarning) {
+		t.Error("severity order")
+	}
+}
+
+// testNotification 夜間設備數不足的摘要
+func testNotification() AlertNotification {
+	at := time.Date(2024, 3, 2, 3, 14, 0, 0, time.Local)
+	return AlertNotification{
+		Kind:     "too-few-devices",
+		Severity: SeverityCritical,
+		Domain:   "Dante1",
+		Message:  "Dante1 has 9 devices, expected at least 12",
+		Alerts:   []Alert{{Kind: "too-few-devices", Domain: "Dante1", Message: "Dante1 has 9 devices, expected at least 12", Time: at}},
+		Time:     at,
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	cfg := EmailConfig{From: "golane@example.com", To: []string{"a@example.com", "b@example.com"}}
+	msg := string(emailMessage(cfg, testNotification()))
+	for _, want := range []string{
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: [GOlane] CRITICAL: Dante1 has 9 devices, expected at least 12\r\n",
+		"\r\n\r\nHost:",
+		"Domain:   Dante1\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message lacks %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestPostSlack(t *testing.T) {
+	var got slackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		if got.Channel == "#broken" {
+			http.Error(w, "channel_not_found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := SlackConfig{WebhookURL: srv.URL, Channel: "#av-oncall"}
+	if err := postSlack(context.Background(), srv.Client(), cfg, testNotification()); err != nil {
+		t.Fatal(err)
+	}
+	if got.Channel != "#av-oncall" || !strings.HasPrefix(got.Text, ":rotating_light: *[GOlane] CRITICAL:") {
+		t.Errorf("message: %+v", got)
+	}
+	cfg.Channel = "#broken"
+	if err := postSlack(context.Background(), srv.Client(), cfg, testNotification()); err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Errorf("err = %v", err)
+	}
+}
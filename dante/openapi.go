@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"danteCS/golane"
+	"danteCS/internal/dante"
+	"danteCS/internal/igmp"
+	"danteCS/internal/openapi"
+	"danteCS/internal/qos"
+)
+
+//==============================================================================
+// OpenAPI 文件 (/api/openapi.json)
+//==============================================================================
+
+// 控制室軟體的 client SDK 由 /api/openapi.json 產生，不再手寫。文件由實際
+// 註冊的路由 (handle/handlePublic 記錄在 endpoints) 與下面的 apiDocs 組成：
+// 停用的子系統不會出現在文件中，schema 直接由回應與請求的 Go 型別產生，
+// 改了結構後文件自動跟著變。新增路由時要在 apiDocs 加上說明 (測試會檢查)。
+
+// apiEndpoint 註冊的路由
+type apiEndpoint struct {
+	pattern string
+	role    string // 需要的角色 (空白表示依方法決定)
+	public  bool   // 不需要權杖
+}
+
+// apiParam 查詢參數
+type apiParam struct {
+	Name        string
+	Description string
+}
+
+// apiDoc 路由的說明
+type apiDoc struct {
+	ID       string // operationId (SDK 的方法名稱)
+	Summary  string
+	Query    []apiParam
+	Request  any    // 請求內容的型別 (nil 表示沒有)
+	Response any    // 成功回應的型別 (nil 表示 204 No Content)
+	Created  bool   // 成功時為 201
+	Accepted bool   // 成功時為 202 (在背景執行)
+	Stream   bool   // WebSocket，Response 為每則訊息的內容
+	Binary   string // 非 JSON 的回應內容類型 (圖片)
+}
+
+// 共用的查詢參數
+var (
+	domainParam = apiParam{"domain", "domain name, required when several domains are configured"}
+	formatParam = func(formats string) apiParam { return apiParam{"format", "response format: " + formats} }
+)
+
+// apiDocs 路由 pattern → 說明
+var apiDocs = map[string]apiDoc{
+	"GET /healthz":          {ID: "getHealth", Summary: "Liveness of the process", Response: HealthReport{}},
+	"GET /readyz":           {ID: "getReadiness", Summary: "Readiness of every domain (503 when any domain is not ready)", Response: HealthReport{}},
+	"GET /{$}":              {ID: "getRoot", Summary: "Redirect to the web UI"},
+	"GET /api/openapi.json": {ID: "getOpenAPI", Summary: "This OpenAPI document", Response: map[string]any{}},
+	"GET /api/i18n": {ID: "getMessages", Summary: "Translations for the web UI in the monitor's -lang (or ?lang=)",
+		Query: []apiParam{{"lang", "language instead of the monitor's -lang: en, zh-TW"}}, Response: apiI18n{}},
+
+	"GET /api/version": {ID: "getVersion", Summary: "Version, build, SDK and feature information for inventory", Response: BuildInfo{}},
+	"GET /api/domains": {ID: "listDomains", Summary: "Dante domains and their state", Response: []apiDomain{}},
+	"GET /api/devices": {ID: "listDevices", Summary: "Devices of all domains",
+		Query: []apiParam{
+			{"name", "part of the device name"}, {"model", "part of the model"},
+			{"ip", "address or CIDR network"}, {"mac", "part of the MAC address, separators ignored"}, {"version", "Dante version, 4.2 matches 4.2.x"},
+			{"sort", "name, ip or model, prefix with - for descending order"},
+			{"limit", "page size, the total is returned in X-Total-Count"}, {"offset", "page offset, requires limit"},
+		},
+		Response: []apiDevice{}},
+	"GET /api/topology":        {ID: "getTopology", Summary: "Devices, links and flows of all domains", Query: []apiParam{formatParam("json, dot")}, Response: Topology{}},
+	"GET /api/bandwidth":       {ID: "getBandwidth", Summary: "Estimated multicast and unicast bandwidth per link", Query: []apiParam{{"sample_rate", "sample rate used for channels without one"}, {"threshold", "link utilisation (0-1) above which a link is reported as saturated"}}, Response: BandwidthReport{}},
+	"GET /api/firmware":        {ID: "getFirmware", Summary: "Devices grouped by model and firmware version", Query: []apiParam{{"min_version", "minimum Dante version, overall and/or per model (4.2.0,PA-4D=4.1.2)"}}, Response: FirmwareReport{}},
+	"GET /api/features":        {ID: "listFeatures", Summary: "Feature flags", Response: []FeatureState{}},
+	"PUT /api/features/{name}": {ID: "setFeature", Summary: "Enable or disable a feature at runtime", Request: featureRequest{}, Response: FeatureState{}},
+	"GET /api/ws":              {ID: "watchSnapshot", Summary: "WebSocket pushing the web UI snapshot whenever it changes", Stream: true, Response: webSnapshot{}},
+
+	"GET /api/interfaces":      {ID: "getInterfaces", Summary: "Detected management and Dante interfaces", Response: NetworkDetector{}},
+	"GET /api/qos":             {ID: "sampleQoS", Summary: "Sample PTP and audio DSCP markings on the Dante interfaces", Query: []apiParam{{"duration", "capture duration such as 5s"}, {"group", "comma-separated multicast groups to include"}}, Response: []qos.Report{}},
+	"GET /api/latency":         {ID: "measureLatency", Summary: "Measure round-trip latency to every device", Query: []apiParam{{"samples", "probes per device"}, {"threshold", "ratio of the configured latency above which a path is at risk"}}, Response: LatencyReport{}},
+	"GET /api/load":            {ID: "getLoad", Summary: "Host load and load shedding state", Response: LoadStatus{}},
+	"GET /api/events":          {ID: "watchEvents", Summary: "WebSocket forwarding events from the event bus", Query: []apiParam{{"topic", "comma-separated topics, all when empty"}}, Stream: true, Response: golane.Event{}},
+	"GET /api/aes67":           {ID: "listAES67Streams", Summary: "AES67 streams announced with SAP", Response: []apiStream{}},
+	"GET /api/igmp":            {ID: "listIGMPQueriers", Summary: "IGMP querier and membership report of every Dante interface", Response: []igmp.Report{}},
+	"GET /api/switchports":     {ID: "listSwitchPorts", Summary: "Switch and port of every device from the FDB of the configured switches", Response: SwitchPortReport{}},
+	"GET /api/lldp":            {ID: "listLLDPNeighbors", Summary: "Switch port and LLDP neighbors of every Dante interface", Response: []LLDPReport{}},
+	"GET /api/alarms":          {ID: "getAlarms", Summary: "Alarm rules and their current state", Response: AlarmStatus{}},
+	"GET /api/webhooks":        {ID: "listWebhooks", Summary: "Webhook delivery statistics", Response: []WebhookStats{}},
+	"GET /api/hooks":           {ID: "listHooks", Summary: "Event hook run statistics", Response: []HookStats{}},
+	"GET /api/sinks":           {ID: "listSinks", Summary: "Output sink write statistics", Response: []SinkStats{}},
+	"GET /debug/pprof/":        {ID: "getProfileIndex", Summary: "Index of the runtime profiles; /debug/pprof/{name} serves a named profile such as heap or goroutine", Query: []apiParam{{"debug", "1 or 2 for a text profile instead of the protobuf format"}, {"seconds", "collect a delta profile over this many seconds"}}, Binary: "application/octet-stream"},
+	"GET /debug/pprof/cmdline": {ID: "getProfileCmdline", Summary: "Command line of the running process", Binary: "text/plain"},
+	"GET /debug/pprof/profile": {ID: "getCPUProfile", Summary: "CPU profile", Query: []apiParam{{"seconds", "profiling duration in seconds (default 30)"}}, Binary: "application/octet-stream"},
+	"GET /debug/pprof/symbol":  {ID: "getProfileSymbols", Summary: "Number of symbols available to go tool pprof", Binary: "text/plain"},
+	"POST /debug/pprof/symbol": {ID: "lookupProfileSymbols", Summary: "Look up the function names of program counters (go tool pprof)", Binary: "text/plain"},
+	"GET /debug/pprof/trace":   {ID: "getExecutionTrace", Summary: "Execution trace for go tool trace", Query: []apiParam{{"seconds", "tracing duration in seconds (default 1)"}}, Binary: "application/octet-stream"},
+	"GET /api/storms":          {ID: "getStorms", Summary: "Packet rates of the Dante interfaces and broadcast/multicast storm state", Response: StormStatus{}},
+	"GET /api/flowstats":       {ID: "listFlowStats", Summary: "Packet error counters of the RX flows of every device, with recent errors and device health", Response: FlowStatsStatus{}},
+	"GET /metrics":             {ID: "getMetrics", Summary: "RX flow packet error counters in the Prometheus text format", Binary: "text/plain; version=0.0.4"},
+	"GET /api/clock":           {ID: "getClock", Summary: "Clock synchronisation state and history of every device", Response: ClockStatus{}},
+	"GET /api/reachability":    {ID: "listReachability", Summary: "Reachability of every device address", Response: []DeviceReachability{}},
+
+	"GET /api/routes/{device}":              {ID: "listRoutes", Summary: "Receive channel subscriptions of a device", Query: []apiParam{domainParam}, Response: []apiSubscription{}},
+	"PUT /api/routes/{device}/{channel}":    {ID: "subscribe", Summary: "Subscribe a receive channel (409 when the transmitter is quarantined)", Query: []apiParam{domainParam}, Request: routeRequest{}},
+	"DELETE /api/routes/{device}/{channel}": {ID: "unsubscribe", Summary: "Clear the subscription of a receive channel", Query: []apiParam{domainParam}},
+
+	"GET /api/devices/{device}/flows":         {ID: "listFlows", Summary: "Transmit flows of a device", Query: []apiParam{domainParam}, Response: []dante.Flow{}},
+	"POST /api/devices/{device}/flows":        {ID: "createFlow", Summary: "Create a multicast transmit flow", Query: []apiParam{domainParam}, Request: dante.FlowConfig{}, Response: flowCreated{}, Created: true},
+	"DELETE /api/devices/{device}/flows/{id}": {ID: "deleteFlow", Summary: "Delete a transmit flow", Query: []apiParam{domainParam}},
+	"GET /api/devices/{device}/firmware":      {ID: "getFirmwareUpgrade", Summary: "Progress of the last firmware upgrade of a device", Query: []apiParam{domainParam}, Response: FirmwareUpgrade{}},
+	"POST /api/devices/{device}/firmware":     {ID: "startFirmwareUpgrade", Summary: "Upgrade the firmware of a device from a TFTP/HTTP server (progress as firmware events)", Query: []apiParam{domainParam}, Request: upgradeRequest{}, Response: FirmwareUpgrade{}, Accepted: true},
+	"GET /api/devices/{device}/meters":        {ID: "getMeters", Summary: "Peak level and signal presence of each TX/RX channel (404 when the device does not send meters)", Query: []apiParam{domainParam}, Response: apiMeters{}},
+
+	"GET /api/quarantine":             {ID: "listQuarantine", Summary: "Quarantined devices", Response: []QuarantineEntry{}},
+	"PUT /api/quarantine/{device}":    {ID: "quarantineDevice", Summary: "Quarantine a device", Request: quarantineRequest{}, Response: QuarantineEntry{}},
+	"DELETE /api/quarantine/{device}": {ID: "releaseDevice", Summary: "Release a device from quarantine"},
+
+	"GET /api/presets":                {ID: "listPresets", Summary: "Routing presets", Response: []Preset{}},
+	"GET /api/presets/{name}/check":   {ID: "checkPreset", Summary: "Check whether a preset can be recalled", Response: PresetCheck{}},
+	"POST /api/presets/{name}/recall": {ID: "recallPreset", Summary: "Recall a preset (409 with the result when it fails)", Query: []apiParam{{"partial", "apply the routes that can be made when others fail"}}, Response: TriggerEvent{}},
+	"GET /api/triggers":               {ID: "getTriggers", Summary: "Trigger input mapping and the last trigger", Response: triggerStatus{}},
+	"POST /api/triggers/{input}":      {ID: "fireTrigger", Summary: "Fire a trigger input", Response: TriggerEvent{}},
+	"GET /api/schedules":              {ID: "getSchedules", Summary: "Routing schedules, their next run and the run history", Response: ScheduleStatus{}},
+
+	"GET /api/audit": {ID: "listAudit", Summary: "Audit entries",
+		Query: []apiParam{
+			{"since", "RFC 3339 time or duration such as 720h"}, {"until", "RFC 3339 time or duration"},
+			{"actor", "user or source"}, {"via", "api, cli or trigger"}, {"device", "device name"},
+			{"operation", "operation or kind"}, formatParam("json, csv"),
+		},
+		Response: []AuditEntry{}},
+	"GET /api/audit/verify": {ID: "verifyAudit", Summary: "Verify the hash chain of the audit log", Response: AuditVerification{}},
+
+	"GET /api/icons":                   {ID: "listIcons", Summary: "Bundled icons and model mappings", Response: map[string]any{}},
+	"GET /api/icons/bundled/{name}":    {ID: "getBundledIcon", Summary: "Bundled icon image", Binary: "image/svg+xml"},
+	"GET /api/icons/models/{model}":    {ID: "getModelIcon", Summary: "Icon image for a device model", Binary: "image/*"},
+	"PUT /api/icons/models/{model}":    {ID: "uploadModelIcon", Summary: "Upload an icon (PNG, SVG or JPEG body) for a device model", Response: map[string]string{}},
+	"DELETE /api/icons/models/{model}": {ID: "deleteModelIcon", Summary: "Remove the uploaded icon of a device model", Response: map[string]string{}},
+
+	"GET /api/floorplan":                   {ID: "getFloorPlan", Summary: "Floor plan with device placements and status", Response: FloorPlanView{}},
+	"PUT /api/floorplan":                   {ID: "replaceFloorPlan", Summary: "Replace the floor plan", Request: FloorPlan{}, Response: FloorPlanView{}},
+	"PUT /api/floorplan/rooms/{id}":        {ID: "putRoom", Summary: "Create or update a room", Request: Room{}, Response: Room{}},
+	"DELETE /api/floorplan/rooms/{id}":     {ID: "deleteRoom", Summary: "Delete a room"},
+	"PUT /api/floorplan/devices/{name}":    {ID: "placeDevice", Summary: "Place a device on the floor plan", Request: DevicePlacement{}, Response: DevicePlacement{}},
+	"DELETE /api/floorplan/devices/{name}": {ID: "removePlacement", Summary: "Remove a device from the floor plan"},
+
+	"GET /api/incidents":               {ID: "listIncidents", Summary: "Incidents", Query: []apiParam{{"status", "open, acknowledged or resolved"}}, Response: []Incident{}},
+	"GET /api/incidents/report":        {ID: "getIncidentReport", Summary: "Incident statistics", Query: []apiParam{{"since", "period such as 168h (default one week)"}}, Response: IncidentReport{}},
+	"GET /api/incidents/{id}":          {ID: "getIncident", Summary: "One incident with its history", Response: Incident{}},
+	"POST /api/incidents/{id}/ack":     {ID: "acknowledgeIncident", Summary: "Acknowledge an incident", Request: incidentAction{}, Response: Incident{}},
+	"POST /api/incidents/{id}/resolve": {ID: "resolveIncident", Summary: "Resolve an incident", Request: incidentAction{}, Response: Incident{}},
+	"POST /api/incidents/{id}/notes":   {ID: "addIncidentNote", Summary: "Add a note to an incident", Request: incidentAction{}, Response: Incident{}},
+}
+
+// pathParam 路徑中的 {name}
+var pathParam = regexp.MustCompile(`\{([A-Za-z_]+)(\.\.\.)?\}`)
+
+// OpenAPI 依註冊的路由產生文件
+func (s *APIServer) OpenAPI() *openapi.Document {
+	gen := openapi.NewGenerator()
+	bearer := []map[string][]string{{"bearer": {}}}
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:   "GOlane management API",
+			Version: version,
+			Description: "Monitoring and control of Dante networks. Requests need Authorization: Bearer <token> when API tokens are configured; " +
+				"GET requests need the viewer role, other methods the operator role unless noted.",
+		},
+		Paths: make(map[string]*openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer", Description: "API token from -api-token, the config file or golane token issue"},
+			},
+		},
+		Security: bearer,
+	}
+	errorResponse := &openapi.Response{Description: "Error", Content: openapi.JSON(gen.SchemaFor(map[string]string{}))}
+
+	for _, e := range s.endpoints {
+		method, path, _ := strings.Cut(e.pattern, " ")
+		path = strings.TrimSuffix(path, "{$}")
+		if path == "" {
+			path = "/"
+		}
+		item := doc.Paths[path]
+		if item == nil {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		slot := item.Method(method)
+		if slot == nil {
+			continue
+		}
+
+		d, ok := apiDocs[e.pattern]
+		if !ok {
+			d = apiDoc{ID: operationID(method, path), Summary: e.pattern}
+		}
+		op := &openapi.Operation{OperationID: d.ID, Summary: d.Summary, Responses: make(map[string]*openapi.Response)}
+		if parts := strings.Split(strings.TrimPrefix(path, "/api/"), "/"); strings.HasPrefix(path, "/api/") {
+			op.Tags = []string{strings.TrimSuffix(parts[0], ".json")}
+		}
+		if e.role != "" {
+			op.Description = fmt.Sprintf("Requires the %s role.", e.role)
+		}
+		if e.public {
+			op.Security = &[]map[string][]string{}
+		}
+		for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: m[1], In: "path", Required: true, Schema: openapi.String()})
+		}
+		for _, q := range d.Query {
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: q.Name, In: "query", Description: q.Description, Schema: openapi.String()})
+		}
+		if d.Request != nil {
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSON(gen.SchemaFor(d.Request))}
+		}
+
+		switch {
+		case d.Stream:
+			op.Description = strings.TrimSpace(op.Description + " Each WebSocket text message is one JSON document of the 101 response schema.")
+			op.Responses["101"] = &openapi.Response{Description: "Switching to WebSocket", Content: openapi.JSON(gen.SchemaFor(d.Response))}
+		case d.Binary != "":
+			op.Responses["200"] = &openapi.Response{Description: "OK", Content: map[string]*openapi.MediaType{d.Binary: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}}}
+		case d.Response == nil && path == "/":
+			op.Responses["302"] = &openapi.Response{Description: "Redirect"}
+		case d.Response == nil:
+			op.Responses["204"] = &openapi.Response{Description: "No Content"}
+		case d.Created:
+			op.Responses["201"] = &openapi.Response{Description: "Created", Content: openapi.JSON(gen.SchemaFor(d.Response))}
+		case d.Accepted:
+			op.Responses["202"] = &openapi.Response{Description: "Accepted", Content: openapi.JSON(gen.SchemaFor(d.Response))}
+		default:
+			op.Responses["200"] = &openapi.Response{Description: "OK", Content: openapi.JSON(gen.SchemaFor(d.Response))}
+		}
+		op.Responses["default"] = errorResponse
+		*slot = op
+	}
+	doc.Components.Schemas = gen.Schemas
+	return doc
+}
+
+// operationID 沒有說明的路由以方法與路徑組成 operationId
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') }) {
+		if part == "api" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// handleOpenAPI GET /api/openapi.json
+func (s *APIServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.OpenAPI())
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"danteCS/golane"
+	"danteCS/internal/aes67"
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+// fullAPIServer 註冊所有路由的 API 伺服器
+func fullAPIServer(t *testing.T) *APIServer {
+	dir := t.TempDir()
+	state, err := OpenStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	icons, err := NewIconStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	floorPlan, err := NewFloorPlanStore(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit, err := OpenAuditLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	incidents, err := NewIncidentStore(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quarantine, err := NewQuarantineStore(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pprof 路由只在有 admin 權杖時註冊
+	tokens, err := NewTokenStore("", "admin-token", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"}, dante.NewSimulatedSDK(&dante.SimulationConfig{}))
+	return NewAPIServer(APIConfig{
+		Tokens:      tokens,
+		Domains:     supervisor.New(supervisor.DefaultConfig()),
+		Detector:    &NetworkDetector{},
+		Routes:      map[string]RouteController{"Dante1": domain},
+		Flows:       map[string]FlowController{"Dante1": domain},
+		Upgrades:    map[string]FirmwareUpgrades{"Dante1": NewUpgradeTracker(context.Background(), nil, "Dante1", domain)},
+		Meters:      map[string]MeterReader{"Dante1": domain},
+		Icons:       icons,
+		FloorPlan:   floorPlan,
+		Incidents:   incidents,
+		Quarantine:  quarantine,
+		Triggers:    &TriggerEngine{},
+		Schedules:   &RouteScheduler{},
+		Audit:       audit,
+		Load:        &LoadMonitor{},
+		Events:      golane.NewBus(),
+		AES67:       aes67.NewDirectory(),
+		IGMP:        &IGMPWatch{},
+		LLDP:        &LLDPWatch{},
+		SwitchPorts: &SwitchPortMapper{},
+		Reach:       &ReachabilityTracker{},
+		Clocks:      &ClockTracker{},
+		FlowStats:   &FlowStatsTracker{},
+		Storms:      &StormDetector{},
+		Alarms:      &AlarmEngine{},
+		Webhooks:    &WebhookDispatcher{},
+		Hooks:       &HookRunner{},
+		Sinks:       &SinkDispatcher{},
+	})
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	s := fullAPIServer(t)
+	for _, e := range s.endpoints {
+		if _, ok := apiDocs[e.pattern]; !ok {
+			t.Errorf("%s is missing from apiDocs", e.pattern)
+		}
+	}
+	// apiDocs 沒有多餘 (已刪除) 的路由
+	registered := make(map[string]bool)
+	for _, e := range s.endpoints {
+		registered[e.pattern] = true
+	}
+	for pattern := range apiDocs {
+		if !registered[pattern] {
+			t.Errorf("apiDocs documents %s, which is not registered", pattern)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	server := httptest.NewServer(NewAPIServer(APIConfig{Token: "secret"}).mux)
+	defer server.Close()
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string             `json:"operationId"`
+			Security    *[]json.RawMessage `json:"security"`
+			Description string             `json:"description"`
+			Parameters  []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	getJSON(t, server.URL+"/api/openapi.json", &doc) // 不需要權杖
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("openapi = %q", doc.OpenAPI)
+	}
+
+	devices := doc.Paths["/api/devices"]["get"]
+	if devices.OperationID != "listDevices" || devices.Security != nil || len(devices.Parameters) == 0 {
+		t.Errorf("/api/devices: %+v", devices)
+	}
+	if ready := doc.Paths["/readyz"]["get"]; ready.Security == nil || len(*ready.Security) != 0 {
+		t.Error("/readyz should not require a token")
+	}
+	if set := doc.Paths["/api/features/{name}"]["put"]; !strings.Contains(set.Description, RoleAdmin) ||
+		len(set.Parameters) != 1 || set.Parameters[0].In != "path" {
+		t.Errorf("PUT /api/features/{name}: %+v", set)
+	}
+	if _, ok := doc.Paths["/api/routes/{device}"]; ok {
+		t.Error("routes documented without a route controller")
+	}
+	for _, name := range []string{"ApiDevice", "HealthReport", "FeatureState"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s missing", name)
+		}
+	}
+	var device struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	json.Unmarshal(doc.Components.Schemas["ApiDevice"], &device)
+	if _, ok := device.Properties["domain"]; !ok {
+		t.Errorf("ApiDevice properties: %v", device.Properties)
+	}
+}
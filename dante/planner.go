@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// 子網路計算與位址規劃 (新安裝用)
+//==============================================================================
+
+// DomainRequirement 單一網域的規劃需求
+type DomainRequirement struct {
+	Name        string // 網域名稱 (Dante1)
+	DeviceCount int    // 預計設備數量
+}
+
+// PlanOptions 規劃參數
+type PlanOptions struct {
+	BaseNetwork  string  // 可分配的母網段 (例如 10.10.0.0/16)
+	Headroom     float64 // 擴充餘裕比例 (0.5 = 預留 50%)
+	StaticHosts  int     // 每個網段保留給交換器/控制端的固定地址數
+	MinPrefixLen int     // 最大子網路 (最小 prefix 長度)
+	MaxPrefixLen int     // 最小子網路 (最大 prefix 長度)
+}
+
+// DefaultPlanOptions 預設規劃參數
+func DefaultPlanOptions() PlanOptions {
+	return PlanOptions{
+		BaseNetwork:  "10.10.0.0/16",
+		Headroom:     0.5,
+		StaticHosts:  10,
+		MinPrefixLen: 16,
+		MaxPrefixLen: 28,
+	}
+}
+
+// Reservation 固定地址保留
+type Reservation struct {
+	Name       string `json:"name"`
+	MacAddress string `json:"mac,omitempty"`
+	IPAddress  string `json:"ip"`
+}
+
+// SubnetPlan 單一網域的子網路規劃
+type SubnetPlan struct {
+	Domain       string        `json:"domain"`
+	Network      string        `json:"network"`
+	NetMask      string        `json:"netmask"`
+	DeviceCount  int           `json:"device_count"`
+	Capacity     int           `json:"capacity"`
+	ControllerIP string        `json:"controller_ip"`
+	StaticStart  string        `json:"static_start"`
+	StaticEnd    string        `json:"static_end"`
+	DHCPStart    string        `json:"dhcp_start"`
+	DHCPEnd      string        `json:"dhcp_end"`
+	Reservations []Reservation `json:"reservations,omitempty"`
+}
+
+// AddressPlan 完整位址規劃
+type AddressPlan struct {
+	BaseNetwork string       `json:"base_network"`
+	Subnets     []SubnetPlan `json:"subnets"`
+}
+
+// ParseDomainRequirements 解析 "Dante1=40,Dante2=24" 格式的需求
+func ParseDomainRequirements(spec string) ([]DomainRequirement, error) {
+	var reqs []DomainRequirement
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, count, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid domain requirement %q (expected name=count)", part)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid device count in %q", part)
+		}
+		reqs = append(reqs, DomainRequirement{Name: strings.TrimSpace(name), DeviceCount: n})
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no domain requirements given")
+	}
+	return reqs, nil
+}
+
+// prefixForHosts 計算容納 hosts 個主機所需的 prefix 長度
+func prefixForHosts(hosts int) int {
+	// 扣除網路與廣播地址
+	bits := int(math.Ceil(math.Log2(float64(hosts + 2))))
+	return 32 - bits
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uint32ToIP(n uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
+
+// GenerateAddressPlan 依設備數量與網域產生子網路規劃
+func GenerateAddressPlan(reqs []DomainRequirement, opts PlanOptions) (*AddressPlan, error) {
+	_, base, err := net.ParseCIDR(opts.BaseNetwork)
+	if err != nil || base.IP.To4() == nil {
+		return nil, fmt.Errorf("invalid IPv4 base network %q", opts.BaseNetwork)
+	}
+	if opts.Headroom < 0 || math.IsNaN(opts.Headroom) {
+		return nil, fmt.Errorf("invalid headroom %v (must not be negative)", opts.Headroom)
+	}
+	if opts.StaticHosts < 0 {
+		return nil, fmt.Errorf("invalid static host count %d (must not be negative)", opts.StaticHosts)
+	}
+	baseOnes, _ := base.Mask.Size()
+	// 以 uint64 計算，/0 的母網段 (2^32 個地址) 不會溢位
+	baseStart := uint64(ipToUint32(base.IP))
+	baseEnd := baseStart + uint64(1)<<uint(32-baseOnes) - 1
+
+	plan := &AddressPlan{BaseNetwork: base.String()}
+	next := baseStart
+
+	for _, req := range reqs {
+		// 控制端 1 個 + 固定保留 + 設備 (含餘裕)
+		hosts := 1 + opts.StaticHosts + int(math.Ceil(float64(req.DeviceCount)*(1+opts.Headroom)))
+		prefix := prefixForHosts(hosts)
+		if prefix > opts.MaxPrefixLen {
+			prefix = opts.MaxPrefixLen
+		}
+		if prefix < opts.MinPrefixLen || prefix < baseOnes {
+			return nil, fmt.Errorf("domain %s needs %d hosts, larger than allowed subnet size", req.Name, hosts)
+		}
+
+		size := uint64(1) << uint(32-prefix)
+		// 對齊子網路邊界
+		if rem := (next - baseStart) % size; rem != 0 {
+			next += size - rem
+		}
+		if next+size-1 > baseEnd {
+			return nil, fmt.Errorf("base network %s exhausted while planning domain %s", base, req.Name)
+		}
+
+		network := uint32(next)
+		broadcast := uint32(next + size - 1)
+		staticStart := network + 2
+		staticEnd := staticStart + uint32(opts.StaticHosts) - 1
+		if opts.StaticHosts == 0 {
+			staticEnd = network + 1
+		}
+
+		mask := net.CIDRMask(prefix, 32)
+		plan.Subnets = append(plan.Subnets, SubnetPlan{
+			Domain:       req.Name,
+			Network:      fmt.Sprintf("%s/%d", uint32ToIP(network), prefix),
+			NetMask:      net.IP(mask).String(),
+			DeviceCount:  req.DeviceCount,
+			Capacity:     int(size) - 2,
+			ControllerIP: uint32ToIP(network + 1).String(),
+			StaticStart:  uint32ToIP(staticStart).String(),
+			StaticEnd:    uint32ToIP(staticEnd).String(),
+			DHCPStart:    uint32ToIP(staticEnd + 1).String(),
+			DHCPEnd:      uint32ToIP(broadcast - 1).String(),
+		})
+
+		next += size
+	}
+
+	return plan, nil
+}
+
+// SubnetFor 取得指定網域的規劃
+func (p *AddressPlan) SubnetFor(domain string) *SubnetPlan {
+	for i := range p.Subnets {
+		if strings.EqualFold(p.Subnets[i].Domain, domain) {
+			return &p.Subnets[i]
+		}
+	}
+	return nil
+}
+
+// ReserveDevices 為已知 MAC 的設備在 DHCP 範圍內分配固定地址
+// 已有保留的 MAC 維持原本的地址，新的設備跳過已保留的地址
+func (sp *SubnetPlan) ReserveDevices(devices []dante.Device) error {
+	start := ipToUint32(net.ParseIP(sp.DHCPStart))
+	end := ipToUint32(net.ParseIP(sp.DHCPEnd))
+
+	used := make(map[uint32]bool)
+	reserved := make(map[string]bool)
+	for _, r := range sp.Reservations {
+		used[ipToUint32(net.ParseIP(r.IPAddress))] = true
+		reserved[strings.ToLower(r.MacAddress)] = true
+	}
+
+	next := start
+	for _, dev := range devices {
+		mac := strings.ToLower(dev.MacAddress)
+		if mac == "" || reserved[mac] {
+			continue
+		}
+		reserved[mac] = true
+		for next <= end && used[next] {
+			next++
+		}
+		if next > end {
+			return fmt.Errorf("DHCP range of %s exhausted while reserving %s", sp.Domain, dev.Name)
+		}
+		sp.Reservations = append(sp.Reservations, Reservation{
+			Name:       dev.Name,
+			MacAddress: dev.MacAddress,
+			IPAddress:  uint32ToIP(next).String(),
+		})
+		used[next] = true
+	}
+	return nil
+}
+
+// Contains 判斷地址是否在規劃的子網路內
+func (sp *SubnetPlan) Contains(address string) bool {
+	_, network, err := net.ParseCIDR(sp.Network)
+	ip := net.ParseIP(address)
+	if err != nil || ip == nil {
+		return false
+	}
+	return network.Contains(ip)
+}
+
+// SaveAddressPlan 匯出位址規劃 (JSON)
+func SaveAddressPlan(plan *AddressPlan, path string) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode address plan: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write address plan: %v", err)
+	}
+	return nil
+}
+
+// LoadAddressPlan 載入已接受的位址規劃
+func LoadAddressPlan(path string) (*AddressPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read address plan: %v", err)
+	}
+	var plan AddressPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse address plan: %v", err)
+	}
+	return &plan, nil
+}
+
+// WriteDnsmasqConfig 由位址規劃產生 DHCP (dnsmasq) 設定
+// interfaces 將網域名稱對應到實際網路介面
+func WriteDnsmasqConfig(plan *AddressPlan, interfaces map[string]string, path string) error {
+	if err := os.WriteFile(path, []byte(dnsmasqConfig(plan, interfaces)), 0644); err != nil {
+		return fmt.Errorf("failed to write dnsmasq config: %v", err)
+	}
+	return nil
+}
+
+// dnsmasqConfig dnsmasq 設定內容
+func dnsmasqConfig(plan *AddressPlan, interfaces map[string]string) string {
+	var b strings.Builder
+	b.WriteString("# Generated by GOlane address planner\n")
+	for _, sp := range plan.Subnets {
+		fmt.Fprintf(&b, "\n# %s: %s (%d devices planned)\n", sp.Domain, sp.Network, sp.DeviceCount)
+		if iface, ok := interfaces[sp.Domain]; ok {
+			fmt.Fprintf(&b, "interface=%s\n", iface)
+		}
+		fmt.Fprintf(&b, "dhcp-range=%s,%s,%s,12h\n", sp.DHCPStart, sp.DHCPEnd, sp.NetMask)
+		for _, r := range sp.Reservations {
+			fmt.Fprintf(&b, "dhcp-host=%s,%s,%s\n", r.MacAddress, r.IPAddress, r.Name)
+		}
+	}
+	return b.String()
+}
+
+// DHCPSeeder 依各網域發現的設備更新共用的 dnsmasq 設定
+// 所有網域的 worker 共用同一份規劃與設定檔，保留地址與寫檔都在鎖內進行，
+// 設定檔包含每個網域的介面，內容沒有變更時不重寫
+type DHCPSeeder struct {
+	mu         sync.Mutex
+	plan       *AddressPlan
+	path       string
+	interfaces map[string]string // 網域 → 介面
+	written    string            // 最後寫入的內容
+}
+
+// NewDHCPSeeder 建立 DHCP 設定產生器，interfaces 為各網域設定的介面
+func NewDHCPSeeder(plan *AddressPlan, path string, interfaces map[string]string) *DHCPSeeder {
+	return &DHCPSeeder{plan: plan, path: path, interfaces: maps.Clone(interfaces)}
+}
+
+// Update 記錄網域目前的介面並為設備保留地址，設定有變更時重寫設定檔 (written 為 true)
+func (s *DHCPSeeder) Update(domain, iface string, devices []dante.Device) (written bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.interfaces == nil {
+		s.interfaces = make(map[string]string)
+	}
+	s.interfaces[domain] = iface
+	var reserveErr error
+	if sp := s.plan.SubnetFor(domain); sp != nil {
+		reserveErr = sp.ReserveDevices(devices)
+	}
+
+	config := dnsmasqConfig(s.plan, s.interfaces)
+	if config == s.written {
+		return false, reserveErr
+	}
+	if err := os.WriteFile(s.path, []byte(config), 0644); err != nil {
+		return false, fmt.Errorf("failed to write dnsmasq config: %v", err)
+	}
+	s.written = config
+	return true, reserveErr
+}
+
+// PrintAddressPlan 顯示位址規劃
+func PrintAddressPlan(plan *AddressPlan) {
+	fmt.Println("📐 Address Plan:")
+	fmt.Println("════════════════════════════════════════════════════════════════")
+	fmt.Printf("Base network: %s\n", plan.BaseNetwork)
+	for _, sp := range plan.Subnets {
+		fmt.Printf("\n  %s → %s (netmask %s)\n", sp.Domain, sp.Network, sp.NetMask)
+		fmt.Printf("    Devices:    %d planned / %d capacity\n", sp.DeviceCount, sp.Capacity)
+		fmt.Printf("    Controller: %s\n", sp.ControllerIP)
+		fmt.Printf("    Static:     %s - %s\n", sp.StaticStart, sp.StaticEnd)
+		fmt.Printf("    DHCP pool:  %s - %s\n", sp.DHCPStart, sp.DHCPEnd)
+		for _, r := range sp.Reservations {
+			fmt.Printf("    Reserved:   %-15s %s (%s)\n", r.IPAddress, r.Name, r.MacAddress)
+		}
+	}
+	fmt.Println("════════════════════════════════════════════════════════════════")
+	fmt.Println()
+}
+
+// validateAgainstPlan 依位址規劃驗證網域介面與設備地址
+func validateAgainstPlan(d *dante.Domain, plan *AddressPlan) []string {
+	return planProblems(plan, d.Name, d.NetworkConfig.InterfaceName, d.NetworkConfig.IPAddress, d.GetDevices())
+}
+
+// planProblems 檢查網域介面與設備是否符合位址規劃 (本機或遠端 daemon 的設備)
+func planProblems(plan *AddressPlan, domain, iface, ip string, devices []dante.Device) []string {
+	sp := plan.SubnetFor(domain)
+	if sp == nil {
+		return []string{fmt.Sprintf("domain %s is not part of the address plan", domain)}
+	}
+
+	var problems []string
+	if !sp.Contains(ip) {
+		problems = append(problems, fmt.Sprintf("interface %s (%s) is outside planned subnet %s",
+			iface, ip, sp.Network))
+	}
+	for _, dev := range devices {
+		if !sp.Contains(dev.IPAddress) {
+			problems = append(problems, fmt.Sprintf("device %s (%s) is outside planned subnet %s",
+				dev.Name, dev.IPAddress, sp.Network))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestPrefixForHosts(t *testing.T) {
+	for _, tt := range []struct{ hosts, prefix int }{
+		{1, 30},
+		{2, 30},
+		{3, 29},
+		{14, 28},
+		{15, 27},
+		{254, 24},
+		{255, 23},
+		{65534, 16},
+		{65535, 15},
+	} {
+		if got := prefixForHosts(tt.hosts); got != tt.prefix {
+			t.Errorf("prefixForHosts(%d) = /%d, want /%d", tt.hosts, got, tt.prefix)
+		}
+	}
+}
+
+func TestGenerateAddressPlan(t *testing.T) {
+	opts := PlanOptions{BaseNetwork: "10.10.0.0/24", StaticHosts: 10, MinPrefixLen: 16, MaxPrefixLen: 28}
+	// 3 台設備：1 + 10 + 3 = 14 個主機 → /28；100 台 → 111 → /25，對齊到 .128
+	plan, err := GenerateAddressPlan([]DomainRequirement{{"Dante1", 3}, {"Dante2", 100}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SubnetPlan{
+		{Domain: "Dante1", Network: "10.10.0.0/28", NetMask: "255.255.255.240", DeviceCount: 3, Capacity: 14,
+			ControllerIP: "10.10.0.1", StaticStart: "10.10.0.2", StaticEnd: "10.10.0.11", DHCPStart: "10.10.0.12", DHCPEnd: "10.10.0.14"},
+		{Domain: "Dante2", Network: "10.10.0.128/25", NetMask: "255.255.255.128", DeviceCount: 100, Capacity: 126,
+			ControllerIP: "10.10.0.129", StaticStart: "10.10.0.130", StaticEnd: "10.10.0.139", DHCPStart: "10.10.0.140", DHCPEnd: "10.10.0.254"},
+	}
+	if len(plan.Subnets) != len(want) {
+		t.Fatalf("subnets = %+v", plan.Subnets)
+	}
+	for i := range want {
+		got := plan.Subnets[i]
+		got.Reservations = nil
+		if got.Domain != want[i].Domain || got.Network != want[i].Network || got.NetMask != want[i].NetMask ||
+			got.Capacity != want[i].Capacity || got.ControllerIP != want[i].ControllerIP ||
+			got.StaticStart != want[i].StaticStart || got.StaticEnd != want[i].StaticEnd ||
+			got.DHCPStart != want[i].DHCPStart || got.DHCPEnd != want[i].DHCPEnd {
+			t.Errorf("subnet %d = %+v\nwant %+v", i, got, want[i])
+		}
+	}
+
+	// 沒有固定保留時只有控制端，小網段以 MaxPrefixLen 為下限
+	small, err := GenerateAddressPlan([]DomainRequirement{{"Dante1", 0}}, PlanOptions{BaseNetwork: "10.10.0.0/24", MinPrefixLen: 16, MaxPrefixLen: 28})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp := small.Subnets[0]; sp.Network != "10.10.0.0/28" || sp.StaticEnd != "10.10.0.1" || sp.DHCPStart != "10.10.0.2" {
+		t.Errorf("small subnet = %+v", sp)
+	}
+
+	// 母網段的邊界：/0 (2^32 個地址) 與地址空間的最後一段
+	for _, tt := range []struct{ base, first, second string }{
+		{"0.0.0.0/0", "0.0.0.0/28", "0.0.0.128/25"},
+		{"255.255.255.0/24", "255.255.255.0/28", "255.255.255.128/25"},
+	} {
+		edge, err := GenerateAddressPlan([]DomainRequirement{{"Dante1", 3}, {"Dante2", 100}}, PlanOptions{BaseNetwork: tt.base, StaticHosts: 10, MaxPrefixLen: 28})
+		if err != nil {
+			t.Errorf("%s: %v", tt.base, err)
+			continue
+		}
+		if edge.Subnets[0].Network != tt.first || edge.Subnets[1].Network != tt.second {
+			t.Errorf("%s: subnets = %s, %s", tt.base, edge.Subnets[0].Network, edge.Subnets[1].Network)
+		}
+	}
+}
+
+func TestGenerateAddressPlanErrors(t *testing.T) {
+	opts := PlanOptions{BaseNetwork: "10.10.0.0/24", StaticHosts: 10, MinPrefixLen: 16, MaxPrefixLen: 28}
+	for _, tt := range []struct {
+		name string
+		reqs []DomainRequirement
+		opts PlanOptions
+		err  string
+	}{
+		{"exhausted", []DomainRequirement{{"Dante1", 3}, {"Dante2", 100}, {"Dante3", 1}}, opts, "base network 10.10.0.0/24 exhausted while planning domain Dante3"},
+		{"larger than base", []DomainRequirement{{"Dante1", 250}}, opts, "domain Dante1 needs 261 hosts"},
+		{"larger than MinPrefixLen", []DomainRequirement{{"Dante1", 300}}, PlanOptions{BaseNetwork: "10.10.0.0/16", MinPrefixLen: 24, MaxPrefixLen: 28}, "larger than allowed subnet size"},
+		{"IPv6 base", []DomainRequirement{{"Dante1", 3}}, PlanOptions{BaseNetwork: "fd00::/64"}, "invalid IPv4 base network"},
+		{"end of address space", []DomainRequirement{{"Dante1", 3}, {"Dante2", 100}, {"Dante3", 1}}, PlanOptions{BaseNetwork: "255.255.255.0/24", StaticHosts: 10, MaxPrefixLen: 28}, "base network 255.255.255.0/24 exhausted while planning domain Dante3"},
+		{"negative headroom", []DomainRequirement{{"Dante1", 3}}, PlanOptions{BaseNetwork: "10.10.0.0/24", Headroom: -0.5, MaxPrefixLen: 28}, "invalid headroom -0.5"},
+		{"negative static hosts", []DomainRequirement{{"Dante1", 3}}, PlanOptions{BaseNetwork: "10.10.0.0/24", StaticHosts: -1, MaxPrefixLen: 28}, "invalid static host count -1"},
+	} {
+		if _, err := GenerateAddressPlan(tt.reqs, tt.opts); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestReserveDevices(t *testing.T) {
+	sp := &SubnetPlan{Domain: "Dante1", DHCPStart: "10.10.0.12", DHCPEnd: "10.10.0.15",
+		Reservations: []Reservation{{Name: "mixer", MacAddress: "00:1d:c1:00:00:01", IPAddress: "10.10.0.12"}}}
+	devices := []dante.Device{
+		{Name: "mixer", MacAddress: "00:1D:C1:00:00:01"}, // 已保留，不重複分配
+		{Name: "amp-1", MacAddress: "00:1d:c1:00:00:02"},
+		{Name: "no-mac"},
+		{Name: "amp-2", MacAddress: "00:1d:c1:00:00:03"},
+	}
+	if err := sp.ReserveDevices(devices); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, r := range sp.Reservations {
+		got[r.Name] = r.IPAddress
+	}
+	if len(sp.Reservations) != 3 || got["mixer"] != "10.10.0.12" || got["amp-1"] != "10.10.0.13" || got["amp-2"] != "10.10.0.14" {
+		t.Errorf("reservations = %+v", sp.Reservations)
+	}
+
+	// 再執行一次不改變保留
+	if err := sp.ReserveDevices(devices); err != nil || len(sp.Reservations) != 3 {
+		t.Errorf("second run: %v, %+v", err, sp.Reservations)
+	}
+
+	// 範圍只剩 .15
+	more := []dante.Device{{Name: "amp-3", MacAddress: "00:1d:c1:00:00:04"}, {Name: "amp-4", MacAddress: "00:1d:c1:00:00:05"}}
+	if err := sp.ReserveDevices(more); err == nil || !strings.Contains(err.Error(), "DHCP range of Dante1 exhausted while reserving amp-4") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestDHCPSeeder(t *testing.T) {
+	plan, err := GenerateAddressPlan([]DomainRequirement{{"Dante1", 10}, {"Dante2", 10}}, DefaultPlanOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "dnsmasq.conf")
+	seeder := NewDHCPSeeder(plan, path, map[string]string{"Dante1": "eth1", "Dante2": "eth2"})
+
+	// 兩個網域的 worker 同時更新 (以 -race 檢查)
+	var wg sync.WaitGroup
+	for i, domain := range []string{"Dante1", "Dante2"} {
+		devices := []dante.Device{{Name: domain + "-amp", MacAddress: fmt.Sprintf("00:1d:c1:00:00:%02x", i+1)}}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := seeder.Update(domain, "eth"+domain[len(domain)-1:], devices); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := string(data)
+	for _, want := range []string{"interface=eth1", "interface=eth2", "Dante1-amp", "Dante2-amp"} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config missing %q:\n%s", want, config)
+		}
+	}
+
+	// 沒有變更時不重寫
+	same := []dante.Device{{Name: "Dante1-amp", MacAddress: "00:1d:c1:00:00:01"}}
+	if written, err := seeder.Update("Dante1", "eth1", same); written || err != nil {
+		t.Errorf("unchanged update: written %v, %v", written, err)
+	}
+	// 介面變更或新設備時重寫
+	if written, _ := seeder.Update("Dante1", "eth3", same); !written {
+		t.Error("interface change not written")
+	}
+	more := append(same, dante.Device{Name: "Dante1-mixer", MacAddress: "00:1d:c1:00:00:10"})
+	if written, _ := seeder.Update("Dante1", "eth3", more); !written {
+		t.Error("new reservation not written")
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "interface=eth2") || !strings.Contains(string(data), "Dante1-mixer") {
+		t.Errorf("config after updates:\n%s", data)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/pprof"
+)
+
+//==============================================================================
+// 執行中的效能分析 (net/http/pprof)
+//==============================================================================
+
+// 長時間運作的主機上事件循環的 CPU 尖峰與記憶體成長，只有在現場才量得到。
+// pprof 路由跟管理 API 在同一個位址 (管理網路)，需要 admin 權杖，並由 pprof
+// 功能開關控制 (預設關閉，需要時以 PUT /api/features/pprof 開啟)：
+//
+//	go tool pprof -http=: 'http://10.0.0.5:8080/debug/pprof/profile?seconds=30'
+//
+// go tool pprof 不送權杖時先以 curl -H "Authorization: Bearer ..." 下載檔案。
+// 沒有設定權杖時 API 不驗證 (所有人都是 admin)，這時 pprof 路由回應 404：
+// profile 會洩漏命令列與記憶體內容，不能在管理網路上公開。發行的權杖在執行中
+// 可能全部撤銷，所以每個請求都重新檢查，而不是只在啟動時決定是否註冊。
+// 匯入 net/http/pprof 也會註冊到 http.DefaultServeMux，這裡沒有任何伺服器使用它。
+
+// registerPprof 註冊 /debug/pprof/ 路由 (沒有 admin 權杖時回應 404)
+func (s *APIServer) registerPprof() {
+	if !s.tokens.HasRole(RoleAdmin) && s.features.Enabled(FeaturePprof) {
+		logger.Warn("Profiling routes unavailable until an admin API token is configured")
+	}
+	profile := func(handler http.HandlerFunc) http.HandlerFunc {
+		return s.requireFeature(FeaturePprof, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.tokens.HasRole(RoleAdmin) {
+				writeError(w, http.StatusNotFound, errors.New("profiling needs an admin API token"))
+				return
+			}
+			handler(w, r)
+		}))
+	}
+	// Index 也依路徑提供 heap、goroutine、allocs 等具名 profile
+	s.handleRole("GET /debug/pprof/", RoleAdmin, profile(pprof.Index))
+	s.handleRole("GET /debug/pprof/cmdline", RoleAdmin, profile(pprof.Cmdline))
+	s.handleRole("GET /debug/pprof/profile", RoleAdmin, profile(pprof.Profile))
+	s.handleRole("GET /debug/pprof/symbol", RoleAdmin, profile(pprof.Symbol))
+	s.handleRole("POST /debug/pprof/symbol", RoleAdmin, profile(pprof.Symbol))
+	s.handleRole("GET /debug/pprof/trace", RoleAdmin, profile(pprof.Trace))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPprofRequiresAdminAndFeature(t *testing.T) {
+	tokens, err := NewTokenStore("", "", []ConfiguredToken{
+		{Name: "ops", Token: "admin-token", Role: RoleAdmin},
+		{Name: "console", Token: "operator-token", Role: RoleOperator},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := DefaultFeatureFlags()
+	server := httptest.NewServer(NewAPIServer(APIConfig{Tokens: tokens, Features: features}).mux)
+	defer server.Close()
+
+	get := func(path, token string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, _ := get("/debug/pprof/", ""); status != http.StatusUnauthorized {
+		t.Fatalf("without token: status %d, want 401", status)
+	}
+	if status, _ := get("/debug/pprof/", "operator-token"); status != http.StatusForbidden {
+		t.Fatalf("operator: status %d, want 403", status)
+	}
+	// 預設關閉
+	if status, _ := get("/debug/pprof/", "admin-token"); status != http.StatusNotFound {
+		t.Fatalf("feature disabled: status %d, want 404", status)
+	}
+
+	if err := features.SetRuntime(FeaturePprof, true); err != nil {
+		t.Fatal(err)
+	}
+	if status, body := get("/debug/pprof/", "admin-token"); status != http.StatusOK || !strings.Contains(body, "goroutine") {
+		t.Fatalf("index: status %d", status)
+	}
+	if status, body := get("/debug/pprof/goroutine?debug=1", "admin-token"); status != http.StatusOK || !strings.Contains(body, "goroutine profile") {
+		t.Fatalf("goroutine profile: status %d, %.100s", status, body)
+	}
+}
+
+func TestPprofUnavailableWithoutAdminToken(t *testing.T) {
+	features := DefaultFeatureFlags()
+	if err := features.SetRuntime(FeaturePprof, true); err != nil {
+		t.Fatal(err)
+	}
+	operatorOnly, err := NewTokenStore("", "", []ConfiguredToken{{Name: "console", Token: "operator-token", Role: RoleOperator}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 沒有權杖時所有人都是 admin，有權杖但沒有 admin 時沒有人能使用
+	for name, tc := range map[string]struct {
+		tokens *TokenStore
+		want   int
+	}{
+		"no tokens":     {nil, http.StatusNotFound},
+		"operator only": {operatorOnly, http.StatusForbidden},
+	} {
+		server := httptest.NewServer(NewAPIServer(APIConfig{Tokens: tc.tokens, Features: features}).mux)
+		status := pprofStatus(t, server.URL, "operator-token")
+		server.Close()
+		if status != tc.want {
+			t.Errorf("%s: status %d, want %d", name, status, tc.want)
+		}
+	}
+}
+
+func TestPprofClosedAfterTokensRevoked(t *testing.T) {
+	features := DefaultFeatureFlags()
+	if err := features.SetRuntime(FeaturePprof, true); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	tokens, err := NewTokenStore(dir, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := IssueToken(dir, "ops", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewAPIServer(APIConfig{Tokens: tokens, Features: features}).mux)
+	defer server.Close()
+
+	if status := pprofStatus(t, server.URL, token); status != http.StatusOK {
+		t.Fatalf("admin: status %d, want 200", status)
+	}
+	// 執行中撤銷最後一個權杖後 API 不再驗證，pprof 必須跟著關閉
+	if err := RevokeToken(dir, "ops"); err != nil {
+		t.Fatal(err)
+	}
+	if status := pprofStatus(t, server.URL, ""); status != http.StatusNotFound {
+		t.Errorf("after revoke: status %d, want 404", status)
+	}
+}
+
+// pprofStatus 以 token 讀取 /debug/pprof/cmdline 的狀態碼
+func pprofStatus(t *testing.T, url, token string) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url+"/debug/pprof/cmdline", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
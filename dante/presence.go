@@ -0,0 +1,73 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// 設備上下線追蹤
+//==============================================================================
+
+// 設備事件種類
+const (
+	DeviceOnline  = "online"
+	DeviceOffline = "offline"
+)
+
+// DeviceEvent 設備上線/離線事件
+type DeviceEvent struct {
+	Kind   string       // DeviceOnline 或 DeviceOffline
+	Domain string       // 網域名稱
+	Device dante.Device // 設備資訊 (離線時為最後一次看到的資訊)
+	Time   time.Time    // 偵測時間
+}
+
+// PresenceTracker 比對每次刷新的設備列表，產生上下線事件
+type PresenceTracker struct {
+	known    map[string]dante.Device // 以小寫設備名稱為 key
+	baseline bool                    // 是否已建立基準
+}
+
+// NewPresenceTracker 建立追蹤器
+func NewPresenceTracker() *PresenceTracker {
+	return &PresenceTracker{known: make(map[string]dante.Device)}
+}
+
+// Update 以最新的設備列表更新狀態
+// 第一次呼叫只建立基準，不產生事件
+func (t *PresenceTracker) Update(domain string, devices []dante.Device) []DeviceEvent {
+	now := time.Now()
+	current := make(map[string]dante.Device, len(devices))
+	for _, dev := range devices {
+		current[strings.ToLower(dev.Name)] = dev
+	}
+
+	var events []DeviceEvent
+	if t.baseline {
+		for key, dev := range current {
+			if _, ok := t.known[key]; !ok {
+				events = append(events, DeviceEvent{Kind: DeviceOnline, Domain: domain, Device: dev, Time: now})
+			}
+		}
+		for key, dev := range t.known {
+			if _, ok := current[key]; !ok {
+				events = append(events, DeviceEvent{Kind: DeviceOffline, Domain: domain, Device: dev, Time: now})
+			}
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Kind != events[j].Kind {
+			return events[i].Kind < events[j].Kind
+		}
+		return events[i].Device.Name < events[j].Device.Name
+	})
+
+	t.known = current
+	t.baseline = true
+	return events
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// Preset 儲存與載入
+//==============================================================================
+
+// 換場或更換設備後要回到先前的路由：preset save 讀取網域中每台設備的接收
+// 通道，把完整的訂閱矩陣存成 JSON 檔 (未訂閱的通道記錄為取消訂閱，載入時
+// 會清除之後才加上的訂閱)；preset load 先以觸發輸入相同的驗證確認設備與
+// 接收通道都存在，沒有問題才套用。檔案格式與設定檔的 presets 項目相同，
+// 可以直接加到 monitor 設定檔給觸發輸入使用。
+
+// CapturePreset 讀取所有設備的接收通道，建立完整訂閱矩陣的 preset
+// 任何一台設備讀取失敗都回傳錯誤 (不儲存不完整的矩陣)；
+// 訂閱不指定網域，檔案可以載入到其他只有一個網域的主機
+func CapturePreset(ctx context.Context, name string, devices []dante.Device, rc RouteController) (Preset, error) {
+	if name == "" {
+		return Preset{}, errors.New("preset without name")
+	}
+	devices = append([]dante.Device{}, devices...)
+	dante.SortDevices(devices, dante.DeviceOrder{Key: dante.SortByName})
+
+	p := Preset{Name: name, Routes: []PresetRoute{}}
+	for _, dev := range devices {
+		subs, err := rc.ListSubscriptions(ctx, dev.Name)
+		if err != nil {
+			return Preset{}, fmt.Errorf("cannot read RX channels of %s: %w", dev.Name, err)
+		}
+		for _, sub := range subs {
+			r := PresetRoute{RxDevice: dev.Name, RxChannel: sub.Channel}
+			if sub.Subscribed() {
+				r.TxDevice, r.TxChannel = sub.TxDevice, sub.TxChannel
+			}
+			p.Routes = append(p.Routes, r)
+		}
+	}
+	return p, nil
+}
+
+// SavePresetFile 寫入 preset 檔
+func SavePresetFile(path string, p Preset) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadPresetFile 讀取 preset 檔
+func LoadPresetFile(path string) (Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Preset{}, err
+	}
+	var p Preset
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Preset{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// newPresetEngine 建立只包含單一 preset 的觸發引擎，用來驗證與套用 preset 檔
+// (欄位檢查與 NewTriggerEngine 相同；domain 是 rc 的網域名稱)
+func newPresetEngine(p Preset, domain string, rc RouteController) (*TriggerEngine, error) {
+	return NewTriggerEngine(TriggerConfig{}, []Preset{p}, map[string]RouteController{domain: rc}, nil, nil)
+}
+
+// inventoryRoutes 附帶設備列表的路由控制：遠端 monitor 的訂閱 API 無法列出
+// 設備，驗證 preset 時以 GET /api/devices 的結果檢查設備是否在線上
+// (以指標傳遞：驗證時路由控制是 map 的 key)
+type inventoryRoutes struct {
+	RouteController
+	devices []dante.Device
+}
+
+func (r *inventoryRoutes) GetDevices() []dante.Device {
+	return r.devices
+}
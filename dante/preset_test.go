@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestPresetSaveAndLoad(t *testing.T) {
+	ctx := context.Background()
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(ctx)
+
+	if err := d.Subscribe(ctx, "Amp-Left", "01", "FOH-Console", "01"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := CapturePreset(ctx, "Show", d.GetDevices(), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "show.json")
+	if err := SavePresetFile(path, p); err != nil {
+		t.Fatal(err)
+	}
+
+	// 之後的變更在載入時還原 (新加的訂閱被清除)
+	if err := d.Subscribe(ctx, "Amp-Left", "01", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Subscribe(ctx, "Amp-Left", "02", "FOH-Console", "02"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPresetFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "Show" || len(loaded.Routes) != len(p.Routes) {
+		t.Fatalf("loaded preset = %+v", loaded)
+	}
+	engine, err := newPresetEngine(loaded, d.Name, &inventoryRoutes{d, d.GetDevices()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := engine.Recall(ctx, loaded.Name, "cli", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Applied != len(loaded.Routes) || len(ev.Errors) != 0 {
+		t.Fatalf("recall = %+v", ev)
+	}
+	subs, err := d.ListSubscriptions(ctx, "Amp-Left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs[0].TxDevice != "FOH-Console" || subs[0].TxChannel != "01" || subs[1].Subscribed() {
+		t.Fatalf("Amp-Left after load = %+v", subs[:2])
+	}
+
+	// 設備不在網路上時整個 preset 不套用
+	engine, err = newPresetEngine(loaded, d.Name, &inventoryRoutes{d, d.GetDevices()[1:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Recall(ctx, loaded.Name, "cli", false); !errors.Is(err, errPresetNotReady) {
+		t.Fatalf("recall with a missing device: %v", err)
+	}
+}
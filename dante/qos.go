@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/qos"
+)
+
+//==============================================================================
+// QoS (DSCP) 檢查
+//==============================================================================
+
+// 交換器沒有信任 DSCP 時，PTP 與音訊的標記在途中被清除，平常聽不出來，
+// 網路一忙才掉音。golane diag qos 與 /api/qos 在 Dante 介面上取樣一段時間，
+// 依來源設備列出收到的標記，並指出哪些路徑上的交換器清除或改寫了標記。
+
+// 取樣時間
+const (
+	defaultQoSDuration = 10 * time.Second
+	maxQoSDuration     = 20 * time.Second // API 請求要在 remoteTimeout 內完成
+)
+
+// sampleQoS 在各介面取樣 duration，回傳各介面的檢查結果 (lists 為網域 → 設備列表，用來標示來源設備)
+// 介面無法取樣 (沒有 CAP_NET_RAW) 時該介面的結果標示為未取樣
+func sampleQoS(ctx context.Context, ifaces []string, duration time.Duration, groups []netip.Addr, lists func() map[string][]dante.Device) ([]qos.Report, error) {
+	sampleCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	// 每個介面各自的 Sampler，無法取樣的介面不會沿用其他介面的取樣時間
+	samplers := make([]*qos.Sampler, len(ifaces))
+	var wg sync.WaitGroup
+	for i, iface := range ifaces {
+		samplers[i] = qos.NewSampler()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := samplers[i].Sample(sampleCtx, iface, groups); err != nil {
+				logger.Warn("QoS sampling unavailable", "iface", iface, "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	devices := make(map[string]string)
+	for _, list := range lists() {
+		for _, dev := range list {
+			for _, ip := range []string{dev.IPAddress, dev.SecondaryIP} {
+				if ip != "" {
+					devices[ip] = dev.Name
+				}
+			}
+		}
+	}
+	reports := make([]qos.Report, 0, len(ifaces))
+	for i, iface := range ifaces {
+		reports = append(reports, qos.Verify(iface, samplers[i].Flows(), samplers[i].Sampled(), devices))
+	}
+	return reports, nil
+}
+
+// parseGroups 解析以逗號分隔的 multicast 群組
+func parseGroups(s string) ([]netip.Addr, error) {
+	var groups []netip.Addr
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(field)
+		if err != nil || !addr.Is4() || !addr.IsMulticast() {
+			return nil, fmt.Errorf("invalid multicast group %q", field)
+		}
+		groups = append(groups, addr)
+	}
+	return groups, nil
+}
+
+// printQoSReports 顯示各介面收到的標記與問題
+func printQoSReports(reports []qos.Report) {
+	for _, r := range reports {
+		fmt.Print(i18n.Sprintf("\n=== %s DSCP (sampled %s) ===\n", r.Interface, r.Sampled.Round(time.Second)))
+		if len(r.Flows) > 0 {
+			printHeader("%-12s %-16s %-20s %-9s %-9s %-16s %s\n", "CLASS", "SOURCE", "DEVICE", "EXPECTED", "PACKETS", "RECEIVED", "VERDICT")
+			fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────────")
+		}
+		for _, f := range r.Flows {
+			device := f.Device
+			if device == "" {
+				device = "-"
+			}
+			var received []string
+			for name, n := range f.DSCP {
+				received = append(received, fmt.Sprintf("%s×%d", name, n))
+			}
+			slices.Sort(received)
+			fmt.Printf("%-12s %-16s %-20s %-9s %-9d %-16s %s\n", f.Class, f.Source, device, f.Expected, f.Packets,
+				strings.Join(received, " "), f.Verdict)
+		}
+		for _, p := range r.Problems {
+			fmt.Printf("  ! %s\n", p)
+		}
+		for _, n := range r.Notes {
+			fmt.Printf("  - %s\n", n)
+		}
+	}
+	fmt.Println()
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// handleQoS GET /api/qos[?duration=&group=]
+func (s *APIServer) handleQoS(w http.ResponseWriter, r *http.Request) {
+	duration := defaultQoSDuration
+	if v := r.URL.Query().Get("duration"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxQoSDuration {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid duration %q, use up to %s", v, maxQoSDuration))
+			return
+		}
+		duration = d
+	}
+	groups, err := parseGroups(r.URL.Query().Get("group"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	reports, err := sampleQoS(r.Context(), danteInterfaceNames(s.detector), duration, groups, func() map[string][]dante.Device {
+		lists := make(map[string][]dante.Device)
+		for _, d := range s.snapshots() {
+			lists[d.Name] = d.Devices
+		}
+		return lists
+	})
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// QoSReports daemon 取樣的 DSCP 檢查
+func (c *RemoteClient) QoSReports(duration time.Duration, groups string) ([]qos.Report, error) {
+	q := url.Values{}
+	q.Set("duration", duration.String())
+	if groups != "" {
+		q.Set("group", groups)
+	}
+	var reports []qos.Report
+	return reports, c.do(http.MethodGet, "/api/qos?"+q.Encode(), nil, &reports)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newQoSCommand golane diag qos
+func newQoSCommand() *Command {
+	fs := newFlagSet("qos")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	duration := fs.Duration("duration", defaultQoSDuration, "how long to sample packets")
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery (names the sources)")
+	groups := fs.String("group", "", "comma-separated multicast flows to join while sampling, e.g. 239.255.1.10")
+	jsonOut := fs.Bool("json", false, "print the verification as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "qos",
+		Short: "Sample PTP and audio packets and verify their DSCP markings survive the switches",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			joined, err := parseGroups(*groups)
+			if err != nil {
+				return err
+			}
+
+			var reports []qos.Report
+			if remote.enabled() {
+				if *duration > maxQoSDuration {
+					return fmt.Errorf("-duration %s exceeds %s in remote mode", *duration, maxQoSDuration)
+				}
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				if reports, err = client.QoSReports(*duration, *groups); err != nil {
+					return err
+				}
+			} else {
+				if err := ifaces.checkTiming(*wait); err != nil {
+					return err
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				names := danteInterfaceNames(detector)
+				if len(names) == 0 {
+					return fmt.Errorf("Dante interface not found (expected one of %v)", detector.DanteInterfaceNames)
+				}
+				ctx, cancel := commandContext()
+				defer cancel()
+
+				// 取樣的同時發現設備，用來標示來源；SDK 無法使用時只列出地址
+				var devices []dante.Device
+				var discovered sync.WaitGroup
+				discovered.Add(1)
+				defer discovered.Wait()
+				go func() {
+					defer discovered.Done()
+					domain, err := ifaces.openPrimaryDomain(ctx, detector)
+					if err != nil {
+						logger.Warn("Device discovery unavailable, listing addresses only", "err", err)
+						return
+					}
+					defer domain.Cleanup()
+					if discover(ctx, domain, *wait) == nil {
+						devices = domain.GetDevices()
+					}
+				}()
+				reports, err = sampleQoS(ctx, names, *duration, joined, func() map[string][]dante.Device {
+					discovered.Wait()
+					return map[string][]dante.Device{"Dante1": devices}
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if *jsonOut {
+				return printJSON(reports)
+			}
+			printQoSReports(reports)
+			for _, r := range reports {
+				if len(r.Problems) > 0 {
+					fmt.Fprintln(os.Stderr, "WARNING: DSCP markings are not preserved, see the problems above")
+					break
+				}
+			}
+			return nil
+		},
+	}
+}
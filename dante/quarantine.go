@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//==============================================================================
+// 設備隔離 (quarantine)
+//==============================================================================
+
+// 懷疑故障或來路不明的設備可以標記為隔離：API 與 Web UI 把它標示出來、
+// 訂閱列表標記經過它的路由，新的路由必須明確 override 才會送到 SDK，
+// 避免演出中誤把訊號接到已知有問題的硬體。取消訂閱不受限制。
+
+// quarantineSection 隔離列表在狀態檔中的 section 名稱
+const quarantineSection = "quarantine"
+
+// errQuarantined 路由涉及隔離中的設備
+var errQuarantined = errors.New("device is quarantined")
+
+// errNotQuarantined 設備不在隔離列表中
+var errNotQuarantined = errors.New("device is not quarantined")
+
+// QuarantineEntry 隔離中的設備
+type QuarantineEntry struct {
+	Device string    `json:"device"`
+	Reason string    `json:"reason,omitempty"`
+	By     string    `json:"by,omitempty"` // 標記的人員
+	Since  time.Time `json:"since"`
+}
+
+// QuarantineStore 隔離列表 (保存在狀態檔)
+type QuarantineStore struct {
+	mu      sync.RWMutex
+	state   *StateStore
+	entries []QuarantineEntry
+}
+
+// NewQuarantineStore 從狀態檔載入隔離列表
+func NewQuarantineStore(state *StateStore) (*QuarantineStore, error) {
+	qs := &QuarantineStore{state: state}
+	if _, err := state.Load(quarantineSection, &qs.entries); err != nil {
+		return nil, err
+	}
+	return qs, nil
+}
+
+// List 隔離中的設備 (依名稱排序)
+func (qs *QuarantineStore) List() []QuarantineEntry {
+	qs.mu.RLock()
+	defer qs.mu.RUnlock()
+	list := append([]QuarantineEntry{}, qs.entries...)
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Device) < strings.ToLower(list[j].Device) })
+	return list
+}
+
+// Get 查詢設備是否隔離中 (名稱不分大小寫)
+func (qs *QuarantineStore) Get(device string) (QuarantineEntry, bool) {
+	if qs == nil || device == "" {
+		return QuarantineEntry{}, false
+	}
+	qs.mu.RLock()
+	defer qs.mu.RUnlock()
+	for _, e := range qs.entries {
+		if strings.EqualFold(e.Device, device) {
+			return e, true
+		}
+	}
+	return QuarantineEntry{}, false
+}
+
+// update 保存修改後的隔離列表
+func (qs *QuarantineStore) update(change func(entries []QuarantineEntry) ([]QuarantineEntry, error)) error {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	next, err := change(append([]QuarantineEntry{}, qs.entries...))
+	if err != nil {
+		return err
+	}
+	if err := qs.state.Save(quarantineSection, next); err != nil {
+		return err
+	}
+	qs.entries = next
+	return nil
+}
+
+// Quarantine 把設備加入隔離列表 (已隔離時更新原因)
+func (qs *QuarantineStore) Quarantine(device, reason, by string) (QuarantineEntry, error) {
+	if strings.TrimSpace(device) == "" {
+		return QuarantineEntry{}, errors.New("device name is required")
+	}
+	entry := QuarantineEntry{Device: device, Reason: reason, By: by, Since: time.Now().UTC()}
+	err := qs.update(func(entries []QuarantineEntry) ([]QuarantineEntry, error) {
+		for i := range entries {
+			if strings.EqualFold(entries[i].Device, device) {
+				entry.Since = entries[i].Since
+				entries[i] = entry
+				return entries, nil
+			}
+		}
+		return append(entries, entry), nil
+	})
+	return entry, err
+}
+
+// Release 解除設備的隔離
+func (qs *QuarantineStore) Release(device string) error {
+	return qs.update(func(entries []QuarantineEntry) ([]QuarantineEntry, error) {
+		for i := range entries {
+			if strings.EqualFold(entries[i].Device, device) {
+				return append(entries[:i], entries[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", errNotQuarantined, device)
+	})
+}
+
+// CheckRoute 路由的接收或發送設備隔離中時回傳 errQuarantined
+func (qs *QuarantineStore) CheckRoute(rxDevice, txDevice string) error {
+	for _, device := range []string{rxDevice, txDevice} {
+		if e, ok := qs.Get(device); ok {
+			if e.Reason != "" {
+				return fmt.Errorf("%w: %s (%s)", errQuarantined, e.Device, e.Reason)
+			}
+			return fmt.Errorf("%w: %s", errQuarantined, e.Device)
+		}
+	}
+	return nil
+}
+
+// quarantineAuditValue 稽核紀錄中隔離狀態的值
+func quarantineAuditValue(e QuarantineEntry) string {
+	if e.Reason == "" {
+		return "quarantined"
+	}
+	return "quarantined: " + e.Reason
+}
+
+// routeOverrideKey context 中的隔離 override 標記
+type routeOverrideKey struct{}
+
+// withRouteOverride 標記這次路由操作明確允許隔離中的設備 (route add -override)
+func withRouteOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeOverrideKey{}, true)
+}
+
+// routeOverride ctx 是否允許隔離中的設備
+func routeOverride(ctx context.Context) bool {
+	override, _ := ctx.Value(routeOverrideKey{}).(bool)
+	return override
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/reach"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 設備可達性
+//==============================================================================
+
+// 發現列表來自 mDNS，關機或拔線的設備要等快取過期才會消失，IP 衝突或
+// 錯誤 VLAN 的設備也照樣列出。reachability 功能在發現與刷新後 (最多每
+// Interval 一次) 從對應的 Dante 介面以 ICMP 或 ARP 檢查每台設備的主要與
+// 次要地址，記錄往返時間，列出但沒有回應的設備產生告警。
+
+// AlertDeviceUnreachable 設備在發現列表上但地址沒有回應
+const AlertDeviceUnreachable = "device-unreachable"
+
+// ReachOptions 可達性檢查參數
+type ReachOptions struct {
+	Method   string        `json:"method"`   // icmp 或 arp
+	Interval time.Duration `json:"interval"` // 兩次檢查的最短間隔
+	Timeout  time.Duration `json:"timeout"`  // 每次檢查等待回應的時間
+}
+
+// DefaultReachOptions ICMP、每分鐘最多一次、等待 2 秒
+func DefaultReachOptions() ReachOptions {
+	return ReachOptions{Method: reach.MethodICMP, Interval: time.Minute, Timeout: reach.DefaultTimeout}
+}
+
+// Validate 檢查參數
+func (o ReachOptions) Validate() error {
+	if _, err := reach.ParseMethod(o.Method); err != nil {
+		return err
+	}
+	if o.Interval < 0 || o.Timeout <= 0 {
+		return fmt.Errorf("invalid reachability interval %s or timeout %s", o.Interval, o.Timeout)
+	}
+	return nil
+}
+
+// DeviceReachability 單一設備的檢查結果
+type DeviceReachability struct {
+	Domain    string        `json:"domain"`
+	Device    string        `json:"device"`
+	Primary   *reach.Result `json:"primary,omitempty"`
+	Secondary *reach.Result `json:"secondary,omitempty"` // 沒有次要地址時為 nil
+	Checked   time.Time     `json:"checked"`
+}
+
+// unanswered 沒有回應的地址 (介面無法檢查的地址不算)
+func (r DeviceReachability) unanswered() []string {
+	var list []string
+	for _, res := range []*reach.Result{r.Primary, r.Secondary} {
+		if res != nil && !res.Reachable && strings.HasPrefix(res.Error, "no ") {
+			list = append(list, fmt.Sprintf("%s on %s", res.Address, res.Interface))
+		}
+	}
+	return list
+}
+
+// reachInterface 地址所在網段的 Dante 介面 (找不到時為 fallback)
+func reachInterface(detector *NetworkDetector, ip, fallback string) string {
+	addr := netip.MustParseAddr(ip)
+	for _, iface := range detector.DanteInterfaces {
+		for _, a := range iface.Addresses {
+			if p := a.Prefix(); p != nil && !a.IsIPv6 && p.Contains(addr.AsSlice()) {
+				return iface.Name
+			}
+		}
+	}
+	return fallback
+}
+
+// CheckReachability 從對應的介面檢查設備的主要與次要地址
+// 主要地址找不到同網段的介面時使用網域的介面，次要地址使用第二個 Dante 介面
+func CheckReachability(ctx context.Context, detector *NetworkDetector, domain, iface string, devices []dante.Device, opts ReachOptions) []DeviceReachability {
+	secondaryIface := ""
+	if len(detector.DanteInterfaces) > 1 {
+		secondaryIface = detector.DanteInterfaces[1].Name
+	}
+
+	type slot struct {
+		device    int
+		secondary bool
+	}
+	targets := make(map[string][]netip.Addr)
+	slots := make(map[string][]slot)
+	results := make([]DeviceReachability, len(devices))
+	now := time.Now().UTC()
+	for i, dev := range devices {
+		results[i] = DeviceReachability{Domain: domain, Device: dev.Name, Checked: now}
+		for _, s := range []slot{{i, false}, {i, true}} {
+			ip, fallback := dev.IPAddress, iface
+			if s.secondary {
+				ip, fallback = dev.SecondaryIP, secondaryIface
+			}
+			addr, err := netip.ParseAddr(ip)
+			if err != nil || !addr.Is4() {
+				continue
+			}
+			name := reachInterface(detector, ip, fallback)
+			if name == "" {
+				results[i].Secondary = &reach.Result{Address: ip, Method: opts.Method, Error: "no Dante interface on the secondary network"}
+				continue
+			}
+			targets[name] = append(targets[name], addr)
+			slots[name] = append(slots[name], s)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, addrs := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list := reach.Probe(ctx, opts.Method, name, addrs, opts.Timeout)
+			mu.Lock()
+			defer mu.Unlock()
+			for j, s := range slots[name] {
+				res := list[j]
+				if s.secondary {
+					results[s.device].Secondary = &res
+				} else {
+					results[s.device].Primary = &res
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// ReachabilityTracker 各網域最後的檢查結果，設備沒有回應時告警、恢復時解除
+type ReachabilityTracker struct {
+	mu      sync.Mutex
+	alerts  *AlertManager
+	results map[string][]DeviceReachability // 網域 → 結果
+	failing map[string]bool                 // 網域|設備 → 已告警
+}
+
+// NewReachabilityTracker 建立追蹤器
+func NewReachabilityTracker(alerts *AlertManager) *ReachabilityTracker {
+	return &ReachabilityTracker{
+		alerts:  alerts,
+		results: make(map[string][]DeviceReachability),
+		failing: make(map[string]bool),
+	}
+}
+
+// Update 記錄網域的檢查結果 (取代上一次的結果)
+func (t *ReachabilityTracker) Update(domain string, results []DeviceReachability) {
+	t.mu.Lock()
+	t.results[domain] = results
+	var raise []Alert
+	var resolve []string
+	checked := make(map[string]bool, len(results))
+	for _, r := range results {
+		key := domain + "|" + r.Device
+		checked[key] = true
+		if failed := r.unanswered(); len(failed) > 0 {
+			if !t.failing[key] {
+				t.failing[key] = true
+				raise = append(raise, Alert{
+					Kind:     AlertDeviceUnreachable,
+					Severity: SeverityWarning,
+					Domain:   domain,
+					Subject:  r.Device,
+					Message:  fmt.Sprintf("device %s is listed by discovery but %s does not answer", r.Device, strings.Join(failed, ", ")),
+					Time:     r.Checked,
+				})
+			}
+		} else if t.failing[key] {
+			delete(t.failing, key)
+			resolve = append(resolve, r.Device)
+		}
+	}
+	// 離開列表的設備由 device-offline 處理
+	for key := range t.failing {
+		if d, device, _ := strings.Cut(key, "|"); d == domain && !checked[key] {
+			delete(t.failing, key)
+			resolve = append(resolve, device)
+		}
+	}
+	t.mu.Unlock()
+
+	if t.alerts == nil {
+		return
+	}
+	for _, a := range raise {
+		t.alerts.Raise(a)
+	}
+	for _, device := range resolve {
+		t.alerts.Resolve(AlertDeviceUnreachable, domain, device)
+	}
+}
+
+// List 所有網域的最新結果 (依網域與設備排序)
+func (t *ReachabilityTracker) List() []DeviceReachability {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := []DeviceReachability{}
+	for _, results := range t.results {
+		list = append(list, results...)
+	}
+	slices.SortFunc(list, func(a, b DeviceReachability) int {
+		if c := strings.Compare(a.Domain, b.Domain); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Device, b.Device)
+	})
+	return list
+}
+
+// printReachability 顯示檢查結果
+func printReachability(list []DeviceReachability) {
+	fmt.Print(i18n.T("\n=== Reachability ===\n"))
+	printHeader("%-10s %-20s %-30s %s\n", "DOMAIN", "DEVICE", "PRIMARY", "SECONDARY")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────")
+	for _, r := range list {
+		fmt.Printf("%-10s %-20s %-30s %s\n", r.Domain, r.Device, reachText(r.Primary), reachText(r.Secondary))
+	}
+	fmt.Println()
+	for _, r := range list {
+		for _, res := range []*reach.Result{r.Primary, r.Secondary} {
+			if res != nil && !res.Reachable {
+				fmt.Printf("  ! %s %s: %s\n", r.Device, res.Address, res.Error)
+			}
+		}
+	}
+}
+
+// reachText 單一地址的結果
+func reachText(res *reach.Result) string {
+	switch {
+	case res == nil:
+		return "-"
+	case res.Reachable:
+		return fmt.Sprintf("%s %s %s", res.Address, res.Method, res.RTT.Round(10*time.Microsecond))
+	}
+	return res.Address + " unreachable"
+}
+
+//------------------------------------------------------------------------------
+// 監控
+//------------------------------------------------------------------------------
+
+// checkReachability 距離上次檢查超過 Interval 時在背景檢查設備 (reachability 功能)
+// 呼叫者持有 w.mu
+func (w *domainWorker) checkReachability(devices []dante.Device) {
+	d := w.domain
+	if w.reach == nil || d.Simulated() || !w.opts.Features.Enabled(FeatureReachability) {
+		return
+	}
+	if w.reachRunning || time.Since(w.reachChecked) < w.opts.Reach.Interval {
+		return
+	}
+	w.reachRunning, w.reachChecked = true, time.Now()
+	devices = slices.Clone(devices)
+	recovery.Go(d.Name+"/reach", func() {
+		defer func() {
+			w.mu.Lock()
+			w.reachRunning = false
+			w.mu.Unlock()
+		}()
+		results := CheckReachability(context.Background(), w.detector, d.Name, d.NetworkConfig.InterfaceName, devices, w.opts.Reach)
+		w.reach.Update(d.Name, results)
+	})
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// handleReachability GET /api/reachability
+func (s *APIServer) handleReachability(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.reach.List())
+}
+
+// Reachability daemon 最後的可達性檢查
+func (c *RemoteClient) Reachability() ([]DeviceReachability, error) {
+	var list []DeviceReachability
+	return list, c.do(http.MethodGet, "/api/reachability", nil, &list)
+}
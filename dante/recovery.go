@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net/http"
+
+	"danteCS/internal/recovery"
+)
+
+// recoverHandler HTTP handler 的 panic 回復 middleware，回傳 500 而非中斷連線
+func recoverHandler(site string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				recovery.Record(site+" "+r.Method+" "+r.URL.Path, rec)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"danteCS/internal/recovery"
+)
+
+func TestRecoverHandlerReturns500(t *testing.T) {
+	handler := recoverHandler("test/api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failure")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/devices", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if recovery.Counts()["test/api GET /api/devices"] == 0 {
+		t.Fatal("handler panic was not recorded")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// 備援網路的重複設備
+//==============================================================================
+
+// 主機同時接在 Dante 的 primary 與 secondary 網路時 (兩個網域各用一張網卡)，
+// 支援備援的設備在兩個網域都會被發現。兩筆記錄是同一台實體設備：名稱相同，
+// 而且 MAC 相同、或其中一筆的主要地址是另一筆的次要地址。
+// 設備列表把它們合併為一筆 (兩個地址、看到它的所有網域)，不重複計算，
+// 也不當成名稱衝突 (見 conflicts.go)。
+
+// samePhysicalDevice a 與 b 是否為同一台設備在兩個網路上的記錄
+func samePhysicalDevice(a, b dante.Device) bool {
+	if !strings.EqualFold(a.Name, b.Name) {
+		return false
+	}
+	if a.MacAddress != "" && b.MacAddress != "" {
+		if strings.EqualFold(a.MacAddress, b.MacAddress) {
+			return true
+		}
+	} else if a.IPAddress != "" && a.IPAddress == b.IPAddress {
+		return true
+	}
+	return crossAddressed(a, b) || crossAddressed(b, a)
+}
+
+// crossAddressed secondary 的主要地址是否為 primary 的次要地址
+func crossAddressed(primary, secondary dante.Device) bool {
+	return primary.SecondaryIP != "" && primary.SecondaryIP == secondary.IPAddress
+}
+
+// domainDevices 一個網域的設備列表
+type domainDevices struct {
+	Domain  string
+	Devices []dante.Device
+}
+
+// LogicalDevice 一台實體設備，在兩個網路都被發現時合併為一筆
+type LogicalDevice struct {
+	Domain  string       // 主要網路所在的網域
+	Device  dante.Device // 合併後的資訊 (包含兩個地址)
+	Domains []string     // 發現這台設備的網域 (依輸入順序)
+}
+
+// mergeRedundantDevices 依輸入順序合併各網域中同一台實體設備的記錄
+func mergeRedundantDevices(lists []domainDevices) []LogicalDevice {
+	var merged []LogicalDevice
+	byName := make(map[string][]int) // 小寫名稱 → merged 中的位置
+	for _, list := range lists {
+		for _, dev := range list.Devices {
+			key := strings.ToLower(dev.Name)
+			found := -1
+			for _, i := range byName[key] {
+				if samePhysicalDevice(merged[i].Device, dev) {
+					found = i
+					break
+				}
+			}
+			if found < 0 {
+				byName[key] = append(byName[key], len(merged))
+				merged = append(merged, LogicalDevice{Domain: list.Domain, Device: dev, Domains: []string{list.Domain}})
+				continue
+			}
+			m := &merged[found]
+			m.Domains = append(m.Domains, list.Domain)
+			if crossAddressed(dev, m.Device) {
+				// 先看到的是 secondary 網路上的記錄
+				m.Domain = list.Domain
+				m.Device = mergeDeviceInfo(dev, m.Device)
+			} else {
+				m.Device = mergeDeviceInfo(m.Device, dev)
+			}
+		}
+	}
+	return merged
+}
+
+// mergeDeviceInfo 以 primary 網路的記錄為主，補上 secondary 網路記錄的地址與缺少的資訊
+func mergeDeviceInfo(primary, secondary dante.Device) dante.Device {
+	dev := primary
+	if dev.SecondaryIP == "" && secondary.IPAddress != "" && secondary.IPAddress != dev.IPAddress {
+		dev.SecondaryIP, dev.SecondarySpeed = secondary.IPAddress, secondary.LinkSpeed
+	}
+	if dev.MacAddress == "" {
+		dev.MacAddress = secondary.MacAddress
+	}
+	if dev.Manufacturer == "" {
+		dev.Manufacturer, dev.SerialNumber = secondary.Manufacturer, secondary.SerialNumber
+	}
+	return dev
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestMergeRedundantDevices(t *testing.T) {
+	primary := []dante.Device{
+		{Name: "Stage-Box-A", IPAddress: "10.0.1.10", LinkSpeed: 1000, SecondaryIP: "10.0.2.10", SecondarySpeed: 1000, MacAddress: "00:1d:c1:00:00:01"},
+		{Name: "Amp-Left", IPAddress: "10.0.1.20", LinkSpeed: 1000},
+	}
+	secondary := []dante.Device{
+		// secondary 網路上的同一台設備 (SDK 只回報這個網路的地址)
+		{Name: "stage-box-a", IPAddress: "10.0.2.10", LinkSpeed: 1000},
+		// 同名但不同的設備
+		{Name: "Amp-Left", IPAddress: "10.0.2.20", LinkSpeed: 1000, MacAddress: "00:1d:c1:00:00:02"},
+	}
+
+	// secondary 網域先列出時仍以 primary 網路的記錄為主
+	merged := mergeRedundantDevices([]domainDevices{{"Dante2", secondary}, {"Dante1", primary}})
+	if len(merged) != 3 {
+		t.Fatalf("got %d devices, want 3: %+v", len(merged), merged)
+	}
+	box := merged[0]
+	if box.Domain != "Dante1" || !slices.Equal(box.Domains, []string{"Dante2", "Dante1"}) ||
+		box.Device.IPAddress != "10.0.1.10" || box.Device.SecondaryIP != "10.0.2.10" || box.Device.MacAddress == "" {
+		t.Fatalf("merged device: %+v", box)
+	}
+
+	// 兩筆都沒有次要地址時以 MAC 判斷，另一個網路的地址成為次要地址
+	merged = mergeRedundantDevices([]domainDevices{
+		{"Dante1", []dante.Device{{Name: "Mic-1", IPAddress: "10.0.1.30", LinkSpeed: 100, MacAddress: "00:1d:c1:00:00:03"}}},
+		{"Dante2", []dante.Device{{Name: "Mic-1", IPAddress: "10.0.2.30", LinkSpeed: 100, MacAddress: "00:1D:C1:00:00:03"}}},
+	})
+	if len(merged) != 1 || merged[0].Device.SecondaryIP != "10.0.2.30" || merged[0].Device.Redundancy() != dante.RedundancyRedundant {
+		t.Fatalf("merged by MAC: %+v", merged)
+	}
+
+	// 同一台設備不算名稱衝突，同名的另一台仍然是
+	conflicts := FindNameConflicts(map[string][]dante.Device{"Dante1": primary, "Dante2": secondary})
+	if _, ok := conflicts["stage-box-a"]; ok {
+		t.Errorf("redundant device reported as a name conflict")
+	}
+	if c, ok := conflicts["amp-left"]; !ok || len(c.Devices) != 2 {
+		t.Errorf("conflicts: %+v", conflicts)
+	}
+}
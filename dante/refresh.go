@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+//==============================================================================
+// 事件驅動刷新
+//==============================================================================
+
+// 設備列表不再以固定間隔刷新：SDK 通知網路變更 (Domain.Changes) 後等待
+// Debounce 合併連續的通知再刷新，兩次刷新至少間隔 MinInterval；
+// 沒有任何通知時仍每 MaxInterval 刷新一次，補上 SDK 漏掉的變化
+// (例如設備直接斷電時 mDNS 記錄要等 TTL 過期)。
+
+// RefreshPolicy 設備列表刷新的時間設定
+type RefreshPolicy struct {
+	Debounce    time.Duration // 變更通知後等待這麼久沒有新通知才刷新
+	MinInterval time.Duration // 兩次刷新的最短間隔
+	MaxInterval time.Duration // 沒有通知時的刷新間隔 (0 表示只依通知刷新)
+}
+
+// DefaultRefreshPolicy 預設的刷新設定
+func DefaultRefreshPolicy() RefreshPolicy {
+	return RefreshPolicy{
+		Debounce:    500 * time.Millisecond,
+		MinInterval: 2 * time.Second,
+		MaxInterval: time.Minute,
+	}
+}
+
+// Validate 檢查設定是否合理
+func (p RefreshPolicy) Validate() error {
+	if p.Debounce < 0 || p.MinInterval < 0 || p.MaxInterval < 0 {
+		return errors.New("refresh durations must not be negative")
+	}
+	if p.MaxInterval > 0 && p.MaxInterval < p.MinInterval {
+		return errors.New("the maximum refresh interval must not be shorter than the minimum")
+	}
+	return nil
+}
+
+// next 下次刷新的時間
+// last 為上次刷新時間，changed 為最後一次變更通知的時間 (零值表示沒有待處理的通知)；
+// 回傳零值表示只等待通知
+func (p RefreshPolicy) next(last, changed time.Time) time.Time {
+	var at time.Time
+	if !changed.IsZero() {
+		at = changed.Add(p.Debounce)
+		if earliest := last.Add(p.MinInterval); at.Before(earliest) {
+			at = earliest
+		}
+	}
+	// 持續變動的網路也不會讓刷新延後超過 MaxInterval
+	if p.MaxInterval > 0 {
+		if latest := last.Add(p.MaxInterval); at.IsZero() || latest.Before(at) {
+			at = latest
+		}
+	}
+	return at
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshPolicyNext(t *testing.T) {
+	p := RefreshPolicy{Debounce: time.Second, MinInterval: 5 * time.Second, MaxInterval: time.Minute}
+	last := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return last.Add(d) }
+
+	tests := []struct {
+		name    string
+		policy  RefreshPolicy
+		changed time.Time
+		want    time.Time
+	}{
+		{"no change waits for max interval", p, time.Time{}, at(time.Minute)},
+		{"change is debounced", p, at(10 * time.Second), at(11 * time.Second)},
+		{"min interval after last refresh", p, at(time.Second), at(5 * time.Second)},
+		{"max interval caps debounce", p, at(time.Minute), at(time.Minute)},
+		{"only on changes", RefreshPolicy{Debounce: time.Second}, time.Time{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.next(last, tt.changed); !got.Equal(tt.want) {
+			t.Errorf("%s: next = %v, want %v", tt.name, got.Sub(last), tt.want.Sub(last))
+		}
+	}
+
+	if err := DefaultRefreshPolicy().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (RefreshPolicy{MinInterval: time.Minute, MaxInterval: time.Second}).Validate(); err == nil {
+		t.Fatal("max interval shorter than min interval accepted")
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 遠端模式
+//==============================================================================
+
+// 加上 -host 時子命令不初始化本機 SDK，而是透過管理 API 對執行中的
+// monitor daemon 操作，一台筆電就能從管理 VLAN 管理整棟建築的主機。
+// plan 不需要 daemon 狀態、instance 管理的是本機行程，所以沒有遠端模式。
+
+// apiTokenEnv 預設 API 權杖的環境變數
+const apiTokenEnv = "GOLANE_API_TOKEN"
+
+// remoteTimeout 遠端請求逾時 (路由操作需要等待設備回應)
+const remoteTimeout = 30 * time.Second
+
+// remoteFlags -host 與 -token
+type remoteFlags struct {
+	host  string
+	token string
+	ca    string
+}
+
+// addRemoteFlags 加入遠端模式參數
+func addRemoteFlags(fs *flag.FlagSet) *remoteFlags {
+	f := &remoteFlags{}
+	fs.StringVar(&f.host, "host", "", "run against the management API of a running monitor (host:port or URL) instead of the local SDK")
+	fs.StringVar(&f.token, "token", os.Getenv(apiTokenEnv), "API token for -host (default $"+apiTokenEnv+")")
+	fs.StringVar(&f.ca, "tls-ca", "", "PEM certificate to trust for an https -host, such as the monitor's self-signed tls-cert.pem")
+	return f
+}
+
+// enabled 是否使用遠端模式
+func (f *remoteFlags) enabled() bool {
+	return f.host != ""
+}
+
+// client 建立遠端 API client
+func (f *remoteFlags) client() (*RemoteClient, error) {
+	base := f.host
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid -host %q", f.host)
+	}
+	client := &http.Client{Timeout: remoteTimeout}
+	if f.ca != "" {
+		if u.Scheme != "https" {
+			return nil, fmt.Errorf("-tls-ca needs an https:// -host")
+		}
+		pool, err := loadCertPool(f.ca)
+		if err != nil {
+			return nil, fmt.Errorf("-tls-ca: %v", err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		client.Transport = transport
+	}
+	return &RemoteClient{
+		base:  strings.TrimSuffix(u.String(), "/"),
+		token: f.token,
+		http:  client,
+	}, nil
+}
+
+// RemoteClient 管理 API client
+type RemoteClient struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// do 送出請求並解析 JSON 回應 (out 為 nil 時忽略回應內容)
+func (c *RemoteClient) do(method, path string, body, out any) error {
+	return c.doContext(context.Background(), method, path, body, out)
+}
+
+// doContext 同 do，ctx 取消時中斷請求並把 ctx 的 span 以 traceparent 傳給 daemon
+func (c *RemoteClient) doContext(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	injectTraceparent(ctx, req.Header)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if user := os.Getenv("USER"); user != "" {
+		req.Header.Set(auditActorHeader, user)
+	}
+	req.Header.Set(auditViaHeader, AuditViaCLI)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s %s: %s", method, path, apiErr.Error)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Domains 網域狀態
+func (c *RemoteClient) Domains() ([]apiDomain, error) {
+	var domains []apiDomain
+	return domains, c.do(http.MethodGet, "/api/domains", nil, &domains)
+}
+
+// Devices 所有網域中符合條件的設備 (篩選、排序與分頁由 daemon 執行)
+func (c *RemoteClient) Devices(filter dante.DeviceFilter, order dante.DeviceOrder, page devicePage) ([]apiDevice, error) {
+	q := deviceFilterQuery(filter)
+	if order.Key != "" {
+		q.Set("sort", order.String())
+	}
+	if page.Limit > 0 {
+		q.Set("limit", strconv.Itoa(page.Limit))
+		q.Set("offset", strconv.Itoa(page.Offset))
+	}
+	path := "/api/devices"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var devices []apiDevice
+	return devices, c.do(http.MethodGet, path, nil, &devices)
+}
+
+// DomainDevices 指定網域的設備 (domain 空白時為所有網域)
+func (c *RemoteClient) DomainDevices(domain string) ([]dante.Device, error) {
+	list, err := c.Devices(dante.DeviceFilter{}, dante.DeviceOrder{}, devicePage{})
+	if err != nil {
+		return nil, err
+	}
+	var devices []dante.Device
+	for _, dev := range list {
+		if domain == "" || dev.Domain == domain {
+			devices = append(devices, dev.Device)
+		}
+	}
+	return devices, nil
+}
+
+// Interfaces daemon 主機的網路介面檢測結果
+func (c *RemoteClient) Interfaces() (*NetworkDetector, error) {
+	detector := &NetworkDetector{}
+	return detector, c.do(http.MethodGet, "/api/interfaces", nil, detector)
+}
+
+// Topology daemon 看到的網路拓撲
+func (c *RemoteClient) Topology() (Topology, error) {
+	var topo Topology
+	return topo, c.do(http.MethodGet, "/api/topology", nil, &topo)
+}
+
+// routePath 路由 API 路徑
+func routePath(domain string, parts ...string) string {
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	path := "/api/routes/" + strings.Join(parts, "/")
+	if domain != "" {
+		path += "?domain=" + url.QueryEscape(domain)
+	}
+	return path
+}
+
+// Routes 指定網域的路由控制 (domain 空白時由 daemon 選擇唯一的網域)
+func (c *RemoteClient) Routes(domain string) RouteController {
+	return remoteRoutes{client: c, domain: domain}
+}
+
+// remoteRoutes 透過 API 實作 RouteController
+type remoteRoutes struct {
+	client *RemoteClient
+	domain string
+}
+
+func (r remoteRoutes) ListSubscriptions(ctx context.Context, rxDevice string) ([]dante.Subscription, error) {
+	var subs []dante.Subscription
+	return subs, r.client.doContext(ctx, http.MethodGet, routePath(r.domain, rxDevice), nil, &subs)
+}
+
+func (r remoteRoutes) Subscribe(ctx context.Context, rxDevice, rxChannel, txDevice, txChannel string) error {
+	if txDevice == "" {
+		return r.client.doContext(ctx, http.MethodDelete, routePath(r.domain, rxDevice, rxChannel), nil, nil)
+	}
+	return r.client.doContext(ctx, http.MethodPut, routePath(r.domain, rxDevice, rxChannel),
+		routeRequest{TxChannel: txChannel, TxDevice: txDevice, Override: routeOverride(ctx)}, nil)
+}
+
+// ListQuarantine 隔離中的設備
+func (c *RemoteClient) ListQuarantine() ([]QuarantineEntry, error) {
+	var entries []QuarantineEntry
+	return entries, c.do(http.MethodGet, "/api/quarantine", nil, &entries)
+}
+
+// Quarantine 隔離設備
+func (c *RemoteClient) Quarantine(device, reason, actor string) (QuarantineEntry, error) {
+	var entry QuarantineEntry
+	return entry, c.do(http.MethodPut, "/api/quarantine/"+url.PathEscape(device),
+		quarantineRequest{Reason: reason, Actor: actor}, &entry)
+}
+
+// Release 解除設備的隔離
+func (c *RemoteClient) Release(device string) error {
+	return c.do(http.MethodDelete, "/api/quarantine/"+url.PathEscape(device), nil, nil)
+}
+
+// ListIncidents 事件單列表
+func (c *RemoteClient) ListIncidents(status string) ([]Incident, error) {
+	var incidents []Incident
+	path := "/api/incidents"
+	if status != "" {
+		path += "?status=" + url.QueryEscape(status)
+	}
+	return incidents, c.do(http.MethodGet, path, nil, &incidents)
+}
+
+// GetIncident 單一事件單
+func (c *RemoteClient) GetIncident(id string) (Incident, error) {
+	var inc Incident
+	return inc, c.do(http.MethodGet, "/api/incidents/"+url.PathEscape(id), nil, &inc)
+}
+
+// IncidentReport 事件單報告
+func (c *RemoteClient) IncidentReport(period time.Duration) (IncidentReport, error) {
+	var report IncidentReport
+	return report, c.do(http.MethodGet, "/api/incidents/report?since="+period.String(), nil, &report)
+}
+
+// AuditEntries 符合條件的稽核紀錄
+func (c *RemoteClient) AuditEntries(filter AuditFilter) ([]AuditEntry, error) {
+	path := "/api/audit"
+	if q := auditFilterQuery(filter); len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var entries []AuditEntry
+	return entries, c.do(http.MethodGet, path, nil, &entries)
+}
+
+// VerifyAudit 驗證 daemon 的稽核紀錄
+func (c *RemoteClient) VerifyAudit() (AuditVerification, error) {
+	var result AuditVerification
+	return result, c.do(http.MethodGet, "/api/audit/verify", nil, &result)
+}
+
+// printRemoteDevices 依網域顯示 daemon 回報的設備
+func printRemoteDevices(domains []apiDomain, devices []apiDevice) {
+	for _, d := range domains {
+		var list []dante.Device
+		for _, dev := range devices {
+			if dev.Domain == d.Name {
+				list = append(list, dev.Device)
+			}
+		}
+		if d.State != "" && d.State != supervisor.StateRunning {
+			fmt.Print(i18n.Sprintf("\n⚠️  %s is %s", d.Name, d.State))
+			if d.LastError != "" {
+				fmt.Printf(": %s", d.LastError)
+			}
+			fmt.Println()
+		}
+		printDeviceTable(d.Name, d.Interface, d.IPAddress, list)
+	}
+}
+
+// errRemoteUnsupported 參數只適用於本機模式
+var errRemoteUnsupported = errors.New("not supported with -host")
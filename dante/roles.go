@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 介面角色
+//==============================================================================
+
+// 設定檔的 interfaces section 明確指定每張網卡的角色，取代內建的網卡名稱清單
+// 與「第一張啟用的網卡是管理網路」的推測：
+//
+//	"interfaces": {"management": "eth0", "dante-primary": "eth1", "dante-secondary": "eth2"}
+//
+// dante-primary 與 dante-secondary 依序成為 Dante 介面 (secondary 為備援網路)，
+// 沒有指定 management 時仍以預設路由所在的介面作為管理網路。
+// 啟動時以偵測到的介面檢查：管理介面不存在時停止啟動；Dante 介面不存在時只警告，
+// 由網域持續重試直到網卡出現 (USB 網卡可能比服務晚就緒)。
+
+// 介面角色
+const (
+	InterfaceRoleManagement     = "management"      // 管理 API、Telnet
+	InterfaceRoleDantePrimary   = "dante-primary"   // Dante primary 網路
+	InterfaceRoleDanteSecondary = "dante-secondary" // Dante secondary (備援) 網路
+)
+
+// interfaceRoles 所有角色 (依顯示順序)
+var interfaceRoles = []string{InterfaceRoleManagement, InterfaceRoleDantePrimary, InterfaceRoleDanteSecondary}
+
+// ErrInvalidInterfaceRoles 介面角色設定錯誤
+var ErrInvalidInterfaceRoles = errors.New("invalid interface roles")
+
+// InterfaceRoles 角色 → 介面名稱
+type InterfaceRoles map[string]string
+
+// Validate 檢查角色名稱，且同一張網卡只能有一個角色
+func (r InterfaceRoles) Validate() error {
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidInterfaceRoles, fmt.Sprintf(format, args...))
+	}
+	owners := make(map[string]string, len(r))
+	for role, name := range r {
+		if !isInterfaceRole(role) {
+			return fail("unknown role %q (use %v)", role, interfaceRoles)
+		}
+		if name == "" {
+			return fail("role %s has no interface", role)
+		}
+		if other, ok := owners[name]; ok {
+			first, second := min(role, other), max(role, other)
+			return fail("interface %s is assigned to both %s and %s", name, first, second)
+		}
+		owners[name] = role
+	}
+	if r[InterfaceRoleDanteSecondary] != "" && r[InterfaceRoleDantePrimary] == "" {
+		return fail("%s requires %s", InterfaceRoleDanteSecondary, InterfaceRoleDantePrimary)
+	}
+	return nil
+}
+
+// danteNames Dante 介面名稱 (primary 在前)
+func (r InterfaceRoles) danteNames() []string {
+	var names []string
+	for _, role := range []string{InterfaceRoleDantePrimary, InterfaceRoleDanteSecondary} {
+		if name := r[role]; name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isInterfaceRole 是否為已知的角色
+func isInterfaceRole(role string) bool {
+	for _, r := range interfaceRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRoles 以偵測到的介面檢查設定的角色
+func (nd *NetworkDetector) checkRoles() error {
+	for _, role := range interfaceRoles {
+		name := nd.Roles[role]
+		if name == "" {
+			continue
+		}
+		info := nd.GetInterfaceByName(nd.currentName(name))
+		switch {
+		case info == nil && role == InterfaceRoleManagement:
+			return fmt.Errorf("management interface %s not found", name)
+		case info == nil:
+			logger.Warn("Interface of the configured role not found", "role", role, "iface", name)
+		case !info.IsUp || !info.HasIP:
+			logger.Warn("Interface of the configured role is down or has no IP address", "role", role, "iface", name)
+		}
+	}
+	return nil
+}
+
+// interfaceRole 介面的角色 (設定檔或偵測結果，沒有角色時為空白)
+func (nd *NetworkDetector) interfaceRole(name string) string {
+	if nd.ManagementInterface != nil && nd.ManagementInterface.Name == name {
+		return InterfaceRoleManagement
+	}
+	for i, info := range nd.DanteInterfaces {
+		if info.Name != name {
+			continue
+		}
+		switch i {
+		case 0:
+			return InterfaceRoleDantePrimary
+		case 1:
+			return InterfaceRoleDanteSecondary
+		}
+	}
+	return ""
+}
+
+// roleText 角色的說明
+func roleText(role string) string {
+	switch role {
+	case InterfaceRoleManagement:
+		return i18n.T("Management (Telnet)")
+	case InterfaceRoleDantePrimary:
+		return i18n.T("Dante primary network")
+	case InterfaceRoleDanteSecondary:
+		return i18n.T("Dante secondary network")
+	}
+	return role
+}
+
+// detectedRoles 目前的角色分配 (可直接寫入設定檔)
+func (nd *NetworkDetector) detectedRoles() InterfaceRoles {
+	roles := InterfaceRoles{}
+	for _, info := range nd.AllInterfaces {
+		if role := nd.interfaceRole(info.Name); role != "" {
+			roles[role] = info.Name
+		}
+	}
+	return roles
+}
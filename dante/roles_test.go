@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInterfaceRolesValidate(t *testing.T) {
+	ok := InterfaceRoles{InterfaceRoleManagement: "eth0", InterfaceRoleDantePrimary: "eth1", InterfaceRoleDanteSecondary: "eth2"}
+	if err := ok.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []InterfaceRoles{
+		{"dante": "eth1"},
+		{InterfaceRoleDantePrimary: ""},
+		{InterfaceRoleManagement: "eth1", InterfaceRoleDantePrimary: "eth1"},
+		{InterfaceRoleDanteSecondary: "eth2"},
+	} {
+		if err := bad.Validate(); !errors.Is(err, ErrInvalidInterfaceRoles) {
+			t.Errorf("%v: err = %v", bad, err)
+		}
+	}
+}
+
+func TestInterfaceRolesAssignment(t *testing.T) {
+	nd := NewNetworkDetector()
+	nd.Roles = InterfaceRoles{InterfaceRoleManagement: "eth1.20", InterfaceRoleDantePrimary: "eth2", InterfaceRoleDanteSecondary: "eth1.10"}
+	// 系統列出的順序與角色不同: 第一張啟用的網卡不一定是管理網路
+	for _, name := range []string{"eth1.10", "eth1.20", "eth2", "wlan0"} {
+		nd.AllInterfaces = append(nd.AllInterfaces, NetworkInterfaceInfo{Name: name, IsUp: true, HasIP: true})
+	}
+	nd.IdentifyDanteInterfaces(nd.danteCandidates())
+	nd.identifyManagementInterface(nd.Roles[InterfaceRoleManagement])
+	if err := nd.checkRoles(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nd.DanteInterfaces) != 2 || nd.DanteInterfaces[0].Name != "eth2" {
+		t.Fatalf("Dante interfaces = %+v", nd.DanteInterfaces)
+	}
+	roles := nd.detectedRoles()
+	if len(roles) != 3 || roles[InterfaceRoleDanteSecondary] != "eth1.10" || roles[InterfaceRoleManagement] != "eth1.20" {
+		t.Errorf("roles = %v", roles)
+	}
+	if role := nd.interfaceRole("wlan0"); role != "" {
+		t.Errorf("wlan0 role = %q", role)
+	}
+
+	nd.Roles[InterfaceRoleManagement] = "eth0"
+	if err := nd.checkRoles(); err == nil {
+		t.Error("missing management interface accepted")
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+//==============================================================================
+// 批次匯入訂閱 (CSV、XLSX、JSON)
+//==============================================================================
+
+// 系統整合商習慣在試算表中規劃路由，每家的欄位名稱與順序都不同。
+// CSV 與 XLSX (第一個工作表) 由標題列對應欄位 (rx_device、"RX Device"、
+// "Receiver" 等寫法都可以，多餘的欄位忽略)；沒有標題列時依
+// rx_device,rx_channel,tx_device,tx_channel 的順序。JSON 格式為
+//
+//	{"routes": [{"rx_device": "Amp-Left", "rx_channel": "01", "tx_device": "FOH-Console", "tx_channel": "01"}]}
+//
+// (也接受只有陣列，preset 檔也可以直接匯入)。tx_device 與 tx_channel
+// 空白表示取消訂閱。三種格式經過相同的驗證：格式有問題或重複設定同一個
+// 接收通道的列不套用，其餘列逐一套用，個別失敗不中斷匯入，結果以每一列
+// 的報告回傳 (列號對應試算表，方便修正後重新匯入)。
+// 試算表的數字儲存格會失去前導零 (01 → 1)，通道欄位請設為文字格式。
+
+// routeImportColumns 沒有標題列時的欄位順序
+var routeImportColumns = []string{"rx_device", "rx_channel", "tx_device", "tx_channel"}
+
+// routeImportAliases 標題列的欄位名稱 (比對時忽略大小寫、空白、底線與連字號)
+var routeImportAliases = map[string][]string{
+	"rx_device":  {"rxdevice", "receiver", "receivingdevice", "destinationdevice", "dstdevice"},
+	"rx_channel": {"rxchannel", "receivingchannel", "destinationchannel", "dstchannel", "rxch"},
+	"tx_device":  {"txdevice", "transmitter", "transmittingdevice", "sourcedevice", "srcdevice"},
+	"tx_channel": {"txchannel", "transmittingchannel", "sourcechannel", "srcchannel", "txch"},
+}
+
+// 匯入檔格式
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatXLSX = "xlsx"
+	ImportFormatJSON = "json"
+)
+
+// 匯入列的結果
+const (
+	ImportApplied = "applied" // 已套用
+	ImportFailed  = "failed"  // 套用時失敗
+	ImportInvalid = "invalid" // 格式錯誤，沒有套用
+	ImportPlanned = "planned" // -dry-run：格式正確，會套用
+)
+
+// RouteImportRow 匯入檔中的一列
+type RouteImportRow struct {
+	Line int `json:"line"` // CSV 與 XLSX 為試算表的列號 (含標題列)，JSON 為 routes 中的第幾筆 (1-based)
+	PresetRoute
+	Error string `json:"error,omitempty"` // 格式錯誤 (不套用)
+}
+
+// RouteImportResult 一列的套用結果
+type RouteImportResult struct {
+	RouteImportRow
+	Status string `json:"status"`
+}
+
+// RouteImportReport 匯入報告
+type RouteImportReport struct {
+	DryRun  bool                `json:"dry_run,omitempty"` // -dry-run：沒有套用任何一列
+	Rows    []RouteImportResult `json:"rows"`
+	Applied int                 `json:"applied"`
+	Failed  int                 `json:"failed"`
+	Invalid int                 `json:"invalid"`
+	Planned int                 `json:"planned,omitempty"` // -dry-run 時會套用的列數
+}
+
+// routeImportRecord 試算表格式的一列儲存格
+type routeImportRecord struct {
+	Line   int
+	Fields []string
+}
+
+// RouteImportFormat 依副檔名判斷匯入檔格式
+func RouteImportFormat(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv", ".txt":
+		return ImportFormatCSV, nil
+	case ".xlsx":
+		return ImportFormatXLSX, nil
+	case ".json":
+		return ImportFormatJSON, nil
+	default:
+		return "", fmt.Errorf("cannot tell the format of %s, use -format csv, xlsx or json", path)
+	}
+}
+
+// ParseRouteImport 讀取匯入檔並驗證每一列
+// 只有整個檔案無法使用時回傳錯誤 (無法解析、標題缺少欄位)，個別列的問題記錄在 Error
+func ParseRouteImport(format string, r io.Reader) ([]RouteImportRow, error) {
+	var rows []RouteImportRow
+	switch format {
+	case ImportFormatCSV:
+		records, err := readRouteCSV(r)
+		if err != nil {
+			return nil, err
+		}
+		if rows, err = mapRouteImportRecords(records); err != nil {
+			return nil, err
+		}
+	case ImportFormatXLSX:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		sheet, err := readXLSXFirstSheet(data)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]routeImportRecord, len(sheet))
+		for i, row := range sheet {
+			records[i] = routeImportRecord{Line: row.Number, Fields: row.Cells}
+		}
+		if rows, err = mapRouteImportRecords(records); err != nil {
+			return nil, err
+		}
+	case ImportFormatJSON:
+		var err error
+		if rows, err = parseRouteJSON(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown import format %q, use csv, xlsx or json", format)
+	}
+	validateRouteImportRows(rows)
+	return rows, nil
+}
+
+// readRouteCSV 讀取 CSV 的所有列 (列號來自 CSV reader，包含引號內的換行)
+func readRouteCSV(r io.Reader) ([]routeImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var records []routeImportRecord
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, routeImportRecord{Line: line, Fields: fields})
+	}
+}
+
+// mapRouteImportRecords 依標題列 (或預設順序) 把儲存格對應到訂閱欄位
+func mapRouteImportRecords(records []routeImportRecord) ([]RouteImportRow, error) {
+	var columns map[string]int // 欄位名稱 → 索引
+	positional := false        // 沒有標題列，依預設順序
+	rows := []RouteImportRow{}
+	for _, rec := range records {
+		if isBlankRecord(rec.Fields) {
+			continue
+		}
+		if columns == nil {
+			header, err := routeImportHeader(rec.Fields)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", rec.Line, err)
+			}
+			if header != nil {
+				columns = header
+				continue
+			}
+			columns, positional = make(map[string]int), true
+			for i, name := range routeImportColumns {
+				columns[name] = i
+			}
+		}
+
+		row := RouteImportRow{Line: rec.Line}
+		if positional && !hasColumns(rec.Fields, len(routeImportColumns)) {
+			row.Error = fmt.Sprintf("expected %d columns (%s), got %d", len(routeImportColumns), strings.Join(routeImportColumns, ","), len(rec.Fields))
+			rows = append(rows, row)
+			continue
+		}
+		field := func(name string) string {
+			if i := columns[name]; i < len(rec.Fields) {
+				return rec.Fields[i]
+			}
+			return ""
+		}
+		row.RxDevice, row.RxChannel = field("rx_device"), field("rx_channel")
+		row.TxDevice, row.TxChannel = field("tx_device"), field("tx_channel")
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// routeImportHeader 辨識標題列；不是標題列時回傳 nil，
+// 看起來是標題但缺少欄位時回傳錯誤 (避免把整個檔案對應錯欄位)
+func routeImportHeader(fields []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, f := range fields {
+		key := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(f)))
+		for name, aliases := range routeImportAliases {
+			if _, dup := columns[name]; !dup && slices.Contains(aliases, key) {
+				columns[name] = i
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	var missing []string
+	for _, name := range routeImportColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("header has no %s column", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// isBlankRecord 所有儲存格都是空白
+func isBlankRecord(fields []string) bool {
+	for _, f := range fields {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasColumns 欄位數正確 (XLSX 的列尾可能有空白儲存格)
+func hasColumns(fields []string, n int) bool {
+	if len(fields) < n {
+		return false
+	}
+	return isBlankRecord(fields[n:])
+}
+
+// routeImportEntry JSON 格式的一筆訂閱
+type routeImportEntry struct {
+	RxDevice  string `json:"rx_device"`
+	RxChannel string `json:"rx_channel"`
+	TxDevice  string `json:"tx_device,omitempty"`
+	TxChannel string `json:"tx_channel,omitempty"`
+}
+
+// parseRouteJSON 讀取 JSON 匯入檔，每一筆個別解析 (錯誤的型別或未知欄位只影響該筆)
+func parseRouteJSON(r io.Reader) ([]RouteImportRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var entries []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, err
+		}
+	} else {
+		var doc struct {
+			Routes []json.RawMessage `json:"routes"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if doc.Routes == nil {
+			return nil, errors.New(`no "routes" array`)
+		}
+		entries = doc.Routes
+	}
+
+	rows := []RouteImportRow{}
+	for i, raw := range entries {
+		row := RouteImportRow{Line: i + 1}
+		var entry routeImportEntry
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&entry); err != nil {
+			row.Error = err.Error()
+		} else {
+			row.PresetRoute = PresetRoute{RxDevice: entry.RxDevice, RxChannel: entry.RxChannel, TxDevice: entry.TxDevice, TxChannel: entry.TxChannel}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// validateRouteImportRows 各種格式共用的驗證：必要欄位，以及同一個接收通道只能設定一次
+func validateRouteImportRows(rows []RouteImportRow) {
+	seen := make(map[string]int) // 接收通道 → 第一次出現的列號
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		row.RxDevice, row.RxChannel = strings.TrimSpace(row.RxDevice), strings.TrimSpace(row.RxChannel)
+		row.TxDevice, row.TxChannel = strings.TrimSpace(row.TxDevice), strings.TrimSpace(row.TxChannel)
+		switch {
+		case row.RxDevice == "" || row.RxChannel == "":
+			row.Error = "rx_device and rx_channel are required"
+		case (row.TxDevice == "") != (row.TxChannel == ""):
+			row.Error = "tx_device and tx_channel go together (leave both empty to unsubscribe)"
+		}
+		if row.Error != "" {
+			continue
+		}
+		key := strings.ToLower(row.RxDevice) + "\x00" + row.RxChannel
+		if first, dup := seen[key]; dup {
+			row.Error = fmt.Sprintf("%s@%s is already set on line %d", row.RxChannel, row.RxDevice, first)
+			continue
+		}
+		seen[key] = row.Line
+	}
+}
+
+// ApplyRouteImport 逐列套用訂閱 (格式錯誤的列略過，dry-run 時只標記為 planned)
+func ApplyRouteImport(ctx context.Context, rc RouteController, rows []RouteImportRow) RouteImportReport {
+	report := RouteImportReport{DryRun: dryRun(ctx), Rows: []RouteImportResult{}}
+	for _, row := range rows {
+		result := RouteImportResult{RouteImportRow: row}
+		if row.Error != "" {
+			result.Status = ImportInvalid
+			report.Invalid++
+		} else if report.DryRun {
+			result.Status = ImportPlanned
+			report.Planned++
+		} else if err := rc.Subscribe(ctx, row.RxDevice, row.RxChannel, row.TxDevice, row.TxChannel); err != nil {
+			result.Status = ImportFailed
+			result.Error = err.Error()
+			report.Failed++
+		} else {
+			result.Status = ImportApplied
+			report.Applied++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	return report
+}
+
+// PrintRouteImportReport 顯示匯入報告
+func PrintRouteImportReport(w io.Writer, report RouteImportReport) {
+	fmt.Fprintf(w, "%-6s %-8s %-32s %-32s %s\n", "LINE", "STATUS", "RX CHANNEL", "SUBSCRIPTION", "ERROR")
+	fmt.Fprintln(w, "────────────────────────────────────────────────────────────────────────────")
+	for _, r := range report.Rows {
+		subscription := "-"
+		if r.TxDevice != "" {
+			subscription = r.TxChannel + "@" + r.TxDevice
+		}
+		fmt.Fprintf(w, "%-6d %-8s %-32s %-32s %s\n", r.Line, r.Status, r.RxChannel+"@"+r.RxDevice, subscription, r.Error)
+	}
+	if report.DryRun {
+		fmt.Fprintf(w, "\n%d planned, %d invalid (dry run, nothing applied)\n", report.Planned, report.Invalid)
+		return
+	}
+	fmt.Fprintf(w, "\n%d applied, %d failed, %d invalid\n", report.Applied, report.Failed, report.Invalid)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRouteImportCSV(t *testing.T) {
+	csv := "rx_device,rx_channel,tx_device,tx_channel\n" +
+		"Amp-Left,01,FOH-Console,01\n" +
+		"Amp-Left, 02 ,FOH-Console,02\n" +
+		"\n" +
+		"Amp-Left,09,FOH-Console,03\n" +
+		"Amp-Right,01,FOH-Console,\n" +
+		"Amp-Left,01,FOH-Console,04\n" +
+		"Amp-Right,02,,\n"
+	rows, err := ParseRouteImport(ImportFormatCSV, strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 6 || rows[0].Line != 2 || rows[2].Line != 5 || rows[1].RxChannel != "02" {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if rows[4].Error != "01@Amp-Left is already set on line 2" {
+		t.Fatalf("duplicate row = %+v", rows[4])
+	}
+
+	_, d := newTriggerEngine(t, false)
+	if err := d.Subscribe(context.Background(), "Amp-Right", "02", "FOH-Console", "05"); err != nil {
+		t.Fatal(err)
+	}
+	report := ApplyRouteImport(context.Background(), d, rows)
+	if report.Applied != 3 || report.Failed != 1 || report.Invalid != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	statuses := []string{ImportApplied, ImportApplied, ImportFailed, ImportInvalid, ImportInvalid, ImportApplied}
+	for i, want := range statuses {
+		if got := report.Rows[i]; got.Status != want || (want != ImportApplied) == (got.Error == "") {
+			t.Errorf("line %d = %+v, want %s", got.Line, got, want)
+		}
+	}
+
+	subs, err := d.ListSubscriptions(context.Background(), "Amp-Right")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs[1].Subscribed() {
+		t.Fatalf("Amp-Right 02 still subscribed: %+v", subs[1])
+	}
+
+	// 無法解析的 CSV (未結束的引號) 整個檔案拒絕
+	if _, err := ParseRouteImport(ImportFormatCSV, strings.NewReader("Amp-Left,\"01,FOH-Console,01\n")); err == nil {
+		t.Fatal("malformed CSV accepted")
+	}
+	// 沒有標題列時依預設順序，欄位數必須正確
+	rows, err = ParseRouteImport(ImportFormatCSV, strings.NewReader("Amp-Left,01,FOH-Console,01\nAmp-Left,02,FOH-Console\n"))
+	if err != nil || rows[0].Error != "" || !strings.HasPrefix(rows[1].Error, "expected 4 columns") {
+		t.Fatalf("positional rows = %+v, %v", rows, err)
+	}
+}
+
+func TestRouteImportHeaderMapping(t *testing.T) {
+	csv := "Notes,Source Device,Source Channel,Receiver,RX-Channel\n" +
+		"vocals,FOH-Console,01,Amp-Left,01\n"
+	rows, err := ParseRouteImport(ImportFormatCSV, strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PresetRoute{RxDevice: "Amp-Left", RxChannel: "01", TxDevice: "FOH-Console", TxChannel: "01"}
+	if len(rows) != 1 || rows[0].PresetRoute != want || rows[0].Line != 2 {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	// 看起來是標題但少了欄位時整個檔案拒絕
+	if _, err := ParseRouteImport(ImportFormatCSV, strings.NewReader("Receiver,RX Channel,Source Device\n")); err == nil ||
+		!strings.Contains(err.Error(), "tx_channel") {
+		t.Fatalf("incomplete header: %v", err)
+	}
+}
+
+func TestRouteImportXLSX(t *testing.T) {
+	data := buildXLSX(t, [][]string{
+		{"RX Device", "RX Channel", "TX Device", "TX Channel"},
+		{"Amp-Left", "01", "FOH-Console", "01"},
+		{},
+		{"Amp-Left", "", "FOH-Console", "02"},
+	})
+	rows, err := ParseRouteImport(ImportFormatXLSX, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].TxDevice != "FOH-Console" || rows[0].Error != "" ||
+		rows[1].Line != 4 || rows[1].Error != "rx_device and rx_channel are required" {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	if _, err := ParseRouteImport(ImportFormatXLSX, strings.NewReader("not a zip")); err == nil {
+		t.Fatal("invalid XLSX accepted")
+	}
+}
+
+func TestRouteImportJSON(t *testing.T) {
+	doc := `{"name": "Show", "routes": [
+		{"rx_device": "Amp-Left", "rx_channel": "01", "tx_device": "FOH-Console", "tx_channel": "01"},
+		{"rx_device": "Amp-Left", "rx_channel": 2},
+		{"rx_device": "Amp-Left", "rx_channel": "03", "gain": 3},
+		{"rx_device": "Amp-Left", "rx_channel": "04"}
+	]}`
+	rows, err := ParseRouteImport(ImportFormatJSON, strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0].Error != "" || rows[1].Error == "" || !strings.Contains(rows[2].Error, "gain") ||
+		rows[3].Error != "" || rows[3].Line != 4 {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	rows, err = ParseRouteImport(ImportFormatJSON, strings.NewReader(`[{"rx_device": "Amp-Left", "rx_channel": "01"}]`))
+	if err != nil || len(rows) != 1 || rows[0].Error != "" {
+		t.Fatalf("array rows = %+v, %v", rows, err)
+	}
+	if _, err := ParseRouteImport(ImportFormatJSON, strings.NewReader(`{"presets": []}`)); err == nil {
+		t.Fatal("document without routes accepted")
+	}
+}
+
+// buildXLSX 建立最小的 XLSX (第一個字串欄以共用字串表，其他以 inline string)
+func buildXLSX(t *testing.T, rows [][]string) []byte {
+	t.Helper()
+	var sheet, shared strings.Builder
+	sharedCount := 0
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, v := range row {
+			ref := fmt.Sprintf("%c%d", 'A'+j, i+1)
+			if v == "" {
+				continue
+			}
+			if j == 0 {
+				fmt.Fprintf(&sheet, `<c r="%s" t="s"><v>%d</v></c>`, ref, sharedCount)
+				fmt.Fprintf(&shared, `<si><r><t>%s</t></r></si>`, v)
+				sharedCount++
+			} else {
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, v)
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Routing" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":     `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + shared.String() + `</sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheet.String() + `</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/cron"
+	"danteCS/internal/i18n"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 排程的路由變更
+//==============================================================================
+
+// 場館的日常切換 (例如閉館時把廣播路由切到夜間的 preset) 不需要有人在場：
+// 設定檔的 schedules 在指定的時間套用 preset，套用方式與 API 的 recall 相同
+// (先驗證，partial 決定有問題時是否套用其餘訂閱)。
+//
+//	"schedules": [
+//	  {"name": "venue-close", "preset": "Night Paging", "cron": "30 23 * * *"},
+//	  {"name": "gala", "preset": "Gala", "at": "2026-12-31T18:00:00+08:00"}
+//	]
+//
+// at 為每天的時間 (15:04) 或一次性的時間 (RFC 3339)，cron 為五個欄位的 cron
+// 表示式 (見 internal/cron)。主機睡眠或時間跳動而錯過多次時只執行最近的一次，
+// 其餘記在歷史的 missed；monitor 沒有執行期間排定的不補做。
+// 執行歷史保存在狀態檔，重啟後仍可查詢。
+
+// scheduleSection 狀態檔中執行歷史的 section
+const scheduleSection = "schedule_history"
+
+// maxScheduleHistory 保留的執行歷史筆數
+const maxScheduleHistory = 200
+
+// scheduleMaxSleep 兩次檢查之間最長的等待 (主機時間被校正時不會錯過太久)
+const scheduleMaxSleep = time.Minute
+
+// ScheduleEntry 設定檔 schedules section 的一項
+type ScheduleEntry struct {
+	Name     string `json:"name"`
+	Preset   string `json:"preset"`
+	At       string `json:"at,omitempty"`       // 每天的時間 (15:04) 或一次性的時間 (RFC 3339)，與 cron 擇一
+	Cron     string `json:"cron,omitempty"`     // cron 表示式 (分 時 日 月 週)
+	Timezone string `json:"timezone,omitempty"` // IANA 時區名稱 (預設為主機時區)
+	Partial  bool   `json:"partial,omitempty"`  // 驗證有問題時仍套用其餘訂閱
+}
+
+// ScheduleRun 一次排程執行的結果
+type ScheduleRun struct {
+	Schedule string    `json:"schedule"`
+	Due      time.Time `json:"due"` // 排定的時間
+	TriggerEvent
+	Error   string `json:"error,omitempty"`   // 沒有套用的原因 (驗證問題、功能停用)
+	Skipped bool   `json:"skipped,omitempty"` // 排程功能停用而沒有執行
+	Missed  int    `json:"missed,omitempty"`  // 之前錯過 (主機睡眠或時間跳動) 而沒有補做的次數
+}
+
+// ScheduleInfo 一個排程與下一次執行時間
+type ScheduleInfo struct {
+	ScheduleEntry
+	Next *time.Time `json:"next,omitempty"` // 不會再執行時為 nil (已過的一次性排程)
+}
+
+// ScheduleStatus GET /api/schedules 的內容
+type ScheduleStatus struct {
+	Enabled   bool           `json:"enabled"`
+	Schedules []ScheduleInfo `json:"schedules"`
+	History   []ScheduleRun  `json:"history"` // 新的在前
+}
+
+// presetRecaller 套用 preset (TriggerEngine)
+type presetRecaller interface {
+	Recall(ctx context.Context, name, source string, partial bool) (TriggerEvent, error)
+}
+
+// scheduledJob 一個排程與下一次執行時間
+type scheduledJob struct {
+	entry ScheduleEntry
+	next  func(after time.Time) time.Time // after 之後的下一次執行，不會再執行時為零值
+	due   time.Time
+}
+
+// RouteScheduler 依排程套用 preset
+type RouteScheduler struct {
+	presets  presetRecaller
+	features *FeatureFlags
+	state    *StateStore // nil 表示不保存歷史
+
+	mu      sync.Mutex
+	jobs    []*scheduledJob
+	history []ScheduleRun // 舊的在前
+}
+
+// NewRouteScheduler 驗證排程後建立排程器 (engine 的 preset 必須包含排程使用的 preset)
+func NewRouteScheduler(entries []ScheduleEntry, engine *TriggerEngine, state *StateStore, features *FeatureFlags) (*RouteScheduler, error) {
+	s := &RouteScheduler{presets: engine, features: features, state: state}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Name == "" {
+			return nil, errors.New("schedule without name")
+		}
+		key := strings.ToLower(entry.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate schedule %s", entry.Name)
+		}
+		seen[key] = true
+		if engine == nil {
+			return nil, fmt.Errorf("schedule %s: %w %s (no presets configured)", entry.Name, errUnknownPreset, entry.Preset)
+		}
+		if _, ok := engine.presets[strings.ToLower(entry.Preset)]; !ok {
+			return nil, fmt.Errorf("schedule %s: %w %s", entry.Name, errUnknownPreset, entry.Preset)
+		}
+		next, err := parseScheduleTime(entry)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %v", entry.Name, err)
+		}
+		s.jobs = append(s.jobs, &scheduledJob{entry: entry, next: next})
+	}
+	if state != nil {
+		if _, err := state.Load(scheduleSection, &s.history); err != nil {
+			return nil, err
+		}
+	}
+	s.plan(time.Now())
+	return s, nil
+}
+
+// parseScheduleTime 依 at 或 cron 建立計算下一次執行時間的函式
+func parseScheduleTime(entry ScheduleEntry) (func(after time.Time) time.Time, error) {
+	loc := time.Local
+	if entry.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(entry.Timezone); err != nil {
+			return nil, fmt.Errorf("timezone: %v", err)
+		}
+	}
+	switch {
+	case (entry.At == "") == (entry.Cron == ""):
+		return nil, errors.New("exactly one of at and cron is required")
+	case entry.Cron != "":
+		c, err := cron.Parse(entry.Cron)
+		if err != nil {
+			return nil, err
+		}
+		return func(after time.Time) time.Time { return c.Next(after.In(loc)) }, nil
+	}
+
+	if once, err := time.Parse(time.RFC3339, entry.At); err == nil {
+		return func(after time.Time) time.Time {
+			if once.After(after) {
+				return once
+			}
+			return time.Time{}
+		}, nil
+	}
+	daily, err := time.Parse("15:04", entry.At)
+	if err != nil {
+		return nil, fmt.Errorf("at %q: want HH:MM or an RFC 3339 time", entry.At)
+	}
+	return func(after time.Time) time.Time {
+		after = after.In(loc)
+		t := time.Date(after.Year(), after.Month(), after.Day(), daily.Hour(), daily.Minute(), 0, 0, loc)
+		if !t.After(after) {
+			t = time.Date(after.Year(), after.Month(), after.Day()+1, daily.Hour(), daily.Minute(), 0, 0, loc)
+		}
+		return t
+	}, nil
+}
+
+// plan 計算每個排程在 now 之後的下一次執行
+func (s *RouteScheduler) plan(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		job.due = job.next(now)
+	}
+}
+
+// Start 在背景執行排程，直到 ctx 結束
+func (s *RouteScheduler) Start(ctx context.Context) {
+	if len(s.jobs) == 0 {
+		return
+	}
+	logger.Info("Routing schedules loaded", "schedules", len(s.jobs))
+	recovery.GoLoop(ctx, "schedules", func() { s.run(ctx) })
+}
+
+// run 等到最早的排程時間後執行到期的排程
+func (s *RouteScheduler) run(ctx context.Context) {
+	for {
+		wait := scheduleMaxSleep
+		if next := s.nextDue(); !next.IsZero() {
+			wait = min(wait, max(time.Until(next), 0))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.runDue(ctx, time.Now())
+	}
+}
+
+// nextDue 最早的下一次執行時間，沒有排程會再執行時為零值
+func (s *RouteScheduler) nextDue() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next time.Time
+	for _, job := range s.jobs {
+		if !job.due.IsZero() && (next.IsZero() || job.due.Before(next)) {
+			next = job.due
+		}
+	}
+	return next
+}
+
+// runDue 執行 now 時已到期的排程；同一個排程錯過多次時只執行一次
+func (s *RouteScheduler) runDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	var due []ScheduleRun
+	var entries []ScheduleEntry
+	for _, job := range s.jobs {
+		if job.due.IsZero() || now.Before(job.due) {
+			continue
+		}
+		run := ScheduleRun{Schedule: job.entry.Name, Due: job.due}
+		next := job.next(job.due)
+		for !next.IsZero() && !now.Before(next) {
+			run.Missed++
+			run.Due = next
+			next = job.next(next)
+		}
+		job.due = next
+		due = append(due, run)
+		entries = append(entries, job.entry)
+	}
+	s.mu.Unlock()
+
+	for i, run := range due {
+		s.record(s.execute(ctx, entries[i], run))
+	}
+}
+
+// execute 套用排程的 preset
+func (s *RouteScheduler) execute(ctx context.Context, entry ScheduleEntry, run ScheduleRun) ScheduleRun {
+	if run.Missed > 0 {
+		logger.Warn("Scheduled runs missed", "schedule", entry.Name, "missed", run.Missed)
+	}
+	if !s.features.Enabled(FeatureSchedules) {
+		run.Preset, run.Source, run.Time = entry.Preset, "schedule", time.Now()
+		run.Skipped = true
+		run.Error = fmt.Sprintf("feature %s is disabled", FeatureSchedules)
+		logger.Info("Scheduled preset skipped, schedules disabled", "schedule", entry.Name, "preset", entry.Preset)
+		return run
+	}
+	ev, err := s.presets.Recall(ctx, entry.Preset, "schedule", entry.Partial)
+	if ev.Preset == "" {
+		ev.Preset, ev.Source, ev.Time = entry.Preset, "schedule", time.Now()
+	}
+	run.TriggerEvent = ev
+	if err != nil {
+		run.Error = err.Error()
+		logger.Warn("Scheduled preset failed", "schedule", entry.Name, "preset", entry.Preset, "err", err)
+	}
+	return run
+}
+
+// record 加入執行歷史並保存
+func (s *RouteScheduler) record(run ScheduleRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, run)
+	if len(s.history) > maxScheduleHistory {
+		s.history = append([]ScheduleRun{}, s.history[len(s.history)-maxScheduleHistory:]...)
+	}
+	if s.state == nil {
+		return
+	}
+	if err := s.state.Save(scheduleSection, s.history); err != nil {
+		logger.Warn("Failed to save schedule history", "err", err)
+	}
+}
+
+// Status 排程、下一次執行時間與執行歷史
+func (s *RouteScheduler) Status() ScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := ScheduleStatus{
+		Enabled:   s.features.Enabled(FeatureSchedules),
+		Schedules: make([]ScheduleInfo, 0, len(s.jobs)),
+		History:   make([]ScheduleRun, 0, len(s.history)),
+	}
+	for _, job := range s.jobs {
+		info := ScheduleInfo{ScheduleEntry: job.entry}
+		if !job.due.IsZero() {
+			due := job.due
+			info.Next = &due
+		}
+		status.Schedules = append(status.Schedules, info)
+	}
+	for i := len(s.history) - 1; i >= 0; i-- {
+		status.History = append(status.History, s.history[i])
+	}
+	return status
+}
+
+//------------------------------------------------------------------------------
+// API 與 CLI
+//------------------------------------------------------------------------------
+
+func (s *APIServer) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.schedules.Status())
+}
+
+// Schedules 遠端 monitor 的排程與執行歷史
+func (c *RemoteClient) Schedules() (ScheduleStatus, error) {
+	var status ScheduleStatus
+	err := c.do(http.MethodGet, "/api/schedules", nil, &status)
+	return status, err
+}
+
+// newSchedulesCommand golane schedules
+func newSchedulesCommand() *Command {
+	fs := newFlagSet("schedules")
+	lf := addLogFlags(fs)
+	limit := fs.Int("limit", 20, "show at most this many recent runs (0 = all)")
+	jsonOut := fs.Bool("json", false, "print the schedules and runs as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "schedules",
+		Short: "Show the routing schedules of a running monitor and their recent runs",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if !remote.enabled() {
+				return errors.New("schedules are run by a running monitor, use -host")
+			}
+			client, err := remote.client()
+			if err != nil {
+				return err
+			}
+			status, err := client.Schedules()
+			if err != nil {
+				return err
+			}
+			if *limit > 0 && len(status.History) > *limit {
+				status.History = status.History[:*limit]
+			}
+			if *jsonOut {
+				return printJSON(status)
+			}
+			printSchedules(status)
+			return nil
+		},
+	}
+}
+
+// printSchedules 印出排程與最近的執行
+func printSchedules(status ScheduleStatus) {
+	if !status.Enabled {
+		fmt.Println(i18n.T("Schedules are disabled, scheduled presets are skipped"))
+	}
+	fmt.Print(i18n.Sprintf("\n=== Schedules (%d) ===\n", len(status.Schedules)))
+	printHeader("%-20s %-20s %-24s %s\n", "NAME", "PRESET", "WHEN", "NEXT")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────")
+	for _, sched := range status.Schedules {
+		when := sched.Cron
+		if when == "" {
+			when = sched.At
+		}
+		next := "-"
+		if sched.Next != nil {
+			next = sched.Next.Local().Format(time.DateTime)
+		}
+		fmt.Printf("%-20s %-20s %-24s %s\n", sched.Name, sched.Preset, when, next)
+	}
+
+	fmt.Print(i18n.Sprintf("\n=== Recent runs (%d) ===\n", len(status.History)))
+	printHeader("%-19s %-20s %-20s %-8s %s\n", "TIME", "NAME", "PRESET", "APPLIED", "RESULT")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────")
+	for _, run := range status.History {
+		result := "ok"
+		switch {
+		case run.Skipped:
+			result = "skipped"
+		case run.Error != "":
+			result = run.Error
+		case len(run.Errors) > 0:
+			result = strings.Join(run.Errors, "; ")
+		}
+		fmt.Printf("%-19s %-20s %-20s %-8d %s\n", run.Time.Local().Format(time.DateTime), run.Schedule, run.Preset, run.Applied, result)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleTime(t *testing.T) {
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	from := time.Date(2026, 3, 6, 23, 45, 0, 0, taipei)
+	tests := []struct {
+		entry ScheduleEntry
+		want  time.Time
+	}{
+		{ScheduleEntry{At: "23:30", Timezone: "Asia/Taipei"}, time.Date(2026, 3, 7, 23, 30, 0, 0, taipei)},
+		{ScheduleEntry{At: "23:50", Timezone: "Asia/Taipei"}, time.Date(2026, 3, 6, 23, 50, 0, 0, taipei)},
+		{ScheduleEntry{Cron: "0 7 * * mon-fri", Timezone: "Asia/Taipei"}, time.Date(2026, 3, 9, 7, 0, 0, 0, taipei)},
+		{ScheduleEntry{At: "2026-12-31T18:00:00+08:00"}, time.Date(2026, 12, 31, 18, 0, 0, 0, taipei)},
+		{ScheduleEntry{At: "2026-01-01T00:00:00Z"}, time.Time{}},
+	}
+	for _, tt := range tests {
+		next, err := parseScheduleTime(tt.entry)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.entry, err)
+		}
+		if got := next(from); !got.Equal(tt.want) {
+			t.Errorf("%+v: next = %v, want %v", tt.entry, got, tt.want)
+		}
+	}
+
+	for _, entry := range []ScheduleEntry{
+		{},
+		{At: "23:30", Cron: "30 23 * * *"},
+		{At: "half past eleven"},
+		{Cron: "30 25 * * *"},
+		{At: "23:30", Timezone: "Mars/Olympus"},
+	} {
+		if _, err := parseScheduleTime(entry); err == nil {
+			t.Errorf("%+v accepted", entry)
+		}
+	}
+}
+
+func TestRouteScheduler(t *testing.T) {
+	engine, d := newTriggerEngine(t, false)
+	state, err := OpenStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := DefaultFeatureFlags()
+
+	if _, err := NewRouteScheduler([]ScheduleEntry{{Name: "close", Preset: "Night", Cron: "@daily"}}, engine, state, features); err == nil {
+		t.Fatal("schedule with unknown preset accepted")
+	}
+	now := time.Now()
+	s, err := NewRouteScheduler([]ScheduleEntry{
+		{Name: "doors", Preset: "cam1", At: now.Add(-30 * time.Minute).Format("15:04")},
+		{Name: "broken", Preset: "Cam3", Cron: "*/5 * * * *"},
+	}, engine, state, features)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 一小時後：doors 到期一次，每 5 分鐘的 broken 錯過 11 次只執行一次
+	s.plan(now.Add(-time.Hour))
+	s.runDue(context.Background(), now)
+	status := s.Status()
+	if len(status.History) != 2 {
+		t.Fatalf("history: %+v", status.History)
+	}
+	for _, run := range status.History {
+		switch run.Schedule {
+		case "doors":
+			if run.Error != "" || run.Applied != 2 || run.Source != "schedule" {
+				t.Errorf("doors run: %+v", run)
+			}
+		case "broken":
+			if run.Missed != 11 || !strings.Contains(run.Error, "problem") {
+				t.Errorf("broken run: %+v", run)
+			}
+		}
+	}
+	if got := txOf(t, d, "Amp-Left", "01"); got != "01@FOH-Console" {
+		t.Errorf("Amp-Left 01 after schedule: %q", got)
+	}
+	for _, sched := range status.Schedules {
+		if sched.Next == nil || !sched.Next.After(now) {
+			t.Errorf("%s: next run %v", sched.Name, sched.Next)
+		}
+	}
+
+	// 停用時記錄為略過，不套用
+	if err := features.SetRuntime(FeatureSchedules, false); err != nil {
+		t.Fatal(err)
+	}
+	s.plan(now.Add(-time.Hour))
+	s.runDue(context.Background(), now)
+	if last := s.Status().History[0]; !last.Skipped || last.Applied != 0 {
+		t.Errorf("run while disabled: %+v", last)
+	}
+
+	// 歷史保存在狀態檔
+	reopened, err := NewRouteScheduler(nil, engine, state, features)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reopened.Status().History); got != 4 {
+		t.Errorf("history after restart has %d runs, want 4", got)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"danteCS/internal/recovery"
+	"danteCS/internal/serial"
+)
+
+//==============================================================================
+// 序列埠控制 (RS-232)
+//==============================================================================
+
+// 舊型中控系統以 RS-232 控制，設定檔的 serial_control section 在序列埠上
+// 提供 ASCII 控制台 (指令見 console.go)：
+//
+//	"serial_control": {"device": "/dev/ttyUSB0", "baud": 9600}
+//
+// 固定 8N1、無流量控制。USB 轉 RS-232 線拔除或開啟失敗時每 serialReopen 重試。
+
+// serialReopen 序列埠開啟失敗或中斷後的重試間隔
+const serialReopen = 5 * time.Second
+
+// defaultSerialBaud 預設鮑率 (多數中控設備的預設值)
+const defaultSerialBaud = 9600
+
+// SerialControlConfig 設定檔的 serial_control section
+type SerialControlConfig struct {
+	Device string `json:"device"`         // 例如 /dev/ttyUSB0、/dev/ttyS0
+	Baud   int    `json:"baud,omitempty"` // 預設 9600
+	Echo   bool   `json:"echo,omitempty"` // 回顯輸入 (以終端機手動操作時使用)
+}
+
+// Validate 檢查裝置與鮑率
+func (c SerialControlConfig) Validate() error {
+	if c.Device == "" {
+		return errors.New("serial_control: device is required")
+	}
+	if c.Baud != 0 && !serial.ValidBaud(c.Baud) {
+		return fmt.Errorf("serial_control: unsupported baud rate %d (use one of %v)", c.Baud, serial.Bauds())
+	}
+	return nil
+}
+
+// StartSerialControl 在背景服務序列埠上的控制台直到 ctx 結束
+func StartSerialControl(ctx context.Context, cfg SerialControlConfig, console *ControlConsole) {
+	baud := cfg.Baud
+	if baud == 0 {
+		baud = defaultSerialBaud
+	}
+	recovery.GoLoop(ctx, "serial", func() {
+		failing := false
+		for ctx.Err() == nil {
+			port, err := serial.Open(cfg.Device, baud)
+			if err != nil {
+				if !failing {
+					logger.Warn("Failed to open the serial control port, retrying", "device", cfg.Device, "err", err)
+					failing = true
+				}
+			} else {
+				failing = false
+				logger.Info("Serial control listening", "device", cfg.Device, "baud", baud)
+				stop := context.AfterFunc(ctx, func() { port.Close() })
+				err = console.Serve(ctx, port, &consoleSession{source: "serial", echo: cfg.Echo})
+				stop()
+				port.Close()
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Warn("Serial control port closed, reopening", "device", cfg.Device, "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(serialReopen):
+			}
+		}
+	})
+}
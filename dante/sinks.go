@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 輸出 sink (golane.Sink 外掛)
+//==============================================================================
+
+// 設定檔 sinks section 的每個項目以 golane.RegisterSink 登記的種類建立一個
+// sink，訂閱事件匯流排的主題並依序寫入。內建 file (JSON lines)，其他種類
+// (專有協定、中控系統) 在 main 套件旁實作 golane.Sink 並於 init 登記：
+//
+//	"sinks": [
+//	  {"name": "archive", "type": "file", "config": {"path": "/var/log/golane-events.jsonl"}},
+//	  {"name": "crestron", "type": "crestron", "topics": ["alarm"], "config": {"host": "10.0.0.5"}}
+//	]
+
+const (
+	sinkQueueSize      = 64               // 每個 sink 的事件緩衝 (滿時由匯流排丟棄)
+	sinkDefaultTimeout = 10 * time.Second // 每次 Write 的逾時
+)
+
+// sinkTopics 可訂閱的主題 (未設定 topics 時除了每次刷新的完整設備列表都訂閱)
+var sinkTopics = []string{
+	golane.TopicDevices, golane.TopicDeviceOnline, golane.TopicDeviceOffline, golane.TopicRoute,
+	golane.TopicAlert, golane.TopicAlarm, golane.TopicDomainFailed, golane.TopicFirmware,
+}
+
+// ErrInvalidSink sink 設定錯誤
+var ErrInvalidSink = errors.New("invalid sink")
+
+// SinkConfig 設定檔 sinks section 的一個 sink
+type SinkConfig struct {
+	Name    string          `json:"name"`
+	Type    string          `json:"type"`              // golane.RegisterSink 登記的種類
+	Topics  []string        `json:"topics,omitempty"`  // 訂閱的主題 (空白表示 devices 以外全部)
+	Timeout string          `json:"timeout,omitempty"` // 每次 Write 的逾時 (Go duration，預設 10s)
+	Config  json.RawMessage `json:"config,omitempty"`  // 交給種類 factory 的設定
+
+	timeout time.Duration
+}
+
+// compileSinks 檢查 sink 並補上預設值 (種類的 config 在建立時才檢查)
+func compileSinks(sinks []SinkConfig) ([]SinkConfig, error) {
+	names := make(map[string]bool, len(sinks))
+	out := make([]SinkConfig, len(sinks))
+	for i, s := range sinks {
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("%w %q: %s", ErrInvalidSink, s.Name, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case s.Name == "":
+			return nil, fmt.Errorf("%w #%d: name is required", ErrInvalidSink, i+1)
+		case names[s.Name]:
+			return nil, fail("duplicate name")
+		case !slices.Contains(golane.SinkTypes(), s.Type):
+			return nil, fail("unknown type %q (%v)", s.Type, golane.SinkTypes())
+		}
+		names[s.Name] = true
+
+		for _, topic := range s.Topics {
+			if !slices.Contains(sinkTopics, topic) {
+				return nil, fail("unknown topic %q (%v)", topic, sinkTopics)
+			}
+		}
+		if len(s.Topics) == 0 {
+			s.Topics = slices.DeleteFunc(slices.Clone(sinkTopics), func(t string) bool { return t == golane.TopicDevices })
+		}
+		s.timeout = sinkDefaultTimeout
+		if s.Timeout != "" {
+			var err error
+			if s.timeout, err = time.ParseDuration(s.Timeout); err != nil || s.timeout <= 0 {
+				return nil, fail("invalid timeout %q", s.Timeout)
+			}
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// SinkStats sink 的寫入統計 (/api/sinks)
+type SinkStats struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Topics    []string  `json:"topics"`
+	Written   int64     `json:"written"`
+	Failed    int64     `json:"failed"`
+	Dropped   int64     `json:"dropped"` // 緩衝已滿而丟棄的事件
+	LastError string    `json:"last_error,omitempty"`
+	LastWrite time.Time `json:"last_write,omitempty"`
+}
+
+// sinkWorker 單一 sink 的訂閱與統計
+type sinkWorker struct {
+	cfg  SinkConfig
+	sink golane.Sink
+
+	mu    sync.Mutex
+	sub   *golane.BusSubscription // Start 之後才有
+	stats SinkStats
+}
+
+// SinkDispatcher 把事件寫入各 sink
+type SinkDispatcher struct {
+	workers []*sinkWorker
+}
+
+// NewSinkDispatcher 建立各 sink (configs 須先經過 compileSinks)，任何一個失敗時關閉已建立的
+func NewSinkDispatcher(configs []SinkConfig) (*SinkDispatcher, error) {
+	d := &SinkDispatcher{}
+	for _, cfg := range configs {
+		sink, err := golane.OpenSink(cfg.Type, cfg.Config)
+		if err != nil {
+			for _, w := range d.workers {
+				w.sink.Close()
+			}
+			return nil, fmt.Errorf("sink %q: %v", cfg.Name, err)
+		}
+		d.workers = append(d.workers, &sinkWorker{
+			cfg:   cfg,
+			sink:  sink,
+			stats: SinkStats{Name: cfg.Name, Type: cfg.Type, Topics: cfg.Topics},
+		})
+	}
+	return d, nil
+}
+
+// Start 訂閱事件並開始寫入，ctx 結束時關閉各 sink
+func (d *SinkDispatcher) Start(ctx context.Context, events *golane.Bus) {
+	for _, w := range d.workers {
+		sub := events.Subscribe(sinkQueueSize, w.cfg.Topics...)
+		w.mu.Lock()
+		w.sub = sub
+		w.mu.Unlock()
+		name := "sinks/" + w.cfg.Name
+		recovery.Go(name, func() {
+			defer w.sink.Close()
+			defer sub.Close()
+			recovery.Loop(ctx, name, func() {
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case e := <-sub.C:
+						w.write(ctx, e)
+					}
+				}
+			})
+		})
+	}
+}
+
+// write 寫入一則事件並更新統計
+func (w *sinkWorker) write(ctx context.Context, e golane.Event) {
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.timeout)
+	defer cancel()
+	err := w.sink.Write(ctx, e)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.LastWrite = time.Now()
+	if err != nil {
+		w.stats.Failed++
+		w.stats.LastError = err.Error()
+		logger.Warn("Sink write failed", "sink", w.cfg.Name, "topic", e.Topic, "err", err)
+		return
+	}
+	w.stats.Written++
+	w.stats.LastError = ""
+}
+
+// Stats 各 sink 的寫入統計 (依設定順序)
+func (d *SinkDispatcher) Stats() []SinkStats {
+	stats := make([]SinkStats, 0, len(d.workers))
+	for _, w := range d.workers {
+		w.mu.Lock()
+		s := w.stats
+		if w.sub != nil {
+			s.Dropped = w.sub.Dropped()
+		}
+		w.mu.Unlock()
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// handleSinks GET /api/sinks
+func (s *APIServer) handleSinks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.sinks.Stats())
+}
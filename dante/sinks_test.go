@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"danteCS/golane"
+)
+
+// recordingSink 記錄收到的事件 (測試登記的種類)
+type recordingSink struct {
+	mu     sync.Mutex
+	events []golane.Event
+	closed bool
+}
+
+func (s *recordingSink) Write(_ context.Context, e golane.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.Subject == "Broken" {
+		return errors.New("control system rejected event")
+	}
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+var testSinks = map[string]*recordingSink{}
+
+func init() {
+	golane.RegisterSink("test-recording", func(config json.RawMessage) (golane.Sink, error) {
+		var c struct{ ID string }
+		if err := json.Unmarshal(config, &c); err != nil || c.ID == "" {
+			return nil, errors.New("id is required")
+		}
+		s := &recordingSink{}
+		testSinks[c.ID] = s
+		return s, nil
+	})
+}
+
+func TestCompileSinks(t *testing.T) {
+	sinks, err := compileSinks([]SinkConfig{
+		{Name: "archive", Type: "file", Config: json.RawMessage(`{"path": "events.jsonl"}`)},
+		{Name: "alarms", Type: "test-recording", Topics: []string{golane.TopicAlarm}, Timeout: "2s"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sinks[0].Topics) != len(sinkTopics)-1 || sinks[0].timeout != sinkDefaultTimeout || sinks[1].timeout != 2*time.Second {
+		t.Errorf("sinks: %+v", sinks)
+	}
+
+	for _, bad := range [][]SinkConfig{
+		{{Type: "file"}},
+		{{Name: "a", Type: "file"}, {Name: "a", Type: "file"}},
+		{{Name: "a", Type: "crestron"}},
+		{{Name: "a", Type: "file", Topics: []string{"device.added"}}},
+		{{Name: "a", Type: "file", Timeout: "soon"}},
+	} {
+		if _, err := compileSinks(bad); !errors.Is(err, ErrInvalidSink) {
+			t.Errorf("%+v: err = %v", bad, err)
+		}
+	}
+}
+
+func TestSinkDispatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	configs, err := compileSinks([]SinkConfig{
+		{Name: "archive", Type: "file", Config: json.RawMessage(`{"path": "` + path + `"}`)},
+		{Name: "alarms", Type: "test-recording", Topics: []string{golane.TopicAlarm}, Config: json.RawMessage(`{"ID": "dispatcher"}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSinkDispatcher(append(configs, SinkConfig{Name: "bad", Type: "test-recording"})); err == nil {
+		t.Fatal("sink with invalid config accepted")
+	}
+
+	d, err := NewSinkDispatcher(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := golane.NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	d.Start(ctx, events)
+
+	events.Publish(golane.Event{Topic: golane.TopicDevices, Domain: "Dante1"}) // 預設不訂閱
+	events.Publish(golane.Event{Topic: golane.TopicDeviceOffline, Domain: "Dante1", Subject: "Stage-Box-A"})
+	events.Publish(golane.Event{Topic: golane.TopicAlarm, Domain: "Dante1", Subject: "Broken"})
+	events.Publish(golane.Event{Topic: golane.TopicAlarm, Domain: "Dante1", Subject: "Clock"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := d.Stats()
+		if stats[0].Written == 3 && stats[1].Written+stats[1].Failed == 2 {
+			if stats[1].Failed != 1 || stats[1].LastError != "" {
+				t.Errorf("alarms: %+v", stats[1])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stats: %+v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rec := testSinks["dispatcher"]
+	rec.mu.Lock()
+	if len(rec.events) != 1 || rec.events[0].Subject != "Clock" {
+		t.Errorf("recorded events: %+v", rec.events)
+	}
+	rec.mu.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var topics []string
+	for scan := bufio.NewScanner(f); scan.Scan(); {
+		var e golane.Event
+		if err := json.Unmarshal(scan.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		topics = append(topics, e.Topic)
+	}
+	if len(topics) != 3 || topics[0] != golane.TopicDeviceOffline {
+		t.Errorf("file sink topics: %v", topics)
+	}
+
+	// 結束時關閉 sink
+	cancel()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		rec.mu.Lock()
+		closed := rec.closed
+		rec.mu.Unlock()
+		if closed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sink not closed after stop")
+		}
+	}
+}
@@ -0,0 +1,714 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+//==============================================================================
+// 系統快照 (snapshot create / restore)
+//==============================================================================
+
+// preset 只保存訂閱；更換故障設備後還要重新設定設備名稱、通道標籤、取樣率
+// 與延遲，訂閱才會重新 resolve。snapshot create 把網域中每台設備的這些設定
+// 與訂閱寫成一個 .tar.gz 封存檔：manifest.json 記錄格式版本與設備檔案，
+// 每台設備一個 devices/NNN.json。snapshot restore 依名稱對應設備，名稱找不到
+// 時以型號找出唯一的新設備 (或以 -map 指定)，依序改名、設定取樣率、延遲
+// 與通道標籤，最後才套用所有訂閱 (發送設備與通道要先恢復名稱)。
+
+// snapshotVersion 目前的快照格式版本 (格式變更時加一，讀取時拒絕較新的版本)
+const snapshotVersion = 1
+
+// snapshotManifestName 封存檔中的 manifest 檔名
+const snapshotManifestName = "manifest.json"
+
+// snapshotMaxEntrySize 封存檔中單一檔案的上限 (避免解壓縮炸彈)
+const snapshotMaxEntrySize = 8 << 20
+
+// 還原步驟
+const (
+	SnapshotMatch      = "match" // 快照中的設備沒有對應的網路設備
+	SnapshotRename     = "rename"
+	SnapshotSampleRate = "sample_rate"
+	SnapshotLatency    = "latency"
+	SnapshotTxLabel    = "tx_label"
+	SnapshotRxLabel    = "rx_label"
+	SnapshotSubscribe  = "subscribe"
+	SnapshotRead       = "read" // 讀取目前設定失敗時記錄，該設備不套用其他步驟
+)
+
+// 還原步驟的狀態
+const (
+	SnapshotPlanned = "planned" // -dry-run
+	SnapshotApplied = "applied"
+	SnapshotFailed  = "failed"
+	SnapshotSkipped = "skipped" // 設備不在網路上或沒有該通道
+)
+
+// SettingsController 快照需要的設備設定操作 (本機網域)
+type SettingsController interface {
+	RouteController
+	TxChannels(ctx context.Context, device string) ([]dante.Channel, error)
+	DeviceSettings(ctx context.Context, device string) (dante.DeviceSettings, error)
+	RenameDevice(ctx context.Context, device, newName string) error
+	SetTxChannelName(ctx context.Context, device string, channelID int, name string) error
+	SetRxChannelName(ctx context.Context, device string, channelID int, name string) error
+	SetLatency(ctx context.Context, device string, latencyUs int) error
+	SetSampleRate(ctx context.Context, device string, sampleRate int) error
+}
+
+var _ SettingsController = (*dante.Domain)(nil)
+
+// SnapshotManifest 快照的 manifest.json
+type SnapshotManifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Host    string    `json:"host,omitempty"`
+	Domain  string    `json:"domain,omitempty"`
+	Files   []string  `json:"files"` // 設備檔案，依設備名稱排序
+}
+
+// DeviceSnapshot 一台設備的設定與訂閱
+type DeviceSnapshot struct {
+	Name       string              `json:"name"`
+	Model      string              `json:"model,omitempty"`
+	MacAddress string              `json:"mac_address,omitempty"`   // 只供參考 (更換設備後不同)
+	Serial     string              `json:"serial_number,omitempty"` // 只供參考 (同上，ConMon 監控中才有)
+	SampleRate int                 `json:"sample_rate,omitempty"`   // 0 表示未知，不還原
+	LatencyUs  int                 `json:"latency_us,omitempty"`    // 0 表示未知，不還原
+	TxChannels []dante.Channel     `json:"tx_channels"`
+	RxChannels []SnapshotRxChannel `json:"rx_channels"`
+}
+
+// SnapshotRxChannel 接收通道的標籤與訂閱 (TxDevice 空白表示未訂閱)
+type SnapshotRxChannel struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	TxDevice  string `json:"tx_device,omitempty"`
+	TxChannel string `json:"tx_channel,omitempty"`
+}
+
+// Snapshot 快照封存檔的內容
+type Snapshot struct {
+	Manifest SnapshotManifest
+	Devices  []DeviceSnapshot
+}
+
+// CaptureSnapshot 讀取所有設備的設定與訂閱
+// 任何一台設備讀取失敗都回傳錯誤 (不保存不完整的快照)
+func CaptureSnapshot(ctx context.Context, domain string, devices []dante.Device, sc SettingsController) (*Snapshot, error) {
+	devices = append([]dante.Device{}, devices...)
+	dante.SortDevices(devices, dante.DeviceOrder{Key: dante.SortByName})
+
+	host, _ := os.Hostname()
+	snap := &Snapshot{
+		Manifest: SnapshotManifest{Version: snapshotVersion, Created: time.Now().UTC(), Host: host, Domain: domain, Files: []string{}},
+		Devices:  []DeviceSnapshot{},
+	}
+	for _, dev := range devices {
+		settings, err := sc.DeviceSettings(ctx, dev.Name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read settings of %s: %w", dev.Name, err)
+		}
+		tx, err := sc.TxChannels(ctx, dev.Name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read TX channels of %s: %w", dev.Name, err)
+		}
+		subs, err := sc.ListSubscriptions(ctx, dev.Name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read RX channels of %s: %w", dev.Name, err)
+		}
+
+		ds := DeviceSnapshot{
+			Name:       dev.Name,
+			Model:      dev.Model,
+			MacAddress: dev.MacAddress,
+			Serial:     dev.SerialNumber,
+			SampleRate: settings.SampleRate,
+			LatencyUs:  settings.LatencyUs,
+			TxChannels: append([]dante.Channel{}, tx...),
+			RxChannels: []SnapshotRxChannel{},
+		}
+		for _, sub := range subs {
+			rx := SnapshotRxChannel{ID: sub.ChannelID, Name: sub.Channel}
+			if sub.Subscribed() {
+				rx.TxDevice, rx.TxChannel = sub.TxDevice, sub.TxChannel
+			}
+			ds.RxChannels = append(ds.RxChannels, rx)
+		}
+		snap.Devices = append(snap.Devices, ds)
+		snap.Manifest.Files = append(snap.Manifest.Files, fmt.Sprintf("devices/%03d.json", len(snap.Devices)))
+	}
+	return snap, nil
+}
+
+//------------------------------------------------------------------------------
+// 封存檔
+//------------------------------------------------------------------------------
+
+// WriteSnapshot 把快照寫成 .tar.gz
+func WriteSnapshot(w io.Writer, snap *Snapshot) error {
+	if len(snap.Manifest.Files) != len(snap.Devices) {
+		return fmt.Errorf("snapshot has %d devices but %d files", len(snap.Devices), len(snap.Manifest.Files))
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	write := func(name string, v any) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: snap.Manifest.Created}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+	if err := write(snapshotManifestName, snap.Manifest); err != nil {
+		return err
+	}
+	for i, dev := range snap.Devices {
+		if err := write(snap.Manifest.Files[i], dev); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadSnapshot 讀取快照封存檔 (拒絕比目前程式新的格式版本)
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a snapshot archive: %w", err)
+	}
+	defer gz.Close()
+
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a snapshot archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Size > snapshotMaxEntrySize {
+			return nil, fmt.Errorf("%s: too large (%d bytes)", hdr.Name, hdr.Size)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+		entries[path.Clean(hdr.Name)] = data
+	}
+
+	data, ok := entries[snapshotManifestName]
+	if !ok {
+		return nil, fmt.Errorf("not a snapshot archive: %s missing", snapshotManifestName)
+	}
+	snap := &Snapshot{Devices: []DeviceSnapshot{}}
+	if err := json.Unmarshal(data, &snap.Manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", snapshotManifestName, err)
+	}
+	if snap.Manifest.Version == 0 {
+		return nil, fmt.Errorf("%s: no format version", snapshotManifestName)
+	}
+	if snap.Manifest.Version > snapshotVersion {
+		return nil, fmt.Errorf("snapshot has version %d, newer than supported %d", snap.Manifest.Version, snapshotVersion)
+	}
+
+	names := make(map[string]bool)
+	for _, file := range snap.Manifest.Files {
+		data, ok := entries[path.Clean(file)]
+		if !ok {
+			return nil, fmt.Errorf("snapshot is incomplete: %s missing", file)
+		}
+		var dev DeviceSnapshot
+		if err := json.Unmarshal(data, &dev); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if dev.Name == "" {
+			return nil, fmt.Errorf("%s: device without name", file)
+		}
+		if names[dev.Name] {
+			return nil, fmt.Errorf("%s: duplicate device %s", file, dev.Name)
+		}
+		names[dev.Name] = true
+		snap.Devices = append(snap.Devices, dev)
+	}
+	return snap, nil
+}
+
+// SaveSnapshotFile 寫入快照檔
+func SaveSnapshotFile(path string, snap *Snapshot) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteSnapshot(f, snap); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadSnapshotFile 讀取快照檔
+func LoadSnapshotFile(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	snap, err := ReadSnapshot(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return snap, nil
+}
+
+//------------------------------------------------------------------------------
+// 還原
+//------------------------------------------------------------------------------
+
+// SnapshotRestoreOptions 還原選項
+type SnapshotRestoreOptions struct {
+	Map    map[string]string // 快照中的設備名稱 → 網路上要套用的設備 (更換的設備)
+	DryRun bool              // 只列出會變更的設定
+}
+
+// SnapshotStep 還原的一個步驟
+type SnapshotStep struct {
+	Device string `json:"device"`           // 快照中的設備名稱
+	Target string `json:"target,omitempty"` // 套用的設備 (還原前的名稱)
+	Action string `json:"action"`
+	Detail string `json:"detail,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SnapshotRestoreReport 還原報告
+type SnapshotRestoreReport struct {
+	DryRun    bool           `json:"dry_run"`
+	Steps     []SnapshotStep `json:"steps"`
+	Planned   int            `json:"planned"`
+	Applied   int            `json:"applied"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped"`
+	Unchanged int            `json:"unchanged"` // 已經與快照相同的設定
+}
+
+// add 記錄步驟並計數
+func (r *SnapshotRestoreReport) add(step SnapshotStep) {
+	switch step.Status {
+	case SnapshotPlanned:
+		r.Planned++
+	case SnapshotApplied:
+		r.Applied++
+	case SnapshotFailed:
+		r.Failed++
+	case SnapshotSkipped:
+		r.Skipped++
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+// run 執行一個步驟 (dry-run 時只記錄)，回傳是否已套用
+func (r *SnapshotRestoreReport) run(dryRun bool, step SnapshotStep, fn func() error) bool {
+	if dryRun {
+		step.Status = SnapshotPlanned
+	} else if err := fn(); err != nil {
+		step.Status = SnapshotFailed
+		step.Error = err.Error()
+	} else {
+		step.Status = SnapshotApplied
+	}
+	r.add(step)
+	return step.Status == SnapshotApplied
+}
+
+// MatchSnapshotDevices 決定快照中每台設備要套用到網路上的哪台設備
+// 依序使用 mapping、相同名稱，最後是型號相同且沒有其他對應的設備 (更換後的新設備)；
+// 找不到的設備記錄在 missing (原因)
+func MatchSnapshotDevices(snap *Snapshot, online []dante.Device, mapping map[string]string) (map[string]string, map[string]string) {
+	targets := make(map[string]string)
+	missing := make(map[string]string)
+	claimed := make(map[string]bool) // 已對應的網路設備
+	isOnline := func(name string) bool {
+		return slices.ContainsFunc(online, func(d dante.Device) bool { return d.Name == name })
+	}
+
+	for _, dev := range snap.Devices {
+		target, ok := mapping[dev.Name]
+		if !ok {
+			continue
+		}
+		if !isOnline(target) {
+			missing[dev.Name] = fmt.Sprintf("mapped device %s is not on the network", target)
+			continue
+		}
+		targets[dev.Name] = target
+		claimed[target] = true
+	}
+	for _, dev := range snap.Devices {
+		if _, mapped := mapping[dev.Name]; mapped || claimed[dev.Name] || !isOnline(dev.Name) {
+			continue
+		}
+		targets[dev.Name] = dev.Name
+		claimed[dev.Name] = true
+	}
+
+	// 其餘的設備以型號對應：同型號只剩一台未對應的快照設備與一台未對應的網路設備
+	var rest []DeviceSnapshot
+	restByModel := make(map[string]int)
+	for _, dev := range snap.Devices {
+		_, matched := targets[dev.Name]
+		_, failed := missing[dev.Name]
+		if !matched && !failed {
+			rest = append(rest, dev)
+			restByModel[dev.Model]++
+		}
+	}
+	for _, dev := range rest {
+		var candidates []string
+		for _, d := range online {
+			if !claimed[d.Name] && d.Model == dev.Model {
+				candidates = append(candidates, d.Name)
+			}
+		}
+		switch {
+		case dev.Model != "" && restByModel[dev.Model] == 1 && len(candidates) == 1:
+			targets[dev.Name] = candidates[0]
+		case len(candidates) == 0:
+			missing[dev.Name] = "not on the network"
+		default:
+			missing[dev.Name] = fmt.Sprintf("not on the network and %d %s devices could replace it (use -map %s=<device>)",
+				len(candidates), dev.Model, dev.Name)
+		}
+	}
+	return targets, missing
+}
+
+// RestoreSnapshot 把快照套用到網路上的設備
+// 每台設備依序改名、設定取樣率、延遲與通道標籤，所有設備完成後才套用訂閱；
+// 失敗的步驟記錄在報告中並繼續下一步，已經相同的設定不會重新寫入
+func RestoreSnapshot(ctx context.Context, snap *Snapshot, online []dante.Device, sc SettingsController, opts SnapshotRestoreOptions) SnapshotRestoreReport {
+	report := SnapshotRestoreReport{DryRun: opts.DryRun, Steps: []SnapshotStep{}}
+	targets, missing := MatchSnapshotDevices(snap, online, opts.Map)
+
+	type restored struct {
+		name    string               // 目前的設備名稱
+		rxNames map[int]string       // 接收通道編號 → 目前名稱
+		subs    []dante.Subscription // 還原前的訂閱
+	}
+	states := make(map[string]*restored)
+
+	for _, dev := range snap.Devices {
+		target, ok := targets[dev.Name]
+		if !ok {
+			report.add(SnapshotStep{Device: dev.Name, Action: SnapshotMatch, Status: SnapshotSkipped, Error: missing[dev.Name]})
+			continue
+		}
+
+		settings, err := sc.DeviceSettings(ctx, target)
+		var tx []dante.Channel
+		var subs []dante.Subscription
+		if err == nil {
+			tx, err = sc.TxChannels(ctx, target)
+		}
+		if err == nil {
+			subs, err = sc.ListSubscriptions(ctx, target)
+		}
+		if err != nil {
+			report.add(SnapshotStep{Device: dev.Name, Target: target, Action: SnapshotRead, Status: SnapshotFailed, Error: err.Error()})
+			continue
+		}
+
+		st := &restored{name: target, rxNames: make(map[int]string), subs: subs}
+		for _, sub := range subs {
+			st.rxNames[sub.ChannelID] = sub.Channel
+		}
+		states[dev.Name] = st
+		step := func(action, detail string) SnapshotStep {
+			return SnapshotStep{Device: dev.Name, Target: target, Action: action, Detail: detail}
+		}
+
+		if target != dev.Name {
+			if report.run(opts.DryRun, step(SnapshotRename, target+" → "+dev.Name), func() error {
+				return sc.RenameDevice(ctx, target, dev.Name)
+			}) {
+				st.name = dev.Name
+			}
+		}
+		if dev.SampleRate == 0 || dev.SampleRate == settings.SampleRate {
+			report.Unchanged++
+		} else {
+			report.run(opts.DryRun, step(SnapshotSampleRate, fmt.Sprintf("%d → %d Hz", settings.SampleRate, dev.SampleRate)), func() error {
+				return sc.SetSampleRate(ctx, st.name, dev.SampleRate)
+			})
+		}
+		if dev.LatencyUs == 0 || dev.LatencyUs == settings.LatencyUs {
+			report.Unchanged++
+		} else {
+			report.run(opts.DryRun, step(SnapshotLatency, fmt.Sprintf("%d → %d µs", settings.LatencyUs, dev.LatencyUs)), func() error {
+				return sc.SetLatency(ctx, st.name, dev.LatencyUs)
+			})
+		}
+
+		for _, ch := range dev.TxChannels {
+			i := slices.IndexFunc(tx, func(c dante.Channel) bool { return c.ID == ch.ID })
+			switch {
+			case i < 0:
+				report.add(SnapshotStep{Device: dev.Name, Target: target, Action: SnapshotTxLabel, Detail: fmt.Sprintf("%d: %s", ch.ID, ch.Name),
+					Status: SnapshotSkipped, Error: fmt.Sprintf("TX channel %d not on %s", ch.ID, target)})
+			case tx[i].Name == ch.Name:
+				report.Unchanged++
+			default:
+				report.run(opts.DryRun, step(SnapshotTxLabel, fmt.Sprintf("%d: %s → %s", ch.ID, tx[i].Name, ch.Name)), func() error {
+					return sc.SetTxChannelName(ctx, st.name, ch.ID, ch.Name)
+				})
+			}
+		}
+		for _, ch := range dev.RxChannels {
+			current, ok := st.rxNames[ch.ID]
+			switch {
+			case !ok:
+				report.add(SnapshotStep{Device: dev.Name, Target: target, Action: SnapshotRxLabel, Detail: fmt.Sprintf("%d: %s", ch.ID, ch.Name),
+					Status: SnapshotSkipped, Error: fmt.Sprintf("RX channel %d not on %s", ch.ID, target)})
+			case current == ch.Name:
+				report.Unchanged++
+			default:
+				if report.run(opts.DryRun, step(SnapshotRxLabel, fmt.Sprintf("%d: %s → %s", ch.ID, current, ch.Name)), func() error {
+					return sc.SetRxChannelName(ctx, st.name, ch.ID, ch.Name)
+				}) {
+					st.rxNames[ch.ID] = ch.Name
+				}
+			}
+		}
+	}
+
+	// 訂閱以快照中的名稱指定發送端，所以等所有設備與通道都改名後才套用
+	for _, dev := range snap.Devices {
+		st, ok := states[dev.Name]
+		if !ok {
+			continue
+		}
+		for _, ch := range dev.RxChannels {
+			rxName, ok := st.rxNames[ch.ID]
+			if !ok {
+				continue
+			}
+			var current SnapshotRxChannel
+			if i := slices.IndexFunc(st.subs, func(s dante.Subscription) bool { return s.ChannelID == ch.ID }); i >= 0 && st.subs[i].Subscribed() {
+				current.TxDevice, current.TxChannel = st.subs[i].TxDevice, st.subs[i].TxChannel
+			}
+			if current.TxDevice == ch.TxDevice && current.TxChannel == ch.TxChannel {
+				report.Unchanged++
+				continue
+			}
+			detail := rxName + " → -"
+			if ch.TxDevice != "" {
+				detail = rxName + " → " + ch.TxChannel + "@" + ch.TxDevice
+			}
+			report.run(opts.DryRun, SnapshotStep{Device: dev.Name, Target: targets[dev.Name], Action: SnapshotSubscribe, Detail: detail}, func() error {
+				return sc.Subscribe(ctx, st.name, rxName, ch.TxDevice, ch.TxChannel)
+			})
+		}
+	}
+	return report
+}
+
+// PrintSnapshotRestoreReport 顯示還原報告
+func PrintSnapshotRestoreReport(w io.Writer, report SnapshotRestoreReport) {
+	fmt.Fprintf(w, "%-8s %-20s %-12s %-36s %s\n", "STATUS", "DEVICE", "ACTION", "CHANGE", "ERROR")
+	fmt.Fprintln(w, "────────────────────────────────────────────────────────────────────────────")
+	for _, s := range report.Steps {
+		device := s.Device
+		if s.Target != "" && s.Target != s.Device {
+			device += " (" + s.Target + ")"
+		}
+		fmt.Fprintf(w, "%-8s %-20s %-12s %-36s %s\n", s.Status, device, s.Action, s.Detail, s.Error)
+	}
+	if report.DryRun {
+		fmt.Fprintf(w, "\n%d planned, %d skipped, %d unchanged (dry run, nothing applied)\n", report.Planned, report.Skipped, report.Unchanged)
+		return
+	}
+	fmt.Fprintf(w, "\n%d applied, %d failed, %d skipped, %d unchanged\n", report.Applied, report.Failed, report.Skipped, report.Unchanged)
+}
+
+// parseSnapshotMap 解析 -map (old=new,...)
+func parseSnapshotMap(spec string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(item, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid -map entry %q, expected <snapshot-device>=<device>", item)
+		}
+		if _, dup := mapping[from]; dup {
+			return nil, fmt.Errorf("-map has %s twice", from)
+		}
+		mapping[from] = to
+	}
+	return mapping, nil
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newSnapshotCommand golane snapshot create|restore
+// 改名與設定需要直接操作 SDK，只支援本機網域 (遠端 monitor 沒有對應的 API)
+func newSnapshotCommand() *Command {
+	createFlags := newFlagSet("snapshot create")
+	createLog := addLogFlags(createFlags)
+	createIfaces := addInterfaceFlags(createFlags)
+	createWait := createFlags.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	out := createFlags.String("out", "", "archive file (default snapshot-<time>.tar.gz)")
+
+	create := &Command{
+		Name:  "create",
+		Short: "Save device names, channel labels, sample rates, latency and subscriptions to an archive",
+		Flags: createFlags,
+		log:   createLog,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+
+			d, err := openSnapshotDomain(ctx, createIfaces, *createWait, false)
+			if err != nil {
+				return err
+			}
+			defer d.Cleanup()
+			snap, err := CaptureSnapshot(ctx, d.Name, d.GetDevices(), d)
+			if err != nil {
+				return err
+			}
+			path := *out
+			if path == "" {
+				path = "snapshot-" + snap.Manifest.Created.Format("20060102-150405") + ".tar.gz"
+			}
+			if err := SaveSnapshotFile(path, snap); err != nil {
+				return err
+			}
+			fmt.Printf("Saved %d devices of %s to %s\n", len(snap.Devices), d.Name, path)
+			return nil
+		},
+	}
+
+	restoreFlags := newFlagSet("snapshot restore")
+	restoreLog := addLogFlags(restoreFlags)
+	restoreIfaces := addInterfaceFlags(restoreFlags)
+	restoreWait := restoreFlags.Duration("wait", 3*time.Second, "how long to wait for device discovery")
+	mapSpec := restoreFlags.String("map", "", "comma-separated <snapshot-device>=<device> pairs for replaced devices the model cannot tell apart")
+	dryRun := restoreFlags.Bool("dry-run", false, "show the changes without applying them")
+	restoreStateDir := restoreFlags.String("state-dir", ".", "state directory whose audit log records the applied changes")
+	jsonOut := restoreFlags.Bool("json", false, "print the report as JSON")
+
+	restore := &Command{
+		Name:  "restore",
+		Short: "Apply a snapshot archive to the devices on the network",
+		Args:  "<file.tar.gz>",
+		Flags: restoreFlags,
+		log:   restoreLog,
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			mapping, err := parseSnapshotMap(*mapSpec)
+			if err != nil {
+				return err
+			}
+			snap, err := LoadSnapshotFile(args[0])
+			if err != nil {
+				return err
+			}
+			audit, err := OpenAuditLog(*restoreStateDir)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+			ctx = withAuditNote(cliAuditContext(ctx), "snapshot "+filepath.Base(args[0]))
+
+			d, err := openSnapshotDomain(ctx, restoreIfaces, *restoreWait, !*dryRun)
+			if err != nil {
+				return err
+			}
+			defer d.Cleanup()
+			report := RestoreSnapshot(ctx, snap, d.GetDevices(), auditSettings(audit, d.Name, d), SnapshotRestoreOptions{Map: mapping, DryRun: *dryRun})
+			if *jsonOut {
+				if err := printJSON(report); err != nil {
+					return err
+				}
+			} else {
+				PrintSnapshotRestoreReport(os.Stdout, report)
+			}
+			if report.Failed > 0 || report.Skipped > 0 {
+				return fmt.Errorf("%d of %d steps not applied", report.Failed+report.Skipped, len(report.Steps))
+			}
+			return nil
+		},
+	}
+
+	return &Command{
+		Name:  "snapshot",
+		Short: "Save the settings of every device to an archive and restore them after a device swap",
+		Sub:   []*Command{create, restore},
+	}
+}
+
+// openSnapshotDomain 開啟本機主要網域並等待設備發現
+// 取樣率經由 ConMon 設定，monitor 為 true 時在發現設備前啟動 (連線需要時間)
+func openSnapshotDomain(ctx context.Context, ifaces *interfaceFlags, wait time.Duration, monitor bool) (*dante.Domain, error) {
+	if err := ifaces.checkTiming(wait); err != nil {
+		return nil, err
+	}
+	detector, err := ifaces.detect()
+	if err != nil {
+		return nil, err
+	}
+	d, err := ifaces.openPrimaryDomain(ctx, detector)
+	if err != nil {
+		return nil, err
+	}
+	if monitor {
+		if err := d.StartMonitoring(); err != nil {
+			d.Logger().Warn("Sample rates cannot be restored", "err", err)
+		}
+	}
+	if err := discover(ctx, d, wait); err != nil {
+		d.Cleanup()
+		return nil, err
+	}
+	return d, nil
+}
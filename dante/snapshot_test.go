@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+// newSnapshotDomain 以模擬設定建立已發現設備的網域
+func newSnapshotDomain(t *testing.T, cfg *dante.SimulationConfig) *dante.Domain {
+	t.Helper()
+	ctx := context.Background()
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"}, dante.NewSimulatedSDK(cfg))
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(ctx)
+	return d
+}
+
+func TestSnapshotRestoreAfterDeviceSwap(t *testing.T) {
+	ctx := context.Background()
+	d := newSnapshotDomain(t, dante.DefaultSimulationConfig())
+	for _, err := range []error{
+		d.SetTxChannelName(ctx, "Stage-Box-A", 1, "Vocal"),
+		d.SetRxChannelName(ctx, "Amp-Left", 1, "L-In"),
+		d.Subscribe(ctx, "Amp-Left", "L-In", "Stage-Box-A", "Vocal"),
+		d.SetLatency(ctx, "Amp-Left", 2000),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snap, err := CaptureSnapshot(ctx, d.Name, d.GetDevices(), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, snap); err != nil {
+		t.Fatal(err)
+	}
+	snap, err = ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Manifest.Version != snapshotVersion || len(snap.Devices) != 4 {
+		t.Fatalf("unexpected snapshot: %+v", snap.Manifest)
+	}
+
+	// Amp-Left 故障更換：新設備是出廠名稱與設定，Stage-Box-A 的標籤被重設
+	cfg := dante.DefaultSimulationConfig()
+	cfg.Devices[2].Name = "PA-4D-0a1b2c"
+	swapped := newSnapshotDomain(t, cfg)
+
+	plan := RestoreSnapshot(ctx, snap, swapped.GetDevices(), swapped, SnapshotRestoreOptions{DryRun: true})
+	if plan.Planned != 5 || plan.Applied != 0 || plan.Skipped != 0 {
+		t.Fatalf("dry run: %+v", plan)
+	}
+	if _, err := swapped.DeviceSettings(ctx, "PA-4D-0a1b2c"); err != nil {
+		t.Fatalf("dry run changed the network: %v", err)
+	}
+
+	report := RestoreSnapshot(ctx, snap, swapped.GetDevices(), swapped, SnapshotRestoreOptions{})
+	if report.Applied != 5 || report.Failed != 0 || report.Skipped != 0 {
+		var out strings.Builder
+		PrintSnapshotRestoreReport(&out, report)
+		t.Fatalf("restore:\n%s", out.String())
+	}
+	if report.Steps[0].Action != SnapshotRename || report.Steps[0].Target != "PA-4D-0a1b2c" {
+		t.Fatalf("first step = %+v, want rename of the new device", report.Steps[0])
+	}
+	subs, err := swapped.ListSubscriptions(ctx, "Amp-Left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs[0].Channel != "L-In" || subs[0].TxChannel != "Vocal" || !strings.HasPrefix(subs[0].StatusText(), "connected") {
+		t.Fatalf("restored RX channel: %+v (%s)", subs[0], subs[0].StatusText())
+	}
+	if settings, _ := swapped.DeviceSettings(ctx, "Amp-Left"); settings.LatencyUs != 2000 {
+		t.Fatalf("latency = %d, want 2000", settings.LatencyUs)
+	}
+
+	// 再次還原沒有需要變更的設定
+	swapped.RefreshDevices(ctx)
+	again := RestoreSnapshot(ctx, snap, swapped.GetDevices(), swapped, SnapshotRestoreOptions{})
+	if len(again.Steps) != 0 {
+		t.Fatalf("second restore: %+v", again.Steps)
+	}
+}
+
+func TestSnapshotMatchDevices(t *testing.T) {
+	snap := &Snapshot{Devices: []DeviceSnapshot{
+		{Name: "Amp-Left", Model: "PA-4D"},
+		{Name: "Amp-Right", Model: "PA-4D"},
+		{Name: "FOH", Model: "DL32"},
+	}}
+	online := []dante.Device{
+		{Name: "FOH", Model: "DL32"},
+		{Name: "PA-4D-1", Model: "PA-4D"},
+		{Name: "PA-4D-2", Model: "PA-4D"},
+	}
+
+	// 兩台同型號設備都更換時無法判斷對應
+	targets, missing := MatchSnapshotDevices(snap, online, nil)
+	if targets["FOH"] != "FOH" || len(targets) != 1 || !strings.Contains(missing["Amp-Left"], "-map") {
+		t.Fatalf("targets %v, missing %v", targets, missing)
+	}
+
+	// 指定一台後另一台以型號對應
+	targets, missing = MatchSnapshotDevices(snap, online, map[string]string{"Amp-Left": "PA-4D-2"})
+	if targets["Amp-Left"] != "PA-4D-2" || targets["Amp-Right"] != "PA-4D-1" || len(missing) != 0 {
+		t.Fatalf("targets %v, missing %v", targets, missing)
+	}
+
+	if _, missing = MatchSnapshotDevices(snap, online, map[string]string{"FOH": "FOH-2"}); missing["FOH"] == "" {
+		t.Fatal("mapping to an offline device accepted")
+	}
+}
+
+func TestSnapshotArchiveVersion(t *testing.T) {
+	snap := &Snapshot{Manifest: SnapshotManifest{Version: snapshotVersion + 1, Created: time.Now()}}
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, snap); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSnapshot(&buf); err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("newer snapshot: err = %v", err)
+	}
+	if _, err := ReadSnapshot(strings.NewReader("not gzip")); err == nil {
+		t.Fatal("read garbage as a snapshot")
+	}
+
+	if _, err := parseSnapshotMap("Amp-Left=PA-4D-1, Amp-Right"); err == nil {
+		t.Fatal("-map entry without device accepted")
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"danteCS/golane"
+	"danteCS/internal/dante"
+	"danteCS/internal/recovery"
+	"danteCS/internal/snmp"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// SNMP agent
+//==============================================================================
+
+// 設施的 NMS 只支援 SNMP：monitor 以 -snmp 啟動唯讀的 v1/v2c agent，提供
+// 網域、設備與 Dante 介面表格，設備離線/上線與網域失敗時送出 v2c trap。
+// 預設的 OID 位於 NET-SNMP 的 netSnmpPlaypen (1.3.6.1.4.1.8072.9999.9999，
+// 供本地使用)，有自己的 enterprise number 時以 -snmp-oid-base 改掉。
+//
+//	base.1.1.<d>           golaneDomainEntry     (d: 網域編號，依加入順序)
+//	  .1 index  .2 name  .3 state (starting 1, running 2, failed 3, stopped 4)
+//	  .4 interface  .5 ipAddress  .6 deviceCount  .7 restarts  .8 lastError  .9 stale (TruthValue)
+//	base.2.1.<d>.<i>       golaneDeviceEntry     (i: 設備在網域中依名稱排序的位置)
+//	  .1 name  .2 model  .3 ipAddress  .4 macAddress  .5 linkSpeed (Mbps)
+//	  .6 secondaryIp  .7 secondarySpeed  .8 redundancy (redundant 1, primary-only 2, secondary-down 3, primary-down 4)
+//	  .9 danteVersion
+//	base.3.1.<n>           golaneInterfaceEntry  (n: Dante 介面編號)
+//	  .1 name  .2 operStatus (up 1, down 2)  .3 ipAddress  .4 macAddress  .5 speed (Mbps，未知為 0)  .6 vlanId
+//	base.4.0.1/.2/.3       golaneDeviceLost / golaneDeviceFound / golaneDomainFailed
+//	base.4.1.1-4.0         trap 的內容：網域、設備、地址、說明
+//
+// 設備列表變動時設備的位置會改變，NMS 應以 name 欄識別設備。
+
+// DefaultSNMPBase 預設的 OID 前綴 (NET-SNMP-MIB::netSnmpPlaypen)
+const DefaultSNMPBase = "1.3.6.1.4.1.8072.9999.9999"
+
+// sysfsNet Linux 介面速度所在的目錄
+const sysfsNet = "/sys/class/net"
+
+// 標準 system group
+var (
+	sysDescr    = snmp.MustParseOID("1.3.6.1.2.1.1.1.0")
+	sysObjectID = snmp.MustParseOID("1.3.6.1.2.1.1.2.0")
+	sysName     = snmp.MustParseOID("1.3.6.1.2.1.1.5.0")
+)
+
+// SNMPOptions -snmp 參數
+type SNMPOptions struct {
+	Addr          string // 監聽地址 (空白表示停用)
+	Community     string
+	Traps         string // trap 目的地 (逗號分隔的 host[:port])
+	TrapCommunity string
+	Base          string // OID 前綴
+}
+
+// DefaultSNMPOptions 預設值
+func DefaultSNMPOptions() SNMPOptions {
+	return SNMPOptions{Community: "public", Base: DefaultSNMPBase}
+}
+
+// Validate 檢查參數
+func (o SNMPOptions) Validate() error {
+	if o.Addr == "" {
+		return nil
+	}
+	if o.Community == "" {
+		return errors.New("-snmp-community must not be empty")
+	}
+	if _, err := snmp.ParseOID(o.Base); err != nil {
+		return fmt.Errorf("-snmp-oid-base: %v", err)
+	}
+	return nil
+}
+
+// snmpMIB 由 supervisor 快照與 Dante 介面組成的變數
+type snmpMIB struct {
+	base     snmp.OID
+	domains  *supervisor.Supervisor
+	detector *NetworkDetector
+	uptime   func() uint32
+	hostname string
+}
+
+// 表格的 OID
+func (m *snmpMIB) domainEntry() snmp.OID    { return m.base.Append(1, 1) }
+func (m *snmpMIB) deviceEntry() snmp.OID    { return m.base.Append(2, 1) }
+func (m *snmpMIB) interfaceEntry() snmp.OID { return m.base.Append(3, 1) }
+func (m *snmpMIB) trap(n uint32) snmp.OID   { return m.base.Append(4, 0, n) }
+func (m *snmpMIB) notifyObject(n uint32) snmp.OID {
+	return m.base.Append(4, 1, n, 0)
+}
+
+// Variables 實作 snmp.Source
+func (m *snmpMIB) Variables() []snmp.VarBind {
+	vars := []snmp.VarBind{
+		{OID: sysDescr, Value: snmp.String("GOlane Dante network monitor")},
+		{OID: sysObjectID, Value: snmp.ObjectID(m.base)},
+		{OID: snmp.SysUpTime, Value: snmp.TimeTicks(m.uptime())},
+		{OID: sysName, Value: snmp.String(m.hostname)},
+	}
+	add := func(entry snmp.OID, column uint32, index []uint32, v snmp.Value) {
+		vars = append(vars, snmp.VarBind{OID: entry.Append(column).Append(index...), Value: v})
+	}
+
+	for i, snap := range m.domains.Snapshots() {
+		d := uint32(i + 1)
+		idx := []uint32{d}
+		e := m.domainEntry()
+		add(e, 1, idx, snmp.Integer(int(d)))
+		add(e, 2, idx, snmp.String(snap.Name))
+		add(e, 3, idx, snmp.Integer(snmpDomainState(snap.State)))
+		add(e, 4, idx, snmp.String(snap.Interface))
+		add(e, 5, idx, snmpIP(snap.IPAddress))
+		add(e, 6, idx, snmp.Gauge32(uint32(len(snap.Devices))))
+		add(e, 7, idx, snmp.Counter32(uint32(snap.Restarts)))
+		add(e, 8, idx, snmp.String(snap.LastError))
+		add(e, 9, idx, snmpTruth(snap.Stale))
+
+		dante.SortDevices(snap.Devices, dante.DeviceOrder{Key: dante.SortByName})
+		for j, dev := range snap.Devices {
+			idx := []uint32{d, uint32(j + 1)}
+			e := m.deviceEntry()
+			add(e, 1, idx, snmp.String(dev.Name))
+			add(e, 2, idx, snmp.String(dev.Model))
+			add(e, 3, idx, snmpIP(dev.IPAddress))
+			add(e, 4, idx, snmp.String(dev.MacAddress))
+			add(e, 5, idx, snmp.Gauge32(uint32(max(dev.LinkSpeed, 0))))
+			add(e, 6, idx, snmpIP(dev.SecondaryIP))
+			add(e, 7, idx, snmp.Gauge32(uint32(max(dev.SecondarySpeed, 0))))
+			add(e, 8, idx, snmp.Integer(snmpRedundancy(dev.Redundancy())))
+			add(e, 9, idx, snmp.String(dev.DanteVersion))
+		}
+	}
+
+	if m.detector != nil {
+		for i, info := range m.detector.DanteInterfaces {
+			idx := []uint32{uint32(i + 1)}
+			e := m.interfaceEntry()
+			add(e, 1, idx, snmp.String(info.Name))
+			add(e, 2, idx, snmp.Integer(snmpOperStatus(info.Name)))
+			add(e, 3, idx, snmpIP(info.IPAddress))
+			add(e, 4, idx, snmp.String(info.MacAddress))
+			add(e, 5, idx, snmp.Gauge32(linkSpeed(info.Name)))
+			add(e, 6, idx, snmp.Integer(info.VLANID))
+		}
+	}
+	return vars
+}
+
+// snmpDomainState 網域狀態的列舉值
+func snmpDomainState(state string) int {
+	switch state {
+	case supervisor.StateStarting:
+		return 1
+	case supervisor.StateRunning:
+		return 2
+	case supervisor.StateFailed:
+		return 3
+	}
+	return 4
+}
+
+// snmpRedundancy 備援狀態的列舉值
+func snmpRedundancy(r string) int {
+	switch r {
+	case dante.RedundancyRedundant:
+		return 1
+	case dante.RedundancyPrimaryOnly:
+		return 2
+	case dante.RedundancySecondaryDown:
+		return 3
+	}
+	return 4
+}
+
+// snmpTruth TruthValue (true 1, false 2)
+func snmpTruth(b bool) snmp.Value {
+	if b {
+		return snmp.Integer(1)
+	}
+	return snmp.Integer(2)
+}
+
+// snmpIP IpAddress (無法解析時為 0.0.0.0)
+func snmpIP(s string) snmp.Value {
+	addr, _ := netip.ParseAddr(s)
+	return snmp.IPAddress(addr)
+}
+
+// snmpOperStatus 介面目前的狀態 (up 1, down 2)
+func snmpOperStatus(name string) int {
+	iface, err := net.InterfaceByName(name)
+	if err != nil || iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagRunning == 0 {
+		return 2
+	}
+	return 1
+}
+
+// linkSpeed 介面的連線速度 (Mbps，無法得知時為 0)
+func linkSpeed(name string) uint32 {
+	data, err := os.ReadFile(filepath.Join(sysfsNet, name, "speed"))
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return uint32(n)
+}
+
+// startSNMP 啟動 agent，並把設備上下線與網域失敗轉成 trap
+func startSNMP(ctx context.Context, opts SNMPOptions, domains *supervisor.Supervisor, detector *NetworkDetector, events *golane.Bus) error {
+	var traps []string
+	for _, t := range strings.Split(opts.Traps, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			traps = append(traps, t)
+		}
+	}
+	hostname, _ := os.Hostname()
+	mib := &snmpMIB{base: snmp.MustParseOID(opts.Base), domains: domains, detector: detector, hostname: hostname}
+	agent, err := snmp.NewAgent(snmp.Config{Addr: opts.Addr, Community: opts.Community, Traps: traps, TrapCommunity: opts.TrapCommunity}, mib.Variables)
+	if err != nil {
+		return err
+	}
+	mib.uptime = agent.Uptime
+	if err := agent.Listen(ctx); err != nil {
+		return err
+	}
+	logger.Info("SNMP agent listening", "addr", agent.LocalAddr(), "base", opts.Base, "traps", traps)
+
+	if len(traps) == 0 {
+		return nil
+	}
+	sub := events.Subscribe(0, golane.TopicDeviceOffline, golane.TopicDeviceOnline, golane.TopicDomainFailed)
+	recovery.Go("snmp/traps", func() {
+		defer sub.Close()
+		recovery.Loop(ctx, "snmp/traps", func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e := <-sub.C:
+					trap, binds := mib.trapFor(e)
+					if trap == nil {
+						continue
+					}
+					if err := agent.Trap(trap, binds...); err != nil {
+						logger.Warn("SNMP trap failed", "err", err)
+					}
+				}
+			}
+		})
+	})
+	return nil
+}
+
+// trapFor 事件對應的 trap 與內容 (不送 trap 的事件回傳 nil)
+func (m *snmpMIB) trapFor(e golane.Event) (snmp.OID, []snmp.VarBind) {
+	var trap snmp.OID
+	var address, message string
+	switch e.Topic {
+	case golane.TopicDeviceOffline, golane.TopicDeviceOnline:
+		dev, _ := e.Data.(dante.Device)
+		address = dev.IPAddress
+		if e.Topic == golane.TopicDeviceOffline {
+			trap, message = m.trap(1), fmt.Sprintf("device %s (%s) left domain %s", e.Subject, dev.IPAddress, e.Domain)
+		} else {
+			trap, message = m.trap(2), fmt.Sprintf("device %s (%s) joined domain %s", e.Subject, dev.IPAddress, e.Domain)
+		}
+	case golane.TopicDomainFailed:
+		f, _ := e.Data.(golane.DomainFailure)
+		address = f.IPAddress
+		trap, message = m.trap(3), fmt.Sprintf("domain %s failed: %s", e.Domain, f.LastError)
+	default:
+		return nil, nil
+	}
+	return trap, []snmp.VarBind{
+		{OID: m.notifyObject(1), Value: snmp.String(e.Domain)},
+		{OID: m.notifyObject(2), Value: snmp.String(e.Subject)},
+		{OID: m.notifyObject(3), Value: snmpIP(address)},
+		{OID: m.notifyObject(4), Value: snmp.String(message)},
+	}
+}
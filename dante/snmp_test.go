@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/dante"
+	"danteCS/internal/snmp"
+	"danteCS/internal/supervisor"
+)
+
+func TestSNMPVariables(t *testing.T) {
+	domains := supervisor.New(supervisor.DefaultConfig())
+	domains.Add(supervisor.Spec{Name: "Dante1", Interface: "eth1", IPAddress: "10.0.1.1"})
+	domains.Seed("Dante1", []dante.Device{
+		{Name: "Stage", IPAddress: "10.0.1.30", LinkSpeed: 1000},
+		{Name: "Amp", IPAddress: "10.0.1.20", LinkSpeed: 100, SecondaryIP: "10.0.2.20"},
+	}, time.Now())
+
+	mib := &snmpMIB{base: snmp.MustParseOID(DefaultSNMPBase), domains: domains, uptime: func() uint32 { return 1 }}
+	vars := make(map[string]snmp.Value)
+	for _, vb := range mib.Variables() {
+		vars[vb.OID.String()] = vb.Value
+	}
+	text := func(oid string) string {
+		s, _ := vars[DefaultSNMPBase+oid].Text()
+		return s
+	}
+	number := func(oid string) int64 {
+		n, _ := vars[DefaultSNMPBase+oid].Int()
+		return n
+	}
+
+	if text(".1.1.2.1") != "Dante1" || number(".1.1.6.1") != 2 || number(".1.1.9.1") != 1 {
+		t.Errorf("domain row: name %q, devices %d, stale %d", text(".1.1.2.1"), number(".1.1.6.1"), number(".1.1.9.1"))
+	}
+	// 設備依名稱排序
+	if text(".2.1.1.1.1") != "Amp" || text(".2.1.1.1.2") != "Stage" {
+		t.Errorf("device names: %q, %q", text(".2.1.1.1.1"), text(".2.1.1.1.2"))
+	}
+	if number(".2.1.5.1.1") != 100 || number(".2.1.8.1.1") != 3 {
+		t.Errorf("Amp: speed %d, redundancy %d", number(".2.1.5.1.1"), number(".2.1.8.1.1"))
+	}
+	if _, ok := vars["1.3.6.1.2.1.1.3.0"]; !ok {
+		t.Error("sysUpTime missing")
+	}
+}
+
+func TestSNMPTrapFor(t *testing.T) {
+	mib := &snmpMIB{base: snmp.MustParseOID(DefaultSNMPBase)}
+	trap, binds := mib.trapFor(golane.Event{Topic: golane.TopicDeviceOffline, Domain: "Dante1", Subject: "Amp", Data: dante.Device{Name: "Amp", IPAddress: "10.0.1.20"}})
+	if trap.String() != DefaultSNMPBase+".4.0.1" || len(binds) != 4 {
+		t.Fatalf("trap %s %v", trap, binds)
+	}
+	if s, _ := binds[1].Value.Text(); s != "Amp" {
+		t.Errorf("device = %q", s)
+	}
+	if trap, _ := mib.trapFor(golane.Event{Topic: golane.TopicDomainFailed, Domain: "Dante1", Data: golane.DomainFailure{}}); trap.String() != DefaultSNMPBase+".4.0.3" {
+		t.Errorf("domain failure trap %s", trap)
+	}
+	if trap, _ := mib.trapFor(golane.Event{Topic: golane.TopicRoute}); trap != nil {
+		t.Errorf("route event sent as trap %s", trap)
+	}
+}
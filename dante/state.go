@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//==============================================================================
+// 持久化狀態
+//==============================================================================
+
+// 所有需要跨重啟保存的資料 (平面圖、...) 都放在 <state-dir>/state.json，
+// 每個子系統使用自己的 section，寫入時先寫暫存檔再 rename，避免斷電損毀。
+// 格式變更時由 migrations.go 的升級步驟把舊版本的狀態檔升級。
+
+// stateFileName 狀態檔名稱
+const stateFileName = "state.json"
+
+// stateVersion 目前的狀態檔格式版本
+const stateVersion = 1
+
+// stateDocument 狀態檔內容
+type stateDocument struct {
+	Version  int                        `json:"version"`
+	Sections map[string]json.RawMessage `json:"sections"`
+}
+
+// StateStore 狀態檔存取
+type StateStore struct {
+	mu   sync.Mutex
+	dir  string
+	path string
+	doc  stateDocument
+}
+
+// OpenStateStore 開啟狀態目錄下的狀態檔，不存在時建立空白狀態
+// 較舊版本的狀態檔會先備份再升級到目前的版本
+func OpenStateStore(dir string) (*StateStore, error) {
+	return openStateStore(dir, stateMigrations, stateVersion)
+}
+
+// openStateStore 以指定的升級步驟與目標版本開啟狀態檔
+func openStateStore(dir string, migrations []StateMigration, version int) (*StateStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state dir: %v", err)
+	}
+
+	s := &StateStore{
+		dir:  dir,
+		path: filepath.Join(dir, stateFileName),
+		doc:  stateDocument{Version: version, Sections: make(map[string]json.RawMessage)},
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &s.doc); err != nil {
+		return nil, fmt.Errorf("failed to parse state %s: %v", s.path, err)
+	}
+	if s.doc.Version > version {
+		return nil, fmt.Errorf("state %s has version %d, newer than supported %d", s.path, s.doc.Version, version)
+	}
+	if s.doc.Sections == nil {
+		s.doc.Sections = make(map[string]json.RawMessage)
+	}
+
+	if s.doc.Version < version {
+		if err := s.migrate(data, migrations, version); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// migrate 備份原始狀態檔，升級後寫回
+func (s *StateStore) migrate(original []byte, migrations []StateMigration, version int) error {
+	from := s.doc.Version
+	backup, err := backupStateFile(s.path, from, original)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrateState(&s.doc, migrations, version)
+	if err != nil {
+		return fmt.Errorf("%v (original kept in %s)", err, backup)
+	}
+	for _, m := range applied {
+		logger.Info("State migrated", "version", m.Version, "change", m.Description)
+	}
+
+	if err := s.flushLocked(); err != nil {
+		return err
+	}
+	logger.Info("State upgraded", "path", s.path, "from", from, "to", version, "backup", backup)
+	return nil
+}
+
+// Version 狀態檔格式版本
+func (s *StateStore) Version() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.doc.Version
+}
+
+// Dir 狀態目錄
+func (s *StateStore) Dir() string {
+	return s.dir
+}
+
+// Load 讀取 section 到 v，section 不存在時回傳 false
+func (s *StateStore) Load(section string, v any) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.doc.Sections[section]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("failed to decode state section %s: %v", section, err)
+	}
+	return true, nil
+}
+
+// Save 寫入 section 並保存狀態檔
+func (s *StateStore) Save(section string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode state section %s: %v", section, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.doc.Sections[section] = raw
+	return s.flushLocked()
+}
+
+// flushLocked 以暫存檔 + rename 的方式寫入
+func (s *StateStore) flushLocked() error {
+	data, err := json.MarshalIndent(s.doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %v", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write state: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace state: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testMigrations 測試用的升級步驟：v2 把 floorplan.unit 改名為 units，v3 加入 incidents.next_id
+var testMigrations = []StateMigration{
+	{Version: 2, Description: "rename floorplan unit", Migrate: func(sections map[string]json.RawMessage) error {
+		return migrateSection(sections, floorPlanSection, func(obj map[string]any) error {
+			if unit, ok := obj["unit"]; ok {
+				obj["units"] = unit
+				delete(obj, "unit")
+			}
+			return nil
+		})
+	}},
+	{Version: 3, Description: "default incident id", Migrate: func(sections map[string]json.RawMessage) error {
+		return migrateSection(sections, incidentSection, func(obj map[string]any) error {
+			if _, ok := obj["next_id"]; !ok {
+				obj["next_id"] = 1
+			}
+			return nil
+		})
+	}},
+}
+
+func writeStateFile(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, stateFileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func stateBackups(t *testing.T, dir string) []string {
+	t.Helper()
+	backups, err := filepath.Glob(filepath.Join(dir, stateFileName+".v*.bak"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backups
+}
+
+func TestStateMigratesForwardWithBackup(t *testing.T) {
+	dir := t.TempDir()
+	original := `{"version": 1, "sections": {"floorplan": {"unit": "m", "rooms": []}, "incidents": {"incidents": []}}}`
+	writeStateFile(t, dir, original)
+
+	s, err := openStateStore(dir, testMigrations, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Version() != 3 {
+		t.Fatalf("version = %d, want 3", s.Version())
+	}
+
+	var plan struct {
+		Unit  string `json:"unit"`
+		Units string `json:"units"`
+	}
+	if _, err := s.Load(floorPlanSection, &plan); err != nil {
+		t.Fatal(err)
+	}
+	if plan.Units != "m" || plan.Unit != "" {
+		t.Fatalf("floorplan not migrated: %+v", plan)
+	}
+	var incidents incidentState
+	if _, err := s.Load(incidentSection, &incidents); err != nil {
+		t.Fatal(err)
+	}
+	if incidents.NextID != 1 {
+		t.Fatalf("next_id = %d, want 1", incidents.NextID)
+	}
+
+	// 備份保存原始內容，狀態檔已寫回新版本
+	backups := stateBackups(t, dir)
+	if len(backups) != 1 || !strings.Contains(backups[0], ".v1-") {
+		t.Fatalf("backups = %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != original {
+		t.Fatalf("backup content = %s", data)
+	}
+	reopened, err := openStateStore(dir, testMigrations, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Version() != 3 || len(stateBackups(t, dir)) != 1 {
+		t.Fatal("already migrated state was migrated again")
+	}
+}
+
+func TestStateMigrationFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	original := `{"version": 2, "sections": {"incidents": {"incidents": []}}}`
+	writeStateFile(t, dir, original)
+
+	failing := append([]StateMigration{}, testMigrations...)
+	failing[1].Migrate = func(map[string]json.RawMessage) error { return errors.New("boom") }
+
+	_, err := openStateStore(dir, failing, 3)
+	if err == nil || !strings.Contains(err.Error(), "version 3") || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("open = %v, want migration error", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, stateFileName)); string(data) != original {
+		t.Fatalf("state file changed after failed migration: %s", data)
+	}
+	if len(stateBackups(t, dir)) != 1 {
+		t.Fatal("no backup written before migration")
+	}
+}
+
+func TestStateRejectsNewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeStateFile(t, dir, `{"version": 4, "sections": {}}`)
+
+	if _, err := openStateStore(dir, testMigrations, 3); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("open = %v, want version error", err)
+	}
+	if len(stateBackups(t, dir)) != 0 {
+		t.Fatal("backup written for a state that was not migrated")
+	}
+}
+
+func TestStateMigrationsAreContiguous(t *testing.T) {
+	if err := checkMigrations(stateMigrations, stateVersion); err != nil {
+		t.Fatal(err)
+	}
+	gap := []StateMigration{testMigrations[1]}
+	if err := checkMigrations(gap, 3); err == nil {
+		t.Fatal("gap in migrations not detected")
+	}
+}
+
+func TestNewStateStoreUsesCurrentVersion(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenStateStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("test", map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	var doc stateDocument
+	data, _ := os.ReadFile(filepath.Join(dir, stateFileName))
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != stateVersion {
+		t.Fatalf("version = %d, want %d", doc.Version, stateVersion)
+	}
+}
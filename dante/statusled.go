@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"danteCS/internal/recovery"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 狀態 LED
+//==============================================================================
+
+// 機櫃裡沒有螢幕，技術人員只能看面板燈號。StatusLED 以一顆 LED 表示整體狀態：
+//
+//	慢閃 (1 Hz)  掃描中：網域啟動中或還沒有發現設備
+//	恆亮         正常：所有網域運行中且發現設備
+//	快閃 (5 Hz)  告警：網域失敗、有作用中的告警或網路風暴
+//
+// LED 可以是 sysfs GPIO (需先 export 並設為輸出) 或 /sys/class/leds 之下的
+// LED (RTD1619B 開發板的使用者 LED)。結束時熄滅。
+
+// ledTick 燈號的最小時間單位 (快閃的半週期)
+const ledTick = 100 * time.Millisecond
+
+// ledEvaluateTicks 每幾個 tick 重新判斷一次狀態
+const ledEvaluateTicks = 10
+
+// ledRoot sysfs LED 目錄 (測試時替換)
+var ledRoot = "/sys/class/leds"
+
+// LED 燈號
+const (
+	LEDOff      = "off"
+	LEDScanning = "scanning" // 慢閃
+	LEDReady    = "ready"    // 恆亮
+	LEDAlarm    = "alarm"    // 快閃
+)
+
+// StatusLEDConfig 設定檔的 status_led section
+type StatusLEDConfig struct {
+	GPIO      *int   `json:"gpio,omitempty"`       // sysfs GPIO 編號
+	LED       string `json:"led,omitempty"`        // /sys/class/leds 之下的名稱 (與 gpio 擇一)
+	ActiveLow bool   `json:"active_low,omitempty"` // 輸出 0 時點亮 (只用於 GPIO)
+}
+
+// Validate 檢查只指定一種 LED
+func (c StatusLEDConfig) Validate() error {
+	switch {
+	case c.GPIO == nil && c.LED == "":
+		return errors.New("status_led: set gpio or led")
+	case c.GPIO != nil && c.LED != "":
+		return errors.New("status_led: set only one of gpio and led")
+	case c.GPIO != nil && *c.GPIO < 0:
+		return fmt.Errorf("status_led: invalid GPIO %d", *c.GPIO)
+	}
+	return nil
+}
+
+// path 寫入亮滅的 sysfs 檔案
+func (c StatusLEDConfig) path() string {
+	if c.GPIO != nil {
+		return filepath.Join(gpioRoot, fmt.Sprintf("gpio%d", *c.GPIO), "value")
+	}
+	return filepath.Join(ledRoot, c.LED, "brightness")
+}
+
+// StatusLED 依網域、告警與風暴狀態驅動 LED
+type StatusLED struct {
+	cfg     StatusLEDConfig
+	domains *supervisor.Supervisor
+	alarms  *AlarmEngine   // nil 表示不檢查
+	storms  *StormDetector // nil 表示不檢查
+
+	pattern string
+	lit     bool
+	written bool // 已寫入過 (第一次一定寫入)
+}
+
+// NewStatusLED 建立狀態 LED (尚未開始)
+func NewStatusLED(cfg StatusLEDConfig, domains *supervisor.Supervisor, alarms *AlarmEngine, storms *StormDetector) *StatusLED {
+	return &StatusLED{cfg: cfg, domains: domains, alarms: alarms, storms: storms, pattern: LEDOff}
+}
+
+// Start 在背景驅動 LED 直到 ctx 結束 (結束時熄滅)
+func (l *StatusLED) Start(ctx context.Context) {
+	if err := l.set(false); err != nil {
+		logger.Warn("Status LED unavailable", "path", l.cfg.path(), "err", err)
+		return
+	}
+	logger.Info("Status LED enabled", "path", l.cfg.path())
+	recovery.GoLoop(ctx, "statusled", func() {
+		ticker := time.NewTicker(ledTick)
+		defer ticker.Stop()
+		for tick := 0; ; tick++ {
+			if tick%ledEvaluateTicks == 0 {
+				l.evaluate()
+			}
+			l.set(ledLit(l.pattern, tick))
+			select {
+			case <-ctx.Done():
+				l.set(false)
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// evaluate 重新判斷燈號
+func (l *StatusLED) evaluate() {
+	alarms := 0
+	if l.alarms != nil {
+		alarms = len(l.alarms.Status().Active)
+	}
+	if l.storms != nil {
+		for _, s := range l.storms.Status().Interfaces {
+			if s.Storm {
+				alarms++
+			}
+		}
+	}
+	if pattern := ledPattern(l.domains.Snapshots(), alarms); pattern != l.pattern {
+		logger.Debug("Status LED changed", "from", l.pattern, "to", pattern)
+		l.pattern = pattern
+	}
+}
+
+// set 點亮或熄滅 (狀態沒變時不寫入)
+func (l *StatusLED) set(on bool) error {
+	if l.written && on == l.lit {
+		return nil
+	}
+	high := on
+	if l.cfg.GPIO != nil && l.cfg.ActiveLow {
+		high = !on
+	}
+	value := "0"
+	if high {
+		value = "1"
+	}
+	if err := os.WriteFile(l.cfg.path(), []byte(value), 0644); err != nil {
+		return err
+	}
+	l.lit, l.written = on, true
+	return nil
+}
+
+// ledPattern 依網域快照與作用中的告警數決定燈號
+func ledPattern(snaps []supervisor.Snapshot, alarms int) string {
+	if len(snaps) == 0 {
+		return LEDScanning
+	}
+	pattern := LEDReady
+	for _, snap := range snaps {
+		switch {
+		case snap.State == supervisor.StateFailed:
+			return LEDAlarm
+		case snap.State != supervisor.StateRunning || len(snap.Devices) == 0:
+			pattern = LEDScanning
+		}
+	}
+	if alarms > 0 {
+		return LEDAlarm
+	}
+	return pattern
+}
+
+// ledLit 燈號在第 tick 個時間單位是否點亮
+func ledLit(pattern string, tick int) bool {
+	switch pattern {
+	case LEDReady:
+		return true
+	case LEDScanning:
+		return tick/5%2 == 0
+	case LEDAlarm:
+		return tick%2 == 0
+	}
+	return false
+}
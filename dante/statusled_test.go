@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+func TestLEDPattern(t *testing.T) {
+	running := supervisor.Snapshot{Name: "Dante1", State: supervisor.StateRunning, Devices: []dante.Device{{Name: "Amp"}}}
+	empty := supervisor.Snapshot{Name: "Dante2", State: supervisor.StateRunning}
+	starting := supervisor.Snapshot{Name: "Dante2", State: supervisor.StateStarting}
+	failed := supervisor.Snapshot{Name: "Dante2", State: supervisor.StateFailed}
+
+	for _, c := range []struct {
+		snaps  []supervisor.Snapshot
+		alarms int
+		want   string
+	}{
+		{nil, 0, LEDScanning},
+		{[]supervisor.Snapshot{running}, 0, LEDReady},
+		{[]supervisor.Snapshot{running, starting}, 0, LEDScanning},
+		{[]supervisor.Snapshot{running, empty}, 0, LEDScanning},
+		{[]supervisor.Snapshot{running, failed}, 0, LEDAlarm},
+		{[]supervisor.Snapshot{running}, 1, LEDAlarm},
+	} {
+		if got := ledPattern(c.snaps, c.alarms); got != c.want {
+			t.Errorf("%+v, %d alarms: %s, want %s", c.snaps, c.alarms, got, c.want)
+		}
+	}
+
+	// 慢閃 500 ms 亮 500 ms 滅，快閃每 100 ms 切換
+	var scanning, alarm []bool
+	for tick := range 10 {
+		scanning = append(scanning, ledLit(LEDScanning, tick))
+		alarm = append(alarm, ledLit(LEDAlarm, tick))
+	}
+	if !scanning[4] || scanning[5] || !alarm[0] || alarm[1] || !ledLit(LEDReady, 7) || ledLit(LEDOff, 0) {
+		t.Errorf("scanning %v, alarm %v", scanning, alarm)
+	}
+}
+
+func TestStatusLEDWrite(t *testing.T) {
+	saved := gpioRoot
+	gpioRoot = t.TempDir()
+	t.Cleanup(func() { gpioRoot = saved })
+	if err := os.Mkdir(filepath.Join(gpioRoot, "gpio17"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	pin := 17
+	l := NewStatusLED(StatusLEDConfig{GPIO: &pin, ActiveLow: true}, nil, nil, nil)
+	read := func() string {
+		data, _ := os.ReadFile(filepath.Join(gpioRoot, "gpio17", "value"))
+		return string(data)
+	}
+	if err := l.set(true); err != nil || read() != "0" {
+		t.Errorf("active-low on: %q, err = %v", read(), err)
+	}
+	l.set(false)
+	if read() != "1" {
+		t.Errorf("active-low off: %q", read())
+	}
+
+	if err := (StatusLEDConfig{GPIO: &pin, LED: "status"}).Validate(); err == nil {
+		t.Error("both gpio and led accepted")
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 廣播/多播風暴偵測
+//==============================================================================
+
+// 交換器迴圈或 IGMP snooping 失效時，Dante 介面會被廣播與多播封包淹沒：
+// 網卡中斷與 SDK 的 mDNS 發現佔滿 CPU，事件處理與路由操作跟著變慢。
+// StormDetector 每個取樣間隔讀取介面的 sysfs 計數 (rx_packets、multicast)，
+// 換算每秒封包數；連續 Sustain 次超過門檻才視為風暴並發出告警，
+// 兩者都低於門檻的 90% (loadRecoverRatio) 才解除。核心沒有單獨的廣播計數，
+// 廣播包含在總封包數中；訂閱的多播音訊 flow 也會計入，門檻要高於正常的音訊流量。
+// PauseDiscovery 開啟時，風暴期間暫停該介面網域的設備刷新，結束後立即刷新一次。
+
+// AlertNetworkStorm 介面的廣播/多播風暴
+const AlertNetworkStorm = "network-storm"
+
+// StormOptions 風暴偵測參數
+type StormOptions struct {
+	Packets        float64       `json:"packets"`         // 每秒接收封包數門檻 (含廣播)，0 表示不檢查
+	Multicast      float64       `json:"multicast"`       // 每秒多播封包數門檻，0 表示不檢查
+	Sustain        int           `json:"sustain"`         // 連續超過門檻的取樣次數
+	Interval       time.Duration `json:"interval"`        // 取樣間隔
+	PauseDiscovery bool          `json:"pause_discovery"` // 風暴期間暫停網域的設備刷新
+}
+
+// DefaultStormOptions 預設的風暴門檻 (遠高於數十個 1 ms 音訊 flow 的封包數)
+func DefaultStormOptions() StormOptions {
+	return StormOptions{
+		Packets:   200000,
+		Multicast: 100000,
+		Sustain:   3,
+		Interval:  time.Second,
+	}
+}
+
+// Validate 檢查參數
+func (o StormOptions) Validate() error {
+	if o.Packets < 0 || o.Multicast < 0 {
+		return errors.New("storm thresholds must not be negative")
+	}
+	if o.Sustain < 1 {
+		return fmt.Errorf("the storm sustain count must be at least 1, got %d", o.Sustain)
+	}
+	if o.Interval <= 0 {
+		return fmt.Errorf("invalid storm sampling interval %s", o.Interval)
+	}
+	return nil
+}
+
+// ifaceCounters 介面的累計接收封包數
+type ifaceCounters struct {
+	packets, multicast uint64
+}
+
+// StormInterface 單一介面的封包速率與風暴狀態
+type StormInterface struct {
+	Interface    string    `json:"interface"`
+	PPS          float64   `json:"pps"`           // 每秒接收封包數
+	MulticastPPS float64   `json:"multicast_pps"` // 其中的多播
+	Storm        bool      `json:"storm"`
+	Since        time.Time `json:"since,omitempty"`    // 目前風暴開始的時間
+	PeakPPS      float64   `json:"peak_pps,omitempty"` // 目前 (或上次) 風暴的峰值
+	Storms       int       `json:"storms"`             // 累計風暴次數
+	Error        string    `json:"error,omitempty"`    // 無法讀取計數
+}
+
+// StormStatus /api/storms 的回應
+type StormStatus struct {
+	Options    StormOptions     `json:"options"`
+	Interfaces []StormInterface `json:"interfaces"`
+}
+
+// stormState 介面的取樣狀態
+type stormState struct {
+	status StormInterface
+	last   ifaceCounters
+	lastAt time.Time // 零值表示尚未取樣
+	over   int       // 連續超過門檻的次數
+}
+
+// StormDetector 取樣 Dante 介面的封包速率並偵測風暴
+type StormDetector struct {
+	alerts *AlertManager
+	opts   StormOptions
+	read   func(iface string) (ifaceCounters, error) // 讀取計數 (測試時替換)
+
+	mu     sync.Mutex
+	ifaces map[string]*stormState
+}
+
+// NewStormDetector 建立偵測器 (尚未開始取樣)
+func NewStormDetector(alerts *AlertManager, opts StormOptions, ifaces []string) *StormDetector {
+	d := &StormDetector{alerts: alerts, opts: opts, read: readIfaceCounters, ifaces: make(map[string]*stormState)}
+	for _, name := range ifaces {
+		d.ifaces[name] = &stormState{status: StormInterface{Interface: name}}
+	}
+	return d
+}
+
+// Start 在背景定期取樣直到 ctx 結束
+func (d *StormDetector) Start(ctx context.Context) {
+	d.update(time.Now())
+	recovery.GoLoop(ctx, "storm", func() {
+		ticker := time.NewTicker(d.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				d.update(now)
+			}
+		}
+	})
+}
+
+// update 取樣所有介面並更新風暴狀態
+func (d *StormDetector) update(now time.Time) {
+	var raise []Alert
+	var resolve []string
+
+	d.mu.Lock()
+	for name, st := range d.ifaces {
+		c, err := d.read(name)
+		if err != nil {
+			st.status.Error = err.Error()
+			st.lastAt = time.Time{}
+			continue
+		}
+		st.status.Error = ""
+		prev, prevAt := st.last, st.lastAt
+		st.last, st.lastAt = c, now
+		// 第一次取樣或計數歸零 (驅動程式重新載入) 時只記錄基準
+		if prevAt.IsZero() || !now.After(prevAt) || c.packets < prev.packets || c.multicast < prev.multicast {
+			continue
+		}
+		elapsed := now.Sub(prevAt).Seconds()
+		st.status.PPS = float64(c.packets-prev.packets) / elapsed
+		st.status.MulticastPPS = float64(c.multicast-prev.multicast) / elapsed
+
+		if d.exceeds(st.status, 1) {
+			st.over++
+		} else {
+			st.over = 0
+		}
+		switch {
+		case !st.status.Storm && st.over >= d.opts.Sustain:
+			st.status.Storm = true
+			st.status.Since = now
+			st.status.PeakPPS = st.status.PPS
+			st.status.Storms++
+			logger.Warn("Broadcast/multicast storm detected", "interface", name,
+				"pps", math.Round(st.status.PPS), "multicast_pps", math.Round(st.status.MulticastPPS),
+				"pause_discovery", d.opts.PauseDiscovery)
+			raise = append(raise, Alert{
+				Kind:     AlertNetworkStorm,
+				Severity: SeverityCritical,
+				Subject:  name,
+				Message:  d.stormMessage(st.status),
+				Time:     now,
+			})
+		case st.status.Storm && !d.exceeds(st.status, loadRecoverRatio):
+			logger.Info("Broadcast/multicast storm ended", "interface", name,
+				"peak_pps", math.Round(st.status.PeakPPS), "duration", now.Sub(st.status.Since).Round(time.Second))
+			st.status.Storm = false
+			st.status.Since = time.Time{}
+			resolve = append(resolve, name)
+		case st.status.Storm:
+			st.status.PeakPPS = max(st.status.PeakPPS, st.status.PPS)
+		}
+	}
+	d.mu.Unlock()
+
+	if d.alerts == nil {
+		return
+	}
+	for _, a := range raise {
+		d.alerts.Raise(a)
+	}
+	for _, name := range resolve {
+		d.alerts.Resolve(AlertNetworkStorm, "", name)
+	}
+}
+
+// exceeds 速率是否超過門檻的 ratio 倍 (任一門檻即可)
+func (d *StormDetector) exceeds(s StormInterface, ratio float64) bool {
+	return (d.opts.Packets > 0 && s.PPS > d.opts.Packets*ratio) ||
+		(d.opts.Multicast > 0 && s.MulticastPPS > d.opts.Multicast*ratio)
+}
+
+// stormMessage 告警說明
+func (d *StormDetector) stormMessage(s StormInterface) string {
+	msg := fmt.Sprintf("broadcast/multicast storm on %s: %.0f packets/s received (%.0f multicast), check the switches for loops",
+		s.Interface, s.PPS, s.MulticastPPS)
+	if d.opts.PauseDiscovery {
+		msg += ", device discovery paused"
+	}
+	return msg
+}
+
+// Paused 介面的網域是否因風暴暫停設備刷新 (nil 表示未偵測)
+func (d *StormDetector) Paused(iface string) bool {
+	if d == nil || !d.opts.PauseDiscovery {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st, ok := d.ifaces[iface]
+	return ok && st.status.Storm
+}
+
+// Status 各介面目前的封包速率與風暴狀態 (依介面名稱排序)
+func (d *StormDetector) Status() StormStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := StormStatus{Options: d.opts, Interfaces: make([]StormInterface, 0, len(d.ifaces))}
+	for _, st := range d.ifaces {
+		status.Interfaces = append(status.Interfaces, st.status)
+	}
+	slices.SortFunc(status.Interfaces, func(a, b StormInterface) int { return strings.Compare(a.Interface, b.Interface) })
+	return status
+}
+
+// readIfaceCounters 讀取 sysfs 的接收封包計數
+func readIfaceCounters(iface string) (ifaceCounters, error) {
+	var c ifaceCounters
+	for _, f := range []struct {
+		name string
+		dst  *uint64
+	}{{"rx_packets", &c.packets}, {"multicast", &c.multicast}} {
+		data, err := os.ReadFile(filepath.Join(sysfsNet, iface, "statistics", f.name))
+		if err != nil {
+			return c, err
+		}
+		if *f.dst, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err != nil {
+			return c, fmt.Errorf("%s %s: %v", iface, f.name, err)
+		}
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+func (s *APIServer) handleStorms(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.storms.Status())
+}
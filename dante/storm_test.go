@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStormDetector(t *testing.T) {
+	opts := DefaultStormOptions()
+	opts.PauseDiscovery = true
+	var notes []AlertNotification
+	alerts := NewAlertManager(NoiseFloor{}, func(n AlertNotification) { notes = append(notes, n) })
+	var resolved []string
+	alerts.OnResolve(func(kind, domain, subject string) { resolved = append(resolved, kind+"|"+subject) })
+	d := NewStormDetector(alerts, opts, []string{"eth1", "eth2"})
+
+	counters := map[string]ifaceCounters{}
+	d.read = func(iface string) (ifaceCounters, error) {
+		if iface == "eth2" {
+			return ifaceCounters{}, errors.New("no such device")
+		}
+		return counters[iface], nil
+	}
+	now := time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC)
+	// 每秒取樣一次，eth1 收到 pps 個封包 (其中 mcast 個多播)
+	step := func(pps, mcast uint64) {
+		c := counters["eth1"]
+		c.packets += pps
+		c.multicast += mcast
+		counters["eth1"] = c
+		now = now.Add(time.Second)
+		d.update(now)
+	}
+
+	step(0, 0) // 基準
+	step(20000, 15000)
+	if s := d.Status().Interfaces; len(s) != 2 || s[0].PPS != 20000 || s[0].MulticastPPS != 15000 || s[1].Error == "" {
+		t.Fatalf("status = %+v", s)
+	}
+
+	// 持續 Sustain 次超過多播門檻才發出告警
+	step(150000, 120000)
+	step(150000, 120000)
+	if d.Paused("eth1") || len(notes) != 0 {
+		t.Fatal("storm raised before the sustain count")
+	}
+	step(250000, 120000)
+	if !d.Paused("eth1") || d.Paused("eth2") || len(notes) != 1 || notes[0].Alerts[0].Kind != AlertNetworkStorm {
+		t.Fatalf("storm not raised: paused %v, notes %+v", d.Paused("eth1"), notes)
+	}
+
+	// 低於門檻但未低於恢復門檻時維持風暴
+	step(190000, 95000)
+	if s := d.Status().Interfaces[0]; !s.Storm || s.PeakPPS != 250000 || s.Storms != 1 {
+		t.Fatalf("status during storm = %+v", s)
+	}
+	step(30000, 20000)
+	if d.Paused("eth1") || len(resolved) != 1 || resolved[0] != AlertNetworkStorm+"|eth1" {
+		t.Fatalf("storm not cleared: %+v", d.Status().Interfaces[0])
+	}
+
+	// 計數歸零時不產生負的速率
+	counters["eth1"] = ifaceCounters{}
+	now = now.Add(time.Second)
+	d.update(now)
+	if s := d.Status().Interfaces[0]; s.PPS != 30000 {
+		t.Errorf("rate after counter reset = %v", s.PPS)
+	}
+
+	var none *StormDetector
+	if none.Paused("eth1") {
+		t.Error("nil detector paused discovery")
+	}
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/i18n"
+	"danteCS/internal/recovery"
+	"danteCS/internal/snmp"
+)
+
+//==============================================================================
+// 設備 → 交換器埠
+//==============================================================================
+
+// 支援電話的第一個問題是「設備接在哪台交換器的哪個埠」。設定檔 switches
+// section 列出的交換器定期以 SNMP 讀取 FDB (學到的 MAC 與埠) 與 ARP 表，
+// 再與發現的設備對應：設備的 MAC 來自發現，沒有 MAC 時以 IP 查 ARP 表。
+// 同一個 MAC 在每台交換器往設備方向的埠上都學得到，學到最少 MAC 的埠是
+// 設備實際接的埠，其他的是上行埠。
+//
+//	"switches": [
+//	  {"name": "core-sw1", "address": "10.0.0.2", "community": "monitoring"},
+//	  {"name": "stage-sw", "address": "10.0.0.3", "version": "1"}
+//	]
+
+const (
+	switchPollInterval = 5 * time.Minute // 輪詢間隔 (FDB 老化時間通常為 5 分鐘)
+	switchTimeout      = 2 * time.Second // 每個 SNMP 請求的逾時
+	switchRetries      = 1
+)
+
+// 讀取的 MIB 表格
+var (
+	oidIfDescr    = snmp.MustParseOID("1.3.6.1.2.1.2.2.1.2")        // IF-MIB::ifDescr
+	oidIfName     = snmp.MustParseOID("1.3.6.1.2.1.31.1.1.1.1")     // IF-MIB::ifName
+	oidBasePortIf = snmp.MustParseOID("1.3.6.1.2.1.17.1.4.1.2")     // BRIDGE-MIB::dot1dBasePortIfIndex
+	oidTpFdbPort  = snmp.MustParseOID("1.3.6.1.2.1.17.4.3.1.2")     // BRIDGE-MIB::dot1dTpFdbPort (MAC)
+	oidQTpFdbPort = snmp.MustParseOID("1.3.6.1.2.1.17.7.1.2.2.1.2") // Q-BRIDGE-MIB::dot1qTpFdbPort (VLAN.MAC)
+	oidNetToMedia = snmp.MustParseOID("1.3.6.1.2.1.4.22.1.2")       // IP-MIB::ipNetToMediaPhysAddress (ifIndex.IP)
+)
+
+// ErrInvalidSwitch 交換器設定錯誤
+var ErrInvalidSwitch = errors.New("invalid switch")
+
+// SwitchConfig 設定檔 switches section 的一台交換器
+type SwitchConfig struct {
+	Name      string `json:"name"`
+	Address   string `json:"address"`             // host[:port] (預設埠 161)
+	Community string `json:"community,omitempty"` // 讀取 community (預設 public)
+	Version   string `json:"version,omitempty"`   // "2c" (預設) 或 "1"
+}
+
+// compileSwitches 檢查交換器設定並補上預設值
+func compileSwitches(switches []SwitchConfig) ([]SwitchConfig, error) {
+	names := make(map[string]bool, len(switches))
+	out := make([]SwitchConfig, len(switches))
+	for i, sw := range switches {
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("%w %q: %s", ErrInvalidSwitch, sw.Name, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case sw.Name == "":
+			return nil, fmt.Errorf("%w #%d: name is required", ErrInvalidSwitch, i+1)
+		case names[sw.Name]:
+			return nil, fail("duplicate name")
+		case sw.Address == "":
+			return nil, fail("address is required")
+		case sw.Version != "" && sw.Version != "1" && sw.Version != "2c":
+			return nil, fail("unknown SNMP version %q (1, 2c)", sw.Version)
+		}
+		names[sw.Name] = true
+		if sw.Community == "" {
+			sw.Community = "public"
+		}
+		if sw.Version == "" {
+			sw.Version = "2c"
+		}
+		out[i] = sw
+	}
+	return out, nil
+}
+
+//------------------------------------------------------------------------------
+// 輪詢
+//------------------------------------------------------------------------------
+
+// snmpWalker 走訪 SNMP 子樹 (*snmp.Client，測試可替換)
+type snmpWalker interface {
+	Walk(ctx context.Context, root snmp.OID) ([]snmp.VarBind, error)
+}
+
+// fdbEntry 交換器學到 MAC 的埠
+type fdbEntry struct {
+	Port string
+	VLAN int // Q-BRIDGE 的 FDB ID (通常等於 VLAN，BRIDGE-MIB 為 0)
+}
+
+// switchTable 一台交換器一次輪詢的結果
+type switchTable struct {
+	Switch   string
+	FDB      map[string]fdbEntry // MAC → 埠
+	PortMACs map[string]int      // 埠 → 學到的 MAC 數
+	ARP      map[string]string   // IP → MAC
+	Polled   time.Time
+	Err      error
+}
+
+// pollSwitch 讀取介面名稱、FDB 與 ARP 表
+// 只支援 BRIDGE-MIB 的交換器改讀 dot1dTpFdbPort，沒有 ARP 表 (L2 交換器) 不算錯誤
+func pollSwitch(ctx context.Context, name string, w snmpWalker) (switchTable, error) {
+	t := switchTable{Switch: name, FDB: make(map[string]fdbEntry), PortMACs: make(map[string]int), ARP: make(map[string]string)}
+
+	ifNames := make(map[uint32]string)
+	for _, root := range []snmp.OID{oidIfName, oidIfDescr} {
+		vars, err := w.Walk(ctx, root)
+		if err != nil {
+			return t, fmt.Errorf("interface names: %w", err)
+		}
+		for _, vb := range vars {
+			if s, ok := vb.Value.Text(); ok && s != "" {
+				ifNames[vb.OID[len(vb.OID)-1]] = s
+			}
+		}
+		if len(ifNames) > 0 {
+			break
+		}
+	}
+	vars, err := w.Walk(ctx, oidBasePortIf)
+	if err != nil {
+		return t, fmt.Errorf("bridge ports: %w", err)
+	}
+	portIf := make(map[int64]int64, len(vars))
+	for _, vb := range vars {
+		if ifIndex, ok := vb.Value.Int(); ok {
+			portIf[int64(vb.OID[len(vb.OID)-1])] = ifIndex
+		}
+	}
+	portName := func(port int64) string {
+		if ifIndex, ok := portIf[port]; ok {
+			if name, ok := ifNames[uint32(ifIndex)]; ok {
+				return name
+			}
+		}
+		return fmt.Sprintf("port %d", port)
+	}
+
+	// Q-BRIDGE: index 為 FDB ID 與 6 個位元組的 MAC
+	root, vlan := oidQTpFdbPort, true
+	if vars, err = w.Walk(ctx, root); err == nil && len(vars) == 0 {
+		root, vlan = oidTpFdbPort, false
+		vars, err = w.Walk(ctx, root)
+	}
+	if err != nil {
+		return t, fmt.Errorf("forwarding table: %w", err)
+	}
+	for _, vb := range vars {
+		index := vb.OID[len(root):]
+		port, ok := vb.Value.Int()
+		if !ok || port == 0 || len(index) != 6+boolInt(vlan) {
+			continue // 0 表示埠未知 (例如交換器自己的 MAC)
+		}
+		entry := fdbEntry{Port: portName(port)}
+		if vlan {
+			entry.VLAN, index = int(index[0]), index[1:]
+		}
+		mac := make(net.HardwareAddr, 6)
+		for i, b := range index {
+			mac[i] = byte(b)
+		}
+		t.FDB[mac.String()] = entry
+		t.PortMACs[entry.Port]++
+	}
+
+	// ARP: index 為 ifIndex 與 4 個位元組的 IPv4 地址
+	if vars, err = w.Walk(ctx, oidNetToMedia); err != nil {
+		return t, fmt.Errorf("ARP table: %w", err)
+	}
+	for _, vb := range vars {
+		index := vb.OID[len(oidNetToMedia):]
+		mac, _ := vb.Value.Text()
+		if len(index) != 5 || len(mac) != 6 {
+			continue
+		}
+		ip := netip.AddrFrom4([4]byte{byte(index[1]), byte(index[2]), byte(index[3]), byte(index[4])})
+		t.ARP[ip.String()] = net.HardwareAddr(mac).String()
+	}
+	return t, nil
+}
+
+// boolInt true 為 1
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// SwitchPortMapper 定期輪詢交換器，與發現的設備對應出交換器埠
+type SwitchPortMapper struct {
+	switches []SwitchConfig
+
+	mu     sync.Mutex
+	tables map[string]switchTable // 交換器名稱 → 最後一次輪詢
+}
+
+// NewSwitchPortMapper 建立 mapper (switches 須先經過 compileSwitches)
+func NewSwitchPortMapper(switches []SwitchConfig) *SwitchPortMapper {
+	return &SwitchPortMapper{switches: switches, tables: make(map[string]switchTable)}
+}
+
+// Start 立即輪詢一次，之後每 switchPollInterval 輪詢，直到 ctx 結束
+func (m *SwitchPortMapper) Start(ctx context.Context) {
+	recovery.GoLoop(ctx, "switchports", func() {
+		for {
+			m.Poll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(switchPollInterval):
+			}
+		}
+	})
+}
+
+// Poll 依序輪詢所有交換器 (失敗的交換器保留上一次的 FDB)
+func (m *SwitchPortMapper) Poll(ctx context.Context) {
+	for _, sw := range m.switches {
+		client := &snmp.Client{Addr: sw.Address, Community: sw.Community, V1: sw.Version == "1", Timeout: switchTimeout, Retries: switchRetries}
+		t, err := pollSwitch(ctx, sw.Name, client)
+		if ctx.Err() != nil {
+			return
+		}
+		m.mu.Lock()
+		if err != nil {
+			logger.Warn("Switch poll failed", "switch", sw.Name, "addr", sw.Address, "err", err)
+			t = m.tables[sw.Name]
+			t.Switch, t.Err = sw.Name, err
+		} else {
+			logger.Debug("Switch polled", "switch", sw.Name, "macs", len(t.FDB))
+			t.Polled = time.Now()
+		}
+		m.tables[sw.Name] = t
+		m.mu.Unlock()
+	}
+}
+
+//------------------------------------------------------------------------------
+// 對應
+//------------------------------------------------------------------------------
+
+// DevicePort 設備連接的交換器埠
+type DevicePort struct {
+	Domain     string   `json:"domain"`
+	Device     string   `json:"device"`
+	IPAddress  string   `json:"ip_address,omitempty"`
+	MacAddress string   `json:"mac_address,omitempty"`
+	Switch     string   `json:"switch,omitempty"` // 沒有交換器學到這個 MAC 時為空白
+	Port       string   `json:"port,omitempty"`
+	VLAN       int      `json:"vlan,omitempty"`
+	Shared     int      `json:"shared,omitempty"` // 同一埠學到的其他 MAC (非管理型交換器或上行埠)
+	Via        []string `json:"via,omitempty"`    // 其他學到這個 MAC 的交換器埠 (往設備方向的上行)
+}
+
+// SwitchStatus 交換器的輪詢狀態
+type SwitchStatus struct {
+	Name    string    `json:"name"`
+	Address string    `json:"address"`
+	Polled  time.Time `json:"polled,omitempty"` // 最後一次成功的輪詢
+	MACs    int       `json:"macs"`
+	Error   string    `json:"error,omitempty"` // 最後一次輪詢的錯誤
+}
+
+// SwitchPortReport /api/switchports
+type SwitchPortReport struct {
+	Devices  []DevicePort   `json:"devices"`
+	Switches []SwitchStatus `json:"switches"`
+}
+
+// Report 以最後一次輪詢的結果對應 domains 中的設備
+func (m *SwitchPortMapper) Report(domains []domainDevices) SwitchPortReport {
+	m.mu.Lock()
+	tables := make([]switchTable, 0, len(m.switches))
+	statuses := make([]SwitchStatus, 0, len(m.switches))
+	for _, sw := range m.switches {
+		t, ok := m.tables[sw.Name]
+		status := SwitchStatus{Name: sw.Name, Address: sw.Address, Polled: t.Polled, MACs: len(t.FDB)}
+		if t.Err != nil {
+			status.Error = t.Err.Error()
+		}
+		statuses = append(statuses, status)
+		if ok {
+			tables = append(tables, t)
+		}
+	}
+	m.mu.Unlock()
+	return SwitchPortReport{Devices: mapDevicePorts(tables, domains), Switches: statuses}
+}
+
+// mapDevicePorts 每台設備 (備援設備合併為一台) 學到最少 MAC 的交換器埠
+func mapDevicePorts(tables []switchTable, domains []domainDevices) []DevicePort {
+	ports := []DevicePort{}
+	for _, logical := range mergeRedundantDevices(domains) {
+		dev := logical.Device
+		p := DevicePort{Domain: logical.Domain, Device: dev.Name, IPAddress: dev.IPAddress, MacAddress: normalizeMAC(dev.MacAddress)}
+		if p.MacAddress == "" {
+			for _, t := range tables {
+				if mac, ok := t.ARP[dev.IPAddress]; ok {
+					p.MacAddress = mac
+					break
+				}
+			}
+		}
+
+		type sighting struct {
+			table switchTable
+			entry fdbEntry
+		}
+		var seen []sighting
+		for _, t := range tables {
+			if e, ok := t.FDB[p.MacAddress]; ok && p.MacAddress != "" {
+				seen = append(seen, sighting{t, e})
+			}
+		}
+		slices.SortStableFunc(seen, func(a, b sighting) int {
+			return a.table.PortMACs[a.entry.Port] - b.table.PortMACs[b.entry.Port]
+		})
+		for i, s := range seen {
+			if i == 0 {
+				p.Switch, p.Port, p.VLAN = s.table.Switch, s.entry.Port, s.entry.VLAN
+				p.Shared = s.table.PortMACs[s.entry.Port] - 1
+				continue
+			}
+			p.Via = append(p.Via, s.table.Switch+" "+s.entry.Port)
+		}
+		ports = append(ports, p)
+	}
+	slices.SortFunc(ports, func(a, b DevicePort) int { return strings.Compare(a.Device, b.Device) })
+	return ports
+}
+
+// printSwitchPorts 顯示設備的交換器埠與交換器的輪詢狀態
+func printSwitchPorts(r SwitchPortReport) {
+	fmt.Println()
+	printHeader("%-20s %-10s %-17s %-16s %-16s %s\n", "DEVICE", "DOMAIN", "MAC ADDRESS", "SWITCH", "PORT", "VLAN")
+	fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────────")
+	for _, p := range r.Devices {
+		sw, port, vlan := p.Switch, p.Port, "-"
+		if sw == "" {
+			sw, port = "-", "-"
+		}
+		if p.VLAN > 0 {
+			vlan = fmt.Sprint(p.VLAN)
+		}
+		if p.Shared > 0 {
+			port += i18n.Sprintf(" (+%d MACs)", p.Shared)
+		}
+		mac := p.MacAddress
+		if mac == "" {
+			mac = "-"
+		}
+		fmt.Printf("%-20s %-10s %-17s %-16s %-16s %s\n", p.Device, p.Domain, mac, sw, port, vlan)
+	}
+	fmt.Println()
+	for _, s := range r.Switches {
+		switch {
+		case s.Error != "":
+			fmt.Printf("  ! %s (%s): %s\n", s.Name, s.Address, s.Error)
+		case s.Polled.IsZero():
+			fmt.Print(i18n.Sprintf("  - %s (%s): not polled yet\n", s.Name, s.Address))
+		default:
+			fmt.Print(i18n.Sprintf("  - %s (%s): %d MACs, polled %s\n", s.Name, s.Address, s.MACs, s.Polled.Format(time.TimeOnly)))
+		}
+	}
+	fmt.Println()
+}
+
+//------------------------------------------------------------------------------
+// API 與命令列
+//------------------------------------------------------------------------------
+
+// handleSwitchPorts GET /api/switchports
+func (s *APIServer) handleSwitchPorts(w http.ResponseWriter, r *http.Request) {
+	snapshots := s.snapshots()
+	domains := make([]domainDevices, 0, len(snapshots))
+	for _, d := range snapshots {
+		domains = append(domains, domainDevices{Domain: d.Name, Devices: d.Devices})
+	}
+	writeJSON(w, http.StatusOK, s.switchPorts.Report(domains))
+}
+
+// SwitchPorts daemon 對應的設備交換器埠
+func (c *RemoteClient) SwitchPorts() (SwitchPortReport, error) {
+	var r SwitchPortReport
+	return r, c.do(http.MethodGet, "/api/switchports", nil, &r)
+}
+
+// newSwitchPortsCommand golane switchports
+func newSwitchPortsCommand() *Command {
+	fs := newFlagSet("switchports")
+	lf := addLogFlags(fs)
+	jsonOut := fs.Bool("json", false, "print the port map as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "switchports",
+		Short: "Show the switch and port each device is connected to (switches polled with SNMP)",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			if !remote.enabled() {
+				return errors.New("switches are polled by a running monitor, use -host")
+			}
+			client, err := remote.client()
+			if err != nil {
+				return err
+			}
+			report, err := client.SwitchPorts()
+			if err != nil {
+				return err
+			}
+			if *jsonOut {
+				return printJSON(report)
+			}
+			printSwitchPorts(report)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/snmp"
+)
+
+// fakeSwitch 以根 OID 回傳固定表格的 snmpWalker
+type fakeSwitch map[string][]snmp.VarBind
+
+func (f fakeSwitch) Walk(_ context.Context, root snmp.OID) ([]snmp.VarBind, error) {
+	return f[root.String()], nil
+}
+
+// add 加入 root 之下 index 的變數
+func (f fakeSwitch) add(root snmp.OID, value snmp.Value, index ...uint32) {
+	f[root.String()] = append(f[root.String()], snmp.VarBind{OID: root.Append(index...), Value: value})
+}
+
+// fdb 在 Q-BRIDGE FDB 加入 VLAN 10 的 MAC (00:1d:c1:00:00:last)
+func (f fakeSwitch) fdb(last uint32, port int) {
+	f.add(oidQTpFdbPort, snmp.Integer(port), 10, 0x00, 0x1d, 0xc1, 0, 0, last)
+}
+
+func TestCompileSwitches(t *testing.T) {
+	switches, err := compileSwitches([]SwitchConfig{{Name: "core-sw1", Address: "10.0.0.2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if switches[0].Community != "public" || switches[0].Version != "2c" {
+		t.Errorf("defaults: %+v", switches[0])
+	}
+	for _, bad := range [][]SwitchConfig{
+		{{Address: "10.0.0.2"}},
+		{{Name: "a", Address: "10.0.0.2"}, {Name: "a", Address: "10.0.0.3"}},
+		{{Name: "a"}},
+		{{Name: "a", Address: "10.0.0.2", Version: "3"}},
+	} {
+		if _, err := compileSwitches(bad); !errors.Is(err, ErrInvalidSwitch) {
+			t.Errorf("%+v: err = %v", bad, err)
+		}
+	}
+}
+
+func TestSwitchPortMap(t *testing.T) {
+	// core-sw1: Gi1/0/1 是往 stage-sw 的上行 (學到 3 個 MAC)，Gi1/0/5 接功放
+	core := fakeSwitch{}
+	core.add(oidIfName, snmp.String("Gi1/0/1"), 10101)
+	core.add(oidIfName, snmp.String("Gi1/0/5"), 10105)
+	core.add(oidBasePortIf, snmp.Integer(10101), 1)
+	core.add(oidBasePortIf, snmp.Integer(10105), 5)
+	core.fdb(1, 1)
+	core.fdb(2, 1)
+	core.fdb(3, 1)
+	core.fdb(4, 5)
+	core.add(oidNetToMedia, snmp.String("\x00\x1d\xc1\x00\x00\x03"), 10101, 169, 254, 1, 30)
+
+	// stage-sw 只有 BRIDGE-MIB，沒有 ifName
+	stage := fakeSwitch{}
+	stage.add(oidIfDescr, snmp.String("port3"), 3)
+	stage.add(oidBasePortIf, snmp.Integer(3), 3)
+	stage.add(oidTpFdbPort, snmp.Integer(3), 0x00, 0x1d, 0xc1, 0, 0, 1)
+	stage.add(oidTpFdbPort, snmp.Integer(7), 0x00, 0x1d, 0xc1, 0, 0, 2)
+	stage.add(oidTpFdbPort, snmp.Integer(0), 0x00, 0x1d, 0xc1, 0, 0, 9) // 交換器自己
+
+	var tables []switchTable
+	for name, sw := range map[string]fakeSwitch{"core-sw1": core, "stage-sw": stage} {
+		table, err := pollSwitch(context.Background(), name, sw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, table)
+	}
+	slices.SortFunc(tables, func(a, b switchTable) int { return len(b.FDB) - len(a.FDB) })
+	if len(tables[0].FDB) != 4 || tables[0].ARP["169.254.1.30"] != "00:1d:c1:00:00:03" || len(tables[1].FDB) != 2 {
+		t.Fatalf("tables: %+v", tables)
+	}
+
+	domains := []domainDevices{{Domain: "Dante1", Devices: []dante.Device{
+		{Name: "Stage-Box-A", MacAddress: "00:1D:C1:00:00:01"},
+		{Name: "Stage-Box-B", MacAddress: "00:1d:c1:00:00:02"},
+		{Name: "Monitor", IPAddress: "169.254.1.30"}, // 只有 IP，以 ARP 查 MAC
+		{Name: "Amp", MacAddress: "00:1d:c1:00:00:04"},
+		{Name: "Laptop", MacAddress: "00:1d:c1:00:00:99"},
+	}}}
+	want := map[string]DevicePort{
+		"Stage-Box-A": {Switch: "stage-sw", Port: "port3", Via: []string{"core-sw1 Gi1/0/1"}},
+		"Stage-Box-B": {Switch: "stage-sw", Port: "port 7", Via: []string{"core-sw1 Gi1/0/1"}},
+		"Monitor":     {Switch: "core-sw1", Port: "Gi1/0/1", VLAN: 10, Shared: 2},
+		"Amp":         {Switch: "core-sw1", Port: "Gi1/0/5", VLAN: 10},
+		"Laptop":      {},
+	}
+	ports := mapDevicePorts(tables, domains)
+	if len(ports) != len(want) || ports[0].Device != "Amp" {
+		t.Fatalf("ports: %+v", ports)
+	}
+	for _, p := range ports {
+		w := want[p.Device]
+		if p.Switch != w.Switch || p.Port != w.Port || p.VLAN != w.VLAN || p.Shared != w.Shared || !slices.Equal(p.Via, w.Via) {
+			t.Errorf("%s: %+v, want %+v", p.Device, p, w)
+		}
+	}
+	if ports[slices.IndexFunc(ports, func(p DevicePort) bool { return p.Device == "Monitor" })].MacAddress != "00:1d:c1:00:00:03" {
+		t.Error("MAC not resolved from the ARP table")
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// TCP 控制協定 (Crestron/AMX)
+//==============================================================================
+
+// Crestron/AMX 的控制模組以 TCP 送出簡短的文字指令，並依設備主動送出的回饋
+// 更新按鍵狀態，不會處理 JSON 或 HTTP。設定檔的 tcp_control section 提供這種協定：
+//
+//	"tcp_control": {"addr": ":23000", "allow": ["10.0.10.0/24"]}
+//
+// 一行一個指令 (CR、LF 或 CRLF)，不分大小寫，回應以 CRLF 結尾：
+//
+//	STATUS?                     STATUS <domain> <state> <devices>  (每個網域一行)
+//	DEV?                        DEV <name> <ip> ... DEV END
+//	ROUTE? <rx-device>          ROUTE <rx-ch>@<rx-dev> <tx-ch>@<tx-dev>|- ... ROUTE END
+//	ROUTE <rx-ch>@<rx-dev> <tx-ch>@<tx-dev>|-    ROUTE <rx> <tx> (- 取消訂閱)
+//	PRESET <name>               PRESET <name> <applied>
+//	DOMAIN <name>               DOMAIN <name> (多網域時選擇操作的網域)
+//	PING                        PONG
+//
+// 失敗時回應 ERR <原因>。狀態改變時對所有連線主動送出：
+//
+//	ROUTE <rx> <tx>             訂閱變更 (任何來源，包括自己送出的 ROUTE)
+//	DEV <name> ONLINE|OFFLINE
+//	STATUS <domain> failed
+//	ALARM RAISED|CLEARED <name> <subject>
+//
+// 回饋是狀態而非確認，重複收到同一行不影響模組。協定沒有驗證，allow 限制
+// 可以連線的網段；路由變更與序列埠控制台一樣經過 routing 功能開關與隔離檢查。
+
+// tcpControlQueue 每條連線待送出的行數 (滿了時丟棄主動回饋)
+const tcpControlQueue = 256
+
+// defaultTCPControlClients 預設的同時連線上限
+const defaultTCPControlClients = 16
+
+// TCPControlConfig 設定檔的 tcp_control section
+type TCPControlConfig struct {
+	Addr       string   `json:"addr"`                  // 監聽地址 (例如 :23000)
+	Allow      []string `json:"allow,omitempty"`       // 允許連線的網段 (CIDR，空白表示不限制)
+	MaxClients int      `json:"max_clients,omitempty"` // 同時連線上限 (預設 16)
+}
+
+// Validate 檢查地址與網段
+func (c TCPControlConfig) Validate() error {
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("tcp_control: invalid addr %q: %v", c.Addr, err)
+	}
+	if _, err := c.allowed(); err != nil {
+		return err
+	}
+	if c.MaxClients < 0 {
+		return fmt.Errorf("tcp_control: invalid max_clients %d", c.MaxClients)
+	}
+	return nil
+}
+
+// allowed 解析 allow 網段
+func (c TCPControlConfig) allowed() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.Allow))
+	for _, s := range c.Allow {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("tcp_control: invalid allow %q (use CIDR, e.g. 10.0.10.0/24)", s)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// TCPControl TCP 控制協定的伺服器
+type TCPControl struct {
+	console *ControlConsole
+	allow   []netip.Prefix
+	limit   int
+
+	mu      sync.Mutex
+	clients map[*tcpControlClient]struct{}
+}
+
+// tcpControlClient 一條連線
+type tcpControlClient struct {
+	conn    net.Conn
+	out     chan string
+	session consoleSession
+}
+
+// NewTCPControl 建立伺服器 (cfg 已經過 Validate)
+func NewTCPControl(cfg TCPControlConfig, console *ControlConsole) *TCPControl {
+	allow, _ := cfg.allowed()
+	limit := cfg.MaxClients
+	if limit == 0 {
+		limit = defaultTCPControlClients
+	}
+	return &TCPControl{console: console, allow: allow, limit: limit, clients: make(map[*tcpControlClient]struct{})}
+}
+
+// Start 開始監聽並轉送事件，直到 ctx 結束 (回傳實際監聽的地址)
+func (t *TCPControl) Start(ctx context.Context, addr string, events *golane.Bus) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	context.AfterFunc(ctx, func() { ln.Close() })
+	logger.Info("TCP control listening", "addr", ln.Addr().String())
+
+	sub := events.Subscribe(0, golane.TopicRoute, golane.TopicDeviceOnline,
+		golane.TopicDeviceOffline, golane.TopicDomainFailed, golane.TopicAlarm)
+	recovery.Go("tcpcontrol/events", func() {
+		defer sub.Close()
+		recovery.Loop(ctx, "tcpcontrol/events", func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e := <-sub.C:
+					if line := tcpControlFeedback(e); line != "" {
+						t.broadcast(line)
+					}
+				}
+			}
+		})
+	})
+	recovery.Go("tcpcontrol", func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Warn("TCP control stopped accepting connections", "err", err)
+				}
+				return
+			}
+			t.accept(ctx, conn)
+		}
+	})
+	return ln.Addr(), nil
+}
+
+// accept 檢查來源與連線數後開始服務
+func (t *TCPControl) accept(ctx context.Context, conn net.Conn) {
+	remote := conn.RemoteAddr().String()
+	if !t.permitted(conn.RemoteAddr()) {
+		logger.Warn("TCP control connection refused", "remote", remote)
+		conn.Close()
+		return
+	}
+	c := &tcpControlClient{conn: conn, out: make(chan string, tcpControlQueue), session: consoleSession{source: "tcp"}}
+	t.mu.Lock()
+	if len(t.clients) >= t.limit {
+		t.mu.Unlock()
+		fmt.Fprint(conn, "ERR too many connections\r\n")
+		conn.Close()
+		return
+	}
+	t.clients[c] = struct{}{}
+	t.mu.Unlock()
+	logger.Info("TCP control client connected", "remote", remote)
+
+	connCtx, cancel := context.WithCancel(ctx)
+	context.AfterFunc(connCtx, func() { conn.Close() })
+	recovery.Go("tcpcontrol/write", func() {
+		defer cancel()
+		for {
+			select {
+			case <-connCtx.Done():
+				return
+			case line := <-c.out:
+				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if _, err := conn.Write([]byte(line + "\r\n")); err != nil {
+					return
+				}
+			}
+		}
+	})
+	recovery.Go("tcpcontrol/read", func() {
+		defer func() {
+			cancel()
+			t.mu.Lock()
+			delete(t.clients, c)
+			t.mu.Unlock()
+			logger.Info("TCP control client disconnected", "remote", remote)
+		}()
+		scanner := bufio.NewScanner(conn)
+		scanner.Buffer(make([]byte, 0, 256), consoleMaxLine)
+		scanner.Split(scanConsoleLines)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			cmdCtx, cancelCmd := context.WithTimeout(connCtx, consoleCommandTimeout)
+			lines, err := t.execute(cmdCtx, &c.session, line)
+			cancelCmd()
+			if err != nil {
+				lines = append(lines, "ERR "+strings.ReplaceAll(err.Error(), "\n", " "))
+			}
+			for _, l := range lines {
+				select {
+				case c.out <- l:
+				case <-connCtx.Done():
+					return
+				}
+			}
+		}
+	})
+}
+
+// permitted 來源地址是否在 allow 網段內
+func (t *TCPControl) permitted(addr net.Addr) bool {
+	if len(t.allow) == 0 {
+		return true
+	}
+	ap, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := ap.Addr().Unmap()
+	for _, p := range t.allow {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcast 送給所有連線 (佇列已滿的連線丟棄這行)
+func (t *TCPControl) broadcast(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for c := range t.clients {
+		select {
+		case c.out <- line:
+		default:
+			logger.Debug("TCP control client too slow, feedback dropped", "remote", c.conn.RemoteAddr().String())
+		}
+	}
+}
+
+// execute 執行一行指令，回傳回應的各行
+func (t *TCPControl) execute(ctx context.Context, s *consoleSession, line string) ([]string, error) {
+	args, err := splitConsoleArgs(line)
+	if err != nil || len(args) == 0 {
+		return nil, err
+	}
+	cmd, args := strings.ToUpper(args[0]), args[1:]
+	want := func(n int, usage string) error {
+		if len(args) != n {
+			return fmt.Errorf("usage: %s", usage)
+		}
+		return nil
+	}
+
+	switch cmd {
+	case "PING":
+		return []string{"PONG"}, nil
+	case "STATUS?":
+		var lines []string
+		for _, snap := range t.console.domains.Snapshots() {
+			lines = append(lines, fmt.Sprintf("STATUS %s %s %d", snap.Name, snap.State, len(snap.Devices)))
+		}
+		return lines, nil
+	case "DEV?":
+		snap, err := t.console.snapshot(s)
+		if err != nil {
+			return nil, err
+		}
+		lines := make([]string, 0, len(snap.Devices)+1)
+		for _, d := range snap.Devices {
+			lines = append(lines, fmt.Sprintf("DEV %s %s", d.Name, consoleValue(d.IPAddress)))
+		}
+		return append(lines, "DEV END"), nil
+	case "ROUTE?":
+		if err := want(1, "ROUTE? <rx-device>"); err != nil {
+			return nil, err
+		}
+		name, err := t.console.domainName(s)
+		if err != nil {
+			return nil, err
+		}
+		subs, err := t.console.routes[name].ListSubscriptions(ctx, args[0])
+		if err != nil {
+			return nil, err
+		}
+		lines := make([]string, 0, len(subs)+1)
+		for _, sub := range subs {
+			lines = append(lines, tcpRouteLine(sub.Channel, args[0], sub.TxChannel, sub.TxDevice))
+		}
+		return append(lines, "ROUTE END"), nil
+	case "ROUTE":
+		if err := want(2, "ROUTE <rx-channel>@<rx-device> <tx-channel>@<tx-device>|-"); err != nil {
+			return nil, err
+		}
+		tx := args[1]
+		if tx == "-" {
+			tx = ""
+		}
+		if err := t.console.route(ctx, s, args[0], tx); err != nil {
+			return nil, err
+		}
+		return []string{"ROUTE " + consoleQuote(args[0]) + " " + consoleValue(tx)}, nil
+	case "PRESET":
+		if err := want(1, "PRESET <name>"); err != nil {
+			return nil, err
+		}
+		if t.console.triggers == nil {
+			return nil, errors.New("no presets configured")
+		}
+		ev, err := t.console.triggers.Recall(ctx, args[0], s.source, false)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("PRESET %s %d", consoleQuote(args[0]), ev.Applied)}, nil
+	case "DOMAIN":
+		if len(args) == 0 {
+			return []string{"DOMAIN " + consoleValue(s.domain)}, nil
+		}
+		if _, err := t.console.Execute(ctx, s, line); err != nil {
+			return nil, err
+		}
+		return []string{"DOMAIN " + s.domain}, nil
+	}
+	return nil, fmt.Errorf("unknown command %s", cmd)
+}
+
+// tcpRouteLine ROUTE <rx-ch>@<rx-dev> <tx-ch>@<tx-dev>|-
+func tcpRouteLine(rxChannel, rxDevice, txChannel, txDevice string) string {
+	tx := "-"
+	if txChannel != "" {
+		tx = consoleQuote(txChannel + "@" + txDevice)
+	}
+	return "ROUTE " + consoleQuote(rxChannel+"@"+rxDevice) + " " + tx
+}
+
+// tcpControlFeedback 事件對應的主動回饋 (不轉送的事件回傳空白)
+func tcpControlFeedback(e golane.Event) string {
+	switch e.Topic {
+	case golane.TopicRoute:
+		if r, ok := e.Data.(golane.RouteChange); ok {
+			return tcpRouteLine(r.RxChannel, r.RxDevice, r.TxChannel, r.TxDevice)
+		}
+	case golane.TopicDeviceOnline:
+		return "DEV " + e.Subject + " ONLINE"
+	case golane.TopicDeviceOffline:
+		return "DEV " + e.Subject + " OFFLINE"
+	case golane.TopicDomainFailed:
+		return "STATUS " + e.Domain + " failed"
+	case golane.TopicAlarm:
+		if a, ok := e.Data.(AlarmEvent); ok {
+			return fmt.Sprintf("ALARM %s %s %s", strings.ToUpper(a.State), a.Name, consoleValue(a.Subject))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"danteCS/golane"
+)
+
+func TestTCPControl(t *testing.T) {
+	events := golane.NewBus()
+	console, d := newTestConsole(t, events)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	addr, err := NewTCPControl(TCPControlConfig{Addr: "127.0.0.1:0"}, console).Start(ctx, "127.0.0.1:0", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dial := func() (net.Conn, *bufio.Reader) {
+		conn, err := net.Dial("tcp", addr.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		return conn, bufio.NewReader(conn)
+	}
+	expect := func(r *bufio.Reader, want string) {
+		t.Helper()
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading %q: %v", want, err)
+		}
+		if line != want+"\r\n" {
+			t.Fatalf("got %q, want %q", line, want)
+		}
+	}
+
+	ctrlConn, ctrl := dial()
+	fmt.Fprint(ctrlConn, "ping\r")
+	expect(ctrl, "PONG")
+	fmt.Fprint(ctrlConn, "STATUS?\r")
+	expect(ctrl, "STATUS Dante1 running 4")
+
+	// 另一條連線的路由變更主動送給所有連線
+	otherConn, other := dial()
+	fmt.Fprint(otherConn, "ROUTE 01@Amp-Left 02@FOH-Console\r\n")
+	expect(other, "ROUTE 01@Amp-Left 02@FOH-Console")
+	expect(ctrl, "ROUTE 01@Amp-Left 02@FOH-Console")
+
+	fmt.Fprint(ctrlConn, "ROUTE 01@Amp-Left 01@Stage-Box-A\r")
+	line, _ := ctrl.ReadString('\n')
+	if line[:4] != "ERR " {
+		t.Errorf("route to a quarantined device = %q", line)
+	}
+	fmt.Fprint(ctrlConn, "ROUTE 02@Amp-Left -\rROUTE? Amp-Left\r")
+	for line := ""; line != "ROUTE END\r\n"; {
+		if line, err = ctrl.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+	}
+	subs, err := d.ListSubscriptions(ctx, "Amp-Left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs[0].TxChannel != "02" || subs[1].Subscribed() {
+		t.Errorf("Amp-Left = %+v", subs[:2])
+	}
+}
+
+func TestTCPControlAllow(t *testing.T) {
+	cfg := TCPControlConfig{Addr: ":23000", Allow: []string{"10.0.10.0/24"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	c := NewTCPControl(cfg, nil)
+	for addr, want := range map[string]bool{"10.0.10.7:5000": true, "[::ffff:10.0.10.7]:5000": true, "10.0.11.7:5000": false} {
+		tcp, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.permitted(tcp); got != want {
+			t.Errorf("permitted(%s) = %v, want %v", addr, got, want)
+		}
+	}
+	if (TCPControlConfig{Addr: ":23000", Allow: []string{"10.0.10.0"}}).Validate() == nil {
+		t.Error("allow without prefix length accepted")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"danteCS/internal/backoff"
+)
+
+//==============================================================================
+// 時間設定
+//==============================================================================
+
+// 事件處理間隔、首次發現等待與刷新間隔可由參數或設定檔的 timing section 調整：
+// 慢速或大型網路需要較長的發現時間，展示時則希望盡快看到設備。
+// 命令列明確指定的參數優先於設定檔。
+
+// 時間設定的允許範圍
+const (
+	minEventInterval = 50 * time.Millisecond // 更短只會佔住 SDK thread
+	maxEventInterval = 5 * time.Second       // 更長會讓事件通知明顯延遲
+	maxDiscoveryWait = 5 * time.Minute
+	maxDeviceTTL     = time.Minute // 更長時錯過變更通知的設備資訊會過時太久
+	maxCallAttempts  = 10          // SDK 呼叫的重試次數上限 (更多次只會拖慢刷新)
+)
+
+// TimingConfig 設定檔的 timing section (Go duration 格式，例如 "250ms"、"1m")
+type TimingConfig struct {
+	EventInterval      string `json:"event_interval,omitempty"`       // -event-interval
+	DiscoveryWait      string `json:"discovery_wait,omitempty"`       // -wait
+	RefreshInterval    string `json:"refresh_interval,omitempty"`     // -interval
+	RefreshDebounce    string `json:"refresh_debounce,omitempty"`     // -refresh-debounce
+	RefreshMinInterval string `json:"refresh_min_interval,omitempty"` // -refresh-min-interval
+}
+
+// apply 把設定值寫入命令列沒有明確指定的參數
+func (tc *TimingConfig) apply(fs *flag.FlagSet) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	values := []struct{ flag, value string }{
+		{"event-interval", tc.EventInterval},
+		{"wait", tc.DiscoveryWait},
+		{"interval", tc.RefreshInterval},
+		{"refresh-debounce", tc.RefreshDebounce},
+		{"refresh-min-interval", tc.RefreshMinInterval},
+	}
+	for _, v := range values {
+		if v.value == "" || explicit[v.flag] {
+			continue
+		}
+		if err := fs.Set(v.flag, v.value); err != nil {
+			return fmt.Errorf("config timing (-%s): %v", v.flag, err)
+		}
+	}
+	return nil
+}
+
+// checkEventInterval 檢查背景事件處理間隔
+func checkEventInterval(interval time.Duration) error {
+	if interval < minEventInterval || interval > maxEventInterval {
+		return fmt.Errorf("-event-interval must be between %v and %v, got %v", minEventInterval, maxEventInterval, interval)
+	}
+	return nil
+}
+
+// checkDeviceTTL 檢查設備資訊快取的有效時間
+func checkDeviceTTL(ttl time.Duration) error {
+	if ttl < 0 || ttl > maxDeviceTTL {
+		return fmt.Errorf("-device-cache-ttl must be between 0 and %v, got %v", maxDeviceTTL, ttl)
+	}
+	return nil
+}
+
+// checkDiscoveryWait 檢查首次設備發現的等待時間
+func checkDiscoveryWait(wait time.Duration) error {
+	if wait < 0 || wait > maxDiscoveryWait {
+		return fmt.Errorf("-wait must be between 0 and %v, got %v", maxDiscoveryWait, wait)
+	}
+	return nil
+}
+
+// checkCallRetry 檢查 SDK 呼叫的重試 (不允許無限重試，否則刷新會一直卡在同一次呼叫)
+func checkCallRetry(p backoff.Policy) error {
+	switch {
+	case p.MaxAttempts < 1 || p.MaxAttempts > maxCallAttempts:
+		return fmt.Errorf("-sdk-retry-attempts must be between 1 and %d, got %d", maxCallAttempts, p.MaxAttempts)
+	case p.Initial < 0 || p.Max < 0:
+		return fmt.Errorf("-sdk-retry-delay and -sdk-retry-max-delay must not be negative")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("-sdk-retry-jitter must be between 0 and 1, got %g", p.Jitter)
+	}
+	return nil
+}
+
+// checkTiming 檢查事件處理間隔、設備快取、SDK 重試與發現等待時間 (開始偵測介面前)
+func (f *interfaceFlags) checkTiming(wait time.Duration) error {
+	if err := checkEventInterval(f.eventInterval); err != nil {
+		return err
+	}
+	if err := checkDeviceTTL(f.deviceTTL); err != nil {
+		return err
+	}
+	if err := checkCallRetry(f.callRetry); err != nil {
+		return err
+	}
+	return checkDiscoveryWait(wait)
+}
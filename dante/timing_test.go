@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimingConfigRespectsExplicitFlags(t *testing.T) {
+	fs := flag.NewFlagSet("monitor", flag.ContinueOnError)
+	ifaces := addInterfaceFlags(fs)
+	opts := &MonitorOptions{Refresh: DefaultRefreshPolicy()}
+	fs.DurationVar(&opts.Wait, "wait", 3*time.Second, "")
+	fs.DurationVar(&opts.Refresh.MaxInterval, "interval", opts.Refresh.MaxInterval, "")
+	fs.DurationVar(&opts.Refresh.Debounce, "refresh-debounce", opts.Refresh.Debounce, "")
+	fs.DurationVar(&opts.Refresh.MinInterval, "refresh-min-interval", opts.Refresh.MinInterval, "")
+	if err := fs.Parse([]string{"-wait", "1s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := &TimingConfig{EventInterval: "250ms", DiscoveryWait: "20s", RefreshInterval: "2m"}
+	if err := tc.apply(fs); err != nil {
+		t.Fatal(err)
+	}
+	if ifaces.eventInterval != 250*time.Millisecond || opts.Refresh.MaxInterval != 2*time.Minute {
+		t.Fatalf("config not applied: event %v, interval %v", ifaces.eventInterval, opts.Refresh.MaxInterval)
+	}
+	if opts.Wait != time.Second {
+		t.Fatalf("-wait = %v, the command line must win over the config", opts.Wait)
+	}
+
+	if err := (&TimingConfig{RefreshDebounce: "soon"}).apply(fs); err == nil || !strings.Contains(err.Error(), "refresh-debounce") {
+		t.Fatalf("invalid duration accepted: %v", err)
+	}
+}
+
+func TestTimingBounds(t *testing.T) {
+	if err := checkEventInterval(10 * time.Millisecond); err == nil {
+		t.Fatal("10ms event interval accepted")
+	}
+	if err := checkEventInterval(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkDeviceTTL(2 * time.Minute); err == nil {
+		t.Fatal("2m device cache TTL accepted")
+	}
+	if err := checkDeviceTTL(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkDiscoveryWait(-time.Second); err == nil {
+		t.Fatal("negative discovery wait accepted")
+	}
+	if err := checkDiscoveryWait(0); err != nil {
+		t.Fatal(err)
+	}
+}
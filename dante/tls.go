@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//==============================================================================
+// TLS (管理 API 與 WebSocket)
+//==============================================================================
+
+// 管理 API、/api/events 的 WebSocket 與網頁介面共用同一個 listener，啟用
+// TLS 後全部改走 HTTPS/WSS：
+//
+//	-tls-cert/-tls-key    使用既有的憑證 (PEM，憑證檔可以包含中繼憑證)
+//	-tls-self-signed      第一次啟動時在狀態目錄產生自簽憑證，之後沿用
+//
+// 自簽憑證的 SAN 包含主機名稱、localhost 與產生時本機所有介面的地址；
+// 地址變更後刪除 tls-cert.pem 與 tls-key.pem 即可在下次啟動時重新產生。
+// 遠端命令以 -host https://... -tls-ca tls-cert.pem 信任自簽憑證。
+
+// 自簽憑證的檔名 (位於狀態目錄)
+const (
+	selfSignedCertFile = "tls-cert.pem"
+	selfSignedKeyFile  = "tls-key.pem"
+)
+
+// selfSignedValidity 自簽憑證的有效期間
+const selfSignedValidity = 5 * 365 * 24 * time.Hour
+
+// TLSOptions -tls 參數
+type TLSOptions struct {
+	Cert       string // 憑證檔
+	Key        string // 私鑰檔
+	SelfSigned bool   // 沒有憑證時產生自簽憑證
+}
+
+// Enabled 是否啟用 TLS
+func (o TLSOptions) Enabled() bool {
+	return o.Cert != "" || o.SelfSigned
+}
+
+// Validate 檢查參數
+func (o TLSOptions) Validate() error {
+	if (o.Cert == "") != (o.Key == "") {
+		return errors.New("-tls-cert and -tls-key must be set together")
+	}
+	if o.Cert != "" && o.SelfSigned {
+		return errors.New("-tls-self-signed cannot be combined with -tls-cert")
+	}
+	return nil
+}
+
+// Config 載入 (或產生) 憑證，回傳伺服器的 TLS 設定
+func (o TLSOptions) Config(stateDir string) (*tls.Config, error) {
+	certFile, keyFile := o.Cert, o.Key
+	if o.SelfSigned {
+		certFile = filepath.Join(stateDir, selfSignedCertFile)
+		keyFile = filepath.Join(stateDir, selfSignedKeyFile)
+		if _, err := os.Stat(certFile); os.IsNotExist(err) {
+			if err := generateSelfSigned(certFile, keyFile, selfSignedHosts()); err != nil {
+				return nil, fmt.Errorf("failed to generate self-signed certificate: %v", err)
+			}
+			logger.Info("Generated self-signed TLS certificate", "cert", certFile)
+		}
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS certificate: %v", err)
+	}
+	if time.Now().After(leaf.NotAfter) {
+		logger.Warn("TLS certificate has expired", "cert", certFile, "not_after", leaf.NotAfter)
+	}
+	logger.Info("TLS enabled", "cert", certFile, "sha256", certFingerprint(leaf), "not_after", leaf.NotAfter.Format(time.DateOnly))
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// certFingerprint 憑證的 SHA-256 指紋 (與瀏覽器顯示的格式相同)
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// selfSignedHosts 自簽憑證的 SAN：主機名稱、localhost 與本機所有地址
+func selfSignedHosts() []string {
+	hosts := []string{"localhost"}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		hosts = append(hosts, name)
+	}
+	addrs, _ := net.InterfaceAddrs()
+	for _, addr := range addrs {
+		if prefix, ok := addr.(*net.IPNet); ok {
+			hosts = append(hosts, prefix.IP.String())
+		}
+	}
+	return hosts
+}
+
+// generateSelfSigned 產生 ECDSA P-256 自簽憑證 (私鑰檔權限 0600)
+func generateSelfSigned(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "GOlane management API", Organization: []string{"GOlane"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+}
+
+// loadCertPool 讀取 -tls-ca 的憑證
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSelfSignedTLS(t *testing.T) {
+	dir := t.TempDir()
+	opts := TLSOptions{SelfSigned: true}
+	cfg, err := opts.Config(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := os.ReadFile(filepath.Join(dir, selfSignedCertFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, selfSignedKeyFile)); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("key file: %v %v", info.Mode(), err)
+	}
+	// 第二次啟動沿用既有的憑證
+	if _, err := opts.Config(dir); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, selfSignedCertFile)); !bytes.Equal(again, certPEM) {
+		t.Error("certificate regenerated on second start")
+	}
+
+	server := httptest.NewUnstartedServer(NewAPIServer(APIConfig{}).mux)
+	server.TLS = cfg
+	server.StartTLS()
+	defer server.Close()
+
+	remote := &remoteFlags{host: server.URL, ca: filepath.Join(dir, selfSignedCertFile)}
+	client, err := remote.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("request with -tls-ca: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status %d", resp.StatusCode)
+	}
+
+	// 沒有 -tls-ca 時不信任自簽憑證
+	client, _ = (&remoteFlags{host: server.URL}).client()
+	if _, err := client.http.Get(server.URL + "/healthz"); err == nil {
+		t.Error("self-signed certificate trusted without -tls-ca")
+	}
+}
+
+func TestTLSOptionsValidate(t *testing.T) {
+	for _, o := range []TLSOptions{{Cert: "a.pem"}, {Key: "a.key"}, {Cert: "a.pem", Key: "a.key", SelfSigned: true}} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("%+v accepted", o)
+		}
+	}
+	if err := (TLSOptions{Cert: "a.pem", Key: "a.key"}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 管理 API 權杖
+//==============================================================================
+
+// 管理 API 在 eth0 上，沒有權杖時任何人都能改路由。權杖有三個來源：
+//
+//	-api-token          單一權杖 (名稱 default，沿用舊的設定)
+//	設定檔 api_tokens   具名的權杖，可以只寫 SHA-256 避免設定檔保存明文
+//	golane token issue  發行後保存在 <state-dir>/api-tokens.json (只保存 SHA-256)，
+//	                    執行中的 monitor 在檔案變更時重新讀取，不需要重啟
+//
+// 有任何權杖時 API 需要 Authorization: Bearer <token>。每個權杖有一個角色，
+// 在 API 層依路由檢查：
+//
+//	viewer    只能讀取 (GET、HEAD)：設備列表、狀態、拓撲、稽核紀錄…
+//	operator  加上控制操作：路由訂閱、flow、preset、觸發輸入、隔離、事件處理、
+//	          圖示與平面圖
+//	admin     加上管理操作：功能開關 (未指定角色時的預設值)
+//
+// -api-public-reads 讓讀取不需要權杖，未帶權杖的請求視為 viewer。稽核紀錄
+// 在沒有 X-Golane-Actor 時以 token:<名稱> 作為操作人員。
+//
+// 設定檔範例：
+//
+//	"api_tokens": [
+//	  {"name": "automation", "token": "…"},
+//	  {"name": "console", "token": "…", "role": "operator"},
+//	  {"name": "dashboard", "sha256": "9f86d0…", "role": "viewer"}
+//	]
+
+// tokenFileName 發行的權杖檔名稱
+const tokenFileName = "api-tokens.json"
+
+// tokenPrefix 發行的權杖前綴 (在日誌或設定中容易辨識)
+const tokenPrefix = "glt_"
+
+// defaultTokenName -api-token 的名稱
+const defaultTokenName = "default"
+
+// 權杖角色 (由低到高)
+const (
+	RoleViewer   = "viewer"   // 只能讀取
+	RoleOperator = "operator" // 讀取與控制操作
+	RoleAdmin    = "admin"    // 全部操作
+)
+
+// roles 角色依權限由低到高排列
+var roles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+// ErrInvalidToken 權杖設定錯誤
+var ErrInvalidToken = errors.New("invalid API token")
+
+// ConfiguredToken 設定檔 api_tokens 的項目 (Token 與 SHA256 擇一)
+type ConfiguredToken struct {
+	Name   string `json:"name"`
+	Token  string `json:"token,omitempty"`
+	SHA256 string `json:"sha256,omitempty"` // 權杖的 SHA-256 (hex)
+	Role   string `json:"role,omitempty"`   // viewer、operator 或 admin (預設)
+}
+
+// IssuedToken golane token issue 發行的權杖 (不保存明文)
+type IssuedToken struct {
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	SHA256  string    `json:"sha256"`
+	Created time.Time `json:"created"`
+}
+
+// apiToken 驗證用的權杖
+type apiToken struct {
+	name string
+	role string
+	hash [sha256.Size]byte
+}
+
+// hashToken 權杖的 SHA-256
+func hashToken(token string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(token))
+}
+
+// validRole 檢查角色，空白為 admin
+func validRole(role string) (string, error) {
+	if role == "" {
+		return RoleAdmin, nil
+	}
+	if !slices.Contains(roles, role) {
+		return "", fmt.Errorf("%w: unknown role %q, use %s", ErrInvalidToken, role, strings.Join(roles, ", "))
+	}
+	return role, nil
+}
+
+// roleAllows 角色是否具有 need 的權限
+func roleAllows(role, need string) bool {
+	return slices.Index(roles, role) >= slices.Index(roles, need)
+}
+
+// compileTokens 檢查設定檔的權杖 (名稱不可重複)
+func compileTokens(configured []ConfiguredToken) ([]apiToken, error) {
+	var tokens []apiToken
+	seen := make(map[string]bool)
+	for i, c := range configured {
+		if c.Name == "" {
+			return nil, fmt.Errorf("%w: api_tokens[%d] has no name", ErrInvalidToken, i)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("%w: duplicate name %q", ErrInvalidToken, c.Name)
+		}
+		seen[c.Name] = true
+		role, err := validRole(c.Role)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		t := apiToken{name: c.Name, role: role}
+		switch {
+		case c.Token != "" && c.SHA256 != "":
+			return nil, fmt.Errorf("%w: %s sets both token and sha256", ErrInvalidToken, c.Name)
+		case c.Token != "":
+			t.hash = hashToken(c.Token)
+		case c.SHA256 != "":
+			sum, err := hex.DecodeString(c.SHA256)
+			if err != nil || len(sum) != sha256.Size {
+				return nil, fmt.Errorf("%w: %s sha256 must be 64 hex digits", ErrInvalidToken, c.Name)
+			}
+			copy(t.hash[:], sum)
+		default:
+			return nil, fmt.Errorf("%w: %s needs a token or sha256", ErrInvalidToken, c.Name)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// TokenStore 設定的權杖與狀態目錄中發行的權杖
+type TokenStore struct {
+	mu      sync.Mutex
+	static  []apiToken // -api-token 與設定檔
+	path    string     // 發行的權杖檔 (空白表示沒有)
+	modTime time.Time  // 上次讀取時權杖檔的修改時間與大小
+	size    int64
+	issued  []apiToken
+}
+
+// NewTokenStore 建立權杖驗證 (dir 空白時不讀取發行的權杖)
+func NewTokenStore(dir, token string, configured []ConfiguredToken) (*TokenStore, error) {
+	static, err := compileTokens(configured)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		if slices.ContainsFunc(static, func(t apiToken) bool { return t.name == defaultTokenName }) {
+			return nil, fmt.Errorf("%w: api_tokens must not use the name %q with -api-token", ErrInvalidToken, defaultTokenName)
+		}
+		static = append(static, apiToken{name: defaultTokenName, role: RoleAdmin, hash: hashToken(token)})
+	}
+	s := &TokenStore{static: static}
+	if dir != "" {
+		s.path = filepath.Join(dir, tokenFileName)
+		s.mu.Lock()
+		err := s.reloadLocked()
+		s.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// reloadLocked 權杖檔變更時重新讀取 (必須持有 mu)
+func (s *TokenStore) reloadLocked() error {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		s.issued, s.modTime, s.size = nil, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+	issued, err := readIssuedTokens(s.path)
+	if err != nil {
+		return err
+	}
+	s.issued = s.issued[:0]
+	for _, t := range issued {
+		sum, err := hex.DecodeString(t.SHA256)
+		if err != nil || len(sum) != sha256.Size {
+			logger.Warn("Ignoring malformed issued API token", "name", t.Name, "file", s.path)
+			continue
+		}
+		entry := apiToken{name: t.Name, role: t.Role}
+		copy(entry.hash[:], sum)
+		s.issued = append(s.issued, entry)
+	}
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// tokens 目前所有的權杖 (讀取失敗時沿用上次的發行權杖)
+func (s *TokenStore) tokens() []apiToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path != "" {
+		if err := s.reloadLocked(); err != nil {
+			logger.Warn("Failed to reload issued API tokens", "file", s.path, "err", err)
+		}
+	}
+	return append(slices.Clip(s.static), s.issued...)
+}
+
+// Enabled 是否有任何權杖 (沒有時 API 不驗證)
+func (s *TokenStore) Enabled() bool {
+	return s != nil && len(s.tokens()) > 0
+}
+
+// HasRole 是否有角色為 role 的權杖
+func (s *TokenStore) HasRole(role string) bool {
+	return s != nil && slices.ContainsFunc(s.tokens(), func(t apiToken) bool { return t.role == role })
+}
+
+// Authenticate 驗證權杖，回傳名稱與角色
+func (s *TokenStore) Authenticate(token string) (name, role string, ok bool) {
+	if s == nil || token == "" {
+		return "", "", false
+	}
+	sum := hashToken(token)
+	for _, t := range s.tokens() {
+		if subtle.ConstantTimeCompare(sum[:], t.hash[:]) == 1 {
+			name, role, ok = t.name, t.role, true
+		}
+	}
+	return name, role, ok
+}
+
+//------------------------------------------------------------------------------
+// 發行與撤銷 (golane token)
+//------------------------------------------------------------------------------
+
+// readIssuedTokens 讀取權杖檔 (不存在時回傳空白)
+func readIssuedTokens(path string) ([]IssuedToken, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tokens []IssuedToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return tokens, nil
+}
+
+// writeIssuedTokens 以暫存檔 + rename 寫入 (只有擁有者可讀)
+func writeIssuedTokens(path string, tokens []IssuedToken) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// IssueToken 發行新權杖並保存 SHA-256，回傳只顯示這一次的明文
+func IssueToken(dir, name, role string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: name must not be empty", ErrInvalidToken)
+	}
+	role, err := validRole(role)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, tokenFileName)
+	tokens, err := readIssuedTokens(path)
+	if err != nil {
+		return "", err
+	}
+	if slices.ContainsFunc(tokens, func(t IssuedToken) bool { return t.Name == name }) {
+		return "", fmt.Errorf("%w: a token named %q already exists, revoke it first", ErrInvalidToken, name)
+	}
+
+	var secret [24]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return "", err
+	}
+	token := tokenPrefix + hex.EncodeToString(secret[:])
+	sum := hashToken(token)
+	tokens = append(tokens, IssuedToken{Name: name, Role: role, SHA256: hex.EncodeToString(sum[:]), Created: time.Now().UTC()})
+	if err := writeIssuedTokens(path, tokens); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeToken 撤銷發行的權杖
+func RevokeToken(dir, name string) error {
+	path := filepath.Join(dir, tokenFileName)
+	tokens, err := readIssuedTokens(path)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(tokens, func(t IssuedToken) bool { return t.Name == name })
+	if i < 0 {
+		return fmt.Errorf("no issued token named %q", name)
+	}
+	return writeIssuedTokens(path, slices.Delete(tokens, i, i+1))
+}
+
+// ListIssuedTokens 發行的權杖 (不含明文)
+func ListIssuedTokens(dir string) ([]IssuedToken, error) {
+	return readIssuedTokens(filepath.Join(dir, tokenFileName))
+}
+
+// printIssuedTokens 以表格列出發行的權杖
+func printIssuedTokens(w io.Writer, tokens []IssuedToken) {
+	if len(tokens) == 0 {
+		fmt.Fprintln(w, i18n.T("No issued tokens"))
+		return
+	}
+	rows := [][]string{{i18n.T("NAME"), i18n.T("ROLE"), i18n.T("CREATED"), "SHA-256"}}
+	for _, t := range tokens {
+		rows = append(rows, []string{t.Name, t.Role, t.Created.Local().Format(time.DateTime), t.SHA256[:min(12, len(t.SHA256))] + "…"})
+	}
+	printTable(w, rows)
+}
+
+//------------------------------------------------------------------------------
+// 驗證結果
+//------------------------------------------------------------------------------
+
+// apiTokenKey context 中通過驗證的權杖
+type apiTokenKey struct{}
+
+// apiIdentity 請求的權杖名稱與角色
+type apiIdentity struct {
+	name string
+	role string
+}
+
+// withToken 標記請求的權杖與角色 (name 空白表示未帶權杖)
+func withToken(ctx context.Context, name, role string) context.Context {
+	return context.WithValue(ctx, apiTokenKey{}, apiIdentity{name: name, role: role})
+}
+
+// tokenName 請求使用的權杖名稱 (未驗證時為空白)
+func tokenName(r *http.Request) string {
+	id, _ := r.Context().Value(apiTokenKey{}).(apiIdentity)
+	return id.name
+}
+
+// requestRole 請求的角色 (沒有經過 authorize 時為 admin)
+func requestRole(r *http.Request) string {
+	if id, ok := r.Context().Value(apiTokenKey{}).(apiIdentity); ok {
+		return id.role
+	}
+	return RoleAdmin
+}
+
+// requireRole 限制路由需要的角色 (authorize 已依方法檢查過 viewer/operator)
+func requireRole(need string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if role := requestRole(r); !roleAllows(role, need) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("this request requires the %s role, the API token has %s", need, role))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// bearerToken 請求的權杖：Authorization: Bearer，瀏覽器的 WebSocket 無法附加標頭，
+// 所以也接受 ?token= 參數
+func bearerToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
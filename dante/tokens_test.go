@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenStore(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha256.Sum256([]byte("hashed"))
+	store, err := NewTokenStore(dir, "secret", []ConfiguredToken{
+		{Name: "nms", SHA256: hex.EncodeToString(sum[:]), Role: RoleViewer},
+		{Name: "console", Token: "plain"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for token, want := range map[string][2]string{
+		"secret": {defaultTokenName, RoleAdmin},
+		"hashed": {"nms", RoleViewer},
+		"plain":  {"console", RoleAdmin},
+	} {
+		if name, role, ok := store.Authenticate(token); !ok || name != want[0] || role != want[1] {
+			t.Errorf("%s: %s %s %v", token, name, role, ok)
+		}
+	}
+	if _, _, ok := store.Authenticate("wrong"); ok {
+		t.Error("unknown token accepted")
+	}
+
+	// 發行的權杖不需要重啟就生效，撤銷後失效
+	token, err := IssueToken(dir, "ops", RoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, role, ok := store.Authenticate(token); !ok || name != "ops" || role != RoleViewer {
+		t.Errorf("issued token: %s %s %v", name, role, ok)
+	}
+	if _, err := IssueToken(dir, "ops", ""); err == nil {
+		t.Error("duplicate name issued")
+	}
+	if err := RevokeToken(dir, "ops"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := store.Authenticate(token); ok {
+		t.Error("revoked token accepted")
+	}
+
+	for _, bad := range [][]ConfiguredToken{
+		{{Name: "a"}},
+		{{Name: "a", Token: "x"}, {Name: "a", Token: "y"}},
+		{{Name: "a", SHA256: "abc"}},
+		{{Name: "a", Token: "x", Role: "write"}},
+	} {
+		if _, err := NewTokenStore("", "", bad); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%+v: %v", bad, err)
+		}
+	}
+}
+
+func TestAuthorizeRoles(t *testing.T) {
+	store, err := NewTokenStore("", "", []ConfiguredToken{
+		{Name: "admin", Token: "admin-token"},
+		{Name: "console", Token: "operator-token", Role: RoleOperator},
+		{Name: "nms", Token: "viewer-token", Role: RoleViewer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actor string
+	handler := func(w http.ResponseWriter, r *http.Request) { actor = requestActor(r) }
+
+	cases := []struct {
+		open   bool
+		method string
+		admin  bool // 需要 admin 的路由
+		token  string
+		status int
+	}{
+		{false, http.MethodGet, false, "", http.StatusUnauthorized},
+		{false, http.MethodGet, false, "viewer-token", http.StatusOK},
+		{false, http.MethodPost, false, "viewer-token", http.StatusForbidden},
+		{false, http.MethodPost, false, "operator-token", http.StatusOK},
+		{false, http.MethodPut, true, "operator-token", http.StatusForbidden},
+		{false, http.MethodPut, true, "admin-token", http.StatusOK},
+		{true, http.MethodGet, false, "", http.StatusOK},
+		{true, http.MethodPost, false, "", http.StatusUnauthorized},
+		{true, http.MethodGet, false, "bogus", http.StatusOK},
+	}
+	for _, c := range cases {
+		s := NewAPIServer(APIConfig{Tokens: store, OpenReads: c.open})
+		h := http.HandlerFunc(handler)
+		if c.admin {
+			h = requireRole(RoleAdmin, h)
+		}
+		req := httptest.NewRequest(c.method, "/api/routes", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		rec := httptest.NewRecorder()
+		s.authorize(h)(rec, req)
+		if rec.Code != c.status {
+			t.Errorf("open %v %s admin %v %q: %d, want %d", c.open, c.method, c.admin, c.token, rec.Code, c.status)
+		}
+	}
+
+	// 沒有權杖時不驗證，所有路由都可以使用
+	rec := httptest.NewRecorder()
+	NewAPIServer(APIConfig{}).authorize(requireRole(RoleAdmin, handler))(rec, httptest.NewRequest(http.MethodPut, "/api/features/x", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("no tokens: %d", rec.Code)
+	}
+
+	// 稽核紀錄以權杖名稱識別
+	s := NewAPIServer(APIConfig{Tokens: store})
+	req := httptest.NewRequest(http.MethodPost, "/api/routes", nil)
+	req.Header.Set("Authorization", "Bearer operator-token")
+	s.authorize(handler)(httptest.NewRecorder(), req)
+	if actor != "token:console" {
+		t.Errorf("actor = %q", actor)
+	}
+}
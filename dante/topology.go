@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/supervisor"
+)
+
+//==============================================================================
+// 網路拓撲匯出
+//==============================================================================
+
+// 交機文件需要一份「現場長什麼樣子」的紀錄：主機介面、各網域、已發現的
+// 設備與目前的訂閱。拓撲可匯出為 JSON (保存、比對) 或 Graphviz dot
+// (dot -Tsvg 產生圖)。訂閱需要逐台讀取接收通道，讀取失敗的設備記錄在
+// Errors 中，不讓一台設備擋住整份匯出。
+
+// Topology 網路拓撲
+type Topology struct {
+	Generated  time.Time              `json:"generated"`
+	Host       []NetworkInterfaceInfo `json:"host_interfaces,omitempty"`
+	Management string                 `json:"management_interface,omitempty"`
+	Domains    []TopologyDomain       `json:"domains"`
+}
+
+// TopologyDomain 單一網域
+type TopologyDomain struct {
+	Name      string           `json:"name"`
+	Interface string           `json:"interface"`
+	IPAddress string           `json:"ip_address"`
+	State     string           `json:"state,omitempty"`
+	Devices   []TopologyDevice `json:"devices"`
+	Routes    []TopologyRoute  `json:"routes"`
+	Errors    []string         `json:"errors,omitempty"` // 無法讀取訂閱的設備
+}
+
+// TopologyDevice 拓撲中的設備
+type TopologyDevice struct {
+	dante.Device
+	Redundancy string `json:"redundancy"`
+}
+
+// TopologyRoute 一條訂閱 (發送通道 → 接收通道)
+type TopologyRoute struct {
+	TxDevice  string `json:"tx_device"`
+	TxChannel string `json:"tx_channel"`
+	RxDevice  string `json:"rx_device"`
+	RxChannel string `json:"rx_channel"`
+	Status    string `json:"status"`
+	State     string `json:"state"`               // dante.Subscription.State
+	Reason    string `json:"reason,omitempty"`    // 沒有聲音的原因
+	Multicast bool   `json:"multicast,omitempty"` // 由 multicast flow 提供
+}
+
+// topologySource 建立拓撲所需的網域資料 (本機 SDK 或 supervisor 快照)
+type topologySource struct {
+	Name      string
+	Interface string
+	IPAddress string
+	State     string
+	Devices   []dante.Device
+	Routes    RouteController // nil 表示不讀取訂閱
+}
+
+// BuildTopology 建立拓撲 (detector 為 nil 時不包含主機介面)
+func BuildTopology(ctx context.Context, detector *NetworkDetector, sources []topologySource) Topology {
+	topo := Topology{Generated: time.Now().UTC(), Domains: []TopologyDomain{}}
+	if detector != nil {
+		topo.Host = detector.AllInterfaces
+		if detector.ManagementInterface != nil {
+			topo.Management = detector.ManagementInterface.Name
+		}
+	}
+
+	for _, src := range sources {
+		domain := TopologyDomain{
+			Name:      src.Name,
+			Interface: src.Interface,
+			IPAddress: src.IPAddress,
+			State:     src.State,
+			Devices:   []TopologyDevice{},
+			Routes:    []TopologyRoute{},
+		}
+		devices := append([]dante.Device{}, src.Devices...)
+		dante.SortDevices(devices, dante.DeviceOrder{Key: dante.SortByName})
+		for _, dev := range devices {
+			domain.Devices = append(domain.Devices, TopologyDevice{Device: dev, Redundancy: dev.Redundancy()})
+			if src.Routes == nil {
+				continue
+			}
+			subs, err := src.Routes.ListSubscriptions(ctx, dev.Name)
+			if err != nil {
+				domain.Errors = append(domain.Errors, fmt.Sprintf("%s: %v", dev.Name, err))
+				continue
+			}
+			for _, sub := range subs {
+				if !sub.Subscribed() {
+					continue
+				}
+				domain.Routes = append(domain.Routes, TopologyRoute{
+					TxDevice:  sub.TxDevice,
+					TxChannel: sub.TxChannel,
+					RxDevice:  dev.Name,
+					RxChannel: sub.Channel,
+					Status:    sub.StatusText(),
+					State:     sub.State(),
+					Reason:    sub.Reason(),
+					Multicast: sub.Multicast(),
+				})
+			}
+		}
+		topo.Domains = append(topo.Domains, domain)
+	}
+	return topo
+}
+
+// maxDotEdgeChannels dot 連線標籤最多列出的通道數
+const maxDotEdgeChannels = 4
+
+// WriteTopologyDot 以 Graphviz dot 格式輸出：每個網域一個 cluster，
+// 同一對設備之間的訂閱合併為一條連線 (標籤列出通道)
+func WriteTopologyDot(w io.Writer, topo Topology) error {
+	var b strings.Builder
+	b.WriteString("digraph golane {\n")
+	b.WriteString("  graph [rankdir=LR, fontname=\"Helvetica\"];\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+	fmt.Fprintf(&b, "  label=%s;\n", dotQuote("Generated "+topo.Generated.Format(time.DateTime)+" UTC"))
+
+	for i, d := range topo.Domains {
+		fmt.Fprintf(&b, "\n  subgraph cluster_%d {\n", i)
+		label := fmt.Sprintf("%s\n%s (%s)", d.Name, d.Interface, d.IPAddress)
+		if d.State != "" {
+			label += "\n" + d.State
+		}
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(label))
+
+		known := make(map[string]bool)
+		for _, dev := range d.Devices {
+			known[dev.Name] = true
+			label := dev.Name
+			if dev.Model != "" {
+				label += "\n" + dev.Model
+			}
+			label += "\n" + dev.IPAddress
+			attrs := ""
+			if dev.Redundancy != dante.RedundancyRedundant && dev.Redundancy != dante.RedundancyPrimaryOnly {
+				attrs = ", color=orange"
+			}
+			fmt.Fprintf(&b, "    %s [label=%s%s];\n", dotQuote(dotNodeID(d.Name, dev.Name)), dotQuote(label), attrs)
+		}
+
+		// 發送設備不在列表中 (離線或在其他網域) 時以虛線表示，
+		// 有訂閱沒有聲音的連線以紅色表示
+		type pair struct{ tx, rx string }
+		channels := make(map[pair][]string)
+		broken := make(map[pair]bool)
+		var pairs []pair
+		for _, r := range d.Routes {
+			p := pair{r.TxDevice, r.RxDevice}
+			if _, ok := channels[p]; !ok {
+				pairs = append(pairs, p)
+			}
+			channels[p] = append(channels[p], r.TxChannel+" → "+r.RxChannel)
+			if r.State != "" && r.State != dante.SubscriptionOK {
+				broken[p] = true
+			}
+			if !known[r.TxDevice] {
+				known[r.TxDevice] = true
+				fmt.Fprintf(&b, "    %s [label=%s, style=\"rounded,dashed\"];\n",
+					dotQuote(dotNodeID(d.Name, r.TxDevice)), dotQuote(r.TxDevice))
+			}
+		}
+		b.WriteString("  }\n")
+
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return pairs[i].tx+"\x00"+pairs[i].rx < pairs[j].tx+"\x00"+pairs[j].rx
+		})
+		for _, p := range pairs {
+			list := channels[p]
+			label := strings.Join(list[:min(len(list), maxDotEdgeChannels)], "\n")
+			if len(list) > maxDotEdgeChannels {
+				label += fmt.Sprintf("\n+%d more", len(list)-maxDotEdgeChannels)
+			}
+			attrs := ""
+			if broken[p] {
+				attrs = ", color=red"
+			}
+			fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n",
+				dotQuote(dotNodeID(d.Name, p.tx)), dotQuote(dotNodeID(d.Name, p.rx)), dotQuote(label), attrs)
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotNodeID 設備節點 ID (不同網域的同名設備是不同節點)
+func dotNodeID(domain, device string) string {
+	return domain + "/" + device
+}
+
+// dotQuote 以 dot 的雙引號字串表示 (換行轉成 \n)
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// topology 以 supervisor 快照與路由控制建立拓撲 (只有執行中的網域讀取訂閱)
+func (s *APIServer) topology(ctx context.Context) Topology {
+	var sources []topologySource
+	for _, snap := range s.snapshots() {
+		src := topologySource{
+			Name:      snap.Name,
+			Interface: snap.Interface,
+			IPAddress: snap.IPAddress,
+			State:     snap.State,
+			Devices:   snap.Devices,
+		}
+		if snap.State == supervisor.StateRunning {
+			src.Routes = s.routes[snap.Name]
+		}
+		sources = append(sources, src)
+	}
+	return BuildTopology(ctx, s.detector, sources)
+}
+
+// handleTopology GET /api/topology[?format=dot]
+func (s *APIServer) handleTopology(w http.ResponseWriter, r *http.Request) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		writeJSON(w, http.StatusOK, s.topology(r.Context()))
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		WriteTopologyDot(w, s.topology(r.Context()))
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q, use json or dot", format))
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"danteCS/internal/dante"
+)
+
+func TestTopologyExport(t *testing.T) {
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0", IPAddress: "192.168.100.1"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	ctx := context.Background()
+	if err := d.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartDeviceScan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(ctx)
+
+	for _, ch := range []string{"01", "02", "03", "04"} {
+		if err := d.Subscribe(ctx, "Amp-Left", ch, "FOH-Console", ch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Subscribe(ctx, "Amp-Right", "01", "Playback-PC", "01"); err != nil {
+		t.Fatal(err)
+	}
+
+	topo := BuildTopology(ctx, nil, []topologySource{{
+		Name: d.Name, Interface: "sim0", IPAddress: "192.168.100.1", Devices: d.GetDevices(), Routes: d,
+	}})
+	if len(topo.Domains) != 1 {
+		t.Fatalf("got %d domains", len(topo.Domains))
+	}
+	domain := topo.Domains[0]
+	if len(domain.Devices) != 4 || domain.Devices[0].Name != "Amp-Left" || len(domain.Routes) != 5 || len(domain.Errors) != 0 {
+		t.Fatalf("unexpected topology: %+v", domain)
+	}
+	if r := domain.Routes[0]; r.TxDevice != "FOH-Console" || r.RxDevice != "Amp-Left" || r.RxChannel != "01" {
+		t.Fatalf("unexpected route: %+v", r)
+	}
+
+	var dot strings.Builder
+	if err := WriteTopologyDot(&dot, topo); err != nil {
+		t.Fatal(err)
+	}
+	out := dot.String()
+	for _, want := range []string{
+		`"Dante1/FOH-Console" -> "Dante1/Amp-Left" [label="01 → 01\n02 → 02\n03 → 03\n04 → 04"];`,
+		`"Dante1/Playback-PC" [label="Playback-PC", style="rounded,dashed"];`,
+		`"Dante1/Amp-Right" [label="Amp-Right\nPA-4D\n169.254.12.7"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dot output missing %s\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"danteCS/internal/trace"
+)
+
+//==============================================================================
+// 分散式追蹤 (HTTP)
+//==============================================================================
+
+// API 請求的 server span 與遠端請求的 traceparent 傳遞；span 與 OTLP 匯出在
+// internal/trace。
+
+// injectTraceparent 把 ctx 的 span 識別加到外送請求
+func injectTraceparent(ctx context.Context, h http.Header) {
+	if sc := trace.FromContext(ctx); sc.Valid() {
+		h.Set(trace.TraceparentHeader, trace.FormatTraceparent(sc))
+	}
+}
+
+// statusRecorder 記錄回應狀態碼
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap 讓 http.ResponseController 取得原始的 ResponseWriter (WebSocket hijack)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// traceHandler 為每個 API 請求建立 server span (延續呼叫端的 traceparent)
+func traceHandler(pattern string, next http.Handler) http.Handler {
+	method, route, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, route = "", pattern
+	}
+	name := strings.TrimSpace(method + " " + route)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !trace.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		if sc, ok := trace.ParseTraceparent(r.Header.Get(trace.TraceparentHeader)); ok {
+			ctx = trace.WithRemote(ctx, sc)
+		}
+		ctx, span := trace.StartKind(ctx, name, trace.KindServer,
+			slog.String("http.request.method", r.Method),
+			slog.String("http.route", route),
+			slog.String("url.path", r.URL.Path))
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(slog.Int("http.response.status_code", rec.status))
+		if rec.status >= 500 {
+			span.RecordError(fmt.Errorf("HTTP %d", rec.status))
+		}
+	})
+}
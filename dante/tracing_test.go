@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/trace"
+)
+
+// exportedSpan OTLP/HTTP JSON 中測試需要的欄位
+type exportedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+}
+
+// fakeCollector 收集 OTLP/HTTP JSON 送來的 span
+type fakeCollector struct {
+	mu    sync.Mutex
+	spans []exportedSpan
+}
+
+func (c *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []exportedSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+// byName 依名稱找 span
+func (c *fakeCollector) byName(name string) (exportedSpan, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.spans {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return exportedSpan{}, false
+}
+
+func TestRouteRequestTrace(t *testing.T) {
+	collector := &fakeCollector{}
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+	tr, err := trace.NewTracer(trace.Config{Endpoint: server.URL, ServiceName: "test", SampleRatio: 1, Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace.SetGlobal(tr)
+	t.Cleanup(func() {
+		trace.SetGlobal(nil)
+		tr.Shutdown(context.Background())
+	})
+
+	sim := dante.NewSimulatedSDK(dante.DefaultSimulationConfig())
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"}, sim)
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+
+	api := httptest.NewServer(NewAPIServer(APIConfig{Routes: map[string]RouteController{d.Name: d}}).mux)
+	t.Cleanup(api.Close)
+
+	const caller = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req, _ := http.NewRequest(http.MethodPut, api.URL+"/api/routes/Amp-Left/01",
+		strings.NewReader(`{"tx_channel": "01", "tx_device": "FOH-Console"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(trace.TraceparentHeader, caller)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT route: status %d", resp.StatusCode)
+	}
+
+	tr.Flush(context.Background())
+	request, ok := collector.byName("PUT /api/routes/{device}/{channel}")
+	if !ok {
+		t.Fatalf("no server span exported: %+v", collector.spans)
+	}
+	sdk, ok := collector.byName("dante.route_subscribe")
+	if !ok {
+		t.Fatal("no SDK span exported")
+	}
+	if request.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || request.ParentSpanID != "00f067aa0ba902b7" || request.Kind != trace.KindServer {
+		t.Fatalf("server span does not continue the caller's trace: %+v", request)
+	}
+	if sdk.TraceID != request.TraceID || sdk.ParentSpanID != request.SpanID {
+		t.Fatalf("SDK span is not a child of the request: %+v", sdk)
+	}
+}
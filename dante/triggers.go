@@ -0,0 +1,680 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/recovery"
+	"danteCS/internal/trace"
+)
+
+//==============================================================================
+// 觸發輸入 (audio-follow-video)
+//==============================================================================
+
+// 視訊切換台切換訊號源時送出觸發 (HTTP、OSC 或 GPIO 接點)，依設定檔的
+// triggers.map 找到對應的 preset 並套用其中的訂閱，讓音訊跟著畫面切換。
+// preset 只包含要改變的通道；套用前先驗證 (經過隔離中設備的訂閱視為問題，
+// 自動觸發不 override)，triggers.partial 決定有問題時是否套用其餘訂閱。
+
+// oscTriggerAddress 通用的 OSC 觸發位址：/golane/trigger <input> 或 /golane/trigger/<input>
+const oscTriggerAddress = "/golane/trigger"
+
+// defaultTriggerDebounce 同一個輸入重複觸發的忽略時間 (切換台常重送 tally)
+const defaultTriggerDebounce = 250 * time.Millisecond
+
+// gpioPollInterval GPIO 接點的輪詢間隔
+const gpioPollInterval = 20 * time.Millisecond
+
+// gpioRoot sysfs GPIO 目錄 (測試時替換)
+var gpioRoot = "/sys/class/gpio"
+
+// errUnknownTrigger 輸入沒有對應的 preset
+var errUnknownTrigger = errors.New("unknown trigger input")
+
+// errUnknownPreset preset 不存在
+var errUnknownPreset = errors.New("unknown preset")
+
+// Preset 一組訂閱 (場景)
+type Preset struct {
+	Name   string        `json:"name"`
+	Routes []PresetRoute `json:"routes"`
+}
+
+// PresetRoute preset 中的一條訂閱，TxDevice 空白表示取消訂閱
+type PresetRoute struct {
+	Domain    string `json:"domain,omitempty"` // 多個網域時指定
+	RxDevice  string `json:"rx_device"`
+	RxChannel string `json:"rx_channel"`
+	TxDevice  string `json:"tx_device,omitempty"`
+	TxChannel string `json:"tx_channel,omitempty"`
+}
+
+// GPIOInput 以 sysfs GPIO 接點作為觸發輸入 (需先由系統 export)
+type GPIOInput struct {
+	Pin       int    `json:"pin"`
+	Input     string `json:"input"`                // 觸發輸入名稱 (對應 triggers.map)
+	ActiveLow bool   `json:"active_low,omitempty"` // 接點拉低時觸發
+}
+
+// TriggerConfig 設定檔的 triggers section
+type TriggerConfig struct {
+	Map        map[string]string `json:"map"`                   // 觸發輸入 → preset 名稱
+	OSCAddr    string            `json:"osc_addr,omitempty"`    // OSC 的 UDP 監聽地址 (例如 :9000)
+	GPIO       []GPIOInput       `json:"gpio,omitempty"`        // GPIO 接點
+	DebounceMS int               `json:"debounce_ms,omitempty"` // 同一輸入重複觸發的忽略時間 (0 使用預設值)
+	Partial    bool              `json:"partial,omitempty"`     // 驗證有問題時仍套用其餘訂閱 (預設整個 preset 不套用)
+}
+
+// TriggerEvent 一次觸發的結果
+type TriggerEvent struct {
+	Input     string          `json:"input,omitempty"`
+	Preset    string          `json:"preset"`
+	Source    string          `json:"source"` // http、osc、gpio、serial
+	Time      time.Time       `json:"time"`
+	Applied   int             `json:"applied"`             // 成功套用的訂閱數
+	Problems  []PresetProblem `json:"problems,omitempty"`  // 套用前驗證發現的問題 (這些訂閱沒有套用)
+	Errors    []string        `json:"errors,omitempty"`    // 套用時失敗的訂閱
+	Debounced bool            `json:"debounced,omitempty"` // 重複觸發而被忽略
+}
+
+// TriggerEngine 把觸發輸入轉成 preset 套用
+type TriggerEngine struct {
+	presets    map[string]Preset // 小寫名稱為 key
+	inputs     map[string]string // 觸發輸入 → preset 名稱
+	routes     map[string]RouteController
+	quarantine *QuarantineStore
+	features   *FeatureFlags
+	debounce   time.Duration
+	partial    bool // 自動觸發時允許部分套用
+
+	mu    sync.Mutex
+	fired map[string]time.Time // 各輸入最後觸發時間
+	last  *TriggerEvent
+}
+
+// NewTriggerEngine 驗證 preset 與對應後建立觸發引擎
+func NewTriggerEngine(cfg TriggerConfig, presets []Preset, routes map[string]RouteController, quarantine *QuarantineStore, features *FeatureFlags) (*TriggerEngine, error) {
+	e := &TriggerEngine{
+		presets:    make(map[string]Preset),
+		inputs:     make(map[string]string),
+		routes:     routes,
+		quarantine: quarantine,
+		features:   features,
+		debounce:   defaultTriggerDebounce,
+		partial:    cfg.Partial,
+		fired:      make(map[string]time.Time),
+	}
+	if cfg.DebounceMS > 0 {
+		e.debounce = time.Duration(cfg.DebounceMS) * time.Millisecond
+	}
+
+	for _, p := range presets {
+		key := strings.ToLower(p.Name)
+		if p.Name == "" {
+			return nil, errors.New("preset without name")
+		}
+		if _, dup := e.presets[key]; dup {
+			return nil, fmt.Errorf("duplicate preset %s", p.Name)
+		}
+		for i, r := range p.Routes {
+			if r.RxDevice == "" || r.RxChannel == "" {
+				return nil, fmt.Errorf("preset %s route %d: rx_device and rx_channel are required", p.Name, i+1)
+			}
+			if (r.TxDevice == "") != (r.TxChannel == "") {
+				return nil, fmt.Errorf("preset %s route %d: tx_device and tx_channel go together", p.Name, i+1)
+			}
+			if _, err := e.controller(r.Domain); err != nil {
+				return nil, fmt.Errorf("preset %s route %d: %v", p.Name, i+1, err)
+			}
+		}
+		e.presets[key] = p
+	}
+
+	for input, preset := range cfg.Map {
+		if _, ok := e.presets[strings.ToLower(preset)]; !ok {
+			return nil, fmt.Errorf("trigger %q: %w %s", input, errUnknownPreset, preset)
+		}
+		e.inputs[input] = preset
+	}
+	for _, g := range cfg.GPIO {
+		if _, ok := e.inputs[g.Input]; !ok {
+			return nil, fmt.Errorf("gpio %d: trigger input %q is not mapped", g.Pin, g.Input)
+		}
+	}
+	return e, nil
+}
+
+// controller 依網域名稱選擇路由控制，只有一個網域時可省略
+func (e *TriggerEngine) controller(domain string) (RouteController, error) {
+	if domain != "" {
+		rc, ok := e.routes[domain]
+		if !ok {
+			return nil, fmt.Errorf("unknown domain %q", domain)
+		}
+		return rc, nil
+	}
+	if len(e.routes) != 1 {
+		return nil, errors.New("domain is required when several domains are available")
+	}
+	for _, rc := range e.routes {
+		return rc, nil
+	}
+	return nil, errors.New("no domain available")
+}
+
+// Presets 所有 preset
+func (e *TriggerEngine) Presets() []Preset {
+	list := make([]Preset, 0, len(e.presets))
+	for _, p := range e.presets {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+	return list
+}
+
+// triggerStatus GET /api/triggers 的內容
+type triggerStatus struct {
+	Map  map[string]string `json:"map"`
+	Last *TriggerEvent     `json:"last,omitempty"`
+}
+
+// Status 觸發對應與最後一次觸發
+func (e *TriggerEngine) Status() triggerStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return triggerStatus{Map: e.inputs, Last: e.last}
+}
+
+// Fire 處理觸發輸入：找到對應的 preset 並套用 (同一輸入在 debounce 內重複時忽略)
+func (e *TriggerEngine) Fire(ctx context.Context, input, source string) (TriggerEvent, error) {
+	if !e.features.Enabled(FeatureTriggers) {
+		return TriggerEvent{}, fmt.Errorf("feature %s is disabled", FeatureTriggers)
+	}
+	preset, ok := e.inputs[input]
+	if !ok {
+		return TriggerEvent{}, fmt.Errorf("%w %q", errUnknownTrigger, input)
+	}
+
+	now := time.Now()
+	e.mu.Lock()
+	if last, ok := e.fired[input]; ok && now.Sub(last) < e.debounce {
+		e.mu.Unlock()
+		return TriggerEvent{Input: input, Preset: preset, Source: source, Time: now, Debounced: true}, nil
+	}
+	e.fired[input] = now
+	e.mu.Unlock()
+
+	ev, err := e.Recall(ctx, preset, source, e.partial)
+	ev.Input = input
+	return ev, err
+}
+
+// Recall 驗證後套用 preset 的訂閱
+// 驗證有問題時不套用任何訂閱並回傳 errPresetNotReady (結果中列出問題)，
+// partial 為 true 時只跳過有問題的訂閱；套用時個別失敗不中斷其他訂閱
+func (e *TriggerEngine) Recall(ctx context.Context, name, source string, partial bool) (TriggerEvent, error) {
+	p, ok := e.presets[strings.ToLower(name)]
+	if !ok {
+		return TriggerEvent{}, fmt.Errorf("%w %s", errUnknownPreset, name)
+	}
+
+	ctx, span := trace.Start(ctx, "trigger.recall",
+		slog.String("trigger.preset", p.Name), slog.String("trigger.source", source))
+	defer span.End()
+	// 稽核紀錄中的訂閱變更標示來自哪個 preset (OSC、GPIO 沒有操作人員，以來源代替)
+	ctx = withAuditNote(withAuditVia(withAuditActor(ctx, source), AuditViaTrigger), "preset "+p.Name)
+
+	check := e.check(ctx, p)
+	ev := TriggerEvent{Preset: p.Name, Source: source, Time: time.Now(), Problems: check.Problems}
+	if !check.Ready && !partial {
+		span.SetAttributes(slog.Int("trigger.problems", len(check.Problems)))
+		logger.Warn("Preset not recalled, endpoints missing", "preset", p.Name, "source", source,
+			"problems", len(check.Problems))
+		return ev, fmt.Errorf("%w: %s has %d problem(s)", errPresetNotReady, p.Name, len(check.Problems))
+	}
+
+	skip := make(map[int]bool)
+	for _, problem := range check.Problems {
+		skip[problem.Route] = true
+	}
+	for i, r := range p.Routes {
+		if skip[i] {
+			continue
+		}
+		if err := e.apply(ctx, r); err != nil {
+			ev.Errors = append(ev.Errors, fmt.Sprintf("%s/%s: %v", r.RxDevice, r.RxChannel, err))
+			continue
+		}
+		ev.Applied++
+	}
+	span.SetAttributes(slog.Int("trigger.applied", ev.Applied), slog.Int("trigger.failed", len(ev.Errors)),
+		slog.Int("trigger.problems", len(ev.Problems)))
+
+	if len(ev.Errors) > 0 || len(ev.Problems) > 0 {
+		logger.Warn("Preset partially recalled", "preset", p.Name, "source", source,
+			"applied", ev.Applied, "skipped", len(ev.Problems), "errors", ev.Errors)
+	} else {
+		logger.Info("Preset recalled", "preset", p.Name, "source", source, "applied", ev.Applied)
+	}
+
+	e.mu.Lock()
+	e.last = &ev
+	e.mu.Unlock()
+	return ev, nil
+}
+
+// apply 套用單一訂閱 (已通過驗證)
+func (e *TriggerEngine) apply(ctx context.Context, r PresetRoute) error {
+	rc, err := e.controller(r.Domain)
+	if err != nil {
+		return err
+	}
+	return rc.Subscribe(ctx, r.RxDevice, r.RxChannel, r.TxDevice, r.TxChannel)
+}
+
+//------------------------------------------------------------------------------
+// 套用前驗證
+//------------------------------------------------------------------------------
+
+// 現場切換時套用到一半才發現設備不在線上最難收拾，所以套用前先對照
+// 目前的設備列表與接收通道檢查每一條訂閱。發送通道名稱無法從 SDK 列出，
+// 只檢查發送設備在線上 (通道不存在時訂閱會顯示 unresolved)。
+
+// errPresetNotReady preset 驗證有問題而沒有套用
+var errPresetNotReady = errors.New("preset not ready")
+
+// deviceInventory 可列出已發現設備的路由控制 (dante.Domain)
+type deviceInventory interface {
+	GetDevices() []dante.Device
+}
+
+// PresetProblem 一條訂閱的驗證問題
+type PresetProblem struct {
+	Route int `json:"route"` // preset 中的訂閱索引 (0-based)
+	PresetRoute
+	Problem string `json:"problem"`
+}
+
+// PresetCheck preset 的驗證結果
+type PresetCheck struct {
+	Preset   string          `json:"preset"`
+	Ready    bool            `json:"ready"`
+	Problems []PresetProblem `json:"problems,omitempty"`
+}
+
+// Check 對照目前的設備列表驗證 preset
+func (e *TriggerEngine) Check(ctx context.Context, name string) (PresetCheck, error) {
+	p, ok := e.presets[strings.ToLower(name)]
+	if !ok {
+		return PresetCheck{}, fmt.Errorf("%w %s", errUnknownPreset, name)
+	}
+	return e.check(ctx, p), nil
+}
+
+// check 驗證每條訂閱的設備在線上、接收通道存在且沒有經過隔離中的設備
+func (e *TriggerEngine) check(ctx context.Context, p Preset) PresetCheck {
+	result := PresetCheck{Preset: p.Name}
+	online := make(map[RouteController]map[string]bool)
+	channels := make(map[string][]dante.Subscription)
+
+	for i, r := range p.Routes {
+		problem := func(format string, args ...any) {
+			result.Problems = append(result.Problems, PresetProblem{Route: i, PresetRoute: r, Problem: fmt.Sprintf(format, args...)})
+		}
+
+		rc, err := e.controller(r.Domain)
+		if err != nil {
+			problem("%v", err)
+			continue
+		}
+		if _, ok := online[rc]; !ok {
+			online[rc] = onlineDevices(rc)
+		}
+		devices := online[rc]
+
+		if devices != nil && !devices[strings.ToLower(r.RxDevice)] {
+			problem("RX device %s is offline", r.RxDevice)
+			continue
+		}
+		if r.TxDevice != "" && devices != nil && !devices[strings.ToLower(r.TxDevice)] {
+			problem("TX device %s is offline", r.TxDevice)
+			continue
+		}
+
+		key := r.Domain + "/" + strings.ToLower(r.RxDevice)
+		subs, ok := channels[key]
+		if !ok {
+			subs, err = rc.ListSubscriptions(ctx, r.RxDevice)
+			if err != nil {
+				problem("cannot read RX channels of %s: %v", r.RxDevice, err)
+				continue
+			}
+			channels[key] = subs
+		}
+		if !slices.ContainsFunc(subs, func(s dante.Subscription) bool { return s.Channel == r.RxChannel }) {
+			problem("RX channel %s not found on %s", r.RxChannel, r.RxDevice)
+			continue
+		}
+
+		if r.TxDevice != "" {
+			if err := e.quarantine.CheckRoute(r.RxDevice, r.TxDevice); err != nil {
+				problem("%v", err)
+			}
+		}
+	}
+	result.Ready = len(result.Problems) == 0
+	return result
+}
+
+// onlineDevices 已發現的設備名稱 (小寫)；無法列出設備時回傳 nil (不檢查)
+func onlineDevices(rc RouteController) map[string]bool {
+	if wrapped, ok := rc.(interface{ Unwrap() RouteController }); ok {
+		rc = wrapped.Unwrap()
+	}
+	inv, ok := rc.(deviceInventory)
+	if !ok {
+		return nil
+	}
+	names := make(map[string]bool)
+	for _, dev := range inv.GetDevices() {
+		names[strings.ToLower(dev.Name)] = true
+	}
+	return names
+}
+
+//------------------------------------------------------------------------------
+// OSC (UDP)
+//------------------------------------------------------------------------------
+
+// oscMessage 解析後的 OSC 訊息 (只保留觸發需要的參數型別)
+type oscMessage struct {
+	Address string
+	Args    []any // string、int32、float32
+}
+
+// oscInput OSC 訊息對應的觸發輸入名稱
+// /golane/trigger "cam1" 與 /golane/trigger/cam1 都是 "cam1"；
+// 其他位址 (例如切換台的 /atem/program 3) 以 "位址/第一個參數" 表示
+func (m oscMessage) oscInput() string {
+	var arg string
+	if len(m.Args) > 0 {
+		switch v := m.Args[0].(type) {
+		case string:
+			arg = v
+		case int32:
+			arg = strconv.Itoa(int(v))
+		case float32:
+			arg = strconv.FormatFloat(float64(v), 'f', -1, 32)
+		}
+	}
+	if input, ok := strings.CutPrefix(m.Address, oscTriggerAddress+"/"); ok {
+		return input
+	}
+	if m.Address == oscTriggerAddress {
+		return arg
+	}
+	if arg != "" {
+		return m.Address + "/" + arg
+	}
+	return m.Address
+}
+
+// parseOSC 解析 OSC 封包 (訊息或 #bundle)
+func parseOSC(packet []byte) ([]oscMessage, error) {
+	if bytes.HasPrefix(packet, []byte("#bundle\x00")) {
+		if len(packet) < 16 {
+			return nil, errors.New("OSC bundle too short")
+		}
+		rest := packet[16:] // "#bundle\0" + 8 bytes timetag
+		var messages []oscMessage
+		for len(rest) > 0 {
+			if len(rest) < 4 {
+				return nil, errors.New("truncated OSC bundle element")
+			}
+			size := int(binary.BigEndian.Uint32(rest))
+			if size < 0 || size > len(rest)-4 {
+				return nil, errors.New("invalid OSC bundle element size")
+			}
+			inner, err := parseOSC(rest[4 : 4+size])
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, inner...)
+			rest = rest[4+size:]
+		}
+		return messages, nil
+	}
+
+	address, rest, err := oscString(packet)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(address, "/") {
+		return nil, fmt.Errorf("invalid OSC address %q", address)
+	}
+	msg := oscMessage{Address: address}
+	if len(rest) == 0 {
+		return []oscMessage{msg}, nil
+	}
+
+	tags, rest, err := oscString(rest)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range strings.TrimPrefix(tags, ",") {
+		switch tag {
+		case 's':
+			var s string
+			if s, rest, err = oscString(rest); err != nil {
+				return nil, err
+			}
+			msg.Args = append(msg.Args, s)
+		case 'i', 'f':
+			if len(rest) < 4 {
+				return nil, errors.New("truncated OSC argument")
+			}
+			v := binary.BigEndian.Uint32(rest)
+			if tag == 'i' {
+				msg.Args = append(msg.Args, int32(v))
+			} else {
+				msg.Args = append(msg.Args, math.Float32frombits(v))
+			}
+			rest = rest[4:]
+		case 'T', 'F', 'N', 'I':
+			// 沒有資料的型別
+		default:
+			// 不認得的型別無法得知長度，停止解析其餘參數
+			return []oscMessage{msg}, nil
+		}
+	}
+	return []oscMessage{msg}, nil
+}
+
+// oscString 讀取以 NUL 結尾並補齊到 4 bytes 的字串
+func oscString(b []byte) (string, []byte, error) {
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return "", nil, errors.New("unterminated OSC string")
+	}
+	next := (end + 4) &^ 3
+	if next > len(b) {
+		next = len(b)
+	}
+	return string(b[:end]), b[next:], nil
+}
+
+// ListenOSC 在 UDP 地址接收 OSC 觸發，ctx 結束時關閉
+func (e *TriggerEngine) ListenOSC(ctx context.Context, addr string) (net.Addr, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for OSC on %s: %v", addr, err)
+	}
+	logger.Info("OSC trigger input listening", "addr", conn.LocalAddr().String())
+
+	recovery.Go("triggers/osc-close", func() {
+		<-ctx.Done()
+		conn.Close()
+	})
+	recovery.GoLoop(ctx, "triggers/osc", func() {
+		buf := make([]byte, 65536)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Error("OSC trigger input stopped", "err", err)
+				}
+				return
+			}
+			messages, err := parseOSC(buf[:n])
+			if err != nil {
+				logger.Debug("Ignoring invalid OSC packet", "from", from.String(), "err", err)
+				continue
+			}
+			for _, msg := range messages {
+				input := msg.oscInput()
+				if _, err := e.Fire(ctx, input, "osc"); err != nil {
+					logger.Debug("OSC message ignored", "address", msg.Address, "input", input, "err", err)
+				}
+			}
+		}
+	})
+	return conn.LocalAddr(), nil
+}
+
+//------------------------------------------------------------------------------
+// GPIO (sysfs)
+//------------------------------------------------------------------------------
+
+// readGPIO 讀取接點狀態，依 ActiveLow 轉成是否觸發中
+func readGPIO(g GPIOInput) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(gpioRoot, fmt.Sprintf("gpio%d", g.Pin), "value"))
+	if err != nil {
+		return false, err
+	}
+	high := strings.TrimSpace(string(data)) == "1"
+	return high != g.ActiveLow, nil
+}
+
+// WatchGPIO 輪詢 GPIO 接點，由非作用轉為作用時觸發 (讀不到的接點記錄後略過)
+func (e *TriggerEngine) WatchGPIO(ctx context.Context, inputs []GPIOInput) {
+	active := make([]bool, len(inputs))
+	usable := make([]bool, len(inputs))
+	for i, g := range inputs {
+		state, err := readGPIO(g)
+		if err != nil {
+			logger.Warn("GPIO trigger input unavailable (export the pin first)", "pin", g.Pin, "err", err)
+			continue
+		}
+		active[i], usable[i] = state, true
+		logger.Info("GPIO trigger input watching", "pin", g.Pin, "input", g.Input)
+	}
+
+	recovery.GoLoop(ctx, "triggers/gpio", func() {
+		ticker := time.NewTicker(gpioPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for i, g := range inputs {
+				if !usable[i] {
+					continue
+				}
+				state, err := readGPIO(g)
+				if err != nil {
+					continue
+				}
+				if state && !active[i] {
+					if _, err := e.Fire(ctx, g.Input, "gpio"); err != nil {
+						logger.Warn("GPIO trigger failed", "pin", g.Pin, "input", g.Input, "err", err)
+					}
+				}
+				active[i] = state
+			}
+		}
+	})
+}
+
+// Start 啟動設定檔中的 OSC 與 GPIO 輸入
+func (e *TriggerEngine) Start(ctx context.Context, cfg TriggerConfig) error {
+	if cfg.OSCAddr != "" {
+		if _, err := e.ListenOSC(ctx, cfg.OSCAddr); err != nil {
+			return err
+		}
+	}
+	if len(cfg.GPIO) > 0 {
+		e.WatchGPIO(ctx, cfg.GPIO)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+func (s *APIServer) handlePresets(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.triggers.Presets())
+}
+
+func (s *APIServer) handleCheckPreset(w http.ResponseWriter, r *http.Request) {
+	check, err := s.triggers.Check(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, check)
+}
+
+// handleRecallPreset POST /api/presets/{name}/recall[?partial=true]
+// 驗證有問題時回傳 409 與問題列表，確認後以 partial=true 套用其餘訂閱
+func (s *APIServer) handleRecallPreset(w http.ResponseWriter, r *http.Request) {
+	partial, _ := strconv.ParseBool(r.URL.Query().Get("partial"))
+	ev, err := s.triggers.Recall(r.Context(), r.PathValue("name"), "http", partial)
+	switch {
+	case errors.Is(err, errUnknownPreset):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, errPresetNotReady):
+		writeJSON(w, http.StatusConflict, ev)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		writeJSON(w, http.StatusOK, ev)
+	}
+}
+
+func (s *APIServer) handleTriggers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.triggers.Status())
+}
+
+func (s *APIServer) handleFireTrigger(w http.ResponseWriter, r *http.Request) {
+	ev, err := s.triggers.Fire(r.Context(), r.PathValue("input"), "http")
+	switch {
+	case errors.Is(err, errUnknownTrigger):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, errPresetNotReady):
+		writeJSON(w, http.StatusConflict, ev)
+	case err != nil:
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeJSON(w, http.StatusOK, ev)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/internal/dante"
+)
+
+// newTriggerEngine 以模擬網域建立觸發引擎：cam1 接 FOH 1/2，cam2 接 FOH 3 並斷開 02，
+// cam3 經過隔離中的 Stage-Box-A
+func newTriggerEngine(t *testing.T, partial bool) (*TriggerEngine, *dante.Domain) {
+	t.Helper()
+	state, err := OpenStateStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	quarantine, err := NewQuarantineStore(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := quarantine.Quarantine("Stage-Box-A", "intermittent clock", "a1"); err != nil {
+		t.Fatal(err)
+	}
+
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartDeviceScan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	d.RefreshDevices(context.Background())
+
+	presets := []Preset{
+		{Name: "Cam1", Routes: []PresetRoute{
+			{RxDevice: "Amp-Left", RxChannel: "01", TxDevice: "FOH-Console", TxChannel: "01"},
+			{RxDevice: "Amp-Left", RxChannel: "02", TxDevice: "FOH-Console", TxChannel: "02"},
+		}},
+		{Name: "Cam2", Routes: []PresetRoute{
+			{RxDevice: "Amp-Left", RxChannel: "01", TxDevice: "FOH-Console", TxChannel: "03"},
+			{RxDevice: "Amp-Left", RxChannel: "02"},
+		}},
+		{Name: "Cam3", Routes: []PresetRoute{
+			{RxDevice: "Amp-Left", RxChannel: "03", TxDevice: "Stage-Box-A", TxChannel: "01"},
+			{RxDevice: "Amp-Left", RxChannel: "04", TxDevice: "FOH-Console", TxChannel: "04"},
+		}},
+	}
+	cfg := TriggerConfig{
+		Map:        map[string]string{"cam1": "Cam1", "cam2": "cam2", "/atem/program/3": "Cam3"},
+		DebounceMS: 200,
+		Partial:    partial,
+	}
+	e, err := NewTriggerEngine(cfg, presets, map[string]RouteController{d.Name: d}, quarantine, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, d
+}
+
+// txOf 接收通道目前接到的發送通道 (channel@device，未訂閱時空白)
+func txOf(t *testing.T, d *dante.Domain, rxDevice, rxChannel string) string {
+	t.Helper()
+	subs, err := d.ListSubscriptions(context.Background(), rxDevice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range subs {
+		if s.Channel == rxChannel && s.Subscribed() {
+			return s.TxChannel + "@" + s.TxDevice
+		}
+	}
+	return ""
+}
+
+func TestTriggerRecallsPreset(t *testing.T) {
+	e, d := newTriggerEngine(t, true)
+	ctx := context.Background()
+
+	ev, err := e.Fire(ctx, "cam1", "http")
+	if err != nil || ev.Applied != 2 || len(ev.Errors) != 0 {
+		t.Fatalf("Fire(cam1) = %+v, %v", ev, err)
+	}
+	if got := txOf(t, d, "Amp-Left", "02"); got != "02@FOH-Console" {
+		t.Fatalf("Amp-Left/02 = %q", got)
+	}
+
+	// 同一輸入在 debounce 內重送不會再套用
+	if ev, _ := e.Fire(ctx, "cam1", "http"); !ev.Debounced {
+		t.Fatalf("repeated trigger not debounced: %+v", ev)
+	}
+
+	ev, err = e.Fire(ctx, "cam2", "osc")
+	if err != nil || ev.Applied != 2 {
+		t.Fatalf("Fire(cam2) = %+v, %v", ev, err)
+	}
+	if got := txOf(t, d, "Amp-Left", "01"); got != "03@FOH-Console" {
+		t.Fatalf("Amp-Left/01 = %q", got)
+	}
+	if got := txOf(t, d, "Amp-Left", "02"); got != "" {
+		t.Fatalf("Amp-Left/02 still routed to %q", got)
+	}
+
+	// partial: 經過隔離中設備的訂閱被跳過，其餘訂閱照常套用
+	ev, err = e.Fire(ctx, "/atem/program/3", "osc")
+	if err != nil || ev.Applied != 1 || len(ev.Problems) != 1 || !strings.Contains(ev.Problems[0].Problem, "quarantined") {
+		t.Fatalf("Fire(cam3) = %+v, %v", ev, err)
+	}
+	if got := txOf(t, d, "Amp-Left", "03"); got != "" {
+		t.Fatalf("quarantined route applied: %q", got)
+	}
+	if last := e.Status().Last; last == nil || last.Preset != "Cam3" {
+		t.Fatalf("Status().Last = %+v", last)
+	}
+
+	if _, err := e.Fire(ctx, "cam9", "http"); err == nil {
+		t.Fatal("unmapped trigger accepted")
+	}
+	if _, err := NewTriggerEngine(TriggerConfig{Map: map[string]string{"x": "Nope"}}, nil, nil, nil, nil); err == nil {
+		t.Fatal("mapping to a missing preset accepted")
+	}
+}
+
+// oscPacket 編碼 OSC 訊息 (string 與 int32 參數)
+func oscPacket(address string, args ...any) []byte {
+	pad := func(s string) []byte {
+		b := append([]byte(s), 0)
+		for len(b)%4 != 0 {
+			b = append(b, 0)
+		}
+		return b
+	}
+	tags := ","
+	var data []byte
+	for _, a := range args {
+		switch v := a.(type) {
+		case string:
+			tags += "s"
+			data = append(data, pad(v)...)
+		case int32:
+			tags += "i"
+			data = binary.BigEndian.AppendUint32(data, uint32(v))
+		}
+	}
+	packet := append(pad(address), pad(tags)...)
+	return append(packet, data...)
+}
+
+func TestParseOSC(t *testing.T) {
+	tests := []struct {
+		packet []byte
+		input  string
+	}{
+		{oscPacket("/golane/trigger", "cam1"), "cam1"},
+		{oscPacket("/golane/trigger/cam2"), "cam2"},
+		{oscPacket("/atem/program", int32(3)), "/atem/program/3"},
+	}
+	for _, tt := range tests {
+		messages, err := parseOSC(tt.packet)
+		if err != nil || len(messages) != 1 {
+			t.Fatalf("parseOSC(%q) = %v, %v", tt.packet, messages, err)
+		}
+		if got := messages[0].oscInput(); got != tt.input {
+			t.Errorf("input = %q, want %q", got, tt.input)
+		}
+	}
+
+	// bundle 中的每個訊息都會解析
+	bundle := append([]byte("#bundle\x00"), make([]byte, 8)...)
+	for _, msg := range [][]byte{oscPacket("/golane/trigger/a"), oscPacket("/golane/trigger", "b")} {
+		bundle = binary.BigEndian.AppendUint32(bundle, uint32(len(msg)))
+		bundle = append(bundle, msg...)
+	}
+	messages, err := parseOSC(bundle)
+	if err != nil || len(messages) != 2 || messages[1].oscInput() != "b" {
+		t.Fatalf("parseOSC(bundle) = %+v, %v", messages, err)
+	}
+
+	for _, bad := range [][]byte{[]byte("nope\x00\x00\x00\x00"), []byte("/no-terminator"), []byte("#bundle\x00")} {
+		if _, err := parseOSC(bad); err == nil {
+			t.Errorf("parseOSC(%q) accepted", bad)
+		}
+	}
+}
+
+func TestTriggerInputsOSCAndGPIO(t *testing.T) {
+	e, d := newTriggerEngine(t, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr, err := e.ListenOSC(ctx, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("udp", addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(oscPacket("/golane/trigger", "cam2")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return txOf(t, d, "Amp-Left", "01") == "03@FOH-Console" })
+
+	// GPIO 接點 (active low) 拉低時套用 cam1
+	root := t.TempDir()
+	old := gpioRoot
+	gpioRoot = root
+	t.Cleanup(func() { gpioRoot = old })
+	value := filepath.Join(root, "gpio17", "value")
+	if err := os.MkdirAll(filepath.Dir(value), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(value, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e.WatchGPIO(ctx, []GPIOInput{{Pin: 17, Input: "cam1", ActiveLow: true}})
+	if err := os.WriteFile(value, []byte("0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return txOf(t, d, "Amp-Left", "01") == "01@FOH-Console" })
+}
+
+// waitFor 等待條件成立 (最多 2 秒)
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPresetValidationBeforeRecall(t *testing.T) {
+	e, d := newTriggerEngine(t, false)
+	ctx := context.Background()
+
+	check, err := e.Check(ctx, "cam3")
+	if err != nil || check.Ready || len(check.Problems) != 1 || check.Problems[0].Route != 0 {
+		t.Fatalf("Check(cam3) = %+v, %v", check, err)
+	}
+
+	// 沒有 partial 時整個 preset 都不套用
+	ev, err := e.Recall(ctx, "Cam3", "http", false)
+	if !errors.Is(err, errPresetNotReady) || ev.Applied != 0 || len(ev.Problems) != 1 {
+		t.Fatalf("Recall(Cam3) = %+v, %v", ev, err)
+	}
+	if got := txOf(t, d, "Amp-Left", "04"); got != "" {
+		t.Fatalf("route applied although the preset was not ready: %q", got)
+	}
+
+	// 設定中不存在的接收通道與離線設備
+	e.presets["cam1"] = Preset{Name: "Cam1", Routes: []PresetRoute{
+		{RxDevice: "Amp-Left", RxChannel: "09", TxDevice: "FOH-Console", TxChannel: "01"},
+		{RxDevice: "Amp-Gone", RxChannel: "01", TxDevice: "FOH-Console", TxChannel: "01"},
+		{RxDevice: "Amp-Left", RxChannel: "01", TxDevice: "FOH-Console", TxChannel: "01"},
+	}}
+	check, _ = e.Check(ctx, "cam1")
+	if len(check.Problems) != 2 || !strings.Contains(check.Problems[0].Problem, "RX channel 09") ||
+		!strings.Contains(check.Problems[1].Problem, "offline") {
+		t.Fatalf("Check(cam1) = %+v", check)
+	}
+	ev, err = e.Recall(ctx, "cam1", "http", true)
+	if err != nil || ev.Applied != 1 {
+		t.Fatalf("partial Recall(cam1) = %+v, %v", ev, err)
+	}
+	if got := txOf(t, d, "Amp-Left", "01"); got != "01@FOH-Console" {
+		t.Fatalf("Amp-Left/01 = %q", got)
+	}
+}
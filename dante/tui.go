@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 互動式終端機儀表板
+//==============================================================================
+
+// monitor -tui 以儀表板取代定期輸出的設備表格：
+// 各網域設備 (含時鐘狀態)、Dante 介面狀態與最近的日誌，
+// 按鍵可立即刷新、讓選取的設備閃燈識別或查看詳細資訊。
+// 只使用 ANSI 控制碼與 stty，不需要額外套件。
+// C wrapper 的 printf 直接寫到 fd 1，儀表板執行時 fd 1 改接到管線，
+// 輸出逐行轉成 Debug 日誌，畫面則寫到另外複製的終端機 fd。
+
+// ANSI 控制碼
+const (
+	ansiAltScreenOn  = "\x1b[?1049h"
+	ansiAltScreenOff = "\x1b[?1049l"
+	ansiHideCursor   = "\x1b[?25l"
+	ansiShowCursor   = "\x1b[?25h"
+	ansiHome         = "\x1b[H"
+	ansiClearLine    = "\x1b[K"
+	ansiClearBelow   = "\x1b[J"
+	ansiReverse      = "\x1b[7m"
+	ansiBold         = "\x1b[1m"
+	ansiDim          = "\x1b[2m"
+	ansiRed          = "\x1b[31m"
+	ansiGreen        = "\x1b[32m"
+	ansiYellow       = "\x1b[33m"
+	ansiReset        = "\x1b[0m"
+)
+
+// dashboardLogLines 儀表板保留的日誌行數
+const dashboardLogLines = 200
+
+// DashboardConfig 儀表板設定
+type DashboardConfig struct {
+	Domains  []*dante.Domain
+	Detector *NetworkDetector
+	Logs     *LogBuffer    // 日誌來源 (nil 表示不顯示)
+	Refresh  func()        // 立即刷新設備列表
+	Interval time.Duration // 畫面更新間隔
+}
+
+// dashboardRow 設備表格中的一列
+type dashboardRow struct {
+	domain *dante.Domain
+	device dante.Device
+	clock  dante.ClockInfo
+	hasClk bool
+}
+
+// Dashboard 互動式終端機儀表板
+type Dashboard struct {
+	cfg DashboardConfig
+
+	width, height int
+	selected      int
+	detail        bool // 顯示選取設備的詳細資訊
+
+	out *os.File // 終端機 (執行時 fd 1 導向日誌)
+
+	mu      sync.Mutex
+	status  string        // 底部狀態訊息
+	changed chan struct{} // 背景工作完成時要求重繪
+}
+
+// NewDashboard 建立儀表板
+func NewDashboard(cfg DashboardConfig) *Dashboard {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	return &Dashboard{
+		cfg:     cfg,
+		out:     os.Stdout,
+		width:   80,
+		height:  24,
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Run 顯示儀表板直到按下 q / Ctrl+C 或收到 quit 信號
+func (db *Dashboard) Run(quit <-chan os.Signal) error {
+	restore, err := enterRawMode()
+	if err != nil {
+		return fmt.Errorf("failed to set terminal raw mode: %v", err)
+	}
+	defer restore()
+
+	terminal, restoreStdout, err := redirectStdout(func(line string) {
+		logger.Debug("SDK output", "line", line)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to redirect standard output: %v", err)
+	}
+	defer restoreStdout()
+	db.out = terminal
+	defer func() { db.out = os.Stdout }()
+
+	db.out.WriteString(ansiAltScreenOn + ansiHideCursor)
+	defer db.out.WriteString(ansiShowCursor + ansiAltScreenOff)
+
+	// 讀取 stdin 的 goroutine 在結束後仍會阻塞在 Read 上，行程隨即結束所以不處理
+	keys := make(chan []byte)
+	recovery.Go("tui/input", func() { readKeys(keys) })
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	ticker := time.NewTicker(db.cfg.Interval)
+	defer ticker.Stop()
+
+	db.resize()
+	for {
+		db.draw()
+
+		select {
+		case <-quit:
+			return nil
+		case <-winch:
+			db.resize()
+		case key, ok := <-keys:
+			if !ok || db.handleKey(key) {
+				return nil
+			}
+		case <-ticker.C:
+		case <-db.changed:
+		}
+	}
+}
+
+// handleKey 處理按鍵，回傳 true 表示離開
+func (db *Dashboard) handleKey(key []byte) bool {
+	switch string(key) {
+	case "q", "Q", "\x03":
+		return true
+	case "r", "R":
+		db.background("Refreshing device list...", func() string {
+			db.cfg.Refresh()
+			return "Device list refreshed"
+		})
+	case "i", "I":
+		row, ok := db.selectedRow()
+		if !ok {
+			db.setStatus("No device selected")
+			break
+		}
+		db.background("Identifying "+row.device.Name+"...", func() string {
+			if err := row.domain.Identify(row.device.Name); err != nil {
+				return "Identify failed: " + err.Error()
+			}
+			return "Identify sent to " + row.device.Name
+		})
+	case "\r", "\n", "d", "D":
+		db.detail = !db.detail
+	case "\x1b":
+		db.detail = false
+	case "k", "\x1b[A":
+		if db.selected > 0 {
+			db.selected--
+		}
+	case "j", "\x1b[B":
+		db.selected++
+	}
+	return false
+}
+
+// background 在背景執行可能阻塞的操作 (刷新、識別)，完成後更新狀態訊息
+func (db *Dashboard) background(start string, fn func() string) {
+	db.setStatus(start)
+	recovery.Go("tui/action", func() {
+		db.setStatus(fn())
+	})
+}
+
+// setStatus 更新底部狀態訊息並要求重繪
+func (db *Dashboard) setStatus(msg string) {
+	db.mu.Lock()
+	db.status = msg
+	db.mu.Unlock()
+
+	select {
+	case db.changed <- struct{}{}:
+	default:
+	}
+}
+
+// rows 收集所有網域的設備列
+func (db *Dashboard) rows() []dashboardRow {
+	var rows []dashboardRow
+	for _, d := range db.cfg.Domains {
+		for _, dev := range d.GetDevices() {
+			clock, ok := d.ClockInfo(dev.Name)
+			rows = append(rows, dashboardRow{domain: d, device: dev, clock: clock, hasClk: ok})
+		}
+	}
+	return rows
+}
+
+// selectedRow 取得目前選取的設備
+func (db *Dashboard) selectedRow() (dashboardRow, bool) {
+	rows := db.rows()
+	if len(rows) == 0 {
+		return dashboardRow{}, false
+	}
+	if db.selected >= len(rows) {
+		db.selected = len(rows) - 1
+	}
+	return rows[db.selected], true
+}
+
+// resize 讀取終端機大小
+func (db *Dashboard) resize() {
+	if h, w, err := terminalSize(); err == nil && w > 0 && h > 0 {
+		db.width, db.height = w, h
+	}
+}
+
+//----------------------------------------------------------------------
+// 畫面繪製
+//----------------------------------------------------------------------
+
+// draw 重繪整個畫面 (覆寫而非清除，避免閃爍)
+func (db *Dashboard) draw() {
+	rows := db.rows()
+	if db.selected >= len(rows) {
+		db.selected = max(len(rows)-1, 0)
+	}
+
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	title := " GOlane Dante Dashboard"
+	clock := time.Now().Format(time.TimeOnly) + " "
+	add("%s%s%s%s%s", ansiReverse+ansiBold, title, strings.Repeat(" ", max(db.width-len(title)-len(clock), 1)), clock, ansiReset)
+
+	// 介面狀態
+	add("")
+	add("%sInterfaces%s", ansiBold, ansiReset)
+	if db.cfg.Detector != nil {
+		for _, iface := range db.cfg.Detector.DanteInterfaces {
+			up, addr := interfaceStatus(iface.Name)
+			state := ansiRed + "DOWN" + ansiReset
+			if up {
+				state = ansiGreen + "UP" + ansiReset
+			}
+			add("  %-12s %s  %-18s %s", iface.Name, state, addr, iface.MacAddress)
+		}
+	}
+
+	// 各網域設備
+	index := 0
+	for _, d := range db.cfg.Domains {
+		add("")
+		add("%s%s%s  %s (%s)", ansiBold, d.Name, ansiReset, d.NetworkConfig.InterfaceName, d.NetworkConfig.IPAddress)
+		add("%s  %-3s %-20s %-16s %-16s %-6s %-12s %-10s %-8s%s", ansiDim, "ID", "Name", "Model", "IP Address", "Link", "Clock", "Servo", "Source", ansiReset)
+
+		count := 0
+		for _, row := range rows {
+			if row.domain != d {
+				continue
+			}
+			count++
+			line := db.formatRow(row)
+			if index == db.selected {
+				line = ansiReverse + line + ansiReset
+			}
+			add("%s", line)
+			index++
+		}
+		if count == 0 {
+			add("  %s(no devices discovered)%s", ansiDim, ansiReset)
+		}
+	}
+
+	// 詳細資訊或日誌
+	add("")
+	if db.detail && len(rows) > 0 {
+		lines = append(lines, db.detailLines(rows[db.selected])...)
+	} else if db.cfg.Logs != nil {
+		add("%sLog%s", ansiBold, ansiReset)
+		// 保留標題、按鍵說明與狀態列
+		free := db.height - len(lines) - 2
+		for _, l := range db.cfg.Logs.Tail(free) {
+			add("  %s", l)
+		}
+	}
+
+	db.mu.Lock()
+	status := db.status
+	db.mu.Unlock()
+
+	// 按鍵說明固定在最後一行
+	for len(lines) < db.height-2 {
+		add("")
+	}
+	if len(lines) > db.height-2 {
+		lines = lines[:db.height-2]
+	}
+	add("%s", status)
+	help := " [r] refresh  [i] identify  [enter] details  [↑/↓] select  [q] quit"
+	add("%s%s%s%s", ansiReverse, help, strings.Repeat(" ", max(db.width-utf8.RuneCountInString(help), 0)), ansiReset)
+
+	var b strings.Builder
+	b.WriteString(ansiHome)
+	for i, l := range lines {
+		b.WriteString(truncateANSI(l, db.width))
+		b.WriteString(ansiClearLine)
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString(ansiClearBelow)
+	db.out.WriteString(b.String())
+}
+
+// formatRow 設備表格的一列
+func (db *Dashboard) formatRow(row dashboardRow) string {
+	dev := row.device
+	ip := dev.IPAddress
+	if dev.IsLinkLocal() {
+		ip += "*"
+	}
+	link := "-"
+	if dev.LinkSpeed > 0 {
+		link = formatLinkSpeed(dev.LinkSpeed)
+	}
+
+	clockState, servo, source := "-", "-", "-"
+	if row.hasClk {
+		clockState, servo, source = row.clock.ClockState, row.clock.ServoState, row.clock.ClockSource
+		if row.clock.IsGrandmaster {
+			clockState += " (GM)"
+		}
+	}
+	return fmt.Sprintf("  %-3d %-20s %-16s %-16s %-6s %-12s %-10s %-8s",
+		dev.ID, dev.Name, dev.Model, ip, link, clockState, servo, source)
+}
+
+// detailLines 選取設備的詳細資訊
+func (db *Dashboard) detailLines(row dashboardRow) []string {
+	dev := row.device
+	lines := []string{
+		fmt.Sprintf("%sDetails: %s%s  (%s)", ansiBold, dev.Name, ansiReset, row.domain.Name),
+		fmt.Sprintf("  Model:           %s", dev.Model),
+		fmt.Sprintf("  Product version: %s", dev.ProductVersion),
+		fmt.Sprintf("  Dante version:   %s", dev.DanteVersion),
+		fmt.Sprintf("  MAC address:     %s", dev.MacAddress),
+		fmt.Sprintf("  Channels TX/RX:  %s", dev.ChannelCounts()),
+		fmt.Sprintf("  Primary:         %s  %s", dev.IPAddress, formatLinkSpeed(dev.LinkSpeed)),
+	}
+	if dev.SerialNumber != "" {
+		lines = append(lines,
+			fmt.Sprintf("  Manufacturer:    %s", dev.Manufacturer),
+			fmt.Sprintf("  Serial number:   %s", dev.SerialNumber))
+	}
+	if caps := dev.Capabilities.List(); len(caps) > 0 {
+		lines = append(lines, fmt.Sprintf("  Capabilities:    %s", strings.Join(caps, ", ")))
+	}
+	if dev.SecondaryIP != "" {
+		lines = append(lines, fmt.Sprintf("  Secondary:       %s  %s", dev.SecondaryIP, formatLinkSpeed(dev.SecondarySpeed)))
+	}
+	if row.hasClk {
+		gm := "no"
+		if row.clock.IsGrandmaster {
+			gm = ansiYellow + "yes" + ansiReset
+		}
+		lines = append(lines,
+			fmt.Sprintf("  Clock state:     %s", row.clock.ClockState),
+			fmt.Sprintf("  Servo state:     %s", row.clock.ServoState),
+			fmt.Sprintf("  Clock source:    %s", row.clock.ClockSource),
+			fmt.Sprintf("  Grandmaster:     %s", gm),
+			fmt.Sprintf("  Clock updated:   %s", row.clock.Updated.Format(time.TimeOnly)),
+		)
+	} else {
+		lines = append(lines, "  Clock:           (no status received)")
+	}
+	if dev.Capabilities.Metering {
+		lines = append(lines, db.meterLines(row)...)
+	}
+	return lines
+}
+
+// dashboardMeterWidth 每行顯示的通道數
+const dashboardMeterWidth = 32
+
+// meterLines 選取設備每個通道是否有訊號 (詳細資訊開啟時才訂閱電平)
+func (db *Dashboard) meterLines(row dashboardRow) []string {
+	if err := row.domain.WatchMeters(row.device.Name); err != nil {
+		return []string{"  Signal:          (" + err.Error() + ")"}
+	}
+	meters, ok := row.domain.Meters(row.device.Name)
+	if !ok {
+		return []string{"  Signal:          (no meters received)"}
+	}
+	var lines []string
+	for _, dir := range []struct {
+		label  string
+		levels []dante.ChannelLevel
+	}{{"TX", meters.TX}, {"RX", meters.RX}} {
+		for start := 0; start < len(dir.levels); start += dashboardMeterWidth {
+			label := ""
+			if start == 0 {
+				label = "Signal " + dir.label + ":"
+			}
+			lines = append(lines, fmt.Sprintf("  %-16s %3d %s", label, start+1,
+				formatSignal(dir.levels[start:min(start+dashboardMeterWidth, len(dir.levels))])))
+		}
+	}
+	return lines
+}
+
+// formatSignal 每個通道一格：綠色有訊號、紅色削峰、暗色沒有訊號
+func formatSignal(levels []dante.ChannelLevel) string {
+	var b strings.Builder
+	for _, level := range levels {
+		switch {
+		case level.Clip:
+			b.WriteString(ansiRed + "■" + ansiReset)
+		case level.Signal:
+			b.WriteString(ansiGreen + "■" + ansiReset)
+		default:
+			b.WriteString(ansiDim + "·" + ansiReset)
+		}
+	}
+	return b.String()
+}
+
+// formatLinkSpeed 以 Mbps/Gbps 顯示連線速度
+func formatLinkSpeed(mbps int) string {
+	switch {
+	case mbps <= 0:
+		return "unknown"
+	case mbps >= 1000 && mbps%1000 == 0:
+		return strconv.Itoa(mbps/1000) + "G"
+	default:
+		return strconv.Itoa(mbps) + "M"
+	}
+}
+
+// truncateANSI 依可見字元數截斷一行 (略過 ANSI 控制碼)
+func truncateANSI(s string, width int) string {
+	visible := 0
+	inEscape := false
+	for i, r := range s {
+		switch {
+		case inEscape:
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				inEscape = false
+			}
+		case r == '\x1b':
+			inEscape = true
+		default:
+			visible++
+			if visible > width {
+				return s[:i] + ansiReset
+			}
+		}
+	}
+	return s
+}
+
+// interfaceStatus 即時讀取介面狀態與第一個 IPv4 地址
+func interfaceStatus(name string) (bool, string) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return false, "-"
+	}
+	up := iface.Flags&net.FlagUp != 0
+	addrs, _ := iface.Addrs()
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			return up, ipnet.String()
+		}
+	}
+	return up, "-"
+}
+
+//----------------------------------------------------------------------
+// 終端機
+//----------------------------------------------------------------------
+
+// enterRawMode 以 stty 切換到 raw 模式，回傳還原函數
+func enterRawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() { stty(strings.TrimSpace(saved)) }, nil
+}
+
+// terminalSize 取得終端機行數與列數
+func terminalSize() (int, int, error) {
+	out, err := stty("size")
+	if err != nil {
+		return 0, 0, err
+	}
+	var rows, cols int
+	if _, err := fmt.Sscan(out, &rows, &cols); err != nil {
+		return 0, 0, err
+	}
+	return rows, cols, nil
+}
+
+// stty 對目前的終端機執行 stty
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// redirectStdout 把 fd 1 改接到管線並逐行交給 line，回傳原本的終端機與還原函數
+func redirectStdout(line func(string)) (*os.File, func(), error) {
+	saved, err := syscall.Dup(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	syscall.CloseOnExec(saved)
+	r, w, err := os.Pipe()
+	if err != nil {
+		syscall.Close(saved)
+		return nil, nil, err
+	}
+	err = dupFD(int(w.Fd()), 1)
+	w.Close() // fd 1 持有寫入端
+	if err != nil {
+		r.Close()
+		syscall.Close(saved)
+		return nil, nil, err
+	}
+
+	done := make(chan struct{})
+	recovery.Go("tui/stdout", func() {
+		defer close(done)
+		defer r.Close()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line(scanner.Text())
+		}
+		// 行太長時丟棄剩下的輸出，不能讓 printf 卡在寫滿的管線上
+		io.Copy(io.Discard, r)
+	})
+
+	terminal := os.NewFile(uintptr(saved), os.Stdout.Name())
+	restore := func() {
+		// 關閉 fd 1 上的寫入端，讀取端收到 EOF
+		if err := dupFD(saved, 1); err != nil {
+			logger.Warn("Failed to restore standard output", "err", err)
+			return
+		}
+		<-done
+		terminal.Close()
+	}
+	return terminal, restore, nil
+}
+
+// readKeys 讀取按鍵 (方向鍵等跳脫序列一次讀入)
+func readKeys(keys chan<- []byte) {
+	defer close(keys)
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		keys <- append([]byte{}, buf[:n]...)
+	}
+}
+
+//==============================================================================
+// 日誌緩衝
+//==============================================================================
+
+// LogBuffer 保留最近的日誌行 (TUI 模式的日誌輸出目的地)
+type LogBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	limit int
+	part  string // 尚未換行的部分
+}
+
+// NewLogBuffer 建立最多保留 limit 行的日誌緩衝
+func NewLogBuffer(limit int) *LogBuffer {
+	return &LogBuffer{limit: limit}
+}
+
+// Write 實作 io.Writer
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	text := b.part + string(p)
+	parts := strings.Split(text, "\n")
+	b.part = parts[len(parts)-1]
+	b.lines = append(b.lines, parts[:len(parts)-1]...)
+	if over := len(b.lines) - b.limit; over > 0 {
+		b.lines = append([]string{}, b.lines[over:]...)
+	}
+	return len(p), nil
+}
+
+// Tail 取得最後 n 行
+func (b *LogBuffer) Tail(n int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n <= 0 {
+		return nil
+	}
+	if n > len(b.lines) {
+		n = len(b.lines)
+	}
+	return append([]string{}, b.lines[len(b.lines)-n:]...)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "syscall"
+
+// dupFD 讓 newfd 指向 oldfd 的檔案
+func dupFD(oldfd, newfd int) error {
+	return syscall.Dup2(oldfd, newfd)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+func TestRedirectStdout(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	terminal, restore, err := redirectStdout(func(line string) {
+		mu.Lock()
+		lines = append(lines, line)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模擬 C wrapper 的 printf：直接寫 fd 1
+	syscall.Write(1, []byte("[DEBUG] first\n[INFO] second\npartial"))
+	if terminal.Fd() == 1 {
+		t.Error("terminal is fd 1")
+	}
+	restore()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"[DEBUG] first", "[INFO] second", "partial"}; !slices.Equal(lines, want) {
+		t.Errorf("lines %q, want %q", lines, want)
+	}
+}
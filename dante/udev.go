@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//==============================================================================
+// USB 網卡的固定名稱 (udev)
+//==============================================================================
+
+// USB 網卡的核心名稱來自 MAC (enxf8e43bd6309e)，換一張網卡名稱就變了，
+// 以前只能修改程式內建的名稱清單。設定檔的 nic_names section 指定穩定名稱與 MAC：
+//
+//	"nic_names": {"dante1": "f8:e4:3b:d6:30:9e", "dante2": "f8:e4:3b:d5:5d:f6"}
+//
+// golane udev 依此產生 udev 規則，讓網卡插入時改名為 dante1/dante2；
+// 內建清單與角色設定都使用這些名稱，換網卡時只要改 MAC 並重新產生規則。
+// 啟動時檢查已安裝的規則是否與設定檔一致；規則尚未生效 (網卡還沒重新插拔)
+// 時以 MAC 找到網卡目前的名稱。
+
+// udevRulesPath 產生的 udev 規則檔
+const udevRulesPath = "/etc/udev/rules.d/70-golane-dante.rules"
+
+// ErrInvalidNICNames 網卡名稱設定錯誤
+var ErrInvalidNICNames = errors.New("invalid NIC names")
+
+// NICNames 穩定的介面名稱 → MAC 地址
+type NICNames map[string]string
+
+// ifaceNamePattern 介面名稱 (IFNAMSIZ 16，含結尾的 NUL)
+var ifaceNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,14}$`)
+
+// danteNICPattern golane udev 建議的名稱
+var danteNICPattern = regexp.MustCompile(`^dante[0-9]+$`)
+
+// kernelNamePrefixes 核心與 systemd 自動命名使用的前綴 (改名為這些名稱會與核心衝突)
+var kernelNamePrefixes = []string{"eth", "enx", "enp", "ens", "eno", "wlan", "wlx", "usb"}
+
+// compileNICNames 檢查名稱並將 MAC 正規化為小寫冒號格式
+func compileNICNames(names NICNames) (NICNames, error) {
+	fail := func(format string, args ...any) (NICNames, error) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidNICNames, fmt.Sprintf(format, args...))
+	}
+	out := make(NICNames, len(names))
+	owners := make(map[string]string, len(names))
+	for name, mac := range names {
+		if !ifaceNamePattern.MatchString(name) {
+			return fail("interface name %q must be 1-15 letters, digits, - or _", name)
+		}
+		for _, prefix := range kernelNamePrefixes {
+			if strings.HasPrefix(name, prefix) {
+				return fail("%s uses the kernel prefix %s, choose another name such as dante1", name, prefix)
+			}
+		}
+		m := normalizeMAC(mac)
+		if m == "" {
+			return fail("%s: invalid MAC address %q", name, mac)
+		}
+		if other, ok := owners[m]; ok {
+			return fail("MAC %s is assigned to both %s and %s", m, min(name, other), max(name, other))
+		}
+		owners[m] = name
+		out[name] = m
+	}
+	return out, nil
+}
+
+// udevRules 產生規則檔內容 (依名稱排序)
+func udevRules(names NICNames) string {
+	var b strings.Builder
+	b.WriteString("# Generated by golane udev from the nic_names of the config file.\n")
+	b.WriteString("# After replacing an adapter, update its MAC there and run golane udev -write again.\n")
+	for _, name := range sortedNICNames(names) {
+		fmt.Fprintf(&b, "SUBSYSTEM==\"net\", ACTION==\"add\", ATTR{address}==\"%s\", NAME=\"%s\"\n", names[name], name)
+	}
+	return b.String()
+}
+
+// sortedNICNames 依名稱排序
+func sortedNICNames(names NICNames) []string {
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// checkUdevRules 已安裝的規則與設定檔不同時警告 (換網卡後忘了重新產生)
+func checkUdevRules(path string, names NICNames) {
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		logger.Warn("udev rules for the NIC names are not installed, run golane udev -write", "path", path)
+	case err != nil:
+		logger.Warn("Failed to read the udev rules", "path", path, "err", err)
+	case string(data) != udevRules(names):
+		logger.Warn("udev rules differ from nic_names in the config, run golane udev -write", "path", path)
+	}
+}
+
+// usbNIC 偵測到的 USB 網卡
+type usbNIC struct {
+	Name string
+	MAC  string
+}
+
+// usbNICs 列出 root (/sys/class/net) 之下的 USB 網卡 (依名稱排序)
+func usbNICs(root string) []usbNIC {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var nics []usbNIC
+	for _, e := range entries {
+		subsystem, err := filepath.EvalSymlinks(filepath.Join(root, e.Name(), "device", "subsystem"))
+		if err != nil || filepath.Base(subsystem) != "usb" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, e.Name(), "address"))
+		if err != nil {
+			continue
+		}
+		if mac := normalizeMAC(strings.TrimSpace(string(data))); mac != "" {
+			nics = append(nics, usbNIC{Name: e.Name(), MAC: mac})
+		}
+	}
+	return nics
+}
+
+// proposeNICNames 為 USB 網卡建議名稱：已經是 danteN 的保留，其他依序取下一個未使用的 danteN
+func proposeNICNames(nics []usbNIC) NICNames {
+	names := make(NICNames, len(nics))
+	for _, nic := range nics {
+		if danteNICPattern.MatchString(nic.Name) {
+			names[nic.Name] = nic.MAC
+		}
+	}
+	next := 1
+	for _, nic := range nics {
+		if _, ok := names[nic.Name]; ok {
+			continue
+		}
+		for names[fmt.Sprintf("dante%d", next)] != "" {
+			next++
+		}
+		names[fmt.Sprintf("dante%d", next)] = nic.MAC
+	}
+	return names
+}
+
+// currentName 穩定名稱還沒有生效時 (網卡尚未重新插拔)，以 MAC 找到網卡目前的名稱
+func (nd *NetworkDetector) currentName(name string) string {
+	mac := nd.NICNames[name]
+	if mac == "" || nd.GetInterfaceByName(name) != nil {
+		return name
+	}
+	for _, info := range nd.AllInterfaces {
+		if normalizeMAC(info.MacAddress) == mac {
+			logger.Warn("NIC not renamed yet, re-plug it or reboot to apply the udev rules", "name", name, "iface", info.Name)
+			return info.Name
+		}
+	}
+	return name
+}
+
+// newUdevCommand golane udev [name=mac ...]
+func newUdevCommand() *Command {
+	fs := newFlagSet("udev")
+	lf := addLogFlags(fs)
+	configFile := fs.String("config", "", "monitor config file whose \"nic_names\" section maps names to MAC addresses")
+	rulesPath := fs.String("rules", udevRulesPath, "udev rules file to write")
+	write := fs.Bool("write", false, "write the rules file instead of printing it")
+
+	return &Command{
+		Name:  "udev",
+		Short: "Generate udev rules that give the USB Dante NICs stable names (dante1, dante2)",
+		Args:  "[name=mac ...]",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			names := NICNames{}
+			if *configFile != "" {
+				cfg, err := LoadMonitorConfig(*configFile)
+				if err != nil {
+					return err
+				}
+				maps.Copy(names, cfg.NICNames)
+			}
+			for _, arg := range args {
+				name, mac, ok := strings.Cut(arg, "=")
+				if !ok {
+					return errUsage
+				}
+				names[name] = mac
+			}
+			// 沒有指定時以目前的 USB 網卡建議名稱
+			proposed := len(names) == 0
+			if proposed {
+				if names = proposeNICNames(usbNICs(sysfsNet)); len(names) == 0 {
+					return errors.New("no USB network adapters found, pass name=mac pairs")
+				}
+			}
+			names, err := compileNICNames(names)
+			if err != nil {
+				return err
+			}
+			rules := udevRules(names)
+
+			if !*write {
+				fmt.Print(rules)
+				if proposed {
+					data, _ := json.Marshal(names)
+					fmt.Println("\nProposed from the USB adapters found. Add this to the config file and run golane udev -config <file> -write:")
+					fmt.Printf("  \"nic_names\": %s\n", data)
+				}
+				return nil
+			}
+			tmp := *rulesPath + ".tmp"
+			if err := os.WriteFile(tmp, []byte(rules), 0644); err != nil {
+				return err
+			}
+			if err := os.Rename(tmp, *rulesPath); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s\n", *rulesPath)
+			fmt.Println("Run udevadm control --reload, then re-plug the adapters (or reboot) to apply the names.")
+			fmt.Println("dante1 and dante2 are used by default; assign other names with -dante-ifaces or the interfaces section of the config.")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompileNICNames(t *testing.T) {
+	names, err := compileNICNames(NICNames{"dante1": "F8-E4-3B-D6-30-9E", "dante2": "f8:e4:3b:d5:5d:f6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names["dante1"] != "f8:e4:3b:d6:30:9e" {
+		t.Errorf("MAC not normalized: %v", names)
+	}
+	rules := udevRules(names)
+	want := `SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="f8:e4:3b:d6:30:9e", NAME="dante1"` + "\n" +
+		`SUBSYSTEM=="net", ACTION=="add", ATTR{address}=="f8:e4:3b:d5:5d:f6", NAME="dante2"` + "\n"
+	if !strings.HasSuffix(rules, want) {
+		t.Errorf("rules:\n%s", rules)
+	}
+
+	for _, bad := range []NICNames{
+		{"eth9": "f8:e4:3b:d6:30:9e"},
+		{"dante-primary-nic": "f8:e4:3b:d6:30:9e"},
+		{"dante1": "f8:e4:3b"},
+		{"dante1": "f8:e4:3b:d6:30:9e", "dante2": "F8:E4:3B:D6:30:9E"},
+	} {
+		if _, err := compileNICNames(bad); !errors.Is(err, ErrInvalidNICNames) {
+			t.Errorf("%v: err = %v", bad, err)
+		}
+	}
+}
+
+func TestUSBNICs(t *testing.T) {
+	root := t.TempDir()
+	bus := filepath.Join(root, "bus")
+	for _, sub := range []string{"usb", "pci"} {
+		if err := os.MkdirAll(filepath.Join(bus, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nic := func(name, subsystem, mac string) {
+		dir := filepath.Join(root, "net", name)
+		if err := os.MkdirAll(filepath.Join(dir, "device"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join(bus, subsystem), filepath.Join(dir, "device", "subsystem")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "address"), []byte(mac+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nic("eth0", "pci", "00:11:22:33:44:55")
+	nic("dante2", "usb", "f8:e4:3b:d5:5d:f6")
+	nic("enxf8e43b000001", "usb", "f8:e4:3b:00:00:01")
+
+	nics := usbNICs(filepath.Join(root, "net"))
+	if len(nics) != 2 || nics[0].Name != "dante2" {
+		t.Fatalf("USB NICs = %+v", nics)
+	}
+	// 已經命名的 dante2 保留，新網卡取得 dante1
+	names := proposeNICNames(nics)
+	if len(names) != 2 || names["dante1"] != "f8:e4:3b:00:00:01" || names["dante2"] != "f8:e4:3b:d5:5d:f6" {
+		t.Errorf("proposed = %v", names)
+	}
+
+	// 規則尚未生效時以 MAC 找到網卡目前的名稱
+	nd := NewNetworkDetector()
+	nd.NICNames = names
+	nd.AllInterfaces = []NetworkInterfaceInfo{{Name: "enxf8e43b000001", MacAddress: "f8:e4:3b:00:00:01"}, {Name: "dante2", MacAddress: "f8:e4:3b:d5:5d:f6"}}
+	nd.IdentifyDanteInterfaces(defaultDanteInterfaceNames)
+	if len(nd.DanteInterfaces) != 2 || nd.DanteInterfaces[0].Name != "enxf8e43b000001" || nd.DanteInterfaces[1].Name != "dante2" {
+		t.Errorf("Dante interfaces = %+v", nd.DanteInterfaces)
+	}
+}
+
+func TestIdentifyDanteInterfacesBeforeRename(t *testing.T) {
+	// dante1 對應的網卡還是核心名稱 enxf8e43bd6309e，內建清單同時列出兩個名稱
+	nd := NewNetworkDetector()
+	nd.NICNames = NICNames{"dante1": "f8:e4:3b:d6:30:9e"}
+	nd.AllInterfaces = []NetworkInterfaceInfo{
+		{Name: "enxf8e43bd6309e", MacAddress: "f8:e4:3b:d6:30:9e"},
+		{Name: "enxf8e43bd55df6", MacAddress: "f8:e4:3b:d5:5d:f6"},
+	}
+	nd.IdentifyDanteInterfaces(defaultDanteInterfaceNames)
+	if len(nd.DanteInterfaces) != 2 || nd.DanteInterfaces[0].Name != "enxf8e43bd6309e" || nd.DanteInterfaces[1].Name != "enxf8e43bd55df6" {
+		t.Errorf("Dante interfaces = %+v", nd.DanteInterfaces)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 韌體升級
+//==============================================================================
+
+// 設備自己從檔案伺服器下載 .dnt 檔並寫入 flash (見 internal/dante/upgrade.go)。
+// 請求送出後 UpgradeTracker 在背景輪詢進度，狀態或進度改變時發布 TopicFirmware
+// 事件 (/api/events 轉送給 Web UI 與遠端)，API 與命令列讀取最新的狀態。
+// 升級完成後設備重新開機、暫時中斷音訊，所以 API 需要 operator 權限。
+
+// FirmwareUpgrader 韌體升級操作 (由 dante.Domain 實作)
+type FirmwareUpgrader interface {
+	StartFirmwareUpgrade(ctx context.Context, device string, src dante.FirmwareSource) error
+	UpgradeStatus(device string) (dante.UpgradeStatus, bool)
+}
+
+var _ FirmwareUpgrader = (*dante.Domain)(nil)
+
+// FirmwareUpgrades 升級請求與進度 (本機由 UpgradeTracker、-host 由 RemoteClient 實作)
+type FirmwareUpgrades interface {
+	StartUpgrade(ctx context.Context, device string, src dante.FirmwareSource) (FirmwareUpgrade, error)
+	UpgradeProgress(ctx context.Context, device string) (FirmwareUpgrade, error)
+}
+
+// ErrNoUpgrade 設備沒有升級紀錄
+var ErrNoUpgrade = errors.New("no firmware upgrade requested")
+
+// FirmwareUpgrade 一次升級的狀態 (TopicFirmware 事件的內容與 API 的回應)
+type FirmwareUpgrade struct {
+	Device  string    `json:"device"`
+	Source  string    `json:"source"`          // 韌體位置 (URL)
+	Started time.Time `json:"started"`         // 送出請求的時間
+	Ended   time.Time `json:"ended,omitempty"` // 追蹤結束 (完成、失敗或逾時) 的時間
+	dante.UpgradeStatus
+}
+
+// Active 仍在追蹤中
+func (u FirmwareUpgrade) Active() bool {
+	return u.Ended.IsZero()
+}
+
+const (
+	upgradePollInterval = time.Second      // 輪詢進度的間隔
+	upgradeTimeout      = 15 * time.Minute // 沒有結束就停止追蹤 (設備可能已經離線)
+)
+
+// UpgradeTracker 送出升級請求並在背景追蹤進度
+type UpgradeTracker struct {
+	ctx      context.Context
+	events   *golane.Bus // nil 時不發布事件 (命令列)
+	domain   string
+	upgrader FirmwareUpgrader
+	poll     time.Duration
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	upgrades map[string]FirmwareUpgrade // 設備名稱 → 最新狀態
+}
+
+// NewUpgradeTracker 建立追蹤器，ctx 結束時停止所有追蹤
+func NewUpgradeTracker(ctx context.Context, events *golane.Bus, domain string, u FirmwareUpgrader) *UpgradeTracker {
+	return &UpgradeTracker{
+		ctx:      ctx,
+		events:   events,
+		domain:   domain,
+		upgrader: u,
+		poll:     upgradePollInterval,
+		timeout:  upgradeTimeout,
+		upgrades: make(map[string]FirmwareUpgrade),
+	}
+}
+
+var _ FirmwareUpgrades = (*UpgradeTracker)(nil)
+
+// StartUpgrade 送出升級請求並開始追蹤 (同一台設備的升級結束前拒絕新的請求)
+func (t *UpgradeTracker) StartUpgrade(ctx context.Context, device string, src dante.FirmwareSource) (FirmwareUpgrade, error) {
+	now := time.Now()
+	up := FirmwareUpgrade{Device: device, Source: src.String(), Started: now,
+		UpgradeStatus: dante.UpgradeStatus{State: dante.UpgradeRequested, Updated: now}}
+
+	t.mu.Lock()
+	prev, seen := t.upgrades[device]
+	if seen && prev.Active() {
+		t.mu.Unlock()
+		return FirmwareUpgrade{}, fmt.Errorf("%w: %s is already upgrading (%s)", dante.ErrAccessDenied, device, prev.State)
+	}
+	t.upgrades[device] = up // 保留位置，同時送出的請求只有一個成功
+	t.mu.Unlock()
+
+	if err := t.upgrader.StartFirmwareUpgrade(ctx, device, src); err != nil {
+		t.mu.Lock()
+		if seen {
+			t.upgrades[device] = prev
+		} else {
+			delete(t.upgrades, device)
+		}
+		t.mu.Unlock()
+		return FirmwareUpgrade{}, err
+	}
+	t.update(up)
+	recovery.Go(t.domain+"/firmware", func() { t.track(up) })
+	return up, nil
+}
+
+// UpgradeProgress 設備最新的升級狀態
+func (t *UpgradeTracker) UpgradeProgress(_ context.Context, device string) (FirmwareUpgrade, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	up, ok := t.upgrades[device]
+	if !ok {
+		return FirmwareUpgrade{}, fmt.Errorf("%w: %s", ErrNoUpgrade, device)
+	}
+	return up, nil
+}
+
+// track 輪詢設備的升級狀態直到結束、逾時或 ctx 結束
+func (t *UpgradeTracker) track(up FirmwareUpgrade) {
+	ctx, cancel := context.WithTimeout(t.ctx, t.timeout)
+	defer cancel()
+	ticker := time.NewTicker(t.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				up.Ended = time.Now()
+				up.Error = fmt.Sprintf("upgrade did not finish within %s", t.timeout)
+				logger.Warn("Firmware upgrade tracking timed out", "domain", t.domain, "device", up.Device, "state", up.State)
+				t.update(up)
+			}
+			return
+		case <-ticker.C:
+		}
+
+		status, ok := t.upgrader.UpgradeStatus(up.Device)
+		if !ok || (status.State == up.State && status.Current == up.Current && status.Error == up.Error) {
+			continue
+		}
+		switch {
+		case status.State == up.State:
+		case status.State == dante.UpgradeFailed:
+			logger.Warn("Firmware upgrade failed", "domain", t.domain, "device", up.Device, "err", status.Error)
+		default:
+			logger.Info("Firmware upgrade state changed", "domain", t.domain, "device", up.Device, "state", status.State)
+		}
+		up.UpgradeStatus = status
+		if status.Finished() {
+			up.Ended = time.Now()
+		}
+		t.update(up)
+		if !up.Active() {
+			return
+		}
+	}
+}
+
+// update 記錄最新狀態並發布 TopicFirmware 事件
+func (t *UpgradeTracker) update(up FirmwareUpgrade) {
+	t.mu.Lock()
+	t.upgrades[up.Device] = up
+	t.mu.Unlock()
+	if t.events != nil {
+		t.events.Publish(golane.Event{Topic: golane.TopicFirmware, Domain: t.domain, Subject: up.Device, Time: time.Now(), Data: up})
+	}
+}
+
+//------------------------------------------------------------------------------
+// API
+//------------------------------------------------------------------------------
+
+// upgradeRequest 開始升級的內容
+type upgradeRequest struct {
+	URL string `json:"url"` // tftp://、http:// 或 https:// 加 IPv4 位址
+}
+
+// upgradeStatus 升級操作失敗的 HTTP 狀態
+func upgradeStatus(err error) int {
+	switch {
+	case errors.Is(err, dante.ErrInvalidFirmwareSource):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNoUpgrade):
+		return http.StatusNotFound
+	}
+	return subscribeStatus(err)
+}
+
+func (s *APIServer) handleStartUpgrade(w http.ResponseWriter, r *http.Request) {
+	fu, err := selectDomain(r, s.upgrades)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req upgradeRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	src, err := dante.ParseFirmwareSource(req.URL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	up, err := fu.StartUpgrade(r.Context(), r.PathValue("device"), src)
+	if err != nil {
+		writeError(w, upgradeStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, up)
+}
+
+func (s *APIServer) handleUpgradeStatus(w http.ResponseWriter, r *http.Request) {
+	fu, err := selectDomain(r, s.upgrades)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	up, err := fu.UpgradeProgress(r.Context(), r.PathValue("device"))
+	if err != nil {
+		writeError(w, upgradeStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, up)
+}
+
+//------------------------------------------------------------------------------
+// 遠端
+//------------------------------------------------------------------------------
+
+// Upgrades 指定網域的韌體升級 (domain 空白時由 daemon 選擇唯一的網域)
+func (c *RemoteClient) Upgrades(domain string) FirmwareUpgrades {
+	return remoteUpgrades{client: c, domain: domain}
+}
+
+// remoteUpgrades 透過 API 實作 FirmwareUpgrades
+type remoteUpgrades struct {
+	client *RemoteClient
+	domain string
+}
+
+// path /api/devices/<device>/firmware
+func (u remoteUpgrades) path(device string) string {
+	path := "/api/devices/" + url.PathEscape(device) + "/firmware"
+	if u.domain != "" {
+		path += "?domain=" + url.QueryEscape(u.domain)
+	}
+	return path
+}
+
+func (u remoteUpgrades) StartUpgrade(ctx context.Context, device string, src dante.FirmwareSource) (FirmwareUpgrade, error) {
+	var up FirmwareUpgrade
+	return up, u.client.doContext(ctx, http.MethodPost, u.path(device), upgradeRequest{URL: src.String()}, &up)
+}
+
+func (u remoteUpgrades) UpgradeProgress(ctx context.Context, device string) (FirmwareUpgrade, error) {
+	var up FirmwareUpgrade
+	return up, u.client.doContext(ctx, http.MethodGet, u.path(device), nil, &up)
+}
+
+//------------------------------------------------------------------------------
+// 命令列
+//------------------------------------------------------------------------------
+
+// newDevicesUpgradeCommand golane devices upgrade
+func newDevicesUpgradeCommand() *Command {
+	fs := newFlagSet("devices upgrade")
+	lf := addLogFlags(fs)
+	ifaces := addInterfaceFlags(fs)
+	wait := fs.Duration("wait", 3*time.Second, "how long to wait for device discovery and ConMon")
+	follow := fs.Bool("follow", true, "print progress until the upgrade finishes (the device keeps upgrading if interrupted)")
+	jsonOut := fs.Bool("json", false, "print the final status as JSON")
+	remote := addRemoteFlags(fs)
+	domain := fs.String("domain", "", "domain on the remote monitor (required with -host when it runs several domains)")
+	stateDir := fs.String("state-dir", ".", "state directory whose audit log records local upgrades (the monitor records upgrades made with -host)")
+
+	return &Command{
+		Name:  "upgrade",
+		Short: "Upgrade a device's firmware from a TFTP/HTTP server (the device reboots when done)",
+		Args:  "<device> <url>",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) != 2 {
+				return errUsage
+			}
+			src, err := dante.ParseFirmwareSource(args[1])
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext()
+			defer cancel()
+
+			var upgrades FirmwareUpgrades
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				upgrades = client.Upgrades(*domain)
+			} else {
+				audit, err := OpenAuditLog(*stateDir)
+				if err != nil {
+					return err
+				}
+				if err := ifaces.checkTiming(*wait); err != nil {
+					return err
+				}
+				detector, err := ifaces.detect()
+				if err != nil {
+					return err
+				}
+				d, err := ifaces.openPrimaryDomain(ctx, detector)
+				if err != nil {
+					return err
+				}
+				defer d.Cleanup()
+				// ConMon 連線需要時間，在發現設備前啟動
+				if err := d.StartMonitoring(); err != nil {
+					return err
+				}
+				if err := discover(ctx, d, *wait); err != nil {
+					return err
+				}
+				upgrades = auditUpgrades(audit, d.Name, NewUpgradeTracker(ctx, nil, d.Name, d))
+				ctx = cliAuditContext(ctx)
+			}
+
+			up, err := upgrades.StartUpgrade(ctx, args[0], src)
+			if err != nil {
+				return err
+			}
+			if *follow {
+				if up, err = followUpgrade(ctx, upgrades, up); err != nil {
+					return err
+				}
+			}
+			if *jsonOut {
+				return printJSON(up)
+			}
+			switch {
+			case up.State == dante.UpgradeDone:
+				fmt.Print(i18n.Sprintf("Firmware of %s written, the device restarts\n", up.Device))
+			case !up.Active():
+				return fmt.Errorf("firmware upgrade of %s %s: %s", up.Device, up.State, up.Error)
+			default:
+				// 沒有 -follow 或中斷: 設備繼續自己升級
+				fmt.Print(i18n.Sprintf("Firmware upgrade of %s requested from %s\n", up.Device, up.Source))
+			}
+			return nil
+		},
+	}
+}
+
+// followUpgrade 輪詢升級狀態直到結束，每次改變時印出一行 (中斷時回傳最後的狀態)
+func followUpgrade(ctx context.Context, upgrades FirmwareUpgrades, up FirmwareUpgrade) (FirmwareUpgrade, error) {
+	printUpgradeProgress(up)
+	ticker := time.NewTicker(upgradePollInterval)
+	defer ticker.Stop()
+	for up.Active() {
+		select {
+		case <-ctx.Done():
+			return up, nil
+		case <-ticker.C:
+		}
+		next, err := upgrades.UpgradeProgress(ctx, up.Device)
+		if err != nil {
+			return up, err
+		}
+		if next.State != up.State || next.Percent() != up.Percent() || next.Error != up.Error {
+			printUpgradeProgress(next)
+		}
+		up = next
+	}
+	return up, nil
+}
+
+// printUpgradeProgress 印出升級狀態 (一行)
+func printUpgradeProgress(up FirmwareUpgrade) {
+	progress := "-"
+	if p := up.Percent(); p >= 0 {
+		progress = fmt.Sprintf("%d%%", p)
+	}
+	fmt.Printf("%s  %-12s %5s  %s\n", up.Updated.Format(time.TimeOnly), up.State, progress, up.Error)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/dante"
+)
+
+func TestFirmwareUpgradeAPI(t *testing.T) {
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	audit, err := OpenAuditLog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := NewTokenStore("", "", []ConfiguredToken{
+		{Name: "console", Token: "operator-token", Role: RoleOperator},
+		{Name: "nms", Token: "viewer-token", Role: RoleViewer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := golane.NewBus()
+	sub := events.Subscribe(32, golane.TopicFirmware)
+	defer sub.Close()
+	tracker := NewUpgradeTracker(ctx, events, d.Name, d)
+	tracker.poll = time.Millisecond
+
+	server := httptest.NewServer(NewAPIServer(APIConfig{
+		Tokens:   tokens,
+		Upgrades: map[string]FirmwareUpgrades{d.Name: auditUpgrades(audit, d.Name, tracker)},
+		Audit:    audit,
+	}).mux)
+	defer server.Close()
+
+	send := func(method, path, token, body string) (int, FirmwareUpgrade) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var up FirmwareUpgrade
+		json.NewDecoder(resp.Body).Decode(&up)
+		return resp.StatusCode, up
+	}
+	const path = "/api/devices/FOH-Console/firmware"
+	const body = `{"url": "tftp://10.0.0.5/dl32.dnt"}`
+
+	if status, _ := send(http.MethodPost, path, "viewer-token", body); status != http.StatusForbidden {
+		t.Fatalf("viewer upgrade: status %d, want 403", status)
+	}
+	if status, _ := send(http.MethodGet, path, "viewer-token", ""); status != http.StatusNotFound {
+		t.Fatalf("status before upgrade: %d, want 404", status)
+	}
+	if status, _ := send(http.MethodPost, path, "operator-token", `{"url": "ftp://10.0.0.5/dl32.dnt"}`); status != http.StatusBadRequest {
+		t.Fatalf("unsupported protocol: status %d, want 400", status)
+	}
+	if status, _ := send(http.MethodPost, "/api/devices/missing/firmware", "operator-token", body); status != http.StatusNotFound {
+		t.Fatalf("missing device: status %d, want 404", status)
+	}
+
+	status, up := send(http.MethodPost, path, "operator-token", body)
+	if status != http.StatusAccepted || up.State != dante.UpgradeRequested || up.Source != "tftp://10.0.0.5/dl32.dnt" {
+		t.Fatalf("upgrade: status %d, %+v", status, up)
+	}
+
+	// 每次狀態改變發布一則事件，最後一則為完成
+	var states []string
+	timeout := time.After(5 * time.Second)
+	for len(states) == 0 || states[len(states)-1] != dante.UpgradeDone {
+		select {
+		case e := <-sub.C:
+			up := e.Data.(FirmwareUpgrade)
+			if e.Subject != "FOH-Console" || e.Domain != "Dante1" {
+				t.Fatalf("event = %+v", e)
+			}
+			states = append(states, up.State)
+		case <-timeout:
+			t.Fatalf("upgrade did not finish, states %v", states)
+		}
+	}
+	if states[0] != dante.UpgradeRequested || !strings.Contains(strings.Join(states, ","), dante.UpgradeWriting) {
+		t.Errorf("states = %v", states)
+	}
+
+	status, up = send(http.MethodGet, path, "viewer-token", "")
+	if status != http.StatusOK || up.State != dante.UpgradeDone || up.Active() || up.Percent() != 100 {
+		t.Fatalf("final status: %d, %+v", status, up)
+	}
+
+	entries, err := audit.Entries(AuditFilter{Operation: AuditFirmwareUpgrade})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 格式錯誤的請求在送出前拒絕，不記錄
+	if len(entries) != 2 || entries[0].Error == "" || entries[1].Error != "" ||
+		entries[1].After != "tftp://10.0.0.5/dl32.dnt" || entries[1].Actor != "token:console" {
+		t.Fatalf("audit entries = %+v", entries)
+	}
+}
+
+func TestUpgradeTrackerRejectsConcurrentUpgrade(t *testing.T) {
+	d := dante.NewSimulatedDomain("Dante1", dante.NetworkConfig{InterfaceName: "sim0"},
+		dante.NewSimulatedSDK(dante.DefaultSimulationConfig()))
+	if err := d.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Cleanup)
+	if err := d.StartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker := NewUpgradeTracker(ctx, nil, d.Name, d)
+	tracker.poll = time.Hour // 不推進狀態
+
+	src := dante.FirmwareSource{Protocol: "http", Server: "10.0.0.5", Path: "/fw.dnt"}
+	if _, err := tracker.StartUpgrade(ctx, "FOH-Console", src); err != nil {
+		t.Fatal(err)
+	}
+	_, err := tracker.StartUpgrade(ctx, "FOH-Console", src)
+	if status := upgradeStatus(err); status != http.StatusConflict {
+		t.Errorf("second upgrade: %v (%d), want 409", err, status)
+	}
+	if up, err := tracker.UpgradeProgress(ctx, "FOH-Console"); err != nil || !up.Active() {
+		t.Errorf("progress = %+v, %v", up, err)
+	}
+}
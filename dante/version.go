@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"danteCS/internal/dante"
+	"danteCS/internal/i18n"
+)
+
+//==============================================================================
+// 版本與建置資訊
+//==============================================================================
+
+// 資產盤點要知道每台主機跑的是哪一版、用哪個 SDK 建置、開了哪些功能。
+// golane version 與 /api/version 回傳同樣的內容；版本、commit 與建置時間由
+// Makefile 以 -ldflags "-X main.version=..." 寫入，直接 go build 時從 Go 的
+// VCS 建置資訊取得 commit 與時間。
+
+// 建置時寫入 (見 Makefile)
+var (
+	version   = "1.0.0"
+	commit    = ""
+	buildDate = ""
+)
+
+// BuildInfo 版本與建置資訊
+type BuildInfo struct {
+	Version    string            `json:"version"`
+	Commit     string            `json:"commit,omitempty"`
+	Modified   bool              `json:"modified,omitempty"` // 建置時工作目錄有未提交的變更
+	BuildDate  string            `json:"build_date,omitempty"`
+	GoVersion  string            `json:"go_version"`
+	Platform   string            `json:"platform"`
+	SDKVersion string            `json:"sdk_version"`         // libdapi (nodante 建置為 nodante)
+	Libraries  map[string]string `json:"libraries,omitempty"` // 一起連結的 libcurl、OpenSSL、zlib
+	Features   []FeatureState    `json:"features"`
+	Hostname   string            `json:"hostname,omitempty"`
+	Started    *time.Time        `json:"started,omitempty"` // 行程啟動時間 (只有執行中的 monitor)
+}
+
+// buildInfo 目前執行檔的建置資訊 (features 為 nil 時列出預設的功能開關)
+func buildInfo(features *FeatureFlags) BuildInfo {
+	if features == nil {
+		features = DefaultFeatureFlags()
+	}
+	info := BuildInfo{
+		Version:    version,
+		Commit:     commit,
+		BuildDate:  buildDate,
+		GoVersion:  runtime.Version(),
+		Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+		SDKVersion: dante.SDKVersion(),
+		Libraries:  dante.LibraryVersions(),
+		Features:   features.List(),
+	}
+	info.Hostname, _ = os.Hostname()
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// printBuildInfo 以文字輸出建置資訊
+func printBuildInfo(w io.Writer, info BuildInfo) {
+	commit := info.Commit
+	if commit == "" {
+		commit = i18n.T("unknown")
+	} else if info.Modified {
+		commit += i18n.T(" (modified)")
+	}
+	rows := [][]string{
+		{i18n.T("Version:"), info.Version},
+		{i18n.T("Commit:"), commit},
+	}
+	if info.BuildDate != "" {
+		rows = append(rows, []string{i18n.T("Built:"), info.BuildDate})
+	}
+	rows = append(rows,
+		[]string{"Go:", info.GoVersion + " " + info.Platform},
+		[]string{"Dante SDK:", info.SDKVersion})
+	names := make([]string, 0, len(info.Libraries))
+	for name := range info.Libraries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rows = append(rows, []string{"  " + name + ":", info.Libraries[name]})
+	}
+	if info.Started != nil {
+		rows = append(rows, []string{i18n.T("Running since:"),
+			fmt.Sprintf("%s (%s)", info.Started.Local().Format(time.DateTime), time.Since(*info.Started).Round(time.Second))})
+	}
+	var enabled, disabled []string
+	for _, f := range info.Features {
+		if f.Enabled {
+			enabled = append(enabled, f.Name)
+		} else {
+			disabled = append(disabled, f.Name)
+		}
+	}
+	rows = append(rows, []string{i18n.T("Features:"), strings.Join(enabled, ", ")})
+	if len(disabled) > 0 {
+		rows = append(rows, []string{i18n.T("Disabled:"), strings.Join(disabled, ", ")})
+	}
+	printTable(w, rows)
+}
+
+// handleVersion GET /api/version
+func (s *APIServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	info := buildInfo(s.features)
+	info.Started = &processStarted
+	writeJSON(w, http.StatusOK, info)
+}
+
+// newVersionCommand golane version
+func newVersionCommand() *Command {
+	fs := newFlagSet("version")
+	lf := addLogFlags(fs)
+	asJSON := fs.Bool("json", false, "print as JSON")
+	remote := addRemoteFlags(fs)
+
+	return &Command{
+		Name:  "version",
+		Short: "Show version, build and SDK information (of a running monitor with -host)",
+		Flags: fs,
+		log:   lf,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errUsage
+			}
+			info := buildInfo(nil)
+			if remote.enabled() {
+				client, err := remote.client()
+				if err != nil {
+					return err
+				}
+				info = BuildInfo{}
+				if err := client.do(http.MethodGet, "/api/version", nil, &info); err != nil {
+					return err
+				}
+			}
+			if *asJSON {
+				return printJSON(info)
+			}
+			printBuildInfo(os.Stdout, info)
+			return nil
+		},
+	}
+}
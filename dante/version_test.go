@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVersionEndpoint(t *testing.T) {
+	features := DefaultFeatureFlags()
+	if err := features.SetRuntime(FeatureWebUI, false); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewAPIServer(APIConfig{Features: features}).mux)
+	defer server.Close()
+
+	var info BuildInfo
+	getJSON(t, server.URL+"/api/version", &info)
+	if info.Version != version || info.SDKVersion != "nodante" || info.Started == nil || len(info.Features) == 0 {
+		t.Fatalf("info %+v", info)
+	}
+	for _, f := range info.Features {
+		if f.Name == FeatureWebUI && f.Enabled {
+			t.Error("runtime feature state not reported")
+		}
+	}
+
+	var out bytes.Buffer
+	printBuildInfo(&out, info)
+	for _, want := range []string{"Version:", "nodante", "Running since:", "Disabled:", FeatureWebUI} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
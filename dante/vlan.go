@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+//==============================================================================
+// 802.1Q VLAN 子介面
+//==============================================================================
+
+// 單一實體網卡接在 trunk 埠時，可以用 eth1.10 (Dante) / eth1.20 (管理)
+// 兩個子介面同時承載兩個網路。
+
+// vlanProcConfig Linux VLAN 設定檔
+const vlanProcConfig = "/proc/net/vlan/config"
+
+// maxIfaceNameLen 介面名稱的最大長度 (IFNAMSIZ 16，含結尾的 NUL)
+const maxIfaceNameLen = 15
+
+// VLANInfo VLAN 子介面資訊
+type VLANInfo struct {
+	Name   string // 子介面名稱 (eth1.10)
+	ID     int    // VLAN ID
+	Parent string // 實體介面 (eth1)
+}
+
+// IsVLAN 是否為 VLAN 子介面
+func (info NetworkInterfaceInfo) IsVLAN() bool {
+	return info.VLANID > 0
+}
+
+// readVLANConfig 從 /proc/net/vlan/config 讀取目前的 VLAN 子介面
+// 格式: "eth1.10        | 10  | eth1"
+func readVLANConfig() map[string]VLANInfo {
+	vlans := make(map[string]VLANInfo)
+
+	file, err := os.Open(vlanProcConfig)
+	if err != nil {
+		// 未載入 8021q 模組時檔案不存在
+		return vlans
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) != 3 {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			// 標頭行
+			continue
+		}
+		name := strings.TrimSpace(fields[0])
+		vlans[name] = VLANInfo{Name: name, ID: id, Parent: strings.TrimSpace(fields[2])}
+	}
+
+	return vlans
+}
+
+// ParseVLANName 解析 "eth1.10" 格式的子介面名稱
+func ParseVLANName(name string) (VLANInfo, error) {
+	idx := strings.LastIndex(name, ".")
+	if idx <= 0 || idx == len(name)-1 {
+		return VLANInfo{}, fmt.Errorf("invalid VLAN interface name %q (expected parent.id)", name)
+	}
+
+	id, err := strconv.Atoi(name[idx+1:])
+	if err != nil || id < 1 || id > 4094 {
+		return VLANInfo{}, fmt.Errorf("invalid VLAN ID in %q (1-4094)", name)
+	}
+
+	// 核心拒絕過長的名稱時 ip link 只回報模糊的錯誤，先在這裡檢查；
+	// USB 網卡的核心名稱 (enxf8e43bd6309e) 已經 15 個字元，需要先以 golane udev 改名
+	if len(name) > maxIfaceNameLen {
+		return VLANInfo{}, fmt.Errorf("VLAN interface name %s is %d characters, the kernel allows %d; give %s a shorter name (golane udev)",
+			name, len(name), maxIfaceNameLen, name[:idx])
+	}
+
+	return VLANInfo{Name: name, ID: id, Parent: name[:idx]}, nil
+}
+
+// EnsureVLANInterface 確認 VLAN 子介面存在，不存在時建立並啟用
+func EnsureVLANInterface(vlan VLANInfo) (bool, error) {
+	if _, err := net.InterfaceByName(vlan.Name); err == nil {
+		return false, nil
+	}
+
+	if _, err := net.InterfaceByName(vlan.Parent); err != nil {
+		return false, fmt.Errorf("parent interface %s for %s not found", vlan.Parent, vlan.Name)
+	}
+
+	for _, args := range vlanCommands(vlan) {
+		if output, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			return false, fmt.Errorf("%s failed: %v (%s)",
+				strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	return true, nil
+}
+
+// vlanCommands 建立並啟用 VLAN 子介面的指令
+func vlanCommands(vlan VLANInfo) [][]string {
+	return [][]string{
+		{"ip", "link", "add", "link", vlan.Parent, "name", vlan.Name, "type", "vlan", "id", strconv.Itoa(vlan.ID)},
+		{"ip", "link", "set", vlan.Name, "up"},
+	}
+}
+
+// parseVLANSpec 解析 "eth1.10,eth1.20" 規格 (空白項目略過)
+func parseVLANSpec(spec string) ([]VLANInfo, error) {
+	var vlans []VLANInfo
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		vlan, err := ParseVLANName(name)
+		if err != nil {
+			return nil, err
+		}
+		vlans = append(vlans, vlan)
+	}
+	return vlans, nil
+}
+
+// PlanVLANs 檢查 "eth1.10,eth1.20" 規格，回傳建立缺少的子介面要執行的指令
+// (-dry-run，不修改網路設定；子介面都已存在時回傳空白)
+func PlanVLANs(spec string) ([]string, error) {
+	vlans, err := parseVLANSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	var planned []string
+	for _, vlan := range vlans {
+		if _, err := net.InterfaceByName(vlan.Name); err == nil {
+			continue
+		}
+		if _, err := net.InterfaceByName(vlan.Parent); err != nil {
+			return nil, fmt.Errorf("parent interface %s for %s not found", vlan.Parent, vlan.Name)
+		}
+		for _, args := range vlanCommands(vlan) {
+			planned = append(planned, strings.Join(args, " "))
+		}
+	}
+	return planned, nil
+}
+
+// ConfigureVLANs 依 "eth1.10,eth1.20" 規格建立所需的 VLAN 子介面
+func (nd *NetworkDetector) ConfigureVLANs(spec string) error {
+	vlans, err := parseVLANSpec(spec)
+	if err != nil {
+		return err
+	}
+	for _, vlan := range vlans {
+		created, err := EnsureVLANInterface(vlan)
+		if err != nil {
+			return err
+		}
+		if created {
+			logger.Info("Created VLAN interface", "iface", vlan.Name, "vlan", vlan.ID, "parent", vlan.Parent)
+		}
+	}
+	return nil
+}
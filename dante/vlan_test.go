@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVLANName(t *testing.T) {
+	tests := []struct {
+		name string
+		want VLANInfo
+		err  string // 空白表示成功
+	}{
+		{name: "eth1.10", want: VLANInfo{Name: "eth1.10", ID: 10, Parent: "eth1"}},
+		{name: "eth1.1", want: VLANInfo{Name: "eth1.1", ID: 1, Parent: "eth1"}},
+		{name: "eth1.4094", want: VLANInfo{Name: "eth1.4094", ID: 4094, Parent: "eth1"}},
+		{name: "br.lan.20", want: VLANInfo{Name: "br.lan.20", ID: 20, Parent: "br.lan"}},
+		{name: "dante1.100", want: VLANInfo{Name: "dante1.100", ID: 100, Parent: "dante1"}},
+		{name: "eth1", err: "expected parent.id"},
+		{name: ".10", err: "expected parent.id"},
+		{name: "eth1.", err: "expected parent.id"},
+		{name: "eth1.0", err: "1-4094"},
+		{name: "eth1.4095", err: "1-4094"},
+		{name: "eth1.x", err: "1-4094"},
+		// 15 個字元可以，16 個字元超過 IFNAMSIZ
+		{name: "abcdefghijk.100", want: VLANInfo{Name: "abcdefghijk.100", ID: 100, Parent: "abcdefghijk"}},
+		{name: "abcdefghijk.1000", err: "VLAN interface name abcdefghijk.1000 is 16 characters"},
+		{name: "enxf8e43bd6309e.10", err: "give enxf8e43bd6309e a shorter name"},
+	}
+	for _, tt := range tests {
+		got, err := ParseVLANName(tt.name)
+		switch {
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.err == "" && got != tt.want:
+			t.Errorf("%s = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := parseVLANSpec("eth1.10, ,enxf8e43bd6309e.20"); err == nil {
+		t.Error("spec with an over-long VLAN name accepted")
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/backoff"
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// Webhook
+//==============================================================================
+
+// 設定檔 webhooks section 的每個目標會以 HTTP POST 收到 JSON 事件：設備
+// 加入與移除、告警規則發出與清除、網域工作失敗。事件來自事件匯流排，
+// 與 /api/events 相同；每個目標有獨立的佇列依序送出，失敗時依退避重試，
+// 4xx 回應 (408、429 除外) 視為目標拒收，不再重試。
+//
+// 設定 secret 時每個請求帶有簽章，接收端以相同的 secret 驗證：
+//
+//	X-GOlane-Timestamp: 1700000000
+//	X-GOlane-Signature: sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+//
+// 設定檔範例：
+//
+//	"webhooks": [
+//	  {"name": "ops", "url": "https://ops.example.com/hooks/golane", "secret": "s3cret"},
+//	  {"name": "alarms-only", "url": "http://10.0.0.5:8080/dante", "events": ["alarm.raised", "alarm.cleared"], "retries": 10}
+//	]
+
+// Webhook 事件種類
+const (
+	WebhookDeviceAdded   = "device.added"
+	WebhookDeviceRemoved = "device.removed"
+	WebhookAlarmRaised   = "alarm.raised"
+	WebhookAlarmCleared  = "alarm.cleared"
+	WebhookDomainFailed  = "domain.failed"
+)
+
+// webhookEvents 可訂閱的事件種類
+var webhookEvents = []string{WebhookDeviceAdded, WebhookDeviceRemoved, WebhookAlarmRaised, WebhookAlarmCleared, WebhookDomainFailed}
+
+const (
+	webhookQueueSize      = 256              // 每個目標等待送出的事件數 (滿時丟棄)
+	webhookDefaultTimeout = 10 * time.Second // 每次請求的逾時
+	webhookDefaultRetries = 5                // 失敗後的重試次數
+	webhookUserAgent      = "GOlane-Webhook"
+)
+
+// webhookRetry 重試退避 (MaxAttempts 由目標的 Retries 決定)
+var webhookRetry = backoff.Policy{Initial: time.Second, Max: time.Minute}
+
+// WebhookTarget 設定檔 webhooks section 的一個目標
+type WebhookTarget struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"`  // 簽章金鑰 (空白表示不簽章)
+	Events  []string `json:"events,omitempty"`  // 只送這些事件 (空白表示全部)
+	Timeout string   `json:"timeout,omitempty"` // 每次請求的逾時 (Go duration，預設 10s)
+	Retries *int     `json:"retries,omitempty"` // 失敗後的重試次數 (預設 5，0 表示不重試)
+
+	timeout time.Duration
+	retries int
+}
+
+// ErrInvalidWebhook 目標設定錯誤
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// compileWebhooks 檢查目標並補上預設值
+func compileWebhooks(targets []WebhookTarget) ([]WebhookTarget, error) {
+	names := make(map[string]bool, len(targets))
+	out := make([]WebhookTarget, len(targets))
+	for i, t := range targets {
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("%w %q: %s", ErrInvalidWebhook, t.Name, fmt.Sprintf(format, args...))
+		}
+		switch {
+		case t.Name == "":
+			return nil, fmt.Errorf("%w #%d: name is required", ErrInvalidWebhook, i+1)
+		case names[t.Name]:
+			return nil, fail("duplicate name")
+		}
+		names[t.Name] = true
+
+		u, err := url.Parse(t.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fail("url must be an http or https URL, got %q", t.URL)
+		}
+		for _, e := range t.Events {
+			if !slices.Contains(webhookEvents, e) {
+				return nil, fail("unknown event %q (%v)", e, webhookEvents)
+			}
+		}
+		t.timeout = webhookDefaultTimeout
+		if t.Timeout != "" {
+			if t.timeout, err = time.ParseDuration(t.Timeout); err != nil || t.timeout <= 0 {
+				return nil, fail("invalid timeout %q", t.Timeout)
+			}
+		}
+		t.retries = webhookDefaultRetries
+		if t.Retries != nil {
+			if *t.Retries < 0 {
+				return nil, fail("retries must not be negative")
+			}
+			t.retries = *t.Retries
+		}
+		out[i] = t
+	}
+	return out, nil
+}
+
+// wants 目標是否訂閱這個事件種類
+func (t WebhookTarget) wants(event string) bool {
+	return len(t.Events) == 0 || slices.Contains(t.Events, event)
+}
+
+// WebhookPayload 送出的 JSON 內容
+type WebhookPayload struct {
+	ID      string    `json:"id"`    // 每個事件唯一 (重試時不變，接收端可用來去除重複)
+	Event   string    `json:"event"` // WebhookDeviceAdded, ...
+	Time    time.Time `json:"time"`
+	Domain  string    `json:"domain,omitempty"`
+	Subject string    `json:"subject,omitempty"` // 設備名稱、告警名稱或網域名稱
+	Data    any       `json:"data"`              // dante.Device、AlarmEvent 或 golane.DomainFailure
+}
+
+// webhookPayload 把匯流排事件轉成 webhook 內容 (不送出的主題回傳 false)
+func webhookPayload(e golane.Event) (WebhookPayload, bool) {
+	p := WebhookPayload{Time: e.Time, Domain: e.Domain, Subject: e.Subject, Data: e.Data}
+	switch e.Topic {
+	case golane.TopicDeviceOnline:
+		p.Event = WebhookDeviceAdded
+	case golane.TopicDeviceOffline:
+		p.Event = WebhookDeviceRemoved
+	case golane.TopicDomainFailed:
+		p.Event = WebhookDomainFailed
+	case golane.TopicAlarm:
+		a, ok := e.Data.(AlarmEvent)
+		if !ok {
+			return p, false
+		}
+		p.Event = WebhookAlarmRaised
+		if a.State == AlarmCleared {
+			p.Event = WebhookAlarmCleared
+		}
+	default:
+		return p, false
+	}
+	var id [8]byte
+	rand.Read(id[:])
+	p.ID = hex.EncodeToString(id[:])
+	return p, true
+}
+
+// signWebhook 計算簽章標頭值
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookStats 目標的送出統計 (/api/webhooks)
+type WebhookStats struct {
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events,omitempty"`
+	Delivered   int64     `json:"delivered"`
+	Failed      int64     `json:"failed"`  // 用完重試或被拒收的事件
+	Dropped     int64     `json:"dropped"` // 佇列已滿而丟棄的事件
+	Queued      int       `json:"queued"`
+	LastStatus  int       `json:"last_status,omitempty"` // 最後一次的 HTTP 狀態碼
+	LastError   string    `json:"last_error,omitempty"`
+	LastAttempt time.Time `json:"last_attempt,omitempty"`
+}
+
+// webhookWorker 單一目標的佇列
+type webhookWorker struct {
+	target WebhookTarget
+	queue  chan WebhookPayload
+
+	mu    sync.Mutex
+	stats WebhookStats
+}
+
+// WebhookDispatcher 訂閱事件匯流排並送到各目標
+type WebhookDispatcher struct {
+	workers []*webhookWorker
+	client  *http.Client
+	retry   backoff.Policy
+}
+
+// NewWebhookDispatcher 建立 dispatcher (targets 須先經過 compileWebhooks)
+func NewWebhookDispatcher(targets []WebhookTarget) *WebhookDispatcher {
+	d := &WebhookDispatcher{client: &http.Client{}, retry: webhookRetry}
+	for _, t := range targets {
+		d.workers = append(d.workers, &webhookWorker{
+			target: t,
+			queue:  make(chan WebhookPayload, webhookQueueSize),
+			stats:  WebhookStats{Name: t.Name, URL: t.URL, Events: t.Events},
+		})
+	}
+	return d
+}
+
+// Start 訂閱事件並啟動各目標的送出，直到 ctx 結束 (佇列中未送出的事件會被放棄)
+func (d *WebhookDispatcher) Start(ctx context.Context, events *golane.Bus) {
+	sub := events.Subscribe(webhookQueueSize, golane.TopicDeviceOnline, golane.TopicDeviceOffline, golane.TopicAlarm, golane.TopicDomainFailed)
+	recovery.Go("webhooks", func() {
+		defer sub.Close()
+		recovery.Loop(ctx, "webhooks", func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e := <-sub.C:
+					if p, ok := webhookPayload(e); ok {
+						d.enqueue(p)
+					}
+				}
+			}
+		})
+	})
+	for _, w := range d.workers {
+		recovery.GoLoop(ctx, "webhooks/"+w.target.Name, func() { d.run(ctx, w) })
+	}
+}
+
+// enqueue 交給訂閱這個事件的目標 (佇列已滿時丟棄，不拖住其他目標)
+func (d *WebhookDispatcher) enqueue(p WebhookPayload) {
+	for _, w := range d.workers {
+		if !w.target.wants(p.Event) {
+			continue
+		}
+		select {
+		case w.queue <- p:
+		default:
+			w.mu.Lock()
+			w.stats.Dropped++
+			w.mu.Unlock()
+			logger.Warn("Webhook queue full, event dropped", "webhook", w.target.Name, "event", p.Event)
+		}
+	}
+}
+
+// run 依序送出目標的事件
+func (d *WebhookDispatcher) run(ctx context.Context, w *webhookWorker) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-w.queue:
+			d.deliver(ctx, w, p)
+		}
+	}
+}
+
+// deliver 送出一個事件，失敗時依退避重試
+func (d *WebhookDispatcher) deliver(ctx context.Context, w *webhookWorker, p WebhookPayload) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		w.record(0, fmt.Errorf("failed to encode payload: %v", err), true)
+		return
+	}
+	policy := d.retry
+	policy.MaxAttempts = w.target.retries + 1
+
+	var rejected error
+	onRetry := func(attempt int, err error, delay time.Duration) {
+		logger.Warn("Webhook delivery failed, retrying", "webhook", w.target.Name, "event", p.Event, "attempt", attempt, "err", err, "retry_in", delay)
+	}
+	err = backoff.Retry(ctx, policy, onRetry, func(int) error {
+		status, err := d.post(ctx, w.target, p, body)
+		w.record(status, err, false)
+		if err != nil && status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests {
+			rejected = err // 目標拒收，重試也不會成功
+			return nil
+		}
+		return err
+	})
+	switch {
+	case rejected != nil:
+		err = rejected
+	case err == nil:
+		return
+	}
+	w.record(0, err, true)
+	logger.Error("Webhook delivery failed", "webhook", w.target.Name, "event", p.Event, "id", p.ID, "err", err)
+}
+
+// post 送出一次請求，回傳 HTTP 狀態碼 (沒有回應時為 0)
+func (d *WebhookDispatcher) post(ctx context.Context, t WebhookTarget, p WebhookPayload, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set("X-GOlane-Event", p.Event)
+	req.Header.Set("X-GOlane-Delivery", p.ID)
+	req.Header.Set("X-GOlane-Timestamp", timestamp)
+	if t.Secret != "" {
+		req.Header.Set("X-GOlane-Signature", signWebhook(t.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s returned %s", t.URL, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// record 更新統計 (final 表示事件已放棄)
+func (w *webhookWorker) record(status int, err error, final bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !final {
+		w.stats.LastAttempt = time.Now()
+		w.stats.LastStatus = status
+	}
+	switch {
+	case final:
+		w.stats.Failed++
+		w.stats.LastError = err.Error()
+	case err == nil:
+		w.stats.Delivered++
+		w.stats.LastError = ""
+	default:
+		w.stats.LastError = err.Error()
+	}
+}
+
+// Stats 各目標的送出統計 (依設定順序)
+func (d *WebhookDispatcher) Stats() []WebhookStats {
+	stats := make([]WebhookStats, 0, len(d.workers))
+	for _, w := range d.workers {
+		w.mu.Lock()
+		s := w.stats
+		s.Queued = len(w.queue)
+		w.mu.Unlock()
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// handleWebhooks GET /api/webhooks
+func (s *APIServer) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.webhooks.Stats())
+}
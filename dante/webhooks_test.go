@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"danteCS/golane"
+	"danteCS/internal/backoff"
+	"danteCS/internal/dante"
+)
+
+func TestCompileWebhooks(t *testing.T) {
+	zero := 0
+	targets, err := compileWebhooks([]WebhookTarget{
+		{Name: "ops", URL: "https://ops.example.com/hook"},
+		{Name: "alarms", URL: "http://10.0.0.5:8080/", Events: []string{WebhookAlarmRaised}, Timeout: "2s", Retries: &zero},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets[0].timeout != webhookDefaultTimeout || targets[0].retries != webhookDefaultRetries {
+		t.Errorf("defaults: %+v", targets[0])
+	}
+	if targets[1].timeout != 2*time.Second || targets[1].retries != 0 || targets[1].wants(WebhookDeviceAdded) {
+		t.Errorf("alarms target: %+v", targets[1])
+	}
+
+	for _, bad := range [][]WebhookTarget{
+		{{URL: "http://a/"}},
+		{{Name: "a", URL: "http://a/"}, {Name: "a", URL: "http://b/"}},
+		{{Name: "a", URL: "ftp://a/"}},
+		{{Name: "a", URL: "http://a/", Events: []string{"device.renamed"}}},
+		{{Name: "a", URL: "http://a/", Timeout: "soon"}},
+	} {
+		if _, err := compileWebhooks(bad); !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("%+v: err = %v", bad, err)
+		}
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	var mu sync.Mutex
+	var received []WebhookPayload
+	attempts := 0
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable) // 第一次失敗，之後重試
+			return
+		}
+		if got := r.Header.Get("X-GOlane-Signature"); got != signWebhook("s3cret", r.Header.Get("X-GOlane-Timestamp"), body) {
+			t.Errorf("signature %q does not verify", got)
+		}
+		var p WebhookPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Error(err)
+		}
+		if r.Header.Get("X-GOlane-Event") != p.Event || r.Header.Get("X-GOlane-Delivery") != p.ID {
+			t.Errorf("headers do not match payload: %v", r.Header)
+		}
+		received = append(received, p)
+	}))
+	defer ok.Close()
+	var rejects int
+	reject := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		rejects++
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer reject.Close()
+
+	targets, err := compileWebhooks([]WebhookTarget{
+		{Name: "ops", URL: ok.URL, Secret: "s3cret", Events: []string{WebhookDeviceAdded, WebhookAlarmCleared, WebhookDomainFailed}},
+		{Name: "gone", URL: reject.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewWebhookDispatcher(targets)
+	d.retry = backoff.Policy{Initial: time.Millisecond}
+	events := golane.NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx, events)
+
+	events.Publish(golane.Event{Topic: golane.TopicDeviceOnline, Domain: "Dante1", Subject: "Amp", Data: dante.Device{Name: "Amp"}})
+	events.Publish(golane.Event{Topic: golane.TopicDeviceOffline, Domain: "Dante1", Subject: "Stage"}) // ops 沒有訂閱
+	events.Publish(golane.Event{Topic: golane.TopicAlarm, Domain: "Dante1", Subject: "slow-link", Data: AlarmEvent{State: AlarmCleared, Alarm: Alarm{Name: "slow-link"}}})
+	golane.PublishDomainFailures(events)(golane.DomainStatus{Name: "Dante2", LastError: "no IP"}, true)
+	events.Publish(golane.Event{Topic: golane.TopicRoute, Domain: "Dante1"}) // 不是 webhook 事件
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stats := d.Stats()
+		if stats[0].Delivered == 3 && stats[1].Failed == 4 {
+			if stats[0].Failed != 0 || stats[1].LastStatus != http.StatusNotFound {
+				t.Errorf("stats: %+v", stats)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stats: %+v", stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{WebhookDeviceAdded, WebhookAlarmCleared, WebhookDomainFailed}
+	for i, p := range received {
+		if p.Event != want[i] {
+			t.Errorf("delivery %d: %s, want %s", i, p.Event, want[i])
+		}
+	}
+	if received[1].Subject != "slow-link" || received[2].Domain != "Dante2" {
+		t.Errorf("payloads: %+v", received)
+	}
+	if rejects != 4 {
+		t.Errorf("rejected target called %d times, 4xx must not be retried", rejects)
+	}
+}
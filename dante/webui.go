@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"embed"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"danteCS/internal/recovery"
+)
+
+//==============================================================================
+// 內嵌 Web UI
+//==============================================================================
+
+// 給沒有 SSH 權限的技術人員使用：管理介面上的單頁 UI 顯示網域、設備、
+// 連線速度與備援狀態。資料來自 /api/ws (WebSocket 推送)，
+// 瀏覽器不支援或連線中斷時改用 REST API 輪詢。
+
+//go:embed web
+var webAssets embed.FS
+
+// webPushInterval WebSocket 檢查資料變化的間隔
+const webPushInterval = 2 * time.Second
+
+// webSnapshot WebSocket 推送的完整狀態
+type webSnapshot struct {
+	Time    time.Time   `json:"time"`
+	Domains []apiDomain `json:"domains"`
+	Devices []apiDevice `json:"devices"`
+	Streams []apiStream `json:"aes67"`
+}
+
+// registerWebUI 註冊 Web UI 靜態檔與 WebSocket
+func (s *APIServer) registerWebUI() {
+	static, _ := fs.Sub(webAssets, "web")
+	s.mux.Handle("GET /ui/", s.requireFeature(FeatureWebUI, http.StripPrefix("/ui/", http.FileServer(http.FS(static)))))
+	s.handlePublic("GET /{$}", s.requireFeature(FeatureWebUI, http.RedirectHandler("/ui/", http.StatusFound)))
+	s.handle("GET /api/ws", s.requireFeature(FeatureWebUI, http.HandlerFunc(s.handleWebSocket)))
+}
+
+// snapshot 目前的網域與設備狀態
+func (s *APIServer) snapshot() webSnapshot {
+	return webSnapshot{Time: time.Now(), Domains: s.domainList(), Devices: s.deviceList(), Streams: s.streamList()}
+}
+
+// handleWebSocket 連線後立即推送一次狀態，之後只在資料變化時推送
+func (s *APIServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, rw, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer conn.Close()
+
+	// 讀取端只用來偵測瀏覽器關閉連線
+	closed := make(chan struct{})
+	recovery.Go("api/ws-read", func() {
+		defer close(closed)
+		for {
+			opcode, _, err := readWebSocketFrame(rw.Reader)
+			if err != nil || opcode == wsOpClose {
+				return
+			}
+		}
+	})
+
+	ticker := time.NewTicker(webPushInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		snap := s.snapshot()
+		// 時間戳記每次都不同，比較時不含時間
+		body, _ := json.Marshal(struct {
+			Domains []apiDomain `json:"domains"`
+			Devices []apiDevice `json:"devices"`
+			Streams []apiStream `json:"aes67"`
+		}{snap.Domains, snap.Devices, snap.Streams})
+		if !bytes.Equal(body, last) {
+			data, _ := json.Marshal(snap)
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := writeWebSocketFrame(rw.Writer, wsOpText, data); err != nil {
+				return
+			}
+			last = body
+		}
+
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleEvents GET /api/events[?topic=devices,route,...] 以 WebSocket 逐則轉送事件匯流排的事件
+// 內容與嵌入 golane 套件時 Bus.Subscribe 收到的 Event 相同
+func (s *APIServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var topics []string
+	if q := r.URL.Query().Get("topic"); q != "" {
+		topics = strings.Split(q, ",")
+	}
+	conn, rw, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer conn.Close()
+
+	sub := s.events.Subscribe(0, topics...)
+	defer sub.Close()
+
+	closed := make(chan struct{})
+	recovery.Go("api/events-read", func() {
+		defer close(closed)
+		for {
+			opcode, _, err := readWebSocketFrame(rw.Reader)
+			if err != nil || opcode == wsOpClose {
+				return
+			}
+		}
+	})
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case e := <-sub.C:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := writeWebSocketFrame(rw.Writer, wsOpText, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+//----------------------------------------------------------------------
+// WebSocket (RFC 6455，只實作伺服器推送需要的部分)
+//----------------------------------------------------------------------
+
+// WebSocket opcode
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+)
+
+// wsGUID 計算 Sec-WebSocket-Accept 用的固定字串
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsMaxFrame 接受的用戶端訊息大小上限
+const wsMaxFrame = 64 << 10
+
+// upgradeWebSocket 完成 WebSocket 握手並接管連線
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter, error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, nil, errors.New("not a websocket handshake")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	// ResponseController 透過 Unwrap 找到原始連線 (handler 可能被追蹤等中介層包裝)
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, nil, fmt.Errorf("connection cannot be upgraded: %v", err)
+	}
+
+	sum := sha1.Sum([]byte(key + wsGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, rw, nil
+}
+
+// headerContains 判斷以逗號分隔的標頭是否包含 token (不分大小寫)
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeWebSocketFrame 送出一個未遮罩的完整訊框
+func writeWebSocketFrame(w *bufio.Writer, opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// readWebSocketFrame 讀取一個用戶端訊框 (用戶端送出的訊框一定有遮罩)
+func readWebSocketFrame(r *bufio.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if !masked {
+		return 0, nil, errors.New("unmasked client frame")
+	}
+	if length > wsMaxFrame {
+		return 0, nil, errors.New("client frame too large")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
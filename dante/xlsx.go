@@ -0,0 +1,194 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+//==============================================================================
+// XLSX 讀取 (只讀第一個工作表的儲存格文字)
+//==============================================================================
+
+// 匯入只需要儲存格的文字，不值得為此加入第三方套件：XLSX 是 zip 中的
+// XML，依 workbook → relationships 找到第一個工作表，再以共用字串表
+// 還原文字儲存格。公式取快取的計算結果，格式與樣式全部忽略。
+
+// xlsxMaxPartSize 單一 XML 檔解壓縮後的上限 (避免 zip bomb)
+const xlsxMaxPartSize = 32 << 20
+
+// xlsxRow 工作表的一列
+type xlsxRow struct {
+	Number int      // 試算表中的列號 (1-based)
+	Cells  []string // 依欄位 (A、B、...) 排列，空白儲存格為 ""
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		T    string `xml:"t"`
+		Runs []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			V      string `xml:"v"`
+			Inline struct {
+				T string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSXFirstSheet 讀取第一個工作表的所有列 (沒有內容的列不回傳)
+func readXLSXFirstSheet(data []byte) ([]xlsxRow, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an XLSX file: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("workbook has no sheets")
+	}
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetPath := ""
+	for _, rel := range rels.Rels {
+		if rel.ID == workbook.Sheets[0].RID {
+			// Target 相對於 xl/，也可能是以 / 開頭的絕對路徑
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPath = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", rel.Target)
+			}
+		}
+	}
+	if sheetPath == "" {
+		return nil, fmt.Errorf("sheet %q not found in workbook", workbook.Sheets[0].Name)
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst xlsxSharedStrings
+		if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.Items {
+			text := si.T
+			for _, run := range si.Runs {
+				text += run.T
+			}
+			shared = append(shared, text)
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := decodeXLSXPart(files, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+	var rows []xlsxRow
+	for i, r := range sheet.Rows {
+		row := xlsxRow{Number: r.R}
+		if row.Number == 0 {
+			row.Number = i + 1
+		}
+		for j, c := range r.Cells {
+			col := j
+			if c.R != "" {
+				if col, err = xlsxColumn(c.R); err != nil {
+					return nil, err
+				}
+			}
+			var value string
+			switch c.T {
+			case "s":
+				var index int
+				if _, err := fmt.Sscan(c.V, &index); err != nil || index < 0 || index >= len(shared) {
+					return nil, fmt.Errorf("cell %s: invalid shared string %q", c.R, c.V)
+				}
+				value = shared[index]
+			case "inlineStr":
+				value = c.Inline.T
+			default:
+				value = c.V
+			}
+			for len(row.Cells) <= col {
+				row.Cells = append(row.Cells, "")
+			}
+			row.Cells[col] = value
+		}
+		if len(row.Cells) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// decodeXLSXPart 解析 zip 中的 XML 檔
+func decodeXLSXPart(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("not an XLSX file: %s missing", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, xlsxMaxPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// xlsxColumn 儲存格參照 (例如 AB12) 的欄位索引 (0-based)
+func xlsxColumn(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 || n > 3 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}